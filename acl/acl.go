@@ -0,0 +1,222 @@
+package acl
+
+// ManagementACL is a singleton used for management tokens
+var ManagementACL *ACL
+
+func init() {
+	var err error
+	ManagementACL, err = NewACL(true, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// capabilitySet is a type wrapper to help managing a set of capabilities
+type capabilitySet map[string]struct{}
+
+func (c capabilitySet) Check(k string) bool {
+	_, ok := c[k]
+	return ok
+}
+
+func (c capabilitySet) Set(k string) {
+	c[k] = struct{}{}
+}
+
+func (c capabilitySet) Clear() {
+	for cap := range c {
+		delete(c, cap)
+	}
+}
+
+// ACL object is used to convert a set of policies into a structure that
+// can be efficiently evaluated to determine if an action is allowed.
+type ACL struct {
+	// management tokens are allowed to do anything
+	management bool
+
+	// namespaces maps a namespace to a capabilitySet
+	namespaces map[string]capabilitySet
+
+	agent    string
+	node     string
+	operator string
+	quota    string
+}
+
+// maxPrivilege returns the policy which grants the most privilege
+// This handling is not symmetric
+func maxPrivilege(a, b string) string {
+	switch {
+	case a == PolicyDeny || b == PolicyDeny:
+		return PolicyDeny
+	case a == PolicyWrite || b == PolicyWrite:
+		return PolicyWrite
+	case a == PolicyRead || b == PolicyRead:
+		return PolicyRead
+	default:
+		return ""
+	}
+}
+
+// NewACL compiles a set of policies into an ACL object
+func NewACL(management bool, policies []*Policy) (*ACL, error) {
+	// Hot-path management tokens
+	acl := &ACL{}
+	if management {
+		acl.management = true
+		return acl, nil
+	}
+
+	// Create the namespace capabilities
+	acl.namespaces = make(map[string]capabilitySet)
+
+	// Extract all the policies
+	for _, policy := range policies {
+		for _, ns := range policy.Namespaces {
+			// Check for existing capabilities
+			capabilities, ok := acl.namespaces[ns.Name]
+			if !ok {
+				capabilities = make(capabilitySet)
+				acl.namespaces[ns.Name] = capabilities
+			}
+
+			// Deny always takes precedence
+			if capabilities.Check(NamespaceCapabilityDeny) {
+				continue
+			}
+
+			// Add in all the capabilities
+			for _, cap := range ns.Capabilities {
+				if cap == NamespaceCapabilityDeny {
+					// Overwrite any existing capabilities
+					capabilities.Clear()
+					capabilities.Set(NamespaceCapabilityDeny)
+					break
+				}
+				capabilities.Set(cap)
+			}
+		}
+
+		// Take the maximum privilege for the other policies
+		if policy.Agent != nil {
+			acl.agent = maxPrivilege(acl.agent, policy.Agent.Policy)
+		}
+		if policy.Node != nil {
+			acl.node = maxPrivilege(acl.node, policy.Node.Policy)
+		}
+		if policy.Operator != nil {
+			acl.operator = maxPrivilege(acl.operator, policy.Operator.Policy)
+		}
+		if policy.Quota != nil {
+			acl.quota = maxPrivilege(acl.quota, policy.Quota.Policy)
+		}
+	}
+	return acl, nil
+}
+
+// AllowNamespaceOperation checks if a given operation is allowed for a namespace
+func (a *ACL) AllowNamespaceOperation(ns string, op string) bool {
+	// Hot path management tokens
+	if a.management {
+		return true
+	}
+
+	// Check for a matching capability set
+	capabilities, ok := a.namespaces[ns]
+	if !ok {
+		return false
+	}
+
+	// Check if the capability has been granted
+	return capabilities.Check(op)
+}
+
+// AllowNamespace checks if any operations are allowed for a namespace
+func (a *ACL) AllowNamespace(ns string) bool {
+	// Hot path management tokens
+	if a.management {
+		return true
+	}
+
+	// Check for a matching capability set
+	capabilities, ok := a.namespaces[ns]
+	if !ok {
+		return false
+	}
+
+	// Check if the capability has been granted
+	if len(capabilities) == 0 {
+		return false
+	}
+	return !capabilities.Check(NamespaceCapabilityDeny)
+}
+
+// AllowAgentRead checks if read operations are allowed for an agent
+func (a *ACL) AllowAgentRead() bool {
+	return a.allowRead(a.agent)
+}
+
+// AllowAgentWrite checks if write operations are allowed for an agent
+func (a *ACL) AllowAgentWrite() bool {
+	return a.allowWrite(a.agent)
+}
+
+// AllowNodeRead checks if read operations are allowed for a node
+func (a *ACL) AllowNodeRead() bool {
+	return a.allowRead(a.node)
+}
+
+// AllowNodeWrite checks if write operations are allowed for a node
+func (a *ACL) AllowNodeWrite() bool {
+	return a.allowWrite(a.node)
+}
+
+// AllowOperatorRead checks if read operations are allowed for an operator
+func (a *ACL) AllowOperatorRead() bool {
+	return a.allowRead(a.operator)
+}
+
+// AllowOperatorWrite checks if write operations are allowed for an operator
+func (a *ACL) AllowOperatorWrite() bool {
+	return a.allowWrite(a.operator)
+}
+
+// AllowQuotaRead checks if read operations are allowed for all quotas
+func (a *ACL) AllowQuotaRead() bool {
+	return a.allowRead(a.quota)
+}
+
+// AllowQuotaWrite checks if write operations are allowed for quotas
+func (a *ACL) AllowQuotaWrite() bool {
+	return a.allowWrite(a.quota)
+}
+
+// IsManagement checks if this represents a management token
+func (a *ACL) IsManagement() bool {
+	return a.management
+}
+
+func (a *ACL) allowRead(policy string) bool {
+	switch {
+	case a.management:
+		return true
+	case policy == PolicyWrite:
+		return true
+	case policy == PolicyRead:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *ACL) allowWrite(policy string) bool {
+	switch {
+	case a.management:
+		return true
+	case policy == PolicyWrite:
+		return true
+	default:
+		return false
+	}
+}
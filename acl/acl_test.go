@@ -0,0 +1,207 @@
+package acl
+
+import (
+	"testing"
+)
+
+func TestMaxPrivilege(t *testing.T) {
+	type tcase struct {
+		Privilege      string
+		PrecedenceOver []string
+	}
+	tcases := []tcase{
+		{
+			PolicyDeny,
+			[]string{PolicyDeny, PolicyWrite, PolicyRead, ""},
+		},
+		{
+			PolicyWrite,
+			[]string{PolicyWrite, PolicyRead, ""},
+		},
+		{
+			PolicyRead,
+			[]string{PolicyRead, ""},
+		},
+	}
+
+	for idx1, tc := range tcases {
+		for idx2, po := range tc.PrecedenceOver {
+			if maxPrivilege(tc.Privilege, po) != tc.Privilege {
+				t.Fatalf("failed %d %d", idx1, idx2)
+			}
+			if maxPrivilege(po, tc.Privilege) != tc.Privilege {
+				t.Fatalf("failed %d %d", idx1, idx2)
+			}
+		}
+	}
+}
+
+func TestACLManagement(t *testing.T) {
+	// Create management ACL
+	acl, err := NewACL(true, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check default namespace rights
+	if !acl.AllowNamespaceOperation("default", NamespaceCapabilityListJobs) {
+		t.Fatalf("expected list-jobs to be allowed")
+	}
+	if !acl.AllowNamespaceOperation("default", NamespaceCapabilitySubmitJob) {
+		t.Fatalf("expected submit-job to be allowed")
+	}
+	if !acl.AllowNamespace("default") {
+		t.Fatalf("expected default namespace to be allowed")
+	}
+
+	// Check non-specified namespace
+	if !acl.AllowNamespaceOperation("foo", NamespaceCapabilityListJobs) {
+		t.Fatalf("expected list-jobs to be allowed")
+	}
+
+	// Check the other simpler operations
+	if !acl.IsManagement() || !acl.AllowAgentRead() || !acl.AllowAgentWrite() ||
+		!acl.AllowNodeRead() || !acl.AllowNodeWrite() ||
+		!acl.AllowOperatorRead() || !acl.AllowOperatorWrite() ||
+		!acl.AllowQuotaRead() || !acl.AllowQuotaWrite() {
+		t.Fatalf("expected management to be allowed everything")
+	}
+}
+
+func TestACLMerge(t *testing.T) {
+	// Merge read + write policy
+	p1, err := Parse(readAll)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	p2, err := Parse(writeAll)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	acl, err := NewACL(false, []*Policy{p1, p2})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check default namespace rights
+	if !acl.AllowNamespaceOperation("default", NamespaceCapabilityListJobs) {
+		t.Fatalf("expected list-jobs to be allowed")
+	}
+	if !acl.AllowNamespaceOperation("default", NamespaceCapabilitySubmitJob) {
+		t.Fatalf("expected submit-job to be allowed")
+	}
+
+	// Check non-specified namespace
+	if acl.AllowNamespaceOperation("foo", NamespaceCapabilityListJobs) {
+		t.Fatalf("expected list-jobs to be denied")
+	}
+	if acl.AllowNamespace("foo") {
+		t.Fatalf("expected foo namespace to be denied")
+	}
+
+	// Check the other simpler operations
+	if acl.IsManagement() {
+		t.Fatalf("expected a client ACL")
+	}
+	if !acl.AllowAgentRead() || !acl.AllowAgentWrite() ||
+		!acl.AllowNodeRead() || !acl.AllowNodeWrite() ||
+		!acl.AllowOperatorRead() || !acl.AllowOperatorWrite() ||
+		!acl.AllowQuotaRead() || !acl.AllowQuotaWrite() {
+		t.Fatalf("expected write privileges")
+	}
+
+	// Merge read + blank
+	p3, err := Parse("")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	acl, err = NewACL(false, []*Policy{p1, p3})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !acl.AllowNamespaceOperation("default", NamespaceCapabilityReadJob) {
+		t.Fatalf("expected read-job to be allowed")
+	}
+	if acl.AllowNamespaceOperation("default", NamespaceCapabilitySubmitJob) {
+		t.Fatalf("expected submit-job to be denied")
+	}
+	if !acl.AllowAgentRead() || acl.AllowAgentWrite() ||
+		!acl.AllowNodeRead() || acl.AllowNodeWrite() ||
+		!acl.AllowOperatorRead() || acl.AllowOperatorWrite() ||
+		!acl.AllowQuotaRead() || acl.AllowQuotaWrite() {
+		t.Fatalf("expected read privileges")
+	}
+
+	// Merge read + deny
+	p4, err := Parse(denyAll)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	acl, err = NewACL(false, []*Policy{p1, p4})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if acl.AllowNamespaceOperation("default", NamespaceCapabilityListJobs) {
+		t.Fatalf("expected list-jobs to be denied")
+	}
+	if acl.AllowNamespace("default") {
+		t.Fatalf("expected default namespace to be denied")
+	}
+	if acl.AllowAgentRead() || acl.AllowNodeRead() || acl.AllowOperatorRead() || acl.AllowQuotaRead() {
+		t.Fatalf("expected deny to take precedence")
+	}
+}
+
+var readAll = `
+namespace "default" {
+	policy = "read"
+}
+agent {
+	policy = "read"
+}
+node {
+	policy = "read"
+}
+operator {
+	policy = "read"
+}
+quota {
+	policy = "read"
+}
+`
+
+var writeAll = `
+namespace "default" {
+	policy = "write"
+}
+agent {
+	policy = "write"
+}
+node {
+	policy = "write"
+}
+operator {
+	policy = "write"
+}
+quota {
+	policy = "write"
+}
+`
+
+var denyAll = `
+namespace "default" {
+	policy = "deny"
+}
+agent {
+	policy = "deny"
+}
+node {
+	policy = "deny"
+}
+operator {
+	policy = "deny"
+}
+quota {
+	policy = "deny"
+}
+`
@@ -0,0 +1,193 @@
+package acl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	type tcase struct {
+		Raw    string
+		ErrStr string
+		Expect *Policy
+	}
+	tcases := []tcase{
+		{
+			`
+			namespace "default" {
+				policy = "read"
+			}
+			`,
+			"",
+			&Policy{
+				Namespaces: []*NamespacePolicy{
+					&NamespacePolicy{
+						Name:   "default",
+						Policy: PolicyRead,
+						Capabilities: []string{
+							NamespaceCapabilityListJobs,
+							NamespaceCapabilityReadJob,
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+			namespace "default" {
+				policy = "read"
+			}
+			namespace "other" {
+				policy = "write"
+			}
+			namespace "secret" {
+				capabilities = ["deny", "read-logs"]
+			}
+			agent {
+				policy = "write"
+			}
+			node {
+				policy = "read"
+			}
+			operator {
+				policy = "deny"
+			}
+			quota {
+				policy = "write"
+			}
+			`,
+			"",
+			&Policy{
+				Namespaces: []*NamespacePolicy{
+					&NamespacePolicy{
+						Name:   "default",
+						Policy: PolicyRead,
+						Capabilities: []string{
+							NamespaceCapabilityListJobs,
+							NamespaceCapabilityReadJob,
+						},
+					},
+					&NamespacePolicy{
+						Name:   "other",
+						Policy: PolicyWrite,
+						Capabilities: []string{
+							NamespaceCapabilityListJobs,
+							NamespaceCapabilityReadJob,
+							NamespaceCapabilitySubmitJob,
+							NamespaceCapabilityDispatchJob,
+							NamespaceCapabilityReadLogs,
+							NamespaceCapabilityReadFS,
+							NamespaceCapabilityAllocLifecycle,
+						},
+					},
+					&NamespacePolicy{
+						Name: "secret",
+						Capabilities: []string{
+							NamespaceCapabilityDeny,
+							NamespaceCapabilityReadLogs,
+						},
+					},
+				},
+				Agent: &AgentPolicy{
+					Policy: PolicyWrite,
+				},
+				Node: &NodePolicy{
+					Policy: PolicyRead,
+				},
+				Operator: &OperatorPolicy{
+					Policy: PolicyDeny,
+				},
+				Quota: &QuotaPolicy{
+					Policy: PolicyWrite,
+				},
+			},
+		},
+		{
+			`
+			namespace "default" {
+				policy = "foo"
+			}
+			`,
+			"Invalid namespace policy",
+			nil,
+		},
+		{
+			`
+			namespace "default" {
+				capabilities = ["deny", "foo"]
+			}
+			`,
+			"Invalid namespace capability",
+			nil,
+		},
+		{
+			`
+			agent {
+				policy = "foo"
+			}
+			`,
+			"Invalid agent policy",
+			nil,
+		},
+		{
+			`
+			node {
+				policy = "foo"
+			}
+			`,
+			"Invalid node policy",
+			nil,
+		},
+		{
+			`
+			operator {
+				policy = "foo"
+			}
+			`,
+			"Invalid operator policy",
+			nil,
+		},
+		{
+			`
+			{
+				"Name": "my-policy",
+				"Description": "This is a great policy",
+				"Rules": "anything"
+			}
+			`,
+			"Invalid policy",
+			nil,
+		},
+		{
+			`
+			namespace "has a space"{
+				policy = "read"
+			}
+			`,
+			"Invalid namespace name",
+			nil,
+		},
+	}
+
+	for idx, tc := range tcases {
+		p, err := Parse(tc.Raw)
+		if err != nil {
+			if tc.ErrStr == "" {
+				t.Fatalf("case %d: unexpected err: %v", idx, err)
+			}
+			if !strings.Contains(err.Error(), tc.ErrStr) {
+				t.Fatalf("case %d: expected err %q, got: %v", idx, tc.ErrStr, err)
+			}
+			continue
+		}
+		if tc.ErrStr != "" {
+			t.Fatalf("case %d: expected err %q", idx, tc.ErrStr)
+		}
+
+		tc.Expect.Raw = tc.Raw
+		if !reflect.DeepEqual(p, tc.Expect) {
+			t.Fatalf("case %d: bad: %#v %#v", idx, p, tc.Expect)
+		}
+	}
+}
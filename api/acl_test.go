@@ -0,0 +1,138 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestACLPolicies_Upsert_Info_Delete(t *testing.T) {
+	c, s, _ := makeACLClient(t, nil, nil)
+	defer s.Stop()
+	ap := c.ACLPolicies()
+
+	// Listing when nothing exists returns empty
+	result, qm, err := ap.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result) != 0 {
+		t.Fatalf("expected 0 policies, got: %d", len(result))
+	}
+
+	// Register a policy
+	policy := &ACLPolicy{
+		Name:        "test",
+		Description: "test",
+		Rules: `namespace "default" {
+			policy = "read"
+		}
+		`,
+	}
+	wm, err := ap.Upsert(policy, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Query the policy
+	out, qm, err := ap.Info(policy.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if out.Name != policy.Name || out.Rules != policy.Rules {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// List the policies
+	result, qm, err = ap.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(result) != 1 || result[0].Name != policy.Name {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// Delete the policy
+	wm, err = ap.Delete(policy.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if _, _, err := ap.Info(policy.Name, nil); err == nil {
+		t.Fatalf("expected error querying deleted policy")
+	}
+}
+
+func TestACLTokens_Create_Update_Delete(t *testing.T) {
+	c, s, root := makeACLClient(t, nil, nil)
+	defer s.Stop()
+	at := c.ACLTokens()
+
+	// The bootstrap token is listed
+	result, qm, err := at.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(result) != 1 || result[0].AccessorID != root.AccessorID {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// Create a token
+	token := &ACLToken{
+		Name:     "foo",
+		Type:     "client",
+		Policies: []string{"foo1"},
+	}
+	out, wm, err := at.Create(token, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if out.AccessorID == "" || out.SecretID == "" {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Update the token
+	out.Name = "bar"
+	updated, wm, err := at.Update(out, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if updated.Name != "bar" || updated.SecretID != out.SecretID {
+		t.Fatalf("bad: %#v", updated)
+	}
+
+	// Query the token
+	info, qm, err := at.Info(out.AccessorID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if info.Name != "bar" {
+		t.Fatalf("bad: %#v", info)
+	}
+
+	// Query our own token
+	self, _, err := at.Self(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if self.AccessorID != root.AccessorID {
+		t.Fatalf("bad: %#v", self)
+	}
+
+	// Delete the token
+	wm, err = at.Delete(out.AccessorID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if _, _, err := at.Info(out.AccessorID, nil); err == nil {
+		t.Fatalf("expected error querying deleted token")
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+// Affinity is used to serialize a job placement preference.
+type Affinity struct {
+	LTarget string
+	RTarget string
+	Operand string
+	Weight  int
+}
+
+// NewAffinity generates a new job placement preference.
+func NewAffinity(left, operand, right string, weight int) *Affinity {
+	return &Affinity{
+		LTarget: left,
+		RTarget: right,
+		Operand: operand,
+		Weight:  weight,
+	}
+}
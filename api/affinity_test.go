@@ -0,0 +1,19 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompose_Affinities(t *testing.T) {
+	a := NewAffinity("${node.datacenter}", "=", "dc1", 50)
+	expect := &Affinity{
+		LTarget: "${node.datacenter}",
+		RTarget: "dc1",
+		Operand: "=",
+		Weight:  50,
+	}
+	if !reflect.DeepEqual(a, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, a)
+	}
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"bufio"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 )
 
@@ -148,6 +150,13 @@ func (a *Agent) ForceLeave(node string) error {
 	return err
 }
 
+// Reload asks the agent to re-read its configuration files and apply the
+// settings that can be changed without a restart.
+func (a *Agent) Reload() error {
+	_, err := a.client.write("/v1/agent/reload", nil, nil, nil)
+	return err
+}
+
 // Servers is used to query the list of servers on a client node.
 func (a *Agent) Servers() ([]string, error) {
 	var resp []string
@@ -170,6 +179,78 @@ func (a *Agent) SetServers(addrs []string) error {
 	return err
 }
 
+// Monitor streams the logs of the agent at the given level, starting with its
+// recent logs. The returned channel is closed when the stream ends, and
+// closing stopCh stops the stream.
+func (a *Agent) Monitor(logLevel string, stopCh <-chan struct{}, q *QueryOptions) (<-chan string, error) {
+	if q == nil {
+		q = &QueryOptions{}
+	}
+	if q.Params == nil {
+		q.Params = make(map[string]string)
+	}
+	if logLevel != "" {
+		q.Params["log_level"] = logLevel
+	}
+
+	r, err := a.client.rawQuery("/v1/agent/monitor", q)
+	if err != nil {
+		return nil, err
+	}
+
+	logCh := make(chan string, 64)
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(logCh)
+		defer close(doneCh)
+		defer r.Close()
+
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			select {
+			case logCh <- scanner.Text():
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	// Close the stream to unblock the reads when stopped
+	go func() {
+		select {
+		case <-stopCh:
+			r.Close()
+		case <-doneCh:
+		}
+	}()
+
+	return logCh, nil
+}
+
+// Metrics returns the summary of the metrics of the agent for the current
+// interval.
+func (a *Agent) Metrics(q *QueryOptions) (*MetricsSummary, error) {
+	var resp MetricsSummary
+	_, err := a.client.query("/v1/metrics", &resp, q)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Profile returns a runtime profile of the agent, which is only served if the
+// agent runs with enable_debug and requires an agent:write token if ACLs are
+// enabled. The profile is the name of one of the profiles of runtime/pprof,
+// "profile" for a CPU profile or "trace" for an execution trace.
+func (a *Agent) Profile(profile string, q *QueryOptions) ([]byte, error) {
+	r, err := a.client.rawQuery("/v1/agent/pprof/"+profile, q)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
 // ListKeys returns the list of installed keys
 func (a *Agent) ListKeys() (*KeyringResponse, error) {
 	var resp KeyringResponse
@@ -239,6 +320,38 @@ type AgentMember struct {
 	DelegateCur uint8
 }
 
+// MetricsSummary holds the metrics of an agent for an interval
+type MetricsSummary struct {
+	Timestamp string
+	Gauges    []GaugeValue
+	Points    []PointValue
+	Counters  []SampledValue
+	Samples   []SampledValue
+}
+
+// GaugeValue is the last value of a gauge
+type GaugeValue struct {
+	Name  string
+	Value float32
+}
+
+// PointValue is the values emitted for a key
+type PointValue struct {
+	Name   string
+	Points []float32
+}
+
+// SampledValue is the aggregation of a counter or a sample
+type SampledValue struct {
+	Name   string
+	Count  int
+	Sum    float64
+	Min    float64
+	Max    float64
+	Mean   float64
+	Stddev float64
+}
+
 // AgentMembersNameSort implements sort.Interface for []*AgentMembersNameSort
 // based on the Name, DC and Region
 type AgentMembersNameSort []*AgentMember
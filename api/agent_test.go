@@ -3,7 +3,9 @@ package api
 import (
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/testutil"
 )
@@ -124,6 +126,91 @@ func TestAgent_ForceLeave(t *testing.T) {
 	// TODO: test force-leave on an existing node
 }
 
+func TestAgent_Reload(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	if err := c.Agent().Reload(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestAgent_Monitor(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Agent()
+
+	// An invalid level is rejected
+	if _, err := a.Monitor("foo", nil, nil); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	stopCh := make(chan struct{})
+	logCh, err := a.Monitor("debug", stopCh, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The recent logs of the agent are streamed
+	select {
+	case line := <-logCh:
+		if !strings.Contains(line, "[") {
+			t.Fatalf("bad line: %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no logs received")
+	}
+
+	// Stopping the monitor closes the channel
+	close(stopCh)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-logCh:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("monitor not stopped")
+		}
+	}
+}
+
+func TestAgent_Metrics(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	a := c.Agent()
+
+	metrics, err := a.Metrics(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if metrics.Timestamp == "" || len(metrics.Gauges) == 0 {
+		t.Fatalf("bad: %#v", metrics)
+	}
+}
+
+func TestAgent_Profile(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.EnableDebug = true
+	})
+	defer s.Stop()
+	a := c.Agent()
+
+	q := &QueryOptions{Params: map[string]string{"debug": "2"}}
+	out, err := a.Profile("goroutine", q)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(out), "goroutine") {
+		t.Fatalf("bad: %s", out)
+	}
+
+	if _, err := a.Profile("unknown", nil); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func (a *AgentMember) String() string {
 	return "{Name: " + a.Name + " Region: " + a.Tags["region"] + " DC: " + a.Tags["dc"] + "}"
 }
@@ -1,9 +1,15 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
 	"time"
+
+	"github.com/hashicorp/nomad/helper/websocket"
 )
 
 var (
@@ -48,6 +54,157 @@ func (a *Allocations) Info(allocID string, q *QueryOptions) (*Allocation, *Query
 }
 
 func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceUsage, error) {
+	client, err := a.nodeClient(alloc, q)
+	if err != nil {
+		return nil, err
+	}
+	var resp AllocResourceUsage
+	_, err = client.query("/v1/client/allocation/"+alloc.ID+"/stats", &resp, nil)
+	return &resp, err
+}
+
+// Stop is used to stop an allocation. The scheduler replaces the allocation
+// according to its job.
+func (a *Allocations) Stop(allocID string, q *WriteOptions) (*AllocStopResponse, *WriteMeta, error) {
+	var resp AllocStopResponse
+	wm, err := a.client.write("/v1/allocation/stop/"+allocID, nil, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Restart is used to restart the tasks of an allocation in place on its node,
+// or only the given task if it is set.
+func (a *Allocations) Restart(alloc *Allocation, taskName string, q *QueryOptions) error {
+	client, err := a.nodeClient(alloc, q)
+	if err != nil {
+		return err
+	}
+	req := &AllocRestartRequest{TaskName: taskName}
+	_, err = client.write("/v1/client/allocation/"+alloc.ID+"/restart", req, nil, nil)
+	return err
+}
+
+// Signal is used to send a signal to the tasks of an allocation, or only to
+// the given task if it is set.
+func (a *Allocations) Signal(alloc *Allocation, taskName, signal string, q *QueryOptions) error {
+	client, err := a.nodeClient(alloc, q)
+	if err != nil {
+		return err
+	}
+	req := &AllocSignalRequest{TaskName: taskName, Signal: signal}
+	_, err = client.write("/v1/client/allocation/"+alloc.ID+"/signal", req, nil, nil)
+	return err
+}
+
+// Exec is used to run a command in the context of a running task of an
+// allocation. The input is read from stdin and the output of the command is
+// written to stdout and stderr, which receives no output when a terminal is
+// allocated. The terminal is resized to the sizes received on the channel. The
+// exit code of the command is returned once it exits.
+func (a *Allocations) Exec(ctx context.Context, alloc *Allocation, task string, tty bool, command []string,
+	stdin io.Reader, stdout, stderr io.Writer, terminalSizeCh <-chan TerminalSize, q *QueryOptions) (int, error) {
+
+	client, err := a.nodeClient(alloc, q)
+	if err != nil {
+		return -1, err
+	}
+
+	commandJSON, err := json.Marshal(command)
+	if err != nil {
+		return -1, err
+	}
+	var query QueryOptions
+	if q != nil {
+		query = *q
+	}
+	query.Params = make(map[string]string, len(query.Params)+3)
+	if q != nil {
+		for k, v := range q.Params {
+			query.Params[k] = v
+		}
+	}
+	query.Params["task"] = task
+	query.Params["tty"] = strconv.FormatBool(tty)
+	query.Params["command"] = string(commandJSON)
+
+	conn, err := client.websocketConn("/v1/client/allocation/"+alloc.ID+"/exec", &query)
+	if err != nil {
+		return -1, err
+	}
+	defer conn.Close()
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-doneCh:
+		}
+	}()
+
+	// Send the input and terminal sizes until the command exits
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				input := &ExecStreamingInput{Stdin: &ExecStreamingIOOperation{Data: buf[:n]}}
+				if conn.WriteJSON(input) != nil {
+					return
+				}
+			}
+			if err != nil {
+				conn.WriteJSON(&ExecStreamingInput{Stdin: &ExecStreamingIOOperation{Close: true}})
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case size := <-terminalSizeCh:
+				if conn.WriteJSON(&ExecStreamingInput{TTYSize: &size}) != nil {
+					return
+				}
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	var result *ExecStreamingExitResult
+	for {
+		var output ExecStreamingOutput
+		err := conn.ReadJSON(&output)
+		if closeErr, ok := err.(*websocket.CloseError); ok {
+			if result != nil {
+				return result.ExitCode, nil
+			}
+			return -1, fmt.Errorf("exec failed: %s", closeErr.Text)
+		} else if err != nil {
+			if ctx.Err() != nil {
+				return -1, ctx.Err()
+			}
+			return -1, err
+		}
+
+		if output.Stdout != nil {
+			stdout.Write(output.Stdout.Data)
+		}
+		if output.Stderr != nil {
+			stderr.Write(output.Stderr.Data)
+		}
+		if output.Exited && output.Result != nil {
+			result = output.Result
+		}
+	}
+}
+
+// nodeClient returns a client of the agent of the node running the allocation.
+func (a *Allocations) nodeClient(alloc *Allocation, q *QueryOptions) (*Client, error) {
 	node, _, err := a.client.Nodes().Info(alloc.NodeID, q)
 	if err != nil {
 		return nil, err
@@ -58,37 +215,108 @@ func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceU
 	if node.HTTPAddr == "" {
 		return nil, fmt.Errorf("http addr of the node where alloc %q is running is not advertised", alloc.ID)
 	}
-	client, err := NewClient(a.client.config.CopyConfig(node.HTTPAddr, node.TLSEnabled))
-	if err != nil {
-		return nil, err
-	}
-	var resp AllocResourceUsage
-	_, err = client.query("/v1/client/allocation/"+alloc.ID+"/stats", &resp, nil)
-	return &resp, err
+	return NewClient(a.client.config.CopyConfig(node.HTTPAddr, node.TLSEnabled))
+}
+
+// AllocStopResponse is used to respond to an allocation stop.
+type AllocStopResponse struct {
+	EvalID          string
+	EvalCreateIndex uint64
+}
+
+// AllocRestartRequest is used to restart the tasks of an allocation.
+type AllocRestartRequest struct {
+	TaskName string
+}
+
+// AllocSignalRequest is used to send a signal to the tasks of an allocation.
+type AllocSignalRequest struct {
+	TaskName string
+	Signal   string
+}
+
+// TerminalSize is the size of the terminal of a command executed in a task
+type TerminalSize struct {
+	Height int `json:"height,omitempty"`
+	Width  int `json:"width,omitempty"`
+}
+
+// ExecStreamingIOOperation is a chunk of a standard stream of a command
+// executed in a task, or its end if Close is set.
+type ExecStreamingIOOperation struct {
+	Data  []byte `json:"data,omitempty"`
+	Close bool   `json:"close,omitempty"`
+}
+
+// ExecStreamingInput is a message sent to a command executed in a task
+type ExecStreamingInput struct {
+	Stdin   *ExecStreamingIOOperation `json:"stdin,omitempty"`
+	TTYSize *TerminalSize             `json:"tty_size,omitempty"`
+}
+
+// ExecStreamingExitResult is the result of a command executed in a task
+type ExecStreamingExitResult struct {
+	ExitCode int `json:"exit_code"`
+}
+
+// ExecStreamingOutput is a message sent by a command executed in a task. The
+// last message has Exited set along with the result of the command.
+type ExecStreamingOutput struct {
+	Stdout *ExecStreamingIOOperation `json:"stdout,omitempty"`
+	Stderr *ExecStreamingIOOperation `json:"stderr,omitempty"`
+
+	Exited bool                     `json:"exited,omitempty"`
+	Result *ExecStreamingExitResult `json:"result,omitempty"`
 }
 
 // Allocation is used for serialization of allocations.
 type Allocation struct {
-	ID                 string
-	EvalID             string
-	Name               string
-	NodeID             string
-	JobID              string
-	Job                *Job
-	TaskGroup          string
-	Resources          *Resources
-	TaskResources      map[string]*Resources
-	Services           map[string]string
-	Metrics            *AllocationMetric
-	DesiredStatus      string
-	DesiredDescription string
-	ClientStatus       string
-	ClientDescription  string
-	TaskStates         map[string]*TaskState
-	PreviousAllocation string
-	CreateIndex        uint64
-	ModifyIndex        uint64
-	CreateTime         int64
+	ID                    string
+	EvalID                string
+	Name                  string
+	NodeID                string
+	JobID                 string
+	Job                   *Job
+	TaskGroup             string
+	Resources             *Resources
+	TaskResources         map[string]*Resources
+	Services              map[string]string
+	Metrics               *AllocationMetric
+	DesiredStatus         string
+	DesiredDescription    string
+	ClientStatus          string
+	ClientDescription     string
+	TaskStates            map[string]*TaskState
+	PreviousAllocation    string
+	DeploymentID          string
+	Canary                bool
+	DeploymentStatus      *AllocDeploymentStatus
+	RescheduleTracker     *RescheduleTracker
+	PreemptedAllocations  []string
+	PreemptedByAllocation string
+	CreateIndex           uint64
+	ModifyIndex           uint64
+	CreateTime            int64
+}
+
+// AllocDeploymentStatus is the health of an allocation placed by a
+// deployment as determined by the client.
+type AllocDeploymentStatus struct {
+	Healthy   *bool
+	Timestamp time.Time
+}
+
+// RescheduleTracker records the reschedules of the failed allocations an
+// allocation replaces.
+type RescheduleTracker struct {
+	Events []*RescheduleEvent
+}
+
+// RescheduleEvent is used to serialize the reschedule of an allocation.
+type RescheduleEvent struct {
+	RescheduleTime int64
+	PrevAllocID    string
+	PrevNodeID     string
 }
 
 // AllocationMetric is used to deserialize allocation metrics.
@@ -101,28 +329,42 @@ type AllocationMetric struct {
 	NodesExhausted     int
 	ClassExhausted     map[string]int
 	DimensionExhausted map[string]int
+	QuotaExhausted     []string
 	Scores             map[string]float64
 	AllocationTime     time.Duration
 	CoalescedFailures  int
+	NodeTraces         map[string]*NodeTrace
+}
+
+// NodeTrace records why a node was or wasn't picked for a placement of a
+// traced evaluation.
+type NodeTrace struct {
+	NodeName  string
+	Filtered  string
+	Exhausted string
+	Scores    map[string]float64
 }
 
 // AllocationListStub is used to return a subset of an allocation
 // during list operations.
 type AllocationListStub struct {
-	ID                 string
-	EvalID             string
-	Name               string
-	NodeID             string
-	JobID              string
-	TaskGroup          string
-	DesiredStatus      string
-	DesiredDescription string
-	ClientStatus       string
-	ClientDescription  string
-	TaskStates         map[string]*TaskState
-	CreateIndex        uint64
-	ModifyIndex        uint64
-	CreateTime         int64
+	ID                    string
+	EvalID                string
+	Name                  string
+	NodeID                string
+	JobID                 string
+	TaskGroup             string
+	DeploymentID          string
+	DesiredStatus         string
+	DesiredDescription    string
+	ClientStatus          string
+	ClientDescription     string
+	TaskStates            map[string]*TaskState
+	PreemptedAllocations  []string
+	PreemptedByAllocation string
+	CreateIndex           uint64
+	ModifyIndex           uint64
+	CreateTime            int64
 }
 
 // AllocIndexSort reverse sorts allocs by CreateIndex.
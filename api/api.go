@@ -3,10 +3,14 @@ package api
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -16,6 +20,13 @@ import (
 
 	"github.com/hashicorp/go-cleanhttp"
 	rootcerts "github.com/hashicorp/go-rootcerts"
+	"github.com/hashicorp/nomad/helper/websocket"
+)
+
+const (
+	// AllNamespacesNamespace is a sentinel Namespace value to indicate that
+	// list queries should return the objects of all namespaces.
+	AllNamespacesNamespace = "*"
 )
 
 // QueryOptions are used to parameterize a query
@@ -24,6 +35,10 @@ type QueryOptions struct {
 	// by the Config
 	Region string
 
+	// Namespace is the namespace to query. Providing it overwrites the
+	// namespace provided by the Config.
+	Namespace string
+
 	// AllowStale allows any Nomad server (non-leader) to service
 	// a read. This allows for lower latency and higher throughput
 	AllowStale bool
@@ -39,8 +54,46 @@ type QueryOptions struct {
 	// If set, used as prefix for resource list searches
 	Prefix string
 
+	// If set, the objects returned by list searches must match this filter
+	// expression, e.g. `Status == "running"`
+	Filter string
+
+	// PerPage is the maximum number of objects returned by a list search.
+	// The NextToken of the QueryMeta then resumes the search.
+	PerPage int32
+
+	// NextToken resumes a list search where the previous page ended
+	NextToken string
+
 	// Set HTTP parameters on the query.
 	Params map[string]string
+
+	// AuthToken is the secret ID of an ACL token. If provided it overrides
+	// the token of the Config.
+	AuthToken string
+
+	// ctx is an optional context to cancel the HTTP request with
+	ctx context.Context
+}
+
+// Context returns the context of the query, or the background context if
+// none was set.
+func (q *QueryOptions) Context() context.Context {
+	if q != nil && q.ctx != nil {
+		return q.ctx
+	}
+	return context.Background()
+}
+
+// WithContext returns a copy of the query options using the context. The HTTP
+// request of the query is canceled once the context is done.
+func (q *QueryOptions) WithContext(ctx context.Context) *QueryOptions {
+	q2 := new(QueryOptions)
+	if q != nil {
+		*q2 = *q
+	}
+	q2.ctx = ctx
+	return q2
 }
 
 // WriteOptions are used to parameterize a write
@@ -48,6 +101,37 @@ type WriteOptions struct {
 	// Providing a datacenter overwrites the region provided
 	// by the Config
 	Region string
+
+	// Namespace is the namespace to write to. Providing it overwrites the
+	// namespace provided by the Config.
+	Namespace string
+
+	// AuthToken is the secret ID of an ACL token. If provided it overrides
+	// the token of the Config.
+	AuthToken string
+
+	// ctx is an optional context to cancel the HTTP request with
+	ctx context.Context
+}
+
+// Context returns the context of the write, or the background context if
+// none was set.
+func (w *WriteOptions) Context() context.Context {
+	if w != nil && w.ctx != nil {
+		return w.ctx
+	}
+	return context.Background()
+}
+
+// WithContext returns a copy of the write options using the context. The HTTP
+// request of the write is canceled once the context is done.
+func (w *WriteOptions) WithContext(ctx context.Context) *WriteOptions {
+	w2 := new(WriteOptions)
+	if w != nil {
+		*w2 = *w
+	}
+	w2.ctx = ctx
+	return w2
 }
 
 // QueryMeta is used to return meta data about a query
@@ -65,6 +149,10 @@ type QueryMeta struct {
 
 	// How long did the request take
 	RequestTime time.Duration
+
+	// NextToken is set if a list search has more pages, and is passed as the
+	// NextToken of the QueryOptions to get the next page
+	NextToken string
 }
 
 // WriteMeta is used to return meta data about a write
@@ -94,6 +182,13 @@ type Config struct {
 	// Region to use. If not provided, the default agent region is used.
 	Region string
 
+	// Namespace to use. If not provided, list queries return the objects of
+	// all namespaces and jobs are registered in the default namespace.
+	Namespace string
+
+	// SecretID to use. This can be overwritten per request.
+	SecretID string
+
 	// HttpClient is the client to use. Default will be
 	// used if not provided.
 	HttpClient *http.Client
@@ -108,6 +203,48 @@ type Config struct {
 	// TLSConfig provides the various TLS related configurations for the http
 	// client
 	TLSConfig *TLSConfig
+
+	// Retry configures the retries of failed requests. If not provided,
+	// requests are not retried.
+	Retry *RetryConfig
+}
+
+// RetryConfig configures how requests failing with a connection error or a 5xx
+// status code, e.g. while the servers elect a new leader, are retried. Writes
+// failing with a 5xx status code are only retried if the cluster has no leader
+// or the agent is unavailable (503), as they may have been applied otherwise.
+// Requests whose body can't be read again are not retried.
+type RetryConfig struct {
+	// MaxRetries is the number of times a failed request is retried. Zero
+	// disables retries.
+	MaxRetries int
+
+	// MinBackoff is the wait before the first retry. It doubles with every
+	// retry up to MaxBackoff, and a random jitter of up to half of it is
+	// subtracted so that clients don't retry in lockstep. Defaults to 250ms.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum wait between two retries. Defaults to 5s.
+	MaxBackoff time.Duration
+}
+
+// backoff returns the wait before the given retry, counting from zero
+func (r *RetryConfig) backoff(retry int) time.Duration {
+	min, max := r.MinBackoff, r.MaxBackoff
+	if min <= 0 {
+		min = 250 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+	wait := min
+	for i := 0; i < retry && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait - time.Duration(rand.Int63n(int64(wait)/2+1))
 }
 
 // CopyConfig copies the configuration with a new address
@@ -119,10 +256,13 @@ func (c *Config) CopyConfig(address string, tlsEnabled bool) *Config {
 	config := &Config{
 		Address:    fmt.Sprintf("%s://%s", scheme, address),
 		Region:     c.Region,
+		Namespace:  c.Namespace,
+		SecretID:   c.SecretID,
 		HttpClient: c.HttpClient,
 		HttpAuth:   c.HttpAuth,
 		WaitTime:   c.WaitTime,
 		TLSConfig:  c.TLSConfig,
+		Retry:      c.Retry,
 	}
 
 	return config
@@ -157,10 +297,16 @@ type TLSConfig struct {
 func DefaultConfig() *Config {
 	config := &Config{
 		Address:    "http://127.0.0.1:4646",
-		HttpClient: cleanhttp.DefaultClient(),
+		HttpClient: cleanhttp.DefaultPooledClient(),
 		TLSConfig:  &TLSConfig{},
 	}
+
+	// Keep connections to the agent alive so that consecutive requests, e.g.
+	// blocking queries, don't pay for new TCP and TLS handshakes. Idle
+	// connections are closed after a while so clients don't leak them.
 	transport := config.HttpClient.Transport.(*http.Transport)
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
 	transport.TLSHandshakeTimeout = 10 * time.Second
 	transport.TLSClientConfig = &tls.Config{
 		MinVersion: tls.VersionTLS12,
@@ -169,6 +315,12 @@ func DefaultConfig() *Config {
 	if addr := os.Getenv("NOMAD_ADDR"); addr != "" {
 		config.Address = addr
 	}
+	if namespace := os.Getenv("NOMAD_NAMESPACE"); namespace != "" {
+		config.Namespace = namespace
+	}
+	if token := os.Getenv("NOMAD_TOKEN"); token != "" {
+		config.SecretID = token
+	}
 	if auth := os.Getenv("NOMAD_HTTP_AUTH"); auth != "" {
 		var username, password string
 		if strings.Contains(auth, ":") {
@@ -252,6 +404,10 @@ func (c *Config) ConfigureTLS() error {
 // Client provides a client to the Nomad API
 type Client struct {
 	config Config
+
+	// unixSocket is the path of the Unix domain socket of the agent if the
+	// address is a unix:// URL
+	unixSocket string
 }
 
 // NewClient returns a new client
@@ -277,6 +433,24 @@ func NewClient(config *Config) (*Client, error) {
 	client := &Client{
 		config: *config,
 	}
+
+	// Dial the socket of unix:// addresses
+	if strings.HasPrefix(config.Address, "unix://") {
+		path := strings.TrimPrefix(config.Address, "unix://")
+		if path == "" {
+			return nil, fmt.Errorf("invalid address '%s': missing socket path", config.Address)
+		}
+		transport, ok := config.HttpClient.Transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("unix socket addresses require an *http.Transport")
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+		client.unixSocket = path
+	}
 	return client, nil
 }
 
@@ -285,14 +459,26 @@ func (c *Client) SetRegion(region string) {
 	c.config.Region = region
 }
 
+// SetNamespace sets the namespace to scope API requests to.
+func (c *Client) SetNamespace(namespace string) {
+	c.config.Namespace = namespace
+}
+
+// SetSecretID sets the ACL token secret for API requests.
+func (c *Client) SetSecretID(secretID string) {
+	c.config.SecretID = secretID
+}
+
 // request is used to help build up a request
 type request struct {
 	config *Config
 	method string
 	url    *url.URL
 	params url.Values
+	token  string
 	body   io.Reader
 	obj    interface{}
+	ctx    context.Context
 }
 
 // setQueryOptions is used to annotate the request with
@@ -304,6 +490,9 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Region != "" {
 		r.params.Set("region", q.Region)
 	}
+	if q.Namespace != "" {
+		r.params.Set("namespace", q.Namespace)
+	}
 	if q.AllowStale {
 		r.params.Set("stale", "")
 	}
@@ -316,6 +505,21 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Prefix != "" {
 		r.params.Set("prefix", q.Prefix)
 	}
+	if q.Filter != "" {
+		r.params.Set("filter", q.Filter)
+	}
+	if q.PerPage != 0 {
+		r.params.Set("per_page", strconv.FormatInt(int64(q.PerPage), 10))
+	}
+	if q.NextToken != "" {
+		r.params.Set("next_token", q.NextToken)
+	}
+	if q.AuthToken != "" {
+		r.token = q.AuthToken
+	}
+	if q.ctx != nil {
+		r.ctx = q.ctx
+	}
 	for k, v := range q.Params {
 		r.params.Set(k, v)
 	}
@@ -335,6 +539,15 @@ func (r *request) setWriteOptions(q *WriteOptions) {
 	if q.Region != "" {
 		r.params.Set("region", q.Region)
 	}
+	if q.Namespace != "" {
+		r.params.Set("namespace", q.Namespace)
+	}
+	if q.AuthToken != "" {
+		r.token = q.AuthToken
+	}
+	if q.ctx != nil {
+		r.ctx = q.ctx
+	}
 }
 
 // toHTTP converts the request to an HTTP request
@@ -356,6 +569,9 @@ func (r *request) toHTTP() (*http.Request, error) {
 	if err != nil {
 		return nil, err
 	}
+	if r.ctx != nil {
+		req = req.WithContext(r.ctx)
+	}
 
 	// Optionally configure HTTP basic authentication
 	if r.url.User != nil {
@@ -366,6 +582,10 @@ func (r *request) toHTTP() (*http.Request, error) {
 		req.SetBasicAuth(r.config.HttpAuth.Username, r.config.HttpAuth.Password)
 	}
 
+	if r.token != "" {
+		req.Header.Set("X-Nomad-Token", r.token)
+	}
+
 	req.Header.Add("Accept-Encoding", "gzip")
 	req.URL.Host = r.url.Host
 	req.URL.Scheme = r.url.Scheme
@@ -376,6 +596,10 @@ func (r *request) toHTTP() (*http.Request, error) {
 // newRequest is used to create a new request
 func (c *Client) newRequest(method, path string) *request {
 	base, _ := url.Parse(c.config.Address)
+	if c.unixSocket != "" {
+		// The transport dials the socket whatever the host is
+		base = &url.URL{Scheme: "http", Host: "localhost"}
+	}
 	u, _ := url.Parse(path)
 	r := &request{
 		config: &c.config,
@@ -391,9 +615,15 @@ func (c *Client) newRequest(method, path string) *request {
 	if c.config.Region != "" {
 		r.params.Set("region", c.config.Region)
 	}
+	if c.config.Namespace != "" {
+		r.params.Set("namespace", c.config.Namespace)
+	}
 	if c.config.WaitTime != 0 {
 		r.params.Set("wait", durToMsec(r.config.WaitTime))
 	}
+	if c.config.SecretID != "" {
+		r.token = r.config.SecretID
+	}
 
 	// Add in the query parameters, if any
 	for key, values := range u.Query() {
@@ -405,6 +635,39 @@ func (c *Client) newRequest(method, path string) *request {
 	return r
 }
 
+// websocketConn opens a websocket connection to the endpoint
+func (c *Client) websocketConn(endpoint string, q *QueryOptions) (*websocket.Conn, error) {
+	r := c.newRequest("GET", endpoint)
+	r.setQueryOptions(q)
+	req, err := r.toHTTP()
+	if err != nil {
+		return nil, err
+	}
+
+	// Compressed responses can't be upgraded
+	req.Header.Del("Accept-Encoding")
+
+	u := *req.URL
+	u.Scheme = "ws"
+	if req.URL.Scheme == "https" {
+		u.Scheme = "wss"
+	}
+
+	if c.unixSocket != "" {
+		conn, err := net.Dial("unix", c.unixSocket)
+		if err != nil {
+			return nil, err
+		}
+		return websocket.NewClient(conn, &u, req.Header)
+	}
+
+	var tlsConfig *tls.Config
+	if transport, ok := c.config.HttpClient.Transport.(*http.Transport); ok {
+		tlsConfig = transport.TLSClientConfig
+	}
+	return websocket.Dial(u.String(), req.Header, tlsConfig)
+}
+
 // multiCloser is to wrap a ReadCloser such that when close is called, multiple
 // Closes occur.
 type multiCloser struct {
@@ -425,39 +688,101 @@ func (m *multiCloser) Read(p []byte) (int, error) {
 	return m.reader.Read(p)
 }
 
-// doRequest runs a request with our client
+// doRequest runs a request with our client, retrying it as configured
 func (c *Client) doRequest(r *request) (time.Duration, *http.Response, error) {
 	req, err := r.toHTTP()
 	if err != nil {
 		return 0, nil, err
 	}
 	start := time.Now()
-	resp, err := c.config.HttpClient.Do(req)
-	diff := time.Now().Sub(start)
-
-	// If the response is compressed, we swap the body's reader.
-	if resp != nil && resp.Header != nil {
-		var reader io.ReadCloser
-		switch resp.Header.Get("Content-Encoding") {
-		case "gzip":
-			greader, err := gzip.NewReader(resp.Body)
+	resp, err := c.do(req)
+	for retry := 0; c.shouldRetry(req, resp, err, retry); retry++ {
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		// Wait before retrying, unless the request is canceled meanwhile
+		timer := time.NewTimer(c.config.Retry.backoff(retry))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return time.Now().Sub(start), nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
 			if err != nil {
-				return 0, nil, err
+				return time.Now().Sub(start), nil, err
 			}
+			req.Body = body
+		}
+		resp, err = c.do(req)
+	}
+	return time.Now().Sub(start), resp, err
+}
 
-			// The gzip reader doesn't close the wrapped reader so we use
-			// multiCloser.
-			reader = &multiCloser{
-				reader:       greader,
-				inorderClose: []io.Closer{greader, resp.Body},
-			}
-		default:
-			reader = resp.Body
+// do runs a single attempt of the request. If the response is compressed, the
+// reader of its body is swapped.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.config.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Header != nil && resp.Header.Get("Content-Encoding") == "gzip" {
+		greader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
 		}
-		resp.Body = reader
+
+		// The gzip reader doesn't close the wrapped reader so we use
+		// multiCloser.
+		resp.Body = &multiCloser{
+			reader:       greader,
+			inorderClose: []io.Closer{greader, resp.Body},
+		}
+	}
+	return resp, nil
+}
+
+// shouldRetry returns whether the request should be retried after the
+// response or error of its last attempt. Writes failing with a 5xx status code
+// may have been applied, so they are only retried when the cluster had no
+// leader or the agent was unavailable.
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response, err error, retry int) bool {
+	if c.config.Retry == nil || retry >= c.config.Retry.MaxRetries {
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
 	}
+	if err != nil {
+		return true
+	}
+	if resp.StatusCode < 500 {
+		return false
+	}
+	if req.Method == "GET" || resp.StatusCode == 503 {
+		return true
+	}
+	return isNoLeaderResponse(resp)
+}
 
-	return diff, resp, err
+// isNoLeaderResponse returns whether the body of the error response reports
+// that the cluster has no leader. The body is left intact for the caller.
+func isNoLeaderResponse(resp *http.Response) bool {
+	peek, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	resp.Body = &multiCloser{
+		reader:       io.MultiReader(bytes.NewReader(peek), resp.Body),
+		inorderClose: []io.Closer{resp.Body},
+	}
+	return strings.Contains(string(peek), "No cluster leader")
 }
 
 // rawQuery makes a GET request to the specified endpoint but returns just the
@@ -565,6 +890,9 @@ func parseQueryMeta(resp *http.Response, q *QueryMeta) error {
 	default:
 		q.KnownLeader = false
 	}
+
+	// Parse the X-Nomad-NextToken
+	q.NextToken = header.Get("X-Nomad-NextToken")
 	return nil
 }
 
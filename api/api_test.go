@@ -1,14 +1,21 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/NYTimes/gziphandler"
+	"github.com/hashicorp/nomad/helper/websocket"
 	"github.com/hashicorp/nomad/testutil"
 )
 
@@ -42,6 +49,27 @@ func makeClient(t *testing.T, cb1 configCallback,
 	return client, server
 }
 
+// makeACLClient creates a client against a server with ACLs enabled and
+// configures it with the bootstrap management token.
+func makeACLClient(t *testing.T, cb1 configCallback,
+	cb2 testutil.ServerConfigCallback) (*Client, *testutil.TestServer, *ACLToken) {
+	client, server := makeClient(t, cb1, func(c *testutil.TestServerConfig) {
+		c.ACL.Enabled = true
+		if cb2 != nil {
+			cb2(c)
+		}
+	})
+
+	// Bootstrap the ACL system and use the management token
+	root, _, err := client.ACLTokens().Bootstrap(nil)
+	if err != nil {
+		server.Stop()
+		t.Fatalf("failed to bootstrap ACLs: %v", err)
+	}
+	client.SetSecretID(root.SecretID)
+	return client, server, root
+}
+
 func TestRequestTime(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		time.Sleep(100 * time.Millisecond)
@@ -121,15 +149,22 @@ func TestSetQueryOptions(t *testing.T) {
 	r := c.newRequest("GET", "/v1/jobs")
 	q := &QueryOptions{
 		Region:     "foo",
+		Namespace:  "bar",
 		AllowStale: true,
 		WaitIndex:  1000,
 		WaitTime:   100 * time.Second,
+		Filter:     `Status == "running"`,
+		PerPage:    10,
+		NextToken:  "abc",
 	}
 	r.setQueryOptions(q)
 
 	if r.params.Get("region") != "foo" {
 		t.Fatalf("bad: %v", r.params)
 	}
+	if r.params.Get("namespace") != "bar" {
+		t.Fatalf("bad: %v", r.params)
+	}
 	if _, ok := r.params["stale"]; !ok {
 		t.Fatalf("bad: %v", r.params)
 	}
@@ -139,6 +174,15 @@ func TestSetQueryOptions(t *testing.T) {
 	if r.params.Get("wait") != "100000ms" {
 		t.Fatalf("bad: %v", r.params)
 	}
+	if r.params.Get("filter") != `Status == "running"` {
+		t.Fatalf("bad: %v", r.params)
+	}
+	if r.params.Get("per_page") != "10" {
+		t.Fatalf("bad: %v", r.params)
+	}
+	if r.params.Get("next_token") != "abc" {
+		t.Fatalf("bad: %v", r.params)
+	}
 }
 
 func TestSetWriteOptions(t *testing.T) {
@@ -147,13 +191,35 @@ func TestSetWriteOptions(t *testing.T) {
 
 	r := c.newRequest("GET", "/v1/jobs")
 	q := &WriteOptions{
-		Region: "foo",
+		Region:    "foo",
+		Namespace: "bar",
 	}
 	r.setWriteOptions(q)
 
 	if r.params.Get("region") != "foo" {
 		t.Fatalf("bad: %v", r.params)
 	}
+	if r.params.Get("namespace") != "bar" {
+		t.Fatalf("bad: %v", r.params)
+	}
+}
+
+func TestClient_Namespace(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	c.SetNamespace("foo")
+
+	// The namespace of the config is used by default
+	r := c.newRequest("GET", "/v1/jobs")
+	if r.params.Get("namespace") != "foo" {
+		t.Fatalf("bad: %v", r.params)
+	}
+
+	// The namespace of the query overrides it
+	r.setQueryOptions(&QueryOptions{Namespace: AllNamespacesNamespace})
+	if r.params.Get("namespace") != AllNamespacesNamespace {
+		t.Fatalf("bad: %v", r.params)
+	}
 }
 
 func TestRequestToHTTP(t *testing.T) {
@@ -178,6 +244,411 @@ func TestRequestToHTTP(t *testing.T) {
 	}
 }
 
+func TestQueryOptions_WithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var q *QueryOptions
+	if q.Context() != context.Background() {
+		t.Fatalf("bad: %v", q.Context())
+	}
+
+	q = &QueryOptions{Region: "foo"}
+	q2 := q.WithContext(ctx)
+	if q2.Region != "foo" || q2.Context() != ctx {
+		t.Fatalf("bad: %#v", q2)
+	}
+	if q.ctx != nil {
+		t.Fatalf("original options modified: %#v", q)
+	}
+
+	w := (*WriteOptions)(nil).WithContext(ctx)
+	if w.Context() != ctx {
+		t.Fatalf("bad: %#v", w)
+	}
+}
+
+func TestClient_BlockingQueries(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+
+	// Queries of tables that do not change block until the wait time expires
+	queries := map[string]func(q *QueryOptions) (*QueryMeta, error){
+		"jobs": func(q *QueryOptions) (*QueryMeta, error) {
+			_, qm, err := c.Jobs().List(q)
+			return qm, err
+		},
+		"evaluations": func(q *QueryOptions) (*QueryMeta, error) {
+			_, qm, err := c.Evaluations().List(q)
+			return qm, err
+		},
+		"allocations": func(q *QueryOptions) (*QueryMeta, error) {
+			_, qm, err := c.Allocations().List(q)
+			return qm, err
+		},
+		"deployments": func(q *QueryOptions) (*QueryMeta, error) {
+			_, qm, err := c.Deployments().List(q)
+			return qm, err
+		},
+	}
+	for name, query := range queries {
+		qm, err := query(nil)
+		if err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		index := qm.LastIndex
+		if index == 0 {
+			index = 1
+		}
+
+		start := time.Now()
+		qm, err = query(&QueryOptions{WaitIndex: index, WaitTime: 200 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("%s: err: %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+			t.Fatalf("%s: query did not block: %s", name, elapsed)
+		}
+		if qm.LastIndex > index {
+			t.Fatalf("%s: bad index: %d", name, qm.LastIndex)
+		}
+	}
+
+	// Register a job and wait for its next change
+	jobs := c.Jobs()
+	job := testJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, qm, err := jobs.Info(job.ID, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	type result struct {
+		qm  *QueryMeta
+		err error
+	}
+	listCh := make(chan result, 1)
+	infoCh := make(chan result, 1)
+	q := &QueryOptions{WaitIndex: qm.LastIndex, WaitTime: 10 * time.Second}
+	go func() {
+		_, qm, err := jobs.List(q)
+		listCh <- result{qm, err}
+	}()
+	go func() {
+		_, qm, err := jobs.Info(job.ID, q)
+		infoCh <- result{qm, err}
+	}()
+
+	// Updating the job wakes up the queries
+	time.Sleep(100 * time.Millisecond)
+	job.Meta = map[string]string{"foo": "bar"}
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, ch := range []chan result{listCh, infoCh} {
+		select {
+		case res := <-ch:
+			if res.err != nil {
+				t.Fatalf("err: %v", res.err)
+			}
+			if res.qm.LastIndex <= qm.LastIndex {
+				t.Fatalf("bad index: %d", res.qm.LastIndex)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("query not woken up by the update")
+		}
+	}
+}
+
+func TestClient_ContextCancel(t *testing.T) {
+	// The server blocks until the request is canceled
+	done := make(chan struct{})
+	defer close(done)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer ts.Close()
+
+	conf := DefaultConfig()
+	conf.Address = ts.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err = c.Jobs().List((&QueryOptions{WaitIndex: 1000}).WithContext(ctx))
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("request not canceled")
+	}
+
+	_, _, err = c.Jobs().Deregister("example", (&WriteOptions{}).WithContext(ctx))
+	if err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	// The server fails the first attempts of every request
+	var attempts int
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ = ioutil.ReadAll(r.Body)
+		if attempts%3 != 0 {
+			w.WriteHeader(500)
+			w.Write([]byte("No cluster leader"))
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+
+	conf := DefaultConfig()
+	conf.Address = ts.URL
+	conf.Retry = &RetryConfig{MaxRetries: 2, MinBackoff: time.Millisecond}
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, _, err := c.Jobs().List(nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("bad: %d attempts", attempts)
+	}
+
+	// The body of writes is sent again
+	if _, err := c.write("/v1/foo", map[string]string{"foo": "bar"}, nil, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if attempts != 6 || string(body) != "{\"foo\":\"bar\"}\n" {
+		t.Fatalf("bad: %d attempts, body %q", attempts, body)
+	}
+
+	// Requests fail once the retries are exhausted
+	conf.Retry.MaxRetries = 1
+	c, err = NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	attempts = 0
+	if _, _, err := c.Jobs().List(nil); err == nil || !strings.Contains(err.Error(), "No cluster leader") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("bad: %d attempts", attempts)
+	}
+
+	// Requests are not retried by default
+	conf.Retry = nil
+	c, err = NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	attempts = 0
+	if _, _, err := c.Jobs().List(nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	if attempts != 1 {
+		t.Fatalf("bad: %d attempts", attempts)
+	}
+}
+
+func TestClient_Retry_Write(t *testing.T) {
+	// The server fails every request with an internal error
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(500)
+		w.Write([]byte("failed to apply"))
+	}))
+	defer ts.Close()
+
+	conf := DefaultConfig()
+	conf.Address = ts.URL
+	conf.Retry = &RetryConfig{MaxRetries: 2, MinBackoff: time.Millisecond}
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Writes may have been applied so they are sent once
+	_, err = c.write("/v1/foo", map[string]string{"foo": "bar"}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to apply") {
+		t.Fatalf("expected error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("bad: %d attempts", attempts)
+	}
+
+	// Reads are retried
+	attempts = 0
+	if _, _, err := c.Jobs().List(nil); err == nil {
+		t.Fatalf("expected error")
+	}
+	if attempts != 3 {
+		t.Fatalf("bad: %d attempts", attempts)
+	}
+}
+
+func TestClient_Retry_ConnectionError(t *testing.T) {
+	// Reserve an address nothing listens on
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	conf := DefaultConfig()
+	conf.Address = ts.URL
+	conf.Retry = &RetryConfig{MaxRetries: 10, MinBackoff: time.Hour}
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The context cancels the wait between retries
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := c.Jobs().List((&QueryOptions{}).WithContext(ctx)); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+}
+
+func TestRetryConfig_Backoff(t *testing.T) {
+	r := &RetryConfig{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	cases := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{100, time.Second},
+	}
+	for _, c := range cases {
+		if wait := r.backoff(c.retry); wait > c.max || wait < c.max/2 {
+			t.Fatalf("bad: retry %d waits %v, expected up to %v", c.retry, wait, c.max)
+		}
+	}
+}
+
+func TestClient_UnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-api")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nomad.sock")
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/jobs":
+			w.Write([]byte(`[{"ID":"example"}]`))
+		case "/v1/echo":
+			conn, err := websocket.Upgrade(w, r)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			if messageType, data, err := conn.ReadMessage(); err == nil {
+				conn.WriteMessage(messageType, data)
+			}
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	ts.Listener = l
+	ts.Start()
+	defer ts.Close()
+
+	conf := DefaultConfig()
+	conf.Address = "unix://" + path
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	jobs, _, err := c.Jobs().List(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "example" {
+		t.Fatalf("bad: %#v", jobs)
+	}
+
+	// Websockets are dialed over the socket too
+	conn, err := c.websocketConn("/v1/echo", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("foo")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "foo" {
+		t.Fatalf("bad: %q %v", data, err)
+	}
+
+	// The socket path is required
+	conf = DefaultConfig()
+	conf.Address = "unix://"
+	if _, err := NewClient(conf); err == nil || !strings.Contains(err.Error(), "missing socket path") {
+		t.Fatalf("expected error, got %v", err)
+	}
+}
+
+func TestClient_GzipKeepAlive(t *testing.T) {
+	// The server compresses responses and counts the connections opened
+	var conns int32
+	ts := httptest.NewUnstartedServer(gziphandler.GzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"ID":"example"}]`))
+	})))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	conf := DefaultConfig()
+	conf.Address = ts.URL
+	c, err := NewClient(conf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		jobs, _, err := c.Jobs().List(nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(jobs) != 1 || jobs[0].ID != "example" {
+			t.Fatalf("bad: %#v", jobs)
+		}
+	}
+
+	// The connection is reused between requests
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Fatalf("bad: %d connections", n)
+	}
+}
+
 func TestParseQueryMeta(t *testing.T) {
 	resp := &http.Response{
 		Header: make(map[string][]string),
@@ -185,6 +656,7 @@ func TestParseQueryMeta(t *testing.T) {
 	resp.Header.Set("X-Nomad-Index", "12345")
 	resp.Header.Set("X-Nomad-LastContact", "80")
 	resp.Header.Set("X-Nomad-KnownLeader", "true")
+	resp.Header.Set("X-Nomad-NextToken", "abc")
 
 	qm := &QueryMeta{}
 	if err := parseQueryMeta(resp, qm); err != nil {
@@ -200,6 +672,9 @@ func TestParseQueryMeta(t *testing.T) {
 	if !qm.KnownLeader {
 		t.Fatalf("Bad: %v", qm)
 	}
+	if qm.NextToken != "abc" {
+		t.Fatalf("Bad: %v", qm)
+	}
 }
 
 func TestParseWriteMeta(t *testing.T) {
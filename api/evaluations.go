@@ -69,6 +69,9 @@ type Evaluation struct {
 	PreviousEval      string
 	BlockedEval       string
 	FailedTGAllocs    map[string]*AllocationMetric
+	QueuedAllocations map[string]int
+	QuotaLimitReached string
+	Trace             bool
 	CreateIndex       uint64
 	ModifyIndex       uint64
 }
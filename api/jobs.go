@@ -33,26 +33,40 @@ func (c *Client) Jobs() *Jobs {
 // Register is used to register a new job. It returns the ID
 // of the evaluation, along with any errors encountered.
 func (j *Jobs) Register(job *Job, q *WriteOptions) (string, *WriteMeta, error) {
-
-	var resp registerJobResponse
-
-	req := &RegisterJobRequest{Job: job}
-	wm, err := j.client.write("/v1/jobs", req, &resp, q)
-	if err != nil {
-		return "", nil, err
-	}
-	return resp.EvalID, wm, nil
+	return j.RegisterOpts(job, nil, q)
 }
 
 // EnforceRegister is used to register a job enforcing its job modify index.
 func (j *Jobs) EnforceRegister(job *Job, modifyIndex uint64, q *WriteOptions) (string, *WriteMeta, error) {
+	opts := &RegisterOptions{
+		EnforceIndex: true,
+		ModifyIndex:  modifyIndex,
+	}
+	return j.RegisterOpts(job, opts, q)
+}
+
+// RegisterOptions is used to pass through job registration parameters
+type RegisterOptions struct {
+	// EnforceIndex only registers the job if its job modify index matches
+	// ModifyIndex
+	EnforceIndex bool
+	ModifyIndex  uint64
+
+	// Trace records the decision of the scheduler for each node considered
+	// while evaluating the job
+	Trace bool
+}
 
+// RegisterOpts is used to register a new job with the passed RegisterOpts. It
+// returns the ID of the evaluation, along with any errors encountered.
+func (j *Jobs) RegisterOpts(job *Job, opts *RegisterOptions, q *WriteOptions) (string, *WriteMeta, error) {
 	var resp registerJobResponse
 
-	req := &RegisterJobRequest{
-		Job:            job,
-		EnforceIndex:   true,
-		JobModifyIndex: modifyIndex,
+	req := &RegisterJobRequest{Job: job}
+	if opts != nil {
+		req.EnforceIndex = opts.EnforceIndex
+		req.JobModifyIndex = opts.ModifyIndex
+		req.Trace = opts.Trace
 	}
 	wm, err := j.client.write("/v1/jobs", req, &resp, q)
 	if err != nil {
@@ -88,6 +102,37 @@ func (j *Jobs) Info(jobID string, q *QueryOptions) (*Job, *QueryMeta, error) {
 	return &resp, qm, nil
 }
 
+// Versions is used to retrieve the tracked versions of a particular job,
+// sorted from newest to oldest. If diffs is set, the diffs between consecutive
+// versions are also returned.
+func (j *Jobs) Versions(jobID string, diffs bool, q *QueryOptions) ([]*Job, []*JobDiff, *QueryMeta, error) {
+	var resp jobVersionsResponse
+	qm, err := j.client.query(fmt.Sprintf("/v1/job/%s/versions?diffs=%v", jobID, diffs), &resp, q)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return resp.Versions, resp.Diffs, qm, nil
+}
+
+// Revert is used to revert the given job to the passed version. If
+// enforcePriorVersion is set, the job is only reverted if its current
+// version matches.
+func (j *Jobs) Revert(jobID string, version uint64, enforcePriorVersion *uint64,
+	q *WriteOptions) (string, *WriteMeta, error) {
+
+	var resp registerJobResponse
+	req := &JobRevertRequest{
+		JobID:               jobID,
+		JobVersion:          version,
+		EnforcePriorVersion: enforcePriorVersion,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/revert", req, &resp, q)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.EvalID, wm, nil
+}
+
 // Allocations is used to return the allocs for a given job ID.
 func (j *Jobs) Allocations(jobID string, q *QueryOptions) ([]*AllocationListStub, *QueryMeta, error) {
 	var resp []*AllocationListStub
@@ -159,6 +204,42 @@ func (j *Jobs) Plan(job *Job, diff bool, q *WriteOptions) (*JobPlanResponse, *Wr
 	return &resp, wm, nil
 }
 
+// Validate is used to run the server side validation of the job without
+// registering it.
+func (j *Jobs) Validate(job *Job, q *WriteOptions) (*JobValidateResponse, *WriteMeta, error) {
+	if job == nil {
+		return nil, nil, fmt.Errorf("must pass non-nil job")
+	}
+
+	var resp JobValidateResponse
+	req := &JobValidateRequest{
+		Job: job,
+	}
+	wm, err := j.client.write("/v1/validate/job", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &resp, wm, nil
+}
+
+// Dispatch is used to dispatch an instance of the given parameterized job
+// with the passed metadata and payload.
+func (j *Jobs) Dispatch(jobID string, meta map[string]string,
+	payload []byte, q *WriteOptions) (*JobDispatchResponse, *WriteMeta, error) {
+	var resp JobDispatchResponse
+	req := &JobDispatchRequest{
+		JobID:   jobID,
+		Meta:    meta,
+		Payload: payload,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/dispatch", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 func (j *Jobs) Summary(jobID string, q *QueryOptions) (*JobSummary, *QueryMeta, error) {
 	var resp JobSummary
 	qm, err := j.client.query("/v1/job/"+jobID+"/summary", &resp, q)
@@ -175,8 +256,12 @@ type periodicForceResponse struct {
 
 // UpdateStrategy is for serializing update strategy for a job.
 type UpdateStrategy struct {
-	Stagger     time.Duration
-	MaxParallel int
+	Stagger         time.Duration
+	MaxParallel     int
+	Canary          int
+	AutoRevert      bool
+	HealthCheck     string
+	HealthyDeadline time.Duration
 }
 
 // PeriodicConfig is for serializing periodic config for a job.
@@ -187,24 +272,40 @@ type PeriodicConfig struct {
 	ProhibitOverlap bool
 }
 
+// ParameterizedJobConfig is used to configure a job that is dispatched
+// with metadata and a payload.
+type ParameterizedJobConfig struct {
+	Payload      string
+	MetaRequired []string
+	MetaOptional []string
+}
+
 // Job is used to serialize a job.
 type Job struct {
 	Region            string
+	Regions           []string
 	ID                string
 	ParentID          string
+	Namespace         string
 	Name              string
 	Type              string
 	Priority          int
 	AllAtOnce         bool
 	Datacenters       []string
 	Constraints       []*Constraint
+	Affinities        []*Affinity
+	Spreads           []*Spread
 	TaskGroups        []*TaskGroup
 	Update            *UpdateStrategy
 	Periodic          *PeriodicConfig
+	ParameterizedJob  *ParameterizedJobConfig
+	Payload           []byte
 	Meta              map[string]string
 	VaultToken        string
 	Status            string
 	StatusDescription string
+	Version           uint64
+	Stable            bool
 	CreateIndex       uint64
 	ModifyIndex       uint64
 	JobModifyIndex    uint64
@@ -308,6 +409,18 @@ func (j *Job) Constrain(c *Constraint) *Job {
 	return j
 }
 
+// AddAffinity is used to add a placement preference to a job.
+func (j *Job) AddAffinity(a *Affinity) *Job {
+	j.Affinities = append(j.Affinities, a)
+	return j
+}
+
+// AddSpread is used to add a spread to a job.
+func (j *Job) AddSpread(s *Spread) *Job {
+	j.Spreads = append(j.Spreads, s)
+	return j
+}
+
 // AddTaskGroup adds a task group to an existing job.
 func (j *Job) AddTaskGroup(grp *TaskGroup) *Job {
 	j.TaskGroups = append(j.TaskGroups, grp)
@@ -325,6 +438,7 @@ type RegisterJobRequest struct {
 	Job            *Job
 	EnforceIndex   bool   `json:",omitempty"`
 	JobModifyIndex uint64 `json:",omitempty"`
+	Trace          bool   `json:",omitempty"`
 }
 
 // registerJobResponse is used to deserialize a job response
@@ -332,11 +446,40 @@ type registerJobResponse struct {
 	EvalID string
 }
 
+// jobVersionsResponse is used to decode a versions response
+type jobVersionsResponse struct {
+	Versions []*Job
+	Diffs    []*JobDiff
+}
+
+// JobRevertRequest is used to serialize a job revert request
+type JobRevertRequest struct {
+	JobID               string
+	JobVersion          uint64
+	EnforcePriorVersion *uint64 `json:",omitempty"`
+}
+
 // deregisterJobResponse is used to decode a deregister response
 type deregisterJobResponse struct {
 	EvalID string
 }
 
+// JobDispatchRequest is used to serialize a job dispatch request
+type JobDispatchRequest struct {
+	JobID   string
+	Payload []byte
+	Meta    map[string]string
+}
+
+// JobDispatchResponse is used to decode a job dispatch response
+type JobDispatchResponse struct {
+	DispatchedJobID string
+	EvalID          string
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+	QueryMeta
+}
+
 type JobPlanRequest struct {
 	Job  *Job
 	Diff bool
@@ -351,6 +494,22 @@ type JobPlanResponse struct {
 	NextPeriodicLaunch time.Time
 }
 
+// JobValidateRequest is used to validate a job
+type JobValidateRequest struct {
+	Job *Job
+}
+
+// JobValidateResponse is the response from a job validate request
+type JobValidateResponse struct {
+	// ValidationErrors are the problems preventing the job from being
+	// registered.
+	ValidationErrors []string
+
+	// Warnings are the problems that don't prevent the job from being
+	// registered but are likely unintended.
+	Warnings []string
+}
+
 type JobDiff struct {
 	Type       string
 	ID         string
@@ -368,6 +368,83 @@ func TestJobs_ForceEvaluate(t *testing.T) {
 	t.Fatalf("evaluation %q missing", evalID)
 }
 
+func TestJobs_Versions(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Querying the versions of a non-existent job fails
+	_, _, _, err := jobs.Versions("job1", false, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %#v", err)
+	}
+
+	// Register the job twice
+	job := testJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	job.Priority = 2
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Query the versions along with the diffs
+	versions, diffs, qm, err := jobs.Versions("job1", true, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(versions) != 2 || versions[0].Version != 1 || versions[1].Version != 0 {
+		t.Fatalf("bad: %#v", versions)
+	}
+	if len(diffs) != 1 || diffs[0].Type != "Edited" {
+		t.Fatalf("bad: %#v", diffs)
+	}
+}
+
+func TestJobs_Revert(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Register the job twice
+	job := testJob()
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	job.Priority = 2
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Reverting with the wrong prior version fails
+	wrong := uint64(0)
+	if _, _, err := jobs.Revert("job1", 0, &wrong, nil); err == nil {
+		t.Fatalf("expected enforcement error")
+	}
+
+	// Revert to the first version
+	prior := uint64(1)
+	evalID, wm, err := jobs.Revert("job1", 0, &prior, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if evalID == "" {
+		t.Fatalf("missing eval ID")
+	}
+
+	// Check the job was registered as a new version
+	out, _, err := jobs.Info("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.Version != 2 || out.Priority != 1 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestJobs_PeriodicForce(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -420,6 +497,92 @@ func TestJobs_PeriodicForce(t *testing.T) {
 	t.Fatalf("evaluation %q missing", evalID)
 }
 
+func TestJobs_Dispatch(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Dispatching a non-existent job fails
+	_, _, err := jobs.Dispatch("job1", nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %#v", err)
+	}
+
+	// Register a parameterized job
+	job := testJob()
+	job.ParameterizedJob = &ParameterizedJobConfig{
+		MetaRequired: []string{"foo"},
+	}
+	if _, _, err := jobs.Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Dispatching without the required meta fails
+	_, _, err = jobs.Dispatch(job.ID, nil, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "required meta keys") {
+		t.Fatalf("expected meta error, got: %#v", err)
+	}
+
+	// Dispatch the job
+	resp, wm, err := jobs.Dispatch(job.ID, map[string]string{"foo": "bar"}, []byte("hello"), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+	if resp.EvalID == "" || !strings.HasPrefix(resp.DispatchedJobID, job.ID+"/dispatch-") {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The dispatched job carries the meta and payload
+	out, _, err := jobs.Info(resp.DispatchedJobID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.ParentID != job.ID || out.Meta["foo"] != "bar" || string(out.Payload) != "hello" {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobs_Validate(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	// Check that passing a nil job fails
+	if _, _, err := jobs.Validate(nil, nil); err == nil {
+		t.Fatalf("expect an error when job isn't provided")
+	}
+
+	// Validate a valid job
+	job := testJob()
+	resp, _, err := jobs.Validate(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(resp.ValidationErrors) != 0 {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Validate an invalid job
+	job.Datacenters = nil
+	resp, _, err = jobs.Validate(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(resp.ValidationErrors) != 1 || !strings.Contains(resp.ValidationErrors[0], "datacenters") {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The job wasn't registered
+	list, _, err := jobs.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("bad: %#v", list)
+	}
+}
+
 func TestJobs_Plan(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
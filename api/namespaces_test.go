@@ -0,0 +1,62 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestNamespaces_Register_Info_Delete(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	n := c.Namespaces()
+
+	// The default namespace always exists
+	result, qm, err := n.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result) != 1 || result[0].Name != "default" {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// Register a namespace
+	ns := &Namespace{
+		Name:        "team-a",
+		Description: "Team A",
+	}
+	wm, err := n.Register(ns, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Query the namespace
+	out, qm, err := n.Info(ns.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if out.Name != ns.Name || out.Description != ns.Description {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Query the namespace by prefix
+	result, qm, err = n.PrefixList("team", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if len(result) != 1 || result[0].Name != ns.Name {
+		t.Fatalf("bad: %#v", result)
+	}
+
+	// Delete the namespace
+	wm, err = n.Delete(ns.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	if _, _, err := n.Info(ns.Name, nil); err == nil {
+		t.Fatalf("expected error querying deleted namespace")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"time"
 )
 
 // Nodes is used to query node-related API endpoints
@@ -41,14 +42,42 @@ func (n *Nodes) Info(nodeID string, q *QueryOptions) (*Node, *QueryMeta, error)
 	return &resp, qm, nil
 }
 
-// ToggleDrain is used to toggle drain mode on/off for a given node.
-func (n *Nodes) ToggleDrain(nodeID string, drain bool, q *WriteOptions) (*WriteMeta, error) {
-	drainArg := strconv.FormatBool(drain)
-	wm, err := n.client.write("/v1/node/"+nodeID+"/drain?enable="+drainArg, nil, nil, q)
+// ToggleDrain is used to toggle drain mode on/off for a given node. The IDs
+// of the evaluations created to migrate the allocations of the node are
+// returned.
+func (n *Nodes) ToggleDrain(nodeID string, drain bool, q *WriteOptions) ([]string, *WriteMeta, error) {
+	return n.UpdateDrain(nodeID, drain, 0, q)
+}
+
+// UpdateDrain is used to toggle drain mode on/off for a given node with a
+// deadline after which the remaining allocations are migrated at once. A
+// zero deadline means there is no deadline and a negative one migrates all
+// the allocations immediately. The deadline is ignored when disabling drain.
+func (n *Nodes) UpdateDrain(nodeID string, drain bool, deadline time.Duration, q *WriteOptions) ([]string, *WriteMeta, error) {
+	var resp nodeDrainResponse
+	path := "/v1/node/" + nodeID + "/drain?enable=" + strconv.FormatBool(drain)
+	if drain && deadline != 0 {
+		path += "&deadline=" + deadline.String()
+	}
+	wm, err := n.client.write(path, nil, &resp, q)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return wm, nil
+	return resp.EvalIDs, wm, nil
+}
+
+// ToggleEligibility is used to toggle the scheduling eligibility of a node.
+// Ineligible nodes receive no new allocations but keep running the existing
+// ones. The IDs of the evaluations created when making the node eligible are
+// returned.
+func (n *Nodes) ToggleEligibility(nodeID string, eligible bool, q *WriteOptions) ([]string, *WriteMeta, error) {
+	var resp nodeEligibilityResponse
+	path := "/v1/node/" + nodeID + "/eligibility?enable=" + strconv.FormatBool(eligible)
+	wm, err := n.client.write(path, nil, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.EvalIDs, wm, nil
 }
 
 // Allocations is used to return the allocations associated with a node.
@@ -91,25 +120,65 @@ func (n *Nodes) Stats(nodeID string, q *QueryOptions) (*HostStats, error) {
 	return &resp, nil
 }
 
+// GC garbage collects the terminal allocations of the node.
+func (n *Nodes) GC(nodeID string, q *QueryOptions) error {
+	node, _, err := n.client.Nodes().Info(nodeID, q)
+	if err != nil {
+		return err
+	}
+	if node.HTTPAddr == "" {
+		return fmt.Errorf("http addr of the node %q is not advertised", nodeID)
+	}
+	client, err := NewClient(n.client.config.CopyConfig(node.HTTPAddr, node.TLSEnabled))
+	if err != nil {
+		return err
+	}
+	_, err = client.write("/v1/client/gc", nil, nil, nil)
+	return err
+}
+
+const (
+	// NodeSchedulingEligible and NodeSchedulingIneligible are the scheduling
+	// eligibilities of a node
+	NodeSchedulingEligible   = "eligible"
+	NodeSchedulingIneligible = "ineligible"
+)
+
 // Node is used to deserialize a node entry.
 type Node struct {
-	ID                string
-	Datacenter        string
-	Name              string
-	HTTPAddr          string
-	TLSEnabled        bool
-	Attributes        map[string]string
-	Resources         *Resources
-	Reserved          *Resources
-	Links             map[string]string
-	Meta              map[string]string
-	NodeClass         string
-	Drain             bool
-	Status            string
-	StatusDescription string
-	StatusUpdatedAt   int64
-	CreateIndex       uint64
-	ModifyIndex       uint64
+	ID                    string
+	Datacenter            string
+	Name                  string
+	HTTPAddr              string
+	TLSEnabled            bool
+	Attributes            map[string]string
+	Resources             *Resources
+	Reserved              *Resources
+	HostVolumes           map[string]*HostVolumeInfo
+	Links                 map[string]string
+	Meta                  map[string]string
+	NodeClass             string
+	Drain                 bool
+	DrainStrategy         *DrainStrategy
+	SchedulingEligibility string
+	Status                string
+	StatusDescription     string
+	StatusUpdatedAt       int64
+	CreateIndex           uint64
+	ModifyIndex           uint64
+}
+
+// HostVolumeInfo is a directory of the host that a node exposes to task groups
+type HostVolumeInfo struct {
+	Name     string
+	Path     string
+	ReadOnly bool
+}
+
+// DrainStrategy describes how the allocations of a draining node are migrated
+type DrainStrategy struct {
+	Deadline      time.Duration
+	ForceDeadline time.Time
 }
 
 // HostStats represents resource usage stats of the host running a Nomad client
@@ -148,15 +217,16 @@ type HostDiskStats struct {
 // NodeListStub is a subset of information returned during
 // node list operations.
 type NodeListStub struct {
-	ID                string
-	Datacenter        string
-	Name              string
-	NodeClass         string
-	Drain             bool
-	Status            string
-	StatusDescription string
-	CreateIndex       uint64
-	ModifyIndex       uint64
+	ID                    string
+	Datacenter            string
+	Name                  string
+	NodeClass             string
+	Drain                 bool
+	SchedulingEligibility string
+	Status                string
+	StatusDescription     string
+	CreateIndex           uint64
+	ModifyIndex           uint64
 }
 
 // NodeIndexSort reverse sorts nodes by CreateIndex
@@ -179,6 +249,16 @@ type nodeEvalResponse struct {
 	EvalID string
 }
 
+// nodeEligibilityResponse is used to decode an eligibility toggle.
+type nodeEligibilityResponse struct {
+	EvalIDs []string
+}
+
+// nodeDrainResponse is used to decode a drain toggle.
+type nodeDrainResponse struct {
+	EvalIDs []string
+}
+
 // AllocationSort reverse sorts allocs by CreateIndex.
 type AllocationSort []*Allocation
 
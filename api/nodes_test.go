@@ -130,6 +130,60 @@ func TestNodes_Info(t *testing.T) {
 	}
 }
 
+func TestNodes_ToggleEligibility(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.DevMode = true
+	})
+	defer s.Stop()
+	nodes := c.Nodes()
+
+	// Wait for node registration and get the ID
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		out, _, err := nodes.List(nil)
+		if err != nil {
+			return false, err
+		}
+		if n := len(out); n != 1 {
+			return false, fmt.Errorf("expected 1 node, got: %d", n)
+		}
+		nodeID = out[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	// Mark the node ineligible
+	_, wm, err := nodes.ToggleEligibility(nodeID, false, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	out, _, err := nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.SchedulingEligibility != NodeSchedulingIneligible {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Mark it eligible again
+	_, wm, err = nodes.ToggleEligibility(nodeID, true, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	out, _, err = nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.SchedulingEligibility != NodeSchedulingEligible {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestNodes_ToggleDrain(t *testing.T) {
 	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
 		c.DevMode = true
@@ -163,7 +217,7 @@ func TestNodes_ToggleDrain(t *testing.T) {
 	}
 
 	// Toggle it on
-	wm, err := nodes.ToggleDrain(nodeID, true, nil)
+	_, wm, err := nodes.ToggleDrain(nodeID, true, nil)
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -179,7 +233,7 @@ func TestNodes_ToggleDrain(t *testing.T) {
 	}
 
 	// Toggle off again
-	wm, err = nodes.ToggleDrain(nodeID, false, nil)
+	_, wm, err = nodes.ToggleDrain(nodeID, false, nil)
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -195,6 +249,60 @@ func TestNodes_ToggleDrain(t *testing.T) {
 	}
 }
 
+func TestNodes_UpdateDrain(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.DevMode = true
+	})
+	defer s.Stop()
+	nodes := c.Nodes()
+
+	// Wait for node registration and get the ID
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		out, _, err := nodes.List(nil)
+		if err != nil {
+			return false, err
+		}
+		if n := len(out); n != 1 {
+			return false, fmt.Errorf("expected 1 node, got: %d", n)
+		}
+		nodeID = out[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	// Drain with a deadline
+	_, wm, err := nodes.UpdateDrain(nodeID, true, time.Hour, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	out, _, err := nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !out.Drain || out.DrainStrategy == nil || out.DrainStrategy.Deadline != time.Hour {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.DrainStrategy.ForceDeadline.IsZero() {
+		t.Fatalf("missing force deadline: %#v", out.DrainStrategy)
+	}
+
+	// Disabling drain clears the strategy
+	if _, _, err := nodes.UpdateDrain(nodeID, false, 0, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, _, err = nodes.Info(nodeID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.Drain || out.DrainStrategy != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
 func TestNodes_Allocations(t *testing.T) {
 	c, s := makeClient(t, nil, nil)
 	defer s.Stop()
@@ -251,6 +359,41 @@ func TestNodes_ForceEvaluate(t *testing.T) {
 	}
 }
 
+func TestNodes_GC(t *testing.T) {
+	c, s := makeClient(t, nil, func(c *testutil.TestServerConfig) {
+		c.DevMode = true
+		c.AdvertiseAddrs.HTTP = "127.0.0.1"
+	})
+	defer s.Stop()
+	nodes := c.Nodes()
+
+	// GC on a non-existent node fails
+	err := nodes.GC("12345678-abcd-efab-cdef-123456789abc", nil)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error, got: %#v", err)
+	}
+
+	// Wait for node registration and get the ID
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		out, _, err := nodes.List(nil)
+		if err != nil {
+			return false, err
+		}
+		if n := len(out); n != 1 {
+			return false, fmt.Errorf("expected 1 node, got: %d", n)
+		}
+		nodeID = out[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	if err := nodes.GC(nodeID, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
 func TestNodes_Sort(t *testing.T) {
 	nodes := []*NodeListStub{
 		&NodeListStub{CreateIndex: 2},
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Operator is used to query the operator endpoints.
+type Operator struct {
+	client *Client
+}
+
+// Operator returns a handle on the operator endpoints.
+func (c *Client) Operator() *Operator {
+	return &Operator{client: c}
+}
+
+// BrokerStats is used to query the stats of the evaluation broker of the
+// leader.
+func (o *Operator) BrokerStats(q *QueryOptions) (*BrokerStats, *QueryMeta, error) {
+	var resp BrokerStats
+	qm, err := o.client.query("/v1/operator/broker", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Snapshot is used to capture a snapshot of the state of the servers. The
+// returned reader streams the snapshot archive and must be closed by the
+// caller.
+func (o *Operator) Snapshot(q *QueryOptions) (io.ReadCloser, error) {
+	return o.client.rawQuery("/v1/operator/snapshot", q)
+}
+
+// SnapshotRestore is used to replace the state of the servers with the state
+// of the snapshot archive read from in.
+func (o *Operator) SnapshotRestore(in io.Reader, q *WriteOptions) (*WriteMeta, error) {
+	r := o.client.newRequest("PUT", "/v1/operator/snapshot")
+	r.setWriteOptions(q)
+	r.body = in
+	rtt, resp, err := requireOK(o.client.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	parseWriteMeta(resp, wm)
+	return wm, nil
+}
+
+// AutopilotGetConfiguration is used to query the current Autopilot
+// configuration.
+func (o *Operator) AutopilotGetConfiguration(q *QueryOptions) (*AutopilotConfiguration, *QueryMeta, error) {
+	var resp AutopilotConfiguration
+	qm, err := o.client.query("/v1/operator/autopilot/configuration", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// AutopilotSetConfiguration is used to set the current Autopilot
+// configuration.
+func (o *Operator) AutopilotSetConfiguration(conf *AutopilotConfiguration, q *WriteOptions) (*WriteMeta, error) {
+	var updated bool
+	wm, err := o.client.write("/v1/operator/autopilot/configuration", conf, &updated, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// AutopilotCASConfiguration is used to perform a check-and-set update of the
+// Autopilot configuration. The ModifyIndex of the configuration must match
+// the index of the stored configuration for the update to be applied. The
+// returned bool reports whether the update was applied.
+func (o *Operator) AutopilotCASConfiguration(conf *AutopilotConfiguration, q *WriteOptions) (bool, *WriteMeta, error) {
+	var updated bool
+	endpoint := "/v1/operator/autopilot/configuration?cas=" + strconv.FormatUint(conf.ModifyIndex, 10)
+	wm, err := o.client.write(endpoint, conf, &updated, q)
+	if err != nil {
+		return false, nil, err
+	}
+	return updated, wm, nil
+}
+
+// SchedulerGetConfiguration is used to query the current scheduler
+// configuration.
+func (o *Operator) SchedulerGetConfiguration(q *QueryOptions) (*SchedulerConfiguration, *QueryMeta, error) {
+	var resp SchedulerConfiguration
+	qm, err := o.client.query("/v1/operator/scheduler/configuration", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// SchedulerSetConfiguration is used to set the current scheduler
+// configuration.
+func (o *Operator) SchedulerSetConfiguration(conf *SchedulerConfiguration, q *WriteOptions) (*WriteMeta, error) {
+	var updated bool
+	wm, err := o.client.write("/v1/operator/scheduler/configuration", conf, &updated, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// SchedulerCASConfiguration is used to perform a check-and-set update of the
+// scheduler configuration. The ModifyIndex of the configuration must match
+// the index of the stored configuration for the update to be applied. The
+// returned bool reports whether the update was applied.
+func (o *Operator) SchedulerCASConfiguration(conf *SchedulerConfiguration, q *WriteOptions) (bool, *WriteMeta, error) {
+	var updated bool
+	endpoint := "/v1/operator/scheduler/configuration?cas=" + strconv.FormatUint(conf.ModifyIndex, 10)
+	wm, err := o.client.write(endpoint, conf, &updated, q)
+	if err != nil {
+		return false, nil, err
+	}
+	return updated, wm, nil
+}
+
+// AutopilotServerHealth is used to query the health of the servers as
+// tracked by the leader. An unhealthy cluster is not an error.
+func (o *Operator) AutopilotServerHealth(q *QueryOptions) (*OperatorHealthReply, *QueryMeta, error) {
+	r := o.client.newRequest("GET", "/v1/operator/autopilot/health")
+	r.setQueryOptions(q)
+	rtt, resp, err := o.client.doRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	// The endpoint returns a 429 status code when the cluster is unhealthy
+	if resp.StatusCode != 200 && resp.StatusCode != 429 {
+		var buf bytes.Buffer
+		io.Copy(&buf, resp.Body)
+		return nil, nil, fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, buf.Bytes())
+	}
+
+	qm := &QueryMeta{RequestTime: rtt}
+	parseQueryMeta(resp, qm)
+
+	var out OperatorHealthReply
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
+
+// RaftGetConfiguration is used to query the current Raft peer set.
+func (o *Operator) RaftGetConfiguration(q *QueryOptions) ([]*RaftServer, *QueryMeta, error) {
+	var resp []*RaftServer
+	qm, err := o.client.query("/v1/operator/raft/configuration", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// RaftRemovePeerByAddress is used to remove a stale peer, one that is still
+// in the Raft peer set although its server is gone, by address in the form of
+// "IP:port".
+func (o *Operator) RaftRemovePeerByAddress(address string, q *WriteOptions) (*WriteMeta, error) {
+	wm, err := o.client.delete("/v1/operator/raft/peer?address="+url.QueryEscape(address), nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// BrokerStats is the stats of the evaluation broker.
+type BrokerStats struct {
+	TotalReady   int
+	TotalUnacked int
+	TotalBlocked int
+	TotalWaiting int
+	ByScheduler  map[string]*SchedulerStats
+}
+
+// SchedulerStats is the stats of the evaluation broker for a scheduler.
+type SchedulerStats struct {
+	Ready   int
+	Unacked int
+}
+
+// AutopilotConfiguration is used for querying/setting the Autopilot
+// configuration. Autopilot helps manage operator tasks related to Nomad
+// servers like removing failed servers from the Raft peer set.
+type AutopilotConfiguration struct {
+	// CleanupDeadServers controls whether to remove servers from the Raft
+	// peer set once they have been failed for ServerStabilizationTime.
+	CleanupDeadServers bool
+
+	// LastContactThreshold is the limit on the amount of time a server can go
+	// without leader contact before being considered unhealthy.
+	LastContactThreshold time.Duration
+
+	// MaxTrailingLogs is the amount of entries in the Raft Log that a server
+	// can be behind before being considered unhealthy.
+	MaxTrailingLogs uint64
+
+	// ServerStabilizationTime is the minimum amount of time a server must be
+	// healthy before it is added to the Raft peer set, and the minimum amount
+	// of time it must be failed before it is cleaned up.
+	ServerStabilizationTime time.Duration
+
+	// CreateIndex holds the index corresponding the creation of this
+	// configuration.
+	CreateIndex uint64
+
+	// ModifyIndex can be used to perform a check-and-set operation.
+	ModifyIndex uint64
+}
+
+const (
+	// SchedulerAlgorithmBinpack places allocations on the nodes that are
+	// the most utilized.
+	SchedulerAlgorithmBinpack = "binpack"
+
+	// SchedulerAlgorithmSpread places allocations on the nodes that are the
+	// least utilized.
+	SchedulerAlgorithmSpread = "spread"
+)
+
+// SchedulerConfiguration is used for querying/setting the scheduler
+// configuration of the cluster.
+type SchedulerConfiguration struct {
+	// SchedulerAlgorithm is the algorithm used to score the nodes an
+	// allocation fits on, either binpack or spread.
+	SchedulerAlgorithm string
+
+	// CreateIndex holds the index corresponding the creation of this
+	// configuration.
+	CreateIndex uint64
+
+	// ModifyIndex can be used to perform a check-and-set operation.
+	ModifyIndex uint64
+}
+
+// ServerHealth is the health (from the leader's point of view) of a server.
+type ServerHealth struct {
+	Name        string
+	Address     string
+	Version     string
+	SerfStatus  string
+	Leader      bool
+	Voter       bool
+	LastContact time.Duration
+	LastTerm    uint64
+	LastIndex   uint64
+	Healthy     bool
+	StableSince time.Time
+}
+
+// OperatorHealthReply is a representation of the overall health of the
+// cluster.
+type OperatorHealthReply struct {
+	// Healthy is true if all the servers in the cluster are healthy.
+	Healthy bool
+
+	// FailureTolerance is the number of healthy servers that could be lost
+	// without an outage occurring.
+	FailureTolerance int
+
+	// Servers holds the health of each server.
+	Servers []ServerHealth
+}
+
+// RaftServer has information about a server in the Raft configuration.
+type RaftServer struct {
+	// Node is the name of the server, as known by Serf, or "(unknown)" if it
+	// is not known.
+	Node string
+
+	// Address is the IP:port of the server, used for both Raft and RPC.
+	Address string
+
+	// Leader is true if this server is the current cluster leader.
+	Leader bool
+
+	// Voter is true if this server has a vote in the cluster.
+	Voter bool
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestOperator_BrokerStats(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	stats, qm, err := o.BrokerStats(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !qm.KnownLeader {
+		t.Fatalf("expected known leader, got none")
+	}
+	if stats == nil || stats.ByScheduler == nil {
+		t.Fatalf("missing stats")
+	}
+}
+
+func TestOperator_Snapshot_Restore(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	// Take a snapshot with only the default namespace
+	snap, err := o.Snapshot(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, snap); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	snap.Close()
+	if buf.Len() == 0 {
+		t.Fatalf("empty snapshot")
+	}
+
+	// Create a namespace after the snapshot
+	if _, err := c.Namespaces().Register(&Namespace{Name: "team-a"}, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Restoring the snapshot removes the namespace
+	if _, err := o.SnapshotRestore(&buf, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	namespaces, _, err := c.Namespaces().List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(namespaces) != 1 || namespaces[0].Name != "default" {
+		t.Fatalf("bad: %#v", namespaces)
+	}
+
+	// Restoring garbage fails
+	if _, err := o.SnapshotRestore(bytes.NewReader([]byte("nope")), nil); err == nil {
+		t.Fatalf("expected error restoring invalid snapshot")
+	}
+}
+
+func TestOperator_AutopilotGetSetConfiguration(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	config, _, err := o.AutopilotGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !config.CleanupDeadServers {
+		t.Fatalf("bad: %v", config)
+	}
+
+	// Change a config setting
+	newConf := &AutopilotConfiguration{CleanupDeadServers: false}
+	if _, err := o.AutopilotSetConfiguration(newConf, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	config, _, err = o.AutopilotGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.CleanupDeadServers {
+		t.Fatalf("bad: %v", config)
+	}
+}
+
+func TestOperator_AutopilotCASConfiguration(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	config, _, err := o.AutopilotGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !config.CleanupDeadServers {
+		t.Fatalf("bad: %v", config)
+	}
+
+	// Pass an invalid ModifyIndex
+	{
+		newConf := &AutopilotConfiguration{
+			CleanupDeadServers: false,
+			ModifyIndex:        config.ModifyIndex - 1,
+		}
+		resp, _, err := o.AutopilotCASConfiguration(newConf, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp {
+			t.Fatalf("bad: %v", resp)
+		}
+	}
+
+	// Pass a valid ModifyIndex
+	{
+		newConf := &AutopilotConfiguration{
+			CleanupDeadServers: false,
+			ModifyIndex:        config.ModifyIndex,
+		}
+		resp, _, err := o.AutopilotCASConfiguration(newConf, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !resp {
+			t.Fatalf("bad: %v", resp)
+		}
+	}
+}
+
+func TestOperator_SchedulerGetSetConfiguration(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	config, _, err := o.SchedulerGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.SchedulerAlgorithm != SchedulerAlgorithmBinpack {
+		t.Fatalf("bad: %v", config)
+	}
+
+	// Invalid algorithms are rejected
+	newConf := &SchedulerConfiguration{SchedulerAlgorithm: "foo"}
+	if _, err := o.SchedulerSetConfiguration(newConf, nil); err == nil || !strings.Contains(err.Error(), "invalid scheduler algorithm") {
+		t.Fatalf("expected error, got: %v", err)
+	}
+
+	// Pass an invalid ModifyIndex
+	newConf = &SchedulerConfiguration{
+		SchedulerAlgorithm: SchedulerAlgorithmSpread,
+		ModifyIndex:        config.ModifyIndex - 1,
+	}
+	resp, _, err := o.SchedulerCASConfiguration(newConf, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	// Pass a valid ModifyIndex
+	newConf.ModifyIndex = config.ModifyIndex
+	resp, _, err = o.SchedulerCASConfiguration(newConf, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !resp {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	config, _, err = o.SchedulerGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.SchedulerAlgorithm != SchedulerAlgorithmSpread {
+		t.Fatalf("bad: %v", config)
+	}
+}
+
+func TestOperator_AutopilotServerHealth(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	testutil.WaitForResult(func() (bool, error) {
+		out, _, err := o.AutopilotServerHealth(nil)
+		if err != nil {
+			return false, err
+		}
+		if !out.Healthy || len(out.Servers) != 1 {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		if h := out.Servers[0]; !h.Leader || !h.Voter || h.SerfStatus != "alive" {
+			return false, fmt.Errorf("bad: %#v", h)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestOperator_RaftGetConfiguration(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	servers, qm, err := o.RaftGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !qm.KnownLeader {
+		t.Fatalf("expected known leader, got none")
+	}
+	if len(servers) != 1 || !servers[0].Leader || !servers[0].Voter {
+		t.Fatalf("bad: %#v", servers)
+	}
+}
+
+func TestOperator_RaftRemovePeerByAddress(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	o := c.Operator()
+
+	// If we get this error, it proves we sent the address all the way
+	// through.
+	_, err := o.RaftRemovePeerByAddress("nope", nil)
+	if err == nil || !strings.Contains(err.Error(), `address "nope" was not found in the Raft configuration`) {
+		t.Fatalf("err: %v", err)
+	}
+}
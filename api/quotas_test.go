@@ -0,0 +1,79 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuotas_Register_Info_Usage_Delete(t *testing.T) {
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	q := c.Quotas()
+
+	// Listing when nothing exists returns empty
+	result, _, err := q.List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n := len(result); n != 0 {
+		t.Fatalf("expected 0 quotas, got: %d", n)
+	}
+
+	// Register a quota
+	spec := &QuotaSpec{
+		Name:        "team-a",
+		Description: "Team A",
+		Limit: &QuotaLimit{
+			CPU:      1000,
+			MemoryMB: 512,
+		},
+	}
+	wm, err := q.Register(spec, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+
+	// Query the quota
+	out, qm, err := q.Info(spec.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if !reflect.DeepEqual(out.Limit, spec.Limit) {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Attach the quota to a namespace and query the usage
+	ns := &Namespace{Name: "team-a", Quota: spec.Name}
+	if _, err := c.Namespaces().Register(ns, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	usage, qm, err := q.Usage(spec.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertQueryMeta(t, qm)
+	if !reflect.DeepEqual(usage.Namespaces, []string{ns.Name}) {
+		t.Fatalf("bad: %#v", usage)
+	}
+	if usage.Used == nil || usage.Used.Count != 0 {
+		t.Fatalf("bad: %#v", usage.Used)
+	}
+
+	// Attached quotas can not be deleted
+	if _, err := q.Delete(spec.Name, nil); err == nil {
+		t.Fatalf("expected error deleting attached quota")
+	}
+
+	// Detach and delete the quota
+	ns.Quota = ""
+	if _, err := c.Namespaces().Register(ns, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	wm, err = q.Delete(spec.Name, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	assertWriteMeta(t, wm)
+}
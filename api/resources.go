@@ -3,25 +3,41 @@ package api
 // Resources encapsulates the required resources of
 // a given task or task group.
 type Resources struct {
-	CPU      int
-	MemoryMB int
-	DiskMB   int
-	IOPS     int
-	Networks []*NetworkResource
+	CPU           int
+	Cores         int
+	ReservedCores []int
+	MemoryMB      int
+	DiskMB        int
+	IOPS          int
+	Networks      []*NetworkResource
+	Devices       []*DeviceResource
 }
 
 type Port struct {
 	Label string
 	Value int
+	To    int
 }
 
 // NetworkResource is used to describe required network
-// resources of a given task.
+// resources of a given task or task group.
 type NetworkResource struct {
+	Mode          string
+	AddressFamily string
 	Public        bool
 	CIDR          string
 	ReservedPorts []Port
 	DynamicPorts  []Port
 	IP            string
+	IPv6          string
 	MBits         int
 }
+
+// DeviceResource is used to describe the devices, such as GPUs, required by
+// a given task or available on a node.
+type DeviceResource struct {
+	Name       string
+	Count      int
+	IDs        []string
+	Attributes map[string]string
+}
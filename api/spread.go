@@ -0,0 +1,33 @@
+package api
+
+// Spread is used to serialize the balancing of allocations across the values
+// of a node attribute.
+type Spread struct {
+	Attribute    string
+	Weight       int
+	SpreadTarget []*SpreadTarget
+}
+
+// SpreadTarget is used to serialize the desired percentage of allocations for
+// a value of the spread attribute.
+type SpreadTarget struct {
+	Value   string
+	Percent uint32
+}
+
+// NewSpread generates a new spread across the values of the attribute.
+func NewSpread(attribute string, weight int, targets []*SpreadTarget) *Spread {
+	return &Spread{
+		Attribute:    attribute,
+		Weight:       weight,
+		SpreadTarget: targets,
+	}
+}
+
+// NewSpreadTarget generates a new target percentage for a value of a spread.
+func NewSpreadTarget(value string, percent uint32) *SpreadTarget {
+	return &SpreadTarget{
+		Value:   value,
+		Percent: percent,
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompose_Spreads(t *testing.T) {
+	s := NewSpread("${node.datacenter}", 50, []*SpreadTarget{
+		NewSpreadTarget("dc1", 70),
+		NewSpreadTarget("dc2", 30),
+	})
+	expect := &Spread{
+		Attribute: "${node.datacenter}",
+		Weight:    50,
+		SpreadTarget: []*SpreadTarget{
+			&SpreadTarget{
+				Value:   "dc1",
+				Percent: 70,
+			},
+			&SpreadTarget{
+				Value:   "dc2",
+				Percent: 30,
+			},
+		},
+	}
+	if !reflect.DeepEqual(s, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, s)
+	}
+}
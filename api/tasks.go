@@ -57,6 +57,15 @@ type RestartPolicy struct {
 	Mode     string
 }
 
+// ReschedulePolicy defines how the Nomad servers replace the failed
+// allocations of a taskgroup
+type ReschedulePolicy struct {
+	Attempts  int
+	Interval  time.Duration
+	Delay     time.Duration
+	Unlimited bool
+}
+
 // The ServiceCheck data model represents the consul health check that
 // Nomad registers for a Task
 type ServiceCheck struct {
@@ -70,7 +79,18 @@ type ServiceCheck struct {
 	PortLabel     string `mapstructure:"port"`
 	Interval      time.Duration
 	Timeout       time.Duration
-	InitialStatus string `mapstructure:"initial_status"`
+	InitialStatus string        `mapstructure:"initial_status"`
+	GRPCService   string        `mapstructure:"grpc_service"`
+	GRPCUseTLS    bool          `mapstructure:"grpc_use_tls"`
+	CheckRestart  *CheckRestart `mapstructure:"check_restart"`
+}
+
+// CheckRestart describes when a task is restarted if its check stays
+// unhealthy.
+type CheckRestart struct {
+	Limit          int
+	Grace          time.Duration
+	IgnoreWarnings bool `mapstructure:"ignore_warnings"`
 }
 
 // The Service model represents a Consul service definition
@@ -89,15 +109,46 @@ type EphemeralDisk struct {
 	SizeMB  int `mapstructure:"size"`
 }
 
+// VolumeRequest is a request by a task group for a volume
+type VolumeRequest struct {
+	Name     string
+	Type     string
+	Source   string
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+// VolumeMount mounts a volume of the task group into a task
+type VolumeMount struct {
+	Volume      string
+	Destination string
+	ReadOnly    bool `mapstructure:"read_only"`
+}
+
+// MigrateStrategy controls how the allocations of a task group are migrated
+// off draining nodes.
+type MigrateStrategy struct {
+	MaxParallel     int
+	HealthCheck     string
+	MinHealthyTime  time.Duration
+	HealthyDeadline time.Duration
+}
+
 // TaskGroup is the unit of scheduling.
 type TaskGroup struct {
-	Name          string
-	Count         int
-	Constraints   []*Constraint
-	Tasks         []*Task
-	RestartPolicy *RestartPolicy
-	EphemeralDisk *EphemeralDisk
-	Meta          map[string]string
+	Name             string
+	Count            int
+	Constraints      []*Constraint
+	Affinities       []*Affinity
+	Spreads          []*Spread
+	Tasks            []*Task
+	RestartPolicy    *RestartPolicy
+	ReschedulePolicy *ReschedulePolicy
+	EphemeralDisk    *EphemeralDisk
+	Volumes          map[string]*VolumeRequest
+	Networks         []*NetworkResource
+	Update           *UpdateStrategy
+	Migrate          *MigrateStrategy
+	Meta             map[string]string
 }
 
 // NewTaskGroup creates a new TaskGroup.
@@ -114,6 +165,18 @@ func (g *TaskGroup) Constrain(c *Constraint) *TaskGroup {
 	return g
 }
 
+// AddAffinity is used to add a placement preference to a task group.
+func (g *TaskGroup) AddAffinity(a *Affinity) *TaskGroup {
+	g.Affinities = append(g.Affinities, a)
+	return g
+}
+
+// AddSpread is used to add a spread to a task group.
+func (g *TaskGroup) AddSpread(s *Spread) *TaskGroup {
+	g.Spreads = append(g.Spreads, s)
+	return g
+}
+
 // AddMeta is used to add a meta k/v pair to a task group
 func (g *TaskGroup) SetMeta(key, val string) *TaskGroup {
 	if g.Meta == nil {
@@ -135,6 +198,12 @@ func (g *TaskGroup) RequireDisk(disk *EphemeralDisk) *TaskGroup {
 	return g
 }
 
+// RequireNetwork adds a network shared by the tasks to the task group
+func (g *TaskGroup) RequireNetwork(network *NetworkResource) *TaskGroup {
+	g.Networks = append(g.Networks, network)
+	return g
+}
+
 // LogConfig provides configuration for log rotation
 type LogConfig struct {
 	MaxFiles      int
@@ -143,20 +212,42 @@ type LogConfig struct {
 
 // Task is a single process in a task group.
 type Task struct {
-	Name        string
-	Driver      string
-	User        string
-	Config      map[string]interface{}
-	Constraints []*Constraint
-	Env         map[string]string
-	Services    []Service
-	Resources   *Resources
-	Meta        map[string]string
-	KillTimeout time.Duration
-	LogConfig   *LogConfig
-	Artifacts   []*TaskArtifact
-	Vault       *Vault
-	Templates   []*Template
+	Name            string
+	Driver          string
+	User            string
+	Config          map[string]interface{}
+	Constraints     []*Constraint
+	Affinities      []*Affinity
+	Env             map[string]string
+	Services        []Service
+	Resources       *Resources
+	Meta            map[string]string
+	KillTimeout     time.Duration
+	LogConfig       *LogConfig
+	Artifacts       []*TaskArtifact
+	Vault           *Vault
+	Templates       []*Template
+	DispatchPayload *DispatchPayloadConfig
+	VolumeMounts    []*VolumeMount
+	Lifecycle       *TaskLifecycle
+}
+
+// DispatchPayloadConfig configures how a task gets its input from a job
+// dispatch
+type DispatchPayloadConfig struct {
+	File string
+}
+
+const (
+	TaskLifecycleHookPrestart  = "prestart"
+	TaskLifecycleHookPoststart = "poststart"
+	TaskLifecycleHookPoststop  = "poststop"
+)
+
+// TaskLifecycle orders a task relative to the main tasks of its task group
+type TaskLifecycle struct {
+	Hook    string
+	Sidecar bool
 }
 
 // TaskArtifact is used to download artifacts before running a task.
@@ -221,6 +312,12 @@ func (t *Task) Constrain(c *Constraint) *Task {
 	return t
 }
 
+// AddAffinity adds a new placement preference to a single task.
+func (t *Task) AddAffinity(a *Affinity) *Task {
+	t.Affinities = append(t.Affinities, a)
+	return t
+}
+
 // SetLogConfig sets a log config to a task
 func (t *Task) SetLogConfig(l *LogConfig) *Task {
 	t.LogConfig = l
@@ -252,6 +349,8 @@ const (
 	TaskSiblingFailed          = "Sibling task failed"
 	TaskSignaling              = "Signaling"
 	TaskRestartSignal          = "Restart Signaled"
+	TaskDriverMessage          = "Driver"
+	TaskMainDead               = "Main Tasks Dead"
 )
 
 // TaskEvent is an event that effects the state of a task and contains meta-data
@@ -266,6 +365,7 @@ type TaskEvent struct {
 	ExitCode         int
 	Signal           int
 	Message          string
+	OOMKilled        bool
 	KillReason       string
 	KillTimeout      time.Duration
 	KillError        string
@@ -278,4 +378,5 @@ type TaskEvent struct {
 	VaultError       string
 	TaskSignalReason string
 	TaskSignal       string
+	DriverMessage    string
 }
@@ -102,6 +102,27 @@ func TestTaskGroup_AddTask(t *testing.T) {
 	}
 }
 
+func TestTaskGroup_RequireNetwork(t *testing.T) {
+	grp := NewTaskGroup("grp1", 1)
+
+	// Add a network to the task group
+	network := &NetworkResource{
+		Mode:         "bridge",
+		DynamicPorts: []Port{{Label: "http", To: 8080}},
+	}
+	out := grp.RequireNetwork(network)
+
+	// Check that we returned the group
+	if out != grp {
+		t.Fatalf("expect: %#v, got: %#v", grp, out)
+	}
+
+	expect := []*NetworkResource{network}
+	if !reflect.DeepEqual(grp.Networks, expect) {
+		t.Fatalf("expect: %#v, got: %#v", expect, grp.Networks)
+	}
+}
+
 func TestTask_NewTask(t *testing.T) {
 	task := NewTask("task1", "exec")
 	expect := &Task{
@@ -170,7 +191,7 @@ func TestTask_Require(t *testing.T) {
 			&NetworkResource{
 				CIDR:          "0.0.0.0/0",
 				MBits:         100,
-				ReservedPorts: []Port{{"", 80}, {"", 443}},
+				ReservedPorts: []Port{{"", 80, 0}, {"", 443, 0}},
 			},
 		},
 	}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// ResolveToken resolves an ACL Token Secret ID into an ACL object for the
+// endpoints served by the client. The token and its policies are looked up
+// on the servers, so it must only be called if ACLs are enabled. An empty
+// secret resolves to the anonymous token.
+func (c *Client) ResolveToken(secretID string) (*acl.ACL, error) {
+	token := structs.AnonymousACLToken
+	if secretID != "" {
+		req := structs.ResolveACLTokenRequest{
+			SecretID: secretID,
+			QueryOptions: structs.QueryOptions{
+				Region:     c.Region(),
+				AllowStale: true,
+			},
+		}
+		var resp structs.ResolveACLTokenResponse
+		if err := c.RPC("ACL.ResolveToken", &req, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Token == nil {
+			return nil, structs.ErrTokenNotFound
+		}
+		token = resp.Token
+	}
+
+	if token.Type == structs.ACLManagementToken {
+		return acl.ManagementACL, nil
+	}
+
+	// Fetch the policies of the token with the token itself. Policies that
+	// have been deleted are skipped.
+	policies := make([]*acl.Policy, 0, len(token.Policies))
+	for _, name := range token.Policies {
+		req := structs.ACLPolicySpecificRequest{
+			Name: name,
+			QueryOptions: structs.QueryOptions{
+				Region:     c.Region(),
+				AllowStale: true,
+				AuthToken:  secretID,
+			},
+		}
+		var resp structs.SingleACLPolicyResponse
+		if err := c.RPC("ACL.GetPolicy", &req, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Policy == nil {
+			continue
+		}
+
+		parsed, err := acl.Parse(resp.Policy.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy %q: %v", name, err)
+		}
+		policies = append(policies, parsed)
+	}
+
+	return acl.NewACL(false, policies)
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestClient_ResolveToken(t *testing.T) {
+	s1, _ := testServer(t, func(c *nomad.Config) {
+		c.ACLEnabled = true
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.RPCHandler = s1
+	})
+	defer c1.Shutdown()
+
+	policy := mock.ACLPolicy()
+	policy.Rules = `agent { policy = "write" }`
+	anonymous := mock.ACLPolicy()
+	anonymous.Name = "anonymous"
+	anonymous.Rules = `agent { policy = "read" }`
+	if err := s1.State().UpsertACLPolicies(1000, []*structs.ACLPolicy{policy, anonymous}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	token := mock.ACLToken()
+	token.Policies = []string{policy.Name, "deleted"}
+	mgmt := mock.ACLManagementToken()
+	if err := s1.State().UpsertACLTokens(1010, []*structs.ACLToken{token, mgmt}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The policies of the token are resolved
+	out, err := c1.ResolveToken(token.SecretID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.IsManagement() || !out.AllowAgentWrite() || out.AllowNodeRead() {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Management tokens are allowed everything
+	out, err = c1.ResolveToken(mgmt.SecretID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != acl.ManagementACL {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Anonymous requests get the anonymous policy
+	out, err = c1.ResolveToken("")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.AllowAgentRead() || out.AllowAgentWrite() {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Unknown tokens are rejected
+	_, err = c1.ResolveToken(structs.GenerateUUID())
+	if err == nil || err.Error() != structs.ErrTokenNotFound.Error() {
+		t.Fatalf("expected token not found: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// allocHealthInterval is the interval at which the tasks and checks of an
+	// allocation are inspected until its health is determined.
+	allocHealthInterval = 5 * time.Second
+)
+
+// ConsulChecks looks up the Consul checks of the services registered for the
+// tasks of an allocation.
+type ConsulChecks interface {
+	// DomainChecks returns the checks of the services of the domain, keyed
+	// by check ID.
+	DomainChecks(domain consul.ServiceDomain) (map[string]*consulapi.AgentCheck, error)
+}
+
+// SetConsulChecks sets the lookup of the Consul checks used to determine the
+// health of the allocation. It must be set before the allocation is run.
+func (r *AllocRunner) SetConsulChecks(checks ConsulChecks) {
+	r.consulChecks = checks
+}
+
+// watchHealth determines the health of an allocation placed by a deployment
+// whose update strategy derives the health from the Consul checks. The
+// allocation is healthy once its tasks are running and all the checks of
+// their services are passing. It is unhealthy if a task fails or the healthy
+// deadline is reached first.
+func (r *AllocRunner) watchHealth(tg *structs.TaskGroup) {
+	r.allocLock.Lock()
+	alloc := r.alloc
+	r.allocLock.Unlock()
+
+	strategy := alloc.Job.LookupUpdateStrategy(tg.Name)
+	if alloc.DeploymentID == "" || !strategy.HealthChecks() || r.consulChecks == nil {
+		return
+	}
+
+	// The health was determined before the client restarted
+	if alloc.DeploymentStatus != nil && alloc.DeploymentStatus.Healthy != nil {
+		return
+	}
+
+	deadline := time.NewTimer(strategy.Deadline())
+	defer deadline.Stop()
+	ticker := time.NewTicker(allocHealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.destroyCh:
+			return
+		case <-deadline.C:
+			r.logger.Printf("[DEBUG] client: alloc %q did not become healthy before its deadline", alloc.ID)
+			r.setHealth(false)
+			return
+		case <-ticker.C:
+		}
+
+		healthy, unhealthy := r.checkHealth(tg)
+		if healthy || unhealthy {
+			r.setHealth(healthy)
+			return
+		}
+	}
+}
+
+// checkHealth returns whether the allocation is healthy or unhealthy. Neither
+// is set while its tasks are starting or its checks are not yet passing.
+func (r *AllocRunner) checkHealth(tg *structs.TaskGroup) (healthy, unhealthy bool) {
+	expected := 0
+	r.taskStatusLock.RLock()
+	for _, task := range tg.Tasks {
+		state, ok := r.taskStates[task.Name]
+		if ok && state.Failed {
+			r.taskStatusLock.RUnlock()
+			return false, true
+		}
+
+		// Only the main tasks and sidecars run for the lifetime of the
+		// allocation
+		if !task.IsMainTask() && !task.IsSidecar() {
+			continue
+		}
+		if !ok || state.State != structs.TaskStateRunning {
+			r.taskStatusLock.RUnlock()
+			return false, false
+		}
+		for _, service := range task.Services {
+			expected += len(service.Checks)
+		}
+	}
+	r.taskStatusLock.RUnlock()
+
+	passing := 0
+	for _, task := range tg.Tasks {
+		if !task.IsMainTask() && !task.IsSidecar() {
+			continue
+		}
+
+		checks, err := r.consulChecks.DomainChecks(consul.NewExecutorDomain(r.alloc.ID, task.Name))
+		if err != nil {
+			r.logger.Printf("[WARN] client: failed to look up checks of task %q in alloc %q: %v", task.Name, r.alloc.ID, err)
+			return false, false
+		}
+		for _, check := range checks {
+			if check.Status == consulapi.HealthPassing {
+				passing++
+			}
+		}
+	}
+	return passing >= expected, false
+}
+
+// setHealth records the health of the allocation and syncs it to the servers.
+func (r *AllocRunner) setHealth(healthy bool) {
+	r.allocLock.Lock()
+	r.deploymentStatus = &structs.AllocDeploymentStatus{
+		Healthy:   &healthy,
+		Timestamp: time.Now(),
+	}
+	r.allocLock.Unlock()
+	r.markDirty()
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"testing"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// mockConsulChecks returns the checks of each domain
+type mockConsulChecks map[consul.ServiceDomain]map[string]*consulapi.AgentCheck
+
+func (m mockConsulChecks) DomainChecks(domain consul.ServiceDomain) (map[string]*consulapi.AgentCheck, error) {
+	return m[domain], nil
+}
+
+func TestAllocRunner_CheckHealth(t *testing.T) {
+	_, ar := testAllocRunner(true)
+	tg := ar.alloc.Job.LookupTaskGroup(ar.alloc.TaskGroup)
+	domain := consul.NewExecutorDomain(ar.alloc.ID, "web")
+	checks := mockConsulChecks{}
+	ar.SetConsulChecks(checks)
+
+	// The health is unknown while the task is pending
+	ar.taskStates["web"] = &structs.TaskState{State: structs.TaskStatePending}
+	if healthy, unhealthy := ar.checkHealth(tg); healthy || unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+
+	// The running task waits for its checks to pass
+	ar.taskStates["web"].State = structs.TaskStateRunning
+	if healthy, unhealthy := ar.checkHealth(tg); healthy || unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+	check := &consulapi.AgentCheck{CheckID: "check1", Status: consulapi.HealthCritical}
+	checks[domain] = map[string]*consulapi.AgentCheck{"check1": check}
+	if healthy, unhealthy := ar.checkHealth(tg); healthy || unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+	check.Status = consulapi.HealthPassing
+	if healthy, unhealthy := ar.checkHealth(tg); !healthy || unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+
+	// A failed task is unhealthy
+	ar.taskStates["web"].Failed = true
+	if healthy, unhealthy := ar.checkHealth(tg); healthy || !unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+}
+
+func TestAllocRunner_SetHealth(t *testing.T) {
+	_, ar := testAllocRunner(true)
+	ar.setHealth(true)
+
+	alloc := ar.Alloc()
+	if !alloc.DeploymentStatus.IsHealthy() || alloc.DeploymentStatus.Timestamp.IsZero() {
+		t.Fatalf("bad: %#v", alloc.DeploymentStatus)
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/client/cni"
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// bridgeNetwork returns the bridge network shared by the tasks of the
+// allocation or nil if the tasks don't share one.
+func bridgeNetwork(alloc *structs.Allocation) *structs.NetworkResource {
+	if alloc.SharedResources == nil {
+		return nil
+	}
+	for _, n := range alloc.SharedResources.Networks {
+		if n.Mode == structs.NetworkModeBridge {
+			return n
+		}
+	}
+	return nil
+}
+
+// networkManager returns the driver creating the network namespace of the
+// allocation. All the tasks of the group must be run by drivers that can join
+// the network namespace.
+func (r *AllocRunner) networkManager(tg *structs.TaskGroup) (driver.DriverNetworkManager, error) {
+	var manager driver.DriverNetworkManager
+	for _, task := range tg.Tasks {
+		driverCtx := driver.NewDriverContext(task.Name, r.config, r.config.Node, r.logger, nil, nil)
+		d, err := driver.NewDriver(task.Driver, driverCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		nm, ok := d.(driver.DriverNetworkManager)
+		if !ok {
+			return nil, fmt.Errorf("task %q uses driver %q which doesn't support %q networking",
+				task.Name, task.Driver, structs.NetworkModeBridge)
+		}
+		if manager == nil {
+			manager = nm
+		}
+	}
+	return manager, nil
+}
+
+// cniInvoker returns the invoker of the CNI plugins configured on the client.
+func (r *AllocRunner) cniInvoker() *cni.Invoker {
+	return cni.NewInvoker(&cni.Config{
+		PluginDir:  r.config.CNIPath,
+		BridgeName: r.config.BridgeNetworkName,
+		Subnet:     r.config.BridgeNetworkSubnet,
+	}, r.logger)
+}
+
+// setupNetwork creates the network namespace shared by the tasks of the
+// allocation, attaches it to the bridge and maps the ports of the task group
+// into it. The context lock must be held.
+func (r *AllocRunner) setupNetwork(tg *structs.TaskGroup) error {
+	network := bridgeNetwork(r.alloc)
+	if network == nil || r.ctx.Network != nil {
+		return nil
+	}
+
+	manager, err := r.networkManager(tg)
+	if err != nil {
+		return err
+	}
+
+	spec, err := manager.CreateNetwork(r.alloc.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create network namespace: %v", err)
+	}
+
+	// Record the network namespace before configuring it so that it is torn
+	// down if the configuration fails
+	r.ctx.Network = spec
+	if err := r.cniInvoker().Setup(r.alloc.ID, spec.Path, portMappings(network)); err != nil {
+		return fmt.Errorf("failed to configure network namespace: %v", err)
+	}
+	return nil
+}
+
+// destroyNetwork tears down the network namespace of the allocation if it has
+// one.
+func (r *AllocRunner) destroyNetwork() error {
+	r.ctxLock.Lock()
+	defer r.ctxLock.Unlock()
+
+	if r.ctx == nil || r.ctx.Network == nil {
+		return nil
+	}
+	spec := r.ctx.Network
+
+	if network := bridgeNetwork(r.alloc); network != nil {
+		if err := r.cniInvoker().Teardown(r.alloc.ID, spec.Path, portMappings(network)); err != nil {
+			r.logger.Printf("[WARN] client: %v", err)
+		}
+	}
+
+	tg := r.alloc.Job.LookupTaskGroup(r.alloc.TaskGroup)
+	if tg == nil {
+		return fmt.Errorf("missing task group %q", r.alloc.TaskGroup)
+	}
+	manager, err := r.networkManager(tg)
+	if err != nil {
+		return err
+	}
+	if err := manager.DestroyNetwork(r.alloc.ID, spec); err != nil {
+		return fmt.Errorf("failed to destroy network namespace: %v", err)
+	}
+
+	r.ctx.Network = nil
+	return nil
+}
+
+// portMappings returns the mappings of the host ports of the network to the
+// ports of the network namespace, for both TCP and UDP.
+func portMappings(network *structs.NetworkResource) []cni.PortMapping {
+	var mappings []cni.PortMapping
+	mapped := network.PortMap()
+	for label, value := range network.MapLabelToValues(nil) {
+		for _, protocol := range []string{"tcp", "udp"} {
+			mappings = append(mappings, cni.PortMapping{
+				HostPort:      value,
+				ContainerPort: mapped[label],
+				Protocol:      protocol,
+				HostIP:        network.IP,
+			})
+		}
+	}
+	return mappings
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -46,6 +47,12 @@ type AllocRunner struct {
 	allocClientDescription string
 	allocLock              sync.Mutex
 
+	// deploymentStatus is the health of the allocation determined from its
+	// checks and consulChecks looks them up. The status is guarded by the
+	// allocLock.
+	deploymentStatus *structs.AllocDeploymentStatus
+	consulChecks     ConsulChecks
+
 	dirtyCh chan struct{}
 
 	ctx        *driver.ExecContext
@@ -57,6 +64,10 @@ type AllocRunner struct {
 
 	taskStatusLock sync.RWMutex
 
+	// taskCoordinator gates the start of the tasks on their lifecycle. It is
+	// guarded by the taskStatusLock.
+	taskCoordinator *taskHookCoordinator
+
 	updateCh chan *structs.Allocation
 
 	vaultClient vaultclient.VaultClient
@@ -136,6 +147,15 @@ func (r *AllocRunner) RestoreState() error {
 
 	r.taskStates = snap.Alloc.TaskStates
 
+	// Restore the lifecycle of the tasks from their states
+	tg := r.alloc.Job.LookupTaskGroup(r.alloc.TaskGroup)
+	if tg != nil {
+		r.taskStatusLock.Lock()
+		r.taskCoordinator = newTaskHookCoordinator(tg.Tasks)
+		r.taskCoordinator.taskStateUpdated(r.taskStates)
+		r.taskStatusLock.Unlock()
+	}
+
 	// Restore the task runners
 	var mErr multierror.Error
 	for name, state := range r.taskStates {
@@ -143,8 +163,16 @@ func (r *AllocRunner) RestoreState() error {
 		r.restored[name] = struct{}{}
 
 		task := &structs.Task{Name: name}
+		if tg != nil {
+			if t := tg.LookupTask(name); t != nil {
+				task = t.Copy()
+			}
+		}
 		tr := NewTaskRunner(r.logger, r.config, r.setTaskState, r.ctx, r.Alloc(),
 			task, r.vaultClient)
+		if r.taskCoordinator != nil {
+			tr.setStartCondition(r.taskCoordinator.startConditionForTask(task))
+		}
 		r.tasks[name] = tr
 
 		// Skip tasks in terminal states.
@@ -233,7 +261,14 @@ func (r *AllocRunner) DestroyState() error {
 
 // DestroyContext is used to destroy the context
 func (r *AllocRunner) DestroyContext() error {
-	return r.ctx.AllocDir.Destroy()
+	var mErr multierror.Error
+	if err := r.destroyNetwork(); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	}
+	if err := r.ctx.AllocDir.Destroy(); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	}
+	return mErr.ErrorOrNil()
 }
 
 // copyTaskStates returns a copy of the passed task states.
@@ -249,6 +284,9 @@ func copyTaskStates(states map[string]*structs.TaskState) map[string]*structs.Ta
 func (r *AllocRunner) Alloc() *structs.Allocation {
 	r.allocLock.Lock()
 	alloc := r.alloc.Copy()
+	if r.deploymentStatus != nil {
+		alloc.DeploymentStatus = r.deploymentStatus.Copy()
+	}
 
 	// The status has explicitly been set.
 	if r.allocClientStatus != "" || r.allocClientDescription != "" {
@@ -325,14 +363,12 @@ func (r *AllocRunner) setStatus(status, desc string) {
 	r.allocClientStatus = status
 	r.allocClientDescription = desc
 	r.allocLock.Unlock()
-	select {
-	case r.dirtyCh <- struct{}{}:
-	default:
-	}
+	r.markDirty()
 }
 
 // setTaskState is used to set the status of a task. If state is empty then the
-// event is appended but not synced with the server. The event may be omitted
+// event is appended without changing the state of the task. The event may be
+// omitted.
 func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEvent) {
 	r.taskStatusLock.Lock()
 	defer r.taskStatusLock.Unlock()
@@ -350,17 +386,23 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 		r.appendTaskEvent(taskState, event)
 	}
 
+	// Events without a state change, such as driver messages, still need to
+	// be synced to the servers.
 	if state == "" {
+		if event != nil {
+			r.markDirty()
+		}
 		return
 	}
 
 	taskState.State = state
 	if state == structs.TaskStateDead {
-		// If the task failed, we should kill all the other tasks in the task group.
+		// If the task failed, we should kill all the other tasks in the task
+		// group. Poststop tasks are spared so they can clean up.
 		if taskState.Failed {
 			var destroyingTasks []string
 			for task, tr := range r.tasks {
-				if task != taskName {
+				if task != taskName && !tr.task.IsPoststop() {
 					destroyingTasks = append(destroyingTasks, task)
 					tr.Destroy(structs.NewTaskEvent(structs.TaskSiblingFailed).SetFailedSibling(taskName))
 				}
@@ -371,6 +413,24 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 		}
 	}
 
+	// Start the tasks whose lifecycle allows them to and kill the sidecars
+	// once the main tasks are dead
+	if r.taskCoordinator != nil {
+		r.taskCoordinator.taskStateUpdated(r.taskStates)
+		if state == structs.TaskStateDead && r.taskCoordinator.mainTasksDead() {
+			for _, sidecar := range r.taskCoordinator.sidecars {
+				if tr, ok := r.tasks[sidecar]; ok {
+					tr.Destroy(structs.NewTaskEvent(structs.TaskMainDead))
+				}
+			}
+		}
+	}
+
+	r.markDirty()
+}
+
+// markDirty signals that the alloc should be synced to the servers
+func (r *AllocRunner) markDirty() {
 	select {
 	case r.dirtyCh <- struct{}{}:
 	default:
@@ -419,6 +479,7 @@ func (r *AllocRunner) Run() {
 			return
 		}
 		r.ctx = driver.NewExecContext(allocDir, r.alloc.ID)
+		r.ctx.Volumes = tg.Volumes
 		if r.otherAllocDir != nil {
 			if err := allocDir.Move(r.otherAllocDir, tg.Tasks); err != nil {
 				r.logger.Printf("[ERROR] client: failed to move alloc dir into alloc %q: %v", r.alloc.ID, err)
@@ -428,6 +489,19 @@ func (r *AllocRunner) Run() {
 			}
 		}
 	}
+
+	// Create the network namespace shared by the tasks
+	if !alloc.TerminalStatus() {
+		if err := r.setupNetwork(tg); err != nil {
+			r.logger.Printf("[ERR] client: failed to set up network of alloc %q: %v", r.alloc.ID, err)
+			r.setStatus(structs.AllocClientStatusFailed, fmt.Sprintf("failed to set up network: %v", err))
+			r.ctxLock.Unlock()
+			if err := r.destroyNetwork(); err != nil {
+				r.logger.Printf("[ERR] client: failed to destroy network of alloc %q: %v", r.alloc.ID, err)
+			}
+			return
+		}
+	}
 	r.ctxLock.Unlock()
 
 	// Check if the allocation is in a terminal status. In this case, we don't
@@ -440,6 +514,14 @@ func (r *AllocRunner) Run() {
 		return
 	}
 
+	// Create the coordinator ordering the tasks by their lifecycle unless it
+	// was restored
+	r.taskStatusLock.Lock()
+	if r.taskCoordinator == nil {
+		r.taskCoordinator = newTaskHookCoordinator(tg.Tasks)
+	}
+	r.taskStatusLock.Unlock()
+
 	// Start the task runners
 	r.logger.Printf("[DEBUG] client: starting task runners for alloc '%s'", r.alloc.ID)
 	r.taskLock.Lock()
@@ -449,6 +531,7 @@ func (r *AllocRunner) Run() {
 		}
 
 		tr := NewTaskRunner(r.logger, r.config, r.setTaskState, r.ctx, r.Alloc(), task.Copy(), r.vaultClient)
+		tr.setStartCondition(r.taskCoordinator.startConditionForTask(task))
 		r.tasks[task.Name] = tr
 		tr.MarkReceived()
 
@@ -456,6 +539,11 @@ func (r *AllocRunner) Run() {
 	}
 	r.taskLock.Unlock()
 
+	// Determine the health of the allocation for its deployment and restart
+	// the tasks whose checks stay unhealthy
+	go r.watchHealth(tg)
+	go r.watchChecks(tg)
+
 	// taskDestroyEvent contains an event that caused the destroyment of a task
 	// in the allocation.
 	var taskDestroyEvent *structs.TaskEvent
@@ -504,14 +592,27 @@ func (r *AllocRunner) SetPreviousAllocDir(allocDir *allocdir.AllocDir) {
 // destroyTaskRunners destroys the task runners, waits for them to terminate and
 // then saves state.
 func (r *AllocRunner) destroyTaskRunners(destroyEvent *structs.TaskEvent) {
-	// Destroy each sub-task
+	// Destroy each sub-task except the poststop tasks which are started once
+	// the main tasks are dead
+	var poststop []*TaskRunner
 	runners := r.getTaskRunners()
 	for _, tr := range runners {
+		if tr.task.IsPoststop() {
+			poststop = append(poststop, tr)
+			continue
+		}
 		tr.Destroy(destroyEvent)
 	}
 
 	// Wait for termination of the task runners
 	for _, tr := range runners {
+		if !tr.task.IsPoststop() {
+			<-tr.WaitCh()
+		}
+	}
+
+	// Wait for the poststop tasks to complete
+	for _, tr := range poststop {
 		<-tr.WaitCh()
 	}
 
@@ -563,6 +664,64 @@ func (r *AllocRunner) getTaskRunners() []*TaskRunner {
 	return runners
 }
 
+// lookupTaskRunners returns the task runner of the given task or all the task
+// runners of the allocation if the task is empty.
+func (r *AllocRunner) lookupTaskRunners(task string) ([]*TaskRunner, error) {
+	if task == "" {
+		return r.getTaskRunners(), nil
+	}
+
+	r.taskLock.RLock()
+	defer r.taskLock.RUnlock()
+	tr, ok := r.tasks[task]
+	if !ok {
+		return nil, fmt.Errorf("task %q not found in allocation %q", task, r.alloc.ID)
+	}
+	return []*TaskRunner{tr}, nil
+}
+
+// RestartTasks restarts the running tasks of the allocation or only the given
+// task if it is set.
+func (r *AllocRunner) RestartTasks(task string) error {
+	runners, err := r.lookupTaskRunners(task)
+	if err != nil {
+		return err
+	}
+	for _, tr := range runners {
+		tr.Restart("user", "restart requested")
+	}
+	return nil
+}
+
+// SignalTasks sends the signal to the running tasks of the allocation or only
+// to the given task if it is set.
+func (r *AllocRunner) SignalTasks(task string, s os.Signal) error {
+	runners, err := r.lookupTaskRunners(task)
+	if err != nil {
+		return err
+	}
+
+	var mErr multierror.Error
+	for _, tr := range runners {
+		if err := tr.Signal("user", "signal requested", s); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("failed to signal task %q: %v", tr.task.Name, err))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ExecTask runs a command in the context of the given task of the allocation
+// and returns its exit code.
+func (r *AllocRunner) ExecTask(ctx context.Context, task string, opts *driver.ExecStreamingOptions) (int, error) {
+	r.taskLock.RLock()
+	tr, ok := r.tasks[task]
+	r.taskLock.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("task %q not found in allocation %q", task, r.alloc.ID)
+	}
+	return tr.ExecStreaming(ctx, opts)
+}
+
 // LatestAllocStats returns the latest allocation stats. If the optional taskFilter is set
 // the allocation stats will only include the given task.
 func (r *AllocRunner) LatestAllocStats(taskFilter string) (*cstructs.AllocResourceUsage, error) {
@@ -635,6 +794,13 @@ func (r *AllocRunner) Destroy() {
 	close(r.destroyCh)
 }
 
+// IsDestroyed returns whether the alloc runner has been destroyed
+func (r *AllocRunner) IsDestroyed() bool {
+	r.destroyLock.Lock()
+	defer r.destroyLock.Unlock()
+	return r.destroy
+}
+
 // WaitCh returns a channel to wait for termination
 func (r *AllocRunner) WaitCh() <-chan struct{} {
 	return r.waitCh
@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 
+	"github.com/hashicorp/nomad/client/cni"
 	"github.com/hashicorp/nomad/client/config"
 	ctestutil "github.com/hashicorp/nomad/client/testutil"
 	"github.com/hashicorp/nomad/client/vaultclient"
@@ -521,6 +524,142 @@ func TestAllocRunner_TaskFailed_KillTG(t *testing.T) {
 	})
 }
 
+func TestAllocRunner_TaskLifecycle(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{"run_for": "500ms"}
+
+	initTask := task.Copy()
+	initTask.Name = "init"
+	initTask.Config = map[string]interface{}{"run_for": "100ms"}
+	initTask.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPrestart}
+
+	proxyTask := task.Copy()
+	proxyTask.Name = "proxy"
+	proxyTask.Config = map[string]interface{}{"run_for": "100s"}
+	proxyTask.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPrestart, Sidecar: true}
+
+	cleanupTask := task.Copy()
+	cleanupTask.Name = "cleanup"
+	cleanupTask.Config = map[string]interface{}{"run_for": "100ms"}
+	cleanupTask.Lifecycle = &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPoststop}
+
+	for _, lt := range []*structs.Task{initTask, proxyTask, cleanupTask} {
+		alloc.Job.TaskGroups[0].Tasks = append(alloc.Job.TaskGroups[0].Tasks, lt)
+		alloc.TaskResources[lt.Name] = lt.Resources
+	}
+
+	upd, ar := testAllocRunnerFromAlloc(alloc, false)
+	go ar.Run()
+	defer ar.Destroy()
+
+	// eventTime returns the time of the first event of the type of the task
+	eventTime := func(state *structs.TaskState, eventType string) int64 {
+		for _, e := range state.Events {
+			if e.Type == eventType {
+				return e.Time
+			}
+		}
+		return 0
+	}
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, fmt.Errorf("No updates")
+		}
+		last := upd.Allocs[upd.Count-1]
+		if last.ClientStatus != structs.AllocClientStatusComplete {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusComplete)
+		}
+
+		for name, state := range last.TaskStates {
+			if state.State != structs.TaskStateDead || state.Failed {
+				return false, fmt.Errorf("task %q: got state %v, failed %v", name, state.State, state.Failed)
+			}
+		}
+
+		// The main task starts once the init task completed and the cleanup
+		// task once the main task is dead
+		initDone := eventTime(last.TaskStates["init"], structs.TaskTerminated)
+		webStarted := eventTime(last.TaskStates["web"], structs.TaskStarted)
+		webDone := eventTime(last.TaskStates["web"], structs.TaskTerminated)
+		cleanupStarted := eventTime(last.TaskStates["cleanup"], structs.TaskStarted)
+		if initDone == 0 || webStarted < initDone {
+			return false, fmt.Errorf("web started at %d before init completed at %d", webStarted, initDone)
+		}
+		if webDone == 0 || cleanupStarted < webDone {
+			return false, fmt.Errorf("cleanup started at %d before web completed at %d", cleanupStarted, webDone)
+		}
+
+		// The sidecar is killed once the main task is dead
+		if eventTime(last.TaskStates["proxy"], structs.TaskMainDead) == 0 {
+			return false, fmt.Errorf("proxy wasn't killed when the main task died")
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestAllocRunner_BridgeNetwork_UnsupportedDriver(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{"run_for": "10s"}
+	alloc.SharedResources.Networks = []*structs.NetworkResource{
+		{
+			Mode:         structs.NetworkModeBridge,
+			IP:           "127.0.0.1",
+			DynamicPorts: []structs.Port{{Label: "http", Value: 25000, To: 8080}},
+		},
+	}
+
+	upd, ar := testAllocRunnerFromAlloc(alloc, false)
+	go ar.Run()
+	defer ar.Destroy()
+
+	testutil.WaitForResult(func() (bool, error) {
+		if upd.Count == 0 {
+			return false, fmt.Errorf("No updates")
+		}
+		last := upd.Allocs[upd.Count-1]
+		if last.ClientStatus != structs.AllocClientStatusFailed {
+			return false, fmt.Errorf("got status %v; want %v", last.ClientStatus, structs.AllocClientStatusFailed)
+		}
+		if !strings.Contains(last.ClientDescription, "doesn't support") {
+			return false, fmt.Errorf("got description %q", last.ClientDescription)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestAllocRunner_PortMappings(t *testing.T) {
+	network := &structs.NetworkResource{
+		Mode:          structs.NetworkModeBridge,
+		IP:            "10.0.0.1",
+		ReservedPorts: []structs.Port{{Label: "http", Value: 80, To: 8080}},
+		DynamicPorts:  []structs.Port{{Label: "admin", Value: 25000}},
+	}
+
+	mappings := make(map[string]cni.PortMapping)
+	for _, m := range portMappings(network) {
+		mappings[fmt.Sprintf("%d/%s", m.HostPort, m.Protocol)] = m
+	}
+
+	expected := map[string]cni.PortMapping{
+		"80/tcp":    {HostPort: 80, ContainerPort: 8080, Protocol: "tcp", HostIP: "10.0.0.1"},
+		"80/udp":    {HostPort: 80, ContainerPort: 8080, Protocol: "udp", HostIP: "10.0.0.1"},
+		"25000/tcp": {HostPort: 25000, ContainerPort: 25000, Protocol: "tcp", HostIP: "10.0.0.1"},
+		"25000/udp": {HostPort: 25000, ContainerPort: 25000, Protocol: "udp", HostIP: "10.0.0.1"},
+	}
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Fatalf("bad: %#v", mappings)
+	}
+}
+
 func TestAllocRunner_MoveAllocDir(t *testing.T) {
 	// Create an alloc runner
 	alloc := mock.Alloc()
@@ -588,3 +727,19 @@ func TestAllocRunner_MoveAllocDir(t *testing.T) {
 		t.Fatalf("file %v not found", dataFile)
 	}
 }
+
+func TestAllocRunner_RestartSignalTasks_UnknownTask(t *testing.T) {
+	_, ar := testAllocRunner(false)
+
+	if err := ar.RestartTasks("foo"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("bad: %v", err)
+	}
+	if err := ar.SignalTasks("foo", os.Interrupt); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// Without a task, all the task runners are used
+	if err := ar.RestartTasks(""); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
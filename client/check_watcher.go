@@ -0,0 +1,202 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// checkWatch tracks the health of a check with a restart policy
+type checkWatch struct {
+	task  string
+	check *structs.ServiceCheck
+
+	// unhealthySince is when the check was first seen unhealthy. It is zero
+	// while the check is healthy.
+	unhealthySince time.Time
+}
+
+// checkWatcher restarts the tasks of an allocation whose checks stay
+// unhealthy according to their check_restart policy.
+type checkWatcher struct {
+	allocID string
+	checks  ConsulChecks
+	logger  *log.Logger
+	watches []*checkWatch
+
+	// interval is the shortest interval of the watched checks
+	interval time.Duration
+
+	// running returns whether the task is running and name interpolates the
+	// name of one of its checks
+	running func(task string) bool
+	name    func(task, check string) string
+
+	// restart restarts the task for the reason
+	restart func(task, reason string)
+
+	// runningSince is when each task was first seen running since it was
+	// last started
+	runningSince map[string]time.Time
+}
+
+// newCheckWatcher returns a watcher of the checks of the task group with a
+// restart policy or nil if there are none.
+func newCheckWatcher(allocID string, tg *structs.TaskGroup, checks ConsulChecks, logger *log.Logger) *checkWatcher {
+	w := &checkWatcher{
+		allocID:      allocID,
+		checks:       checks,
+		logger:       logger,
+		runningSince: make(map[string]time.Time),
+	}
+	for _, task := range tg.Tasks {
+		for _, service := range task.Services {
+			for _, check := range service.Checks {
+				if check.CheckRestart == nil || check.CheckRestart.Limit == 0 {
+					continue
+				}
+				w.watches = append(w.watches, &checkWatch{task: task.Name, check: check})
+				if w.interval == 0 || check.Interval < w.interval {
+					w.interval = check.Interval
+				}
+			}
+		}
+	}
+	if len(w.watches) == 0 {
+		return nil
+	}
+	return w
+}
+
+// run inspects the checks at the interval of the most frequent check until
+// the stop channel is closed.
+func (w *checkWatcher) run(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			w.check(now)
+		}
+	}
+}
+
+// check inspects the checks of the running tasks and restarts the tasks
+// whose checks have been unhealthy for the limit of their policy. A check
+// restarting its task after limit consecutive unhealthy results has been
+// unhealthy for limit - 1 check intervals.
+func (w *checkWatcher) check(now time.Time) {
+	statuses := make(map[string]map[string]string)
+	restarted := make(map[string]bool)
+	for _, watch := range w.watches {
+		if restarted[watch.task] {
+			continue
+		}
+		if !w.running(watch.task) {
+			delete(w.runningSince, watch.task)
+			watch.unhealthySince = time.Time{}
+			continue
+		}
+		since, ok := w.runningSince[watch.task]
+		if !ok {
+			since = now
+			w.runningSince[watch.task] = now
+		}
+
+		policy := watch.check.CheckRestart
+		if now.Sub(since) < policy.Grace {
+			watch.unhealthySince = time.Time{}
+			continue
+		}
+
+		// Look up the status of the checks of the task by name
+		byName, ok := statuses[watch.task]
+		if !ok {
+			checks, err := w.checks.DomainChecks(consul.NewExecutorDomain(w.allocID, watch.task))
+			if err != nil {
+				w.logger.Printf("[WARN] client: failed to look up checks of task %q in alloc %q: %v", watch.task, w.allocID, err)
+				return
+			}
+			byName = make(map[string]string, len(checks))
+			for _, check := range checks {
+				byName[check.Name] = check.Status
+			}
+			statuses[watch.task] = byName
+		}
+
+		// Checks that aren't registered yet are considered healthy
+		name := w.name(watch.task, watch.check.Name)
+		switch status, ok := byName[name]; {
+		case !ok, status == consulapi.HealthPassing,
+			status == consulapi.HealthWarning && policy.IgnoreWarnings:
+			watch.unhealthySince = time.Time{}
+			continue
+		}
+
+		if watch.unhealthySince.IsZero() {
+			watch.unhealthySince = now
+		}
+		if now.Sub(watch.unhealthySince) < time.Duration(policy.Limit-1)*watch.check.Interval {
+			continue
+		}
+
+		w.logger.Printf("[DEBUG] client: restarting task %q in alloc %q because check %q is unhealthy", watch.task, w.allocID, name)
+		w.restart(watch.task, fmt.Sprintf("check %q unhealthy", name))
+		restarted[watch.task] = true
+	}
+
+	// The grace period and the health of the checks of the restarted tasks
+	// start over
+	for _, watch := range w.watches {
+		if restarted[watch.task] {
+			delete(w.runningSince, watch.task)
+			watch.unhealthySince = time.Time{}
+		}
+	}
+}
+
+// watchChecks restarts the tasks whose checks stay unhealthy until the
+// allocation is destroyed.
+func (r *AllocRunner) watchChecks(tg *structs.TaskGroup) {
+	if r.consulChecks == nil {
+		return
+	}
+	w := newCheckWatcher(r.alloc.ID, tg, r.consulChecks, r.logger)
+	if w == nil {
+		return
+	}
+
+	w.running = func(task string) bool {
+		r.taskStatusLock.RLock()
+		defer r.taskStatusLock.RUnlock()
+		state, ok := r.taskStates[task]
+		return ok && state.State == structs.TaskStateRunning
+	}
+	w.name = func(task, check string) string {
+		r.taskLock.RLock()
+		tr, ok := r.tasks[task]
+		r.taskLock.RUnlock()
+		if !ok {
+			return check
+		}
+		if env := tr.getTaskEnv(); env != nil {
+			return env.ReplaceEnv(check)
+		}
+		return check
+	}
+	w.restart = func(task, reason string) {
+		r.taskLock.RLock()
+		tr, ok := r.tasks[task]
+		r.taskLock.RUnlock()
+		if ok {
+			go tr.Restart("healthcheck", reason)
+		}
+	}
+	w.run(r.destroyCh)
+}
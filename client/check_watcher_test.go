@@ -0,0 +1,106 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// testCheckWatcher returns a watcher of the check of the web task of an
+// allocation and the restarts it triggers.
+func testCheckWatcher(t *testing.T, policy *structs.CheckRestart) (*checkWatcher, *consulapi.AgentCheck, *[]string) {
+	alloc := mock.Alloc()
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	check := tg.Tasks[0].Services[0].Checks[0]
+	check.Interval = 10 * time.Second
+	check.CheckRestart = policy
+
+	status := &consulapi.AgentCheck{Name: check.Name, Status: consulapi.HealthPassing}
+	checks := mockConsulChecks{
+		consul.NewExecutorDomain(alloc.ID, "web"): {"check1": status},
+	}
+
+	w := newCheckWatcher(alloc.ID, tg, checks, testLogger())
+	if w == nil {
+		t.Fatalf("expected a watcher")
+	}
+	var restarts []string
+	w.running = func(task string) bool { return true }
+	w.name = func(task, check string) string { return check }
+	w.restart = func(task, reason string) { restarts = append(restarts, task) }
+	return w, status, &restarts
+}
+
+func TestCheckWatcher_Restart(t *testing.T) {
+	w, status, restarts := testCheckWatcher(t, &structs.CheckRestart{
+		Limit: 3,
+		Grace: 5 * time.Second,
+	})
+	now := time.Now()
+
+	// Failures are ignored during the grace period
+	status.Status = consulapi.HealthCritical
+	w.check(now)
+	w.check(now.Add(4 * time.Second))
+
+	// The task is restarted after three consecutive failures
+	w.check(now.Add(10 * time.Second))
+	w.check(now.Add(20 * time.Second))
+	if len(*restarts) != 0 {
+		t.Fatalf("bad: %v", *restarts)
+	}
+	w.check(now.Add(30 * time.Second))
+	if len(*restarts) != 1 || (*restarts)[0] != "web" {
+		t.Fatalf("bad: %v", *restarts)
+	}
+
+	// The grace period starts over after the restart
+	w.check(now.Add(40 * time.Second))
+	w.check(now.Add(44 * time.Second))
+	if len(*restarts) != 1 {
+		t.Fatalf("bad: %v", *restarts)
+	}
+
+	// A passing result resets the failures
+	w.check(now.Add(50 * time.Second))
+	status.Status = consulapi.HealthPassing
+	w.check(now.Add(60 * time.Second))
+	status.Status = consulapi.HealthCritical
+	w.check(now.Add(70 * time.Second))
+	w.check(now.Add(80 * time.Second))
+	if len(*restarts) != 1 {
+		t.Fatalf("bad: %v", *restarts)
+	}
+}
+
+func TestCheckWatcher_IgnoreWarnings(t *testing.T) {
+	w, status, restarts := testCheckWatcher(t, &structs.CheckRestart{
+		Limit:          1,
+		IgnoreWarnings: true,
+	})
+	now := time.Now()
+
+	status.Status = consulapi.HealthWarning
+	w.check(now)
+	if len(*restarts) != 0 {
+		t.Fatalf("bad: %v", *restarts)
+	}
+
+	w.watches[0].check.CheckRestart.IgnoreWarnings = false
+	w.check(now.Add(10 * time.Second))
+	if len(*restarts) != 1 {
+		t.Fatalf("bad: %v", *restarts)
+	}
+}
+
+func TestCheckWatcher_NoPolicy(t *testing.T) {
+	alloc := mock.Alloc()
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if w := newCheckWatcher(alloc.ID, tg, mockConsulChecks{}, testLogger()); w != nil {
+		t.Fatalf("bad: %#v", w)
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"archive/tar"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -10,12 +11,14 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul-template/signals"
 	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/go-multierror"
@@ -154,6 +157,9 @@ type Client struct {
 	// migratingAllocs is the set of allocs whose data migration is in flight
 	migratingAllocs     map[string]chan struct{}
 	migratingAllocsLock sync.Mutex
+
+	// garbageCollector destroys the alloc dirs of terminal allocations
+	garbageCollector *AllocGarbageCollector
 }
 
 var (
@@ -192,6 +198,7 @@ func NewClient(cfg *config.Config, consulSyncer *consul.Syncer, logger *log.Logg
 		triggerDiscoveryCh:  make(chan struct{}),
 		serversDiscoveredCh: make(chan struct{}),
 	}
+	c.garbageCollector = NewAllocGarbageCollector(logger, cfg, c)
 
 	// Initialize the client
 	if err := c.init(); err != nil {
@@ -268,6 +275,9 @@ func NewClient(cfg *config.Config, consulSyncer *consul.Syncer, logger *log.Logg
 	// Start collecting stats
 	go c.collectHostStats()
 
+	// Garbage collect the terminal allocations exceeding the limits
+	go c.garbageCollector.Run()
+
 	c.logger.Printf("[INFO] client: Node ID %q", c.Node().ID)
 	return c, nil
 }
@@ -367,14 +377,16 @@ func (c *Client) Shutdown() error {
 		c.vaultClient.Stop()
 	}
 
-	// Destroy all the running allocations.
+	// Stop garbage collecting allocations
+	c.garbageCollector.Stop()
+
+	// Destroy all the running allocations. The alloc runners are waited for
+	// without holding the allocLock as they sync their status on termination.
 	if c.config.DevMode {
-		c.allocLock.Lock()
-		for _, ar := range c.allocs {
+		for _, ar := range c.getAllocRunners() {
 			ar.Destroy()
 			<-ar.WaitCh()
 		}
-		c.allocLock.Unlock()
 	}
 
 	c.shutdown = true
@@ -412,6 +424,20 @@ func (c *Client) RPC(method string, args interface{}, reply interface{}) error {
 	return mErr.ErrorOrNil()
 }
 
+// SnapshotRPC forwards a snapshot request to a nomad server, or fails if
+// there are no servers. The returned reader must be closed by the caller.
+func (c *Client) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader,
+	reply *structs.SnapshotResponse) (io.ReadCloser, error) {
+	servers := c.servers.all()
+	if len(servers) == 0 {
+		return nil, noServersErr
+	}
+
+	// The input can only be streamed once so the request is not retried
+	// against the other servers
+	return nomad.SnapshotRPC(c.connPool, c.Region(), servers[0].addr, args, in, reply)
+}
+
 // Stats is used to return statistics for debugging and insight
 // for various sub-systems
 func (c *Client) Stats() map[string]map[string]string {
@@ -434,6 +460,48 @@ func (c *Client) Stats() map[string]map[string]string {
 	return stats
 }
 
+// GetConfig returns a copy of the client's configuration
+func (c *Client) GetConfig() *config.Config {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+	return c.config.Copy()
+}
+
+// Reload applies the reloadable fields of the given configuration to the
+// running client. The RPC TLS settings are rebuilt from the certificate files
+// and, if the node's reserved resources changed, the node is re-registered so
+// the schedulers see the new capacity. Running allocations are unaffected.
+func (c *Client) Reload(newConfig *config.Config) error {
+	var tlsWrap tlsutil.RegionWrapper
+	if newConfig.TLSConfig.EnableRPC {
+		tw, err := newConfig.TLSConfiguration().OutgoingTLSWrapper()
+		if err != nil {
+			c.logger.Printf("[ERR] client: failed to reload TLS configuration: %v", err)
+			return err
+		}
+		tlsWrap = tw
+	}
+	c.connPool.ReloadTLS(tlsWrap)
+
+	c.configLock.Lock()
+	c.config.TLSConfig = newConfig.TLSConfig
+	c.configCopy.TLSConfig = newConfig.TLSConfig
+	reservedChanged := !reflect.DeepEqual(c.config.Node.Reserved, newConfig.Node.Reserved)
+	if reservedChanged {
+		c.config.Node.Reserved = newConfig.Node.Reserved.Copy()
+		c.configCopy.Node = c.config.Node.Copy()
+	}
+	c.configLock.Unlock()
+
+	if reservedChanged {
+		c.logger.Printf("[INFO] client: reserved resources changed, updating node")
+		go c.retryRegisterNode()
+	}
+
+	c.logger.Printf("[INFO] client: reloaded configuration")
+	return nil
+}
+
 // Node returns the locally registered node
 func (c *Client) Node() *structs.Node {
 	c.configLock.RLock()
@@ -464,6 +532,47 @@ func (c *Client) LatestHostStats() *stats.HostStats {
 	return c.resourceUsage
 }
 
+// RestartAlloc restarts the tasks of an allocation or only the given task if
+// it is set.
+func (c *Client) RestartAlloc(allocID, task string) error {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown allocation ID %q", allocID)
+	}
+	return ar.RestartTasks(task)
+}
+
+// SignalAlloc sends the named signal to the tasks of an allocation or only to
+// the given task if it is set.
+func (c *Client) SignalAlloc(allocID, task, signal string) error {
+	sig, err := signals.Parse(signal)
+	if err != nil {
+		return err
+	}
+
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown allocation ID %q", allocID)
+	}
+	return ar.SignalTasks(task, sig)
+}
+
+// ExecAlloc runs a command in the context of a task of an allocation and
+// returns its exit code.
+func (c *Client) ExecAlloc(ctx context.Context, allocID, task string, opts *driver.ExecStreamingOptions) (int, error) {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("unknown allocation ID %q", allocID)
+	}
+	return ar.ExecTask(ctx, task, opts)
+}
+
 // GetAllocFS returns the AllocFS interface for the alloc dir of an allocation
 func (c *Client) GetAllocFS(allocID string) (allocdir.AllocDirFS, error) {
 	c.allocLock.RLock()
@@ -473,9 +582,50 @@ func (c *Client) GetAllocFS(allocID string) (allocdir.AllocDirFS, error) {
 	if !ok {
 		return nil, fmt.Errorf("alloc not found")
 	}
+	if ar.IsDestroyed() {
+		return nil, fmt.Errorf("alloc %q has been garbage collected", allocID)
+	}
 	return ar.GetAllocDir(), nil
 }
 
+// AllocNamespace returns the namespace of the job of an allocation. Jobs that
+// do not specify one are registered in the default namespace.
+func (c *Client) AllocNamespace(allocID string) (string, error) {
+	c.allocLock.RLock()
+	ar, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown allocation ID %q", allocID)
+	}
+
+	alloc := ar.Alloc()
+	if alloc.Job == nil || alloc.Job.Namespace == "" {
+		return structs.DefaultNamespace, nil
+	}
+	return alloc.Job.Namespace, nil
+}
+
+// NumAllocs returns the number of allocations of the client whose alloc dirs
+// have not been garbage collected
+func (c *Client) NumAllocs() int {
+	c.allocLock.RLock()
+	defer c.allocLock.RUnlock()
+
+	n := 0
+	for _, ar := range c.allocs {
+		if !ar.IsDestroyed() {
+			n++
+		}
+	}
+	return n
+}
+
+// CollectAllAllocs garbage collects all the terminal allocations of the client
+// and returns the number of collected allocations
+func (c *Client) CollectAllAllocs() int {
+	return c.garbageCollector.CollectAll()
+}
+
 // GetServers returns the list of nomad servers this client is aware of.
 func (c *Client) GetServers() []string {
 	endpoints := c.servers.all()
@@ -537,6 +687,7 @@ func (c *Client) restoreState() error {
 		alloc := &structs.Allocation{ID: id}
 		c.configLock.RLock()
 		ar := NewAllocRunner(c.logger, c.configCopy, c.updateAllocStatus, alloc, c.vaultClient)
+		ar.SetConsulChecks(c.consulSyncer)
 		c.configLock.RUnlock()
 		c.allocLock.Lock()
 		c.allocs[id] = ar
@@ -546,6 +697,9 @@ func (c *Client) restoreState() error {
 			mErr.Errors = append(mErr.Errors, err)
 		} else {
 			go ar.Run()
+			if ar.Alloc().Terminated() {
+				c.garbageCollector.MarkForCollection(ar)
+			}
 		}
 	}
 	return mErr.ErrorOrNil()
@@ -559,6 +713,10 @@ func (c *Client) saveState() error {
 
 	var mErr multierror.Error
 	for id, ar := range c.getAllocRunners() {
+		// The state of garbage collected allocations has been destroyed
+		if ar.IsDestroyed() {
+			continue
+		}
 		if err := ar.SaveState(); err != nil {
 			c.logger.Printf("[ERR] client: failed to save state for alloc %s: %v",
 				id, err)
@@ -792,7 +950,7 @@ func (c *Client) setupDrivers() error {
 
 	var avail []string
 	var skipped []string
-	driverCtx := driver.NewDriverContext("", c.config, c.config.Node, c.logger, nil)
+	driverCtx := driver.NewDriverContext("", c.config, c.config.Node, c.logger, nil, nil)
 	for name := range driver.BuiltinDrivers {
 		// Skip fingerprinting drivers that are not in the whitelist if it is
 		// enabled.
@@ -1076,6 +1234,7 @@ func (c *Client) updateAllocStatus(alloc *structs.Allocation) {
 	stripped.TaskStates = alloc.TaskStates
 	stripped.ClientStatus = alloc.ClientStatus
 	stripped.ClientDescription = alloc.ClientDescription
+	stripped.DeploymentStatus = alloc.DeploymentStatus
 	select {
 	case c.allocUpdates <- stripped:
 	case <-c.shutdownCh:
@@ -1097,6 +1256,14 @@ func (c *Client) allocSync() {
 			// Batch the allocation updates until the timer triggers.
 			updates[alloc.ID] = alloc
 
+			// Queue the alloc dir of terminated allocations for garbage
+			// collection
+			if alloc.Terminated() {
+				if ar, ok := c.getAllocRunners()[alloc.ID]; ok {
+					c.garbageCollector.MarkForCollection(ar)
+				}
+			}
+
 			// If this alloc was blocking another alloc and transitioned to a
 			// terminal state then start the blocked allocation
 			c.blockedAllocsLock.Lock()
@@ -1156,6 +1323,10 @@ type allocUpdates struct {
 	// filtered is the set of allocations that were not pulled because their
 	// AllocModifyIndex didn't change.
 	filtered map[string]struct{}
+
+	// migrateTokens are the tokens used to migrate the data of the previous
+	// allocations from other nodes, keyed by the migrating allocation ID.
+	migrateTokens map[string]string
 }
 
 // watchAllocations is used to scan for updates to allocations
@@ -1180,6 +1351,7 @@ func (c *Client) watchAllocations(updates chan *allocUpdates) {
 		QueryOptions: structs.QueryOptions{
 			Region:     c.Region(),
 			AllowStale: true,
+			AuthToken:  n.SecretID,
 		},
 	}
 	var allocsResp structs.AllocsGetResponse
@@ -1285,8 +1457,9 @@ func (c *Client) watchAllocations(updates chan *allocUpdates) {
 			pulled[alloc.ID] = alloc
 		}
 		update := &allocUpdates{
-			filtered: filtered,
-			pulled:   pulled,
+			filtered:      filtered,
+			pulled:        pulled,
+			migrateTokens: resp.MigrateTokens,
 		}
 		select {
 		case updates <- update:
@@ -1366,6 +1539,10 @@ func (c *Client) runAllocs(update *allocUpdates) {
 		}
 	}
 
+	// Garbage collect terminal allocations to keep the number of allocations
+	// under the limit once the new ones are started
+	c.garbageCollector.MakeRoomFor(len(diff.added))
+
 	// Start the new allocations
 	for _, add := range diff.added {
 		// If the allocation is chained and the previous allocation hasn't
@@ -1385,7 +1562,7 @@ func (c *Client) runAllocs(update *allocUpdates) {
 			c.migratingAllocsLock.Lock()
 			c.migratingAllocs[add.ID] = make(chan struct{})
 			c.migratingAllocsLock.Unlock()
-			go c.blockForRemoteAlloc(add)
+			go c.blockForRemoteAlloc(add, update.migrateTokens[add.ID])
 			continue
 		}
 
@@ -1393,7 +1570,7 @@ func (c *Client) runAllocs(update *allocUpdates) {
 		// previous allocation
 		var prevAllocDir *allocdir.AllocDir
 		tg := add.Job.LookupTaskGroup(add.TaskGroup)
-		if tg != nil && tg.EphemeralDisk != nil && tg.EphemeralDisk.Sticky == true && ar != nil {
+		if tg != nil && tg.EphemeralDisk != nil && tg.EphemeralDisk.Sticky == true && ar != nil && !ar.IsDestroyed() {
 			prevAllocDir = ar.GetAllocDir()
 		}
 
@@ -1410,8 +1587,9 @@ func (c *Client) runAllocs(update *allocUpdates) {
 }
 
 // blockForRemoteAlloc blocks until the previous allocation of an allocation has
-// been terminated and migrates the snapshot data
-func (c *Client) blockForRemoteAlloc(alloc *structs.Allocation) {
+// been terminated and migrates the snapshot data, authenticating with the
+// migrate token when ACLs are enabled
+func (c *Client) blockForRemoteAlloc(alloc *structs.Allocation, migrateToken string) {
 	// Removing the allocation from the set of allocs which are currently
 	// undergoing migration
 	defer func() {
@@ -1442,7 +1620,7 @@ func (c *Client) blockForRemoteAlloc(alloc *structs.Allocation) {
 		}
 
 		// Migrate the data from the remote node
-		prevAllocDir, err = c.migrateRemoteAllocDir(prevAlloc, alloc.ID)
+		prevAllocDir, err = c.migrateRemoteAllocDir(prevAlloc, alloc.ID, migrateToken)
 		if err != nil {
 			c.logger.Printf("[ERR] client: error migrating data from remote alloc %q: %v",
 				alloc.PreviousAllocation, err)
@@ -1464,6 +1642,7 @@ func (c *Client) waitForAllocTerminal(allocID string) (*structs.Allocation, erro
 		QueryOptions: structs.QueryOptions{
 			Region:     c.Region(),
 			AllowStale: true,
+			AuthToken:  c.Node().SecretID,
 		},
 	}
 
@@ -1495,9 +1674,15 @@ func (c *Client) waitForAllocTerminal(allocID string) (*structs.Allocation, erro
 	}
 }
 
+// ValidateMigrateToken returns whether the token allows another node to
+// migrate the data of the allocation running on this node.
+func (c *Client) ValidateMigrateToken(allocID, migrateToken string) bool {
+	return structs.CompareMigrateToken(allocID, c.Node().SecretID, migrateToken)
+}
+
 // migrateRemoteAllocDir migrates the allocation directory from a remote node to
-// the current node
-func (c *Client) migrateRemoteAllocDir(alloc *structs.Allocation, allocID string) (*allocdir.AllocDir, error) {
+// the current node. The migrate token is sent as the token of the request.
+func (c *Client) migrateRemoteAllocDir(alloc *structs.Allocation, allocID, migrateToken string) (*allocdir.AllocDir, error) {
 	if alloc == nil {
 		return nil, nil
 	}
@@ -1545,6 +1730,7 @@ func (c *Client) migrateRemoteAllocDir(alloc *structs.Allocation, allocID string
 	// Create an API client
 	apiConfig := nomadapi.DefaultConfig()
 	apiConfig.Address = fmt.Sprintf("%s://%s", scheme, node.HTTPAddr)
+	apiConfig.SecretID = migrateToken
 	apiConfig.TLSConfig = &nomadapi.TLSConfig{
 		CACert:     c.config.TLSConfig.CAFile,
 		ClientCert: c.config.TLSConfig.CertFile,
@@ -1625,7 +1811,14 @@ func (c *Client) migrateRemoteAllocDir(alloc *structs.Allocation, allocID string
 					return nil, nil
 				}
 
+				// The last chunk of the file may be returned along with
+				// io.EOF, so it is written before checking the error
 				n, err := tr.Read(buf)
+				if _, err := f.Write(buf[:n]); err != nil {
+					f.Close()
+					os.RemoveAll(pathToAllocDir)
+					return nil, fmt.Errorf("error writing to file %q: %v", f.Name(), err)
+				}
 				if err != nil {
 					f.Close()
 					if err != io.EOF {
@@ -1633,11 +1826,6 @@ func (c *Client) migrateRemoteAllocDir(alloc *structs.Allocation, allocID string
 					}
 					break
 				}
-				if _, err := f.Write(buf[:n]); err != nil {
-					f.Close()
-					os.RemoveAll(pathToAllocDir)
-					return nil, fmt.Errorf("error writing to file %q: %v", f.Name(), err)
-				}
 			}
 
 		}
@@ -1651,6 +1839,7 @@ func (c *Client) getNode(nodeID string) (*structs.Node, error) {
 		QueryOptions: structs.QueryOptions{
 			Region:     c.Region(),
 			AllowStale: true,
+			AuthToken:  c.Node().SecretID,
 		},
 	}
 
@@ -1685,6 +1874,7 @@ func (c *Client) removeAlloc(alloc *structs.Allocation) error {
 	delete(c.allocs, alloc.ID)
 	c.allocLock.Unlock()
 
+	c.garbageCollector.Remove(ar)
 	ar.Destroy()
 	return nil
 }
@@ -1699,6 +1889,11 @@ func (c *Client) updateAlloc(exist, update *structs.Allocation) error {
 		return nil
 	}
 
+	// Garbage collected allocations are terminal and no longer updated
+	if ar.IsDestroyed() {
+		return nil
+	}
+
 	ar.Update(update)
 	return nil
 }
@@ -1708,6 +1903,7 @@ func (c *Client) addAlloc(alloc *structs.Allocation, prevAllocDir *allocdir.Allo
 	c.configLock.RLock()
 	ar := NewAllocRunner(c.logger, c.configCopy, c.updateAllocStatus, alloc, c.vaultClient)
 	ar.SetPreviousAllocDir(prevAllocDir)
+	ar.SetConsulChecks(c.consulSyncer)
 	c.configLock.RUnlock()
 	go ar.Run()
 
@@ -1715,6 +1911,11 @@ func (c *Client) addAlloc(alloc *structs.Allocation, prevAllocDir *allocdir.Allo
 	c.allocLock.Lock()
 	c.allocs[alloc.ID] = ar
 	c.allocLock.Unlock()
+
+	// Allocations received once terminated only wait to be collected
+	if alloc.Terminated() {
+		c.garbageCollector.MarkForCollection(ar)
+	}
 	return nil
 }
 
@@ -1,13 +1,17 @@
 package client
 
 import (
+	"archive/tar"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -17,6 +21,7 @@ import (
 	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
+	sconfig "github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/hashstructure"
 
@@ -404,6 +409,78 @@ func TestClient_Register(t *testing.T) {
 	})
 }
 
+func TestClient_Reload_Reserved(t *testing.T) {
+	s1, _ := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.RPCHandler = s1
+	})
+	defer c1.Shutdown()
+
+	// Change the reserved resources and reload
+	newConfig := c1.GetConfig()
+	if newConfig.Node.Reserved == nil {
+		newConfig.Node.Reserved = &structs.Resources{}
+	}
+	newConfig.Node.Reserved.CPU = 512
+	newConfig.Node.Reserved.MemoryMB = 256
+	if err := c1.Reload(newConfig); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if reserved := c1.Node().Reserved; reserved.CPU != 512 || reserved.MemoryMB != 256 {
+		t.Fatalf("bad: %#v", reserved)
+	}
+
+	// The server should see the updated node
+	req := structs.NodeSpecificRequest{
+		NodeID:       c1.Node().ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var out structs.SingleNodeResponse
+	testutil.WaitForResult(func() (bool, error) {
+		if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+			return false, err
+		}
+		if out.Node == nil || out.Node.Reserved == nil {
+			return false, fmt.Errorf("missing reg")
+		}
+		if out.Node.Reserved.CPU != 512 {
+			return false, fmt.Errorf("bad reserved cpu: %d", out.Node.Reserved.CPU)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestClient_Reload_TLS(t *testing.T) {
+	c1 := testClient(t, nil)
+	defer c1.Shutdown()
+
+	// Enabling TLS with a missing key should fail
+	newConfig := c1.GetConfig()
+	newConfig.TLSConfig = &sconfig.TLSConfig{
+		EnableRPC: true,
+		CAFile:    "../helper/tlsutil/testdata/ca.pem",
+		CertFile:  "../helper/tlsutil/testdata/nomad-foo.pem",
+		KeyFile:   "/does/not/exist",
+	}
+	if err := c1.Reload(newConfig); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	newConfig.TLSConfig.KeyFile = "../helper/tlsutil/testdata/nomad-foo-key.pem"
+	if err := c1.Reload(newConfig); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !c1.GetConfig().TLSConfig.EnableRPC {
+		t.Fatalf("expected TLS to be enabled")
+	}
+}
+
 func TestClient_Heartbeat(t *testing.T) {
 	s1, _ := testServer(t, func(c *nomad.Config) {
 		c.MinHeartbeatTTL = 50 * time.Millisecond
@@ -804,3 +881,106 @@ func TestClient_BlockedAllocations(t *testing.T) {
 	c1.allocLock.Unlock()
 
 }
+
+func TestClient_MigrateRemoteAllocDir_ACL(t *testing.T) {
+	s1, _ := testServer(t, func(c *nomad.Config) {
+		c.ACLEnabled = true
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.RPCHandler = s1
+	})
+	defer c1.Shutdown()
+
+	// Wait til the node is ready
+	waitTilNodeReady(c1, t)
+
+	// The remote node only serves the snapshot to the holder of a valid
+	// migrate token
+	remote := mock.Node()
+	job := mock.Job()
+	job.TaskGroups[0].EphemeralDisk.Sticky = true
+	job.TaskGroups[0].EphemeralDisk.Migrate = true
+	prevAlloc := mock.Alloc()
+	prevAlloc.Job = job
+	prevAlloc.JobID = job.ID
+	prevAlloc.NodeID = remote.ID
+	prevAlloc.DesiredStatus = structs.AllocDesiredStatusStop
+	prevAlloc.ClientStatus = structs.AllocClientStatusComplete
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf("/v1/client/allocation/%s/snapshot", prevAlloc.ID) ||
+			!structs.CompareMigrateToken(prevAlloc.ID, remote.SecretID, r.Header.Get("X-Nomad-Token")) {
+			w.WriteHeader(403)
+			return
+		}
+		tw := tar.NewWriter(w)
+		data := []byte("migrated")
+		tw.WriteHeader(&tar.Header{Name: "foo", Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+		tw.Write(data)
+		tw.Close()
+	}))
+	defer srv.Close()
+	remote.HTTPAddr = strings.TrimPrefix(srv.URL, "http://")
+
+	// The new allocation is placed on a node without a client so that only
+	// the test migrates its data
+	local := mock.Node()
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = local.ID
+	alloc.PreviousAllocation = prevAlloc.ID
+
+	state := s1.State()
+	if err := state.UpsertNode(99, local); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertNode(100, remote); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(101, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(102, []*structs.Allocation{prevAlloc, alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The servers hand out the migrate token along with the allocations
+	req := structs.NodeSpecificRequest{
+		NodeID:       local.ID,
+		SecretID:     local.SecretID,
+		QueryOptions: structs.QueryOptions{Region: c1.Region()},
+	}
+	var resp structs.NodeClientAllocsResponse
+	if err := c1.RPC("Node.GetClientAllocs", &req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	token, ok := resp.MigrateTokens[alloc.ID]
+	if !ok {
+		t.Fatalf("missing migrate token: %#v", resp.MigrateTokens)
+	}
+
+	c1.migratingAllocsLock.Lock()
+	c1.migratingAllocs[alloc.ID] = make(chan struct{})
+	c1.migratingAllocsLock.Unlock()
+
+	// Migrating without the token is denied
+	if _, err := c1.migrateRemoteAllocDir(prevAlloc, alloc.ID, ""); err == nil {
+		t.Fatalf("expected an error migrating without a token")
+	}
+
+	prevAllocDir, err := c1.migrateRemoteAllocDir(prevAlloc, alloc.ID, token)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(prevAllocDir.AllocDir, "foo"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(data) != "migrated" {
+		t.Fatalf("bad: %q", data)
+	}
+}
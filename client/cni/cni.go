@@ -0,0 +1,220 @@
+// Package cni configures the network namespaces of allocations by invoking
+// CNI plugins as described by the Container Network Interface specification.
+package cni
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const (
+	// Version is the version of the CNI specification the plugins are
+	// invoked with
+	Version = "0.4.0"
+
+	// NetworkName is the name of the network configured by the plugins
+	NetworkName = "nomad"
+
+	// DefaultPluginDir is the default directory holding the CNI plugins
+	DefaultPluginDir = "/opt/cni/bin"
+
+	// DefaultBridgeName is the default name of the bridge the network
+	// namespaces of the allocations are attached to
+	DefaultBridgeName = "nomad"
+
+	// DefaultSubnet is the default subnet the addresses of the allocations
+	// are allocated from
+	DefaultSubnet = "172.26.64.0/20"
+
+	// ifName is the name of the interface created in the network namespace
+	ifName = "eth0"
+)
+
+// Config configures the CNI plugins invoked to set up the bridge network of
+// allocations.
+type Config struct {
+	// PluginDir is the directory holding the CNI plugins
+	PluginDir string
+
+	// BridgeName is the name of the bridge on the host
+	BridgeName string
+
+	// Subnet is the subnet the addresses of the allocations are allocated
+	// from
+	Subnet string
+}
+
+// PortMapping maps a port of the host to a port of the network namespace.
+type PortMapping struct {
+	HostPort      int    `json:"hostPort"`
+	ContainerPort int    `json:"containerPort"`
+	Protocol      string `json:"protocol"`
+	HostIP        string `json:"hostIP,omitempty"`
+}
+
+// pluginError is the error returned by a plugin on its standard output.
+type pluginError struct {
+	Code    int    `json:"code"`
+	Msg     string `json:"msg"`
+	Details string `json:"details"`
+}
+
+// execFn executes a plugin with the passed environment and standard input and
+// returns its standard output.
+type execFn func(path string, env []string, stdin []byte) ([]byte, error)
+
+// Invoker invokes the chain of CNI plugins configuring the bridge network of
+// the allocations.
+type Invoker struct {
+	config *Config
+	logger *log.Logger
+	exec   execFn
+}
+
+// NewInvoker returns an Invoker for the passed configuration. Unset fields of
+// the configuration are set to their default.
+func NewInvoker(config *Config, logger *log.Logger) *Invoker {
+	c := *config
+	if c.PluginDir == "" {
+		c.PluginDir = DefaultPluginDir
+	}
+	if c.BridgeName == "" {
+		c.BridgeName = DefaultBridgeName
+	}
+	if c.Subnet == "" {
+		c.Subnet = DefaultSubnet
+	}
+	return &Invoker{
+		config: &c,
+		logger: logger,
+		exec:   execPlugin,
+	}
+}
+
+// Setup attaches the network namespace to the bridge and maps the ports of
+// the host into it. The id identifies the network namespace across calls.
+func (i *Invoker) Setup(id, netns string, ports []PortMapping) error {
+	var prevResult json.RawMessage
+	for _, plugin := range i.plugins(ports) {
+		if prevResult != nil {
+			plugin["prevResult"] = prevResult
+		}
+
+		result, err := i.invoke("ADD", id, netns, plugin)
+		if err != nil {
+			return err
+		}
+		prevResult = result
+	}
+
+	i.logger.Printf("[DEBUG] client.cni: configured network namespace %s of %q", netns, id)
+	return nil
+}
+
+// Teardown detaches the network namespace from the bridge and removes its
+// port mappings. The plugins are invoked in the reverse order of Setup and
+// all of them are invoked even if some fail.
+func (i *Invoker) Teardown(id, netns string, ports []PortMapping) error {
+	plugins := i.plugins(ports)
+
+	var errs []error
+	for j := len(plugins) - 1; j >= 0; j-- {
+		if _, err := i.invoke("DEL", id, netns, plugins[j]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to tear down network namespace of %q: %v", id, errs)
+	}
+	return nil
+}
+
+// plugins returns the configurations of the chain of plugins.
+func (i *Invoker) plugins(ports []PortMapping) []map[string]interface{} {
+	if ports == nil {
+		ports = []PortMapping{}
+	}
+
+	return []map[string]interface{}{
+		{
+			"type":             "bridge",
+			"bridge":           i.config.BridgeName,
+			"isDefaultGateway": true,
+			"ipMasq":           true,
+			"ipam": map[string]interface{}{
+				"type": "host-local",
+				"ranges": [][]map[string]string{
+					{{"subnet": i.config.Subnet}},
+				},
+				"routes": []map[string]string{
+					{"dst": "0.0.0.0/0"},
+				},
+			},
+		},
+		{
+			"type":                   "firewall",
+			"backend":                "iptables",
+			"iptablesAdminChainName": "NOMAD-ADMIN",
+		},
+		{
+			"type":         "portmap",
+			"capabilities": map[string]bool{"portMappings": true},
+			"snat":         true,
+			"runtimeConfig": map[string]interface{}{
+				"portMappings": ports,
+			},
+		},
+	}
+}
+
+// invoke executes the plugin of the passed configuration with the command and
+// returns its result.
+func (i *Invoker) invoke(command, id, netns string, plugin map[string]interface{}) (json.RawMessage, error) {
+	plugin["cniVersion"] = Version
+	plugin["name"] = NetworkName
+
+	stdin, err := json.Marshal(plugin)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginType := plugin["type"].(string)
+	env := append(os.Environ(),
+		"CNI_COMMAND="+command,
+		"CNI_CONTAINERID="+id,
+		"CNI_NETNS="+netns,
+		"CNI_IFNAME="+ifName,
+		"CNI_PATH="+i.config.PluginDir,
+	)
+
+	out, err := i.exec(filepath.Join(i.config.PluginDir, pluginType), env, stdin)
+	if err != nil {
+		var perr pluginError
+		if jerr := json.Unmarshal(out, &perr); jerr == nil && perr.Msg != "" {
+			return nil, fmt.Errorf("plugin %q failed to %s %q: %s", pluginType, command, id, perr.Msg)
+		}
+		return nil, fmt.Errorf("plugin %q failed to %s %q: %v", pluginType, command, id, err)
+	}
+
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	return json.RawMessage(out), nil
+}
+
+// execPlugin executes the plugin binary.
+func execPlugin(path string, env []string, stdin []byte) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Env = env
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	return stdout.Bytes(), err
+}
@@ -0,0 +1,135 @@
+package cni
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// invocation is a recorded execution of a plugin
+type invocation struct {
+	plugin string
+	env    map[string]string
+	config map[string]interface{}
+}
+
+// testInvoker returns an invoker recording the plugins it executes. The
+// plugin named fail fails.
+func testInvoker(t *testing.T, fail string) (*Invoker, *[]invocation) {
+	var calls []invocation
+	i := NewInvoker(&Config{PluginDir: "/cni"}, log.New(os.Stderr, "", log.LstdFlags))
+	i.exec = func(path string, env []string, stdin []byte) ([]byte, error) {
+		call := invocation{
+			plugin: filepath.Base(path),
+			env:    make(map[string]string),
+		}
+		for _, kv := range env {
+			if strings.HasPrefix(kv, "CNI_") {
+				parts := strings.SplitN(kv, "=", 2)
+				call.env[parts[0]] = parts[1]
+			}
+		}
+		if err := json.Unmarshal(stdin, &call.config); err != nil {
+			t.Fatalf("bad config: %v", err)
+		}
+		calls = append(calls, call)
+
+		if call.plugin == fail {
+			return []byte(`{"code": 100, "msg": "no bridge"}`), fmt.Errorf("exit status 1")
+		}
+		return []byte(fmt.Sprintf(`{"cniVersion": %q, "plugin": %q}`, Version, call.plugin)), nil
+	}
+	return i, &calls
+}
+
+func TestInvoker_Setup(t *testing.T) {
+	i, calls := testInvoker(t, "")
+	ports := []PortMapping{{HostPort: 25000, ContainerPort: 8080, Protocol: "tcp"}}
+	if err := i.Setup("alloc1", "/proc/1/ns/net", ports); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var plugins []string
+	for _, call := range *calls {
+		plugins = append(plugins, call.plugin)
+	}
+	if !reflect.DeepEqual(plugins, []string{"bridge", "firewall", "portmap"}) {
+		t.Fatalf("bad: %v", plugins)
+	}
+
+	expEnv := map[string]string{
+		"CNI_COMMAND":     "ADD",
+		"CNI_CONTAINERID": "alloc1",
+		"CNI_NETNS":       "/proc/1/ns/net",
+		"CNI_IFNAME":      "eth0",
+		"CNI_PATH":        "/cni",
+	}
+	for _, call := range *calls {
+		if !reflect.DeepEqual(call.env, expEnv) {
+			t.Fatalf("bad: %#v", call.env)
+		}
+		if call.config["cniVersion"] != Version || call.config["name"] != NetworkName {
+			t.Fatalf("bad: %#v", call.config)
+		}
+	}
+
+	// The bridge is configured with the default subnet
+	bridge := (*calls)[0].config
+	if bridge["bridge"] != DefaultBridgeName || !strings.Contains(fmt.Sprint(bridge["ipam"]), DefaultSubnet) {
+		t.Fatalf("bad: %#v", bridge)
+	}
+	if _, ok := bridge["prevResult"]; ok {
+		t.Fatalf("bad: %#v", bridge)
+	}
+
+	// The result of the previous plugin and the port mappings are passed to
+	// the portmap plugin
+	portmap := (*calls)[2].config
+	prev := portmap["prevResult"].(map[string]interface{})
+	if prev["plugin"] != "firewall" {
+		t.Fatalf("bad: %#v", prev)
+	}
+	mappings := portmap["runtimeConfig"].(map[string]interface{})["portMappings"].([]interface{})
+	mapping := mappings[0].(map[string]interface{})
+	if len(mappings) != 1 || mapping["hostPort"] != float64(25000) || mapping["containerPort"] != float64(8080) {
+		t.Fatalf("bad: %#v", mappings)
+	}
+}
+
+func TestInvoker_Setup_Error(t *testing.T) {
+	i, calls := testInvoker(t, "bridge")
+	err := i.Setup("alloc1", "/proc/1/ns/net", nil)
+	if err == nil || !strings.Contains(err.Error(), "no bridge") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The chain stops at the failing plugin
+	if len(*calls) != 1 {
+		t.Fatalf("bad: %#v", *calls)
+	}
+}
+
+func TestInvoker_Teardown(t *testing.T) {
+	i, calls := testInvoker(t, "firewall")
+	err := i.Teardown("alloc1", "/proc/1/ns/net", nil)
+	if err == nil || !strings.Contains(err.Error(), "no bridge") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// All the plugins are invoked in the reverse order
+	var plugins []string
+	for _, call := range *calls {
+		plugins = append(plugins, call.plugin)
+		if call.env["CNI_COMMAND"] != "DEL" {
+			t.Fatalf("bad: %#v", call.env)
+		}
+	}
+	if !reflect.DeepEqual(plugins, []string{"portmap", "firewall", "bridge"}) {
+		t.Fatalf("bad: %v", plugins)
+	}
+}
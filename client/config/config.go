@@ -100,6 +100,18 @@ type Config struct {
 	// devices and IPs.
 	GloballyReservedPorts []int
 
+	// CNIPath is the directory holding the CNI plugins used to set up the
+	// bridge networks of task groups. If empty, the CNI default is used.
+	CNIPath string
+
+	// BridgeNetworkName is the name of the bridge the network namespaces of
+	// the allocations are attached to
+	BridgeNetworkName string
+
+	// BridgeNetworkSubnet is the subnet the addresses of the allocations in
+	// bridge networking mode are allocated from
+	BridgeNetworkSubnet string
+
 	// A mapping of directories on the host OS to attempt to embed inside each
 	// task's chroot.
 	ChrootEnv map[string]string
@@ -134,8 +146,33 @@ type Config struct {
 	// allocation metrics to remote Telemetry sinks
 	PublishAllocationMetrics bool
 
+	// AllocationMetricsLabels are the labels, among
+	// ValidAllocationMetricsLabels, included in the keys of allocation metrics
+	// in this order
+	AllocationMetricsLabels []string
+
 	// TLSConfig holds various TLS related configurations
 	TLSConfig *config.TLSConfig
+
+	// GCInterval is the interval at which the client checks whether terminal
+	// allocations have to be garbage collected
+	GCInterval time.Duration
+
+	// GCDiskUsageThreshold is the disk usage percentage of the alloc dir
+	// above which terminal allocations are garbage collected
+	GCDiskUsageThreshold float64
+
+	// GCInodeUsageThreshold is the inode usage percentage of the alloc dir
+	// above which terminal allocations are garbage collected
+	GCInodeUsageThreshold float64
+
+	// GCMaxAllocs is the number of allocations the client keeps above which
+	// terminal allocations are garbage collected. Zero disables the limit.
+	GCMaxAllocs int
+
+	// GCMaxAllocAge is how long a terminal allocation is kept before being
+	// garbage collected. Zero disables the limit.
+	GCMaxAllocAge time.Duration
 }
 
 func (c *Config) Copy() *Config {
@@ -145,11 +182,22 @@ func (c *Config) Copy() *Config {
 	nc.Servers = structs.CopySliceString(nc.Servers)
 	nc.Options = structs.CopyMapStringString(nc.Options)
 	nc.GloballyReservedPorts = structs.CopySliceInt(c.GloballyReservedPorts)
+	nc.AllocationMetricsLabels = structs.CopySliceString(c.AllocationMetricsLabels)
 	nc.ConsulConfig = c.ConsulConfig.Copy()
 	nc.VaultConfig = c.VaultConfig.Copy()
 	return nc
 }
 
+var (
+	// ValidAllocationMetricsLabels are the labels the keys of allocation
+	// metrics can include
+	ValidAllocationMetricsLabels = []string{"namespace", "job", "task_group", "alloc_id", "task"}
+
+	// DefaultAllocationMetricsLabels are the labels included in the keys of
+	// allocation metrics by default
+	DefaultAllocationMetricsLabels = []string{"job", "task_group", "alloc_id", "task"}
+)
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -159,6 +207,11 @@ func DefaultConfig() *Config {
 		Region:                  "global",
 		StatsCollectionInterval: 1 * time.Second,
 		TLSConfig:               &config.TLSConfig{},
+		AllocationMetricsLabels: DefaultAllocationMetricsLabels,
+		GCInterval:              1 * time.Minute,
+		GCDiskUsageThreshold:    80,
+		GCInodeUsageThreshold:   70,
+		GCMaxAllocs:             50,
 	}
 }
 
@@ -200,6 +253,29 @@ func (c *Config) ReadBoolDefault(id string, defaultValue bool) bool {
 	return val
 }
 
+// ReadDuration parses the specified option as a duration.
+func (c *Config) ReadDuration(id string) (time.Duration, error) {
+	val, ok := c.Options[id]
+	if !ok {
+		return time.Duration(0), fmt.Errorf("Specified config is missing from options")
+	}
+	dval, err := time.ParseDuration(val)
+	if err != nil {
+		return time.Duration(0), fmt.Errorf("Failed to parse %s as time duration: %s", val, err)
+	}
+	return dval, nil
+}
+
+// ReadDurationDefault tries to parse the specified option as a duration. If
+// there is an error in parsing, the default option is returned.
+func (c *Config) ReadDurationDefault(id string, defaultValue time.Duration) time.Duration {
+	val, err := c.ReadDuration(id)
+	if err != nil {
+		return defaultValue
+	}
+	return val
+}
+
 // ReadStringListToMap tries to parse the specified option as a comma separated list.
 // If there is an error in parsing, an empty list is returned.
 func (c *Config) ReadStringListToMap(key string) map[string]struct{} {
@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestConfigRead(t *testing.T) {
 	config := Config{}
@@ -34,3 +37,25 @@ func TestConfigReadDefault(t *testing.T) {
 		t.Errorf("Expected %s, found %s", expected, actual)
 	}
 }
+
+func TestConfigReadDurationDefault(t *testing.T) {
+	config := Config{}
+
+	expected := 3 * time.Minute
+	actual := config.ReadDurationDefault("cake", expected)
+	if actual != expected {
+		t.Errorf("Expected %s, found %s", expected, actual)
+	}
+
+	config.Options = map[string]string{"cake": "10s"}
+	actual = config.ReadDurationDefault("cake", expected)
+	if actual != 10*time.Second {
+		t.Errorf("Expected %s, found %s", 10*time.Second, actual)
+	}
+
+	config.Options = map[string]string{"cake": "chocolate"}
+	actual = config.ReadDurationDefault("cake", expected)
+	if actual != expected {
+		t.Errorf("Expected %s, found %s", expected, actual)
+	}
+}
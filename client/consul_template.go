@@ -384,10 +384,12 @@ func parseTemplateConfigs(tmpls []*structs.Template, taskDir string,
 			dest = filepath.Join(taskDir, taskEnv.ReplaceEnv(tmpl.DestPath))
 		}
 
+		// Only the namespaced variables are interpolated in the embedded
+		// template as it may hold scripts using variables of their own
 		ct := ctconf.ConfigTemplate{
 			Source:           src,
 			Destination:      dest,
-			EmbeddedTemplate: tmpl.EmbeddedTmpl,
+			EmbeddedTemplate: taskEnv.ReplaceNamespaced(tmpl.EmbeddedTmpl),
 			Perms:            ctconf.DefaultFilePerms,
 			Wait:             &watch.Wait{},
 		}
@@ -780,6 +780,39 @@ func TestTaskTemplateManager_Interpolate_Destination(t *testing.T) {
 	}
 }
 
+func TestTaskTemplateManager_Interpolate_Data(t *testing.T) {
+	// Make a template whose embedded data has the node ID interpolated while
+	// the bare variables are left for the script to expand
+	template := &structs.Template{
+		EmbeddedTmpl: "echo ${node.unique.id} ${HOME}",
+		DestPath:     "my.sh",
+		ChangeMode:   structs.TemplateChangeModeNoop,
+	}
+
+	harness := newTestHarness(t, []*structs.Template{template}, false, false)
+	harness.start(t)
+	defer harness.stop()
+
+	// Ensure unblock
+	select {
+	case <-harness.mockHooks.UnblockCh:
+	case <-time.After(time.Duration(5*testutil.TestMultiplier()) * time.Second):
+		t.Fatalf("Task unblock should have been called")
+	}
+
+	// Check the file is there
+	path := filepath.Join(harness.taskDir, "my.sh")
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read rendered template from %q: %v", path, err)
+	}
+
+	expected := fmt.Sprintf("echo %s ${HOME}", harness.node.ID)
+	if s := string(raw); s != expected {
+		t.Fatalf("Unexpected template data; got %q, want %q", s, expected)
+	}
+}
+
 func TestTaskTemplateManager_Signal_Error(t *testing.T) {
 	// Make a template that renders based on a key in Consul and sends SIGALRM
 	key1 := "foo"
@@ -1,8 +1,11 @@
 package driver
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
 	"os"
@@ -69,6 +72,27 @@ const (
 	dockerVolumesConfigOption  = "docker.volumes.enabled"
 	dockerVolumesConfigDefault = true
 
+	// dockerDevicesConfigOption is the key for enabling the passthrough of
+	// host devices to containers.
+	dockerDevicesConfigOption  = "docker.devices.enabled"
+	dockerDevicesConfigDefault = true
+
+	// dockerAuthConfigOption is the key for the dockercfg file holding the
+	// credentials of private registries.
+	dockerAuthConfigOption = "docker.auth.config"
+
+	// dockerAuthHelperConfigOption is the key for the name of the docker
+	// credential helper used to fetch the credentials of private registries.
+	dockerAuthHelperConfigOption = "docker.auth.helper"
+
+	// dockerAuthHelperPrefix is the prefix of the docker credential helper
+	// binaries.
+	dockerAuthHelperPrefix = "docker-credential-"
+
+	// dockerHubRegistry is the registry address passed to credential helpers
+	// for images of the Docker Hub.
+	dockerHubRegistry = "https://index.docker.io/v1/"
+
 	// dockerPrivilegedConfigOption is the key for running containers in
 	// Docker's privileged mode.
 	dockerPrivilegedConfigOption = "docker.privileged.enabled"
@@ -95,6 +119,12 @@ type DockerLoggingOpts struct {
 	Config    map[string]string   `mapstructure:"-"`
 }
 
+// usesSyslogCollector returns whether the logging options send the container's
+// logs to the syslog collector of the task.
+func (l *DockerLoggingOpts) usesSyslogCollector() bool {
+	return l.Type == "syslog" && l.Config["syslog-address"] == ""
+}
+
 type DockerDriverConfig struct {
 	ImageName        string              `mapstructure:"image"`              // Container's Image Name
 	LoadImages       []string            `mapstructure:"load"`               // LoadImage is array of paths to image archive files
@@ -121,6 +151,34 @@ type DockerDriverConfig struct {
 	WorkDir          string              `mapstructure:"work_dir"`           // Working directory inside the container
 	Logging          []DockerLoggingOpts `mapstructure:"logging"`            // Logging options for syslog server
 	Volumes          []string            `mapstructure:"volumes"`            // Host-Volumes to mount in, syntax: /path/to/host/directory:/destination/path/in/container
+	Tmpfs            []string            `mapstructure:"tmpfs"`              // Tmpfs mounts, syntax: /destination/path/in/container[:options]
+	Devices          []DockerDevice      `mapstructure:"devices"`            // Host devices to pass through to the container
+}
+
+// DockerDevice is a host device passed through to the container
+type DockerDevice struct {
+	HostPath          string `mapstructure:"host_path"`
+	ContainerPath     string `mapstructure:"container_path"`
+	CgroupPermissions string `mapstructure:"cgroup_permissions"`
+}
+
+// Validate validates a device and sets its defaults
+func (d *DockerDevice) Validate() error {
+	if d.HostPath == "" {
+		return fmt.Errorf("device host_path must be set")
+	}
+	if d.ContainerPath == "" {
+		d.ContainerPath = d.HostPath
+	}
+	if d.CgroupPermissions == "" {
+		d.CgroupPermissions = "rwm"
+	}
+	for _, c := range d.CgroupPermissions {
+		if c != 'r' && c != 'w' && c != 'm' {
+			return fmt.Errorf("invalid device cgroup_permissions %q: must be a combination of r, w and m", d.CgroupPermissions)
+		}
+	}
+	return nil
 }
 
 // Validate validates a docker driver config
@@ -134,6 +192,16 @@ func (c *DockerDriverConfig) Validate() error {
 	if len(c.Logging) > 0 {
 		c.Logging[0].Config = mapMergeStrStr(c.Logging[0].ConfigRaw...)
 	}
+	for _, mount := range c.Tmpfs {
+		if !filepath.IsAbs(strings.SplitN(mount, ":", 2)[0]) {
+			return fmt.Errorf("invalid docker tmpfs mount %q: path must be absolute", mount)
+		}
+	}
+	for i := range c.Devices {
+		if err := c.Devices[i].Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -159,6 +227,7 @@ func NewDockerDriverConfig(task *structs.Task, env *env.TaskEnvironment) (*Docke
 	dconf.Hostname = env.ReplaceEnv(dconf.Hostname)
 	dconf.WorkDir = env.ReplaceEnv(dconf.WorkDir)
 	dconf.Volumes = env.ParseAndReplace(dconf.Volumes)
+	dconf.Tmpfs = env.ParseAndReplace(dconf.Tmpfs)
 	dconf.DNSServers = env.ParseAndReplace(dconf.DNSServers)
 	dconf.DNSSearchDomains = env.ParseAndReplace(dconf.DNSSearchDomains)
 	dconf.LoadImages = env.ParseAndReplace(dconf.LoadImages)
@@ -194,6 +263,11 @@ func NewDockerDriverConfig(task *structs.Task, env *env.TaskEnvironment) (*Docke
 		}
 	}
 
+	for i, d := range dconf.Devices {
+		dconf.Devices[i].HostPath = env.ReplaceEnv(d.HostPath)
+		dconf.Devices[i].ContainerPath = env.ReplaceEnv(d.ContainerPath)
+	}
+
 	// Remove any http
 	if strings.Contains(dconf.ImageName, "https://") {
 		dconf.ImageName = strings.Replace(dconf.ImageName, "https://", "", 1)
@@ -221,6 +295,8 @@ type DockerHandle struct {
 	waitClient        *docker.Client
 	logger            *log.Logger
 	cleanupImage      bool
+	coordinator       *dockerCoordinator
+	callerID          string
 	imageID           string
 	containerID       string
 	version           string
@@ -312,6 +388,12 @@ func (d *DockerDriver) Validate(config map[string]interface{}) error {
 			"volumes": &fields.FieldSchema{
 				Type: fields.TypeArray,
 			},
+			"tmpfs": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
+			"devices": &fields.FieldSchema{
+				Type: fields.TypeArray,
+			},
 		},
 	}
 
@@ -364,6 +446,11 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle
 	}
 	d.logger.Printf("[DEBUG] driver.docker: identified image %s as %s", image, dockerImage.ID)
 
+	// Reference the image so that it isn't removed while the task uses it
+	coordinator := d.coordinator(client)
+	callerID := dockerImageCallerID(ctx, task.Name)
+	coordinator.IncrementImageReference(dockerImage.ID, callerID)
+
 	bin, err := discover.NomadExecutable()
 	if err != nil {
 		return nil, fmt.Errorf("unable to find the nomad binary: %v", err)
@@ -395,7 +482,7 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle
 	syslogAddr := ""
 	if runtime.GOOS == "darwin" && len(driverConfig.Logging) == 0 {
 		d.logger.Printf("[DEBUG] driver.docker: disabling syslog driver as Docker for Mac workaround")
-	} else if len(driverConfig.Logging) == 0 || driverConfig.Logging[0].Type == "syslog" {
+	} else if len(driverConfig.Logging) == 0 || driverConfig.Logging[0].usesSyslogCollector() {
 		ss, err := exec.LaunchSyslogServer()
 		if err != nil {
 			pluginClient.Kill()
@@ -438,6 +525,8 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle
 		executor:       exec,
 		pluginClient:   pluginClient,
 		cleanupImage:   cleanupImage,
+		coordinator:    coordinator,
+		callerID:       callerID,
 		logger:         d.logger,
 		imageID:        dockerImage.ID,
 		containerID:    container.ID,
@@ -455,6 +544,21 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle
 	return h, nil
 }
 
+// coordinator returns the coordinator shared by the Docker drivers of the
+// client.
+func (d *DockerDriver) coordinator(client *docker.Client) *dockerCoordinator {
+	return GetDockerCoordinator(&dockerCoordinatorConfig{
+		logger:      d.logger,
+		client:      client,
+		removeDelay: d.config.ReadDurationDefault(dockerImageRemoveDelayConfigOption, dockerImageRemoveDelayConfigDefault),
+	})
+}
+
+// dockerImageCallerID returns the ID used to reference the image of a task.
+func dockerImageCallerID(ctx *ExecContext, taskName string) string {
+	return fmt.Sprintf("%s-%s", ctx.AllocID, taskName)
+}
+
 // dockerClients creates two *docker.Client, one for long running operations and
 // the other for shorter operations. In test / dev mode we can use ENV vars to
 // connect to the docker daemon. In production mode we will read docker.endpoint
@@ -563,12 +667,18 @@ func (d *DockerDriver) Fingerprint(cfg *config.Config, node *structs.Node) (bool
 		node.Attributes["driver."+dockerVolumesConfigOption] = "1"
 	}
 
+	// Advertise if this node supports passing through Docker devices
+	if d.config.ReadBoolDefault(dockerDevicesConfigOption, dockerDevicesConfigDefault) {
+		node.Attributes["driver."+dockerDevicesConfigOption] = "1"
+	}
+
 	return true, nil
 }
 
-func (d *DockerDriver) containerBinds(driverConfig *DockerDriverConfig, alloc *allocdir.AllocDir,
+func (d *DockerDriver) containerBinds(driverConfig *DockerDriverConfig, ctx *ExecContext,
 	task *structs.Task) ([]string, error) {
 
+	alloc := ctx.AllocDir
 	shared := alloc.SharedDir
 	taskDir, ok := alloc.TaskDirs[task.Name]
 	if !ok {
@@ -613,6 +723,15 @@ func (d *DockerDriver) containerBinds(driverConfig *DockerDriverConfig, alloc *a
 		binds = append(binds, strings.Join(parts, ":"))
 	}
 
+	// Mount the host volumes requested by the task group
+	for _, m := range task.VolumeMounts {
+		bind, err := d.hostVolumeBind(ctx.Volumes, m)
+		if err != nil {
+			return nil, err
+		}
+		binds = append(binds, bind)
+	}
+
 	if selinuxLabel := d.config.Read(dockerSELinuxLabelConfigOption); selinuxLabel != "" {
 		// Apply SELinux Label to each volume
 		for i := range binds {
@@ -623,6 +742,33 @@ func (d *DockerDriver) containerBinds(driverConfig *DockerDriverConfig, alloc *a
 	return binds, nil
 }
 
+// hostVolumeBind returns the bind of the host volume mounted by the volume
+// mount. The volume is mounted read-only if the volume request, the mount or
+// the host volume is read-only.
+func (d *DockerDriver) hostVolumeBind(volumes map[string]*structs.VolumeRequest, m *structs.VolumeMount) (string, error) {
+	req, ok := volumes[m.Volume]
+	if !ok {
+		return "", fmt.Errorf("volume %q is not requested by the task group", m.Volume)
+	}
+	if req.Type != structs.VolumeTypeHost {
+		return "", fmt.Errorf("volume %q has unsupported type %q", m.Volume, req.Type)
+	}
+
+	var hostVol *structs.ClientHostVolumeConfig
+	if d.node != nil {
+		hostVol = d.node.HostVolumes[req.Source]
+	}
+	if hostVol == nil {
+		return "", fmt.Errorf("host volume %q is not available on this node", req.Source)
+	}
+
+	bind := fmt.Sprintf("%s:%s", hostVol.Path, m.Destination)
+	if req.ReadOnly || m.ReadOnly || hostVol.ReadOnly {
+		bind += ":ro"
+	}
+	return bind, nil
+}
+
 // createContainerConfig initializes a struct needed to call docker.client.CreateContainer()
 func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Task,
 	driverConfig *DockerDriverConfig, syslogAddr string) (docker.CreateContainerOptions, error) {
@@ -634,7 +780,7 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 		return c, fmt.Errorf("task.Resources is empty")
 	}
 
-	binds, err := d.containerBinds(driverConfig, ctx.AllocDir, task)
+	binds, err := d.containerBinds(driverConfig, ctx, task)
 	if err != nil {
 		return c, err
 	}
@@ -678,6 +824,15 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 	}
 
 	if len(driverConfig.Logging) != 0 {
+		// Ship syslog logs to the collector unless an address was given so
+		// they still end up in the allocation's log directory
+		if driverConfig.Logging[0].usesSyslogCollector() && syslogAddr != "" {
+			if driverConfig.Logging[0].Config == nil {
+				driverConfig.Logging[0].Config = make(map[string]string, 1)
+			}
+			driverConfig.Logging[0].Config["syslog-address"] = syslogAddr
+		}
+
 		d.logger.Printf("[DEBUG] driver.docker: Using config for logging: %+v", driverConfig.Logging[0])
 		hostConfig.LogConfig = docker.LogConfig{
 			Type:   driverConfig.Logging[0].Type,
@@ -687,6 +842,10 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 
 	d.logger.Printf("[DEBUG] driver.docker: using %d bytes memory for %s", hostConfig.Memory, task.Name)
 	d.logger.Printf("[DEBUG] driver.docker: using %d cpu shares for %s", hostConfig.CPUShares, task.Name)
+	if len(task.Resources.ReservedCores) != 0 {
+		hostConfig.CPUSetCPUs = structs.FormatCores(task.Resources.ReservedCores)
+		d.logger.Printf("[DEBUG] driver.docker: pinning %s to cores %s", task.Name, hostConfig.CPUSetCPUs)
+	}
 	d.logger.Printf("[DEBUG] driver.docker: binding directories %#v for %s", hostConfig.Binds, task.Name)
 
 	//  set privileged mode
@@ -696,6 +855,33 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 	}
 	hostConfig.Privileged = driverConfig.Privileged
 
+	// set tmpfs mounts
+	if len(driverConfig.Tmpfs) > 0 {
+		hostConfig.Tmpfs = make(map[string]string, len(driverConfig.Tmpfs))
+		for _, mount := range driverConfig.Tmpfs {
+			parts := strings.SplitN(mount, ":", 2)
+			opts := ""
+			if len(parts) == 2 {
+				opts = parts[1]
+			}
+			hostConfig.Tmpfs[parts[0]] = opts
+		}
+	}
+
+	// set the devices passed through from the host
+	if len(driverConfig.Devices) > 0 {
+		if !d.config.ReadBoolDefault(dockerDevicesConfigOption, dockerDevicesConfigDefault) {
+			return c, fmt.Errorf("%s is false; cannot pass through host devices", dockerDevicesConfigOption)
+		}
+		for _, device := range driverConfig.Devices {
+			hostConfig.Devices = append(hostConfig.Devices, docker.Device{
+				PathOnHost:        device.HostPath,
+				PathInContainer:   device.ContainerPath,
+				CgroupPermissions: device.CgroupPermissions,
+			})
+		}
+	}
+
 	// set SHM size
 	if driverConfig.ShmSize != 0 {
 		hostConfig.ShmSize = driverConfig.ShmSize
@@ -721,7 +907,18 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 	hostConfig.UsernsMode = driverConfig.UsernsMode
 
 	hostConfig.NetworkMode = driverConfig.NetworkMode
-	if hostConfig.NetworkMode == "" {
+	if id := networkContainer(ctx); id != "" {
+		// Join the network namespace shared by the tasks of the allocation.
+		// Its ports are mapped by the client.
+		if driverConfig.NetworkMode != "" {
+			return c, fmt.Errorf("network_mode can't be set for tasks of a task group with a bridge network")
+		}
+		if len(task.Resources.Networks) != 0 || len(driverConfig.PortMap) != 0 {
+			return c, fmt.Errorf("ports of tasks of a task group with a bridge network must be requested by the task group")
+		}
+		hostConfig.NetworkMode = fmt.Sprintf("container:%s", id)
+		d.logger.Printf("[DEBUG] driver.docker: joining network namespace of container %s", id)
+	} else if hostConfig.NetworkMode == "" {
 		// docker default
 		d.logger.Printf("[DEBUG] driver.docker: networking mode not specified; defaulting to %s", defaultNetworkMode)
 		hostConfig.NetworkMode = defaultNetworkMode
@@ -751,8 +948,8 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 			hostPortStr := strconv.Itoa(port.Value)
 			containerPort := docker.Port(strconv.Itoa(containerPortInt))
 
-			publishedPorts[containerPort+"/tcp"] = getPortBinding(network.IP, hostPortStr)
-			publishedPorts[containerPort+"/udp"] = getPortBinding(network.IP, hostPortStr)
+			publishedPorts[containerPort+"/tcp"] = portBindings(network, hostPortStr)
+			publishedPorts[containerPort+"/udp"] = portBindings(network, hostPortStr)
 			d.logger.Printf("[DEBUG] driver.docker: allocated port %s:%d -> %d (static)", network.IP, port.Value, port.Value)
 
 			exposedPorts[containerPort+"/tcp"] = struct{}{}
@@ -772,8 +969,8 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 			hostPortStr := strconv.Itoa(port.Value)
 			containerPort := docker.Port(strconv.Itoa(containerPortInt))
 
-			publishedPorts[containerPort+"/tcp"] = getPortBinding(network.IP, hostPortStr)
-			publishedPorts[containerPort+"/udp"] = getPortBinding(network.IP, hostPortStr)
+			publishedPorts[containerPort+"/tcp"] = portBindings(network, hostPortStr)
+			publishedPorts[containerPort+"/udp"] = portBindings(network, hostPortStr)
 			d.logger.Printf("[DEBUG] driver.docker: allocated port %s:%d -> %d (mapped)", network.IP, port.Value, containerPortInt)
 
 			exposedPorts[containerPort+"/tcp"] = struct{}{}
@@ -825,6 +1022,23 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 	}, nil
 }
 
+// portBindings returns the bindings of the host port on the addresses of the
+// network, which has an IPv6 address in addition to its IP if dual-stack.
+func portBindings(network *structs.NetworkResource, hostPort string) []docker.PortBinding {
+	bindings := getPortBinding(network.IP, hostPort)
+	if network.IPv6 == "" {
+		return bindings
+	}
+
+	// Platforms binding all the addresses return the same binding
+	for _, binding := range getPortBinding(network.IPv6, hostPort) {
+		if binding != bindings[0] {
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings
+}
+
 var (
 	// imageNotFoundMatcher is a regex expression that matches the image not
 	// found error Docker returns.
@@ -876,50 +1090,165 @@ func (d *DockerDriver) createImage(driverConfig *DockerDriverConfig, client *doc
 
 // pullImage creates an image by pulling it from a docker registry
 func (d *DockerDriver) pullImage(driverConfig *DockerDriverConfig, client *docker.Client, repo string, tag string) error {
+	authOptions, err := d.resolveRegistryAuthentication(driverConfig)
+	if err != nil {
+		return err
+	}
+
+	// Track the progress of the pull and periodically report it as a task
+	// event since pulling large images may take a long time
+	progress := newImageProgress()
 	pullOptions := docker.PullImageOptions{
-		Repository: repo,
-		Tag:        tag,
+		Repository:    repo,
+		Tag:           tag,
+		OutputStream:  progress,
+		RawJSONStream: true,
+	}
+
+	d.emitEvent("Downloading image %s:%s", repo, tag)
+	stopCh := make(chan struct{})
+	go progress.reportProgress(driverConfig.ImageName, dockerPullProgressEmitInterval, d.emitEvent, stopCh)
+	err = client.PullImage(pullOptions, authOptions)
+	close(stopCh)
+	if err != nil {
+		d.logger.Printf("[ERR] driver.docker: failed pulling container %s:%s: %s", repo, tag, err)
+		return d.recoverablePullError(err, driverConfig.ImageName)
+	}
+	d.logger.Printf("[DEBUG] driver.docker: docker pull %s:%s succeeded", repo, tag)
+	return nil
+}
+
+// resolveRegistryAuthentication returns the credentials used to pull the image
+// of the task. The credentials of the registry in the client's dockercfg file
+// take precedence over the ones of the job. If neither has credentials, they
+// are fetched with the credential helper of the registry in the dockercfg file
+// or else with the credential helper configured on the client.
+func (d *DockerDriver) resolveRegistryAuthentication(driverConfig *DockerDriverConfig) (docker.AuthConfiguration, error) {
+	var helper string
+	if authConfigFile := d.config.Read(dockerAuthConfigOption); authConfigFile != "" {
+		cfg, err := loadDockerConfigFile(authConfigFile)
+		if err != nil {
+			return docker.AuthConfiguration{}, err
+		}
+
+		authConfigurationKey := ""
+		if driverConfig.SSL {
+			authConfigurationKey += "https://"
+		}
+		authConfigurationKey += strings.Split(driverConfig.ImageName, "/")[0]
+		if authConfiguration, ok := cfg.auths[authConfigurationKey]; ok {
+			return authConfiguration, nil
+		}
+
+		helper = cfg.CredsStore
+		if h, ok := cfg.CredHelpers[registryAddress(driverConfig.ImageName)]; ok {
+			helper = h
+		}
 	}
 
-	authOptions := docker.AuthConfiguration{}
 	if len(driverConfig.Auth) != 0 {
-		authOptions = docker.AuthConfiguration{
+		return docker.AuthConfiguration{
 			Username:      driverConfig.Auth[0].Username,
 			Password:      driverConfig.Auth[0].Password,
 			Email:         driverConfig.Auth[0].Email,
 			ServerAddress: driverConfig.Auth[0].ServerAddress,
-		}
+		}, nil
 	}
 
-	if authConfigFile := d.config.Read("docker.auth.config"); authConfigFile != "" {
-		if f, err := os.Open(authConfigFile); err == nil {
-			defer f.Close()
-			var authConfigurations *docker.AuthConfigurations
-			if authConfigurations, err = docker.NewAuthConfigurations(f); err != nil {
-				return fmt.Errorf("Failed to create docker auth object: %v", err)
-			}
+	if helper == "" {
+		helper = d.config.Read(dockerAuthHelperConfigOption)
+	}
+	if helper == "" {
+		return docker.AuthConfiguration{}, nil
+	}
+	return authFromHelper(helper, registryAddress(driverConfig.ImageName))
+}
 
-			authConfigurationKey := ""
-			if driverConfig.SSL {
-				authConfigurationKey += "https://"
-			}
+// dockerConfigFile is the content of a dockercfg file used for authenticating
+// to private registries
+type dockerConfigFile struct {
+	// CredHelpers maps registries to the credential helper to use for them
+	CredHelpers map[string]string `json:"credHelpers"`
 
-			authConfigurationKey += strings.Split(driverConfig.ImageName, "/")[0]
-			if authConfiguration, ok := authConfigurations.Configs[authConfigurationKey]; ok {
-				authOptions = authConfiguration
-			}
-		} else {
-			return fmt.Errorf("Failed to open auth config file: %v, error: %v", authConfigFile, err)
-		}
+	// CredsStore is the credential helper used for all the registries
+	CredsStore string `json:"credsStore"`
+
+	// auths holds the static credentials by registry
+	auths map[string]docker.AuthConfiguration
+}
+
+// loadDockerConfigFile parses the dockercfg file at the given path
+func loadDockerConfigFile(path string) (*dockerConfigFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open auth config file: %v, error: %v", path, err)
 	}
 
-	err := client.PullImage(pullOptions, authOptions)
+	var cfg dockerConfigFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("Failed to parse auth config file %v: %v", path, err)
+	}
+
+	// A file that only configures credential helpers has no static
+	// credentials to parse
+	var auths struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+	json.Unmarshal(data, &auths)
+	if len(auths.Auths) == 0 && (cfg.CredsStore != "" || len(cfg.CredHelpers) != 0) {
+		return &cfg, nil
+	}
+
+	authConfigurations, err := docker.NewAuthConfigurations(bytes.NewReader(data))
 	if err != nil {
-		d.logger.Printf("[ERR] driver.docker: failed pulling container %s:%s: %s", repo, tag, err)
-		return d.recoverablePullError(err, driverConfig.ImageName)
+		return nil, fmt.Errorf("Failed to create docker auth object: %v", err)
 	}
-	d.logger.Printf("[DEBUG] driver.docker: docker pull %s:%s succeeded", repo, tag)
-	return nil
+	cfg.auths = authConfigurations.Configs
+	return &cfg, nil
+}
+
+// registryAddress returns the address of the registry of the image, as
+// expected by credential helpers
+func registryAddress(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 1 || (!strings.ContainsAny(parts[0], ".:") && parts[0] != "localhost") {
+		return dockerHubRegistry
+	}
+	return parts[0]
+}
+
+// authFromHelper fetches the credentials of the registry from the docker
+// credential helper with the given name. The helper binary must be in the
+// PATH of the client. No credentials are returned if the helper has none for
+// the registry.
+func authFromHelper(helperName, registry string) (docker.AuthConfiguration, error) {
+	helper := dockerAuthHelperPrefix + helperName
+	cmd := exec.Command(helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// Helpers report missing credentials on stdout and exit with an error
+		if strings.Contains(stdout.String(), "credentials not found") {
+			return docker.AuthConfiguration{}, nil
+		}
+		return docker.AuthConfiguration{}, fmt.Errorf("docker credential helper %s failed: %v: %s",
+			helper, err, strings.TrimSpace(stderr.String()+stdout.String()))
+	}
+
+	var response struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return docker.AuthConfiguration{}, fmt.Errorf("failed to parse output of docker credential helper %s: %v", helper, err)
+	}
+	return docker.AuthConfiguration{
+		Username:      response.Username,
+		Password:      response.Secret,
+		ServerAddress: registry,
+	}, nil
 }
 
 // loadImage creates an image by loading it from the file system
@@ -1056,6 +1385,11 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 	ver, _ := exec.Version()
 	d.logger.Printf("[DEBUG] driver.docker: version of executor: %v", ver.Version)
 
+	// Reference the image of the container again
+	coordinator := d.coordinator(client)
+	callerID := dockerImageCallerID(ctx, d.DriverContext.taskName)
+	coordinator.IncrementImageReference(pid.ImageID, callerID)
+
 	// Return a driver handle
 	h := &DockerHandle{
 		client:         client,
@@ -1063,6 +1397,8 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 		executor:       exec,
 		pluginClient:   pluginClient,
 		cleanupImage:   cleanupImage,
+		coordinator:    coordinator,
+		callerID:       callerID,
 		logger:         d.logger,
 		imageID:        pid.ImageID,
 		containerID:    pid.ContainerID,
@@ -1153,6 +1489,66 @@ func (h *DockerHandle) Kill() error {
 	return nil
 }
 
+// ExecStreaming runs a command in the container of the task with docker exec.
+func (h *DockerHandle) ExecStreaming(ctx context.Context, opts *ExecStreamingOptions) (int, error) {
+	e, err := h.client.CreateExec(docker.CreateExecOptions{
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          opts.Tty,
+		Cmd:          opts.Command,
+		Container:    h.containerID,
+		Context:      ctx,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create exec: %v", err)
+	}
+
+	// The terminal can only be resized once the exec is started, which is
+	// signalled on the success channel.
+	successCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go func() {
+		select {
+		case <-successCh:
+			successCh <- struct{}{}
+		case <-doneCh:
+			return
+		}
+		for {
+			select {
+			case size := <-opts.ResizeCh:
+				if err := h.client.ResizeExecTTY(e.ID, size.Height, size.Width); err != nil {
+					h.logger.Printf("[DEBUG] driver.docker: failed to resize exec %s: %v", e.ID, err)
+				}
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	err = h.waitClient.StartExec(e.ID, docker.StartExecOptions{
+		InputStream:  opts.Stdin,
+		OutputStream: opts.Stdout,
+		ErrorStream:  opts.Stderr,
+		Tty:          opts.Tty,
+		RawTerminal:  opts.Tty,
+		Success:      successCh,
+		Context:      ctx,
+	})
+	opts.Stdin.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start exec: %v", err)
+	}
+
+	inspect, err := h.client.InspectExec(e.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect exec: %v", err)
+	}
+	return inspect.ExitCode, nil
+}
+
 func (h *DockerHandle) Stats() (*cstructs.TaskResourceUsage, error) {
 	h.resourceUsageLock.RLock()
 	defer h.resourceUsageLock.RUnlock()
@@ -1174,6 +1570,14 @@ func (h *DockerHandle) run() {
 		werr = fmt.Errorf("Docker container exited with non-zero exit code: %d", exitCode)
 	}
 
+	// Check whether the container was OOM killed before it is removed
+	oomKilled := false
+	if container, err := h.waitClient.InspectContainer(h.containerID); err != nil {
+		h.logger.Printf("[DEBUG] driver.docker: failed to inspect container %s: %v", h.containerID, err)
+	} else {
+		oomKilled = container.State.OOMKilled
+	}
+
 	close(h.doneCh)
 
 	// Remove services
@@ -1202,15 +1606,15 @@ func (h *DockerHandle) run() {
 		h.logger.Printf("[ERR] driver.docker: error removing container: %v", err)
 	}
 
-	// Cleanup the image
+	// Cleanup the image once no other task uses it
 	if h.cleanupImage {
-		if err := h.client.RemoveImage(h.imageID); err != nil {
-			h.logger.Printf("[DEBUG] driver.docker: error removing image: %v", err)
-		}
+		h.coordinator.RemoveImage(h.imageID, h.callerID)
+	} else {
+		h.coordinator.ReleaseImageReference(h.imageID, h.callerID)
 	}
 
 	// Send the results
-	h.waitCh <- dstructs.NewWaitResult(exitCode, 0, werr)
+	h.waitCh <- &dstructs.WaitResult{ExitCode: exitCode, Signal: 0, Err: werr, OOMKilled: oomKilled}
 	close(h.waitCh)
 }
 
@@ -0,0 +1,200 @@
+package driver
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+var (
+	// createCoordinator allows us to only create a single coordinator
+	createCoordinator sync.Once
+
+	// globalCoordinator is the shared coordinator and should only be retreived
+	// using the GetDockerCoordinator() method.
+	globalCoordinator *dockerCoordinator
+)
+
+const (
+	// dockerImageRemoveDelayConfigOption is the key for configuring how long
+	// an unused image is retained before it is removed.
+	dockerImageRemoveDelayConfigOption  = "docker.cleanup.image.delay"
+	dockerImageRemoveDelayConfigDefault = 3 * time.Minute
+
+	// dockerImageRemoveRetries is the number of times the removal of an
+	// image is attempted.
+	dockerImageRemoveRetries = 3
+
+	// dockerImageRemoveRetryBackoff is the base backoff between attempts to
+	// remove an image.
+	dockerImageRemoveRetryBackoff = 5 * time.Second
+)
+
+// dockerImageClient is the subset of the docker client used by the
+// coordinator.
+type dockerImageClient interface {
+	RemoveImage(name string) error
+}
+
+// dockerCoordinatorConfig is used to configure the Docker coordinator.
+type dockerCoordinatorConfig struct {
+	// logger is the logger the coordinator should use
+	logger *log.Logger
+
+	// client is the Docker client to use for removing images
+	client dockerImageClient
+
+	// removeDelay is the delay between an image's reference count going to
+	// zero and the image actually being deleted.
+	removeDelay time.Duration
+}
+
+// dockerCoordinator is used to coordinate actions against images across all
+// the Docker drivers of a client. Removing an image is delayed until none of
+// the tasks on the client reference it anymore and the configured retention
+// has passed, so images shared between tasks or reused shortly after are not
+// pulled again.
+type dockerCoordinator struct {
+	*dockerCoordinatorConfig
+
+	// imageLock is used to lock access to all images
+	imageLock sync.Mutex
+
+	// imageRefCount is the reference count of image IDs
+	imageRefCount map[string]map[string]struct{}
+
+	// deleteFuture is indexed by image ID and has a cancable delete future
+	deleteFuture map[string]context.CancelFunc
+}
+
+// NewDockerCoordinator returns a new Docker coordinator
+func NewDockerCoordinator(config *dockerCoordinatorConfig) *dockerCoordinator {
+	return &dockerCoordinator{
+		dockerCoordinatorConfig: config,
+		imageRefCount:           make(map[string]map[string]struct{}),
+		deleteFuture:            make(map[string]context.CancelFunc),
+	}
+}
+
+// GetDockerCoordinator returns the shared dockerCoordinator instance. The
+// configuration of the first caller is used to create it.
+func GetDockerCoordinator(config *dockerCoordinatorConfig) *dockerCoordinator {
+	createCoordinator.Do(func() {
+		globalCoordinator = NewDockerCoordinator(config)
+	})
+
+	return globalCoordinator
+}
+
+// IncrementImageReference is used to increment an image reference count. The
+// callerID is used to deduplicate references from the same task. If the image
+// was scheduled for removal, the removal is cancelled.
+func (d *dockerCoordinator) IncrementImageReference(imageID, callerID string) {
+	d.imageLock.Lock()
+	defer d.imageLock.Unlock()
+
+	references, ok := d.imageRefCount[imageID]
+	if !ok {
+		references = make(map[string]struct{})
+		d.imageRefCount[imageID] = references
+	}
+	references[callerID] = struct{}{}
+
+	// Cancel any pending deletion
+	if cancel, ok := d.deleteFuture[imageID]; ok {
+		d.logger.Printf("[DEBUG] driver.docker: cancelling removal of image %q", imageID)
+		cancel()
+		delete(d.deleteFuture, imageID)
+	}
+}
+
+// ReleaseImageReference drops the reference the caller holds on the image
+// without scheduling the image for removal.
+func (d *dockerCoordinator) ReleaseImageReference(imageID, callerID string) {
+	d.imageLock.Lock()
+	defer d.imageLock.Unlock()
+	d.releaseImageReferenceLocked(imageID, callerID)
+}
+
+// RemoveImage drops the reference the caller holds on the image. Once no
+// references remain, the image is removed after the configured delay unless
+// it is referenced again in the meantime.
+func (d *dockerCoordinator) RemoveImage(imageID, callerID string) {
+	d.imageLock.Lock()
+	defer d.imageLock.Unlock()
+
+	if remaining := d.releaseImageReferenceLocked(imageID, callerID); remaining != 0 {
+		return
+	}
+
+	// Don't schedule the removal twice
+	if _, ok := d.deleteFuture[imageID]; ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go d.removeImageImpl(imageID, ctx)
+	d.deleteFuture[imageID] = cancel
+}
+
+// releaseImageReferenceLocked drops the reference of the caller and returns
+// the number of references remaining. The image lock must be held.
+func (d *dockerCoordinator) releaseImageReferenceLocked(imageID, callerID string) int {
+	references, ok := d.imageRefCount[imageID]
+	if !ok {
+		return 0
+	}
+
+	delete(references, callerID)
+	if len(references) != 0 {
+		d.logger.Printf("[DEBUG] driver.docker: image %q still has %d references", imageID, len(references))
+		return len(references)
+	}
+
+	delete(d.imageRefCount, imageID)
+	return 0
+}
+
+// removeImageImpl removes the image once the removal delay has passed. The
+// removal can be cancelled using the passed context until the delay is over.
+func (d *dockerCoordinator) removeImageImpl(id string, ctx context.Context) {
+	// Wait for the delay or a cancellation event
+	select {
+	case <-ctx.Done():
+		// We have been cancelled
+		return
+	case <-time.After(d.removeDelay):
+	}
+
+	// Cleanup the future from the map so that a later removal of the image
+	// gets scheduled again. The image may have been referenced while we were
+	// acquiring the lock in which case the removal is cancelled.
+	d.imageLock.Lock()
+	if ctx.Err() != nil {
+		d.imageLock.Unlock()
+		return
+	}
+	cancel := d.deleteFuture[id]
+	delete(d.deleteFuture, id)
+	d.imageLock.Unlock()
+	cancel()
+
+	for i := 1; ; i++ {
+		err := d.client.RemoveImage(id)
+		if err == nil || err == docker.ErrNoSuchImage {
+			d.logger.Printf("[DEBUG] driver.docker: cleanup removed image %q", id)
+			return
+		}
+
+		if i == dockerImageRemoveRetries {
+			d.logger.Printf("[ERR] driver.docker: failed to remove image %q: %v", id, err)
+			return
+		}
+
+		d.logger.Printf("[DEBUG] driver.docker: failed to remove image %q (attempt %d): %v", id, i, err)
+		time.Sleep(time.Duration(i) * dockerImageRemoveRetryBackoff)
+	}
+}
@@ -0,0 +1,118 @@
+package driver
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/testutil"
+)
+
+type mockImageClient struct {
+	removed map[string]int
+	lock    sync.Mutex
+}
+
+func newMockImageClient() *mockImageClient {
+	return &mockImageClient{
+		removed: make(map[string]int),
+	}
+}
+
+func (m *mockImageClient) RemoveImage(id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.removed[id]++
+	return nil
+}
+
+func (m *mockImageClient) removedCount(id string) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.removed[id]
+}
+
+func TestDockerCoordinator_RemoveImage(t *testing.T) {
+	mock := newMockImageClient()
+	config := &dockerCoordinatorConfig{
+		logger:      testLogger(),
+		client:      mock,
+		removeDelay: 1 * time.Millisecond,
+	}
+	coordinator := NewDockerCoordinator(config)
+
+	imageID := "foo"
+	callerIDs := []string{"1", "2", "3"}
+	for _, id := range callerIDs {
+		coordinator.IncrementImageReference(imageID, id)
+	}
+	if l := len(coordinator.imageRefCount[imageID]); l != len(callerIDs) {
+		t.Fatalf("bad: %d", l)
+	}
+
+	// Releasing all but one reference must not remove the image
+	for _, id := range callerIDs[1:] {
+		coordinator.RemoveImage(imageID, id)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if c := mock.removedCount(imageID); c != 0 {
+		t.Fatalf("image removed while still referenced: %d", c)
+	}
+
+	coordinator.RemoveImage(imageID, callerIDs[0])
+	testutil.WaitForResult(func() (bool, error) {
+		if c := mock.removedCount(imageID); c != 1 {
+			return false, fmt.Errorf("expected image to be removed once; got %d", c)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestDockerCoordinator_RemoveImage_Cancel(t *testing.T) {
+	mock := newMockImageClient()
+	config := &dockerCoordinatorConfig{
+		logger:      testLogger(),
+		client:      mock,
+		removeDelay: 100 * time.Millisecond,
+	}
+	coordinator := NewDockerCoordinator(config)
+
+	imageID := "foo"
+	coordinator.IncrementImageReference(imageID, "1")
+	coordinator.RemoveImage(imageID, "1")
+
+	// Reusing the image before the delay passes cancels the removal
+	coordinator.IncrementImageReference(imageID, "2")
+	time.Sleep(200 * time.Millisecond)
+	if c := mock.removedCount(imageID); c != 0 {
+		t.Fatalf("image removed while referenced: %d", c)
+	}
+	if _, ok := coordinator.deleteFuture[imageID]; ok {
+		t.Fatalf("removal of image still scheduled")
+	}
+}
+
+func TestDockerCoordinator_ReleaseImageReference(t *testing.T) {
+	mock := newMockImageClient()
+	config := &dockerCoordinatorConfig{
+		logger:      testLogger(),
+		client:      mock,
+		removeDelay: 1 * time.Millisecond,
+	}
+	coordinator := NewDockerCoordinator(config)
+
+	imageID := "foo"
+	coordinator.IncrementImageReference(imageID, "1")
+	coordinator.ReleaseImageReference(imageID, "1")
+	time.Sleep(10 * time.Millisecond)
+
+	if c := mock.removedCount(imageID); c != 0 {
+		t.Fatalf("released image removed: %d", c)
+	}
+	if _, ok := coordinator.imageRefCount[imageID]; ok {
+		t.Fatalf("image still referenced")
+	}
+}
@@ -0,0 +1,114 @@
+package driver
+
+import (
+	"fmt"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// dockerPauseImageConfigOption is the key for configuring the image of
+	// the container holding the network namespace of an allocation.
+	dockerPauseImageConfigOption  = "docker.pause.image"
+	dockerPauseImageConfigDefault = "gcr.io/google_containers/pause-amd64:3.0"
+
+	// dockerNetworkContainerLabel is the label of the network isolation spec
+	// holding the ID of the pause container.
+	dockerNetworkContainerLabel = "docker_sandbox_container_id"
+)
+
+// CreateNetwork creates the network namespace of the allocation by starting a
+// pause container. The tasks of the allocation join the network namespace of
+// the container.
+func (d *DockerDriver) CreateNetwork(allocID string) (*NetworkIsolationSpec, error) {
+	client, _, err := d.dockerClients()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to docker daemon: %s", err)
+	}
+
+	image := d.config.ReadDefault(dockerPauseImageConfigOption, dockerPauseImageConfigDefault)
+	if _, err := client.InspectImage(image); err == docker.ErrNoSuchImage {
+		repo, tag := docker.ParseRepositoryTag(image)
+		if tag == "" {
+			tag = "latest"
+		}
+		d.logger.Printf("[DEBUG] driver.docker: pulling pause image %s", image)
+		pullOptions := docker.PullImageOptions{Repository: repo, Tag: tag}
+		if err := client.PullImage(pullOptions, docker.AuthConfiguration{}); err != nil {
+			return nil, fmt.Errorf("failed to pull pause image %q: %v", image, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to inspect pause image %q: %v", image, err)
+	}
+
+	config := docker.CreateContainerOptions{
+		Name: fmt.Sprintf("nomad_init_%s", allocID),
+		Config: &docker.Config{
+			Image: image,
+		},
+		HostConfig: &docker.HostConfig{
+			// The network namespace is configured by the client
+			NetworkMode: "none",
+		},
+	}
+
+	// A container left behind by a previous attempt is replaced since the
+	// network namespace it holds was never handed to the tasks
+	container, err := client.CreateContainer(config)
+	if err == docker.ErrContainerAlreadyExists {
+		removeOptions := docker.RemoveContainerOptions{ID: config.Name, Force: true}
+		if err := client.RemoveContainer(removeOptions); err != nil {
+			return nil, fmt.Errorf("failed to purge pause container %q: %v", config.Name, err)
+		}
+		container, err = client.CreateContainer(config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pause container: %v", err)
+	}
+
+	if err := client.StartContainer(container.ID, nil); err != nil {
+		return nil, fmt.Errorf("failed to start pause container %s: %v", container.ID, err)
+	}
+
+	container, err = client.InspectContainer(container.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect pause container: %v", err)
+	}
+	d.logger.Printf("[DEBUG] driver.docker: started pause container %s for alloc %q", container.ID, allocID)
+
+	return &NetworkIsolationSpec{
+		Path: fmt.Sprintf("/proc/%d/ns/net", container.State.Pid),
+		Labels: map[string]string{
+			dockerNetworkContainerLabel: container.ID,
+		},
+	}, nil
+}
+
+// DestroyNetwork removes the pause container holding the network namespace of
+// the allocation.
+func (d *DockerDriver) DestroyNetwork(allocID string, spec *NetworkIsolationSpec) error {
+	client, _, err := d.dockerClients()
+	if err != nil {
+		return fmt.Errorf("Failed to connect to docker daemon: %s", err)
+	}
+
+	id := spec.Labels[dockerNetworkContainerLabel]
+	if id == "" {
+		return fmt.Errorf("network of alloc %q has no pause container", allocID)
+	}
+
+	err = client.RemoveContainer(docker.RemoveContainerOptions{ID: id, Force: true})
+	if _, ok := err.(*docker.NoSuchContainer); ok {
+		return nil
+	}
+	return err
+}
+
+// networkContainer returns the ID of the container whose network namespace
+// the tasks of the allocation join, if any.
+func networkContainer(ctx *ExecContext) string {
+	if ctx.Network == nil {
+		return ""
+	}
+	return ctx.Network.Labels[dockerNetworkContainerLabel]
+}
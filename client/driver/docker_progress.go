@@ -0,0 +1,191 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// dockerPullProgressEmitInterval is the interval at which the progress
+	// of an image pull is emitted as a task event.
+	dockerPullProgressEmitInterval = 2 * time.Minute
+)
+
+// layerStatus is the pull status of a single image layer.
+type layerStatus int
+
+const (
+	layerWaiting layerStatus = iota
+	layerDownloading
+	layerExtracting
+	layerComplete
+)
+
+// layerProgress tracks the download progress of a single image layer.
+type layerProgress struct {
+	status       layerStatus
+	currentBytes int64
+	totalBytes   int64
+}
+
+// pullMessage is a message of the JSON stream returned by the Docker daemon
+// while pulling an image.
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	Error          string `json:"error"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+}
+
+// imageProgress tracks the progress of an image pull by consuming the JSON
+// stream of the Docker daemon. It implements io.Writer so that it can be set
+// as the output stream of the pull.
+type imageProgress struct {
+	sync.Mutex
+
+	// start is the time the pull started at
+	start time.Time
+
+	// layers is the progress of the image layers indexed by layer ID
+	layers map[string]*layerProgress
+
+	// buf holds the partial message written so far
+	buf bytes.Buffer
+}
+
+// newImageProgress returns an imageProgress for a pull starting now.
+func newImageProgress() *imageProgress {
+	return &imageProgress{
+		start:  time.Now(),
+		layers: make(map[string]*layerProgress),
+	}
+}
+
+// Write consumes the newline delimited JSON messages of the pull stream.
+func (p *imageProgress) Write(b []byte) (int, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.buf.Write(b)
+	for {
+		line, err := p.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the partial message until the rest of it is written
+			p.buf.Reset()
+			p.buf.Write(line)
+			break
+		}
+
+		var msg pullMessage
+		if err := json.Unmarshal(bytes.TrimSpace(line), &msg); err != nil {
+			continue
+		}
+		p.update(&msg)
+	}
+	return len(b), nil
+}
+
+// update updates the progress of the layer the message is about. The lock
+// must be held.
+func (p *imageProgress) update(msg *pullMessage) {
+	// Messages not about a layer, such as the digest of the image or the
+	// repository being pulled from, are ignored
+	if msg.ID == "" || msg.Error != "" || strings.HasPrefix(msg.Status, "Pulling from") {
+		return
+	}
+
+	layer, ok := p.layers[msg.ID]
+	if !ok {
+		layer = &layerProgress{}
+		p.layers[msg.ID] = layer
+	}
+
+	switch msg.Status {
+	case "Pulling fs layer", "Waiting":
+		layer.status = layerWaiting
+	case "Downloading":
+		layer.status = layerDownloading
+		layer.currentBytes = msg.ProgressDetail.Current
+		layer.totalBytes = msg.ProgressDetail.Total
+	case "Verifying Checksum", "Download complete", "Extracting":
+		layer.status = layerExtracting
+		if layer.totalBytes != 0 {
+			layer.currentBytes = layer.totalBytes
+		}
+	case "Pull complete", "Already exists":
+		layer.status = layerComplete
+		if layer.totalBytes != 0 {
+			layer.currentBytes = layer.totalBytes
+		}
+	}
+}
+
+// String returns a human readable summary of the progress of the pull
+// including an estimate of the time remaining.
+func (p *imageProgress) String() string {
+	p.Lock()
+	defer p.Unlock()
+
+	var waiting, downloading, extracting, complete int
+	var current, total int64
+	for _, layer := range p.layers {
+		switch layer.status {
+		case layerWaiting:
+			waiting++
+		case layerDownloading:
+			downloading++
+		case layerExtracting:
+			extracting++
+		case layerComplete:
+			complete++
+		}
+		current += layer.currentBytes
+		total += layer.totalBytes
+	}
+
+	msg := fmt.Sprintf("Pulled %d/%d (%s/%s) layers: %d waiting/%d pulling",
+		complete, len(p.layers), humanize.Bytes(uint64(current)), humanize.Bytes(uint64(total)),
+		waiting, downloading+extracting)
+
+	if eta, ok := p.estimateRemaining(current, total); ok {
+		msg += fmt.Sprintf(" - est %v remaining", eta)
+	}
+	return msg
+}
+
+// estimateRemaining estimates the time left to download the layers from the
+// download rate so far. It returns false if no estimate can be made yet.
+func (p *imageProgress) estimateRemaining(current, total int64) (time.Duration, bool) {
+	elapsed := time.Since(p.start)
+	if current <= 0 || total <= current || elapsed <= 0 {
+		return 0, false
+	}
+
+	rate := float64(current) / elapsed.Seconds()
+	return time.Duration(float64(total-current)/rate) * time.Second, true
+}
+
+// reportProgress emits the progress of the pull every interval until the
+// stop channel is closed.
+func (p *imageProgress) reportProgress(image string, interval time.Duration, emit LogEventFn, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			emit("Docker image %s pull progress: %s", image, p.String())
+		case <-stopCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImageProgress_Write(t *testing.T) {
+	p := newImageProgress()
+
+	stream := `{"status":"Pulling from library/redis","id":"3.2"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"a"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"b"}
+{"status":"Pulling fs layer","progressDetail":{},"id":"c"}
+{"status":"Downloading","progressDetail":{"current":500,"total":1000},"id":"a"}
+{"status":"Downloading","progressDetail":{"current":100,"total":1000},"id":"b"}
+{"status":"Pull complete","progressDetail":{},"id":"b"}
+`
+	// Write the stream in chunks splitting the messages
+	for len(stream) > 0 {
+		n := 7
+		if n > len(stream) {
+			n = len(stream)
+		}
+		if _, err := p.Write([]byte(stream[:n])); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		stream = stream[n:]
+	}
+
+	if l := len(p.layers); l != 3 {
+		t.Fatalf("bad: %d", l)
+	}
+	if s := p.layers["a"]; s.status != layerDownloading || s.currentBytes != 500 || s.totalBytes != 1000 {
+		t.Fatalf("bad: %#v", s)
+	}
+	if s := p.layers["b"]; s.status != layerComplete || s.currentBytes != 1000 {
+		t.Fatalf("bad: %#v", s)
+	}
+	if s := p.layers["c"]; s.status != layerWaiting {
+		t.Fatalf("bad: %#v", s)
+	}
+
+	// Pretend the pull has been running for a while to estimate the time
+	// remaining
+	p.start = time.Now().Add(-15 * time.Second)
+	out := p.String()
+	if !strings.HasPrefix(out, "Pulled 1/3 (1.5 kB/2.0 kB) layers: 1 waiting/1 pulling") {
+		t.Fatalf("bad: %q", out)
+	}
+	if !strings.HasSuffix(out, "- est 5s remaining") {
+		t.Fatalf("bad: %q", out)
+	}
+}
@@ -67,8 +67,8 @@ func dockerTask() (*structs.Task, int, int) {
 			Networks: []*structs.NetworkResource{
 				&structs.NetworkResource{
 					IP:            "127.0.0.1",
-					ReservedPorts: []structs.Port{{"main", docker_reserved}},
-					DynamicPorts:  []structs.Port{{"REDIS", docker_dynamic}},
+					ReservedPorts: []structs.Port{{"main", docker_reserved, 0}},
+					DynamicPorts:  []structs.Port{{"REDIS", docker_dynamic, 0}},
 				},
 			},
 		},
@@ -1035,7 +1035,11 @@ func setupDockerVolumes(t *testing.T, cfg *config.Config, hostpath string) (*str
 		t.Fatalf("Failed to get task env: %v", err)
 	}
 
-	driverCtx := NewDriverContext(task.Name, cfg, cfg.Node, testLogger(), taskEnv)
+	logger := testLogger()
+	emitter := func(m string, args ...interface{}) {
+		logger.Printf("[EVENT] "+m, args...)
+	}
+	driverCtx := NewDriverContext(task.Name, cfg, cfg.Node, logger, taskEnv, emitter)
 	driver := NewDockerDriver(driverCtx)
 	copyImage(execCtx, task, "busybox.tar", t)
 
@@ -1126,6 +1130,374 @@ func TestDockerDriver_VolumesEnabled(t *testing.T) {
 	}
 }
 
+// This test should always pass, even if docker daemon is not available
+func TestDockerDriver_TmpfsDevices(t *testing.T) {
+	task := &structs.Task{
+		Name: "foo",
+		Config: map[string]interface{}{
+			"image": "busybox",
+			"tmpfs": []string{"/run", "/tmp:rw,size=64m"},
+			"devices": []map[string]interface{}{
+				{"host_path": "/dev/fuse"},
+				{
+					"host_path":          "/dev/sda1",
+					"container_path":     "/dev/xvdc",
+					"cgroup_permissions": "r",
+				},
+			},
+		},
+		Resources: basicResources,
+	}
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	d := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task, d.taskEnv)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	c, err := d.createContainerConfig(execCtx, task, driverConfig, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expectedTmpfs := map[string]string{"/run": "", "/tmp": "rw,size=64m"}
+	if !reflect.DeepEqual(c.HostConfig.Tmpfs, expectedTmpfs) {
+		t.Fatalf("bad: %#v", c.HostConfig.Tmpfs)
+	}
+	expectedDevices := []docker.Device{
+		{PathOnHost: "/dev/fuse", PathInContainer: "/dev/fuse", CgroupPermissions: "rwm"},
+		{PathOnHost: "/dev/sda1", PathInContainer: "/dev/xvdc", CgroupPermissions: "r"},
+	}
+	if !reflect.DeepEqual(c.HostConfig.Devices, expectedDevices) {
+		t.Fatalf("bad: %#v", c.HostConfig.Devices)
+	}
+
+	// Devices can be disabled on the agent
+	driverCtx.config.Options = map[string]string{dockerDevicesConfigOption: "false"}
+	if _, err := d.createContainerConfig(execCtx, task, driverConfig, ""); err == nil ||
+		!strings.Contains(err.Error(), "cannot pass through host devices") {
+		t.Fatalf("expected devices to be disabled: %v", err)
+	}
+}
+
+// This test should always pass, even if docker daemon is not available
+func TestDockerDriver_TmpfsDevices_Invalid(t *testing.T) {
+	cases := []map[string]interface{}{
+		{"image": "busybox", "tmpfs": []string{"relative"}},
+		{"image": "busybox", "devices": []map[string]interface{}{{"container_path": "/dev/fuse"}}},
+		{"image": "busybox", "devices": []map[string]interface{}{{"host_path": "/dev/fuse", "cgroup_permissions": "rx"}}},
+	}
+	for _, config := range cases {
+		task := &structs.Task{Name: "foo", Config: config, Resources: basicResources}
+		driverCtx, execCtx := testDriverContexts(task)
+		d := NewDockerDriver(driverCtx).(*DockerDriver)
+		if _, err := NewDockerDriverConfig(task, d.taskEnv); err == nil {
+			t.Fatalf("expected error for config %#v", config)
+		}
+		execCtx.AllocDir.Destroy()
+	}
+}
+
+// This test should always pass, even if docker daemon is not available
+func TestDockerDriver_LoggingConfig(t *testing.T) {
+	cases := []struct {
+		logging  map[string]interface{}
+		expected docker.LogConfig
+	}{
+		{
+			logging: map[string]interface{}{"type": "syslog"},
+			expected: docker.LogConfig{
+				Type:   "syslog",
+				Config: map[string]string{"syslog-address": "unix:///tmp/syslog.sock"},
+			},
+		},
+		{
+			logging: map[string]interface{}{
+				"type":   "syslog",
+				"config": []map[string]interface{}{{"syslog-address": "udp://1.2.3.4:514"}},
+			},
+			expected: docker.LogConfig{
+				Type:   "syslog",
+				Config: map[string]string{"syslog-address": "udp://1.2.3.4:514"},
+			},
+		},
+		{
+			logging: map[string]interface{}{
+				"type":   "json-file",
+				"config": []map[string]interface{}{{"max-size": "10m", "max-file": "3"}},
+			},
+			expected: docker.LogConfig{
+				Type:   "json-file",
+				Config: map[string]string{"max-size": "10m", "max-file": "3"},
+			},
+		},
+	}
+	for _, c := range cases {
+		task := &structs.Task{
+			Name: "foo",
+			Config: map[string]interface{}{
+				"image":   "busybox",
+				"logging": []map[string]interface{}{c.logging},
+			},
+			Resources: basicResources,
+		}
+		driverCtx, execCtx := testDriverContexts(task)
+		d := NewDockerDriver(driverCtx).(*DockerDriver)
+
+		driverConfig, err := NewDockerDriverConfig(task, d.taskEnv)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		config, err := d.createContainerConfig(execCtx, task, driverConfig, "unix:///tmp/syslog.sock")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !reflect.DeepEqual(config.HostConfig.LogConfig, c.expected) {
+			t.Fatalf("bad: %#v", config.HostConfig.LogConfig)
+		}
+		execCtx.AllocDir.Destroy()
+	}
+}
+
+func TestDockerDriver_HostVolumes(t *testing.T) {
+	task := &structs.Task{
+		Name: "foo",
+		Config: map[string]interface{}{
+			"image": "busybox",
+		},
+		Resources: basicResources,
+		VolumeMounts: []*structs.VolumeMount{
+			&structs.VolumeMount{Volume: "certs", Destination: "/etc/ssl/certs"},
+			&structs.VolumeMount{Volume: "data", Destination: "/data", ReadOnly: true},
+		},
+	}
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	driverCtx.node = &structs.Node{
+		HostVolumes: map[string]*structs.ClientHostVolumeConfig{
+			"ca-certs": &structs.ClientHostVolumeConfig{Name: "ca-certs", Path: "/etc/ssl/certs"},
+			"data":     &structs.ClientHostVolumeConfig{Name: "data", Path: "/srv/data"},
+		},
+	}
+	execCtx.Volumes = map[string]*structs.VolumeRequest{
+		"certs": &structs.VolumeRequest{Name: "certs", Type: "host", Source: "ca-certs"},
+		"data":  &structs.VolumeRequest{Name: "data", Type: "host", Source: "data"},
+	}
+	d := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task, d.taskEnv)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	config, err := d.createContainerConfig(execCtx, task, driverConfig, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	binds := config.HostConfig.Binds
+	expected := []string{"/etc/ssl/certs:/etc/ssl/certs", "/srv/data:/data:ro"}
+	if len(binds) < 2 || !reflect.DeepEqual(binds[len(binds)-2:], expected) {
+		t.Fatalf("bad: %#v", binds)
+	}
+
+	// Mounting a volume the node doesn't have fails
+	delete(driverCtx.node.HostVolumes, "data")
+	if _, err := d.createContainerConfig(execCtx, task, driverConfig, ""); err == nil {
+		t.Fatalf("expected error for missing host volume")
+	}
+}
+
+func TestDockerDriver_GroupNetwork(t *testing.T) {
+	task := &structs.Task{
+		Name: "foo",
+		Config: map[string]interface{}{
+			"image": "busybox",
+		},
+		Resources: &structs.Resources{
+			CPU:      250,
+			MemoryMB: 256,
+		},
+	}
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	execCtx.Network = &NetworkIsolationSpec{
+		Path:   "/proc/1/ns/net",
+		Labels: map[string]string{dockerNetworkContainerLabel: "abc123"},
+	}
+	d := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task, d.taskEnv)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	config, err := d.createContainerConfig(execCtx, task, driverConfig, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The task joins the network namespace of the pause container
+	if mode := config.HostConfig.NetworkMode; mode != "container:abc123" {
+		t.Fatalf("bad: %q", mode)
+	}
+	if len(config.HostConfig.PortBindings) != 0 {
+		t.Fatalf("bad: %#v", config.HostConfig.PortBindings)
+	}
+
+	// Tasks can't publish ports of their own
+	task.Resources = basicResources
+	if _, err := d.createContainerConfig(execCtx, task, driverConfig, ""); err == nil {
+		t.Fatalf("expected error for task ports")
+	}
+}
+
+func TestDockerDriver_DualStackPorts(t *testing.T) {
+	task := &structs.Task{
+		Name: "foo",
+		Config: map[string]interface{}{
+			"image": "busybox",
+		},
+		Resources: &structs.Resources{
+			CPU:      250,
+			MemoryMB: 256,
+			Networks: []*structs.NetworkResource{
+				&structs.NetworkResource{
+					AddressFamily: structs.NetworkAddressFamilyDual,
+					IP:            "127.0.0.1",
+					IPv6:          "::1",
+					ReservedPorts: []structs.Port{{Label: "main", Value: 8080}},
+				},
+			},
+		},
+	}
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	d := NewDockerDriver(driverCtx).(*DockerDriver)
+
+	driverConfig, err := NewDockerDriverConfig(task, d.taskEnv)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	config, err := d.createContainerConfig(execCtx, task, driverConfig, "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The port is published on both addresses
+	expected := []docker.PortBinding{
+		{HostIP: "127.0.0.1", HostPort: "8080"},
+		{HostIP: "::1", HostPort: "8080"},
+	}
+	if bindings := config.HostConfig.PortBindings["8080/tcp"]; !reflect.DeepEqual(bindings, expected) {
+		t.Fatalf("bad: %#v", bindings)
+	}
+}
+
+func TestDockerDriver_RegistryAddress(t *testing.T) {
+	cases := map[string]string{
+		"redis":                          dockerHubRegistry,
+		"library/redis:3.2":              dockerHubRegistry,
+		"localhost/redis":                "localhost",
+		"registry.local:5000/team/redis": "registry.local:5000",
+		"123.dkr.ecr.us-east-1.amazonaws.com/redis": "123.dkr.ecr.us-east-1.amazonaws.com",
+	}
+	for image, expected := range cases {
+		if actual := registryAddress(image); actual != expected {
+			t.Fatalf("bad registry for %q: got %q; want %q", image, actual, expected)
+		}
+	}
+}
+
+// This test should always pass, even if docker daemon is not available
+func TestDockerDriver_ResolveRegistryAuthentication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomadtest_docker_auth")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Fake credential helpers that only know the ECR registry
+	helper := `#!/bin/sh
+read registry
+if [ "$registry" = "123.dkr.ecr.us-east-1.amazonaws.com" ]; then
+  echo '{"ServerURL":"'$registry'","Username":"AWS","Secret":"%s"}'
+else
+  echo "credentials not found in native keychain"
+  exit 1
+fi
+`
+	for name, secret := range map[string]string{"ecr-login": "token", "store": "stored"} {
+		path := filepath.Join(dir, dockerAuthHelperPrefix+name)
+		if err := ioutil.WriteFile(path, []byte(fmt.Sprintf(helper, secret)), 0755); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+
+	dockercfg := filepath.Join(dir, "config.json")
+	config := `{
+  "auths": {"https://registry.local": {"auth": "dXNlcjpwYXNz"}},
+  "credHelpers": {"123.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"}
+}`
+	if err := ioutil.WriteFile(dockercfg, []byte(config), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	task := &structs.Task{Name: "foo", Resources: basicResources}
+	driverCtx, execCtx := testDriverContexts(task)
+	defer execCtx.AllocDir.Destroy()
+	d := NewDockerDriver(driverCtx).(*DockerDriver)
+	driverCtx.config.Options = map[string]string{
+		dockerAuthConfigOption:       dockercfg,
+		dockerAuthHelperConfigOption: "store",
+	}
+
+	jobAuth := []DockerDriverAuth{{Username: "job", Password: "secret"}}
+	cases := []struct {
+		driverConfig *DockerDriverConfig
+		username     string
+		password     string
+	}{
+		// Static credentials of the dockercfg file
+		{&DockerDriverConfig{ImageName: "registry.local/redis", SSL: true, Auth: jobAuth}, "user", "pass"},
+
+		// Credentials of the job
+		{&DockerDriverConfig{ImageName: "other.local/redis", SSL: true, Auth: jobAuth}, "job", "secret"},
+
+		// Credential helper of the registry in the dockercfg file
+		{&DockerDriverConfig{ImageName: "123.dkr.ecr.us-east-1.amazonaws.com/redis", SSL: true}, "AWS", "token"},
+
+		// Credential helper of the client without credentials
+		{&DockerDriverConfig{ImageName: "redis", SSL: true}, "", ""},
+	}
+	for _, c := range cases {
+		auth, err := d.resolveRegistryAuthentication(c.driverConfig)
+		if err != nil {
+			t.Fatalf("err for %q: %v", c.driverConfig.ImageName, err)
+		}
+		if auth.Username != c.username || auth.Password != c.password {
+			t.Fatalf("bad auth for %q: %#v", c.driverConfig.ImageName, auth)
+		}
+	}
+
+	// The client's credential helper is used without a dockercfg file
+	driverCtx.config.Options = map[string]string{dockerAuthHelperConfigOption: "store"}
+	auth, err := d.resolveRegistryAuthentication(&DockerDriverConfig{ImageName: "123.dkr.ecr.us-east-1.amazonaws.com/redis"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if auth.Username != "AWS" || auth.Password != "stored" {
+		t.Fatalf("bad: %#v", auth)
+	}
+
+	// A missing helper is an error
+	driverCtx.config.Options = map[string]string{dockerAuthHelperConfigOption: "nope"}
+	if _, err := d.resolveRegistryAuthentication(&DockerDriverConfig{ImageName: "redis"}); err == nil {
+		t.Fatalf("expected error with missing credential helper")
+	}
+}
+
 func copyImage(execCtx *ExecContext, task *structs.Task, image string, t *testing.T) {
 	taskDir, _ := execCtx.AllocDir.TaskDirs[task.Name]
 	dst := filepath.Join(taskDir, allocdir.TaskLocal, image)
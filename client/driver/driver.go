@@ -1,7 +1,9 @@
 package driver
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -64,12 +66,36 @@ type Driver interface {
 	Abilities() DriverAbilities
 }
 
+// DriverNetworkManager is implemented by drivers that can create the network
+// namespace shared by the tasks of an allocation whose task group requests a
+// bridge network.
+type DriverNetworkManager interface {
+	// CreateNetwork creates the network namespace of the allocation
+	CreateNetwork(allocID string) (*NetworkIsolationSpec, error)
+
+	// DestroyNetwork destroys the network namespace of the allocation
+	DestroyNetwork(allocID string, spec *NetworkIsolationSpec) error
+}
+
+// NetworkIsolationSpec describes the network namespace of an allocation.
+type NetworkIsolationSpec struct {
+	// Path is the path of the network namespace
+	Path string
+
+	// Labels are driver specific labels identifying the network namespace,
+	// such as the container holding it.
+	Labels map[string]string
+}
+
 // DriverAbilities marks the abilities the driver has.
 type DriverAbilities struct {
 	// SendSignals marks the driver as being able to send signals
 	SendSignals bool
 }
 
+// LogEventFn is a callback which allows Drivers to emit task events.
+type LogEventFn func(message string, args ...interface{})
+
 // DriverContext is a means to inject dependencies such as loggers, configs, and
 // node attributes into a Driver without having to change the Driver interface
 // each time we do it. Used in conjection with Factory, above.
@@ -79,6 +105,8 @@ type DriverContext struct {
 	logger   *log.Logger
 	node     *structs.Node
 	taskEnv  *env.TaskEnvironment
+
+	emitEvent LogEventFn
 }
 
 // NewEmptyDriverContext returns a DriverContext with all fields set to their
@@ -98,13 +126,14 @@ func NewEmptyDriverContext() *DriverContext {
 // private to the driver. If we want to change this later we can gorename all of
 // the fields in DriverContext.
 func NewDriverContext(taskName string, config *config.Config, node *structs.Node,
-	logger *log.Logger, taskEnv *env.TaskEnvironment) *DriverContext {
+	logger *log.Logger, taskEnv *env.TaskEnvironment, eventEmitter LogEventFn) *DriverContext {
 	return &DriverContext{
-		taskName: taskName,
-		config:   config,
-		node:     node,
-		logger:   logger,
-		taskEnv:  taskEnv,
+		taskName:  taskName,
+		config:    config,
+		node:      node,
+		logger:    logger,
+		taskEnv:   taskEnv,
+		emitEvent: eventEmitter,
 	}
 }
 
@@ -131,6 +160,32 @@ type DriverHandle interface {
 	Signal(s os.Signal) error
 }
 
+// ExecStreamingHandle is implemented by the handles of drivers that can run an
+// interactive command inside the isolation context of their task.
+type ExecStreamingHandle interface {
+	// ExecStreaming runs the command until it exits or the context is
+	// cancelled and returns its exit code.
+	ExecStreaming(ctx context.Context, opts *ExecStreamingOptions) (int, error)
+}
+
+// ExecStreamingOptions are the command and the standard streams of a command
+// executed in a task.
+type ExecStreamingOptions struct {
+	// Command is the command and its arguments
+	Command []string
+
+	// Tty runs the command in a terminal whose output is written to Stdout
+	Tty bool
+
+	// Stdin is closed once the command exits
+	Stdin  io.ReadCloser
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// ResizeCh receives the size of the terminal when it changes
+	ResizeCh <-chan cstructs.TerminalSize
+}
+
 // ExecContext is shared between drivers within an allocation
 type ExecContext struct {
 	// AllocDir contains information about the alloc directory structure.
@@ -138,6 +193,13 @@ type ExecContext struct {
 
 	// Alloc ID
 	AllocID string
+
+	// Volumes are the volumes requested by the task group of the alloc
+	Volumes map[string]*structs.VolumeRequest
+
+	// Network is the network namespace shared by the tasks of the alloc if
+	// its task group requests a bridge network
+	Network *NetworkIsolationSpec
 }
 
 // NewExecContext is used to create a new execution context
@@ -156,9 +218,15 @@ func GetTaskEnv(allocDir *allocdir.AllocDir, node *structs.Node,
 		SetTaskGroupMeta(tg.Meta).
 		SetJobMeta(alloc.Job.Meta).
 		SetJobName(alloc.Job.Name).
+		SetTaskGroupName(alloc.TaskGroup).
+		SetRegion(alloc.Job.Region).
 		SetEnvvars(task.Env).
 		SetTaskName(task.Name)
 
+	if node != nil {
+		env.SetDatacenter(node.Datacenter)
+	}
+
 	if allocDir != nil {
 		env.SetAllocDir(allocDir.SharedDir)
 		taskdir, ok := allocDir.TaskDirs[task.Name]
@@ -173,11 +241,16 @@ func GetTaskEnv(allocDir *allocdir.AllocDir, node *structs.Node,
 	if task.Resources != nil {
 		env.SetMemLimit(task.Resources.MemoryMB).
 			SetCpuLimit(task.Resources.CPU).
-			SetNetworks(task.Resources.Networks)
+			SetCpuCores(task.Resources.ReservedCores).
+			SetNetworks(task.Resources.Networks).
+			SetDevices(task.Resources.Devices)
 	}
 
 	if alloc != nil {
 		env.SetAlloc(alloc)
+		if alloc.SharedResources != nil {
+			env.SetGroupNetworks(alloc.SharedResources.Networks)
+		}
 	}
 
 	if task.Vault != nil {
@@ -24,8 +24,8 @@ var basicResources = &structs.Resources{
 	Networks: []*structs.NetworkResource{
 		&structs.NetworkResource{
 			IP:            "0.0.0.0",
-			ReservedPorts: []structs.Port{{"main", 12345}},
-			DynamicPorts:  []structs.Port{{"HTTP", 43330}},
+			ReservedPorts: []structs.Port{{"main", 12345, 0}},
+			DynamicPorts:  []structs.Port{{"HTTP", 43330, 0}},
 		},
 	},
 }
@@ -89,7 +89,11 @@ func testDriverContexts(task *structs.Task) (*DriverContext, *ExecContext) {
 		return nil, nil
 	}
 
-	driverCtx := NewDriverContext(task.Name, cfg, cfg.Node, testLogger(), taskEnv)
+	logger := testLogger()
+	emitter := func(m string, args ...interface{}) {
+		logger.Printf("[EVENT] "+m, args...)
+	}
+	driverCtx := NewDriverContext(task.Name, cfg, cfg.Node, logger, taskEnv, emitter)
 	return driverCtx, execCtx
 }
 
@@ -106,8 +110,8 @@ func TestDriver_GetTaskEnv(t *testing.T) {
 			Networks: []*structs.NetworkResource{
 				&structs.NetworkResource{
 					IP:            "1.2.3.4",
-					ReservedPorts: []structs.Port{{"one", 80}, {"two", 443}},
-					DynamicPorts:  []structs.Port{{"admin", 8081}, {"web", 8086}},
+					ReservedPorts: []structs.Port{{"one", 80, 0}, {"two", 443, 0}},
+					DynamicPorts:  []structs.Port{{"admin", 8081, 0}, {"web", 8086, 0}},
 				},
 			},
 		},
@@ -119,7 +123,7 @@ func TestDriver_GetTaskEnv(t *testing.T) {
 
 	alloc := mock.Alloc()
 	alloc.Name = "Bar"
-	env, err := GetTaskEnv(nil, nil, task, alloc, "")
+	env, err := GetTaskEnv(nil, mock.Node(), task, alloc, "")
 	if err != nil {
 		t.Fatalf("GetTaskEnv() failed: %v", err)
 	}
@@ -153,7 +157,10 @@ func TestDriver_GetTaskEnv(t *testing.T) {
 		"NOMAD_ALLOC_ID":                alloc.ID,
 		"NOMAD_ALLOC_NAME":              alloc.Name,
 		"NOMAD_TASK_NAME":               task.Name,
+		"NOMAD_GROUP_NAME":              alloc.TaskGroup,
 		"NOMAD_JOB_NAME":                alloc.Job.Name,
+		"NOMAD_DC":                      "dc1",
+		"NOMAD_REGION":                  "global",
 	}
 
 	act := env.EnvMap()
@@ -2,6 +2,7 @@ package env
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -31,6 +32,10 @@ const (
 	// CpuLimit is the environment variable with the tasks CPU limit in MHz.
 	CpuLimit = "NOMAD_CPU_LIMIT"
 
+	// CpuCores is the environment variable with the cores reserved for the
+	// task, in the list format of the cpuset cgroup.
+	CpuCores = "NOMAD_CPU_CORES"
+
 	// AllocID is the environment variable for passing the allocation ID.
 	AllocID = "NOMAD_ALLOC_ID"
 
@@ -40,9 +45,20 @@ const (
 	// TaskName is the environment variable for passing the task name.
 	TaskName = "NOMAD_TASK_NAME"
 
+	// GroupName is the environment variable for passing the task group name.
+	GroupName = "NOMAD_GROUP_NAME"
+
 	// JobName is the environment variable for passing the job name.
 	JobName = "NOMAD_JOB_NAME"
 
+	// Datacenter is the environment variable for passing the datacenter in
+	// which the alloc is running.
+	Datacenter = "NOMAD_DC"
+
+	// Region is the environment variable for passing the region in which the
+	// alloc is running.
+	Region = "NOMAD_REGION"
+
 	// AllocIndex is the environment variable for passing the allocation index.
 	AllocIndex = "NOMAD_ALLOC_INDEX"
 
@@ -54,6 +70,15 @@ const (
 	// IpPrefix is the prefix for passing the IP of a port allocation to a task.
 	IpPrefix = "NOMAD_IP_"
 
+	// Addr6Prefix is the prefix for passing the IPv6 address and port of a
+	// dual-stack port allocation to a task.
+	// E.g$NOMAD_ADDR6_http=[2001:db8::1]:80
+	Addr6Prefix = "NOMAD_ADDR6_"
+
+	// Ip6Prefix is the prefix for passing the IPv6 address of a dual-stack
+	// port allocation to a task.
+	Ip6Prefix = "NOMAD_IP6_"
+
 	// PortPrefix is the prefix for passing the port allocation to a task.
 	PortPrefix = "NOMAD_PORT_"
 
@@ -66,6 +91,14 @@ const (
 
 	// VaultToken is the environment variable for passing the Vault token
 	VaultToken = "VAULT_TOKEN"
+
+	// NvidiaVisibleDevices is the environment variable used by the NVIDIA
+	// container runtime to expose the GPUs assigned to the task.
+	NvidiaVisibleDevices = "NVIDIA_VISIBLE_DEVICES"
+
+	// CudaVisibleDevices is the environment variable used by CUDA to restrict
+	// the task to the GPUs assigned to it.
+	CudaVisibleDevices = "CUDA_VISIBLE_DEVICES"
 )
 
 // The node values that can be interpreted.
@@ -78,6 +111,7 @@ const (
 	// Prefixes used for lookups.
 	nodeAttributePrefix = "attr."
 	nodeMetaPrefix      = "meta."
+	envPrefix           = "env."
 )
 
 // TaskEnvironment is used to expose information to a task via environment
@@ -91,17 +125,23 @@ type TaskEnvironment struct {
 	TaskDir          string
 	SecretsDir       string
 	CpuLimit         int
+	CpuCores         []int
 	MemLimit         int
 	TaskName         string
+	TaskGroupName    string
 	AllocIndex       int
 	AllocId          string
 	AllocName        string
 	Node             *structs.Node
 	Networks         []*structs.NetworkResource
+	GroupNetworks    []*structs.NetworkResource
+	Devices          []*structs.DeviceResource
 	PortMap          map[string]int
 	VaultToken       string
 	InjectVaultToken bool
 	JobName          string
+	Datacenter       string
+	Region           string
 
 	// taskEnv is the variables that will be set in the tasks environment
 	TaskEnv map[string]string
@@ -109,6 +149,10 @@ type TaskEnvironment struct {
 	// nodeValues is the values that are allowed for interprolation from the
 	// node.
 	NodeValues map[string]string
+
+	// EnvValues is the variables of the tasks environment namespaced for
+	// interpolation, such as ${env.NOMAD_ALLOC_ID}.
+	EnvValues map[string]string
 }
 
 func NewTaskEnvironment(node *structs.Node) *TaskEnvironment {
@@ -120,7 +164,7 @@ func NewTaskEnvironment(node *structs.Node) *TaskEnvironment {
 func (t *TaskEnvironment) ParseAndReplace(args []string) []string {
 	replaced := make([]string, len(args))
 	for i, arg := range args {
-		replaced[i] = hargs.ReplaceEnv(arg, t.TaskEnv, t.NodeValues)
+		replaced[i] = hargs.ReplaceEnv(arg, t.TaskEnv, t.NodeValues, t.EnvValues)
 	}
 
 	return replaced
@@ -130,7 +174,15 @@ func (t *TaskEnvironment) ParseAndReplace(args []string) []string {
 // and nomad variables.  If the variable is found in the passed map it is
 // replaced, otherwise the original string is returned.
 func (t *TaskEnvironment) ReplaceEnv(arg string) string {
-	return hargs.ReplaceEnv(arg, t.TaskEnv, t.NodeValues)
+	return hargs.ReplaceEnv(arg, t.TaskEnv, t.NodeValues, t.EnvValues)
+}
+
+// ReplaceNamespaced takes an arg and replaces only the occurrences of nomad
+// variables, which are namespaced such as ${attr.kernel.name} or
+// ${env.NOMAD_TASK_NAME}. Bare variables such as ${HOME} are left as is, making
+// it suitable for content that may use them itself, like scripts.
+func (t *TaskEnvironment) ReplaceNamespaced(arg string) string {
+	return hargs.ReplaceEnv(arg, t.NodeValues, t.EnvValues)
 }
 
 // Build must be called after all the tasks environment values have been set.
@@ -145,6 +197,19 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 		}
 	}
 
+	// Build the ports of the network shared by the task group. In bridge
+	// mode, the task listens on the port the host port is mapped to.
+	for _, network := range t.GroupNetworks {
+		ports := network.MapLabelToValues(nil)
+		mapped := network.PortMap()
+		for label, value := range ports {
+			t.TaskEnv[fmt.Sprintf("%s%s", IpPrefix, label)] = network.IP
+			t.TaskEnv[fmt.Sprintf("%s%s", HostPortPrefix, label)] = strconv.Itoa(value)
+			t.TaskEnv[fmt.Sprintf("%s%s", PortPrefix, label)] = strconv.Itoa(mapped[label])
+			t.TaskEnv[fmt.Sprintf("%s%s", AddrPrefix, label)] = net.JoinHostPort(network.IP, strconv.Itoa(mapped[label]))
+		}
+	}
+
 	// Build the ports
 	for _, network := range t.Networks {
 		for label, value := range network.MapLabelToValues(nil) {
@@ -154,12 +219,30 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 				value = forwardedPort
 			}
 			t.TaskEnv[fmt.Sprintf("%s%s", PortPrefix, label)] = fmt.Sprintf("%d", value)
-			IPPort := fmt.Sprintf("%s:%d", network.IP, value)
+			IPPort := net.JoinHostPort(network.IP, strconv.Itoa(value))
 			t.TaskEnv[fmt.Sprintf("%s%s", AddrPrefix, label)] = IPPort
 
+			// Dual-stack networks also listen on an IPv6 address
+			if network.IPv6 != "" {
+				t.TaskEnv[fmt.Sprintf("%s%s", Ip6Prefix, label)] = network.IPv6
+				t.TaskEnv[fmt.Sprintf("%s%s", Addr6Prefix, label)] = net.JoinHostPort(network.IPv6, strconv.Itoa(value))
+			}
+
 		}
 	}
 
+	// Build the GPUs
+	var gpus []string
+	for _, device := range t.Devices {
+		if device.Vendor() == "nvidia" && device.Type() == "gpu" {
+			gpus = append(gpus, device.IDs...)
+		}
+	}
+	if len(gpus) != 0 {
+		t.TaskEnv[NvidiaVisibleDevices] = strings.Join(gpus, ",")
+		t.TaskEnv[CudaVisibleDevices] = strings.Join(gpus, ",")
+	}
+
 	// Build the directories
 	if t.AllocDir != "" {
 		t.TaskEnv[AllocDir] = t.AllocDir
@@ -178,6 +261,9 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 	if t.CpuLimit != 0 {
 		t.TaskEnv[CpuLimit] = strconv.Itoa(t.CpuLimit)
 	}
+	if len(t.CpuCores) != 0 {
+		t.TaskEnv[CpuCores] = structs.FormatCores(t.CpuCores)
+	}
 
 	// Build the tasks ids
 	if t.AllocId != "" {
@@ -192,10 +278,21 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 	if t.TaskName != "" {
 		t.TaskEnv[TaskName] = t.TaskName
 	}
+	if t.TaskGroupName != "" {
+		t.TaskEnv[GroupName] = t.TaskGroupName
+	}
 	if t.JobName != "" {
 		t.TaskEnv[JobName] = t.JobName
 	}
 
+	// Build the location of the alloc
+	if t.Datacenter != "" {
+		t.TaskEnv[Datacenter] = t.Datacenter
+	}
+	if t.Region != "" {
+		t.TaskEnv[Region] = t.Region
+	}
+
 	// Build the node
 	if t.Node != nil {
 		// Set up the node values.
@@ -230,6 +327,12 @@ func (t *TaskEnvironment) Build() *TaskEnvironment {
 		t.TaskEnv[k] = v
 	}
 
+	// Namespace the environment for interpolation
+	t.EnvValues = make(map[string]string, len(t.TaskEnv))
+	for k, v := range t.TaskEnv {
+		t.EnvValues[fmt.Sprintf("%s%s", envPrefix, k)] = v
+	}
+
 	return t
 }
 
@@ -304,6 +407,11 @@ func (t *TaskEnvironment) ClearCpuLimit() *TaskEnvironment {
 	return t
 }
 
+func (t *TaskEnvironment) SetCpuCores(cores []int) *TaskEnvironment {
+	t.CpuCores = cores
+	return t
+}
+
 func (t *TaskEnvironment) SetNetworks(networks []*structs.NetworkResource) *TaskEnvironment {
 	t.Networks = networks
 	return t
@@ -314,6 +422,17 @@ func (t *TaskEnvironment) clearNetworks() *TaskEnvironment {
 	return t
 }
 
+// SetGroupNetworks sets the networks shared by the tasks of the task group.
+func (t *TaskEnvironment) SetGroupNetworks(networks []*structs.NetworkResource) *TaskEnvironment {
+	t.GroupNetworks = networks
+	return t
+}
+
+func (t *TaskEnvironment) SetDevices(devices []*structs.DeviceResource) *TaskEnvironment {
+	t.Devices = devices
+	return t
+}
+
 func (t *TaskEnvironment) SetPortMap(portMap map[string]int) *TaskEnvironment {
 	t.PortMap = portMap
 	return t
@@ -474,6 +593,36 @@ func (t *TaskEnvironment) ClearJobName() *TaskEnvironment {
 	return t
 }
 
+func (t *TaskEnvironment) SetTaskGroupName(name string) *TaskEnvironment {
+	t.TaskGroupName = name
+	return t
+}
+
+func (t *TaskEnvironment) ClearTaskGroupName() *TaskEnvironment {
+	t.TaskGroupName = ""
+	return t
+}
+
+func (t *TaskEnvironment) SetDatacenter(dc string) *TaskEnvironment {
+	t.Datacenter = dc
+	return t
+}
+
+func (t *TaskEnvironment) ClearDatacenter() *TaskEnvironment {
+	t.Datacenter = ""
+	return t
+}
+
+func (t *TaskEnvironment) SetRegion(region string) *TaskEnvironment {
+	t.Region = region
+	return t
+}
+
+func (t *TaskEnvironment) ClearRegion() *TaskEnvironment {
+	t.Region = ""
+	return t
+}
+
 func (t *TaskEnvironment) SetVaultToken(token string, inject bool) *TaskEnvironment {
 	t.VaultToken = token
 	t.InjectVaultToken = inject
@@ -33,8 +33,8 @@ var (
 	networks = []*structs.NetworkResource{
 		&structs.NetworkResource{
 			IP:            "127.0.0.1",
-			ReservedPorts: []structs.Port{{"http", 80}},
-			DynamicPorts:  []structs.Port{{"https", 8080}},
+			ReservedPorts: []structs.Port{{"http", 80, 0}},
+			DynamicPorts:  []structs.Port{{"https", 8080, 0}},
 		},
 	}
 	portMap = map[string]int{
@@ -105,6 +105,17 @@ func TestEnvironment_ParseAndReplace_Node(t *testing.T) {
 	}
 }
 
+func TestEnvironment_ParseAndReplace_EnvNamespace(t *testing.T) {
+	input := []string{fmt.Sprintf("${%v%v}", envPrefix, envOneKey)}
+	exp := []string{envOneVal}
+	env := testTaskEnvironment()
+	act := env.ParseAndReplace(input)
+
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("ParseAndReplace(%v) returned %#v; want %#v", input, act, exp)
+	}
+}
+
 func TestEnvironment_ParseAndReplace_Mixed(t *testing.T) {
 	input := []string{
 		fmt.Sprintf("${%v}${%v%v}", nodeNameKey, nodeAttributePrefix, attrKey),
@@ -135,6 +146,17 @@ func TestEnvironment_ReplaceEnv_Mixed(t *testing.T) {
 	}
 }
 
+func TestEnvironment_ReplaceNamespaced(t *testing.T) {
+	input := fmt.Sprintf("${%v}${%v%v}${%v%v}${%v}", nodeNameKey, nodeAttributePrefix, attrKey, envPrefix, envOneKey, envTwoKey)
+	exp := fmt.Sprintf("%v%v%v${%v}", nodeName, attrVal, envOneVal, envTwoKey)
+	env := testTaskEnvironment()
+	act := env.ReplaceNamespaced(input)
+
+	if act != exp {
+		t.Fatalf("ReplaceNamespaced(%v) returned %#v; want %#v", input, act, exp)
+	}
+}
+
 func TestEnvironment_AsList(t *testing.T) {
 	n := mock.Node()
 	env := NewTaskEnvironment(n).
@@ -180,6 +202,125 @@ func TestEnvironment_VaultToken(t *testing.T) {
 	}
 }
 
+func TestEnvironment_Devices(t *testing.T) {
+	n := mock.Node()
+	devices := []*structs.DeviceResource{
+		{Name: "nvidia/gpu/Tesla K80", Count: 2, IDs: []string{"GPU-1", "GPU-2"}},
+		{Name: "xilinx/fpga/VU9P", Count: 1, IDs: []string{"fpga-1"}},
+	}
+	env := NewTaskEnvironment(n).SetDevices(devices).Build()
+
+	act := env.EnvList()
+	sort.Strings(act)
+	exp := []string{"CUDA_VISIBLE_DEVICES=GPU-1,GPU-2", "NVIDIA_VISIBLE_DEVICES=GPU-1,GPU-2"}
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("env.List() returned %v; want %v", act, exp)
+	}
+}
+
+func TestEnvironment_CpuCores(t *testing.T) {
+	n := mock.Node()
+	env := NewTaskEnvironment(n).SetCpuCores([]int{3, 1}).Build()
+
+	act := env.EnvList()
+	exp := []string{"NOMAD_CPU_CORES=1,3"}
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("env.List() returned %v; want %v", act, exp)
+	}
+}
+
+func TestEnvironment_GroupNetworks(t *testing.T) {
+	n := mock.Node()
+	groupNetworks := []*structs.NetworkResource{
+		&structs.NetworkResource{
+			Mode:          structs.NetworkModeBridge,
+			IP:            "127.0.0.1",
+			ReservedPorts: []structs.Port{{Label: "admin", Value: 9000}},
+			DynamicPorts:  []structs.Port{{Label: "web", Value: 25000, To: 8080}},
+		},
+	}
+	env := NewTaskEnvironment(n).SetGroupNetworks(groupNetworks).Build()
+
+	act := env.EnvList()
+	exp := []string{
+		"NOMAD_ADDR_admin=127.0.0.1:9000",
+		"NOMAD_PORT_admin=9000",
+		"NOMAD_IP_admin=127.0.0.1",
+		"NOMAD_HOST_PORT_admin=9000",
+		"NOMAD_ADDR_web=127.0.0.1:8080",
+		"NOMAD_PORT_web=8080",
+		"NOMAD_IP_web=127.0.0.1",
+		"NOMAD_HOST_PORT_web=25000",
+	}
+	sort.Strings(act)
+	sort.Strings(exp)
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("env.List() returned %v; want %v", act, exp)
+	}
+}
+
+func TestEnvironment_IPv6Networks(t *testing.T) {
+	n := mock.Node()
+	ipv6Networks := []*structs.NetworkResource{
+		&structs.NetworkResource{
+			AddressFamily: structs.NetworkAddressFamilyIPv6,
+			IP:            "2001:db8::1",
+			ReservedPorts: []structs.Port{{Label: "admin", Value: 9000}},
+		},
+		&structs.NetworkResource{
+			AddressFamily: structs.NetworkAddressFamilyDual,
+			IP:            "127.0.0.1",
+			IPv6:          "2001:db8::2",
+			DynamicPorts:  []structs.Port{{Label: "web", Value: 25000}},
+		},
+	}
+	env := NewTaskEnvironment(n).SetNetworks(ipv6Networks).Build()
+
+	act := env.EnvList()
+	exp := []string{
+		"NOMAD_ADDR_admin=[2001:db8::1]:9000",
+		"NOMAD_PORT_admin=9000",
+		"NOMAD_IP_admin=2001:db8::1",
+		"NOMAD_HOST_PORT_admin=9000",
+		"NOMAD_ADDR_web=127.0.0.1:25000",
+		"NOMAD_PORT_web=25000",
+		"NOMAD_IP_web=127.0.0.1",
+		"NOMAD_HOST_PORT_web=25000",
+		"NOMAD_ADDR6_web=[2001:db8::2]:25000",
+		"NOMAD_IP6_web=2001:db8::2",
+	}
+	sort.Strings(act)
+	sort.Strings(exp)
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("env.List() returned %v; want %v", act, exp)
+	}
+}
+
+func TestEnvironment_Location(t *testing.T) {
+	n := mock.Node()
+	env := NewTaskEnvironment(n).
+		SetTaskGroupName("web").
+		SetDatacenter(n.Datacenter).
+		SetRegion("global").Build()
+
+	act := env.EnvList()
+	exp := []string{
+		"NOMAD_GROUP_NAME=web",
+		"NOMAD_DC=dc1",
+		"NOMAD_REGION=global",
+	}
+	sort.Strings(act)
+	sort.Strings(exp)
+	if !reflect.DeepEqual(act, exp) {
+		t.Fatalf("env.List() returned %v; want %v", act, exp)
+	}
+
+	env = env.ClearTaskGroupName().ClearDatacenter().ClearRegion().Build()
+	if act := env.EnvList(); len(act) != 0 {
+		t.Fatalf("Unexpected environment variables: %v", act)
+	}
+}
+
 func TestEnvironment_ClearEnvvars(t *testing.T) {
 	n := mock.Node()
 	env := NewTaskEnvironment(n).
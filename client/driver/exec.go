@@ -1,12 +1,15 @@
 package driver
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -46,6 +49,7 @@ type execHandle struct {
 	executor        executor.Executor
 	isolationConfig *dstructs.IsolationConfig
 	userPid         int
+	user            string
 	allocDir        *allocdir.AllocDir
 	killTimeout     time.Duration
 	maxKillTimeout  time.Duration
@@ -161,6 +165,7 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 	h := &execHandle{
 		pluginClient:    pluginClient,
 		userPid:         ps.Pid,
+		user:            execCmd.User,
 		executor:        exec,
 		allocDir:        ctx.AllocDir,
 		isolationConfig: ps.IsolationConfig,
@@ -183,6 +188,7 @@ type execId struct {
 	KillTimeout     time.Duration
 	MaxKillTimeout  time.Duration
 	UserPid         int
+	User            string
 	TaskDir         string
 	AllocDir        *allocdir.AllocDir
 	IsolationConfig *dstructs.IsolationConfig
@@ -225,6 +231,7 @@ func (d *ExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, erro
 		pluginClient:    client,
 		executor:        exec,
 		userPid:         id.UserPid,
+		user:            id.User,
 		allocDir:        id.AllocDir,
 		isolationConfig: id.IsolationConfig,
 		logger:          d.logger,
@@ -248,6 +255,7 @@ func (h *execHandle) ID() string {
 		MaxKillTimeout:  h.maxKillTimeout,
 		PluginConfig:    NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
 		UserPid:         h.userPid,
+		User:            h.user,
 		AllocDir:        h.allocDir,
 		IsolationConfig: h.isolationConfig,
 	}
@@ -303,6 +311,33 @@ func (h *execHandle) Stats() (*cstructs.TaskResourceUsage, error) {
 	return h.executor.Stats()
 }
 
+// ExecStreaming runs a command in the chroot of the task, as the user of the
+// task, by entering the context of the task process with nsenter.
+func (h *execHandle) ExecStreaming(ctx context.Context, opts *ExecStreamingOptions) (int, error) {
+	nsenter, err := exec.LookPath("nsenter")
+	if err != nil {
+		return 0, fmt.Errorf("nsenter is required to exec in tasks: %v", err)
+	}
+	u, err := user.Lookup(h.user)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up user %q: %v", h.user, err)
+	}
+
+	args := []string{
+		"--target", strconv.Itoa(h.userPid),
+		"--root", "--wd",
+		"--setuid", u.Uid,
+		"--setgid", u.Gid,
+		"--",
+	}
+	cmd := exec.CommandContext(ctx, nsenter, append(args, opts.Command...)...)
+	cmd.Env = []string{"PATH=/usr/local/bin:/usr/bin:/bin", "HOME=" + u.HomeDir}
+	if opts.Tty {
+		cmd.Env = append(cmd.Env, "TERM=xterm")
+	}
+	return runExecStreaming(cmd, opts)
+}
+
 func (h *execHandle) run() {
 	ps, werr := h.executor.Wait()
 	close(h.doneCh)
@@ -336,6 +371,6 @@ func (h *execHandle) run() {
 	h.pluginClient.Kill()
 
 	// Send the results
-	h.waitCh <- dstructs.NewWaitResult(ps.ExitCode, ps.Signal, werr)
+	h.waitCh <- &dstructs.WaitResult{ExitCode: ps.ExitCode, Signal: ps.Signal, Err: werr, OOMKilled: ps.OOMKilled}
 	close(h.waitCh)
 }
@@ -0,0 +1,55 @@
+package driver
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"syscall"
+)
+
+// runExecStreaming runs the command with the standard streams of the options,
+// through a pseudo terminal if one is requested, and returns its exit code.
+func runExecStreaming(cmd *exec.Cmd, opts *ExecStreamingOptions) (int, error) {
+	if opts.Tty {
+		return runExecStreamingTTY(cmd, opts)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return 0, err
+	}
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	go func() {
+		io.Copy(stdin, opts.Stdin)
+		stdin.Close()
+	}()
+
+	err = cmd.Wait()
+	opts.Stdin.Close()
+	return execExitCode(err)
+}
+
+// execExitCode returns the exit code of a command from the error returned
+// waiting for it. Commands killed by a signal exit with 128 plus the signal
+// as in shells.
+func execExitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return 0, err
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	if !ok {
+		return 0, fmt.Errorf("unknown exit status: %v", err)
+	}
+	if status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return status.ExitStatus(), nil
+}
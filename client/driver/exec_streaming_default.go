@@ -0,0 +1,12 @@
+// +build !linux
+
+package driver
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func runExecStreamingTTY(cmd *exec.Cmd, opts *ExecStreamingOptions) (int, error) {
+	return 0, fmt.Errorf("terminals are not supported on this platform")
+}
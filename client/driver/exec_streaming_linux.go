@@ -0,0 +1,100 @@
+package driver
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+)
+
+// runExecStreamingTTY runs the command in a new session whose controlling
+// terminal is a pseudo terminal relayed to the streams of the options.
+func runExecStreamingTTY(cmd *exec.Cmd, opts *ExecStreamingOptions) (int, error) {
+	master, slave, err := openPty()
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate a terminal: %v", err)
+	}
+	defer master.Close()
+
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	err = cmd.Start()
+	slave.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	// Resize the terminal until the command exits
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go func() {
+		for {
+			select {
+			case size := <-opts.ResizeCh:
+				setPtySize(master, size)
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	// The output is read until every process holding the terminal exits
+	outputCh := make(chan struct{})
+	go func() {
+		io.Copy(opts.Stdout, master)
+		close(outputCh)
+	}()
+	go io.Copy(master, opts.Stdin)
+
+	err = cmd.Wait()
+	<-outputCh
+	opts.Stdin.Close()
+	return execExitCode(err)
+}
+
+// openPty allocates a pseudo terminal and returns its master and slave sides.
+func openPty() (*os.File, *os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unlock int32
+	if err := ptyIoctl(master, syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	var n uint32
+	if err := ptyIoctl(master, syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		master.Close()
+		return nil, nil, err
+	}
+	return master, slave, nil
+}
+
+// setPtySize sets the size of the pseudo terminal
+func setPtySize(f *os.File, size cstructs.TerminalSize) error {
+	ws := struct {
+		Row, Col, X, Y uint16
+	}{Row: uint16(size.Height), Col: uint16(size.Width)}
+	return ptyIoctl(f, syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(&ws)))
+}
+
+func ptyIoctl(f *os.File, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
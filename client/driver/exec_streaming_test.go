@@ -0,0 +1,48 @@
+package driver
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunExecStreaming(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	opts := &ExecStreamingOptions{
+		Stdin:  ioutil.NopCloser(strings.NewReader("foo")),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	cmd := exec.Command("/bin/sh", "-c", "cat; echo bar >&2; exit 3")
+	code, err := runExecStreaming(cmd, opts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if code != 3 || stdout.String() != "foo" || stderr.String() != "bar\n" {
+		t.Fatalf("bad: %d %q %q", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunExecStreaming_TTY(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("terminals are only supported on linux")
+	}
+
+	var stdout bytes.Buffer
+	opts := &ExecStreamingOptions{
+		Tty:    true,
+		Stdin:  ioutil.NopCloser(strings.NewReader("")),
+		Stdout: &stdout,
+	}
+	cmd := exec.Command("/bin/sh", "-c", "test -t 0 && echo tty")
+	code, err := runExecStreaming(cmd, opts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if code != 0 || !strings.Contains(stdout.String(), "tty") {
+		t.Fatalf("bad: %d %q", code, stdout.String())
+	}
+}
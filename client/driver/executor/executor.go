@@ -145,6 +145,7 @@ type ProcessState struct {
 	Pid             int
 	ExitCode        int
 	Signal          int
+	OOMKilled       bool
 	IsolationConfig *dstructs.IsolationConfig
 	Time            time.Time
 }
@@ -439,7 +440,14 @@ func (e *UniversalExecutor) wait() {
 		e.logger.Printf("[DEBUG] executor: unexpected Wait() error type: %v", err)
 	}
 
-	e.exitState = &ProcessState{Pid: 0, ExitCode: exitCode, Signal: signal, IsolationConfig: ic, Time: time.Now()}
+	e.exitState = &ProcessState{
+		Pid:             0,
+		ExitCode:        exitCode,
+		Signal:          signal,
+		OOMKilled:       e.resConCtx.oomKilled(),
+		IsolationConfig: ic,
+		Time:            time.Now(),
+	}
 }
 
 var (
@@ -116,6 +116,11 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	// Set the relative CPU shares for this cgroup.
 	e.resConCtx.groups.Resources.CpuShares = int64(resources.CPU)
 
+	// Pin the task to its reserved cores
+	if len(resources.ReservedCores) != 0 {
+		e.resConCtx.groups.Resources.CpusetCpus = structs.FormatCores(resources.ReservedCores)
+	}
+
 	if resources.IOPS != 0 {
 		// Validate it is in an acceptable range.
 		if resources.IOPS < 10 || resources.IOPS > 1000 {
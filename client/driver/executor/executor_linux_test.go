@@ -114,3 +114,31 @@ ld.so.conf.d/`
 		t.Fatalf("Command output incorrectly: want %v; got %v", expected, act)
 	}
 }
+
+func TestResourceContainer_OOMKilled(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	rc := &resourceContainerContext{cgPaths: map[string]string{"memory": dir}}
+	if rc.oomKilled() {
+		t.Fatalf("oom killed without oom_control")
+	}
+
+	path := filepath.Join(dir, "memory.oom_control")
+	if err := ioutil.WriteFile(path, []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 0\n"), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if rc.oomKilled() {
+		t.Fatalf("oom killed without kills")
+	}
+
+	if err := ioutil.WriteFile(path, []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 1\n"), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !rc.oomKilled() {
+		t.Fatalf("expected oom killed")
+	}
+}
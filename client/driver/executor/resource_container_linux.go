@@ -1,7 +1,11 @@
 package executor
 
 import (
+	"bufio"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 
 	dstructs "github.com/hashicorp/nomad/client/driver/structs"
@@ -40,3 +44,30 @@ func (rc *resourceContainerContext) getIsolationConfig() *dstructs.IsolationConf
 		CgroupPaths: rc.cgPaths,
 	}
 }
+
+// oomKilled returns whether a process of the memory cgroup was killed for
+// exceeding the memory limit.
+func (rc *resourceContainerContext) oomKilled() bool {
+	rc.cgLock.Lock()
+	defer rc.cgLock.Unlock()
+	path, ok := rc.cgPaths["memory"]
+	if !ok {
+		return false
+	}
+
+	f, err := os.Open(filepath.Join(path, "memory.oom_control"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, err := strconv.Atoi(fields[1])
+			return err == nil && count > 0
+		}
+	}
+	return false
+}
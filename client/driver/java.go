@@ -42,9 +42,11 @@ type JavaDriver struct {
 }
 
 type JavaDriverConfig struct {
-	JarPath string   `mapstructure:"jar_path"`
-	JvmOpts []string `mapstructure:"jvm_options"`
-	Args    []string `mapstructure:"args"`
+	Class     string   `mapstructure:"class"`
+	ClassPath string   `mapstructure:"class_path"`
+	JarPath   string   `mapstructure:"jar_path"`
+	JvmOpts   []string `mapstructure:"jvm_options"`
+	Args      []string `mapstructure:"args"`
 }
 
 // javaHandle is returned from Start/Open as a handle to the PID
@@ -74,9 +76,14 @@ func (d *JavaDriver) Validate(config map[string]interface{}) error {
 	fd := &fields.FieldData{
 		Raw: config,
 		Schema: map[string]*fields.FieldSchema{
+			"class": &fields.FieldSchema{
+				Type: fields.TypeString,
+			},
+			"class_path": &fields.FieldSchema{
+				Type: fields.TypeString,
+			},
 			"jar_path": &fields.FieldSchema{
-				Type:     fields.TypeString,
-				Required: true,
+				Type: fields.TypeString,
 			},
 			"jvm_options": &fields.FieldSchema{
 				Type: fields.TypeArray,
@@ -91,9 +98,32 @@ func (d *JavaDriver) Validate(config map[string]interface{}) error {
 		return err
 	}
 
+	if fd.Get("jar_path").(string) == "" && fd.Get("class").(string) == "" {
+		return fmt.Errorf("jar_path or class must be specified")
+	}
+
 	return nil
 }
 
+// javaCmdArgs returns the arguments to invoke java with. A jar takes
+// precedence over a class as the entry point of the task.
+func javaCmdArgs(driverConfig *JavaDriverConfig) []string {
+	args := []string{}
+	args = append(args, driverConfig.JvmOpts...)
+
+	if driverConfig.ClassPath != "" {
+		args = append(args, "-cp", driverConfig.ClassPath)
+	}
+
+	if driverConfig.JarPath != "" {
+		args = append(args, "-jar", driverConfig.JarPath)
+	} else {
+		args = append(args, driverConfig.Class)
+	}
+
+	return append(args, driverConfig.Args...)
+}
+
 func (d *JavaDriver) Abilities() DriverAbilities {
 	return DriverAbilities{
 		SendSignals: true,
@@ -178,22 +208,17 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		return nil, fmt.Errorf("Could not find task directory for task: %v", d.DriverContext.taskName)
 	}
 
-	if driverConfig.JarPath == "" {
-		return nil, fmt.Errorf("jar_path must be specified")
+	if driverConfig.JarPath == "" && driverConfig.Class == "" {
+		return nil, fmt.Errorf("jar_path or class must be specified")
 	}
 
-	args := []string{}
 	// Look for jvm options
 	if len(driverConfig.JvmOpts) != 0 {
 		d.logger.Printf("[DEBUG] driver.java: found JVM options: %s", driverConfig.JvmOpts)
-		args = append(args, driverConfig.JvmOpts...)
 	}
 
 	// Build the argument list.
-	args = append(args, "-jar", driverConfig.JarPath)
-	if len(driverConfig.Args) != 0 {
-		args = append(args, driverConfig.Args...)
-	}
+	args := javaCmdArgs(&driverConfig)
 
 	bin, err := discover.NomadExecutable()
 	if err != nil {
@@ -432,6 +457,6 @@ func (h *javaHandle) run() {
 	h.pluginClient.Kill()
 
 	// Send the results
-	h.waitCh <- &dstructs.WaitResult{ExitCode: ps.ExitCode, Signal: ps.Signal, Err: werr}
+	h.waitCh <- &dstructs.WaitResult{ExitCode: ps.ExitCode, Signal: ps.Signal, Err: werr, OOMKilled: ps.OOMKilled}
 	close(h.waitCh)
 }
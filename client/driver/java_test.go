@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"syscall"
@@ -65,6 +66,52 @@ func TestJavaDriver_Fingerprint(t *testing.T) {
 	}
 }
 
+func TestJavaDriver_Validate(t *testing.T) {
+	d := &JavaDriver{}
+	valid := []map[string]interface{}{
+		{"jar_path": "demoapp.jar"},
+		{"class": "Hello", "class_path": "local"},
+	}
+	for _, config := range valid {
+		if err := d.Validate(config); err != nil {
+			t.Fatalf("err for %#v: %v", config, err)
+		}
+	}
+
+	if err := d.Validate(map[string]interface{}{"args": []string{"foo"}}); err == nil {
+		t.Fatalf("expected error without jar_path or class")
+	}
+}
+
+func TestJavaDriver_CmdArgs(t *testing.T) {
+	cases := []struct {
+		config   *JavaDriverConfig
+		expected []string
+	}{
+		{
+			config: &JavaDriverConfig{
+				JarPath: "demoapp.jar",
+				JvmOpts: []string{"-Xmx64m"},
+				Args:    []string{"1"},
+			},
+			expected: []string{"-Xmx64m", "-jar", "demoapp.jar", "1"},
+		},
+		{
+			config: &JavaDriverConfig{
+				Class:     "Hello",
+				ClassPath: "local:lib/dep.jar",
+				Args:      []string{"world"},
+			},
+			expected: []string{"-cp", "local:lib/dep.jar", "Hello", "world"},
+		},
+	}
+	for _, c := range cases {
+		if actual := javaCmdArgs(c.config); !reflect.DeepEqual(actual, c.expected) {
+			t.Fatalf("bad: %#v", actual)
+		}
+	}
+}
+
 func TestJavaDriver_StartOpen_Wait(t *testing.T) {
 	if !javaLocated() {
 		t.Skip("Java not found; skipping")
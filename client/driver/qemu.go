@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -33,6 +34,18 @@ const (
 	// The key populated in Node Attributes to indicate presence of the Qemu
 	// driver
 	qemuDriverAttr = "driver.qemu"
+
+	// qemuMonitorSocketName is the name of the monitor socket created in the
+	// task directory when graceful shutdown is enabled
+	qemuMonitorSocketName = "qemu-monitor.sock"
+
+	// qemuGracefulShutdownMsg is the command sent to the monitor socket to ask
+	// the guest to power down
+	qemuGracefulShutdownMsg = "system_powerdown\n"
+
+	// qemuMaxMonitorPathLen is the longest path of a unix socket the monitor
+	// can be bound to
+	qemuMaxMonitorPathLen = 107
 )
 
 // QemuDriver is a driver for running images via Qemu
@@ -44,10 +57,11 @@ type QemuDriver struct {
 }
 
 type QemuDriverConfig struct {
-	ImagePath   string           `mapstructure:"image_path"`
-	Accelerator string           `mapstructure:"accelerator"`
-	PortMap     []map[string]int `mapstructure:"port_map"` // A map of host port labels and to guest ports.
-	Args        []string         `mapstructure:"args"`     // extra arguments to qemu executable
+	ImagePath        string           `mapstructure:"image_path"`
+	Accelerator      string           `mapstructure:"accelerator"`
+	GracefulShutdown bool             `mapstructure:"graceful_shutdown"` // Power down the guest via the monitor socket on kill
+	PortMap          []map[string]int `mapstructure:"port_map"`          // A map of host port labels and to guest ports.
+	Args             []string         `mapstructure:"args"`              // extra arguments to qemu executable
 }
 
 // qemuHandle is returned from Start/Open as a handle to the PID
@@ -56,6 +70,7 @@ type qemuHandle struct {
 	userPid        int
 	executor       executor.Executor
 	allocDir       *allocdir.AllocDir
+	monitorPath    string
 	killTimeout    time.Duration
 	maxKillTimeout time.Duration
 	logger         *log.Logger
@@ -81,6 +96,9 @@ func (d *QemuDriver) Validate(config map[string]interface{}) error {
 			"accelerator": &fields.FieldSchema{
 				Type: fields.TypeString,
 			},
+			"graceful_shutdown": &fields.FieldSchema{
+				Type: fields.TypeBool,
+			},
 			"port_map": &fields.FieldSchema{
 				Type: fields.TypeArray,
 			},
@@ -183,6 +201,18 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		"-nographic",
 	}
 
+	// Create a monitor socket in the task directory which is used to power
+	// down the guest when the task is killed
+	monitorPath := ""
+	if driverConfig.GracefulShutdown {
+		monitorPath = filepath.Join(taskDir, qemuMonitorSocketName)
+		if len(monitorPath) > qemuMaxMonitorPathLen {
+			return nil, fmt.Errorf("monitor socket path %q exceeds the maximum length of %d; can't enable graceful_shutdown",
+				monitorPath, qemuMaxMonitorPathLen)
+		}
+		args = append(args, "-monitor", fmt.Sprintf("unix:%s,server,nowait", monitorPath))
+	}
+
 	// Add pass through arguments to qemu executable. A user can specify
 	// these arguments in driver task configuration. These arguments are
 	// passed directly to the qemu driver as command line options.
@@ -278,6 +308,7 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (DriverHandle,
 		executor:       exec,
 		userPid:        ps.Pid,
 		allocDir:       ctx.AllocDir,
+		monitorPath:    monitorPath,
 		killTimeout:    GetKillTimeout(task.KillTimeout, maxKill),
 		maxKillTimeout: maxKill,
 		version:        d.config.Version,
@@ -300,6 +331,7 @@ type qemuId struct {
 	UserPid        int
 	PluginConfig   *PluginReattachConfig
 	AllocDir       *allocdir.AllocDir
+	MonitorPath    string
 }
 
 func (d *QemuDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
@@ -329,6 +361,7 @@ func (d *QemuDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, erro
 		executor:       exec,
 		userPid:        id.UserPid,
 		allocDir:       id.AllocDir,
+		monitorPath:    id.MonitorPath,
 		logger:         d.logger,
 		killTimeout:    id.KillTimeout,
 		maxKillTimeout: id.MaxKillTimeout,
@@ -351,6 +384,7 @@ func (h *qemuHandle) ID() string {
 		PluginConfig:   NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
 		UserPid:        h.userPid,
 		AllocDir:       h.allocDir,
+		MonitorPath:    h.monitorPath,
 	}
 
 	data, err := json.Marshal(id)
@@ -377,14 +411,27 @@ func (h *qemuHandle) Signal(s os.Signal) error {
 	return fmt.Errorf("Qemu driver can't send signals")
 }
 
-// TODO: allow a 'shutdown_command' that can be executed over a ssh connection
-// to the VM
+// Kill powers down the guest via the monitor socket if graceful shutdown is
+// enabled and otherwise shuts down the executor. If the VM is still running
+// after the kill timeout it is forcefully killed.
 func (h *qemuHandle) Kill() error {
-	if err := h.executor.ShutDown(); err != nil {
-		if h.pluginClient.Exited() {
-			return nil
+	gracefulShutdownSent := false
+	if h.monitorPath != "" {
+		if err := sendQemuShutdown(h.monitorPath); err != nil {
+			h.logger.Printf("[WARN] driver.qemu: failed to send graceful shutdown to the guest: %v", err)
+		} else {
+			h.logger.Printf("[DEBUG] driver.qemu: sent graceful shutdown to the guest via %s", h.monitorPath)
+			gracefulShutdownSent = true
+		}
+	}
+
+	if !gracefulShutdownSent {
+		if err := h.executor.ShutDown(); err != nil {
+			if h.pluginClient.Exited() {
+				return nil
+			}
+			return fmt.Errorf("executor Shutdown failed: %v", err)
 		}
-		return fmt.Errorf("executor Shutdown failed: %v", err)
 	}
 
 	select {
@@ -402,6 +449,21 @@ func (h *qemuHandle) Kill() error {
 	}
 }
 
+// sendQemuShutdown asks the guest to power down by sending the
+// system_powerdown command to the monitor socket of the VM.
+func sendQemuShutdown(monitorPath string) error {
+	conn, err := net.DialTimeout("unix", monitorPath, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to monitor socket %q: %v", monitorPath, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(qemuGracefulShutdownMsg)); err != nil {
+		return fmt.Errorf("failed to send shutdown message to monitor socket %q: %v", monitorPath, err)
+	}
+	return nil
+}
+
 func (h *qemuHandle) Stats() (*cstructs.TaskResourceUsage, error) {
 	return h.executor.Stats()
 }
@@ -2,6 +2,9 @@ package driver
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -63,7 +66,7 @@ func TestQemuDriver_StartOpen_Wait(t *testing.T) {
 			MemoryMB: 512,
 			Networks: []*structs.NetworkResource{
 				&structs.NetworkResource{
-					ReservedPorts: []structs.Port{{"main", 22000}, {"web", 80}},
+					ReservedPorts: []structs.Port{{"main", 22000, 0}, {"web", 80, 0}},
 				},
 			},
 		},
@@ -128,7 +131,7 @@ func TestQemuDriverUser(t *testing.T) {
 			MemoryMB: 512,
 			Networks: []*structs.NetworkResource{
 				&structs.NetworkResource{
-					ReservedPorts: []structs.Port{{"main", 22000}, {"web", 80}},
+					ReservedPorts: []structs.Port{{"main", 22000, 0}, {"web", 80, 0}},
 				},
 			},
 		},
@@ -148,3 +151,41 @@ func TestQemuDriverUser(t *testing.T) {
 		t.Fatalf("Expecting '%v' in '%v'", msg, err)
 	}
 }
+
+func TestQemuDriver_SendShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomadtest_qemu")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	monitorPath := filepath.Join(dir, qemuMonitorSocketName)
+	if err := sendQemuShutdown(monitorPath); err == nil {
+		t.Fatalf("expected error without a monitor socket")
+	}
+
+	l, err := net.Listen("unix", monitorPath)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer l.Close()
+
+	msgCh := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			msgCh <- err.Error()
+			return
+		}
+		defer conn.Close()
+		msg, _ := ioutil.ReadAll(conn)
+		msgCh <- string(msg)
+	}()
+
+	if err := sendQemuShutdown(monitorPath); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if msg := <-msgCh; msg != qemuGracefulShutdownMsg {
+		t.Fatalf("bad: %q", msg)
+	}
+}
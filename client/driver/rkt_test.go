@@ -427,7 +427,7 @@ func TestRktDriver_PortsMapping(t *testing.T) {
 			Networks: []*structs.NetworkResource{
 				&structs.NetworkResource{
 					IP:            "127.0.0.1",
-					ReservedPorts: []structs.Port{{"main", 8080}},
+					ReservedPorts: []structs.Port{{"main", 8080, 0}},
 				},
 			},
 		},
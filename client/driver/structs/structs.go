@@ -18,6 +18,10 @@ type WaitResult struct {
 	ExitCode int
 	Signal   int
 	Err      error
+
+	// OOMKilled is set if the task was killed for exceeding its memory
+	// limit.
+	OOMKilled bool
 }
 
 func NewWaitResult(code, signal int, err error) *WaitResult {
@@ -33,8 +37,8 @@ func (r *WaitResult) Successful() bool {
 }
 
 func (r *WaitResult) String() string {
-	return fmt.Sprintf("Wait returned exit code %v, signal %v, and error %v",
-		r.ExitCode, r.Signal, r.Err)
+	return fmt.Sprintf("Wait returned exit code %v, signal %v, OOM killed %v, and error %v",
+		r.ExitCode, r.Signal, r.OOMKilled, r.Err)
 }
 
 // CheckResult encapsulates the result of a check
@@ -47,6 +47,7 @@ func (f *CPUFingerprint) Fingerprint(cfg *config.Config, node *structs.Node) (bo
 	}
 
 	node.Resources.CPU = int(tt)
+	node.Resources.Cores = numCores
 
 	return true, nil
 }
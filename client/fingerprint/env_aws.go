@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -156,7 +157,18 @@ func (f *EnvAWSFingerprint) Fingerprint(cfg *config.Config, node *structs.Node)
 		node.Resources = &structs.Resources{}
 	}
 	newNetwork.MBits = throughput
-	node.Resources.Networks = []*structs.NetworkResource{newNetwork}
+	networks := []*structs.NetworkResource{newNetwork}
+
+	// Keep the IPv6 addresses found by the network fingerprint as the
+	// metadata only holds the IPv4 address
+	for _, n := range node.Resources.Networks {
+		if ip := net.ParseIP(n.IP); ip != nil && ip.To4() == nil {
+			ipv6 := n.Copy()
+			ipv6.MBits = throughput
+			networks = append(networks, ipv6)
+		}
+	}
+	node.Resources.Networks = networks
 
 	// populate Links
 	node.Links["aws.ec2"] = fmt.Sprintf("%s.%s",
@@ -33,6 +33,8 @@ var (
 		"memory":  NewMemoryFingerprint,
 		"network": NewNetworkFingerprint,
 		"nomad":   NewNomadFingerprint,
+		"nvidia":  NewNvidiaGPUFingerprint,
+		"script":  NewScriptFingerprint,
 		"signal":  NewSignalFingerprint,
 		"storage": NewStorageFingerprint,
 		"vault":   NewVaultFingerprint,
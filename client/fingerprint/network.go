@@ -56,10 +56,6 @@ func NewNetworkFingerprint(logger *log.Logger) Fingerprint {
 }
 
 func (f *NetworkFingerprint) Fingerprint(cfg *config.Config, node *structs.Node) (bool, error) {
-	// newNetwork is populated and addded to the Nodes resources
-	newNetwork := &structs.NetworkResource{}
-	var ip string
-
 	intf, err := f.findInterface(cfg.NetworkInterface)
 	switch {
 	case err != nil:
@@ -69,26 +65,23 @@ func (f *NetworkFingerprint) Fingerprint(cfg *config.Config, node *structs.Node)
 		return false, nil
 	}
 
-	if ip, err = f.ipAddress(intf); err != nil {
+	ipv4, ipv6, err := f.ipAddresses(intf)
+	if err != nil {
 		return false, fmt.Errorf("Unable to find IP address of interface: %s, err: %v", intf.Name, err)
 	}
 
-	newNetwork.Device = intf.Name
-	node.Attributes["unique.network.ip-address"] = ip
-	newNetwork.IP = ip
-	newNetwork.CIDR = newNetwork.IP + "/32"
-
-	f.logger.Printf("[DEBUG] fingerprint.network: Detected interface %v with IP %v during fingerprinting", intf.Name, ip)
+	f.logger.Printf("[DEBUG] fingerprint.network: Detected interface %v with IPv4 %q and IPv6 %q during fingerprinting", intf.Name, ipv4, ipv6)
 
+	var mbits int
 	throughput := f.linkSpeed(intf.Name)
 	if cfg.NetworkSpeed != 0 {
-		newNetwork.MBits = cfg.NetworkSpeed
-		f.logger.Printf("[DEBUG] fingerprint.network: setting link speed to user configured speed: %d", newNetwork.MBits)
+		mbits = cfg.NetworkSpeed
+		f.logger.Printf("[DEBUG] fingerprint.network: setting link speed to user configured speed: %d", mbits)
 	} else if throughput != 0 {
-		newNetwork.MBits = throughput
-		f.logger.Printf("[DEBUG] fingerprint.network: link speed for %v set to %v", intf.Name, newNetwork.MBits)
+		mbits = throughput
+		f.logger.Printf("[DEBUG] fingerprint.network: link speed for %v set to %v", intf.Name, mbits)
 	} else {
-		newNetwork.MBits = defaultNetworkSpeed
+		mbits = defaultNetworkSpeed
 		f.logger.Printf("[DEBUG] fingerprint.network: link speed could not be detected and no speed specified by user. Defaulting to %d", defaultNetworkSpeed)
 	}
 
@@ -96,23 +89,42 @@ func (f *NetworkFingerprint) Fingerprint(cfg *config.Config, node *structs.Node)
 		node.Resources = &structs.Resources{}
 	}
 
-	node.Resources.Networks = append(node.Resources.Networks, newNetwork)
+	// A network resource is added for each address family of the interface.
+	// They share the bandwidth of the device.
+	if ipv4 != "" {
+		node.Attributes["unique.network.ip-address"] = ipv4
+		node.Resources.Networks = append(node.Resources.Networks, &structs.NetworkResource{
+			Device: intf.Name,
+			IP:     ipv4,
+			CIDR:   ipv4 + "/32",
+			MBits:  mbits,
+		})
+	}
+	if ipv6 != "" {
+		node.Attributes["unique.network.ipv6-address"] = ipv6
+		node.Resources.Networks = append(node.Resources.Networks, &structs.NetworkResource{
+			Device: intf.Name,
+			IP:     ipv6,
+			CIDR:   ipv6 + "/128",
+			MBits:  mbits,
+		})
+	}
 
 	// return true, because we have a network connection
 	return true, nil
 }
 
-// Gets the ipv4 addr for a network interface
-func (f *NetworkFingerprint) ipAddress(intf *net.Interface) (string, error) {
+// Gets the first ipv4 and ipv6 addrs for a network interface. Link-local ipv6
+// addrs are skipped as they can't be reached from other networks.
+func (f *NetworkFingerprint) ipAddresses(intf *net.Interface) (ipv4, ipv6 string, err error) {
 	var addrs []net.Addr
-	var err error
 
 	if addrs, err = f.interfaceDetector.Addrs(intf); err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	if len(addrs) == 0 {
-		return "", errors.New(fmt.Sprintf("Interface %s has no IP address", intf.Name))
+		return "", "", errors.New(fmt.Sprintf("Interface %s has no IP address", intf.Name))
 	}
 	for _, addr := range addrs {
 		var ip net.IP
@@ -122,13 +134,25 @@ func (f *NetworkFingerprint) ipAddress(intf *net.Interface) (string, error) {
 		case *net.IPAddr:
 			ip = v.IP
 		}
-		if ip.To4() != nil {
-			return ip.String(), nil
+
+		switch {
+		case ip == nil:
+		case ip.To4() != nil:
+			if ipv4 == "" {
+				ipv4 = ip.String()
+			}
+		case ip.IsLinkLocalUnicast():
+		default:
+			if ipv6 == "" {
+				ipv6 = ip.String()
+			}
 		}
 	}
 
-	return "", fmt.Errorf("Couldn't parse IP address for interface %s", intf.Name)
-
+	if ipv4 == "" && ipv6 == "" {
+		return "", "", fmt.Errorf("Couldn't parse IP address for interface %s", intf.Name)
+	}
+	return ipv4, ipv6, nil
 }
 
 // Checks if the device is marked UP by the operator
@@ -138,7 +162,7 @@ func (f *NetworkFingerprint) isDeviceEnabled(intf *net.Interface) bool {
 
 // Checks if the device has any IP address configured
 func (f *NetworkFingerprint) deviceHasIpAddress(intf *net.Interface) bool {
-	_, err := f.ipAddress(intf)
+	_, _, err := f.ipAddresses(intf)
 	return err == nil
 }
 
@@ -142,6 +142,32 @@ func (n *NetworkInterfaceDetectorMultipleInterfaces) Addrs(intf *net.Interface)
 	return nil, fmt.Errorf("Can't find addresses for device: %v", intf.Name)
 }
 
+// A fake network detector which returns an interface with only IPv6 addresses
+type NetworkInterfaceDetectorIPv6Only struct {
+}
+
+func (n *NetworkInterfaceDetectorIPv6Only) Interfaces() ([]net.Interface, error) {
+	return []net.Interface{eth0}, nil
+}
+
+func (n *NetworkInterfaceDetectorIPv6Only) InterfaceByName(name string) (*net.Interface, error) {
+	if name == "eth0" {
+		return &eth0, nil
+	}
+
+	return nil, fmt.Errorf("No device with name %v found", name)
+}
+
+func (n *NetworkInterfaceDetectorIPv6Only) Addrs(intf *net.Interface) ([]net.Addr, error) {
+	if intf.Name == "eth0" {
+		linkLocal := &net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)}
+		global := &net.IPNet{IP: net.ParseIP("2001:db8::10"), Mask: net.CIDRMask(64, 128)}
+		return []net.Addr{linkLocal, global}, nil
+	}
+
+	return nil, fmt.Errorf("Can't find addresses for device: %v", intf.Name)
+}
+
 func TestNetworkFingerprint_basic(t *testing.T) {
 	if v := os.Getenv(skipOnlineTestsEnvVar); v != "" {
 		t.Skipf("Environment variable %+q not empty, skipping test", skipOnlineTestsEnvVar)
@@ -308,3 +334,62 @@ func TestNetworkFingerPrint_excludelo_down_interfaces(t *testing.T) {
 		t.Fatal("Expected Network Resource to have a non-zero bandwith")
 	}
 }
+
+func TestNetworkFingerPrint_dual_stack(t *testing.T) {
+	f := &NetworkFingerprint{logger: testLogger(), interfaceDetector: &NetworkInterfaceDetectorMultipleInterfaces{}}
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+	}
+	cfg := &config.Config{NetworkSpeed: 100}
+
+	ok, err := f.Fingerprint(cfg, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("should apply")
+	}
+
+	if ip := node.Attributes["unique.network.ipv6-address"]; ip != "2005:db6::" {
+		t.Fatalf("Bad IPv6 address: %s", ip)
+	}
+
+	// A network resource is added for each address family
+	if len(node.Resources.Networks) != 2 {
+		t.Fatalf("Expected two Network Resources; got %#v", node.Resources.Networks)
+	}
+	net := node.Resources.Networks[1]
+	if net.IP != "2005:db6::" || net.CIDR != "2005:db6::/128" || net.Device != "eth0" || net.MBits != 100 {
+		t.Fatalf("Bad IPv6 Network Resource: %#v", net)
+	}
+}
+
+func TestNetworkFingerPrint_ipv6_only(t *testing.T) {
+	f := &NetworkFingerprint{logger: testLogger(), interfaceDetector: &NetworkInterfaceDetectorIPv6Only{}}
+	node := &structs.Node{
+		Attributes: make(map[string]string),
+	}
+	cfg := &config.Config{NetworkSpeed: 100}
+
+	ok, err := f.Fingerprint(cfg, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("should apply")
+	}
+
+	if _, ok := node.Attributes["unique.network.ip-address"]; ok {
+		t.Fatalf("Unexpected IPv4 address: %#v", node.Attributes)
+	}
+	assertNodeAttributeContains(t, node, "unique.network.ipv6-address")
+
+	// The link-local address is skipped
+	if len(node.Resources.Networks) != 1 {
+		t.Fatalf("Expected one Network Resource; got %#v", node.Resources.Networks)
+	}
+	net := node.Resources.Networks[0]
+	if net.IP != "2001:db8::10" || net.CIDR != "2001:db8::10/128" {
+		t.Fatalf("Bad Network Resource: %#v", net)
+	}
+}
@@ -0,0 +1,104 @@
+package fingerprint
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// nvidiaGPUDevicePrefix is the prefix of the names of the GPU devices
+	// fingerprinted by nvidia-smi
+	nvidiaGPUDevicePrefix = "nvidia/gpu/"
+
+	// nvidiaGPUCountAttr is the node attribute with the number of GPUs
+	nvidiaGPUCountAttr = "nvidia.gpu.count"
+)
+
+// NvidiaGPUFingerprint is used to fingerprint the NVIDIA GPUs of the node
+// using nvidia-smi
+type NvidiaGPUFingerprint struct {
+	StaticFingerprinter
+	logger *log.Logger
+}
+
+// NewNvidiaGPUFingerprint is used to create a NVIDIA GPU fingerprint
+func NewNvidiaGPUFingerprint(logger *log.Logger) Fingerprint {
+	return &NvidiaGPUFingerprint{logger: logger}
+}
+
+func (f *NvidiaGPUFingerprint) Fingerprint(cfg *config.Config, node *structs.Node) (bool, error) {
+	path, err := exec.LookPath("nvidia-smi")
+	if err != nil {
+		return false, nil
+	}
+
+	out, err := exec.Command(path, "--query-gpu=uuid,name,memory.total", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		f.logger.Printf("[WARN] fingerprint.nvidia: failed to query GPUs: %v", err)
+		return false, nil
+	}
+
+	devices, err := parseNvidiaSmiOutput(string(out))
+	if err != nil {
+		return false, err
+	}
+
+	if node.Resources == nil {
+		node.Resources = &structs.Resources{}
+	}
+
+	// Replace the previously fingerprinted GPUs
+	existing := node.Resources.Devices
+	node.Resources.Devices = nil
+	for _, d := range existing {
+		if !strings.HasPrefix(d.Name, nvidiaGPUDevicePrefix) {
+			node.Resources.Devices = append(node.Resources.Devices, d)
+		}
+	}
+	node.Resources.Devices = append(node.Resources.Devices, devices...)
+
+	count := 0
+	for _, d := range devices {
+		count += len(d.IDs)
+	}
+	node.Attributes[nvidiaGPUCountAttr] = strconv.Itoa(count)
+	return count > 0, nil
+}
+
+// parseNvidiaSmiOutput parses the uuid, name and total memory of the GPUs
+// listed by nvidia-smi in CSV format and groups them by model.
+func parseNvidiaSmiOutput(out string) ([]*structs.DeviceResource, error) {
+	var devices []*structs.DeviceResource
+	byName := make(map[string]*structs.DeviceResource)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected nvidia-smi output %q", line)
+		}
+		uuid, model := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		memory := strings.TrimSpace(fields[2])
+
+		name := nvidiaGPUDevicePrefix + model
+		d, ok := byName[name]
+		if !ok {
+			d = &structs.DeviceResource{
+				Name:       name,
+				Attributes: map[string]string{"memory_mb": memory},
+			}
+			byName[name] = d
+			devices = append(devices, d)
+		}
+		d.IDs = append(d.IDs, uuid)
+	}
+	return devices, nil
+}
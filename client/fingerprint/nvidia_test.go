@@ -0,0 +1,42 @@
+package fingerprint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestNvidiaGPUFingerprint_ParseOutput(t *testing.T) {
+	out := `GPU-3d1c5b9e-0000-0000-0000-000000000001, Tesla K80, 11441
+GPU-3d1c5b9e-0000-0000-0000-000000000002, Tesla K80, 11441
+GPU-3d1c5b9e-0000-0000-0000-000000000003, Tesla P100-PCIE-16GB, 16276
+`
+	devices, err := parseNvidiaSmiOutput(out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := []*structs.DeviceResource{
+		{
+			Name: "nvidia/gpu/Tesla K80",
+			IDs: []string{
+				"GPU-3d1c5b9e-0000-0000-0000-000000000001",
+				"GPU-3d1c5b9e-0000-0000-0000-000000000002",
+			},
+			Attributes: map[string]string{"memory_mb": "11441"},
+		},
+		{
+			Name:       "nvidia/gpu/Tesla P100-PCIE-16GB",
+			IDs:        []string{"GPU-3d1c5b9e-0000-0000-0000-000000000003"},
+			Attributes: map[string]string{"memory_mb": "16276"},
+		},
+	}
+	if !reflect.DeepEqual(devices, expected) {
+		t.Fatalf("bad: %#v", devices)
+	}
+
+	if _, err := parseNvidiaSmiOutput("garbage"); err == nil {
+		t.Fatalf("expected error parsing invalid output")
+	}
+}
@@ -0,0 +1,161 @@
+package fingerprint
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// scriptDirConfigOption is the client option that sets the directory
+	// containing the fingerprint scripts
+	scriptDirConfigOption = "fingerprint.script.dir"
+
+	// scriptTimeoutConfigOption is the client option that sets how long a
+	// fingerprint script may run
+	scriptTimeoutConfigOption = "fingerprint.script.timeout"
+
+	// scriptPeriodConfigOption is the client option that sets how often the
+	// fingerprint scripts are rerun. Scripts are only run once by default.
+	scriptPeriodConfigOption = "fingerprint.script.period"
+
+	// scriptDefaultTimeout is the default timeout of a fingerprint script
+	scriptDefaultTimeout = 5 * time.Second
+
+	// scriptAttributePrefix is prepended to the keys emitted by scripts so
+	// they can't override the attributes of the built-in fingerprinters
+	scriptAttributePrefix = "script."
+)
+
+// ScriptFingerprint is used to fingerprint the node by running the
+// executables in an operator provided directory. Each line of output of the
+// form key=value is added to the node's attributes as script.<key>.
+type ScriptFingerprint struct {
+	logger *log.Logger
+	period time.Duration
+
+	// attributes tracks the attributes set by the last run so they can be
+	// removed once a script stops emitting them
+	attributes map[string]struct{}
+}
+
+// NewScriptFingerprint is used to create a script fingerprint
+func NewScriptFingerprint(logger *log.Logger) Fingerprint {
+	return &ScriptFingerprint{logger: logger, attributes: make(map[string]struct{})}
+}
+
+func (f *ScriptFingerprint) Fingerprint(cfg *config.Config, node *structs.Node) (bool, error) {
+	dir := cfg.Read(scriptDirConfigOption)
+	if dir == "" {
+		return false, nil
+	}
+
+	timeout := scriptDefaultTimeout
+	if raw := cfg.Read(scriptTimeoutConfigOption); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return false, fmt.Errorf("error parsing %q: %v", scriptTimeoutConfigOption, err)
+		}
+		timeout = d
+	}
+
+	if raw := cfg.Read(scriptPeriodConfigOption); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return false, fmt.Errorf("error parsing %q: %v", scriptPeriodConfigOption, err)
+		}
+		f.period = d
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read fingerprint script directory %q: %v", dir, err)
+	}
+
+	attributes := make(map[string]string)
+	for _, file := range files {
+		// Skip directories and files that are not executable
+		if file.IsDir() || file.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		out, err := runFingerprintScript(path, timeout)
+		if err != nil {
+			f.logger.Printf("[WARN] fingerprint.script: skipping %q: %v", path, err)
+			continue
+		}
+
+		for k, v := range out {
+			attributes[scriptAttributePrefix+k] = v
+		}
+	}
+
+	// Clear the attributes that are no longer emitted
+	for k := range f.attributes {
+		if _, ok := attributes[k]; !ok {
+			delete(node.Attributes, k)
+		}
+	}
+
+	f.attributes = make(map[string]struct{}, len(attributes))
+	for k, v := range attributes {
+		node.Attributes[k] = v
+		f.attributes[k] = struct{}{}
+	}
+
+	return true, nil
+}
+
+func (f *ScriptFingerprint) Periodic() (bool, time.Duration) {
+	return f.period > 0, f.period
+}
+
+// runFingerprintScript runs the script at path and parses the key=value pairs
+// it prints. Empty lines and lines starting with # are ignored.
+func runFingerprintScript(path string, timeout time.Duration) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %v", timeout)
+		}
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	attributes := make(map[string]string)
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || key == "" {
+			return nil, fmt.Errorf("invalid output line %q; expected key=value", line)
+		}
+		attributes[key] = strings.TrimSpace(parts[1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read output: %v", err)
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,101 @@
+package fingerprint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestScriptFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomadtest_fingerprint_script")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	scripts := map[string]string{
+		"license": "#!/bin/sh\n# comment\necho license.matlab=true\n\necho 'rack = r12'\n",
+		"failing": "#!/bin/sh\necho partial=true\nexit 1\n",
+		"invalid": "#!/bin/sh\necho garbage\n",
+		"slow":    "#!/bin/sh\nsleep 10\necho slow=true\n",
+	}
+	for name, script := range scripts {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Files that aren't executable are skipped
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("echo readme=true"), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	f := NewScriptFingerprint(testLogger())
+	node := &structs.Node{Attributes: map[string]string{"script.stale": "true"}}
+	cfg := &config.Config{Options: map[string]string{
+		scriptDirConfigOption:     dir,
+		scriptTimeoutConfigOption: "500ms",
+		scriptPeriodConfigOption:  "1m",
+	}}
+
+	ok, err := f.Fingerprint(cfg, node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("should apply")
+	}
+
+	expected := map[string]string{
+		"script.stale":          "true",
+		"script.license.matlab": "true",
+		"script.rack":           "r12",
+	}
+	if len(node.Attributes) != len(expected) {
+		t.Fatalf("bad: %#v", node.Attributes)
+	}
+	for k, v := range expected {
+		assertNodeAttributeEquals(t, node, k, v)
+	}
+
+	if p, period := f.Periodic(); !p || period != time.Minute {
+		t.Fatalf("bad period: %v %v", p, period)
+	}
+
+	// Attributes no longer emitted are removed
+	if err := os.Remove(filepath.Join(dir, "license")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := f.Fingerprint(cfg, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(node.Attributes) != 1 || node.Attributes["script.stale"] != "true" {
+		t.Fatalf("bad: %#v", node.Attributes)
+	}
+}
+
+func TestScriptFingerprint_Disabled(t *testing.T) {
+	f := NewScriptFingerprint(testLogger())
+	node := &structs.Node{Attributes: make(map[string]string)}
+
+	ok, err := f.Fingerprint(new(config.Config), node)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ok {
+		t.Fatalf("should not apply without a script directory")
+	}
+	if p, _ := f.Periodic(); p {
+		t.Fatalf("should not be periodic")
+	}
+
+	cfg := &config.Config{Options: map[string]string{scriptDirConfigOption: "/nonexistent"}}
+	if _, err := f.Fingerprint(cfg, node); err == nil {
+		t.Fatalf("expected error with missing script directory")
+	}
+}
@@ -0,0 +1,301 @@
+package client
+
+import (
+	"container/heap"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/stats"
+)
+
+// allocCounter counts the allocations of the client whose alloc dirs have not
+// been garbage collected
+type allocCounter interface {
+	NumAllocs() int
+}
+
+// gcAlloc is a terminal allocation awaiting garbage collection
+type gcAlloc struct {
+	allocRunner *AllocRunner
+
+	// terminalAt is the time the allocation was marked for collection
+	terminalAt time.Time
+
+	// index is the position of the allocation in the queue
+	index int
+}
+
+// gcAllocPQ is a priority queue of terminal allocations which pops the
+// allocation that has been terminal the longest first
+type gcAllocPQ []*gcAlloc
+
+func (q gcAllocPQ) Len() int {
+	return len(q)
+}
+
+func (q gcAllocPQ) Less(i, j int) bool {
+	return q[i].terminalAt.Before(q[j].terminalAt)
+}
+
+func (q gcAllocPQ) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *gcAllocPQ) Push(x interface{}) {
+	alloc := x.(*gcAlloc)
+	alloc.index = len(*q)
+	*q = append(*q, alloc)
+}
+
+func (q *gcAllocPQ) Pop() interface{} {
+	old := *q
+	n := len(old)
+	alloc := old[n-1]
+	old[n-1] = nil
+	alloc.index = -1
+	*q = old[:n-1]
+	return alloc
+}
+
+// AllocGarbageCollector destroys the alloc dirs and the driver resources of
+// terminal allocations, oldest first, once the client keeps too many
+// allocations, they are older than the maximum age or the disk holding the
+// alloc dir fills up.
+type AllocGarbageCollector struct {
+	config  *config.Config
+	counter allocCounter
+	logger  *log.Logger
+
+	// diskUsage returns the usage of the disk holding a directory
+	diskUsage func(path string) (*stats.DiskStats, error)
+
+	queue  gcAllocPQ
+	allocs map[string]*gcAlloc
+	lock   sync.Mutex
+
+	shutdownCh chan struct{}
+}
+
+// NewAllocGarbageCollector returns a garbage collector of the terminal
+// allocations counted by the counter
+func NewAllocGarbageCollector(logger *log.Logger, config *config.Config, counter allocCounter) *AllocGarbageCollector {
+	return &AllocGarbageCollector{
+		config:     config,
+		counter:    counter,
+		logger:     logger,
+		diskUsage:  stats.DiskUsage,
+		allocs:     make(map[string]*gcAlloc),
+		shutdownCh: make(chan struct{}),
+	}
+}
+
+// Run periodically garbage collects the terminal allocations exceeding the
+// limits until the collector is stopped
+func (a *AllocGarbageCollector) Run() {
+	if a.config.GCInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(a.config.GCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := a.keepUsageBelowThreshold(); err != nil {
+				a.logger.Printf("[ERR] client.gc: error garbage collecting allocations: %v", err)
+			}
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
+
+// Stop stops the periodic garbage collection
+func (a *AllocGarbageCollector) Stop() {
+	close(a.shutdownCh)
+}
+
+// MarkForCollection queues the alloc runner of a terminal allocation for
+// garbage collection
+func (a *AllocGarbageCollector) MarkForCollection(ar *AllocRunner) {
+	if ar == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	id := ar.Alloc().ID
+	if _, ok := a.allocs[id]; ok {
+		return
+	}
+	alloc := &gcAlloc{
+		allocRunner: ar,
+		terminalAt:  time.Now(),
+	}
+	heap.Push(&a.queue, alloc)
+	a.allocs[id] = alloc
+	a.logger.Printf("[DEBUG] client.gc: marked alloc %q for collection", id)
+}
+
+// Remove removes the alloc runner from the queue of allocations awaiting
+// garbage collection
+func (a *AllocGarbageCollector) Remove(ar *AllocRunner) {
+	a.dequeue(ar.Alloc().ID)
+}
+
+// Collect garbage collects the allocation if it is terminal and returns
+// whether it was collected
+func (a *AllocGarbageCollector) Collect(allocID string) bool {
+	alloc := a.dequeue(allocID)
+	if alloc == nil {
+		return false
+	}
+	a.destroyAllocRunner(alloc.allocRunner, "forced collection")
+	return true
+}
+
+// CollectAll garbage collects all the terminal allocations and returns the
+// number of collected allocations
+func (a *AllocGarbageCollector) CollectAll() int {
+	collected := 0
+	for {
+		alloc := a.popOldest()
+		if alloc == nil {
+			return collected
+		}
+		a.destroyAllocRunner(alloc.allocRunner, "forced collection")
+		collected++
+	}
+}
+
+// MakeRoomFor garbage collects the oldest terminal allocations until the
+// given number of new allocations can be added without exceeding the maximum
+// number of allocations
+func (a *AllocGarbageCollector) MakeRoomFor(n int) {
+	max := a.config.GCMaxAllocs
+	if max <= 0 {
+		return
+	}
+
+	for a.counter.NumAllocs()+n > max {
+		alloc := a.popOldest()
+		if alloc == nil {
+			return
+		}
+		reason := fmt.Sprintf("making room for %d new allocations, limit is %d", n, max)
+		a.destroyAllocRunner(alloc.allocRunner, reason)
+	}
+}
+
+// keepUsageBelowThreshold garbage collects the oldest terminal allocations
+// while any of the limits is exceeded
+func (a *AllocGarbageCollector) keepUsageBelowThreshold() error {
+	for {
+		select {
+		case <-a.shutdownCh:
+			return nil
+		default:
+		}
+
+		oldest := a.peekOldest()
+		if oldest == nil {
+			return nil
+		}
+		reason, err := a.exceededLimit(oldest)
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			return nil
+		}
+
+		// The allocation may have been removed while checking the limits
+		if alloc := a.dequeue(oldest.allocRunner.Alloc().ID); alloc != nil {
+			a.destroyAllocRunner(alloc.allocRunner, reason)
+		}
+	}
+}
+
+// exceededLimit returns why the oldest terminal allocation has to be garbage
+// collected, or an empty reason if no limit is exceeded
+func (a *AllocGarbageCollector) exceededLimit(oldest *gcAlloc) (string, error) {
+	if age := time.Since(oldest.terminalAt); a.config.GCMaxAllocAge > 0 && age > a.config.GCMaxAllocAge {
+		return fmt.Sprintf("terminal for %v, limit is %v", age, a.config.GCMaxAllocAge), nil
+	}
+
+	if max := a.config.GCMaxAllocs; max > 0 {
+		if num := a.counter.NumAllocs(); num > max {
+			return fmt.Sprintf("number of allocations (%d) over limit (%d)", num, max), nil
+		}
+	}
+
+	if a.config.GCDiskUsageThreshold <= 0 && a.config.GCInodeUsageThreshold <= 0 {
+		return "", nil
+	}
+	usage, err := a.diskUsage(a.config.AllocDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the disk usage of %q: %v", a.config.AllocDir, err)
+	}
+	if threshold := a.config.GCDiskUsageThreshold; threshold > 0 && usage.UsedPercent > threshold {
+		return fmt.Sprintf("disk usage (%.2f%%) over threshold (%.2f%%)", usage.UsedPercent, threshold), nil
+	}
+	if threshold := a.config.GCInodeUsageThreshold; threshold > 0 && usage.InodesUsedPercent > threshold {
+		return fmt.Sprintf("inode usage (%.2f%%) over threshold (%.2f%%)", usage.InodesUsedPercent, threshold), nil
+	}
+	return "", nil
+}
+
+// peekOldest returns the allocation that has been terminal the longest, or
+// nil if no allocation is queued
+func (a *AllocGarbageCollector) peekOldest() *gcAlloc {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if len(a.queue) == 0 {
+		return nil
+	}
+	return a.queue[0]
+}
+
+// popOldest removes and returns the allocation that has been terminal the
+// longest, or nil if no allocation is queued
+func (a *AllocGarbageCollector) popOldest() *gcAlloc {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if len(a.queue) == 0 {
+		return nil
+	}
+	alloc := heap.Pop(&a.queue).(*gcAlloc)
+	delete(a.allocs, alloc.allocRunner.Alloc().ID)
+	return alloc
+}
+
+// dequeue removes and returns the allocation from the queue, or nil if it
+// isn't queued
+func (a *AllocGarbageCollector) dequeue(allocID string) *gcAlloc {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	alloc, ok := a.allocs[allocID]
+	if !ok {
+		return nil
+	}
+	heap.Remove(&a.queue, alloc.index)
+	delete(a.allocs, allocID)
+	return alloc
+}
+
+// destroyAllocRunner destroys the alloc dir and the resources of the alloc
+// runner and waits for it to terminate
+func (a *AllocGarbageCollector) destroyAllocRunner(ar *AllocRunner, reason string) {
+	a.logger.Printf("[INFO] client.gc: garbage collecting alloc %q: %s", ar.Alloc().ID, reason)
+	ar.Destroy()
+	<-ar.WaitCh()
+}
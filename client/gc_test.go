@@ -0,0 +1,232 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/stats"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// testAllocCounter counts the alloc runners which haven't been destroyed
+type testAllocCounter []*AllocRunner
+
+func (c testAllocCounter) NumAllocs() int {
+	n := 0
+	for _, ar := range c {
+		if !ar.IsDestroyed() {
+			n++
+		}
+	}
+	return n
+}
+
+// testGCConfig returns a config with all the limits of the garbage collector
+// disabled
+func testGCConfig() *config.Config {
+	conf := config.DefaultConfig()
+	conf.AllocDir = os.TempDir()
+	conf.GCDiskUsageThreshold = 0
+	conf.GCInodeUsageThreshold = 0
+	conf.GCMaxAllocs = 0
+	conf.GCMaxAllocAge = 0
+	return conf
+}
+
+// testTerminalAllocRunner returns a running alloc runner of a completed
+// allocation
+func testTerminalAllocRunner(t *testing.T) *AllocRunner {
+	alloc := mock.Alloc()
+	alloc.ClientStatus = structs.AllocClientStatusComplete
+	_, ar := testAllocRunnerFromAlloc(alloc, false)
+	go ar.Run()
+
+	testutil.WaitForResult(func() (bool, error) {
+		_, err := os.Stat(testAllocDirPath(ar))
+		return err == nil, err
+	}, func(err error) {
+		t.Fatalf("alloc dir of alloc %q not built: %v", alloc.ID, err)
+	})
+	return ar
+}
+
+// testAllocDirPath returns the path of the alloc dir of the alloc runner
+func testAllocDirPath(ar *AllocRunner) string {
+	return filepath.Join(ar.config.AllocDir, ar.Alloc().ID)
+}
+
+// testGCAllocRunners returns n running alloc runners of completed allocations
+// marked for collection from the oldest to the newest
+func testGCAllocRunners(t *testing.T, gc *AllocGarbageCollector, n int) []*AllocRunner {
+	runners := make([]*AllocRunner, n)
+	for i := range runners {
+		runners[i] = testTerminalAllocRunner(t)
+		gc.MarkForCollection(runners[i])
+	}
+	return runners
+}
+
+func assertCollected(t *testing.T, ar *AllocRunner) {
+	select {
+	case <-ar.WaitCh():
+	case <-time.After(time.Duration(testutil.TestMultiplier()*5) * time.Second):
+		t.Fatalf("alloc runner %q not terminated", ar.Alloc().ID)
+	}
+	if _, err := os.Stat(testAllocDirPath(ar)); !os.IsNotExist(err) {
+		t.Fatalf("alloc dir of %q not destroyed: %v", ar.Alloc().ID, err)
+	}
+}
+
+func assertNotCollected(t *testing.T, ar *AllocRunner) {
+	if ar.IsDestroyed() {
+		t.Fatalf("alloc runner %q destroyed", ar.Alloc().ID)
+	}
+	if _, err := os.Stat(testAllocDirPath(ar)); err != nil {
+		t.Fatalf("alloc dir of %q destroyed: %v", ar.Alloc().ID, err)
+	}
+}
+
+func TestAllocGarbageCollector_MarkForCollection(t *testing.T) {
+	gc := NewAllocGarbageCollector(testLogger(), testGCConfig(), testAllocCounter{})
+	ar := testTerminalAllocRunner(t)
+	defer ar.Destroy()
+
+	gc.MarkForCollection(ar)
+	gc.MarkForCollection(ar)
+	if len(gc.queue) != 1 || len(gc.allocs) != 1 {
+		t.Fatalf("bad: %d queued, %d indexed", len(gc.queue), len(gc.allocs))
+	}
+
+	gc.Remove(ar)
+	if len(gc.queue) != 0 || len(gc.allocs) != 0 {
+		t.Fatalf("bad: %d queued, %d indexed", len(gc.queue), len(gc.allocs))
+	}
+	if gc.Collect(ar.Alloc().ID) {
+		t.Fatalf("removed alloc collected")
+	}
+	assertNotCollected(t, ar)
+}
+
+func TestAllocGarbageCollector_Collect(t *testing.T) {
+	gc := NewAllocGarbageCollector(testLogger(), testGCConfig(), testAllocCounter{})
+	runners := testGCAllocRunners(t, gc, 2)
+	defer runners[1].Destroy()
+
+	if !gc.Collect(runners[0].Alloc().ID) {
+		t.Fatalf("alloc not collected")
+	}
+	assertCollected(t, runners[0])
+	assertNotCollected(t, runners[1])
+
+	if gc.Collect("foo") {
+		t.Fatalf("unknown alloc collected")
+	}
+}
+
+func TestAllocGarbageCollector_CollectAll(t *testing.T) {
+	gc := NewAllocGarbageCollector(testLogger(), testGCConfig(), testAllocCounter{})
+	runners := testGCAllocRunners(t, gc, 3)
+
+	if n := gc.CollectAll(); n != 3 {
+		t.Fatalf("bad: %d", n)
+	}
+	for _, ar := range runners {
+		assertCollected(t, ar)
+	}
+	if n := gc.CollectAll(); n != 0 {
+		t.Fatalf("bad: %d", n)
+	}
+}
+
+func TestAllocGarbageCollector_MaxAllocs(t *testing.T) {
+	conf := testGCConfig()
+	conf.GCMaxAllocs = 3
+	gc := NewAllocGarbageCollector(testLogger(), conf, nil)
+	runners := testGCAllocRunners(t, gc, 4)
+	defer runners[2].Destroy()
+	defer runners[3].Destroy()
+
+	// A running allocation counts towards the limit but isn't collected
+	running := testTerminalAllocRunner(t)
+	defer running.Destroy()
+	gc.counter = append(testAllocCounter{running}, runners...)
+
+	if err := gc.keepUsageBelowThreshold(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertCollected(t, runners[0])
+	assertCollected(t, runners[1])
+	assertNotCollected(t, runners[2])
+	assertNotCollected(t, runners[3])
+	assertNotCollected(t, running)
+}
+
+func TestAllocGarbageCollector_MakeRoomFor(t *testing.T) {
+	conf := testGCConfig()
+	conf.GCMaxAllocs = 3
+	gc := NewAllocGarbageCollector(testLogger(), conf, nil)
+	runners := testGCAllocRunners(t, gc, 3)
+	defer runners[2].Destroy()
+	gc.counter = testAllocCounter(runners)
+
+	gc.MakeRoomFor(2)
+	assertCollected(t, runners[0])
+	assertCollected(t, runners[1])
+	assertNotCollected(t, runners[2])
+}
+
+func TestAllocGarbageCollector_MaxAllocAge(t *testing.T) {
+	conf := testGCConfig()
+	conf.GCMaxAllocAge = time.Hour
+	gc := NewAllocGarbageCollector(testLogger(), conf, testAllocCounter{})
+	runners := testGCAllocRunners(t, gc, 2)
+	defer runners[1].Destroy()
+
+	// Age the oldest allocation
+	gc.queue[0].terminalAt = time.Now().Add(-2 * time.Hour)
+
+	if err := gc.keepUsageBelowThreshold(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	assertCollected(t, runners[0])
+	assertNotCollected(t, runners[1])
+}
+
+func TestAllocGarbageCollector_DiskUsage(t *testing.T) {
+	conf := testGCConfig()
+	conf.GCDiskUsageThreshold = 80
+	conf.GCInodeUsageThreshold = 70
+	gc := NewAllocGarbageCollector(testLogger(), conf, nil)
+	runners := testGCAllocRunners(t, gc, 4)
+	defer runners[3].Destroy()
+	counter := testAllocCounter(runners)
+	gc.counter = counter
+
+	// The disk is over the threshold until two allocations are collected and
+	// the inodes until one more is
+	gc.diskUsage = func(path string) (*stats.DiskStats, error) {
+		if path != conf.AllocDir {
+			t.Fatalf("bad: %q", path)
+		}
+		usage := &stats.DiskStats{UsedPercent: 50, InodesUsedPercent: 50}
+		if n := counter.NumAllocs(); n > 2 {
+			usage.UsedPercent = 90
+		} else if n > 1 {
+			usage.InodesUsedPercent = 90
+		}
+		return usage, nil
+	}
+
+	if err := gc.keepUsageBelowThreshold(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, ar := range runners[:3] {
+		assertCollected(t, ar)
+	}
+	assertNotCollected(t, runners[3])
+}
@@ -68,8 +68,17 @@ func GetArtifact(taskEnv *env.TaskEnvironment, artifact *structs.TaskArtifact, t
 		return err
 	}
 
+	// Verify the interpolated destination doesn't escape the task directory
+	relDest := taskEnv.ReplaceEnv(artifact.RelativeDest)
+	escapes, err := structs.PathEscapesAllocDir(relDest)
+	if err != nil {
+		return fmt.Errorf("invalid destination path %q: %v", relDest, err)
+	} else if escapes {
+		return fmt.Errorf("destination %q escapes task's directory", relDest)
+	}
+
 	// Download the artifact
-	dest := filepath.Join(taskDir, artifact.RelativeDest)
+	dest := filepath.Join(taskDir, relDest)
 	if err := getClient(url, dest).Get(); err != nil {
 		return fmt.Errorf("GET error: %v", err)
 	}
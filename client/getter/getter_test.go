@@ -84,6 +84,20 @@ func TestGetArtifact_File_RelativeDest(t *testing.T) {
 	}
 }
 
+func TestGetArtifact_InterpolatedDest_Escapes(t *testing.T) {
+	artifact := &structs.TaskArtifact{
+		GetterSource: "http://foo.com/test.sh",
+		RelativeDest: "${NOMAD_META_DEST}",
+	}
+
+	// The destination is checked before downloading the artifact
+	taskEnv := env.NewTaskEnvironment(mock.Node()).SetTaskMeta(map[string]string{"dest": "../../.."})
+	err := GetArtifact(taskEnv, artifact, "/tmp/nomad-test")
+	if err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("expected escape error; got %v", err)
+	}
+}
+
 func TestGetGetterUrl_Interprolation(t *testing.T) {
 	// Create the artifact
 	artifact := &structs.TaskArtifact{
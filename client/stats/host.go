@@ -118,22 +118,7 @@ func (h *HostStatsCollector) Collect() (*HostStats, error) {
 		if err != nil {
 			return nil, err
 		}
-		ds := DiskStats{
-			Device:            partition.Device,
-			Mountpoint:        partition.Mountpoint,
-			Size:              usage.Total,
-			Used:              usage.Used,
-			Available:         usage.Free,
-			UsedPercent:       usage.UsedPercent,
-			InodesUsedPercent: usage.InodesUsedPercent,
-		}
-		if math.IsNaN(ds.UsedPercent) {
-			ds.UsedPercent = 0.0
-		}
-		if math.IsNaN(ds.InodesUsedPercent) {
-			ds.InodesUsedPercent = 0.0
-		}
-		diskStats = append(diskStats, &ds)
+		diskStats = append(diskStats, newDiskStats(usage, partition.Device, partition.Mountpoint))
 	}
 	hs.DiskStats = diskStats
 
@@ -146,6 +131,35 @@ func (h *HostStatsCollector) Collect() (*HostStats, error) {
 	return hs, nil
 }
 
+// DiskUsage returns the usage of the disk holding the given path
+func DiskUsage(path string) (*DiskStats, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return nil, err
+	}
+	return newDiskStats(usage, "", path), nil
+}
+
+// newDiskStats converts the usage of a disk to its stats
+func newDiskStats(usage *disk.UsageStat, device, mountpoint string) *DiskStats {
+	ds := &DiskStats{
+		Device:            device,
+		Mountpoint:        mountpoint,
+		Size:              usage.Total,
+		Used:              usage.Used,
+		Available:         usage.Free,
+		UsedPercent:       usage.UsedPercent,
+		InodesUsedPercent: usage.InodesUsedPercent,
+	}
+	if math.IsNaN(ds.UsedPercent) {
+		ds.UsedPercent = 0.0
+	}
+	if math.IsNaN(ds.InodesUsedPercent) {
+		ds.InodesUsedPercent = 0.0
+	}
+	return ds
+}
+
 // HostCpuStatsCalculator calculates cpu usage percentages
 type HostCpuStatsCalculator struct {
 	prevIdle   float64
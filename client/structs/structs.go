@@ -78,6 +78,55 @@ type AllocResourceUsage struct {
 	Timestamp int64
 }
 
+// AllocRestartRequest is used to restart the tasks of an allocation
+type AllocRestartRequest struct {
+	// TaskName is the task to restart, all the tasks are restarted if empty
+	TaskName string
+}
+
+// AllocSignalRequest is used to send a signal to the tasks of an allocation
+type AllocSignalRequest struct {
+	// TaskName is the task to signal, all the tasks are signaled if empty
+	TaskName string
+
+	// Signal is the name of the signal to send, such as SIGHUP
+	Signal string
+}
+
+// TerminalSize is the size of a terminal in characters
+type TerminalSize struct {
+	Height int `json:"height,omitempty"`
+	Width  int `json:"width,omitempty"`
+}
+
+// ExecStreamingIOOperation is a chunk of a standard stream of a command
+// executed in a task, or its end if Close is set.
+type ExecStreamingIOOperation struct {
+	Data  []byte `json:"data,omitempty"`
+	Close bool   `json:"close,omitempty"`
+}
+
+// ExecStreamingInput is a message sent to a command executed in a task
+type ExecStreamingInput struct {
+	Stdin   *ExecStreamingIOOperation `json:"stdin,omitempty"`
+	TTYSize *TerminalSize             `json:"tty_size,omitempty"`
+}
+
+// ExecStreamingExitResult is the result of a command executed in a task
+type ExecStreamingExitResult struct {
+	ExitCode int `json:"exit_code"`
+}
+
+// ExecStreamingOutput is a message sent by a command executed in a task. The
+// last message has Exited set along with the result of the command.
+type ExecStreamingOutput struct {
+	Stdout *ExecStreamingIOOperation `json:"stdout,omitempty"`
+	Stderr *ExecStreamingIOOperation `json:"stderr,omitempty"`
+
+	Exited bool                     `json:"exited,omitempty"`
+	Result *ExecStreamingExitResult `json:"result,omitempty"`
+}
+
 // joinStringSet takes two slices of strings and joins them
 func joinStringSet(s1, s2 []string) []string {
 	lookup := make(map[string]struct{}, len(s1))
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// taskHookCoordinator gates the start of the tasks of an allocation on the
+// lifecycle of the main tasks of the task group:
+//
+// * Prestart tasks start immediately. The main tasks wait for the prestart
+//   tasks to complete successfully or, for sidecars, to be running.
+// * Poststart tasks start once all the main tasks have started.
+// * Poststop tasks start once all the main tasks are dead.
+//
+// It is not safe for concurrent use and is guarded by the lock of the task
+// states of the alloc runner.
+type taskHookCoordinator struct {
+	// closedCh is returned for tasks that can start immediately
+	closedCh chan struct{}
+
+	// mainTaskCh is closed once the main tasks can start
+	mainTaskCh chan struct{}
+
+	// poststartTaskCh is closed once the poststart tasks can start
+	poststartTaskCh chan struct{}
+
+	// poststopTaskCh is closed once the poststop tasks can start
+	poststopTaskCh chan struct{}
+
+	// prestartSidecar and prestartEphemeral are the prestart tasks that
+	// block the main tasks
+	prestartSidecar   map[string]struct{}
+	prestartEphemeral map[string]struct{}
+
+	// mainTasksPending are the main tasks that haven't started yet and block
+	// the poststart tasks
+	mainTasksPending map[string]struct{}
+
+	// mainTasksRunning are the main tasks that aren't dead yet and block the
+	// poststop tasks
+	mainTasksRunning map[string]struct{}
+
+	// sidecars are the sidecar tasks to kill once the main tasks are dead
+	sidecars []string
+}
+
+// newTaskHookCoordinator returns a coordinator for the passed tasks of a task
+// group.
+func newTaskHookCoordinator(tasks []*structs.Task) *taskHookCoordinator {
+	closedCh := make(chan struct{})
+	close(closedCh)
+
+	c := &taskHookCoordinator{
+		closedCh:          closedCh,
+		mainTaskCh:        make(chan struct{}),
+		poststartTaskCh:   make(chan struct{}),
+		poststopTaskCh:    make(chan struct{}),
+		prestartSidecar:   make(map[string]struct{}),
+		prestartEphemeral: make(map[string]struct{}),
+		mainTasksPending:  make(map[string]struct{}),
+		mainTasksRunning:  make(map[string]struct{}),
+	}
+
+	for _, task := range tasks {
+		if task.IsMainTask() {
+			c.mainTasksPending[task.Name] = struct{}{}
+			c.mainTasksRunning[task.Name] = struct{}{}
+			continue
+		}
+
+		if task.IsSidecar() {
+			c.sidecars = append(c.sidecars, task.Name)
+		}
+
+		if task.Lifecycle.Hook == structs.TaskLifecycleHookPrestart {
+			if task.Lifecycle.Sidecar {
+				c.prestartSidecar[task.Name] = struct{}{}
+			} else {
+				c.prestartEphemeral[task.Name] = struct{}{}
+			}
+		}
+	}
+
+	c.unblock()
+	return c
+}
+
+// startConditionForTask returns a channel that is closed once the task can
+// start.
+func (c *taskHookCoordinator) startConditionForTask(task *structs.Task) <-chan struct{} {
+	if task.IsMainTask() {
+		return c.mainTaskCh
+	}
+
+	switch task.Lifecycle.Hook {
+	case structs.TaskLifecycleHookPoststart:
+		return c.poststartTaskCh
+	case structs.TaskLifecycleHookPoststop:
+		return c.poststopTaskCh
+	default:
+		return c.closedCh
+	}
+}
+
+// taskStateUpdated updates the tasks the gates are waiting on from the task
+// states of the allocation and opens the gates whose tasks are done.
+func (c *taskHookCoordinator) taskStateUpdated(states map[string]*structs.TaskState) {
+	for name := range c.prestartSidecar {
+		if st, ok := states[name]; ok && st.State == structs.TaskStateRunning {
+			delete(c.prestartSidecar, name)
+		}
+	}
+
+	for name := range c.prestartEphemeral {
+		if st, ok := states[name]; ok && st.State == structs.TaskStateDead && !st.Failed {
+			delete(c.prestartEphemeral, name)
+		}
+	}
+
+	for name := range c.mainTasksPending {
+		if st, ok := states[name]; ok && st.State != "" && st.State != structs.TaskStatePending {
+			delete(c.mainTasksPending, name)
+		}
+	}
+
+	for name := range c.mainTasksRunning {
+		if st, ok := states[name]; ok && st.State == structs.TaskStateDead {
+			delete(c.mainTasksRunning, name)
+		}
+	}
+
+	c.unblock()
+}
+
+// mainTasksDead returns whether all the main tasks are dead.
+func (c *taskHookCoordinator) mainTasksDead() bool {
+	return len(c.mainTasksRunning) == 0
+}
+
+// unblock opens the gates that have nothing left to wait on.
+func (c *taskHookCoordinator) unblock() {
+	if len(c.prestartSidecar) == 0 && len(c.prestartEphemeral) == 0 {
+		closeOnce(c.mainTaskCh)
+	}
+	if len(c.mainTasksPending) == 0 {
+		closeOnce(c.poststartTaskCh)
+	}
+	if len(c.mainTasksRunning) == 0 {
+		closeOnce(c.poststopTaskCh)
+	}
+}
+
+// closeOnce closes the channel unless it is already closed.
+func closeOnce(ch chan struct{}) {
+	select {
+	case <-ch:
+	default:
+		close(ch)
+	}
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func isChannelClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestTaskHookCoordinator_OnlyMainTasks(t *testing.T) {
+	tasks := []*structs.Task{
+		&structs.Task{Name: "web"},
+		&structs.Task{Name: "db"},
+	}
+	c := newTaskHookCoordinator(tasks)
+
+	for _, task := range tasks {
+		if !isChannelClosed(c.startConditionForTask(task)) {
+			t.Fatalf("task %q should be able to start", task.Name)
+		}
+	}
+}
+
+func TestTaskHookCoordinator_Lifecycle(t *testing.T) {
+	initTask := &structs.Task{
+		Name:      "init",
+		Lifecycle: &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPrestart},
+	}
+	proxyTask := &structs.Task{
+		Name:      "proxy",
+		Lifecycle: &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPrestart, Sidecar: true},
+	}
+	mainTask := &structs.Task{Name: "web"}
+	poststartTask := &structs.Task{
+		Name:      "register",
+		Lifecycle: &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPoststart},
+	}
+	poststopTask := &structs.Task{
+		Name:      "cleanup",
+		Lifecycle: &structs.TaskLifecycleConfig{Hook: structs.TaskLifecycleHookPoststop},
+	}
+	c := newTaskHookCoordinator([]*structs.Task{initTask, proxyTask, mainTask, poststartTask, poststopTask})
+
+	mainCh := c.startConditionForTask(mainTask)
+	poststartCh := c.startConditionForTask(poststartTask)
+	poststopCh := c.startConditionForTask(poststopTask)
+	if !isChannelClosed(c.startConditionForTask(initTask)) || !isChannelClosed(c.startConditionForTask(proxyTask)) {
+		t.Fatalf("prestart tasks should be able to start")
+	}
+	if isChannelClosed(mainCh) || isChannelClosed(poststartCh) || isChannelClosed(poststopCh) {
+		t.Fatalf("tasks should wait on the prestart tasks")
+	}
+
+	states := map[string]*structs.TaskState{
+		"init":     &structs.TaskState{State: structs.TaskStateRunning},
+		"proxy":    &structs.TaskState{State: structs.TaskStateRunning},
+		"web":      &structs.TaskState{State: structs.TaskStatePending},
+		"register": &structs.TaskState{State: structs.TaskStatePending},
+		"cleanup":  &structs.TaskState{State: structs.TaskStatePending},
+	}
+	c.taskStateUpdated(states)
+	if isChannelClosed(mainCh) {
+		t.Fatalf("main task should wait on the init task to complete")
+	}
+
+	// A failed prestart task doesn't unblock the main tasks
+	states["init"] = &structs.TaskState{State: structs.TaskStateDead, Failed: true}
+	c.taskStateUpdated(states)
+	if isChannelClosed(mainCh) {
+		t.Fatalf("main task should wait on the init task to succeed")
+	}
+
+	states["init"] = &structs.TaskState{State: structs.TaskStateDead}
+	c.taskStateUpdated(states)
+	if !isChannelClosed(mainCh) {
+		t.Fatalf("main task should be able to start")
+	}
+	if isChannelClosed(poststartCh) {
+		t.Fatalf("poststart task should wait on the main task")
+	}
+
+	states["web"].State = structs.TaskStateRunning
+	c.taskStateUpdated(states)
+	if !isChannelClosed(poststartCh) {
+		t.Fatalf("poststart task should be able to start")
+	}
+	if isChannelClosed(poststopCh) || c.mainTasksDead() {
+		t.Fatalf("poststop task should wait on the main task")
+	}
+
+	states["web"].State = structs.TaskStateDead
+	c.taskStateUpdated(states)
+	if !isChannelClosed(poststopCh) || !c.mainTasksDead() {
+		t.Fatalf("poststop task should be able to start")
+	}
+	if len(c.sidecars) != 1 || c.sidecars[0] != "proxy" {
+		t.Fatalf("bad: %v", c.sidecars)
+	}
+}
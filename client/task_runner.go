@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
@@ -15,6 +16,7 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/consul-template/signals"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver"
 	"github.com/hashicorp/nomad/client/getter"
@@ -85,6 +87,9 @@ type TaskRunner struct {
 	// downloaded
 	artifactsDownloaded bool
 
+	// payloadRendered tracks whether the payload has been written to disk
+	payloadRendered bool
+
 	// vaultFuture is the means to wait for and get a Vault token
 	vaultFuture *tokenFuture
 
@@ -100,6 +105,10 @@ type TaskRunner struct {
 	// startCh is used to trigger the start of the task
 	startCh chan struct{}
 
+	// startConditionCh is closed once the lifecycle of the task allows it to
+	// start. The task starts immediately if it is nil.
+	startConditionCh <-chan struct{}
+
 	// unblockCh is used to unblock the starting of the task
 	unblockCh   chan struct{}
 	unblocked   bool
@@ -163,6 +172,12 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 	}
 	restartTracker := newRestartTracker(tg.RestartPolicy, alloc.Job.Type)
 
+	// Tasks running before or after the main tasks run to completion and
+	// aren't restarted once they succeed
+	if task.Lifecycle != nil && !task.Lifecycle.Sidecar {
+		restartTracker.onSuccess = false
+	}
+
 	// Get the task directory
 	taskDir, ok := ctx.AllocDir.TaskDirs[task.Name]
 	if !ok {
@@ -198,6 +213,11 @@ func (r *TaskRunner) MarkReceived() {
 	r.updater(r.task.Name, structs.TaskStatePending, structs.NewTaskEvent(structs.TaskReceived))
 }
 
+// setStartCondition sets the channel whose closing allows the task to start.
+func (r *TaskRunner) setStartCondition(ch <-chan struct{}) {
+	r.startConditionCh = ch
+}
+
 // WaitCh returns a channel to wait for termination
 func (r *TaskRunner) WaitCh() <-chan struct{} {
 	return r.waitCh
@@ -346,7 +366,13 @@ func (r *TaskRunner) createDriver() (driver.Driver, error) {
 		return nil, fmt.Errorf("task environment not made for task %q in allocation %q", r.task.Name, r.alloc.ID)
 	}
 
-	driverCtx := driver.NewDriverContext(r.task.Name, r.config, r.config.Node, r.logger, env)
+	eventEmitter := func(m string, args ...interface{}) {
+		msg := fmt.Sprintf(m, args...)
+		r.logger.Printf("[DEBUG] client: driver event for alloc %q: %s", r.alloc.ID, msg)
+		r.setState("", structs.NewTaskEvent(structs.TaskDriverMessage).SetDriverMessage(msg))
+	}
+
+	driverCtx := driver.NewDriverContext(r.task.Name, r.config, r.config.Node, r.logger, env, eventEmitter)
 	driver, err := driver.NewDriver(r.task.Driver, driverCtx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create driver '%s' for alloc %s: %v",
@@ -377,6 +403,25 @@ func (r *TaskRunner) Run() {
 		return
 	}
 
+	// Wait for the lifecycle of the task group to allow the task to start.
+	// Restored tasks that are already running don't wait.
+	r.handleLock.Lock()
+	restored := r.handle != nil
+	r.handleLock.Unlock()
+	if !restored && r.startConditionCh != nil {
+		select {
+		case <-r.startConditionCh:
+		default:
+			r.logger.Printf("[DEBUG] client: task %q in alloc %q waiting on its lifecycle to start", r.task.Name, r.alloc.ID)
+			select {
+			case <-r.startConditionCh:
+			case <-r.destroyCh:
+				r.setState(structs.TaskStateDead, r.destroyEvent)
+				return
+			}
+		}
+	}
+
 	// If there is no Vault policy leave the static future created in
 	// NewTaskRunner
 	if r.task.Vault != nil {
@@ -704,6 +749,27 @@ func (r *TaskRunner) prestart(resultCh chan bool) {
 		return
 	}
 
+	// If the job is a dispatch job and there is a payload write it to disk
+	requirePayload := len(r.alloc.Job.Payload) != 0 &&
+		(r.task.DispatchPayload != nil && r.task.DispatchPayload.File != "")
+	if !r.payloadRendered && requirePayload {
+		renderTo := filepath.Join(r.taskDir, allocdir.TaskLocal, r.task.DispatchPayload.File)
+		err := os.MkdirAll(filepath.Dir(renderTo), 0777)
+		if err == nil {
+			err = ioutil.WriteFile(renderTo, r.alloc.Job.Payload, 0777)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to write dispatch payload: %v", err)
+			r.setState(
+				structs.TaskStateDead,
+				structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask())
+			resultCh <- false
+			return
+		}
+
+		r.payloadRendered = true
+	}
+
 	for {
 		// Download the task's artifacts
 		if !r.artifactsDownloaded && len(r.task.Artifacts) > 0 {
@@ -1224,6 +1290,25 @@ func (r *TaskRunner) Signal(source, reason string, s os.Signal) error {
 	return <-resCh
 }
 
+// ExecStreaming runs a command in the context of the running task and returns
+// its exit code.
+func (r *TaskRunner) ExecStreaming(ctx context.Context, opts *driver.ExecStreamingOptions) (int, error) {
+	r.handleLock.Lock()
+	handle := r.handle
+	r.handleLock.Unlock()
+	if handle == nil {
+		return 0, fmt.Errorf("task %q is not running", r.task.Name)
+	}
+
+	execHandle, ok := handle.(driver.ExecStreamingHandle)
+	if !ok {
+		return 0, fmt.Errorf("driver %q does not support exec", r.task.Driver)
+	}
+
+	r.logger.Printf("[DEBUG] client: executing %q in task %v for alloc %q", opts.Command, r.task.Name, r.alloc.ID)
+	return execHandle.ExecStreaming(ctx, opts)
+}
+
 // Kill will kill a task and store the error, no longer restarting the task. If
 // fail is set, the task is marked as having failed.
 func (r *TaskRunner) Kill(source, reason string, fail bool) {
@@ -1256,6 +1341,7 @@ func (r *TaskRunner) waitErrorToEvent(res *dstructs.WaitResult) *structs.TaskEve
 	return structs.NewTaskEvent(structs.TaskTerminated).
 		SetExitCode(res.ExitCode).
 		SetSignal(res.Signal).
+		SetOOMKilled(res.OOMKilled).
 		SetExitMessage(res.Err)
 }
 
@@ -1286,21 +1372,52 @@ func (r *TaskRunner) Destroy(event *structs.TaskEvent) {
 // emitStats emits resource usage stats of tasks to remote metrics collector
 // sinks
 func (r *TaskRunner) emitStats(ru *cstructs.TaskResourceUsage) {
-	if ru.ResourceUsage.MemoryStats != nil && r.config.PublishAllocationMetrics {
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "rss"}, float32(ru.ResourceUsage.MemoryStats.RSS))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "cache"}, float32(ru.ResourceUsage.MemoryStats.Cache))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "swap"}, float32(ru.ResourceUsage.MemoryStats.Swap))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "max_usage"}, float32(ru.ResourceUsage.MemoryStats.MaxUsage))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "kernel_usage"}, float32(ru.ResourceUsage.MemoryStats.KernelUsage))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "memory", "kernel_max_usage"}, float32(ru.ResourceUsage.MemoryStats.KernelMaxUsage))
-	}
-
-	if ru.ResourceUsage.CpuStats != nil && r.config.PublishAllocationMetrics {
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "total_percent"}, float32(ru.ResourceUsage.CpuStats.Percent))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "system"}, float32(ru.ResourceUsage.CpuStats.SystemMode))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "user"}, float32(ru.ResourceUsage.CpuStats.UserMode))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "throttled_time"}, float32(ru.ResourceUsage.CpuStats.ThrottledTime))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "throttled_periods"}, float32(ru.ResourceUsage.CpuStats.ThrottledPeriods))
-		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "cpu", "total_ticks"}, float32(ru.ResourceUsage.CpuStats.TotalTicks))
+	if !r.config.PublishAllocationMetrics {
+		return
+	}
+	key := r.allocMetricsKey()
+
+	if ms := ru.ResourceUsage.MemoryStats; ms != nil {
+		metrics.SetGauge(append(key, "memory", "rss"), float32(ms.RSS))
+		metrics.SetGauge(append(key, "memory", "cache"), float32(ms.Cache))
+		metrics.SetGauge(append(key, "memory", "swap"), float32(ms.Swap))
+		metrics.SetGauge(append(key, "memory", "max_usage"), float32(ms.MaxUsage))
+		metrics.SetGauge(append(key, "memory", "kernel_usage"), float32(ms.KernelUsage))
+		metrics.SetGauge(append(key, "memory", "kernel_max_usage"), float32(ms.KernelMaxUsage))
+	}
+
+	if cs := ru.ResourceUsage.CpuStats; cs != nil {
+		metrics.SetGauge(append(key, "cpu", "total_percent"), float32(cs.Percent))
+		metrics.SetGauge(append(key, "cpu", "system"), float32(cs.SystemMode))
+		metrics.SetGauge(append(key, "cpu", "user"), float32(cs.UserMode))
+		metrics.SetGauge(append(key, "cpu", "throttled_time"), float32(cs.ThrottledTime))
+		metrics.SetGauge(append(key, "cpu", "throttled_periods"), float32(cs.ThrottledPeriods))
+		metrics.SetGauge(append(key, "cpu", "total_ticks"), float32(cs.TotalTicks))
+	}
+}
+
+// allocMetricsKey returns the prefix of the keys of the metrics of the task,
+// made of the configured allocation metrics labels. The returned slice has no
+// spare capacity so that each append to it allocates a new key.
+func (r *TaskRunner) allocMetricsKey() []string {
+	key := []string{"client", "allocs"}
+	for _, label := range r.config.AllocationMetricsLabels {
+		switch label {
+		case "namespace":
+			namespace := r.alloc.Job.Namespace
+			if namespace == "" {
+				namespace = structs.DefaultNamespace
+			}
+			key = append(key, namespace)
+		case "job":
+			key = append(key, r.alloc.Job.Name)
+		case "task_group":
+			key = append(key, r.alloc.TaskGroup)
+		case "alloc_id":
+			key = append(key, r.alloc.ID)
+		case "task":
+			key = append(key, r.task.Name)
+		}
 	}
+	return key[:len(key):len(key)]
 }
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"syscall"
 	"testing"
 	"time"
@@ -64,7 +65,7 @@ func testTaskRunnerFromAlloc(restarts bool, alloc *structs.Allocation) (*MockTas
 	task := alloc.Job.TaskGroups[0].Tasks[0]
 	// Initialize the port listing. This should be done by the offer process but
 	// we have a mock so that doesn't happen.
-	task.Resources.Networks[0].ReservedPorts = []structs.Port{{"", 80}}
+	task.Resources.Networks[0].ReservedPorts = []structs.Port{{"", 80, 0}}
 
 	allocDir := allocdir.NewAllocDir(filepath.Join(conf.AllocDir, alloc.ID))
 	allocDir.Build([]*structs.Task{task})
@@ -1244,3 +1245,72 @@ func TestTaskRunner_VaultManager_Signal(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	})
 }
+
+func TestTaskRunner_SimpleRun_Dispatch(t *testing.T) {
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"exit_code": "0",
+		"run_for":   "1s",
+	}
+	fileName := "test"
+	task.DispatchPayload = &structs.DispatchPayloadConfig{
+		File: fileName,
+	}
+	alloc.Job.ParameterizedJob = &structs.ParameterizedJobConfig{}
+
+	// Add the payload
+	expected := []byte("hello world")
+	alloc.Job.Payload = expected
+
+	upd, tr := testTaskRunnerFromAlloc(false, alloc)
+	tr.MarkReceived()
+	defer tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	defer tr.ctx.AllocDir.Destroy()
+	go tr.Run()
+
+	select {
+	case <-tr.WaitCh():
+	case <-time.After(time.Duration(testutil.TestMultiplier()*15) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	if len(upd.events) != 3 {
+		t.Fatalf("should have 3 updates: %#v", upd.events)
+	}
+
+	if upd.state != structs.TaskStateDead {
+		t.Fatalf("TaskState %v; want %v", upd.state, structs.TaskStateDead)
+	}
+
+	// Check that the file was written to disk properly
+	payloadPath := filepath.Join(tr.taskDir, allocdir.TaskLocal, fileName)
+	data, err := ioutil.ReadFile(payloadPath)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Fatalf("Bad; got %v; want %v", string(data), string(expected))
+	}
+}
+
+func TestTaskRunner_AllocMetricsKey(t *testing.T) {
+	alloc := mock.Alloc()
+	_, tr := testTaskRunnerFromAlloc(false, alloc)
+	defer tr.ctx.AllocDir.Destroy()
+
+	key := tr.allocMetricsKey()
+	expected := []string{"client", "allocs", alloc.Job.Name, alloc.TaskGroup, alloc.ID, tr.task.Name}
+	if !reflect.DeepEqual(key, expected) {
+		t.Fatalf("bad: %#v", key)
+	}
+
+	// Only the configured labels are included
+	tr.config.AllocationMetricsLabels = []string{"namespace", "job"}
+	key = tr.allocMetricsKey()
+	expected = []string{"client", "allocs", structs.DefaultNamespace, alloc.Job.Name}
+	if !reflect.DeepEqual(key, expected) {
+		t.Fatalf("bad: %#v", key)
+	}
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type ACLCommand struct {
+	Meta
+}
+
+func (f *ACLCommand) Help() string {
+	helpText := `
+Usage: nomad acl <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with ACL policies and tokens.
+  Users can bootstrap Nomad's ACL system, create policies that restrict access,
+  and generate tokens from those policies.
+
+Subcommands:
+
+  bootstrap  Bootstrap the ACL system for initial token
+  policy     Interact with ACL policies
+  token      Interact with ACL tokens
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *ACLCommand) Synopsis() string {
+	return "Interact with ACL policies and tokens"
+}
+
+func (f *ACLCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
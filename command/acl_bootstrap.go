@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type ACLBootstrapCommand struct {
+	Meta
+}
+
+func (c *ACLBootstrapCommand) Help() string {
+	helpText := `
+Usage: nomad acl bootstrap [options]
+
+  Bootstrap is used to bootstrap the ACL system and get an initial token. The
+  bootstrap token is a management token and may only be created once.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLBootstrapCommand) Synopsis() string {
+	return "Bootstrap the ACL system for initial token"
+}
+
+func (c *ACLBootstrapCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("acl bootstrap", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Get the bootstrap token
+	token, _, err := client.ACLTokens().Bootstrap(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error bootstrapping: %s", err))
+		return 1
+	}
+
+	// Format the output
+	c.Ui.Output(formatKVACLToken(token))
+	return 0
+}
+
+// formatKVACLToken returns a K/V formatted ACL token
+func formatKVACLToken(token *api.ACLToken) string {
+	output := []string{
+		fmt.Sprintf("Accessor ID|%s", token.AccessorID),
+		fmt.Sprintf("Secret ID|%s", token.SecretID),
+		fmt.Sprintf("Name|%s", token.Name),
+		fmt.Sprintf("Type|%s", token.Type),
+		fmt.Sprintf("Policies|%v", token.Policies),
+		fmt.Sprintf("Create Time|%v", token.CreateTime),
+		fmt.Sprintf("Create Index|%d", token.CreateIndex),
+		fmt.Sprintf("Modify Index|%d", token.ModifyIndex),
+	}
+	return formatKV(output)
+}
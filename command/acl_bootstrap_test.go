@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/mitchellh/cli"
+)
+
+func TestACLBootstrapCommand_Implements(t *testing.T) {
+	var _ cli.Command = &ACLBootstrapCommand{}
+}
+
+func TestACLBootstrapCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, func(c *testutil.TestServerConfig) {
+		c.ACL.Enabled = true
+	})
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &ACLBootstrapCommand{Meta: Meta{Ui: ui}}
+
+	// The first bootstrap succeeds
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Secret ID") || !strings.Contains(out, "management") {
+		t.Fatalf("expected token output, got: %s", out)
+	}
+
+	// A second bootstrap fails
+	if code := cmd.Run([]string{"-address=" + url}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error bootstrapping") {
+		t.Fatalf("expected bootstrap error, got: %s", out)
+	}
+}
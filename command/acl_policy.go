@@ -0,0 +1,37 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type ACLPolicyCommand struct {
+	Meta
+}
+
+func (f *ACLPolicyCommand) Help() string {
+	helpText := `
+Usage: nomad acl policy <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with ACL policies. Policies
+  are written in HCL and grant capabilities on namespaces as well as access to
+  nodes, operator endpoints and quotas.
+
+Subcommands:
+
+  apply      Create or update an ACL policy
+  delete     Delete an existing ACL policy
+  info       Fetch info on an existing ACL policy
+  list       List ACL policies
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *ACLPolicyCommand) Synopsis() string {
+	return "Interact with ACL policies"
+}
+
+func (f *ACLPolicyCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
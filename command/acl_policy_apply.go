@@ -0,0 +1,90 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type ACLPolicyApplyCommand struct {
+	Meta
+}
+
+func (c *ACLPolicyApplyCommand) Help() string {
+	helpText := `
+Usage: nomad acl policy apply [options] <name> <path>
+
+  Apply is used to create or update an ACL policy. The policy is sourced from
+  <path> or from stdin if path is "-".
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Apply Options:
+
+  -description
+    Specifies a human readable description for the policy.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLPolicyApplyCommand) Synopsis() string {
+	return "Create or update an ACL policy"
+}
+
+func (c *ACLPolicyApplyCommand) Run(args []string) int {
+	var description string
+
+	flags := c.Meta.FlagSet("acl policy apply", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&description, "description", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly two arguments
+	args = flags.Args()
+	if len(args) != 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Read the policy
+	var raw []byte
+	var err error
+	if path := args[1]; path == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading policy: %s", err))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	policy := &api.ACLPolicy{
+		Name:        name,
+		Description: description,
+		Rules:       string(raw),
+	}
+	if _, err := client.ACLPolicies().Upsert(policy, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error writing ACL policy: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully wrote %q ACL policy!", name))
+	return 0
+}
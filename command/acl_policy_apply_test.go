@@ -0,0 +1,68 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestACLPolicyApplyCommand_Implements(t *testing.T) {
+	var _ cli.Command = &ACLPolicyApplyCommand{}
+}
+
+func TestACLPolicyApplyCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &ACLPolicyApplyCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+}
+
+func TestACLPolicyApplyCommand_Run(t *testing.T) {
+	srv, client, url, root := testACLServer(t)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &ACLPolicyApplyCommand{Meta: Meta{Ui: ui}}
+
+	f, err := ioutil.TempFile("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(f.Name())
+	rules := `namespace "default" { policy = "read" }`
+	if _, err := f.WriteString(rules); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	f.Close()
+
+	// Writing without a token fails
+	if code := cmd.Run([]string{"-address=" + url, "foo", f.Name()}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+
+	// Write the policy
+	args := []string{"-address=" + url, "-token=" + root.SecretID, "-description=test", "foo", f.Name()}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Successfully wrote") {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+
+	policy, _, err := client.ACLPolicies().Info("foo", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if policy.Rules != rules || policy.Description != "test" {
+		t.Fatalf("bad: %#v", policy)
+	}
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestACLPolicyDeleteCommand_Implements(t *testing.T) {
+	var _ cli.Command = &ACLPolicyDeleteCommand{}
+}
+
+func TestACLPolicyDeleteCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &ACLPolicyDeleteCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+}
+
+func TestACLPolicyDeleteCommand_Run(t *testing.T) {
+	srv, client, url, root := testACLServer(t)
+	defer srv.Stop()
+
+	// Create a policy
+	policy := &api.ACLPolicy{
+		Name:  "foo",
+		Rules: `namespace "default" { policy = "read" }`,
+	}
+	if _, err := client.ACLPolicies().Upsert(policy, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &ACLPolicyDeleteCommand{Meta: Meta{Ui: ui}}
+
+	if code := cmd.Run([]string{"-address=" + url, "-token=" + root.SecretID, "foo"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Successfully deleted") {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+
+	if _, _, err := client.ACLPolicies().Info("foo", nil); err == nil {
+		t.Fatalf("expected error querying deleted policy")
+	}
+}
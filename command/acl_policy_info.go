@@ -0,0 +1,68 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ACLPolicyInfoCommand struct {
+	Meta
+}
+
+func (c *ACLPolicyInfoCommand) Help() string {
+	helpText := `
+Usage: nomad acl policy info [options] <name>
+
+  Info is used to fetch information on an existing ACL policy.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLPolicyInfoCommand) Synopsis() string {
+	return "Fetch info on an existing ACL policy"
+}
+
+func (c *ACLPolicyInfoCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("acl policy info", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one policy
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	policy, _, err := client.ACLPolicies().Info(name, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error fetching ACL policy: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("Name|%s", policy.Name),
+		fmt.Sprintf("Description|%s", policy.Description),
+		fmt.Sprintf("Create Index|%d", policy.CreateIndex),
+		fmt.Sprintf("Modify Index|%d", policy.ModifyIndex),
+	}
+	c.Ui.Output(formatKV(basic))
+	c.Ui.Output(c.Colorize().Color("\n[bold]Rules[reset]\n"))
+	c.Ui.Output(strings.TrimSpace(policy.Rules))
+	return 0
+}
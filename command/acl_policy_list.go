@@ -0,0 +1,115 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type ACLPolicyListCommand struct {
+	Meta
+}
+
+func (c *ACLPolicyListCommand) Help() string {
+	helpText := `
+Usage: nomad acl policy list [options]
+
+  List is used to list the ACL policies of the cluster.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Options:
+
+  -json
+    Output the ACL policies in their JSON format.
+
+  -t
+    Format and display the ACL policies using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLPolicyListCommand) Synopsis() string {
+	return "List ACL policies"
+}
+
+func (c *ACLPolicyListCommand) Run(args []string) int {
+	var json bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("acl policy list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	policies, _, err := client.ACLPolicies().List(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving ACL policies: %s", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(policies)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(policies) == 0 {
+		c.Ui.Output("No policies found")
+		return 0
+	}
+
+	c.Ui.Output(formatACLPolicies(policies))
+	return 0
+}
+
+// formatACLPolicies formats a list of ACL policies as a table
+func formatACLPolicies(policies []*api.ACLPolicyListStub) string {
+	rows := make([]string, len(policies)+1)
+	rows[0] = "Name|Description"
+	for i, p := range policies {
+		rows[i+1] = fmt.Sprintf("%s|%s", p.Name, p.Description)
+	}
+	return formatList(rows)
+}
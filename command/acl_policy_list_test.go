@@ -0,0 +1,55 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestACLPolicyListCommand_Implements(t *testing.T) {
+	var _ cli.Command = &ACLPolicyListCommand{}
+}
+
+func TestACLPolicyListCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &ACLPolicyListCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+}
+
+func TestACLPolicyListCommand_Run(t *testing.T) {
+	srv, client, url, root := testACLServer(t)
+	defer srv.Stop()
+
+	// Create a policy
+	policy := &api.ACLPolicy{
+		Name:  "foo",
+		Rules: `namespace "default" { policy = "read" }`,
+	}
+	if _, err := client.ACLPolicies().Upsert(policy, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &ACLPolicyListCommand{Meta: Meta{Ui: ui}}
+
+	// Listing without a token fails
+	if code := cmd.Run([]string{"-address=" + url}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+
+	if code := cmd.Run([]string{"-address=" + url, "-token=" + root.SecretID}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, policy.Name) {
+		t.Fatalf("expected policy in output, got: %s", out)
+	}
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type ACLTokenCommand struct {
+	Meta
+}
+
+func (f *ACLTokenCommand) Help() string {
+	helpText := `
+Usage: nomad acl token <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with ACL tokens. Management
+  tokens have full access to the cluster while client tokens are restricted to
+  the capabilities granted by their policies.
+
+Subcommands:
+
+  create     Create a new ACL token
+  delete     Delete an existing ACL token
+  info       Fetch information on an existing ACL token
+  list       List ACL tokens
+  self       Lookup self ACL token
+  update     Update an existing ACL token
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *ACLTokenCommand) Synopsis() string {
+	return "Interact with ACL tokens"
+}
+
+func (f *ACLTokenCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
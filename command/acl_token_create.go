@@ -0,0 +1,87 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
+)
+
+type ACLTokenCreateCommand struct {
+	Meta
+}
+
+func (c *ACLTokenCreateCommand) Help() string {
+	helpText := `
+Usage: nomad acl token create [options]
+
+  Create is used to issue new ACL tokens. Requires a management token.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Create Options:
+
+  -name=""
+    Sets the human readable name for the ACL token.
+
+  -type="client"
+    Sets the type of token. Must be one of "client" (default), or "management".
+
+  -policy=""
+    Specifies a policy to associate with the token. Can be specified multiple
+    times, but only with client type tokens.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLTokenCreateCommand) Synopsis() string {
+	return "Create a new ACL token"
+}
+
+func (c *ACLTokenCreateCommand) Run(args []string) int {
+	var name, tokenType string
+	var policies flaghelper.StringFlag
+
+	flags := c.Meta.FlagSet("acl token create", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&name, "name", "", "")
+	flags.StringVar(&tokenType, "type", "client", "")
+	flags.Var(&policies, "policy", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Create the token
+	tk := &api.ACLToken{
+		Name:     name,
+		Type:     tokenType,
+		Policies: policies,
+	}
+	token, _, err := client.ACLTokens().Create(tk, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error creating token: %s", err))
+		return 1
+	}
+
+	// Format the output
+	c.Ui.Output(formatKVACLToken(token))
+	return 0
+}
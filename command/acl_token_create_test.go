@@ -0,0 +1,49 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestACLTokenCreateCommand_Implements(t *testing.T) {
+	var _ cli.Command = &ACLTokenCreateCommand{}
+}
+
+func TestACLTokenCreateCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &ACLTokenCreateCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+}
+
+func TestACLTokenCreateCommand_Run(t *testing.T) {
+	srv, client, url, root := testACLServer(t)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &ACLTokenCreateCommand{Meta: Meta{Ui: ui}}
+
+	args := []string{"-address=" + url, "-token=" + root.SecretID, "-name=foo", "-policy=foo1", "-policy=foo2"}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "foo1") || !strings.Contains(out, "client") {
+		t.Fatalf("expected token output, got: %s", out)
+	}
+
+	tokens, _, err := client.ACLTokens().List(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(tokens) != 2 {
+		t.Fatalf("bad: %#v", tokens)
+	}
+}
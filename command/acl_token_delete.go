@@ -0,0 +1,59 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ACLTokenDeleteCommand struct {
+	Meta
+}
+
+func (c *ACLTokenDeleteCommand) Help() string {
+	helpText := `
+Usage: nomad acl token delete [options] <token_accessor_id>
+
+  Delete is used to delete an existing ACL token. Requires a management token.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLTokenDeleteCommand) Synopsis() string {
+	return "Delete an existing ACL token"
+}
+
+func (c *ACLTokenDeleteCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("acl token delete", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one accessor
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	accessor := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if _, err := client.ACLTokens().Delete(accessor, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error deleting token: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Token %s successfully deleted", accessor))
+	return 0
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestACLTokenDeleteCommand_Implements(t *testing.T) {
+	var _ cli.Command = &ACLTokenDeleteCommand{}
+}
+
+func TestACLTokenDeleteCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &ACLTokenDeleteCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+}
+
+func TestACLTokenDeleteCommand_Run(t *testing.T) {
+	srv, client, url, root := testACLServer(t)
+	defer srv.Stop()
+
+	// Create a token
+	token, _, err := client.ACLTokens().Create(&api.ACLToken{
+		Name:     "foo",
+		Type:     "client",
+		Policies: []string{"foo1"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &ACLTokenDeleteCommand{Meta: Meta{Ui: ui}}
+
+	args := []string{"-address=" + url, "-token=" + root.SecretID, token.AccessorID}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+
+	if _, _, err := client.ACLTokens().Info(token.AccessorID, nil); err == nil {
+		t.Fatalf("expected error querying deleted token")
+	}
+}
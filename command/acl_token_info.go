@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ACLTokenInfoCommand struct {
+	Meta
+}
+
+func (c *ACLTokenInfoCommand) Help() string {
+	helpText := `
+Usage: nomad acl token info [options] <token_accessor_id>
+
+  Info is used to fetch information on an existing ACL token. Requires a
+  management token unless the token being fetched is the one in use.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLTokenInfoCommand) Synopsis() string {
+	return "Fetch information on an existing ACL token"
+}
+
+func (c *ACLTokenInfoCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("acl token info", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one accessor
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	accessor := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	token, _, err := client.ACLTokens().Info(accessor, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error fetching token: %s", err))
+		return 1
+	}
+
+	// Format the output
+	c.Ui.Output(formatKVACLToken(token))
+	return 0
+}
@@ -0,0 +1,120 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type ACLTokenListCommand struct {
+	Meta
+}
+
+func (c *ACLTokenListCommand) Help() string {
+	helpText := `
+Usage: nomad acl token list [options]
+
+  List is used to list the ACL tokens of the cluster. Requires a management
+  token.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Options:
+
+  -json
+    Output the ACL tokens in their JSON format.
+
+  -t
+    Format and display the ACL tokens using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLTokenListCommand) Synopsis() string {
+	return "List ACL tokens"
+}
+
+func (c *ACLTokenListCommand) Run(args []string) int {
+	var json bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("acl token list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	tokens, _, err := client.ACLTokens().List(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving ACL tokens: %s", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(tokens)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(tokens) == 0 {
+		c.Ui.Output("No tokens found")
+		return 0
+	}
+
+	c.Ui.Output(formatACLTokens(tokens))
+	return 0
+}
+
+// formatACLTokens formats a list of ACL tokens as a table
+func formatACLTokens(tokens []*api.ACLTokenListStub) string {
+	rows := make([]string, len(tokens)+1)
+	rows[0] = "Name|Type|Accessor ID|Policies"
+	for i, t := range tokens {
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s",
+			t.Name,
+			t.Type,
+			t.AccessorID,
+			strings.Join(t.Policies, ","))
+	}
+	return formatList(rows)
+}
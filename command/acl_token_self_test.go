@@ -0,0 +1,52 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestACLTokenSelfCommand_Implements(t *testing.T) {
+	var _ cli.Command = &ACLTokenSelfCommand{}
+}
+
+func TestACLTokenSelfCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &ACLTokenSelfCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+}
+
+func TestACLTokenSelfCommand_Run(t *testing.T) {
+	srv, client, url, _ := testACLServer(t)
+	defer srv.Stop()
+
+	// Create a token
+	token, _, err := client.ACLTokens().Create(&api.ACLToken{
+		Name:     "foo",
+		Type:     "client",
+		Policies: []string{"foo1"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &ACLTokenSelfCommand{Meta: Meta{Ui: ui}}
+
+	// Lookup the token using itself
+	if code := cmd.Run([]string{"-address=" + url, "-token=" + token.SecretID}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, token.AccessorID) {
+		t.Fatalf("expected token output, got: %s", out)
+	}
+}
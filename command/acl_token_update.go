@@ -0,0 +1,101 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
+)
+
+type ACLTokenUpdateCommand struct {
+	Meta
+}
+
+func (c *ACLTokenUpdateCommand) Help() string {
+	helpText := `
+Usage: nomad acl token update [options] <token_accessor_id>
+
+  Update is used to update an existing ACL token. Requires a management token.
+  Only the given fields are changed.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Update Options:
+
+  -name=""
+    Sets the human readable name for the ACL token.
+
+  -type=""
+    Sets the type of token. Must be one of "client" or "management".
+
+  -policy=""
+    Specifies a policy to associate with the token. Can be specified multiple
+    times, but only with client type tokens. Replaces the existing policies.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *ACLTokenUpdateCommand) Synopsis() string {
+	return "Update an existing ACL token"
+}
+
+func (c *ACLTokenUpdateCommand) Run(args []string) int {
+	var name, tokenType string
+	var policies flaghelper.StringFlag
+
+	flags := c.Meta.FlagSet("acl token update", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&name, "name", "", "")
+	flags.StringVar(&tokenType, "type", "", "")
+	flags.Var(&policies, "policy", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one accessor
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	accessor := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Get the specified token
+	token, _, err := client.ACLTokens().Info(accessor, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error fetching token: %s", err))
+		return 1
+	}
+
+	// Apply the changes
+	if name != "" {
+		token.Name = name
+	}
+	if tokenType != "" {
+		token.Type = tokenType
+	}
+	if len(policies) != 0 {
+		token.Policies = policies
+	}
+
+	// Update the token
+	updated, _, err := client.ACLTokens().Update(token, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error updating token: %s", err))
+		return 1
+	}
+
+	// Format the output
+	c.Ui.Output(formatKVACLToken(updated))
+	return 0
+}
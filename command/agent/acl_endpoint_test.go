@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func aclBootstrap(t *testing.T, s *TestServer) *structs.ACLToken {
+	req, err := http.NewRequest("PUT", "/v1/acl/bootstrap", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	respW := httptest.NewRecorder()
+	obj, err := s.Server.ACLTokenBootstrap(respW, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return obj.(*structs.ACLToken)
+}
+
+func setToken(req *http.Request, token *structs.ACLToken) {
+	req.Header.Set("X-Nomad-Token", token.SecretID)
+}
+
+// createTestToken upserts a policy with the given rules along with a client
+// token tied to it.
+func createTestToken(t *testing.T, s *TestServer, index uint64, rules string) *structs.ACLToken {
+	policy := mock.ACLPolicy()
+	policy.Rules = rules
+	policy.SetHash()
+	state := s.Agent.server.State()
+	if err := state.UpsertACLPolicies(index, []*structs.ACLPolicy{policy}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	token := mock.ACLToken()
+	token.Policies = []string{policy.Name}
+	if err := state.UpsertACLTokens(index+1, []*structs.ACLToken{token}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return token
+}
+
+func TestHTTP_ACLPolicyList(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		p1 := mock.ACLPolicy()
+		p2 := mock.ACLPolicy()
+		if err := state.UpsertACLPolicies(1000, []*structs.ACLPolicy{p1, p2}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/acl/policies", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		setToken(req, root)
+
+		// Make the request
+		obj, err := s.Server.ACLPoliciesRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the policies
+		out := obj.([]*structs.ACLPolicy)
+		if len(out) != 2 {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_ACLPolicyCRUD(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+		policy := mock.ACLPolicy()
+
+		// Create the policy without a token, expect failure
+		req, err := http.NewRequest("PUT", "/v1/acl/policy/"+policy.Name, encodeReq(policy))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.ACLPolicySpecificRequest(respW, req); err == nil {
+			t.Fatalf("expected permission denied")
+		}
+
+		// Create the policy
+		req, err = http.NewRequest("PUT", "/v1/acl/policy/"+policy.Name, encodeReq(policy))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.ACLPolicySpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Query the policy
+		req, err = http.NewRequest("GET", "/v1/acl/policy/"+policy.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.ACLPolicySpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out := obj.(*structs.ACLPolicy)
+		if out.Name != policy.Name || out.Rules != policy.Rules {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// Delete the policy
+		req, err = http.NewRequest("DELETE", "/v1/acl/policy/"+policy.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.ACLPolicySpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// The policy is gone
+		req, err = http.NewRequest("GET", "/v1/acl/policy/"+policy.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.ACLPolicySpecificRequest(respW, req); err == nil {
+			t.Fatalf("expected not found")
+		}
+	})
+}
+
+func TestHTTP_ACLTokenBootstrap(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		token := aclBootstrap(t, s)
+		if token.Type != structs.ACLManagementToken || token.SecretID == "" {
+			t.Fatalf("bad: %#v", token)
+		}
+
+		// Bootstrapping a second time fails
+		req, err := http.NewRequest("PUT", "/v1/acl/bootstrap", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.ACLTokenBootstrap(respW, req); err == nil {
+			t.Fatalf("expected bootstrap error")
+		}
+	})
+}
+
+func TestHTTP_ACLTokenList(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		token := mock.ACLToken()
+		if err := state.UpsertACLTokens(1000, []*structs.ACLToken{token}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		req, err := http.NewRequest("GET", "/v1/acl/tokens", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.ACLTokensRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the tokens, including the bootstrap token
+		out := obj.([]*structs.ACLToken)
+		if len(out) != 2 {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_ACLTokenCRUD(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+		token := mock.ACLToken()
+		token.AccessorID = ""
+		token.SecretID = ""
+
+		// Create the token
+		req, err := http.NewRequest("PUT", "/v1/acl/token", encodeReq(token))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.ACLTokenCreateRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		created := obj.(*structs.ACLToken)
+		if created.AccessorID == "" || created.SecretID == "" {
+			t.Fatalf("bad: %#v", created)
+		}
+
+		// Update the token
+		created.Name = "updated"
+		req, err = http.NewRequest("PUT", "/v1/acl/token/"+created.AccessorID, encodeReq(created))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.ACLTokenSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Query the token
+		req, err = http.NewRequest("GET", "/v1/acl/token/"+created.AccessorID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.ACLTokenSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out := obj.(*structs.ACLToken)
+		if out.Name != "updated" || out.SecretID != created.SecretID {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// Query the token using itself
+		req, err = http.NewRequest("GET", "/v1/acl/token/self", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, created)
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.ACLTokenSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		self := obj.(*structs.ACLToken)
+		if self.AccessorID != created.AccessorID {
+			t.Fatalf("bad: %#v", self)
+		}
+
+		// Delete the token
+		req, err = http.NewRequest("DELETE", "/v1/acl/token/"+created.AccessorID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.ACLTokenSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
@@ -14,6 +14,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/client"
 	clientconfig "github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/command/agent/consul"
@@ -42,6 +44,10 @@ type Agent struct {
 	logger    *log.Logger
 	logOutput io.Writer
 
+	// logWriter buffers the logs of the agent and streams them to the
+	// /v1/agent/monitor endpoint
+	logWriter *logWriter
+
 	// consulSyncer registers the Nomad agent with the Consul Agent
 	consulSyncer *consul.Syncer
 
@@ -49,6 +55,20 @@ type Agent struct {
 
 	server *nomad.Server
 
+	// inmemSink and prometheusSink hold the metrics served by the
+	// /v1/metrics endpoint. The Prometheus sink is only set if enabled.
+	inmemSink      *metrics.InmemSink
+	prometheusSink *prometheusSink
+
+	// auditor records the requests to the HTTP API if the audit log is
+	// enabled
+	auditor *auditor
+
+	// reloadCh is used by the /v1/agent/reload endpoint to request that the
+	// agent command re-reads its configuration. The result of the reload is
+	// sent on the provided channel.
+	reloadCh chan chan error
+
 	shutdown     bool
 	shutdownCh   chan struct{}
 	shutdownLock sync.Mutex
@@ -60,12 +80,20 @@ func NewAgent(config *Config, logOutput io.Writer) (*Agent, error) {
 		config:     config,
 		logger:     log.New(logOutput, "", log.LstdFlags|log.Lmicroseconds),
 		logOutput:  logOutput,
+		reloadCh:   make(chan chan error),
 		shutdownCh: make(chan struct{}),
 	}
 
 	if err := a.setupConsulSyncer(); err != nil {
 		return nil, fmt.Errorf("Failed to initialize Consul syncer task: %v", err)
 	}
+	if config.Audit != nil && config.Audit.Enabled {
+		auditor, err := newAuditor(config.Audit)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to initialize the audit log: %v", err)
+		}
+		a.auditor = auditor
+	}
 	if err := a.setupServer(); err != nil {
 		return nil, err
 	}
@@ -165,6 +193,34 @@ func (a *Agent) serverConfig() (*nomad.Config, error) {
 		}
 		conf.NodeGCThreshold = dur
 	}
+	if gcThreshold := a.config.Server.JobGCThreshold; gcThreshold != "" {
+		dur, err := time.ParseDuration(gcThreshold)
+		if err != nil {
+			return nil, err
+		}
+		conf.JobGCThreshold = dur
+	}
+	if gcThreshold := a.config.Server.EvalGCThreshold; gcThreshold != "" {
+		dur, err := time.ParseDuration(gcThreshold)
+		if err != nil {
+			return nil, err
+		}
+		conf.EvalGCThreshold = dur
+	}
+	if gcThreshold := a.config.Server.AllocGCThreshold; gcThreshold != "" {
+		dur, err := time.ParseDuration(gcThreshold)
+		if err != nil {
+			return nil, err
+		}
+		conf.AllocGCThreshold = dur
+	}
+	if gcThreshold := a.config.Server.DeploymentGCThreshold; gcThreshold != "" {
+		dur, err := time.ParseDuration(gcThreshold)
+		if err != nil {
+			return nil, err
+		}
+		conf.DeploymentGCThreshold = dur
+	}
 
 	if heartbeatGrace := a.config.Server.HeartbeatGrace; heartbeatGrace != "" {
 		dur, err := time.ParseDuration(heartbeatGrace)
@@ -174,6 +230,14 @@ func (a *Agent) serverConfig() (*nomad.Config, error) {
 		conf.HeartbeatGrace = dur
 	}
 
+	if agingInterval := a.config.Server.EvalAgingInterval; agingInterval != "" {
+		dur, err := time.ParseDuration(agingInterval)
+		if err != nil {
+			return nil, err
+		}
+		conf.EvalAgingInterval = dur
+	}
+
 	if a.config.Consul.AutoAdvertise && a.config.Consul.ServerServiceName == "" {
 		return nil, fmt.Errorf("server_service_name must be set when auto_advertise is enabled")
 	}
@@ -182,9 +246,30 @@ func (a *Agent) serverConfig() (*nomad.Config, error) {
 	conf.ConsulConfig = a.config.Consul
 	conf.VaultConfig = a.config.Vault
 
+	// Set the ACL config
+	if a.config.ACL != nil {
+		conf.ACLEnabled = a.config.ACL.Enabled
+	}
+
 	// Set the TLS config
 	conf.TLSConfig = a.config.TLSConfig
 
+	// Set the Autopilot config used to initialize a new cluster
+	if autopilot := a.config.Autopilot; autopilot != nil {
+		if autopilot.CleanupDeadServers != nil {
+			conf.AutopilotConfig.CleanupDeadServers = *autopilot.CleanupDeadServers
+		}
+		if autopilot.LastContactThreshold != 0 {
+			conf.AutopilotConfig.LastContactThreshold = autopilot.LastContactThreshold
+		}
+		if autopilot.MaxTrailingLogs != 0 {
+			conf.AutopilotConfig.MaxTrailingLogs = uint64(autopilot.MaxTrailingLogs)
+		}
+		if autopilot.ServerStabilizationTime != 0 {
+			conf.AutopilotConfig.ServerStabilizationTime = autopilot.ServerStabilizationTime
+		}
+	}
+
 	return conf, nil
 }
 
@@ -247,6 +332,28 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	}
 	conf.ClientMaxPort = uint(a.config.Client.ClientMaxPort)
 	conf.ClientMinPort = uint(a.config.Client.ClientMinPort)
+	conf.CNIPath = a.config.Client.CNIPath
+	conf.BridgeNetworkName = a.config.Client.BridgeNetworkName
+	conf.BridgeNetworkSubnet = a.config.Client.BridgeNetworkSubnet
+
+	// Setup the garbage collection of terminal allocations
+	if a.config.Client.GCInterval != "" {
+		dur, err := time.ParseDuration(a.config.Client.GCInterval)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing gc interval: %s", err)
+		}
+		conf.GCInterval = dur
+	}
+	if a.config.Client.GCMaxAllocAge != "" {
+		dur, err := time.ParseDuration(a.config.Client.GCMaxAllocAge)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing gc max alloc age: %s", err)
+		}
+		conf.GCMaxAllocAge = dur
+	}
+	conf.GCDiskUsageThreshold = a.config.Client.GCDiskUsageThreshold
+	conf.GCInodeUsageThreshold = a.config.Client.GCInodeUsageThreshold
+	conf.GCMaxAllocs = a.config.Client.GCMaxAllocs
 
 	// Setup the node
 	conf.Node = new(structs.Node)
@@ -255,19 +362,25 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	conf.Node.Meta = a.config.Client.Meta
 	conf.Node.NodeClass = a.config.Client.NodeClass
 
+	// Expose the host volumes to the task groups requesting them
+	if len(a.config.Client.HostVolumes) > 0 {
+		conf.Node.HostVolumes = make(map[string]*structs.ClientHostVolumeConfig, len(a.config.Client.HostVolumes))
+		for _, v := range a.config.Client.HostVolumes {
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid host_volume %q: %v", v.Name, err)
+			}
+			conf.Node.HostVolumes[v.Name] = v.Copy()
+		}
+	}
+
 	// Set up the HTTP advertise address
 	conf.Node.HTTPAddr = a.config.AdvertiseAddrs.HTTP
 
 	// Reserve resources on the node.
-	r := conf.Node.Reserved
-	if r == nil {
-		r = new(structs.Resources)
-		conf.Node.Reserved = r
-	}
-	r.CPU = a.config.Client.Reserved.CPU
-	r.MemoryMB = a.config.Client.Reserved.MemoryMB
-	r.DiskMB = a.config.Client.Reserved.DiskMB
-	r.IOPS = a.config.Client.Reserved.IOPS
+	if conf.Node.Reserved == nil {
+		conf.Node.Reserved = new(structs.Resources)
+	}
+	setReservedResources(conf.Node.Reserved, a.config.Client.Reserved)
 	conf.GloballyReservedPorts = a.config.Client.Reserved.ParsedReservedPorts
 
 	conf.Version = fmt.Sprintf("%s%s", a.config.Version, a.config.VersionPrerelease)
@@ -282,6 +395,9 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	conf.StatsCollectionInterval = a.config.Telemetry.collectionInterval
 	conf.PublishNodeMetrics = a.config.Telemetry.PublishNodeMetrics
 	conf.PublishAllocationMetrics = a.config.Telemetry.PublishAllocationMetrics
+	if labels := a.config.Telemetry.AllocationMetricsLabels; len(labels) != 0 {
+		conf.AllocationMetricsLabels = labels
+	}
 
 	// Set the TLS related configs
 	conf.TLSConfig = a.config.TLSConfig
@@ -560,6 +676,45 @@ func (a *Agent) Leave() error {
 	return nil
 }
 
+// Reload applies the reloadable parts of a new configuration to the running
+// server and client. The TLS certificates are re-read and the client's
+// reserved resources updated; running allocations are not restarted.
+func (a *Agent) Reload(newConfig *Config) error {
+	if newConfig.TLSConfig.EnableHTTP != a.config.TLSConfig.EnableHTTP {
+		return fmt.Errorf("Enabling or disabling TLS for HTTP requires a restart")
+	}
+
+	if a.server != nil {
+		conf := *a.server.GetConfig()
+		conf.TLSConfig = newConfig.TLSConfig
+		if err := a.server.Reload(&conf); err != nil {
+			return fmt.Errorf("Failed to reload server: %v", err)
+		}
+	}
+
+	if a.client != nil {
+		conf := a.client.GetConfig()
+		conf.TLSConfig = newConfig.TLSConfig
+		if conf.Node.Reserved == nil {
+			conf.Node.Reserved = new(structs.Resources)
+		}
+		setReservedResources(conf.Node.Reserved, newConfig.Client.Reserved)
+		if err := a.client.Reload(conf); err != nil {
+			return fmt.Errorf("Failed to reload client: %v", err)
+		}
+	}
+	return nil
+}
+
+// setReservedResources sets the node's reserved resources to those reserved
+// in the agent's client configuration.
+func setReservedResources(r *structs.Resources, reserved *Resources) {
+	r.CPU = reserved.CPU
+	r.MemoryMB = reserved.MemoryMB
+	r.DiskMB = reserved.DiskMB
+	r.IOPS = reserved.IOPS
+}
+
 // Shutdown is used to terminate the agent.
 func (a *Agent) Shutdown() error {
 	a.shutdownLock.Lock()
@@ -584,6 +739,11 @@ func (a *Agent) Shutdown() error {
 	if err := a.consulSyncer.Shutdown(); err != nil {
 		a.logger.Printf("[ERR] agent: shutting down consul service failed: %v", err)
 	}
+	if a.auditor != nil {
+		if err := a.auditor.Close(); err != nil {
+			a.logger.Printf("[ERR] agent: closing the audit log failed: %v", err)
+		}
+	}
 
 	a.logger.Println("[INFO] agent: shutdown complete")
 	a.shutdown = true
@@ -599,6 +759,17 @@ func (a *Agent) RPC(method string, args interface{}, reply interface{}) error {
 	return a.client.RPC(method, args, reply)
 }
 
+// SnapshotRPC performs a snapshot request against the local server, or
+// forwards it to one of the servers of the client. The returned reader
+// streams the snapshot of a save request and must be closed by the caller.
+func (a *Agent) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader,
+	reply *structs.SnapshotResponse) (io.ReadCloser, error) {
+	if a.server != nil {
+		return a.server.SnapshotRPC(args, in, reply)
+	}
+	return a.client.SnapshotRPC(args, in, reply)
+}
+
 // Client returns the configured client or nil
 func (a *Agent) Client() *client.Client {
 	return a.client
@@ -628,6 +799,19 @@ func (a *Agent) Stats() map[string]map[string]string {
 	return stats
 }
 
+// resolveToken resolves the secret ID of a request to the endpoints served by
+// the agent into an ACL object, nil if ACLs are disabled. Servers resolve it
+// from their state while clients query the servers.
+func (a *Agent) resolveToken(secretID string) (*acl.ACL, error) {
+	if !a.config.ACL.Enabled {
+		return nil, nil
+	}
+	if a.server != nil {
+		return a.server.ResolveToken(secretID)
+	}
+	return a.client.ResolveToken(secretID)
+}
+
 // setupConsulSyncer creates the Consul tasks used by this Nomad Agent
 // (either Client or Server mode).
 func (a *Agent) setupConsulSyncer() error {
@@ -1,10 +1,16 @@
 package agent
 
 import (
+	"fmt"
 	"net"
 	"net/http"
+	"net/http/pprof"
+	runtimepprof "runtime/pprof"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/hashicorp/logutils"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/serf/serf"
 )
@@ -44,6 +50,16 @@ func (s *HTTPServer) AgentSelfRequest(resp http.ResponseWriter, req *http.Reques
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
 
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentRead() {
+		return nil, structs.ErrPermissionDenied
+	}
+
 	// Get the member as a server
 	var member serf.Member
 	srv := s.agent.Server()
@@ -63,6 +79,16 @@ func (s *HTTPServer) AgentJoinRequest(resp http.ResponseWriter, req *http.Reques
 	if req.Method != "PUT" && req.Method != "POST" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
 	srv := s.agent.Server()
 	if srv == nil {
 		return nil, CodedError(501, ErrInvalidMethod)
@@ -88,6 +114,17 @@ func (s *HTTPServer) AgentMembersRequest(resp http.ResponseWriter, req *http.Req
 	if req.Method != "GET" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentRead() {
+		return nil, structs.ErrPermissionDenied
+	}
+
 	args := &structs.GenericRequest{}
 	var out structs.ServerMembersResponse
 	if err := s.agent.RPC("Status.Members", args, &out); err != nil {
@@ -101,6 +138,16 @@ func (s *HTTPServer) AgentForceLeaveRequest(resp http.ResponseWriter, req *http.
 	if req.Method != "PUT" && req.Method != "POST" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
 	srv := s.agent.Server()
 	if srv == nil {
 		return nil, CodedError(501, ErrInvalidMethod)
@@ -113,7 +160,7 @@ func (s *HTTPServer) AgentForceLeaveRequest(resp http.ResponseWriter, req *http.
 	}
 
 	// Attempt remove
-	err := srv.RemoveFailedNode(node)
+	err = srv.RemoveFailedNode(node)
 	return nil, err
 }
 
@@ -132,6 +179,16 @@ func (s *HTTPServer) AgentServersRequest(resp http.ResponseWriter, req *http.Req
 }
 
 func (s *HTTPServer) listServers(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentRead() {
+		return nil, structs.ErrPermissionDenied
+	}
+
 	client := s.agent.Client()
 	if client == nil {
 		return nil, CodedError(501, ErrInvalidMethod)
@@ -142,6 +199,16 @@ func (s *HTTPServer) listServers(resp http.ResponseWriter, req *http.Request) (i
 }
 
 func (s *HTTPServer) updateServers(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
+
 	client := s.agent.Client()
 	if client == nil {
 		return nil, CodedError(501, ErrInvalidMethod)
@@ -163,8 +230,20 @@ func (s *HTTPServer) updateServers(resp http.ResponseWriter, req *http.Request)
 	return nil, nil
 }
 
-// KeyringOperationRequest allows an operator to install/delete/use keys
+// KeyringOperationRequest allows an operator to install/delete/use keys. Every
+// operation, including listing the keys, requires an agent:write token when
+// ACLs are enabled.
 func (s *HTTPServer) KeyringOperationRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
+
 	srv := s.agent.Server()
 	if srv == nil {
 		return nil, CodedError(501, ErrInvalidMethod)
@@ -172,7 +251,6 @@ func (s *HTTPServer) KeyringOperationRequest(resp http.ResponseWriter, req *http
 
 	kmgr := srv.KeyManager()
 	var sresp *serf.KeyResponse
-	var err error
 
 	// Get the key from the req body
 	var args structs.KeyringRequest
@@ -213,6 +291,174 @@ func (s *HTTPServer) KeyringOperationRequest(resp http.ResponseWriter, req *http
 	return kresp, nil
 }
 
+// monitorBufferSize is the number of log lines buffered for a monitor before
+// lines are dropped
+const monitorBufferSize = 512
+
+// AgentMonitor streams the logs of the agent, starting with the recent ones,
+// until the client disconnects. The log_level parameter sets the minimum
+// level of the streamed logs and defaults to INFO. The endpoint requires an
+// agent:read token when ACLs are enabled.
+func (s *HTTPServer) AgentMonitor(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentRead() {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	if s.agent.logWriter == nil {
+		return nil, CodedError(501, "agent logs are not available")
+	}
+
+	filter := LevelFilter()
+	if logLevel := req.URL.Query().Get("log_level"); logLevel != "" {
+		filter.MinLevel = logutils.LogLevel(strings.ToUpper(logLevel))
+	}
+	if !ValidateLevelFilter(filter.MinLevel, filter) {
+		return nil, CodedError(400, fmt.Sprintf("invalid log level %q, must be one of %v",
+			filter.MinLevel, filter.Levels))
+	}
+
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		return nil, CodedError(500, "streaming is not supported")
+	}
+
+	handler := &monitorLogHandler{
+		logCh:  make(chan string, monitorBufferSize),
+		filter: filter,
+	}
+	s.agent.logWriter.RegisterHandler(handler)
+	defer s.agent.logWriter.DeregisterHandler(handler)
+
+	resp.Header().Set("Content-Type", "text/plain")
+	resp.WriteHeader(200)
+	flusher.Flush()
+
+	for {
+		select {
+		case line := <-handler.logCh:
+			if dropped := atomic.SwapUint64(&handler.dropped, 0); dropped > 0 {
+				line = fmt.Sprintf("[WARN] agent: monitor dropped %d log lines\n%s", dropped, line)
+			}
+			if _, err := resp.Write([]byte(line + "\n")); err != nil {
+				return nil, nil
+			}
+			flusher.Flush()
+		case <-req.Context().Done():
+			return nil, nil
+		case <-s.agent.shutdownCh:
+			return nil, nil
+		}
+	}
+}
+
+// monitorLogHandler passes the logs of the agent at the level of the filter
+// to a monitor. It never blocks the logger, so lines are dropped if the
+// monitor falls behind.
+type monitorLogHandler struct {
+	// dropped is first to be aligned for atomic operations
+	dropped uint64
+	logCh   chan string
+	filter  *logutils.LevelFilter
+}
+
+func (h *monitorLogHandler) HandleLog(line string) {
+	if !h.filter.Check([]byte(line)) {
+		return
+	}
+	select {
+	case h.logCh <- line:
+	default:
+		atomic.AddUint64(&h.dropped, 1)
+	}
+}
+
+// AgentPprofRequest serves the runtime profiles of the agent. The endpoint
+// requires enable_debug, and an agent:write token when ACLs are enabled.
+func (s *HTTPServer) AgentPprofRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if !s.agent.config.EnableDebug {
+		return nil, CodedError(403, "profiling requires enable_debug")
+	}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	profile := strings.TrimPrefix(req.URL.Path, "/v1/agent/pprof/")
+	switch profile {
+	case "cmdline":
+		pprof.Cmdline(resp, req)
+	case "profile":
+		pprof.Profile(resp, req)
+	case "symbol":
+		pprof.Symbol(resp, req)
+	case "trace":
+		pprof.Trace(resp, req)
+	default:
+		if runtimepprof.Lookup(profile) == nil {
+			return nil, CodedError(404, fmt.Sprintf("unknown profile %q", profile))
+		}
+		pprof.Handler(profile).ServeHTTP(resp, req)
+	}
+	return nil, nil
+}
+
+// agentReloadTimeout is how long a reload request waits for the agent
+// command to pick it up
+const agentReloadTimeout = 10 * time.Second
+
+// AgentReloadRequest asks the agent to re-read its configuration files and
+// apply the reloadable settings, as if it had received a SIGHUP. It requires
+// an agent:write token when ACLs are enabled.
+func (s *HTTPServer) AgentReloadRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowAgentWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	// Hand the request to the agent command, which owns the configuration
+	errCh := make(chan error, 1)
+	select {
+	case s.agent.reloadCh <- errCh:
+	case <-time.After(agentReloadTimeout):
+		return nil, CodedError(503, "timed out waiting for the agent to accept the reload")
+	case <-s.agent.shutdownCh:
+		return nil, CodedError(503, "agent is shutting down")
+	}
+
+	if err := <-errCh; err != nil {
+		return nil, CodedError(500, err.Error())
+	}
+	return nil, nil
+}
+
 type agentSelf struct {
 	Config *Config                      `json:"config"`
 	Member Member                       `json:"member,omitempty"`
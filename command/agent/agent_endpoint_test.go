@@ -1,14 +1,17 @@
 package agent
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -38,6 +41,148 @@ func TestHTTP_AgentSelf(t *testing.T) {
 	})
 }
 
+func TestHTTP_AgentMonitor(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		logWriter := NewLogWriter(16)
+		s.Agent.logWriter = logWriter
+		logWriter.Write([]byte("[DEBUG] agent: old debug line"))
+		logWriter.Write([]byte("[WARN] agent: old warn line"))
+
+		// An invalid level is rejected
+		req, err := http.NewRequest("GET", "/v1/agent/monitor?log_level=foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.AgentMonitor(httptest.NewRecorder(), req)
+		if code, ok := err.(HTTPCodedError); !ok || code.Code() != 400 {
+			t.Fatalf("bad: %v", err)
+		}
+
+		resp, err := http.Get("http://" + s.Server.addr + "/v1/agent/monitor?log_level=warn")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("bad: %d", resp.StatusCode)
+		}
+		logWriter.Write([]byte("[INFO] agent: new info line"))
+		logWriter.Write([]byte("[ERR] agent: new error line\n"))
+
+		// The lines below the level are filtered
+		scanner := bufio.NewScanner(resp.Body)
+		for _, expected := range []string{"[WARN] agent: old warn line", "[ERR] agent: new error line"} {
+			if !scanner.Scan() {
+				t.Fatalf("err: %v", scanner.Err())
+			}
+			if line := scanner.Text(); line != expected {
+				t.Fatalf("bad: %q", line)
+			}
+		}
+	})
+}
+
+func TestHTTP_AgentMonitor_ACL(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+		s.Agent.logWriter = NewLogWriter(16)
+
+		// Tokens without agent:read are denied
+		token := createTestToken(t, s, 1000, `node { policy = "read" }`)
+		req, err := http.NewRequest("GET", "/v1/agent/monitor", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, token)
+		respW := httptest.NewRecorder()
+		s.Server.mux.ServeHTTP(respW, req)
+		if respW.Code != 403 {
+			t.Fatalf("bad: %d", respW.Code)
+		}
+
+		// Management tokens are allowed
+		req, err = http.NewRequest("GET", "http://"+s.Server.addr+"/v1/agent/monitor", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			t.Fatalf("bad: %d", resp.StatusCode)
+		}
+	})
+}
+
+func TestHTTP_AgentPprof(t *testing.T) {
+	// Profiling requires enable_debug
+	httpTest(t, func(c *Config) { c.EnableDebug = false }, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/agent/pprof/goroutine", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.AgentPprofRequest(httptest.NewRecorder(), req)
+		if code, ok := err.(HTTPCodedError); !ok || code.Code() != 403 {
+			t.Fatalf("bad: %v", err)
+		}
+	})
+
+	cb := func(c *Config) {
+		c.EnableDebug = true
+		c.ACL.Enabled = true
+	}
+	httpTest(t, cb, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+
+		// Tokens without agent:write are denied
+		policy := mock.ACLPolicy()
+		policy.Rules = `agent { policy = "read" }`
+		token := mock.ACLToken()
+		token.Policies = []string{policy.Name}
+		state := s.Agent.server.State()
+		if err := state.UpsertACLPolicies(1000, []*structs.ACLPolicy{policy}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertACLTokens(1010, []*structs.ACLToken{token}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		req, err := http.NewRequest("GET", "/v1/agent/pprof/goroutine?debug=1", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, token)
+		_, err = s.Server.AgentPprofRequest(httptest.NewRecorder(), req)
+		if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+			t.Fatalf("expected permission denied: %v", err)
+		}
+
+		// Management tokens are allowed
+		setToken(req, root)
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.AgentPprofRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !strings.Contains(respW.Body.String(), "goroutine profile:") {
+			t.Fatalf("bad: %s", respW.Body.String())
+		}
+
+		// Unknown profiles are not found
+		req, err = http.NewRequest("GET", "/v1/agent/pprof/foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		_, err = s.Server.AgentPprofRequest(httptest.NewRecorder(), req)
+		if code, ok := err.(HTTPCodedError); !ok || code.Code() != 404 {
+			t.Fatalf("bad: %v", err)
+		}
+	})
+}
+
 func TestHTTP_AgentJoin(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Determine the join address
@@ -289,3 +434,106 @@ func TestHTTP_AgentRemoveKey(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_AgentReload(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Only PUT and POST are allowed
+		req, err := http.NewRequest("GET", "/v1/agent/reload", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.AgentReloadRequest(httptest.NewRecorder(), req)
+		if code, ok := err.(HTTPCodedError); !ok || code.Code() != 405 {
+			t.Fatalf("bad: %v", err)
+		}
+
+		// Act as the agent command, failing the first reload
+		go func() {
+			errCh := <-s.Agent.reloadCh
+			errCh <- fmt.Errorf("bad config")
+			errCh = <-s.Agent.reloadCh
+			errCh <- nil
+		}()
+
+		req, err = http.NewRequest("PUT", "/v1/agent/reload", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.AgentReloadRequest(httptest.NewRecorder(), req)
+		if code, ok := err.(HTTPCodedError); !ok || code.Code() != 500 {
+			t.Fatalf("bad: %v", err)
+		}
+
+		if _, err := s.Server.AgentReloadRequest(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_Agent_ACL(t *testing.T) {
+	key1 := "HS5lJ+XuTlYKWaeGYyG+/A=="
+	key2 := "wH1Bn9hlJ0emgWB1JttVRA=="
+
+	httpTest(t, func(c *Config) {
+		c.ACL.Enabled = true
+		c.Server.EncryptKey = key1
+	}, func(s *TestServer) {
+		readToken := createTestToken(t, s, 1000, `agent { policy = "read" }`)
+		writeToken := createTestToken(t, s, 1010, `agent { policy = "write" }`)
+
+		cases := []struct {
+			method  string
+			url     string
+			body    interface{}
+			handler func(http.ResponseWriter, *http.Request) (interface{}, error)
+			write   bool
+		}{
+			{"GET", "/v1/agent/self", nil, s.Server.AgentSelfRequest, false},
+			{"GET", "/v1/agent/members", nil, s.Server.AgentMembersRequest, false},
+			{"GET", "/v1/agent/servers", nil, s.Server.AgentServersRequest, false},
+			{"PUT", "/v1/agent/join?address=127.0.0.1:1", nil, s.Server.AgentJoinRequest, true},
+			{"PUT", "/v1/agent/force-leave?node=foo", nil, s.Server.AgentForceLeaveRequest, true},
+			{"PUT", "/v1/agent/servers?address=127.0.0.1:4647", nil, s.Server.AgentServersRequest, true},
+			{"GET", "/v1/agent/keyring/list", nil, s.Server.KeyringOperationRequest, true},
+			{"PUT", "/v1/agent/keyring/install", &structs.KeyringRequest{Key: key2}, s.Server.KeyringOperationRequest, true},
+			{"PUT", "/v1/agent/keyring/use", &structs.KeyringRequest{Key: key2}, s.Server.KeyringOperationRequest, true},
+			{"PUT", "/v1/agent/keyring/remove", &structs.KeyringRequest{Key: key1}, s.Server.KeyringOperationRequest, true},
+		}
+
+		for _, c := range cases {
+			newReq := func(token *structs.ACLToken) *http.Request {
+				var body io.Reader
+				if c.body != nil {
+					body = encodeReq(c.body)
+				}
+				req, err := http.NewRequest(c.method, c.url, body)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				if token != nil {
+					setToken(req, token)
+				}
+				return req
+			}
+
+			// Anonymous requests are denied
+			_, err := c.handler(httptest.NewRecorder(), newReq(nil))
+			if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+				t.Fatalf("%s %s: expected permission denied, got %v", c.method, c.url, err)
+			}
+
+			// agent:read is enough for the reads only
+			_, err = c.handler(httptest.NewRecorder(), newReq(readToken))
+			denied := err != nil && err.Error() == structs.ErrPermissionDenied.Error()
+			if denied != c.write {
+				t.Fatalf("%s %s: bad result with read token: %v", c.method, c.url, err)
+			}
+
+			// agent:write is allowed everything
+			_, err = c.handler(httptest.NewRecorder(), newReq(writeToken))
+			if err != nil && err.Error() == structs.ErrPermissionDenied.Error() {
+				t.Fatalf("%s %s: denied with write token", c.method, c.url)
+			}
+		}
+	})
+}
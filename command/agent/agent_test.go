@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad/nomad"
+	"github.com/hashicorp/nomad/nomad/structs"
 	sconfig "github.com/hashicorp/nomad/nomad/structs/config"
 )
 
@@ -98,6 +99,31 @@ func TestAgent_RPCPing(t *testing.T) {
 	}
 }
 
+func TestAgent_Reload(t *testing.T) {
+	dir, agent := makeAgent(t, nil)
+	defer os.RemoveAll(dir)
+	defer agent.Shutdown()
+
+	// Toggling TLS for HTTP isn't supported
+	newConf := DevConfig()
+	newConf.TLSConfig.EnableHTTP = true
+	if err := agent.Reload(newConf); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Reserved resources are applied to the running client
+	newConf = DevConfig()
+	newConf.Client.Reserved.CPU = 300
+	newConf.Client.Reserved.MemoryMB = 128
+	if err := agent.Reload(newConf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	reserved := agent.Client().Node().Reserved
+	if reserved.CPU != 300 || reserved.MemoryMB != 128 {
+		t.Fatalf("bad: %#v", reserved)
+	}
+}
+
 func TestAgent_ServerConfig(t *testing.T) {
 	conf := DefaultConfig()
 	conf.DevMode = true // allow localhost for advertise addrs
@@ -230,6 +256,36 @@ func TestAgent_ServerConfig(t *testing.T) {
 		t.Fatalf("expect 10s, got: %s", threshold)
 	}
 
+	conf.Server.DeploymentGCThreshold = "42g"
+	if err := conf.normalizeAddrs(); err != nil {
+		t.Fatalf("error normalizing config: %v", err)
+	}
+	out, err = a.serverConfig()
+	if err == nil || !strings.Contains(err.Error(), "unknown unit") {
+		t.Fatalf("expected unknown unit error, got: %#v", err)
+	}
+
+	conf.Server.JobGCThreshold = "1h"
+	conf.Server.EvalGCThreshold = "2h"
+	conf.Server.AllocGCThreshold = "3h"
+	conf.Server.DeploymentGCThreshold = "4h"
+	if err := conf.normalizeAddrs(); err != nil {
+		t.Fatalf("error normalizing config: %v", err)
+	}
+	out, err = a.serverConfig()
+	if threshold := out.JobGCThreshold; threshold != time.Hour {
+		t.Fatalf("expect 1h, got: %s", threshold)
+	}
+	if threshold := out.EvalGCThreshold; threshold != 2*time.Hour {
+		t.Fatalf("expect 2h, got: %s", threshold)
+	}
+	if threshold := out.AllocGCThreshold; threshold != 3*time.Hour {
+		t.Fatalf("expect 3h, got: %s", threshold)
+	}
+	if threshold := out.DeploymentGCThreshold; threshold != 4*time.Hour {
+		t.Fatalf("expect 4h, got: %s", threshold)
+	}
+
 	conf.Server.HeartbeatGrace = "42g"
 	if err := conf.normalizeAddrs(); err != nil {
 		t.Fatalf("error normalizing config: %v", err)
@@ -248,6 +304,15 @@ func TestAgent_ServerConfig(t *testing.T) {
 		t.Fatalf("expect 37s, got: %s", threshold)
 	}
 
+	conf.Server.EvalAgingInterval = "2m"
+	if err := conf.normalizeAddrs(); err != nil {
+		t.Fatalf("error normalizing config: %v", err)
+	}
+	out, err = a.serverConfig()
+	if interval := out.EvalAgingInterval; interval != 2*time.Minute {
+		t.Fatalf("expect 2m, got: %s", interval)
+	}
+
 	// Defaults to the global bind addr
 	conf.Addresses.RPC = ""
 	conf.Addresses.Serf = ""
@@ -357,3 +422,32 @@ func TestAgent_ClientConfig(t *testing.T) {
 		t.Fatalf("Expected http addr: %v, got: %v", expectedHttpAddr, c.Node.HTTPAddr)
 	}
 }
+
+func TestAgent_ClientConfig_HostVolumes(t *testing.T) {
+	conf := DefaultConfig()
+	conf.DevMode = true
+	a := &Agent{config: conf}
+	conf.Client.Enabled = true
+	conf.Client.HostVolumes = []*structs.ClientHostVolumeConfig{
+		{Name: "certs", Path: "/etc/ssl/certs", ReadOnly: true},
+	}
+
+	if err := conf.normalizeAddrs(); err != nil {
+		t.Fatalf("error normalizing config: %v", err)
+	}
+	c, err := a.clientConfig()
+	if err != nil {
+		t.Fatalf("got err: %v", err)
+	}
+
+	vol, ok := c.Node.HostVolumes["certs"]
+	if !ok || vol.Path != "/etc/ssl/certs" || !vol.ReadOnly {
+		t.Fatalf("bad: %#v", c.Node.HostVolumes)
+	}
+
+	// Relative paths are rejected
+	conf.Client.HostVolumes[0].Path = "certs"
+	if _, err := a.clientConfig(); err == nil || !strings.Contains(err.Error(), "must be absolute") {
+		t.Fatalf("expected path error, got: %v", err)
+	}
+}
@@ -1,16 +1,29 @@
 package agent
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/client/driver"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/websocket"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 const (
 	allocNotFoundErr    = "allocation not found"
 	resourceNotFoundErr = "resource not found"
+
+	// execCloseTimeout is how long the closing handshake of an exec session
+	// is waited for before the connection is closed.
+	execCloseTimeout = 5 * time.Second
 )
 
 func (s *HTTPServer) AllocsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -36,7 +49,12 @@ func (s *HTTPServer) AllocsRequest(resp http.ResponseWriter, req *http.Request)
 }
 
 func (s *HTTPServer) AllocSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
-	allocID := strings.TrimPrefix(req.URL.Path, "/v1/allocation/")
+	path := strings.TrimPrefix(req.URL.Path, "/v1/allocation/")
+	if strings.HasPrefix(path, "stop/") {
+		return s.allocStop(resp, req, strings.TrimPrefix(path, "stop/"))
+	}
+
+	allocID := path
 	if req.Method != "GET" {
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
@@ -60,6 +78,24 @@ func (s *HTTPServer) AllocSpecificRequest(resp http.ResponseWriter, req *http.Re
 	return out.Alloc, nil
 }
 
+func (s *HTTPServer) allocStop(resp http.ResponseWriter, req *http.Request, allocID string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.AllocStopRequest{
+		AllocID: allocID,
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.AllocStopResponse
+	if err := s.agent.RPC("Alloc.Stop", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
 func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if s.agent.client == nil {
 		return nil, clientNotRunning
@@ -79,12 +115,79 @@ func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Requ
 		return s.allocStats(allocID, resp, req)
 	case "snapshot":
 		return s.allocSnapshot(allocID, resp, req)
+	case "restart":
+		return s.allocRestart(allocID, resp, req)
+	case "signal":
+		return s.allocSignal(allocID, resp, req)
+	case "exec":
+		return s.allocExec(allocID, resp, req)
 	}
 
 	return nil, CodedError(404, resourceNotFoundErr)
 }
 
+// ClientGCRequest garbage collects the terminal allocations of the client. The
+// endpoint requires a node:write token when ACLs are enabled.
+func (s *HTTPServer) ClientGCRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if s.agent.client == nil {
+		return nil, clientNotRunning
+	}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowNodeWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	s.agent.client.CollectAllAllocs()
+	return nil, nil
+}
+
+// checkAllocOperation resolves the token of the request and returns
+// ErrPermissionDenied if it is granted none of the given capabilities in the
+// namespace of the allocation. Allocations unknown to the client are checked
+// against the default namespace.
+func (s *HTTPServer) checkAllocOperation(req *http.Request, allocID string, ops ...string) error {
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return err
+	}
+	if aclObj == nil {
+		return nil
+	}
+
+	namespace, err := s.agent.client.AllocNamespace(allocID)
+	if err != nil {
+		namespace = structs.DefaultNamespace
+	}
+	for _, op := range ops {
+		if aclObj.AllowNamespaceOperation(namespace, op) {
+			return nil
+		}
+	}
+	return structs.ErrPermissionDenied
+}
+
+// allocSnapshot streams the data of the allocation. Besides read-fs tokens, it
+// accepts the migrate token of the node the data is migrated to.
 func (s *HTTPServer) allocSnapshot(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var secretID string
+	s.parseToken(req, &secretID)
+	if !s.agent.Client().ValidateMigrateToken(allocID, secretID) {
+		if err := s.checkAllocOperation(req, allocID, acl.NamespaceCapabilityReadFS); err != nil {
+			return nil, err
+		}
+	}
+
 	allocFS, err := s.agent.Client().GetAllocFS(allocID)
 	if err != nil {
 		return nil, fmt.Errorf(allocNotFoundErr)
@@ -95,7 +198,57 @@ func (s *HTTPServer) allocSnapshot(allocID string, resp http.ResponseWriter, req
 	return nil, nil
 }
 
+func (s *HTTPServer) allocRestart(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if err := s.checkAllocOperation(req, allocID, acl.NamespaceCapabilityAllocLifecycle,
+		acl.NamespaceCapabilitySubmitJob); err != nil {
+		return nil, err
+	}
+
+	// The body is optional as all the tasks are restarted by default
+	var args cstructs.AllocRestartRequest
+	if req.ContentLength != 0 {
+		if err := decodeBody(req, &args); err != nil {
+			return nil, CodedError(400, err.Error())
+		}
+	}
+
+	if err := s.agent.Client().RestartAlloc(allocID, args.TaskName); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *HTTPServer) allocSignal(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	if err := s.checkAllocOperation(req, allocID, acl.NamespaceCapabilityAllocLifecycle,
+		acl.NamespaceCapabilitySubmitJob); err != nil {
+		return nil, err
+	}
+
+	var args cstructs.AllocSignalRequest
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.Signal == "" {
+		return nil, CodedError(400, "missing signal")
+	}
+
+	if err := s.agent.Client().SignalAlloc(allocID, args.TaskName, args.Signal); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
 func (s *HTTPServer) allocStats(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if err := s.checkAllocOperation(req, allocID, acl.NamespaceCapabilityReadJob); err != nil {
+		return nil, err
+	}
+
 	clientStats := s.agent.client.StatsReporter()
 	aStats, err := clientStats.GetAllocStats(allocID)
 	if err != nil {
@@ -105,3 +258,129 @@ func (s *HTTPServer) allocStats(allocID string, resp http.ResponseWriter, req *h
 	task := req.URL.Query().Get("task")
 	return aStats.LatestAllocStats(task)
 }
+
+// allocExec runs a command in a task of the allocation and streams its
+// input and output over a websocket connection. As it grants a shell in the
+// task, the alloc-exec capability is never implied by a namespace policy.
+func (s *HTTPServer) allocExec(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if err := s.checkAllocOperation(req, allocID, acl.NamespaceCapabilityAllocExec); err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	task := q.Get("task")
+	if task == "" {
+		return nil, CodedError(400, "missing task")
+	}
+
+	var command []string
+	if err := json.Unmarshal([]byte(q.Get("command")), &command); err != nil {
+		return nil, CodedError(400, fmt.Sprintf("invalid command: %v", err))
+	}
+	if len(command) == 0 {
+		return nil, CodedError(400, "missing command")
+	}
+
+	tty := false
+	if v := q.Get("tty"); v != "" {
+		var err error
+		if tty, err = strconv.ParseBool(v); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("invalid tty: %v", err))
+		}
+	}
+
+	conn, err := websocket.Upgrade(resp, req)
+	if err == websocket.ErrBadOrigin {
+		return nil, CodedError(403, err.Error())
+	} else if err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	defer conn.Close()
+
+	s.execStream(conn, allocID, task, tty, command)
+	return nil, nil
+}
+
+// execStream runs the command, relaying the input frames of the connection
+// to it and its output and exit code back, then closes the connection.
+func (s *HTTPServer) execStream(conn *websocket.Conn, allocID, task string, tty bool, command []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdinR, stdinW := io.Pipe()
+	resizeCh := make(chan cstructs.TerminalSize)
+	readerDoneCh := make(chan struct{})
+	go func() {
+		defer close(readerDoneCh)
+		defer stdinW.Close()
+		for {
+			var input cstructs.ExecStreamingInput
+			if err := conn.ReadJSON(&input); err != nil {
+				// The client is gone so the command is stopped
+				cancel()
+				return
+			}
+
+			if input.Stdin != nil {
+				if len(input.Stdin.Data) != 0 {
+					stdinW.Write(input.Stdin.Data)
+				}
+				if input.Stdin.Close {
+					stdinW.Close()
+				}
+			}
+			if input.TTYSize != nil {
+				select {
+				case resizeCh <- *input.TTYSize:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+
+	opts := &driver.ExecStreamingOptions{
+		Command:  command,
+		Tty:      tty,
+		Stdin:    stdinR,
+		Stdout:   &execOutputWriter{conn: conn},
+		Stderr:   &execOutputWriter{conn: conn, stderr: true},
+		ResizeCh: resizeCh,
+	}
+	code, err := s.agent.Client().ExecAlloc(ctx, allocID, task, opts)
+	stdinR.Close()
+	if err != nil {
+		s.logger.Printf("[ERR] http: failed to exec in task %q of alloc %q: %v", task, allocID, err)
+		conn.WriteClose(websocket.CloseInternalServerErr, err.Error())
+	} else {
+		conn.WriteJSON(&cstructs.ExecStreamingOutput{
+			Exited: true,
+			Result: &cstructs.ExecStreamingExitResult{ExitCode: code},
+		})
+		conn.WriteClose(websocket.CloseNormalClosure, "")
+	}
+
+	// Wait for the client to acknowledge the close
+	select {
+	case <-readerDoneCh:
+	case <-time.After(execCloseTimeout):
+	}
+}
+
+// execOutputWriter sends the data written to it as output frames of an exec
+// session.
+type execOutputWriter struct {
+	conn   *websocket.Conn
+	stderr bool
+}
+
+func (w *execOutputWriter) Write(p []byte) (int, error) {
+	op := &cstructs.ExecStreamingIOOperation{Data: p}
+	out := &cstructs.ExecStreamingOutput{Stdout: op}
+	if w.stderr {
+		out = &cstructs.ExecStreamingOutput{Stderr: op}
+	}
+	if err := w.conn.WriteJSON(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
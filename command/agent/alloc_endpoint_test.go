@@ -1,11 +1,13 @@
 package agent
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/helper/websocket"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -161,6 +163,106 @@ func TestHTTP_AllocQuery(t *testing.T) {
 	})
 }
 
+func TestHTTP_AllocStop(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		alloc := mock.Alloc()
+		if err := state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)); err != nil {
+			t.Fatal(err)
+		}
+		if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("POST", "/v1/allocation/stop/"+alloc.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.AllocSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the response
+		out := obj.(structs.AllocStopResponse)
+		if out.EvalID == "" {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_AllocSignal(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// A signal is required
+		req, err := http.NewRequest("POST", "/v1/client/allocation/123/signal", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), "missing signal") {
+			t.Fatalf("err: %v", err)
+		}
+
+		// The allocation must exist
+		body := strings.NewReader(`{"Signal": "SIGHUP"}`)
+		req, err = http.NewRequest("POST", "/v1/client/allocation/123/signal", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.ClientAllocRequest(respW, req)
+		if err == nil || !strings.Contains(err.Error(), "unknown allocation") {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_AllocExec(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// The task and command are required
+		cases := map[string]string{
+			"/v1/client/allocation/123/exec":                                   "missing task",
+			"/v1/client/allocation/123/exec?task=web":                          "invalid command",
+			"/v1/client/allocation/123/exec?task=web&command=%5B%5D":           "missing command",
+			"/v1/client/allocation/123/exec?task=web&command=%5B%22ls%22%5D":   "not a websocket handshake",
+			"/v1/client/allocation/123/exec?task=web&command=[%22ls%22]&tty=x": "invalid tty",
+		}
+		for path, expected := range cases {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			_, err = s.Server.ClientAllocRequest(httptest.NewRecorder(), req)
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Fatalf("%s: expected %q, got: %v", path, expected, err)
+			}
+		}
+
+		// The error of the exec closes the connection
+		path := "/v1/client/allocation/123/exec?task=web&command=%5B%22ls%22%5D"
+		conn, err := websocket.Dial("ws://"+s.Server.addr+path, nil, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer conn.Close()
+		_, _, err = conn.ReadMessage()
+		closeErr, ok := err.(*websocket.CloseError)
+		if !ok || closeErr.Code != websocket.CloseInternalServerErr || !strings.Contains(closeErr.Text, "unknown allocation") {
+			t.Fatalf("bad: %v", err)
+		}
+	})
+}
+
 func TestHTTP_AllocStats(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Make the HTTP request
@@ -194,3 +296,146 @@ func TestHTTP_AllocSnapshot(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_AllocSnapshot_MigrateToken(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		allocID := structs.GenerateUUID()
+		path := fmt.Sprintf("/v1/client/allocation/%s/snapshot", allocID)
+
+		// Anonymous requests and tokens for other allocations are rejected
+		// before reaching the handler
+		otherToken, err := structs.GenerateMigrateToken(structs.GenerateUUID(), s.Agent.client.Node().SecretID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		for _, token := range []string{"", otherToken} {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			req.Header.Set("X-Nomad-Token", token)
+			_, err = s.Server.ClientAllocRequest(httptest.NewRecorder(), req)
+			if err == nil || strings.Contains(err.Error(), allocNotFoundErr) {
+				t.Fatalf("expected the token %q to be rejected, got %v", token, err)
+			}
+		}
+
+		// The migrate token of the allocation reaches the handler which does
+		// not know the alloc
+		token, err := structs.GenerateMigrateToken(allocID, s.Agent.client.Node().SecretID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		req.Header.Set("X-Nomad-Token", token)
+		_, err = s.Server.ClientAllocRequest(httptest.NewRecorder(), req)
+		if err == nil || !strings.Contains(err.Error(), allocNotFoundErr) {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
+func TestHTTP_ClientAlloc_ACL(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+		listJobs := createTestToken(t, s, 1000, `namespace "default" { capabilities = ["list-jobs"] }`)
+		readJob := createTestToken(t, s, 1010, `namespace "default" { policy = "read" }`)
+		readFS := createTestToken(t, s, 1020, `namespace "default" { capabilities = ["read-fs"] }`)
+		lifecycle := createTestToken(t, s, 1030, `namespace "default" { capabilities = ["alloc-lifecycle"] }`)
+		submitJob := createTestToken(t, s, 1040, `namespace "default" { capabilities = ["submit-job"] }`)
+		write := createTestToken(t, s, 1050, `namespace "default" { policy = "write" }`)
+		exec := createTestToken(t, s, 1060, `namespace "default" { capabilities = ["alloc-exec"] }`)
+
+		cases := []struct {
+			method string
+			path   string
+			denied []*structs.ACLToken
+			passed []*structs.ACLToken
+		}{
+			{"GET", "/v1/client/allocation/123/stats", []*structs.ACLToken{listJobs, readFS}, []*structs.ACLToken{readJob, root}},
+			{"GET", "/v1/client/allocation/123/snapshot", []*structs.ACLToken{listJobs, readJob}, []*structs.ACLToken{readFS, root}},
+			{"PUT", "/v1/client/allocation/123/restart", []*structs.ACLToken{readJob, readFS}, []*structs.ACLToken{lifecycle, submitJob, root}},
+			{"PUT", "/v1/client/allocation/123/signal", []*structs.ACLToken{readJob, readFS}, []*structs.ACLToken{lifecycle, submitJob, root}},
+			{"GET", "/v1/client/allocation/123/exec", []*structs.ACLToken{readJob, lifecycle, write}, []*structs.ACLToken{exec, root}},
+		}
+		for _, c := range cases {
+			for _, token := range c.denied {
+				req, err := http.NewRequest(c.method, c.path, encodeReq(struct{}{}))
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				setToken(req, token)
+				respW := httptest.NewRecorder()
+				s.Server.mux.ServeHTTP(respW, req)
+				if respW.Code != 403 {
+					t.Fatalf("%s: expected 403 for %q, got %d", c.path, token.Name, respW.Code)
+				}
+			}
+
+			// Allowed tokens reach the handler which does not know the alloc
+			for _, token := range c.passed {
+				req, err := http.NewRequest(c.method, c.path, encodeReq(struct{}{}))
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				setToken(req, token)
+				respW := httptest.NewRecorder()
+				s.Server.mux.ServeHTTP(respW, req)
+				if respW.Code == 403 {
+					t.Fatalf("%s: unexpected 403 for %q: %s", c.path, token.Name, respW.Body.String())
+				}
+			}
+		}
+	})
+}
+
+func TestHTTP_ClientGC(t *testing.T) {
+	cb := func(c *Config) {
+		c.ACL.Enabled = true
+	}
+	httpTest(t, cb, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+
+		// Only writes are allowed
+		req, err := http.NewRequest("GET", "/v1/client/gc", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, root)
+		_, err = s.Server.ClientGCRequest(httptest.NewRecorder(), req)
+		if err == nil || !strings.Contains(err.Error(), ErrInvalidMethod) {
+			t.Fatalf("expected invalid method: %v", err)
+		}
+
+		// Tokens without node:write are denied
+		policy := mock.ACLPolicy()
+		token := mock.ACLToken()
+		token.Policies = []string{policy.Name}
+		state := s.Agent.server.State()
+		if err := state.UpsertACLPolicies(1000, []*structs.ACLPolicy{policy}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertACLTokens(1010, []*structs.ACLToken{token}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		req, err = http.NewRequest("PUT", "/v1/client/gc", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		setToken(req, token)
+		_, err = s.Server.ClientGCRequest(httptest.NewRecorder(), req)
+		if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+			t.Fatalf("expected permission denied: %v", err)
+		}
+
+		// Management tokens are allowed
+		setToken(req, root)
+		if _, err := s.Server.ClientGCRequest(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
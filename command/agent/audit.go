@@ -0,0 +1,252 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// auditEvent is the record of a request to the HTTP API written to the audit
+// log as a line of JSON.
+type auditEvent struct {
+	ID       string        `json:"id"`
+	Time     time.Time     `json:"time"`
+	Actor    auditActor    `json:"actor"`
+	Request  auditRequest  `json:"request"`
+	Response auditResponse `json:"response"`
+}
+
+// auditActor identifies the author of a request. The token is only resolved
+// on servers, whose state holds the ACL tokens.
+type auditActor struct {
+	AccessorID string `json:"accessor_id,omitempty"`
+	TokenName  string `json:"token_name,omitempty"`
+	RemoteAddr string `json:"remote_addr"`
+}
+
+// auditRequest summarizes a request without its payload
+type auditRequest struct {
+	Method    string `json:"method"`
+	Endpoint  string `json:"endpoint"`
+	Query     string `json:"query,omitempty"`
+	BodyBytes int64  `json:"body_bytes"`
+}
+
+// auditResponse is the result of a request
+type auditResponse struct {
+	StatusCode int           `json:"status_code"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// auditor writes the events of the audit log to its sink
+type auditor struct {
+	config *AuditConfig
+
+	l    sync.Mutex
+	sink io.WriteCloser
+}
+
+// newAuditor returns an auditor writing to the file or socket of the config
+func newAuditor(config *AuditConfig) (*auditor, error) {
+	switch {
+	case config.Path == "" && config.Address == "":
+		return nil, fmt.Errorf("either a path or an address is required")
+	case config.Path != "" && config.Address != "":
+		return nil, fmt.Errorf("path and address can't both be set")
+	}
+	switch config.Network {
+	case "", "tcp", "udp", "unix":
+	default:
+		return nil, fmt.Errorf("invalid network %q, must be one of tcp, udp and unix", config.Network)
+	}
+
+	a := &auditor{config: config}
+	sink, err := a.open()
+	if err != nil {
+		return nil, err
+	}
+	a.sink = sink
+	return a, nil
+}
+
+// open opens the sink of the audit log
+func (a *auditor) open() (io.WriteCloser, error) {
+	if a.config.Path != "" {
+		return os.OpenFile(a.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	}
+
+	network := a.config.Network
+	if network == "" {
+		network = "tcp"
+	}
+	return net.DialTimeout(network, a.config.Address, 5*time.Second)
+}
+
+// excluded returns whether a filter excludes the request from the audit log
+func (a *auditor) excluded(req *http.Request) bool {
+	for _, f := range a.config.Filters {
+		if matchAuditFilter(f.Endpoints, req.URL.Path, true) && matchAuditFilter(f.Methods, req.Method, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAuditFilter returns whether the value matches one of the patterns or
+// there are none. If prefix is set, a trailing * matches any suffix.
+func matchAuditFilter(patterns []string, value string, prefix bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if prefix && strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(value, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if strings.EqualFold(p, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes the event to the sink. The connection to a socket is opened
+// again once if writing to it fails.
+func (a *auditor) write(event *auditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	a.l.Lock()
+	defer a.l.Unlock()
+	for attempt := 0; ; attempt++ {
+		if a.sink == nil {
+			if a.sink, err = a.open(); err != nil {
+				return err
+			}
+		}
+		_, err = a.sink.Write(data)
+		if err == nil || a.config.Path != "" || attempt > 0 {
+			return err
+		}
+		a.sink.Close()
+		a.sink = nil
+	}
+}
+
+// Close closes the sink of the audit log
+func (a *auditor) Close() error {
+	a.l.Lock()
+	defer a.l.Unlock()
+	if a.sink == nil {
+		return nil
+	}
+	err := a.sink.Close()
+	a.sink = nil
+	return err
+}
+
+// auditRecorder tracks a request to record it in the audit log once it is
+// served.
+type auditRecorder struct {
+	auditor *auditor
+	event   *auditEvent
+	start   time.Time
+	body    *countingReadCloser
+	resp    *auditResponseWriter
+}
+
+// start starts tracking the request unless it is excluded, in which case a
+// nil recorder is returned. The returned response writer must be used to
+// serve the request.
+func (a *auditor) start(resp http.ResponseWriter, req *http.Request, actor auditActor) (http.ResponseWriter, *auditRecorder) {
+	if a.excluded(req) {
+		return resp, nil
+	}
+
+	r := &auditRecorder{
+		auditor: a,
+		start:   time.Now(),
+		resp:    &auditResponseWriter{ResponseWriter: resp, status: http.StatusOK},
+		event: &auditEvent{
+			ID:    structs.GenerateUUID(),
+			Actor: actor,
+			Request: auditRequest{
+				Method:   req.Method,
+				Endpoint: req.URL.Path,
+				Query:    req.URL.RawQuery,
+			},
+		},
+	}
+	if req.Body != nil {
+		r.body = &countingReadCloser{ReadCloser: req.Body}
+		req.Body = r.body
+	}
+	return r.resp, r
+}
+
+// finish writes the event of the request served with the handler error
+func (r *auditRecorder) finish(handlerErr error) error {
+	r.event.Time = r.start.UTC()
+	if r.body != nil {
+		r.event.Request.BodyBytes = r.body.n
+	}
+	r.event.Response.StatusCode = r.resp.status
+	r.event.Response.Duration = time.Since(r.start)
+	if handlerErr != nil {
+		r.event.Response.Error = handlerErr.Error()
+	}
+	return r.auditor.write(r.event)
+}
+
+// countingReadCloser counts the bytes read from a request body
+type countingReadCloser struct {
+	io.ReadCloser
+	n int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// auditResponseWriter records the status code of a response
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *auditResponseWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush allows streamed responses to be audited
+func (w *auditResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack allows upgraded connections to be audited
+func (w *auditResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response does not support hijacking")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAuditor_Excluded(t *testing.T) {
+	a := &auditor{config: &AuditConfig{
+		Filters: []*AuditFilter{
+			{Endpoints: []string{"/v1/agent/*"}},
+			{Endpoints: []string{"/v1/jobs"}, Methods: []string{"GET"}},
+		},
+	}}
+
+	cases := map[string]bool{
+		"GET /v1/agent/self":   true,
+		"PUT /v1/agent/join":   true,
+		"GET /v1/jobs":         true,
+		"PUT /v1/jobs":         false,
+		"GET /v1/jobs/foo":     false,
+		"GET /v1/job/example":  false,
+		"DELETE /v1/job/ex":    false,
+		"GET /v1/agent":        false,
+		"get /v1/agent/health": true,
+	}
+	for c, expected := range cases {
+		parts := strings.SplitN(c, " ", 2)
+		req, err := http.NewRequest(strings.ToUpper(parts[0]), parts[1], nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if excluded := a.excluded(req); excluded != expected {
+			t.Fatalf("%s: expected %v, got %v", c, expected, excluded)
+		}
+	}
+}
+
+func TestNewAuditor_Invalid(t *testing.T) {
+	configs := []*AuditConfig{
+		{},
+		{Path: "/tmp/audit.log", Address: "127.0.0.1:9000"},
+		{Address: "127.0.0.1:9000", Network: "foo"},
+	}
+	for _, config := range configs {
+		if _, err := newAuditor(config); err == nil {
+			t.Fatalf("expected error for %#v", config)
+		}
+	}
+}
+
+func TestHTTP_Audit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-audit")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	cb := func(c *Config) {
+		c.Audit = &AuditConfig{
+			Enabled: true,
+			Path:    path,
+			Filters: []*AuditFilter{{Endpoints: []string{"/v1/agent/*"}}},
+		}
+	}
+	httpTest(t, cb, func(s *TestServer) {
+		for _, p := range []string{"/v1/agent/self", "/v1/jobs?prefix=foo", "/v1/job/unknown"} {
+			resp, err := http.Get("http://" + s.Server.addr + p)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			resp.Body.Close()
+		}
+		body := strings.NewReader(`{"Job": {}}`)
+		resp, err := http.Post("http://"+s.Server.addr+"/v1/jobs", "application/json", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		resp.Body.Close()
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		defer f.Close()
+
+		var events []*auditEvent
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var event auditEvent
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			events = append(events, &event)
+		}
+
+		// The agent endpoints are filtered out
+		if len(events) != 3 {
+			t.Fatalf("bad: %d events", len(events))
+		}
+		e := events[0]
+		if e.ID == "" || e.Request.Method != "GET" || e.Request.Endpoint != "/v1/jobs" ||
+			e.Request.Query != "prefix=foo" || e.Response.StatusCode != 200 || e.Actor.RemoteAddr == "" {
+			t.Fatalf("bad: %#v", e)
+		}
+		e = events[1]
+		if e.Request.Endpoint != "/v1/job/unknown" || e.Response.StatusCode != 404 || e.Response.Error == "" {
+			t.Fatalf("bad: %#v", e)
+		}
+		e = events[2]
+		if e.Request.Method != "POST" || e.Request.BodyBytes != 11 || e.Response.StatusCode == 200 {
+			t.Fatalf("bad: %#v", e)
+		}
+	})
+}
@@ -48,8 +48,12 @@ type Command struct {
 	httpServer     *HTTPServer
 	logFilter      *logutils.LevelFilter
 	logOutput      io.Writer
+	logWriter      *logWriter
 	retryJoinErrCh chan struct{}
 
+	inmemSink      *metrics.InmemSink
+	prometheusSink *prometheusSink
+
 	scadaProvider *scada.Provider
 	scadaHttp     *HTTPServer
 }
@@ -327,6 +331,7 @@ func (c *Command) setupLoggers(config *Config) (*gatedwriter.Writer, *logWriter,
 		logOutput = io.MultiWriter(c.logFilter, logWriter)
 	}
 	c.logOutput = logOutput
+	c.logWriter = logWriter
 	log.SetOutput(logOutput)
 	return logGate, logWriter, logOutput
 }
@@ -340,6 +345,9 @@ func (c *Command) setupAgent(config *Config, logOutput io.Writer) error {
 		return err
 	}
 	c.agent = agent
+	agent.inmemSink = c.inmemSink
+	agent.prometheusSink = c.prometheusSink
+	agent.logWriter = c.logWriter
 
 	// Enable the SCADA integration
 	if err := c.setupSCADA(config); err != nil {
@@ -528,6 +536,12 @@ WAIT:
 		sig = os.Interrupt
 	case <-c.retryJoinErrCh:
 		return 1
+	case errCh := <-c.agent.reloadCh:
+		c.Ui.Output("Reload requested through the HTTP API")
+		conf, err := c.handleReload(config)
+		*config = *conf
+		errCh <- err
+		goto WAIT
 	}
 	c.Ui.Output(fmt.Sprintf("Caught signal: %v", sig))
 
@@ -538,9 +552,8 @@ WAIT:
 
 	// Check if this is a SIGHUP
 	if sig == syscall.SIGHUP {
-		if conf := c.handleReload(config); conf != nil {
-			*config = *conf
-		}
+		conf, _ := c.handleReload(config)
+		*config = *conf
 		goto WAIT
 	}
 
@@ -579,13 +592,15 @@ WAIT:
 	}
 }
 
-// handleReload is invoked when we should reload our configs, e.g. SIGHUP
-func (c *Command) handleReload(config *Config) *Config {
+// handleReload is invoked when we should reload our configs, e.g. SIGHUP. It
+// returns the configuration now in effect, which is the previous one if the
+// reload failed.
+func (c *Command) handleReload(config *Config) (*Config, error) {
 	c.Ui.Output("Reloading configuration...")
 	newConf := c.readConfig()
 	if newConf == nil {
 		c.Ui.Error(fmt.Sprintf("Failed to reload configs"))
-		return config
+		return config, fmt.Errorf("Failed to reload configs")
 	}
 
 	// Change the log level
@@ -600,7 +615,33 @@ func (c *Command) handleReload(config *Config) *Config {
 		// Keep the current log level
 		newConf.LogLevel = config.LogLevel
 	}
-	return newConf
+
+	// Reload the TLS certificates and the client's reserved resources
+	if err := c.agent.Reload(newConf); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to reload agent: %v", err))
+		return config, err
+	}
+	if c.httpServer != nil {
+		if err := c.httpServer.ReloadTLS(newConf.TLSConfig); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to reload HTTP TLS certificate: %v", err))
+			return config, err
+		}
+	}
+
+	// Rebuild the telemetry sinks if their configuration changed. The
+	// Prometheus sink backs the metrics endpoint and can't be toggled.
+	if newConf.Telemetry.PrometheusMetrics != config.Telemetry.PrometheusMetrics {
+		c.Ui.Error("Changing telemetry.prometheus_metrics requires a restart")
+		newConf.Telemetry.PrometheusMetrics = config.Telemetry.PrometheusMetrics
+	}
+	if !reflect.DeepEqual(newConf.Telemetry, config.Telemetry) {
+		if err := c.setupTelemetry(newConf); err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to reload telemetry: %v", err))
+			newConf.Telemetry = config.Telemetry
+			return newConf, err
+		}
+	}
+	return newConf, nil
 }
 
 // setupTelemetry is used ot setup the telemetry sub-systems
@@ -609,8 +650,11 @@ func (c *Command) setupTelemetry(config *Config) error {
 	Aggregate on 10 second intervals for 1 minute. Expose the
 	metrics over stderr when there is a SIGUSR1 received.
 	*/
-	inm := metrics.NewInmemSink(10*time.Second, time.Minute)
-	metrics.DefaultInmemSignal(inm)
+	if c.inmemSink == nil {
+		c.inmemSink = metrics.NewInmemSink(10*time.Second, time.Minute)
+		metrics.DefaultInmemSignal(c.inmemSink)
+	}
+	inm := c.inmemSink
 
 	var telConfig *Telemetry
 	if config.Telemetry == nil {
@@ -683,6 +727,14 @@ func (c *Command) setupTelemetry(config *Config) error {
 		fanout = append(fanout, sink)
 	}
 
+	// Keep the metrics to be scraped by Prometheus
+	if telConfig.PrometheusMetrics {
+		if c.prometheusSink == nil {
+			c.prometheusSink = newPrometheusSink()
+		}
+		fanout = append(fanout, c.prometheusSink)
+	}
+
 	// Initialize the global sink
 	if len(fanout) > 0 {
 		fanout = append(fanout, inm)
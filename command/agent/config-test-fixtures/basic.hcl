@@ -48,6 +48,18 @@ client {
 	}
 	client_min_port = 1000
 	client_max_port = 2000
+	host_volume "certs" {
+		path = "/etc/ssl/certs"
+		read_only = true
+	}
+	cni_path = "/tmp/cni"
+	bridge_network_name = "nomad0"
+	bridge_network_subnet = "10.10.0.0/16"
+	gc_interval = "6s"
+	gc_disk_usage_threshold = 82
+	gc_inode_usage_threshold = 99
+	gc_max_allocs = 50
+	gc_max_alloc_age = "72h"
     max_kill_timeout = "10s"
     stats {
         data_points = 35
@@ -62,7 +74,12 @@ server {
 	num_schedulers = 2
 	enabled_schedulers = ["test"]
 	node_gc_threshold = "12h"
+	job_gc_threshold = "12h"
+	eval_gc_threshold = "12h"
+	alloc_gc_threshold = "6h"
+	deployment_gc_threshold = "12h"
 	heartbeat_grace   = "30s"
+	eval_aging_interval = "2m"
 	retry_join = [ "1.1.1.1", "2.2.2.2" ]
 	start_join = [ "1.1.1.1", "2.2.2.2" ]
 	retry_max = 3
@@ -77,6 +94,8 @@ telemetry {
     collection_interval = "3s"
     publish_allocation_metrics = true
     publish_node_metrics = true
+    prometheus_metrics = true
+    allocation_metrics_labels = ["namespace", "job", "task"]
 }
 leave_on_interrupt = true
 leave_on_terminate = true
@@ -90,6 +109,9 @@ atlas {
 	join = true
 	endpoint = "127.0.0.1:1234"
 }
+acl {
+	enabled = true
+}
 http_api_response_headers {
 	Access-Control-Allow-Origin = "*"
 }
@@ -130,3 +152,17 @@ tls {
     cert_file = "bar"
     key_file = "pipe"
 }
+autopilot {
+    cleanup_dead_servers = true
+    last_contact_threshold = "12705s"
+    max_trailing_logs = 17849
+    server_stabilization_time = "23057s"
+}
+audit {
+    enabled = true
+    path = "/var/log/nomad/audit.log"
+    filter {
+        endpoints = ["/v1/agent/*", "/v1/status/*"]
+        methods = ["GET"]
+    }
+}
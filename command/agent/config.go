@@ -15,6 +15,7 @@ import (
 
 	client "github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/nomad"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 )
 
@@ -89,6 +90,12 @@ type Config struct {
 	// AtlasConfig is used to configure Atlas
 	Atlas *AtlasConfig `mapstructure:"atlas"`
 
+	// ACL contains the configuration for the ACL system
+	ACL *ACLConfig `mapstructure:"acl"`
+
+	// Audit contains the configuration of the audit log of the HTTP API
+	Audit *AuditConfig `mapstructure:"audit"`
+
 	// Consul contains the configuration for the Consul Agent and
 	// parameters necessary to register services, their checks, and
 	// discover the current Nomad servers.
@@ -121,6 +128,10 @@ type Config struct {
 	// client
 	TLSConfig *config.TLSConfig `mapstructure:"tls"`
 
+	// Autopilot contains the configuration used to initialize the Autopilot
+	// configuration of a new cluster
+	Autopilot *config.AutopilotConfig `mapstructure:"autopilot"`
+
 	// HTTPAPIResponseHeaders allows users to configure the Nomad http agent to
 	// set arbritrary headers on API responses
 	HTTPAPIResponseHeaders map[string]string `mapstructure:"http_api_response_headers"`
@@ -144,6 +155,41 @@ type AtlasConfig struct {
 	Endpoint string `mapstructure:"endpoint"`
 }
 
+// ACLConfig is configuration specific to the ACL system
+type ACLConfig struct {
+	// Enabled controls if we are enforce and manage ACLs
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// AuditConfig is the configuration of the audit log, recording the requests
+// made to the HTTP API of the agent and their results.
+type AuditConfig struct {
+	// Enabled controls if the requests are recorded
+	Enabled bool `mapstructure:"enabled"`
+
+	// Path is the file the events are appended to
+	Path string `mapstructure:"path"`
+
+	// Network and Address are the socket the events are sent to instead of a
+	// file. The network is one of tcp, udp and unix.
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+
+	// Filters exclude the matching requests from the audit log
+	Filters []*AuditFilter `mapstructure:"filter"`
+}
+
+// AuditFilter matches the requests of the audit log that are not recorded.
+// An empty list matches every request.
+type AuditFilter struct {
+	// Endpoints are the paths of the requests. A trailing * matches any
+	// path with the prefix.
+	Endpoints []string `mapstructure:"endpoints"`
+
+	// Methods are the HTTP methods of the requests
+	Methods []string `mapstructure:"methods"`
+}
+
 // ClientConfig is configuration specific to the client mode
 type ClientConfig struct {
 	// Enabled controls if we are a client
@@ -196,6 +242,42 @@ type ClientConfig struct {
 	// be used to target a certain utilization or to prevent Nomad from using a
 	// particular set of ports.
 	Reserved *Resources `mapstructure:"reserved"`
+
+	// HostVolumes are the directories of the host exposed to the task groups
+	// requesting them
+	HostVolumes []*structs.ClientHostVolumeConfig `mapstructure:"host_volume"`
+
+	// CNIPath is the directory holding the CNI plugins used to set up the
+	// bridge networks of task groups
+	CNIPath string `mapstructure:"cni_path"`
+
+	// BridgeNetworkName is the name of the bridge the network namespaces of
+	// the allocations are attached to
+	BridgeNetworkName string `mapstructure:"bridge_network_name"`
+
+	// BridgeNetworkSubnet is the subnet the addresses of the allocations in
+	// bridge networking mode are allocated from
+	BridgeNetworkSubnet string `mapstructure:"bridge_network_subnet"`
+
+	// GCInterval is the interval at which terminal allocations are garbage
+	// collected if they exceed any of the limits
+	GCInterval string `mapstructure:"gc_interval"`
+
+	// GCDiskUsageThreshold is the disk usage percentage of the alloc dir
+	// above which terminal allocations are garbage collected
+	GCDiskUsageThreshold float64 `mapstructure:"gc_disk_usage_threshold"`
+
+	// GCInodeUsageThreshold is the inode usage percentage of the alloc dir
+	// above which terminal allocations are garbage collected
+	GCInodeUsageThreshold float64 `mapstructure:"gc_inode_usage_threshold"`
+
+	// GCMaxAllocs is the number of allocations kept by the client above which
+	// terminal allocations are garbage collected
+	GCMaxAllocs int `mapstructure:"gc_max_allocs"`
+
+	// GCMaxAllocAge is how long a terminal allocation is kept before being
+	// garbage collected
+	GCMaxAllocAge string `mapstructure:"gc_max_alloc_age"`
 }
 
 // ServerConfig is configuration specific to the server mode
@@ -227,10 +309,28 @@ type ServerConfig struct {
 	// NodeGCThreshold controls how "old" a node must be to be collected by GC.
 	NodeGCThreshold string `mapstructure:"node_gc_threshold"`
 
+	// JobGCThreshold controls how "old" a job must be to be collected by GC.
+	JobGCThreshold string `mapstructure:"job_gc_threshold"`
+
+	// EvalGCThreshold controls how "old" an eval must be to be collected by GC.
+	EvalGCThreshold string `mapstructure:"eval_gc_threshold"`
+
+	// AllocGCThreshold controls how "old" a terminal allocation must be to be
+	// collected by GC.
+	AllocGCThreshold string `mapstructure:"alloc_gc_threshold"`
+
+	// DeploymentGCThreshold controls how "old" a terminal deployment must be
+	// to be collected by GC.
+	DeploymentGCThreshold string `mapstructure:"deployment_gc_threshold"`
+
 	// HeartbeatGrace is the grace period beyond the TTL to account for network,
 	// processing delays and clock skew before marking a node as "down".
 	HeartbeatGrace string `mapstructure:"heartbeat_grace"`
 
+	// EvalAgingInterval is the time an evaluation must wait to be scheduled
+	// for its priority to be raised by one.
+	EvalAgingInterval string `mapstructure:"eval_aging_interval"`
+
 	// StartJoin is a list of addresses to attempt to join when the
 	// agent starts. If Serf is unable to communicate with any of these
 	// addresses, then the agent will error and exit.
@@ -276,6 +376,15 @@ type Telemetry struct {
 	PublishAllocationMetrics bool          `mapstructure:"publish_allocation_metrics"`
 	PublishNodeMetrics       bool          `mapstructure:"publish_node_metrics"`
 
+	// AllocationMetricsLabels are the labels included in the keys of the
+	// allocation metrics, in order. Dropping high cardinality labels such as
+	// alloc_id bounds the number of published metrics.
+	AllocationMetricsLabels []string `mapstructure:"allocation_metrics_labels"`
+
+	// PrometheusMetrics keeps the metrics in the Prometheus format so that
+	// they can be scraped from the /v1/metrics endpoint.
+	PrometheusMetrics bool `mapstructure:"prometheus_metrics"`
+
 	// Circonus: see https://github.com/circonus-labs/circonus-gometrics
 	// for more details on the various configuration options.
 	// Valid configuration combinations:
@@ -482,14 +591,21 @@ func DefaultConfig() *Config {
 		Addresses:      &Addresses{},
 		AdvertiseAddrs: &AdvertiseAddrs{},
 		Atlas:          &AtlasConfig{},
+		ACL:            &ACLConfig{},
+		Audit:          &AuditConfig{},
 		Consul:         config.DefaultConsulConfig(),
 		Vault:          config.DefaultVaultConfig(),
+		Autopilot:      config.DefaultAutopilotConfig(),
 		Client: &ClientConfig{
-			Enabled:        false,
-			MaxKillTimeout: "30s",
-			ClientMinPort:  14000,
-			ClientMaxPort:  14512,
-			Reserved:       &Resources{},
+			Enabled:               false,
+			MaxKillTimeout:        "30s",
+			ClientMinPort:         14000,
+			ClientMaxPort:         14512,
+			Reserved:              &Resources{},
+			GCInterval:            "1m",
+			GCDiskUsageThreshold:  80,
+			GCInodeUsageThreshold: 70,
+			GCMaxAllocs:           50,
 		},
 		Server: &ServerConfig{
 			Enabled:          false,
@@ -640,6 +756,22 @@ func (c *Config) Merge(b *Config) *Config {
 		result.Atlas = result.Atlas.Merge(b.Atlas)
 	}
 
+	// Apply the ACL configuration
+	if result.ACL == nil && b.ACL != nil {
+		aclConfig := *b.ACL
+		result.ACL = &aclConfig
+	} else if b.ACL != nil {
+		result.ACL = result.ACL.Merge(b.ACL)
+	}
+
+	// Apply the audit configuration
+	if result.Audit == nil && b.Audit != nil {
+		auditConfig := *b.Audit
+		result.Audit = &auditConfig
+	} else if b.Audit != nil {
+		result.Audit = result.Audit.Merge(b.Audit)
+	}
+
 	// Apply the Consul Configuration
 	if result.Consul == nil && b.Consul != nil {
 		consulConfig := *b.Consul
@@ -656,6 +788,14 @@ func (c *Config) Merge(b *Config) *Config {
 		result.Vault = result.Vault.Merge(b.Vault)
 	}
 
+	// Apply the Autopilot Configuration
+	if result.Autopilot == nil && b.Autopilot != nil {
+		autopilot := *b.Autopilot
+		result.Autopilot = &autopilot
+	} else if b.Autopilot != nil {
+		result.Autopilot = result.Autopilot.Merge(b.Autopilot)
+	}
+
 	// Merge config files lists
 	result.Files = append(result.Files, b.Files...)
 
@@ -812,9 +952,24 @@ func (a *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 	if b.NodeGCThreshold != "" {
 		result.NodeGCThreshold = b.NodeGCThreshold
 	}
+	if b.JobGCThreshold != "" {
+		result.JobGCThreshold = b.JobGCThreshold
+	}
+	if b.EvalGCThreshold != "" {
+		result.EvalGCThreshold = b.EvalGCThreshold
+	}
+	if b.AllocGCThreshold != "" {
+		result.AllocGCThreshold = b.AllocGCThreshold
+	}
+	if b.DeploymentGCThreshold != "" {
+		result.DeploymentGCThreshold = b.DeploymentGCThreshold
+	}
 	if b.HeartbeatGrace != "" {
 		result.HeartbeatGrace = b.HeartbeatGrace
 	}
+	if b.EvalAgingInterval != "" {
+		result.EvalAgingInterval = b.EvalAgingInterval
+	}
 	if b.RetryMaxAttempts != 0 {
 		result.RetryMaxAttempts = b.RetryMaxAttempts
 	}
@@ -879,10 +1034,37 @@ func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 	if b.Reserved != nil {
 		result.Reserved = result.Reserved.Merge(b.Reserved)
 	}
+	if b.CNIPath != "" {
+		result.CNIPath = b.CNIPath
+	}
+	if b.BridgeNetworkName != "" {
+		result.BridgeNetworkName = b.BridgeNetworkName
+	}
+	if b.BridgeNetworkSubnet != "" {
+		result.BridgeNetworkSubnet = b.BridgeNetworkSubnet
+	}
+	if b.GCInterval != "" {
+		result.GCInterval = b.GCInterval
+	}
+	if b.GCDiskUsageThreshold != 0 {
+		result.GCDiskUsageThreshold = b.GCDiskUsageThreshold
+	}
+	if b.GCInodeUsageThreshold != 0 {
+		result.GCInodeUsageThreshold = b.GCInodeUsageThreshold
+	}
+	if b.GCMaxAllocs != 0 {
+		result.GCMaxAllocs = b.GCMaxAllocs
+	}
+	if b.GCMaxAllocAge != "" {
+		result.GCMaxAllocAge = b.GCMaxAllocAge
+	}
 
 	// Add the servers
 	result.Servers = append(result.Servers, b.Servers...)
 
+	// Add the host volumes, a later volume of the same name takes precedence
+	result.HostVolumes = append(result.HostVolumes, b.HostVolumes...)
+
 	// Add the options map values
 	if result.Options == nil {
 		result.Options = make(map[string]string)
@@ -938,6 +1120,12 @@ func (a *Telemetry) Merge(b *Telemetry) *Telemetry {
 	if b.PublishAllocationMetrics {
 		result.PublishAllocationMetrics = true
 	}
+	if b.PrometheusMetrics {
+		result.PrometheusMetrics = true
+	}
+	if len(b.AllocationMetricsLabels) != 0 {
+		result.AllocationMetricsLabels = b.AllocationMetricsLabels
+	}
 	if b.CirconusAPIToken != "" {
 		result.CirconusAPIToken = b.CirconusAPIToken
 	}
@@ -1047,6 +1235,36 @@ func (a *AtlasConfig) Merge(b *AtlasConfig) *AtlasConfig {
 	return &result
 }
 
+// Merge merges two ACL configurations together.
+func (a *ACLConfig) Merge(b *ACLConfig) *ACLConfig {
+	result := *a
+
+	if b.Enabled {
+		result.Enabled = true
+	}
+	return &result
+}
+
+// Merge merges two audit configurations together.
+func (a *AuditConfig) Merge(b *AuditConfig) *AuditConfig {
+	result := *a
+
+	if b.Enabled {
+		result.Enabled = true
+	}
+	if b.Path != "" {
+		result.Path = b.Path
+	}
+	if b.Network != "" {
+		result.Network = b.Network
+	}
+	if b.Address != "" {
+		result.Address = b.Address
+	}
+	result.Filters = append(result.Filters[:len(result.Filters):len(result.Filters)], b.Filters...)
+	return &result
+}
+
 func (r *Resources) Merge(b *Resources) *Resources {
 	result := *r
 	if b.CPU != 0 {
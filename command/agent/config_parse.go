@@ -11,6 +11,8 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	clientconfig "github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/mitchellh/mapstructure"
 )
@@ -92,10 +94,13 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 		"disable_update_check",
 		"disable_anonymous_signature",
 		"atlas",
+		"acl",
+		"audit",
 		"consul",
 		"vault",
 		"tls",
 		"http_api_response_headers",
+		"autopilot",
 	}
 	if err := checkHCLKeys(list, valid); err != nil {
 		return multierror.Prefix(err, "config:")
@@ -114,10 +119,13 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 	delete(m, "server")
 	delete(m, "telemetry")
 	delete(m, "atlas")
+	delete(m, "acl")
+	delete(m, "audit")
 	delete(m, "consul")
 	delete(m, "vault")
 	delete(m, "tls")
 	delete(m, "http_api_response_headers")
+	delete(m, "autopilot")
 
 	// Decode the rest
 	if err := mapstructure.WeakDecode(m, result); err != nil {
@@ -173,6 +181,20 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse the ACL config
+	if o := list.Filter("acl"); len(o.Items) > 0 {
+		if err := parseACL(&result.ACL, o); err != nil {
+			return multierror.Prefix(err, "acl ->")
+		}
+	}
+
+	// Parse the audit config
+	if o := list.Filter("audit"); len(o.Items) > 0 {
+		if err := parseAudit(&result.Audit, o); err != nil {
+			return multierror.Prefix(err, "audit ->")
+		}
+	}
+
 	// Parse the consul config
 	if o := list.Filter("consul"); len(o.Items) > 0 {
 		if err := parseConsulConfig(&result.Consul, o); err != nil {
@@ -194,6 +216,13 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse Autopilot config
+	if o := list.Filter("autopilot"); len(o.Items) > 0 {
+		if err := parseAutopilot(&result.Autopilot, o); err != nil {
+			return multierror.Prefix(err, "autopilot ->")
+		}
+	}
+
 	// Parse out http_api_response_headers fields. These are in HCL as a list so
 	// we need to iterate over them and merge them.
 	if headersO := list.Filter("http_api_response_headers"); len(headersO.Items) > 0 {
@@ -341,6 +370,15 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		"client_min_port",
 		"reserved",
 		"stats",
+		"host_volume",
+		"cni_path",
+		"bridge_network_name",
+		"bridge_network_subnet",
+		"gc_interval",
+		"gc_disk_usage_threshold",
+		"gc_inode_usage_threshold",
+		"gc_max_allocs",
+		"gc_max_alloc_age",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return err
@@ -356,6 +394,7 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 	delete(m, "chroot_env")
 	delete(m, "reserved")
 	delete(m, "stats")
+	delete(m, "host_volume")
 
 	var config ClientConfig
 	if err := mapstructure.WeakDecode(m, &config); err != nil {
@@ -411,10 +450,47 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse the host volumes
+	if o := listVal.Filter("host_volume"); len(o.Items) > 0 {
+		if err := parseHostVolumes(&config.HostVolumes, o); err != nil {
+			return multierror.Prefix(err, "host_volume ->")
+		}
+	}
+
 	*result = &config
 	return nil
 }
 
+func parseHostVolumes(result *[]*structs.ClientHostVolumeConfig, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		if len(o.Keys) == 0 {
+			return fmt.Errorf("host volumes must be named")
+		}
+		name := o.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"path",
+			"read_only",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", name))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		v := &structs.ClientHostVolumeConfig{Name: name}
+		if err := mapstructure.WeakDecode(m, v); err != nil {
+			return err
+		}
+		*result = append(*result, v)
+	}
+	return nil
+}
+
 func parseReserved(result **Resources, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -487,7 +563,12 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		"num_schedulers",
 		"enabled_schedulers",
 		"node_gc_threshold",
+		"job_gc_threshold",
+		"eval_gc_threshold",
+		"alloc_gc_threshold",
+		"deployment_gc_threshold",
 		"heartbeat_grace",
+		"eval_aging_interval",
 		"start_join",
 		"retry_join",
 		"retry_max",
@@ -530,6 +611,8 @@ func parseTelemetry(result **Telemetry, list *ast.ObjectList) error {
 		"collection_interval",
 		"publish_allocation_metrics",
 		"publish_node_metrics",
+		"allocation_metrics_labels",
+		"prometheus_metrics",
 		"datadog_address",
 		"circonus_api_token",
 		"circonus_api_app",
@@ -558,6 +641,16 @@ func parseTelemetry(result **Telemetry, list *ast.ObjectList) error {
 	if err := mapstructure.WeakDecode(m, &telemetry); err != nil {
 		return err
 	}
+	valid = clientconfig.ValidAllocationMetricsLabels
+	for _, label := range telemetry.AllocationMetricsLabels {
+		found := false
+		for _, v := range valid {
+			found = found || v == label
+		}
+		if !found {
+			return fmt.Errorf("invalid allocation_metrics_labels value %q, must be one of %v", label, valid)
+		}
+	}
 	if telemetry.CollectionInterval != "" {
 		if dur, err := time.ParseDuration(telemetry.CollectionInterval); err != nil {
 			return fmt.Errorf("error parsing value of %q: %v", "collection_interval", err)
@@ -602,6 +695,100 @@ func parseAtlas(result **AtlasConfig, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseACL(result **ACLConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'acl' block allowed")
+	}
+
+	// Get our ACL object
+	listVal := list.Items[0].Val
+
+	// Check for invalid keys
+	valid := []string{
+		"enabled",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var aclConfig ACLConfig
+	if err := mapstructure.WeakDecode(m, &aclConfig); err != nil {
+		return err
+	}
+	*result = &aclConfig
+	return nil
+}
+
+func parseAudit(result **AuditConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'audit' block allowed")
+	}
+
+	// Get our audit object
+	obj := list.Items[0]
+
+	// Value should be an object
+	var listVal *ast.ObjectList
+	if ot, ok := obj.Val.(*ast.ObjectType); ok {
+		listVal = ot.List
+	} else {
+		return fmt.Errorf("audit value: should be an object")
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"enabled",
+		"path",
+		"network",
+		"address",
+		"filter",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+	delete(m, "filter")
+
+	var auditConfig AuditConfig
+	if err := mapstructure.WeakDecode(m, &auditConfig); err != nil {
+		return err
+	}
+
+	// Parse the filters
+	if o := listVal.Filter("filter"); len(o.Items) > 0 {
+		for _, item := range o.Items {
+			if err := checkHCLKeys(item.Val, []string{"endpoints", "methods"}); err != nil {
+				return multierror.Prefix(err, "filter ->")
+			}
+
+			var m map[string]interface{}
+			if err := hcl.DecodeObject(&m, item.Val); err != nil {
+				return err
+			}
+
+			var filter AuditFilter
+			if err := mapstructure.WeakDecode(m, &filter); err != nil {
+				return err
+			}
+			auditConfig.Filters = append(auditConfig.Filters, &filter)
+		}
+	}
+
+	*result = &auditConfig
+	return nil
+}
+
 func parseConsulConfig(result **config.ConsulConfig, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -768,3 +955,46 @@ func checkHCLKeys(node ast.Node, valid []string) error {
 
 	return result
 }
+
+func parseAutopilot(result **config.AutopilotConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'autopilot' block allowed")
+	}
+
+	// Get our Autopilot object
+	listVal := list.Items[0].Val
+
+	// Check for invalid keys
+	valid := []string{
+		"cleanup_dead_servers",
+		"last_contact_threshold",
+		"max_trailing_logs",
+		"server_stabilization_time",
+	}
+
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var autopilotConfig config.AutopilotConfig
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &autopilotConfig,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*result = &autopilotConfig
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
 )
 
@@ -70,22 +71,38 @@ func TestConfig_Parse(t *testing.T) {
 						ReservedPorts:       "1,100,10-12",
 						ParsedReservedPorts: []int{1, 10, 11, 12, 100},
 					},
+					HostVolumes: []*structs.ClientHostVolumeConfig{
+						{Name: "certs", Path: "/etc/ssl/certs", ReadOnly: true},
+					},
+					CNIPath:               "/tmp/cni",
+					BridgeNetworkName:     "nomad0",
+					BridgeNetworkSubnet:   "10.10.0.0/16",
+					GCInterval:            "6s",
+					GCDiskUsageThreshold:  82,
+					GCInodeUsageThreshold: 99,
+					GCMaxAllocs:           50,
+					GCMaxAllocAge:         "72h",
 				},
 				Server: &ServerConfig{
-					Enabled:           true,
-					BootstrapExpect:   5,
-					DataDir:           "/tmp/data",
-					ProtocolVersion:   3,
-					NumSchedulers:     2,
-					EnabledSchedulers: []string{"test"},
-					NodeGCThreshold:   "12h",
-					HeartbeatGrace:    "30s",
-					RetryJoin:         []string{"1.1.1.1", "2.2.2.2"},
-					StartJoin:         []string{"1.1.1.1", "2.2.2.2"},
-					RetryInterval:     "15s",
-					RejoinAfterLeave:  true,
-					RetryMaxAttempts:  3,
-					EncryptKey:        "abc",
+					Enabled:               true,
+					BootstrapExpect:       5,
+					DataDir:               "/tmp/data",
+					ProtocolVersion:       3,
+					NumSchedulers:         2,
+					EnabledSchedulers:     []string{"test"},
+					NodeGCThreshold:       "12h",
+					JobGCThreshold:        "12h",
+					EvalGCThreshold:       "12h",
+					AllocGCThreshold:      "6h",
+					DeploymentGCThreshold: "12h",
+					HeartbeatGrace:        "30s",
+					EvalAgingInterval:     "2m",
+					RetryJoin:             []string{"1.1.1.1", "2.2.2.2"},
+					StartJoin:             []string{"1.1.1.1", "2.2.2.2"},
+					RetryInterval:         "15s",
+					RejoinAfterLeave:      true,
+					RetryMaxAttempts:      3,
+					EncryptKey:            "abc",
 				},
 				Telemetry: &Telemetry{
 					StatsiteAddr:             "127.0.0.1:1234",
@@ -95,6 +112,8 @@ func TestConfig_Parse(t *testing.T) {
 					collectionInterval:       3 * time.Second,
 					PublishAllocationMetrics: true,
 					PublishNodeMetrics:       true,
+					PrometheusMetrics:        true,
+					AllocationMetricsLabels:  []string{"namespace", "job", "task"},
 				},
 				LeaveOnInt:                true,
 				LeaveOnTerm:               true,
@@ -108,6 +127,9 @@ func TestConfig_Parse(t *testing.T) {
 					Join:           true,
 					Endpoint:       "127.0.0.1:1234",
 				},
+				ACL: &ACLConfig{
+					Enabled: true,
+				},
 				Consul: &config.ConsulConfig{
 					ServerServiceName:  "nomad",
 					ClientServiceName:  "nomad-client",
@@ -148,6 +170,22 @@ func TestConfig_Parse(t *testing.T) {
 				HTTPAPIResponseHeaders: map[string]string{
 					"Access-Control-Allow-Origin": "*",
 				},
+				Autopilot: &config.AutopilotConfig{
+					CleanupDeadServers:      &trueValue,
+					LastContactThreshold:    12705 * time.Second,
+					MaxTrailingLogs:         17849,
+					ServerStabilizationTime: 23057 * time.Second,
+				},
+				Audit: &AuditConfig{
+					Enabled: true,
+					Path:    "/var/log/nomad/audit.log",
+					Filters: []*AuditFilter{
+						{
+							Endpoints: []string{"/v1/agent/*", "/v1/status/*"},
+							Methods:   []string{"GET"},
+						},
+					},
+				},
 			},
 			false,
 		},
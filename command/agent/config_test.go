@@ -102,6 +102,9 @@ func TestConfig_Merge(t *testing.T) {
 			Join:           false,
 			Endpoint:       "foo",
 		},
+		ACL: &ACLConfig{
+			Enabled: false,
+		},
 		HTTPAPIResponseHeaders: map[string]string{
 			"Access-Control-Allow-Origin": "*",
 		},
@@ -133,6 +136,16 @@ func TestConfig_Merge(t *testing.T) {
 			ServerAutoJoin:    false,
 			ClientAutoJoin:    false,
 		},
+		Autopilot: &config.AutopilotConfig{
+			CleanupDeadServers:      &falseValue,
+			LastContactThreshold:    1 * time.Second,
+			MaxTrailingLogs:         1,
+			ServerStabilizationTime: 1 * time.Second,
+		},
+		Audit: &AuditConfig{
+			Enabled: false,
+			Path:    "/tmp/audit1.log",
+		},
 	}
 
 	c2 := &Config{
@@ -156,6 +169,8 @@ func TestConfig_Merge(t *testing.T) {
 			DisableHostname:                    true,
 			PublishNodeMetrics:                 true,
 			PublishAllocationMetrics:           true,
+			PrometheusMetrics:                  true,
+			AllocationMetricsLabels:            []string{"job", "task"},
 			CirconusAPIToken:                   "1",
 			CirconusAPIApp:                     "nomad",
 			CirconusAPIURL:                     "https://api.circonus.com/v2",
@@ -183,11 +198,17 @@ func TestConfig_Merge(t *testing.T) {
 				"foo": "bar",
 				"baz": "zip",
 			},
-			ChrootEnv:      map[string]string{},
-			ClientMaxPort:  20000,
-			ClientMinPort:  22000,
-			NetworkSpeed:   105,
-			MaxKillTimeout: "50s",
+			ChrootEnv:             map[string]string{},
+			ClientMaxPort:         20000,
+			ClientMinPort:         22000,
+			NetworkSpeed:          105,
+			MaxKillTimeout:        "50s",
+			CNIPath:               "/opt/cni/bin",
+			GCInterval:            "2m",
+			GCDiskUsageThreshold:  90,
+			GCInodeUsageThreshold: 80,
+			GCMaxAllocs:           100,
+			GCMaxAllocAge:         "48h",
 			Reserved: &Resources{
 				CPU:                 15,
 				MemoryMB:            15,
@@ -198,19 +219,24 @@ func TestConfig_Merge(t *testing.T) {
 			},
 		},
 		Server: &ServerConfig{
-			Enabled:           true,
-			BootstrapExpect:   2,
-			DataDir:           "/tmp/data2",
-			ProtocolVersion:   2,
-			NumSchedulers:     2,
-			EnabledSchedulers: []string{structs.JobTypeBatch},
-			NodeGCThreshold:   "12h",
-			HeartbeatGrace:    "2m",
-			RejoinAfterLeave:  true,
-			StartJoin:         []string{"1.1.1.1"},
-			RetryJoin:         []string{"1.1.1.1"},
-			RetryInterval:     "10s",
-			retryInterval:     time.Second * 10,
+			Enabled:               true,
+			BootstrapExpect:       2,
+			DataDir:               "/tmp/data2",
+			ProtocolVersion:       2,
+			NumSchedulers:         2,
+			EnabledSchedulers:     []string{structs.JobTypeBatch},
+			NodeGCThreshold:       "12h",
+			JobGCThreshold:        "12h",
+			EvalGCThreshold:       "12h",
+			AllocGCThreshold:      "6h",
+			DeploymentGCThreshold: "12h",
+			HeartbeatGrace:        "2m",
+			EvalAgingInterval:     "30s",
+			RejoinAfterLeave:      true,
+			StartJoin:             []string{"1.1.1.1"},
+			RetryJoin:             []string{"1.1.1.1"},
+			RetryInterval:         "10s",
+			retryInterval:         time.Second * 10,
 		},
 		Ports: &Ports{
 			HTTP: 20000,
@@ -232,6 +258,9 @@ func TestConfig_Merge(t *testing.T) {
 			Join:           true,
 			Endpoint:       "bar",
 		},
+		ACL: &ACLConfig{
+			Enabled: true,
+		},
 		HTTPAPIResponseHeaders: map[string]string{
 			"Access-Control-Allow-Origin":  "*",
 			"Access-Control-Allow-Methods": "GET, POST, OPTIONS",
@@ -264,6 +293,21 @@ func TestConfig_Merge(t *testing.T) {
 			ServerAutoJoin:    true,
 			ClientAutoJoin:    true,
 		},
+		Autopilot: &config.AutopilotConfig{
+			CleanupDeadServers:      &trueValue,
+			LastContactThreshold:    2 * time.Second,
+			MaxTrailingLogs:         2,
+			ServerStabilizationTime: 2 * time.Second,
+		},
+		Audit: &AuditConfig{
+			Enabled: true,
+			Path:    "/tmp/audit2.log",
+			Filters: []*AuditFilter{
+				{
+					Endpoints: []string{"/v1/agent/*"},
+				},
+			},
+		},
 	}
 
 	result := c1.Merge(c2)
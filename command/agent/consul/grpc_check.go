@@ -0,0 +1,223 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	cstructs "github.com/hashicorp/nomad/client/driver/structs"
+)
+
+const (
+	// grpcHealthPath is the path of the Check method of the standard gRPC
+	// health checking service.
+	grpcHealthPath = "/grpc.health.v1.Health/Check"
+
+	// grpcMaxMessageSize is the maximum size of the response read from the
+	// health checking service.
+	grpcMaxMessageSize = 4096
+
+	// grpcServing is the status of a healthy service in the response of
+	// the health checking service.
+	grpcServing = 1
+)
+
+// grpcStatuses are the names of the serving statuses of the gRPC health
+// checking protocol.
+var grpcStatuses = map[uint64]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+// GRPCCheck queries the standard gRPC health checking service of a service
+// and updates the corresponding Consul TTL check. The check passes if the
+// service reports that it is serving.
+type GRPCCheck struct {
+	id       string        // id of the check
+	addr     string        // host and port of the gRPC server
+	service  string        // name of the service checked, empty for the server
+	useTLS   bool          // whether the server uses TLS
+	interval time.Duration // interval of the check
+	timeout  time.Duration // timeout of the check
+
+	client *http.Client
+}
+
+// NewGRPCCheck returns a gRPC check querying the server at the address.
+func NewGRPCCheck(id, addr, service string, useTLS bool, interval, timeout time.Duration) *GRPCCheck {
+	protocols := new(http.Protocols)
+	if useTLS {
+		protocols.SetHTTP2(true)
+	} else {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+
+	return &GRPCCheck{
+		id:       id,
+		addr:     addr,
+		service:  service,
+		useTLS:   useTLS,
+		interval: interval,
+		timeout:  timeout,
+		client: &http.Client{
+			Transport: &http.Transport{Protocols: protocols},
+		},
+	}
+}
+
+// Run queries the health checking service
+func (g *GRPCCheck) Run() *cstructs.CheckResult {
+	ts := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), g.Timeout())
+	defer cancel()
+
+	scheme := "http"
+	if g.useTLS {
+		scheme = "https"
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s://%s%s", scheme, g.addr, grpcHealthPath),
+		bytes.NewReader(grpcFrame(grpcHealthRequest(g.service))))
+	if err != nil {
+		return &cstructs.CheckResult{Err: err, Timestamp: ts}
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return &cstructs.CheckResult{Err: err, Timestamp: ts}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, grpcMaxMessageSize))
+	if err != nil {
+		return &cstructs.CheckResult{Err: err, Timestamp: ts}
+	}
+	duration := time.Since(ts)
+
+	// Errors without a message are returned in the headers rather than the
+	// trailers
+	code, msg := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if code == "" {
+		code, msg = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if resp.StatusCode != http.StatusOK || code != "0" {
+		return &cstructs.CheckResult{
+			ExitCode:  2,
+			Output:    fmt.Sprintf("gRPC health check failed with HTTP status %d, gRPC status %q: %s", resp.StatusCode, code, msg),
+			Timestamp: ts,
+			Duration:  duration,
+		}
+	}
+
+	status, err := grpcHealthResponse(body)
+	if err != nil {
+		return &cstructs.CheckResult{Err: err, Timestamp: ts, Duration: duration}
+	}
+	result := &cstructs.CheckResult{
+		ExitCode:  2,
+		Output:    fmt.Sprintf("gRPC service %q is %s", g.service, grpcStatuses[status]),
+		Timestamp: ts,
+		Duration:  duration,
+	}
+	if status == grpcServing {
+		result.ExitCode = 0
+	}
+	return result
+}
+
+// ID returns the check id
+func (g *GRPCCheck) ID() string {
+	return g.id
+}
+
+// Interval returns the interval at which the check has to run
+func (g *GRPCCheck) Interval() time.Duration {
+	return g.interval
+}
+
+// Timeout returns the duration after which a check is timed out.
+func (g *GRPCCheck) Timeout() time.Duration {
+	return g.timeout
+}
+
+// grpcHealthRequest encodes the protobuf request of the health checking
+// service, whose only field is the name of the service.
+func grpcHealthRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	msg := []byte{0x0a}
+	msg = binary.AppendUvarint(msg, uint64(len(service)))
+	return append(msg, service...)
+}
+
+// grpcHealthResponse decodes the serving status of the framed protobuf
+// response of the health checking service.
+func grpcHealthResponse(body []byte) (uint64, error) {
+	if len(body) < 5 || body[0] != 0 {
+		return 0, fmt.Errorf("invalid gRPC response")
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	msg := body[5:]
+	if uint32(len(msg)) != size {
+		return 0, fmt.Errorf("invalid gRPC response length %d", size)
+	}
+
+	// The status is the first field and other fields are skipped
+	var status uint64
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return 0, fmt.Errorf("invalid gRPC response")
+		}
+		msg = msg[n:]
+
+		switch tag & 0x7 {
+		case 0:
+			v, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return 0, fmt.Errorf("invalid gRPC response")
+			}
+			msg = msg[n:]
+			if tag>>3 == 1 {
+				status = v
+			}
+		case 1:
+			if len(msg) < 8 {
+				return 0, fmt.Errorf("invalid gRPC response")
+			}
+			msg = msg[8:]
+		case 2:
+			l, n := binary.Uvarint(msg)
+			if n <= 0 || uint64(len(msg)-n) < l {
+				return 0, fmt.Errorf("invalid gRPC response")
+			}
+			msg = msg[n+int(l):]
+		case 5:
+			if len(msg) < 4 {
+				return 0, fmt.Errorf("invalid gRPC response")
+			}
+			msg = msg[4:]
+		default:
+			return 0, fmt.Errorf("invalid gRPC response")
+		}
+	}
+	return status, nil
+}
+
+// grpcFrame prefixes the uncompressed message with its length as in the gRPC
+// wire format.
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
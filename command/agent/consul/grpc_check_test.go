@@ -0,0 +1,92 @@
+package consul
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testGRPCServer returns a server implementing the gRPC health checking
+// service which reports the status of the services.
+func testGRPCServer(t *testing.T, statuses map[string]byte) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != grpcHealthPath || r.Header.Get("Content-Type") != "application/grpc" {
+			t.Errorf("bad request: %v %v", r.URL.Path, r.Header)
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("err: %v", err)
+		}
+		service := ""
+		if len(body) > 7 {
+			service = string(body[7:])
+		}
+
+		w.Header().Set("Content-Type", "application/grpc")
+		status, ok := statuses[service]
+		if !ok {
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown service")
+			return
+		}
+		w.Write(grpcFrame([]byte{0x08, status}))
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	})
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Config.Protocols = new(http.Protocols)
+	srv.Config.Protocols.SetUnencryptedHTTP2(true)
+	srv.Start()
+	return srv
+}
+
+func TestGRPCCheck_Run(t *testing.T) {
+	srv := testGRPCServer(t, map[string]byte{"": 1, "db": 2})
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+
+	check := NewGRPCCheck("1", addr, "", false, time.Second, time.Second)
+	res := check.Run()
+	if res.Err != nil || res.ExitCode != 0 || !strings.Contains(res.Output, "SERVING") {
+		t.Fatalf("bad: %#v", res)
+	}
+
+	check = NewGRPCCheck("2", addr, "db", false, time.Second, time.Second)
+	res = check.Run()
+	if res.Err != nil || res.ExitCode != 2 || !strings.Contains(res.Output, "NOT_SERVING") {
+		t.Fatalf("bad: %#v", res)
+	}
+
+	check = NewGRPCCheck("3", addr, "foo", false, time.Second, time.Second)
+	res = check.Run()
+	if res.Err != nil || res.ExitCode != 2 || !strings.Contains(res.Output, "unknown service") {
+		t.Fatalf("bad: %#v", res)
+	}
+}
+
+func TestGRPCCheck_Run_Unreachable(t *testing.T) {
+	srv := testGRPCServer(t, nil)
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	srv.Close()
+
+	check := NewGRPCCheck("1", addr, "", false, time.Second, time.Second)
+	if res := check.Run(); res.Err == nil {
+		t.Fatalf("bad: %#v", res)
+	}
+}
+
+func TestGRPCHealthResponse(t *testing.T) {
+	// Unknown fields are skipped
+	body := grpcFrame([]byte{0x12, 0x01, 'a', 0x08, 0x03})
+	status, err := grpcHealthResponse(body)
+	if err != nil || status != 3 {
+		t.Fatalf("bad: %v %v", status, err)
+	}
+
+	if _, err := grpcHealthResponse([]byte{0, 0, 0, 0, 2, 0x08}); err == nil {
+		t.Fatalf("expected error")
+	}
+}
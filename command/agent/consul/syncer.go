@@ -228,19 +228,27 @@ func (c *Syncer) SetServices(domain ServiceDomain, services map[ServiceKey]*stru
 				continue
 			}
 
-			// creating a nomad check if we have to handle this particular check type
+			// creating a nomad check if we have to handle this particular
+			// check type. gRPC checks are always run by Nomad.
 			c.registryLock.RLock()
-			if _, ok := c.delegateChecks[chk.Type]; ok {
+			if _, ok := c.delegateChecks[chk.Type]; ok || chk.Type == structs.ServiceCheckGRPC {
 				_, ok := c.checkRunners[consulCheckID(chkReg.ID)]
 				c.registryLock.RUnlock()
 				if ok {
 					continue
 				}
 
-				nc, err := c.createDelegatedCheck(chk, chkReg.ID)
-				if err != nil {
-					mErr.Errors = append(mErr.Errors, err)
-					continue
+				var nc Check
+				if chk.Type == structs.ServiceCheckGRPC {
+					host, port := c.checkAddr(chk, serviceReg)
+					nc = NewGRPCCheck(chkReg.ID, net.JoinHostPort(host, strconv.Itoa(port)),
+						chk.GRPCService, chk.GRPCUseTLS, chk.Interval, chk.Timeout)
+				} else {
+					nc, err = c.createDelegatedCheck(chk, chkReg.ID)
+					if err != nil {
+						mErr.Errors = append(mErr.Errors, err)
+						continue
+					}
 				}
 
 				cr := NewCheckRunner(nc, c.runCheck, c.logger)
@@ -400,6 +408,24 @@ func (c *Syncer) queryChecks() (map[consulCheckID]*consul.AgentCheck, error) {
 	return c.filterConsulChecks(checks), nil
 }
 
+// DomainChecks queries the Consul Agent for the checks of the services
+// registered in the given domain, keyed by check ID.
+func (c *Syncer) DomainChecks(domain ServiceDomain) (map[string]*consul.AgentCheck, error) {
+	checks, err := c.client.Agent().Checks()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s-%s-", nomadServicePrefix, domain)
+	domainChecks := make(map[string]*consul.AgentCheck)
+	for checkID, check := range checks {
+		if strings.HasPrefix(check.ServiceID, prefix) {
+			domainChecks[checkID] = check
+		}
+	}
+	return domainChecks, nil
+}
+
 // queryAgentServices queries the Consul Agent for a list of Consul services that
 // have been registered with this Consul Syncer.
 func (c *Syncer) queryAgentServices() (map[consulServiceID]*consul.AgentService, error) {
@@ -718,10 +744,7 @@ func (c *Syncer) createCheckReg(check *structs.ServiceCheck, serviceReg *consul.
 	}
 	chkReg.Timeout = check.Timeout.String()
 	chkReg.Interval = check.Interval.String()
-	host, port := serviceReg.Address, serviceReg.Port
-	if check.PortLabel != "" {
-		host, port = c.addrFinder(check.PortLabel)
-	}
+	host, port := c.checkAddr(check, serviceReg)
 	switch check.Type {
 	case structs.ServiceCheckHTTP:
 		if check.Protocol == "" {
@@ -739,7 +762,7 @@ func (c *Syncer) createCheckReg(check *structs.ServiceCheck, serviceReg *consul.
 		chkReg.HTTP = url.String()
 	case structs.ServiceCheckTCP:
 		chkReg.TCP = net.JoinHostPort(host, strconv.Itoa(port))
-	case structs.ServiceCheckScript:
+	case structs.ServiceCheckScript, structs.ServiceCheckGRPC:
 		chkReg.TTL = (check.Interval + ttlCheckBuffer).String()
 	default:
 		return nil, fmt.Errorf("check type %+q not valid", check.Type)
@@ -748,6 +771,15 @@ func (c *Syncer) createCheckReg(check *structs.ServiceCheck, serviceReg *consul.
 	return &chkReg, nil
 }
 
+// checkAddr returns the host and port a check is performed against. Checks
+// default to the address of their service.
+func (c *Syncer) checkAddr(check *structs.ServiceCheck, serviceReg *consul.AgentServiceRegistration) (string, int) {
+	if check.PortLabel != "" {
+		return c.addrFinder(check.PortLabel)
+	}
+	return serviceReg.Address, serviceReg.Port
+}
+
 // generateConsulServiceID takes the domain and service key and returns a Consul
 // ServiceID
 func generateConsulServiceID(domain ServiceDomain, key ServiceKey) consulServiceID {
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_DeploymentList(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d1 := mock.Deployment()
+		d2 := mock.Deployment()
+		if err := state.UpsertDeployment(1000, d1, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if err := state.UpsertDeployment(1001, d2, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployments", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+
+		// Check the deployments
+		out := obj.([]*structs.Deployment)
+		if len(out) != 2 {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_DeploymentQuery(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		d := mock.Deployment()
+		if err := state.UpsertDeployment(1000, d, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/deployment/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+
+		// Check the deployment
+		out := obj.(*structs.Deployment)
+		if out.ID != d.ID {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_DeploymentPromote(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		job := mock.Job()
+		if err := state.UpsertJob(999, job); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		d := mock.Deployment()
+		d.JobID = job.ID
+		if err := state.UpsertDeployment(1000, d, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		args := structs.DeploymentPromoteRequest{All: true}
+		buf := encodeReq(args)
+		req, err := http.NewRequest("PUT", "/v1/deployment/promote/"+d.ID, buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.EvalID == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the deployment was promoted
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !out.TaskGroups["web"].Promoted {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_DeploymentFail(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		job := mock.Job()
+		if err := state.UpsertJob(999, job); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		d := mock.Deployment()
+		d.JobID = job.ID
+		if err := state.UpsertDeployment(1000, d, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/deployment/fail/"+d.ID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.DeploymentSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		resp := obj.(structs.DeploymentUpdateResponse)
+		if resp.EvalID == "" {
+			t.Fatalf("bad: %#v", resp)
+		}
+
+		// Check the deployment was failed
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.Status != structs.DeploymentStatusFailed {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"gopkg.in/tomb.v1"
 
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hpcloud/tail/watch"
 	"github.com/ugorji/go/codec"
@@ -69,6 +70,19 @@ func (s *HTTPServer) FsRequest(resp http.ResponseWriter, req *http.Request) (int
 	}
 
 	path := strings.TrimPrefix(req.URL.Path, "/v1/client/fs/")
+
+	// The logs of an allocation require read-logs and its other files read-fs
+	// in the namespace of its job
+	if i := strings.Index(path, "/"); i != -1 {
+		op := acl.NamespaceCapabilityReadFS
+		if strings.HasPrefix(path, "logs/") {
+			op = acl.NamespaceCapabilityReadLogs
+		}
+		if err := s.checkAllocOperation(req, path[i+1:], op); err != nil {
+			return nil, err
+		}
+	}
+
 	switch {
 	case strings.HasPrefix(path, "ls/"):
 		return s.DirectoryListRequest(resp, req)
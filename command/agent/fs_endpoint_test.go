@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/ugorji/go/codec"
 )
@@ -111,6 +112,56 @@ func (w *WriteCloseChecker) Close() error {
 
 // This test checks, that even if the frame size has not been hit, a flush will
 // periodically occur.
+func TestHTTP_FS_ACL(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		root := aclBootstrap(t, s)
+		readJob := createTestToken(t, s, 1000, `namespace "default" { policy = "read" }`)
+		readFS := createTestToken(t, s, 1010, `namespace "default" { capabilities = ["read-fs"] }`)
+		readLogs := createTestToken(t, s, 1020, `namespace "default" { capabilities = ["read-logs"] }`)
+
+		cases := []struct {
+			path   string
+			denied []*structs.ACLToken
+			passed []*structs.ACLToken
+		}{
+			{"/v1/client/fs/ls/123", []*structs.ACLToken{readJob, readLogs}, []*structs.ACLToken{readFS, root}},
+			{"/v1/client/fs/stat/123?path=foo", []*structs.ACLToken{readJob, readLogs}, []*structs.ACLToken{readFS, root}},
+			{"/v1/client/fs/readat/123?path=foo&offset=0", []*structs.ACLToken{readJob, readLogs}, []*structs.ACLToken{readFS, root}},
+			{"/v1/client/fs/cat/123?path=foo", []*structs.ACLToken{readJob, readLogs}, []*structs.ACLToken{readFS, root}},
+			{"/v1/client/fs/stream/123?path=foo", []*structs.ACLToken{readJob, readLogs}, []*structs.ACLToken{readFS, root}},
+			{"/v1/client/fs/logs/123?task=foo&type=stdout&follow=false", []*structs.ACLToken{readJob, readFS}, []*structs.ACLToken{readLogs, root}},
+		}
+		for _, c := range cases {
+			for _, token := range c.denied {
+				req, err := http.NewRequest("GET", c.path, nil)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				setToken(req, token)
+				respW := httptest.NewRecorder()
+				s.Server.mux.ServeHTTP(respW, req)
+				if respW.Code != 403 {
+					t.Fatalf("%s: expected 403 for %q, got %d", c.path, token.Name, respW.Code)
+				}
+			}
+
+			// Allowed tokens reach the handler which does not know the alloc
+			for _, token := range c.passed {
+				req, err := http.NewRequest("GET", c.path, nil)
+				if err != nil {
+					t.Fatalf("err: %v", err)
+				}
+				setToken(req, token)
+				respW := httptest.NewRecorder()
+				s.Server.mux.ServeHTTP(respW, req)
+				if respW.Code == 403 {
+					t.Fatalf("%s: unexpected 403 for %q: %s", c.path, token.Name, respW.Body.String())
+				}
+			}
+		}
+	})
+}
+
 func TestStreamFramer_Flush(t *testing.T) {
 	// Create the stream framer
 	r, w := io.Pipe()
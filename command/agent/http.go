@@ -11,11 +11,14 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/NYTimes/gziphandler"
+	"github.com/hashicorp/nomad/helper/filter"
 	"github.com/hashicorp/nomad/helper/tlsutil"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/ugorji/go/codec"
 )
 
@@ -44,6 +47,10 @@ type HTTPServer struct {
 	listener net.Listener
 	logger   *log.Logger
 	addr     string
+
+	// keyLoader serves the HTTPS certificate and allows it to be reloaded.
+	// It is nil if TLS is not enabled for HTTP.
+	keyLoader *tlsutil.KeyLoader
 }
 
 // NewHTTPServer starts new HTTP server over the agent
@@ -59,6 +66,7 @@ func NewHTTPServer(agent *Agent, config *Config, logOutput io.Writer) (*HTTPServ
 	}
 
 	// If TLS is enabled, wrap the listener with a TLS listener
+	var keyLoader *tlsutil.KeyLoader
 	if config.TLSConfig.EnableHTTP {
 		tlsConf := &tlsutil.Config{
 			VerifyIncoming:       false,
@@ -72,6 +80,17 @@ func NewHTTPServer(agent *Agent, config *Config, logOutput io.Writer) (*HTTPServ
 		if err != nil {
 			return nil, err
 		}
+
+		// Serve the certificate through the key loader so it can be
+		// rotated on reload without recreating the listener
+		if tlsConf.CertFile != "" && tlsConf.KeyFile != "" {
+			keyLoader = &tlsutil.KeyLoader{}
+			if _, err := keyLoader.LoadKeyPair(tlsConf.CertFile, tlsConf.KeyFile); err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = nil
+			tlsConfig.GetCertificate = keyLoader.GetCertificate
+		}
 		ln = tls.NewListener(tcpKeepAliveListener{ln.(*net.TCPListener)}, tlsConfig)
 	}
 
@@ -80,11 +99,12 @@ func NewHTTPServer(agent *Agent, config *Config, logOutput io.Writer) (*HTTPServ
 
 	// Create the server
 	srv := &HTTPServer{
-		agent:    agent,
-		mux:      mux,
-		listener: ln,
-		logger:   agent.logger,
-		addr:     ln.Addr().String(),
+		agent:     agent,
+		mux:       mux,
+		listener:  ln,
+		logger:    agent.logger,
+		addr:      ln.Addr().String(),
+		keyLoader: keyLoader,
 	}
 	srv.registerHandlers(config.EnableDebug)
 
@@ -131,6 +151,19 @@ func (ln tcpKeepAliveListener) Accept() (c net.Conn, err error) {
 	return tc, nil
 }
 
+// ReloadTLS re-reads the certificate and key served by the HTTPS listener.
+// It is a no-op if the listener does not serve a certificate.
+func (s *HTTPServer) ReloadTLS(tlsConfig *config.TLSConfig) error {
+	if s.keyLoader == nil {
+		return nil
+	}
+	if _, err := s.keyLoader.LoadKeyPair(tlsConfig.CertFile, tlsConfig.KeyFile); err != nil {
+		return err
+	}
+	s.logger.Printf("[INFO] http: reloaded TLS certificate")
+	return nil
+}
+
 // Shutdown is used to shutdown the HTTP server
 func (s *HTTPServer) Shutdown() {
 	if s != nil {
@@ -143,6 +176,7 @@ func (s *HTTPServer) Shutdown() {
 func (s *HTTPServer) registerHandlers(enableDebug bool) {
 	s.mux.HandleFunc("/v1/jobs", s.wrap(s.JobsRequest))
 	s.mux.HandleFunc("/v1/job/", s.wrap(s.JobSpecificRequest))
+	s.mux.HandleFunc("/v1/validate/job", s.wrap(s.ValidateJobRequest))
 
 	s.mux.HandleFunc("/v1/nodes", s.wrap(s.NodesRequest))
 	s.mux.HandleFunc("/v1/node/", s.wrap(s.NodeSpecificRequest))
@@ -153,16 +187,41 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 	s.mux.HandleFunc("/v1/evaluations", s.wrap(s.EvalsRequest))
 	s.mux.HandleFunc("/v1/evaluation/", s.wrap(s.EvalSpecificRequest))
 
+	s.mux.HandleFunc("/v1/deployments", s.wrap(s.DeploymentsRequest))
+	s.mux.HandleFunc("/v1/deployment/", s.wrap(s.DeploymentSpecificRequest))
+
+	s.mux.HandleFunc("/v1/namespaces", s.wrap(s.NamespacesRequest))
+	s.mux.HandleFunc("/v1/namespace", s.wrap(s.NamespaceCreateRequest))
+	s.mux.HandleFunc("/v1/namespace/", s.wrap(s.NamespaceSpecificRequest))
+
+	s.mux.HandleFunc("/v1/quotas", s.wrap(s.QuotasRequest))
+	s.mux.HandleFunc("/v1/quota", s.wrap(s.QuotaCreateRequest))
+	s.mux.HandleFunc("/v1/quota/", s.wrap(s.QuotaSpecificRequest))
+
+	s.mux.HandleFunc("/v1/acl/policies", s.wrap(s.ACLPoliciesRequest))
+	s.mux.HandleFunc("/v1/acl/policy/", s.wrap(s.ACLPolicySpecificRequest))
+
+	s.mux.HandleFunc("/v1/acl/bootstrap", s.wrap(s.ACLTokenBootstrap))
+	s.mux.HandleFunc("/v1/acl/tokens", s.wrap(s.ACLTokensRequest))
+	s.mux.HandleFunc("/v1/acl/token", s.wrap(s.ACLTokenCreateRequest))
+	s.mux.HandleFunc("/v1/acl/token/", s.wrap(s.ACLTokenSpecificRequest))
+
 	s.mux.HandleFunc("/v1/client/fs/", s.wrap(s.FsRequest))
 	s.mux.HandleFunc("/v1/client/stats", s.wrap(s.ClientStatsRequest))
 	s.mux.HandleFunc("/v1/client/allocation/", s.wrap(s.ClientAllocRequest))
+	s.mux.HandleFunc("/v1/client/gc", s.wrap(s.ClientGCRequest))
 
 	s.mux.HandleFunc("/v1/agent/self", s.wrap(s.AgentSelfRequest))
 	s.mux.HandleFunc("/v1/agent/join", s.wrap(s.AgentJoinRequest))
 	s.mux.HandleFunc("/v1/agent/members", s.wrap(s.AgentMembersRequest))
 	s.mux.HandleFunc("/v1/agent/force-leave", s.wrap(s.AgentForceLeaveRequest))
 	s.mux.HandleFunc("/v1/agent/servers", s.wrap(s.AgentServersRequest))
+	s.mux.HandleFunc("/v1/agent/monitor", s.wrap(s.AgentMonitor))
+	s.mux.HandleFunc("/v1/agent/pprof/", s.wrap(s.AgentPprofRequest))
 	s.mux.HandleFunc("/v1/agent/keyring/", s.wrap(s.KeyringOperationRequest))
+	s.mux.HandleFunc("/v1/agent/reload", s.wrap(s.AgentReloadRequest))
+
+	s.mux.HandleFunc("/v1/metrics", s.wrap(s.MetricsRequest))
 
 	s.mux.HandleFunc("/v1/regions", s.wrap(s.RegionListRequest))
 
@@ -172,6 +231,17 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 	s.mux.HandleFunc("/v1/system/gc", s.wrap(s.GarbageCollectRequest))
 	s.mux.HandleFunc("/v1/system/reconcile/summaries", s.wrap(s.ReconcileJobSummaries))
 
+	s.mux.HandleFunc("/v1/operator/broker", s.wrap(s.OperatorBrokerRequest))
+	s.mux.HandleFunc("/v1/operator/snapshot", s.wrap(s.SnapshotRequest))
+	s.mux.HandleFunc("/v1/operator/autopilot/configuration", s.wrap(s.OperatorAutopilotConfiguration))
+	s.mux.HandleFunc("/v1/operator/autopilot/health", s.wrap(s.OperatorServerHealth))
+	s.mux.HandleFunc("/v1/operator/scheduler/configuration", s.wrap(s.OperatorSchedulerConfiguration))
+	s.mux.HandleFunc("/v1/operator/raft/configuration", s.wrap(s.OperatorRaftConfiguration))
+	s.mux.HandleFunc("/v1/operator/raft/peer", s.wrap(s.OperatorRaftPeer))
+
+	s.mux.HandleFunc("/ui/", s.UIRequest)
+	s.mux.HandleFunc("/", s.UIRedirect)
+
 	if enableDebug {
 		s.mux.HandleFunc("/debug/pprof/", pprof.Index)
 		s.mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
@@ -213,7 +283,19 @@ func (s *HTTPServer) wrap(handler func(resp http.ResponseWriter, req *http.Reque
 		defer func() {
 			s.logger.Printf("[DEBUG] http: Request %v (%v)", reqURL, time.Now().Sub(start))
 		}()
+		var audit *auditRecorder
+		if s.agent.auditor != nil {
+			resp, audit = s.agent.auditor.start(resp, req, s.auditActor(req))
+		}
+
 		obj, err := handler(resp, req)
+		if audit != nil {
+			defer func(handlerErr error) {
+				if err := audit.finish(handlerErr); err != nil {
+					s.logger.Printf("[ERR] http: failed to write audit event for request %v: %v", reqURL, err)
+				}
+			}(err)
+		}
 
 		// Check for an error
 	HAS_ERR:
@@ -222,6 +304,15 @@ func (s *HTTPServer) wrap(handler func(resp http.ResponseWriter, req *http.Reque
 			code := 500
 			if http, ok := err.(HTTPCodedError); ok {
 				code = http.Code()
+			} else {
+				// Errors returned over RPC lose their type so compare the
+				// messages of the ACL errors
+				switch msg := err.Error(); {
+				case msg == structs.ErrPermissionDenied.Error(), msg == structs.ErrTokenNotFound.Error():
+					code = 403
+				case strings.HasPrefix(msg, structs.ErrInvalidFilterPrefix):
+					code = 400
+				}
 			}
 			resp.WriteHeader(code)
 			resp.Write([]byte(err.Error()))
@@ -258,6 +349,22 @@ func (s *HTTPServer) wrap(handler func(resp http.ResponseWriter, req *http.Reque
 	return f
 }
 
+// auditActor returns the author of the request recorded in the audit log
+func (s *HTTPServer) auditActor(req *http.Request) auditActor {
+	actor := auditActor{RemoteAddr: req.RemoteAddr}
+
+	var secretID string
+	s.parseToken(req, &secretID)
+	if secretID != "" && s.agent.server != nil {
+		token, err := s.agent.server.State().ACLTokenBySecretID(secretID)
+		if err == nil && token != nil {
+			actor.AccessorID = token.AccessorID
+			actor.TokenName = token.Name
+		}
+	}
+	return actor
+}
+
 // decodeBody is used to decode a JSON request body
 func decodeBody(req *http.Request, out interface{}) error {
 	dec := json.NewDecoder(req.Body)
@@ -289,6 +396,14 @@ func setMeta(resp http.ResponseWriter, m *structs.QueryMeta) {
 	setIndex(resp, m.Index)
 	setLastContact(resp, m.LastContact)
 	setKnownLeader(resp, m.KnownLeader)
+	setNextToken(resp, m.NextToken)
+}
+
+// setNextToken is used to set the next token header of a list page
+func setNextToken(resp http.ResponseWriter, nextToken string) {
+	if nextToken != "" {
+		resp.Header().Set("X-Nomad-NextToken", nextToken)
+	}
 }
 
 // setHeaders is used to set canonical response header fields
@@ -339,6 +454,33 @@ func parsePrefix(req *http.Request, b *structs.QueryOptions) {
 	}
 }
 
+// parseFilter is used to parse the ?filter, ?per_page and ?next_token query
+// params of list queries
+func parseFilter(resp http.ResponseWriter, req *http.Request, b *structs.QueryOptions) bool {
+	query := req.URL.Query()
+	if expr := query.Get("filter"); expr != "" {
+		if _, err := filter.Parse(expr); err != nil {
+			resp.WriteHeader(400)
+			resp.Write([]byte(fmt.Sprintf("%s: %v", structs.ErrInvalidFilterPrefix, err)))
+			return true
+		}
+		b.Filter = expr
+	}
+	if perPage := query.Get("per_page"); perPage != "" {
+		n, err := strconv.ParseInt(perPage, 10, 32)
+		if err != nil || n < 0 {
+			resp.WriteHeader(400)
+			resp.Write([]byte("Invalid per_page value"))
+			return true
+		}
+		b.PerPage = int32(n)
+	}
+	if nextToken := query.Get("next_token"); nextToken != "" {
+		b.NextToken = nextToken
+	}
+	return false
+}
+
 // parseRegion is used to parse the ?region query param
 func (s *HTTPServer) parseRegion(req *http.Request, r *string) {
 	if other := req.URL.Query().Get("region"); other != "" {
@@ -348,10 +490,37 @@ func (s *HTTPServer) parseRegion(req *http.Request, r *string) {
 	}
 }
 
+// parseNamespace is used to parse the ?namespace query param
+func parseNamespace(req *http.Request, n *string) {
+	if other := req.URL.Query().Get("namespace"); other != "" {
+		*n = other
+	}
+}
+
+// parseToken is used to parse the X-Nomad-Token header
+func (s *HTTPServer) parseToken(req *http.Request, token *string) {
+	if other := req.Header.Get("X-Nomad-Token"); other != "" {
+		*token = other
+	}
+}
+
+// parseWriteRequest is a convenience method for endpoints that issue writes
+// and need to parse the region, namespace and ACL token
+func (s *HTTPServer) parseWriteRequest(req *http.Request, w *structs.WriteRequest) {
+	s.parseRegion(req, &w.Region)
+	parseNamespace(req, &w.Namespace)
+	s.parseToken(req, &w.AuthToken)
+}
+
 // parse is a convenience method for endpoints that need to parse multiple flags
 func (s *HTTPServer) parse(resp http.ResponseWriter, req *http.Request, r *string, b *structs.QueryOptions) bool {
 	s.parseRegion(req, r)
+	parseNamespace(req, &b.Namespace)
+	s.parseToken(req, &b.AuthToken)
 	parseConsistency(req, b)
 	parsePrefix(req, b)
+	if parseFilter(resp, req, b) {
+		return true
+	}
 	return parseWait(resp, req, b)
 }
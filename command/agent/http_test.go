@@ -93,6 +93,51 @@ func BenchmarkHTTPRequests(b *testing.B) {
 	})
 }
 
+func TestHTTPServer_ReloadTLS(t *testing.T) {
+	const (
+		foocert = "../../helper/tlsutil/testdata/nomad-foo.pem"
+		fookey  = "../../helper/tlsutil/testdata/nomad-foo-key.pem"
+		badcert = "../../helper/tlsutil/testdata/nomad-bad.pem"
+		badkey  = "../../helper/tlsutil/testdata/nomad-bad-key.pem"
+	)
+
+	s := makeHTTPServer(t, func(c *Config) {
+		c.TLSConfig.EnableHTTP = true
+		c.TLSConfig.CertFile = foocert
+		c.TLSConfig.KeyFile = fookey
+	})
+	defer s.Cleanup()
+
+	orig, err := s.Server.keyLoader.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A missing key is rejected and the current certificate kept
+	newConf := *s.Agent.config.TLSConfig
+	newConf.KeyFile = "/does/not/exist"
+	if err := s.Server.ReloadTLS(&newConf); err == nil {
+		t.Fatalf("expected error")
+	}
+	if cert, _ := s.Server.keyLoader.GetCertificate(nil); cert != orig {
+		t.Fatalf("certificate changed")
+	}
+
+	// Rotate the certificate
+	newConf.CertFile = badcert
+	newConf.KeyFile = badkey
+	if err := s.Server.ReloadTLS(&newConf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	cert, err := s.Server.keyLoader.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if bytes.Equal(cert.Certificate[0], orig.Certificate[0]) {
+		t.Fatalf("certificate not reloaded")
+	}
+}
+
 func TestSetIndex(t *testing.T) {
 	resp := httptest.NewRecorder()
 	setIndex(resp, 1000)
@@ -150,6 +195,17 @@ func TestSetMeta(t *testing.T) {
 	if header != "123" {
 		t.Fatalf("Bad: %v", header)
 	}
+	if _, ok := resp.HeaderMap["X-Nomad-Nexttoken"]; ok {
+		t.Fatalf("Bad: %v", resp.HeaderMap)
+	}
+
+	meta.NextToken = "foo"
+	resp = httptest.NewRecorder()
+	setMeta(resp, &meta)
+	header = resp.Header().Get("X-Nomad-NextToken")
+	if header != "foo" {
+		t.Fatalf("Bad: %v", header)
+	}
 }
 
 func TestSetHeaders(t *testing.T) {
@@ -296,6 +352,45 @@ func TestParseWait_InvalidIndex(t *testing.T) {
 	}
 }
 
+func TestParseFilter(t *testing.T) {
+	resp := httptest.NewRecorder()
+	var b structs.QueryOptions
+
+	req, err := http.NewRequest("GET",
+		`/v1/allocations?per_page=10&next_token=abc&filter=ClientStatus+%3D%3D+%22running%22`, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if d := parseFilter(resp, req, &b); d {
+		t.Fatalf("unexpected done")
+	}
+
+	if b.PerPage != 10 || b.NextToken != "abc" || b.Filter != `ClientStatus == "running"` {
+		t.Fatalf("Bad: %#v", b)
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, query := range []string{"per_page=foo", "per_page=-1", "filter=ClientStatus+%3D%3D"} {
+		resp := httptest.NewRecorder()
+		var b structs.QueryOptions
+
+		req, err := http.NewRequest("GET", "/v1/allocations?"+query, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if d := parseFilter(resp, req, &b); !d {
+			t.Fatalf("expected done for %q", query)
+		}
+
+		if resp.Code != 400 {
+			t.Fatalf("bad code for %q: %v", query, resp.Code)
+		}
+	}
+}
+
 func TestParseConsistency(t *testing.T) {
 	var b structs.QueryOptions
 
@@ -351,6 +446,46 @@ func TestParseRegion(t *testing.T) {
 	}
 }
 
+func TestParseNamespace(t *testing.T) {
+	req, err := http.NewRequest("GET", "/v1/jobs?namespace=foo", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var namespace string
+	parseNamespace(req, &namespace)
+	if namespace != "foo" {
+		t.Fatalf("bad %s", namespace)
+	}
+
+	req, err = http.NewRequest("GET", "/v1/jobs", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	parseNamespace(req, &namespace)
+	if namespace != "foo" {
+		t.Fatalf("bad %s", namespace)
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	s := makeHTTPServer(t, nil)
+	defer s.Cleanup()
+
+	req, err := http.NewRequest("GET", "/v1/jobs", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	req.Header.Add("X-Nomad-Token", "foobar")
+
+	var token string
+	s.Server.parseToken(req, &token)
+	if token != "foobar" {
+		t.Fatalf("bad %s", token)
+	}
+}
+
 // assertIndex tests that X-Nomad-Index is set and non-zero
 func assertIndex(t *testing.T, resp *httptest.ResponseRecorder) {
 	header := resp.Header().Get("X-Nomad-Index")
@@ -3,6 +3,7 @@ package agent
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -521,3 +522,225 @@ func TestHTTP_JobPlan(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_ValidateJob(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create an invalid job
+		job := mock.Job()
+		job.Priority = 0
+		args := structs.JobValidateRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/validate/job", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.ValidateJobRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		out := obj.(structs.JobValidateResponse)
+		if len(out.ValidationErrors) != 1 || !strings.Contains(out.ValidationErrors[0], "priority") {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// The job wasn't registered
+		getReq := structs.JobSpecificRequest{
+			JobID:        job.ID,
+			QueryOptions: structs.QueryOptions{Region: "global"},
+		}
+		var getResp structs.SingleJobResponse
+		if err := s.Agent.RPC("Job.GetJob", &getReq, &getResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if getResp.Job != nil {
+			t.Fatalf("bad: %#v", getResp.Job)
+		}
+	})
+}
+
+func TestHTTP_JobVersions(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Update the job
+		job2 := mock.Job()
+		job2.ID = job.ID
+		job2.Priority = 100
+		args.Job = job2
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/job/"+job.ID+"/versions?diffs=true", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+		if respW.HeaderMap.Get("X-Nomad-LastContact") == "" {
+			t.Fatalf("missing last contact")
+		}
+
+		// Check the versions and diffs
+		out := obj.(structs.JobVersionsResponse)
+		if len(out.Versions) != 2 {
+			t.Fatalf("got %d versions; want 2", len(out.Versions))
+		}
+		if v := out.Versions[0]; v.Version != 1 || v.Priority != 100 {
+			t.Fatalf("bad %v", v)
+		}
+		if v := out.Versions[1]; v.Version != 0 {
+			t.Fatalf("bad %v", v)
+		}
+		if len(out.Diffs) != 1 {
+			t.Fatalf("got %d diffs; want 1", len(out.Diffs))
+		}
+	})
+}
+
+func TestHTTP_JobRevert(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the job and register it twice
+		job := mock.Job()
+		regReq := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var regResp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &regReq, &regResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		job2 := mock.Job()
+		job2.ID = job.ID
+		job2.Priority = 100
+		regReq.Job = job2
+		if err := s.Agent.RPC("Job.Register", &regReq, &regResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		args := structs.JobRevertRequest{
+			JobID:        job.ID,
+			JobVersion:   0,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/revert", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		reg := obj.(structs.JobRegisterResponse)
+		if reg.EvalID == "" {
+			t.Fatalf("bad: %v", reg)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the job is reverted to the first version
+		getReq := structs.JobSpecificRequest{
+			JobID:        job.ID,
+			QueryOptions: structs.QueryOptions{Region: "global"},
+		}
+		var getResp structs.SingleJobResponse
+		if err := s.Agent.RPC("Job.GetJob", &getReq, &getResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if getResp.Job.Version != 2 || getResp.Job.Priority != job.Priority {
+			t.Fatalf("job not reverted: %#v", getResp.Job)
+		}
+	})
+}
+
+func TestHTTP_JobDispatch(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the parameterized job
+		job := mock.Job()
+		job.Type = structs.JobTypeBatch
+		job.ParameterizedJob = &structs.ParameterizedJobConfig{}
+
+		args := structs.JobRegisterRequest{
+			Job:          job,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the request
+		respW := httptest.NewRecorder()
+		args2 := structs.JobDispatchRequest{
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		buf := encodeReq(args2)
+
+		// Make the HTTP request
+		req2, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/dispatch", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req2)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		dispatch := obj.(structs.JobDispatchResponse)
+		if dispatch.EvalID == "" {
+			t.Fatalf("bad: %v", dispatch)
+		}
+
+		if dispatch.DispatchedJobID == "" {
+			t.Fatalf("bad: %v", dispatch)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/armon/go-metrics"
+)
+
+// invalidPrometheusChars matches the characters that are not allowed in the
+// names of Prometheus metrics.
+var invalidPrometheusChars = regexp.MustCompile("[^a-zA-Z0-9_:]")
+
+// prometheusSink is a MetricSink keeping the metrics in the form expected by
+// Prometheus: the counters are cumulative and the samples are summarized by
+// their count and sum since the agent started.
+type prometheusSink struct {
+	l         sync.Mutex
+	gauges    map[string]float32
+	counters  map[string]float64
+	summaries map[string]*prometheusSummary
+}
+
+type prometheusSummary struct {
+	count int
+	sum   float64
+}
+
+func newPrometheusSink() *prometheusSink {
+	return &prometheusSink{
+		gauges:    make(map[string]float32),
+		counters:  make(map[string]float64),
+		summaries: make(map[string]*prometheusSummary),
+	}
+}
+
+func (p *prometheusSink) SetGauge(key []string, val float32) {
+	name := prometheusName(key)
+	p.l.Lock()
+	p.gauges[name] = val
+	p.l.Unlock()
+}
+
+func (p *prometheusSink) EmitKey(key []string, val float32) {
+	p.SetGauge(key, val)
+}
+
+func (p *prometheusSink) IncrCounter(key []string, val float32) {
+	name := prometheusName(key)
+	p.l.Lock()
+	p.counters[name] += float64(val)
+	p.l.Unlock()
+}
+
+func (p *prometheusSink) AddSample(key []string, val float32) {
+	name := prometheusName(key)
+	p.l.Lock()
+	defer p.l.Unlock()
+	s, ok := p.summaries[name]
+	if !ok {
+		s = &prometheusSummary{}
+		p.summaries[name] = s
+	}
+	s.count++
+	s.sum += float64(val)
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (p *prometheusSink) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	p.l.Lock()
+	for _, name := range sortedNames(p.gauges) {
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n%s %s\n", name, name, formatFloat(float64(p.gauges[name])))
+	}
+	for _, name := range sortedNames(p.counters) {
+		fmt.Fprintf(&buf, "# TYPE %s counter\n%s %s\n", name, name, formatFloat(p.counters[name]))
+	}
+	for _, name := range sortedNames(p.summaries) {
+		s := p.summaries[name]
+		fmt.Fprintf(&buf, "# TYPE %s summary\n%s_sum %s\n%s_count %d\n", name, name, formatFloat(s.sum), name, s.count)
+	}
+	p.l.Unlock()
+
+	return buf.WriteTo(w)
+}
+
+// prometheusName returns the name of the metric of the key
+func prometheusName(key []string) string {
+	return invalidPrometheusChars.ReplaceAllString(strings.Join(key, "_"), "_")
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return fmt.Sprintf("%g", f)
+}
+
+// MetricsSummary is the metrics of the last aggregation interval of the agent
+type MetricsSummary struct {
+	Timestamp string
+	Gauges    []GaugeValue
+	Points    []PointValue
+	Counters  []SampledValue
+	Samples   []SampledValue
+}
+
+// GaugeValue is the last value of a gauge
+type GaugeValue struct {
+	Name  string
+	Value float32
+}
+
+// PointValue is the values emitted for a key
+type PointValue struct {
+	Name   string
+	Points []float32
+}
+
+// SampledValue is the aggregation of a counter or a sample
+type SampledValue struct {
+	Name   string
+	Count  int
+	Sum    float64
+	Min    float64
+	Max    float64
+	Mean   float64
+	Stddev float64
+}
+
+// newMetricsSummary returns the summary of the current interval of the sink
+func newMetricsSummary(inm *metrics.InmemSink) *MetricsSummary {
+	summary := &MetricsSummary{
+		Gauges:   make([]GaugeValue, 0),
+		Points:   make([]PointValue, 0),
+		Counters: make([]SampledValue, 0),
+		Samples:  make([]SampledValue, 0),
+	}
+
+	data := inm.Data()
+	if len(data) == 0 {
+		return summary
+	}
+	interval := data[len(data)-1]
+	interval.RLock()
+	defer interval.RUnlock()
+
+	summary.Timestamp = interval.Interval.String()
+	for _, name := range sortedNames(interval.Gauges) {
+		summary.Gauges = append(summary.Gauges, GaugeValue{Name: name, Value: interval.Gauges[name]})
+	}
+	for _, name := range sortedNames(interval.Points) {
+		summary.Points = append(summary.Points, PointValue{Name: name, Points: interval.Points[name]})
+	}
+	for _, name := range sortedNames(interval.Counters) {
+		summary.Counters = append(summary.Counters, newSampledValue(name, interval.Counters[name]))
+	}
+	for _, name := range sortedNames(interval.Samples) {
+		summary.Samples = append(summary.Samples, newSampledValue(name, interval.Samples[name]))
+	}
+	return summary
+}
+
+func newSampledValue(name string, agg *metrics.AggregateSample) SampledValue {
+	return SampledValue{
+		Name:   name,
+		Count:  agg.Count,
+		Sum:    agg.Sum,
+		Min:    agg.Min,
+		Max:    agg.Max,
+		Mean:   agg.Mean(),
+		Stddev: agg.Stddev(),
+	}
+}
+
+// sortedNames returns the sorted keys of a map of metrics
+func sortedNames(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.String()
+	}
+	sort.Strings(names)
+	return names
+}
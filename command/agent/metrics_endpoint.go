@@ -0,0 +1,27 @@
+package agent
+
+import (
+	"net/http"
+)
+
+// MetricsRequest returns the metrics of the agent, in the Prometheus text
+// format if requested with format=prometheus.
+func (s *HTTPServer) MetricsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	if req.URL.Query().Get("format") == "prometheus" {
+		if s.agent.prometheusSink == nil {
+			return nil, CodedError(400, "Prometheus metrics are not enabled, set prometheus_metrics in the telemetry configuration")
+		}
+		resp.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.agent.prometheusSink.WriteTo(resp)
+		return nil, nil
+	}
+
+	if s.agent.inmemSink == nil {
+		return nil, CodedError(500, "metrics are not initialized")
+	}
+	return newMetricsSummary(s.agent.inmemSink), nil
+}
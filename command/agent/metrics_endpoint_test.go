@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+func TestHTTP_Metrics(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		s.Agent.inmemSink = metrics.NewInmemSink(10*time.Second, time.Minute)
+		s.Agent.inmemSink.SetGauge([]string{"foo"}, 1)
+
+		req, err := http.NewRequest("GET", "/v1/metrics", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		obj, err := s.Server.MetricsRequest(httptest.NewRecorder(), req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		summary := obj.(*MetricsSummary)
+		if len(summary.Gauges) != 1 || summary.Gauges[0].Name != "foo" {
+			t.Fatalf("bad: %#v", summary)
+		}
+
+		// Prometheus must be enabled
+		req, err = http.NewRequest("GET", "/v1/metrics?format=prometheus", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		_, err = s.Server.MetricsRequest(httptest.NewRecorder(), req)
+		if err == nil || !strings.Contains(err.Error(), "not enabled") {
+			t.Fatalf("err: %v", err)
+		}
+
+		s.Agent.prometheusSink = newPrometheusSink()
+		s.Agent.prometheusSink.SetGauge([]string{"foo"}, 1)
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.MetricsRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out := respW.Body.String(); !strings.Contains(out, "foo 1\n") {
+			t.Fatalf("bad: %s", out)
+		}
+	})
+}
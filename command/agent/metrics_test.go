@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+func TestPrometheusSink(t *testing.T) {
+	sink := newPrometheusSink()
+	sink.SetGauge([]string{"nomad", "client", "allocs", "running"}, 2)
+	sink.IncrCounter([]string{"nomad", "worker", "invoke-scheduler"}, 1)
+	sink.IncrCounter([]string{"nomad", "worker", "invoke-scheduler"}, 2)
+	sink.AddSample([]string{"nomad", "plan", "evaluate"}, 1.5)
+	sink.AddSample([]string{"nomad", "plan", "evaluate"}, 2.5)
+
+	var buf bytes.Buffer
+	if _, err := sink.WriteTo(&buf); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expected := `# TYPE nomad_client_allocs_running gauge
+nomad_client_allocs_running 2
+# TYPE nomad_worker_invoke_scheduler counter
+nomad_worker_invoke_scheduler 3
+# TYPE nomad_plan_evaluate summary
+nomad_plan_evaluate_sum 4
+nomad_plan_evaluate_count 2
+`
+	if out := buf.String(); out != expected {
+		t.Fatalf("bad: %s", out)
+	}
+}
+
+func TestMetricsSummary(t *testing.T) {
+	inm := metrics.NewInmemSink(10*time.Second, time.Minute)
+	if summary := newMetricsSummary(inm); len(summary.Gauges) != 0 {
+		t.Fatalf("bad: %#v", summary)
+	}
+
+	inm.SetGauge([]string{"b"}, 2)
+	inm.SetGauge([]string{"a"}, 1)
+	inm.AddSample([]string{"c"}, 3)
+	summary := newMetricsSummary(inm)
+	if len(summary.Gauges) != 2 || summary.Gauges[0].Name != "a" || summary.Gauges[1].Value != 2 {
+		t.Fatalf("bad: %#v", summary.Gauges)
+	}
+	if len(summary.Samples) != 1 || summary.Samples[0].Count != 1 || summary.Samples[0].Mean != 3 {
+		t.Fatalf("bad: %#v", summary.Samples)
+	}
+}
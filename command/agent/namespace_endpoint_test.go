@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_NamespaceList(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		ns := mock.Namespace()
+		if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/namespaces", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.NamespacesRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the namespaces, including the default namespace
+		out := obj.([]*structs.Namespace)
+		if len(out) != 2 {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_NamespaceCRUD(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		ns := mock.Namespace()
+
+		// Create the namespace
+		req, err := http.NewRequest("PUT", "/v1/namespace/"+ns.Name, encodeReq(ns))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.NamespaceSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Query the namespace
+		req, err = http.NewRequest("GET", "/v1/namespace/"+ns.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.NamespaceSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out := obj.(*structs.Namespace)
+		if out.Name != ns.Name || out.Description != ns.Description {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// Delete the namespace
+		req, err = http.NewRequest("DELETE", "/v1/namespace/"+ns.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.NamespaceSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Querying the deleted namespace is a 404
+		req, err = http.NewRequest("GET", "/v1/namespace/"+ns.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		_, err = s.Server.NamespaceSpecificRequest(respW, req)
+		if err == nil || err.Error() != "namespace not found" {
+			t.Fatalf("expected not found error: %v", err)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -271,6 +272,101 @@ func TestHTTP_NodeDrain(t *testing.T) {
 	})
 }
 
+func TestHTTP_NodeEligibility(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the node
+		node := mock.Node()
+		args := structs.NodeRegisterRequest{
+			Node:         node,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.NodeUpdateResponse
+		if err := s.Agent.RPC("Node.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// The enable value is required
+		req, err := http.NewRequest("POST", "/v1/node/"+node.ID+"/eligibility", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.NodeSpecificRequest(respW, req); err == nil {
+			t.Fatalf("expected error")
+		}
+
+		// Make the HTTP request
+		req, err = http.NewRequest("POST", "/v1/node/"+node.ID+"/eligibility?enable=false", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+
+		// Make the request
+		_, err = s.Server.NodeSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the node is ineligible
+		out, err := s.Agent.server.State().NodeByID(node.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if out.SchedulingEligibility != structs.NodeSchedulingIneligible {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_NodeDrain_Deadline(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Create the node
+		node := mock.Node()
+		args := structs.NodeRegisterRequest{
+			Node:         node,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.NodeUpdateResponse
+		if err := s.Agent.RPC("Node.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// A deadline requires enabling drain
+		req, err := http.NewRequest("POST", "/v1/node/"+node.ID+"/drain?enable=false&deadline=1h", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.NodeSpecificRequest(respW, req); err == nil {
+			t.Fatalf("expected error")
+		}
+
+		// Drain with a deadline
+		req, err = http.NewRequest("POST", "/v1/node/"+node.ID+"/drain?enable=true&deadline=1h", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.NodeSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		out, err := s.Agent.server.State().NodeByID(node.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if !out.Drain || out.DrainStrategy == nil || out.DrainStrategy.Deadline != time.Hour {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
 func TestHTTP_NodeQuery(t *testing.T) {
 	httpTest(t, nil, func(s *TestServer) {
 		// Create the job
@@ -0,0 +1,255 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func (s *HTTPServer) OperatorBrokerRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.GenericRequest
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.BrokerStatsResponse
+	if err := s.agent.RPC("Operator.BrokerStats", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	return out.Stats, nil
+}
+
+func (s *HTTPServer) SnapshotRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		return s.snapshotSaveRequest(resp, req)
+	case "PUT", "POST":
+		return s.snapshotRestoreRequest(resp, req)
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+func (s *HTTPServer) snapshotSaveRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.SnapshotRequest{
+		Op: structs.SnapshotSave,
+	}
+	s.parseRegion(req, &args.Region)
+	s.parseToken(req, &args.AuthToken)
+	if _, ok := req.URL.Query()["stale"]; ok {
+		args.AllowStale = true
+	}
+
+	var reply structs.SnapshotResponse
+	snap, err := s.agent.SnapshotRPC(&args, nil, &reply)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+
+	// Stream the snapshot straight to the response
+	setMeta(resp, &reply.QueryMeta)
+	resp.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := io.Copy(resp, snap); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *HTTPServer) snapshotRestoreRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.SnapshotRequest{
+		Op: structs.SnapshotRestore,
+	}
+	s.parseRegion(req, &args.Region)
+	s.parseToken(req, &args.AuthToken)
+
+	// The request body is streamed to the servers
+	var reply structs.SnapshotResponse
+	snap, err := s.agent.SnapshotRPC(&args, req.Body, &reply)
+	if err != nil {
+		return nil, err
+	}
+	snap.Close()
+	return nil, nil
+}
+
+// OperatorAutopilotConfiguration is used to inspect and update the current
+// Autopilot configuration.
+func (s *HTTPServer) OperatorAutopilotConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		var args structs.GenericRequest
+		if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+			return nil, nil
+		}
+
+		var reply structs.AutopilotConfigResponse
+		if err := s.agent.RPC("Operator.AutopilotGetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		setMeta(resp, &reply.QueryMeta)
+		return reply.Config, nil
+
+	case "PUT", "POST":
+		var args structs.AutopilotSetConfigRequest
+		s.parseRegion(req, &args.Region)
+		s.parseToken(req, &args.AuthToken)
+
+		if err := decodeBody(req, &args.Config); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Error parsing autopilot config: %v", err))
+		}
+
+		// Check for cas value
+		if casStr := req.URL.Query().Get("cas"); casStr != "" {
+			casVal, err := strconv.ParseUint(casStr, 10, 64)
+			if err != nil {
+				return nil, CodedError(400, fmt.Sprintf("Error parsing cas value: %v", err))
+			}
+			args.Config.ModifyIndex = casVal
+			args.CAS = true
+		}
+
+		var reply structs.AutopilotSetConfigResponse
+		if err := s.agent.RPC("Operator.AutopilotSetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		setIndex(resp, reply.Index)
+		return reply.Updated, nil
+
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+// OperatorSchedulerConfiguration is used to inspect and update the current
+// scheduler configuration.
+func (s *HTTPServer) OperatorSchedulerConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		var args structs.GenericRequest
+		if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+			return nil, nil
+		}
+
+		var reply structs.SchedulerConfigResponse
+		if err := s.agent.RPC("Operator.SchedulerGetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		setMeta(resp, &reply.QueryMeta)
+		return reply.Config, nil
+
+	case "PUT", "POST":
+		var args structs.SchedulerSetConfigRequest
+		s.parseRegion(req, &args.Region)
+		s.parseToken(req, &args.AuthToken)
+
+		if err := decodeBody(req, &args.Config); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Error parsing scheduler config: %v", err))
+		}
+		if err := args.Config.Validate(); err != nil {
+			return nil, CodedError(400, err.Error())
+		}
+
+		// Check for cas value
+		if casStr := req.URL.Query().Get("cas"); casStr != "" {
+			casVal, err := strconv.ParseUint(casStr, 10, 64)
+			if err != nil {
+				return nil, CodedError(400, fmt.Sprintf("Error parsing cas value: %v", err))
+			}
+			args.Config.ModifyIndex = casVal
+			args.CAS = true
+		}
+
+		var reply structs.SchedulerSetConfigResponse
+		if err := s.agent.RPC("Operator.SchedulerSetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		setIndex(resp, reply.Index)
+		return reply.Updated, nil
+
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+// OperatorServerHealth is used to get the health of the servers in the
+// region. The status code is 429 if any server is unhealthy so the endpoint
+// can be used as a health check.
+func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.GenericRequest
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var reply structs.ServerHealthResponse
+	if err := s.agent.RPC("Operator.ServerHealth", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &reply.QueryMeta)
+	if !reply.Health.Healthy {
+		resp.Header().Set("Content-Type", "application/json")
+		resp.WriteHeader(http.StatusTooManyRequests)
+	}
+	return reply.Health, nil
+}
+
+// OperatorRaftConfiguration is used to inspect the current Raft peer set.
+func (s *HTTPServer) OperatorRaftConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.GenericRequest
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var reply structs.RaftConfigurationResponse
+	if err := s.agent.RPC("Operator.RaftGetConfiguration", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &reply.QueryMeta)
+	return reply.Servers, nil
+}
+
+// OperatorRaftPeer is used to remove a stale peer from the Raft peer set.
+func (s *HTTPServer) OperatorRaftPeer(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "DELETE" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.RaftPeerByAddressRequest
+	s.parseRegion(req, &args.Region)
+	s.parseToken(req, &args.AuthToken)
+
+	params := req.URL.Query()
+	if _, ok := params["address"]; !ok {
+		return nil, CodedError(400, "Must specify ?address with IP:port of peer to remove")
+	}
+	args.Address = params.Get("address")
+
+	var reply struct{}
+	if err := s.agent.RPC("Operator.RaftRemovePeerByAddress", &args, &reply); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
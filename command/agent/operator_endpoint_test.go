@@ -0,0 +1,209 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestHTTP_OperatorBroker(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/operator/broker", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.OperatorBrokerRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-KnownLeader") != "true" {
+			t.Fatalf("missing known leader")
+		}
+
+		// Check the stats
+		stats := obj.(*structs.BrokerStats)
+		if stats.ByScheduler == nil {
+			t.Fatalf("bad: %#v", stats)
+		}
+	})
+}
+
+func TestHTTP_OperatorAutopilotConfiguration(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Update the configuration
+		body := bytes.NewBufferString(`{"CleanupDeadServers": false, "MaxTrailingLogs": 100}`)
+		req, err := http.NewRequest("PUT", "/v1/operator/autopilot/configuration", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.OperatorAutopilotConfiguration(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if updated := obj.(bool); !updated {
+			t.Fatalf("config not updated")
+		}
+
+		// Read it back
+		req, err = http.NewRequest("GET", "/v1/operator/autopilot/configuration", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.OperatorAutopilotConfiguration(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		config := obj.(*structs.AutopilotConfig)
+		if config.CleanupDeadServers || config.MaxTrailingLogs != 100 {
+			t.Fatalf("bad: %#v", config)
+		}
+
+		// A check-and-set with a stale index is not applied
+		body = bytes.NewBufferString(`{"CleanupDeadServers": true}`)
+		url := fmt.Sprintf("/v1/operator/autopilot/configuration?cas=%d", config.ModifyIndex-1)
+		req, err = http.NewRequest("PUT", url, body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.OperatorAutopilotConfiguration(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if updated := obj.(bool); updated {
+			t.Fatalf("config should not be updated")
+		}
+	})
+}
+
+func TestHTTP_OperatorSchedulerConfiguration(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Invalid algorithms are rejected
+		body := bytes.NewBufferString(`{"SchedulerAlgorithm": "foo"}`)
+		req, err := http.NewRequest("PUT", "/v1/operator/scheduler/configuration", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		_, err = s.Server.OperatorSchedulerConfiguration(respW, req)
+		if codedErr, ok := err.(HTTPCodedError); !ok || codedErr.Code() != 400 {
+			t.Fatalf("expected 400 error, got: %v", err)
+		}
+
+		// Update the configuration
+		body = bytes.NewBufferString(`{"SchedulerAlgorithm": "spread"}`)
+		req, err = http.NewRequest("PUT", "/v1/operator/scheduler/configuration", body)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.OperatorSchedulerConfiguration(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if updated := obj.(bool); !updated {
+			t.Fatalf("config not updated")
+		}
+
+		// Read it back
+		req, err = http.NewRequest("GET", "/v1/operator/scheduler/configuration", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.OperatorSchedulerConfiguration(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		config := obj.(*structs.SchedulerConfig)
+		if config.SchedulerAlgorithm != structs.SchedulerAlgorithmSpread {
+			t.Fatalf("bad: %#v", config)
+		}
+	})
+}
+
+func TestHTTP_OperatorServerHealth(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		testutil.WaitForResult(func() (bool, error) {
+			req, err := http.NewRequest("GET", "/v1/operator/autopilot/health", nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+			obj, err := s.Server.OperatorServerHealth(respW, req)
+			if err != nil {
+				return false, err
+			}
+
+			health := obj.(*structs.ClusterHealth)
+			if respW.Code != 200 || !health.Healthy || len(health.Servers) != 1 {
+				return false, fmt.Errorf("bad: %d %#v", respW.Code, health)
+			}
+			return true, nil
+		}, func(err error) {
+			t.Fatalf("err: %v", err)
+		})
+	})
+}
+
+func TestHTTP_OperatorRaftConfiguration(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/operator/raft/configuration", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.OperatorRaftConfiguration(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.Code != 200 {
+			t.Fatalf("bad code: %d", respW.Code)
+		}
+
+		servers := obj.([]*structs.RaftServer)
+		if len(servers) != 1 || !servers[0].Leader || !servers[0].Voter {
+			t.Fatalf("bad: %#v", servers)
+		}
+	})
+}
+
+func TestHTTP_OperatorRaftPeer(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// The address is required
+		req, err := http.NewRequest("DELETE", "/v1/operator/raft/peer", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		_, err = s.Server.OperatorRaftPeer(respW, req)
+		if err == nil || !strings.Contains(err.Error(), "Must specify ?address") {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Unknown peers can not be removed
+		req, err = http.NewRequest("DELETE", "/v1/operator/raft/peer?address=nope", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		_, err = s.Server.OperatorRaftPeer(respW, req)
+		if err == nil || !strings.Contains(err.Error(), "not found in the Raft configuration") {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestHTTP_QuotaList(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		q1 := mock.QuotaSpec()
+		q2 := mock.QuotaSpec()
+		if err := state.UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q1, q2}); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/quotas", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.QuotasRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the quotas
+		out := obj.([]*structs.QuotaSpec)
+		if len(out) != 2 {
+			t.Fatalf("bad: %#v", out)
+		}
+	})
+}
+
+func TestHTTP_QuotaCRUD(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		q := mock.QuotaSpec()
+
+		// Create the quota
+		req, err := http.NewRequest("PUT", "/v1/quota/"+q.Name, encodeReq(q))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		if _, err := s.Server.QuotaSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Query the quota
+		req, err = http.NewRequest("GET", "/v1/quota/"+q.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err := s.Server.QuotaSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		out := obj.(*structs.QuotaSpec)
+		if !reflect.DeepEqual(out.Limit, q.Limit) {
+			t.Fatalf("bad: %#v", out)
+		}
+
+		// Query the usage of the quota
+		req, err = http.NewRequest("GET", "/v1/quota/usage/"+q.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.QuotaSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		usage := obj.(*structs.QuotaUsage)
+		if usage.Name != q.Name || usage.Used == nil || usage.Used.Count != 0 {
+			t.Fatalf("bad: %#v", usage)
+		}
+
+		// Delete the quota
+		req, err = http.NewRequest("DELETE", "/v1/quota/"+q.Name, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		if _, err := s.Server.QuotaSpecificRequest(respW, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		state := s.Agent.server.State()
+		existing, err := state.QuotaSpecByName(q.Name)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if existing != nil {
+			t.Fatalf("quota not deleted: %#v", existing)
+		}
+	})
+}
@@ -1,12 +1,26 @@
 package agent
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
 
 func (s *HTTPServer) ClientStatsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	if s.agent.client == nil {
 		return nil, clientNotRunning
 	}
 
+	var secretID string
+	s.parseToken(req, &secretID)
+	aclObj, err := s.agent.resolveToken(secretID)
+	if err != nil {
+		return nil, err
+	}
+	if aclObj != nil && !aclObj.AllowNodeRead() {
+		return nil, structs.ErrPermissionDenied
+	}
+
 	clientStats := s.agent.client.StatsReporter()
 	return clientStats.LatestHostStats(), nil
 }
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 func TestClientStatsRequest(t *testing.T) {
@@ -20,3 +22,25 @@ func TestClientStatsRequest(t *testing.T) {
 		}
 	})
 }
+
+func TestClientStatsRequest_ACL(t *testing.T) {
+	httpTest(t, func(c *Config) { c.ACL.Enabled = true }, func(s *TestServer) {
+		req, err := http.NewRequest("GET", "/v1/client/stats", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Anonymous requests are denied
+		_, err = s.Server.ClientStatsRequest(httptest.NewRecorder(), req)
+		if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+			t.Fatalf("expected permission denied, got %v", err)
+		}
+
+		// Tokens with node:read are allowed
+		token := createTestToken(t, s, 1000, `node { policy = "read" }`)
+		setToken(req, token)
+		if _, err := s.Server.ClientStatsRequest(httptest.NewRecorder(), req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
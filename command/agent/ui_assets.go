@@ -0,0 +1,319 @@
+package agent
+
+// uiIndex is the single page of the web UI. It renders the jobs, allocations,
+// evaluations, clients and servers of the cluster by querying the HTTP API from
+// the browser, and routes between its views with the fragment of the URL so
+// that the agent only has to serve this page.
+const uiIndex = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Nomad</title>
+<style>
+  body { margin: 0; font-family: -apple-system, "Helvetica Neue", Arial, sans-serif; font-size: 14px; color: #222; background: #f7f7f9; }
+  nav { background: #25ba81; padding: 0 24px; display: flex; align-items: center; }
+  nav a { color: #fff; text-decoration: none; padding: 14px 12px; display: inline-block; }
+  nav a.active { background: rgba(0, 0, 0, 0.15); }
+  nav .brand { font-weight: bold; font-size: 16px; margin-right: 16px; }
+  main { padding: 16px 24px; }
+  h1 { font-size: 20px; font-weight: normal; }
+  h2 { font-size: 16px; margin-top: 24px; }
+  table { border-collapse: collapse; width: 100%; background: #fff; }
+  th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #e4e4e8; }
+  th { background: #eeeef2; font-weight: 600; }
+  dl { display: grid; grid-template-columns: max-content auto; gap: 4px 16px; background: #fff; padding: 12px; }
+  dt { font-weight: 600; }
+  dd { margin: 0; }
+  a { color: #1563ff; }
+  .error { color: #c73445; }
+  .muted { color: #777; }
+  .status-running, .status-ready, .status-alive, .status-complete, .status-successful { color: #25ba81; }
+  .status-failed, .status-lost, .status-down, .status-dead { color: #c73445; }
+  .status-pending, .status-blocked, .status-initializing { color: #d08f00; }
+  input[type=text] { width: 420px; padding: 6px; }
+  code { font-size: 12px; }
+</style>
+</head>
+<body>
+<nav>
+  <a class="brand" href="#/jobs">Nomad</a>
+  <a href="#/jobs" data-view="jobs">Jobs</a>
+  <a href="#/allocations" data-view="allocations">Allocations</a>
+  <a href="#/evaluations" data-view="evaluations">Evaluations</a>
+  <a href="#/clients" data-view="clients">Clients</a>
+  <a href="#/servers" data-view="servers">Servers</a>
+  <a href="#/settings" data-view="settings">Settings</a>
+</nav>
+<main id="content"></main>
+<script>
+(function() {
+  "use strict";
+
+  var content = document.getElementById("content");
+  var refreshTimer = null;
+
+  function esc(v) {
+    if (v === null || v === undefined) {
+      return "";
+    }
+    return String(v).replace(/[&<>"']/g, function(c) {
+      return {"&": "&amp;", "<": "&lt;", ">": "&gt;", "\"": "&quot;", "'": "&#39;"}[c];
+    });
+  }
+
+  function short(id) {
+    return esc((id || "").substring(0, 8));
+  }
+
+  function status(s) {
+    return "<span class=\"status-" + esc(s) + "\">" + esc(s) + "</span>";
+  }
+
+  function link(view, id, text) {
+    return "<a href=\"#/" + view + "/" + encodeURIComponent(id) + "\">" + (text || esc(id)) + "</a>";
+  }
+
+  function time(nanos) {
+    if (!nanos) {
+      return "";
+    }
+    return esc(new Date(nanos / 1e6).toLocaleString());
+  }
+
+  // get queries the HTTP API with the ACL token of the settings
+  function get(path) {
+    var headers = {};
+    var token = window.localStorage.getItem("nomad.token");
+    if (token) {
+      headers["X-Nomad-Token"] = token;
+    }
+    return fetch(path, {headers: headers, credentials: "same-origin"}).then(function(resp) {
+      if (!resp.ok) {
+        return resp.text().then(function(body) {
+          throw new Error(resp.status + ": " + body);
+        });
+      }
+      return resp.json();
+    });
+  }
+
+  function table(headers, rows) {
+    if (!rows || rows.length === 0) {
+      return "<p class=\"muted\">None</p>";
+    }
+    var out = "<table><tr>";
+    headers.forEach(function(h) {
+      out += "<th>" + esc(h) + "</th>";
+    });
+    out += "</tr>";
+    rows.forEach(function(row) {
+      out += "<tr>";
+      row.forEach(function(cell) {
+        out += "<td>" + cell + "</td>";
+      });
+      out += "</tr>";
+    });
+    return out + "</table>";
+  }
+
+  function details(pairs) {
+    var out = "<dl>";
+    pairs.forEach(function(p) {
+      out += "<dt>" + esc(p[0]) + "</dt><dd>" + p[1] + "</dd>";
+    });
+    return out + "</dl>";
+  }
+
+  function allocRows(allocs) {
+    return (allocs || []).map(function(a) {
+      return [link("allocations", a.ID, short(a.ID)), link("jobs", a.JobID), esc(a.TaskGroup),
+        link("clients", a.NodeID, short(a.NodeID)), esc(a.DesiredStatus), status(a.ClientStatus), time(a.CreateTime)];
+    });
+  }
+  var allocHeaders = ["ID", "Job", "Task Group", "Client", "Desired", "Status", "Created"];
+
+  function evalRows(evals) {
+    return (evals || []).map(function(e) {
+      return [short(e.ID), link("jobs", e.JobID), esc(e.Type), esc(e.TriggeredBy), esc(e.Priority), status(e.Status)];
+    });
+  }
+  var evalHeaders = ["ID", "Job", "Type", "Triggered By", "Priority", "Status"];
+
+  function summary(s) {
+    var totals = {Queued: 0, Starting: 0, Running: 0, Failed: 0, Complete: 0, Lost: 0};
+    if (s && s.Summary) {
+      Object.keys(s.Summary).forEach(function(tg) {
+        Object.keys(totals).forEach(function(k) {
+          totals[k] += s.Summary[tg][k] || 0;
+        });
+      });
+    }
+    return Object.keys(totals).filter(function(k) {
+      return totals[k] > 0;
+    }).map(function(k) {
+      return esc(k) + " " + totals[k];
+    }).join(", ");
+  }
+
+  var views = {
+    jobs: function() {
+      return get("/v1/jobs").then(function(jobs) {
+        return "<h1>Jobs</h1>" + table(["ID", "Type", "Priority", "Status", "Allocations"],
+          jobs.map(function(j) {
+            return [link("jobs", j.ID), esc(j.Type), esc(j.Priority), status(j.Status), summary(j.JobSummary)];
+          }));
+      });
+    },
+
+    job: function(id) {
+      var base = "/v1/job/" + encodeURIComponent(id);
+      return Promise.all([get(base), get(base + "/allocations"), get(base + "/evaluations")]).then(function(r) {
+        var job = r[0];
+        return "<h1>Job " + esc(job.ID) + "</h1>" + details([
+          ["Name", esc(job.Name)],
+          ["Namespace", esc(job.Namespace)],
+          ["Type", esc(job.Type)],
+          ["Priority", esc(job.Priority)],
+          ["Datacenters", esc((job.Datacenters || []).join(", "))],
+          ["Status", status(job.Status)],
+          ["Version", esc(job.Version)]
+        ]) + "<h2>Task Groups</h2>" + table(["Name", "Count", "Tasks"],
+          (job.TaskGroups || []).map(function(tg) {
+            return [esc(tg.Name), esc(tg.Count), esc((tg.Tasks || []).map(function(t) {
+              return t.Name;
+            }).join(", "))];
+          })) +
+          "<h2>Allocations</h2>" + table(allocHeaders, allocRows(r[1])) +
+          "<h2>Evaluations</h2>" + table(evalHeaders, evalRows(r[2]));
+      });
+    },
+
+    allocations: function() {
+      return get("/v1/allocations").then(function(allocs) {
+        return "<h1>Allocations</h1>" + table(allocHeaders, allocRows(allocs));
+      });
+    },
+
+    allocation: function(id) {
+      return get("/v1/allocation/" + encodeURIComponent(id)).then(function(a) {
+        var states = a.TaskStates || {};
+        return "<h1>Allocation " + esc(a.ID) + "</h1>" + details([
+          ["Name", esc(a.Name)],
+          ["Job", link("jobs", a.JobID)],
+          ["Task Group", esc(a.TaskGroup)],
+          ["Client", link("clients", a.NodeID)],
+          ["Evaluation", esc(a.EvalID)],
+          ["Desired Status", esc(a.DesiredStatus) + " <span class=\"muted\">" + esc(a.DesiredDescription) + "</span>"],
+          ["Client Status", status(a.ClientStatus) + " <span class=\"muted\">" + esc(a.ClientDescription) + "</span>"],
+          ["Created", time(a.CreateTime)]
+        ]) + "<h2>Tasks</h2>" + table(["Name", "State", "Failed", "Restarts", "Last Event"],
+          Object.keys(states).sort().map(function(name) {
+            var s = states[name];
+            var events = s.Events || [];
+            var last = events.length ? events[events.length - 1] : null;
+            return [esc(name), status(s.State), esc(s.Failed), esc(s.Restarts),
+              last ? esc(last.Type) + " <span class=\"muted\">" + time(last.Time) + "</span>" : ""];
+          }));
+      });
+    },
+
+    evaluations: function() {
+      return get("/v1/evaluations").then(function(evals) {
+        return "<h1>Evaluations</h1>" + table(evalHeaders, evalRows(evals));
+      });
+    },
+
+    clients: function() {
+      return get("/v1/nodes").then(function(nodes) {
+        return "<h1>Clients</h1>" + table(["ID", "Name", "Datacenter", "Class", "Drain", "Status"],
+          nodes.map(function(n) {
+            return [link("clients", n.ID, short(n.ID)), esc(n.Name), esc(n.Datacenter), esc(n.NodeClass),
+              esc(n.Drain), status(n.Status)];
+          }));
+      });
+    },
+
+    client: function(id) {
+      var base = "/v1/node/" + encodeURIComponent(id);
+      return Promise.all([get(base), get(base + "/allocations")]).then(function(r) {
+        var n = r[0];
+        var attrs = n.Attributes || {};
+        return "<h1>Client " + esc(n.Name) + "</h1>" + details([
+          ["ID", esc(n.ID)],
+          ["Datacenter", esc(n.Datacenter)],
+          ["Class", esc(n.NodeClass)],
+          ["Address", esc(n.HTTPAddr)],
+          ["Drain", esc(n.Drain)],
+          ["Status", status(n.Status)],
+          ["Drivers", esc(Object.keys(attrs).filter(function(k) {
+            return /^driver\.[^.]+$/.test(k);
+          }).map(function(k) {
+            return k.substring(7);
+          }).sort().join(", "))]
+        ]) + "<h2>Allocations</h2>" + table(allocHeaders, allocRows(r[1]));
+      });
+    },
+
+    servers: function() {
+      return get("/v1/agent/members").then(function(m) {
+        return "<h1>Servers</h1>" + table(["Name", "Address", "Port", "Status", "Region", "Datacenter", "Build"],
+          (m.Members || []).map(function(s) {
+            var tags = s.Tags || {};
+            return [esc(s.Name), esc(s.Addr), esc(s.Port), status(s.Status), esc(tags.region), esc(tags.dc), esc(tags.build)];
+          }));
+      });
+    },
+
+    settings: function() {
+      var token = window.localStorage.getItem("nomad.token") || "";
+      return Promise.resolve("<h1>Settings</h1>" +
+        "<p>The ACL token is stored in the browser and sent with every request to the HTTP API.</p>" +
+        "<form id=\"token-form\"><label>Secret ID <input type=\"text\" id=\"token\" value=\"" + esc(token) + "\"></label> " +
+        "<button type=\"submit\">Save</button></form>");
+    }
+  };
+
+  // routes maps the fragment of the URL to a view and its argument
+  function route() {
+    var parts = window.location.hash.replace(/^#\/?/, "").split("/");
+    var section = parts[0] || "jobs";
+    var id = parts.length > 1 ? decodeURIComponent(parts.slice(1).join("/")) : "";
+    var singular = {jobs: "job", allocations: "allocation", clients: "client"};
+    if (id && singular[section]) {
+      return {name: section, view: views[singular[section]], arg: id};
+    }
+    return {name: section, view: views[section] || views.jobs, arg: null};
+  }
+
+  function render() {
+    var r = route();
+    Array.prototype.forEach.call(document.querySelectorAll("nav a[data-view]"), function(a) {
+      a.className = a.getAttribute("data-view") === r.name ? "active" : "";
+    });
+
+    clearTimeout(refreshTimer);
+    r.view(r.arg).then(function(html) {
+      content.innerHTML = html;
+      var form = document.getElementById("token-form");
+      if (form) {
+        form.onsubmit = function(e) {
+          e.preventDefault();
+          window.localStorage.setItem("nomad.token", document.getElementById("token").value.trim());
+          window.location.hash = "#/jobs";
+        };
+        return;
+      }
+      refreshTimer = setTimeout(render, 5000);
+    }).catch(function(err) {
+      content.innerHTML = "<p class=\"error\">" + esc(err.message) + "</p>";
+    });
+  }
+
+  window.addEventListener("hashchange", render);
+  render();
+})();
+</script>
+</body>
+</html>
+`
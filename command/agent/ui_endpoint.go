@@ -0,0 +1,32 @@
+package agent
+
+import (
+	"net/http"
+)
+
+// UIRequest serves the page of the web UI. The views of the UI are selected by
+// the fragment of the URL, so only the /ui/ path itself exists.
+func (s *HTTPServer) UIRequest(resp http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/ui/" {
+		http.NotFound(resp, req)
+		return
+	}
+	if req.Method != "GET" && req.Method != "HEAD" {
+		resp.WriteHeader(405)
+		resp.Write([]byte(ErrInvalidMethod))
+		return
+	}
+
+	resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+	resp.Header().Set("X-Frame-Options", "DENY")
+	resp.Write([]byte(uiIndex))
+}
+
+// UIRedirect redirects the root of the HTTP server to the web UI
+func (s *HTTPServer) UIRedirect(resp http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(resp, req)
+		return
+	}
+	http.Redirect(resp, req, "/ui/", http.StatusTemporaryRedirect)
+}
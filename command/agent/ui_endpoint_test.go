@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTP_UI(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		// The page of the UI is served through the mux of the agent
+		req, err := http.NewRequest("GET", "/ui/", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+		s.Server.mux.ServeHTTP(respW, req)
+
+		if respW.Code != 200 {
+			t.Fatalf("bad: %d", respW.Code)
+		}
+		if ct := respW.HeaderMap.Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+			t.Fatalf("bad: %q", ct)
+		}
+		if !strings.Contains(respW.Body.String(), "/v1/jobs") {
+			t.Fatalf("bad: %s", respW.Body.String())
+		}
+
+		// Other paths under the UI don't exist
+		req, err = http.NewRequest("GET", "/ui/foo", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW = httptest.NewRecorder()
+		s.Server.mux.ServeHTTP(respW, req)
+		if respW.Code != 404 {
+			t.Fatalf("bad: %d", respW.Code)
+		}
+	})
+}
+
+func TestHTTP_UIRedirect(t *testing.T) {
+	httpTest(t, nil, func(s *TestServer) {
+		for path, code := range map[string]int{"/": 307, "/ui": 301, "/foo": 404} {
+			req, err := http.NewRequest("GET", path, nil)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			respW := httptest.NewRecorder()
+			s.Server.mux.ServeHTTP(respW, req)
+
+			if respW.Code != code {
+				t.Fatalf("bad: %s: %d", path, respW.Code)
+			}
+			if code != 404 && !strings.HasSuffix(respW.HeaderMap.Get("Location"), "/ui/") {
+				t.Fatalf("bad: %s: %q", path, respW.HeaderMap.Get("Location"))
+			}
+		}
+	})
+}
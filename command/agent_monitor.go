@@ -0,0 +1,92 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+type AgentMonitorCommand struct {
+	Meta
+}
+
+func (c *AgentMonitorCommand) Help() string {
+	helpText := `
+Usage: nomad monitor [options]
+
+  Stream the logs of a running Nomad agent, starting with its recent logs.
+  The logs of the agent targeted by the -address option are streamed until
+  the command is interrupted, which allows remotely debugging a client or a
+  server.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Monitor Options:
+
+  -log-level <level>
+    The minimum level of the streamed logs. One of "trace", "debug", "info",
+    "warn" and "err". Defaults to "info".
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AgentMonitorCommand) Synopsis() string {
+	return "Stream the logs of a Nomad agent"
+}
+
+func (c *AgentMonitorCommand) Run(args []string) int {
+	var logLevel string
+
+	flags := c.Meta.FlagSet("monitor", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&logLevel, "log-level", "info", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) > 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	stopCh := make(chan struct{})
+	logCh, err := client.Agent().Monitor(logLevel, stopCh, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting monitor: %s", err))
+		return 1
+	}
+
+	// Stop streaming on interrupt
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	for {
+		select {
+		case line, ok := <-logCh:
+			if !ok {
+				c.Ui.Error("Remote side ended the monitor! This usually means that the\n" +
+					"remote side has exited or crashed.")
+				return 1
+			}
+			c.Ui.Output(line)
+		case <-signalCh:
+			close(stopCh)
+			return 0
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type AgentPprofCommand struct {
+	Meta
+}
+
+func (c *AgentPprofCommand) Help() string {
+	helpText := `
+Usage: nomad agent-pprof [options] <profile>
+
+  Capture a runtime profile of a running Nomad agent, which can be inspected
+  with "go tool pprof", or "go tool trace" for an execution trace. The profile
+  is one of "profile" for a CPU profile, "trace", "heap", "goroutine",
+  "block", "mutex" and "threadcreate".
+
+  The agent must run with enable_debug, and an agent:write token is required
+  if ACLs are enabled.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Pprof Options:
+
+  -node-id <id>
+    Profile the client with the given node ID instead of the agent targeted
+    by the -address option.
+
+  -seconds <n>
+    The duration of a CPU profile or of a trace, in seconds. Defaults to 30.
+
+  -debug <n>
+    Write the profile in a text format instead of the binary format of pprof.
+    A value of 2 writes the stack traces of the goroutines in the format of a
+    panic. Ignored for CPU profiles and traces.
+
+  -output <path>
+    The file the profile is written to, or "-" to write it to stdout.
+    Defaults to "<profile>.prof".
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AgentPprofCommand) Synopsis() string {
+	return "Capture a runtime profile of an agent"
+}
+
+func (c *AgentPprofCommand) Run(args []string) int {
+	var nodeID, output string
+	var seconds, debug int
+
+	flags := c.Meta.FlagSet("agent-pprof", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&nodeID, "node-id", "", "")
+	flags.IntVar(&seconds, "seconds", 30, "")
+	flags.IntVar(&debug, "debug", 0, "")
+	flags.StringVar(&output, "output", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one profile
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	profile := args[0]
+	if output == "" {
+		output = profile + ".prof"
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if nodeID != "" {
+		node, _, err := client.Nodes().Info(nodeID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying node: %s", err))
+			return 1
+		}
+		if node.HTTPAddr == "" {
+			c.Ui.Error(fmt.Sprintf("Http addr of node %s is not advertised", nodeID))
+			return 1
+		}
+		client, err = api.NewClient(c.Meta.clientConfig().CopyConfig(node.HTTPAddr, node.TLSEnabled))
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+			return 1
+		}
+	}
+
+	// The duration only applies to CPU profiles and traces
+	q := &api.QueryOptions{Params: make(map[string]string)}
+	switch profile {
+	case "profile", "trace":
+		q.Params["seconds"] = strconv.Itoa(seconds)
+	default:
+		if debug > 0 {
+			q.Params["debug"] = strconv.Itoa(debug)
+		}
+	}
+	out, err := client.Agent().Profile(profile, q)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error capturing profile: %s", err))
+		return 1
+	}
+
+	if output == "-" {
+		os.Stdout.Write(out)
+		return 0
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error creating profile file: %s", err))
+		return 1
+	}
+	if _, err := f.Write(out); err != nil {
+		f.Close()
+		c.Ui.Error(fmt.Sprintf("Error writing profile file: %s", err))
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error writing profile file: %s", err))
+		return 1
+	}
+	c.Ui.Output(fmt.Sprintf("Wrote %s profile to %q", profile, output))
+	return 0
+}
@@ -0,0 +1,81 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/mitchellh/cli"
+)
+
+func TestAgentPprofCommand_Implements(t *testing.T) {
+	var _ cli.Command = &AgentPprofCommand{}
+}
+
+func TestAgentPprofCommand_Fails(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &AgentPprofCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "heap"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error capturing profile") {
+		t.Fatalf("expected failed profile error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails if the agent doesn't run with enable_debug
+	if code := cmd.Run([]string{"-address=" + url, "-output=-", "heap"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "enable_debug") {
+		t.Fatalf("expected enable_debug error, got: %s", out)
+	}
+}
+
+func TestAgentPprofCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, func(c *testutil.TestServerConfig) {
+		c.EnableDebug = true
+	})
+	defer srv.Stop()
+
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "goroutine.txt")
+
+	ui := new(cli.MockUi)
+	cmd := &AgentPprofCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, "-debug=1", "-output=" + file, "goroutine"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Wrote goroutine profile") {
+		t.Fatalf("bad: %q", out)
+	}
+
+	out, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(string(out), "goroutine profile:") {
+		t.Fatalf("bad: %s", out)
+	}
+}
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+type AllocCommand struct {
+	Meta
+}
+
+func (f *AllocCommand) Help() string {
+	helpText := `
+Usage: nomad alloc <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with individual
+  allocations without modifying their job. Use the alloc-status command to
+  display the status of an allocation.
+
+Subcommands:
+
+  exec       Run a command in a running task of an allocation
+  restart    Restart the tasks of an allocation in place
+  signal     Send a signal to the tasks of an allocation
+  stop       Stop an allocation and have it rescheduled
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *AllocCommand) Synopsis() string {
+	return "Interact with allocations"
+}
+
+func (f *AllocCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// lookupAlloc returns the allocation matching the ID or prefix. It returns an
+// error listing the matched allocations if the prefix isn't unique.
+func lookupAlloc(client *api.Client, allocID string, length int) (*api.Allocation, error) {
+	if len(allocID) == 1 {
+		return nil, fmt.Errorf("Identifier must contain at least two characters.")
+	}
+	if len(allocID)%2 == 1 {
+		// Identifiers must be of even length, so we strip off the last byte
+		// to provide a consistent user experience.
+		allocID = allocID[:len(allocID)-1]
+	}
+
+	allocs, _, err := client.Allocations().PrefixList(allocID)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying allocation: %v", err)
+	}
+	if len(allocs) == 0 {
+		return nil, fmt.Errorf("No allocation(s) with prefix or id %q found", allocID)
+	}
+	if len(allocs) > 1 {
+		out := make([]string, len(allocs)+1)
+		out[0] = "ID|Eval ID|Job ID|Task Group|Desired Status|Client Status"
+		for i, alloc := range allocs {
+			out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s|%s",
+				limit(alloc.ID, length),
+				limit(alloc.EvalID, length),
+				alloc.JobID,
+				alloc.TaskGroup,
+				alloc.DesiredStatus,
+				alloc.ClientStatus,
+			)
+		}
+		return nil, fmt.Errorf("Prefix matched multiple allocations\n\n%s", formatList(out))
+	}
+
+	alloc, _, err := client.Allocations().Info(allocs[0].ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error querying allocation: %s", err)
+	}
+	return alloc, nil
+}
+
+// validateAllocTask returns an error if the task isn't part of the task group
+// of the allocation.
+func validateAllocTask(alloc *api.Allocation, task string) error {
+	if task == "" {
+		return nil
+	}
+	if alloc.Job != nil {
+		for _, tg := range alloc.Job.TaskGroups {
+			if tg.Name != alloc.TaskGroup {
+				continue
+			}
+			for _, t := range tg.Tasks {
+				if t.Name == task {
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("Task %q not found in allocation %q", task, alloc.ID)
+}
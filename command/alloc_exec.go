@@ -0,0 +1,143 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type AllocExecCommand struct {
+	Meta
+}
+
+func (c *AllocExecCommand) Help() string {
+	helpText := `
+Usage: nomad alloc exec [options] <allocation> <command> [<args>...]
+
+  Run a command in the context of a running task of an allocation, such as
+  a shell to debug the task. The command runs in the isolation context of the
+  task, for example inside its container when using the Docker driver, and
+  its exit code is returned. The driver of the task must support exec.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Exec Options:
+
+  -task <task>
+    The task to run the command in. Required if the task group of the
+    allocation has more than one task.
+
+  -i
+    Pass the standard input to the command. Defaults to true.
+
+  -t
+    Allocate a pseudo terminal for the command. Defaults to true if the
+    standard input is a terminal.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocExecCommand) Synopsis() string {
+	return "Run a command in a running task of an allocation"
+}
+
+func (c *AllocExecCommand) Run(args []string) int {
+	var verbose, stdinOpt, ttyOpt bool
+	var task string
+
+	flags := c.Meta.FlagSet("alloc exec", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&task, "task", "", "")
+	flags.BoolVar(&stdinOpt, "i", true, "")
+	flags.BoolVar(&ttyOpt, "t", isTerminal(os.Stdin), "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got an allocation and a command
+	args = flags.Args()
+	if len(args) < 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	alloc, err := lookupAlloc(client, args[0], length)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if task == "" {
+		if task, err = allocSingleTask(alloc); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	} else if err := validateAllocTask(alloc, task); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	var stdin io.Reader = os.Stdin
+	if !stdinOpt {
+		stdin = strings.NewReader("")
+	}
+
+	// Put the local terminal in raw mode so that the remote terminal handles
+	// every key, and forward its size
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	var sizeCh <-chan api.TerminalSize
+	restore := func() {}
+	if ttyOpt && stdinOpt && isTerminal(os.Stdin) {
+		if restore, err = makeRawTerminal(os.Stdin); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error configuring terminal: %s", err))
+			return 1
+		}
+		sizeCh = watchTerminalSize(os.Stdin, doneCh)
+	}
+
+	code, err := client.Allocations().Exec(context.Background(), alloc, task, ttyOpt,
+		args[1:], stdin, os.Stdout, os.Stderr, sizeCh, nil)
+	restore()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error executing command in task %q: %s", task, err))
+		return 1
+	}
+	return code
+}
+
+// allocSingleTask returns the task of the allocation if its task group has
+// only one.
+func allocSingleTask(alloc *api.Allocation) (string, error) {
+	if alloc.Job != nil {
+		for _, tg := range alloc.Job.TaskGroups {
+			if tg.Name == alloc.TaskGroup && len(tg.Tasks) == 1 {
+				return tg.Tasks[0].Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("Allocation %q has multiple tasks, specify one with -task", alloc.ID)
+}
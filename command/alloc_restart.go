@@ -0,0 +1,92 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AllocRestartCommand struct {
+	Meta
+}
+
+func (c *AllocRestartCommand) Help() string {
+	helpText := `
+Usage: nomad alloc restart [options] <allocation> [<task>]
+
+  Restart the running tasks of an allocation in place on its node. If a task
+  is given, only that task is restarted. The restart does not count against
+  the restart policy of the task group.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Restart Options:
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocRestartCommand) Synopsis() string {
+	return "Restart the tasks of an allocation"
+}
+
+func (c *AllocRestartCommand) Run(args []string) int {
+	var verbose bool
+
+	flags := c.Meta.FlagSet("alloc restart", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got an allocation and optionally a task
+	args = flags.Args()
+	if len(args) < 1 || len(args) > 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	var task string
+	if len(args) == 2 {
+		task = args[1]
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	alloc, err := lookupAlloc(client, args[0], length)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if err := validateAllocTask(alloc, task); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if err := client.Allocations().Restart(alloc, task, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error restarting allocation: %s", err))
+		return 1
+	}
+
+	if task != "" {
+		c.Ui.Output(fmt.Sprintf("Restarted task %q of allocation %q", task, limit(alloc.ID, length)))
+	} else {
+		c.Ui.Output(fmt.Sprintf("Restarted the tasks of allocation %q", limit(alloc.ID, length)))
+	}
+	return 0
+}
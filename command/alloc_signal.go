@@ -0,0 +1,97 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AllocSignalCommand struct {
+	Meta
+}
+
+func (c *AllocSignalCommand) Help() string {
+	helpText := `
+Usage: nomad alloc signal [options] -s <signal> <allocation> [<task>]
+
+  Send a signal to the running tasks of an allocation, for example SIGHUP to
+  have them reload their configuration. If a task is given, only that task is
+  signaled. The driver of the tasks must support signals.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Signal Options:
+
+  -s
+    The signal to send, such as SIGHUP or SIGUSR1. Required.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocSignalCommand) Synopsis() string {
+	return "Send a signal to the tasks of an allocation"
+}
+
+func (c *AllocSignalCommand) Run(args []string) int {
+	var verbose bool
+	var signal string
+
+	flags := c.Meta.FlagSet("alloc signal", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&signal, "s", "", "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got a signal, an allocation and optionally a task
+	args = flags.Args()
+	if signal == "" || len(args) < 1 || len(args) > 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	var task string
+	if len(args) == 2 {
+		task = args[1]
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	alloc, err := lookupAlloc(client, args[0], length)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	if err := validateAllocTask(alloc, task); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if err := client.Allocations().Signal(alloc, task, signal, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error signaling allocation: %s", err))
+		return 1
+	}
+
+	if task != "" {
+		c.Ui.Output(fmt.Sprintf("Sent %s to task %q of allocation %q", signal, task, limit(alloc.ID, length)))
+	} else {
+		c.Ui.Output(fmt.Sprintf("Sent %s to the tasks of allocation %q", signal, limit(alloc.ID, length)))
+	}
+	return 0
+}
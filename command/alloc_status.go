@@ -211,6 +211,8 @@ func (c *AllocStatusCommand) Run(args []string) int {
 		fmt.Sprintf("Name|%s", alloc.Name),
 		fmt.Sprintf("Node ID|%s", limit(alloc.NodeID, length)),
 		fmt.Sprintf("Job ID|%s", alloc.JobID),
+		fmt.Sprintf("Desired Status|%s", alloc.DesiredStatus),
+		fmt.Sprintf("Desired Description|%s", alloc.DesiredDescription),
 		fmt.Sprintf("Client Status|%s", alloc.ClientStatus),
 		fmt.Sprintf("Client Description|%s", alloc.ClientDescription),
 		fmt.Sprintf("Created At|%s", formatUnixNanoTime(alloc.CreateTime)),
@@ -330,6 +332,10 @@ func (c *AllocStatusCommand) outputTaskStatus(state *api.TaskState) {
 				parts = append(parts, fmt.Sprintf("Signal: %d", event.Signal))
 			}
 
+			if event.OOMKilled {
+				parts = append(parts, "OOM Killed")
+			}
+
 			if event.Message != "" {
 				parts = append(parts, fmt.Sprintf("Exit Message: %q", event.Message))
 			}
@@ -378,6 +384,10 @@ func (c *AllocStatusCommand) outputTaskStatus(state *api.TaskState) {
 			} else {
 				desc = "Task signaled to restart"
 			}
+		case api.TaskDriverMessage:
+			desc = event.DriverMessage
+		case api.TaskMainDead:
+			desc = "Main tasks in the group died"
 		}
 
 		// Reverse order so we are sorted by time
@@ -127,6 +127,9 @@ func TestAllocStatusCommand_Run(t *testing.T) {
 	if !strings.Contains(out, "Created At") {
 		t.Fatalf("expected to have 'Created At' but saw: %s", out)
 	}
+	if !strings.Contains(out, "Desired Status") {
+		t.Fatalf("expected to have 'Desired Status' but saw: %s", out)
+	}
 	ui.OutputWriter.Reset()
 
 	if code := cmd.Run([]string{"-address=" + url, "-verbose", allocId1}); code != 0 {
@@ -139,5 +142,22 @@ func TestAllocStatusCommand_Run(t *testing.T) {
 	if !strings.Contains(out, "Created At") {
 		t.Fatalf("expected to have 'Created At' but saw: %s", out)
 	}
+
+	// The placement metrics are only output once
+	if n := strings.Count(out, "Placement Metrics"); n != 1 {
+		t.Fatalf("expected 'Placement Metrics' once but saw it %d times: %s", n, out)
+	}
+	alloc, _, err := client.Allocations().Info(allocId1, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, line := range strings.Split(formatAllocMetrics(alloc.Metrics, true, "  "), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if n := strings.Count(out, line); n != 1 {
+			t.Fatalf("expected %q once but saw it %d times: %s", line, n, out)
+		}
+	}
 	ui.OutputWriter.Reset()
 }
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AllocStopCommand struct {
+	Meta
+}
+
+func (c *AllocStopCommand) Help() string {
+	helpText := `
+Usage: nomad alloc stop [options] <allocation>
+
+  Stop an allocation without modifying its job. The scheduler places a
+  replacement allocation, possibly on another node, according to the job.
+
+  Upon success, an interactive monitor session will start to display log
+  lines as the job is re-evaluated. It is safe to exit the monitor early
+  using ctrl+c.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Stop Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. After the
+    allocation is stopped, the evaluation ID is printed to the screen,
+    which can be used to examine the evaluation using the eval-status
+    command.
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocStopCommand) Synopsis() string {
+	return "Stop and reschedule an allocation"
+}
+
+func (c *AllocStopCommand) Run(args []string) int {
+	var detach, verbose, quiet bool
+
+	flags := c.Meta.FlagSet("alloc stop", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one allocation
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	alloc, err := lookupAlloc(client, args[0], length)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Stop the allocation
+	resp, _, err := client.Allocations().Stop(alloc.ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error stopping allocation: %s", err))
+		return 1
+	}
+
+	if detach {
+		c.Ui.Output(resp.EvalID)
+		return 0
+	}
+
+	// Monitor the evaluation of the job
+	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	return mon.monitor(resp.EvalID, false)
+}
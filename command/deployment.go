@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type DeploymentCommand struct {
+	Meta
+}
+
+func (f *DeploymentCommand) Help() string {
+	helpText := `
+Usage: nomad deployment <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with deployments. A
+  deployment is created when a job using a rolling or canary update
+  strategy is modified and tracks the health of the allocations of the new
+  version of the job. The canaries of each task group must be promoted
+  before the remaining allocations are updated.
+
+Subcommands:
+
+  list       List all deployments
+  status     Display the status of a deployment
+  promote    Promote the canaries of a deployment
+  fail       Manually fail a deployment
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *DeploymentCommand) Synopsis() string {
+	return "Interact with deployments"
+}
+
+func (f *DeploymentCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
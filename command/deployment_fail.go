@@ -0,0 +1,128 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DeploymentFailCommand struct {
+	Meta
+}
+
+func (c *DeploymentFailCommand) Help() string {
+	helpText := `
+Usage: nomad deployment fail [options] <deployment-id>
+
+  Mark a deployment as failed. Failing a deployment stops the placement of
+  new allocations as part of the deployment. Canaries that were already
+  placed are left running.
+
+  Upon success, an interactive monitor session will start to display log
+  lines as the job is re-evaluated. It is safe to exit the monitor early
+  using ctrl+c.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Fail Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. After the
+    deployment is failed, the evaluation ID is printed to the screen,
+    which can be used to examine the evaluation using the eval-status
+    command.
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentFailCommand) Synopsis() string {
+	return "Manually fail a deployment"
+}
+
+func (c *DeploymentFailCommand) Run(args []string) int {
+	var detach, verbose, quiet bool
+
+	flags := c.Meta.FlagSet("deployment fail", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one deployment
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	deploymentID := args[0]
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Look up the deployment by prefix
+	if len(deploymentID) == 1 {
+		c.Ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
+		return 1
+	}
+	if len(deploymentID)%2 == 1 {
+		// Identifiers must be of even length, so we strip off the last byte
+		// to provide a consistent user experience.
+		deploymentID = deploymentID[:len(deploymentID)-1]
+	}
+
+	deploys, _, err := client.Deployments().PrefixList(deploymentID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying deployment: %s", err))
+		return 1
+	}
+	if len(deploys) == 0 {
+		c.Ui.Error(fmt.Sprintf("No deployment(s) with prefix or id %q found", deploymentID))
+		return 1
+	}
+	if len(deploys) > 1 {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple deployments\n\n%s",
+			formatDeployments(deploys, length)))
+		return 0
+	}
+	deploymentID = deploys[0].ID
+
+	// Fail the deployment
+	resp, _, err := client.Deployments().Fail(deploymentID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error failing deployment: %s", err))
+		return 1
+	}
+
+	if detach {
+		c.Ui.Output(resp.EvalID)
+		return 0
+	}
+
+	// Monitor the evaluation of the job
+	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	return mon.monitor(resp.EvalID, false)
+}
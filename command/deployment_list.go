@@ -0,0 +1,140 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type DeploymentListCommand struct {
+	Meta
+	listPager
+}
+
+func (c *DeploymentListCommand) Help() string {
+	helpText := `
+Usage: nomad deployment list [options]
+
+  List is used to list the deployments of the registered jobs, newest first.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Options:
+
+  -json
+    Output the deployments in their JSON format.
+
+  -t
+    Format and display the deployments using a Go template.
+
+  -verbose
+    Display full information.
+
+  ` + listOptionsUsage() + `
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentListCommand) Synopsis() string {
+	return "List all deployments"
+}
+
+func (c *DeploymentListCommand) Run(args []string) int {
+	var json, verbose bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("deployment list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+	c.addListFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	deploys, qm, err := client.Deployments().List(c.queryOptions())
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving deployments: %s", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(deploys)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		if hint := nextPageHint(qm); hint != "" {
+			c.Ui.Warn(hint)
+		}
+		return 0
+	}
+
+	if len(deploys) == 0 {
+		c.Ui.Output("No deployments found")
+		return 0
+	}
+
+	c.Ui.Output(formatDeployments(deploys, length))
+	if hint := nextPageHint(qm); hint != "" {
+		c.Ui.Warn(hint)
+	}
+	return 0
+}
+
+// formatDeployments formats a list of deployments as a table
+func formatDeployments(deploys []*api.Deployment, length int) string {
+	rows := make([]string, len(deploys)+1)
+	rows[0] = "ID|Job ID|Job Modify Index|Status|Description"
+	for i, d := range deploys {
+		rows[i+1] = fmt.Sprintf("%s|%s|%d|%s|%s",
+			limit(d.ID, length),
+			d.JobID,
+			d.JobModifyIndex,
+			d.Status,
+			d.StatusDescription)
+	}
+	return formatList(rows)
+}
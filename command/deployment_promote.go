@@ -0,0 +1,148 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/helper/flag-helpers"
+)
+
+type DeploymentPromoteCommand struct {
+	Meta
+}
+
+func (c *DeploymentPromoteCommand) Help() string {
+	helpText := `
+Usage: nomad deployment promote [options] <deployment-id>
+
+  Promote the canaries of a deployment. Once promoted, the remaining
+  allocations of the task group are updated using the rolling update
+  settings of the group. By default all task groups are promoted.
+
+  Upon successful promotion, an interactive monitor session will start to
+  display log lines as the evaluation continuing the deployment is
+  processed. It is safe to exit the monitor early using ctrl+c.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Promote Options:
+
+  -group
+    Promote only the canaries of the given task group. The flag may be
+    specified multiple times.
+
+  -detach
+    Return immediately instead of entering monitor mode. After the
+    deployment is promoted, the evaluation ID is printed to the screen,
+    which can be used to examine the evaluation using the eval-status
+    command.
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentPromoteCommand) Synopsis() string {
+	return "Promote the canaries of a deployment"
+}
+
+func (c *DeploymentPromoteCommand) Run(args []string) int {
+	var detach, verbose, quiet bool
+	var groups flaghelper.StringFlag
+
+	flags := c.Meta.FlagSet("deployment promote", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
+	flags.Var(&groups, "group", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one deployment
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	deploymentID := args[0]
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Look up the deployment by prefix
+	if len(deploymentID) == 1 {
+		c.Ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
+		return 1
+	}
+	if len(deploymentID)%2 == 1 {
+		// Identifiers must be of even length, so we strip off the last byte
+		// to provide a consistent user experience.
+		deploymentID = deploymentID[:len(deploymentID)-1]
+	}
+
+	deploys, _, err := client.Deployments().PrefixList(deploymentID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying deployment: %s", err))
+		return 1
+	}
+	if len(deploys) == 0 {
+		c.Ui.Error(fmt.Sprintf("No deployment(s) with prefix or id %q found", deploymentID))
+		return 1
+	}
+	if len(deploys) > 1 {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple deployments\n\n%s",
+			formatDeployments(deploys, length)))
+		return 0
+	}
+	deploymentID = deploys[0].ID
+
+	// Promote the deployment
+	deployments := client.Deployments()
+	var evalID string
+	if len(groups) == 0 {
+		resp, _, err := deployments.PromoteAll(deploymentID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error promoting deployment: %s", err))
+			return 1
+		}
+		evalID = resp.EvalID
+	} else {
+		resp, _, err := deployments.PromoteGroups(deploymentID, groups, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error promoting deployment: %s", err))
+			return 1
+		}
+		evalID = resp.EvalID
+	}
+
+	if detach {
+		c.Ui.Output(evalID)
+		return 0
+	}
+
+	// Monitor the evaluation continuing the deployment
+	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	return mon.monitor(evalID, false)
+}
@@ -0,0 +1,183 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type DeploymentStatusCommand struct {
+	Meta
+}
+
+func (c *DeploymentStatusCommand) Help() string {
+	helpText := `
+Usage: nomad deployment status [options] <deployment-id>
+
+  Status is used to display the status of a deployment. The status shows
+  the health of the allocations placed for each task group of the deployed
+  version of the job. The deployment can be referenced by an ID prefix.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Status Options:
+
+  -json
+    Output the deployment in its JSON format.
+
+  -t
+    Format and display the deployment using a Go template.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentStatusCommand) Synopsis() string {
+	return "Display the status of a deployment"
+}
+
+func (c *DeploymentStatusCommand) Run(args []string) int {
+	var json, verbose bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("deployment status", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one deployment
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	deploymentID := args[0]
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Query the deployment info
+	if len(deploymentID) == 1 {
+		c.Ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
+		return 1
+	}
+	if len(deploymentID)%2 == 1 {
+		// Identifiers must be of even length, so we strip off the last byte
+		// to provide a consistent user experience.
+		deploymentID = deploymentID[:len(deploymentID)-1]
+	}
+
+	deploys, _, err := client.Deployments().PrefixList(deploymentID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying deployment: %s", err))
+		return 1
+	}
+	if len(deploys) == 0 {
+		c.Ui.Error(fmt.Sprintf("No deployment(s) with prefix or id %q found", deploymentID))
+		return 1
+	}
+	if len(deploys) > 1 {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple deployments\n\n%s",
+			formatDeployments(deploys, length)))
+		return 0
+	}
+
+	// Prefix lookup matched a single deployment
+	d, _, err := client.Deployments().Info(deploys[0].ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying deployment: %s", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(d)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	basic := []string{
+		fmt.Sprintf("ID|%s", limit(d.ID, length)),
+		fmt.Sprintf("Job ID|%s", d.JobID),
+		fmt.Sprintf("Job Modify Index|%d", d.JobModifyIndex),
+		fmt.Sprintf("Status|%s", d.Status),
+		fmt.Sprintf("Description|%s", d.StatusDescription),
+	}
+	c.Ui.Output(formatKV(basic))
+
+	if len(d.TaskGroups) != 0 {
+		c.Ui.Output(c.Colorize().Color("\n[bold]Deployed[reset]"))
+		c.Ui.Output(formatDeploymentGroups(d))
+	}
+	return 0
+}
+
+// formatDeploymentGroups formats the state of the task groups of a
+// deployment as a table
+func formatDeploymentGroups(d *api.Deployment) string {
+	names := make([]string, 0, len(d.TaskGroups))
+	for name := range d.TaskGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := make([]string, len(names)+1)
+	rows[0] = "Task Group|Auto Revert|Promoted|Desired|Canaries|Placed|Healthy|Unhealthy"
+	for i, name := range names {
+		state := d.TaskGroups[name]
+		promoted := "N/A"
+		if state.DesiredCanaries > 0 {
+			promoted = fmt.Sprintf("%v", state.Promoted)
+		}
+		rows[i+1] = fmt.Sprintf("%s|%v|%s|%d|%d|%d|%d|%d",
+			name,
+			state.AutoRevert,
+			promoted,
+			state.DesiredTotal,
+			state.DesiredCanaries,
+			state.PlacedAllocs,
+			state.HealthyAllocs,
+			state.UnhealthyAllocs)
+	}
+	return formatList(rows)
+}
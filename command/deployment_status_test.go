@@ -0,0 +1,78 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestDeploymentStatusCommand_Implements(t *testing.T) {
+	var _ cli.Command = &DeploymentStatusCommand{}
+}
+
+func TestDeploymentStatusCommand_Fails(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &DeploymentStatusCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on deployment lookup failure
+	if code := cmd.Run([]string{"-address=" + url, "3E55C771-76FC-423B-BCED-3E5314F433B1"}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "No deployment(s) with prefix or id") {
+		t.Fatalf("expect not found error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "12345678-abcd-efab-cdef-123456789abc"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying deployment") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestDeploymentStatusCommand_FormatGroups(t *testing.T) {
+	d := &api.Deployment{
+		TaskGroups: map[string]*api.DeploymentState{
+			"web": &api.DeploymentState{
+				AutoRevert:      true,
+				DesiredCanaries: 1,
+				DesiredTotal:    3,
+				PlacedAllocs:    1,
+				HealthyAllocs:   1,
+			},
+			"cache": &api.DeploymentState{
+				DesiredTotal:    2,
+				PlacedAllocs:    2,
+				UnhealthyAllocs: 1,
+			},
+		},
+	}
+
+	out := formatDeploymentGroups(d)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("bad: %s", out)
+	}
+	if !strings.HasPrefix(lines[1], "cache") || !strings.Contains(lines[1], "N/A") {
+		t.Fatalf("bad: %s", out)
+	}
+	if !strings.HasPrefix(lines[2], "web") || !strings.Contains(lines[2], "false") {
+		t.Fatalf("bad: %s", out)
+	}
+}
@@ -29,11 +29,23 @@ Eval Status Options:
   -monitor
     Monitor an outstanding evaluation
 
+  -explain
+    Display the decision of the scheduler for each node considered for the
+    placements of the evaluation, explaining why the allocations landed where
+    they did or why they could not be placed. Requires the evaluation to be
+    traced by running the job with "nomad run -trace".
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
   -verbose
-    Show full information.
+    Show full information. When combined with -monitor, every allocation
+    transition is shown instead of coalescing the ones observed at once.
 
   -json
-    Output the evaluation in its JSON format.
+    Output the evaluation in its JSON format. When combined with -monitor,
+    each event observed by the monitor is output as a JSON object.
 
   -t
     Format and display evaluation using a Go template.
@@ -47,13 +59,15 @@ func (c *EvalStatusCommand) Synopsis() string {
 }
 
 func (c *EvalStatusCommand) Run(args []string) int {
-	var monitor, verbose, json bool
+	var monitor, verbose, json, quiet, explain bool
 	var tmpl string
 
 	flags := c.Meta.FlagSet("eval-status", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&monitor, "monitor", false, "")
+	flags.BoolVar(&explain, "explain", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
 	flags.BoolVar(&json, "json", false, "")
 	flags.StringVar(&tmpl, "t", "", "")
 
@@ -164,6 +178,10 @@ func (c *EvalStatusCommand) Run(args []string) int {
 	// If we are in monitor mode, monitor and exit
 	if monitor {
 		mon := newMonitor(c.Ui, client, length)
+		mon.color = c.Colorize()
+		mon.quiet = quiet
+		mon.verbose = verbose
+		mon.json = json
 		return mon.monitor(evals[0].ID, true)
 	}
 
@@ -219,9 +237,9 @@ func (c *EvalStatusCommand) Run(args []string) int {
 	if verbose {
 		// NextEval, PreviousEval, BlockedEval
 		basic = append(basic,
-			fmt.Sprintf("Previous Eval|%s", eval.PreviousEval),
-			fmt.Sprintf("Next Eval|%s", eval.NextEval),
-			fmt.Sprintf("Blocked Eval|%s", eval.BlockedEval))
+			fmt.Sprintf("Previous Eval|%s", limit(eval.PreviousEval, length)),
+			fmt.Sprintf("Next Eval|%s", limit(eval.NextEval, length)),
+			fmt.Sprintf("Blocked Eval|%s", limit(eval.BlockedEval, length)))
 	}
 	c.Ui.Output(formatKV(basic))
 
@@ -229,14 +247,18 @@ func (c *EvalStatusCommand) Run(args []string) int {
 		c.Ui.Output(c.Colorize().Color("\n[bold]Failed Placements[reset]"))
 		sorted := sortedTaskGroupFromMetrics(eval.FailedTGAllocs)
 		for _, tg := range sorted {
-			metrics := eval.FailedTGAllocs[tg]
+			c.Ui.Output(formatPlacementFailure(tg, eval.FailedTGAllocs[tg]))
+			c.Ui.Output("")
+		}
 
-			noun := "allocation"
-			if metrics.CoalescedFailures > 0 {
-				noun += "s"
+		if queued := sortedTaskGroupFromQueued(eval.QueuedAllocations); len(queued) != 0 {
+			c.Ui.Output(c.Colorize().Color("[bold]Queued Placements[reset]"))
+			rows := make([]string, len(queued)+1)
+			rows[0] = "Task Group|Queued"
+			for i, tg := range queued {
+				rows[i+1] = fmt.Sprintf("%s|%d", tg, eval.QueuedAllocations[tg])
 			}
-			c.Ui.Output(fmt.Sprintf("Task Group %q (failed to place %d %s):", tg, metrics.CoalescedFailures+1, noun))
-			c.Ui.Output(formatAllocMetrics(metrics, false, "  "))
+			c.Ui.Output(formatList(rows))
 			c.Ui.Output("")
 		}
 
@@ -246,9 +268,191 @@ func (c *EvalStatusCommand) Run(args []string) int {
 		}
 	}
 
+	if explain {
+		return c.explain(client, eval, length)
+	}
+
+	return 0
+}
+
+// explain outputs the decisions recorded by the scheduler for each node
+// considered for the placements of a traced evaluation
+func (c *EvalStatusCommand) explain(client *api.Client, eval *api.Evaluation, length int) int {
+	c.Ui.Output(c.Colorize().Color("\n[bold]Placement Decisions[reset]"))
+	if !eval.Trace {
+		c.Ui.Output("Evaluation was not traced, run the job with \"nomad run -trace\" to record the decisions of the scheduler")
+		return 0
+	}
+
+	allocs, _, err := client.Evaluations().Allocations(eval.ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying evaluation allocations: %s", err))
+		return 1
+	}
+	for _, stub := range allocs {
+		alloc, _, err := client.Allocations().Info(stub.ID, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying allocation: %s", err))
+			return 1
+		}
+		if alloc.Metrics == nil || len(alloc.Metrics.NodeTraces) == 0 {
+			continue
+		}
+		c.Ui.Output(fmt.Sprintf("Allocation %q (%s) placed on node %q:",
+			limit(alloc.ID, length), alloc.Name, limit(alloc.NodeID, length)))
+		c.Ui.Output(formatNodeTraces(alloc.Metrics.NodeTraces, alloc.NodeID, length))
+		c.Ui.Output("")
+	}
+
+	for _, tg := range sortedTaskGroupFromMetrics(eval.FailedTGAllocs) {
+		metrics := eval.FailedTGAllocs[tg]
+		if len(metrics.NodeTraces) == 0 {
+			continue
+		}
+		c.Ui.Output(fmt.Sprintf("Task Group %q could not be placed:", tg))
+		c.Ui.Output(formatNodeTraces(metrics.NodeTraces, "", length))
+		c.Ui.Output("")
+	}
 	return 0
 }
 
+// formatNodeTraces formats the decisions made for each node, listing the
+// placed node first, followed by the other nodes by descending score and then
+// the infeasible ones.
+func formatNodeTraces(traces map[string]*api.NodeTrace, placedID string, length int) string {
+	decisions := make([]*nodeDecision, 0, len(traces))
+	for nodeID, trace := range traces {
+		d := &nodeDecision{nodeID: nodeID, trace: trace}
+		switch {
+		case nodeID == placedID:
+			d.decision, d.rank = "placed", 0
+		case trace.Filtered != "":
+			d.decision, d.rank, d.reason = "filtered", 3, trace.Filtered
+		case trace.Exhausted != "":
+			d.decision, d.rank, d.reason = "exhausted", 2, trace.Exhausted
+		default:
+			d.decision, d.rank = "feasible", 1
+		}
+
+		names := make([]string, 0, len(trace.Scores))
+		for name, score := range trace.Scores {
+			d.score += score
+			names = append(names, name)
+		}
+		if d.reason == "" && len(names) != 0 {
+			sort.Strings(names)
+			scores := make([]string, len(names))
+			for i, name := range names {
+				scores[i] = fmt.Sprintf("%s=%.3f", name, trace.Scores[name])
+			}
+			d.reason = strings.Join(scores, ", ")
+		}
+		decisions = append(decisions, d)
+	}
+	sort.Sort(nodeDecisions(decisions))
+
+	rows := make([]string, len(decisions)+1)
+	rows[0] = "Node ID|Node Name|Decision|Score|Details"
+	for i, d := range decisions {
+		score := ""
+		if len(d.trace.Scores) != 0 {
+			score = fmt.Sprintf("%.3f", d.score)
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s",
+			limit(d.nodeID, length), d.trace.NodeName, d.decision, score, d.reason)
+	}
+	return formatList(rows)
+}
+
+// nodeDecision is the decision made for a node by a traced evaluation
+type nodeDecision struct {
+	nodeID   string
+	trace    *api.NodeTrace
+	decision string
+	reason   string
+	score    float64
+
+	// rank orders the decisions, placed nodes first
+	rank int
+}
+
+// nodeDecisions sorts the decisions by rank, score and node name
+type nodeDecisions []*nodeDecision
+
+func (n nodeDecisions) Len() int      { return len(n) }
+func (n nodeDecisions) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+func (n nodeDecisions) Less(i, j int) bool {
+	if n[i].rank != n[j].rank {
+		return n[i].rank < n[j].rank
+	}
+	if n[i].score != n[j].score {
+		return n[i].score > n[j].score
+	}
+	if n[i].trace.NodeName != n[j].trace.NodeName {
+		return n[i].trace.NodeName < n[j].trace.NodeName
+	}
+	return n[i].nodeID < n[j].nodeID
+}
+
+// sortedTaskGroupFromQueued returns the sorted task groups that have queued
+// allocations
+func sortedTaskGroupFromQueued(queued map[string]int) []string {
+	tgs := make([]string, 0, len(queued))
+	for tg, num := range queued {
+		if num > 0 {
+			tgs = append(tgs, tg)
+		}
+	}
+	sort.Strings(tgs)
+	return tgs
+}
+
+// sortedTaskGroupFromGroups returns the sorted task groups of the placement
+// summaries of the monitor
+func sortedTaskGroupFromGroups(groups map[string]*groupState) []string {
+	tgs := make([]string, 0, len(groups))
+	for tg := range groups {
+		tgs = append(tgs, tg)
+	}
+	sort.Strings(tgs)
+	return tgs
+}
+
+// sortedTaskGroupFromAllocs returns the sorted task groups of the allocations
+// coalesced by the monitor
+func sortedTaskGroupFromAllocs(allocs map[string][]*allocState) []string {
+	tgs := make([]string, 0, len(allocs))
+	for tg := range allocs {
+		tgs = append(tgs, tg)
+	}
+	sort.Strings(tgs)
+	return tgs
+}
+
+// sortedAllocTransitions returns the sorted status changes of the allocations
+// coalesced by the monitor
+func sortedAllocTransitions(changed map[allocTransition][]*allocState) []allocTransition {
+	transitions := make([]allocTransition, 0, len(changed))
+	for transition := range changed {
+		transitions = append(transitions, transition)
+	}
+	sort.Sort(allocTransitions(transitions))
+	return transitions
+}
+
+// allocTransitions sorts status changes by the previous and then the new
+// status
+type allocTransitions []allocTransition
+
+func (t allocTransitions) Len() int      { return len(t) }
+func (t allocTransitions) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t allocTransitions) Less(i, j int) bool {
+	if t[i].from != t[j].from {
+		return t[i].from < t[j].from
+	}
+	return t[i].to < t[j].to
+}
+
 func sortedTaskGroupFromMetrics(groups map[string]*api.AllocationMetric) []string {
 	tgs := make([]string, 0, len(groups))
 	for tg, _ := range groups {
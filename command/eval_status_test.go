@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -54,3 +55,49 @@ func TestEvalStatusCommand_Fails(t *testing.T) {
 	}
 
 }
+
+func TestEvalStatusCommand_FormatNodeTraces(t *testing.T) {
+	traces := map[string]*api.NodeTrace{
+		"node1": &api.NodeTrace{
+			NodeName: "filtered",
+			Filtered: "${attr.kernel.name} = linux",
+		},
+		"node2": &api.NodeTrace{
+			NodeName: "low",
+			Scores:   map[string]float64{"binpack": 2},
+		},
+		"node3": &api.NodeTrace{
+			NodeName:  "exhausted",
+			Exhausted: "memory exhausted",
+		},
+		"node4": &api.NodeTrace{
+			NodeName: "high",
+			Scores:   map[string]float64{"binpack": 9, "job-anti-affinity": -1},
+		},
+		"node5": &api.NodeTrace{
+			NodeName: "placed",
+			Scores:   map[string]float64{"binpack": 1},
+		},
+	}
+
+	out := formatNodeTraces(traces, "node5", fullId)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 6 {
+		t.Fatalf("bad: %s", out)
+	}
+
+	// The placed node comes first, followed by the feasible nodes by score
+	// and then the infeasible ones
+	expected := []string{"placed", "high", "low", "exhausted", "filtered"}
+	for i, name := range expected {
+		if !strings.Contains(lines[i+1], name) {
+			t.Fatalf("expected %q on line %d, got: %s", name, i+1, out)
+		}
+	}
+	if !strings.Contains(lines[2], "8.000") || !strings.Contains(lines[2], "binpack=9.000, job-anti-affinity=-1.000") {
+		t.Fatalf("bad: %s", lines[2])
+	}
+	if !strings.Contains(lines[5], "${attr.kernel.name} = linux") {
+		t.Fatalf("bad: %s", lines[5])
+	}
+}
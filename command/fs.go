@@ -25,6 +25,23 @@ const (
 	defaultTailLines int64 = 10
 )
 
+// fsMode determines how the fs command treats the requested path.
+type fsMode int
+
+const (
+	// fsModeAuto lists directories and displays the contents of files.
+	fsModeAuto fsMode = iota
+
+	// fsModeList lists the path.
+	fsModeList
+
+	// fsModeStat displays the file stat information of the path.
+	fsModeStat
+
+	// fsModeCat displays the contents of the path, which must be a file.
+	fsModeCat
+)
+
 type FSCommand struct {
 	Meta
 }
@@ -37,6 +54,9 @@ Usage: nomad fs <alloc-id> <path>
   or displays the file at the given path. The path is relative to the root of the alloc
   dir and defaults to root if unspecified.
 
+  The "fs ls", "fs stat" and "fs cat" subcommands can be used to explicitly
+  list a directory, display file stat information or display a file.
+
 General Options:
 
   ` + generalOptionsUsage() + `
@@ -78,10 +98,16 @@ func (f *FSCommand) Synopsis() string {
 }
 
 func (f *FSCommand) Run(args []string) int {
+	return f.run("fs", args, fsModeAuto)
+}
+
+// run executes the fs command for the given mode. The name is used for the
+// flag set so that the subcommands report errors under their own name.
+func (f *FSCommand) run(name string, args []string, mode fsMode) int {
 	var verbose, machine, job, stat, tail, follow bool
 	var numLines, numBytes int64
 
-	flags := f.Meta.FlagSet("fs", FlagSetClient)
+	flags := f.Meta.FlagSet(name, FlagSetClient)
 	flags.Usage = func() { f.Ui.Output(f.Help()) }
 	flags.BoolVar(&verbose, "verbose", false, "")
 	flags.BoolVar(&machine, "H", false, "")
@@ -97,6 +123,10 @@ func (f *FSCommand) Run(args []string) int {
 	}
 	args = flags.Args()
 
+	if stat {
+		mode = fsModeStat
+	}
+
 	if len(args) < 1 {
 		if job {
 			f.Ui.Error("job ID is required")
@@ -188,29 +218,24 @@ func (f *FSCommand) Run(args []string) int {
 		return 1
 	}
 
-	// If we want file stats, print those and exit.
-	if stat {
+	// If we want file stats, print those and exit. Listing a file is
+	// equivalent to displaying its stat information.
+	if mode == fsModeStat || (mode == fsModeList && !file.IsDir) {
 		// Display the file information
 		out := make([]string, 2)
 		out[0] = "Mode|Size|Modified Time|Name"
 		if file != nil {
-			fn := file.Name
-			if file.IsDir {
-				fn = fmt.Sprintf("%s/", fn)
-			}
-			var size string
-			if machine {
-				size = fmt.Sprintf("%d", file.Size)
-			} else {
-				size = humanize.IBytes(uint64(file.Size))
-			}
-			out[1] = fmt.Sprintf("%s|%s|%s|%s", file.FileMode, size,
-				formatTime(file.ModTime), fn)
+			out[1] = formatFileInfo(file, machine)
 		}
 		f.Ui.Output(formatList(out))
 		return 0
 	}
 
+	if mode == fsModeCat && file.IsDir {
+		f.Ui.Error(fmt.Sprintf("Path %q is a directory", path))
+		return 1
+	}
+
 	// Determine if the path is a file or a directory.
 	if file.IsDir {
 		// We have a directory, list it.
@@ -223,22 +248,7 @@ func (f *FSCommand) Run(args []string) int {
 		out := make([]string, len(files)+1)
 		out[0] = "Mode|Size|Modified Time|Name"
 		for i, file := range files {
-			fn := file.Name
-			if file.IsDir {
-				fn = fmt.Sprintf("%s/", fn)
-			}
-			var size string
-			if machine {
-				size = fmt.Sprintf("%d", file.Size)
-			} else {
-				size = humanize.IBytes(uint64(file.Size))
-			}
-			out[i+1] = fmt.Sprintf("%s|%s|%s|%s",
-				file.FileMode,
-				size,
-				formatTime(file.ModTime),
-				fn,
-			)
+			out[i+1] = formatFileInfo(file, machine)
 		}
 		f.Ui.Output(formatList(out))
 		return 0
@@ -310,6 +320,22 @@ func (f *FSCommand) Run(args []string) int {
 	return 0
 }
 
+// formatFileInfo formats the file information as a row for formatList. If
+// machine is set, the size is displayed in bytes.
+func formatFileInfo(file *api.AllocFileInfo, machine bool) string {
+	fn := file.Name
+	if file.IsDir {
+		fn = fmt.Sprintf("%s/", fn)
+	}
+	var size string
+	if machine {
+		size = fmt.Sprintf("%d", file.Size)
+	} else {
+		size = humanize.IBytes(uint64(file.Size))
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", file.FileMode, size, formatTime(file.ModTime), fn)
+}
+
 // followFile outputs the contents of the file to stdout relative to the end of
 // the file. If numLines does not equal -1, then tail -n behavior is used.
 func (f *FSCommand) followFile(client *api.Client, alloc *api.Allocation,
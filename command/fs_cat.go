@@ -0,0 +1,54 @@
+package command
+
+import "strings"
+
+type FSCatCommand struct {
+	Meta
+}
+
+func (f *FSCatCommand) Help() string {
+	helpText := `
+Usage: nomad fs cat [options] <alloc-id> <path>
+
+  cat displays the contents of the file at the given path in the allocation
+  directory of the passed allocation. The path is relative to the root of the
+  alloc dir.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Cat Options:
+
+  -verbose
+    Show full information.
+
+  -job <job-id>
+    Use a random allocation from the specified job ID.
+
+  -f
+    Causes the output to not stop when the end of the file is reached, but rather to
+    wait for additional output.
+
+  -tail
+    Show the files contents with offsets relative to the end of the file. If no
+    offset is given, -n is defaulted to 10.
+
+  -n
+    Sets the tail location in best-efforted number of lines relative to the end
+    of the file.
+
+  -c
+    Sets the tail location in number of bytes relative to the end of the file.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *FSCatCommand) Synopsis() string {
+	return "Display the contents of a file in an allocation directory"
+}
+
+func (f *FSCatCommand) Run(args []string) int {
+	cmd := &FSCommand{Meta: f.Meta}
+	return cmd.run("fs cat", args, fsModeCat)
+}
@@ -0,0 +1,43 @@
+package command
+
+import "strings"
+
+type FSListCommand struct {
+	Meta
+}
+
+func (f *FSListCommand) Help() string {
+	helpText := `
+Usage: nomad fs ls [options] <alloc-id> <path>
+
+  ls displays the contents of the allocation directory for the passed
+  allocation at the given path. The path is relative to the root of the alloc
+  dir and defaults to root if unspecified. If the path is a file, its stat
+  information is displayed.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Ls Options:
+
+  -H
+    Machine friendly output.
+
+  -verbose
+    Show full information.
+
+  -job <job-id>
+    Use a random allocation from the specified job ID.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *FSListCommand) Synopsis() string {
+	return "List files in an allocation directory"
+}
+
+func (f *FSListCommand) Run(args []string) int {
+	cmd := &FSCommand{Meta: f.Meta}
+	return cmd.run("fs ls", args, fsModeList)
+}
@@ -0,0 +1,42 @@
+package command
+
+import "strings"
+
+type FSStatCommand struct {
+	Meta
+}
+
+func (f *FSStatCommand) Help() string {
+	helpText := `
+Usage: nomad fs stat [options] <alloc-id> <path>
+
+  stat displays file stat information for the given path in the allocation
+  directory of the passed allocation. The path is relative to the root of the
+  alloc dir and defaults to root if unspecified.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Stat Options:
+
+  -H
+    Machine friendly output.
+
+  -verbose
+    Show full information.
+
+  -job <job-id>
+    Use a random allocation from the specified job ID.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *FSStatCommand) Synopsis() string {
+	return "Stat a file in an allocation directory"
+}
+
+func (f *FSStatCommand) Run(args []string) int {
+	cmd := &FSCommand{Meta: f.Meta}
+	return cmd.run("fs stat", args, fsModeStat)
+}
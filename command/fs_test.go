@@ -11,6 +11,30 @@ func TestFSCommand_Implements(t *testing.T) {
 	var _ cli.Command = &FSCommand{}
 }
 
+func TestFSSubcommands_Implements(t *testing.T) {
+	var _ cli.Command = &FSListCommand{}
+	var _ cli.Command = &FSStatCommand{}
+	var _ cli.Command = &FSCatCommand{}
+}
+
+func TestFSSubcommands_Fails(t *testing.T) {
+	for _, cmd := range []cli.Command{
+		&FSListCommand{Meta: Meta{Ui: new(cli.MockUi)}},
+		&FSStatCommand{Meta: Meta{Ui: new(cli.MockUi)}},
+		&FSCatCommand{Meta: Meta{Ui: new(cli.MockUi)}},
+	} {
+		// Fails on lack of allocation ID
+		if code := cmd.Run([]string{}); code != 1 {
+			t.Fatalf("expected exit code 1, got: %d", code)
+		}
+
+		// Fails on connection failure
+		if code := cmd.Run([]string{"-address=nope", "foobar"}); code != 1 {
+			t.Fatalf("expected exit code 1, got: %d", code)
+		}
+	}
+}
+
 func TestFSCommand_Fails(t *testing.T) {
 	srv, _, url := testServer(t, nil)
 	defer srv.Stop()
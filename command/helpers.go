@@ -2,15 +2,19 @@ package command
 
 import (
 	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	gg "github.com/hashicorp/go-getter"
 	"github.com/hashicorp/nomad/api"
+	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
 	"github.com/hashicorp/nomad/jobspec"
 	"github.com/hashicorp/nomad/nomad/structs"
 
@@ -230,10 +234,45 @@ READ:
 }
 
 type JobGetter struct {
+	// vars and varFiles set the input variables of the job file
+	vars     []string
+	varFiles []string
+
 	// The fields below can be overwritten for tests
 	testStdin io.Reader
 }
 
+// addVarFlags registers the -var and -var-file flags setting the input
+// variables of the job file.
+func (j *JobGetter) addVarFlags(flags *flag.FlagSet) {
+	flags.Var((*flaghelper.StringFlag)(&j.vars), "var", "")
+	flags.Var((*flaghelper.StringFlag)(&j.varFiles), "var-file", "")
+}
+
+// jobVars returns the values of the input variables. The files are read in
+// order and the values of the -var flags override those of the files.
+func (j *JobGetter) jobVars() (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, path := range j.varFiles {
+		fileVars, err := jobspec.ParseVarFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+
+	for _, kv := range j.vars {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid variable %q, expected key=value", kv)
+		}
+		vars[parts[0]] = parts[1]
+	}
+	return vars, nil
+}
+
 // StructJob returns the Job struct from jobfile.
 func (j *JobGetter) StructJob(jpath string) (*structs.Job, error) {
 	var jobfile io.Reader
@@ -283,12 +322,114 @@ func (j *JobGetter) StructJob(jpath string) (*structs.Job, error) {
 		}
 	}
 
-	// Parse the JobFile
-	jobStruct, err := jobspec.Parse(jobfile)
+	vars, err := j.jobVars()
 	if err != nil {
-		fmt.Errorf("Error parsing job file from %s: %v", jpath, err)
 		return nil, err
 	}
 
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, jobfile); err != nil {
+		return nil, fmt.Errorf("Error reading job file from %s: %v", jpath, err)
+	}
+
+	// Jobs in the JSON format of the HTTP API are decoded as is
+	if isAPIJSON(buf.Bytes()) {
+		if len(vars) != 0 {
+			return nil, fmt.Errorf("Variables can't be set for JSON job file %s", jpath)
+		}
+		jobStruct, err := parseAPIJSON(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing JSON job file from %s: %v", jpath, err)
+		}
+		return jobStruct, nil
+	}
+
+	// Parse the JobFile
+	jobStruct, err := jobspec.ParseWithVars(&buf, vars)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing job file from %s: %v", jpath, err)
+	}
+
 	return jobStruct, nil
 }
+
+// isAPIJSON returns whether the job file is a job in the JSON format of the
+// HTTP API, either alone or wrapped as in a registration request, as opposed
+// to a job specification written in HCL or its JSON syntax.
+func isAPIJSON(contents []byte) bool {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &m); err != nil {
+		return false
+	}
+	_, ok := m["job"]
+	return !ok
+}
+
+// parseAPIJSON decodes a job in the JSON format of the HTTP API.
+func parseAPIJSON(contents []byte) (*structs.Job, error) {
+	var req struct {
+		Job *structs.Job
+	}
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, err
+	}
+	if req.Job != nil {
+		return req.Job, nil
+	}
+
+	var job structs.Job
+	if err := json.Unmarshal(contents, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// listPager holds the flags filtering and paginating the output of the list
+// commands
+type listPager struct {
+	filter    string
+	perPage   int
+	pageToken string
+}
+
+// addListFlags registers the -filter, -per-page and -page-token flags
+func (l *listPager) addListFlags(flags *flag.FlagSet) {
+	flags.StringVar(&l.filter, "filter", "", "")
+	flags.IntVar(&l.perPage, "per-page", 0, "")
+	flags.StringVar(&l.pageToken, "page-token", "", "")
+}
+
+// queryOptions returns the query options listing the page selected by the
+// flags
+func (l *listPager) queryOptions() *api.QueryOptions {
+	return &api.QueryOptions{
+		Filter:    l.filter,
+		PerPage:   int32(l.perPage),
+		NextToken: l.pageToken,
+	}
+}
+
+// nextPageHint returns how to list the next page, or an empty string if the
+// query returned the last page
+func nextPageHint(qm *api.QueryMeta) string {
+	if qm == nil || qm.NextToken == "" {
+		return ""
+	}
+	return fmt.Sprintf("Results have been paginated. To get the next page run the command with -page-token=%s", qm.NextToken)
+}
+
+// listOptionsUsage returns the help string of the list flags
+func listOptionsUsage() string {
+	helpText := `
+  -filter=<expr>
+    Only list the objects matching the filter expression, for example
+    'Status == "running" and Name matches "^web"'.
+
+  -per-page=<num>
+    The maximum number of objects to list. The command prints the token of
+    the next page if more objects are left.
+
+  -page-token=<token>
+    The token of the page to list, printed by the previous page.`
+	return strings.TrimSpace(helpText)
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -252,3 +254,64 @@ func TestStructJobWithHTTPServer(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 }
+
+// Test StructJob with jobfiles in the JSON format of the HTTP API
+func TestStructJobWithJSON(t *testing.T) {
+	j := &JobGetter{}
+	path := writeTempJob(t, job)
+	defer os.Remove(path)
+	expected, err := j.StructJob(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected.Canonicalize()
+
+	apiJob, err := convertStructJob(expected)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Both the output of run -output and a bare job are accepted
+	wrapped, err := json.Marshal(api.RegisterJobRequest{Job: apiJob})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	bare, err := json.Marshal(apiJob)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, contents := range [][]byte{wrapped, bare} {
+		path := writeTempJob(t, string(contents))
+		defer os.Remove(path)
+		sj, err := j.StructJob(path)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !reflect.DeepEqual(sj, expected) {
+			t.Fatalf("bad: %#v", sj)
+		}
+	}
+
+	// Variables only apply to HCL job files
+	path = writeTempJob(t, string(bare))
+	defer os.Remove(path)
+	j.vars = []string{"image=redis"}
+	_, err = j.StructJob(path)
+	if err == nil || !strings.Contains(err.Error(), "Variables can't be set") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+// writeTempJob writes the job file to a temporary file and returns its path.
+func writeTempJob(t *testing.T, contents string) string {
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer fh.Close()
+	if _, err := fh.WriteString(contents); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return fh.Name()
+}
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type JobCommand struct {
+	Meta
+}
+
+func (f *JobCommand) Help() string {
+	helpText := `
+Usage: nomad job <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with jobs. Every time a
+  job is registered its version is incremented and a limited number of
+  prior versions are kept so that a job can be reverted to a known good
+  version.
+
+Subcommands:
+
+  dispatch    Dispatch an instance of a parameterized job
+  history     Display the version history of a job
+  inspect     Inspect the specification of a submitted job
+  periodic    Interact with periodic jobs
+  revert      Revert a job to a prior version
+  validate    Check a job specification for errors without registering it
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *JobCommand) Synopsis() string {
+	return "Interact with jobs"
+}
+
+func (f *JobCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
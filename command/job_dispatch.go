@@ -0,0 +1,167 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/helper/flag-helpers"
+)
+
+type JobDispatchCommand struct {
+	Meta
+}
+
+func (c *JobDispatchCommand) Help() string {
+	helpText := `
+Usage: nomad job dispatch [options] <parameterized job> [input source]
+
+  Dispatch creates an instance of a parameterized job. A data payload to the
+  dispatched instance can be provided via stdin by using "-" or by specifying
+  a path to a file. Metadata can be supplied by using the meta flag one or
+  more times.
+
+  Upon successful creation, the dispatched job ID will be printed and the
+  triggered evaluation will be monitored. This can be disabled by supplying
+  the detach flag.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Dispatch Options:
+
+  -meta <key>=<value>
+    Meta takes a key/value pair separated by "=". The metadata key will be
+    merged into the job's metadata. The job may define a default value for
+    the key which is overridden when dispatching. The flag can be provided
+    more than once to inject multiple metadata key/value pairs. Arbitrary keys
+    are not allowed. The parameterized job must allow the key to be merged.
+
+  -detach
+    Return immediately instead of entering monitor mode. After job dispatch,
+    the evaluation ID will be printed to the screen, which can be used to
+    examine the evaluation using the eval-status command.
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobDispatchCommand) Synopsis() string {
+	return "Dispatch an instance of a parameterized job"
+}
+
+func (c *JobDispatchCommand) Run(args []string) int {
+	var detach, verbose, quiet bool
+	var meta flaghelper.StringFlag
+
+	flags := c.Meta.FlagSet("job dispatch", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
+	flags.Var(&meta, "meta", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Check that we got one or two arguments
+	args = flags.Args()
+	if l := len(args); l < 1 || l > 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	jobID := args[0]
+
+	// Read the input
+	var payload []byte
+	if len(args) == 2 {
+		var err error
+		switch args[1] {
+		case "-":
+			payload, err = ioutil.ReadAll(os.Stdin)
+		default:
+			payload, err = ioutil.ReadFile(args[1])
+		}
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading input data: %v", err))
+			return 1
+		}
+	}
+
+	// Build the meta
+	metaMap := make(map[string]string, len(meta))
+	for _, m := range meta {
+		split := strings.SplitN(m, "=", 2)
+		if len(split) != 2 {
+			c.Ui.Error(fmt.Sprintf("Error parsing meta value: %v", m))
+			return 1
+		}
+
+		metaMap[split[0]] = split[1]
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Look up the job by prefix
+	jobs, _, err := client.Jobs().PrefixList(jobID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying job: %s", err))
+		return 1
+	}
+	if len(jobs) == 0 {
+		c.Ui.Error(fmt.Sprintf("No job(s) with prefix or id %q found", jobID))
+		return 1
+	}
+	if len(jobs) > 1 && strings.TrimSpace(jobID) != jobs[0].ID {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple jobs\n\n%s", createStatusListOutput(jobs)))
+		return 0
+	}
+	jobID = jobs[0].ID
+
+	// Dispatch the job
+	resp, _, err := client.Jobs().Dispatch(jobID, metaMap, payload, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to dispatch job: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("Dispatched Job ID|%s", resp.DispatchedJobID),
+		fmt.Sprintf("Evaluation ID|%s", limit(resp.EvalID, length)),
+	}
+	c.Ui.Output(formatKV(basic))
+
+	// Periodic dispatched jobs are not evaluated
+	if detach || resp.EvalID == "" {
+		return 0
+	}
+
+	// Monitor the evaluation of the dispatched job
+	if !quiet {
+		c.Ui.Output("")
+	}
+	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	return mon.monitor(resp.EvalID, false)
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestJobDispatchCommand_Implements(t *testing.T) {
+	var _ cli.Command = &JobDispatchCommand{}
+}
+
+func TestJobDispatchCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &JobDispatchCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails when specified file does not exist
+	if code := cmd.Run([]string{"foo", "/unicorns/rainbows"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error reading input data") {
+		t.Fatalf("expected error reading input data, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a malformed meta value
+	if code := cmd.Run([]string{"-meta=foo", "example"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error parsing meta value") {
+		t.Fatalf("expected meta error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "example"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying job") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestJobDispatchCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &JobDispatchCommand{Meta: Meta{Ui: ui}}
+
+	// Register a parameterized job
+	job := testJob("job1")
+	job.ParameterizedJob = &api.ParameterizedJobConfig{
+		MetaRequired: []string{"foo"},
+	}
+	if _, _, err := client.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Fails without the required meta
+	if code := cmd.Run([]string{"-address=" + url, "-detach", "job1"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Failed to dispatch job") {
+		t.Fatalf("expected dispatch error, got: %s", out)
+	}
+
+	// Dispatches the job given by prefix
+	if code := cmd.Run([]string{"-address=" + url, "-detach", "-meta", "foo=bar", "jo"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Dispatched Job ID = job1/dispatch-") {
+		t.Fatalf("expected dispatched job, got: %s", out)
+	}
+	// The dispatched job is listed by the status of the parameterized job
+	ui = new(cli.MockUi)
+	status := &StatusCommand{Meta: Meta{Ui: ui}}
+	if code := status.Run([]string{"-address=" + url, "job1"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, "Parameterized = true") || !strings.Contains(out, "job1/dispatch-") {
+		t.Fatalf("expected dispatched jobs, got: %s", out)
+	}
+}
@@ -0,0 +1,208 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type JobHistoryCommand struct {
+	Meta
+}
+
+func (c *JobHistoryCommand) Help() string {
+	helpText := `
+Usage: nomad job history [options] <job>
+
+  History is used to display the known versions of a particular job. The
+  versions are displayed from newest to oldest. A prior version of the job
+  can be restored using the "nomad job revert" command.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+History Options:
+
+  -p
+    Display the difference between each job version and its predecessor.
+
+  -version <job version>
+    Display only the history for the given job version.
+
+  -json
+    Output the job versions in their JSON format.
+
+  -t
+    Format and display the job versions using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobHistoryCommand) Synopsis() string {
+	return "Display the version history of a job"
+}
+
+func (c *JobHistoryCommand) Run(args []string) int {
+	var json, diff bool
+	var versionStr, tmpl string
+
+	flags := c.Meta.FlagSet("job history", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&diff, "p", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&versionStr, "version", "", "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one job
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	jobID := args[0]
+
+	if (json || len(tmpl) != 0) && diff {
+		c.Ui.Error("-p is not compatible with -json or -t")
+		return 1
+	}
+
+	// Parse the version to display
+	var version *uint64
+	if versionStr != "" {
+		v, err := strconv.ParseUint(versionStr, 10, 64)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error parsing version value %q: %v", versionStr, err))
+			return 1
+		}
+		version = &v
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Look up the job by prefix
+	jobs, _, err := client.Jobs().PrefixList(jobID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying job: %s", err))
+		return 1
+	}
+	if len(jobs) == 0 {
+		c.Ui.Error(fmt.Sprintf("No job(s) with prefix or id %q found", jobID))
+		return 1
+	}
+	if len(jobs) > 1 && strings.TrimSpace(jobID) != jobs[0].ID {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple jobs\n\n%s", createStatusListOutput(jobs)))
+		return 0
+	}
+	jobID = jobs[0].ID
+
+	// Query the job versions
+	versions, diffs, _, err := client.Jobs().Versions(jobID, diff, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving job versions: %s", err))
+		return 1
+	}
+
+	// Filter down to the requested version. The diff of a version is stored
+	// at the same position as the version itself.
+	if version != nil {
+		found := false
+		for i, job := range versions {
+			if job.Version != *version {
+				continue
+			}
+			found = true
+			versions = versions[i : i+1]
+			if i < len(diffs) {
+				diffs = diffs[i : i+1]
+			} else {
+				diffs = nil
+			}
+			break
+		}
+		if !found {
+			c.Ui.Error(fmt.Sprintf("Job %q has no version %d", jobID, *version))
+			return 1
+		}
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		var data interface{} = versions
+		if version != nil {
+			data = versions[0]
+		}
+		out, err := f.TransformData(data)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if !diff {
+		c.Ui.Output(formatJobVersions(versions))
+		return 0
+	}
+
+	for i, job := range versions {
+		if i != 0 {
+			c.Ui.Output("")
+		}
+		basic := []string{
+			fmt.Sprintf("Version|%d", job.Version),
+			fmt.Sprintf("Stable|%t", job.Stable),
+			fmt.Sprintf("Job Modify Index|%d", job.JobModifyIndex),
+		}
+		c.Ui.Output(formatKV(basic))
+
+		// The oldest tracked version has nothing to be compared against
+		if i < len(diffs) {
+			c.Ui.Output(c.Colorize().Color("\n[bold]Diff[reset]"))
+			c.Ui.Output(c.Colorize().Color(strings.TrimSpace(formatJobDiff(diffs[i], false))))
+		}
+	}
+	return 0
+}
+
+// formatJobVersions formats the versions of a job as a table
+func formatJobVersions(versions []*api.Job) string {
+	rows := make([]string, len(versions)+1)
+	rows[0] = "Version|Stable|Job Modify Index|Priority|Task Groups"
+	for i, job := range versions {
+		rows[i+1] = fmt.Sprintf("%d|%t|%d|%d|%d",
+			job.Version,
+			job.Stable,
+			job.JobModifyIndex,
+			job.Priority,
+			len(job.TaskGroups))
+	}
+	return formatList(rows)
+}
@@ -0,0 +1,80 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestJobHistoryCommand_Implements(t *testing.T) {
+	var _ cli.Command = &JobHistoryCommand{}
+}
+
+func TestJobHistoryCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &JobHistoryCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an invalid version
+	if code := cmd.Run([]string{"-version=foo", "example"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error parsing version") {
+		t.Fatalf("expected version error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "example"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying job") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestJobHistoryCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &JobHistoryCommand{Meta: Meta{Ui: ui}}
+
+	// Register the job twice
+	job := testJob("job1")
+	if _, _, err := client.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	job.Priority = 2
+	if _, _, err := client.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The versions are listed newest first
+	if code := cmd.Run([]string{"-address=" + url, "job1"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Version") || strings.Index(out, "\n1 ") > strings.Index(out, "\n0 ") {
+		t.Fatalf("expected versions, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// The diff of the priority is shown for a job given by prefix
+	if code := cmd.Run([]string{"-address=" + url, "-p", "-version=1", "jo"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, "Priority") || !strings.Contains(out, `"1" => "2"`) {
+		t.Fatalf("expected diff, got: %s", out)
+	}
+}
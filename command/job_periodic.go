@@ -0,0 +1,34 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type JobPeriodicCommand struct {
+	Meta
+}
+
+func (f *JobPeriodicCommand) Help() string {
+	helpText := `
+Usage: nomad job periodic <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with periodic jobs. The
+  instances previously launched by a periodic job are listed by the status
+  command.
+
+Subcommands:
+
+  force    Force the launch of a periodic job
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *JobPeriodicCommand) Synopsis() string {
+	return "Interact with periodic jobs"
+}
+
+func (f *JobPeriodicCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type JobPeriodicForceCommand struct {
+	Meta
+}
+
+func (c *JobPeriodicForceCommand) Help() string {
+	helpText := `
+Usage: nomad job periodic force [options] <job>
+
+  Force is used to launch an instance of a periodic job immediately,
+  regardless of its schedule. The launched instance is a child job whose
+  status can be inspected like any other job.
+
+  Upon successful launch, an interactive monitor session will start to
+  display log lines as the evaluation of the launched job is processed. It
+  is safe to exit the monitor early using ctrl+c.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Periodic Force Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. After the job is
+    launched, the evaluation ID is printed to the screen, which can be used
+    to examine the evaluation using the eval-status command.
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobPeriodicForceCommand) Synopsis() string {
+	return "Force the launch of a periodic job"
+}
+
+func (c *JobPeriodicForceCommand) Run(args []string) int {
+	var detach, verbose, quiet bool
+
+	flags := c.Meta.FlagSet("job periodic force", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one job
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	jobID := args[0]
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Look up the job by prefix
+	jobs, _, err := client.Jobs().PrefixList(jobID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying job: %s", err))
+		return 1
+	}
+	if len(jobs) == 0 {
+		c.Ui.Error(fmt.Sprintf("No job(s) with prefix or id %q found", jobID))
+		return 1
+	}
+	if len(jobs) > 1 && strings.TrimSpace(jobID) != jobs[0].ID {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple jobs\n\n%s", createStatusListOutput(jobs)))
+		return 0
+	}
+
+	job, _, err := client.Jobs().Info(jobs[0].ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying job: %s", err))
+		return 1
+	}
+	if job.Periodic == nil || !job.Periodic.Enabled {
+		c.Ui.Error(fmt.Sprintf("Job %q is not periodic", job.ID))
+		return 1
+	}
+
+	// Force the launch of the job
+	evalID, _, err := client.Jobs().PeriodicForce(job.ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error forcing periodic job %q: %s", job.ID, err))
+		return 1
+	}
+
+	if detach {
+		c.Ui.Output(evalID)
+		return 0
+	}
+
+	// Monitor the evaluation of the launched job
+	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	return mon.monitor(evalID, false)
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestJobPeriodicForceCommand_Implements(t *testing.T) {
+	var _ cli.Command = &JobPeriodicForceCommand{}
+}
+
+func TestJobPeriodicForceCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &JobPeriodicForceCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "example"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying job") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestJobPeriodicForceCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &JobPeriodicForceCommand{Meta: Meta{Ui: ui}}
+
+	// Register a periodic and a regular job
+	periodic := testJob("periodic1").AddPeriodicConfig(&api.PeriodicConfig{
+		Enabled:  true,
+		Spec:     "0 0 1 1 *",
+		SpecType: "cron",
+	})
+	if _, _, err := client.Jobs().Register(periodic, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, _, err := client.Jobs().Register(testJob("regular1"), nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Fails on a job that is not periodic
+	if code := cmd.Run([]string{"-address=" + url, "regular1"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "not periodic") {
+		t.Fatalf("expected periodic error, got: %s", out)
+	}
+
+	// Launches the periodic job given by prefix
+	if code := cmd.Run([]string{"-address=" + url, "-detach", "per"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	evalID := strings.TrimSpace(ui.OutputWriter.String())
+	eval, _, err := client.Evaluations().Info(evalID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.HasPrefix(eval.JobID, "periodic1/periodic-") {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
@@ -0,0 +1,130 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type JobRevertCommand struct {
+	Meta
+}
+
+func (c *JobRevertCommand) Help() string {
+	helpText := `
+Usage: nomad job revert [options] <job> <version>
+
+  Revert is used to revert a job to a prior version of the job. The
+  available versions to revert to can be found using the "nomad job history"
+  command. Reverting registers the prior version as a new version of the
+  job.
+
+  Upon successful revert, an interactive monitor session will start to
+  display log lines as the evaluation of the reverted job is processed. It
+  is safe to exit the monitor early using ctrl+c.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Revert Options:
+
+  -detach
+    Return immediately instead of entering monitor mode. After the job is
+    reverted, the evaluation ID is printed to the screen, which can be used
+    to examine the evaluation using the eval-status command.
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobRevertCommand) Synopsis() string {
+	return "Revert a job to a prior version"
+}
+
+func (c *JobRevertCommand) Run(args []string) int {
+	var detach, verbose, quiet bool
+
+	flags := c.Meta.FlagSet("job revert", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&detach, "detach", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got a job and a version
+	args = flags.Args()
+	if len(args) != 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	jobID := args[0]
+
+	version, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing version value %q: %v", args[1], err))
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Look up the job by prefix
+	jobs, _, err := client.Jobs().PrefixList(jobID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying job: %s", err))
+		return 1
+	}
+	if len(jobs) == 0 {
+		c.Ui.Error(fmt.Sprintf("No job(s) with prefix or id %q found", jobID))
+		return 1
+	}
+	if len(jobs) > 1 && strings.TrimSpace(jobID) != jobs[0].ID {
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple jobs\n\n%s", createStatusListOutput(jobs)))
+		return 0
+	}
+	jobID = jobs[0].ID
+
+	// Revert the job
+	evalID, _, err := client.Jobs().Revert(jobID, version, nil, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reverting job: %s", err))
+		return 1
+	}
+
+	// Periodic jobs are not evaluated when registered
+	if evalID == "" {
+		c.Ui.Output(fmt.Sprintf("Job %q reverted to version %d", jobID, version))
+		return 0
+	}
+
+	if detach {
+		c.Ui.Output(evalID)
+		return 0
+	}
+
+	// Monitor the evaluation of the reverted job
+	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	return mon.monitor(evalID, false)
+}
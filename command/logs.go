@@ -28,7 +28,7 @@ General Options:
 
 Logs Specific Options:
 
-  -stderr:
+  -stderr
     Display stderr logs.
 
   -verbose
@@ -47,10 +47,11 @@ Logs Specific Options:
 
   -n
     Sets the tail location in best-efforted number of lines relative to the end
-    of the logs.
+    of the logs. Implies -tail.
 
   -c
     Sets the tail location in number of bytes relative to the end of the logs.
+    Implies -tail.
 	`
 	return strings.TrimSpace(helpText)
 }
@@ -78,6 +79,11 @@ func (l *LogsCommand) Run(args []string) int {
 	}
 	args = flags.Args()
 
+	// Setting a tail location implies tailing the logs
+	if numLines != -1 || numBytes != -1 {
+		tail = true
+	}
+
 	if numArgs := len(args); numArgs < 1 {
 		if job {
 			l.Ui.Error("Job ID required. See help:\n")
@@ -164,6 +170,10 @@ func (l *LogsCommand) Run(args []string) int {
 			l.Ui.Error("Task name required")
 			return 1
 		}
+		if !allocHasTask(alloc, task) {
+			l.Ui.Error(fmt.Sprintf("Could not find task named %q in allocation %q", task, limit(alloc.ID, length)))
+			return 1
+		}
 
 	} else {
 		// Try to determine the tasks name from the allocation
@@ -240,6 +250,25 @@ func (l *LogsCommand) Run(args []string) int {
 	return 0
 }
 
+// allocHasTask returns whether the task group of the allocation contains the
+// given task. If the allocation's job is unknown the task is assumed to exist.
+func allocHasTask(alloc *api.Allocation, task string) bool {
+	if alloc.Job == nil {
+		return true
+	}
+	for _, tg := range alloc.Job.TaskGroups {
+		if tg.Name != alloc.TaskGroup {
+			continue
+		}
+		for _, t := range tg.Tasks {
+			if t.Name == task {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // followFile outputs the contents of the file to stdout relative to the end of
 // the file.
 func (l *LogsCommand) followFile(client *api.Client, alloc *api.Allocation,
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -63,3 +64,25 @@ func TestLogsCommand_Fails(t *testing.T) {
 	}
 
 }
+
+func TestLogsCommand_AllocHasTask(t *testing.T) {
+	alloc := &api.Allocation{
+		TaskGroup: "group1",
+		Job: &api.Job{
+			TaskGroups: []*api.TaskGroup{
+				api.NewTaskGroup("group1", 1).AddTask(api.NewTask("web", "exec")),
+				api.NewTaskGroup("group2", 1).AddTask(api.NewTask("db", "exec")),
+			},
+		},
+	}
+
+	if !allocHasTask(alloc, "web") {
+		t.Fatalf("expected task web")
+	}
+	if allocHasTask(alloc, "db") {
+		t.Fatalf("task db belongs to a different group")
+	}
+	if allocHasTask(alloc, "nope") {
+		t.Fatalf("unexpected task nope")
+	}
+}
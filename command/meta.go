@@ -15,8 +15,10 @@ import (
 const (
 	// Names of environment variables used to supply various
 	// config options to the Nomad CLI.
-	EnvNomadAddress = "NOMAD_ADDR"
-	EnvNomadRegion  = "NOMAD_REGION"
+	EnvNomadAddress   = "NOMAD_ADDR"
+	EnvNomadRegion    = "NOMAD_REGION"
+	EnvNomadNamespace = "NOMAD_NAMESPACE"
+	EnvNomadToken     = "NOMAD_TOKEN"
 
 	// Constants for CLI identifier length
 	shortId = 8
@@ -47,6 +49,12 @@ type Meta struct {
 	// The region to send API requests
 	region string
 
+	// The namespace to scope API requests to
+	namespace string
+
+	// token is used for ACLs to access privileged information
+	token string
+
 	caCert     string
 	caPath     string
 	clientCert string
@@ -66,6 +74,7 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 	if fs&FlagSetClient != 0 {
 		f.StringVar(&m.flagAddress, "address", "", "")
 		f.StringVar(&m.region, "region", "", "")
+		f.StringVar(&m.namespace, "namespace", "", "")
 		f.BoolVar(&m.noColor, "no-color", false, "")
 		f.StringVar(&m.caCert, "ca-cert", "", "")
 		f.StringVar(&m.caPath, "ca-path", "", "")
@@ -73,6 +82,7 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 		f.StringVar(&m.clientKey, "client-key", "", "")
 		f.BoolVar(&m.insecure, "insecure", false, "")
 		f.BoolVar(&m.insecure, "tls-skip-verify", false, "")
+		f.StringVar(&m.token, "token", "", "")
 
 	}
 
@@ -95,6 +105,12 @@ func (m *Meta) FlagSet(n string, fs FlagSetFlags) *flag.FlagSet {
 // Client is used to initialize and return a new API client using
 // the default command line arguments and env vars.
 func (m *Meta) Client() (*api.Client, error) {
+	return api.NewClient(m.clientConfig())
+}
+
+// clientConfig returns the configuration of the API client set by the
+// default command line arguments and env vars.
+func (m *Meta) clientConfig() *api.Config {
 	config := api.DefaultConfig()
 	if v := os.Getenv(EnvNomadAddress); v != "" {
 		config.Address = v
@@ -108,6 +124,18 @@ func (m *Meta) Client() (*api.Client, error) {
 	if m.region != "" {
 		config.Region = m.region
 	}
+	if v := os.Getenv(EnvNomadNamespace); v != "" {
+		config.Namespace = v
+	}
+	if m.namespace != "" {
+		config.Namespace = m.namespace
+	}
+	if v := os.Getenv(EnvNomadToken); v != "" {
+		config.SecretID = v
+	}
+	if m.token != "" {
+		config.SecretID = m.token
+	}
 	// If we need custom TLS configuration, then set it
 	if m.caCert != "" || m.caPath != "" || m.clientCert != "" || m.clientKey != "" || m.insecure {
 		t := &api.TLSConfig{
@@ -120,7 +148,7 @@ func (m *Meta) Client() (*api.Client, error) {
 		config.TLSConfig = t
 	}
 
-	return api.NewClient(config)
+	return config
 }
 
 func (m *Meta) Colorize() *colorstring.Colorize {
@@ -135,7 +163,8 @@ func (m *Meta) Colorize() *colorstring.Colorize {
 func generalOptionsUsage() string {
 	helpText := `
   -address=<addr>
-    The address of the Nomad server.
+    The address of the Nomad server, or unix:///path/to/socket for a
+    Unix domain socket.
     Overrides the NOMAD_ADDR environment variable if set.
     Default = http://127.0.0.1:4646
 
@@ -143,6 +172,13 @@ func generalOptionsUsage() string {
     The region of the Nomad servers to forward commands to.
     Overrides the NOMAD_REGION environment variable if set.
     Defaults to the Agent's local region.
+
+  -namespace=<namespace>
+    The namespace to scope the commands to. Jobs that do not specify a
+    namespace are registered in it, and lists only show its objects. Use
+    "*" to list the objects of all namespaces. Overrides the NOMAD_NAMESPACE
+    environment variable if set. Defaults to listing all namespaces and
+    registering jobs in the "default" namespace.
   
   -no-color
     Disables colored command output.
@@ -171,6 +207,10 @@ func generalOptionsUsage() string {
   -tls-skip-verify        
     Do not verify TLS certificate. This is highly not recommended. Verification
     will also be skipped if NOMAD_SKIP_VERIFY is set.
+
+  -token
+    The SecretID of an ACL token to use to authenticate API requests with.
+    Overrides the NOMAD_TOKEN environment variable if set.
 `
 	return strings.TrimSpace(helpText)
 }
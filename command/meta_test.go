@@ -2,6 +2,7 @@ package command
 
 import (
 	"flag"
+	"os"
 	"reflect"
 	"sort"
 	"testing"
@@ -22,12 +23,14 @@ func TestMeta_FlagSet(t *testing.T) {
 				"address",
 				"no-color",
 				"region",
+				"namespace",
 				"ca-cert",
 				"ca-path",
 				"client-cert",
 				"client-key",
 				"insecure",
 				"tls-skip-verify",
+				"token",
 			},
 		},
 	}
@@ -49,3 +52,19 @@ func TestMeta_FlagSet(t *testing.T) {
 		}
 	}
 }
+
+func TestMeta_ClientConfig_Namespace(t *testing.T) {
+	os.Setenv(EnvNomadNamespace, "foo")
+	defer os.Unsetenv(EnvNomadNamespace)
+
+	var m Meta
+	if config := m.clientConfig(); config.Namespace != "foo" {
+		t.Fatalf("bad: %q", config.Namespace)
+	}
+
+	// The flag overrides the environment variable
+	m.namespace = "*"
+	if config := m.clientConfig(); config.Namespace != "*" {
+		t.Fatalf("bad: %q", config.Namespace)
+	}
+}
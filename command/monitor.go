@@ -1,6 +1,8 @@
 package command
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
@@ -9,25 +11,37 @@ import (
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/mitchellh/cli"
+	"github.com/mitchellh/colorstring"
 )
 
 const (
-	// updateWait is the amount of time to wait between status
-	// updates. Because the monitor is poll-based, we use this
-	// delay to avoid overwhelming the API server.
+	// updateWait is the maximum amount of time a blocking query
+	// for the evaluation waits for a change. Bounding the wait
+	// ensures allocation updates are still displayed while the
+	// evaluation itself is unchanged.
 	updateWait = time.Second
 )
 
 // evalState is used to store the current "state of the world"
 // in the context of monitoring an evaluation.
 type evalState struct {
+	id     string
 	status string
 	desc   string
 	node   string
 	job    string
+	deploy string
 	allocs map[string]*allocState
 	wait   time.Duration
 	index  uint64
+
+	// blocked is the blocked evaluation created to place the remaining
+	// allocations once capacity is available.
+	blocked string
+
+	// groups summarizes the placements of each task group of the job. It
+	// is only set once the evaluation reached a terminal status.
+	groups map[string]*groupState
 }
 
 // newEvalState creates and initializes a new monitorState
@@ -49,12 +63,100 @@ type allocState struct {
 	clientDesc  string
 	index       uint64
 
+	// preempted are the allocations preempted to make room for the
+	// allocation.
+	preempted []string
+
 	// full is the allocation struct with full details. This
 	// must be queried for explicitly so it is only included
 	// if there is important error information inside.
 	full *api.Allocation
 }
 
+// allocTransition is a change of the client status of allocations
+type allocTransition struct {
+	from string
+	to   string
+}
+
+// groupState is used to track the placements of a task group
+type groupState struct {
+	// desired is the number of allocations the job asks for.
+	desired int
+
+	// placed is the number of allocations of the job that are running or
+	// about to be started.
+	placed int
+
+	// queued is the number of allocations the scheduler could not place.
+	queued int
+}
+
+// Exit codes returned by the monitor, and so by the commands monitoring
+// evaluations. They let scripts tell the outcomes of a monitor apart.
+const (
+	// monitorExitSuccess is returned when the evaluations completed and all
+	// the allocations were placed.
+	monitorExitSuccess = 0
+
+	// monitorExitError is returned on any other error, such as API
+	// connectivity issues or internal errors.
+	monitorExitError = 1
+
+	// monitorExitPlacementFailure is returned when allocations could not be
+	// placed, e.g. because of unsatisfiable constraints or exhausted
+	// resources.
+	monitorExitPlacementFailure = 2
+
+	// monitorExitTimeout is returned when the timeout of the monitor expired
+	// before the evaluations finished. The evaluations are not affected and
+	// keep being processed by the servers.
+	monitorExitTimeout = 3
+)
+
+// Monitor event types emitted when the monitor is in JSON mode.
+const (
+	monitorEventEvalMonitor     = "EvalMonitor"
+	monitorEventEvalTriggered   = "EvalTriggered"
+	monitorEventEvalStatus      = "EvalStatus"
+	monitorEventEvalFinished    = "EvalFinished"
+	monitorEventEvalBlocked     = "EvalBlocked"
+	monitorEventEvalNext        = "EvalNext"
+	monitorEventRollingBatch    = "RollingBatch"
+	monitorEventDeployment      = "Deployment"
+	monitorEventAllocCreated    = "AllocCreated"
+	monitorEventAllocModified   = "AllocModified"
+	monitorEventAllocStatus     = "AllocStatus"
+	monitorEventAllocPreempted  = "AllocPreempted"
+	monitorEventPlacementFailed = "PlacementFailed"
+	monitorEventPlacementQueued = "PlacementQueued"
+	monitorEventGroupSummary    = "GroupSummary"
+	monitorEventTimeout         = "Timeout"
+)
+
+// monitorEvent is a single state transition observed by the monitor. In
+// JSON mode each event is written to the ui as a single line object so
+// that callers can parse the progress of an evaluation.
+type monitorEvent struct {
+	Type           string
+	EvalID         string                `json:",omitempty"`
+	AllocID        string                `json:",omitempty"`
+	NodeID         string                `json:",omitempty"`
+	JobID          string                `json:",omitempty"`
+	DeploymentID   string                `json:",omitempty"`
+	TaskGroup      string                `json:",omitempty"`
+	Status         string                `json:",omitempty"`
+	PreviousStatus string                `json:",omitempty"`
+	Description    string                `json:",omitempty"`
+	Wait           time.Duration         `json:",omitempty"`
+	Metrics        *api.AllocationMetric `json:",omitempty"`
+	ExitCode       int                   `json:",omitempty"`
+	Desired        *int                  `json:",omitempty"`
+	Placed         *int                  `json:",omitempty"`
+	Queued         *int                  `json:",omitempty"`
+	Message        string
+}
+
 // monitor wraps an evaluation monitor and holds metadata and
 // state information.
 type monitor struct {
@@ -62,9 +164,46 @@ type monitor struct {
 	client *api.Client
 	state  *evalState
 
+	// rawUi is the ui without any prefixing applied. It is used to write
+	// events when the monitor is in JSON mode.
+	rawUi cli.Ui
+
 	// length determines the number of characters for identifiers in the ui.
 	length int
 
+	// json causes the monitor to emit each event as a JSON object instead
+	// of human readable text.
+	json bool
+
+	// quiet suppresses the progress of the evaluations so that only the
+	// final status and exit code are written.
+	quiet bool
+
+	// verbose outputs every allocation transition instead of coalescing the
+	// ones observed at once.
+	verbose bool
+
+	// color is used to highlight failures in the human readable output.
+	color *colorstring.Colorize
+
+	// batch counts the batches of a rolling update that have been placed.
+	batch int
+
+	// deployment is the deployment the monitored evaluations belong to. It
+	// is kept across the chained evaluations of a rollout.
+	deployment string
+
+	// timeout aborts the monitoring once expired. Zero waits until the
+	// evaluations finish. With a timeout, the blocked evaluation created when
+	// not all allocations could be placed is monitored until they are or the
+	// timeout expires. Otherwise the blocked evaluation is only reported.
+	timeout time.Duration
+
+	// deadline is when the timeout expires. It is set by the first call to
+	// monitor so that it is shared by the chained evaluations, and may be set
+	// beforehand to share it between monitors.
+	deadline time.Time
+
 	sync.Mutex
 }
 
@@ -79,13 +218,86 @@ func newMonitor(ui cli.Ui, client *api.Client, length int) *monitor {
 			ErrorPrefix:  "==> ",
 			Ui:           ui,
 		},
+		rawUi:  ui,
 		client: client,
 		state:  newEvalState(),
 		length: length,
+		color: &colorstring.Colorize{
+			Colors:  colorstring.DefaultColors,
+			Disable: true,
+		},
 	}
 	return mon
 }
 
+// output writes an event to the ui. In JSON mode the event is serialized,
+// otherwise the human readable message is written as regular output.
+func (m *monitor) output(ev *monitorEvent) {
+	if m.quiet {
+		return
+	}
+	if m.json {
+		m.outputJSON(ev)
+		return
+	}
+	m.ui.Output(ev.Message)
+}
+
+// info writes an event to the ui. In JSON mode the event is serialized,
+// otherwise the human readable message is written as an info line.
+func (m *monitor) info(ev *monitorEvent) {
+	if m.quiet {
+		return
+	}
+	m.finish(ev)
+}
+
+// finish writes an event as an info line regardless of quiet mode. It is
+// used for the final status of the monitored evaluations.
+func (m *monitor) finish(ev *monitorEvent) {
+	if m.json {
+		m.outputJSON(ev)
+		return
+	}
+	m.ui.Info(ev.Message)
+}
+
+// failure highlights a message describing a failure. Messages are written
+// unchanged when coloring is disabled.
+func (m *monitor) failure(msg string) string {
+	if m.json {
+		return msg
+	}
+	return m.color.Color("[bold][red]" + msg + "[reset]")
+}
+
+// timedOut reports that the timeout expired while the evaluation was being
+// processed, and returns the exit code for it.
+func (m *monitor) timedOut(evalID string) int {
+	message := fmt.Sprintf("Monitoring timed out after %s, evaluation %q continues in the background",
+		m.timeout, limit(evalID, m.length))
+	if m.quiet && !m.json {
+		message += fmt.Sprintf(" (exit code %d)", monitorExitTimeout)
+	}
+	m.finish(&monitorEvent{
+		Type:     monitorEventTimeout,
+		EvalID:   evalID,
+		ExitCode: monitorExitTimeout,
+		Message:  m.failure(message),
+	})
+	return monitorExitTimeout
+}
+
+// outputJSON serializes an event as a single line JSON object.
+func (m *monitor) outputJSON(ev *monitorEvent) {
+	buf, err := json.Marshal(ev)
+	if err != nil {
+		m.ui.Error(fmt.Sprintf("Error encoding monitor event: %s", err))
+		return
+	}
+	m.rawUi.Output(string(buf))
+}
+
 // update is used to update our monitor with new state. It can be
 // called whether the passed information is new or not, and will
 // only dump update messages when state changes.
@@ -102,67 +314,286 @@ func (m *monitor) update(update *evalState) {
 
 	// Check if the evaluation was triggered by a node
 	if existing.node == "" && update.node != "" {
-		m.ui.Output(fmt.Sprintf("Evaluation triggered by node %q",
-			limit(update.node, m.length)))
+		m.output(&monitorEvent{
+			Type:    monitorEventEvalTriggered,
+			EvalID:  update.id,
+			NodeID:  update.node,
+			Message: fmt.Sprintf("Evaluation triggered by node %q", limit(update.node, m.length)),
+		})
 	}
 
 	// Check if the evaluation was triggered by a job
 	if existing.job == "" && update.job != "" {
-		m.ui.Output(fmt.Sprintf("Evaluation triggered by job %q", update.job))
+		m.output(&monitorEvent{
+			Type:    monitorEventEvalTriggered,
+			EvalID:  update.id,
+			JobID:   update.job,
+			Message: fmt.Sprintf("Evaluation triggered by job %q", update.job),
+		})
+	}
+
+	// Check if the evaluation started or continued a deployment
+	if update.deploy != "" && update.deploy != m.deployment {
+		m.deployment = update.deploy
+		m.output(&monitorEvent{
+			Type:         monitorEventDeployment,
+			EvalID:       update.id,
+			JobID:        update.job,
+			DeploymentID: update.deploy,
+			Message: fmt.Sprintf("Evaluation within deployment: %q",
+				limit(update.deploy, m.length)),
+		})
 	}
 
-	// Check the allocations
+	// Check the allocations. Unless verbose, the allocations created and the
+	// identical status changes observed by the same update are coalesced
+	// into a single line each to reduce the noise of large jobs.
+	coalesce := !m.verbose && !m.json
+	created := make(map[string][]*allocState)
+	changed := make(map[allocTransition][]*allocState)
 	for allocID, alloc := range update.allocs {
 		if existing, ok := existing.allocs[allocID]; !ok {
 			switch {
 			case alloc.index < update.index:
 				// New alloc with create index lower than the eval
 				// create index indicates modification
-				m.ui.Output(fmt.Sprintf(
-					"Allocation %q modified: node %q, group %q",
-					limit(alloc.id, m.length), limit(alloc.node, m.length), alloc.group))
+				m.output(&monitorEvent{
+					Type:      monitorEventAllocModified,
+					EvalID:    update.id,
+					AllocID:   alloc.id,
+					NodeID:    alloc.node,
+					TaskGroup: alloc.group,
+					Status:    alloc.client,
+					Message: fmt.Sprintf("Allocation %q modified: node %q, group %q",
+						limit(alloc.id, m.length), limit(alloc.node, m.length), alloc.group),
+				})
 
 			case alloc.desired == structs.AllocDesiredStatusRun:
 				// New allocation with desired status running
-				m.ui.Output(fmt.Sprintf(
-					"Allocation %q created: node %q, group %q",
-					limit(alloc.id, m.length), limit(alloc.node, m.length), alloc.group))
-			}
-		} else {
-			switch {
-			case existing.client != alloc.client:
-				description := ""
-				if alloc.clientDesc != "" {
-					description = fmt.Sprintf(" (%s)", alloc.clientDesc)
+				if coalesce {
+					created[alloc.group] = append(created[alloc.group], alloc)
+				} else {
+					m.output(m.allocCreated(update.id, alloc))
+
+					// Show the transitions the allocation went through
+					// before it was first observed
+					if m.verbose && alloc.client != "" &&
+						alloc.client != structs.AllocClientStatusPending {
+						m.output(m.allocStatus(update.id, alloc, structs.AllocClientStatusPending))
+					}
+				}
+
+				// Report the allocations preempted by the new allocation
+				for _, preempted := range alloc.preempted {
+					m.output(&monitorEvent{
+						Type:    monitorEventAllocPreempted,
+						EvalID:  update.id,
+						AllocID: preempted,
+						NodeID:  alloc.node,
+						Message: fmt.Sprintf("Allocation %q preempted by allocation %q",
+							limit(preempted, m.length), limit(alloc.id, m.length)),
+					})
 				}
-				// Allocation status has changed
-				m.ui.Output(fmt.Sprintf(
-					"Allocation %q status changed: %q -> %q%s",
-					limit(alloc.id, m.length), existing.client, alloc.client, description))
 			}
+		} else if existing.client != alloc.client {
+			// Allocation status has changed
+			if coalesce {
+				transition := allocTransition{from: existing.client, to: alloc.client}
+				changed[transition] = append(changed[transition], alloc)
+			} else {
+				m.output(m.allocStatus(update.id, alloc, existing.client))
+			}
+		}
+	}
+
+	// Output the coalesced allocations
+	for _, group := range sortedTaskGroupFromAllocs(created) {
+		allocs := created[group]
+		if len(allocs) == 1 {
+			m.output(m.allocCreated(update.id, allocs[0]))
+			continue
+		}
+		m.output(&monitorEvent{
+			Type:      monitorEventAllocCreated,
+			EvalID:    update.id,
+			TaskGroup: group,
+			Message:   fmt.Sprintf("%d allocations created: group %q", len(allocs), group),
+		})
+	}
+	for _, transition := range sortedAllocTransitions(changed) {
+		allocs := changed[transition]
+		if len(allocs) == 1 {
+			m.output(m.allocStatus(update.id, allocs[0], transition.from))
+			continue
+		}
+		message := fmt.Sprintf("%d allocations status changed: %q -> %q",
+			len(allocs), transition.from, transition.to)
+		if transition.to == structs.AllocClientStatusFailed ||
+			transition.to == structs.AllocClientStatusLost {
+			message = m.failure(message)
 		}
+		m.output(&monitorEvent{
+			Type:           monitorEventAllocStatus,
+			EvalID:         update.id,
+			Status:         transition.to,
+			PreviousStatus: transition.from,
+			Message:        message,
+		})
 	}
 
 	// Check if the status changed. We skip any transitions to pending status.
 	if existing.status != "" &&
 		update.status != structs.AllocClientStatusPending &&
 		existing.status != update.status {
-		m.ui.Output(fmt.Sprintf("Evaluation status changed: %q -> %q",
-			existing.status, update.status))
+		message := fmt.Sprintf("Evaluation status changed: %q -> %q",
+			existing.status, update.status)
+		if update.status == structs.EvalStatusFailed {
+			message = m.failure(message)
+		}
+		m.output(&monitorEvent{
+			Type:           monitorEventEvalStatus,
+			EvalID:         update.id,
+			Status:         update.status,
+			PreviousStatus: existing.status,
+			Description:    update.desc,
+			Message:        message,
+		})
+	}
+
+	// Check the placements of the task groups
+	for _, tg := range sortedTaskGroupFromGroups(update.groups) {
+		group := update.groups[tg]
+		if prev, ok := existing.groups[tg]; ok && *prev == *group {
+			continue
+		}
+
+		message := fmt.Sprintf("Task Group %q: %d desired, %d placed, %d queued",
+			tg, group.desired, group.placed, group.queued)
+		switch {
+		case group.queued > 0 && update.blocked != "":
+			message += " (waiting for additional capacity)"
+		case group.queued > 0:
+			message = m.failure(message + " (placement failed)")
+		}
+		desired, placed, queued := group.desired, group.placed, group.queued
+		m.output(&monitorEvent{
+			Type:      monitorEventGroupSummary,
+			EvalID:    update.id,
+			JobID:     update.job,
+			TaskGroup: tg,
+			Desired:   &desired,
+			Placed:    &placed,
+			Queued:    &queued,
+			Message:   message,
+		})
+	}
+}
+
+// allocCreated returns the event of the creation of the allocation
+func (m *monitor) allocCreated(evalID string, alloc *allocState) *monitorEvent {
+	return &monitorEvent{
+		Type:      monitorEventAllocCreated,
+		EvalID:    evalID,
+		AllocID:   alloc.id,
+		NodeID:    alloc.node,
+		TaskGroup: alloc.group,
+		Status:    alloc.client,
+		Message: fmt.Sprintf("Allocation %q created: node %q, group %q",
+			limit(alloc.id, m.length), limit(alloc.node, m.length), alloc.group),
+	}
+}
+
+// allocStatus returns the event of the change of the client status of the
+// allocation from the previous status
+func (m *monitor) allocStatus(evalID string, alloc *allocState, previous string) *monitorEvent {
+	description := ""
+	if alloc.clientDesc != "" {
+		description = fmt.Sprintf(" (%s)", alloc.clientDesc)
+	}
+	message := fmt.Sprintf("Allocation %q status changed: %q -> %q%s",
+		limit(alloc.id, m.length), previous, alloc.client, description)
+	if alloc.client == structs.AllocClientStatusFailed ||
+		alloc.client == structs.AllocClientStatusLost {
+		message = m.failure(message)
+	}
+
+	return &monitorEvent{
+		Type:           monitorEventAllocStatus,
+		EvalID:         evalID,
+		AllocID:        alloc.id,
+		NodeID:         alloc.node,
+		TaskGroup:      alloc.group,
+		Status:         alloc.client,
+		PreviousStatus: previous,
+		Description:    alloc.clientDesc,
+		Message:        message,
 	}
 }
 
+// groupStates returns the desired, placed and queued allocations of each task
+// group of the job of the evaluation. The summary is informational only, so
+// nil is returned if the job can't be queried.
+func (m *monitor) groupStates(ctx context.Context, eval *api.Evaluation) map[string]*groupState {
+	if eval.JobID == "" {
+		return nil
+	}
+	q := (&api.QueryOptions{}).WithContext(ctx)
+	job, _, err := m.client.Jobs().Info(eval.JobID, q)
+	if err != nil {
+		return nil
+	}
+	allocs, _, err := m.client.Jobs().Allocations(eval.JobID, q)
+	if err != nil {
+		return nil
+	}
+
+	groups := make(map[string]*groupState, len(job.TaskGroups))
+	for _, tg := range job.TaskGroups {
+		groups[tg.Name] = &groupState{
+			desired: tg.Count,
+			queued:  eval.QueuedAllocations[tg.Name],
+		}
+	}
+	for _, alloc := range allocs {
+		group, ok := groups[alloc.TaskGroup]
+		if !ok || alloc.DesiredStatus != structs.AllocDesiredStatusRun {
+			continue
+		}
+		switch alloc.ClientStatus {
+		case structs.AllocClientStatusPending, structs.AllocClientStatusRunning:
+			group.placed++
+		}
+	}
+	return groups
+}
+
 // monitor is used to start monitoring the given evaluation ID. It
 // writes output directly to the monitor's ui, and returns the
 // exit code for the command. If allowPrefix is false, monitor will only accept
 // exact matching evalIDs.
 //
+// The follow-up evaluations of a rolling update are monitored until the last
+// one reaches a terminal state. Blocked evaluations created because of
+// exhausted resources are only monitored until the deadline, if any.
+//
 // The return code will be 0 on successful evaluation. If there are
 // problems scheduling the job (impossible constraints, resources
 // exhausted, etc), then the return code will be 2. For any other
 // failures (API connectivity, internal errors, etc), the return code
-// will be 1.
+// will be 1. If the timeout of the monitor expires before the
+// evaluations finish, the return code will be 3.
 func (m *monitor) monitor(evalID string, allowPrefix bool) int {
+	// Bound the queries of the evaluations by the timeout
+	if m.timeout > 0 && m.deadline.IsZero() {
+		m.deadline = time.Now().Add(m.timeout)
+	}
+	ctx := context.Background()
+	if !m.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, m.deadline)
+		defer cancel()
+	}
+
 	// Track if we encounter a scheduling failure. This can only be
 	// detected while querying allocations, so we use this bool to
 	// carry that status into the return code.
@@ -173,20 +604,36 @@ func (m *monitor) monitor(evalID string, allowPrefix bool) int {
 	// variable to keep track if we've already written the header message.
 	var headerWritten bool
 
+	// waitIndex is the index of the last observed evaluation. It is used
+	// to issue blocking queries so that changes are seen as soon as they
+	// happen without repeatedly polling the servers.
+	var waitIndex uint64
+
+	// finished is the final status of the evaluation, written last when
+	// the monitor is in quiet mode.
+	var finished *monitorEvent
+
 	// Add the initial pending state
 	m.update(newEvalState())
 
 	for {
-		// Query the evaluation
-		eval, _, err := m.client.Evaluations().Info(evalID, nil)
+		// Query the evaluation, blocking until it changes
+		q := &api.QueryOptions{
+			WaitIndex: waitIndex,
+			WaitTime:  updateWait,
+		}
+		eval, meta, err := m.client.Evaluations().Info(evalID, q.WithContext(ctx))
 		if err != nil {
+			if ctx.Err() != nil {
+				return m.timedOut(evalID)
+			}
 			if !allowPrefix {
 				m.ui.Error(fmt.Sprintf("No evaluation with id %q found", evalID))
-				return 1
+				return monitorExitError
 			}
 			if len(evalID) == 1 {
 				m.ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
-				return 1
+				return monitorExitError
 			}
 			if len(evalID)%2 == 1 {
 				// Identifiers must be of even length, so we strip off the last byte
@@ -197,11 +644,11 @@ func (m *monitor) monitor(evalID string, allowPrefix bool) int {
 			evals, _, err := m.client.Evaluations().PrefixList(evalID)
 			if err != nil {
 				m.ui.Error(fmt.Sprintf("Error reading evaluation: %s", err))
-				return 1
+				return monitorExitError
 			}
 			if len(evals) == 0 {
 				m.ui.Error(fmt.Sprintf("No evaluation(s) with prefix or id %q found", evalID))
-				return 1
+				return monitorExitError
 			}
 			if len(evals) > 1 {
 				// Format the evaluations
@@ -216,38 +663,52 @@ func (m *monitor) monitor(evalID string, allowPrefix bool) int {
 						eval.Status)
 				}
 				m.ui.Output(fmt.Sprintf("Prefix matched multiple evaluations\n\n%s", formatList(out)))
-				return 0
+				return monitorExitSuccess
 			}
 			// Prefix lookup matched a single evaluation
-			eval, _, err = m.client.Evaluations().Info(evals[0].ID, nil)
+			eval, meta, err = m.client.Evaluations().Info(evals[0].ID, nil)
 			if err != nil {
 				m.ui.Error(fmt.Sprintf("Error reading evaluation: %s", err))
+				return monitorExitError
 			}
+
+			// Avoid the prefix lookup on subsequent queries
+			evalID = eval.ID
 		}
+		waitIndex = meta.LastIndex
 
 		if !headerWritten {
-			m.ui.Info(fmt.Sprintf("Monitoring evaluation %q", limit(eval.ID, m.length)))
+			m.info(&monitorEvent{
+				Type:    monitorEventEvalMonitor,
+				EvalID:  eval.ID,
+				Message: fmt.Sprintf("Monitoring evaluation %q", limit(eval.ID, m.length)),
+			})
 			headerWritten = true
 		}
 
 		// Create the new eval state.
 		state := newEvalState()
+		state.id = eval.ID
 		state.status = eval.Status
 		state.desc = eval.StatusDescription
 		state.node = eval.NodeID
 		state.job = eval.JobID
 		state.wait = eval.Wait
 		state.index = eval.CreateIndex
+		state.blocked = eval.BlockedEval
 
 		// Query the allocations associated with the evaluation
 		allocs, _, err := m.client.Evaluations().Allocations(eval.ID, nil)
 		if err != nil {
 			m.ui.Error(fmt.Sprintf("Error reading allocations: %s", err))
-			return 1
+			return monitorExitError
 		}
 
 		// Add the allocs to the state
 		for _, alloc := range allocs {
+			if alloc.DeploymentID != "" {
+				state.deploy = alloc.DeploymentID
+			}
 			state.allocs[alloc.ID] = &allocState{
 				id:          alloc.ID,
 				group:       alloc.TaskGroup,
@@ -257,72 +718,154 @@ func (m *monitor) monitor(evalID string, allowPrefix bool) int {
 				client:      alloc.ClientStatus,
 				clientDesc:  alloc.ClientDescription,
 				index:       alloc.CreateIndex,
+				preempted:   alloc.PreemptedAllocations,
 			}
 		}
 
+		// Summarize the placements of the job once the scheduler is done
+		switch eval.Status {
+		case structs.EvalStatusComplete, structs.EvalStatusFailed, structs.EvalStatusCancelled:
+			state.groups = m.groupStates(ctx, eval)
+		}
+
 		// Update the state
 		m.update(state)
 
 		switch eval.Status {
 		case structs.EvalStatusComplete, structs.EvalStatusFailed, structs.EvalStatusCancelled:
+			finished = &monitorEvent{
+				Type:        monitorEventEvalFinished,
+				EvalID:      eval.ID,
+				Status:      eval.Status,
+				Description: eval.StatusDescription,
+				Message: fmt.Sprintf("Evaluation %q finished with status %q",
+					limit(eval.ID, m.length), eval.Status),
+			}
+			if eval.Status == structs.EvalStatusFailed {
+				finished.Message = m.failure(finished.Message)
+			}
+
 			if len(eval.FailedTGAllocs) == 0 {
-				m.ui.Info(fmt.Sprintf("Evaluation %q finished with status %q",
-					limit(eval.ID, m.length), eval.Status))
+				m.info(finished)
 			} else {
 				// There were failures making the allocations
 				schedFailure = true
-				m.ui.Info(fmt.Sprintf("Evaluation %q finished with status %q but failed to place all allocations:",
-					limit(eval.ID, m.length), eval.Status))
+				message := fmt.Sprintf("Evaluation %q finished with status %q but failed to place all allocations",
+					limit(eval.ID, m.length), eval.Status)
+				finished.Message = m.failure(message)
+
+				header := *finished
+				header.Message = m.failure(message + ":")
+				m.info(&header)
 
 				// Print the failures per task group
-				for tg, metrics := range eval.FailedTGAllocs {
-					noun := "allocation"
-					if metrics.CoalescedFailures > 0 {
-						noun += "s"
+				for _, tg := range sortedTaskGroupFromMetrics(eval.FailedTGAllocs) {
+					if m.quiet {
+						break
 					}
-					m.ui.Output(fmt.Sprintf("Task Group %q (failed to place %d %s):", tg, metrics.CoalescedFailures+1, noun))
-					metrics := formatAllocMetrics(metrics, false, "  ")
-					for _, line := range strings.Split(metrics, "\n") {
+					metrics := eval.FailedTGAllocs[tg]
+					if m.json {
+						m.outputJSON(&monitorEvent{
+							Type:      monitorEventPlacementFailed,
+							EvalID:    eval.ID,
+							TaskGroup: tg,
+							Metrics:   metrics,
+							Message:   formatPlacementFailureHeader(tg, metrics),
+						})
+						continue
+					}
+
+					m.ui.Output(m.failure(formatPlacementFailureHeader(tg, metrics)))
+					for _, line := range strings.Split(strings.TrimSuffix(formatAllocMetrics(metrics, false, "  "), "\n"), "\n") {
 						m.ui.Output(line)
 					}
 				}
 
 				if eval.BlockedEval != "" {
-					m.ui.Output(fmt.Sprintf("Evaluation %q waiting for additional capacity to place remainder",
-						limit(eval.BlockedEval, m.length)))
+					// Report the placements left queued on the blocked eval
+					for _, tg := range sortedTaskGroupFromQueued(eval.QueuedAllocations) {
+						m.output(&monitorEvent{
+							Type:      monitorEventPlacementQueued,
+							EvalID:    eval.ID,
+							TaskGroup: tg,
+							Message: fmt.Sprintf("Task Group %q has %d queued allocation(s)",
+								tg, eval.QueuedAllocations[tg]),
+						})
+					}
+
+					m.output(&monitorEvent{
+						Type:   monitorEventEvalBlocked,
+						EvalID: eval.BlockedEval,
+						Message: fmt.Sprintf("Evaluation %q waiting for additional capacity to place remainder",
+							limit(eval.BlockedEval, m.length)),
+					})
 				}
 			}
 		default:
-			// Wait for the next update
-			time.Sleep(updateWait)
+			// The next query blocks until the evaluation changes
 			continue
 		}
 
-		// Monitor the next eval in the chain, if present
+		// Monitor the next eval in the chain, if present. Evaluations are
+		// only chained while a rolling update is in progress.
 		if eval.NextEval != "" {
+			m.batch++
+			m.info(&monitorEvent{
+				Type:   monitorEventRollingBatch,
+				EvalID: eval.ID,
+				JobID:  eval.JobID,
+				Message: fmt.Sprintf("Rolling update batch %d placed %d allocation(s), waiting for the next batch",
+					m.batch, len(allocs)),
+			})
+
 			if eval.Wait.Nanoseconds() != 0 {
-				m.ui.Info(fmt.Sprintf(
-					"Monitoring next evaluation %q in %s",
-					limit(eval.NextEval, m.length), eval.Wait))
+				m.info(&monitorEvent{
+					Type:   monitorEventEvalNext,
+					EvalID: eval.NextEval,
+					Wait:   eval.Wait,
+					Message: fmt.Sprintf("Monitoring next evaluation %q in %s",
+						limit(eval.NextEval, m.length), eval.Wait),
+				})
 
 				// Skip some unnecessary polling
-				time.Sleep(eval.Wait)
+				select {
+				case <-ctx.Done():
+					return m.timedOut(eval.NextEval)
+				case <-time.After(eval.Wait):
+				}
 			}
 
 			// Reset the state and monitor the new eval
 			m.state = newEvalState()
 			return m.monitor(eval.NextEval, allowPrefix)
 		}
+
+		// Follow the blocked eval until the remaining allocations are
+		// placed when there is a deadline. The blocked eval is re-evaluated
+		// by the servers once capacity becomes available.
+		if eval.BlockedEval != "" && !m.deadline.IsZero() {
+			m.state = newEvalState()
+			return m.monitor(eval.BlockedEval, allowPrefix)
+		}
 		break
 	}
 
 	// Treat scheduling failures specially using a dedicated exit code.
 	// This makes it easier to detect failures from the CLI.
+	code := monitorExitSuccess
 	if schedFailure {
-		return 2
+		code = monitorExitPlacementFailure
 	}
 
-	return 0
+	// In quiet mode only the final status of the last evaluation is written
+	if m.quiet && finished != nil {
+		finished.ExitCode = code
+		if !m.json {
+			finished.Message += fmt.Sprintf(" (exit code %d)", code)
+		}
+		m.finish(finished)
+	}
+	return code
 }
 
 // dumpAllocStatus is a helper to generate a more user-friendly error message
@@ -336,6 +879,22 @@ func dumpAllocStatus(ui cli.Ui, alloc *api.Allocation, length int) {
 	ui.Output(formatAllocMetrics(alloc.Metrics, true, "  "))
 }
 
+// formatPlacementFailureHeader returns the summary line describing how many
+// allocations of a task group failed to be placed.
+func formatPlacementFailureHeader(tg string, metrics *api.AllocationMetric) string {
+	noun := "allocation"
+	if metrics.CoalescedFailures > 0 {
+		noun += "s"
+	}
+	return fmt.Sprintf("Task Group %q (failed to place %d %s):", tg, metrics.CoalescedFailures+1, noun)
+}
+
+// formatPlacementFailure formats the placement failure of a task group along
+// with the reasons nodes were filtered or exhausted.
+func formatPlacementFailure(tg string, metrics *api.AllocationMetric) string {
+	return formatPlacementFailureHeader(tg, metrics) + "\n" + formatAllocMetrics(metrics, false, "  ")
+}
+
 func formatAllocMetrics(metrics *api.AllocationMetric, scores bool, prefix string) string {
 	// Print a helpful message if we have an eligibility problem
 	var out string
@@ -369,6 +928,9 @@ func formatAllocMetrics(metrics *api.AllocationMetric, scores bool, prefix strin
 	for dim, num := range metrics.DimensionExhausted {
 		out += fmt.Sprintf("%s* Dimension %q exhausted on %d nodes\n", prefix, dim, num)
 	}
+	for _, dim := range metrics.QuotaExhausted {
+		out += fmt.Sprintf("%s* Quota limit hit %q\n", prefix, dim)
+	}
 
 	// Print scores
 	if scores {
@@ -1,6 +1,8 @@
 package command
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -8,6 +10,7 @@ import (
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/mitchellh/cli"
+	"github.com/mitchellh/colorstring"
 )
 
 func TestMonitor_Update_Eval(t *testing.T) {
@@ -133,6 +136,125 @@ func TestMonitor_Update_Allocs(t *testing.T) {
 	}
 }
 
+func TestMonitor_Update_AllocsCoalesced(t *testing.T) {
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, nil, fullId)
+
+	// Allocations created at once are coalesced per group
+	allocs := make(map[string]*allocState)
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("%d7654321-abcd-efab-cdef-123456789abc", i)
+		allocs[id] = &allocState{
+			id:      id,
+			group:   "group1",
+			node:    "12345678-abcd-efab-cdef-123456789abc",
+			desired: structs.AllocDesiredStatusRun,
+			client:  structs.AllocClientStatusPending,
+			index:   1,
+		}
+	}
+	mon.update(&evalState{allocs: allocs})
+
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, `3 allocations created: group "group1"`) {
+		t.Fatalf("missing coalesced allocs\n\n%s", out)
+	}
+	if strings.Contains(out, "17654321") {
+		t.Fatalf("unexpected alloc\n\n%s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Identical status changes are coalesced as well
+	updated := make(map[string]*allocState)
+	for id, alloc := range allocs {
+		alloc := *alloc
+		alloc.client = structs.AllocClientStatusRunning
+		updated[id] = &alloc
+	}
+	updated["17654321-abcd-efab-cdef-123456789abc"].client = structs.AllocClientStatusFailed
+	mon.update(&evalState{allocs: updated})
+
+	out = ui.OutputWriter.String()
+	if !strings.Contains(out, `2 allocations status changed: "pending" -> "running"`) {
+		t.Fatalf("missing coalesced status\n\n%s", out)
+	}
+	if !strings.Contains(out, `Allocation "17654321-abcd-efab-cdef-123456789abc" status changed: "pending" -> "failed"`) {
+		t.Fatalf("missing failed alloc\n\n%s", out)
+	}
+}
+
+func TestMonitor_Update_AllocsVerbose(t *testing.T) {
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, nil, fullId)
+	mon.verbose = true
+
+	// Every allocation is shown, including the transitions it went through
+	// before it was first observed
+	mon.update(&evalState{
+		allocs: map[string]*allocState{
+			"alloc1": &allocState{
+				id:      "17654321-abcd-efab-cdef-123456789abc",
+				group:   "group1",
+				node:    "12345678-abcd-efab-cdef-123456789abc",
+				desired: structs.AllocDesiredStatusRun,
+				client:  structs.AllocClientStatusRunning,
+				index:   1,
+			},
+			"alloc2": &allocState{
+				id:      "27654321-abcd-efab-cdef-123456789abc",
+				group:   "group1",
+				node:    "12345678-abcd-efab-cdef-123456789abc",
+				desired: structs.AllocDesiredStatusRun,
+				client:  structs.AllocClientStatusPending,
+				index:   1,
+			},
+		},
+	})
+
+	out := ui.OutputWriter.String()
+	for _, expected := range []string{
+		`Allocation "17654321-abcd-efab-cdef-123456789abc" created`,
+		`Allocation "17654321-abcd-efab-cdef-123456789abc" status changed: "pending" -> "running"`,
+		`Allocation "27654321-abcd-efab-cdef-123456789abc" created`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("missing %q\n\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "allocations created") {
+		t.Fatalf("unexpected coalesced allocs\n\n%s", out)
+	}
+}
+
+func TestMonitor_Update_AllocPreempted(t *testing.T) {
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, nil, fullId)
+
+	// New allocations report the allocations they preempted
+	state := &evalState{
+		allocs: map[string]*allocState{
+			"alloc1": &allocState{
+				id:        "87654321-abcd-efab-cdef-123456789abc",
+				group:     "group1",
+				node:      "12345678-abcd-efab-cdef-123456789abc",
+				desired:   structs.AllocDesiredStatusRun,
+				client:    structs.AllocClientStatusPending,
+				index:     1,
+				preempted: []string{"11111111-abcd-efab-cdef-123456789abc"},
+			},
+		},
+	}
+	mon.update(state)
+
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "created") {
+		t.Fatalf("missing created\n\n%s", out)
+	}
+	if !strings.Contains(out, `Allocation "11111111-abcd-efab-cdef-123456789abc" preempted`) {
+		t.Fatalf("missing preemption\n\n%s", out)
+	}
+}
+
 func TestMonitor_Update_AllocModification(t *testing.T) {
 	ui := new(cli.MockUi)
 	mon := newMonitor(ui, nil, fullId)
@@ -168,6 +290,186 @@ func TestMonitor_Update_AllocModification(t *testing.T) {
 	}
 }
 
+func TestMonitor_Update_Deployment(t *testing.T) {
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, nil, fullId)
+
+	// An evaluation placing allocations of a deployment links to it
+	state := &evalState{
+		id:     "11111111-abcd-efab-cdef-123456789abc",
+		deploy: "22222222-abcd-efab-cdef-123456789abc",
+	}
+	mon.update(state)
+
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Evaluation within deployment: \"22222222-abcd-efab-cdef-123456789abc\"") {
+		t.Fatalf("missing deployment\n\n%s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// The next evaluation of the same deployment isn't logged again
+	mon.state = newEvalState()
+	mon.update(&evalState{
+		id:     "33333333-abcd-efab-cdef-123456789abc",
+		deploy: "22222222-abcd-efab-cdef-123456789abc",
+	})
+	if out := ui.OutputWriter.String(); strings.Contains(out, "deployment") {
+		t.Fatalf("unexpected output\n\n%s", out)
+	}
+}
+
+func TestMonitor_Update_Groups(t *testing.T) {
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, nil, fullId)
+
+	// The placements of the task groups are summarized
+	state := &evalState{
+		id:      "11111111-abcd-efab-cdef-123456789abc",
+		status:  structs.EvalStatusComplete,
+		blocked: "22222222-abcd-efab-cdef-123456789abc",
+		groups: map[string]*groupState{
+			"web": &groupState{desired: 3, placed: 1, queued: 2},
+			"db":  &groupState{desired: 1, placed: 1},
+		},
+	}
+	mon.update(state)
+
+	out := ui.OutputWriter.String()
+	expected := `Task Group "db": 1 desired, 1 placed, 0 queued`
+	if !strings.Contains(out, expected) {
+		t.Fatalf("missing %q\n\n%s", expected, out)
+	}
+	expected = `Task Group "web": 3 desired, 1 placed, 2 queued (waiting for additional capacity)`
+	if !strings.Contains(out, expected) {
+		t.Fatalf("missing %q\n\n%s", expected, out)
+	}
+	if strings.Index(out, `"db"`) > strings.Index(out, `"web"`) {
+		t.Fatalf("task groups not sorted\n\n%s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// No change yields no logs
+	mon.update(state)
+	if out := ui.OutputWriter.String(); out != "" {
+		t.Fatalf("expected no output\n\n%s", out)
+	}
+
+	// Queued placements without a blocked eval failed permanently
+	mon.state = newEvalState()
+	mon.update(&evalState{
+		id:     "11111111-abcd-efab-cdef-123456789abc",
+		status: structs.EvalStatusComplete,
+		groups: map[string]*groupState{
+			"web": &groupState{desired: 3, placed: 1, queued: 2},
+			"db":  &groupState{desired: 1, placed: 1},
+		},
+	})
+	out = ui.OutputWriter.String()
+	expected = `Task Group "web": 3 desired, 1 placed, 2 queued (placement failed)`
+	if !strings.Contains(out, expected) {
+		t.Fatalf("missing %q\n\n%s", expected, out)
+	}
+}
+
+func TestMonitor_Update_JSON(t *testing.T) {
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, nil, shortId)
+	mon.json = true
+
+	// New allocations emit a created event
+	state := &evalState{
+		id:     "11111111-abcd-efab-cdef-123456789abc",
+		status: structs.EvalStatusPending,
+		job:    "job1",
+		allocs: map[string]*allocState{
+			"alloc1": &allocState{
+				id:      "87654321-abcd-efab-cdef-123456789abc",
+				group:   "group1",
+				node:    "12345678-abcd-efab-cdef-123456789abc",
+				desired: structs.AllocDesiredStatusRun,
+				client:  structs.AllocClientStatusPending,
+				index:   1,
+			},
+		},
+	}
+	mon.update(state)
+
+	// Each event is a single JSON object per line
+	out := strings.TrimSpace(ui.OutputWriter.String())
+	lines := strings.Split(out, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 events, got %d\n\n%s", len(lines), out)
+	}
+
+	events := make(map[string]*monitorEvent)
+	for _, line := range lines {
+		var ev monitorEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("err: %v\n\n%s", err, line)
+		}
+		events[ev.Type] = &ev
+	}
+
+	if ev, ok := events[monitorEventEvalTriggered]; !ok || ev.JobID != "job1" {
+		t.Fatalf("bad trigger event: %#v", ev)
+	}
+	ev, ok := events[monitorEventAllocCreated]
+	if !ok {
+		t.Fatalf("missing alloc created event\n\n%s", out)
+	}
+
+	// Identifiers are never truncated in JSON mode
+	if ev.AllocID != "87654321-abcd-efab-cdef-123456789abc" {
+		t.Fatalf("bad alloc id: %q", ev.AllocID)
+	}
+	if ev.EvalID != state.id || ev.TaskGroup != "group1" {
+		t.Fatalf("bad event: %#v", ev)
+	}
+
+	// No human readable prefix is written
+	if strings.Contains(out, "    ") {
+		t.Fatalf("unexpected prefix\n\n%s", out)
+	}
+}
+
+func TestMonitor_Update_Color(t *testing.T) {
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, nil, fullId)
+	mon.color = &colorstring.Colorize{Colors: colorstring.DefaultColors}
+
+	alloc := &allocState{
+		id:      "87654321-abcd-efab-cdef-123456789abc",
+		group:   "group1",
+		node:    "12345678-abcd-efab-cdef-123456789abc",
+		desired: structs.AllocDesiredStatusRun,
+		client:  structs.AllocClientStatusRunning,
+		index:   1,
+	}
+	mon.update(&evalState{
+		status: structs.EvalStatusPending,
+		allocs: map[string]*allocState{"alloc1": alloc},
+	})
+	if out := ui.OutputWriter.String(); strings.Contains(out, "\033[") {
+		t.Fatalf("unexpected color\n\n%s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Failed allocations and evaluations are highlighted in red
+	failed := *alloc
+	failed.client = structs.AllocClientStatusFailed
+	mon.update(&evalState{
+		status: structs.EvalStatusFailed,
+		allocs: map[string]*allocState{"alloc1": &failed},
+	})
+	out := ui.OutputWriter.String()
+	if strings.Count(out, "\033[31m") != 2 {
+		t.Fatalf("missing failure color\n\n%s", out)
+	}
+	if !strings.Contains(out, alloc.id) {
+		t.Fatalf("missing alloc\n\n%s", out)
+	}
+}
+
 func TestMonitor_Monitor(t *testing.T) {
 	srv, client, _ := testServer(t, nil)
 	defer srv.Stop()
@@ -212,6 +514,27 @@ func TestMonitor_Monitor(t *testing.T) {
 	if !strings.Contains(out, "finished with status") {
 		t.Fatalf("missing final status\n\n%s", out)
 	}
+	if !strings.Contains(out, "queued allocation(s)") {
+		t.Fatalf("missing queued placements\n\n%s", out)
+	}
+	if !strings.Contains(out, `Task Group "group1": 1 desired, 0 placed, 1 queued (waiting for additional capacity)`) {
+		t.Fatalf("missing group summary\n\n%s", out)
+	}
+
+	// The blocked eval is reported but not followed
+	eval, _, err := client.Evaluations().Info(evalID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if eval.BlockedEval == "" {
+		t.Fatalf("expected blocked eval: %#v", eval)
+	}
+	if !strings.Contains(out, fmt.Sprintf("Evaluation %q waiting for additional capacity", eval.BlockedEval)) {
+		t.Fatalf("missing blocked eval\n\n%s", out)
+	}
+	if strings.Contains(out, fmt.Sprintf("Monitoring evaluation %q", eval.BlockedEval)) {
+		t.Fatalf("blocked eval followed\n\n%s", out)
+	}
 }
 
 func TestMonitor_MonitorWithPrefix(t *testing.T) {
@@ -282,6 +605,99 @@ func TestMonitor_MonitorWithPrefix(t *testing.T) {
 
 }
 
+func TestMonitor_Monitor_Timeout(t *testing.T) {
+	srv, client, _ := testServer(t, nil)
+	defer srv.Stop()
+
+	// Create the monitor
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, client, fullId)
+	mon.timeout = time.Second
+
+	// There are no clients on the test server, so the monitor follows the
+	// blocked eval until the timeout expires.
+	job := testJob("job1")
+	evalID, _, err := client.Jobs().Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var code int
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		code = mon.monitor(evalID, false)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("eval monitor didn't time out")
+	}
+	if code != monitorExitTimeout {
+		t.Fatalf("expect exit %d, got: %d", monitorExitTimeout, code)
+	}
+
+	// The evaluation keeps running on the servers
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Monitoring timed out after 1s") {
+		t.Fatalf("missing timeout\n\n%s", out)
+	}
+	eval, _, err := client.Evaluations().Info(evalID, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if eval.BlockedEval == "" {
+		t.Fatalf("expected blocked eval: %#v", eval)
+	}
+	if !strings.Contains(out, fmt.Sprintf("Monitoring evaluation %q", eval.BlockedEval)) {
+		t.Fatalf("missing blocked eval\n\n%s", out)
+	}
+}
+
+func TestMonitor_Monitor_Quiet(t *testing.T) {
+	srv, client, _ := testServer(t, nil)
+	defer srv.Stop()
+
+	// Create the monitor
+	ui := new(cli.MockUi)
+	mon := newMonitor(ui, client, fullId)
+	mon.quiet = true
+
+	// Register and stop a job. The stop evaluation completes without any
+	// placements.
+	job := testJob("job1")
+	if _, _, err := client.Jobs().Register(job, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	evalID, _, err := client.Jobs().Deregister("job1", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var code int
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		code = mon.monitor(evalID, false)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("eval monitor took too long")
+	}
+	if code != 0 {
+		t.Fatalf("expect exit 0, got: %d", code)
+	}
+
+	// Only the final status is written
+	out := strings.TrimSpace(ui.OutputWriter.String())
+	expected := fmt.Sprintf("==> Evaluation %q finished with status %q (exit code 0)",
+		evalID, structs.EvalStatusComplete)
+	if out != expected {
+		t.Fatalf("expected %q, got %q", expected, out)
+	}
+}
+
 func TestMonitor_DumpAllocStatus(t *testing.T) {
 	ui := new(cli.MockUi)
 
@@ -349,3 +765,36 @@ func TestMonitor_DumpAllocStatus(t *testing.T) {
 		t.Fatalf("expected alloc id, got %s", out)
 	}
 }
+
+func TestMonitor_FormatPlacementFailure(t *testing.T) {
+	metrics := &api.AllocationMetric{
+		NodesEvaluated:    3,
+		CoalescedFailures: 1,
+		ConstraintFiltered: map[string]int{
+			"${attr.kernel.name} = linux": 2,
+		},
+		ClassExhausted: map[string]int{
+			"large": 1,
+		},
+		DimensionExhausted: map[string]int{
+			"memory": 1,
+		},
+		QuotaExhausted: []string{"cpu"},
+	}
+
+	out := formatPlacementFailure("group1", metrics)
+	lines := strings.Split(out, "\n")
+	if lines[0] != `Task Group "group1" (failed to place 2 allocations):` {
+		t.Fatalf("bad header: %q", lines[0])
+	}
+	for _, exp := range []string{
+		`Constraint "${attr.kernel.name} = linux" filtered 2 nodes`,
+		`Class "large" exhausted on 1 nodes`,
+		`Dimension "memory" exhausted on 1 nodes`,
+		`Quota limit hit "cpu"`,
+	} {
+		if !strings.Contains(out, exp) {
+			t.Fatalf("missing %q\n\n%s", exp, out)
+		}
+	}
+}
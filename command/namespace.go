@@ -0,0 +1,38 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type NamespaceCommand struct {
+	Meta
+}
+
+func (f *NamespaceCommand) Help() string {
+	helpText := `
+Usage: nomad namespace <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with namespaces. Namespaces
+  allow jobs and their associated objects to be segmented from each other and
+  other users of the cluster. A namespace may be attached to a quota
+  specification to limit the resources its jobs may use.
+
+Subcommands:
+
+  apply      Create or update a namespace
+  delete     Delete a namespace
+  list       List all namespaces
+  status     Display the status of a namespace
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *NamespaceCommand) Synopsis() string {
+	return "Interact with namespaces"
+}
+
+func (f *NamespaceCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
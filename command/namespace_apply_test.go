@@ -0,0 +1,66 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestNamespaceApplyCommand_Implements(t *testing.T) {
+	var _ cli.Command = &NamespaceApplyCommand{}
+}
+
+func TestNamespaceApplyCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &NamespaceApplyCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error applying namespace") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestNamespaceApplyCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &NamespaceApplyCommand{Meta: Meta{Ui: ui}}
+
+	// Create the namespace
+	if code := cmd.Run([]string{"-address=" + url, "-description=Team A", "team-a"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Successfully applied namespace") {
+		t.Fatalf("expected success output, got: %s", out)
+	}
+
+	ns, _, err := client.Namespaces().Info("team-a", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ns.Description != "Team A" {
+		t.Fatalf("bad: %#v", ns)
+	}
+
+	// Attaching an unknown quota fails
+	if code := cmd.Run([]string{"-address=" + url, "-quota=nope", "team-a"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "unknown quota") {
+		t.Fatalf("expected unknown quota error, got: %s", out)
+	}
+}
@@ -0,0 +1,118 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type NamespaceListCommand struct {
+	Meta
+}
+
+func (c *NamespaceListCommand) Help() string {
+	helpText := `
+Usage: nomad namespace list [options]
+
+  List is used to list the namespaces of the cluster.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Options:
+
+  -json
+    Output the namespaces in their JSON format.
+
+  -t
+    Format and display the namespaces using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceListCommand) Synopsis() string {
+	return "List all namespaces"
+}
+
+func (c *NamespaceListCommand) Run(args []string) int {
+	var json bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("namespace list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	namespaces, _, err := client.Namespaces().List(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving namespaces: %s", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(namespaces)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(namespaces) == 0 {
+		c.Ui.Output("No namespaces found")
+		return 0
+	}
+
+	c.Ui.Output(formatNamespaces(namespaces))
+	return 0
+}
+
+// formatNamespaces formats a list of namespaces as a table
+func formatNamespaces(namespaces []*api.Namespace) string {
+	rows := make([]string, len(namespaces)+1)
+	rows[0] = "Name|Quota|Description"
+	for i, ns := range namespaces {
+		rows[i+1] = fmt.Sprintf("%s|%s|%s",
+			ns.Name,
+			ns.Quota,
+			ns.Description)
+	}
+	return formatList(rows)
+}
@@ -0,0 +1,84 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type NamespaceStatusCommand struct {
+	Meta
+}
+
+func (c *NamespaceStatusCommand) Help() string {
+	helpText := `
+Usage: nomad namespace status [options] <namespace>
+
+  Status is used to view the status of a namespace. If the namespace is
+  attached to a quota specification, the resources used against the quota
+  are displayed as well.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NamespaceStatusCommand) Synopsis() string {
+	return "Display the status of a namespace"
+}
+
+func (c *NamespaceStatusCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("namespace status", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one namespace
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	ns, _, err := client.Namespaces().Info(name, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving namespace: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("Name|%s", ns.Name),
+		fmt.Sprintf("Description|%s", ns.Description),
+		fmt.Sprintf("Quota|%s", ns.Quota),
+	}
+	c.Ui.Output(formatKV(basic))
+
+	if ns.Quota == "" {
+		return 0
+	}
+
+	spec, _, err := client.Quotas().Info(ns.Quota, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving quota: %s", err))
+		return 1
+	}
+	usage, _, err := client.Quotas().Usage(ns.Quota, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving quota usage: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(c.Colorize().Color("\n[bold]Quota Limits[reset]"))
+	c.Ui.Output(formatQuotaLimits(spec, usage))
+	return 0
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type NodeDrainCommand struct {
@@ -17,6 +18,10 @@ Usage: nomad node-drain [options] <node>
   that either -enable or -disable is specified, but not both.
   The -self flag is useful to drain the local node.
 
+  When draining is enabled, the allocations of the node are migrated
+  gradually according to the migrate stanza of their task groups. Once the
+  deadline is reached, the remaining allocations are migrated at once.
+
 General Options:
 
   ` + generalOptionsUsage() + `
@@ -29,11 +34,31 @@ Node Drain Options:
   -enable
     Enable draining for the specified node.
 
+  -deadline <duration>
+    Set the deadline by which all allocations must be moved off the node.
+    Remaining allocations after the deadline are migrated at once. Defaults
+    to 1 hour.
+
+  -no-deadline
+    No deadline is set and the allocations are only migrated according to
+    the migrate stanza of their task groups.
+
+  -force
+    Migrate all the allocations of the node immediately.
+
   -self
     Query the status of the local node.
 
+  -monitor
+    Enter monitor mode to follow the evaluations created when toggling the
+    drain. Allocations migrated gradually are placed by later evaluations.
+    It is safe to exit the monitor early using ctrl+c.
+
   -yes
     Automatic yes to prompts.
+
+  -verbose
+    Display full information when monitoring the evaluations.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -43,7 +68,8 @@ func (c *NodeDrainCommand) Synopsis() string {
 }
 
 func (c *NodeDrainCommand) Run(args []string) int {
-	var enable, disable, self, autoYes bool
+	var enable, disable, self, autoYes, monitor, verbose, noDeadline, force bool
+	var deadlineStr string
 
 	flags := c.Meta.FlagSet("node-drain", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
@@ -51,6 +77,11 @@ func (c *NodeDrainCommand) Run(args []string) int {
 	flags.BoolVar(&disable, "disable", false, "Disable drain mode")
 	flags.BoolVar(&self, "self", false, "")
 	flags.BoolVar(&autoYes, "yes", false, "Automatic yes to prompts.")
+	flags.BoolVar(&monitor, "monitor", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.StringVar(&deadlineStr, "deadline", "1h", "")
+	flags.BoolVar(&noDeadline, "no-deadline", false, "")
+	flags.BoolVar(&force, "force", false, "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -62,6 +93,27 @@ func (c *NodeDrainCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Parse the deadline of the drain
+	if noDeadline && force {
+		c.Ui.Error("-no-deadline and -force can't be used together")
+		return 1
+	}
+	deadline, err := time.ParseDuration(deadlineStr)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing deadline value %q: %v", deadlineStr, err))
+		return 1
+	}
+	if deadline <= 0 {
+		c.Ui.Error("The deadline must be positive")
+		return 1
+	}
+	switch {
+	case noDeadline:
+		deadline = 0
+	case force:
+		deadline = -1
+	}
+
 	// Check that we got a node ID
 	args = flags.Args()
 	if l := len(args); self && l != 0 || !self && l != 1 {
@@ -163,9 +215,34 @@ func (c *NodeDrainCommand) Run(args []string) int {
 	}
 
 	// Toggle node draining
-	if _, err := client.Nodes().ToggleDrain(node.ID, enable, nil); err != nil {
+	evalIDs, _, err := client.Nodes().UpdateDrain(node.ID, enable, deadline, nil)
+	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error toggling drain mode: %s", err))
 		return 1
 	}
-	return 0
+
+	if !monitor {
+		return 0
+	}
+	if len(evalIDs) == 0 {
+		c.Ui.Output(fmt.Sprintf("No evaluations created for node %q", node.ID))
+		return 0
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Monitor each evaluation in turn, returning the worst exit code
+	code := 0
+	for _, evalID := range evalIDs {
+		mon := newMonitor(c.Ui, client, length)
+		mon.color = c.Colorize()
+		if rc := mon.monitor(evalID, false); rc > code {
+			code = rc
+		}
+	}
+	return code
 }
@@ -1,9 +1,12 @@
 package command
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/cli"
 )
 
@@ -79,4 +82,92 @@ func TestNodeDrainCommand_Fails(t *testing.T) {
 	if out := ui.ErrorWriter.String(); !strings.Contains(out, "No node(s) with prefix or id") {
 		t.Fatalf("expected not exist error, got: %s", out)
 	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an invalid deadline
+	if code := cmd.Run([]string{"-address=" + url, "-enable", "-deadline=-1h", "12345678-abcd-efab-cdef-123456789abc"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "deadline must be positive") {
+		t.Fatalf("expected deadline error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails if both -no-deadline and -force are specified
+	if code := cmd.Run([]string{"-address=" + url, "-enable", "-no-deadline", "-force", "12345678-abcd-efab-cdef-123456789abc"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "can't be used together") {
+		t.Fatalf("expected conflicting flags error, got: %s", out)
+	}
+}
+
+func TestNodeDrainCommand_Monitor(t *testing.T) {
+	srv, client, url := testServer(t, func(c *testutil.TestServerConfig) {
+		c.DevMode = true
+	})
+	defer srv.Stop()
+
+	// Wait for a node to be ready
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		nodes, _, err := client.Nodes().List(nil)
+		if err != nil {
+			return false, err
+		}
+		for _, node := range nodes {
+			if node.Status == structs.NodeStatusReady {
+				nodeID = node.ID
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("no ready nodes")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	ui := new(cli.MockUi)
+	cmd := &NodeDrainCommand{Meta: Meta{Ui: ui}}
+
+	// Place an allocation on the node and wait for it to complete so that
+	// the drain has nothing left to place on the other nodes.
+	job := testJob("job1")
+	job.TaskGroups[0].Tasks[0].Config["run_for"] = "10ms"
+	evalID, _, err := client.Jobs().Register(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if code := waitForSuccess(ui, client, fullId, t, evalID); code != 0 {
+		t.Fatalf("status code non zero saw %d", code)
+	}
+	ui.OutputWriter.Reset()
+	testutil.WaitForResult(func() (bool, error) {
+		allocs, _, err := client.Jobs().Allocations("job1", nil)
+		if err != nil {
+			return false, err
+		}
+		for _, alloc := range allocs {
+			if alloc.ClientStatus != structs.AllocClientStatusComplete {
+				return false, fmt.Errorf("alloc %q is %s", alloc.ID, alloc.ClientStatus)
+			}
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Draining the node monitors the evaluation of the job
+	if code := cmd.Run([]string{"-address=" + url, "-enable", "-monitor", nodeID}); code != 0 {
+		t.Fatalf("expected successful monitor, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Monitoring evaluation") || !strings.Contains(out, `job "job1"`) {
+		t.Fatalf("expected monitor output, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Disabling the drain monitors the evaluation of the job again
+	if code := cmd.Run([]string{"-address=" + url, "-disable", "-monitor", nodeID}); code != 0 {
+		t.Fatalf("expected successful monitor, got: %d: %s", code, ui.ErrorWriter.String())
+	}
 }
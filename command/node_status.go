@@ -23,6 +23,7 @@ const (
 
 type NodeStatusCommand struct {
 	Meta
+	listPager
 	color       *colorstring.Colorize
 	length      int
 	short       bool
@@ -74,6 +75,9 @@ Node Status Options:
 
   -t
     Format and display node using a Go template.
+
+  ` + listOptionsUsage() + `
+    The list flags only apply when listing all the nodes.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -93,6 +97,7 @@ func (c *NodeStatusCommand) Run(args []string) int {
 	flags.BoolVar(&c.stats, "stats", false, "")
 	flags.BoolVar(&c.json, "json", false, "")
 	flags.StringVar(&c.tmpl, "t", "", "")
+	c.addListFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -132,7 +137,7 @@ func (c *NodeStatusCommand) Run(args []string) int {
 		}
 
 		// Query the node info
-		nodes, _, err := client.Nodes().List(nil)
+		nodes, qm, err := client.Nodes().List(c.queryOptions())
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf("Error querying node status: %s", err))
 			return 1
@@ -156,15 +161,18 @@ func (c *NodeStatusCommand) Run(args []string) int {
 				return 1
 			}
 			c.Ui.Output(out)
+			if hint := nextPageHint(qm); hint != "" {
+				c.Ui.Warn(hint)
+			}
 			return 0
 		}
 
 		// Format the nodes list
 		out := make([]string, len(nodes)+1)
 		if c.list_allocs {
-			out[0] = "ID|DC|Name|Class|Drain|Status|Running Allocs"
+			out[0] = "ID|DC|Name|Class|Drain|Eligibility|Status|Running Allocs"
 		} else {
-			out[0] = "ID|DC|Name|Class|Drain|Status"
+			out[0] = "ID|DC|Name|Class|Drain|Eligibility|Status"
 		}
 
 		for i, node := range nodes {
@@ -174,27 +182,32 @@ func (c *NodeStatusCommand) Run(args []string) int {
 					c.Ui.Error(fmt.Sprintf("Error querying node allocations: %s", err))
 					return 1
 				}
-				out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s|%v",
+				out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s|%s|%v",
 					limit(node.ID, c.length),
 					node.Datacenter,
 					node.Name,
 					node.NodeClass,
 					node.Drain,
+					nodeEligibility(node.SchedulingEligibility),
 					node.Status,
 					len(numAllocs))
 			} else {
-				out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s",
+				out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s|%s",
 					limit(node.ID, c.length),
 					node.Datacenter,
 					node.Name,
 					node.NodeClass,
 					node.Drain,
+					nodeEligibility(node.SchedulingEligibility),
 					node.Status)
 			}
 		}
 
 		// Dump the output
 		c.Ui.Output(formatList(out))
+		if hint := nextPageHint(qm); hint != "" {
+			c.Ui.Warn(hint)
+		}
 		return 0
 	}
 
@@ -233,14 +246,15 @@ func (c *NodeStatusCommand) Run(args []string) int {
 		// Format the nodes list that matches the prefix so that the user
 		// can create a more specific request
 		out := make([]string, len(nodes)+1)
-		out[0] = "ID|DC|Name|Class|Drain|Status"
+		out[0] = "ID|DC|Name|Class|Drain|Eligibility|Status"
 		for i, node := range nodes {
-			out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s",
+			out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s|%s",
 				limit(node.ID, c.length),
 				node.Datacenter,
 				node.Name,
 				node.NodeClass,
 				node.Drain,
+				nodeEligibility(node.SchedulingEligibility),
 				node.Status)
 		}
 		// Dump the output
@@ -283,6 +297,15 @@ func (c *NodeStatusCommand) Run(args []string) int {
 	return c.formatNode(client, node)
 }
 
+// nodeEligibility returns the scheduling eligibility of a node, which is
+// eligible unless set otherwise
+func nodeEligibility(eligibility string) string {
+	if eligibility == "" {
+		return api.NodeSchedulingEligible
+	}
+	return eligibility
+}
+
 func (c *NodeStatusCommand) formatNode(client *api.Client, node *api.Node) int {
 	// Format the header output
 	basic := []string{
@@ -291,6 +314,7 @@ func (c *NodeStatusCommand) formatNode(client *api.Client, node *api.Node) int {
 		fmt.Sprintf("Class|%s", node.NodeClass),
 		fmt.Sprintf("DC|%s", node.Datacenter),
 		fmt.Sprintf("Drain|%v", node.Drain),
+		fmt.Sprintf("Eligibility|%s", nodeEligibility(node.SchedulingEligibility)),
 		fmt.Sprintf("Status|%s", node.Status),
 	}
 
@@ -320,6 +344,11 @@ func (c *NodeStatusCommand) formatNode(client *api.Client, node *api.Node) int {
 		c.Ui.Output(c.Colorize().Color("\n[bold]Allocated Resources[reset]"))
 		c.Ui.Output(formatList(allocatedResources))
 
+		if reservedCores := getReservedCores(runningAllocs, c.length); len(reservedCores) > 1 {
+			c.Ui.Output(c.Colorize().Color("\n[bold]Reserved Cores[reset]"))
+			c.Ui.Output(formatList(reservedCores))
+		}
+
 		actualResources, err := getActualResources(client, runningAllocs, node)
 		if err == nil {
 			c.Ui.Output(c.Colorize().Color("\n[bold]Allocation Resource Utilization[reset]"))
@@ -508,6 +537,29 @@ func getAllocatedResources(client *api.Client, runningAllocs []*api.Allocation,
 	return resources
 }
 
+// getReservedCores returns the cores reserved by the tasks of the running
+// allocations, ordered by core.
+func getReservedCores(runningAllocs []*api.Allocation, length int) []string {
+	var cores []int
+	reservedBy := make(map[int]string)
+	for _, alloc := range runningAllocs {
+		for task, resources := range alloc.TaskResources {
+			for _, core := range resources.ReservedCores {
+				cores = append(cores, core)
+				reservedBy[core] = fmt.Sprintf("%s|%s", limit(alloc.ID, length), task)
+			}
+		}
+	}
+	sort.Ints(cores)
+
+	out := make([]string, len(cores)+1)
+	out[0] = "Core|Alloc ID|Task"
+	for i, core := range cores {
+		out[i+1] = fmt.Sprintf("%d|%s", core, reservedBy[core])
+	}
+	return out
+}
+
 // computeNodeTotalResources returns the total allocatable resources (resources
 // minus reserved)
 func computeNodeTotalResources(node *api.Node) api.Resources {
@@ -0,0 +1,37 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorCommand struct {
+	Meta
+}
+
+func (f *OperatorCommand) Help() string {
+	helpText := `
+Usage: nomad operator <subcommand> [options] [args]
+
+  This command groups subcommands for operators to manage the Nomad servers.
+  Most users will not need to interact with these commands.
+
+Subcommands:
+
+  autopilot    Inspect and modify the Autopilot configuration
+  debug        Capture a debug archive of the cluster and its agents
+  raft         Inspect and manage the Raft peer set of the servers
+  scheduler    Inspect and modify the scheduler configuration
+  snapshot     Save and restore snapshots of the state of the servers
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *OperatorCommand) Synopsis() string {
+	return "Provides cluster-level tools for Nomad operators"
+}
+
+func (f *OperatorCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorAutopilotCommand struct {
+	Meta
+}
+
+func (c *OperatorAutopilotCommand) Help() string {
+	helpText := `
+Usage: nomad operator autopilot <subcommand> [options]
+
+  This command groups subcommands for interacting with the Autopilot
+  configuration of the servers. Autopilot adds new servers to the Raft peer
+  set once they are stable and removes servers that have been failed for too
+  long.
+
+Subcommands:
+
+  get-config    Display the current Autopilot configuration
+  set-config    Modify the current Autopilot configuration
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorAutopilotCommand) Synopsis() string {
+	return "Provides tools for modifying Autopilot configuration"
+}
+
+func (c *OperatorAutopilotCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
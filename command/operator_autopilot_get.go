@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type OperatorAutopilotGetCommand struct {
+	Meta
+}
+
+func (c *OperatorAutopilotGetCommand) Help() string {
+	helpText := `
+Usage: nomad operator autopilot get-config [options]
+
+  Displays the current Autopilot configuration.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorAutopilotGetCommand) Synopsis() string {
+	return "Display the current Autopilot configuration"
+}
+
+func (c *OperatorAutopilotGetCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("operator autopilot get-config", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration
+	config, _, err := client.Operator().AutopilotGetConfiguration(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying Autopilot configuration: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("CleanupDeadServers|%v", config.CleanupDeadServers),
+		fmt.Sprintf("LastContactThreshold|%v", config.LastContactThreshold),
+		fmt.Sprintf("MaxTrailingLogs|%v", config.MaxTrailingLogs),
+		fmt.Sprintf("ServerStabilizationTime|%v", config.ServerStabilizationTime),
+	}
+	c.Ui.Output(formatKV(basic))
+	return 0
+}
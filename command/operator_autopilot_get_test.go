@@ -0,0 +1,49 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorAutopilotGetCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorAutopilotGetCommand{}
+}
+
+func TestOperatorAutopilotGetCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorAutopilotGetCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying Autopilot configuration") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestOperatorAutopilotGetCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &OperatorAutopilotGetCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "CleanupDeadServers") || !strings.Contains(out, "true") {
+		t.Fatalf("bad: %q", out)
+	}
+}
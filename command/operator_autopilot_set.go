@@ -0,0 +1,121 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type OperatorAutopilotSetCommand struct {
+	Meta
+}
+
+func (c *OperatorAutopilotSetCommand) Help() string {
+	helpText := `
+Usage: nomad operator autopilot set-config [options]
+
+  Modifies the current Autopilot configuration. Only the given options are
+  changed.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Set Config Options:
+
+  -cleanup-dead-servers=[true|false]
+    Controls whether Nomad will automatically remove dead servers from the
+    Raft peer set once they have been failed for the server stabilization
+    time.
+
+  -last-contact-threshold=<duration>
+    Controls the maximum amount of time a server can go without contact from
+    the leader before being considered unhealthy. Must be a duration value
+    such as "200ms".
+
+  -max-trailing-logs=<value>
+    Controls the maximum number of log entries that a server can trail the
+    leader by before being considered unhealthy.
+
+  -server-stabilization-time=<duration>
+    Controls the minimum amount of time a server must be healthy before
+    being added to the Raft peer set, and failed before being removed from
+    it. Must be a duration value such as "10s".
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorAutopilotSetCommand) Synopsis() string {
+	return "Modify the current Autopilot configuration"
+}
+
+func (c *OperatorAutopilotSetCommand) Run(args []string) int {
+	var cleanupDeadServers bool
+	var maxTrailingLogs uint64
+	var lastContactThreshold, serverStabilizationTime time.Duration
+
+	flags := c.Meta.FlagSet("operator autopilot set-config", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&cleanupDeadServers, "cleanup-dead-servers", false, "")
+	flags.Uint64Var(&maxTrailingLogs, "max-trailing-logs", 0, "")
+	flags.DurationVar(&lastContactThreshold, "last-contact-threshold", 0, "")
+	flags.DurationVar(&serverStabilizationTime, "server-stabilization-time", 0, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Track which options were given so only those are changed
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration
+	operator := client.Operator()
+	conf, _, err := operator.AutopilotGetConfiguration(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying Autopilot configuration: %s", err))
+		return 1
+	}
+
+	// Update the config values based on the set flags
+	if set["cleanup-dead-servers"] {
+		conf.CleanupDeadServers = cleanupDeadServers
+	}
+	if set["max-trailing-logs"] {
+		conf.MaxTrailingLogs = maxTrailingLogs
+	}
+	if set["last-contact-threshold"] {
+		conf.LastContactThreshold = lastContactThreshold
+	}
+	if set["server-stabilization-time"] {
+		conf.ServerStabilizationTime = serverStabilizationTime
+	}
+
+	// Check-and-set the new configuration
+	result, _, err := operator.AutopilotCASConfiguration(conf, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error setting Autopilot configuration: %s", err))
+		return 1
+	}
+	if !result {
+		c.Ui.Error("Autopilot configuration could not be atomically updated, please try again")
+		return 1
+	}
+
+	c.Ui.Output("Configuration updated!")
+	return 0
+}
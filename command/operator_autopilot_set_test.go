@@ -0,0 +1,70 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorAutopilotSetCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorAutopilotSetCommand{}
+}
+
+func TestOperatorAutopilotSetCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorAutopilotSetCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-max-trailing-logs=10"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying Autopilot configuration") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestOperatorAutopilotSetCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &OperatorAutopilotSetCommand{Meta: Meta{Ui: ui}}
+	args := []string{
+		"-address=" + url,
+		"-cleanup-dead-servers=false",
+		"-max-trailing-logs=99",
+		"-last-contact-threshold=123ms",
+	}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Configuration updated") {
+		t.Fatalf("bad: %q", out)
+	}
+
+	// Only the given options were changed
+	conf, _, err := client.Operator().AutopilotGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.CleanupDeadServers {
+		t.Fatalf("bad: %#v", conf)
+	}
+	if conf.MaxTrailingLogs != 99 || conf.LastContactThreshold != 123*time.Millisecond {
+		t.Fatalf("bad: %#v", conf)
+	}
+	if conf.ServerStabilizationTime != 10*time.Second {
+		t.Fatalf("bad: %#v", conf)
+	}
+}
@@ -0,0 +1,503 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
+	"github.com/mitchellh/cli"
+)
+
+type OperatorDebugCommand struct {
+	Meta
+}
+
+func (c *OperatorDebugCommand) Help() string {
+	helpText := `
+Usage: nomad operator debug [options]
+
+  Debug captures the state of the cluster and of a set of agents during a
+  period of time, and writes it to a compressed archive that can be attached
+  to a bug report or a support ticket.
+
+  The archive holds the jobs, nodes, evaluations, allocations and deployments
+  of the cluster, and for each agent its configuration, its logs, snapshots of
+  its metrics and, if the agent runs with enable_debug, a goroutine dump and
+  pprof profiles. The profiles require an agent:write token if ACLs are
+  enabled.
+
+  The agent targeted by the -address option is always captured. Other servers
+  are selected by their HTTP address, and clients by their node ID.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Debug Options:
+
+  -duration <duration>
+    The duration of the capture. Defaults to 2m.
+
+  -interval <duration>
+    The interval between the snapshots of the metrics of the agents. Defaults
+    to 30s.
+
+  -log-level <level>
+    The minimum level of the captured logs. One of "trace", "debug", "info",
+    "warn" and "err". Defaults to "debug".
+
+  -node-id <ids>
+    A comma separated list of the IDs or ID prefixes of the client nodes to
+    capture, or "all" to capture every node of the cluster.
+
+  -server-address <address>
+    The HTTP address of another server to capture. This option can be
+    specified multiple times.
+
+  -pprof-duration <duration>
+    The duration of the CPU profiles of the agents. Defaults to 1s.
+
+  -output <dir>
+    The directory the archive is written to. Defaults to the current
+    directory.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorDebugCommand) Synopsis() string {
+	return "Capture a debug archive of the cluster and its agents"
+}
+
+// debugTarget is an agent captured in the debug archive
+type debugTarget struct {
+	// dir is the directory of the agent in the archive
+	dir    string
+	client *api.Client
+}
+
+func (c *OperatorDebugCommand) Run(args []string) int {
+	var duration, interval, pprofDuration time.Duration
+	var logLevel, nodeIDs, output string
+	var serverAddrs flaghelper.StringFlag
+
+	flags := c.Meta.FlagSet("operator debug", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.DurationVar(&duration, "duration", 2*time.Minute, "")
+	flags.DurationVar(&interval, "interval", 30*time.Second, "")
+	flags.DurationVar(&pprofDuration, "pprof-duration", time.Second, "")
+	flags.StringVar(&logLevel, "log-level", "debug", "")
+	flags.StringVar(&nodeIDs, "node-id", "", "")
+	flags.Var(&serverAddrs, "server-address", "")
+	flags.StringVar(&output, "output", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) > 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	if duration <= 0 {
+		c.Ui.Error("The duration must be positive")
+		return 1
+	}
+	if interval <= 0 || interval > duration {
+		c.Ui.Error("The interval must be positive and at most the duration")
+		return 1
+	}
+	if pprofDuration < time.Second {
+		c.Ui.Error("The pprof duration must be at least 1s")
+		return 1
+	}
+
+	// The agents are captured concurrently
+	c.Ui = &cli.ConcurrentUi{Ui: c.Ui}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	targets, err := c.debugTargets(client, serverAddrs, nodeIDs)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error selecting agents: %s", err))
+		return 1
+	}
+
+	tmp, err := ioutil.TempDir("", "nomad-debug")
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error creating temporary directory: %s", err))
+		return 1
+	}
+	defer os.RemoveAll(tmp)
+
+	name := fmt.Sprintf("nomad-debug-%s", time.Now().UTC().Format("2006-01-02-150405Z"))
+	dir := filepath.Join(tmp, name)
+
+	c.Ui.Output("Capturing cluster state")
+	c.captureCluster(client, filepath.Join(dir, "cluster"))
+
+	// Capture the logs and profiles of the agents in the background
+	stopCh := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		c.Ui.Output(fmt.Sprintf("Capturing agent %s", target.dir))
+		targetDir := filepath.Join(dir, target.dir)
+		self, err := target.client.Agent().Self()
+		c.writeJSON(filepath.Join(targetDir, "agent-self.json"), self, err)
+
+		wg.Add(2)
+		go func(target *debugTarget) {
+			defer wg.Done()
+			c.captureLogs(target, targetDir, logLevel, stopCh)
+		}(target)
+		go func(target *debugTarget) {
+			defer wg.Done()
+			c.captureProfiles(target, targetDir, pprofDuration)
+		}(target)
+	}
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	// Snapshot the metrics at every interval until the end of the capture
+	c.Ui.Output(fmt.Sprintf("Capturing for %s, interrupt to stop early", duration))
+	deadline := time.After(duration)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+OUTER:
+	for i := 1; ; i++ {
+		for _, target := range targets {
+			metrics, err := target.client.Agent().Metrics(nil)
+			path := filepath.Join(dir, target.dir, fmt.Sprintf("metrics-%04d.json", i))
+			c.writeJSON(path, metrics, err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			break OUTER
+		case <-signalCh:
+			c.Ui.Output("Interrupted, archiving the data captured so far")
+			break OUTER
+		}
+	}
+	close(stopCh)
+	wg.Wait()
+
+	archive := filepath.Join(output, name+".tar.gz")
+	if err := writeDebugArchive(archive, tmp); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error writing archive: %s", err))
+		return 1
+	}
+	c.Ui.Output(fmt.Sprintf("Created debug archive: %s", archive))
+	return 0
+}
+
+// debugTargets returns the agents to capture: the agent the client talks to,
+// the servers at the given addresses and the client nodes matching the
+// comma separated list of ID prefixes.
+func (c *OperatorDebugCommand) debugTargets(client *api.Client, serverAddrs []string, nodeIDs string) ([]*debugTarget, error) {
+	var targets []*debugTarget
+	seen := make(map[string]struct{})
+	addAgent := func(client *api.Client, address string) error {
+		self, err := client.Agent().Self()
+		if err != nil {
+			return fmt.Errorf("failed to query agent %s: %v", address, err)
+		}
+		dir, nodeID := agentDebugDir(self)
+		if _, ok := seen[dir]; ok {
+			return nil
+		}
+		seen[dir] = struct{}{}
+		if nodeID != "" {
+			seen[filepath.Join("client", nodeID)] = struct{}{}
+		}
+		targets = append(targets, &debugTarget{dir: dir, client: client})
+		return nil
+	}
+
+	// The agent targeted by the address is always captured
+	config := c.Meta.clientConfig()
+	if err := addAgent(client, config.Address); err != nil {
+		return nil, err
+	}
+
+	for _, addr := range serverAddrs {
+		serverConfig := c.Meta.clientConfig()
+		serverConfig.Address = addr
+		serverClient, err := api.NewClient(serverConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := addAgent(serverClient, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	ids, err := debugNodeIDs(client, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		dir := filepath.Join("client", id)
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+
+		node, _, err := client.Nodes().Info(id, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query node %s: %v", id, err)
+		}
+		if node.HTTPAddr == "" {
+			return nil, fmt.Errorf("http addr of node %s is not advertised", id)
+		}
+		nodeClient, err := api.NewClient(config.CopyConfig(node.HTTPAddr, node.TLSEnabled))
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, &debugTarget{dir: dir, client: nodeClient})
+	}
+	return targets, nil
+}
+
+// agentDebugDir returns the directory of an agent in the archive, and the ID
+// of its node if it runs a client.
+func agentDebugDir(self map[string]map[string]interface{}) (string, string) {
+	var nodeID string
+	if stats, ok := self["stats"]["client"].(map[string]interface{}); ok {
+		nodeID, _ = stats["node_id"].(string)
+	}
+	if name, _ := self["member"]["Name"].(string); name != "" {
+		return filepath.Join("server", name), nodeID
+	}
+	if nodeID != "" {
+		return filepath.Join("client", nodeID), nodeID
+	}
+	return "agent", ""
+}
+
+// debugNodeIDs returns the IDs of the nodes matching the comma separated list
+// of ID prefixes, or of every node for "all". Each prefix must match exactly
+// one node.
+func debugNodeIDs(client *api.Client, nodeIDs string) ([]string, error) {
+	if nodeIDs == "" {
+		return nil, nil
+	}
+	nodes, _, err := client.Nodes().List(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	var ids []string
+	if nodeIDs == "all" {
+		for _, node := range nodes {
+			ids = append(ids, node.ID)
+		}
+		return ids, nil
+	}
+
+	for _, prefix := range strings.Split(nodeIDs, ",") {
+		prefix = strings.TrimSpace(prefix)
+		var matches []string
+		for _, node := range nodes {
+			if strings.HasPrefix(node.ID, prefix) {
+				matches = append(matches, node.ID)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("no node matches the ID prefix %q", prefix)
+		case 1:
+			ids = append(ids, matches[0])
+		default:
+			return nil, fmt.Errorf("the ID prefix %q matches multiple nodes", prefix)
+		}
+	}
+	return ids, nil
+}
+
+// captureCluster writes the state of the cluster to the directory
+func (c *OperatorDebugCommand) captureCluster(client *api.Client, dir string) {
+	jobs, _, err := client.Jobs().List(nil)
+	c.writeJSON(filepath.Join(dir, "jobs.json"), jobs, err)
+
+	nodes, _, err := client.Nodes().List(nil)
+	c.writeJSON(filepath.Join(dir, "nodes.json"), nodes, err)
+
+	evals, _, err := client.Evaluations().List(nil)
+	c.writeJSON(filepath.Join(dir, "evaluations.json"), evals, err)
+
+	allocs, _, err := client.Allocations().List(nil)
+	c.writeJSON(filepath.Join(dir, "allocations.json"), allocs, err)
+
+	deployments, _, err := client.Deployments().List(nil)
+	c.writeJSON(filepath.Join(dir, "deployments.json"), deployments, err)
+}
+
+// captureLogs writes the logs of the agent to the directory until stopCh is
+// closed.
+func (c *OperatorDebugCommand) captureLogs(target *debugTarget, dir, logLevel string, stopCh <-chan struct{}) {
+	logCh, err := target.client.Agent().Monitor(logLevel, stopCh, nil)
+	if err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to capture the logs of agent %s: %v", target.dir, err))
+		return
+	}
+
+	f, err := createDebugFile(filepath.Join(dir, "monitor.log"))
+	if err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to capture the logs of agent %s: %v", target.dir, err))
+		return
+	}
+	defer f.Close()
+
+	for line := range logCh {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			c.Ui.Warn(fmt.Sprintf("Failed to capture the logs of agent %s: %v", target.dir, err))
+			return
+		}
+	}
+}
+
+// captureProfiles writes the goroutine dump and the pprof profiles of the
+// agent to the directory. They are only served if the agent runs with
+// enable_debug, to agent:write tokens if ACLs are enabled.
+func (c *OperatorDebugCommand) captureProfiles(target *debugTarget, dir string, pprofDuration time.Duration) {
+	profiles := []struct {
+		name   string
+		file   string
+		params map[string]string
+	}{
+		{"goroutine", "goroutine.txt", map[string]string{"debug": "2"}},
+		{"heap", "heap.prof", nil},
+		{"profile", "profile.prof", map[string]string{
+			"seconds": strconv.Itoa(int(pprofDuration.Seconds())),
+		}},
+	}
+
+	for _, profile := range profiles {
+		out, err := target.client.Agent().Profile(profile.name, &api.QueryOptions{Params: profile.params})
+		if err != nil {
+			c.Ui.Warn(fmt.Sprintf("Failed to capture the %s profile of agent %s, enable_debug and an agent:write token are required: %v",
+				profile.name, target.dir, err))
+			return
+		}
+		path := filepath.Join(dir, profile.file)
+		if err := writeDebugFile(path, out); err != nil {
+			c.Ui.Warn(fmt.Sprintf("Failed to write %s: %v", path, err))
+		}
+	}
+}
+
+// writeJSON writes the object as JSON to the path, or warns of the error of
+// the request that returned it.
+func (c *OperatorDebugCommand) writeJSON(path string, obj interface{}, err error) {
+	if err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to capture %s: %v", filepath.Base(path), err))
+		return
+	}
+	out, err := json.MarshalIndent(obj, "", "  ")
+	if err == nil {
+		err = writeDebugFile(path, out)
+	}
+	if err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to write %s: %v", path, err))
+	}
+}
+
+// createDebugFile creates a file of the archive and its directory
+func createDebugFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// writeDebugFile writes the data to a file of the archive
+func writeDebugFile(path string, data []byte) error {
+	f, err := createDebugFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeDebugArchive writes the content of the directory to a gzipped tar
+// archive at path.
+func writeDebugArchive(path, dir string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	err = filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(tw, in)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,139 @@
+package command
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorDebugCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorDebugCommand{}
+}
+
+func TestOperatorDebugCommand_Fails(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &OperatorDebugCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an interval longer than the duration
+	if code := cmd.Run([]string{"-duration=1s", "-interval=2s"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "interval must be positive") {
+		t.Fatalf("expected interval error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error selecting agents") {
+		t.Fatalf("expected agent selection error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an unknown node
+	if code := cmd.Run([]string{"-address=" + url, "-node-id=12345678"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "no node matches") {
+		t.Fatalf("expected unknown node error, got: %s", out)
+	}
+}
+
+func TestOperatorDebugCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, func(c *testutil.TestServerConfig) {
+		c.EnableDebug = true
+	})
+	defer srv.Stop()
+
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ui := new(cli.MockUi)
+	cmd := &OperatorDebugCommand{Meta: Meta{Ui: ui}}
+	args := []string{"-address=" + url, "-duration=1s", "-interval=500ms", "-output=" + dir}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Created debug archive") {
+		t.Fatalf("bad: %q", out)
+	}
+
+	archives, err := filepath.Glob(filepath.Join(dir, "nomad-debug-*.tar.gz"))
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("bad: %v %v", archives, err)
+	}
+	f, err := os.Open(archives[0])
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	files := make(map[string]struct{})
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		files[header.Name] = struct{}{}
+	}
+
+	agent := "server/" + srv.Config.NodeName + ".global/"
+	expected := []string{
+		"cluster/jobs.json",
+		"cluster/nodes.json",
+		"cluster/evaluations.json",
+		"cluster/allocations.json",
+		"cluster/deployments.json",
+		agent + "agent-self.json",
+		agent + "monitor.log",
+		agent + "metrics-0001.json",
+		agent + "metrics-0002.json",
+		agent + "goroutine.txt",
+		agent + "heap.prof",
+		agent + "profile.prof",
+	}
+	for _, file := range expected {
+		found := false
+		for name := range files {
+			if strings.HasSuffix(name, file) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("missing %s in %v", file, files)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorRaftCommand struct {
+	Meta
+}
+
+func (c *OperatorRaftCommand) Help() string {
+	helpText := `
+Usage: nomad operator raft <subcommand> [options]
+
+  This command groups subcommands for operators to inspect and manage the Raft
+  peer set of the servers. It can be used to recover from a server that failed
+  without leaving the cluster, without having to edit the peers.json file of
+  each server.
+
+Subcommands:
+
+  list-peers     Display the current Raft peer configuration
+  remove-peer    Remove a Nomad server from the Raft configuration
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftCommand) Synopsis() string {
+	return "Provides access to the Raft subsystem"
+}
+
+func (c *OperatorRaftCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type OperatorRaftListCommand struct {
+	Meta
+}
+
+func (c *OperatorRaftListCommand) Help() string {
+	helpText := `
+Usage: nomad operator raft list-peers [options]
+
+  Displays the current Raft peer configuration.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Peers Options:
+
+  -stale
+    The list is served by the leader unless -stale is set, in which case any
+    server can answer. This is useful to inspect the peer set of a server when
+    the cluster has lost its leader.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftListCommand) Synopsis() string {
+	return "Display the current Raft peer configuration"
+}
+
+func (c *OperatorRaftListCommand) Run(args []string) int {
+	var stale bool
+
+	flags := c.Meta.FlagSet("operator raft list-peers", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&stale, "stale", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration
+	q := &api.QueryOptions{
+		AllowStale: stale,
+	}
+	servers, _, err := client.Operator().RaftGetConfiguration(q)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error getting peers: %s", err))
+		return 1
+	}
+
+	// Format it as a nice table
+	rows := []string{"Node|Address|State|Voter"}
+	for _, s := range servers {
+		state := "follower"
+		if s.Leader {
+			state = "leader"
+		}
+		rows = append(rows, fmt.Sprintf("%s|%s|%s|%v", s.Node, s.Address, state, s.Voter))
+	}
+	c.Ui.Output(formatList(rows))
+	return 0
+}
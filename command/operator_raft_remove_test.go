@@ -0,0 +1,58 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorRaftRemoveCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorRaftRemoveCommand{}
+}
+
+func TestOperatorRaftRemoveCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorRaftRemoveCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails without an address
+	if code := cmd.Run(nil); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-peer-address=127.0.0.1:4647"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error removing peer") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestOperatorRaftRemoveCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	// Unknown peers can not be removed
+	ui := new(cli.MockUi)
+	cmd := &OperatorRaftRemoveCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, "-peer-address=nope"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, `address "nope" was not found in the Raft configuration`) {
+		t.Fatalf("bad: %q", out)
+	}
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorSchedulerCommand struct {
+	Meta
+}
+
+func (c *OperatorSchedulerCommand) Help() string {
+	helpText := `
+Usage: nomad operator scheduler <subcommand> [options]
+
+  This command groups subcommands for interacting with the scheduler
+  configuration of the servers. The scheduler configuration selects how the
+  nodes allocations fit on are scored, either bin-packing allocations on the
+  most utilized nodes or spreading them across the least utilized ones.
+
+Subcommands:
+
+  get-config    Display the current scheduler configuration
+  set-config    Modify the current scheduler configuration
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSchedulerCommand) Synopsis() string {
+	return "Provides tools for modifying the scheduler configuration"
+}
+
+func (c *OperatorSchedulerCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
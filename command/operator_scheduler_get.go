@@ -0,0 +1,61 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type OperatorSchedulerGetCommand struct {
+	Meta
+}
+
+func (c *OperatorSchedulerGetCommand) Help() string {
+	helpText := `
+Usage: nomad operator scheduler get-config [options]
+
+  Displays the current scheduler configuration.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSchedulerGetCommand) Synopsis() string {
+	return "Display the current scheduler configuration"
+}
+
+func (c *OperatorSchedulerGetCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("operator scheduler get-config", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration
+	config, _, err := client.Operator().SchedulerGetConfiguration(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying scheduler configuration: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("SchedulerAlgorithm|%s", config.SchedulerAlgorithm),
+	}
+	c.Ui.Output(formatKV(basic))
+	return 0
+}
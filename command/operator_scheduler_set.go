@@ -0,0 +1,106 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type OperatorSchedulerSetCommand struct {
+	Meta
+}
+
+func (c *OperatorSchedulerSetCommand) Help() string {
+	helpText := `
+Usage: nomad operator scheduler set-config [options]
+
+  Modifies the current scheduler configuration. Only the given options are
+  changed.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Set Config Options:
+
+  -scheduler-algorithm=[binpack|spread]
+    Controls how the nodes allocations fit on are scored. "binpack" places
+    allocations on the most utilized nodes, keeping the other nodes free for
+    large allocations. "spread" places allocations on the least utilized
+    nodes, limiting the allocations lost with a node.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSchedulerSetCommand) Synopsis() string {
+	return "Modify the current scheduler configuration"
+}
+
+func (c *OperatorSchedulerSetCommand) Run(args []string) int {
+	var schedulerAlgorithm string
+
+	flags := c.Meta.FlagSet("operator scheduler set-config", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&schedulerAlgorithm, "scheduler-algorithm", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Track which options were given so only those are changed
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// Validate the options before contacting the servers
+	if set["scheduler-algorithm"] {
+		switch schedulerAlgorithm {
+		case api.SchedulerAlgorithmBinpack, api.SchedulerAlgorithmSpread:
+		default:
+			c.Ui.Error(fmt.Sprintf("Invalid scheduler algorithm %q, must be %q or %q",
+				schedulerAlgorithm, api.SchedulerAlgorithmBinpack, api.SchedulerAlgorithmSpread))
+			return 1
+		}
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the current configuration
+	operator := client.Operator()
+	conf, _, err := operator.SchedulerGetConfiguration(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying scheduler configuration: %s", err))
+		return 1
+	}
+
+	// Update the config values based on the set flags
+	if set["scheduler-algorithm"] {
+		conf.SchedulerAlgorithm = schedulerAlgorithm
+	}
+
+	// Check-and-set the new configuration
+	result, _, err := operator.SchedulerCASConfiguration(conf, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error setting scheduler configuration: %s", err))
+		return 1
+	}
+	if !result {
+		c.Ui.Error("Scheduler configuration could not be atomically updated, please try again")
+		return 1
+	}
+
+	c.Ui.Output("Configuration updated!")
+	return 0
+}
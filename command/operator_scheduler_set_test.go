@@ -0,0 +1,70 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorSchedulerSetCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorSchedulerSetCommand{}
+}
+
+func TestOperatorSchedulerSetCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorSchedulerSetCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on invalid algorithms
+	if code := cmd.Run([]string{"-scheduler-algorithm=foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Invalid scheduler algorithm") {
+		t.Fatalf("expected invalid algorithm error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-scheduler-algorithm=spread"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying scheduler configuration") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestOperatorSchedulerSetCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &OperatorSchedulerSetCommand{Meta: Meta{Ui: ui}}
+	args := []string{
+		"-address=" + url,
+		"-scheduler-algorithm=spread",
+	}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Configuration updated") {
+		t.Fatalf("bad: %q", out)
+	}
+
+	conf, _, err := client.Operator().SchedulerGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if conf.SchedulerAlgorithm != api.SchedulerAlgorithmSpread {
+		t.Fatalf("bad: %#v", conf)
+	}
+}
@@ -0,0 +1,36 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorSnapshotCommand struct {
+	Meta
+}
+
+func (f *OperatorSnapshotCommand) Help() string {
+	helpText := `
+Usage: nomad operator snapshot <subcommand> [options] [args]
+
+  This command groups subcommands for saving and restoring snapshots of the
+  state of the Nomad servers. Snapshots contain all the jobs, allocations,
+  evaluations, nodes and ACL data of a region and can be used to back up and
+  recover a cluster without touching the data directories of the servers.
+
+Subcommands:
+
+  restore    Restore a snapshot of the state of the servers
+  save       Save a snapshot of the state of the servers
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *OperatorSnapshotCommand) Synopsis() string {
+	return "Save and restore snapshots of the state of the servers"
+}
+
+func (f *OperatorSnapshotCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,73 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorSnapshotRestoreCommand_Implements(t *testing.T) {
+	var _ cli.Command = &OperatorSnapshotRestoreCommand{}
+}
+
+func TestOperatorSnapshotRestoreCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &OperatorSnapshotRestoreCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an invalid snapshot
+	file, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("nope")
+	file.Close()
+
+	if code := cmd.Run([]string{"-address=nope", file.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error verifying snapshot") {
+		t.Fatalf("expected verification error, got: %s", out)
+	}
+}
+
+func TestOperatorSnapshotRestoreCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "backup.snap")
+
+	// Save a snapshot to restore
+	ui := new(cli.MockUi)
+	save := &OperatorSnapshotSaveCommand{Meta: Meta{Ui: ui}}
+	if code := save.Run([]string{"-address=" + url, file}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+
+	ui = new(cli.MockUi)
+	cmd := &OperatorSnapshotRestoreCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, file}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Restored snapshot") {
+		t.Fatalf("bad: %q", out)
+	}
+}
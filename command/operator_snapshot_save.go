@@ -0,0 +1,125 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper/snapshot"
+)
+
+type OperatorSnapshotSaveCommand struct {
+	Meta
+}
+
+func (c *OperatorSnapshotSaveCommand) Help() string {
+	helpText := `
+Usage: nomad operator snapshot save [options] <file>
+
+  Save retrieves a snapshot of the state of the Nomad servers and writes it to
+  the given file. The snapshot is verified after it has been written.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Save Options:
+
+  -stale
+    Allow any server to serve the snapshot, instead of only the leader. This
+    is useful to take a snapshot of a cluster that has lost its leader, but
+    the snapshot may be missing the latest changes.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorSnapshotSaveCommand) Synopsis() string {
+	return "Save a snapshot of the state of the servers"
+}
+
+func (c *OperatorSnapshotSaveCommand) Run(args []string) int {
+	var stale bool
+
+	flags := c.Meta.FlagSet("operator snapshot save", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&stale, "stale", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one file
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	path := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Retrieve the snapshot
+	q := &api.QueryOptions{AllowStale: stale}
+	snap, err := client.Operator().Snapshot(q)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving snapshot: %s", err))
+		return 1
+	}
+	defer snap.Close()
+
+	// Write the snapshot to a temporary file first so a failed save does not
+	// clobber an existing snapshot
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error creating snapshot file: %s", err))
+		return 1
+	}
+	if _, err := io.Copy(f, snap); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		c.Ui.Error(fmt.Sprintf("Error writing snapshot file: %s", err))
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		c.Ui.Error(fmt.Sprintf("Error writing snapshot file: %s", err))
+		return 1
+	}
+
+	// Verify the snapshot before moving it into place
+	meta, err := verifySnapshotFile(tmp)
+	if err != nil {
+		os.Remove(tmp)
+		c.Ui.Error(fmt.Sprintf("Error verifying snapshot: %s", err))
+		return 1
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		c.Ui.Error(fmt.Sprintf("Error moving snapshot into place: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Saved and verified snapshot of index %d to %q", meta.Index, path))
+	return 0
+}
+
+// verifySnapshotFile checks the archive at the given path and returns its
+// metadata
+func verifySnapshotFile(path string) (*snapshot.Metadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	meta, _, err := snapshot.Read(f)
+	return meta, err
+}
@@ -40,7 +40,8 @@ Usage: nomad plan [options] <file>
 
   If the supplied path is "-", the jobfile is read from stdin. Otherwise
   it is read from the file at the supplied path or downloaded and
-  read from URL specified.
+  read from URL specified. The job file is either written in HCL or holds
+  a job in the JSON format of the HTTP API, as printed by "nomad run -output".
 
   A job modify index is returned with the plan. This value can be used when
   submitting the job using "nomad run -check-index", which will check that the job
@@ -66,7 +67,16 @@ Plan Options:
 
   -diff
     Determines whether the diff between the remote job and planned job is shown.
-    Defaults to true.
+    If disabled, a summary of the allocations that would be created, updated or
+    destroyed for each task group is shown instead. Defaults to true.
+
+  -var 'key=value'
+    Sets an input variable declared by the job file. This flag can be
+    specified multiple times and overrides the values set by -var-file.
+
+  -var-file=<path>
+    Sets the input variables declared by the job file from the variables
+    file at the path. This flag can be specified multiple times.
 
   -verbose
     Increase diff verbosity.
@@ -85,6 +95,7 @@ func (c *PlanCommand) Run(args []string) int {
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&diff, "diff", true, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
+	c.JobGetter.addVarFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return 255
@@ -140,10 +151,15 @@ func (c *PlanCommand) Run(args []string) int {
 		return 255
 	}
 
-	// Print the diff if not disabled
+	// Print the diff if not disabled. Otherwise summarize the changes each
+	// task group would undergo since they are only part of the diff.
 	if diff {
 		c.Ui.Output(fmt.Sprintf("%s\n",
 			c.Colorize().Color(strings.TrimSpace(formatJobDiff(resp.Diff, verbose)))))
+	} else if updates := formatDesiredUpdates(resp); updates != "" {
+		c.Ui.Output(c.Colorize().Color("[bold]Task Group Updates:[reset]"))
+		c.Ui.Output(c.Colorize().Color(updates))
+		c.Ui.Output("")
 	}
 
 	// Print the scheduler dry-run output
@@ -170,6 +186,50 @@ func getExitCode(resp *api.JobPlanResponse) int {
 	return 0
 }
 
+// formatDesiredUpdates produces a summary of the allocations that would be
+// created, updated, migrated or destroyed per task group.
+func formatDesiredUpdates(resp *api.JobPlanResponse) string {
+	if resp.Annotations == nil || len(resp.Annotations.DesiredTGUpdates) == 0 {
+		return ""
+	}
+
+	groups := make([]string, 0, len(resp.Annotations.DesiredTGUpdates))
+	for tg := range resp.Annotations.DesiredTGUpdates {
+		groups = append(groups, tg)
+	}
+	sort.Strings(groups)
+
+	var out string
+	for _, tg := range groups {
+		d := resp.Annotations.DesiredTGUpdates[tg]
+		var updates []string
+		if d.Place > 0 {
+			updates = append(updates, fmt.Sprintf("[green]%d %s", d.Place, scheduler.UpdateTypeCreate))
+		}
+		if d.DestructiveUpdate > 0 {
+			updates = append(updates, fmt.Sprintf("[yellow]%d %s", d.DestructiveUpdate, scheduler.UpdateTypeDestructiveUpdate))
+		}
+		if d.InPlaceUpdate > 0 {
+			updates = append(updates, fmt.Sprintf("[cyan]%d %s", d.InPlaceUpdate, scheduler.UpdateTypeInplaceUpdate))
+		}
+		if d.Migrate > 0 {
+			updates = append(updates, fmt.Sprintf("[blue]%d %s", d.Migrate, scheduler.UpdateTypeMigrate))
+		}
+		if d.Stop > 0 {
+			updates = append(updates, fmt.Sprintf("[red]%d %s", d.Stop, scheduler.UpdateTypeDestroy))
+		}
+		if d.Ignore > 0 {
+			updates = append(updates, fmt.Sprintf("%d %s", d.Ignore, scheduler.UpdateTypeIgnore))
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		out += fmt.Sprintf("- Task Group %q (%s[reset])\n", tg, strings.Join(updates, "[reset], "))
+	}
+
+	return strings.TrimSuffix(out, "\n")
+}
+
 // formatJobModifyIndex produces a help string that displays the job modify
 // index and how to submit a job with it.
 func formatJobModifyIndex(jobModifyIndex uint64, jobName string) string {
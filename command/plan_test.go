@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -158,3 +159,39 @@ func TestPlanCommand_From_URL(t *testing.T) {
 		t.Fatalf("expected error getting jobfile, got: %s", out)
 	}
 }
+
+func TestPlanCommand_FormatDesiredUpdates(t *testing.T) {
+	resp := &api.JobPlanResponse{
+		Annotations: &api.PlanAnnotations{
+			DesiredTGUpdates: map[string]*api.DesiredUpdates{
+				"web": &api.DesiredUpdates{
+					Place:             2,
+					DestructiveUpdate: 1,
+					Stop:              1,
+				},
+				"cache": &api.DesiredUpdates{
+					Ignore: 3,
+				},
+			},
+		},
+	}
+
+	out := formatDesiredUpdates(resp)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got: %q", out)
+	}
+	if !strings.Contains(lines[0], `"cache"`) || !strings.Contains(lines[0], "3 ignore") {
+		t.Fatalf("bad line: %q", lines[0])
+	}
+	for _, exp := range []string{`"web"`, "2 create", "1 create/destroy update", "1 destroy"} {
+		if !strings.Contains(lines[1], exp) {
+			t.Fatalf("missing %q in %q", exp, lines[1])
+		}
+	}
+
+	// No annotations result in no output
+	if out := formatDesiredUpdates(&api.JobPlanResponse{}); out != "" {
+		t.Fatalf("expected no output, got: %q", out)
+	}
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type QuotaCommand struct {
+	Meta
+}
+
+func (f *QuotaCommand) Help() string {
+	helpText := `
+Usage: nomad quota <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with resource quotas. A
+  quota specification limits the CPU, memory and number of allocations that
+  the jobs of the namespaces attached to it may use. Placements that would
+  exceed the quota are blocked until resources are freed or the quota is
+  raised.
+
+Subcommands:
+
+  apply      Create or update a quota specification
+  delete     Delete a quota specification
+  list       List all quota specifications
+  status     Display the usage of a quota specification
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *QuotaCommand) Synopsis() string {
+	return "Interact with quotas"
+}
+
+func (f *QuotaCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
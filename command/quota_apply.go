@@ -0,0 +1,132 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/nomad/api"
+)
+
+type QuotaApplyCommand struct {
+	Meta
+}
+
+func (c *QuotaApplyCommand) Help() string {
+	helpText := `
+Usage: nomad quota apply [options] <input>
+
+  Apply is used to create or update a quota specification. The specification
+  file will be read and the quota will be submitted to Nomad. If the supplied
+  path is "-", the specification is read from stdin. Specifications may be
+  written in HCL or JSON, for example:
+
+    name        = "default-quota"
+    description = "Limit the shared default namespace"
+
+    limit {
+      cpu    = 2500
+      memory = 1000
+      count  = 10
+    }
+
+  A limit of zero leaves the resource unlimited.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaApplyCommand) Synopsis() string {
+	return "Create or update a quota specification"
+}
+
+func (c *QuotaApplyCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("quota apply", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one specification
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Read the specification
+	var raw []byte
+	var err error
+	if path := args[0]; path == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading quota specification: %s", err))
+		return 1
+	}
+
+	spec, err := parseQuotaSpec(raw)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing quota specification: %s", err))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if _, err := client.Quotas().Register(spec, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error applying quota specification: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully applied quota specification %q!", spec.Name))
+	return 0
+}
+
+// quotaSpecFile is the on-disk format of a quota specification.
+type quotaSpecFile struct {
+	Name        string `hcl:"name"`
+	Description string `hcl:"description"`
+	Limit       *struct {
+		CPU    int `hcl:"cpu"`
+		Memory int `hcl:"memory"`
+		Count  int `hcl:"count"`
+	} `hcl:"limit"`
+}
+
+// parseQuotaSpec parses an HCL or JSON quota specification.
+func parseQuotaSpec(raw []byte) (*api.QuotaSpec, error) {
+	var file quotaSpecFile
+	if err := hcl.Decode(&file, string(raw)); err != nil {
+		return nil, err
+	}
+
+	if file.Name == "" {
+		return nil, fmt.Errorf("missing quota name")
+	}
+	if file.Limit == nil {
+		return nil, fmt.Errorf("missing limit block")
+	}
+
+	limit := file.Limit
+	return &api.QuotaSpec{
+		Name:        file.Name,
+		Description: file.Description,
+		Limit: &api.QuotaLimit{
+			CPU:      limit.CPU,
+			MemoryMB: limit.Memory,
+			Count:    limit.Count,
+		},
+	}, nil
+}
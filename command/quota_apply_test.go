@@ -0,0 +1,102 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestQuotaApplyCommand_Implements(t *testing.T) {
+	var _ cli.Command = &QuotaApplyCommand{}
+}
+
+func TestQuotaApplyCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &QuotaApplyCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "/nope/quota.hcl"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error reading quota specification") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestQuotaApplyCommand_ParseQuotaSpec(t *testing.T) {
+	spec, err := parseQuotaSpec([]byte(`
+name        = "team-a"
+description = "Team A"
+
+limit {
+  cpu    = 2500
+  memory = 1000
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &api.QuotaSpec{
+		Name:        "team-a",
+		Description: "Team A",
+		Limit: &api.QuotaLimit{
+			CPU:      2500,
+			MemoryMB: 1000,
+		},
+	}
+	if !reflect.DeepEqual(spec, expected) {
+		t.Fatalf("bad: %#v", spec)
+	}
+
+	// A limit block is required
+	if _, err := parseQuotaSpec([]byte(`name = "team-a"`)); err == nil {
+		t.Fatalf("expected missing limit error")
+	}
+}
+
+func TestQuotaApplyCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	if _, err := fh.WriteString(`
+name = "team-a"
+limit {
+  count = 3
+}
+`); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &QuotaApplyCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, fh.Name()}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+
+	spec, _, err := client.Quotas().Info("team-a", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if spec.Limit == nil || spec.Limit.Count != 3 {
+		t.Fatalf("bad: %#v", spec)
+	}
+}
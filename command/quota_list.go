@@ -0,0 +1,117 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type QuotaListCommand struct {
+	Meta
+}
+
+func (c *QuotaListCommand) Help() string {
+	helpText := `
+Usage: nomad quota list [options]
+
+  List is used to list the quota specifications of the cluster.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Options:
+
+  -json
+    Output the quota specifications in their JSON format.
+
+  -t
+    Format and display the quota specifications using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaListCommand) Synopsis() string {
+	return "List all quota specifications"
+}
+
+func (c *QuotaListCommand) Run(args []string) int {
+	var json bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("quota list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	args = flags.Args()
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	specs, _, err := client.Quotas().List(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving quotas: %s", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	var format string
+	if json && len(tmpl) > 0 {
+		c.Ui.Error("Both -json and -t are not allowed")
+		return 1
+	} else if json {
+		format = "json"
+	} else if len(tmpl) > 0 {
+		format = "template"
+	}
+	if len(format) > 0 {
+		f, err := DataFormat(format, tmpl)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting formatter: %s", err))
+			return 1
+		}
+
+		out, err := f.TransformData(specs)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting the data: %s", err))
+			return 1
+		}
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(specs) == 0 {
+		c.Ui.Output("No quotas found")
+		return 0
+	}
+
+	c.Ui.Output(formatQuotaSpecs(specs))
+	return 0
+}
+
+// formatQuotaSpecs formats a list of quota specifications as a table
+func formatQuotaSpecs(specs []*api.QuotaSpec) string {
+	rows := make([]string, len(specs)+1)
+	rows[0] = "Name|Description"
+	for i, spec := range specs {
+		rows[i+1] = fmt.Sprintf("%s|%s",
+			spec.Name,
+			spec.Description)
+	}
+	return formatList(rows)
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+type QuotaStatusCommand struct {
+	Meta
+}
+
+func (c *QuotaStatusCommand) Help() string {
+	helpText := `
+Usage: nomad quota status [options] <quota>
+
+  Status is used to view the limits of a quota specification and the
+  resources currently used against them by the attached namespaces.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaStatusCommand) Synopsis() string {
+	return "Display the usage of a quota specification"
+}
+
+func (c *QuotaStatusCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("quota status", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one quota
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	name := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	spec, _, err := client.Quotas().Info(name, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving quota: %s", err))
+		return 1
+	}
+	usage, _, err := client.Quotas().Usage(name, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving quota usage: %s", err))
+		return 1
+	}
+
+	basic := []string{
+		fmt.Sprintf("Name|%s", spec.Name),
+		fmt.Sprintf("Description|%s", spec.Description),
+		fmt.Sprintf("Namespaces|%s", strings.Join(usage.Namespaces, ",")),
+	}
+	c.Ui.Output(formatKV(basic))
+
+	c.Ui.Output(c.Colorize().Color("\n[bold]Quota Limits[reset]"))
+	c.Ui.Output(formatQuotaLimits(spec, usage))
+	return 0
+}
+
+// formatQuotaLimits formats the limits of a quota specification alongside
+// the resources used against them.
+func formatQuotaLimits(spec *api.QuotaSpec, usage *api.QuotaUsage) string {
+	limit := spec.Limit
+	if limit == nil {
+		limit = &api.QuotaLimit{}
+	}
+	used := usage.Used
+	if used == nil {
+		used = &api.QuotaLimit{}
+	}
+
+	rows := make([]string, 2)
+	rows[0] = "CPU Usage|Memory Usage|Allocation Count"
+	rows[1] = fmt.Sprintf("%s|%s|%s",
+		formatQuotaUsage(used.CPU, limit.CPU),
+		formatQuotaUsage(used.MemoryMB, limit.MemoryMB),
+		formatQuotaUsage(used.Count, limit.Count))
+	return formatList(rows)
+}
+
+// formatQuotaUsage formats a used amount against its limit, where a zero
+// limit is unlimited.
+func formatQuotaUsage(used, limit int) string {
+	if limit == 0 {
+		return fmt.Sprintf("%d / inf", used)
+	}
+	return fmt.Sprintf("%d / %d", used, limit)
+}
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestQuotaStatusCommand_Implements(t *testing.T) {
+	var _ cli.Command = &QuotaStatusCommand{}
+}
+
+func TestQuotaStatusCommand_Fails(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &QuotaStatusCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foo"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error retrieving quota") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestQuotaStatusCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	spec := &api.QuotaSpec{
+		Name:  "team-a",
+		Limit: &api.QuotaLimit{CPU: 1000},
+	}
+	if _, err := client.Quotas().Register(spec, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &QuotaStatusCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, "team-a"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "0 / 1000") || !strings.Contains(out, "0 / inf") {
+		t.Fatalf("expected quota limits, got: %s", out)
+	}
+}
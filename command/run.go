@@ -35,7 +35,8 @@ Usage: nomad run [options] <path>
 
   If the supplied path is "-", the jobfile is read from stdin. Otherwise
   it is read from the file at the supplied path or downloaded and
-  read from URL specified.
+  read from URL specified. The job file is either written in HCL or holds
+  a job in the JSON format of the HTTP API, as printed by "nomad run -output".
 
   Upon successful job submission, this command will immediately
   enter an interactive monitor. This is useful to watch Nomad's
@@ -46,12 +47,17 @@ Usage: nomad run [options] <path>
   On successful job submission and scheduling, exit code 0 will be
   returned. If there are job placement issues encountered
   (unsatisfiable constraints, resource exhaustion, etc), then the
-  exit code will be 2. Any other errors, including client connection
-  issues or internal errors, are indicated by exit code 1.
+  exit code will be 2. If the -timeout expires before the evaluation
+  finishes, the exit code will be 3. Any other errors, including client
+  connection issues or internal errors, are indicated by exit code 1.
 
   If the job has specified the region, the -region flag and NOMAD_REGION
   environment variable are overridden and the job's region is used.
 
+  If the job has specified a list of regions, a copy of the job is submitted to
+  each of them through the federated servers, and the evaluation of each region
+  is monitored in turn. The exit code is the highest one of all the regions.
+
   The run command will set the vault_token of the job based on the following
   precedence, going from highest to lowest: the -vault-token flag, the
   $VAULT_TOKEN environment variable and finally the value in the job file.
@@ -68,15 +74,47 @@ Run Options:
     zero is passed, the job is only registered if it does not yet exist. If a
     non-zero value is passed, it ensures that the job is being updated from a
     known state. The use of this flag is most common in conjunction with plan
-    command.
+    command. If the job is not updated because the index does not match, the
+    diff against the registered job is displayed.
 
   -detach
     Return immediately instead of entering monitor mode. After job submission,
     the evaluation ID will be printed to the screen, which can be used to
     examine the evaluation using the eval-status command.
 
+  -json
+    Output each event observed by the monitor as a JSON object on a single
+    line instead of human readable text.
+
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -trace
+    Record the decision of the scheduler for each node considered while
+    placing the job: the constraint that filtered it, the resource it ran out
+    of and its scores. The decisions are displayed by "nomad eval-status
+    -explain".
+
+  -timeout=<duration>
+    Abort the monitor with exit code 3 if the evaluation hasn't finished
+    after the duration, e.g. "5m". The evaluation keeps being processed by the
+    servers. If not all allocations could be placed, the resulting blocked
+    evaluation is monitored until the remaining allocations are placed or the
+    timeout expires. Defaults to waiting until the evaluation finishes and
+    reporting the blocked evaluation with exit code 2.
+
   -verbose
-    Display full information.
+    Display full information, including every allocation transition observed
+    by the monitor instead of coalescing the ones observed at once.
+
+  -var 'key=value'
+    Sets an input variable declared by the job file. This flag can be
+    specified multiple times and overrides the values set by -var-file.
+
+  -var-file=<path>
+    Sets the input variables declared by the job file from the variables
+    file at the path. This flag can be specified multiple times.
 
   -vault-token
     If set, the passed Vault token is stored in the job before sending to the
@@ -96,20 +134,30 @@ func (c *RunCommand) Synopsis() string {
 }
 
 func (c *RunCommand) Run(args []string) int {
-	var detach, verbose, output bool
+	var detach, verbose, output, jsonOutput, quiet, trace bool
 	var checkIndexStr, vaultToken string
+	var timeout time.Duration
 
 	flags := c.Meta.FlagSet("run", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&detach, "detach", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
 	flags.BoolVar(&output, "output", false, "")
+	flags.BoolVar(&jsonOutput, "json", false, "")
+	flags.BoolVar(&trace, "trace", false, "")
 	flags.StringVar(&checkIndexStr, "check-index", "", "")
 	flags.StringVar(&vaultToken, "vault-token", "", "")
+	flags.DurationVar(&timeout, "timeout", 0, "")
+	c.JobGetter.addVarFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
+	if timeout < 0 {
+		c.Ui.Error("The -timeout flag must not be negative")
+		return 1
+	}
 
 	// Truncate the id unless full length is requested
 	length := shortId
@@ -147,8 +195,9 @@ func (c *RunCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Check if the job is periodic.
+	// Check if the job is periodic or is a parameterized job
 	periodic := job.IsPeriodic()
+	paramjob := job.IsParameterized()
 
 	// Parse the Vault token
 	if vaultToken == "" {
@@ -215,13 +264,22 @@ func (c *RunCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Submit a multi-region job to each of its regions
+	if len(job.Regions) > 1 {
+		if enforce {
+			c.Ui.Error("The -check-index flag can not be used with multi-region jobs")
+			return 1
+		}
+		return c.runRegions(client, apiJob, job.Regions, detach || periodic || paramjob, length, quiet, verbose, jsonOutput, trace, timeout)
+	}
+
 	// Submit the job
-	var evalID string
-	if enforce {
-		evalID, _, err = client.Jobs().EnforceRegister(apiJob, checkIndex, nil)
-	} else {
-		evalID, _, err = client.Jobs().Register(apiJob, nil)
+	opts := &api.RegisterOptions{
+		EnforceIndex: enforce,
+		ModifyIndex:  checkIndex,
+		Trace:        trace,
 	}
+	evalID, _, err := client.Jobs().RegisterOpts(apiJob, opts, nil)
 	if err != nil {
 		if strings.Contains(err.Error(), api.RegisterEnforceIndexErrPrefix) {
 			// Format the error specially if the error is due to index
@@ -230,6 +288,13 @@ func (c *RunCommand) Run(args []string) int {
 			if len(matches) == 2 {
 				c.Ui.Error(matches[1]) // The matched group
 				c.Ui.Error("Job not updated")
+
+				// Display how the job differs from the registered version
+				// so the conflicting modification can be reviewed.
+				if resp, _, err := client.Jobs().Plan(apiJob, true, nil); err == nil && resp.Diff != nil {
+					c.Ui.Output(c.Colorize().Color("\n[bold]Changes against the registered job:[reset]"))
+					c.Ui.Output(c.Colorize().Color(strings.TrimSpace(formatJobDiff(resp.Diff, verbose))))
+				}
 				return 1
 			}
 		}
@@ -239,14 +304,14 @@ func (c *RunCommand) Run(args []string) int {
 	}
 
 	// Check if we should enter monitor mode
-	if detach || periodic {
+	if detach || periodic || paramjob {
 		c.Ui.Output("Job registration successful")
-		if periodic {
+		if periodic && !paramjob {
 			now := time.Now().UTC()
 			next := job.Periodic.Next(now)
 			c.Ui.Output(fmt.Sprintf("Approximate next launch time: %s (%s from now)",
 				formatTime(next), formatTimeDifference(now, next, time.Second)))
-		} else {
+		} else if !paramjob {
 			c.Ui.Output("Evaluation ID: " + evalID)
 		}
 
@@ -255,10 +320,71 @@ func (c *RunCommand) Run(args []string) int {
 
 	// Detach was not specified, so start monitoring
 	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	mon.verbose = verbose
+	mon.json = jsonOutput
+	mon.timeout = timeout
 	return mon.monitor(evalID, false)
 
 }
 
+// runRegions registers a copy of the job in each of the given regions and
+// monitors the resulting evaluations one region after the other. The returned
+// exit code is the highest one of all the regions, and the timeout bounds the
+// monitoring of all of them.
+func (c *RunCommand) runRegions(client *api.Client, job *api.Job, regions []string,
+	detach bool, length int, quiet, verbose, jsonOutput, trace bool, timeout time.Duration) int {
+
+	evalIDs := make(map[string]string, len(regions))
+	for _, region := range regions {
+		regionJob := *job
+		regionJob.Region = region
+		client.SetRegion(region)
+
+		evalID, _, err := client.Jobs().RegisterOpts(&regionJob, &api.RegisterOptions{Trace: trace}, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error submitting job to region %q: %s", region, err))
+			return 1
+		}
+		c.Ui.Output(fmt.Sprintf("Job registration successful in region %q", region))
+		evalIDs[region] = evalID
+	}
+
+	if detach {
+		for _, region := range regions {
+			if evalID := evalIDs[region]; evalID != "" {
+				c.Ui.Output(fmt.Sprintf("Evaluation ID in region %q: %s", region, evalID))
+			}
+		}
+		return 0
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	code := 0
+	for _, region := range regions {
+		if !jsonOutput {
+			c.Ui.Output(c.Colorize().Color(fmt.Sprintf("\n[bold]Region %q:[reset]", region)))
+		}
+		client.SetRegion(region)
+		mon := newMonitor(c.Ui, client, length)
+		mon.color = c.Colorize()
+		mon.quiet = quiet
+		mon.verbose = verbose
+		mon.json = jsonOutput
+		mon.timeout = timeout
+		mon.deadline = deadline
+		if rc := mon.monitor(evalIDs[region], false); rc > code {
+			code = rc
+		}
+	}
+	return code
+}
+
 // parseCheckIndex parses the check-index flag and returns the index, whether it
 // was set and potentially an error during parsing.
 func parseCheckIndex(input string) (uint64, bool, error) {
@@ -1,11 +1,14 @@
 package command
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/cli"
 )
 
@@ -48,6 +51,251 @@ job "job1" {
 	}
 }
 
+func TestRunCommand_Detach(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Meta: Meta{Ui: ui}}
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`
+job "job1" {
+	type = "service"
+	datacenters = [ "dc1" ]
+	group "group1" {
+		count = 1
+		task "task1" {
+			driver = "exec"
+			config {
+				command = "/bin/sleep"
+			}
+			resources = {
+				cpu = 1000
+				memory = 512
+			}
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Detach returns immediately with the evaluation ID
+	if code := cmd.Run([]string{"-address=" + url, "-detach", fh.Name()}); code != 0 {
+		t.Fatalf("expected exit code 0, got: %d", code)
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Evaluation ID: ") {
+		t.Fatalf("expected evaluation id, got: %s", out)
+	}
+	if strings.Contains(out, "Monitoring evaluation") {
+		t.Fatalf("should not monitor, got: %s", out)
+	}
+
+	// The printed evaluation exists
+	evalID := strings.TrimSpace(out[strings.Index(out, "Evaluation ID: ")+len("Evaluation ID: "):])
+	if _, _, err := client.Evaluations().Info(evalID, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestRunCommand_Timeout(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Meta: Meta{Ui: ui}}
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`
+job "job1" {
+	type = "service"
+	datacenters = [ "dc1" ]
+	group "group1" {
+		count = 1
+		task "task1" {
+			driver = "exec"
+			config {
+				command = "/bin/sleep"
+			}
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Fails on negative timeouts
+	if code := cmd.Run([]string{"-address=" + url, "-timeout=-1s", fh.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+
+	// There are no clients, so the monitor times out on the followed blocked
+	// eval
+	if code := cmd.Run([]string{"-address=" + url, "-timeout=1s", fh.Name()}); code != 3 {
+		t.Fatalf("expected exit code 3, got: %d", code)
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Monitoring timed out") {
+		t.Fatalf("expected timeout, got: %s", out)
+	}
+}
+
+func TestRunCommand_MultiRegion(t *testing.T) {
+	// The servers advertise a reachable address so requests can be forwarded
+	// between the regions
+	srv1, client1, url := testServer(t, func(c *testutil.TestServerConfig) {
+		c.Region = "regionA"
+		c.AdvertiseAddrs.RPC = "127.0.0.1"
+		c.AdvertiseAddrs.Serf = "127.0.0.1"
+	})
+	defer srv1.Stop()
+	srv2, client2, _ := testServer(t, func(c *testutil.TestServerConfig) {
+		c.Region = "regionB"
+		c.AdvertiseAddrs.RPC = "127.0.0.1"
+		c.AdvertiseAddrs.Serf = "127.0.0.1"
+	})
+	defer srv2.Stop()
+
+	// Federate the regions
+	if _, err := client2.Agent().Join(srv1.SerfAddr); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testutil.WaitForResult(func() (bool, error) {
+		regions, err := client1.Regions().List()
+		if err != nil {
+			return false, err
+		}
+		return len(regions) == 2, fmt.Errorf("bad: %#v", regions)
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`
+job "job1" {
+	regions = ["regionA", "regionB"]
+	type = "service"
+	datacenters = [ "dc1" ]
+	group "group1" {
+		task "task1" {
+			driver = "exec"
+			config {
+				command = "/bin/sleep"
+			}
+		}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Multi-region jobs can not enforce an index
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, "-check-index=0", fh.Name()}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "can not be used with multi-region jobs") {
+		t.Fatalf("bad: %s", out)
+	}
+
+	// The job is registered in both regions through the first one
+	ui = new(cli.MockUi)
+	cmd = &RunCommand{Meta: Meta{Ui: ui}}
+	if code := cmd.Run([]string{"-address=" + url, "-detach", fh.Name()}); code != 0 {
+		t.Fatalf("expected exit code 0, got: %d; %s", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	for _, region := range []string{"regionA", "regionB"} {
+		if !strings.Contains(out, fmt.Sprintf("Evaluation ID in region %q", region)) {
+			t.Fatalf("expected evaluation of region %q, got: %s", region, out)
+		}
+
+		job, _, err := client1.Jobs().Info("job1", &api.QueryOptions{Region: region})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if job.Region != region {
+			t.Fatalf("bad: %#v", job)
+		}
+	}
+}
+
+func TestRunCommand_CheckIndex_Diff(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Meta: Meta{Ui: ui}}
+
+	jobFile := func(count int) string {
+		fh, err := ioutil.TempFile("", "nomad")
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		defer fh.Close()
+		_, err = fh.WriteString(fmt.Sprintf(`
+job "job1" {
+	type = "service"
+	datacenters = [ "dc1" ]
+	group "group1" {
+		count = %d
+		task "task1" {
+			driver = "exec"
+			config {
+				command = "/bin/sleep"
+			}
+			resources = {
+				cpu = 1000
+				memory = 512
+			}
+		}
+	}
+}`, count))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return fh.Name()
+	}
+
+	first := jobFile(1)
+	defer os.Remove(first)
+	if code := cmd.Run([]string{"-address=" + url, "-detach", "-check-index=0", first}); code != 0 {
+		t.Fatalf("expected exit code 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	ui.OutputWriter.Reset()
+
+	// Registering again with a zero index fails and shows the diff
+	second := jobFile(2)
+	defer os.Remove(second)
+	if code := cmd.Run([]string{"-address=" + url, "-detach", "-check-index=0", second}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Job not updated") {
+		t.Fatalf("expected job not updated, got: %s", out)
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Changes against the registered job") {
+		t.Fatalf("expected diff, got: %s", out)
+	}
+	if !strings.Contains(out, "Count") {
+		t.Fatalf("expected count change in diff, got: %s", out)
+	}
+}
+
 func TestRunCommand_Fails(t *testing.T) {
 	ui := new(cli.MockUi)
 	cmd := &RunCommand{Meta: Meta{Ui: ui}}
@@ -18,7 +18,8 @@ func (c *ServerMembersCommand) Help() string {
 Usage: nomad server-members [options]
 
   Display a list of the known servers and their status. Only Nomad servers are
-  able to service this command.
+  able to service this command. The servers of all the federated regions are
+  listed.
 
 General Options:
 
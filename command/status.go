@@ -20,6 +20,7 @@ const (
 
 type StatusCommand struct {
 	Meta
+	listPager
 	length  int
 	evals   bool
 	verbose bool
@@ -47,6 +48,9 @@ Status Options:
 
   -verbose
     Display full information.
+
+  ` + listOptionsUsage() + `
+    The list flags only apply when listing all the jobs.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -63,6 +67,7 @@ func (c *StatusCommand) Run(args []string) int {
 	flags.BoolVar(&short, "short", false, "")
 	flags.BoolVar(&c.evals, "evals", false, "")
 	flags.BoolVar(&c.verbose, "verbose", false, "")
+	c.addListFlags(flags)
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -90,7 +95,7 @@ func (c *StatusCommand) Run(args []string) int {
 
 	// Invoke list mode if no job ID.
 	if len(args) == 0 {
-		jobs, _, err := client.Jobs().List(nil)
+		jobs, qm, err := client.Jobs().List(c.queryOptions())
 		if err != nil {
 			c.Ui.Error(fmt.Sprintf("Error querying jobs: %s", err))
 			return 1
@@ -102,6 +107,9 @@ func (c *StatusCommand) Run(args []string) int {
 		} else {
 			c.Ui.Output(createStatusListOutput(jobs))
 		}
+		if hint := nextPageHint(qm); hint != "" {
+			c.Ui.Warn(hint)
+		}
 		return 0
 	}
 
@@ -127,13 +135,14 @@ func (c *StatusCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Check if it is periodic
+	// Check if it is periodic or a parameterized job
 	sJob, err := convertApiJob(job)
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error converting job: %s", err))
 		return 1
 	}
 	periodic := sJob.IsPeriodic()
+	parameterized := sJob.IsParameterized()
 
 	// Format the job info
 	basic := []string{
@@ -144,9 +153,10 @@ func (c *StatusCommand) Run(args []string) int {
 		fmt.Sprintf("Datacenters|%s", strings.Join(job.Datacenters, ",")),
 		fmt.Sprintf("Status|%s", job.Status),
 		fmt.Sprintf("Periodic|%v", periodic),
+		fmt.Sprintf("Parameterized|%v", parameterized),
 	}
 
-	if periodic {
+	if periodic && !parameterized {
 		now := time.Now().UTC()
 		next := sJob.Periodic.Next(now)
 		basic = append(basic, fmt.Sprintf("Next Periodic Launch|%s",
@@ -162,7 +172,7 @@ func (c *StatusCommand) Run(args []string) int {
 	}
 
 	// Print periodic job information
-	if periodic {
+	if periodic && !parameterized {
 		if err := c.outputPeriodicInfo(client, job); err != nil {
 			c.Ui.Error(err.Error())
 			return 1
@@ -171,6 +181,16 @@ func (c *StatusCommand) Run(args []string) int {
 		return 0
 	}
 
+	// Print parameterized job information
+	if parameterized {
+		if err := c.outputParameterizedInfo(client, job); err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		return 0
+	}
+
 	if err := c.outputJobInfo(client, job); err != nil {
 		c.Ui.Error(err.Error())
 		return 1
@@ -212,6 +232,39 @@ func (c *StatusCommand) outputPeriodicInfo(client *api.Client, job *api.Job) err
 	return nil
 }
 
+// outputParameterizedInfo prints information about the jobs dispatched from
+// the passed parameterized job. If a request fails, an error is returned.
+func (c *StatusCommand) outputParameterizedInfo(client *api.Client, job *api.Job) error {
+	// Generate the prefix that matches the jobs dispatched from the job.
+	prefix := fmt.Sprintf("%s%s", job.ID, structs.DispatchLaunchSuffix)
+	children, _, err := client.Jobs().PrefixList(prefix)
+	if err != nil {
+		return fmt.Errorf("Error querying job: %s", err)
+	}
+
+	if len(children) == 0 {
+		c.Ui.Output("\nNo dispatched instances of parameterized job found")
+		return nil
+	}
+
+	out := make([]string, 1)
+	out[0] = "ID|Status"
+	for _, child := range children {
+		// Ensure that we are only showing jobs whose parent is the requested
+		// job.
+		if child.ParentID != job.ID {
+			continue
+		}
+
+		out = append(out, fmt.Sprintf("%s|%s",
+			child.ID,
+			child.Status))
+	}
+
+	c.Ui.Output(fmt.Sprintf("\nDispatched jobs:\n%s", formatList(out)))
+	return nil
+}
+
 // outputJobInfo prints information about the passed non-periodic job. If a
 // request fails, an error is returned.
 func (c *StatusCommand) outputJobInfo(client *api.Client, job *api.Job) error {
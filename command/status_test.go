@@ -164,6 +164,65 @@ func TestStatusCommand_Run(t *testing.T) {
 	}
 }
 
+func TestStatusCommand_Run_Paginated(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	// Register three jobs
+	for _, id := range []string{"job1", "job2", "job3"} {
+		if _, _, err := client.Jobs().Register(testJob(id), nil); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &StatusCommand{Meta: Meta{Ui: ui}}
+
+	// List the first page
+	if code := cmd.Run([]string{"-address=" + url, "-per-page=2"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "job1") || !strings.Contains(out, "job2") || strings.Contains(out, "job3") {
+		t.Fatalf("expected the first two jobs, got: %s", out)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "-page-token=job3") {
+		t.Fatalf("expected next page token, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+	ui.ErrorWriter.Reset()
+
+	// List the last page
+	if code := cmd.Run([]string{"-address=" + url, "-per-page=2", "-page-token=job3"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	out = ui.OutputWriter.String()
+	if strings.Contains(out, "job1") || strings.Contains(out, "job2") || !strings.Contains(out, "job3") {
+		t.Fatalf("expected the last job, got: %s", out)
+	}
+	if out := ui.ErrorWriter.String(); out != "" {
+		t.Fatalf("expected no next page, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// Filter the jobs
+	if code := cmd.Run([]string{"-address=" + url, `-filter=ID == "job2"`}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	out = ui.OutputWriter.String()
+	if strings.Contains(out, "job1") || !strings.Contains(out, "job2") || strings.Contains(out, "job3") {
+		t.Fatalf("expected the filtered job, got: %s", out)
+	}
+
+	// Fails on an invalid filter
+	if code := cmd.Run([]string{"-address=" + url, "-filter=ID =="}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Invalid filter") {
+		t.Fatalf("expected invalid filter error, got: %s", out)
+	}
+}
+
 func TestStatusCommand_Fails(t *testing.T) {
 	ui := new(cli.MockUi)
 	cmd := &StatusCommand{Meta: Meta{Ui: ui}}
@@ -3,6 +3,7 @@ package command
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type StopCommand struct {
@@ -19,6 +20,9 @@ Usage: nomad stop [options] <job>
   the job unwinds its allocations and completes shutting down. It
   is safe to exit the monitor early using ctrl+c.
 
+  The exit code is 0 once the evaluation of the stopped job finishes, 3 if
+  the -timeout expires before it does, and 1 on any error.
+
 General Options:
 
   ` + generalOptionsUsage() + `
@@ -31,11 +35,25 @@ Stop Options:
     screen, which can be used to examine the evaluation using the eval-status
     command.
 
+  -json
+    Output each event observed by the monitor as a JSON object on a single
+    line instead of human readable text.
+
   -yes
     Automatic yes to prompts.
 
+  -quiet
+    Only output the final status of the evaluation and the exit code of the
+    monitor instead of each event observed while monitoring.
+
+  -timeout=<duration>
+    Abort the monitor with exit code 3 if the evaluation hasn't finished
+    after the duration, e.g. "5m". The evaluation keeps being processed by the
+    servers. Defaults to waiting until the evaluation finishes.
+
   -verbose
-    Display full information.
+    Display full information, including every allocation transition observed
+    by the monitor instead of coalescing the ones observed at once.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -45,17 +63,25 @@ func (c *StopCommand) Synopsis() string {
 }
 
 func (c *StopCommand) Run(args []string) int {
-	var detach, verbose, autoYes bool
+	var detach, verbose, autoYes, json, quiet bool
+	var timeout time.Duration
 
 	flags := c.Meta.FlagSet("stop", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&detach, "detach", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&quiet, "quiet", false, "")
 	flags.BoolVar(&autoYes, "yes", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.DurationVar(&timeout, "timeout", 0, "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
+	if timeout < 0 {
+		c.Ui.Error("The -timeout flag must not be negative")
+		return 1
+	}
 
 	// Truncate the id unless full length is requested
 	length := shortId
@@ -150,5 +176,10 @@ func (c *StopCommand) Run(args []string) int {
 
 	// Start monitoring the stop eval
 	mon := newMonitor(c.Ui, client, length)
+	mon.color = c.Colorize()
+	mon.quiet = quiet
+	mon.verbose = verbose
+	mon.json = json
+	mon.timeout = timeout
 	return mon.monitor(evalID, false)
 }
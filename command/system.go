@@ -0,0 +1,33 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type SystemCommand struct {
+	Meta
+}
+
+func (f *SystemCommand) Help() string {
+	helpText := `
+Usage: nomad system <subcommand> [options] [args]
+
+  This command groups subcommands for the maintenance of the state of the
+  Nomad servers. Most users will not need to interact with these commands.
+
+Subcommands:
+
+  gc    Run a garbage collection of the state of the servers
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (f *SystemCommand) Synopsis() string {
+	return "Interact with the system API"
+}
+
+func (f *SystemCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestSystemGCCommand_Implements(t *testing.T) {
+	var _ cli.Command = &SystemGCCommand{}
+}
+
+func TestSystemGCCommand_Run(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	ui := new(cli.MockUi)
+	cmd := &SystemGCCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on extra arguments
+	if code := cmd.Run([]string{"-address=" + url, "foo"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Forces a garbage collection
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error running system garbage collection") {
+		t.Fatalf("expected failed gc error, got: %s", out)
+	}
+}
@@ -0,0 +1,22 @@
+// +build !linux
+
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func makeRawTerminal(f *os.File) (func(), error) {
+	return nil, fmt.Errorf("terminals are not supported on this platform")
+}
+
+func watchTerminalSize(f *os.File, doneCh <-chan struct{}) <-chan api.TerminalSize {
+	return nil
+}
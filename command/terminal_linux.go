@@ -0,0 +1,80 @@
+package command
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// isTerminal returns whether the file is a terminal
+func isTerminal(f *os.File) bool {
+	var termios syscall.Termios
+	return termIoctl(f, syscall.TCGETS, uintptr(unsafe.Pointer(&termios))) == nil
+}
+
+// makeRawTerminal puts the terminal in raw mode, so that every key is sent to
+// the remote terminal, and returns a function restoring its previous mode.
+func makeRawTerminal(f *os.File) (func(), error) {
+	var old syscall.Termios
+	if err := termIoctl(f, syscall.TCGETS, uintptr(unsafe.Pointer(&old))); err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termIoctl(f, syscall.TCSETS, uintptr(unsafe.Pointer(&raw))); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		termIoctl(f, syscall.TCSETS, uintptr(unsafe.Pointer(&old)))
+	}, nil
+}
+
+// watchTerminalSize sends the size of the terminal on the returned channel
+// initially and every time it changes, until the done channel is closed.
+func watchTerminalSize(f *os.File, doneCh <-chan struct{}) <-chan api.TerminalSize {
+	sizeCh := make(chan api.TerminalSize, 1)
+	winchCh := make(chan os.Signal, 1)
+	signal.Notify(winchCh, syscall.SIGWINCH)
+
+	go func() {
+		defer signal.Stop(winchCh)
+		for {
+			var ws struct {
+				Row, Col, X, Y uint16
+			}
+			if err := termIoctl(f, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); err == nil {
+				select {
+				case sizeCh <- api.TerminalSize{Height: int(ws.Row), Width: int(ws.Col)}:
+				case <-doneCh:
+					return
+				}
+			}
+
+			select {
+			case <-winchCh:
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+	return sizeCh
+}
+
+func termIoctl(f *os.File, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
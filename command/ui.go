@@ -0,0 +1,150 @@
+package command
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// openURL opens the URL in the default browser. It is a variable so tests don't
+// launch a browser.
+var openURL = func(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+type UiCommand struct {
+	Meta
+}
+
+func (c *UiCommand) Help() string {
+	helpText := `
+Usage: nomad ui [options] [<identifier>]
+
+  Opens the web UI of the agent in the default browser. If an identifier is
+  given, the page of the job it is the ID of, or otherwise of the allocation
+  it is the ID or ID prefix of, is opened.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+UI Options:
+
+  -show-url
+    Only print the URL of the web UI instead of opening the browser.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *UiCommand) Synopsis() string {
+	return "Open the web UI"
+}
+
+func (c *UiCommand) Run(args []string) int {
+	var showURL bool
+
+	flags := c.Meta.FlagSet("ui", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&showURL, "show-url", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got at most one identifier
+	args = flags.Args()
+	if len(args) > 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	config := c.Meta.clientConfig()
+	if strings.HasPrefix(config.Address, "unix://") {
+		c.Ui.Error("The web UI can't be opened through a Unix domain socket")
+		return 1
+	}
+	base, err := url.Parse(config.Address)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing the address %q: %s", config.Address, err))
+		return 1
+	}
+	base.Path = "/ui/"
+
+	if len(args) == 1 {
+		// Get the HTTP client
+		client, err := c.Meta.Client()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+			return 1
+		}
+
+		fragment, err := uiFragment(client, args[0])
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+		base.Fragment = fragment
+	}
+
+	u := base.String()
+	if showURL {
+		c.Ui.Output(u)
+		return 0
+	}
+
+	c.Ui.Output(fmt.Sprintf("Opening URL %q", u))
+	if err := openURL(u); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error opening the browser, please open the URL manually: %s", err))
+		return 1
+	}
+	return 0
+}
+
+// uiFragment returns the fragment of the page of the web UI showing the job or
+// allocation with the identifier. Jobs take precedence over allocations.
+func uiFragment(client *api.Client, id string) (string, error) {
+	jobs, _, err := client.Jobs().PrefixList(id)
+	if err != nil {
+		return "", fmt.Errorf("Error querying jobs: %s", err)
+	}
+	for _, job := range jobs {
+		if job.ID == id || len(jobs) == 1 {
+			return "/jobs/" + job.ID, nil
+		}
+	}
+	if len(jobs) > 1 {
+		return "", fmt.Errorf("Prefix %q matched multiple jobs", id)
+	}
+
+	// Allocation identifiers are UUIDs, and prefixes must be of even length
+	allocID := id
+	if len(allocID)%2 == 1 {
+		allocID = allocID[:len(allocID)-1]
+	}
+	if allocID != "" && strings.Trim(allocID, "0123456789abcdefABCDEF-") == "" {
+		allocs, _, err := client.Allocations().PrefixList(allocID)
+		if err != nil {
+			return "", fmt.Errorf("Error querying allocations: %s", err)
+		}
+		if len(allocs) == 1 {
+			return "/allocations/" + allocs[0].ID, nil
+		}
+		if len(allocs) > 1 {
+			return "", fmt.Errorf("Prefix %q matched multiple allocations", id)
+		}
+	}
+	return "", fmt.Errorf("No job or allocation with prefix or id %q found", id)
+}
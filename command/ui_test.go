@@ -0,0 +1,72 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestUiCommand_Implements(t *testing.T) {
+	var _ cli.Command = &UiCommand{}
+}
+
+func TestUiCommand_Run(t *testing.T) {
+	srv, client, url := testServer(t, nil)
+	defer srv.Stop()
+
+	// Capture the opened URL instead of launching a browser
+	var opened string
+	defer func(f func(string) error) { openURL = f }(openURL)
+	openURL = func(u string) error {
+		opened = u
+		return nil
+	}
+
+	if _, _, err := client.Jobs().Register(testJob("job1_sfx"), nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &UiCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on extra arguments
+	if code := cmd.Run([]string{"-address=" + url, "foo", "bar"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Opens the UI
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if opened != url+"/ui/" {
+		t.Fatalf("bad: %q", opened)
+	}
+
+	// Opens the page of a job by prefix
+	if code := cmd.Run([]string{"-address=" + url, "job1"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if opened != url+"/ui/#/jobs/job1_sfx" {
+		t.Fatalf("bad: %q", opened)
+	}
+
+	// Only prints the URL
+	opened = ""
+	ui.OutputWriter.Reset()
+	if code := cmd.Run([]string{"-address=" + url, "-show-url", "job1_sfx"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d; %v", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); strings.TrimSpace(out) != url+"/ui/#/jobs/job1_sfx" || opened != "" {
+		t.Fatalf("bad: %q %q", out, opened)
+	}
+
+	// Fails on unknown identifiers
+	if code := cmd.Run([]string{"-address=" + url, "nope"}); code != 1 {
+		t.Fatalf("expected exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "No job or allocation") {
+		t.Fatalf("expected not found error, got: %s", out)
+	}
+}
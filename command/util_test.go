@@ -38,6 +38,21 @@ func testServer(
 	return srv, client, clientConf.Address
 }
 
+// testACLServer starts a test server with ACLs enabled and returns the
+// bootstrap management token along with the server.
+func testACLServer(t *testing.T) (*testutil.TestServer, *api.Client, string, *api.ACLToken) {
+	srv, client, url := testServer(t, func(c *testutil.TestServerConfig) {
+		c.ACL.Enabled = true
+	})
+	root, _, err := client.ACLTokens().Bootstrap(nil)
+	if err != nil {
+		srv.Stop()
+		t.Fatalf("err: %s", err)
+	}
+	client.SetSecretID(root.SecretID)
+	return srv, client, url, root
+}
+
 func testJob(jobID string) *api.Job {
 	task := api.NewTask("task1", "mock_driver").
 		SetConfig("kill_after", "1s").
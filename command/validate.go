@@ -3,6 +3,10 @@ package command
 import (
 	"fmt"
 	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 type ValidateCommand struct {
@@ -17,9 +21,29 @@ Usage: nomad validate [options] <file>
   Checks if a given HCL job file has a valid specification. This can be used to
   check for any syntax errors or validation problems with a job.
 
+  The job is validated by the Nomad servers, which run the same checks as when
+  registering it, including those of the task driver configurations. The job
+  isn't registered. If the agent can't be reached, the job is validated
+  locally instead, without the task driver checks.
+
   If the supplied path is "-", the jobfile is read from stdin. Otherwise
   it is read from the file at the supplied path or downloaded and
-  read from URL specified.
+  read from URL specified. The job file is either written in HCL or holds
+  a job in the JSON format of the HTTP API, as printed by "nomad run -output".
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Validate Options:
+
+  -var 'key=value'
+    Sets an input variable declared by the job file. This flag can be
+    specified multiple times and overrides the values set by -var-file.
+
+  -var-file=<path>
+    Sets the input variables declared by the job file from the variables
+    file at the path. This flag can be specified multiple times.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -29,8 +53,9 @@ func (c *ValidateCommand) Synopsis() string {
 }
 
 func (c *ValidateCommand) Run(args []string) int {
-	flags := c.Meta.FlagSet("validate", FlagSetNone)
+	flags := c.Meta.FlagSet("validate", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	c.JobGetter.addVarFlags(flags)
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
@@ -52,9 +77,19 @@ func (c *ValidateCommand) Run(args []string) int {
 	// Initialize any fields that need to be.
 	job.Canonicalize()
 
+	resp, err := c.validateRemote(job)
+	if err != nil {
+		c.Ui.Warn(fmt.Sprintf("Validating the job locally, the agent failed to validate it: %v", err))
+		resp = validateLocal(job)
+	}
+
+	for _, w := range resp.Warnings {
+		c.Ui.Warn(fmt.Sprintf("Warning: %s", w))
+	}
+
 	// Check that the job is valid
-	if err := job.Validate(); err != nil {
-		c.Ui.Error(fmt.Sprintf("Error validating job: %s", err))
+	if len(resp.ValidationErrors) != 0 {
+		c.Ui.Error(fmt.Sprintf("Error validating job:\n\n%s", strings.Join(resp.ValidationErrors, "\n")))
 		return 1
 	}
 
@@ -62,3 +97,50 @@ func (c *ValidateCommand) Run(args []string) int {
 	c.Ui.Output("Job validation successful")
 	return 0
 }
+
+// validateRemote validates the job with the Nomad servers.
+func (c *ValidateCommand) validateRemote(job *structs.Job) (*api.JobValidateResponse, error) {
+	apiJob, err := convertStructJob(job)
+	if err != nil {
+		return nil, fmt.Errorf("error converting job: %v", err)
+	}
+
+	client, err := c.Meta.Client()
+	if err != nil {
+		return nil, err
+	}
+
+	// Force the region to be that of the job.
+	if r := job.Region; r != "" {
+		client.SetRegion(r)
+	}
+
+	resp, _, err := client.Jobs().Validate(apiJob, nil)
+	return resp, err
+}
+
+// validateLocal validates the job without contacting the Nomad servers.
+func validateLocal(job *structs.Job) *api.JobValidateResponse {
+	return &api.JobValidateResponse{
+		ValidationErrors: errorMessages(job.Validate()),
+		Warnings:         errorMessages(job.Warnings()),
+	}
+}
+
+// errorMessages returns the messages of the errors wrapped by a multierror or
+// of the error itself.
+func errorMessages(err error) []string {
+	if err == nil {
+		return nil
+	}
+	mErr, ok := err.(*multierror.Error)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	msgs := make([]string, 0, len(mErr.Errors))
+	for _, e := range mErr.Errors {
+		msgs = append(msgs, e.Error())
+	}
+	return msgs
+}
@@ -155,3 +155,108 @@ func TestValidateCommand_From_URL(t *testing.T) {
 		t.Fatalf("expected error getting jobfile, got: %s", out)
 	}
 }
+
+func TestValidateCommand_Vars(t *testing.T) {
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`
+variable "driver" {}
+
+variable "count" {
+  default = 1
+}
+
+job "job1" {
+  type = "service"
+  datacenters = [ "dc1" ]
+  group "group1" {
+    count = "${var.count}"
+    task "task1" {
+      driver = "${var.driver}"
+      resources = {
+        cpu = 1000
+        memory = 512
+      }
+    }
+  }
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	vf, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(vf.Name())
+	if _, err := vf.WriteString(`count = 2`); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &ValidateCommand{Meta: Meta{Ui: ui}}
+
+	// Fails without the required variable
+	if code := cmd.Run([]string{fh.Name()}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, `variable "driver" is required`) {
+		t.Fatalf("expect variable error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	cmd = &ValidateCommand{Meta: Meta{Ui: ui}}
+	args := []string{"-var", "driver=exec", "-var-file", vf.Name(), fh.Name()}
+	if code := cmd.Run(args); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %q", code, ui.ErrorWriter.String())
+	}
+}
+
+func TestValidateCommand_Agent(t *testing.T) {
+	srv, _, url := testServer(t, nil)
+	defer srv.Stop()
+
+	fh, err := ioutil.TempFile("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(`
+job "job1" {
+  type = "batch"
+  datacenters = [ "dc1" ]
+  update {
+    canary = 1
+  }
+  group "group1" {
+    task "task1" {
+      driver = "exec"
+      resources = {
+        cpu = 1000
+        memory = 512
+      }
+    }
+  }
+}`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &ValidateCommand{Meta: Meta{Ui: ui}}
+
+	// The servers validate the driver configuration
+	if code := cmd.Run([]string{"-address=" + url, fh.Name()}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	out := ui.ErrorWriter.String()
+	if !strings.Contains(out, `task "task1" -> config`) {
+		t.Fatalf("expect driver config error, got: %s", out)
+	}
+	if !strings.Contains(out, "canaries are ignored") {
+		t.Fatalf("expect canary warning, got: %s", out)
+	}
+}
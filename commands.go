@@ -25,6 +25,101 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 	}
 
 	return map[string]cli.CommandFactory{
+		"acl": func() (cli.Command, error) {
+			return &command.ACLCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl bootstrap": func() (cli.Command, error) {
+			return &command.ACLBootstrapCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl policy": func() (cli.Command, error) {
+			return &command.ACLPolicyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl policy apply": func() (cli.Command, error) {
+			return &command.ACLPolicyApplyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl policy delete": func() (cli.Command, error) {
+			return &command.ACLPolicyDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl policy info": func() (cli.Command, error) {
+			return &command.ACLPolicyInfoCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl policy list": func() (cli.Command, error) {
+			return &command.ACLPolicyListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl token": func() (cli.Command, error) {
+			return &command.ACLTokenCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl token create": func() (cli.Command, error) {
+			return &command.ACLTokenCreateCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl token delete": func() (cli.Command, error) {
+			return &command.ACLTokenDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl token info": func() (cli.Command, error) {
+			return &command.ACLTokenInfoCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl token list": func() (cli.Command, error) {
+			return &command.ACLTokenListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl token self": func() (cli.Command, error) {
+			return &command.ACLTokenSelfCommand{
+				Meta: meta,
+			}, nil
+		},
+		"acl token update": func() (cli.Command, error) {
+			return &command.ACLTokenUpdateCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc": func() (cli.Command, error) {
+			return &command.AllocCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc exec": func() (cli.Command, error) {
+			return &command.AllocExecCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc restart": func() (cli.Command, error) {
+			return &command.AllocRestartCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc signal": func() (cli.Command, error) {
+			return &command.AllocSignalCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc stop": func() (cli.Command, error) {
+			return &command.AllocStopCommand{
+				Meta: meta,
+			}, nil
+		},
 		"alloc-status": func() (cli.Command, error) {
 			return &command.AllocStatusCommand{
 				Meta: meta,
@@ -44,6 +139,11 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"agent-pprof": func() (cli.Command, error) {
+			return &command.AgentPprofCommand{
+				Meta: meta,
+			}, nil
+		},
 		"check": func() (cli.Command, error) {
 			return &command.AgentCheckCommand{
 				Meta: meta,
@@ -54,6 +154,31 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"deployment": func() (cli.Command, error) {
+			return &command.DeploymentCommand{
+				Meta: meta,
+			}, nil
+		},
+		"deployment fail": func() (cli.Command, error) {
+			return &command.DeploymentFailCommand{
+				Meta: meta,
+			}, nil
+		},
+		"deployment list": func() (cli.Command, error) {
+			return &command.DeploymentListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"deployment promote": func() (cli.Command, error) {
+			return &command.DeploymentPromoteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"deployment status": func() (cli.Command, error) {
+			return &command.DeploymentStatusCommand{
+				Meta: meta,
+			}, nil
+		},
 		"eval-status": func() (cli.Command, error) {
 			return &command.EvalStatusCommand{
 				Meta: meta,
@@ -69,6 +194,21 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"fs cat": func() (cli.Command, error) {
+			return &command.FSCatCommand{
+				Meta: meta,
+			}, nil
+		},
+		"fs ls": func() (cli.Command, error) {
+			return &command.FSListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"fs stat": func() (cli.Command, error) {
+			return &command.FSStatCommand{
+				Meta: meta,
+			}, nil
+		},
 		"init": func() (cli.Command, error) {
 			return &command.InitCommand{
 				Meta: meta,
@@ -79,6 +219,46 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"job": func() (cli.Command, error) {
+			return &command.JobCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job dispatch": func() (cli.Command, error) {
+			return &command.JobDispatchCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job history": func() (cli.Command, error) {
+			return &command.JobHistoryCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job inspect": func() (cli.Command, error) {
+			return &command.InspectCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job periodic": func() (cli.Command, error) {
+			return &command.JobPeriodicCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job periodic force": func() (cli.Command, error) {
+			return &command.JobPeriodicForceCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job revert": func() (cli.Command, error) {
+			return &command.JobRevertCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job validate": func() (cli.Command, error) {
+			return &command.ValidateCommand{
+				Meta: meta,
+			}, nil
+		},
 		"keygen": func() (cli.Command, error) {
 			return &command.KeygenCommand{
 				Meta: meta,
@@ -94,23 +274,154 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"monitor": func() (cli.Command, error) {
+			return &command.AgentMonitorCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace": func() (cli.Command, error) {
+			return &command.NamespaceCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace apply": func() (cli.Command, error) {
+			return &command.NamespaceApplyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace delete": func() (cli.Command, error) {
+			return &command.NamespaceDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace list": func() (cli.Command, error) {
+			return &command.NamespaceListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"namespace status": func() (cli.Command, error) {
+			return &command.NamespaceStatusCommand{
+				Meta: meta,
+			}, nil
+		},
 		"node-drain": func() (cli.Command, error) {
 			return &command.NodeDrainCommand{
 				Meta: meta,
 			}, nil
 		},
+		"node-eligibility": func() (cli.Command, error) {
+			return &command.NodeEligibilityCommand{
+				Meta: meta,
+			}, nil
+		},
 		"node-status": func() (cli.Command, error) {
 			return &command.NodeStatusCommand{
 				Meta: meta,
 			}, nil
 		},
 
+		"operator": func() (cli.Command, error) {
+			return &command.OperatorCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator autopilot": func() (cli.Command, error) {
+			return &command.OperatorAutopilotCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator autopilot get-config": func() (cli.Command, error) {
+			return &command.OperatorAutopilotGetCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator autopilot set-config": func() (cli.Command, error) {
+			return &command.OperatorAutopilotSetCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator debug": func() (cli.Command, error) {
+			return &command.OperatorDebugCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator raft": func() (cli.Command, error) {
+			return &command.OperatorRaftCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator raft list-peers": func() (cli.Command, error) {
+			return &command.OperatorRaftListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator raft remove-peer": func() (cli.Command, error) {
+			return &command.OperatorRaftRemoveCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator scheduler": func() (cli.Command, error) {
+			return &command.OperatorSchedulerCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator scheduler get-config": func() (cli.Command, error) {
+			return &command.OperatorSchedulerGetCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator scheduler set-config": func() (cli.Command, error) {
+			return &command.OperatorSchedulerSetCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator snapshot": func() (cli.Command, error) {
+			return &command.OperatorSnapshotCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator snapshot restore": func() (cli.Command, error) {
+			return &command.OperatorSnapshotRestoreCommand{
+				Meta: meta,
+			}, nil
+		},
+		"operator snapshot save": func() (cli.Command, error) {
+			return &command.OperatorSnapshotSaveCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"plan": func() (cli.Command, error) {
 			return &command.PlanCommand{
 				Meta: meta,
 			}, nil
 		},
 
+		"quota": func() (cli.Command, error) {
+			return &command.QuotaCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota apply": func() (cli.Command, error) {
+			return &command.QuotaApplyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota delete": func() (cli.Command, error) {
+			return &command.QuotaDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota list": func() (cli.Command, error) {
+			return &command.QuotaListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"quota status": func() (cli.Command, error) {
+			return &command.QuotaStatusCommand{
+				Meta: meta,
+			}, nil
+		},
 		"run": func() (cli.Command, error) {
 			return &command.RunCommand{
 				Meta: meta,
@@ -146,6 +457,21 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"system": func() (cli.Command, error) {
+			return &command.SystemCommand{
+				Meta: meta,
+			}, nil
+		},
+		"system gc": func() (cli.Command, error) {
+			return &command.SystemGCCommand{
+				Meta: meta,
+			}, nil
+		},
+		"ui": func() (cli.Command, error) {
+			return &command.UiCommand{
+				Meta: meta,
+			}, nil
+		},
 		"validate": func() (cli.Command, error) {
 			return &command.ValidateCommand{
 				Meta: meta,
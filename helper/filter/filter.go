@@ -0,0 +1,508 @@
+// Package filter implements the expressions used to filter the objects
+// returned by the list endpoints, for example:
+//
+//	Status == "ready" and (NodeClass == "gpu" or Name matches "^web-")
+//
+// A selector names a field of the object, and fields of nested structs or
+// keys of maps with dots, e.g. JobSummary.Summary.web. A comparison is one of
+// ==, !=, contains, not contains, matches and not matches, against a quoted
+// string, a number or a boolean. Comparisons are combined with and, or, not
+// and parentheses.
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is a parsed filter expression
+type Filter struct {
+	expr string
+	root node
+}
+
+// Parse parses a filter expression
+func Parse(expr string) (*Filter, error) {
+	p := &parser{lexer: &lexer{input: expr}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.unexpected()
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match returns whether the object matches the filter. It returns an error if
+// the filter selects a field the object doesn't have.
+func (f *Filter) Match(obj interface{}) (bool, error) {
+	return f.root.eval(reflect.ValueOf(obj))
+}
+
+// String returns the filter expression
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// node is a node of the syntax tree of a filter expression
+type node interface {
+	eval(v reflect.Value) (bool, error)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(v reflect.Value) (bool, error) {
+	ok, err := n.left.eval(v)
+	if err != nil || !ok {
+		return false, err
+	}
+	return n.right.eval(v)
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(v reflect.Value) (bool, error) {
+	ok, err := n.left.eval(v)
+	if err != nil || ok {
+		return ok, err
+	}
+	return n.right.eval(v)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(v reflect.Value) (bool, error) {
+	ok, err := n.operand.eval(v)
+	return !ok, err
+}
+
+// Comparison operators
+const (
+	opEqual    = "=="
+	opNotEqual = "!="
+	opContains = "contains"
+	opMatches  = "matches"
+)
+
+// matchNode compares the field of the object named by the selector to a
+// literal value
+type matchNode struct {
+	selector []string
+	op       string
+	negate   bool
+	value    string
+	re       *regexp.Regexp
+}
+
+func (n *matchNode) eval(v reflect.Value) (bool, error) {
+	field, err := n.resolve(v)
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	switch n.op {
+	case opEqual:
+		ok = equal(field, n.value)
+	case opNotEqual:
+		ok = !equal(field, n.value)
+	case opContains:
+		if ok, err = contains(field, n.value); err != nil {
+			return false, fmt.Errorf("%s: %v", strings.Join(n.selector, "."), err)
+		}
+	case opMatches:
+		ok = n.re.MatchString(format(field))
+	}
+	return ok != n.negate, nil
+}
+
+// resolve returns the field of the object named by the selector. The
+// returned value is invalid if a pointer on the way is nil or a map misses
+// the key.
+func (n *matchNode) resolve(v reflect.Value) (reflect.Value, error) {
+	for i, name := range n.selector {
+		v = indirect(v)
+		if !v.IsValid() {
+			return v, nil
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			f, ok := v.Type().FieldByName(name)
+			if !ok || f.PkgPath != "" {
+				return reflect.Value{}, fmt.Errorf("unknown field %q", strings.Join(n.selector[:i+1], "."))
+			}
+			v = v.FieldByIndex(f.Index)
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, fmt.Errorf("cannot select key %q of %s", name, v.Type())
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		default:
+			return reflect.Value{}, fmt.Errorf("cannot select %q of %s", strings.Join(n.selector[:i+1], "."), v.Type())
+		}
+	}
+	return indirect(v), nil
+}
+
+// indirect dereferences pointers and interfaces
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// equal returns whether the value equals the literal. Numbers are compared
+// numerically and other values by their string representation.
+func equal(v reflect.Value, literal string) bool {
+	v = indirect(v)
+	switch {
+	case !v.IsValid():
+		return literal == ""
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Float64:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return false
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()) == f
+		case reflect.Float32, reflect.Float64:
+			return v.Float() == f
+		default:
+			return float64(v.Uint()) == f
+		}
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(literal)
+		return err == nil && v.Bool() == b
+	default:
+		return format(v) == literal
+	}
+}
+
+// contains returns whether a string contains the literal, a slice or array
+// has an element equal to it or a map a key equal to it
+func contains(v reflect.Value, literal string) (bool, error) {
+	if !v.IsValid() {
+		return false, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return strings.Contains(v.String(), literal), nil
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if equal(v.Index(i), literal) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if equal(key, literal) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("contains is not supported on %s", v.Type())
+	}
+}
+
+// format returns the string representation of the value
+func format(v reflect.Value) string {
+	v = indirect(v)
+	switch {
+	case !v.IsValid():
+		return ""
+	case v.Kind() == reflect.String:
+		return v.String()
+	case v.CanInterface():
+		return fmt.Sprint(v.Interface())
+	default:
+		return ""
+	}
+}
+
+// Token kinds
+const (
+	tokEOF = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokEqual
+	tokNotEqual
+	tokDot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// lexer splits a filter expression into tokens
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if start == len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	c := l.input[start]
+	switch {
+	case c == '(':
+		l.pos++
+		return token{kind: tokLParen, text: "(", pos: start}, nil
+	case c == ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")", pos: start}, nil
+	case c == '.':
+		l.pos++
+		return token{kind: tokDot, text: ".", pos: start}, nil
+	case strings.HasPrefix(l.input[start:], opEqual):
+		l.pos += 2
+		return token{kind: tokEqual, text: opEqual, pos: start}, nil
+	case strings.HasPrefix(l.input[start:], opNotEqual):
+		l.pos += 2
+		return token{kind: tokNotEqual, text: opNotEqual, pos: start}, nil
+	case c == '"':
+		return l.lexString()
+	case c == '-' || isDigit(c):
+		l.pos++
+		for l.pos < len(l.input) && (isDigit(l.input[l.pos]) || l.input[l.pos] == '.') {
+			l.pos++
+		}
+		text := l.input[start:l.pos]
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return token{}, fmt.Errorf("invalid number %q at position %d", text, start)
+		}
+		return token{kind: tokNumber, text: text, pos: start}, nil
+	case isIdentStart(c):
+		for l.pos < len(l.input) && (isIdentStart(l.input[l.pos]) || isDigit(l.input[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.input[start:l.pos], pos: start}, nil
+	default:
+		return token{}, fmt.Errorf("unexpected character %q at position %d", c, start)
+	}
+}
+
+// lexString lexes a double quoted string with Go escape sequences
+func (l *lexer) lexString() (token, error) {
+	start := l.pos
+	for l.pos++; l.pos < len(l.input); l.pos++ {
+		switch l.input[l.pos] {
+		case '\\':
+			l.pos++
+		case '"':
+			l.pos++
+			text, err := strconv.Unquote(l.input[start:l.pos])
+			if err != nil {
+				return token{}, fmt.Errorf("invalid string at position %d: %v", start, err)
+			}
+			return token{kind: tokString, text: text, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at position %d", start)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// parser builds the syntax tree of a filter expression:
+//
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" or ")" | comparison
+//	comparison = selector operator value
+//	selector   = ident { "." ( ident | string ) }
+//	operator   = "==" | "!=" | [ "not" ] ( "contains" | "matches" )
+//	value      = string | number | "true" | "false"
+type parser struct {
+	lexer *lexer
+	tok   token
+}
+
+func (p *parser) next() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// keyword returns whether the current token is the given keyword
+func (p *parser) keyword(word string) bool {
+	return p.tok.kind == tokIdent && p.tok.text == word
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("unexpected end of filter expression")
+	}
+	return fmt.Errorf("unexpected %q at position %d", p.tok.text, p.tok.pos)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch {
+	case p.keyword("not"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	case p.tok.kind == tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.unexpected()
+		}
+		return n, p.next()
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	n := &matchNode{}
+
+	// Parse the selector
+	if p.tok.kind != tokIdent {
+		return nil, p.unexpected()
+	}
+	n.selector = append(n.selector, p.tok.text)
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokDot {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokIdent && p.tok.kind != tokString {
+			return nil, p.unexpected()
+		}
+		n.selector = append(n.selector, p.tok.text)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Parse the operator
+	if p.keyword("not") {
+		n.negate = true
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if !p.keyword(opContains) && !p.keyword(opMatches) {
+			return nil, p.unexpected()
+		}
+	}
+	switch {
+	case p.tok.kind == tokEqual, p.tok.kind == tokNotEqual,
+		p.keyword(opContains), p.keyword(opMatches):
+		n.op = p.tok.text
+	default:
+		return nil, p.unexpected()
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	// Parse the value
+	switch {
+	case p.tok.kind == tokString, p.tok.kind == tokNumber,
+		p.keyword("true"), p.keyword("false"):
+		n.value = p.tok.text
+	default:
+		return nil, p.unexpected()
+	}
+	if n.op == opMatches {
+		re, err := regexp.Compile(n.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at position %d: %v", p.tok.pos, err)
+		}
+		n.re = re
+	}
+	return n, p.next()
+}
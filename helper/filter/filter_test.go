@@ -0,0 +1,130 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+)
+
+type testResources struct {
+	CPU int
+}
+
+type testObject struct {
+	ID        string
+	Status    string
+	Count     int
+	Ratio     float64
+	Drain     bool
+	Tags      []string
+	Meta      map[string]string
+	Resources *testResources
+	Missing   *testResources
+	private   string
+}
+
+func testObj() *testObject {
+	return &testObject{
+		ID:        "web-1",
+		Status:    "running",
+		Count:     3,
+		Ratio:     0.5,
+		Tags:      []string{"a", "b"},
+		Meta:      map[string]string{"rack": "r1", "my-key": "foo"},
+		Resources: &testResources{CPU: 500},
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{`Status == "running"`, true},
+		{`Status != "running"`, false},
+		{`Status == "running" and Count == 3`, true},
+		{`Status == "pending" or Count == 3`, true},
+		{`Status == "pending" or Count == 4`, false},
+		{`not Status == "pending"`, true},
+		{`not (Status == "running" and Drain == true)`, true},
+		{`Status == "running" and (Count == 4 or Drain == false)`, true},
+		{`Ratio == 0.5`, true},
+		{`Count == "3"`, true},
+		{`Count == "abc"`, false},
+		{`Tags contains "a"`, true},
+		{`Tags not contains "a"`, false},
+		{`Tags contains "c"`, false},
+		{`Meta contains "rack"`, true},
+		{`Meta.rack == "r1"`, true},
+		{`Meta."my-key" == "foo"`, true},
+		{`Meta.other == ""`, true},
+		{`ID contains "web"`, true},
+		{`ID matches "^web-[0-9]+$"`, true},
+		{`ID not matches "^api"`, true},
+		{`Resources.CPU == 500`, true},
+		{`Missing.CPU == ""`, true},
+		{`Status == "run\"ning"`, false},
+	}
+
+	for _, c := range cases {
+		f, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", c.expr, err)
+		}
+		match, err := f.Match(testObj())
+		if err != nil {
+			t.Fatalf("failed to match %q: %v", c.expr, err)
+		}
+		if match != c.match {
+			t.Fatalf("bad: %q matched %v, expected %v", c.expr, match, c.match)
+		}
+	}
+}
+
+func TestFilter_Match_Error(t *testing.T) {
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{`Foo == "bar"`, `unknown field "Foo"`},
+		{`private == "bar"`, `unknown field "private"`},
+		{`Resources.Foo == 1`, `unknown field "Resources.Foo"`},
+		{`Status.Foo == 1`, `cannot select "Status.Foo"`},
+		{`Count contains "1"`, "contains is not supported"},
+	}
+
+	for _, c := range cases {
+		f, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", c.expr, err)
+		}
+		if _, err := f.Match(testObj()); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("bad: %q: expected error %q, got %v", c.expr, c.err, err)
+		}
+	}
+}
+
+func TestFilter_Parse_Error(t *testing.T) {
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{``, "empty filter"},
+		{`Status`, "unexpected end"},
+		{`Status ==`, "unexpected end"},
+		{`Status = "a"`, `unexpected character '='`},
+		{`Status == running`, `unexpected "running" at position 10`},
+		{`Status == "a" and`, "unexpected end"},
+		{`(Status == "a"`, "unexpected end"},
+		{`Status == "a")`, `unexpected ")"`},
+		{`Status == "a`, "unterminated string"},
+		{`Status not == "a"`, `unexpected "=="`},
+		{`Status matches "("`, "invalid regular expression"},
+		{`Count == 1.2.3`, "invalid number"},
+	}
+
+	for _, c := range cases {
+		if _, err := Parse(c.expr); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("bad: %q: expected error %q, got %v", c.expr, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Package snapshot implements the archive format used to save and restore the
+// state of the Nomad servers.
+//
+// An archive is a gzip compressed tar file with the following members:
+//
+//	meta.json  - JSON encoded metadata about the snapshot
+//	state.bin  - the serialized state of the servers' FSM
+//	SHA256SUMS - checksums of the other members
+//
+// The checksums are verified before any of the state is handed back to the
+// caller, so a truncated or corrupted archive is never restored.
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+const (
+	// Version is the version of the archive format
+	Version = 1
+
+	metaFile  = "meta.json"
+	stateFile = "state.bin"
+	sumsFile  = "SHA256SUMS"
+)
+
+// Metadata describes a snapshot
+type Metadata struct {
+	// Version is the version of the archive format
+	Version int
+
+	// Index is the Raft index the state was captured at
+	Index uint64
+
+	// CreateTime is the time the snapshot was taken
+	CreateTime time.Time
+}
+
+// Write writes an archive of the given state to the writer.
+func Write(w io.Writer, meta *Metadata, state []byte) error {
+	metaBuf, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot metadata: %v", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	archive := tar.NewWriter(gz)
+	now := time.Now()
+
+	var sums bytes.Buffer
+	members := []struct {
+		name string
+		data []byte
+	}{
+		{metaFile, metaBuf},
+		{stateFile, state},
+	}
+	for _, m := range members {
+		if err := writeMember(archive, m.name, m.data, now); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(m.data)
+		fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(sum[:]), m.name)
+	}
+	if err := writeMember(archive, sumsFile, sums.Bytes(), now); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot compression: %v", err)
+	}
+	return nil
+}
+
+// writeMember writes a single member to the archive
+func writeMember(archive *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %q header: %v", name, err)
+	}
+	if _, err := archive.Write(data); err != nil {
+		return fmt.Errorf("failed to write %q: %v", name, err)
+	}
+	return nil
+}
+
+// Read reads an archive, verifies its checksums and returns the metadata and
+// the state it contains.
+func Read(r io.Reader) (*Metadata, []byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decompress snapshot: %v", err)
+	}
+	defer gz.Close()
+
+	members := make(map[string][]byte)
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to read snapshot archive: %v", err)
+		}
+
+		switch header.Name {
+		case metaFile, stateFile, sumsFile:
+		default:
+			return nil, nil, fmt.Errorf("unexpected file %q in snapshot", header.Name)
+		}
+
+		data, err := ioutil.ReadAll(archive)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %q: %v", header.Name, err)
+		}
+		members[header.Name] = data
+	}
+
+	// Verify the checksums before trusting any of the contents
+	sums, ok := members[sumsFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("snapshot is missing %q", sumsFile)
+	}
+	if err := verifySums(sums, members); err != nil {
+		return nil, nil, err
+	}
+
+	var meta Metadata
+	if err := json.Unmarshal(members[metaFile], &meta); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode snapshot metadata: %v", err)
+	}
+	if meta.Version != Version {
+		return nil, nil, fmt.Errorf("unsupported snapshot version %d", meta.Version)
+	}
+	return &meta, members[stateFile], nil
+}
+
+// verifySums checks that the metadata and state members are present and match
+// the checksums listed in the sums member.
+func verifySums(sums []byte, members map[string][]byte) error {
+	expected := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		var sum, name string
+		if _, err := fmt.Sscanf(scanner.Text(), "%s %s", &sum, &name); err != nil {
+			return fmt.Errorf("failed to parse snapshot checksums: %v", err)
+		}
+		expected[name] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read snapshot checksums: %v", err)
+	}
+
+	for _, name := range []string{metaFile, stateFile} {
+		data, ok := members[name]
+		if !ok {
+			return fmt.Errorf("snapshot is missing %q", name)
+		}
+		sum := sha256.Sum256(data)
+		if expected[name] != hex.EncodeToString(sum[:]) {
+			return fmt.Errorf("checksum mismatch for %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchive_WriteRead(t *testing.T) {
+	meta := &Metadata{
+		Version:    Version,
+		Index:      1000,
+		CreateTime: time.Now().UTC().Round(time.Second),
+	}
+	state := []byte("some state")
+
+	var buf bytes.Buffer
+	if err := Write(&buf, meta, state); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	outMeta, outState, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(meta, outMeta) {
+		t.Fatalf("bad: %#v %#v", meta, outMeta)
+	}
+	if !bytes.Equal(state, outState) {
+		t.Fatalf("bad: %q", outState)
+	}
+}
+
+func TestArchive_Read_Corrupt(t *testing.T) {
+	// Not an archive at all
+	if _, _, err := Read(strings.NewReader("nope")); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Build an archive whose state does not match its checksums
+	var good bytes.Buffer
+	meta := &Metadata{Version: Version, Index: 10}
+	if err := Write(&good, meta, []byte("state")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	gr, err := gzip.NewReader(&good)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var bad bytes.Buffer
+	gz := gzip.NewWriter(&bad)
+	tr := tar.NewReader(gr)
+	tw := tar.NewWriter(gz)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		var data bytes.Buffer
+		if _, err := data.ReadFrom(tr); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		contents := data.Bytes()
+		if header.Name == stateFile {
+			contents = []byte("other")
+			header.Size = int64(len(contents))
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := tw.Write(contents); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+
+	_, _, err = Read(&bad)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("expected checksum error: %v", err)
+	}
+}
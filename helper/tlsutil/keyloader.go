@@ -0,0 +1,45 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync"
+)
+
+// KeyLoader holds the certificate served by a TLS listener and allows it to
+// be swapped out without recreating the listener. Its GetCertificate method
+// is meant to be set as the tls.Config callback of the same name.
+type KeyLoader struct {
+	cert *tls.Certificate
+	l    sync.RWMutex
+}
+
+// LoadKeyPair reads and parses the given certificate and key file and, if
+// successful, replaces the certificate being served. On error the
+// previously loaded certificate is retained.
+func (k *KeyLoader) LoadKeyPair(certFile, keyFile string) (*tls.Certificate, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("Both a certificate and key file must be provided")
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load cert/key pair: %v", err)
+	}
+
+	k.l.Lock()
+	k.cert = &cert
+	k.l.Unlock()
+	return &cert, nil
+}
+
+// GetCertificate returns the currently loaded certificate.
+func (k *KeyLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	k.l.RLock()
+	defer k.l.RUnlock()
+
+	if k.cert == nil {
+		return nil, fmt.Errorf("No certificate loaded")
+	}
+	return k.cert, nil
+}
@@ -0,0 +1,49 @@
+package tlsutil
+
+import (
+	"testing"
+)
+
+func TestKeyLoader_LoadKeyPair(t *testing.T) {
+	k := &KeyLoader{}
+	if _, err := k.GetCertificate(nil); err == nil {
+		t.Fatalf("expected error with no certificate loaded")
+	}
+
+	cert, err := k.LoadKeyPair(foocert, fookey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := k.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != cert {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Reload with a different pair and ensure it is served
+	cert2, err := k.LoadKeyPair(badcert, badkey)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = k.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != cert2 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// A failed load should keep serving the previous certificate
+	if _, err := k.LoadKeyPair(badcert, "/does/not/exist"); err == nil {
+		t.Fatalf("expected error")
+	}
+	out, err = k.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != cert2 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
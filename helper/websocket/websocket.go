@@ -0,0 +1,453 @@
+// Package websocket implements the subset of the WebSocket protocol (RFC 6455)
+// used to stream data between the Nomad CLI and agents: the opening handshake
+// on both sides, unfragmented writes, reads of possibly fragmented messages,
+// and the handling of ping and close frames.
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// The message types of the frames
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+// The status codes of close frames
+const (
+	CloseNormalClosure     = 1000
+	CloseNoStatusReceived  = 1005
+	CloseInternalServerErr = 1011
+)
+
+const (
+	// acceptGUID is appended to the key of the client to compute the accept
+	// header of the server.
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// maxMessageSize is the maximum size of a message read from the peer.
+	maxMessageSize = 1 << 20
+
+	// maxControlPayload is the maximum payload of a control frame.
+	maxControlPayload = 125
+)
+
+// ErrBadOrigin is returned by Upgrade when the Origin header of the request
+// doesn't match its host.
+var ErrBadOrigin = errors.New("websocket: request origin not allowed")
+
+// CloseError is returned when the peer closes the connection.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	if e.Text == "" {
+		return fmt.Sprintf("websocket: closed with status %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: closed with status %d: %s", e.Code, e.Text)
+}
+
+// Conn is a WebSocket connection. Messages may be written concurrently but
+// must be read by a single goroutine.
+type Conn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool
+
+	writeLock sync.Mutex
+	closeSent bool
+}
+
+// Upgrade upgrades the HTTP server connection to the WebSocket protocol. If
+// the request isn't a valid handshake, nothing is written to the response and
+// the error is returned. Requests sent by browsers from another origin are
+// rejected with ErrBadOrigin, as the browsers don't restrict them.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != "GET" {
+		return nil, fmt.Errorf("websocket: handshake requires the GET method")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, fmt.Errorf("websocket: not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, fmt.Errorf("websocket: unsupported version %q", r.Header.Get("Sec-WebSocket-Version"))
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("websocket: missing key")
+	}
+	if !checkSameOrigin(r) {
+		return nil, ErrBadOrigin
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("websocket: response does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(resp)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Conn{conn: conn, br: rw.Reader}, nil
+}
+
+// checkSameOrigin returns whether the request has no Origin header, as sent by
+// non-browser clients, or one whose host matches the host of the request.
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header["Origin"]
+	if len(origin) == 0 {
+		return true
+	}
+	u, err := url.Parse(origin[0])
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Dial opens a WebSocket connection to the ws or wss URL. The TLS config is
+// used for wss URLs and may be nil. If the server doesn't upgrade the
+// connection, the error includes the status code and body of its response.
+func Dial(rawurl string, header http.Header, tlsConfig *tls.Config) (*Conn, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		conn, err = net.Dial("tcp", dialAddr(u.Host, "80"))
+	case "wss":
+		// The server name defaults to the host of the address
+		conn, err = tls.Dial("tcp", dialAddr(u.Host, "443"), tlsConfig)
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn, u, header)
+}
+
+// dialAddr returns the address to dial for the host of a URL, adding the
+// default port of the scheme if the host has none.
+func dialAddr(host, defaultPort string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), defaultPort)
+}
+
+// NewClient performs the client handshake of the WebSocket connection to the
+// URL over an established connection, e.g. to a Unix domain socket. The
+// connection is closed if the handshake fails.
+func NewClient(conn net.Conn, u *url.URL, header http.Header) (*Conn, error) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req := &http.Request{
+		Method:     "GET",
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		conn.Close()
+		return nil, fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket: invalid accept key in handshake response")
+	}
+	return &Conn{conn: conn, br: br, client: true}, nil
+}
+
+// ReadMessage returns the type and data of the next text or binary message.
+// Pings are answered while waiting for it. A *CloseError is returned once the
+// peer closes the connection.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var messageType int
+	var message []byte
+	for {
+		final, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			closeErr := &CloseError{Code: CloseNoStatusReceived}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Text = string(payload[2:])
+			}
+
+			// Acknowledge the close unless it was initiated locally
+			c.writeLock.Lock()
+			if !c.closeSent {
+				c.closeSent = true
+				c.writeFrame(CloseMessage, payload[:minInt(len(payload), 2)])
+			}
+			c.writeLock.Unlock()
+			return 0, nil, closeErr
+		case continuationFrame:
+			if messageType == 0 {
+				return 0, nil, fmt.Errorf("websocket: unexpected continuation frame")
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, fmt.Errorf("websocket: expected continuation frame")
+			}
+			messageType = opcode
+		default:
+			return 0, nil, fmt.Errorf("websocket: unknown opcode %d", opcode)
+		}
+
+		if len(message)+len(payload) > maxMessageSize {
+			return 0, nil, fmt.Errorf("websocket: message exceeds %d bytes", maxMessageSize)
+		}
+		message = append(message, payload...)
+		if final {
+			return messageType, message, nil
+		}
+	}
+}
+
+// ReadJSON reads the next message and decodes it as JSON into v.
+func (c *Conn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// WriteMessage writes the data as a single frame of the message type.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if c.closeSent {
+		return fmt.Errorf("websocket: connection closed")
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// WriteJSON writes v encoded as JSON in a text message.
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// WriteClose starts the closing handshake with the status code and text. No
+// message may be written afterwards.
+func (c *Conn) WriteClose(code int, text string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if c.closeSent {
+		return nil
+	}
+	c.closeSent = true
+
+	if len(text) > maxControlPayload-2 {
+		text = text[:maxControlPayload-2]
+	}
+	payload := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	return c.writeFrame(CloseMessage, append(payload, text...))
+}
+
+// Close closes the underlying network connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// writeFrame writes a final frame. Frames sent by clients are masked. The
+// write lock must be held.
+func (c *Conn) writeFrame(opcode int, data []byte) error {
+	frame := make([]byte, 2, 14+len(data))
+	frame[0] = 0x80 | byte(opcode)
+	var length [8]byte
+	switch n := len(data); {
+	case n <= maxControlPayload:
+		frame[1] = byte(n)
+	case n <= 0xffff:
+		frame[1] = 126
+		binary.BigEndian.PutUint16(length[:2], uint16(n))
+		frame = append(frame, length[:2]...)
+	default:
+		frame[1] = 127
+		binary.BigEndian.PutUint64(length[:], uint64(n))
+		frame = append(frame, length[:]...)
+	}
+
+	if !c.client {
+		_, err := c.conn.Write(append(frame, data...))
+		return err
+	}
+
+	var key [4]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return err
+	}
+	frame[1] |= 0x80
+	frame = append(frame, key[:]...)
+	for i, b := range data {
+		frame = append(frame, b^key[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// readFrame reads the next frame and unmasks its payload.
+func (c *Conn) readFrame() (final bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("websocket: unexpected reserved bits")
+	}
+	final = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0f)
+
+	// Clients must mask their frames and servers must not
+	masked := header[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, fmt.Errorf("websocket: invalid frame masking")
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= CloseMessage && (length > maxControlPayload || !final) {
+		return false, 0, nil, fmt.Errorf("websocket: invalid control frame")
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, fmt.Errorf("websocket: frame exceeds %d bytes", maxMessageSize)
+	}
+
+	var key [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, key[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= key[i%4]
+		}
+	}
+	return final, opcode, payload, nil
+}
+
+// acceptKey returns the accept header of the server for the key of the client.
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// headerContains returns whether the comma separated header contains the
+// token, ignoring case.
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
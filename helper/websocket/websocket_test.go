@@ -0,0 +1,154 @@
+package websocket
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testEchoServer returns a server echoing the messages it receives until the
+// client closes the connection.
+func testEchoServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Upgrade(w, r)
+		if err != nil {
+			w.WriteHeader(400)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		defer conn.Close()
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(data) == "close" {
+				conn.WriteClose(CloseInternalServerErr, "closing")
+				continue
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestWebsocket_Echo(t *testing.T) {
+	srv := testEchoServer(t)
+	defer srv.Close()
+
+	conn, err := Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer conn.Close()
+
+	// Messages of each length encoding are echoed
+	for _, size := range []int{0, 10, 1000, 100000} {
+		data := bytes.Repeat([]byte("a"), size)
+		if err := conn.WriteMessage(BinaryMessage, data); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		messageType, out, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if messageType != BinaryMessage || !bytes.Equal(out, data) {
+			t.Fatalf("bad: %d %d", messageType, len(out))
+		}
+	}
+
+	in := map[string]string{"foo": "bar"}
+	if err := conn.WriteJSON(in); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var out map[string]string
+	if err := conn.ReadJSON(&out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out["foo"] != "bar" {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The close of the server is returned with its status
+	if err := conn.WriteMessage(TextMessage, []byte("close")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	closeErr, ok := err.(*CloseError)
+	if !ok || closeErr.Code != CloseInternalServerErr || closeErr.Text != "closing" {
+		t.Fatalf("bad: %v", err)
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("foo")); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestWebsocket_Dial_NotUpgraded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(404)
+		w.Write([]byte("unknown allocation"))
+	}))
+	defer srv.Close()
+
+	_, err := Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "404 (unknown allocation)") {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestWebsocket_Upgrade_Invalid(t *testing.T) {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := Upgrade(httptest.NewRecorder(), req); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestWebsocket_Upgrade_CrossOrigin(t *testing.T) {
+	srv := testEchoServer(t)
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	// Browsers connecting from another site are rejected
+	header := http.Header{}
+	header.Set("Origin", "http://example.com")
+	_, err := Dial(wsURL, header, nil)
+	if err == nil || !strings.Contains(err.Error(), ErrBadOrigin.Error()) {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// The origin of the server itself is allowed
+	header.Set("Origin", srv.URL)
+	conn, err := Dial(wsURL, header, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	conn.Close()
+}
+
+func TestWebsocket_DialAddr(t *testing.T) {
+	cases := map[string]string{
+		"example.com":      "example.com:80",
+		"example.com:4646": "example.com:4646",
+		"[::1]":            "[::1]:80",
+		"[::1]:4646":       "[::1]:4646",
+	}
+	for host, expected := range cases {
+		if addr := dialAddr(host, "80"); addr != expected {
+			t.Fatalf("%s: expected %q, got %q", host, expected, addr)
+		}
+	}
+}
+
+func TestWebsocket_AcceptKey(t *testing.T) {
+	// Example of RFC 6455
+	if key := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); key != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("bad: %v", key)
+	}
+}
@@ -26,6 +26,13 @@ var errPortLabel = fmt.Errorf("Port label does not conform to naming requirement
 // Due to current internal limitations, the entire contents of the
 // io.Reader will be copied into memory first before parsing.
 func Parse(r io.Reader) (*structs.Job, error) {
+	return ParseWithVars(r, nil)
+}
+
+// ParseWithVars parses the job spec from the given io.Reader, setting the
+// input variables declared by the job spec to the passed values. Variables
+// not set take their default value.
+func ParseWithVars(r io.Reader, vars map[string]string) (*structs.Job, error) {
 	// Copy the reader into an in-memory buffer first since HCL requires it.
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, r); err != nil {
@@ -48,11 +55,19 @@ func Parse(r io.Reader) (*structs.Job, error) {
 	// Check for invalid keys
 	valid := []string{
 		"job",
+		"variable",
+		"locals",
 	}
 	if err := checkHCLKeys(list, valid); err != nil {
 		return nil, err
 	}
 
+	// Evaluate the expressions referencing variables, locals and functions
+	ctx, err := newEvalContext(list, vars)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing: %s", err)
+	}
+
 	var job structs.Job
 
 	// Parse the job out
@@ -60,6 +75,9 @@ func Parse(r io.Reader) (*structs.Job, error) {
 	if len(matches.Items) == 0 {
 		return nil, fmt.Errorf("'job' stanza not found")
 	}
+	if err := ctx.interpolate(matches); err != nil {
+		return nil, fmt.Errorf("error parsing 'job': %s", err)
+	}
 	if err := parseJob(&job, matches); err != nil {
 		return nil, fmt.Errorf("error parsing 'job': %s", err)
 	}
@@ -98,10 +116,13 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		return err
 	}
 	delete(m, "constraint")
+	delete(m, "affinity")
+	delete(m, "spread")
 	delete(m, "meta")
 	delete(m, "update")
 	delete(m, "periodic")
 	delete(m, "vault")
+	delete(m, "parameterized")
 
 	// Set the ID and name to the object key
 	result.ID = obj.Keys[0].Token.Value().(string)
@@ -117,6 +138,11 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		return err
 	}
 
+	// A multi-region job defaults to the first of its regions
+	if _, ok := m["region"]; !ok && len(result.Regions) > 0 {
+		result.Region = result.Regions[0]
+	}
+
 	// Value should be an object
 	var listVal *ast.ObjectList
 	if ot, ok := obj.Val.(*ast.ObjectType); ok {
@@ -130,11 +156,15 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		"id",
 		"name",
 		"region",
+		"regions",
+		"namespace",
 		"all_at_once",
 		"type",
 		"priority",
 		"datacenters",
 		"constraint",
+		"affinity",
+		"spread",
 		"update",
 		"periodic",
 		"meta",
@@ -142,6 +172,7 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		"group",
 		"vault",
 		"vault_token",
+		"parameterized",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return multierror.Prefix(err, "job:")
@@ -154,6 +185,20 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse affinities
+	if o := listVal.Filter("affinity"); len(o.Items) > 0 {
+		if err := parseAffinities(&result.Affinities, o); err != nil {
+			return multierror.Prefix(err, "affinity ->")
+		}
+	}
+
+	// Parse spreads
+	if o := listVal.Filter("spread"); len(o.Items) > 0 {
+		if err := parseSpreads(&result.Spreads, o); err != nil {
+			return multierror.Prefix(err, "spread ->")
+		}
+	}
+
 	// If we have an update strategy, then parse that
 	if o := listVal.Filter("update"); len(o.Items) > 0 {
 		if err := parseUpdate(&result.Update, o); err != nil {
@@ -168,6 +213,13 @@ func parseJob(result *structs.Job, list *ast.ObjectList) error {
 		}
 	}
 
+	// If we have a parameterized definition, then parse that
+	if o := listVal.Filter("parameterized"); len(o.Items) > 0 {
+		if err := parseParameterizedJob(&result.ParameterizedJob, o); err != nil {
+			return multierror.Prefix(err, "parameterized ->")
+		}
+	}
+
 	// Parse out meta fields. These are in HCL as a list so we need
 	// to iterate over them and merge them.
 	if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -257,11 +309,18 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 		valid := []string{
 			"count",
 			"constraint",
+			"affinity",
+			"spread",
 			"restart",
+			"reschedule",
 			"meta",
 			"task",
 			"ephemeral_disk",
+			"update",
+			"migrate",
 			"vault",
+			"volume",
+			"network",
 		}
 		if err := checkHCLKeys(listVal, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
@@ -272,11 +331,18 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			return err
 		}
 		delete(m, "constraint")
+		delete(m, "affinity")
+		delete(m, "spread")
 		delete(m, "meta")
 		delete(m, "task")
 		delete(m, "restart")
+		delete(m, "reschedule")
 		delete(m, "ephemeral_disk")
+		delete(m, "update")
+		delete(m, "migrate")
 		delete(m, "vault")
+		delete(m, "volume")
+		delete(m, "network")
 
 		// Default count to 1 if not specified
 		if _, ok := m["count"]; !ok {
@@ -297,6 +363,20 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse affinities
+		if o := listVal.Filter("affinity"); len(o.Items) > 0 {
+			if err := parseAffinities(&g.Affinities, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', affinity ->", n))
+			}
+		}
+
+		// Parse spreads
+		if o := listVal.Filter("spread"); len(o.Items) > 0 {
+			if err := parseSpreads(&g.Spreads, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', spread ->", n))
+			}
+		}
+
 		// Parse restart policy
 		if o := listVal.Filter("restart"); len(o.Items) > 0 {
 			if err := parseRestartPolicy(&g.RestartPolicy, o); err != nil {
@@ -304,6 +384,13 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse reschedule policy
+		if o := listVal.Filter("reschedule"); len(o.Items) > 0 {
+			if err := parseReschedulePolicy(&g.ReschedulePolicy, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', reschedule ->", n))
+			}
+		}
+
 		// Parse ephemeral disk
 		g.EphemeralDisk = structs.DefaultEphemeralDisk()
 		if o := listVal.Filter("ephemeral_disk"); len(o.Items) > 0 {
@@ -312,6 +399,36 @@ func parseGroups(result *structs.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse the volumes requested by the group
+		if o := listVal.Filter("volume"); len(o.Items) > 0 {
+			if err := parseVolumes(&g.Volumes, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', volume ->", n))
+			}
+		}
+
+		// Parse the network shared by the tasks of the group
+		if o := listVal.Filter("network"); len(o.Items) > 0 {
+			if err := parseGroupNetwork(&g.Networks, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', network ->", n))
+			}
+		}
+
+		// Parse the update strategy, overriding the job's for this group
+		if o := listVal.Filter("update"); len(o.Items) > 0 {
+			g.Update = new(structs.UpdateStrategy)
+			if err := parseUpdate(g.Update, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', update ->", n))
+			}
+		}
+
+		// Parse the migrate strategy
+		if o := listVal.Filter("migrate"); len(o.Items) > 0 {
+			g.Migrate = structs.DefaultMigrateStrategy()
+			if err := parseMigrate(g.Migrate, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', migrate ->", n))
+			}
+		}
+
 		// Parse out meta fields. These are in HCL as a list so we need
 		// to iterate over them and merge them.
 		if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -397,8 +514,50 @@ func parseRestartPolicy(final **structs.RestartPolicy, list *ast.ObjectList) err
 	return nil
 }
 
+func parseReschedulePolicy(final **structs.ReschedulePolicy, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'reschedule' block allowed")
+	}
+
+	// Get our job object
+	obj := list.Items[0]
+
+	// Check for invalid keys
+	valid := []string{
+		"attempts",
+		"interval",
+		"delay",
+		"unlimited",
+	}
+	if err := checkHCLKeys(obj.Val, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, obj.Val); err != nil {
+		return err
+	}
+
+	var result structs.ReschedulePolicy
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &result,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*final = &result
+	return nil
+}
+
 func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error {
-	for _, o := range list.Elem().Items {
+	for _, o := range list.Items {
 		// Check for invalid keys
 		valid := []string{
 			"attribute",
@@ -407,6 +566,7 @@ func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error
 			"version",
 			"regexp",
 			"distinct_hosts",
+			"distinct_property",
 			"set_contains",
 		}
 		if err := checkHCLKeys(o.Val, valid); err != nil {
@@ -457,6 +617,14 @@ func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error
 			m["Operand"] = structs.ConstraintDistinctHosts
 		}
 
+		// If "distinct_property" is provided, set the operand to
+		// "distinct_property" and the property to the "LTarget". The "value"
+		// is the number of allocations allowed per value of the property.
+		if property, ok := m[structs.ConstraintDistinctProperty]; ok {
+			m["Operand"] = structs.ConstraintDistinctProperty
+			m["LTarget"] = property
+		}
+
 		// Build the constraint
 		var c structs.Constraint
 		if err := mapstructure.WeakDecode(m, &c); err != nil {
@@ -472,6 +640,136 @@ func parseConstraints(result *[]*structs.Constraint, list *ast.ObjectList) error
 	return nil
 }
 
+func parseAffinities(result *[]*structs.Affinity, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		// Check for invalid keys
+		valid := []string{
+			"attribute",
+			"operator",
+			"value",
+			"version",
+			"regexp",
+			"set_contains",
+			"weight",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		m["LTarget"] = m["attribute"]
+		m["RTarget"] = m["value"]
+		m["Operand"] = m["operator"]
+
+		// If "version" is provided, set the operand
+		// to "version" and the value to the "RTarget"
+		if affinity, ok := m[structs.ConstraintVersion]; ok {
+			m["Operand"] = structs.ConstraintVersion
+			m["RTarget"] = affinity
+		}
+
+		// If "regexp" is provided, set the operand
+		// to "regexp" and the value to the "RTarget"
+		if affinity, ok := m[structs.ConstraintRegex]; ok {
+			m["Operand"] = structs.ConstraintRegex
+			m["RTarget"] = affinity
+		}
+
+		// If "set_contains" is provided, set the operand
+		// to "set_contains" and the value to the "RTarget"
+		if affinity, ok := m[structs.ConstraintSetContains]; ok {
+			m["Operand"] = structs.ConstraintSetContains
+			m["RTarget"] = affinity
+		}
+
+		// Default the weight if it is not specified
+		if _, ok := m["weight"]; !ok {
+			m["weight"] = structs.DefaultAffinityWeight
+		}
+
+		// Build the affinity
+		var a structs.Affinity
+		if err := mapstructure.WeakDecode(m, &a); err != nil {
+			return err
+		}
+		if a.Operand == "" {
+			a.Operand = "="
+		}
+
+		*result = append(*result, &a)
+	}
+
+	return nil
+}
+
+func parseSpreads(result *[]*structs.Spread, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		// Check for invalid keys
+		valid := []string{
+			"attribute",
+			"weight",
+			"target",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "target")
+
+		// Default the weight if it is not specified
+		if _, ok := m["weight"]; !ok {
+			m["weight"] = structs.DefaultSpreadWeight
+		}
+
+		// Build the spread
+		var sp structs.Spread
+		if err := mapstructure.WeakDecode(m, &sp); err != nil {
+			return err
+		}
+
+		// Parse the targets, keyed by the value of the attribute
+		var listVal *ast.ObjectList
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("spread should be an object")
+		}
+		for _, item := range listVal.Filter("target").Items {
+			if len(item.Keys) != 1 {
+				return fmt.Errorf("target should have a value as its key")
+			}
+			value := item.Keys[0].Token.Value().(string)
+
+			if err := checkHCLKeys(item.Val, []string{"percent"}); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("target '%s' ->", value))
+			}
+
+			var tm map[string]interface{}
+			if err := hcl.DecodeObject(&tm, item.Val); err != nil {
+				return err
+			}
+
+			target := &structs.SpreadTarget{Value: value}
+			if err := mapstructure.WeakDecode(tm, target); err != nil {
+				return err
+			}
+			sp.SpreadTarget = append(sp.SpreadTarget, target)
+		}
+
+		*result = append(*result, &sp)
+	}
+
+	return nil
+}
+
 func parseEphemeralDisk(result **structs.EphemeralDisk, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -505,6 +803,43 @@ func parseEphemeralDisk(result **structs.EphemeralDisk, list *ast.ObjectList) er
 	return nil
 }
 
+func parseVolumes(result *map[string]*structs.VolumeRequest, list *ast.ObjectList) error {
+	volumes := make(map[string]*structs.VolumeRequest, len(list.Items))
+	for _, o := range list.Items {
+		if len(o.Keys) == 0 {
+			return fmt.Errorf("volumes must be named")
+		}
+		name := o.Keys[0].Token.Value().(string)
+		if _, ok := volumes[name]; ok {
+			return fmt.Errorf("volume '%s' defined more than once", name)
+		}
+
+		// Check for invalid keys
+		valid := []string{
+			"type",
+			"source",
+			"read_only",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", name))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		v := &structs.VolumeRequest{Name: name}
+		if err := mapstructure.WeakDecode(m, v); err != nil {
+			return err
+		}
+		volumes[name] = v
+	}
+
+	*result = volumes
+	return nil
+}
+
 // parseBool takes an interface value and tries to convert it to a boolean and
 // returns an error if the type can't be converted.
 func parseBool(value interface{}) (bool, error) {
@@ -549,12 +884,15 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 
 		// Check for invalid keys
 		valid := []string{
+			"affinity",
 			"artifact",
 			"config",
 			"constraint",
+			"dispatch_payload",
 			"driver",
 			"env",
 			"kill_timeout",
+			"lifecycle",
 			"logs",
 			"meta",
 			"resources",
@@ -562,6 +900,7 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 			"template",
 			"user",
 			"vault",
+			"volume_mount",
 		}
 		if err := checkHCLKeys(listVal, valid); err != nil {
 			return multierror.Prefix(err, fmt.Sprintf("'%s' ->", n))
@@ -571,16 +910,20 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 		if err := hcl.DecodeObject(&m, item.Val); err != nil {
 			return err
 		}
+		delete(m, "affinity")
 		delete(m, "artifact")
 		delete(m, "config")
 		delete(m, "constraint")
+		delete(m, "dispatch_payload")
 		delete(m, "env")
+		delete(m, "lifecycle")
 		delete(m, "logs")
 		delete(m, "meta")
 		delete(m, "resources")
 		delete(m, "service")
 		delete(m, "template")
 		delete(m, "vault")
+		delete(m, "volume_mount")
 
 		// Build the task
 		var t structs.Task
@@ -657,6 +1000,14 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 			}
 		}
 
+		// Parse affinities
+		if o := listVal.Filter("affinity"); len(o.Items) > 0 {
+			if err := parseAffinities(&t.Affinities, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf(
+					"'%s', affinity ->", n))
+			}
+		}
+
 		// Parse out meta fields. These are in HCL as a list so we need
 		// to iterate over them and merge them.
 		if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -733,6 +1084,66 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 			t.Vault = v
 		}
 
+		// If we have a dispatch_payload block parse that
+		if o := listVal.Filter("dispatch_payload"); len(o.Items) > 0 {
+			if len(o.Items) > 1 {
+				return fmt.Errorf("only one dispatch_payload block is allowed in a task. Number of dispatch_payload blocks found: %d", len(o.Items))
+			}
+			var m map[string]interface{}
+			dispatchBlock := o.Items[0]
+
+			// Check for invalid keys
+			valid := []string{
+				"file",
+			}
+			if err := checkHCLKeys(dispatchBlock.Val, valid); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', dispatch_payload ->", n))
+			}
+
+			if err := hcl.DecodeObject(&m, dispatchBlock.Val); err != nil {
+				return err
+			}
+
+			t.DispatchPayload = &structs.DispatchPayloadConfig{}
+			if err := mapstructure.WeakDecode(m, t.DispatchPayload); err != nil {
+				return err
+			}
+		}
+
+		// If we have a lifecycle block parse that
+		if o := listVal.Filter("lifecycle"); len(o.Items) > 0 {
+			if len(o.Items) > 1 {
+				return fmt.Errorf("only one lifecycle block is allowed in a task. Number of lifecycle blocks found: %d", len(o.Items))
+			}
+			var m map[string]interface{}
+			lifecycleBlock := o.Items[0]
+
+			// Check for invalid keys
+			valid := []string{
+				"hook",
+				"sidecar",
+			}
+			if err := checkHCLKeys(lifecycleBlock.Val, valid); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', lifecycle ->", n))
+			}
+
+			if err := hcl.DecodeObject(&m, lifecycleBlock.Val); err != nil {
+				return err
+			}
+
+			t.Lifecycle = &structs.TaskLifecycleConfig{}
+			if err := mapstructure.WeakDecode(m, t.Lifecycle); err != nil {
+				return err
+			}
+		}
+
+		// Parse the volume mounts
+		if o := listVal.Filter("volume_mount"); len(o.Items) > 0 {
+			if err := parseVolumeMounts(&t.VolumeMounts, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', volume_mount ->", n))
+			}
+		}
+
 		*result = append(*result, &t)
 	}
 
@@ -740,7 +1151,7 @@ func parseTasks(jobName string, taskGroupName string, result *[]*structs.Task, l
 }
 
 func parseArtifacts(result *[]*structs.TaskArtifact, list *ast.ObjectList) error {
-	for _, o := range list.Elem().Items {
+	for _, o := range list.Items {
 		// Check for invalid keys
 		valid := []string{
 			"source",
@@ -789,6 +1200,32 @@ func parseArtifacts(result *[]*structs.TaskArtifact, list *ast.ObjectList) error
 	return nil
 }
 
+func parseVolumeMounts(result *[]*structs.VolumeMount, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		// Check for invalid keys
+		valid := []string{
+			"volume",
+			"destination",
+			"read_only",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		var v structs.VolumeMount
+		if err := mapstructure.WeakDecode(m, &v); err != nil {
+			return err
+		}
+		*result = append(*result, &v)
+	}
+	return nil
+}
+
 func parseArtifactOption(result map[string]string, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -811,7 +1248,7 @@ func parseArtifactOption(result map[string]string, list *ast.ObjectList) error {
 }
 
 func parseTemplates(result *[]*structs.Template, list *ast.ObjectList) error {
-	for _, o := range list.Elem().Items {
+	for _, o := range list.Items {
 		// Check for invalid keys
 		valid := []string{
 			"source",
@@ -921,6 +1358,9 @@ func parseChecks(service *structs.Service, checkObjs *ast.ObjectList) error {
 			"command",
 			"args",
 			"initial_status",
+			"grpc_service",
+			"grpc_use_tls",
+			"check_restart",
 		}
 		if err := checkHCLKeys(co.Val, valid); err != nil {
 			return multierror.Prefix(err, "check ->")
@@ -931,6 +1371,7 @@ func parseChecks(service *structs.Service, checkObjs *ast.ObjectList) error {
 		if err := hcl.DecodeObject(&cm, co.Val); err != nil {
 			return err
 		}
+		delete(cm, "check_restart")
 		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
 			WeaklyTypedInput: true,
@@ -943,12 +1384,57 @@ func parseChecks(service *structs.Service, checkObjs *ast.ObjectList) error {
 			return err
 		}
 
+		// Parse the check restart policy
+		if ot, ok := co.Val.(*ast.ObjectType); ok {
+			if o := ot.List.Filter("check_restart"); len(o.Items) > 0 {
+				check.CheckRestart = &structs.CheckRestart{}
+				if err := parseCheckRestart(check.CheckRestart, o); err != nil {
+					return multierror.Prefix(err, "check ->")
+				}
+			}
+		}
+
 		service.Checks[idx] = &check
 	}
 
 	return nil
 }
 
+func parseCheckRestart(result *structs.CheckRestart, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'check_restart' block allowed")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"limit",
+		"grace",
+		"ignore_warnings",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return multierror.Prefix(err, "check_restart ->")
+	}
+
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           result,
+	})
+	if err != nil {
+		return err
+	}
+	return dec.Decode(m)
+}
+
 func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) == 0 {
@@ -972,10 +1458,12 @@ func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 	// Check for invalid keys
 	valid := []string{
 		"cpu",
+		"cores",
 		"iops",
 		"disk",
 		"memory",
 		"network",
+		"device",
 	}
 	if err := checkHCLKeys(listVal, valid); err != nil {
 		return multierror.Prefix(err, "resources ->")
@@ -986,6 +1474,7 @@ func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 		return err
 	}
 	delete(m, "network")
+	delete(m, "device")
 
 	if err := mapstructure.WeakDecode(m, result); err != nil {
 		return err
@@ -999,6 +1488,7 @@ func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 
 		// Check for invalid keys
 		valid := []string{
+			"address_family",
 			"mbits",
 			"port",
 		}
@@ -1028,6 +1518,13 @@ func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 		result.Networks = []*structs.NetworkResource{&r}
 	}
 
+	// Parse the device resources
+	if o := listVal.Filter("device"); len(o.Items) > 0 {
+		if err := parseDevices(&result.Devices, o); err != nil {
+			return multierror.Prefix(err, "resources ->")
+		}
+	}
+
 	// Combine the parsed resources with a default resource block.
 	min := structs.DefaultResources()
 	min.Merge(result)
@@ -1035,9 +1532,81 @@ func parseResources(result *structs.Resources, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseDevices(result *[]*structs.DeviceResource, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		if len(o.Keys) == 0 {
+			return fmt.Errorf("devices must be named")
+		}
+		name := o.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"count",
+		}
+		if err := checkHCLKeys(o.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("device '%s' ->", name))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		// Request a single device by default
+		d := &structs.DeviceResource{Name: name, Count: 1}
+		if err := mapstructure.WeakDecode(m, d); err != nil {
+			return err
+		}
+		*result = append(*result, d)
+	}
+	return nil
+}
+
+func parseGroupNetwork(result *[]*structs.NetworkResource, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'network' block allowed")
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"mode",
+		"address_family",
+		"mbits",
+		"port",
+	}
+	if err := checkHCLKeys(list.Items[0].Val, valid); err != nil {
+		return err
+	}
+
+	var r structs.NetworkResource
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, list.Items[0].Val); err != nil {
+		return err
+	}
+	delete(m, "port")
+	if err := mapstructure.WeakDecode(m, &r); err != nil {
+		return err
+	}
+
+	var networkObj *ast.ObjectList
+	if ot, ok := list.Items[0].Val.(*ast.ObjectType); ok {
+		networkObj = ot.List
+	} else {
+		return fmt.Errorf("network: should be an object")
+	}
+	if err := parsePorts(networkObj, &r); err != nil {
+		return multierror.Prefix(err, "ports ->")
+	}
+
+	*result = []*structs.NetworkResource{&r}
+	return nil
+}
+
 func parsePorts(networkObj *ast.ObjectList, nw *structs.NetworkResource) error {
 	// Check for invalid keys
 	valid := []string{
+		"mode",
+		"address_family",
 		"mbits",
 		"port",
 	}
@@ -1081,7 +1650,7 @@ func parsePorts(networkObj *ast.ObjectList, nw *structs.NetworkResource) error {
 func parseUpdate(result *structs.UpdateStrategy, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
-		return fmt.Errorf("only one 'update' block allowed per job")
+		return fmt.Errorf("only one 'update' block allowed")
 	}
 
 	// Get our resource object
@@ -1096,6 +1665,46 @@ func parseUpdate(result *structs.UpdateStrategy, list *ast.ObjectList) error {
 	valid := []string{
 		"stagger",
 		"max_parallel",
+		"canary",
+		"auto_revert",
+		"health_check",
+		"healthy_deadline",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           result,
+	})
+	if err != nil {
+		return err
+	}
+	return dec.Decode(m)
+}
+
+func parseMigrate(result *structs.MigrateStrategy, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'migrate' block allowed")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"max_parallel",
+		"health_check",
+		"min_healthy_time",
+		"healthy_deadline",
 	}
 	if err := checkHCLKeys(o.Val, valid); err != nil {
 		return err
@@ -1162,6 +1771,40 @@ func parsePeriodic(result **structs.PeriodicConfig, list *ast.ObjectList) error
 	return nil
 }
 
+func parseParameterizedJob(result **structs.ParameterizedJobConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'parameterized' block allowed per job")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"payload",
+		"meta_required",
+		"meta_optional",
+	}
+	if err := checkHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	// Build the parameterized job block
+	var d structs.ParameterizedJobConfig
+	if err := mapstructure.WeakDecode(m, &d); err != nil {
+		return err
+	}
+
+	*result = &d
+	return nil
+}
+
 func parseVault(result *structs.Vault, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) == 0 {
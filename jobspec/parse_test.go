@@ -1,6 +1,8 @@
 package jobspec
 
 import (
+	"bytes"
+	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -28,6 +30,7 @@ func TestParse(t *testing.T) {
 				AllAtOnce:   true,
 				Datacenters: []string{"us2", "eu1"},
 				Region:      "global",
+				Namespace:   "foo",
 				VaultToken:  "foo",
 
 				Meta: map[string]string{
@@ -117,6 +120,11 @@ func TestParse(t *testing.T) {
 												PortLabel: "admin",
 												Interval:  10 * time.Second,
 												Timeout:   2 * time.Second,
+												CheckRestart: &structs.CheckRestart{
+													Limit:          3,
+													Grace:          10 * time.Second,
+													IgnoreWarnings: true,
+												},
 											},
 										},
 									},
@@ -131,9 +139,10 @@ func TestParse(t *testing.T) {
 									IOPS:     0,
 									Networks: []*structs.NetworkResource{
 										&structs.NetworkResource{
+											AddressFamily: structs.NetworkAddressFamilyDual,
 											MBits:         100,
-											ReservedPorts: []structs.Port{{"one", 1}, {"two", 2}, {"three", 3}},
-											DynamicPorts:  []structs.Port{{"http", 0}, {"https", 0}, {"admin", 0}},
+											ReservedPorts: []structs.Port{{"one", 1, 0}, {"two", 2, 0}, {"three", 3, 0}},
+											DynamicPorts:  []structs.Port{{"http", 0, 0}, {"https", 0, 0}, {"admin", 0, 0}},
 										},
 									},
 								},
@@ -318,6 +327,25 @@ func TestParse(t *testing.T) {
 			false,
 		},
 
+		{
+			"distinctProperty-constraint.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Priority: 50,
+				Region:   "global",
+				Type:     "service",
+				Constraints: []*structs.Constraint{
+					&structs.Constraint{
+						Operand: structs.ConstraintDistinctProperty,
+						LTarget: "${meta.rack}",
+						RTarget: "2",
+					},
+				},
+			},
+			false,
+		},
+
 		{
 			"periodic-cron.hcl",
 			&structs.Job{
@@ -537,6 +565,436 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"group-update.hcl",
+			&structs.Job{
+				ID:       "example",
+				Name:     "example",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				Update: structs.UpdateStrategy{
+					Stagger:     60 * time.Second,
+					MaxParallel: 2,
+				},
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "cache",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Update: &structs.UpdateStrategy{
+							Stagger:         30 * time.Second,
+							MaxParallel:     1,
+							Canary:          1,
+							AutoRevert:      true,
+							HealthCheck:     structs.UpdateHealthCheckChecks,
+							HealthyDeadline: 2 * time.Minute,
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "redis",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+					&structs.TaskGroup{
+						Name:          "cache2",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "redis",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"group-migrate.hcl",
+			&structs.Job{
+				ID:       "example",
+				Name:     "example",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "cache",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Migrate: &structs.MigrateStrategy{
+							MaxParallel:     2,
+							HealthCheck:     structs.MigrateHealthCheckNone,
+							MinHealthyTime:  10 * time.Second,
+							HealthyDeadline: time.Minute,
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "redis",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"group-reschedule.hcl",
+			&structs.Job{
+				ID:       "example",
+				Name:     "example",
+				Type:     "batch",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "cache",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						ReschedulePolicy: &structs.ReschedulePolicy{
+							Attempts: 3,
+							Interval: 2 * time.Hour,
+							Delay:    time.Minute,
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "redis",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"parameterized_job.hcl",
+			&structs.Job{
+				ID:       "parameterized_job",
+				Name:     "parameterized_job",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				ParameterizedJob: &structs.ParameterizedJobConfig{
+					Payload:      "required",
+					MetaRequired: []string{"foo", "bar"},
+					MetaOptional: []string{"baz", "bam"},
+				},
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "foo",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "bar",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								DispatchPayload: &structs.DispatchPayloadConfig{
+									File: "foo/bar",
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"affinity.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				Affinities: []*structs.Affinity{
+					&structs.Affinity{
+						LTarget: "${node.datacenter}",
+						RTarget: "dc1",
+						Operand: "=",
+						Weight:  100,
+					},
+				},
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Affinities: []*structs.Affinity{
+							&structs.Affinity{
+								LTarget: "${meta.rack}",
+								RTarget: "r1",
+								Operand: "!=",
+								Weight:  -50,
+							},
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "baz",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								Affinities: []*structs.Affinity{
+									&structs.Affinity{
+										LTarget: "${attr.kernel.version}",
+										RTarget: "[0-9.]+",
+										Operand: structs.ConstraintRegex,
+										Weight:  structs.DefaultAffinityWeight,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"spread.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				Spreads: []*structs.Spread{
+					&structs.Spread{
+						Attribute: "${node.datacenter}",
+						Weight:    100,
+						SpreadTarget: []*structs.SpreadTarget{
+							&structs.SpreadTarget{
+								Value:   "dc1",
+								Percent: 70,
+							},
+							&structs.SpreadTarget{
+								Value:   "dc2",
+								Percent: 30,
+							},
+						},
+					},
+				},
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Spreads: []*structs.Spread{
+							&structs.Spread{
+								Attribute: "${meta.rack}",
+								Weight:    structs.DefaultSpreadWeight,
+							},
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "baz",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"multi-region.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "us",
+				Regions:  []string{"us", "eu"},
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "baz",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"devices.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:   "baz",
+								Driver: "docker",
+								Resources: &structs.Resources{
+									CPU:      100,
+									MemoryMB: 10,
+									Devices: []*structs.DeviceResource{
+										{Name: "nvidia/gpu", Count: 2},
+										{Name: "fpga", Count: 1},
+									},
+								},
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"volumes.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Volumes: map[string]*structs.VolumeRequest{
+							"certs": &structs.VolumeRequest{
+								Name:     "certs",
+								Type:     "host",
+								Source:   "ca-certs",
+								ReadOnly: true,
+							},
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "baz",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								VolumeMounts: []*structs.VolumeMount{
+									&structs.VolumeMount{
+										Volume:      "certs",
+										Destination: "/etc/ssl/certs",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"lifecycle.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "init",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								Lifecycle: &structs.TaskLifecycleConfig{
+									Hook: structs.TaskLifecycleHookPrestart,
+								},
+							},
+							&structs.Task{
+								Name:      "proxy",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								Lifecycle: &structs.TaskLifecycleConfig{
+									Hook:    structs.TaskLifecycleHookPrestart,
+									Sidecar: true,
+								},
+							},
+							&structs.Task{
+								Name:      "web",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+							&structs.Task{
+								Name:      "cleanup",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+								Lifecycle: &structs.TaskLifecycleConfig{
+									Hook: structs.TaskLifecycleHookPoststop,
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"group-network.hcl",
+			&structs.Job{
+				ID:       "foo",
+				Name:     "foo",
+				Type:     "service",
+				Priority: 50,
+				Region:   "global",
+				TaskGroups: []*structs.TaskGroup{
+					&structs.TaskGroup{
+						Name:          "bar",
+						Count:         1,
+						EphemeralDisk: structs.DefaultEphemeralDisk(),
+						Networks: []*structs.NetworkResource{
+							&structs.NetworkResource{
+								Mode:          structs.NetworkModeBridge,
+								MBits:         20,
+								ReservedPorts: []structs.Port{{Label: "http", Value: 80, To: 8080}},
+								DynamicPorts:  []structs.Port{{Label: "admin", To: 9090}},
+							},
+						},
+						Tasks: []*structs.Task{
+							&structs.Task{
+								Name:      "web",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+							&structs.Task{
+								Name:      "proxy",
+								Driver:    "docker",
+								LogConfig: structs.DefaultLogConfig(),
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -682,3 +1140,120 @@ func TestIncorrectKey(t *testing.T) {
 		t.Fatalf("Expected collision error; got %v", err)
 	}
 }
+
+func TestParseWithVars(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("./test-fixtures", "variables.hcl"))
+	if err != nil {
+		t.Fatalf("Can't get absolute path for file: %s", err)
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	vars, err := ParseVarFile(filepath.Join("./test-fixtures", "variables.vars"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	vars["datacenter"] = "dc2"
+
+	job, err := ParseWithVars(bytes.NewReader(contents), vars)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if job.ID != "web-dc2" || !reflect.DeepEqual(job.Datacenters, []string{"dc2"}) {
+		t.Fatalf("bad: %#v", job)
+	}
+	tg := job.TaskGroups[0]
+	if tg.Count != 3 {
+		t.Fatalf("bad: %#v", tg)
+	}
+	task := tg.Tasks[0]
+	if task.Config["image"] != "redis:latest" {
+		t.Fatalf("bad: %#v", task.Config)
+	}
+
+	// Interpolations of the runtime environment are left as is
+	expEnv := map[string]string{
+		"SERVICE": "WEB-DC2",
+		"PORT":    "${NOMAD_PORT_http}",
+	}
+	if !reflect.DeepEqual(task.Env, expEnv) {
+		t.Fatalf("bad: %#v", task.Env)
+	}
+}
+
+func TestParseWithVars_Errors(t *testing.T) {
+	cases := []struct {
+		Spec string
+		Vars map[string]string
+		Err  string
+	}{
+		{
+			`variable "image" {}
+			job "foo" {}`,
+			nil,
+			`variable "image" is required`,
+		},
+		{
+			`job "foo" {}`,
+			map[string]string{"image": "redis"},
+			`undeclared variable "image"`,
+		},
+		{
+			`job "${var.name}" {}`,
+			nil,
+			`unknown variable "name"`,
+		},
+		{
+			`locals {
+				a = "${local.b}"
+				b = "${local.a}"
+			}
+			job "${local.a}" {}`,
+			nil,
+			`references itself`,
+		},
+		{
+			`job "${md5("foo")}" {}`,
+			nil,
+			`unknown function "md5"`,
+		},
+		{
+			`job "${upper("a", "b")}" {}`,
+			nil,
+			`expected 1 argument`,
+		},
+		{
+			`variable "dcs" {
+				default = ["dc1", "dc2"]
+			}
+			job "foo" {}`,
+			nil,
+			`list and map values are not supported`,
+		},
+		{
+			`locals {
+				tags = { a = "b" }
+			}
+			job "foo" {}`,
+			nil,
+			`list and map values are not supported`,
+		},
+		{
+			`job "foo" {
+				datacenters = ["${[for dc in var.dcs : upper(dc)]}"]
+			}`,
+			nil,
+			`for-expressions and list or map values are not supported`,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseWithVars(strings.NewReader(tc.Spec), tc.Vars)
+		if err == nil || !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("expected error containing %q, got: %v", tc.Err, err)
+		}
+	}
+}
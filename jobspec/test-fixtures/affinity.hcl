@@ -0,0 +1,22 @@
+job "foo" {
+	affinity {
+		attribute = "${node.datacenter}"
+		value = "dc1"
+		weight = 100
+	}
+	group "bar" {
+		affinity {
+			attribute = "${meta.rack}"
+			operator = "!="
+			value = "r1"
+			weight = -50
+		}
+		task "baz" {
+			driver = "docker"
+			affinity {
+				attribute = "${attr.kernel.version}"
+				regexp = "[0-9.]+"
+			}
+		}
+	}
+}
@@ -1,5 +1,6 @@
 job "binstore-storagelocker" {
   region      = "global"
+  namespace   = "foo"
   type        = "service"
   priority    = 50
   all_at_once = true
@@ -79,6 +80,12 @@ job "binstore-storagelocker" {
           interval = "10s"
           timeout  = "2s"
           port     = "admin"
+
+          check_restart {
+            limit           = 3
+            grace           = "10s"
+            ignore_warnings = true
+          }
         }
       }
 
@@ -87,7 +94,8 @@ job "binstore-storagelocker" {
         memory = 128
 
         network {
-          mbits = "100"
+          mbits          = "100"
+          address_family = "dual"
 
           port "one" {
             static = 1
@@ -0,0 +1,13 @@
+job "foo" {
+	group "bar" {
+		task "baz" {
+			driver = "docker"
+			resources {
+				device "nvidia/gpu" {
+					count = 2
+				}
+				device "fpga" {}
+			}
+		}
+	}
+}
@@ -0,0 +1,6 @@
+job "foo" {
+    constraint {
+        distinct_property = "${meta.rack}"
+        value = "2"
+    }
+}
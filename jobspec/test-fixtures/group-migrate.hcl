@@ -0,0 +1,11 @@
+job "example" {
+	group "cache" {
+		migrate {
+			max_parallel = 2
+			health_check = "none"
+			healthy_deadline = "1m"
+		}
+
+		task "redis" { }
+	}
+}
@@ -0,0 +1,25 @@
+job "foo" {
+	group "bar" {
+		network {
+			mode  = "bridge"
+			mbits = 20
+
+			port "http" {
+				static = 80
+				to     = 8080
+			}
+
+			port "admin" {
+				to = 9090
+			}
+		}
+
+		task "web" {
+			driver = "docker"
+		}
+
+		task "proxy" {
+			driver = "docker"
+		}
+	}
+}
@@ -0,0 +1,13 @@
+job "example" {
+	type = "batch"
+
+	group "cache" {
+		reschedule {
+			attempts = 3
+			interval = "2h"
+			delay = "1m"
+		}
+
+		task "redis" { }
+	}
+}
@@ -0,0 +1,23 @@
+job "example" {
+	update {
+		stagger = "60s"
+		max_parallel = 2
+	}
+
+	group "cache" {
+		update {
+			stagger = "30s"
+			max_parallel = 1
+			canary = 1
+			auto_revert = true
+			health_check = "checks"
+			healthy_deadline = "2m"
+		}
+
+		task "redis" { }
+	}
+
+	group "cache2" {
+		task "redis" { }
+	}
+}
@@ -0,0 +1,8 @@
+job "foo" {
+	regions = ["us", "eu"]
+	group "bar" {
+		task "baz" {
+			driver = "docker"
+		}
+	}
+}
@@ -0,0 +1,20 @@
+job "foo" {
+	spread {
+		attribute = "${node.datacenter}"
+		weight = 100
+		target "dc1" {
+			percent = 70
+		}
+		target "dc2" {
+			percent = 30
+		}
+	}
+	group "bar" {
+		spread {
+			attribute = "${meta.rack}"
+		}
+		task "baz" {
+			driver = "docker"
+		}
+	}
+}
@@ -0,0 +1,36 @@
+variable "datacenter" {
+  description = "The datacenter to run the job in"
+  default     = "dc1"
+}
+
+variable "image" {}
+
+variable "count" {
+  default = 2
+}
+
+locals {
+  name  = "web-${var.datacenter}"
+  image = "${lower(var.image)}:latest"
+}
+
+job "${local.name}" {
+  datacenters = ["${var.datacenter}"]
+
+  group "web" {
+    count = "${var.count}"
+
+    task "server" {
+      driver = "docker"
+
+      config {
+        image = "${local.image}"
+      }
+
+      env {
+        SERVICE = "${upper(local.name)}"
+        PORT    = "${NOMAD_PORT_http}"
+      }
+    }
+  }
+}
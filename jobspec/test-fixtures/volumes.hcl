@@ -0,0 +1,17 @@
+job "foo" {
+	group "bar" {
+		volume "certs" {
+			type      = "host"
+			source    = "ca-certs"
+			read_only = true
+		}
+
+		task "baz" {
+			driver = "docker"
+			volume_mount {
+				volume      = "certs"
+				destination = "/etc/ssl/certs"
+			}
+		}
+	}
+}
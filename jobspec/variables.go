@@ -0,0 +1,438 @@
+package jobspec
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// funcs are the functions that can be called in expressions.
+var funcs = map[string]func(args []string) (string, error){
+	"lower":     oneArg(strings.ToLower),
+	"upper":     oneArg(strings.ToUpper),
+	"title":     oneArg(strings.Title),
+	"trimspace": oneArg(strings.TrimSpace),
+	"replace": func(args []string) (string, error) {
+		if len(args) != 3 {
+			return "", fmt.Errorf("expected 3 arguments, got %d", len(args))
+		}
+		return strings.Replace(args[0], args[1], args[2], -1), nil
+	},
+	"join": func(args []string) (string, error) {
+		if len(args) < 1 {
+			return "", fmt.Errorf("expected at least 1 argument, got %d", len(args))
+		}
+		return strings.Join(args[1:], args[0]), nil
+	},
+	"coalesce": func(args []string) (string, error) {
+		for _, arg := range args {
+			if arg != "" {
+				return arg, nil
+			}
+		}
+		return "", fmt.Errorf("no non-empty argument")
+	},
+	"format": func(args []string) (string, error) {
+		if len(args) < 1 {
+			return "", fmt.Errorf("expected at least 1 argument, got %d", len(args))
+		}
+		values := make([]interface{}, len(args)-1)
+		for i, arg := range args[1:] {
+			values[i] = arg
+		}
+		return fmt.Sprintf(args[0], values...), nil
+	},
+}
+
+// oneArg wraps a function of a single string into an expression function.
+func oneArg(f func(string) string) func([]string) (string, error) {
+	return func(args []string) (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return f(args[0]), nil
+	}
+}
+
+// ParseVarFile parses the file at the given path setting the values of input
+// variables. The file assigns a value to each variable by name:
+//
+//	datacenter = "dc1"
+//	count      = 3
+func ParseVarFile(path string) (map[string]string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.Decode(&m, string(contents)); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+
+	vars := make(map[string]string, len(m))
+	for name, raw := range m {
+		switch v := raw.(type) {
+		case string, int, int64, float64, bool:
+			vars[name] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("error parsing %s: variable %q must be a string, number or bool, list and map values are not supported", path, name)
+		}
+	}
+	return vars, nil
+}
+
+// evalContext evaluates the expressions of a job file referencing its input
+// variables and local values.
+type evalContext struct {
+	// vars are the values of the input variables
+	vars map[string]string
+
+	// locals are the expressions of the local values and values caches
+	// their results
+	locals     map[string]*ast.LiteralType
+	values     map[string]string
+	evaluating map[string]bool
+}
+
+// newEvalContext returns the evaluation context of the variable and locals
+// blocks of the file given the values set by the user.
+func newEvalContext(list *ast.ObjectList, vars map[string]string) (*evalContext, error) {
+	ctx := &evalContext{
+		vars:       make(map[string]string),
+		locals:     make(map[string]*ast.LiteralType),
+		values:     make(map[string]string),
+		evaluating: make(map[string]bool),
+	}
+
+	for _, item := range list.Filter("variable").Items {
+		if len(item.Keys) != 1 {
+			return nil, fmt.Errorf("variable block must have a name")
+		}
+		name := item.Keys[0].Token.Value().(string)
+		if _, ok := ctx.vars[name]; ok {
+			return nil, fmt.Errorf("variable %q defined more than once", name)
+		}
+
+		obj, ok := item.Val.(*ast.ObjectType)
+		if !ok {
+			return nil, fmt.Errorf("variable %q should be an object", name)
+		}
+		valid := []string{
+			"default",
+			"description",
+		}
+		if err := checkHCLKeys(obj.List, valid); err != nil {
+			return nil, multierror.Prefix(err, fmt.Sprintf("variable %q ->", name))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, obj); err != nil {
+			return nil, err
+		}
+
+		if v, ok := vars[name]; ok {
+			ctx.vars[name] = v
+			continue
+		}
+		switch v := m["default"].(type) {
+		case nil:
+			return nil, fmt.Errorf("variable %q is required but no value was set", name)
+		case string, int, int64, float64, bool:
+			ctx.vars[name] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("variable %q default must be a string, number or bool, list and map values are not supported", name)
+		}
+	}
+
+	for name := range vars {
+		if _, ok := ctx.vars[name]; !ok {
+			return nil, fmt.Errorf("value set for undeclared variable %q", name)
+		}
+	}
+
+	for _, item := range list.Filter("locals").Items {
+		obj, ok := item.Val.(*ast.ObjectType)
+		if !ok || len(item.Keys) != 0 {
+			return nil, fmt.Errorf("locals block should be an object without a name")
+		}
+		for _, local := range obj.List.Items {
+			name := local.Keys[0].Token.Value().(string)
+			if _, ok := ctx.locals[name]; ok {
+				return nil, fmt.Errorf("local value %q defined more than once", name)
+			}
+			lit, ok := local.Val.(*ast.LiteralType)
+			if !ok {
+				return nil, fmt.Errorf("local value %q must be a string, number or bool, list and map values are not supported", name)
+			}
+			ctx.locals[name] = lit
+		}
+	}
+
+	return ctx, nil
+}
+
+// interpolate replaces the expressions referencing input variables, local
+// values or functions in the string literals and keys of the node. Other
+// interpolations, such as those of the runtime environment, are left as is.
+func (c *evalContext) interpolate(node ast.Node) error {
+	var err error
+	ast.Walk(node, func(n ast.Node) (ast.Node, bool) {
+		if err != nil {
+			return n, false
+		}
+		switch n := n.(type) {
+		case *ast.ObjectKey:
+			err = c.interpolateToken(&n.Token)
+		case *ast.LiteralType:
+			err = c.interpolateToken(&n.Token)
+		}
+		return n, true
+	})
+	return err
+}
+
+// interpolateToken replaces the expressions in the text of a string or
+// heredoc token.
+func (c *evalContext) interpolateToken(t *token.Token) error {
+	if t.Type != token.STRING && t.Type != token.HEREDOC {
+		return nil
+	}
+
+	quote := t.Type == token.STRING
+	text, err := c.replace(t.Text, quote)
+	if err != nil {
+		return err
+	}
+	t.Text = text
+	return nil
+}
+
+// local returns the value of the named local value, evaluating it on first
+// use.
+func (c *evalContext) local(name string) (string, error) {
+	if v, ok := c.values[name]; ok {
+		return v, nil
+	}
+	lit, ok := c.locals[name]
+	if !ok {
+		return "", fmt.Errorf("unknown local value %q", name)
+	}
+	if c.evaluating[name] {
+		return "", fmt.Errorf("local value %q references itself", name)
+	}
+	c.evaluating[name] = true
+	defer delete(c.evaluating, name)
+
+	var v string
+	switch lit.Token.Type {
+	case token.STRING, token.HEREDOC:
+		t := lit.Token
+		if err := c.interpolateToken(&t); err != nil {
+			return "", fmt.Errorf("local value %q: %v", name, err)
+		}
+		v = t.Value().(string)
+	default:
+		v = fmt.Sprint(lit.Token.Value())
+	}
+
+	c.values[name] = v
+	return v, nil
+}
+
+// replace replaces the expressions enclosed in ${} of the text with their
+// values. If quote is set, the values are escaped to be placed in a quoted
+// string.
+func (c *evalContext) replace(text string, quote bool) (string, error) {
+	var out []byte
+	for {
+		i := strings.Index(text, "${")
+		if i < 0 {
+			return string(append(out, text...)), nil
+		}
+		out = append(out, text[:i+2]...)
+		text = text[i+2:]
+
+		p := &exprParser{ctx: c, input: text}
+		if !p.evaluated() {
+			continue
+		}
+
+		v, err := p.parseExpr()
+		if err == nil {
+			p.skipSpace()
+			if !p.consume('}') {
+				err = fmt.Errorf("expected '}' at %q", p.input[p.pos:])
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("error evaluating expression: %v", err)
+		}
+
+		if quote {
+			v = strings.Trim(strconv.Quote(v), `"`)
+		}
+		out = append(out[:len(out)-2], v...)
+		text = text[p.pos:]
+	}
+}
+
+// exprParser parses and evaluates an expression. Expressions are string and
+// number literals, references to input variables and local values, and
+// function calls.
+type exprParser struct {
+	ctx   *evalContext
+	input string
+	pos   int
+}
+
+// evaluated returns whether the interpolation starting at the input is an
+// expression to evaluate rather than a reference to the runtime environment.
+// List and map expressions are evaluated so that they are rejected.
+func (p *exprParser) evaluated() bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && (p.input[p.pos] == '[' || p.input[p.pos] == '{') {
+		return true
+	}
+	ident := p.peekIdent()
+	rest := p.input[p.pos+len(ident):]
+	return ident == "var" && strings.HasPrefix(rest, ".") ||
+		ident == "local" && strings.HasPrefix(rest, ".") ||
+		ident != "" && strings.HasPrefix(strings.TrimLeftFunc(rest, unicode.IsSpace), "(")
+}
+
+func (p *exprParser) parseExpr() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return "", fmt.Errorf("unexpected end of expression")
+	}
+
+	switch c := p.input[p.pos]; {
+	case c == '"':
+		return p.parseString()
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseNumber()
+	case c == '[' || c == '{':
+		return "", fmt.Errorf("for-expressions and list or map values are not supported")
+	}
+
+	ident := p.peekIdent()
+	if ident == "" {
+		return "", fmt.Errorf("unexpected %q", p.input[p.pos:])
+	}
+	p.pos += len(ident)
+
+	switch {
+	case ident == "var" && p.consume('.'):
+		name := p.peekIdent()
+		p.pos += len(name)
+		v, ok := p.ctx.vars[name]
+		if !ok {
+			return "", fmt.Errorf("unknown variable %q", name)
+		}
+		return v, nil
+	case ident == "local" && p.consume('.'):
+		name := p.peekIdent()
+		p.pos += len(name)
+		return p.ctx.local(name)
+	}
+
+	p.skipSpace()
+	if !p.consume('(') {
+		return "", fmt.Errorf("unknown reference %q", ident)
+	}
+	f, ok := funcs[ident]
+	if !ok {
+		return "", fmt.Errorf("unknown function %q", ident)
+	}
+
+	var args []string
+	p.skipSpace()
+	for !p.consume(')') {
+		if len(args) != 0 && !p.consume(',') {
+			return "", fmt.Errorf("expected ',' or ')' in call to %q", ident)
+		}
+		arg, err := p.parseExpr()
+		if err != nil {
+			return "", err
+		}
+		args = append(args, arg)
+		p.skipSpace()
+	}
+
+	v, err := f(args)
+	if err != nil {
+		return "", fmt.Errorf("error calling %q: %v", ident, err)
+	}
+	return v, nil
+}
+
+func (p *exprParser) parseString() (string, error) {
+	for end := p.pos + 1; end < len(p.input); end++ {
+		switch p.input[end] {
+		case '\\':
+			end++
+		case '"':
+			v, err := strconv.Unquote(p.input[p.pos : end+1])
+			if err != nil {
+				return "", fmt.Errorf("invalid string %s", p.input[p.pos:end+1])
+			}
+			p.pos = end + 1
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *exprParser) parseNumber() (string, error) {
+	end := p.pos + 1
+	for end < len(p.input) && strings.IndexByte("0123456789.", p.input[end]) >= 0 {
+		end++
+	}
+	v := p.input[p.pos:end]
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return "", fmt.Errorf("invalid number %q", v)
+	}
+	p.pos = end
+	return v, nil
+}
+
+// peekIdent returns the identifier at the current position.
+func (p *exprParser) peekIdent() string {
+	end := p.pos
+	for end < len(p.input) {
+		c := rune(p.input[end])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '-' {
+			break
+		}
+		end++
+	}
+	return p.input[p.pos:end]
+}
+
+func (p *exprParser) consume(c byte) bool {
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
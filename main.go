@@ -40,6 +40,8 @@ func RunCustom(args []string, commands map[string]cli.CommandFactory) int {
 		case "executor":
 		case "syslog":
 		case "fs ls", "fs cat", "fs stat":
+		case "deployment fail", "deployment list", "deployment promote", "deployment status":
+		case "job dispatch", "job history", "job periodic", "job periodic force", "job revert":
 		case "check":
 		default:
 			commandsInclude = append(commandsInclude, k)
@@ -0,0 +1,205 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// resolveToken is used to translate an ACL Token Secret ID into
+// an ACL object, nil if ACLs are disabled, or an error.
+func (s *Server) resolveToken(secretID string) (*acl.ACL, error) {
+	// Fast-path if ACLs are disabled
+	if !s.config.ACLEnabled {
+		return nil, nil
+	}
+
+	// Check if this is the leader's own token
+	if leaderAcl := s.getLeaderAcl(); leaderAcl != "" && secretID == leaderAcl {
+		return acl.ManagementACL, nil
+	}
+
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return resolveTokenFromSnapshot(snap, secretID)
+}
+
+// ResolveToken is used to translate an ACL Token Secret ID into an ACL object
+// for the endpoints served by the agent, nil if ACLs are disabled, or an
+// error.
+func (s *Server) ResolveToken(secretID string) (*acl.ACL, error) {
+	return s.resolveToken(secretID)
+}
+
+// resolveTokenFromSnapshot resolves the secret ID against the given state
+// snapshot. An empty secret resolves to the anonymous token.
+func resolveTokenFromSnapshot(snap *state.StateSnapshot, secretID string) (*acl.ACL, error) {
+	// Lookup the ACL Token
+	var token *structs.ACLToken
+	if secretID == "" {
+		token = structs.AnonymousACLToken
+	} else {
+		var err error
+		token, err = snap.ACLTokenBySecretID(secretID)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, structs.ErrTokenNotFound
+		}
+	}
+
+	// Check if this is a management token
+	if token.Type == structs.ACLManagementToken {
+		return acl.ManagementACL, nil
+	}
+
+	// Parse the policies the token is associated with. Policies that have
+	// been deleted are skipped.
+	policies := make([]*acl.Policy, 0, len(token.Policies))
+	for _, name := range token.Policies {
+		policy, err := snap.ACLPolicyByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if policy == nil {
+			continue
+		}
+
+		parsed, err := acl.Parse(policy.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy %q: %v", name, err)
+		}
+		policies = append(policies, parsed)
+	}
+
+	return acl.NewACL(false, policies)
+}
+
+// resolveNodeSecret returns true if the given secret belongs to a registered
+// client node. Clients authenticate with their node secret for the RPCs they
+// share with users.
+func (s *Server) resolveNodeSecret(secretID string) (bool, error) {
+	if secretID == "" {
+		return false, nil
+	}
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		return false, err
+	}
+	node, err := snap.NodeBySecretID(secretID)
+	if err != nil {
+		return false, err
+	}
+	return node != nil, nil
+}
+
+// checkACL resolves the token and returns ErrPermissionDenied if the given
+// check does not pass. The check is skipped when ACLs are disabled.
+func (s *Server) checkACL(secretID string, allow func(*acl.ACL) bool) error {
+	aclObj, err := s.resolveToken(secretID)
+	if err != nil {
+		return err
+	}
+	if aclObj != nil && !allow(aclObj) {
+		return structs.ErrPermissionDenied
+	}
+	return nil
+}
+
+// checkNamespaceOperation returns ErrPermissionDenied if the token may not
+// perform the operation in the given namespace.
+func (s *Server) checkNamespaceOperation(secretID, namespace, op string) error {
+	if namespace == "" {
+		namespace = structs.DefaultNamespace
+	}
+	return s.checkACL(secretID, func(a *acl.ACL) bool {
+		return a.AllowNamespaceOperation(namespace, op)
+	})
+}
+
+// checkJobOperation returns ErrPermissionDenied if the token may not perform
+// the operation in the namespace of the given job. Jobs that do not exist are
+// checked against the default namespace.
+func (s *Server) checkJobOperation(secretID, jobID, op string) error {
+	// Fast-path if ACLs are disabled
+	if !s.config.ACLEnabled {
+		return nil
+	}
+
+	namespace := structs.DefaultNamespace
+	job, err := s.fsm.State().JobByID(jobID)
+	if err != nil {
+		return err
+	}
+	if job != nil {
+		namespace = job.Namespace
+	}
+	return s.checkNamespaceOperation(secretID, namespace, op)
+}
+
+// checkNodeSecretOrACL allows the request if the secret belongs to a client
+// node and otherwise falls back to the given ACL check.
+func (s *Server) checkNodeSecretOrACL(secretID string, allow func(*acl.ACL) bool) error {
+	// Fast-path if ACLs are disabled
+	if !s.config.ACLEnabled {
+		return nil
+	}
+
+	ok, err := s.resolveNodeSecret(secretID)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	return s.checkACL(secretID, allow)
+}
+
+// setLeaderAcl stores the given ACL token as the current leader's ACL token.
+func (s *Server) setLeaderAcl(token string) {
+	s.leaderAclLock.Lock()
+	s.leaderAcl = token
+	s.leaderAclLock.Unlock()
+}
+
+// getLeaderAcl retrieves the leader's ACL token
+func (s *Server) getLeaderAcl() string {
+	s.leaderAclLock.Lock()
+	defer s.leaderAclLock.Unlock()
+	return s.leaderAcl
+}
+
+// jobNamespaceFilter returns a function that reports whether the ACL allows
+// the operation on the job with the given ID, and whether the namespace of the
+// job is listed by the query. Namespace lookups are cached so it can be used
+// while iterating over many objects of the same job. A nil ACL allows
+// everything.
+func jobNamespaceFilter(snap *state.StateSnapshot, aclObj *acl.ACL, op string, q *structs.QueryOptions) func(jobID string) (bool, error) {
+	allowed := make(map[string]bool)
+	return func(jobID string) (bool, error) {
+		if aclObj == nil && q.MatchesNamespace("") {
+			return true, nil
+		}
+		if ok, cached := allowed[jobID]; cached {
+			return ok, nil
+		}
+
+		namespace := structs.DefaultNamespace
+		job, err := snap.JobByID(jobID)
+		if err != nil {
+			return false, err
+		}
+		if job != nil {
+			namespace = job.Namespace
+		}
+		ok := q.MatchesNamespace(namespace) &&
+			(aclObj == nil || aclObj.AllowNamespaceOperation(namespace, op))
+		allowed[jobID] = ok
+		return ok, nil
+	}
+}
@@ -0,0 +1,552 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+var (
+	// aclDisabled is returned when an ACL endpoint is hit but ACLs are not enabled
+	aclDisabled = fmt.Errorf("ACL support disabled")
+)
+
+// ACL endpoint is used for manipulating ACL tokens and policies
+type ACL struct {
+	srv *Server
+}
+
+// requireManagement resolves the token and returns an error if it is not a
+// management token.
+func (a *ACL) requireManagement(secretID string) error {
+	aclObj, err := a.srv.resolveToken(secretID)
+	if err != nil {
+		return err
+	}
+	if aclObj != nil && !aclObj.IsManagement() {
+		return structs.ErrPermissionDenied
+	}
+	return nil
+}
+
+// UpsertPolicies is used to create or update a set of policies
+func (a *ACL) UpsertPolicies(args *structs.ACLPolicyUpsertRequest, reply *structs.GenericResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.UpsertPolicies", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "upsert_policies"}, time.Now())
+
+	// Check management level permissions
+	if err := a.requireManagement(args.AuthToken); err != nil {
+		return err
+	}
+
+	// Validate non-zero set of policies
+	if len(args.Policies) == 0 {
+		return fmt.Errorf("must specify as least one policy")
+	}
+
+	// Validate each policy, compute hash
+	for idx, policy := range args.Policies {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("policy %d invalid: %v", idx, err)
+		}
+		if _, err := acl.Parse(policy.Rules); err != nil {
+			return fmt.Errorf("policy %d failed to parse: %v", idx, err)
+		}
+		policy.SetHash()
+	}
+
+	// Update via Raft
+	_, index, err := a.srv.raftApply(structs.ACLPolicyUpsertRequestType, args)
+	if err != nil {
+		a.srv.logger.Printf("[ERR] nomad.acl: UpsertPolicies failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// DeletePolicies is used to delete policies
+func (a *ACL) DeletePolicies(args *structs.ACLPolicyDeleteRequest, reply *structs.GenericResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.DeletePolicies", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "delete_policies"}, time.Now())
+
+	// Check management level permissions
+	if err := a.requireManagement(args.AuthToken); err != nil {
+		return err
+	}
+
+	// Validate non-zero set of policies
+	if len(args.Names) == 0 {
+		return fmt.Errorf("must specify as least one policy")
+	}
+
+	// Update via Raft
+	resp, index, err := a.srv.raftApply(structs.ACLPolicyDeleteRequestType, args)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		a.srv.logger.Printf("[ERR] nomad.acl: DeletePolicies failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// ListPolicies is used to list the policies
+func (a *ACL) ListPolicies(args *structs.ACLPolicyListRequest, reply *structs.ACLPolicyListResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.ListPolicies", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "list_policies"}, time.Now())
+
+	// Check management level permissions
+	if err := a.requireManagement(args.AuthToken); err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "acl_policy"}),
+		run: func() error {
+			// Iterate over all the policies
+			snap, err := a.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.ACLPolicyByNamePrefix(prefix)
+			} else {
+				iter, err = snap.ACLPolicies()
+			}
+			if err != nil {
+				return err
+			}
+
+			reply.Policies = nil
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				reply.Policies = append(reply.Policies, raw.(*structs.ACLPolicy))
+			}
+
+			// Use the last index that affected the policy table
+			index, err := snap.Index("acl_policy")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			a.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return a.srv.blockingRPC(&opts)
+}
+
+// GetPolicy is used to get a specific policy. Management tokens may read any
+// policy while client tokens may only read the policies they are tied to.
+func (a *ACL) GetPolicy(args *structs.ACLPolicySpecificRequest, reply *structs.SingleACLPolicyResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.GetPolicy", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "get_policy"}, time.Now())
+
+	// Check the token is management or tied to the policy
+	aclObj, err := a.srv.resolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+	if aclObj != nil && !aclObj.IsManagement() {
+		token := structs.AnonymousACLToken
+		if args.AuthToken != "" {
+			token, err = a.srv.fsm.State().ACLTokenBySecretID(args.AuthToken)
+			if err != nil {
+				return err
+			}
+		}
+		if token == nil || !token.PolicySubset([]string{args.Name}) {
+			return structs.ErrPermissionDenied
+		}
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "acl_policy"}),
+		run: func() error {
+			// Look for the policy
+			out, err := a.srv.fsm.State().ACLPolicyByName(args.Name)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Policy = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the policy table
+				index, err := a.srv.fsm.State().Index("acl_policy")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			a.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return a.srv.blockingRPC(&opts)
+}
+
+// Bootstrap is used to bootstrap the initial management token. It may only be
+// used once per cluster.
+func (a *ACL) Bootstrap(args *structs.ACLTokenBootstrapRequest, reply *structs.ACLTokenUpsertResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.Bootstrap", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "bootstrap"}, time.Now())
+
+	// Check if we can bootstrap
+	ok, _, err := a.srv.fsm.State().CanBootstrapACLToken()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("ACL bootstrap already done")
+	}
+
+	// Create a new global management token, override any parameter
+	args.Token = &structs.ACLToken{
+		AccessorID: structs.GenerateUUID(),
+		SecretID:   structs.GenerateUUID(),
+		Name:       "Bootstrap Token",
+		Type:       structs.ACLManagementToken,
+		CreateTime: time.Now().UTC(),
+	}
+
+	// Update via Raft
+	resp, index, err := a.srv.raftApply(structs.ACLTokenBootstrapRequestType, args)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		a.srv.logger.Printf("[ERR] nomad.acl: Bootstrap failed: %v", err)
+		return err
+	}
+
+	// Lookup the token by accessor to return the committed version
+	out, err := a.srv.fsm.State().ACLTokenByAccessorID(args.Token.AccessorID)
+	if err != nil {
+		return err
+	}
+	if out != nil {
+		reply.Tokens = append(reply.Tokens, out)
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// UpsertTokens is used to create or update a set of tokens
+func (a *ACL) UpsertTokens(args *structs.ACLTokenUpsertRequest, reply *structs.ACLTokenUpsertResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.UpsertTokens", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "upsert_tokens"}, time.Now())
+
+	// Check management level permissions
+	if err := a.requireManagement(args.AuthToken); err != nil {
+		return err
+	}
+
+	// Validate non-zero set of tokens
+	if len(args.Tokens) == 0 {
+		return fmt.Errorf("must specify as least one token")
+	}
+
+	// Snapshot the state
+	snap, err := a.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	// Validate each token
+	for idx, token := range args.Tokens {
+		if err := token.Validate(); err != nil {
+			return fmt.Errorf("token %d invalid: %v", idx, err)
+		}
+
+		// Generate an accessor and secret ID if new
+		if token.AccessorID == "" {
+			token.AccessorID = structs.GenerateUUID()
+			token.SecretID = structs.GenerateUUID()
+			token.CreateTime = time.Now().UTC()
+			continue
+		}
+
+		// Verify the token exists
+		out, err := snap.ACLTokenByAccessorID(token.AccessorID)
+		if err != nil {
+			return fmt.Errorf("token lookup failed: %v", err)
+		}
+		if out == nil {
+			return fmt.Errorf("cannot find token %s", token.AccessorID)
+		}
+
+		// Cannot change the secret or creation time
+		token.SecretID = out.SecretID
+		token.CreateTime = out.CreateTime
+	}
+
+	// Update via Raft
+	_, index, err := a.srv.raftApply(structs.ACLTokenUpsertRequestType, args)
+	if err != nil {
+		a.srv.logger.Printf("[ERR] nomad.acl: UpsertTokens failed: %v", err)
+		return err
+	}
+
+	// Populate the response. We do a lookup against the state to
+	// pickup the proper create / modify times.
+	state := a.srv.fsm.State()
+	for _, token := range args.Tokens {
+		out, err := state.ACLTokenByAccessorID(token.AccessorID)
+		if err != nil {
+			return fmt.Errorf("token lookup failed: %v", err)
+		}
+		reply.Tokens = append(reply.Tokens, out)
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// DeleteTokens is used to delete tokens
+func (a *ACL) DeleteTokens(args *structs.ACLTokenDeleteRequest, reply *structs.GenericResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.DeleteTokens", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "delete_tokens"}, time.Now())
+
+	// Check management level permissions
+	if err := a.requireManagement(args.AuthToken); err != nil {
+		return err
+	}
+
+	// Validate non-zero set of tokens
+	if len(args.AccessorIDs) == 0 {
+		return fmt.Errorf("must specify as least one token")
+	}
+
+	// Update via Raft
+	resp, index, err := a.srv.raftApply(structs.ACLTokenDeleteRequestType, args)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		a.srv.logger.Printf("[ERR] nomad.acl: DeleteTokens failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// ListTokens is used to list the tokens
+func (a *ACL) ListTokens(args *structs.ACLTokenListRequest, reply *structs.ACLTokenListResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.ListTokens", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "list_tokens"}, time.Now())
+
+	// Check management level permissions
+	if err := a.requireManagement(args.AuthToken); err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "acl_token"}),
+		run: func() error {
+			// Iterate over all the tokens
+			snap, err := a.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.ACLTokenByAccessorIDPrefix(prefix)
+			} else {
+				iter, err = snap.ACLTokens()
+			}
+			if err != nil {
+				return err
+			}
+
+			reply.Tokens = nil
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				reply.Tokens = append(reply.Tokens, raw.(*structs.ACLToken))
+			}
+
+			// Use the last index that affected the token table
+			index, err := snap.Index("acl_token")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			a.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return a.srv.blockingRPC(&opts)
+}
+
+// GetToken is used to get a specific token. Management tokens may read any
+// token while client tokens may only read themselves.
+func (a *ACL) GetToken(args *structs.ACLTokenSpecificRequest, reply *structs.SingleACLTokenResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.GetToken", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "get_token"}, time.Now())
+
+	aclObj, err := a.srv.resolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "acl_token"}),
+		run: func() error {
+			// Look for the token
+			out, err := a.srv.fsm.State().ACLTokenByAccessorID(args.AccessorID)
+			if err != nil {
+				return err
+			}
+
+			// Only management tokens may read other tokens
+			if aclObj != nil && !aclObj.IsManagement() &&
+				(out == nil || out.SecretID != args.AuthToken) {
+				return structs.ErrPermissionDenied
+			}
+
+			// Setup the output
+			reply.Token = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the token table
+				index, err := a.srv.fsm.State().Index("acl_token")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			a.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return a.srv.blockingRPC(&opts)
+}
+
+// ResolveToken is used to lookup a specific token by its secret. Holding the
+// secret is sufficient to read the token.
+func (a *ACL) ResolveToken(args *structs.ResolveACLTokenRequest, reply *structs.ResolveACLTokenResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.ResolveToken", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "resolve_token"}, time.Now())
+
+	// Setup the query meta
+	a.srv.setQueryMeta(&reply.QueryMeta)
+
+	// Snapshot the state
+	snap, err := a.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	// Look for the token
+	out, err := snap.ACLTokenBySecretID(args.SecretID)
+	if err != nil {
+		return err
+	}
+
+	// Setup the output
+	reply.Token = out
+	if out != nil {
+		reply.Index = out.ModifyIndex
+	} else {
+		// Use the last index that affected the token table
+		index, err := snap.Index("acl_token")
+		if err != nil {
+			return err
+		}
+		reply.Index = index
+	}
+	return nil
+}
@@ -0,0 +1,472 @@
+package nomad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestACLEndpoint_Disabled(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	req := &structs.ACLTokenBootstrapRequest{
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.ACLTokenUpsertResponse
+	err := msgpackrpc.CallWithCodec(codec, "ACL.Bootstrap", req, &resp)
+	if err == nil || err.Error() != aclDisabled.Error() {
+		t.Fatalf("expected ACL disabled error: %v", err)
+	}
+}
+
+func TestACLEndpoint_UpsertPolicies(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	policy := mock.ACLPolicy()
+	req := &structs.ACLPolicyUpsertRequest{
+		Policies:     []*structs.ACLPolicy{policy},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+
+	// Anonymous requests are denied
+	err := msgpackrpc.CallWithCodec(codec, "ACL.UpsertPolicies", req, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// Management tokens may write policies
+	req.AuthToken = root.SecretID
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.UpsertPolicies", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	out, err := s1.fsm.State().ACLPolicyByName(policy.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Rules != policy.Rules || len(out.Hash) == 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Invalid rules are rejected
+	policy.Rules = `namespace "default" { policy = "invalid" }`
+	err = msgpackrpc.CallWithCodec(codec, "ACL.UpsertPolicies", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "failed to parse") {
+		t.Fatalf("expected parse error: %v", err)
+	}
+}
+
+func TestACLEndpoint_DeletePolicies(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	policy := mock.ACLPolicy()
+	if err := s1.fsm.State().UpsertACLPolicies(1000, []*structs.ACLPolicy{policy}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &structs.ACLPolicyDeleteRequest{
+		Names: []string{policy.Name},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.DeletePolicies", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := s1.fsm.State().ACLPolicyByName(policy.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("policy not deleted: %#v", out)
+	}
+}
+
+func TestACLEndpoint_ListPolicies(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	p1 := mock.ACLPolicy()
+	p1.Name = "aaaa"
+	p2 := mock.ACLPolicy()
+	p2.Name = "aabb"
+	if err := s1.fsm.State().UpsertACLPolicies(1000, []*structs.ACLPolicy{p1, p2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	get := &structs.ACLPolicyListRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.ACLPolicyListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.ListPolicies", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if len(resp.Policies) != 2 {
+		t.Fatalf("bad: %#v", resp.Policies)
+	}
+
+	// Lookup the policies by prefix
+	get.Prefix = "aaaa"
+	var resp2 structs.ACLPolicyListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.ListPolicies", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Policies) != 1 || resp2.Policies[0].Name != "aaaa" {
+		t.Fatalf("bad: %#v", resp2.Policies)
+	}
+
+	// Client tokens may not list policies
+	token := createTestToken(t, s1, 1010, `node { policy = "read" }`)
+	get.AuthToken = token.SecretID
+	err := msgpackrpc.CallWithCodec(codec, "ACL.ListPolicies", get, &resp2)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+}
+
+func TestACLEndpoint_GetPolicy(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	policy := mock.ACLPolicy()
+	if err := s1.fsm.State().UpsertACLPolicies(1000, []*structs.ACLPolicy{policy}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	get := &structs.ACLPolicySpecificRequest{
+		Name: policy.Name,
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.SingleACLPolicyResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if !reflect.DeepEqual(policy, resp.Policy) {
+		t.Fatalf("bad: %#v %#v", policy, resp.Policy)
+	}
+
+	// Client tokens may read the policies they are tied to
+	token := mock.ACLToken()
+	token.Policies = []string{policy.Name}
+	if err := s1.fsm.State().UpsertACLTokens(1010, []*structs.ACLToken{token}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	get.AuthToken = token.SecretID
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// But no other policy
+	get.Name = "other"
+	err := msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", get, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// Anonymous requests may read the anonymous policy
+	get.AuthToken = ""
+	get.Name = "anonymous"
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	get.Name = policy.Name
+	err = msgpackrpc.CallWithCodec(codec, "ACL.GetPolicy", get, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+}
+
+func TestACLEndpoint_Bootstrap(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.ACLEnabled = true
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	req := &structs.ACLTokenBootstrapRequest{
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.ACLTokenUpsertResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.Bootstrap", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Tokens) != 1 {
+		t.Fatalf("bad: %#v", resp.Tokens)
+	}
+	token := resp.Tokens[0]
+	if token.Type != structs.ACLManagementToken || token.SecretID == "" {
+		t.Fatalf("bad: %#v", token)
+	}
+
+	out, err := s1.fsm.State().ACLTokenBySecretID(token.SecretID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.AccessorID != token.AccessorID {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// A second bootstrap fails
+	err = msgpackrpc.CallWithCodec(codec, "ACL.Bootstrap", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "already done") {
+		t.Fatalf("expected bootstrap error: %v", err)
+	}
+}
+
+func TestACLEndpoint_UpsertTokens(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a token
+	token := mock.ACLToken()
+	token.AccessorID = ""
+	token.SecretID = ""
+	req := &structs.ACLTokenUpsertRequest{
+		Tokens: []*structs.ACLToken{token},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.ACLTokenUpsertResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.UpsertTokens", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Tokens) != 1 {
+		t.Fatalf("bad: %#v", resp.Tokens)
+	}
+	created := resp.Tokens[0]
+	if created.AccessorID == "" || created.SecretID == "" || created.CreateTime.IsZero() {
+		t.Fatalf("bad: %#v", created)
+	}
+
+	// Update the token, the secret can not be changed
+	update := created.Copy()
+	update.Name = "updated"
+	update.SecretID = structs.GenerateUUID()
+	req.Tokens = []*structs.ACLToken{update}
+	var resp2 structs.ACLTokenUpsertResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.UpsertTokens", req, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out := resp2.Tokens[0]
+	if out.Name != "updated" || out.SecretID != created.SecretID {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Updating a missing token fails
+	missing := mock.ACLToken()
+	req.Tokens = []*structs.ACLToken{missing}
+	err := msgpackrpc.CallWithCodec(codec, "ACL.UpsertTokens", req, &resp2)
+	if err == nil || !strings.Contains(err.Error(), "cannot find token") {
+		t.Fatalf("expected missing token error: %v", err)
+	}
+
+	// Client tokens may not create tokens
+	req.Tokens = []*structs.ACLToken{token}
+	req.AuthToken = created.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "ACL.UpsertTokens", req, &resp2)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+}
+
+func TestACLEndpoint_DeleteTokens(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	token := mock.ACLToken()
+	if err := s1.fsm.State().UpsertACLTokens(1000, []*structs.ACLToken{token}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := &structs.ACLTokenDeleteRequest{
+		AccessorIDs: []string{token.AccessorID},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.DeleteTokens", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := s1.fsm.State().ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("token not deleted: %#v", out)
+	}
+}
+
+func TestACLEndpoint_ListTokens(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	token := mock.ACLToken()
+	if err := s1.fsm.State().UpsertACLTokens(1000, []*structs.ACLToken{token}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	get := &structs.ACLTokenListRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.ACLTokenListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.ListTokens", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+
+	// The bootstrap token is listed as well
+	if len(resp.Tokens) != 2 {
+		t.Fatalf("bad: %#v", resp.Tokens)
+	}
+
+	// Lookup the tokens by prefix
+	get.Prefix = token.AccessorID[:4]
+	var resp2 structs.ACLTokenListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.ListTokens", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	found := false
+	for _, tk := range resp2.Tokens {
+		if tk.AccessorID == token.AccessorID {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("bad: %#v", resp2.Tokens)
+	}
+}
+
+func TestACLEndpoint_GetToken(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	token := mock.ACLToken()
+	other := mock.ACLToken()
+	if err := s1.fsm.State().UpsertACLTokens(1000, []*structs.ACLToken{token, other}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	get := &structs.ACLTokenSpecificRequest{
+		AccessorID: token.AccessorID,
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.SingleACLTokenResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.GetToken", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if resp.Token == nil || resp.Token.SecretID != token.SecretID {
+		t.Fatalf("bad: %#v", resp.Token)
+	}
+
+	// Client tokens may read themselves
+	get.AuthToken = token.SecretID
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.GetToken", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// But not other tokens
+	get.AccessorID = other.AccessorID
+	err := msgpackrpc.CallWithCodec(codec, "ACL.GetToken", get, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+}
+
+func TestACLEndpoint_ResolveToken(t *testing.T) {
+	s1, _ := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	token := mock.ACLToken()
+	if err := s1.fsm.State().UpsertACLTokens(1000, []*structs.ACLToken{token}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	get := &structs.ResolveACLTokenRequest{
+		SecretID:     token.SecretID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.ResolveACLTokenResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.ResolveToken", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if resp.Token == nil || resp.Token.AccessorID != token.AccessorID {
+		t.Fatalf("bad: %#v", resp.Token)
+	}
+
+	// Lookup a non-existing token
+	get.SecretID = structs.GenerateUUID()
+	var resp2 structs.ResolveACLTokenResponse
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.ResolveToken", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp2.Token != nil {
+		t.Fatalf("unexpected token")
+	}
+}
@@ -0,0 +1,110 @@
+package nomad
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// createTestToken upserts a policy with the given rules along with a client
+// token tied to it.
+func createTestToken(t *testing.T, s *Server, index uint64, rules string) *structs.ACLToken {
+	policy := mock.ACLPolicy()
+	policy.Rules = rules
+	policy.SetHash()
+	if err := s.fsm.State().UpsertACLPolicies(index, []*structs.ACLPolicy{policy}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	token := mock.ACLToken()
+	token.Policies = []string{policy.Name}
+	if err := s.fsm.State().UpsertACLTokens(index+1, []*structs.ACLToken{token}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return token
+}
+
+func TestResolveACLToken(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Management tokens resolve to the management ACL
+	aclObj, err := s1.resolveToken(root.SecretID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if aclObj != acl.ManagementACL {
+		t.Fatalf("expected management ACL: %#v", aclObj)
+	}
+
+	// Client tokens are compiled from their policies
+	token := createTestToken(t, s1, 1000, `namespace "default" { policy = "read" }`)
+	aclObj, err = s1.resolveToken(token.SecretID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if aclObj == nil || aclObj.IsManagement() {
+		t.Fatalf("bad: %#v", aclObj)
+	}
+	if !aclObj.AllowNamespaceOperation("default", acl.NamespaceCapabilityReadJob) {
+		t.Fatalf("expected read-job to be allowed")
+	}
+	if aclObj.AllowNamespaceOperation("default", acl.NamespaceCapabilitySubmitJob) {
+		t.Fatalf("expected submit-job to be denied")
+	}
+
+	// Unknown secrets are rejected
+	if _, err := s1.resolveToken(structs.GenerateUUID()); err != structs.ErrTokenNotFound {
+		t.Fatalf("expected token not found: %v", err)
+	}
+
+	// The anonymous token has no access until an anonymous policy exists
+	aclObj, err = s1.resolveToken("")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if aclObj.AllowNamespaceOperation("default", acl.NamespaceCapabilityListJobs) {
+		t.Fatalf("expected anonymous access to be denied")
+	}
+
+	anonymous := mock.ACLPolicy()
+	anonymous.Name = "anonymous"
+	anonymous.Rules = `namespace "default" { policy = "read" }`
+	if err := s1.fsm.State().UpsertACLPolicies(1010, []*structs.ACLPolicy{anonymous}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	aclObj, err = s1.resolveToken("")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !aclObj.AllowNamespaceOperation("default", acl.NamespaceCapabilityListJobs) {
+		t.Fatalf("expected anonymous access to be allowed")
+	}
+
+	// The leader's token is a management token
+	aclObj, err = s1.resolveToken(s1.getLeaderAcl())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if aclObj != acl.ManagementACL {
+		t.Fatalf("expected management ACL: %#v", aclObj)
+	}
+}
+
+func TestResolveACLToken_Disabled(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	aclObj, err := s1.resolveToken(structs.GenerateUUID())
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if aclObj != nil {
+		t.Fatalf("expected no ACL: %#v", aclObj)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/watch"
 )
@@ -22,6 +23,12 @@ func (a *Alloc) List(args *structs.AllocListRequest, reply *structs.AllocListRes
 	}
 	defer metrics.MeasureSince([]string{"nomad", "alloc", "list"}, time.Now())
 
+	// Resolve the token so allocations can be filtered by namespace
+	aclObj, err := a.srv.resolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -43,16 +50,34 @@ func (a *Alloc) List(args *structs.AllocListRequest, reply *structs.AllocListRes
 				return err
 			}
 
+			paginator, err := newPaginator(&args.QueryOptions)
+			if err != nil {
+				return err
+			}
+
+			allow := jobNamespaceFilter(snap, aclObj, acl.NamespaceCapabilityReadJob, &args.QueryOptions)
 			var allocs []*structs.AllocListStub
-			for {
+			for !paginator.done() {
 				raw := iter.Next()
 				if raw == nil {
 					break
 				}
 				alloc := raw.(*structs.Allocation)
-				allocs = append(allocs, alloc.Stub())
+				if ok, err := allow(alloc.JobID); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+				stub := alloc.Stub()
+				if ok, err := paginator.accept(alloc.ID, stub); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+				allocs = append(allocs, stub)
 			}
 			reply.Allocations = allocs
+			reply.NextToken = paginator.nextPageToken()
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("allocs")
@@ -76,6 +101,12 @@ func (a *Alloc) GetAlloc(args *structs.AllocSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "alloc", "get_alloc"}, time.Now())
 
+	// Check the token may read the allocation. Clients fetch their
+	// allocations using their node secret.
+	if err := a.checkAllocRead(args.AuthToken, args.AllocID); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -120,6 +151,11 @@ func (a *Alloc) GetAllocs(args *structs.AllocsGetRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "alloc", "get_alloc"}, time.Now())
 
+	// Only clients and management tokens may fetch allocations in bulk
+	if err := a.srv.checkNodeSecretOrACL(args.AuthToken, (*acl.ACL).IsManagement); err != nil {
+		return err
+	}
+
 	// Lookup the allocations
 	snap, err := a.srv.fsm.State().Snapshot()
 	if err != nil {
@@ -147,3 +183,90 @@ func (a *Alloc) GetAllocs(args *structs.AllocsGetRequest,
 	reply.Allocs = allocs
 	return nil
 }
+
+// Stop is used to stop an allocation and have the scheduler replace it
+func (a *Alloc) Stop(args *structs.AllocStopRequest, reply *structs.AllocStopResponse) error {
+	if done, err := a.srv.forward("Alloc.Stop", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "alloc", "stop"}, time.Now())
+
+	// Validate the arguments
+	if args.AllocID == "" {
+		return fmt.Errorf("missing allocation ID")
+	}
+
+	alloc, err := a.srv.fsm.State().AllocByID(args.AllocID)
+	if err != nil {
+		return err
+	}
+	if alloc == nil {
+		return fmt.Errorf("unknown alloc id %q", args.AllocID)
+	}
+
+	// Check the token may submit the allocation's job
+	if err := a.srv.checkJobOperation(args.AuthToken, alloc.JobID, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+
+	if alloc.TerminalStatus() {
+		return fmt.Errorf("alloc %q is already terminal", alloc.ID)
+	}
+
+	// Mark the allocation to migrate along with an evaluation of its job
+	eval := &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       alloc.Job.Priority,
+		Type:           alloc.Job.Type,
+		TriggeredBy:    structs.EvalTriggerAllocStop,
+		JobID:          alloc.JobID,
+		JobModifyIndex: alloc.Job.ModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}
+	req := &structs.AllocUpdateDesiredTransitionRequest{
+		Allocs: map[string]*structs.DesiredTransition{
+			alloc.ID: {Migrate: true},
+		},
+		Evals:        []*structs.Evaluation{eval},
+		WriteRequest: args.WriteRequest,
+	}
+	resp, index, err := a.srv.raftApply(structs.AllocUpdateDesiredTransitionRequestType, req)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		a.srv.logger.Printf("[ERR] nomad.alloc: Stop failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = index
+	reply.Index = index
+	return nil
+}
+
+// checkAllocRead returns ErrPermissionDenied if the secret neither belongs to
+// a client node nor grants read access to the allocation's job.
+func (a *Alloc) checkAllocRead(secretID, allocID string) error {
+	// Fast-path if ACLs are disabled
+	if !a.srv.config.ACLEnabled {
+		return nil
+	}
+
+	if ok, err := a.srv.resolveNodeSecret(secretID); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	var jobID string
+	alloc, err := a.srv.fsm.State().AllocByID(allocID)
+	if err != nil {
+		return err
+	}
+	if alloc != nil {
+		jobID = alloc.JobID
+	}
+	return a.srv.checkJobOperation(secretID, jobID, acl.NamespaceCapabilityReadJob)
+}
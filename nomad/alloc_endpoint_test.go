@@ -272,3 +272,53 @@ func TestAllocEndpoint_GetAllocs(t *testing.T) {
 		t.Fatalf("expect error")
 	}
 }
+
+func TestAllocEndpoint_Stop(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create the allocation
+	alloc := mock.Alloc()
+	state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID))
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Stop the allocation
+	req := &structs.AllocStopRequest{
+		AllocID:      alloc.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.AllocStopResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Alloc.Stop", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Check the allocation is marked to migrate along with an evaluation
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DesiredTransition.ShouldMigrate() {
+		t.Fatalf("bad: %#v", out)
+	}
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.JobID != alloc.JobID || eval.TriggeredBy != structs.EvalTriggerAllocStop {
+		t.Fatalf("bad: %#v", eval)
+	}
+
+	// Stopping an unknown allocation is an error
+	req.AllocID = structs.GenerateUUID()
+	if err := msgpackrpc.CallWithCodec(codec, "Alloc.Stop", req, &resp); err == nil {
+		t.Fatalf("expected error")
+	}
+}
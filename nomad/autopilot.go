@@ -0,0 +1,308 @@
+package nomad
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/serf/serf"
+)
+
+// autopilotLoop runs on the leader. It tracks the health of the servers,
+// adds new servers to the Raft peer set once they are stable and removes
+// servers that have been failed for too long.
+func (s *Server) autopilotLoop(stopCh chan struct{}) {
+	healthTicker := time.NewTicker(s.config.ServerHealthInterval)
+	defer healthTicker.Stop()
+	autopilotTicker := time.NewTicker(s.config.AutopilotInterval)
+	defer autopilotTicker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-healthTicker.C:
+			if err := s.updateClusterHealth(); err != nil {
+				s.logger.Printf("[ERR] nomad.autopilot: error updating cluster health: %v", err)
+			}
+		case <-autopilotTicker.C:
+			if err := s.autopilotRun(); err != nil {
+				s.logger.Printf("[ERR] nomad.autopilot: error running autopilot: %v", err)
+			}
+		}
+	}
+}
+
+// getOrCreateAutopilotConfig returns the Autopilot configuration stored in
+// the state store, storing the configuration of the agent first if there is
+// none yet.
+func (s *Server) getOrCreateAutopilotConfig() (*structs.AutopilotConfig, error) {
+	_, config, err := s.fsm.State().AutopilotConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		return config, nil
+	}
+
+	req := structs.AutopilotSetConfigRequest{Config: *s.config.AutopilotConfig}
+	if _, _, err := s.raftApply(structs.AutopilotRequestType, &req); err != nil {
+		return nil, fmt.Errorf("failed to initialize autopilot config: %v", err)
+	}
+	return &req.Config, nil
+}
+
+// autopilotConfig returns the current Autopilot configuration, falling back
+// to the configuration of the agent if none has been stored yet.
+func (s *Server) autopilotConfig() (*structs.AutopilotConfig, error) {
+	_, config, err := s.fsm.State().AutopilotConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return s.config.AutopilotConfig, nil
+	}
+	return config, nil
+}
+
+// autopilotRun promotes stable servers and removes dead ones based on the
+// last computed cluster health.
+func (s *Server) autopilotRun() error {
+	defer metrics.MeasureSince([]string{"nomad", "autopilot", "run"}, time.Now())
+
+	config, err := s.autopilotConfig()
+	if err != nil {
+		return err
+	}
+
+	s.clusterHealthLock.RLock()
+	health := make(map[string]structs.ServerHealth, len(s.clusterHealth.Servers))
+	for _, h := range s.clusterHealth.Servers {
+		health[h.Name] = h
+	}
+	s.clusterHealthLock.RUnlock()
+
+	now := time.Now()
+	var voters int
+	var dead []serf.Member
+	for _, member := range s.serf.Members() {
+		valid, parts := isNomadServer(member)
+		if !valid || parts.Region != s.config.Region {
+			continue
+		}
+		h, ok := health[member.Name]
+		if !ok {
+			continue
+		}
+		if h.Voter {
+			voters++
+		}
+
+		switch {
+		case !h.Voter && member.Status == serf.StatusAlive && h.IsStable(now, config.ServerStabilizationTime):
+			s.logger.Printf("[INFO] nomad.autopilot: promoting server %q, stable since %v", member.Name, h.StableSince)
+			if err := s.addRaftPeer(member, parts); err != nil {
+				return err
+			}
+		case h.Voter && h.IsDead(now, config.ServerStabilizationTime):
+			dead = append(dead, member)
+		}
+	}
+
+	if !config.CleanupDeadServers || len(dead) == 0 {
+		return nil
+	}
+
+	// Only remove dead servers if a majority of the peers is left, otherwise
+	// the removal could cause an outage
+	if len(dead) > (voters-1)/2 {
+		s.logger.Printf("[DEBUG] nomad.autopilot: not removing %d dead servers out of %d peers", len(dead), voters)
+		return nil
+	}
+	for _, member := range dead {
+		s.logger.Printf("[INFO] nomad.autopilot: removing dead server %q", member.Name)
+		if err := s.serf.RemoveFailedNode(member.Name); err != nil {
+			s.logger.Printf("[ERR] nomad.autopilot: failed to remove dead server %q from serf: %v", member.Name, err)
+		}
+		_, parts := isNomadServer(member)
+		if err := s.removeRaftPeer(member, parts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateClusterHealth fetches the Raft stats of the servers of the region and
+// updates the health of the cluster used by autopilot and the operator
+// endpoint.
+func (s *Server) updateClusterHealth() error {
+	defer metrics.MeasureSince([]string{"nomad", "autopilot", "health"}, time.Now())
+
+	config, err := s.autopilotConfig()
+	if err != nil {
+		return err
+	}
+
+	peers, err := s.raftPeers.Peers()
+	if err != nil {
+		return err
+	}
+	voters := make(map[string]struct{}, len(peers))
+	for _, peer := range peers {
+		voters[peer] = struct{}{}
+	}
+
+	// Fetch the stats of the alive servers in parallel
+	var servers []*serverParts
+	statuses := make(map[string]serf.MemberStatus)
+	versions := make(map[string]string)
+	var alive []*serverParts
+	for _, member := range s.serf.Members() {
+		valid, parts := isNomadServer(member)
+		if !valid || parts.Region != s.config.Region {
+			continue
+		}
+		servers = append(servers, parts)
+		statuses[parts.Name] = member.Status
+		versions[parts.Name] = member.Tags["build"]
+		if member.Status == serf.StatusAlive {
+			alive = append(alive, parts)
+		}
+	}
+	stats := s.fetchRaftStats(alive)
+
+	s.clusterHealthLock.RLock()
+	previous := make(map[string]structs.ServerHealth, len(s.clusterHealth.Servers))
+	for _, h := range s.clusterHealth.Servers {
+		previous[h.Name] = h
+	}
+	s.clusterHealthLock.RUnlock()
+
+	now := time.Now()
+	leaderAddr := s.raft.Leader()
+	lastIndex := s.raft.LastIndex()
+	health := structs.ClusterHealth{Healthy: true}
+	var healthyVoters, numVoters int
+	for _, parts := range servers {
+		addr := parts.Addr.String()
+		_, voter := voters[addr]
+		h := structs.ServerHealth{
+			Name:       parts.Name,
+			Address:    addr,
+			Version:    versions[parts.Name],
+			SerfStatus: statuses[parts.Name].String(),
+			Leader:     addr == leaderAddr,
+			Voter:      voter,
+		}
+
+		stat, ok := stats[parts.Name]
+		if ok {
+			h.LastContact = stat.LastContact
+			h.LastTerm = stat.LastTerm
+			h.LastIndex = stat.LastIndex
+		}
+		h.Healthy = ok && isServerHealthy(&h, config, lastIndex)
+
+		// Keep track of when the health of the server last changed
+		h.StableSince = now
+		if prev, ok := previous[parts.Name]; ok && prev.Healthy == h.Healthy {
+			h.StableSince = prev.StableSince
+		}
+
+		if !h.Healthy {
+			health.Healthy = false
+		}
+		if h.Voter {
+			numVoters++
+			if h.Healthy {
+				healthyVoters++
+			}
+		}
+		health.Servers = append(health.Servers, h)
+	}
+
+	// The failure tolerance is the number of healthy voters in excess of the
+	// quorum
+	if tolerance := healthyVoters - (numVoters/2 + 1); tolerance > 0 {
+		health.FailureTolerance = tolerance
+	}
+
+	s.clusterHealthLock.Lock()
+	s.clusterHealth = health
+	s.clusterHealthLock.Unlock()
+	return nil
+}
+
+// isServerHealthy returns whether a server with the given stats is healthy.
+// Servers that are not part of the peer set do not receive the Raft log, so
+// they only have to be alive and reachable.
+func isServerHealthy(h *structs.ServerHealth, config *structs.AutopilotConfig, lastIndex uint64) bool {
+	if h.SerfStatus != serf.StatusAlive.String() {
+		return false
+	}
+	if h.Leader || !h.Voter {
+		return true
+	}
+	if h.LastContact < 0 || h.LastContact > config.LastContactThreshold {
+		return false
+	}
+	if lastIndex > h.LastIndex && lastIndex-h.LastIndex > config.MaxTrailingLogs {
+		return false
+	}
+	return true
+}
+
+// fetchRaftStats queries the Raft stats of the given servers in parallel.
+// Servers that do not respond within the health interval are omitted.
+func (s *Server) fetchRaftStats(servers []*serverParts) map[string]*structs.RaftStats {
+	type result struct {
+		name  string
+		stats *structs.RaftStats
+	}
+	resultCh := make(chan result, len(servers))
+	for _, parts := range servers {
+		go func(parts *serverParts) {
+			var stats structs.RaftStats
+			if parts.Name == s.serf.LocalMember().Name {
+				stats = s.raftStats()
+			} else if err := s.connPool.RPC(s.config.Region, parts.Addr, parts.MajorVersion,
+				"Status.RaftStats", struct{}{}, &stats); err != nil {
+				s.logger.Printf("[DEBUG] nomad.autopilot: failed to get raft stats of %q: %v", parts.Name, err)
+				resultCh <- result{name: parts.Name}
+				return
+			}
+			resultCh <- result{name: parts.Name, stats: &stats}
+		}(parts)
+	}
+
+	out := make(map[string]*structs.RaftStats, len(servers))
+	timeout := time.After(s.config.ServerHealthInterval)
+	for range servers {
+		select {
+		case r := <-resultCh:
+			if r.stats != nil {
+				out[r.name] = r.stats
+			}
+		case <-timeout:
+			return out
+		}
+	}
+	return out
+}
+
+// raftStats returns the Raft stats of this server
+func (s *Server) raftStats() structs.RaftStats {
+	stats := structs.RaftStats{
+		LastContact: -1,
+		LastIndex:   s.raft.LastIndex(),
+	}
+	if term, err := strconv.ParseUint(s.raft.Stats()["last_log_term"], 10, 64); err == nil {
+		stats.LastTerm = term
+	}
+	if last := s.raft.LastContact(); !last.IsZero() {
+		stats.LastContact = time.Since(last)
+	}
+	return stats
+}
@@ -0,0 +1,107 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// waitForPeers waits for the given servers to have the expected number of
+// Raft peers
+func waitForPeers(t *testing.T, expected int, servers ...*Server) {
+	for _, s := range servers {
+		testutil.WaitForResult(func() (bool, error) {
+			peers, err := s.raftPeers.Peers()
+			if err != nil {
+				return false, err
+			}
+			return len(peers) == expected, fmt.Errorf("expected %d peers, got %v", expected, peers)
+		}, func(err error) {
+			t.Fatalf("err: %v", err)
+		})
+	}
+}
+
+func TestAutopilot_CleanupDeadServer(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	s3 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s3.Shutdown()
+	testJoin(t, s1, s2, s3)
+	waitForPeers(t, 3, s1, s2, s3)
+
+	// Kill a follower, it is removed once Serf marks it as failed
+	s3.Shutdown()
+	waitForPeers(t, 2, s1, s2)
+}
+
+func TestAutopilot_CleanupDeadServer_Disabled(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.AutopilotConfig.CleanupDeadServers = false
+	})
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	s3 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s3.Shutdown()
+	testJoin(t, s1, s2, s3)
+	waitForPeers(t, 3, s1, s2, s3)
+
+	// Kill a follower and wait for the leader to see it as unhealthy
+	s3.Shutdown()
+	testutil.WaitForResult(func() (bool, error) {
+		s1.clusterHealthLock.RLock()
+		defer s1.clusterHealthLock.RUnlock()
+		return !s1.clusterHealth.Healthy, fmt.Errorf("cluster still healthy")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The dead server is kept as a peer
+	time.Sleep(500 * time.Millisecond)
+	waitForPeers(t, 3, s1)
+}
+
+func TestAutopilot_PromoteStableServer(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.AutopilotConfig.ServerStabilizationTime = time.Second
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	testJoin(t, s1, s2)
+
+	// The new server is tracked but not added before it is stable
+	testutil.WaitForResult(func() (bool, error) {
+		s1.clusterHealthLock.RLock()
+		defer s1.clusterHealthLock.RUnlock()
+		for _, h := range s1.clusterHealth.Servers {
+			if h.Name == s2.serf.LocalMember().Name {
+				return !h.Voter && h.Healthy, fmt.Errorf("bad: %#v", h)
+			}
+		}
+		return false, fmt.Errorf("server not tracked")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	waitForPeers(t, 1, s1)
+
+	// Once stable it becomes a peer
+	waitForPeers(t, 2, s1, s2)
+}
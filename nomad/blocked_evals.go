@@ -333,6 +333,41 @@ func (b *BlockedEvals) unblock(computedClass string, index uint64) {
 	}
 }
 
+// UnblockQuota causes any evaluation that is blocked on the limit of the
+// passed quota specification to be enqueued into the eval broker.
+func (b *BlockedEvals) UnblockQuota(quota string) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	// Do nothing if not enabled
+	if !b.enabled {
+		return
+	}
+
+	unblocked := make(map[*structs.Evaluation]string, 4)
+	for id, wrapped := range b.captured {
+		if wrapped.eval.QuotaLimitReached == quota {
+			unblocked[wrapped.eval] = wrapped.token
+			delete(b.captured, id)
+			delete(b.jobs, wrapped.eval.JobID)
+		}
+	}
+
+	for id, wrapped := range b.escaped {
+		if wrapped.eval.QuotaLimitReached == quota {
+			unblocked[wrapped.eval] = wrapped.token
+			delete(b.escaped, id)
+			delete(b.jobs, wrapped.eval.JobID)
+			b.stats.TotalEscaped -= 1
+		}
+	}
+
+	if l := len(unblocked); l > 0 {
+		b.stats.TotalBlocked -= l
+		b.evalBroker.EnqueueAll(unblocked)
+	}
+}
+
 // UnblockFailed unblocks all blocked evaluation that were due to scheduler
 // failure.
 func (b *BlockedEvals) UnblockFailed() {
@@ -142,6 +142,11 @@ type Config struct {
 	// the user time to inspect the job.
 	JobGCThreshold time.Duration
 
+	// AllocGCThreshold is how "old" a terminal allocation must be to be
+	// eligible for GC. An evaluation is only collected once all of its
+	// allocations have been.
+	AllocGCThreshold time.Duration
+
 	// NodeGCInterval is how often we dispatch a job to GC failed nodes.
 	NodeGCInterval time.Duration
 
@@ -149,6 +154,15 @@ type Config struct {
 	// for GC. This gives users some time to view and debug a failed nodes.
 	NodeGCThreshold time.Duration
 
+	// DeploymentGCInterval is how often we dispatch a job to GC terminal
+	// deployments.
+	DeploymentGCInterval time.Duration
+
+	// DeploymentGCThreshold is how "old" a terminal deployment must be to be
+	// eligible for GC. This gives users some time to view terminal
+	// deployments.
+	DeploymentGCThreshold time.Duration
+
 	// EvalNackTimeout controls how long we allow a sub-scheduler to
 	// work on an evaluation before we consider it failed and Nack it.
 	// This allows that evaluation to be handed to another sub-scheduler
@@ -161,6 +175,19 @@ type Config struct {
 	// complete eventually fails out of the system.
 	EvalDeliveryLimit int
 
+	// EvalAgingInterval is the time an evaluation must wait in the broker
+	// for its priority to be raised by one. This prevents evaluations of
+	// low priority jobs from being starved by a steady stream of higher
+	// priority evaluations. Setting it to zero disables aging.
+	EvalAgingInterval time.Duration
+
+	// PlanRejectionThreshold is the number of plans a node may reject within
+	// PlanRejectionWindow before it is marked ineligible for scheduling for
+	// PlanRejectionCooldown. Setting it to zero disables the tracking.
+	PlanRejectionThreshold int
+	PlanRejectionWindow    time.Duration
+	PlanRejectionCooldown  time.Duration
+
 	// MinHeartbeatTTL is the minimum time between heartbeats.
 	// This is used as a floor to prevent excessive updates.
 	MinHeartbeatTTL time.Duration
@@ -186,6 +213,9 @@ type Config struct {
 	// VaultConfig is this Agent's Vault configuration
 	VaultConfig *config.VaultConfig
 
+	// ACLEnabled controls if ACL enforcement and management is enabled.
+	ACLEnabled bool
+
 	// RPCHoldTimeout is how long an RPC can be "held" before it is errored.
 	// This is used to paper over a loss of leadership by instead holding RPCs,
 	// so that the caller experiences a slow response rather than an error.
@@ -195,6 +225,24 @@ type Config struct {
 
 	// TLSConfig holds various TLS related configurations
 	TLSConfig *config.TLSConfig
+
+	// AutopilotConfig is the Autopilot configuration used when the cluster
+	// does not have one stored yet. The stored configuration can be changed
+	// at runtime through the operator endpoint.
+	AutopilotConfig *structs.AutopilotConfig
+
+	// SchedulerConfig is the scheduler configuration used when the cluster
+	// does not have one stored yet. The stored configuration can be changed
+	// at runtime through the operator endpoint.
+	SchedulerConfig *structs.SchedulerConfig
+
+	// AutopilotInterval is the interval at which the leader promotes stable
+	// servers and removes dead ones.
+	AutopilotInterval time.Duration
+
+	// ServerHealthInterval is the interval at which the leader checks the
+	// health of the servers.
+	ServerHealthInterval time.Duration
 }
 
 // CheckVersion is used to check if the ProtocolVersion is valid
@@ -234,8 +282,15 @@ func DefaultConfig() *Config {
 		JobGCThreshold:         4 * time.Hour,
 		NodeGCInterval:         5 * time.Minute,
 		NodeGCThreshold:        24 * time.Hour,
+		AllocGCThreshold:       1 * time.Hour,
+		DeploymentGCInterval:   5 * time.Minute,
+		DeploymentGCThreshold:  1 * time.Hour,
 		EvalNackTimeout:        60 * time.Second,
 		EvalDeliveryLimit:      3,
+		EvalAgingInterval:      1 * time.Minute,
+		PlanRejectionThreshold: 100,
+		PlanRejectionWindow:    5 * time.Minute,
+		PlanRejectionCooldown:  10 * time.Minute,
 		MinHeartbeatTTL:        10 * time.Second,
 		MaxHeartbeatsPerSecond: 50.0,
 		HeartbeatGrace:         10 * time.Second,
@@ -244,6 +299,17 @@ func DefaultConfig() *Config {
 		VaultConfig:            config.DefaultVaultConfig(),
 		RPCHoldTimeout:         5 * time.Second,
 		TLSConfig:              &config.TLSConfig{},
+		AutopilotConfig: &structs.AutopilotConfig{
+			CleanupDeadServers:      true,
+			LastContactThreshold:    200 * time.Millisecond,
+			MaxTrailingLogs:         250,
+			ServerStabilizationTime: 10 * time.Second,
+		},
+		SchedulerConfig: &structs.SchedulerConfig{
+			SchedulerAlgorithm: structs.SchedulerAlgorithmBinpack,
+		},
+		AutopilotInterval:    10 * time.Second,
+		ServerHealthInterval: 2 * time.Second,
 	}
 
 	// Enable all known schedulers by default
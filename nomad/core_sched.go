@@ -43,6 +43,8 @@ func (c *CoreScheduler) Process(eval *structs.Evaluation) error {
 		return c.nodeGC(eval)
 	case structs.CoreJobJobGC:
 		return c.jobGC(eval)
+	case structs.CoreJobDeploymentGC:
+		return c.deploymentGC(eval)
 	case structs.CoreJobForceGC:
 		return c.forceGC(eval)
 	default:
@@ -58,6 +60,9 @@ func (c *CoreScheduler) forceGC(eval *structs.Evaluation) error {
 	if err := c.evalGC(eval); err != nil {
 		return err
 	}
+	if err := c.deploymentGC(eval); err != nil {
+		return err
+	}
 
 	// Node GC must occur after the others to ensure the allocations are
 	// cleared.
@@ -108,7 +113,7 @@ OUTER:
 		allEvalsGC := true
 		var jobAlloc, jobEval []string
 		for _, eval := range evals {
-			gc, allocs, err := c.gcEval(eval, oldThreshold, true)
+			gc, allocs, err := c.gcEval(eval, oldThreshold, oldThreshold, true)
 			if err != nil {
 				continue OUTER
 			}
@@ -147,7 +152,8 @@ OUTER:
 		req := structs.JobDeregisterRequest{
 			JobID: job,
 			WriteRequest: structs.WriteRequest{
-				Region: c.srv.config.Region,
+				Region:    c.srv.config.Region,
+				AuthToken: eval.LeaderACL,
 			},
 		}
 		var resp structs.JobDeregisterResponse
@@ -168,21 +174,24 @@ func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
 		return err
 	}
 
-	var oldThreshold uint64
+	var oldThreshold, allocThreshold uint64
 	if eval.JobID == structs.CoreJobForceGC {
-		// The GC was forced, so set the threshold to its maximum so everything
-		// will GC.
+		// The GC was forced, so set the thresholds to their maximum so
+		// everything will GC.
 		oldThreshold = math.MaxUint64
+		allocThreshold = math.MaxUint64
 		c.srv.logger.Println("[DEBUG] sched.core: forced eval GC")
 	} else {
-		// Compute the old threshold limit for GC using the FSM
+		// Compute the old threshold limits for GC using the FSM
 		// time table.  This is a rough mapping of a time to the
 		// Raft index it belongs to.
 		tt := c.srv.fsm.TimeTable()
 		cutoff := time.Now().UTC().Add(-1 * c.srv.config.EvalGCThreshold)
 		oldThreshold = tt.NearestIndex(cutoff)
-		c.srv.logger.Printf("[DEBUG] sched.core: eval GC: scanning before index %d (%v)",
-			oldThreshold, c.srv.config.EvalGCThreshold)
+		allocCutoff := time.Now().UTC().Add(-1 * c.srv.config.AllocGCThreshold)
+		allocThreshold = tt.NearestIndex(allocCutoff)
+		c.srv.logger.Printf("[DEBUG] sched.core: eval GC: scanning before index %d (%v), allocs before index %d (%v)",
+			oldThreshold, c.srv.config.EvalGCThreshold, allocThreshold, c.srv.config.AllocGCThreshold)
 	}
 
 	// Collect the allocations and evaluations to GC
@@ -192,7 +201,7 @@ func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
 
 		// The Evaluation GC should not handle batch jobs since those need to be
 		// garbage collected in one shot
-		gc, allocs, err := c.gcEval(eval, oldThreshold, false)
+		gc, allocs, err := c.gcEval(eval, oldThreshold, allocThreshold, false)
 		if err != nil {
 			return err
 		}
@@ -213,15 +222,15 @@ func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
 	return c.evalReap(gcEval, gcAlloc)
 }
 
-// gcEval returns whether the eval should be garbage collected given a raft
-// threshold index. The eval disqualifies for garbage collection if it or its
-// allocs are not older than the threshold. If the eval should be garbage
-// collected, the associated alloc ids that should also be removed are also
-// returned
-func (c *CoreScheduler) gcEval(eval *structs.Evaluation, thresholdIndex uint64, allowBatch bool) (
+// gcEval returns whether the eval should be garbage collected given the raft
+// threshold indexes of evals and allocs. The eval disqualifies for garbage
+// collection if it is not older than the eval threshold or any of its allocs
+// is not terminal and older than the alloc threshold. The ids of the allocs of
+// the terminal eval that are old enough to be removed are also returned.
+func (c *CoreScheduler) gcEval(eval *structs.Evaluation, thresholdIndex, allocThresholdIndex uint64, allowBatch bool) (
 	bool, []string, error) {
-	// Ignore non-terminal and new evaluations
-	if !eval.TerminalStatus() || eval.ModifyIndex > thresholdIndex {
+	// Ignore non-terminal evaluations
+	if !eval.TerminalStatus() {
 		return false, nil, nil
 	}
 
@@ -255,10 +264,10 @@ func (c *CoreScheduler) gcEval(eval *structs.Evaluation, thresholdIndex uint64,
 	}
 
 	// Scan the allocations to ensure they are terminal and old
-	gcEval := true
+	gcEval := eval.ModifyIndex <= thresholdIndex
 	var gcAllocIDs []string
 	for _, alloc := range allocs {
-		if !alloc.TerminalStatus() || alloc.ModifyIndex > thresholdIndex {
+		if !alloc.TerminalStatus() || alloc.ModifyIndex > allocThresholdIndex {
 			// Can't GC the evaluation since not all of the allocations are
 			// terminal
 			gcEval = false
@@ -404,7 +413,8 @@ OUTER:
 		req := structs.NodeDeregisterRequest{
 			NodeID: nodeID,
 			WriteRequest: structs.WriteRequest{
-				Region: c.srv.config.Region,
+				Region:    c.srv.config.Region,
+				AuthToken: eval.LeaderACL,
 			},
 		}
 		var resp structs.NodeUpdateResponse
@@ -415,3 +425,94 @@ OUTER:
 	}
 	return nil
 }
+
+// deploymentGC is used to garbage collect old deployments
+func (c *CoreScheduler) deploymentGC(eval *structs.Evaluation) error {
+	// Iterate over the deployments
+	iter, err := c.snap.Deployments()
+	if err != nil {
+		return err
+	}
+
+	var oldThreshold uint64
+	if eval.JobID == structs.CoreJobForceGC {
+		// The GC was forced, so set the threshold to its maximum so everything
+		// will GC.
+		oldThreshold = math.MaxUint64
+		c.srv.logger.Println("[DEBUG] sched.core: forced deployment GC")
+	} else {
+		// Compute the old threshold limit for GC using the FSM
+		// time table.  This is a rough mapping of a time to the
+		// Raft index it belongs to.
+		tt := c.srv.fsm.TimeTable()
+		cutoff := time.Now().UTC().Add(-1 * c.srv.config.DeploymentGCThreshold)
+		oldThreshold = tt.NearestIndex(cutoff)
+		c.srv.logger.Printf("[DEBUG] sched.core: deployment GC: scanning before index %d (%v)",
+			oldThreshold, c.srv.config.DeploymentGCThreshold)
+	}
+
+	// Collect the deployments to GC
+	var gcDeployment []string
+OUTER:
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		deploy := raw.(*structs.Deployment)
+
+		// Ignore active and new deployments
+		if deploy.Active() || deploy.ModifyIndex > oldThreshold {
+			continue
+		}
+
+		// Get the allocations of the deployment
+		allocs, err := c.snap.AllocsByDeployment(deploy.ID)
+		if err != nil {
+			c.srv.logger.Printf("[ERR] sched.core: failed to get allocs for deployment %s: %v",
+				deploy.ID, err)
+			continue
+		}
+
+		// Skip the deployment while non-terminal allocations reference it
+		for _, alloc := range allocs {
+			if !alloc.TerminalStatus() {
+				continue OUTER
+			}
+		}
+
+		// Deployment is eligible for garbage collection
+		gcDeployment = append(gcDeployment, deploy.ID)
+	}
+
+	// Fast-path the nothing case
+	if len(gcDeployment) == 0 {
+		return nil
+	}
+	c.srv.logger.Printf("[DEBUG] sched.core: deployment GC: %d deployments eligible", len(gcDeployment))
+
+	return c.deploymentReap(eval, gcDeployment)
+}
+
+// deploymentReap contacts the leader and issues a reap on the passed
+// deployments, ensuring a single request does not contain too many ids.
+func (c *CoreScheduler) deploymentReap(eval *structs.Evaluation, deployments []string) error {
+	for len(deployments) != 0 {
+		n := len(deployments)
+		if n > maxIdsPerReap {
+			n = maxIdsPerReap
+		}
+
+		req := structs.DeploymentDeleteRequest{
+			Deployments: deployments[:n],
+			WriteRequest: structs.WriteRequest{
+				Region:    c.srv.config.Region,
+				AuthToken: eval.LeaderACL,
+			},
+		}
+		var resp structs.GenericResponse
+		if err := c.srv.RPC("Deployment.Reap", &req, &resp); err != nil {
+			c.srv.logger.Printf("[ERR] sched.core: deployment reap failed: %v", err)
+			return err
+		}
+		deployments = deployments[n:]
+	}
+
+	return nil
+}
@@ -285,6 +285,72 @@ func TestCoreScheduler_EvalGC_Partial(t *testing.T) {
 	}
 }
 
+func TestCoreScheduler_EvalGC_AllocThreshold(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.AllocGCThreshold = 2 * time.Hour
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// COMPAT Remove in 0.6: Reset the FSM time table since we reconcile which sets index 0
+	s1.fsm.timetable.table = make([]TimeTableEntry, 1, 10)
+
+	// Insert "dead" eval
+	state := s1.fsm.State()
+	eval := mock.Eval()
+	eval.Status = structs.EvalStatusComplete
+	state.UpsertJobSummary(999, mock.JobSummary(eval.JobID))
+	err := state.UpsertEvals(1000, []*structs.Evaluation{eval})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Insert "dead" alloc
+	alloc := mock.Alloc()
+	alloc.EvalID = eval.ID
+	alloc.DesiredStatus = structs.AllocDesiredStatusStop
+	alloc.JobID = eval.JobID
+	err = state.UpsertAllocs(1001, []*structs.Allocation{alloc})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Update the time tables so the eval is old but the alloc is not
+	tt := s1.fsm.TimeTable()
+	tt.Witness(2000, time.Now().UTC().Add(-1*s1.config.EvalGCThreshold))
+
+	// Create a core scheduler
+	snap, err := state.Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	core := NewCoreScheduler(s1, snap)
+
+	// Attempt the GC
+	gc := s1.coreJobEval(structs.CoreJobEvalGC, 2000)
+	err = core.Process(gc)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Both should still exist
+	out, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("bad: %v", out)
+	}
+
+	outA, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outA == nil {
+		t.Fatalf("bad: %v", outA)
+	}
+}
+
 func TestCoreScheduler_EvalGC_Force(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -965,6 +1031,119 @@ func TestCoreScheduler_JobGC_Force(t *testing.T) {
 	}
 }
 
+func TestCoreScheduler_DeploymentGC(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// COMPAT Remove in 0.6: Reset the FSM time table since we reconcile which sets index 0
+	s1.fsm.timetable.table = make([]TimeTableEntry, 1, 10)
+
+	// Insert a terminal deployment, a terminal deployment still referenced
+	// by a running alloc and a running deployment
+	state := s1.fsm.State()
+	d1, d2, d3 := mock.Deployment(), mock.Deployment(), mock.Deployment()
+	d1.Status = structs.DeploymentStatusSuccessful
+	d2.Status = structs.DeploymentStatusFailed
+	for i, d := range []*structs.Deployment{d1, d2, d3} {
+		if err := state.UpsertDeployment(uint64(1000+i), d, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	alloc := mock.Alloc()
+	alloc.JobID = d2.JobID
+	alloc.DeploymentID = d2.ID
+	state.UpsertJobSummary(1003, mock.JobSummary(alloc.JobID))
+	if err := state.UpsertAllocs(1004, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Insert a new terminal deployment
+	d4 := mock.Deployment()
+	d4.Status = structs.DeploymentStatusCancelled
+	if err := state.UpsertDeployment(2001, d4, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Update the time tables to make this work
+	tt := s1.fsm.TimeTable()
+	tt.Witness(2000, time.Now().UTC().Add(-1*s1.config.DeploymentGCThreshold))
+
+	// Create a core scheduler
+	snap, err := state.Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	core := NewCoreScheduler(s1, snap)
+
+	// Attempt the GC
+	gc := s1.coreJobEval(structs.CoreJobDeploymentGC, 2001)
+	if err := core.Process(gc); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Only the first deployment should be gone
+	for _, d := range []*structs.Deployment{d1, d2, d3, d4} {
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if gone := out == nil; gone != (d == d1) {
+			t.Fatalf("bad: deployment %q gone: %v", d.ID, gone)
+		}
+	}
+}
+
+func TestCoreScheduler_DeploymentGC_Force(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// COMPAT Remove in 0.6: Reset the FSM time table since we reconcile which sets index 0
+	s1.fsm.timetable.table = make([]TimeTableEntry, 1, 10)
+
+	// Insert a terminal and a running deployment
+	state := s1.fsm.State()
+	d1, d2 := mock.Deployment(), mock.Deployment()
+	d1.Status = structs.DeploymentStatusFailed
+	if err := state.UpsertDeployment(1000, d1, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1001, d2, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a core scheduler
+	snap, err := state.Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	core := NewCoreScheduler(s1, snap)
+
+	// Attempt the GC
+	gc := s1.coreJobEval(structs.CoreJobForceGC, 1001)
+	if err := core.Process(gc); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d1.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %v", out)
+	}
+
+	out, err = state.DeploymentByID(d2.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("running deployment gone")
+	}
+}
+
 func TestCoreScheduler_PartitionReap(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
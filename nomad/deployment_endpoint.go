@@ -0,0 +1,330 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Deployment endpoint is used for manipulating deployments
+type Deployment struct {
+	srv *Server
+}
+
+// GetDeployment is used to request information about a specific deployment
+func (d *Deployment) GetDeployment(args *structs.DeploymentSpecificRequest,
+	reply *structs.SingleDeploymentResponse) error {
+	if done, err := d.srv.forward("Deployment.GetDeployment", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "get_deployment"}, time.Now())
+
+	// Check the token may read the deployment's job
+	if err := d.checkDeploymentOperation(args.AuthToken, args.DeploymentID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Deployment: args.DeploymentID}),
+		run: func() error {
+			// Look for the deployment
+			snap, err := d.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.DeploymentByID(args.DeploymentID)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Deployment = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the deployment table
+				index, err := snap.Index("deployment")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// List is used to list the deployments in the system
+func (d *Deployment) List(args *structs.DeploymentListRequest, reply *structs.DeploymentListResponse) error {
+	if done, err := d.srv.forward("Deployment.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "list"}, time.Now())
+
+	// Resolve the token so deployments can be filtered by namespace
+	aclObj, err := d.srv.resolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "deployment"}),
+		run: func() error {
+			// Capture all the deployments
+			snap, err := d.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.DeploymentsByIDPrefix(prefix)
+			} else {
+				iter, err = snap.Deployments()
+			}
+			if err != nil {
+				return err
+			}
+
+			paginator, err := newPaginator(&args.QueryOptions)
+			if err != nil {
+				return err
+			}
+
+			allow := jobNamespaceFilter(snap, aclObj, acl.NamespaceCapabilityReadJob, &args.QueryOptions)
+			var deploys []*structs.Deployment
+			for !paginator.done() {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				deploy := raw.(*structs.Deployment)
+				if ok, err := allow(deploy.JobID); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+				if ok, err := paginator.accept(deploy.ID, deploy); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+				deploys = append(deploys, deploy)
+			}
+			reply.Deployments = deploys
+			reply.NextToken = paginator.nextPageToken()
+
+			// Use the last index that affected the deployment table
+			index, err := snap.Index("deployment")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			d.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return d.srv.blockingRPC(&opts)
+}
+
+// Promote is used to promote the canaries of a deployment so that the
+// remaining allocations are updated
+func (d *Deployment) Promote(args *structs.DeploymentPromoteRequest, reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Promote", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "promote"}, time.Now())
+
+	// Validate the arguments
+	if args.DeploymentID == "" {
+		return fmt.Errorf("missing deployment ID")
+	}
+
+	// Check the token may submit the deployment's job
+	if err := d.checkDeploymentOperation(args.AuthToken, args.DeploymentID, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+	if !args.All && len(args.Groups) == 0 {
+		return fmt.Errorf("must promote all groups or at least one group")
+	}
+
+	deployment, job, err := d.activeDeployment(args.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range args.Groups {
+		if _, ok := deployment.TaskGroups[group]; !ok {
+			return fmt.Errorf("deployment %q has no task group %q", deployment.ID, group)
+		}
+	}
+
+	// Commit the promotion along with the evaluation continuing the rollout
+	eval := deploymentEval(job)
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: *args,
+		Eval:                     eval,
+	}
+	resp, index, err := d.srv.raftApply(structs.DeploymentPromoteRequestType, req)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: Promote failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = index
+	reply.DeploymentModifyIndex = index
+	reply.Index = index
+	return nil
+}
+
+// Fail is used to mark a deployment as failed, stopping the rollout
+func (d *Deployment) Fail(args *structs.DeploymentFailRequest, reply *structs.DeploymentUpdateResponse) error {
+	if done, err := d.srv.forward("Deployment.Fail", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "fail"}, time.Now())
+
+	// Validate the arguments
+	if args.DeploymentID == "" {
+		return fmt.Errorf("missing deployment ID")
+	}
+
+	// Check the token may submit the deployment's job
+	if err := d.checkDeploymentOperation(args.AuthToken, args.DeploymentID, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+
+	deployment, job, err := d.activeDeployment(args.DeploymentID)
+	if err != nil {
+		return err
+	}
+
+	// Commit the status update along with an evaluation of the job
+	eval := deploymentEval(job)
+	req := &structs.DeploymentStatusUpdateRequest{
+		Eval: eval,
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      deployment.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedByUser,
+		},
+		WriteRequest: args.WriteRequest,
+	}
+	resp, index, err := d.srv.raftApply(structs.DeploymentStatusUpdateRequestType, req)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		d.srv.logger.Printf("[ERR] nomad.deployment: Fail failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = index
+	reply.DeploymentModifyIndex = index
+	reply.Index = index
+	return nil
+}
+
+// Reap is used to delete terminal deployments. It is called by the core
+// scheduler to garbage collect them.
+func (d *Deployment) Reap(args *structs.DeploymentDeleteRequest, reply *structs.GenericResponse) error {
+	if done, err := d.srv.forward("Deployment.Reap", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "deployment", "reap"}, time.Now())
+
+	// Check management level permissions
+	if err := d.srv.checkACL(args.AuthToken, (*acl.ACL).IsManagement); err != nil {
+		return err
+	}
+
+	// Update via Raft
+	_, index, err := d.srv.raftApply(structs.DeploymentDeleteRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// checkDeploymentOperation returns ErrPermissionDenied if the token may not
+// perform the operation on the job of the given deployment.
+func (d *Deployment) checkDeploymentOperation(secretID, deploymentID, op string) error {
+	// Fast-path if ACLs are disabled
+	if !d.srv.config.ACLEnabled {
+		return nil
+	}
+
+	var jobID string
+	deployment, err := d.srv.fsm.State().DeploymentByID(deploymentID)
+	if err != nil {
+		return err
+	}
+	if deployment != nil {
+		jobID = deployment.JobID
+	}
+	return d.srv.checkJobOperation(secretID, jobID, op)
+}
+
+// activeDeployment looks up a running deployment and its job
+func (d *Deployment) activeDeployment(id string) (*structs.Deployment, *structs.Job, error) {
+	snap, err := d.srv.fsm.State().Snapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+	deployment, err := snap.DeploymentByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deployment == nil {
+		return nil, nil, fmt.Errorf("deployment not found")
+	}
+	if !deployment.Active() {
+		return nil, nil, fmt.Errorf("can't modify terminal deployment")
+	}
+
+	job, err := snap.JobByID(deployment.JobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job == nil {
+		return nil, nil, fmt.Errorf("job not found")
+	}
+	return deployment, job, nil
+}
+
+// deploymentEval returns an evaluation of the job of a deployment
+func deploymentEval(job *structs.Job) *structs.Evaluation {
+	return &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    structs.EvalTriggerDeployment,
+		JobID:          job.ID,
+		JobModifyIndex: job.ModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}
+}
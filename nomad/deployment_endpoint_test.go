@@ -0,0 +1,199 @@
+package nomad
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestDeploymentEndpoint_GetDeployment(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployment
+	d := mock.Deployment()
+	if err := s1.fsm.State().UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployment
+	get := &structs.DeploymentSpecificRequest{
+		DeploymentID: d.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleDeploymentResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if !reflect.DeepEqual(d, resp.Deployment) {
+		t.Fatalf("bad: %#v %#v", d, resp.Deployment)
+	}
+
+	// Lookup a non-existing deployment
+	get.DeploymentID = structs.GenerateUUID()
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.GetDeployment", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if resp.Deployment != nil {
+		t.Fatalf("unexpected deployment")
+	}
+}
+
+func TestDeploymentEndpoint_List(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the deployments
+	d1 := mock.Deployment()
+	d1.ID = "aaaaaaaa-3350-4b4b-d185-0e1992ed43e9"
+	d2 := mock.Deployment()
+	d2.ID = "aaaabbbb-3350-4b4b-d185-0e1992ed43e9"
+	if err := s1.fsm.State().UpsertDeployment(1000, d1, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s1.fsm.State().UpsertDeployment(1001, d2, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the deployments
+	get := &structs.DeploymentListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1001 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1001)
+	}
+	if len(resp.Deployments) != 2 {
+		t.Fatalf("bad: %#v", resp.Deployments)
+	}
+
+	// Lookup the deployments by prefix
+	get.QueryOptions.Prefix = "aaaabb"
+	var resp2 structs.DeploymentListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.List", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Deployments) != 1 || resp2.Deployments[0].ID != d2.ID {
+		t.Fatalf("bad: %#v", resp2.Deployments)
+	}
+}
+
+func TestDeploymentEndpoint_Promote(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create the job and its deployment
+	job := mock.Job()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := mock.Deployment()
+	d.JobID = job.ID
+	if err := state.UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promoting an unknown group fails
+	req := &structs.DeploymentPromoteRequest{
+		DeploymentID: d.ID,
+		Groups:       []string{"foo"},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Promote the group
+	req.Groups = []string{"web"}
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Promote", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Check the deployment was promoted
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.TaskGroups["web"].Promoted {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Check the eval was created
+	eval, err := state.EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || eval.JobID != job.ID || eval.TriggeredBy != structs.EvalTriggerDeployment {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
+
+func TestDeploymentEndpoint_Fail(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create the job and its deployment
+	job := mock.Job()
+	if err := state.UpsertJob(999, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := mock.Deployment()
+	d.JobID = job.ID
+	if err := state.UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Fail the deployment
+	req := &structs.DeploymentFailRequest{
+		DeploymentID: d.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.DeploymentUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 || resp.EvalID == "" {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// Check the deployment was failed
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Failing a terminal deployment is an error
+	if err := msgpackrpc.CallWithCodec(codec, "Deployment.Fail", req, &resp); err == nil {
+		t.Fatalf("expected error")
+	}
+}
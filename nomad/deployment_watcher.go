@@ -0,0 +1,193 @@
+package nomad
+
+import (
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+const (
+	// deploymentWatchInterval is the interval at which the running
+	// deployments are checked even if no change was observed.
+	deploymentWatchInterval = 30 * time.Second
+)
+
+// watchDeployments is a long lived function that tracks the health of the
+// allocations of the running deployments while we are leader. Deployments
+// whose allocations are all healthy are marked as successful, making the
+// deployed version of the job stable, and deployments with unhealthy
+// allocations are failed, reverting the job to its latest stable version if
+// requested.
+func (s *Server) watchDeployments(stopCh chan struct{}) {
+	notifyCh := make(chan struct{}, 1)
+	items := watch.NewItems(
+		watch.Item{Table: "deployment"},
+		watch.Item{Table: "allocs"},
+	)
+
+	for {
+		// The state store is replaced on a snapshot restore so the watch is
+		// setup again on every iteration.
+		state := s.fsm.State()
+		state.Watch(items, notifyCh)
+		s.checkDeployments()
+
+		select {
+		case <-stopCh:
+			state.StopWatch(items, notifyCh)
+			return
+		case <-notifyCh:
+		case <-time.After(deploymentWatchInterval):
+		}
+		state.StopWatch(items, notifyCh)
+	}
+}
+
+// checkDeployments updates the health of every running deployment
+func (s *Server) checkDeployments() {
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.deployment: failed to snapshot state: %v", err)
+		return
+	}
+
+	iter, err := snap.Deployments()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.deployment: failed to get deployments: %v", err)
+		return
+	}
+
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		d := raw.(*structs.Deployment)
+		if !d.Active() {
+			continue
+		}
+		if err := s.checkDeployment(snap, d); err != nil {
+			s.logger.Printf("[ERR] nomad.deployment: failed to update deployment %q: %v", d.ID, err)
+		}
+	}
+}
+
+// checkDeployment counts the healthy and unhealthy allocations of the
+// deployed version of the job and updates the deployment if they changed.
+func (s *Server) checkDeployment(snap *state.StateSnapshot, d *structs.Deployment) error {
+	allocs, err := snap.AllocsByJob(d.JobID)
+	if err != nil {
+		return err
+	}
+
+	req := &structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentID:    d.ID,
+		HealthyAllocs:   make(map[string]int),
+		UnhealthyAllocs: make(map[string]int),
+	}
+
+	for _, alloc := range allocs {
+		job := alloc.Job
+		if job == nil || job.CreateIndex != d.JobCreateIndex || job.JobModifyIndex != d.JobModifyIndex {
+			continue
+		}
+
+		if _, ok := d.TaskGroups[alloc.TaskGroup]; !ok {
+			continue
+		}
+		switch healthy, unhealthy := allocHealth(alloc); {
+		case unhealthy:
+			req.UnhealthyAllocs[alloc.TaskGroup]++
+		case healthy:
+			req.HealthyAllocs[alloc.TaskGroup]++
+		}
+	}
+
+	// Apply the health to a copy to determine the new status
+	updated := d.Copy()
+	changed := false
+	unhealthy := false
+	for name, group := range updated.TaskGroups {
+		if group.HealthyAllocs != req.HealthyAllocs[name] || group.UnhealthyAllocs != req.UnhealthyAllocs[name] {
+			changed = true
+		}
+		group.HealthyAllocs = req.HealthyAllocs[name]
+		group.UnhealthyAllocs = req.UnhealthyAllocs[name]
+		if group.UnhealthyAllocs > 0 {
+			unhealthy = true
+		}
+	}
+
+	switch {
+	case unhealthy:
+		req.DeploymentUpdate = &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedAllocations,
+		}
+
+		// Revert to the latest stable version of the job
+		if d.HasAutoRevert() {
+			revert, err := latestStableJob(snap, d)
+			if err != nil {
+				return err
+			}
+
+			if revert != nil {
+				req.DeploymentUpdate.StatusDescription = structs.DeploymentStatusDescriptionRollback(
+					structs.DeploymentStatusDescriptionFailedAllocations, revert.Version)
+				req.Job = revert.Copy()
+				req.Eval = deploymentEval(req.Job)
+			} else {
+				s.logger.Printf("[WARN] nomad.deployment: no stable version of job %q to revert deployment %q to",
+					d.JobID, d.ID)
+			}
+		}
+	case updated.Healthy():
+		req.DeploymentUpdate = &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		}
+	case !changed:
+		return nil
+	}
+
+	resp, _, err := s.raftApply(structs.DeploymentAllocHealthRequestType, req)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	return err
+}
+
+// allocHealth returns whether the allocation placed by a deployment is
+// healthy or unhealthy. Neither is set while its health is not yet known. When
+// the update strategy derives the health from checks, only the health
+// reported by the client marks the allocation healthy.
+func allocHealth(alloc *structs.Allocation) (healthy, unhealthy bool) {
+	if alloc.ClientStatus == structs.AllocClientStatusFailed || alloc.DeploymentStatus.IsUnhealthy() {
+		return false, true
+	}
+	if alloc.ClientStatus != structs.AllocClientStatusRunning || alloc.DesiredStatus != structs.AllocDesiredStatusRun {
+		return false, false
+	}
+	if alloc.Job.LookupUpdateStrategy(alloc.TaskGroup).HealthChecks() {
+		return alloc.DeploymentStatus.IsHealthy(), false
+	}
+	return true, false
+}
+
+// latestStableJob returns the latest stable version of the job older than the
+// version deployed by the deployment or nil if there is none.
+func latestStableJob(snap *state.StateSnapshot, d *structs.Deployment) (*structs.Job, error) {
+	versions, err := snap.JobVersionsByID(d.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Versions are sorted from newest to oldest
+	for _, job := range versions {
+		if job.CreateIndex == d.JobCreateIndex && job.JobModifyIndex < d.JobModifyIndex && job.Stable {
+			return job, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,267 @@
+package nomad
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestDeploymentWatcher_Healthy(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create a job and its deployment
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := mock.Deployment()
+	d.JobID = job.ID
+	d.JobCreateIndex = job.CreateIndex
+	d.JobModifyIndex = job.JobModifyIndex
+	d.TaskGroups["web"] = &structs.DeploymentState{DesiredTotal: 2}
+	if err := state.UpsertDeployment(1001, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create running allocations of the deployed version of the job
+	var allocs []*structs.Allocation
+	for i := 0; i < 2; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.DeploymentID = d.ID
+		alloc.ClientStatus = structs.AllocClientStatusRunning
+		allocs = append(allocs, alloc)
+	}
+	if err := state.UpsertAllocs(1002, allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The deployment is marked successful
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusSuccessful {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		if out.TaskGroups["web"].HealthyAllocs != 2 {
+			return false, fmt.Errorf("bad: %#v", out.TaskGroups["web"])
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The deployed version of the job is stable
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.Stable {
+		t.Fatalf("bad: %#v", out)
+	}
+	versions, err := state.JobVersionsByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(versions) != 1 || !versions[0].Stable {
+		t.Fatalf("bad: %#v", versions)
+	}
+}
+
+func TestDeploymentWatcher_AutoRevert(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create a stable job, update it twice and deploy the last version
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stable := mock.Deployment()
+	stable.JobID = job.ID
+	stable.JobCreateIndex = job.CreateIndex
+	stable.JobModifyIndex = job.JobModifyIndex
+	if err := state.UpsertDeployment(1001, stable, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	healthy := &structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentID: stable.ID,
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID: stable.ID,
+			Status:       structs.DeploymentStatusSuccessful,
+		},
+	}
+	if err := state.UpdateDeploymentAllocHealth(1002, healthy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	unstable := job.Copy()
+	unstable.TaskGroups[0].Tasks[0].Config = map[string]interface{}{"command": "/bin/unstable"}
+	if err := state.UpsertJob(1003, unstable); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job2 := job.Copy()
+	job2.TaskGroups[0].Tasks[0].Config = map[string]interface{}{"command": "/bin/other"}
+	if err := state.UpsertJob(1004, job2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	d := mock.Deployment()
+	d.JobID = job.ID
+	d.JobCreateIndex = job2.CreateIndex
+	d.JobModifyIndex = job2.JobModifyIndex
+	d.TaskGroups["web"] = &structs.DeploymentState{
+		AutoRevert:   true,
+		DesiredTotal: 2,
+	}
+	if err := state.UpsertDeployment(1005, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The allocation of the deployed version failed
+	failed := mock.Alloc()
+	failed.Job = job2
+	failed.JobID = job.ID
+	failed.DeploymentID = d.ID
+	failed.ClientStatus = structs.AllocClientStatusFailed
+	if err := state.UpsertAllocs(1006, []*structs.Allocation{failed}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The deployment fails and the job is reverted
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusFailed {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		if !strings.Contains(out.StatusDescription, "rolling back to stable job version 0") {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.JobModifyIndex == job2.JobModifyIndex || out.Stable {
+		t.Fatalf("job not reverted: %#v", out)
+	}
+	if out.TaskGroups[0].Tasks[0].Config["command"] != job.TaskGroups[0].Tasks[0].Config["command"] {
+		t.Fatalf("bad: %#v", out.TaskGroups[0].Tasks[0].Config)
+	}
+
+	// The scheduler may create a blocked eval once the revert is processed,
+	// so only count the evals created by the deployment watcher.
+	evals, err := state.EvalsByJob(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	deploymentEvals := 0
+	for _, eval := range evals {
+		if eval.TriggeredBy == structs.EvalTriggerDeployment && eval.PreviousEval == "" {
+			deploymentEvals++
+		}
+	}
+	if deploymentEvals != 1 {
+		t.Fatalf("bad: %#v", evals)
+	}
+}
+
+func TestDeploymentWatcher_FailedNoRevert(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create a job and its deployment
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := mock.Deployment()
+	d.JobID = job.ID
+	d.JobCreateIndex = job.CreateIndex
+	d.JobModifyIndex = job.JobModifyIndex
+	if err := state.UpsertDeployment(1001, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	failed := mock.Alloc()
+	failed.Job = job
+	failed.JobID = job.ID
+	failed.ClientStatus = structs.AllocClientStatusFailed
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{failed}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The deployment fails without touching the job
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.DeploymentByID(d.ID)
+		if err != nil {
+			return false, err
+		}
+		if out.Status != structs.DeploymentStatusFailed ||
+			out.StatusDescription != structs.DeploymentStatusDescriptionFailedAllocations {
+			return false, fmt.Errorf("bad: %#v", out)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.JobModifyIndex != job.JobModifyIndex {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestDeploymentWatcher_AllocHealth_Checks(t *testing.T) {
+	job := mock.Job()
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.ClientStatus = structs.AllocClientStatusRunning
+
+	// A running allocation is healthy by default
+	if healthy, unhealthy := allocHealth(alloc); !healthy || unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+
+	// It waits for the health reported by the client if the health is
+	// derived from the checks
+	job.Update.HealthCheck = structs.UpdateHealthCheckChecks
+	if healthy, unhealthy := allocHealth(alloc); healthy || unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+
+	status := true
+	alloc.DeploymentStatus = &structs.AllocDeploymentStatus{Healthy: &status}
+	if healthy, unhealthy := allocHealth(alloc); !healthy || unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+
+	status = false
+	if healthy, unhealthy := allocHealth(alloc); healthy || !unhealthy {
+		t.Fatalf("bad: %v %v", healthy, unhealthy)
+	}
+}
@@ -0,0 +1,319 @@
+package nomad
+
+import (
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+const (
+	// drainWatchInterval is the maximum interval at which the draining nodes
+	// are checked. Migrated allocations become healthy with the passing of
+	// time so the nodes are checked even if no change was observed.
+	drainWatchInterval = 5 * time.Second
+)
+
+// watchDrains is a long lived function that migrates the allocations of the
+// draining nodes while we are leader. The allocations of each task group are
+// marked for migration according to its migrate strategy so that only a
+// limited number of them are moved at the same time. Allocations of system
+// jobs are migrated last and all the remaining allocations are migrated once
+// the deadline of the drain is reached.
+func (s *Server) watchDrains(stopCh chan struct{}) {
+	notifyCh := make(chan struct{}, 1)
+	items := watch.NewItems(
+		watch.Item{Table: "nodes"},
+		watch.Item{Table: "allocs"},
+	)
+
+	for {
+		// The state store is replaced on a snapshot restore so the watch is
+		// setup again on every iteration.
+		state := s.fsm.State()
+		state.Watch(items, notifyCh)
+		wait := s.checkDrains()
+
+		select {
+		case <-stopCh:
+			state.StopWatch(items, notifyCh)
+			return
+		case <-notifyCh:
+		case <-time.After(wait):
+		}
+		state.StopWatch(items, notifyCh)
+	}
+}
+
+// checkDrains marks the allocations of the draining nodes that can be
+// migrated and returns how long to wait before checking again.
+func (s *Server) checkDrains() time.Duration {
+	wait := drainWatchInterval
+
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.drain: failed to snapshot state: %v", err)
+		return wait
+	}
+
+	iter, err := snap.Nodes()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.drain: failed to get nodes: %v", err)
+		return wait
+	}
+
+	now := time.Now()
+	pass := newDrainPass(snap, now)
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		node := raw.(*structs.Node)
+		if !node.Drain || node.TerminalStatus() {
+			continue
+		}
+
+		// Wake up in time for the deadline of the drain
+		if ok, deadline := node.DrainStrategy.DeadlineTime(); ok && deadline.After(now) {
+			if until := deadline.Sub(now); until < wait {
+				wait = until
+			}
+		}
+
+		if err := pass.drainNode(node); err != nil {
+			s.logger.Printf("[ERR] nomad.drain: failed to drain node %q: %v", node.ID, err)
+		}
+	}
+
+	if len(pass.transitions) == 0 {
+		return wait
+	}
+
+	// Mark the allocations along with an evaluation of each of their jobs
+	req := &structs.AllocUpdateDesiredTransitionRequest{
+		Allocs: pass.transitions,
+	}
+	for _, job := range pass.jobs {
+		req.Evals = append(req.Evals, drainEval(job))
+	}
+	resp, _, err := s.raftApply(structs.AllocUpdateDesiredTransitionRequestType, req)
+	if rErr, ok := resp.(error); ok && rErr != nil {
+		err = rErr
+	}
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.drain: failed to migrate allocations: %v", err)
+	}
+	return wait
+}
+
+// drainGroup tracks the migrations of a task group during a drain pass
+type drainGroup struct {
+	job *structs.Job
+
+	// available is the number of allocations of the group that can still
+	// be migrated.
+	available int
+}
+
+// drainPass computes the allocations to migrate across the draining nodes
+type drainPass struct {
+	snap *state.StateSnapshot
+	now  time.Time
+
+	// groups is the migration state of each task group, keyed by job ID and
+	// group name.
+	groups map[string]map[string]*drainGroup
+
+	// transitions are the allocations to mark for migration and jobs are
+	// the jobs to evaluate because of them.
+	transitions map[string]*structs.DesiredTransition
+	jobs        map[string]*structs.Job
+}
+
+func newDrainPass(snap *state.StateSnapshot, now time.Time) *drainPass {
+	return &drainPass{
+		snap:        snap,
+		now:         now,
+		groups:      make(map[string]map[string]*drainGroup),
+		transitions: make(map[string]*structs.DesiredTransition),
+		jobs:        make(map[string]*structs.Job),
+	}
+}
+
+// drainNode marks the allocations of the node that can be migrated
+func (p *drainPass) drainNode(node *structs.Node) error {
+	allocs, err := p.snap.AllocsByNode(node.ID)
+	if err != nil {
+		return err
+	}
+
+	ok, deadline := node.DrainStrategy.DeadlineTime()
+	force := ok && !deadline.After(p.now)
+
+	var system []*structs.Allocation
+	remaining := false
+	for _, alloc := range allocs {
+		if alloc.TerminalStatus() {
+			continue
+		}
+		if alloc.Job != nil && alloc.Job.Type == structs.JobTypeSystem {
+			system = append(system, alloc)
+			continue
+		}
+
+		// Allocations already marked remain until they are stopped
+		remaining = true
+		if alloc.DesiredTransition.ShouldMigrate() {
+			continue
+		}
+
+		group, err := p.group(alloc)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			continue
+		}
+		if !force {
+			if group.available <= 0 {
+				continue
+			}
+			group.available--
+		}
+		p.migrate(alloc, group.job)
+	}
+
+	// System allocations are only migrated once the other allocations of
+	// the node have been stopped
+	if remaining && !force {
+		return nil
+	}
+	for _, alloc := range system {
+		if alloc.DesiredTransition.ShouldMigrate() {
+			continue
+		}
+		job, err := p.snap.JobByID(alloc.JobID)
+		if err != nil {
+			return err
+		}
+		if job != nil {
+			p.migrate(alloc, job)
+		}
+	}
+	return nil
+}
+
+// migrate marks the allocation for migration
+func (p *drainPass) migrate(alloc *structs.Allocation, job *structs.Job) {
+	p.transitions[alloc.ID] = &structs.DesiredTransition{Migrate: true}
+	p.jobs[job.ID] = job
+}
+
+// group returns the migration state of the task group of the allocation or
+// nil if its job no longer exists.
+func (p *drainPass) group(alloc *structs.Allocation) (*drainGroup, error) {
+	groups, ok := p.groups[alloc.JobID]
+	if !ok {
+		groups = make(map[string]*drainGroup)
+		p.groups[alloc.JobID] = groups
+	}
+	if group, ok := groups[alloc.TaskGroup]; ok {
+		return group, nil
+	}
+
+	job, err := p.snap.JobByID(alloc.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		groups[alloc.TaskGroup] = nil
+		return nil, nil
+	}
+
+	strategy := structs.DefaultMigrateStrategy()
+	if tg := job.LookupTaskGroup(alloc.TaskGroup); tg != nil {
+		strategy = tg.LookupMigrateStrategy()
+	}
+
+	// Count the migrations of the group that are still in flight
+	allocs, err := p.snap.AllocsByJob(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	replacements := make(map[string]*structs.Allocation)
+	for _, a := range allocs {
+		if a.PreviousAllocation != "" {
+			replacements[a.PreviousAllocation] = a
+		}
+	}
+	inflight := 0
+	for _, a := range allocs {
+		if a.TaskGroup != alloc.TaskGroup || !a.DesiredTransition.ShouldMigrate() {
+			continue
+		}
+		if !a.Terminated() {
+			inflight++
+		} else if r, ok := replacements[a.ID]; ok && !migrationSettled(r, strategy, p.now) {
+			inflight++
+		}
+	}
+
+	group := &drainGroup{
+		job:       job,
+		available: strategy.MaxParallel - inflight,
+	}
+	groups[alloc.TaskGroup] = group
+	return group, nil
+}
+
+// migrationSettled returns whether the replacement of a migrated allocation
+// no longer holds back the migration of other allocations. This is the case
+// once it is healthy, terminal or has exceeded the healthy deadline.
+func migrationSettled(replacement *structs.Allocation, strategy *structs.MigrateStrategy, now time.Time) bool {
+	if replacement.TerminalStatus() {
+		return true
+	}
+	if now.Sub(time.Unix(0, replacement.CreateTime)) >= strategy.HealthyDeadline {
+		return true
+	}
+	if replacement.ClientStatus != structs.AllocClientStatusRunning {
+		return false
+	}
+	if strategy.HealthCheck == structs.MigrateHealthCheckNone || replacement.Job == nil {
+		return true
+	}
+
+	// Every task must have been running for the minimum healthy time
+	tg := replacement.Job.LookupTaskGroup(replacement.TaskGroup)
+	if tg == nil {
+		return true
+	}
+	for _, task := range tg.Tasks {
+		ts, ok := replacement.TaskStates[task.Name]
+		if !ok || ts.State != structs.TaskStateRunning {
+			return false
+		}
+
+		var started int64
+		for _, e := range ts.Events {
+			if e.Type == structs.TaskStarted {
+				started = e.Time
+			}
+		}
+		if started == 0 || now.Sub(time.Unix(0, started)) < strategy.MinHealthyTime {
+			return false
+		}
+	}
+	return true
+}
+
+// drainEval returns an evaluation of a job whose allocations are migrated
+func drainEval(job *structs.Job) *structs.Evaluation {
+	return &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    structs.EvalTriggerNodeDrain,
+		JobID:          job.ID,
+		JobModifyIndex: job.ModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}
+}
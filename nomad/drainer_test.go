@@ -0,0 +1,295 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// drainTestAllocs upserts a draining node with running allocations of the job
+func drainTestAllocs(t *testing.T, state *state.StateStore, job *structs.Job, count int) (*structs.Node, []*structs.Allocation) {
+	node := mock.Node()
+	node.Drain = true
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var allocs []*structs.Allocation
+	for i := 0; i < count; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = node.ID
+		alloc.ClientStatus = structs.AllocClientStatusRunning
+		allocs = append(allocs, alloc)
+	}
+	if err := state.UpsertAllocs(1002, allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return node, allocs
+}
+
+func testDrainPass(t *testing.T, state *state.StateStore, node *structs.Node) *drainPass {
+	snap, err := state.Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pass := newDrainPass(snap, time.Now())
+	if err := pass.drainNode(node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return pass
+}
+
+func TestDrainPass_MaxParallel(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	job.TaskGroups[0].Migrate = &structs.MigrateStrategy{
+		MaxParallel:     2,
+		HealthCheck:     structs.MigrateHealthCheckTaskStates,
+		HealthyDeadline: time.Minute,
+	}
+	node, allocs := drainTestAllocs(t, state, job, 3)
+
+	// Only the max parallel allocations are migrated
+	pass := testDrainPass(t, state, node)
+	if len(pass.transitions) != 2 {
+		t.Fatalf("bad: %#v", pass.transitions)
+	}
+	if _, ok := pass.jobs[job.ID]; !ok || len(pass.jobs) != 1 {
+		t.Fatalf("bad: %#v", pass.jobs)
+	}
+
+	// A marked allocation that is still running counts against the limit
+	transitions := map[string]*structs.DesiredTransition{
+		allocs[0].ID: &structs.DesiredTransition{Migrate: true},
+	}
+	if err := state.UpdateAllocsDesiredTransitions(1003, transitions, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pass = testDrainPass(t, state, node)
+	if len(pass.transitions) != 1 {
+		t.Fatalf("bad: %#v", pass.transitions)
+	}
+	if _, ok := pass.transitions[allocs[0].ID]; ok {
+		t.Fatalf("marked allocation migrated again")
+	}
+}
+
+func TestDrainPass_Replacement(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	node, allocs := drainTestAllocs(t, state, job, 2)
+
+	// Migrate the first allocation and place its replacement
+	transitions := map[string]*structs.DesiredTransition{
+		allocs[0].ID: &structs.DesiredTransition{Migrate: true},
+	}
+	if err := state.UpdateAllocsDesiredTransitions(1003, transitions, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stopped := allocs[0].Copy()
+	stopped.DesiredStatus = structs.AllocDesiredStatusStop
+	replacement := mock.Alloc()
+	replacement.Job = job
+	replacement.JobID = job.ID
+	replacement.PreviousAllocation = allocs[0].ID
+	replacement.ClientStatus = structs.AllocClientStatusPending
+	replacement.CreateTime = time.Now().UnixNano()
+	if err := state.UpsertAllocs(1004, []*structs.Allocation{stopped, replacement}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	stopped = stopped.Copy()
+	stopped.ClientStatus = structs.AllocClientStatusComplete
+	if err := state.UpdateAllocsFromClient(1005, []*structs.Allocation{stopped}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The next allocation waits for the replacement to be healthy
+	pass := testDrainPass(t, state, node)
+	if len(pass.transitions) != 0 {
+		t.Fatalf("bad: %#v", pass.transitions)
+	}
+
+	// Once healthy the next allocation is migrated
+	replacement = replacement.Copy()
+	replacement.ClientStatus = structs.AllocClientStatusRunning
+	replacement.TaskStates = map[string]*structs.TaskState{
+		"web": &structs.TaskState{
+			State: structs.TaskStateRunning,
+			Events: []*structs.TaskEvent{
+				&structs.TaskEvent{
+					Type: structs.TaskStarted,
+					Time: time.Now().Add(-time.Minute).UnixNano(),
+				},
+			},
+		},
+	}
+	if err := state.UpdateAllocsFromClient(1006, []*structs.Allocation{replacement}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pass = testDrainPass(t, state, node)
+	if _, ok := pass.transitions[allocs[1].ID]; !ok || len(pass.transitions) != 1 {
+		t.Fatalf("bad: %#v", pass.transitions)
+	}
+}
+
+func TestDrainPass_SystemLast(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	node, allocs := drainTestAllocs(t, state, job, 1)
+
+	sysJob := mock.SystemJob()
+	if err := state.UpsertJob(1003, sysJob); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sysAlloc := mock.Alloc()
+	sysAlloc.Job = sysJob
+	sysAlloc.JobID = sysJob.ID
+	sysAlloc.NodeID = node.ID
+	if err := state.UpsertAllocs(1004, []*structs.Allocation{sysAlloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The system allocation stays while the other allocations run
+	pass := testDrainPass(t, state, node)
+	if _, ok := pass.transitions[allocs[0].ID]; !ok || len(pass.transitions) != 1 {
+		t.Fatalf("bad: %#v", pass.transitions)
+	}
+
+	// Once they are stopped the system allocation is migrated
+	stopped := allocs[0].Copy()
+	stopped.DesiredStatus = structs.AllocDesiredStatusStop
+	if err := state.UpsertAllocs(1005, []*structs.Allocation{stopped}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pass = testDrainPass(t, state, node)
+	if _, ok := pass.transitions[sysAlloc.ID]; !ok || len(pass.transitions) != 1 {
+		t.Fatalf("bad: %#v", pass.transitions)
+	}
+	if _, ok := pass.jobs[sysJob.ID]; !ok {
+		t.Fatalf("bad: %#v", pass.jobs)
+	}
+}
+
+func TestDrainPass_Deadline(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	node, _ := drainTestAllocs(t, state, job, 3)
+
+	sysJob := mock.SystemJob()
+	if err := state.UpsertJob(1003, sysJob); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	sysAlloc := mock.Alloc()
+	sysAlloc.Job = sysJob
+	sysAlloc.JobID = sysJob.ID
+	sysAlloc.NodeID = node.ID
+	if err := state.UpsertAllocs(1004, []*structs.Allocation{sysAlloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Every allocation is migrated once the deadline is reached
+	node.DrainStrategy = &structs.DrainStrategy{
+		Deadline:      time.Second,
+		ForceDeadline: time.Now().Add(-time.Second),
+	}
+	pass := testDrainPass(t, state, node)
+	if len(pass.transitions) != 4 {
+		t.Fatalf("bad: %#v", pass.transitions)
+	}
+}
+
+func TestMigrationSettled(t *testing.T) {
+	strategy := structs.DefaultMigrateStrategy()
+	now := time.Now()
+
+	alloc := mock.Alloc()
+	alloc.CreateTime = now.UnixNano()
+	alloc.ClientStatus = structs.AllocClientStatusPending
+	if migrationSettled(alloc, strategy, now) {
+		t.Fatalf("pending allocation settled")
+	}
+
+	// Running tasks must have been started for the min healthy time
+	alloc.ClientStatus = structs.AllocClientStatusRunning
+	alloc.TaskStates = map[string]*structs.TaskState{
+		"web": &structs.TaskState{
+			State: structs.TaskStateRunning,
+			Events: []*structs.TaskEvent{
+				&structs.TaskEvent{Type: structs.TaskStarted, Time: now.UnixNano()},
+			},
+		},
+	}
+	if migrationSettled(alloc, strategy, now) {
+		t.Fatalf("recently started allocation settled")
+	}
+	if !migrationSettled(alloc, strategy, now.Add(strategy.MinHealthyTime)) {
+		t.Fatalf("healthy allocation not settled")
+	}
+
+	// No health check only requires the allocation to run
+	strategy.HealthCheck = structs.MigrateHealthCheckNone
+	if !migrationSettled(alloc, strategy, now) {
+		t.Fatalf("running allocation not settled")
+	}
+
+	// The healthy deadline stops the wait
+	alloc.ClientStatus = structs.AllocClientStatusPending
+	if !migrationSettled(alloc, strategy, now.Add(strategy.HealthyDeadline)) {
+		t.Fatalf("allocation past healthy deadline not settled")
+	}
+}
+
+func TestDrainer_Force(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	job := mock.Job()
+	node, allocs := drainTestAllocs(t, state, job, 2)
+	strategy := &structs.DrainStrategy{
+		Deadline:      -1,
+		ForceDeadline: time.Now(),
+	}
+	if err := state.UpdateNodeDrain(1003, node.ID, true, strategy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Every allocation is marked for migration and the job evaluated by the
+	// scheduler
+	testutil.WaitForResult(func() (bool, error) {
+		for _, alloc := range allocs {
+			out, err := state.AllocByID(alloc.ID)
+			if err != nil {
+				return false, err
+			}
+			if !out.DesiredTransition.ShouldMigrate() {
+				return false, fmt.Errorf("alloc %q not migrated", alloc.ID)
+			}
+		}
+
+		evals, err := state.EvalsByJob(job.ID)
+		if err != nil {
+			return false, err
+		}
+		for _, eval := range evals {
+			if eval.TriggeredBy == structs.EvalTriggerNodeDrain &&
+				eval.Status == structs.EvalStatusComplete {
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("no completed drain evaluation: %#v", evals)
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
@@ -37,6 +37,10 @@ var (
 // to only dequeue work they know how to handle. The broker is designed to be entirely
 // in-memory and is managed by the leader node.
 //
+// The priority of a ready evaluation is aged by the time it has been waiting
+// so that a steady stream of high priority evaluations can not starve the
+// evaluations of lower priority jobs.
+//
 // The broker must provide at-least-once delivery semantics. It relies on explicit
 // Ack/Nack messages to handle this. If a delivery is not Ack'd in a sufficient time
 // span, it will be assumed Nack'd.
@@ -44,8 +48,16 @@ type EvalBroker struct {
 	nackTimeout   time.Duration
 	deliveryLimit int
 
+	// agingInterval is the time a ready evaluation must wait for its
+	// priority to be raised by one. Aging is disabled if it is zero.
+	agingInterval time.Duration
+
+	// agingEpoch is the reference time the aged priorities are computed
+	// from.
+	agingEpoch time.Time
+
 	enabled bool
-	stats   *BrokerStats
+	stats   *structs.BrokerStats
 
 	// evals tracks queued evaluations by ID to de-duplicate enqueue.
 	// The counter is the number of times we've attempted delivery,
@@ -59,7 +71,7 @@ type EvalBroker struct {
 	blocked map[string]PendingEvaluations
 
 	// ready tracks the ready jobs by scheduler in a priority queue
-	ready map[string]PendingEvaluations
+	ready map[string]ReadyEvaluations
 
 	// unack is a map of evalID to an un-acknowledged evaluation
 	unack map[string]*unackEval
@@ -91,29 +103,47 @@ type unackEval struct {
 // priority queue
 type PendingEvaluations []*structs.Evaluation
 
+// readyEval is an evaluation waiting in a ready queue along with its priority
+// aged by the time it was enqueued.
+type readyEval struct {
+	eval     *structs.Evaluation
+	priority float64
+}
+
+// ReadyEvaluations is a list of evaluations ready to be dequeued by a
+// scheduler. We implement the container/heap interface so that this is a
+// priority queue ordered by the aged priority of the evaluations.
+type ReadyEvaluations []*readyEval
+
 // NewEvalBroker creates a new evaluation broker. This is parameterized
 // with the timeout used for messages that are not acknowledged before we
-// assume a Nack and attempt to redeliver as well as the deliveryLimit
-// which prevents a failing eval from being endlessly delivered.
-func NewEvalBroker(timeout time.Duration, deliveryLimit int) (*EvalBroker, error) {
+// assume a Nack and attempt to redeliver, the interval at which the priority
+// of waiting evaluations is raised as well as the deliveryLimit which
+// prevents a failing eval from being endlessly delivered.
+func NewEvalBroker(timeout, agingInterval time.Duration, deliveryLimit int) (*EvalBroker, error) {
 	if timeout < 0 {
 		return nil, fmt.Errorf("timeout cannot be negative")
 	}
+	if agingInterval < 0 {
+		return nil, fmt.Errorf("aging interval cannot be negative")
+	}
 	b := &EvalBroker{
 		nackTimeout:   timeout,
 		deliveryLimit: deliveryLimit,
+		agingInterval: agingInterval,
+		agingEpoch:    time.Now(),
 		enabled:       false,
-		stats:         new(BrokerStats),
+		stats:         new(structs.BrokerStats),
 		evals:         make(map[string]int),
 		jobEvals:      make(map[string]string),
 		blocked:       make(map[string]PendingEvaluations),
-		ready:         make(map[string]PendingEvaluations),
+		ready:         make(map[string]ReadyEvaluations),
 		unack:         make(map[string]*unackEval),
 		waiting:       make(map[string]chan struct{}),
 		requeue:       make(map[string]*structs.Evaluation),
 		timeWait:      make(map[string]*time.Timer),
 	}
-	b.stats.ByScheduler = make(map[string]*SchedulerStats)
+	b.stats.ByScheduler = make(map[string]*structs.SchedulerStats)
 	return b, nil
 }
 
@@ -229,21 +259,24 @@ func (b *EvalBroker) enqueueLocked(eval *structs.Evaluation, queue string) {
 	// Find the pending by scheduler class
 	pending, ok := b.ready[queue]
 	if !ok {
-		pending = make([]*structs.Evaluation, 0, 16)
+		pending = make([]*readyEval, 0, 16)
 		if _, ok := b.waiting[queue]; !ok {
 			b.waiting[queue] = make(chan struct{}, 1)
 		}
 	}
 
 	// Push onto the heap
-	heap.Push(&pending, eval)
+	heap.Push(&pending, &readyEval{
+		eval:     eval,
+		priority: b.agedPriority(eval, time.Now()),
+	})
 	b.ready[queue] = pending
 
 	// Update the stats
 	b.stats.TotalReady += 1
 	bySched, ok := b.stats.ByScheduler[queue]
 	if !ok {
-		bySched = &SchedulerStats{}
+		bySched = &structs.SchedulerStats{}
 		b.stats.ByScheduler[queue] = bySched
 	}
 	bySched.Ready += 1
@@ -304,7 +337,7 @@ func (b *EvalBroker) scanForSchedulers(schedulers []string) (*structs.Evaluation
 
 	// Scan for eligible work
 	var eligibleSched []string
-	var eligiblePriority int
+	var eligiblePriority float64
 	for _, sched := range schedulers {
 		// Get the pending queue
 		pending, ok := b.ready[sched]
@@ -319,14 +352,14 @@ func (b *EvalBroker) scanForSchedulers(schedulers []string) (*structs.Evaluation
 		}
 
 		// Add to eligible if equal or greater priority
-		if len(eligibleSched) == 0 || ready.Priority > eligiblePriority {
+		if len(eligibleSched) == 0 || ready.priority > eligiblePriority {
 			eligibleSched = []string{sched}
-			eligiblePriority = ready.Priority
+			eligiblePriority = ready.priority
 
-		} else if eligiblePriority > ready.Priority {
+		} else if eligiblePriority > ready.priority {
 			continue
 
-		} else if eligiblePriority == ready.Priority {
+		} else if eligiblePriority == ready.priority {
 			eligibleSched = append(eligibleSched, sched)
 		}
 	}
@@ -356,7 +389,7 @@ func (b *EvalBroker) dequeueForSched(sched string) (*structs.Evaluation, string,
 	pending := b.ready[sched]
 	raw := heap.Pop(&pending)
 	b.ready[sched] = pending
-	eval := raw.(*structs.Evaluation)
+	eval := raw.(*readyEval).eval
 
 	// Generate a UUID for the token
 	token := structs.GenerateUUID()
@@ -615,20 +648,20 @@ func (b *EvalBroker) Flush() {
 	b.stats.TotalUnacked = 0
 	b.stats.TotalBlocked = 0
 	b.stats.TotalWaiting = 0
-	b.stats.ByScheduler = make(map[string]*SchedulerStats)
+	b.stats.ByScheduler = make(map[string]*structs.SchedulerStats)
 	b.evals = make(map[string]int)
 	b.jobEvals = make(map[string]string)
 	b.blocked = make(map[string]PendingEvaluations)
-	b.ready = make(map[string]PendingEvaluations)
+	b.ready = make(map[string]ReadyEvaluations)
 	b.unack = make(map[string]*unackEval)
 	b.timeWait = make(map[string]*time.Timer)
 }
 
 // Stats is used to query the state of the broker
-func (b *EvalBroker) Stats() *BrokerStats {
+func (b *EvalBroker) Stats() *structs.BrokerStats {
 	// Allocate a new stats struct
-	stats := new(BrokerStats)
-	stats.ByScheduler = make(map[string]*SchedulerStats)
+	stats := new(structs.BrokerStats)
+	stats.ByScheduler = make(map[string]*structs.SchedulerStats)
 
 	b.l.RLock()
 	defer b.l.RUnlock()
@@ -639,7 +672,7 @@ func (b *EvalBroker) Stats() *BrokerStats {
 	stats.TotalBlocked = b.stats.TotalBlocked
 	stats.TotalWaiting = b.stats.TotalWaiting
 	for sched, subStat := range b.stats.ByScheduler {
-		subStatCopy := new(SchedulerStats)
+		subStatCopy := new(structs.SchedulerStats)
 		*subStatCopy = *subStat
 		stats.ByScheduler[sched] = subStatCopy
 	}
@@ -667,19 +700,17 @@ func (b *EvalBroker) EmitStats(period time.Duration, stopCh chan struct{}) {
 	}
 }
 
-// BrokerStats returns all the stats about the broker
-type BrokerStats struct {
-	TotalReady   int
-	TotalUnacked int
-	TotalBlocked int
-	TotalWaiting int
-	ByScheduler  map[string]*SchedulerStats
-}
-
-// SchedulerStats returns the stats per scheduler
-type SchedulerStats struct {
-	Ready   int
-	Unacked int
+// agedPriority returns the priority of an evaluation enqueued at the given
+// time. The priority of a waiting evaluation is raised by one every aging
+// interval, so it is offset by the time elapsed between the aging epoch and
+// the enqueue. As every ready evaluation ages at the same rate, ordering them
+// by this value orders them by their current aged priority.
+func (b *EvalBroker) agedPriority(eval *structs.Evaluation, now time.Time) float64 {
+	priority := float64(eval.Priority)
+	if b.agingInterval == 0 {
+		return priority
+	}
+	return priority - float64(now.Sub(b.agingEpoch))/float64(b.agingInterval)
 }
 
 // Len is for the sorting interface
@@ -718,9 +749,50 @@ func (p *PendingEvaluations) Pop() interface{} {
 
 // Peek is used to peek at the next element that would be popped
 func (p PendingEvaluations) Peek() *structs.Evaluation {
-	n := len(p)
-	if n == 0 {
+	if len(p) == 0 {
+		return nil
+	}
+	return p[0]
+}
+
+// Len is for the sorting interface
+func (r ReadyEvaluations) Len() int {
+	return len(r)
+}
+
+// Less is for the sorting interface. We flip the check
+// so that the "min" in the min-heap is the element with the
+// highest aged priority
+func (r ReadyEvaluations) Less(i, j int) bool {
+	if r[i].eval.JobID != r[j].eval.JobID && r[i].priority != r[j].priority {
+		return !(r[i].priority < r[j].priority)
+	}
+	return r[i].eval.CreateIndex < r[j].eval.CreateIndex
+}
+
+// Swap is for the sorting interface
+func (r ReadyEvaluations) Swap(i, j int) {
+	r[i], r[j] = r[j], r[i]
+}
+
+// Push is used to add a new evalution to the slice
+func (r *ReadyEvaluations) Push(e interface{}) {
+	*r = append(*r, e.(*readyEval))
+}
+
+// Pop is used to remove an evaluation from the slice
+func (r *ReadyEvaluations) Pop() interface{} {
+	n := len(*r)
+	e := (*r)[n-1]
+	(*r)[n-1] = nil
+	*r = (*r)[:n-1]
+	return e
+}
+
+// Peek is used to peek at the next element that would be popped
+func (r ReadyEvaluations) Peek() *readyEval {
+	if len(r) == 0 {
 		return nil
 	}
-	return p[n-1]
+	return r[0]
 }
@@ -19,7 +19,7 @@ func testBroker(t *testing.T, timeout time.Duration) *EvalBroker {
 	if timeout == 0 {
 		timeout = 5 * time.Second
 	}
-	b, err := NewEvalBroker(timeout, 3)
+	b, err := NewEvalBroker(timeout, 0, 3)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -447,6 +447,66 @@ func TestEvalBroker_Dequeue_Priority(t *testing.T) {
 	}
 }
 
+// Ensure the highest priority work is dequeued across the schedulers
+func TestEvalBroker_Dequeue_Priority_Schedulers(t *testing.T) {
+	b := testBroker(t, 0)
+	b.SetEnabled(true)
+
+	eval1 := mock.Eval()
+	eval1.Priority = 10
+	b.Enqueue(eval1)
+
+	eval2 := mock.Eval()
+	eval2.Priority = 30
+	b.Enqueue(eval2)
+
+	eval3 := mock.Eval()
+	eval3.Priority = 20
+	b.Enqueue(eval3)
+
+	eval4 := mock.Eval()
+	eval4.Type = structs.JobTypeBatch
+	eval4.Priority = 25
+	b.Enqueue(eval4)
+
+	for _, expected := range []*structs.Evaluation{eval2, eval4, eval3, eval1} {
+		out, _, _ := b.Dequeue(defaultSched, time.Second)
+		if out != expected {
+			t.Fatalf("bad: %#v", out)
+		}
+	}
+}
+
+// Ensure waiting evaluations have their priority raised
+func TestEvalBroker_Dequeue_Aging(t *testing.T) {
+	b, err := NewEvalBroker(5*time.Second, 10*time.Millisecond, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	b.SetEnabled(true)
+
+	eval1 := mock.Eval()
+	eval1.Priority = 40
+	b.Enqueue(eval1)
+
+	// Wait for more than ten aging intervals
+	time.Sleep(150 * time.Millisecond)
+
+	eval2 := mock.Eval()
+	eval2.Priority = 50
+	b.Enqueue(eval2)
+
+	out1, _, _ := b.Dequeue(defaultSched, time.Second)
+	if out1 != eval1 {
+		t.Fatalf("bad: %#v", out1)
+	}
+
+	out2, _, _ := b.Dequeue(defaultSched, time.Second)
+	if out2 != eval2 {
+		t.Fatalf("bad: %#v", out2)
+	}
+}
+
 // Ensure FIFO at fixed priority
 func TestEvalBroker_Dequeue_FIFO(t *testing.T) {
 	b := testBroker(t, 0)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/watch"
 	"github.com/hashicorp/nomad/scheduler"
@@ -29,6 +30,11 @@ func (e *Eval) GetEval(args *structs.EvalSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "eval", "get_eval"}, time.Now())
 
+	// Check the token may read the evaluation's job
+	if err := e.checkEvalOperation(args.AuthToken, args.EvalID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -276,6 +282,12 @@ func (e *Eval) List(args *structs.EvalListRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "eval", "list"}, time.Now())
 
+	// Resolve the token so evaluations can be filtered by namespace
+	aclObj, err := e.srv.resolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -297,16 +309,33 @@ func (e *Eval) List(args *structs.EvalListRequest,
 				return err
 			}
 
+			paginator, err := newPaginator(&args.QueryOptions)
+			if err != nil {
+				return err
+			}
+
+			allow := jobNamespaceFilter(snap, aclObj, acl.NamespaceCapabilityReadJob, &args.QueryOptions)
 			var evals []*structs.Evaluation
-			for {
+			for !paginator.done() {
 				raw := iter.Next()
 				if raw == nil {
 					break
 				}
 				eval := raw.(*structs.Evaluation)
+				if ok, err := allow(eval.JobID); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+				if ok, err := paginator.accept(eval.ID, eval); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
 				evals = append(evals, eval)
 			}
 			reply.Evaluations = evals
+			reply.NextToken = paginator.nextPageToken()
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("evals")
@@ -330,6 +359,11 @@ func (e *Eval) Allocations(args *structs.EvalSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "eval", "allocations"}, time.Now())
 
+	// Check the token may read the evaluation's job
+	if err := e.checkEvalOperation(args.AuthToken, args.EvalID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -367,3 +401,22 @@ func (e *Eval) Allocations(args *structs.EvalSpecificRequest,
 		}}
 	return e.srv.blockingRPC(&opts)
 }
+
+// checkEvalOperation returns ErrPermissionDenied if the token may not perform
+// the operation on the job of the given evaluation.
+func (e *Eval) checkEvalOperation(secretID, evalID, op string) error {
+	// Fast-path if ACLs are disabled
+	if !e.srv.config.ACLEnabled {
+		return nil
+	}
+
+	var jobID string
+	eval, err := e.srv.fsm.State().EvalByID(evalID)
+	if err != nil {
+		return err
+	}
+	if eval != nil {
+		jobID = eval.JobID
+	}
+	return e.srv.checkJobOperation(secretID, jobID, op)
+}
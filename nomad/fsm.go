@@ -1,8 +1,10 @@
 package nomad
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"time"
 
@@ -36,6 +38,14 @@ const (
 	PeriodicLaunchSnapshot
 	JobSummarySnapshot
 	VaultAccessorSnapshot
+	DeploymentSnapshot
+	JobVersionSnapshot
+	NamespaceSnapshot
+	QuotaSpecSnapshot
+	ACLPolicySnapshot
+	ACLTokenSnapshot
+	AutopilotConfigSnapshot
+	SchedulerConfigSnapshot
 )
 
 // nomadFSM implements a finite state machine that is used
@@ -142,6 +152,42 @@ func (n *nomadFSM) Apply(log *raft.Log) interface{} {
 		return n.applyUpsertVaultAccessor(buf[1:], log.Index)
 	case structs.VaultAccessorDegisterRequestType:
 		return n.applyDeregisterVaultAccessor(buf[1:], log.Index)
+	case structs.DeploymentStatusUpdateRequestType:
+		return n.applyDeploymentStatusUpdate(buf[1:], log.Index)
+	case structs.DeploymentPromoteRequestType:
+		return n.applyDeploymentPromotion(buf[1:], log.Index)
+	case structs.DeploymentAllocHealthRequestType:
+		return n.applyDeploymentAllocHealth(buf[1:], log.Index)
+	case structs.DeploymentDeleteRequestType:
+		return n.applyDeploymentDelete(buf[1:], log.Index)
+	case structs.AllocUpdateDesiredTransitionRequestType:
+		return n.applyAllocUpdateDesiredTransition(buf[1:], log.Index)
+	case structs.NamespaceUpsertRequestType:
+		return n.applyNamespaceUpsert(buf[1:], log.Index)
+	case structs.NamespaceDeleteRequestType:
+		return n.applyNamespaceDelete(buf[1:], log.Index)
+	case structs.QuotaSpecUpsertRequestType:
+		return n.applyQuotaSpecUpsert(buf[1:], log.Index)
+	case structs.QuotaSpecDeleteRequestType:
+		return n.applyQuotaSpecDelete(buf[1:], log.Index)
+	case structs.ACLPolicyUpsertRequestType:
+		return n.applyACLPolicyUpsert(buf[1:], log.Index)
+	case structs.ACLPolicyDeleteRequestType:
+		return n.applyACLPolicyDelete(buf[1:], log.Index)
+	case structs.ACLTokenUpsertRequestType:
+		return n.applyACLTokenUpsert(buf[1:], log.Index)
+	case structs.ACLTokenDeleteRequestType:
+		return n.applyACLTokenDelete(buf[1:], log.Index)
+	case structs.ACLTokenBootstrapRequestType:
+		return n.applyACLTokenBootstrap(buf[1:], log.Index)
+	case structs.AutopilotRequestType:
+		return n.applyAutopilotUpdate(buf[1:], log.Index)
+	case structs.SchedulerConfigRequestType:
+		return n.applySchedulerConfigUpdate(buf[1:], log.Index)
+	case structs.NodeUpdateEligibilityRequestType:
+		return n.applyNodeEligibilityUpdate(buf[1:], log.Index)
+	case structs.SnapshotRestoreRequestType:
+		return n.applySnapshotRestore(buf[1:], log.Index)
 	default:
 		if ignoreUnknown {
 			n.logger.Printf("[WARN] nomad.fsm: ignoring unknown message type (%d), upgrade to newer version", msgType)
@@ -221,10 +267,50 @@ func (n *nomadFSM) applyDrainUpdate(buf []byte, index uint64) interface{} {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
 
-	if err := n.state.UpdateNodeDrain(index, req.NodeID, req.Drain); err != nil {
+	if err := n.state.UpdateNodeDrain(index, req.NodeID, req.Drain, req.DrainStrategy); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: UpdateNodeDrain failed: %v", err)
 		return err
 	}
+
+	// Unblock evals for the nodes computed node class if the node is
+	// eligible for placements again.
+	if !req.Drain {
+		node, err := n.state.NodeByID(req.NodeID)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: looking up node %q failed: %v", req.NodeID, err)
+			return err
+		}
+		if node != nil && node.Ready() {
+			n.blockedEvals.Unblock(node.ComputedClass, index)
+		}
+	}
+	return nil
+}
+
+func (n *nomadFSM) applyNodeEligibilityUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "node_eligibility_update"}, time.Now())
+	var req structs.NodeUpdateEligibilityRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateNodeEligibility(index, req.NodeID, req.Eligibility, req.IneligibleUntil); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateNodeEligibility failed: %v", err)
+		return err
+	}
+
+	// Unblock evals for the nodes computed node class if the node is
+	// eligible for placements again.
+	if req.Eligibility != structs.NodeSchedulingIneligible {
+		node, err := n.state.NodeByID(req.NodeID)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: looking up node %q failed: %v", req.NodeID, err)
+			return err
+		}
+		if node != nil && node.Ready() {
+			n.blockedEvals.Unblock(node.ComputedClass, index)
+		}
+	}
 	return nil
 }
 
@@ -412,10 +498,32 @@ func (n *nomadFSM) applyAllocUpdate(buf []byte, index uint64) interface{} {
 		alloc.Resources.Add(alloc.SharedResources)
 	}
 
+	// Apply the deployment changes of the plan before the allocations
+	// referencing them.
+	if req.Deployment != nil || len(req.DeploymentUpdates) != 0 {
+		if err := n.state.UpsertDeployment(index, req.Deployment, req.DeploymentUpdates); err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: UpsertDeployment failed: %v", err)
+			return err
+		}
+	}
+
 	if err := n.state.UpsertAllocs(index, req.Alloc); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: UpsertAllocs failed: %v", err)
 		return err
 	}
+
+	// Create the evaluations rescheduling the preempted allocations
+	if len(req.Evals) != 0 {
+		if err := n.state.UpsertEvals(index, req.Evals); err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: UpsertEvals failed: %v", err)
+			return err
+		}
+		for _, eval := range req.Evals {
+			if eval.ShouldEnqueue() {
+				n.evalBroker.Enqueue(eval)
+			}
+		}
+	}
 	return nil
 }
 
@@ -443,6 +551,19 @@ func (n *nomadFSM) applyAllocClientUpdate(buf []byte, index uint64) interface{}
 		return err
 	}
 
+	// Create the evaluations rescheduling the failed allocations
+	if len(req.Evals) != 0 {
+		if err := n.state.UpsertEvals(index, req.Evals); err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: UpsertEvals failed: %v", err)
+			return err
+		}
+		for _, eval := range req.Evals {
+			if eval.ShouldEnqueue() {
+				n.evalBroker.Enqueue(eval)
+			}
+		}
+	}
+
 	// Unblock evals for the nodes computed node class if the client has
 	// finished running an allocation.
 	for _, alloc := range req.Alloc {
@@ -503,6 +624,322 @@ func (n *nomadFSM) applyDeregisterVaultAccessor(buf []byte, index uint64) interf
 	return nil
 }
 
+// applyNamespaceUpsert is used to create or update a set of namespaces
+func (n *nomadFSM) applyNamespaceUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "namespace_upsert"}, time.Now())
+	var req structs.NamespaceUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	// Capture the quotas detached from the namespaces
+	var detached []string
+	for _, ns := range req.Namespaces {
+		existing, err := n.state.NamespaceByName(ns.Name)
+		if err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: looking up namespace %q failed: %v", ns.Name, err)
+			return err
+		}
+		if existing != nil && existing.Quota != "" && existing.Quota != ns.Quota {
+			detached = append(detached, existing.Quota)
+		}
+	}
+
+	if err := n.state.UpsertNamespaces(index, req.Namespaces); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertNamespaces failed: %v", err)
+		return err
+	}
+
+	// Evaluations blocked on a detached quota may now make progress
+	for _, quota := range detached {
+		n.blockedEvals.UnblockQuota(quota)
+	}
+	return nil
+}
+
+// applyNamespaceDelete is used to delete a set of namespaces
+func (n *nomadFSM) applyNamespaceDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "namespace_delete"}, time.Now())
+	var req structs.NamespaceDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteNamespaces(index, req.Namespaces); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteNamespaces failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// applyQuotaSpecUpsert is used to create or update a set of quota
+// specifications
+func (n *nomadFSM) applyQuotaSpecUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "quota_spec_upsert"}, time.Now())
+	var req structs.QuotaSpecUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertQuotaSpecs(index, req.Quotas); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertQuotaSpecs failed: %v", err)
+		return err
+	}
+
+	// Raising a limit may allow blocked evaluations to make progress
+	for _, quota := range req.Quotas {
+		n.blockedEvals.UnblockQuota(quota.Name)
+	}
+	return nil
+}
+
+// applyQuotaSpecDelete is used to delete a set of quota specifications
+func (n *nomadFSM) applyQuotaSpecDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "quota_spec_delete"}, time.Now())
+	var req structs.QuotaSpecDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteQuotaSpecs(index, req.Names); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteQuotaSpecs failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// applyACLPolicyUpsert is used to upsert a set of policies
+func (n *nomadFSM) applyACLPolicyUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_policy_upsert"}, time.Now())
+	var req structs.ACLPolicyUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertACLPolicies(index, req.Policies); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertACLPolicies failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyACLPolicyDelete is used to delete a set of policies
+func (n *nomadFSM) applyACLPolicyDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_policy_delete"}, time.Now())
+	var req structs.ACLPolicyDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteACLPolicies(index, req.Names); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteACLPolicies failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyACLTokenUpsert is used to upsert a set of tokens
+func (n *nomadFSM) applyACLTokenUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_token_upsert"}, time.Now())
+	var req structs.ACLTokenUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertACLTokens(index, req.Tokens); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertACLTokens failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyACLTokenDelete is used to delete a set of tokens
+func (n *nomadFSM) applyACLTokenDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_token_delete"}, time.Now())
+	var req structs.ACLTokenDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteACLTokens(index, req.AccessorIDs); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteACLTokens failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyACLTokenBootstrap is used to bootstrap an ACL token
+func (n *nomadFSM) applyACLTokenBootstrap(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_token_bootstrap"}, time.Now())
+	var req structs.ACLTokenBootstrapRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.BootstrapACLTokens(index, req.Token); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: BootstrapACLToken failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyAutopilotUpdate is used to update the Autopilot configuration. The
+// result of a check-and-set update is returned as a bool.
+func (n *nomadFSM) applyAutopilotUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "autopilot"}, time.Now())
+	var req structs.AutopilotSetConfigRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if req.CAS {
+		act, err := n.state.AutopilotCASConfig(index, req.Config.ModifyIndex, &req.Config)
+		if err != nil {
+			return err
+		}
+		return act
+	}
+	if err := n.state.AutopilotSetConfig(index, &req.Config); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: AutopilotSetConfig failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applySchedulerConfigUpdate is used to update the scheduler configuration.
+// The result of a check-and-set update is returned as a bool.
+func (n *nomadFSM) applySchedulerConfigUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "scheduler_config"}, time.Now())
+	var req structs.SchedulerSetConfigRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if req.CAS {
+		act, err := n.state.SchedulerCASConfig(index, req.Config.ModifyIndex, &req.Config)
+		if err != nil {
+			return err
+		}
+		return act
+	}
+	if err := n.state.SchedulerSetConfig(index, &req.Config); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: SchedulerSetConfig failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applySnapshotRestore replaces the state with the state of an operator
+// provided snapshot. Restoring through the log keeps all the servers
+// consistent and makes the restore survive log replay.
+func (n *nomadFSM) applySnapshotRestore(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_snapshot_restore"}, time.Now())
+	var req structs.SnapshotRestoreRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.Restore(ioutil.NopCloser(bytes.NewReader(req.State))); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: restoring snapshot at index %d failed: %v", index, err)
+		return err
+	}
+	return nil
+}
+
+// applyDeploymentStatusUpdate is used to update the status of a deployment
+// and create the optional evaluation
+func (n *nomadFSM) applyDeploymentStatusUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_status_update"}, time.Now())
+	var req structs.DeploymentStatusUpdateRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentStatus(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentStatus failed: %v", err)
+		return err
+	}
+
+	if req.Eval != nil && req.Eval.ShouldEnqueue() {
+		n.evalBroker.Enqueue(req.Eval)
+	}
+	return nil
+}
+
+// applyDeploymentPromotion is used to promote the canaries of a deployment
+// and create the evaluation that continues the rollout
+func (n *nomadFSM) applyDeploymentPromotion(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_promotion"}, time.Now())
+	var req structs.ApplyDeploymentPromoteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentPromotion(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentPromotion failed: %v", err)
+		return err
+	}
+
+	if req.Eval != nil && req.Eval.ShouldEnqueue() {
+		n.evalBroker.Enqueue(req.Eval)
+	}
+	return nil
+}
+
+func (n *nomadFSM) applyDeploymentAllocHealth(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_alloc_health"}, time.Now())
+	var req structs.ApplyDeploymentAllocHealthRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentAllocHealth(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentAllocHealth failed: %v", err)
+		return err
+	}
+
+	if req.Eval != nil && req.Eval.ShouldEnqueue() {
+		n.evalBroker.Enqueue(req.Eval)
+	}
+	return nil
+}
+
+func (n *nomadFSM) applyDeploymentDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_delete"}, time.Now())
+	var req structs.DeploymentDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteDeployment(index, req.Deployments); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteDeployment failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (n *nomadFSM) applyAllocUpdateDesiredTransition(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "alloc_update_desired_transition"}, time.Now())
+	var req structs.AllocUpdateDesiredTransitionRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateAllocsDesiredTransitions(index, req.Allocs, req.Evals); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateAllocsDesiredTransitions failed: %v", err)
+		return err
+	}
+
+	for _, eval := range req.Evals {
+		if eval.ShouldEnqueue() {
+			n.evalBroker.Enqueue(eval)
+		}
+	}
+	return nil
+}
+
 func (n *nomadFSM) Snapshot() (raft.FSMSnapshot, error) {
 	// Create a new snapshot
 	snap, err := n.state.Snapshot()
@@ -641,6 +1078,78 @@ func (n *nomadFSM) Restore(old io.ReadCloser) error {
 				return err
 			}
 
+		case DeploymentSnapshot:
+			deployment := new(structs.Deployment)
+			if err := dec.Decode(deployment); err != nil {
+				return err
+			}
+			if err := restore.DeploymentRestore(deployment); err != nil {
+				return err
+			}
+
+		case JobVersionSnapshot:
+			version := new(structs.Job)
+			if err := dec.Decode(version); err != nil {
+				return err
+			}
+			if err := restore.JobVersionRestore(version); err != nil {
+				return err
+			}
+
+		case NamespaceSnapshot:
+			ns := new(structs.Namespace)
+			if err := dec.Decode(ns); err != nil {
+				return err
+			}
+			if err := restore.NamespaceRestore(ns); err != nil {
+				return err
+			}
+
+		case QuotaSpecSnapshot:
+			quota := new(structs.QuotaSpec)
+			if err := dec.Decode(quota); err != nil {
+				return err
+			}
+			if err := restore.QuotaSpecRestore(quota); err != nil {
+				return err
+			}
+
+		case ACLPolicySnapshot:
+			policy := new(structs.ACLPolicy)
+			if err := dec.Decode(policy); err != nil {
+				return err
+			}
+			if err := restore.ACLPolicyRestore(policy); err != nil {
+				return err
+			}
+
+		case ACLTokenSnapshot:
+			token := new(structs.ACLToken)
+			if err := dec.Decode(token); err != nil {
+				return err
+			}
+			if err := restore.ACLTokenRestore(token); err != nil {
+				return err
+			}
+
+		case AutopilotConfigSnapshot:
+			config := new(structs.AutopilotConfig)
+			if err := dec.Decode(config); err != nil {
+				return err
+			}
+			if err := restore.AutopilotConfigRestore(config); err != nil {
+				return err
+			}
+
+		case SchedulerConfigSnapshot:
+			config := new(structs.SchedulerConfig)
+			if err := dec.Decode(config); err != nil {
+				return err
+			}
+			if err := restore.SchedulerConfigRestore(config); err != nil {
+				return err
+			}
+
 		default:
 			return fmt.Errorf("Unrecognized snapshot type: %v", msgType)
 		}
@@ -818,6 +1327,38 @@ func (s *nomadSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
+	if err := s.persistDeployments(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistJobVersions(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistNamespaces(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistQuotaSpecs(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistACLPolicies(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistACLTokens(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistAutopilotConfig(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistSchedulerConfig(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
 	return nil
 }
 
@@ -1031,7 +1572,187 @@ func (s *nomadSnapshot) persistVaultAccessors(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *nomadSnapshot) persistDeployments(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	deployments, err := s.snap.Deployments()
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := deployments.Next()
+		if raw == nil {
+			break
+		}
+
+		deployment := raw.(*structs.Deployment)
+
+		sink.Write([]byte{byte(DeploymentSnapshot)})
+		if err := encoder.Encode(deployment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistJobVersions(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	versions, err := s.snap.JobVersions()
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := versions.Next()
+		if raw == nil {
+			break
+		}
+
+		job := raw.(*structs.Job)
+
+		sink.Write([]byte{byte(JobVersionSnapshot)})
+		if err := encoder.Encode(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Release is a no-op, as we just need to GC the pointer
 // to the state store snapshot. There is nothing to explicitly
 // cleanup.
 func (s *nomadSnapshot) Release() {}
+
+func (s *nomadSnapshot) persistNamespaces(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	namespaces, err := s.snap.Namespaces()
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := namespaces.Next()
+		if raw == nil {
+			break
+		}
+
+		ns := raw.(*structs.Namespace)
+
+		sink.Write([]byte{byte(NamespaceSnapshot)})
+		if err := encoder.Encode(ns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistQuotaSpecs(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	quotas, err := s.snap.QuotaSpecs()
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := quotas.Next()
+		if raw == nil {
+			break
+		}
+
+		quota := raw.(*structs.QuotaSpec)
+
+		sink.Write([]byte{byte(QuotaSpecSnapshot)})
+		if err := encoder.Encode(quota); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistACLPolicies(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	policies, err := s.snap.ACLPolicies()
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := policies.Next()
+		if raw == nil {
+			break
+		}
+
+		policy := raw.(*structs.ACLPolicy)
+
+		sink.Write([]byte{byte(ACLPolicySnapshot)})
+		if err := encoder.Encode(policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistACLTokens(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	tokens, err := s.snap.ACLTokens()
+	if err != nil {
+		return err
+	}
+
+	for {
+		raw := tokens.Next()
+		if raw == nil {
+			break
+		}
+
+		token := raw.(*structs.ACLToken)
+
+		sink.Write([]byte{byte(ACLTokenSnapshot)})
+		if err := encoder.Encode(token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistAutopilotConfig(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	_, config, err := s.snap.AutopilotConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+
+	sink.Write([]byte{byte(AutopilotConfigSnapshot)})
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *nomadSnapshot) persistSchedulerConfig(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+
+	_, config, err := s.snap.SchedulerConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+
+	sink.Write([]byte{byte(SchedulerConfigSnapshot)})
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return nil
+}
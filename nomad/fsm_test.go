@@ -237,9 +237,13 @@ func TestFSM_UpdateNodeDrain(t *testing.T) {
 		t.Fatalf("resp: %v", resp)
 	}
 
+	strategy := &structs.DrainStrategy{
+		Deadline: time.Hour,
+	}
 	req2 := structs.NodeUpdateDrainRequest{
-		NodeID: node.ID,
-		Drain:  true,
+		NodeID:        node.ID,
+		Drain:         true,
+		DrainStrategy: strategy,
 	}
 	buf, err = structs.Encode(structs.NodeUpdateDrainRequestType, req2)
 	if err != nil {
@@ -256,11 +260,146 @@ func TestFSM_UpdateNodeDrain(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if !node.Drain {
+	if !node.Drain || !reflect.DeepEqual(node.DrainStrategy, strategy) {
 		t.Fatalf("bad node: %#v", node)
 	}
 }
 
+func TestFSM_UpdateNodeDrain_Unblock(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.blockedEvals.SetEnabled(true)
+
+	node := mock.Node()
+	node.Drain = true
+	if err := fsm.State().UpsertNode(1, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mark an eval as blocked.
+	eval := mock.Eval()
+	eval.ClassEligibility = map[string]bool{node.ComputedClass: true}
+	fsm.blockedEvals.Block(eval)
+
+	req := structs.NodeUpdateDrainRequest{
+		NodeID: node.ID,
+		Drain:  false,
+	}
+	buf, err := structs.Encode(structs.NodeUpdateDrainRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the eval was unblocked.
+	testutil.WaitForResult(func() (bool, error) {
+		bStats := fsm.blockedEvals.Stats()
+		if bStats.TotalBlocked != 0 {
+			return false, fmt.Errorf("bad: %#v", bStats)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+}
+
+func TestFSM_UpdateNodeEligibility_Unblock(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.blockedEvals.SetEnabled(true)
+
+	node := mock.Node()
+	node.SchedulingEligibility = structs.NodeSchedulingIneligible
+	if err := fsm.State().UpsertNode(1, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mark an eval as blocked.
+	eval := mock.Eval()
+	eval.ClassEligibility = map[string]bool{node.ComputedClass: true}
+	fsm.blockedEvals.Block(eval)
+
+	req := structs.NodeUpdateEligibilityRequest{
+		NodeID:      node.ID,
+		Eligibility: structs.NodeSchedulingEligible,
+	}
+	buf, err := structs.Encode(structs.NodeUpdateEligibilityRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err := fsm.State().NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.Eligible() {
+		t.Fatalf("bad node: %#v", out)
+	}
+
+	// Verify the eval was unblocked.
+	testutil.WaitForResult(func() (bool, error) {
+		bStats := fsm.blockedEvals.Stats()
+		if bStats.TotalBlocked != 0 {
+			return false, fmt.Errorf("bad: %#v", bStats)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+}
+
+func TestFSM_AllocUpdateDesiredTransition(t *testing.T) {
+	fsm := testFSM(t)
+	state := fsm.State()
+
+	alloc := mock.Alloc()
+	state.UpsertJobSummary(9, mock.JobSummary(alloc.JobID))
+	if err := state.UpsertAllocs(10, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	eval.JobID = alloc.JobID
+	req := structs.AllocUpdateDesiredTransitionRequest{
+		Allocs: map[string]*structs.DesiredTransition{
+			alloc.ID: &structs.DesiredTransition{Migrate: true},
+		},
+		Evals: []*structs.Evaluation{eval},
+	}
+	buf, err := structs.Encode(structs.AllocUpdateDesiredTransitionRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DesiredTransition.ShouldMigrate() {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	outE, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outE == nil {
+		t.Fatalf("missing eval")
+	}
+}
+
 func TestFSM_RegisterJob(t *testing.T) {
 	fsm := testFSM(t)
 
@@ -771,16 +910,14 @@ func TestFSM_UpdateAllocFromClient(t *testing.T) {
 	}
 }
 
-func TestFSM_UpsertVaultAccessor(t *testing.T) {
+func TestFSM_UpsertNamespaces(t *testing.T) {
 	fsm := testFSM(t)
-	fsm.blockedEvals.SetEnabled(true)
 
-	va := mock.VaultAccessor()
-	va2 := mock.VaultAccessor()
-	req := structs.VaultAccessorsRequest{
-		Accessors: []*structs.VaultAccessor{va, va2},
+	ns := mock.Namespace()
+	req := structs.NamespaceUpsertRequest{
+		Namespaces: []*structs.Namespace{ns},
 	}
-	buf, err := structs.Encode(structs.VaultAccessorRegisterRequestType, req)
+	buf, err := structs.Encode(structs.NamespaceUpsertRequestType, req)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -791,51 +928,48 @@ func TestFSM_UpsertVaultAccessor(t *testing.T) {
 	}
 
 	// Verify we are registered
-	out1, err := fsm.State().VaultAccessor(va.Accessor)
+	out, err := fsm.State().NamespaceByName(ns.Name)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if out1 == nil {
+	if out == nil {
 		t.Fatalf("not found!")
 	}
-	if out1.CreateIndex != 1 {
-		t.Fatalf("bad index: %d", out1.CreateIndex)
+	if out.CreateIndex != 1 {
+		t.Fatalf("bad index: %d", out.CreateIndex)
 	}
-	out2, err := fsm.State().VaultAccessor(va2.Accessor)
+
+	// Delete the namespace
+	req2 := structs.NamespaceDeleteRequest{
+		Namespaces: []string{ns.Name},
+	}
+	buf, err = structs.Encode(structs.NamespaceDeleteRequestType, req2)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if out2 == nil {
-		t.Fatalf("not found!")
-	}
-	if out1.CreateIndex != 1 {
-		t.Fatalf("bad index: %d", out2.CreateIndex)
+
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
 	}
 
-	tt := fsm.TimeTable()
-	index := tt.NearestIndex(time.Now().UTC())
-	if index != 1 {
-		t.Fatalf("bad: %d", index)
+	out, err = fsm.State().NamespaceByName(ns.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("namespace not deleted")
 	}
 }
 
-func TestFSM_DeregisterVaultAccessor(t *testing.T) {
+func TestFSM_UpsertACLPolicies(t *testing.T) {
 	fsm := testFSM(t)
-	fsm.blockedEvals.SetEnabled(true)
-
-	va := mock.VaultAccessor()
-	va2 := mock.VaultAccessor()
-	accessors := []*structs.VaultAccessor{va, va2}
-
-	// Insert the accessors
-	if err := fsm.State().UpsertVaultAccessor(1000, accessors); err != nil {
-		t.Fatalf("bad: %v", err)
-	}
 
-	req := structs.VaultAccessorsRequest{
-		Accessors: accessors,
+	policy := mock.ACLPolicy()
+	req := structs.ACLPolicyUpsertRequest{
+		Policies: []*structs.ACLPolicy{policy},
 	}
-	buf, err := structs.Encode(structs.VaultAccessorDegisterRequestType, req)
+	buf, err := structs.Encode(structs.ACLPolicyUpsertRequestType, req)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -845,89 +979,630 @@ func TestFSM_DeregisterVaultAccessor(t *testing.T) {
 		t.Fatalf("resp: %v", resp)
 	}
 
-	out1, err := fsm.State().VaultAccessor(va.Accessor)
+	// Verify we are registered
+	out, err := fsm.State().ACLPolicyByName(policy.Name)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	if out1 != nil {
-		t.Fatalf("not deleted!")
+	if out == nil {
+		t.Fatalf("not found!")
+	}
+	if out.CreateIndex != 1 {
+		t.Fatalf("bad index: %d", out.CreateIndex)
 	}
 
-	tt := fsm.TimeTable()
-	index := tt.NearestIndex(time.Now().UTC())
-	if index != 1 {
-		t.Fatalf("bad: %d", index)
+	// Delete the policy
+	req2 := structs.ACLPolicyDeleteRequest{
+		Names: []string{policy.Name},
+	}
+	buf, err = structs.Encode(structs.ACLPolicyDeleteRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-}
 
-func testSnapshotRestore(t *testing.T, fsm *nomadFSM) *nomadFSM {
-	// Snapshot
-	snap, err := fsm.Snapshot()
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err = fsm.State().ACLPolicyByName(policy.Name)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	defer snap.Release()
+	if out != nil {
+		t.Fatalf("policy not deleted: %#v", out)
+	}
+}
 
-	// Persist
-	buf := bytes.NewBuffer(nil)
-	sink := &MockSink{buf, false}
-	if err := snap.Persist(sink); err != nil {
+func TestFSM_UpsertACLTokens(t *testing.T) {
+	fsm := testFSM(t)
+
+	token := mock.ACLToken()
+	req := structs.ACLTokenUpsertRequest{
+		Tokens: []*structs.ACLToken{token},
+	}
+	buf, err := structs.Encode(structs.ACLTokenUpsertRequestType, req)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Try to restore on a new FSM
-	fsm2 := testFSM(t)
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
 
-	// Do a restore
-	if err := fsm2.Restore(sink); err != nil {
+	// Verify we are registered
+	out, err := fsm.State().ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
-	return fsm2
-}
+	if out == nil {
+		t.Fatalf("not found!")
+	}
+	if out.CreateIndex != 1 {
+		t.Fatalf("bad index: %d", out.CreateIndex)
+	}
 
-func TestFSM_SnapshotRestore_Nodes(t *testing.T) {
-	// Add some state
-	fsm := testFSM(t)
-	state := fsm.State()
-	node1 := mock.Node()
-	state.UpsertNode(1000, node1)
-	node2 := mock.Node()
-	state.UpsertNode(1001, node2)
+	// Delete the token
+	req2 := structs.ACLTokenDeleteRequest{
+		AccessorIDs: []string{token.AccessorID},
+	}
+	buf, err = structs.Encode(structs.ACLTokenDeleteRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
 
-	// Verify the contents
-	fsm2 := testSnapshotRestore(t, fsm)
-	state2 := fsm2.State()
-	out1, _ := state2.NodeByID(node1.ID)
-	out2, _ := state2.NodeByID(node2.ID)
-	if !reflect.DeepEqual(node1, out1) {
-		t.Fatalf("bad: \n%#v\n%#v", out1, node1)
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
 	}
-	if !reflect.DeepEqual(node2, out2) {
-		t.Fatalf("bad: \n%#v\n%#v", out2, node2)
+
+	out, err = fsm.State().ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("token not deleted: %#v", out)
 	}
 }
 
-func TestFSM_SnapshotRestore_Jobs(t *testing.T) {
-	// Add some state
+func TestFSM_BootstrapACLTokens(t *testing.T) {
 	fsm := testFSM(t)
-	state := fsm.State()
-	job1 := mock.Job()
-	state.UpsertJob(1000, job1)
-	job2 := mock.Job()
-	state.UpsertJob(1001, job2)
 
-	// Verify the contents
-	fsm2 := testSnapshotRestore(t, fsm)
-	state2 := fsm2.State()
-	out1, _ := state2.JobByID(job1.ID)
-	out2, _ := state2.JobByID(job2.ID)
-	if !reflect.DeepEqual(job1, out1) {
-		t.Fatalf("bad: \n%#v\n%#v", out1, job1)
+	token := mock.ACLManagementToken()
+	req := structs.ACLTokenBootstrapRequest{
+		Token: token,
 	}
-	if !reflect.DeepEqual(job2, out2) {
-		t.Fatalf("bad: \n%#v\n%#v", out2, job2)
+	buf, err := structs.Encode(structs.ACLTokenBootstrapRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
-}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify we are registered
+	out, err := fsm.State().ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("not found!")
+	}
+
+	// A second bootstrap must fail
+	req.Token = mock.ACLManagementToken()
+	buf, err = structs.Encode(structs.ACLTokenBootstrapRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp = fsm.Apply(makeLog(buf))
+	if err, ok := resp.(error); !ok || err == nil {
+		t.Fatalf("expected error: %v", resp)
+	}
+}
+
+func TestFSM_Autopilot(t *testing.T) {
+	fsm := testFSM(t)
+
+	// Set the autopilot config using a request
+	req := structs.AutopilotSetConfigRequest{
+		Config: structs.AutopilotConfig{
+			CleanupDeadServers:   true,
+			LastContactThreshold: 10 * time.Second,
+			MaxTrailingLogs:      300,
+		},
+	}
+	buf, err := structs.Encode(structs.AutopilotRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify key is set directly in the state store
+	_, config, err := fsm.State().AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !config.CleanupDeadServers || config.MaxTrailingLogs != 300 {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	// Now use CAS and provide an old index
+	req.CAS = true
+	req.Config.CleanupDeadServers = false
+	req.Config.ModifyIndex = config.ModifyIndex - 1
+	buf, err = structs.Encode(structs.AutopilotRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp = fsm.Apply(makeLog(buf))
+	if updated, ok := resp.(bool); !ok || updated {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	_, config, err = fsm.State().AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !config.CleanupDeadServers {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestFSM_SchedulerConfig(t *testing.T) {
+	fsm := testFSM(t)
+
+	// Set the scheduler config using a request
+	req := structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfig{
+			SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+		},
+	}
+	buf, err := structs.Encode(structs.SchedulerConfigRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify key is set directly in the state store
+	_, config, err := fsm.State().SchedulerConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.SchedulerAlgorithm != structs.SchedulerAlgorithmSpread {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	// Now use CAS and provide an old index
+	req.CAS = true
+	req.Config.SchedulerAlgorithm = structs.SchedulerAlgorithmBinpack
+	req.Config.ModifyIndex = config.ModifyIndex - 1
+	buf, err = structs.Encode(structs.SchedulerConfigRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	resp = fsm.Apply(makeLog(buf))
+	if updated, ok := resp.(bool); !ok || updated {
+		t.Fatalf("bad: %v", resp)
+	}
+
+	_, config, err = fsm.State().SchedulerConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.SchedulerAlgorithm != structs.SchedulerAlgorithmSpread {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestFSM_UpsertQuotaSpecs_Unblock(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.blockedEvals.SetEnabled(true)
+
+	q := mock.QuotaSpec()
+
+	// Mark an eval as blocked on the quota.
+	eval := mock.Eval()
+	eval.QuotaLimitReached = q.Name
+	fsm.blockedEvals.Block(eval)
+
+	req := structs.QuotaSpecUpsertRequest{
+		Quotas: []*structs.QuotaSpec{q},
+	}
+	buf, err := structs.Encode(structs.QuotaSpecUpsertRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify we are registered
+	out, err := fsm.State().QuotaSpecByName(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("not found!")
+	}
+
+	// Verify the eval was unblocked.
+	testutil.WaitForResult(func() (bool, error) {
+		bStats := fsm.blockedEvals.Stats()
+		if bStats.TotalBlocked != 0 {
+			return false, fmt.Errorf("bad: %#v", bStats)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	// Delete the quota
+	req2 := structs.QuotaSpecDeleteRequest{
+		Names: []string{q.Name},
+	}
+	buf, err = structs.Encode(structs.QuotaSpecDeleteRequestType, req2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp = fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out, err = fsm.State().QuotaSpecByName(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("quota not deleted")
+	}
+}
+
+func TestFSM_UpsertVaultAccessor(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.blockedEvals.SetEnabled(true)
+
+	va := mock.VaultAccessor()
+	va2 := mock.VaultAccessor()
+	req := structs.VaultAccessorsRequest{
+		Accessors: []*structs.VaultAccessor{va, va2},
+	}
+	buf, err := structs.Encode(structs.VaultAccessorRegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify we are registered
+	out1, err := fsm.State().VaultAccessor(va.Accessor)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out1 == nil {
+		t.Fatalf("not found!")
+	}
+	if out1.CreateIndex != 1 {
+		t.Fatalf("bad index: %d", out1.CreateIndex)
+	}
+	out2, err := fsm.State().VaultAccessor(va2.Accessor)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out2 == nil {
+		t.Fatalf("not found!")
+	}
+	if out1.CreateIndex != 1 {
+		t.Fatalf("bad index: %d", out2.CreateIndex)
+	}
+
+	tt := fsm.TimeTable()
+	index := tt.NearestIndex(time.Now().UTC())
+	if index != 1 {
+		t.Fatalf("bad: %d", index)
+	}
+}
+
+func TestFSM_DeregisterVaultAccessor(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.blockedEvals.SetEnabled(true)
+
+	va := mock.VaultAccessor()
+	va2 := mock.VaultAccessor()
+	accessors := []*structs.VaultAccessor{va, va2}
+
+	// Insert the accessors
+	if err := fsm.State().UpsertVaultAccessor(1000, accessors); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	req := structs.VaultAccessorsRequest{
+		Accessors: accessors,
+	}
+	buf, err := structs.Encode(structs.VaultAccessorDegisterRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	out1, err := fsm.State().VaultAccessor(va.Accessor)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out1 != nil {
+		t.Fatalf("not deleted!")
+	}
+
+	tt := fsm.TimeTable()
+	index := tt.NearestIndex(time.Now().UTC())
+	if index != 1 {
+		t.Fatalf("bad: %d", index)
+	}
+}
+
+func TestFSM_UpdateDeploymentStatus(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.evalBroker.SetEnabled(true)
+
+	d := mock.Deployment()
+	if err := fsm.State().UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	req := structs.DeploymentStatusUpdateRequest{
+		Eval: eval,
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedByUser,
+		},
+	}
+	buf, err := structs.Encode(structs.DeploymentStatusUpdateRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the deployment was updated
+	out, err := fsm.State().DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed ||
+		out.StatusDescription != structs.DeploymentStatusDescriptionFailedByUser {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Verify the eval was created and enqueued
+	evalOut, err := fsm.State().EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if evalOut == nil {
+		t.Fatalf("missing eval")
+	}
+	stats := fsm.evalBroker.Stats()
+	if stats.TotalReady != 1 {
+		t.Fatalf("bad: %#v", stats)
+	}
+}
+
+func TestFSM_DeleteDeployment(t *testing.T) {
+	fsm := testFSM(t)
+
+	d := mock.Deployment()
+	if err := fsm.State().UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req := structs.DeploymentDeleteRequest{
+		Deployments: []string{d.ID},
+	}
+	buf, err := structs.Encode(structs.DeploymentDeleteRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the deployment was deleted
+	out, err := fsm.State().DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("deployment found!")
+	}
+}
+
+func TestFSM_UpdateDeploymentPromotion(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.evalBroker.SetEnabled(true)
+
+	d := mock.Deployment()
+	d.StatusDescription = structs.DeploymentStatusDescriptionCanaries
+	if err := fsm.State().UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	req := structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+		Eval: eval,
+	}
+	buf, err := structs.Encode(structs.DeploymentPromoteRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the deployment was promoted
+	out, err := fsm.State().DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.TaskGroups["web"].Promoted ||
+		out.StatusDescription != structs.DeploymentStatusDescriptionRunning {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Verify the eval was enqueued
+	stats := fsm.evalBroker.Stats()
+	if stats.TotalReady != 1 {
+		t.Fatalf("bad: %#v", stats)
+	}
+}
+
+func TestFSM_UpdateDeploymentAllocHealth(t *testing.T) {
+	fsm := testFSM(t)
+	fsm.evalBroker.SetEnabled(true)
+
+	d := mock.Deployment()
+	if err := fsm.State().UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	req := structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentID:    d.ID,
+		HealthyAllocs:   map[string]int{"web": 1},
+		UnhealthyAllocs: map[string]int{"web": 1},
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedAllocations,
+		},
+		Eval: eval,
+	}
+	buf, err := structs.Encode(structs.DeploymentAllocHealthRequestType, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	resp := fsm.Apply(makeLog(buf))
+	if resp != nil {
+		t.Fatalf("resp: %v", resp)
+	}
+
+	// Verify the deployment was updated
+	out, err := fsm.State().DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed || out.TaskGroups["web"].UnhealthyAllocs != 1 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Verify the eval was enqueued
+	stats := fsm.evalBroker.Stats()
+	if stats.TotalReady != 1 {
+		t.Fatalf("bad: %#v", stats)
+	}
+}
+
+func testSnapshotRestore(t *testing.T, fsm *nomadFSM) *nomadFSM {
+	// Snapshot
+	snap, err := fsm.Snapshot()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer snap.Release()
+
+	// Persist
+	buf := bytes.NewBuffer(nil)
+	sink := &MockSink{buf, false}
+	if err := snap.Persist(sink); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Try to restore on a new FSM
+	fsm2 := testFSM(t)
+
+	// Do a restore
+	if err := fsm2.Restore(sink); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return fsm2
+}
+
+func TestFSM_SnapshotRestore_Nodes(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	node1 := mock.Node()
+	state.UpsertNode(1000, node1)
+	node2 := mock.Node()
+	state.UpsertNode(1001, node2)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.NodeByID(node1.ID)
+	out2, _ := state2.NodeByID(node2.ID)
+	if !reflect.DeepEqual(node1, out1) {
+		t.Fatalf("bad: \n%#v\n%#v", out1, node1)
+	}
+	if !reflect.DeepEqual(node2, out2) {
+		t.Fatalf("bad: \n%#v\n%#v", out2, node2)
+	}
+}
+
+func TestFSM_SnapshotRestore_Jobs(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	job1 := mock.Job()
+	state.UpsertJob(1000, job1)
+	job2 := mock.Job()
+	state.UpsertJob(1001, job2)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.JobByID(job1.ID)
+	out2, _ := state2.JobByID(job2.ID)
+	if !reflect.DeepEqual(job1, out1) {
+		t.Fatalf("bad: \n%#v\n%#v", out1, job1)
+	}
+	if !reflect.DeepEqual(job2, out2) {
+		t.Fatalf("bad: \n%#v\n%#v", out2, job2)
+	}
+}
 
 func TestFSM_SnapshotRestore_Evals(t *testing.T) {
 	// Add some state
@@ -1116,6 +1791,135 @@ func TestFSM_SnapshotRestore_VaultAccessors(t *testing.T) {
 	}
 }
 
+func TestFSM_SnapshotRestore_Deployments(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	d1 := mock.Deployment()
+	d2 := mock.Deployment()
+	state.UpsertDeployment(1000, d1, nil)
+	state.UpsertDeployment(1001, d2, nil)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.DeploymentByID(d1.ID)
+	out2, _ := state2.DeploymentByID(d2.ID)
+	if !reflect.DeepEqual(d1, out1) {
+		t.Fatalf("bad: \n%#v\n%#v", out1, d1)
+	}
+	if !reflect.DeepEqual(d2, out2) {
+		t.Fatalf("bad: \n%#v\n%#v", out2, d2)
+	}
+}
+
+func TestFSM_SnapshotRestore_Namespaces(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	q := mock.QuotaSpec()
+	ns := mock.Namespace()
+	ns.Quota = q.Name
+	state.UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q})
+	state.UpsertNamespaces(1001, []*structs.Namespace{ns})
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.QuotaSpecByName(q.Name)
+	out2, _ := state2.NamespaceByName(ns.Name)
+	if !reflect.DeepEqual(q, out1) {
+		t.Fatalf("bad: \n%#v\n%#v", out1, q)
+	}
+	if !reflect.DeepEqual(ns, out2) {
+		t.Fatalf("bad: \n%#v\n%#v", out2, ns)
+	}
+}
+
+func TestFSM_SnapshotRestore_ACL(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	policy := mock.ACLPolicy()
+	token := mock.ACLToken()
+	state.UpsertACLPolicies(1000, []*structs.ACLPolicy{policy})
+	state.UpsertACLTokens(1001, []*structs.ACLToken{token})
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.ACLPolicyByName(policy.Name)
+	out2, _ := state2.ACLTokenByAccessorID(token.AccessorID)
+	if !reflect.DeepEqual(policy, out1) {
+		t.Fatalf("bad: \n%#v\n%#v", out1, policy)
+	}
+	if out2 == nil || out2.SecretID != token.SecretID || !out2.CreateTime.Equal(token.CreateTime) {
+		t.Fatalf("bad: \n%#v\n%#v", out2, token)
+	}
+}
+
+func TestFSM_SnapshotRestore_AutopilotConfig(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	config := &structs.AutopilotConfig{
+		CleanupDeadServers:      true,
+		LastContactThreshold:    100 * time.Millisecond,
+		MaxTrailingLogs:         50,
+		ServerStabilizationTime: 5 * time.Second,
+	}
+	state.AutopilotSetConfig(1000, config)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	_, out, _ := state2.AutopilotConfig()
+	if !reflect.DeepEqual(config, out) {
+		t.Fatalf("bad: \n%#v\n%#v", out, config)
+	}
+}
+
+func TestFSM_SnapshotRestore_SchedulerConfig(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	config := &structs.SchedulerConfig{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	}
+	state.SchedulerSetConfig(1000, config)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	_, out, _ := state2.SchedulerConfig()
+	if !reflect.DeepEqual(config, out) {
+		t.Fatalf("bad: \n%#v\n%#v", out, config)
+	}
+}
+
+func TestFSM_SnapshotRestore_JobVersions(t *testing.T) {
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	job := mock.Job()
+	state.UpsertJob(1000, job)
+	job2 := job.Copy()
+	job2.Priority = 75
+	state.UpsertJob(1001, job2)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	versions, _ := state.JobVersionsByID(job.ID)
+	out, _ := state2.JobVersionsByID(job.ID)
+	if len(out) != 2 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if !reflect.DeepEqual(versions, out) {
+		t.Fatalf("bad: \n%#v\n%#v", out, versions)
+	}
+}
+
 func TestFSM_SnapshotRestore_AddMissingSummary(t *testing.T) {
 	// Add some state
 	fsm := testFSM(t)
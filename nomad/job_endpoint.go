@@ -3,6 +3,7 @@ package nomad
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/client/driver"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/watch"
@@ -50,6 +52,7 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	}
 
 	// Initialize the job fields (sets defaults and any necessary init work).
+	setRequestNamespace(args.Job, &args.WriteRequest)
 	args.Job.Canonicalize()
 
 	// Add implicit constraints
@@ -60,12 +63,37 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return err
 	}
 
-	if args.EnforceIndex {
-		// Lookup the job
-		snap, err := j.srv.fsm.State().Snapshot()
-		if err != nil {
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	// Check the token may submit jobs to the namespace. Moving an existing
+	// job between namespaces requires access to both.
+	if err := j.srv.checkNamespaceOperation(args.AuthToken, args.Job.Namespace, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+	existing, err := snap.JobByID(args.Job.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Namespace != args.Job.Namespace {
+		if err := j.srv.checkNamespaceOperation(args.AuthToken, existing.Namespace, acl.NamespaceCapabilitySubmitJob); err != nil {
 			return err
 		}
+	}
+
+	// Ensure the namespace of the job exists
+	ns, err := snap.NamespaceByName(args.Job.Namespace)
+	if err != nil {
+		return err
+	}
+	if ns == nil {
+		return fmt.Errorf("job %q is in nonexistent namespace %q", args.Job.ID, args.Job.Namespace)
+	}
+
+	if args.EnforceIndex {
+		// Lookup the job
 		job, err := snap.JobByID(args.Job.ID)
 		if err != nil {
 			return err
@@ -133,8 +161,8 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	// Populate the reply with job information
 	reply.JobModifyIndex = index
 
-	// If the job is periodic, we don't create an eval.
-	if args.Job.IsPeriodic() {
+	// If the job is periodic or parameterized, we don't create an eval.
+	if args.Job.IsPeriodic() || args.Job.IsParameterized() {
 		return nil
 	}
 
@@ -147,6 +175,7 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		JobID:          args.Job.ID,
 		JobModifyIndex: index,
 		Status:         structs.EvalStatusPending,
+		Trace:          args.Trace,
 	}
 	update := &structs.EvalUpdateRequest{
 		Evals:        []*structs.Evaluation{eval},
@@ -169,6 +198,14 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	return nil
 }
 
+// setRequestNamespace places a job that does not specify a namespace in the
+// namespace of the request.
+func setRequestNamespace(j *structs.Job, w *structs.WriteRequest) {
+	if j.Namespace == "" && w.Namespace != structs.AllNamespacesSentinel {
+		j.Namespace = w.Namespace
+	}
+}
+
 // setImplicitConstraints adds implicit constraints to the job based on the
 // features it is requesting.
 func setImplicitConstraints(j *structs.Job) {
@@ -247,6 +284,11 @@ func (j *Job) Summary(args *structs.JobSummaryRequest,
 		return err
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job_summary", "get_job_summary"}, time.Now())
+
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -291,6 +333,11 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job", "evaluate"}, time.Now())
 
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+
 	// Validate the arguments
 	if args.JobID == "" {
 		return fmt.Errorf("missing job ID for evaluation")
@@ -311,6 +358,8 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 
 	if job.IsPeriodic() {
 		return fmt.Errorf("can't evaluate periodic job")
+	} else if job.IsParameterized() {
+		return fmt.Errorf("can't evaluate parameterized job")
 	}
 
 	// Create a new evaluation
@@ -350,6 +399,11 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job", "deregister"}, time.Now())
 
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+
 	// Validate the arguments
 	if args.JobID == "" {
 		return fmt.Errorf("missing job ID for evaluation")
@@ -375,8 +429,8 @@ func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobD
 	// Populate the reply with job information
 	reply.JobModifyIndex = index
 
-	// If the job is periodic, we don't create an eval.
-	if job != nil && job.IsPeriodic() {
+	// If the job is periodic or parameterized, we don't create an eval.
+	if job != nil && (job.IsPeriodic() || job.IsParameterized()) {
 		return nil
 	}
 
@@ -420,6 +474,11 @@ func (j *Job) GetJob(args *structs.JobSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job", "get_job"}, time.Now())
 
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -457,6 +516,283 @@ func (j *Job) GetJob(args *structs.JobSpecificRequest,
 	return j.srv.blockingRPC(&opts)
 }
 
+// Versions is used to retrieve the tracked versions of a job
+func (j *Job) Versions(args *structs.JobVersionsRequest,
+	reply *structs.JobVersionsResponse) error {
+	if done, err := j.srv.forward("Job.Versions", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "versions"}, time.Now())
+
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Job: args.JobID}),
+		run: func() error {
+
+			// Look for the job versions
+			snap, err := j.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.JobVersionsByID(args.JobID)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Versions = out
+			if len(out) != 0 {
+				reply.Index = out[0].ModifyIndex
+
+				// Compute the diffs between consecutive versions
+				if args.Diffs {
+					reply.Diffs = nil
+					for i := 0; i < len(out)-1; i++ {
+						diff, err := out[i+1].Diff(out[i], true)
+						if err != nil {
+							return fmt.Errorf("failed to create job diff: %v", err)
+						}
+						reply.Diffs = append(reply.Diffs, diff)
+					}
+				}
+			} else {
+				// Use the last index that affected the job_version table
+				index, err := snap.Index("job_version")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			j.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return j.srv.blockingRPC(&opts)
+}
+
+// Revert is used to revert a job to a prior version
+func (j *Job) Revert(args *structs.JobRevertRequest, reply *structs.JobRegisterResponse) error {
+	if done, err := j.srv.forward("Job.Revert", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "revert"}, time.Now())
+
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing job ID for revert")
+	}
+
+	// Lookup the job by version
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	cur, err := snap.JobByID(args.JobID)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return fmt.Errorf("job not found")
+	}
+	if args.JobVersion == cur.Version {
+		return fmt.Errorf("can't revert to current version")
+	}
+	if args.EnforcePriorVersion != nil && cur.Version != *args.EnforcePriorVersion {
+		return fmt.Errorf("current job has version %d; enforcing version %d",
+			cur.Version, *args.EnforcePriorVersion)
+	}
+
+	job, err := snap.JobByIDAndVersion(args.JobID, args.JobVersion)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return fmt.Errorf("job %q at version %d not found", args.JobID, args.JobVersion)
+	}
+
+	// Build the register request
+	reg := &structs.JobRegisterRequest{
+		Job:          job.Copy(),
+		WriteRequest: args.WriteRequest,
+	}
+
+	// If the enforce version is set, ensure the job hasn't been modified
+	// since the current version was read.
+	if args.EnforcePriorVersion != nil {
+		reg.EnforceIndex = true
+		reg.JobModifyIndex = cur.JobModifyIndex
+	}
+
+	// Register the version.
+	return j.Register(reg, reply)
+}
+
+// Dispatch a parameterized job.
+func (j *Job) Dispatch(args *structs.JobDispatchRequest, reply *structs.JobDispatchResponse) error {
+	if done, err := j.srv.forward("Job.Dispatch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "dispatch"}, time.Now())
+
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilityDispatchJob); err != nil {
+		return err
+	}
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing parameterized job ID")
+	}
+
+	// Lookup the parameterized job
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	parameterizedJob, err := snap.JobByID(args.JobID)
+	if err != nil {
+		return err
+	}
+	if parameterizedJob == nil {
+		return fmt.Errorf("parameterized job not found")
+	}
+	if !parameterizedJob.IsParameterized() {
+		return fmt.Errorf("Specified job %q is not a parameterized job", args.JobID)
+	}
+
+	// Validate the arguments against the parameterized job
+	if err := validateDispatchRequest(args, parameterizedJob); err != nil {
+		return err
+	}
+
+	// Derive the child job and commit it via Raft
+	dispatchJob := parameterizedJob.Copy()
+	dispatchJob.ParameterizedJob = nil
+	dispatchJob.ID = structs.DispatchedID(parameterizedJob.ID, time.Now())
+	dispatchJob.ParentID = parameterizedJob.ID
+	dispatchJob.Name = dispatchJob.ID
+	dispatchJob.Payload = args.Payload
+
+	// Merge in the meta data
+	for k, v := range args.Meta {
+		if dispatchJob.Meta == nil {
+			dispatchJob.Meta = make(map[string]string, len(args.Meta))
+		}
+		dispatchJob.Meta[k] = v
+	}
+
+	regReq := &structs.JobRegisterRequest{
+		Job:          dispatchJob,
+		WriteRequest: args.WriteRequest,
+	}
+
+	// Commit this update via Raft
+	_, jobCreateIndex, err := j.srv.raftApply(structs.JobRegisterRequestType, regReq)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Dispatched job register failed: %v", err)
+		return err
+	}
+
+	reply.JobCreateIndex = jobCreateIndex
+	reply.DispatchedJobID = dispatchJob.ID
+	reply.Index = jobCreateIndex
+
+	// If the job is periodic, we don't create an eval.
+	if dispatchJob.IsPeriodic() {
+		return nil
+	}
+
+	// Create a new evaluation
+	eval := &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       dispatchJob.Priority,
+		Type:           dispatchJob.Type,
+		TriggeredBy:    structs.EvalTriggerJobRegister,
+		JobID:          dispatchJob.ID,
+		JobModifyIndex: jobCreateIndex,
+		Status:         structs.EvalStatusPending,
+	}
+	update := &structs.EvalUpdateRequest{
+		Evals:        []*structs.Evaluation{eval},
+		WriteRequest: structs.WriteRequest{Region: args.Region},
+	}
+
+	// Commit this evaluation via Raft
+	_, evalIndex, err := j.srv.raftApply(structs.EvalUpdateRequestType, update)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Eval create failed: %v", err)
+		return err
+	}
+
+	// Setup the reply
+	reply.EvalID = eval.ID
+	reply.EvalCreateIndex = evalIndex
+	reply.Index = evalIndex
+	return nil
+}
+
+// validateDispatchRequest returns whether the request is valid given the
+// parameterized job.
+func validateDispatchRequest(req *structs.JobDispatchRequest, job *structs.Job) error {
+	// Check the payload constraint is met
+	hasInputData := len(req.Payload) != 0
+	if job.ParameterizedJob.Payload == structs.DispatchPayloadRequired && !hasInputData {
+		return fmt.Errorf("Payload is not provided but required by parameterized job")
+	} else if job.ParameterizedJob.Payload == structs.DispatchPayloadForbidden && hasInputData {
+		return fmt.Errorf("Payload provided but forbidden by parameterized job")
+	}
+
+	// Check the payload doesn't exceed the size limit
+	if l := len(req.Payload); l > structs.DispatchPayloadSizeLimit {
+		return fmt.Errorf("Payload exceeds maximum size; %d > %d", l, structs.DispatchPayloadSizeLimit)
+	}
+
+	// Check the metadata key constraints are met
+	permitted := make(map[string]struct{}, len(job.ParameterizedJob.MetaRequired)+len(job.ParameterizedJob.MetaOptional))
+	for _, k := range job.ParameterizedJob.MetaRequired {
+		permitted[k] = struct{}{}
+	}
+	for _, k := range job.ParameterizedJob.MetaOptional {
+		permitted[k] = struct{}{}
+	}
+
+	var unpermitted []string
+	for k := range req.Meta {
+		if _, ok := permitted[k]; !ok {
+			unpermitted = append(unpermitted, k)
+		}
+	}
+	if len(unpermitted) != 0 {
+		sort.Strings(unpermitted)
+		return fmt.Errorf("Dispatch request included unpermitted metadata keys: %v", unpermitted)
+	}
+
+	var missing []string
+	for _, k := range job.ParameterizedJob.MetaRequired {
+		if _, ok := req.Meta[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) != 0 {
+		return fmt.Errorf("Dispatch did not provide required meta keys: %v", missing)
+	}
+
+	return nil
+}
+
 // List is used to list the jobs registered in the system
 func (j *Job) List(args *structs.JobListRequest,
 	reply *structs.JobListResponse) error {
@@ -465,6 +801,12 @@ func (j *Job) List(args *structs.JobListRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job", "list"}, time.Now())
 
+	// Resolve the token so jobs can be filtered by namespace
+	aclObj, err := j.srv.resolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -479,6 +821,8 @@ func (j *Job) List(args *structs.JobListRequest,
 			var iter memdb.ResultIterator
 			if prefix := args.QueryOptions.Prefix; prefix != "" {
 				iter, err = snap.JobsByIDPrefix(prefix)
+			} else if !args.QueryOptions.MatchesNamespace("") {
+				iter, err = snap.JobsByNamespace(args.QueryOptions.Namespace)
 			} else {
 				iter, err = snap.Jobs()
 			}
@@ -486,20 +830,38 @@ func (j *Job) List(args *structs.JobListRequest,
 				return err
 			}
 
+			paginator, err := newPaginator(&args.QueryOptions)
+			if err != nil {
+				return err
+			}
+
 			var jobs []*structs.JobListStub
-			for {
+			for !paginator.done() {
 				raw := iter.Next()
 				if raw == nil {
 					break
 				}
 				job := raw.(*structs.Job)
+				if !args.QueryOptions.MatchesNamespace(job.Namespace) {
+					continue
+				}
+				if aclObj != nil && !aclObj.AllowNamespaceOperation(job.Namespace, acl.NamespaceCapabilityListJobs) {
+					continue
+				}
 				summary, err := snap.JobSummaryByID(job.ID)
 				if err != nil {
 					return fmt.Errorf("unable to look up summary for job: %v", job.ID)
 				}
-				jobs = append(jobs, job.Stub(summary))
+				stub := job.Stub(summary)
+				if ok, err := paginator.accept(job.ID, stub); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+				jobs = append(jobs, stub)
 			}
 			reply.Jobs = jobs
+			reply.NextToken = paginator.nextPageToken()
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("jobs")
@@ -523,6 +885,11 @@ func (j *Job) Allocations(args *structs.JobSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job", "allocations"}, time.Now())
 
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -570,6 +937,11 @@ func (j *Job) Evaluations(args *structs.JobSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "job", "evaluations"}, time.Now())
 
+	// Check job permissions
+	if err := j.srv.checkJobOperation(args.AuthToken, args.JobID, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -616,8 +988,14 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 	}
 
 	// Initialize the job fields (sets defaults and any necessary init work).
+	setRequestNamespace(args.Job, &args.WriteRequest)
 	args.Job.Canonicalize()
 
+	// Check the token may submit jobs to the namespace
+	if err := j.srv.checkNamespaceOperation(args.AuthToken, args.Job.Namespace, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+
 	// Add implicit constraints
 	setImplicitConstraints(args.Job)
 
@@ -712,6 +1090,54 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 	return nil
 }
 
+// Validate runs the validation the job would go through on registration,
+// without registering it, and returns its errors and warnings.
+func (j *Job) Validate(args *structs.JobValidateRequest, reply *structs.JobValidateResponse) error {
+	if done, err := j.srv.forward("Job.Validate", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "validate"}, time.Now())
+
+	// Validate the arguments
+	if args.Job == nil {
+		return fmt.Errorf("Job required for validation")
+	}
+
+	// Initialize the job fields (sets defaults and any necessary init work).
+	setRequestNamespace(args.Job, &args.WriteRequest)
+	args.Job.Canonicalize()
+
+	// Check the token may read jobs of the namespace
+	if err := j.srv.checkNamespaceOperation(args.AuthToken, args.Job.Namespace, acl.NamespaceCapabilityReadJob); err != nil {
+		return err
+	}
+
+	// Add implicit constraints
+	setImplicitConstraints(args.Job)
+
+	reply.ValidationErrors = flattenErrors(validateJob(args.Job))
+	reply.Warnings = flattenErrors(args.Job.Warnings())
+	return nil
+}
+
+// flattenErrors returns the messages of the errors wrapped by a multierror,
+// recursively, or of the error itself.
+func flattenErrors(err error) []string {
+	if err == nil {
+		return nil
+	}
+	mErr, ok := err.(*multierror.Error)
+	if !ok {
+		return []string{err.Error()}
+	}
+
+	var msgs []string
+	for _, e := range mErr.Errors {
+		msgs = append(msgs, flattenErrors(e)...)
+	}
+	return msgs
+}
+
 // validateJob validates a Job and task drivers and returns an error if there is
 // a validation problem or if the Job is of a type a user is not allowed to
 // submit.
@@ -766,5 +1192,9 @@ func validateJob(job *structs.Job) error {
 		multierror.Append(validationErrors, fmt.Errorf("job type cannot be core"))
 	}
 
+	if len(job.Payload) != 0 {
+		multierror.Append(validationErrors, fmt.Errorf("job can't be submitted with a payload, only dispatched"))
+	}
+
 	return validationErrors.ErrorOrNil()
 }
@@ -87,6 +87,129 @@ func TestJobEndpoint_Register(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_Trace(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request with tracing enabled
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		Trace:        true,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the evaluation is traced
+	eval, err := s1.fsm.State().EvalByID(resp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil || !eval.Trace {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
+
+func TestJobEndpoint_Register_RequestNamespace(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	ns := mock.Namespace()
+	state := s1.fsm.State()
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A job without a namespace is registered in the namespace of the request
+	job := mock.Job()
+	job.Namespace = ""
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global", Namespace: ns.Name},
+	}
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Namespace != ns.Name {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The namespace of the job takes precedence
+	job2 := mock.Job()
+	job2.Namespace = structs.DefaultNamespace
+	req.Job = job2
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.JobByID(job2.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Namespace != structs.DefaultNamespace {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestJobEndpoint_Register_ACL(t *testing.T) {
+	s1, root := testACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Try without a token, expect failure
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// Try with a token that may only read jobs
+	token := createTestToken(t, s1, 1001, `namespace "default" { policy = "read" }`)
+	req.AuthToken = token.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// Try with a token that may submit jobs
+	token = createTestToken(t, s1, 1002, `namespace "default" { policy = "write" }`)
+	req.AuthToken = token.SecretID
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The management token is always allowed
+	req.AuthToken = root.SecretID
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestJobEndpoint_Register_InvalidDriverConfig(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -249,6 +372,52 @@ func TestJobEndpoint_Register_Periodic(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_ParameterizedJob(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request for a parameterized job.
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.ParameterizedJob = &structs.ParameterizedJobConfig{}
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.JobModifyIndex == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	// Check for the job in the FSM
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.CreateIndex != resp.JobModifyIndex {
+		t.Fatalf("index mis-match")
+	}
+	if out.Status != structs.JobStatusRunning {
+		t.Fatalf("bad status: %q", out.Status)
+	}
+	if resp.EvalID != "" {
+		t.Fatalf("Register created an eval for a parameterized job")
+	}
+}
+
 func TestJobEndpoint_Register_EnforceIndex(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -361,6 +530,30 @@ func TestJobEndpoint_Register_EnforceIndex(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_NonexistentNamespace(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request with a job in an unknown namespace
+	job := mock.Job()
+	job.Namespace = "nope"
+	req := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "nonexistent namespace") {
+		t.Fatalf("expected nonexistent namespace error: %v", err)
+	}
+}
+
 func TestJobEndpoint_Register_Vault_Disabled(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -960,6 +1153,414 @@ func TestJobEndpoint_GetJob(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Versions(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register the job twice with a change in between
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Priority = 100
+	reg.Job = job2
+	var resp2 structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the versions along with their diffs
+	get := &structs.JobVersionsRequest{
+		JobID:        job.ID,
+		Diffs:        true,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var versionsResp structs.JobVersionsResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Versions", get, &versionsResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if versionsResp.Index != resp2.JobModifyIndex {
+		t.Fatalf("Bad index: %d %d", versionsResp.Index, resp2.JobModifyIndex)
+	}
+
+	versions := versionsResp.Versions
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions; want 2", len(versions))
+	}
+	if v := versions[0]; v.Version != 1 || v.Priority != 100 {
+		t.Fatalf("bad: %+v", v)
+	}
+	if v := versions[1]; v.Version != 0 || v.Priority != job.Priority {
+		t.Fatalf("bad: %+v", v)
+	}
+
+	// The diff shows the change of the priority
+	if len(versionsResp.Diffs) != 1 {
+		t.Fatalf("got %d diffs; want 1", len(versionsResp.Diffs))
+	}
+	diff := versionsResp.Diffs[0]
+	if diff.Type != structs.DiffTypeEdited || len(diff.Fields) != 1 || diff.Fields[0].Name != "Priority" {
+		t.Fatalf("bad diff: %#v", diff)
+	}
+
+	// Lookup non-existing job
+	get.JobID = "foobarbaz"
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Versions", get, &versionsResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if versionsResp.Index != resp2.JobModifyIndex {
+		t.Fatalf("Bad index: %d %d", versionsResp.Index, resp2.JobModifyIndex)
+	}
+	if len(versionsResp.Versions) != 0 {
+		t.Fatalf("unexpected versions")
+	}
+}
+
+func TestJobEndpoint_Revert(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register the job twice with a change in between
+	job := mock.Job()
+	job.Priority = 100
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobRegisterResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Priority = 50
+	reg.Job = job2
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reverting to the current version fails
+	revert := &structs.JobRevertRequest{
+		JobID:        job.ID,
+		JobVersion:   1,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var revertResp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &revertResp)
+	if err == nil || !strings.Contains(err.Error(), "current version") {
+		t.Fatalf("expected current version error: %v", err)
+	}
+
+	// Reverting while enforcing the wrong prior version fails
+	wrongVersion := uint64(10)
+	revert.JobVersion = 0
+	revert.EnforcePriorVersion = &wrongVersion
+	err = msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &revertResp)
+	if err == nil || !strings.Contains(err.Error(), "enforcing version 10") {
+		t.Fatalf("expected enforcement error: %v", err)
+	}
+
+	// Reverting to an unknown version fails
+	revert.JobVersion = 20
+	revert.EnforcePriorVersion = nil
+	err = msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &revertResp)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected not found error: %v", err)
+	}
+
+	// Revert to the first version
+	priorVersion := uint64(1)
+	revert.JobVersion = 0
+	revert.EnforcePriorVersion = &priorVersion
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Revert", revert, &revertResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if revertResp.EvalID == "" || revertResp.EvalCreateIndex == 0 {
+		t.Fatalf("bad response: %#v", revertResp)
+	}
+
+	// Check the job is registered as a new version with the old definition
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected job")
+	}
+	if out.Version != 2 || out.Priority != 100 {
+		t.Fatalf("bad: %+v", out)
+	}
+	if out.JobModifyIndex != revertResp.JobModifyIndex {
+		t.Fatalf("index mis-match")
+	}
+
+	// Lookup the evaluation
+	eval, err := state.EvalByID(revertResp.EvalID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if eval == nil {
+		t.Fatalf("expected eval")
+	}
+	if eval.JobID != job.ID || eval.JobModifyIndex != revertResp.JobModifyIndex {
+		t.Fatalf("bad: %#v", eval)
+	}
+}
+
+func TestJobEndpoint_Dispatch(t *testing.T) {
+	// No requirements
+	d1 := mock.Job()
+	d1.Type = structs.JobTypeBatch
+	d1.ParameterizedJob = &structs.ParameterizedJobConfig{}
+
+	// Require input data
+	d2 := mock.Job()
+	d2.Type = structs.JobTypeBatch
+	d2.ParameterizedJob = &structs.ParameterizedJobConfig{
+		Payload: structs.DispatchPayloadRequired,
+	}
+
+	// Disallow input data
+	d3 := mock.Job()
+	d3.Type = structs.JobTypeBatch
+	d3.ParameterizedJob = &structs.ParameterizedJobConfig{
+		Payload: structs.DispatchPayloadForbidden,
+	}
+
+	// Require meta
+	d4 := mock.Job()
+	d4.Type = structs.JobTypeBatch
+	d4.ParameterizedJob = &structs.ParameterizedJobConfig{
+		MetaRequired: []string{"foo", "bar"},
+	}
+
+	// Optional meta
+	d5 := mock.Job()
+	d5.Type = structs.JobTypeBatch
+	d5.ParameterizedJob = &structs.ParameterizedJobConfig{
+		MetaOptional: []string{"foo", "bar"},
+	}
+
+	// Periodic dispatch job
+	d6 := mock.PeriodicJob()
+	d6.ParameterizedJob = &structs.ParameterizedJobConfig{}
+
+	reqNoInputNoMeta := &structs.JobDispatchRequest{}
+	reqInputDataNoMeta := &structs.JobDispatchRequest{
+		Payload: []byte("hello world"),
+	}
+	reqNoInputDataMeta := &structs.JobDispatchRequest{
+		Meta: map[string]string{
+			"foo": "f1",
+			"bar": "f2",
+		},
+	}
+	reqInputDataMeta := &structs.JobDispatchRequest{
+		Payload: []byte("hello world"),
+		Meta: map[string]string{
+			"foo": "f1",
+			"bar": "f2",
+		},
+	}
+	reqBadMeta := &structs.JobDispatchRequest{
+		Payload: []byte("hello world"),
+		Meta: map[string]string{
+			"foo": "f1",
+			"bar": "f2",
+			"baz": "f3",
+		},
+	}
+	reqInputDataTooLarge := &structs.JobDispatchRequest{
+		Payload: make([]byte, structs.DispatchPayloadSizeLimit+100),
+	}
+
+	type testCase struct {
+		name             string
+		parameterizedJob *structs.Job
+		dispatchReq      *structs.JobDispatchRequest
+		noEval           bool
+		err              bool
+		errStr           string
+	}
+	cases := []testCase{
+		{
+			name:             "optional input data w/ data",
+			parameterizedJob: d1,
+			dispatchReq:      reqInputDataNoMeta,
+		},
+		{
+			name:             "optional input data w/o data",
+			parameterizedJob: d1,
+			dispatchReq:      reqNoInputNoMeta,
+		},
+		{
+			name:             "require input data w/ data",
+			parameterizedJob: d2,
+			dispatchReq:      reqInputDataNoMeta,
+		},
+		{
+			name:             "require input data w/o data",
+			parameterizedJob: d2,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              true,
+			errStr:           "not provided but required",
+		},
+		{
+			name:             "disallow input data w/o data",
+			parameterizedJob: d3,
+			dispatchReq:      reqNoInputNoMeta,
+		},
+		{
+			name:             "disallow input data w/ data",
+			parameterizedJob: d3,
+			dispatchReq:      reqInputDataNoMeta,
+			err:              true,
+			errStr:           "provided but forbidden",
+		},
+		{
+			name:             "require meta w/ meta",
+			parameterizedJob: d4,
+			dispatchReq:      reqInputDataMeta,
+		},
+		{
+			name:             "require meta w/o meta",
+			parameterizedJob: d4,
+			dispatchReq:      reqNoInputNoMeta,
+			err:              true,
+			errStr:           "did not provide required meta keys",
+		},
+		{
+			name:             "optional meta w/ meta",
+			parameterizedJob: d5,
+			dispatchReq:      reqNoInputDataMeta,
+		},
+		{
+			name:             "optional meta w/o meta",
+			parameterizedJob: d5,
+			dispatchReq:      reqNoInputNoMeta,
+		},
+		{
+			name:             "optional meta w/ bad meta",
+			parameterizedJob: d5,
+			dispatchReq:      reqBadMeta,
+			err:              true,
+			errStr:           "unpermitted metadata keys",
+		},
+		{
+			name:             "optional input w/ too big of input",
+			parameterizedJob: d1,
+			dispatchReq:      reqInputDataTooLarge,
+			err:              true,
+			errStr:           "Payload exceeds maximum size",
+		},
+		{
+			name:             "periodic job dispatched, ensure no eval",
+			parameterizedJob: d6,
+			dispatchReq:      reqNoInputNoMeta,
+			noEval:           true,
+		},
+	}
+
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	for _, tc := range cases {
+		// Create the register request
+		regReq := &structs.JobRegisterRequest{
+			Job:          tc.parameterizedJob,
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+
+		// Fetch the response
+		var regResp structs.JobRegisterResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Job.Register", regReq, &regResp); err != nil {
+			t.Fatalf("%s: err: %v", tc.name, err)
+		}
+
+		// Now try to dispatch
+		tc.dispatchReq.JobID = tc.parameterizedJob.ID
+		tc.dispatchReq.WriteRequest = structs.WriteRequest{Region: "global"}
+
+		var dispatchResp structs.JobDispatchResponse
+		dispatchErr := msgpackrpc.CallWithCodec(codec, "Job.Dispatch", tc.dispatchReq, &dispatchResp)
+		if dispatchErr != nil {
+			if !tc.err {
+				t.Fatalf("%s: got unexpected error: %v", tc.name, dispatchErr)
+			} else if !strings.Contains(dispatchErr.Error(), tc.errStr) {
+				t.Fatalf("%s: expected err to include %q; got %v", tc.name, tc.errStr, dispatchErr)
+			}
+			continue
+		}
+		if tc.err {
+			t.Fatalf("%s: expected error", tc.name)
+		}
+
+		// Check that we got an eval and job id back
+		if dispatchResp.DispatchedJobID == "" {
+			t.Fatalf("%s: bad response: %#v", tc.name, dispatchResp)
+		}
+		if tc.noEval && dispatchResp.EvalID != "" {
+			t.Fatalf("%s: got eval %q", tc.name, dispatchResp.EvalID)
+		} else if !tc.noEval && dispatchResp.EvalID == "" {
+			t.Fatalf("%s: bad response: %#v", tc.name, dispatchResp)
+		}
+
+		out, err := state.JobByID(dispatchResp.DispatchedJobID)
+		if err != nil {
+			t.Fatalf("%s: err: %v", tc.name, err)
+		}
+		if out == nil {
+			t.Fatalf("%s: expected job", tc.name)
+		}
+		if out.CreateIndex != dispatchResp.JobCreateIndex {
+			t.Fatalf("%s: index mis-match", tc.name)
+		}
+		if out.ParentID != tc.parameterizedJob.ID || out.IsParameterized() {
+			t.Fatalf("%s: bad job: %#v", tc.name, out)
+		}
+		if !reflect.DeepEqual(out.Payload, tc.dispatchReq.Payload) {
+			t.Fatalf("%s: bad payload: %q", tc.name, out.Payload)
+		}
+
+		if tc.noEval {
+			continue
+		}
+
+		// Lookup the evaluation
+		eval, err := state.EvalByID(dispatchResp.EvalID)
+		if err != nil {
+			t.Fatalf("%s: err: %v", tc.name, err)
+		}
+		if eval == nil {
+			t.Fatalf("%s: expected eval", tc.name)
+		}
+		if eval.CreateIndex != dispatchResp.EvalCreateIndex {
+			t.Fatalf("%s: index mis-match", tc.name)
+		}
+	}
+}
+
 func TestJobEndpoint_GetJobSummary(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -1230,6 +1831,117 @@ func TestJobEndpoint_ListJobs(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_ListJobs_Namespace(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	ns := mock.Namespace()
+	state := s1.fsm.State()
+	if err := state.UpsertNamespaces(999, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job1 := mock.Job()
+	job2 := mock.Job()
+	job2.Namespace = ns.Name
+	if err := state.UpsertJob(1000, job1); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(1001, job2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	cases := []struct {
+		namespace string
+		expected  int
+	}{
+		{"", 2},
+		{structs.AllNamespacesSentinel, 2},
+		{structs.DefaultNamespace, 1},
+		{ns.Name, 1},
+		{"missing", 0},
+	}
+	for _, c := range cases {
+		get := &structs.JobListRequest{
+			QueryOptions: structs.QueryOptions{Region: "global", Namespace: c.namespace},
+		}
+		var resp structs.JobListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(resp.Jobs) != c.expected {
+			t.Fatalf("bad: namespace %q: %#v", c.namespace, resp.Jobs)
+		}
+	}
+
+	// Allocations are listed by the namespace of their job
+	alloc := mock.Alloc()
+	alloc.JobID = job2.ID
+	alloc.Job = job2
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for namespace, expected := range map[string]int{ns.Name: 1, structs.DefaultNamespace: 0} {
+		get := &structs.AllocListRequest{
+			QueryOptions: structs.QueryOptions{Region: "global", Namespace: namespace},
+		}
+		var resp structs.AllocListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Alloc.List", get, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(resp.Allocations) != expected {
+			t.Fatalf("bad: namespace %q: %#v", namespace, resp.Allocations)
+		}
+	}
+}
+
+func TestJobEndpoint_ListJobs_ACL(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	job := mock.Job()
+	state := s1.fsm.State()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Anonymous requests see no jobs
+	get := &structs.JobListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.JobListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.Jobs) != 0 {
+		t.Fatalf("bad: %#v", resp.Jobs)
+	}
+
+	// A token that may read the namespace sees the job
+	token := createTestToken(t, s1, 1001, `namespace "default" { policy = "read" }`)
+	get.AuthToken = token.SecretID
+	var resp2 structs.JobListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Jobs) != 1 || resp2.Jobs[0].ID != job.ID {
+		t.Fatalf("bad: %#v", resp2.Jobs)
+	}
+
+	// So does the management token
+	get.AuthToken = root.SecretID
+	var resp3 structs.JobListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp3); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp3.Jobs) != 1 {
+		t.Fatalf("bad: %#v", resp3.Jobs)
+	}
+}
+
 func TestJobEndpoint_ListJobs_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -1474,6 +2186,62 @@ func TestJobEndpoint_Evaluations_Blocking(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Validate(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Validate a valid job
+	job := mock.Job()
+	req := &structs.JobValidateRequest{
+		Job:          job,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.JobValidateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Validate", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.ValidationErrors) != 0 || len(resp.Warnings) != 0 {
+		t.Fatalf("bad: %#v", resp)
+	}
+
+	// The job wasn't registered
+	state := s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Validate an invalid job with warnings
+	job = mock.Job()
+	job.Priority = 0
+	job.TaskGroups[0].Tasks[0].Config = map[string]interface{}{}
+	job.TaskGroups[0].Update = &structs.UpdateStrategy{MaxParallel: 1}
+	req.Job = job
+	resp = structs.JobValidateResponse{}
+	if err := msgpackrpc.CallWithCodec(codec, "Job.Validate", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp.ValidationErrors) != 2 {
+		t.Fatalf("bad: %#v", resp.ValidationErrors)
+	}
+	if !strings.Contains(resp.ValidationErrors[0], "priority") {
+		t.Fatalf("bad: %#v", resp.ValidationErrors)
+	}
+	if !strings.Contains(resp.ValidationErrors[1], "config") {
+		t.Fatalf("bad: %#v", resp.ValidationErrors)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "max_parallel") {
+		t.Fatalf("bad: %#v", resp.Warnings)
+	}
+}
+
 func TestJobEndpoint_Plan_WithDiff(t *testing.T) {
 	s1 := testServer(t, func(c *Config) {
 		c.NumSchedulers = 0 // Prevent automatic dequeue
@@ -97,6 +97,17 @@ WAIT:
 			goto RECONCILE
 		case member := <-reconcileCh:
 			s.reconcileMember(member)
+		case errCh := <-s.reassertLeaderCh:
+			// There is nothing to reassert if the initial leadership
+			// actions never succeeded
+			if !establishedLeader {
+				errCh <- fmt.Errorf("leadership has not been established")
+				continue
+			}
+
+			// Recompute the leader state from the current state store
+			s.revokeLeadership()
+			errCh <- s.establishLeadership(stopCh)
 		}
 	}
 }
@@ -116,6 +127,10 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 		}
 	}
 
+	// Generate a leader ACL token. This will allow the leader to issue work
+	// that requires a valid ACL token.
+	s.setLeaderAcl(structs.GenerateUUID())
+
 	// Enable the plan queue, since we are now the leader
 	s.planQueue.SetEnabled(true)
 
@@ -160,6 +175,28 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 	// Periodically unblock failed allocations
 	go s.periodicUnblockFailedEvals(stopCh)
 
+	// Track the health of running deployments
+	go s.watchDeployments(stopCh)
+
+	// Migrate the allocations of draining nodes
+	go s.watchDrains(stopCh)
+
+	// Make the nodes marked ineligible for rejecting plans eligible again
+	// once their ineligibility expired
+	go s.watchRejectingNodes(stopCh)
+
+	// Initialize the Autopilot configuration of a new cluster and start
+	// tracking the health of the servers
+	if _, err := s.getOrCreateAutopilotConfig(); err != nil {
+		s.logger.Printf("[ERR] nomad: %v", err)
+	}
+	go s.autopilotLoop(stopCh)
+
+	// Initialize the scheduler configuration of a new cluster
+	if _, err := s.getOrCreateSchedulerConfig(); err != nil {
+		s.logger.Printf("[ERR] nomad: %v", err)
+	}
+
 	// Setup the heartbeat timers. This is done both when starting up or when
 	// a leader fail over happens. Since the timers are maintained by the leader
 	// node, effectively this means all the timers are renewed at the time of failover.
@@ -185,6 +222,25 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 	return nil
 }
 
+// getOrCreateSchedulerConfig returns the scheduler configuration stored in
+// the state store, storing the configuration of the agent first if there is
+// none yet.
+func (s *Server) getOrCreateSchedulerConfig() (*structs.SchedulerConfig, error) {
+	_, config, err := s.fsm.State().SchedulerConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config != nil {
+		return config, nil
+	}
+
+	req := structs.SchedulerSetConfigRequest{Config: *s.config.SchedulerConfig}
+	if _, _, err := s.raftApply(structs.SchedulerConfigRequestType, &req); err != nil {
+		return nil, fmt.Errorf("failed to initialize scheduler config: %v", err)
+	}
+	return &req.Config, nil
+}
+
 // restoreEvals is used to restore pending evaluations into the eval broker and
 // blocked evaluations into the blocked eval tracker. The broker and blocked
 // eval tracker is maintained only by the leader, so it must be restored anytime
@@ -317,6 +373,8 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 	defer nodeGC.Stop()
 	jobGC := time.NewTicker(s.config.JobGCInterval)
 	defer jobGC.Stop()
+	deploymentGC := time.NewTicker(s.config.DeploymentGCInterval)
+	defer deploymentGC.Stop()
 
 	// getLatest grabs the latest index from the state store. It returns true if
 	// the index was retrieved successfully.
@@ -345,6 +403,10 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 			if index, ok := getLatest(); ok {
 				s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobJobGC, index))
 			}
+		case <-deploymentGC.C:
+			if index, ok := getLatest(); ok {
+				s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobDeploymentGC, index))
+			}
 		case <-stopCh:
 			return
 		}
@@ -361,6 +423,7 @@ func (s *Server) coreJobEval(job string, modifyIndex uint64) *structs.Evaluation
 		JobID:       job,
 		Status:      structs.EvalStatusPending,
 		ModifyIndex: modifyIndex,
+		LeaderACL:   s.getLeaderAcl(),
 	}
 }
 
@@ -455,6 +518,9 @@ func (s *Server) periodicUnblockFailedEvals(stopCh chan struct{}) {
 // revokeLeadership is invoked once we step down as leader.
 // This is used to cleanup any state that may be specific to a leader.
 func (s *Server) revokeLeadership() error {
+	// Clear the leader token since we are no longer the leader.
+	s.setLeaderAcl("")
+
 	// Disable the plan queue, since we are no longer leader
 	s.planQueue.SetEnabled(false)
 
@@ -516,7 +582,13 @@ func (s *Server) reconcileMember(member serf.Member) error {
 	var err error
 	switch member.Status {
 	case serf.StatusAlive:
-		err = s.addRaftPeer(member, parts)
+		// New servers are only added to the peer set by autopilot once they
+		// have been stable for long enough
+		var config *structs.AutopilotConfig
+		config, err = s.autopilotConfig()
+		if err == nil && config.ServerStabilizationTime == 0 {
+			err = s.addRaftPeer(member, parts)
+		}
 	case serf.StatusLeft, StatusReap:
 		err = s.removeRaftPeer(member, parts)
 	}
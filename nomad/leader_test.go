@@ -572,3 +572,83 @@ func TestLeader_RestoreVaultAccessors(t *testing.T) {
 		t.Fatalf("Bad revoked accessors: %v", tvc.RevokedTokens)
 	}
 }
+
+func TestLeader_RejectingNode_Reenabled_Failover(t *testing.T) {
+	cb := func(c *Config) {
+		c.PlanRejectionCooldown = time.Second
+	}
+	s1 := testServer(t, cb)
+	defer s1.Shutdown()
+
+	s2 := testServer(t, func(c *Config) {
+		cb(c)
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+
+	s3 := testServer(t, func(c *Config) {
+		cb(c)
+		c.DevDisableBootstrap = true
+	})
+	defer s3.Shutdown()
+	servers := []*Server{s1, s2, s3}
+	testJoin(t, s1, s2, s3)
+
+	for _, s := range servers {
+		testutil.WaitForResult(func() (bool, error) {
+			peers, _ := s.raftPeers.Peers()
+			return len(peers) == 3, nil
+		}, func(err error) {
+			t.Fatalf("should have 3 peers")
+		})
+	}
+
+	var leader *Server
+	for _, s := range servers {
+		if s.IsLeader() {
+			leader = s
+			break
+		}
+	}
+	if leader == nil {
+		t.Fatalf("Should have a leader")
+	}
+
+	// Register a node and mark it as rejecting plans on the leader
+	node := mock.Node()
+	req := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.NodeUpdateResponse
+	if err := leader.RPC("Node.Register", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	leader.markNodeIneligible(node.ID)
+
+	// Kill the leader before the node is eligible again
+	leader.Leave()
+	leader.Shutdown()
+
+	// The new leader makes the node eligible again
+	var remaining []*Server
+	for _, s := range servers {
+		if s != leader {
+			remaining = append(remaining, s)
+		}
+	}
+	testutil.WaitForResult(func() (bool, error) {
+		for _, s := range remaining {
+			out, err := s.fsm.State().NodeByID(node.ID)
+			if err != nil {
+				return false, err
+			}
+			if out == nil || !out.Eligible() || out.IneligibleUntil != 0 {
+				return false, fmt.Errorf("node still ineligible: %#v", out)
+			}
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -305,6 +306,92 @@ func VaultAccessor() *structs.VaultAccessor {
 	}
 }
 
+func Deployment() *structs.Deployment {
+	return &structs.Deployment{
+		ID:             structs.GenerateUUID(),
+		JobID:          structs.GenerateUUID(),
+		JobModifyIndex: 20,
+		JobCreateIndex: 18,
+		TaskGroups: map[string]*structs.DeploymentState{
+			"web": &structs.DeploymentState{
+				DesiredCanaries: 1,
+				DesiredTotal:    10,
+			},
+		},
+		Status:            structs.DeploymentStatusRunning,
+		StatusDescription: structs.DeploymentStatusDescriptionRunning,
+		ModifyIndex:       23,
+		CreateIndex:       21,
+	}
+}
+
+func Namespace() *structs.Namespace {
+	return &structs.Namespace{
+		Name:        fmt.Sprintf("team-%s", structs.GenerateUUID()[:8]),
+		Description: "Team namespace",
+	}
+}
+
+func QuotaSpec() *structs.QuotaSpec {
+	return &structs.QuotaSpec{
+		Name:        fmt.Sprintf("quota-%s", structs.GenerateUUID()[:8]),
+		Description: "Team quota",
+		Limit: &structs.QuotaLimit{
+			CPU:      2000,
+			MemoryMB: 1024,
+			Count:    4,
+		},
+	}
+}
+
+func ACLPolicy() *structs.ACLPolicy {
+	ap := &structs.ACLPolicy{
+		Name:        fmt.Sprintf("policy-%s", structs.GenerateUUID()),
+		Description: "Super cool policy!",
+		Rules: `
+		namespace "default" {
+			policy = "write"
+		}
+		node {
+			policy = "read"
+		}
+		operator {
+			policy = "read"
+		}
+		`,
+		CreateIndex: 10,
+		ModifyIndex: 20,
+	}
+	ap.SetHash()
+	return ap
+}
+
+func ACLToken() *structs.ACLToken {
+	tk := &structs.ACLToken{
+		AccessorID:  structs.GenerateUUID(),
+		SecretID:    structs.GenerateUUID(),
+		Name:        "my cool token " + structs.GenerateUUID(),
+		Type:        "client",
+		Policies:    []string{"foo", "bar"},
+		CreateTime:  time.Now().UTC(),
+		CreateIndex: 10,
+		ModifyIndex: 20,
+	}
+	return tk
+}
+
+func ACLManagementToken() *structs.ACLToken {
+	return &structs.ACLToken{
+		AccessorID:  structs.GenerateUUID(),
+		SecretID:    structs.GenerateUUID(),
+		Name:        "management " + structs.GenerateUUID(),
+		Type:        "management",
+		CreateTime:  time.Now().UTC(),
+		CreateIndex: 10,
+		ModifyIndex: 20,
+	}
+}
+
 func Plan() *structs.Plan {
 	return &structs.Plan{
 		Priority: 50,
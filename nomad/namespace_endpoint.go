@@ -0,0 +1,234 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Namespace endpoint is used for manipulating namespaces
+type Namespace struct {
+	srv *Server
+}
+
+// UpsertNamespaces is used to create or update a set of namespaces
+func (n *Namespace) UpsertNamespaces(args *structs.NamespaceUpsertRequest,
+	reply *structs.GenericResponse) error {
+	if done, err := n.srv.forward("Namespace.UpsertNamespaces", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "upsert_namespaces"}, time.Now())
+
+	// Check management level permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).IsManagement); err != nil {
+		return err
+	}
+
+	// Validate the arguments
+	if len(args.Namespaces) == 0 {
+		return fmt.Errorf("must specify at least one namespace")
+	}
+
+	snap, err := n.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	for _, ns := range args.Namespaces {
+		if err := ns.Validate(); err != nil {
+			return fmt.Errorf("invalid namespace %q: %v", ns.Name, err)
+		}
+
+		// The attached quota must exist
+		if ns.Quota == "" {
+			continue
+		}
+		quota, err := snap.QuotaSpecByName(ns.Quota)
+		if err != nil {
+			return err
+		}
+		if quota == nil {
+			return fmt.Errorf("namespace %q references unknown quota %q", ns.Name, ns.Quota)
+		}
+	}
+
+	// Update via Raft
+	_, index, err := n.srv.raftApply(structs.NamespaceUpsertRequestType, args)
+	if err != nil {
+		n.srv.logger.Printf("[ERR] nomad.namespace: UpsertNamespaces failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// DeleteNamespaces is used to delete a set of namespaces
+func (n *Namespace) DeleteNamespaces(args *structs.NamespaceDeleteRequest,
+	reply *structs.GenericResponse) error {
+	if done, err := n.srv.forward("Namespace.DeleteNamespaces", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "delete_namespaces"}, time.Now())
+
+	// Check management level permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).IsManagement); err != nil {
+		return err
+	}
+
+	// Validate the arguments
+	if len(args.Namespaces) == 0 {
+		return fmt.Errorf("must specify at least one namespace to delete")
+	}
+
+	snap, err := n.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	for _, name := range args.Namespaces {
+		if name == structs.DefaultNamespace {
+			return fmt.Errorf("can not delete default namespace")
+		}
+
+		// Namespaces that still have jobs can not be deleted
+		iter, err := snap.JobsByNamespace(name)
+		if err != nil {
+			return err
+		}
+		if iter.Next() != nil {
+			return fmt.Errorf("namespace %q has registered jobs", name)
+		}
+	}
+
+	// Update via Raft
+	resp, index, err := n.srv.raftApply(structs.NamespaceDeleteRequestType, args)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		n.srv.logger.Printf("[ERR] nomad.namespace: DeleteNamespaces failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// ListNamespaces is used to list the namespaces
+func (n *Namespace) ListNamespaces(args *structs.NamespaceListRequest,
+	reply *structs.NamespaceListResponse) error {
+	if done, err := n.srv.forward("Namespace.ListNamespaces", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "list_namespaces"}, time.Now())
+
+	// Resolve the token so namespaces can be filtered
+	aclObj, err := n.srv.resolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "namespaces"}),
+		run: func() error {
+			// Capture all the namespaces
+			snap, err := n.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.NamespacesByNamePrefix(prefix)
+			} else {
+				iter, err = snap.Namespaces()
+			}
+			if err != nil {
+				return err
+			}
+
+			reply.Namespaces = nil
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				ns := raw.(*structs.Namespace)
+				if aclObj != nil && !aclObj.AllowNamespace(ns.Name) {
+					continue
+				}
+				reply.Namespaces = append(reply.Namespaces, ns)
+			}
+
+			// Use the last index that affected the namespace table
+			index, err := snap.Index("namespaces")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			n.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return n.srv.blockingRPC(&opts)
+}
+
+// GetNamespace is used to get a specific namespace
+func (n *Namespace) GetNamespace(args *structs.NamespaceSpecificRequest,
+	reply *structs.SingleNamespaceResponse) error {
+	if done, err := n.srv.forward("Namespace.GetNamespace", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "namespace", "get_namespace"}, time.Now())
+
+	// Check the token may access the namespace
+	if err := n.srv.checkACL(args.AuthToken, func(a *acl.ACL) bool {
+		return a.AllowNamespace(args.Name)
+	}); err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "namespaces"}),
+		run: func() error {
+			// Look for the namespace
+			snap, err := n.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.NamespaceByName(args.Name)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Namespace = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the namespace table
+				index, err := snap.Index("namespaces")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			n.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return n.srv.blockingRPC(&opts)
+}
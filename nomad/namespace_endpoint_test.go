@@ -0,0 +1,191 @@
+package nomad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestNamespaceEndpoint_GetNamespace(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the namespace
+	ns := mock.Namespace()
+	if err := s1.fsm.State().UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the namespace
+	get := &structs.NamespaceSpecificRequest{
+		Name:         ns.Name,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleNamespaceResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.GetNamespace", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if !reflect.DeepEqual(ns, resp.Namespace) {
+		t.Fatalf("bad: %#v %#v", ns, resp.Namespace)
+	}
+
+	// Lookup a non-existing namespace
+	get.Name = "nope"
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.GetNamespace", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Namespace != nil {
+		t.Fatalf("unexpected namespace")
+	}
+}
+
+func TestNamespaceEndpoint_ListNamespaces(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the namespaces
+	ns1 := mock.Namespace()
+	ns1.Name = "aaaa"
+	ns2 := mock.Namespace()
+	ns2.Name = "aabb"
+	if err := s1.fsm.State().UpsertNamespaces(1000, []*structs.Namespace{ns1, ns2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the namespaces
+	get := &structs.NamespaceListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.NamespaceListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.ListNamespaces", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+
+	// The default namespace is always present
+	if len(resp.Namespaces) != 3 {
+		t.Fatalf("bad: %#v", resp.Namespaces)
+	}
+
+	// Lookup the namespaces by prefix
+	get.Prefix = "aab"
+	var resp2 structs.NamespaceListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.ListNamespaces", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Namespaces) != 1 || resp2.Namespaces[0].Name != ns2.Name {
+		t.Fatalf("bad: %#v", resp2.Namespaces)
+	}
+}
+
+func TestNamespaceEndpoint_UpsertNamespaces(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Referencing an unknown quota fails
+	ns := mock.Namespace()
+	ns.Quota = "nope"
+	req := &structs.NamespaceUpsertRequest{
+		Namespaces:   []*structs.Namespace{ns},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "Namespace.UpsertNamespaces", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "unknown quota") {
+		t.Fatalf("expected unknown quota error: %v", err)
+	}
+
+	// Create the quota and retry
+	q := mock.QuotaSpec()
+	q.Name = "nope"
+	if err := s1.fsm.State().UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.UpsertNamespaces", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+
+	out, err := s1.fsm.State().NamespaceByName(ns.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.Quota != q.Name {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Invalid names are rejected
+	req.Namespaces = []*structs.Namespace{{Name: "bad name"}}
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.UpsertNamespaces", req, &resp); err == nil {
+		t.Fatalf("expected invalid namespace error")
+	}
+}
+
+func TestNamespaceEndpoint_DeleteNamespaces(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	ns := mock.Namespace()
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job := mock.Job()
+	job.Namespace = ns.Name
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The default namespace can not be deleted
+	req := &structs.NamespaceDeleteRequest{
+		Namespaces:   []string{structs.DefaultNamespace},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.DeleteNamespaces", req, &resp); err == nil {
+		t.Fatalf("expected error deleting the default namespace")
+	}
+
+	// Namespaces with jobs can not be deleted
+	req.Namespaces = []string{ns.Name}
+	err := msgpackrpc.CallWithCodec(codec, "Namespace.DeleteNamespaces", req, &resp)
+	if err == nil || !strings.Contains(err.Error(), "registered jobs") {
+		t.Fatalf("expected registered jobs error: %v", err)
+	}
+
+	// Remove the job and delete the namespace
+	if err := state.DeleteJob(1002, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Namespace.DeleteNamespaces", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.NamespaceByName(ns.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/watch"
@@ -225,6 +226,11 @@ func (n *Node) Deregister(args *structs.NodeDeregisterRequest, reply *structs.No
 	}
 	defer metrics.MeasureSince([]string{"nomad", "client", "deregister"}, time.Now())
 
+	// Check node write permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).AllowNodeWrite); err != nil {
+		return err
+	}
+
 	// Verify the arguments
 	if args.NodeID == "" {
 		return fmt.Errorf("missing node ID for client deregistration")
@@ -379,10 +385,18 @@ func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "client", "update_drain"}, time.Now())
 
+	// Check node write permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).AllowNodeWrite); err != nil {
+		return err
+	}
+
 	// Verify the arguments
 	if args.NodeID == "" {
 		return fmt.Errorf("missing node ID for drain update")
 	}
+	if args.DrainStrategy != nil && !args.Drain {
+		return fmt.Errorf("drain strategy can only be set when enabling drain")
+	}
 
 	// Look for the node
 	snap, err := n.srv.fsm.State().Snapshot()
@@ -400,9 +414,19 @@ func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 	// Update the timestamp to
 	node.StatusUpdatedAt = time.Now().Unix()
 
+	// The deadline of the drain starts when it is enabled
+	if args.DrainStrategy != nil {
+		switch {
+		case args.DrainStrategy.Deadline > 0:
+			args.DrainStrategy.ForceDeadline = time.Now().Add(args.DrainStrategy.Deadline)
+		case args.DrainStrategy.Deadline < 0:
+			args.DrainStrategy.ForceDeadline = time.Now()
+		}
+	}
+
 	// Commit this update via Raft
 	var index uint64
-	if node.Drain != args.Drain {
+	if node.Drain != args.Drain || args.DrainStrategy != nil {
 		_, index, err = n.srv.raftApply(structs.NodeUpdateDrainRequestType, args)
 		if err != nil {
 			n.srv.logger.Printf("[ERR] nomad.client: drain update failed: %v", err)
@@ -426,6 +450,77 @@ func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 	return nil
 }
 
+// UpdateEligibility is used to update the scheduling eligibility of a node.
+// Ineligible nodes receive no new allocations but keep running the existing
+// ones.
+func (n *Node) UpdateEligibility(args *structs.NodeUpdateEligibilityRequest,
+	reply *structs.NodeEligibilityUpdateResponse) error {
+	if done, err := n.srv.forward("Node.UpdateEligibility", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "client", "update_eligibility"}, time.Now())
+
+	// Check node write permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).AllowNodeWrite); err != nil {
+		return err
+	}
+
+	// Verify the arguments
+	if args.NodeID == "" {
+		return fmt.Errorf("missing node ID for eligibility update")
+	}
+	switch args.Eligibility {
+	case structs.NodeSchedulingEligible, structs.NodeSchedulingIneligible:
+	default:
+		return fmt.Errorf("invalid scheduling eligibility %q", args.Eligibility)
+	}
+
+	// Look for the node
+	snap, err := n.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	node, err := snap.NodeByID(args.NodeID)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("node not found")
+	}
+
+	// Only the servers set an expiry on the ineligibility
+	args.IneligibleUntil = 0
+
+	// Commit this update via Raft. An update matching the eligibility of the
+	// node is still committed if the servers marked the node ineligible for
+	// rejecting plans, so that the operator's decision sticks.
+	var index uint64
+	if node.Eligible() != (args.Eligibility == structs.NodeSchedulingEligible) || node.IneligibleUntil != 0 {
+		_, index, err = n.srv.raftApply(structs.NodeUpdateEligibilityRequestType, args)
+		if err != nil {
+			n.srv.logger.Printf("[ERR] nomad.client: eligibility update failed: %v", err)
+			return err
+		}
+		reply.NodeModifyIndex = index
+	}
+
+	// Create Node evaluations once the node is eligible as there may be a
+	// System job registered that should be placed on it.
+	if args.Eligibility == structs.NodeSchedulingEligible {
+		evalIDs, evalIndex, err := n.createNodeEvals(args.NodeID, index)
+		if err != nil {
+			n.srv.logger.Printf("[ERR] nomad.client: eval creation failed: %v", err)
+			return err
+		}
+		reply.EvalIDs = evalIDs
+		reply.EvalCreateIndex = evalIndex
+	}
+
+	// Set the reply index
+	reply.Index = index
+	return nil
+}
+
 // Evaluate is used to force a re-evaluation of the node
 func (n *Node) Evaluate(args *structs.NodeEvaluateRequest, reply *structs.NodeUpdateResponse) error {
 	if done, err := n.srv.forward("Node.Evaluate", args, args, reply); done {
@@ -433,6 +528,11 @@ func (n *Node) Evaluate(args *structs.NodeEvaluateRequest, reply *structs.NodeUp
 	}
 	defer metrics.MeasureSince([]string{"nomad", "client", "evaluate"}, time.Now())
 
+	// Check node write permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).AllowNodeWrite); err != nil {
+		return err
+	}
+
 	// Verify the arguments
 	if args.NodeID == "" {
 		return fmt.Errorf("missing node ID for evaluation")
@@ -480,6 +580,12 @@ func (n *Node) GetNode(args *structs.NodeSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "client", "get_node"}, time.Now())
 
+	// Check node read permissions. Clients read their own node using their
+	// node secret.
+	if err := n.srv.checkNodeSecretOrACL(args.AuthToken, (*acl.ACL).AllowNodeRead); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -532,6 +638,11 @@ func (n *Node) GetAllocs(args *structs.NodeSpecificRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "client", "get_allocs"}, time.Now())
 
+	// Check node read permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).AllowNodeRead); err != nil {
+		return err
+	}
+
 	// Verify the arguments
 	if args.NodeID == "" {
 		return fmt.Errorf("missing node ID")
@@ -635,10 +746,25 @@ func (n *Node) GetClientAllocs(args *structs.NodeSpecificRequest,
 			}
 
 			reply.Allocs = make(map[string]uint64)
+			reply.MigrateTokens = make(map[string]string)
 			// Setup the output
 			if len(allocs) != 0 {
 				for _, alloc := range allocs {
 					reply.Allocs[alloc.ID] = alloc.AllocModifyIndex
+
+					// Issue a migrate token when the previous allocation
+					// is on another node, so the data can be fetched from
+					// it with ACLs enabled
+					if n.srv.config.ACLEnabled && alloc.PreviousAllocation != "" {
+						token, err := n.migrateToken(snap, alloc)
+						if err != nil {
+							return err
+						}
+						if token != "" {
+							reply.MigrateTokens[alloc.ID] = token
+						}
+					}
+
 					reply.Index = maxUint64(reply.Index, alloc.ModifyIndex)
 				}
 			} else {
@@ -661,6 +787,28 @@ func (n *Node) GetClientAllocs(args *structs.NodeSpecificRequest,
 	return n.srv.blockingRPC(&opts)
 }
 
+// migrateToken returns the token the node of the allocation uses to migrate
+// the data of the previous allocation, or an empty string if the previous
+// allocation is not on another node.
+func (n *Node) migrateToken(snap *state.StateSnapshot, alloc *structs.Allocation) (string, error) {
+	prevAlloc, err := snap.AllocByID(alloc.PreviousAllocation)
+	if err != nil {
+		return "", err
+	}
+	if prevAlloc == nil || prevAlloc.NodeID == alloc.NodeID {
+		return "", nil
+	}
+
+	prevNode, err := snap.NodeByID(prevAlloc.NodeID)
+	if err != nil {
+		return "", err
+	}
+	if prevNode == nil {
+		return "", nil
+	}
+	return structs.GenerateMigrateToken(prevAlloc.ID, prevNode.SecretID)
+}
+
 // UpdateAlloc is used to update the client status of an allocation
 func (n *Node) UpdateAlloc(args *structs.AllocUpdateRequest, reply *structs.GenericResponse) error {
 	if done, err := n.srv.forward("Node.UpdateAlloc", args, args, reply); done {
@@ -713,6 +861,7 @@ func (n *Node) batchUpdate(future *batchFuture, updates []*structs.Allocation) {
 	// Prepare the batch update
 	batch := &structs.AllocUpdateRequest{
 		Alloc:        updates,
+		Evals:        n.failedAllocEvals(updates),
 		WriteRequest: structs.WriteRequest{Region: n.srv.config.Region},
 	}
 
@@ -754,6 +903,55 @@ func (n *Node) batchUpdate(future *batchFuture, updates []*structs.Allocation) {
 	future.Respond(index, mErr.ErrorOrNil())
 }
 
+// failedAllocEvals returns an evaluation for each job with allocations that
+// failed on the client so that they are rescheduled. Allocations of system
+// jobs are not rescheduled.
+func (n *Node) failedAllocEvals(updates []*structs.Allocation) []*structs.Evaluation {
+	state := n.srv.fsm.State()
+	var evals []*structs.Evaluation
+	jobs := make(map[string]struct{})
+	for _, update := range updates {
+		if update.ClientStatus != structs.AllocClientStatusFailed {
+			continue
+		}
+
+		// Only evaluate allocations that just failed
+		alloc, err := state.AllocByID(update.ID)
+		if err != nil {
+			n.srv.logger.Printf("[ERR] nomad.client: looking up alloc %q failed: %v", update.ID, err)
+			continue
+		}
+		if alloc == nil || alloc.DesiredStatus != structs.AllocDesiredStatusRun ||
+			alloc.ClientStatus == structs.AllocClientStatusFailed {
+			continue
+		}
+		if _, ok := jobs[alloc.JobID]; ok {
+			continue
+		}
+
+		job, err := state.JobByID(alloc.JobID)
+		if err != nil {
+			n.srv.logger.Printf("[ERR] nomad.client: looking up job %q failed: %v", alloc.JobID, err)
+			continue
+		}
+		if job == nil || job.Type == structs.JobTypeSystem {
+			continue
+		}
+
+		jobs[job.ID] = struct{}{}
+		evals = append(evals, &structs.Evaluation{
+			ID:             structs.GenerateUUID(),
+			Priority:       job.Priority,
+			Type:           job.Type,
+			TriggeredBy:    structs.EvalTriggerAllocFailure,
+			JobID:          job.ID,
+			JobModifyIndex: job.ModifyIndex,
+			Status:         structs.EvalStatusPending,
+		})
+	}
+	return evals
+}
+
 // List is used to list the available nodes
 func (n *Node) List(args *structs.NodeListRequest,
 	reply *structs.NodeListResponse) error {
@@ -762,6 +960,11 @@ func (n *Node) List(args *structs.NodeListRequest,
 	}
 	defer metrics.MeasureSince([]string{"nomad", "client", "list"}, time.Now())
 
+	// Check node read permissions
+	if err := n.srv.checkACL(args.AuthToken, (*acl.ACL).AllowNodeRead); err != nil {
+		return err
+	}
+
 	// Setup the blocking query
 	opts := blockingOptions{
 		queryOpts: &args.QueryOptions,
@@ -783,16 +986,28 @@ func (n *Node) List(args *structs.NodeListRequest,
 				return err
 			}
 
+			paginator, err := newPaginator(&args.QueryOptions)
+			if err != nil {
+				return err
+			}
+
 			var nodes []*structs.NodeListStub
-			for {
+			for !paginator.done() {
 				raw := iter.Next()
 				if raw == nil {
 					break
 				}
 				node := raw.(*structs.Node)
-				nodes = append(nodes, node.Stub())
+				stub := node.Stub()
+				if ok, err := paginator.accept(node.ID, stub); err != nil {
+					return err
+				} else if !ok {
+					continue
+				}
+				nodes = append(nodes, stub)
 			}
 			reply.Nodes = nodes
+			reply.NextToken = paginator.nextPageToken()
 
 			// Use the last index that affected the jobs table
 			index, err := snap.Index("nodes")
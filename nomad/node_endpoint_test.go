@@ -3,6 +3,7 @@ package nomad
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -636,6 +637,122 @@ func TestClientEndpoint_UpdateDrain(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_UpdateEligibility(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	node := mock.Node()
+	reg := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.NodeUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An invalid eligibility is rejected
+	update := &structs.NodeUpdateEligibilityRequest{
+		NodeID:       node.ID,
+		Eligibility:  "foo",
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp2 structs.NodeEligibilityUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateEligibility", update, &resp2); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// Mark the node ineligible
+	update.Eligibility = structs.NodeSchedulingIneligible
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateEligibility", update, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp2.Index == 0 {
+		t.Fatalf("bad index: %d", resp2.Index)
+	}
+
+	// Check for the node in the FSM
+	state := s1.fsm.State()
+	out, err := state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Eligible() || out.Drain {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Mark the node eligible again
+	update.Eligibility = structs.NodeSchedulingEligible
+	var resp3 structs.NodeEligibilityUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateEligibility", update, &resp3); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.Eligible() {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestClientEndpoint_UpdateDrain_Deadline(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the register request
+	node := mock.Node()
+	reg := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+
+	// Fetch the response
+	var resp structs.NodeUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A strategy can't be set when disabling drain
+	req := &structs.NodeUpdateDrainRequest{
+		NodeID:        node.ID,
+		DrainStrategy: &structs.DrainStrategy{Deadline: time.Hour},
+		WriteRequest:  structs.WriteRequest{Region: "global"},
+	}
+	var resp2 structs.NodeDrainUpdateResponse
+	err := msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", req, &resp2)
+	if err == nil || !strings.Contains(err.Error(), "only be set when enabling drain") {
+		t.Fatalf("expected error, got: %v", err)
+	}
+
+	// Drain with a deadline
+	start := time.Now()
+	req.Drain = true
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", req, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The deadline is computed from the time drain was enabled
+	out, err := s1.fsm.State().NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ok, deadline := out.DrainStrategy.DeadlineTime()
+	if !out.Drain || !ok {
+		t.Fatalf("bad: %#v", out)
+	}
+	if deadline.Before(start.Add(time.Hour)) || deadline.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("bad deadline: %v", deadline)
+	}
+}
+
 // This test ensures that Nomad marks client state of allocations which are in
 // pending/running state to lost when a node is marked as down.
 func TestClientEndpoint_Drain_Down(t *testing.T) {
@@ -802,6 +919,7 @@ func TestClientEndpoint_GetNode(t *testing.T) {
 	// Update the status updated at value
 	node.StatusUpdatedAt = resp2.Node.StatusUpdatedAt
 	node.SecretID = ""
+	node.SchedulingEligibility = structs.NodeSchedulingEligible
 	if !reflect.DeepEqual(node, resp2.Node) {
 		t.Fatalf("bad: %#v \n %#v", node, resp2.Node)
 	}
@@ -819,6 +937,54 @@ func TestClientEndpoint_GetNode(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_GetNode_ACL(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	node := mock.Node()
+	state := s1.fsm.State()
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the node without a token, expect failure
+	get := &structs.NodeSpecificRequest{
+		NodeID:       node.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleNodeResponse
+	err := msgpackrpc.CallWithCodec(codec, "Node.GetNode", get, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// The node may look itself up using its secret
+	get.AuthToken = node.SecretID
+	if err := msgpackrpc.CallWithCodec(codec, "Node.GetNode", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Node == nil || resp.Node.ID != node.ID {
+		t.Fatalf("bad: %#v", resp.Node)
+	}
+
+	// A token with node read access is allowed
+	token := createTestToken(t, s1, 1001, `node { policy = "read" }`)
+	get.AuthToken = token.SecretID
+	var resp2 structs.SingleNodeResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.GetNode", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// So is the management token
+	get.AuthToken = root.SecretID
+	var resp3 structs.SingleNodeResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.GetNode", get, &resp3); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestClientEndpoint_GetNode_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -1296,6 +1462,68 @@ func TestClientEndpoint_UpdateAlloc(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_UpdateAlloc_Reschedule(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a job with a running allocation
+	node := mock.Node()
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	state := s1.fsm.State()
+	if err := state.UpsertNode(98, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertJob(99, alloc.Job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(100, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Report the allocation as failed
+	clientAlloc := alloc.Copy()
+	clientAlloc.ClientStatus = structs.AllocClientStatusFailed
+	update := &structs.AllocUpdateRequest{
+		Alloc:        []*structs.Allocation{clientAlloc},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.NodeAllocsResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateAlloc", update, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// An evaluation is created to reschedule the allocation
+	evals, err := state.EvalsByJob(alloc.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 1 {
+		t.Fatalf("bad: %#v", evals)
+	}
+	eval := evals[0]
+	if eval.TriggeredBy != structs.EvalTriggerAllocFailure || eval.Type != alloc.Job.Type ||
+		eval.Status != structs.EvalStatusPending {
+		t.Fatalf("bad: %#v", eval)
+	}
+
+	// Reporting the failure again does not create another evaluation
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateAlloc", update, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	evals, err = state.EvalsByJob(alloc.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 1 {
+		t.Fatalf("bad: %#v", evals)
+	}
+}
+
 func TestClientEndpoint_BatchUpdate(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -1651,6 +1879,65 @@ func TestClientEndpoint_ListNodes(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_ListNodes_Paginated(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create nodes of two classes
+	state := s1.fsm.State()
+	var gpu []string
+	for i := 0; i < 5; i++ {
+		node := mock.Node()
+		if i%2 == 0 {
+			node.NodeClass = "gpu"
+			gpu = append(gpu, node.ID)
+		}
+		if err := state.UpsertNode(uint64(1000+i), node); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	sort.Strings(gpu)
+
+	// Page through the gpu nodes
+	var ids []string
+	get := &structs.NodeListRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:  "global",
+			Filter:  `NodeClass == "gpu"`,
+			PerPage: 2,
+		},
+	}
+	for {
+		var resp structs.NodeListResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Node.List", get, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(resp.Nodes) > 2 {
+			t.Fatalf("bad: %#v", resp.Nodes)
+		}
+		for _, node := range resp.Nodes {
+			ids = append(ids, node.ID)
+		}
+		if resp.NextToken == "" {
+			break
+		}
+		get.NextToken = resp.NextToken
+	}
+	if !reflect.DeepEqual(ids, gpu) {
+		t.Fatalf("bad: %v, expected %v", ids, gpu)
+	}
+
+	// Invalid filters are rejected
+	get.Filter = `Foo == "bar"`
+	var resp structs.NodeListResponse
+	err := msgpackrpc.CallWithCodec(codec, "Node.List", get, &resp)
+	if err == nil || !strings.HasPrefix(err.Error(), structs.ErrInvalidFilterPrefix) {
+		t.Fatalf("expected invalid filter error, got: %v", err)
+	}
+}
+
 func TestClientEndpoint_ListNodes_Blocking(t *testing.T) {
 	s1 := testServer(t, nil)
 	defer s1.Shutdown()
@@ -1692,7 +1979,7 @@ func TestClientEndpoint_ListNodes_Blocking(t *testing.T) {
 
 	// Node drain updates trigger watches.
 	time.AfterFunc(100*time.Millisecond, func() {
-		if err := state.UpdateNodeDrain(3, node.ID, true); err != nil {
+		if err := state.UpdateNodeDrain(3, node.ID, true, nil); err != nil {
 			t.Fatalf("err: %v", err)
 		}
 	})
@@ -0,0 +1,263 @@
+package nomad
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Operator endpoint is used to inspect the internals of the servers
+type Operator struct {
+	srv *Server
+}
+
+// BrokerStats is used to return the stats of the evaluation broker
+func (o *Operator) BrokerStats(args *structs.GenericRequest, reply *structs.BrokerStatsResponse) error {
+	// The broker only runs on the leader so the request is always forwarded
+	// to it
+	args.AllowStale = false
+	if done, err := o.srv.forward("Operator.BrokerStats", args, args, reply); done {
+		return err
+	}
+
+	// Check operator read permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorRead); err != nil {
+		return err
+	}
+
+	reply.Stats = o.srv.evalBroker.Stats()
+	o.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// AutopilotGetConfiguration is used to retrieve the current Autopilot
+// configuration
+func (o *Operator) AutopilotGetConfiguration(args *structs.GenericRequest, reply *structs.AutopilotConfigResponse) error {
+	if done, err := o.srv.forward("Operator.AutopilotGetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// Check operator read permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorRead); err != nil {
+		return err
+	}
+
+	index, config, err := o.srv.fsm.State().AutopilotConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return fmt.Errorf("autopilot config not initialized yet")
+	}
+
+	reply.Config = config
+	reply.Index = index
+	o.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// AutopilotSetConfiguration is used to set the current Autopilot
+// configuration
+func (o *Operator) AutopilotSetConfiguration(args *structs.AutopilotSetConfigRequest, reply *structs.AutopilotSetConfigResponse) error {
+	if done, err := o.srv.forward("Operator.AutopilotSetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// Check operator write permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorWrite); err != nil {
+		return err
+	}
+
+	// Apply the update
+	resp, index, err := o.srv.raftApply(structs.AutopilotRequestType, args)
+	if err != nil {
+		o.srv.logger.Printf("[ERR] nomad.operator: Apply failed: %v", err)
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	// Check if the return type is a bool, which means a check-and-set
+	// update did not go through
+	reply.Updated = true
+	if updated, ok := resp.(bool); ok {
+		reply.Updated = updated
+	}
+	reply.Index = index
+	return nil
+}
+
+// SchedulerGetConfiguration is used to retrieve the current scheduler
+// configuration
+func (o *Operator) SchedulerGetConfiguration(args *structs.GenericRequest, reply *structs.SchedulerConfigResponse) error {
+	if done, err := o.srv.forward("Operator.SchedulerGetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// Check operator read permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorRead); err != nil {
+		return err
+	}
+
+	index, config, err := o.srv.fsm.State().SchedulerConfig()
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return fmt.Errorf("scheduler config not initialized yet")
+	}
+
+	reply.Config = config
+	reply.Index = index
+	o.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// SchedulerSetConfiguration is used to set the current scheduler
+// configuration
+func (o *Operator) SchedulerSetConfiguration(args *structs.SchedulerSetConfigRequest, reply *structs.SchedulerSetConfigResponse) error {
+	if done, err := o.srv.forward("Operator.SchedulerSetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// Check operator write permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorWrite); err != nil {
+		return err
+	}
+
+	// Validate the configuration
+	if err := args.Config.Validate(); err != nil {
+		return err
+	}
+
+	// Apply the update
+	resp, index, err := o.srv.raftApply(structs.SchedulerConfigRequestType, args)
+	if err != nil {
+		o.srv.logger.Printf("[ERR] nomad.operator: Apply failed: %v", err)
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	// Check if the return type is a bool, which means a check-and-set
+	// update did not go through
+	reply.Updated = true
+	if updated, ok := resp.(bool); ok {
+		reply.Updated = updated
+	}
+	reply.Index = index
+	return nil
+}
+
+// ServerHealth is used to get the current health of the servers as tracked
+// by the leader
+func (o *Operator) ServerHealth(args *structs.GenericRequest, reply *structs.ServerHealthResponse) error {
+	// The health is only tracked by the leader so the request is always
+	// forwarded to it
+	args.AllowStale = false
+	if done, err := o.srv.forward("Operator.ServerHealth", args, args, reply); done {
+		return err
+	}
+
+	// Check operator read permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorRead); err != nil {
+		return err
+	}
+
+	o.srv.clusterHealthLock.RLock()
+	health := o.srv.clusterHealth
+	o.srv.clusterHealthLock.RUnlock()
+
+	health.Servers = append([]structs.ServerHealth(nil), health.Servers...)
+	reply.Health = &health
+	o.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// RaftGetConfiguration is used to retrieve the current Raft peer set
+func (o *Operator) RaftGetConfiguration(args *structs.GenericRequest, reply *structs.RaftConfigurationResponse) error {
+	if done, err := o.srv.forward("Operator.RaftGetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// Check operator read permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorRead); err != nil {
+		return err
+	}
+
+	peers, err := o.srv.raftPeers.Peers()
+	if err != nil {
+		return err
+	}
+
+	// Index the servers known by Serf by address so the peers can be named
+	names := make(map[string]string)
+	for _, member := range o.srv.serf.Members() {
+		valid, parts := isNomadServer(member)
+		if !valid || parts.Region != o.srv.config.Region {
+			continue
+		}
+		names[parts.Addr.String()] = member.Name
+	}
+
+	leader := o.srv.raft.Leader()
+	reply.Servers = make([]*structs.RaftServer, 0, len(peers))
+	for _, peer := range peers {
+		node, ok := names[peer]
+		if !ok {
+			node = "(unknown)"
+		}
+		reply.Servers = append(reply.Servers, &structs.RaftServer{
+			Node:    node,
+			Address: peer,
+			Leader:  peer == leader,
+			Voter:   true,
+		})
+	}
+
+	reply.Index = o.srv.raft.LastIndex()
+	o.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// RaftRemovePeerByAddress is used to forcibly remove a stale peer from the
+// Raft peer set. This is used to recover from servers that failed without
+// leaving the cluster and could not be removed through Serf.
+func (o *Operator) RaftRemovePeerByAddress(args *structs.RaftPeerByAddressRequest, reply *struct{}) error {
+	if done, err := o.srv.forward("Operator.RaftRemovePeerByAddress", args, args, reply); done {
+		return err
+	}
+
+	// Check operator write permissions
+	if err := o.srv.checkACL(args.AuthToken, (*acl.ACL).AllowOperatorWrite); err != nil {
+		return err
+	}
+
+	// Only remove peers that are part of the configuration, removing an
+	// unknown peer would otherwise be a silent no-op
+	peers, err := o.srv.raftPeers.Peers()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, peer := range peers {
+		if peer == args.Address {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("address %q was not found in the Raft configuration", args.Address)
+	}
+
+	if err := o.srv.raft.RemovePeer(args.Address).Error(); err != nil {
+		o.srv.logger.Printf("[WARN] nomad.operator: failed to remove Raft peer %q: %v", args.Address, err)
+		return err
+	}
+
+	o.srv.logger.Printf("[WARN] nomad.operator: removed Raft peer %q", args.Address)
+	return nil
+}
@@ -0,0 +1,388 @@
+package nomad
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestOperatorEndpoint_BrokerStats(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	testutil.WaitForResult(func() (bool, error) {
+		return s1.evalBroker.Enabled(), nil
+	}, func(err error) {
+		t.Fatalf("should enable eval broker")
+	})
+
+	// Enqueue evaluations for both schedulers
+	eval := mock.Eval()
+	s1.evalBroker.Enqueue(eval)
+	eval2 := mock.Eval()
+	eval2.Type = structs.JobTypeBatch
+	s1.evalBroker.Enqueue(eval2)
+
+	// Dequeue one of them
+	if _, _, err := s1.evalBroker.Dequeue([]string{structs.JobTypeBatch}, 0); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Query the stats
+	req := &structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.BrokerStatsResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.BrokerStats", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	stats := resp.Stats
+	if stats.TotalReady != 1 || stats.TotalUnacked != 1 {
+		t.Fatalf("bad: %#v", stats)
+	}
+	if s := stats.ByScheduler[structs.JobTypeService]; s == nil || s.Ready != 1 {
+		t.Fatalf("bad: %#v", stats.ByScheduler)
+	}
+	if s := stats.ByScheduler[structs.JobTypeBatch]; s == nil || s.Unacked != 1 {
+		t.Fatalf("bad: %#v", stats.ByScheduler)
+	}
+}
+
+func TestOperatorEndpoint_AutopilotConfiguration(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.AutopilotConfig.MaxTrailingLogs = 100
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// The leader stores the configuration of the agent
+	get := &structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.AutopilotConfigResponse
+	testutil.WaitForResult(func() (bool, error) {
+		err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotGetConfiguration", get, &resp)
+		return err == nil, err
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	if resp.Config.MaxTrailingLogs != 100 || !resp.Config.CleanupDeadServers {
+		t.Fatalf("bad: %#v", resp.Config)
+	}
+
+	// Update it
+	set := &structs.AutopilotSetConfigRequest{
+		Config: structs.AutopilotConfig{
+			CleanupDeadServers: false,
+			MaxTrailingLogs:    200,
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var setResp structs.AutopilotSetConfigResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", set, &setResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !setResp.Updated || setResp.Index == 0 {
+		t.Fatalf("bad: %#v", setResp)
+	}
+
+	_, config, err := s1.fsm.State().AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.CleanupDeadServers || config.MaxTrailingLogs != 200 {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	// A check-and-set update with a stale index is rejected
+	set.CAS = true
+	set.Config.ModifyIndex = config.ModifyIndex - 1
+	set.Config.MaxTrailingLogs = 300
+	var setResp2 structs.AutopilotSetConfigResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", set, &setResp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if setResp2.Updated {
+		t.Fatalf("bad: %#v", setResp2)
+	}
+
+	// And accepted with the current one
+	set.Config.ModifyIndex = config.ModifyIndex
+	var setResp3 structs.AutopilotSetConfigResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", set, &setResp3); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !setResp3.Updated {
+		t.Fatalf("bad: %#v", setResp3)
+	}
+	_, config, err = s1.fsm.State().AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.MaxTrailingLogs != 300 {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestOperatorEndpoint_SchedulerConfiguration(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// The leader stores the default configuration
+	get := &structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SchedulerConfigResponse
+	testutil.WaitForResult(func() (bool, error) {
+		err := msgpackrpc.CallWithCodec(codec, "Operator.SchedulerGetConfiguration", get, &resp)
+		return err == nil, err
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	if resp.Config.SchedulerAlgorithm != structs.SchedulerAlgorithmBinpack {
+		t.Fatalf("bad: %#v", resp.Config)
+	}
+
+	// Invalid algorithms are rejected
+	set := &structs.SchedulerSetConfigRequest{
+		Config: structs.SchedulerConfig{
+			SchedulerAlgorithm: "foo",
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var setResp structs.SchedulerSetConfigResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", set, &setResp)
+	if err == nil || !strings.Contains(err.Error(), "invalid scheduler algorithm") {
+		t.Fatalf("expected error, got: %v", err)
+	}
+
+	// Update it
+	set.Config.SchedulerAlgorithm = structs.SchedulerAlgorithmSpread
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.SchedulerSetConfiguration", set, &setResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !setResp.Updated || setResp.Index == 0 {
+		t.Fatalf("bad: %#v", setResp)
+	}
+
+	_, config, err := s1.fsm.State().SchedulerConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.SchedulerAlgorithm != structs.SchedulerAlgorithmSpread {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestOperatorEndpoint_AutopilotConfiguration_ACL(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Anonymous requests are denied
+	set := &structs.AutopilotSetConfigRequest{
+		Config:       structs.AutopilotConfig{MaxTrailingLogs: 200},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.AutopilotSetConfigResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", set, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// So are read-only tokens
+	token := createTestToken(t, s1, 1001, `operator { policy = "read" }`)
+	set.AuthToken = token.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", set, &resp)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// But they can read the configuration
+	get := &structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{Region: "global", AuthToken: token.SecretID},
+	}
+	var getResp structs.AutopilotConfigResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotGetConfiguration", get, &getResp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Management tokens can update it
+	set.AuthToken = root.SecretID
+	var resp2 structs.AutopilotSetConfigResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.AutopilotSetConfiguration", set, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestOperatorEndpoint_ServerHealth(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	codec := rpcClient(t, s2)
+	testJoin(t, s1, s2)
+	waitForPeers(t, 2, s1, s2)
+
+	// The request is served by the leader through either server
+	req := &structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	testutil.WaitForResult(func() (bool, error) {
+		var resp structs.ServerHealthResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Operator.ServerHealth", req, &resp); err != nil {
+			return false, err
+		}
+		health := resp.Health
+		if !health.Healthy || len(health.Servers) != 2 || health.FailureTolerance != 0 {
+			return false, fmt.Errorf("bad: %#v", health)
+		}
+		var leaders int
+		for _, h := range health.Servers {
+			if !h.Voter || h.SerfStatus != "alive" {
+				return false, fmt.Errorf("bad: %#v", h)
+			}
+			if h.Leader {
+				leaders++
+			}
+		}
+		if leaders != 1 {
+			return false, fmt.Errorf("bad: %#v", health.Servers)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestOperatorEndpoint_RaftGetConfiguration(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	arg := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var reply structs.RaftConfigurationResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftGetConfiguration", &arg, &reply); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if len(reply.Servers) != 1 {
+		t.Fatalf("bad: %#v", reply.Servers)
+	}
+	expected := &structs.RaftServer{
+		Node:    s1.serf.LocalMember().Name,
+		Address: s1.config.RPCAddr.String(),
+		Leader:  true,
+		Voter:   true,
+	}
+	if !reflect.DeepEqual(reply.Servers[0], expected) {
+		t.Fatalf("bad: got %#v; want %#v", reply.Servers[0], expected)
+	}
+	if reply.Index == 0 {
+		t.Fatalf("bad index: %d", reply.Index)
+	}
+}
+
+func TestOperatorEndpoint_RaftRemovePeerByAddress(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.AutopilotConfig.CleanupDeadServers = false
+	})
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	s3 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s3.Shutdown()
+	testJoin(t, s1, s2, s3)
+	waitForPeers(t, 3, s1, s2, s3)
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Removing an unknown peer fails
+	arg := structs.RaftPeerByAddressRequest{
+		Address:      "127.0.0.1:1",
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var reply struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftRemovePeerByAddress", &arg, &reply)
+	if err == nil || !strings.Contains(err.Error(), "not found in the Raft configuration") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Kill a follower and remove it by hand
+	addr := s3.config.RPCAddr.String()
+	s3.Shutdown()
+	arg.Address = addr
+	var reply2 struct{}
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftRemovePeerByAddress", &arg, &reply2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	waitForPeers(t, 2, s1, s2)
+}
+
+func TestOperatorEndpoint_Raft_ACL(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Anonymous requests are denied
+	get := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var reply structs.RaftConfigurationResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.RaftGetConfiguration", &get, &reply)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// Read tokens can list the peers but not remove them
+	token := createTestToken(t, s1, 1001, `operator { policy = "read" }`)
+	get.AuthToken = token.SecretID
+	var reply2 structs.RaftConfigurationResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftGetConfiguration", &get, &reply2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	remove := structs.RaftPeerByAddressRequest{
+		Address: s1.config.RPCAddr.String(),
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: token.SecretID,
+		},
+	}
+	var reply3 struct{}
+	err = msgpackrpc.CallWithCodec(codec, "Operator.RaftRemovePeerByAddress", &remove, &reply3)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// Management tokens can list them
+	get.AuthToken = root.SecretID
+	var reply4 structs.RaftConfigurationResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Operator.RaftGetConfiguration", &get, &reply4); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package nomad
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/helper/filter"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// paginator pages through the objects of a list query in the order of their
+// IDs, skipping the objects before the next token of the query and those not
+// matching its filter.
+type paginator struct {
+	filter    *filter.Filter
+	perPage   int32
+	nextToken string
+
+	// count is the number of objects accepted so far
+	count int32
+
+	// next is the token of the first object of the next page
+	next string
+}
+
+// newPaginator returns a paginator of the list query with the given options
+func newPaginator(opts *structs.QueryOptions) (*paginator, error) {
+	p := &paginator{
+		perPage:   opts.PerPage,
+		nextToken: strings.ToLower(opts.NextToken),
+	}
+	if opts.PerPage < 0 {
+		return nil, fmt.Errorf("invalid number of objects per page: %d", opts.PerPage)
+	}
+	if opts.Filter != "" {
+		f, err := filter.Parse(opts.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", structs.ErrInvalidFilterPrefix, err)
+		}
+		p.filter = f
+	}
+	return p, nil
+}
+
+// accept returns whether the object with the given ID belongs to the page.
+// Objects must be passed in the order of their IDs until done returns true.
+func (p *paginator) accept(id string, obj interface{}) (bool, error) {
+	if p.nextToken != "" && strings.ToLower(id) < p.nextToken {
+		return false, nil
+	}
+	if p.filter != nil {
+		match, err := p.filter.Match(obj)
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", structs.ErrInvalidFilterPrefix, err)
+		}
+		if !match {
+			return false, nil
+		}
+	}
+	if p.perPage > 0 && p.count == p.perPage {
+		p.next = id
+		return false, nil
+	}
+	p.count++
+	return true, nil
+}
+
+// done returns whether the page is full and another object is left
+func (p *paginator) done() bool {
+	return p.next != ""
+}
+
+// nextPageToken returns the token of the next page, or an empty string if this
+// is the last page
+func (p *paginator) nextPageToken() string {
+	return p.next
+}
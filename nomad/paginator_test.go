@@ -0,0 +1,90 @@
+package nomad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+type testPaginatorObject struct {
+	ID     string
+	Status string
+}
+
+// testPaginate returns the IDs of the objects in the page and the token of
+// the next page
+func testPaginate(t *testing.T, opts *structs.QueryOptions, objs []*testPaginatorObject) ([]string, string) {
+	p, err := newPaginator(opts)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var ids []string
+	for _, obj := range objs {
+		if p.done() {
+			break
+		}
+		ok, err := p.accept(obj.ID, obj)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if ok {
+			ids = append(ids, obj.ID)
+		}
+	}
+	return ids, p.nextPageToken()
+}
+
+func TestPaginator(t *testing.T) {
+	objs := []*testPaginatorObject{
+		{ID: "a", Status: "running"},
+		{ID: "B", Status: "pending"},
+		{ID: "c", Status: "running"},
+		{ID: "d", Status: "running"},
+		{ID: "e", Status: "pending"},
+	}
+
+	cases := []struct {
+		opts      structs.QueryOptions
+		ids       []string
+		nextToken string
+	}{
+		{structs.QueryOptions{}, []string{"a", "B", "c", "d", "e"}, ""},
+		{structs.QueryOptions{PerPage: 2}, []string{"a", "B"}, "c"},
+		{structs.QueryOptions{PerPage: 2, NextToken: "c"}, []string{"c", "d"}, "e"},
+		{structs.QueryOptions{PerPage: 2, NextToken: "b"}, []string{"B", "c"}, "d"},
+		{structs.QueryOptions{PerPage: 2, NextToken: "e"}, []string{"e"}, ""},
+		{structs.QueryOptions{PerPage: 5}, []string{"a", "B", "c", "d", "e"}, ""},
+		{structs.QueryOptions{Filter: `Status == "running"`, PerPage: 2}, []string{"a", "c"}, "d"},
+		{structs.QueryOptions{Filter: `Status == "pending"`, PerPage: 1, NextToken: "c"}, []string{"e"}, ""},
+	}
+
+	for _, c := range cases {
+		opts := c.opts
+		ids, nextToken := testPaginate(t, &opts, objs)
+		if !reflect.DeepEqual(ids, c.ids) || nextToken != c.nextToken {
+			t.Fatalf("bad: %#v: got %v %q, expected %v %q", c.opts, ids, nextToken, c.ids, c.nextToken)
+		}
+	}
+}
+
+func TestPaginator_InvalidFilter(t *testing.T) {
+	if _, err := newPaginator(&structs.QueryOptions{Filter: "Status =="}); err == nil ||
+		!strings.HasPrefix(err.Error(), structs.ErrInvalidFilterPrefix) {
+		t.Fatalf("expected invalid filter error, got: %v", err)
+	}
+	if _, err := newPaginator(&structs.QueryOptions{PerPage: -1}); err == nil {
+		t.Fatalf("expected invalid per page error")
+	}
+
+	p, err := newPaginator(&structs.QueryOptions{Filter: `Foo == "bar"`})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := p.accept("a", &testPaginatorObject{ID: "a"}); err == nil ||
+		!strings.HasPrefix(err.Error(), structs.ErrInvalidFilterPrefix) {
+		t.Fatalf("expected invalid filter error, got: %v", err)
+	}
+}
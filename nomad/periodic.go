@@ -194,7 +194,9 @@ func (p *PeriodicDispatch) Add(job *structs.Job) error {
 	}
 
 	// If we were tracking a job and it has been disabled or made non-periodic remove it.
-	disabled := !job.IsPeriodic() || !job.Periodic.Enabled
+	// Parameterized jobs are never launched themselves, only the jobs
+	// dispatched from them are.
+	disabled := !job.IsPeriodic() || !job.Periodic.Enabled || job.IsParameterized()
 	_, tracked := p.tracked[job.ID]
 	if disabled {
 		if tracked {
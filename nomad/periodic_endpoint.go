@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -38,6 +39,11 @@ func (p *Periodic) Force(args *structs.PeriodicForceRequest, reply *structs.Peri
 		return fmt.Errorf("job not found")
 	}
 
+	// Check the token may submit jobs to the job's namespace
+	if err := p.srv.checkNamespaceOperation(args.AuthToken, job.Namespace, acl.NamespaceCapabilitySubmitJob); err != nil {
+		return err
+	}
+
 	if !job.IsPeriodic() {
 		return fmt.Errorf("can't force launch non-periodic job")
 	}
@@ -9,6 +9,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
 	"github.com/hashicorp/raft"
 )
 
@@ -52,6 +53,10 @@ func (s *Server) planApply() {
 	pool := NewEvaluatePool(poolSize, workerPoolBufferSize)
 	defer pool.Shutdown()
 
+	// Track the nodes rejecting plans to mark the ones that keep on doing so
+	// ineligible for scheduling
+	badNodes := NewBadNodeTracker(s.config.PlanRejectionThreshold, s.config.PlanRejectionWindow)
+
 	for {
 		// Pull the next pending plan, exit if we are no longer leader
 		pending, err := s.planQueue.Dequeue(0)
@@ -86,6 +91,14 @@ func (s *Server) planApply() {
 			continue
 		}
 
+		// Mark the nodes that crossed the rejection threshold ineligible
+		now := time.Now()
+		for _, nodeID := range result.RejectedNodes {
+			if badNodes.Add(nodeID, now) {
+				go s.markNodeIneligible(nodeID)
+			}
+		}
+
 		// Fast-path the response if there is nothing to do
 		if result.IsNoOp() {
 			pending.respond(result, nil)
@@ -118,17 +131,132 @@ func (s *Server) planApply() {
 	}
 }
 
+// rejectingNodesWatchInterval is the longest time the leader waits before
+// checking whether the nodes marked ineligible for rejecting plans can be made
+// eligible again
+const rejectingNodesWatchInterval = time.Minute
+
+// markNodeIneligible is used to mark a node that repeatedly rejected plans
+// ineligible for scheduling. The expiry of the ineligibility is recorded on
+// the node so that any leader makes it eligible again once the rejection
+// cooldown has passed.
+func (s *Server) markNodeIneligible(nodeID string) {
+	s.logger.Printf("[WARN] nomad: node %q rejected %d plans within %v, marking it ineligible for %v",
+		nodeID, s.config.PlanRejectionThreshold, s.config.PlanRejectionWindow, s.config.PlanRejectionCooldown)
+	until := time.Now().Add(s.config.PlanRejectionCooldown).UnixNano()
+	if _, err := s.updateNodeEligibility(nodeID, structs.NodeSchedulingIneligible, until); err != nil {
+		s.logger.Printf("[ERR] nomad: failed to mark node %q ineligible: %v", nodeID, err)
+		return
+	}
+	metrics.IncrCounter([]string{"nomad", "plan", "node_rejected"}, 1)
+}
+
+// watchRejectingNodes is a long lived goroutine run by the leader that makes
+// the nodes marked ineligible for rejecting plans eligible again once their
+// ineligibility expired.
+func (s *Server) watchRejectingNodes(stopCh chan struct{}) {
+	notifyCh := make(chan struct{}, 1)
+	items := watch.NewItems(watch.Item{Table: "nodes"})
+
+	for {
+		// The state store is replaced on a snapshot restore so the watch is
+		// setup again on every iteration.
+		state := s.fsm.State()
+		state.Watch(items, notifyCh)
+		wait := s.checkRejectingNodes()
+
+		select {
+		case <-stopCh:
+			state.StopWatch(items, notifyCh)
+			return
+		case <-notifyCh:
+		case <-time.After(wait):
+		}
+		state.StopWatch(items, notifyCh)
+	}
+}
+
+// checkRejectingNodes makes the nodes whose plan rejection ineligibility
+// expired eligible again and returns how long to wait before checking again.
+// Nodes made ineligible by an operator are left alone.
+func (s *Server) checkRejectingNodes() time.Duration {
+	wait := rejectingNodesWatchInterval
+
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to snapshot state: %v", err)
+		return wait
+	}
+	iter, err := snap.Nodes()
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to get nodes: %v", err)
+		return wait
+	}
+
+	now := time.Now()
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		node := raw.(*structs.Node)
+		if node.Eligible() || node.IneligibleUntil == 0 {
+			continue
+		}
+
+		// Wake up in time for the expiry
+		if until := time.Unix(0, node.IneligibleUntil).Sub(now); until > 0 {
+			if until < wait {
+				wait = until
+			}
+			continue
+		}
+
+		index, err := s.updateNodeEligibility(node.ID, structs.NodeSchedulingEligible, 0)
+		if err != nil {
+			s.logger.Printf("[ERR] nomad: failed to mark node %q eligible: %v", node.ID, err)
+			continue
+		}
+
+		// Create the node evaluations as there may be system jobs to place
+		// on the node again
+		if _, _, err := s.endpoints.Node.createNodeEvals(node.ID, index); err != nil {
+			s.logger.Printf("[ERR] nomad: failed to create evals for node %q: %v", node.ID, err)
+		}
+		s.logger.Printf("[INFO] nomad: node %q is eligible for scheduling again", node.ID)
+	}
+	return wait
+}
+
+// updateNodeEligibility is used to update the scheduling eligibility of a
+// node through Raft and returns the index of the update
+func (s *Server) updateNodeEligibility(nodeID, eligibility string, ineligibleUntil int64) (uint64, error) {
+	req := structs.NodeUpdateEligibilityRequest{
+		NodeID:          nodeID,
+		Eligibility:     eligibility,
+		IneligibleUntil: ineligibleUntil,
+		WriteRequest:    structs.WriteRequest{Region: s.config.Region},
+	}
+	resp, index, err := s.raftApply(structs.NodeUpdateEligibilityRequestType, &req)
+	if err != nil {
+		return 0, err
+	}
+	if err, ok := resp.(error); ok && err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
 // applyPlan is used to apply the plan result and to return the alloc index
 func (s *Server) applyPlan(job *structs.Job, result *structs.PlanResult, snap *state.StateSnapshot) (raft.ApplyFuture, error) {
 	// Determine the miniumum number of updates, could be more if there
 	// are multiple updates per node
 	minUpdates := len(result.NodeUpdate)
 	minUpdates += len(result.NodeAllocation)
+	minUpdates += len(result.NodePreemptions)
 
 	// Setup the update request
 	req := structs.AllocUpdateRequest{
-		Job:   job,
-		Alloc: make([]*structs.Allocation, 0, minUpdates),
+		Job:               job,
+		Alloc:             make([]*structs.Allocation, 0, minUpdates),
+		Deployment:        result.Deployment,
+		DeploymentUpdates: result.DeploymentUpdates,
 	}
 	for _, updateList := range result.NodeUpdate {
 		req.Alloc = append(req.Alloc, updateList...)
@@ -137,6 +265,27 @@ func (s *Server) applyPlan(job *structs.Job, result *structs.PlanResult, snap *s
 		req.Alloc = append(req.Alloc, allocList...)
 	}
 
+	// Evict the preempted allocations and create an evaluation of each of
+	// their jobs so that they are rescheduled.
+	preemptedJobs := make(map[string]struct{})
+	for _, preemptedList := range result.NodePreemptions {
+		for _, preempted := range preemptedList {
+			req.Alloc = append(req.Alloc, preempted)
+			if _, ok := preemptedJobs[preempted.JobID]; ok {
+				continue
+			}
+			preemptedJobs[preempted.JobID] = struct{}{}
+
+			eval, err := s.preemptionEval(preempted.JobID)
+			if err != nil {
+				return nil, err
+			}
+			if eval != nil {
+				req.Evals = append(req.Evals, eval)
+			}
+		}
+	}
+
 	// Set the time the alloc was applied for the first time. This can be used
 	// to approximate the scheduling time.
 	now := time.Now().UTC().UnixNano()
@@ -155,6 +304,11 @@ func (s *Server) applyPlan(job *structs.Job, result *structs.PlanResult, snap *s
 	// Optimistically apply to our state view
 	if snap != nil {
 		nextIdx := s.raft.AppliedIndex() + 1
+		if req.Deployment != nil || len(req.DeploymentUpdates) != 0 {
+			if err := snap.UpsertDeployment(nextIdx, req.Deployment.Copy(), req.DeploymentUpdates); err != nil {
+				return future, err
+			}
+		}
 		if err := snap.UpsertAllocs(nextIdx, req.Alloc); err != nil {
 			return future, err
 		}
@@ -162,6 +316,27 @@ func (s *Server) applyPlan(job *structs.Job, result *structs.PlanResult, snap *s
 	return future, nil
 }
 
+// preemptionEval returns an evaluation of the job of a preempted allocation
+// or nil if the job no longer exists.
+func (s *Server) preemptionEval(jobID string) (*structs.Evaluation, error) {
+	job, err := s.fsm.State().JobByID(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preempted job '%s': %v", jobID, err)
+	}
+	if job == nil {
+		return nil, nil
+	}
+	return &structs.Evaluation{
+		ID:             structs.GenerateUUID(),
+		Priority:       job.Priority,
+		Type:           job.Type,
+		TriggeredBy:    structs.EvalTriggerPreemption,
+		JobID:          job.ID,
+		JobModifyIndex: job.ModifyIndex,
+		Status:         structs.EvalStatusPending,
+	}, nil
+}
+
 // asyncPlanWait is used to apply and respond to a plan async
 func (s *Server) asyncPlanWait(waitCh chan struct{}, future raft.ApplyFuture,
 	result *structs.PlanResult, pending *pendingPlan) {
@@ -196,8 +371,32 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 
 	// Create a result holder for the plan
 	result := &structs.PlanResult{
-		NodeUpdate:     make(map[string][]*structs.Allocation),
-		NodeAllocation: make(map[string][]*structs.Allocation),
+		NodeUpdate:        make(map[string][]*structs.Allocation),
+		NodeAllocation:    make(map[string][]*structs.Allocation),
+		NodePreemptions:   make(map[string][]*structs.Allocation),
+		Deployment:        plan.Deployment.Copy(),
+		DeploymentUpdates: plan.DeploymentUpdates,
+	}
+
+	// Reject the placements of the plan if they exceed the quota attached to
+	// the namespace of the job. The scheduler refreshes its state and fails
+	// the placements itself.
+	partialCommit := false
+	quotaFit, err := evaluatePlanQuota(snap, plan)
+	if err != nil {
+		return nil, err
+	}
+	if !quotaFit {
+		partialCommit = true
+		stripped := *plan
+		stripped.NodeAllocation = nil
+		stripped.NodePreemptions = nil
+		if plan.AllAtOnce {
+			stripped.NodeUpdate = nil
+			result.Deployment = nil
+			result.DeploymentUpdates = nil
+		}
+		plan = &stripped
 	}
 
 	// Collect all the nodeIDs
@@ -219,7 +418,6 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 	// Setup a multierror to handle potentially getting many
 	// errors since we are processing in parallel.
 	var mErr multierror.Error
-	partialCommit := false
 
 	// handleResult is used to process the result of evaluateNodePlan
 	handleResult := func(nodeID string, fit bool, err error) (cancel bool) {
@@ -232,11 +430,21 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 			// Set that this is a partial commit
 			partialCommit = true
 
+			// Track the node so that nodes repeatedly rejecting plans can be
+			// detected. Nodes that are not ready or already ineligible are
+			// expected to reject plans and are left out.
+			if node, err := snap.NodeByID(nodeID); err == nil && node != nil && node.Ready() {
+				result.RejectedNodes = append(result.RejectedNodes, nodeID)
+			}
+
 			// If we require all-at-once scheduling, there is no point
 			// to continue the evaluation, as we've already failed.
 			if plan.AllAtOnce {
 				result.NodeUpdate = nil
 				result.NodeAllocation = nil
+				result.NodePreemptions = nil
+				result.Deployment = nil
+				result.DeploymentUpdates = nil
 				return true
 			}
 
@@ -251,6 +459,9 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 		if nodeAlloc := plan.NodeAllocation[nodeID]; len(nodeAlloc) > 0 {
 			result.NodeAllocation[nodeID] = nodeAlloc
 		}
+		if nodePreemptions := plan.NodePreemptions[nodeID]; len(nodePreemptions) > 0 {
+			result.NodePreemptions[nodeID] = nodePreemptions
+		}
 		return
 	}
 
@@ -313,6 +524,76 @@ func evaluatePlan(pool *EvaluatePool, snap *state.StateSnapshot, plan *structs.P
 	return result, mErr.ErrorOrNil()
 }
 
+// evaluatePlanQuota is used to evaluate whether the allocations of the plan
+// fit in the quota attached to the namespace of the job
+func evaluatePlanQuota(snap *state.StateSnapshot, plan *structs.Plan) (bool, error) {
+	if plan.Job == nil || len(plan.NodeAllocation) == 0 {
+		return true, nil
+	}
+
+	ns, err := snap.NamespaceByName(plan.Job.Namespace)
+	if err != nil {
+		return false, fmt.Errorf("failed to get namespace %q: %v", plan.Job.Namespace, err)
+	}
+	if ns == nil || ns.Quota == "" {
+		return true, nil
+	}
+	quota, err := snap.QuotaSpecByName(ns.Quota)
+	if err != nil {
+		return false, fmt.Errorf("failed to get quota %q: %v", ns.Quota, err)
+	}
+	if quota == nil {
+		return true, nil
+	}
+
+	usage, err := snap.QuotaUsage(quota.Name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get usage of quota %q: %v", quota.Name, err)
+	}
+	base := usage.Used.Copy()
+	used := usage.Used
+
+	// Remove the existing allocations of the namespaces that the plan stops,
+	// preempts or updates
+	inQuota := func(alloc *structs.Allocation) bool {
+		if alloc == nil || alloc.TerminalStatus() || alloc.Job == nil {
+			return false
+		}
+		for _, name := range usage.Namespaces {
+			if alloc.Job.Namespace == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, updates := range []map[string][]*structs.Allocation{plan.NodeUpdate, plan.NodePreemptions, plan.NodeAllocation} {
+		for _, allocs := range updates {
+			for _, alloc := range allocs {
+				existing, err := snap.AllocByID(alloc.ID)
+				if err != nil {
+					return false, fmt.Errorf("failed to get allocation %q: %v", alloc.ID, err)
+				}
+				if inQuota(existing) {
+					used.SubtractAlloc(existing)
+				}
+			}
+		}
+	}
+	for _, allocs := range plan.NodeAllocation {
+		for _, alloc := range allocs {
+			used.AddAlloc(alloc)
+		}
+	}
+
+	// Usage above a lowered limit is tolerated as long as the plan does not
+	// increase it
+	if len(used.Exceeded(quota.Limit)) == 0 {
+		return true, nil
+	}
+	increased := used.CPU > base.CPU || used.MemoryMB > base.MemoryMB || used.Count > base.Count
+	return !increased, nil
+}
+
 // evaluateNodePlan is used to evalute the plan for a single node,
 // returning if the plan is valid or if an error is encountered
 func evaluateNodePlan(snap *state.StateSnapshot, plan *structs.Plan, nodeID string) (bool, error) {
@@ -330,7 +611,7 @@ func evaluateNodePlan(snap *state.StateSnapshot, plan *structs.Plan, nodeID stri
 	// If the node does not exist or is not ready for schduling it is not fit
 	// XXX: There is a potential race between when we do this check and when
 	// the Raft commit happens.
-	if node == nil || node.Status != structs.NodeStatusReady || node.Drain {
+	if node == nil || !node.Ready() {
 		return false, nil
 	}
 
@@ -347,6 +628,9 @@ func evaluateNodePlan(snap *state.StateSnapshot, plan *structs.Plan, nodeID stri
 	if update := plan.NodeUpdate[nodeID]; len(update) > 0 {
 		remove = append(remove, update...)
 	}
+	if preempted := plan.NodePreemptions[nodeID]; len(preempted) > 0 {
+		remove = append(remove, preempted...)
+	}
 	if updated := plan.NodeAllocation[nodeID]; len(updated) > 0 {
 		for _, alloc := range updated {
 			remove = append(remove, alloc)
@@ -0,0 +1,48 @@
+package nomad
+
+import "time"
+
+// BadNodeTracker is used to track the nodes rejecting plans. A node that
+// keeps on rejecting plans likely has a state the schedulers disagree with,
+// making them retry placements on it over and over again.
+type BadNodeTracker struct {
+	threshold int
+	window    time.Duration
+
+	// rejections holds the times of the recent rejections per node
+	rejections map[string][]time.Time
+}
+
+// NewBadNodeTracker returns a tracker reporting the nodes rejecting threshold
+// plans within window. A threshold of zero disables the tracking.
+func NewBadNodeTracker(threshold int, window time.Duration) *BadNodeTracker {
+	return &BadNodeTracker{
+		threshold:  threshold,
+		window:     window,
+		rejections: make(map[string][]time.Time),
+	}
+}
+
+// Add records a plan rejection by the node at the given time and returns
+// whether the node crossed the threshold. The rejections of a node that
+// crossed the threshold are reset.
+func (t *BadNodeTracker) Add(nodeID string, now time.Time) bool {
+	if t.threshold <= 0 {
+		return false
+	}
+
+	// Drop the rejections that fell out of the window
+	rejections := t.rejections[nodeID]
+	cutoff := now.Add(-t.window)
+	for len(rejections) > 0 && rejections[0].Before(cutoff) {
+		rejections = rejections[1:]
+	}
+	rejections = append(rejections, now)
+
+	if len(rejections) >= t.threshold {
+		delete(t.rejections, nodeID)
+		return true
+	}
+	t.rejections[nodeID] = rejections
+	return false
+}
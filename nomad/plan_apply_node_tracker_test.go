@@ -0,0 +1,46 @@
+package nomad
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBadNodeTracker(t *testing.T) {
+	tracker := NewBadNodeTracker(3, time.Minute)
+	now := time.Now()
+
+	// Rejections outside of the window don't count
+	if tracker.Add("foo", now) {
+		t.Fatalf("bad")
+	}
+	if tracker.Add("foo", now.Add(2*time.Minute)) {
+		t.Fatalf("bad")
+	}
+	if tracker.Add("foo", now.Add(2*time.Minute+time.Second)) {
+		t.Fatalf("bad")
+	}
+
+	// Other nodes are tracked on their own
+	if tracker.Add("bar", now.Add(2*time.Minute)) {
+		t.Fatalf("bad")
+	}
+
+	// The third rejection within the window crosses the threshold
+	if !tracker.Add("foo", now.Add(2*time.Minute+2*time.Second)) {
+		t.Fatalf("expected node to cross the threshold")
+	}
+
+	// The rejections are reset afterwards
+	if tracker.Add("foo", now.Add(2*time.Minute+3*time.Second)) {
+		t.Fatalf("bad")
+	}
+}
+
+func TestBadNodeTracker_Disabled(t *testing.T) {
+	tracker := NewBadNodeTracker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if tracker.Add("foo", time.Now()) {
+			t.Fatalf("bad")
+		}
+	}
+}
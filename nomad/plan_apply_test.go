@@ -1,9 +1,12 @@
 package nomad
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
@@ -179,6 +182,82 @@ func TestPlanApply_applyPlan(t *testing.T) {
 	}
 }
 
+func TestPlanApply_applyPlan_Preemption(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register node
+	node := mock.Node()
+	testRegisterNode(t, s1, node)
+
+	// Register a low priority job with a running alloc
+	state := s1.fsm.State()
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	alloc.Job.Priority = 20
+	if err := state.UpsertJob(1000, alloc.Job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1001, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Preempt the alloc to place alloc2
+	alloc2 := mock.Alloc()
+	alloc2.NodeID = node.ID
+	job := alloc2.Job
+	alloc2.Job = nil
+	alloc2.PreemptedAllocations = []string{alloc.ID}
+	s1.State().UpsertJobSummary(1500, mock.JobSummary(alloc2.JobID))
+	plan := &structs.Plan{
+		NodeUpdate:     make(map[string][]*structs.Allocation),
+		NodeAllocation: make(map[string][]*structs.Allocation),
+	}
+	plan.AppendAlloc(alloc2)
+	plan.AppendPreemptedAlloc(alloc, alloc2.ID)
+	result := &structs.PlanResult{
+		NodeAllocation:  plan.NodeAllocation,
+		NodePreemptions: plan.NodePreemptions,
+	}
+
+	// Apply the plan
+	future, err := s1.applyPlan(job, result, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := planWaitFuture(future); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the preempted allocation
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.DesiredStatus != structs.AllocDesiredStatusEvict {
+		t.Fatalf("should be evicted alloc: %#v", out)
+	}
+	if out.PreemptedByAllocation != alloc2.ID {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.Job == nil {
+		t.Fatalf("missing job")
+	}
+
+	// An evaluation of the preempted job was created
+	evals, err := state.EvalsByJob(alloc.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(evals) != 1 || evals[0].TriggeredBy != structs.EvalTriggerPreemption {
+		t.Fatalf("bad: %#v", evals)
+	}
+	if evals[0].Priority != 20 {
+		t.Fatalf("bad: %#v", evals[0])
+	}
+}
+
 func TestPlanApply_EvalPlan_Simple(t *testing.T) {
 	state := testStateStore(t)
 	node := mock.Node()
@@ -245,6 +324,43 @@ func TestPlanApply_EvalPlan_Partial(t *testing.T) {
 	if result.RefreshIndex != 1001 {
 		t.Fatalf("bad: %d", result.RefreshIndex)
 	}
+	if len(result.RejectedNodes) != 1 || result.RejectedNodes[0] != node2.ID {
+		t.Fatalf("bad: %v", result.RejectedNodes)
+	}
+}
+
+func TestPlanApply_EvalPlan_RejectedNodes_NotReady(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+	node.Status = structs.NodeStatusDown
+	state.UpsertNode(1000, node)
+	node2 := mock.Node()
+	node2.SchedulingEligibility = structs.NodeSchedulingIneligible
+	state.UpsertNode(1001, node2)
+	snap, _ := state.Snapshot()
+
+	plan := &structs.Plan{
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID:  []*structs.Allocation{mock.Alloc()},
+			node2.ID: []*structs.Allocation{mock.Alloc()},
+		},
+	}
+
+	pool := NewEvaluatePool(workerPoolSize, workerPoolBufferSize)
+	defer pool.Shutdown()
+
+	result, err := evaluatePlan(pool, snap, plan)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(result.NodeAllocation) != 0 {
+		t.Fatalf("bad: %v", result.NodeAllocation)
+	}
+
+	// Nodes that are not ready or eligible are expected to reject plans
+	if len(result.RejectedNodes) != 0 {
+		t.Fatalf("bad: %v", result.RejectedNodes)
+	}
 }
 
 func TestPlanApply_EvalPlan_Partial_AllAtOnce(t *testing.T) {
@@ -285,6 +401,70 @@ func TestPlanApply_EvalPlan_Partial_AllAtOnce(t *testing.T) {
 	}
 }
 
+func TestPlanApply_EvalPlan_QuotaExceeded(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+	state.UpsertNode(1000, node)
+
+	// Attach a quota allowing a single allocation to a namespace
+	q := mock.QuotaSpec()
+	q.Limit.Count = 1
+	ns := mock.Namespace()
+	ns.Quota = q.Name
+	state.UpsertQuotaSpecs(1001, []*structs.QuotaSpec{q})
+	state.UpsertNamespaces(1002, []*structs.Namespace{ns})
+
+	job := mock.Job()
+	job.Namespace = ns.Name
+	state.UpsertJob(1003, job)
+	existing := mock.Alloc()
+	existing.Job = job
+	existing.JobID = job.ID
+	existing.NodeID = node.ID
+	state.UpsertAllocs(1004, []*structs.Allocation{existing})
+	snap, _ := state.Snapshot()
+
+	// Placing a second allocation exceeds the quota
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	plan := &structs.Plan{
+		Job: job,
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: []*structs.Allocation{alloc},
+		},
+	}
+
+	pool := NewEvaluatePool(workerPoolSize, workerPoolBufferSize)
+	defer pool.Shutdown()
+
+	result, err := evaluatePlan(pool, snap, plan)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if result == nil {
+		t.Fatalf("missing result")
+	}
+	if len(result.NodeAllocation) != 0 {
+		t.Fatalf("should not alloc: %v", result.NodeAllocation)
+	}
+	if result.RefreshIndex != 1004 {
+		t.Fatalf("bad: %d", result.RefreshIndex)
+	}
+
+	// Replacing the existing allocation stays within the quota
+	plan.NodeUpdate = map[string][]*structs.Allocation{
+		node.ID: []*structs.Allocation{existing},
+	}
+	result, err = evaluatePlan(pool, snap, plan)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(result.NodeAllocation, plan.NodeAllocation) {
+		t.Fatalf("incorrect node allocations")
+	}
+}
+
 func TestPlanApply_EvalNodePlan_Simple(t *testing.T) {
 	state := testStateStore(t)
 	node := mock.Node()
@@ -353,6 +533,29 @@ func TestPlanApply_EvalNodePlan_NodeDrain(t *testing.T) {
 	}
 }
 
+func TestPlanApply_EvalNodePlan_NodeIneligible(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+	node.SchedulingEligibility = structs.NodeSchedulingIneligible
+	state.UpsertNode(1000, node)
+	snap, _ := state.Snapshot()
+
+	alloc := mock.Alloc()
+	plan := &structs.Plan{
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: []*structs.Allocation{alloc},
+		},
+	}
+
+	fit, err := evaluateNodePlan(snap, plan, node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit {
+		t.Fatalf("bad")
+	}
+}
+
 func TestPlanApply_EvalNodePlan_NodeNotExist(t *testing.T) {
 	state := testStateStore(t)
 	snap, _ := state.Snapshot()
@@ -464,6 +667,44 @@ func TestPlanApply_EvalNodePlan_NodeFull_Evict(t *testing.T) {
 	}
 }
 
+func TestPlanApply_EvalNodePlan_NodeFull_Preemption(t *testing.T) {
+	alloc := mock.Alloc()
+	state := testStateStore(t)
+	node := mock.Node()
+	alloc.NodeID = node.ID
+	node.Resources = alloc.Resources
+	node.Reserved = nil
+	state.UpsertNode(1000, node)
+	state.UpsertAllocs(1001, []*structs.Allocation{alloc})
+	snap, _ := state.Snapshot()
+
+	alloc2 := mock.Alloc()
+	alloc2.NodeID = node.ID
+	plan := &structs.Plan{
+		NodeAllocation: map[string][]*structs.Allocation{
+			node.ID: []*structs.Allocation{alloc2},
+		},
+	}
+
+	// The node is full unless the alloc is preempted
+	fit, err := evaluateNodePlan(snap, plan, node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit {
+		t.Fatalf("bad")
+	}
+
+	plan.AppendPreemptedAlloc(alloc, alloc2.ID)
+	fit, err = evaluateNodePlan(snap, plan, node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !fit {
+		t.Fatalf("bad")
+	}
+}
+
 func TestPlanApply_EvalNodePlan_NodeFull_AllocEvict(t *testing.T) {
 	alloc := mock.Alloc()
 	state := testStateStore(t)
@@ -521,3 +762,91 @@ func TestPlanApply_EvalNodePlan_NodeDown_EvictOnly(t *testing.T) {
 		t.Fatalf("bad")
 	}
 }
+
+func TestPlanApply_RejectingNode_Reenabled(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.PlanRejectionCooldown = 200 * time.Millisecond
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	node := mock.Node()
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	job := mock.SystemJob()
+	if err := state.UpsertJob(1001, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mark the node as rejecting plans
+	s1.markNodeIneligible(node.ID)
+	out, err := state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Eligible() || out.IneligibleUntil == 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// The node is made eligible again after the cooldown, and evaluated so
+	// that the system job is placed on it again
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := state.NodeByID(node.ID)
+		if err != nil {
+			return false, err
+		}
+		if !out.Eligible() || out.IneligibleUntil != 0 {
+			return false, fmt.Errorf("node still ineligible: %#v", out)
+		}
+		evals, err := state.EvalsByJob(job.ID)
+		if err != nil {
+			return false, err
+		}
+		if len(evals) != 1 || evals[0].NodeID != node.ID || evals[0].TriggeredBy != structs.EvalTriggerNodeUpdate {
+			return false, fmt.Errorf("bad evals: %#v", evals)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+}
+
+func TestPlanApply_RejectingNode_OperatorOverride(t *testing.T) {
+	s1 := testServer(t, func(c *Config) {
+		c.PlanRejectionCooldown = 200 * time.Millisecond
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	node := mock.Node()
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Mark the node as rejecting plans, and have an operator mark it
+	// ineligible as well
+	s1.markNodeIneligible(node.ID)
+	update := &structs.NodeUpdateEligibilityRequest{
+		NodeID:       node.ID,
+		Eligibility:  structs.NodeSchedulingIneligible,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.NodeEligibilityUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.UpdateEligibility", update, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The operator's decision outlives the cooldown
+	time.Sleep(3 * s1.config.PlanRejectionCooldown)
+	out, err := state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Eligible() || out.IneligibleUntil != 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+}
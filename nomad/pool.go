@@ -175,6 +175,20 @@ func (p *ConnPool) Shutdown() error {
 	return nil
 }
 
+// ReloadTLS swaps the TLS wrapper used for new connections. Pooled
+// connections are closed so that subsequent RPCs are made using the new
+// settings.
+func (p *ConnPool) ReloadTLS(tlsWrap tlsutil.RegionWrapper) {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, conn := range p.pool {
+		conn.Close()
+	}
+	p.pool = make(map[string]*Conn)
+	p.tlsWrap = tlsWrap
+}
+
 // Acquire is used to get a connection that is
 // pooled or to return a new connection
 func (p *ConnPool) acquire(region string, addr net.Addr, version int) (*Conn, error) {
@@ -238,10 +252,12 @@ func (p *ConnPool) acquire(region string, addr net.Addr, version int) (*Conn, er
 	return nil, fmt.Errorf("rpc error: lead thread didn't get connection")
 }
 
-// getNewConn is used to return a new connection
-func (p *ConnPool) getNewConn(region string, addr net.Addr, version int) (*Conn, error) {
+// DialTimeout is used to establish a raw connection to the given server,
+// switching it into TLS mode if TLS is enabled. The caller is responsible for
+// writing the RPC type byte and closing the connection.
+func (p *ConnPool) DialTimeout(region string, addr net.Addr, timeout time.Duration) (net.Conn, error) {
 	// Try to dial the conn
-	conn, err := net.DialTimeout("tcp", addr.String(), 10*time.Second)
+	conn, err := net.DialTimeout("tcp", addr.String(), timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -253,7 +269,10 @@ func (p *ConnPool) getNewConn(region string, addr net.Addr, version int) (*Conn,
 	}
 
 	// Check if TLS is enabled
-	if p.tlsWrap != nil {
+	p.Lock()
+	tlsWrap := p.tlsWrap
+	p.Unlock()
+	if tlsWrap != nil {
 		// Switch the connection into TLS mode
 		if _, err := conn.Write([]byte{byte(rpcTLS)}); err != nil {
 			conn.Close()
@@ -261,13 +280,22 @@ func (p *ConnPool) getNewConn(region string, addr net.Addr, version int) (*Conn,
 		}
 
 		// Wrap the connection in a TLS client
-		tlsConn, err := p.tlsWrap(region, conn)
+		tlsConn, err := tlsWrap(region, conn)
 		if err != nil {
 			conn.Close()
 			return nil, err
 		}
 		conn = tlsConn
 	}
+	return conn, nil
+}
+
+// getNewConn is used to return a new connection
+func (p *ConnPool) getNewConn(region string, addr net.Addr, version int) (*Conn, error) {
+	conn, err := p.DialTimeout(region, addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
 
 	// Write the multiplex byte to set the mode
 	if _, err := conn.Write([]byte{byte(rpcMultiplex)}); err != nil {
@@ -0,0 +1,263 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/watch"
+)
+
+// Quota endpoint is used for manipulating quota specifications
+type Quota struct {
+	srv *Server
+}
+
+// UpsertQuotaSpecs is used to create or update a set of quota specifications
+func (q *Quota) UpsertQuotaSpecs(args *structs.QuotaSpecUpsertRequest,
+	reply *structs.GenericResponse) error {
+	if done, err := q.srv.forward("Quota.UpsertQuotaSpecs", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "upsert_quota_specs"}, time.Now())
+
+	// Check quota write permissions
+	if err := q.srv.checkACL(args.AuthToken, (*acl.ACL).AllowQuotaWrite); err != nil {
+		return err
+	}
+
+	// Validate the arguments
+	if len(args.Quotas) == 0 {
+		return fmt.Errorf("must specify at least one quota specification")
+	}
+	for _, quota := range args.Quotas {
+		if err := quota.Validate(); err != nil {
+			return fmt.Errorf("invalid quota specification %q: %v", quota.Name, err)
+		}
+	}
+
+	// Update via Raft
+	_, index, err := q.srv.raftApply(structs.QuotaSpecUpsertRequestType, args)
+	if err != nil {
+		q.srv.logger.Printf("[ERR] nomad.quota: UpsertQuotaSpecs failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// DeleteQuotaSpecs is used to delete a set of quota specifications
+func (q *Quota) DeleteQuotaSpecs(args *structs.QuotaSpecDeleteRequest,
+	reply *structs.GenericResponse) error {
+	if done, err := q.srv.forward("Quota.DeleteQuotaSpecs", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "delete_quota_specs"}, time.Now())
+
+	// Check quota write permissions
+	if err := q.srv.checkACL(args.AuthToken, (*acl.ACL).AllowQuotaWrite); err != nil {
+		return err
+	}
+
+	// Validate the arguments
+	if len(args.Names) == 0 {
+		return fmt.Errorf("must specify at least one quota specification to delete")
+	}
+
+	// Quotas attached to namespaces can not be deleted
+	snap, err := q.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	for _, name := range args.Names {
+		namespaces, err := snap.NamespacesByQuota(name)
+		if err != nil {
+			return err
+		}
+		if len(namespaces) != 0 {
+			return fmt.Errorf("quota specification %q is attached to namespace %q", name, namespaces[0].Name)
+		}
+	}
+
+	// Update via Raft
+	resp, index, err := q.srv.raftApply(structs.QuotaSpecDeleteRequestType, args)
+	if err, ok := resp.(error); ok && err != nil {
+		return err
+	}
+	if err != nil {
+		q.srv.logger.Printf("[ERR] nomad.quota: DeleteQuotaSpecs failed: %v", err)
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// ListQuotaSpecs is used to list the quota specifications
+func (q *Quota) ListQuotaSpecs(args *structs.QuotaSpecListRequest,
+	reply *structs.QuotaSpecListResponse) error {
+	if done, err := q.srv.forward("Quota.ListQuotaSpecs", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "list_quota_specs"}, time.Now())
+
+	// Check quota read permissions
+	if err := q.srv.checkACL(args.AuthToken, (*acl.ACL).AllowQuotaRead); err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "quota_specs"}),
+		run: func() error {
+			// Capture all the quota specifications
+			snap, err := q.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			var iter memdb.ResultIterator
+			if prefix := args.QueryOptions.Prefix; prefix != "" {
+				iter, err = snap.QuotaSpecsByNamePrefix(prefix)
+			} else {
+				iter, err = snap.QuotaSpecs()
+			}
+			if err != nil {
+				return err
+			}
+
+			reply.Quotas = nil
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				reply.Quotas = append(reply.Quotas, raw.(*structs.QuotaSpec))
+			}
+
+			// Use the last index that affected the quota table
+			index, err := snap.Index("quota_specs")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			q.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return q.srv.blockingRPC(&opts)
+}
+
+// GetQuotaSpec is used to get a specific quota specification
+func (q *Quota) GetQuotaSpec(args *structs.QuotaSpecificRequest,
+	reply *structs.SingleQuotaSpecResponse) error {
+	if done, err := q.srv.forward("Quota.GetQuotaSpec", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "get_quota_spec"}, time.Now())
+
+	// Check quota read permissions
+	if err := q.srv.checkACL(args.AuthToken, (*acl.ACL).AllowQuotaRead); err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch:     watch.NewItems(watch.Item{Table: "quota_specs"}),
+		run: func() error {
+			// Look for the quota specification
+			snap, err := q.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			out, err := snap.QuotaSpecByName(args.Name)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Quota = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the quota table
+				index, err := snap.Index("quota_specs")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			q.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return q.srv.blockingRPC(&opts)
+}
+
+// GetQuotaUsage is used to get the usage of a specific quota specification
+func (q *Quota) GetQuotaUsage(args *structs.QuotaSpecificRequest,
+	reply *structs.SingleQuotaUsageResponse) error {
+	if done, err := q.srv.forward("Quota.GetQuotaUsage", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quota", "get_quota_usage"}, time.Now())
+
+	// Check quota read permissions
+	if err := q.srv.checkACL(args.AuthToken, (*acl.ACL).AllowQuotaRead); err != nil {
+		return err
+	}
+
+	// Setup the blocking query. The usage changes with the allocations of
+	// the namespaces.
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		watch: watch.NewItems(
+			watch.Item{Table: "quota_specs"},
+			watch.Item{Table: "namespaces"},
+			watch.Item{Table: "allocs"}),
+		run: func() error {
+			snap, err := q.srv.fsm.State().Snapshot()
+			if err != nil {
+				return err
+			}
+			quota, err := snap.QuotaSpecByName(args.Name)
+			if err != nil {
+				return err
+			}
+
+			reply.Usage = nil
+			if quota != nil {
+				reply.Usage, err = snap.QuotaUsage(quota.Name)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Use the last index that affected the usage
+			reply.Index = 0
+			for _, table := range []string{"quota_specs", "namespaces", "allocs"} {
+				index, err := snap.Index(table)
+				if err != nil {
+					return err
+				}
+				reply.Index = maxUint64(reply.Index, index)
+			}
+
+			// Set the query response
+			q.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return q.srv.blockingRPC(&opts)
+}
@@ -0,0 +1,196 @@
+package nomad
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+func TestQuotaEndpoint_GetQuotaSpec(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the quota
+	q := mock.QuotaSpec()
+	if err := s1.fsm.State().UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the quota
+	get := &structs.QuotaSpecificRequest{
+		Name:         q.Name,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleQuotaSpecResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.GetQuotaSpec", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if !reflect.DeepEqual(q, resp.Quota) {
+		t.Fatalf("bad: %#v %#v", q, resp.Quota)
+	}
+
+	// Lookup a non-existing quota
+	get.Name = "nope"
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.GetQuotaSpec", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Quota != nil {
+		t.Fatalf("unexpected quota")
+	}
+}
+
+func TestQuotaEndpoint_ListQuotaSpecs(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the quotas
+	q1 := mock.QuotaSpec()
+	q1.Name = "aaaa"
+	q2 := mock.QuotaSpec()
+	q2.Name = "aabb"
+	if err := s1.fsm.State().UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q1, q2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lookup the quotas
+	get := &structs.QuotaSpecListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.QuotaSpecListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.ListQuotaSpecs", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1000 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1000)
+	}
+	if len(resp.Quotas) != 2 {
+		t.Fatalf("bad: %#v", resp.Quotas)
+	}
+
+	// Lookup the quotas by prefix
+	get.Prefix = "aab"
+	var resp2 structs.QuotaSpecListResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.ListQuotaSpecs", get, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.Quotas) != 1 || resp2.Quotas[0].Name != q2.Name {
+		t.Fatalf("bad: %#v", resp2.Quotas)
+	}
+}
+
+func TestQuotaEndpoint_UpsertDeleteQuotaSpecs(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create the quota
+	q := mock.QuotaSpec()
+	req := &structs.QuotaSpecUpsertRequest{
+		Quotas:       []*structs.QuotaSpec{q},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.UpsertQuotaSpecs", req, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index == 0 {
+		t.Fatalf("bad index: %d", resp.Index)
+	}
+	out, err := state.QuotaSpecByName(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected quota")
+	}
+
+	// Attach the quota to a namespace
+	ns := mock.Namespace()
+	ns.Quota = q.Name
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Attached quotas can not be deleted
+	del := &structs.QuotaSpecDeleteRequest{
+		Names:        []string{q.Name},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	err = msgpackrpc.CallWithCodec(codec, "Quota.DeleteQuotaSpecs", del, &resp)
+	if err == nil || !strings.Contains(err.Error(), ns.Name) {
+		t.Fatalf("expected attached quota error: %v", err)
+	}
+
+	// Detach the quota and delete it
+	ns2 := ns.Copy()
+	ns2.Quota = ""
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.DeleteQuotaSpecs", del, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.QuotaSpecByName(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestQuotaEndpoint_GetQuotaUsage(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	q := mock.QuotaSpec()
+	ns := mock.Namespace()
+	ns.Quota = q.Name
+	if err := state.UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	alloc := mock.Alloc()
+	alloc.Job.Namespace = ns.Name
+	if err := state.UpsertJob(1002, alloc.Job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	get := &structs.QuotaSpecificRequest{
+		Name:         q.Name,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleQuotaUsageResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Quota.GetQuotaUsage", get, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Index != 1003 {
+		t.Fatalf("Bad index: %d %d", resp.Index, 1003)
+	}
+	expected := &structs.QuotaLimit{CPU: 500, MemoryMB: 256, Count: 1}
+	if resp.Usage == nil || !reflect.DeepEqual(resp.Usage.Used, expected) {
+		t.Fatalf("bad: %#v", resp.Usage)
+	}
+}
@@ -27,6 +27,7 @@ const (
 	rpcRaft              = 0x02
 	rpcMultiplex         = 0x03
 	rpcTLS               = 0x04
+	rpcSnapshot          = 0x05
 )
 
 const (
@@ -116,13 +117,17 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 	case rpcMultiplex:
 		s.handleMultiplex(conn)
 
+	case rpcSnapshot:
+		s.handleSnapshotConn(conn)
+
 	case rpcTLS:
-		if s.rpcTLS == nil {
+		rpcTLS := s.getRPCTLS()
+		if rpcTLS == nil {
 			s.logger.Printf("[WARN] nomad.rpc: TLS connection attempted, server not configured for TLS")
 			conn.Close()
 			return
 		}
-		conn = tls.Server(conn, s.rpcTLS)
+		conn = tls.Server(conn, rpcTLS)
 		s.handleConn(conn, true)
 
 	default:
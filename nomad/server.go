@@ -89,13 +89,20 @@ type Server struct {
 	// fsm is the state machine used with Raft
 	fsm *nomadFSM
 
+	// leaderAcl is the management ACL token that is valid when resolved by
+	// the current leader. It is attached to core evaluations so internal RPCs
+	// pass ACL enforcement.
+	leaderAcl     string
+	leaderAclLock sync.Mutex
+
 	// rpcListener is used to listen for incoming connections
 	rpcListener  net.Listener
 	rpcServer    *rpc.Server
 	rpcAdvertise net.Addr
 
 	// rpcTLS is the TLS config for incoming TLS requests
-	rpcTLS *tls.Config
+	rpcTLS     *tls.Config
+	rpcTLSLock sync.RWMutex
 
 	// peers is used to track the known Nomad servers. This is
 	// used for region forwarding and clustering.
@@ -113,6 +120,16 @@ type Server struct {
 	// join/leave from the region.
 	reconcileCh chan serf.Member
 
+	// reassertLeaderCh is used to signal the leader loop to re-run the
+	// leadership actions, for example after the state was replaced by a
+	// snapshot restore.
+	reassertLeaderCh chan chan error
+
+	// clusterHealth is the health of the servers of the region as last
+	// computed by the autopilot loop of the leader
+	clusterHealth     structs.ClusterHealth
+	clusterHealthLock sync.RWMutex
+
 	// eventCh is used to receive events from the serf cluster
 	eventCh chan serf.Event
 
@@ -153,15 +170,20 @@ type Server struct {
 
 // Holds the RPC endpoints
 type endpoints struct {
-	Status   *Status
-	Node     *Node
-	Job      *Job
-	Eval     *Eval
-	Plan     *Plan
-	Alloc    *Alloc
-	Region   *Region
-	Periodic *Periodic
-	System   *System
+	Status     *Status
+	Node       *Node
+	Job        *Job
+	Eval       *Eval
+	Plan       *Plan
+	Alloc      *Alloc
+	Region     *Region
+	Periodic   *Periodic
+	System     *System
+	Deployment *Deployment
+	Operator   *Operator
+	Namespace  *Namespace
+	Quota      *Quota
+	ACL        *ACL
 }
 
 // NewServer is used to construct a new Nomad server from the
@@ -173,7 +195,7 @@ func NewServer(config *Config, consulSyncer *consul.Syncer, logger *log.Logger)
 	}
 
 	// Create an eval broker
-	evalBroker, err := NewEvalBroker(config.EvalNackTimeout, config.EvalDeliveryLimit)
+	evalBroker, err := NewEvalBroker(config.EvalNackTimeout, config.EvalAgingInterval, config.EvalDeliveryLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -188,39 +210,28 @@ func NewServer(config *Config, consulSyncer *consul.Syncer, logger *log.Logger)
 	}
 
 	// Configure TLS
-	var tlsWrap tlsutil.RegionWrapper
-	var incomingTLS *tls.Config
-	if config.TLSConfig.EnableRPC {
-		tlsConf := config.tlsConfig()
-		tw, err := tlsConf.OutgoingTLSWrapper()
-		if err != nil {
-			return nil, err
-		}
-		tlsWrap = tw
-
-		itls, err := tlsConf.IncomingTLSConfig()
-		if err != nil {
-			return nil, err
-		}
-		incomingTLS = itls
+	tlsWrap, incomingTLS, err := getTLSConf(config)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create the server
 	s := &Server{
-		config:       config,
-		consulSyncer: consulSyncer,
-		connPool:     NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, tlsWrap),
-		logger:       logger,
-		rpcServer:    rpc.NewServer(),
-		peers:        make(map[string][]*serverParts),
-		localPeers:   make(map[string]*serverParts),
-		reconcileCh:  make(chan serf.Member, 32),
-		eventCh:      make(chan serf.Event, 256),
-		evalBroker:   evalBroker,
-		blockedEvals: blockedEvals,
-		planQueue:    planQueue,
-		rpcTLS:       incomingTLS,
-		shutdownCh:   make(chan struct{}),
+		config:           config,
+		consulSyncer:     consulSyncer,
+		connPool:         NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, tlsWrap),
+		logger:           logger,
+		rpcServer:        rpc.NewServer(),
+		peers:            make(map[string][]*serverParts),
+		localPeers:       make(map[string]*serverParts),
+		reconcileCh:      make(chan serf.Member, 32),
+		reassertLeaderCh: make(chan chan error),
+		eventCh:          make(chan serf.Event, 256),
+		evalBroker:       evalBroker,
+		blockedEvals:     blockedEvals,
+		planQueue:        planQueue,
+		rpcTLS:           incomingTLS,
+		shutdownCh:       make(chan struct{}),
 	}
 
 	// Create the periodic dispatcher for launching periodic jobs.
@@ -411,6 +422,60 @@ func (s *Server) Leave() error {
 	return nil
 }
 
+// getTLSConf returns the outgoing TLS wrapper and incoming TLS configuration
+// for the given config. Both are nil if TLS is not enabled for RPC.
+func getTLSConf(config *Config) (tlsutil.RegionWrapper, *tls.Config, error) {
+	if !config.TLSConfig.EnableRPC {
+		return nil, nil, nil
+	}
+
+	tlsConf := config.tlsConfig()
+	tlsWrap, err := tlsConf.OutgoingTLSWrapper()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	incomingTLS, err := tlsConf.IncomingTLSConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	return tlsWrap, incomingTLS, nil
+}
+
+// Reload handles a configuration reload of the server. Only the RPC TLS
+// configuration is reloaded, re-reading the certificate files so that they
+// can be rotated without restarting the server. Existing connections are
+// dropped so that they are re-established with the new settings.
+func (s *Server) Reload(newConfig *Config) error {
+	if newConfig == nil || newConfig.TLSConfig == nil {
+		return fmt.Errorf("Reload requires a non-nil TLS configuration")
+	}
+
+	tlsWrap, incomingTLS, err := getTLSConf(newConfig)
+	if err != nil {
+		s.logger.Printf("[ERR] nomad: failed to reload TLS configuration: %v", err)
+		return err
+	}
+
+	s.rpcTLSLock.Lock()
+	s.config.TLSConfig = newConfig.TLSConfig
+	s.rpcTLS = incomingTLS
+	s.rpcTLSLock.Unlock()
+
+	s.connPool.ReloadTLS(tlsWrap)
+	s.raftLayer.ReloadTLS(tlsutil.RegionSpecificWrapper(s.config.Region, tlsWrap))
+
+	s.logger.Printf("[INFO] nomad: reloaded TLS configuration")
+	return nil
+}
+
+// getRPCTLS returns the TLS configuration used for incoming RPC connections.
+func (s *Server) getRPCTLS() *tls.Config {
+	s.rpcTLSLock.RLock()
+	defer s.rpcTLSLock.RUnlock()
+	return s.rpcTLS
+}
+
 // setupBootstrapHandler() creates the closure necessary to support a Consul
 // fallback handler.
 func (s *Server) setupBootstrapHandler() error {
@@ -605,6 +670,11 @@ func (s *Server) setupRPC(tlsWrap tlsutil.RegionWrapper) error {
 	s.endpoints.Region = &Region{s}
 	s.endpoints.Periodic = &Periodic{s}
 	s.endpoints.System = &System{s}
+	s.endpoints.Deployment = &Deployment{s}
+	s.endpoints.Operator = &Operator{s}
+	s.endpoints.Namespace = &Namespace{s}
+	s.endpoints.Quota = &Quota{s}
+	s.endpoints.ACL = &ACL{s}
 
 	// Register the handlers
 	s.rpcServer.Register(s.endpoints.Status)
@@ -616,6 +686,11 @@ func (s *Server) setupRPC(tlsWrap tlsutil.RegionWrapper) error {
 	s.rpcServer.Register(s.endpoints.Region)
 	s.rpcServer.Register(s.endpoints.Periodic)
 	s.rpcServer.Register(s.endpoints.System)
+	s.rpcServer.Register(s.endpoints.Deployment)
+	s.rpcServer.Register(s.endpoints.Operator)
+	s.rpcServer.Register(s.endpoints.Namespace)
+	s.rpcServer.Register(s.endpoints.Quota)
+	s.rpcServer.Register(s.endpoints.ACL)
 
 	list, err := net.ListenTCP("tcp", s.config.RPCAddr)
 	if err != nil {
@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/testutil"
 )
 
@@ -57,6 +60,11 @@ func testServer(t *testing.T, cb func(*Config)) *Server {
 	config.RaftConfig.ElectionTimeout = 50 * time.Millisecond
 	config.RaftTimeout = 500 * time.Millisecond
 
+	// Tighten the autopilot timing and add new servers right away
+	config.AutopilotConfig.ServerStabilizationTime = 0
+	config.AutopilotInterval = 100 * time.Millisecond
+	config.ServerHealthInterval = 50 * time.Millisecond
+
 	// Disable Vault
 	f := false
 	config.VaultConfig.Enabled = &f
@@ -84,6 +92,22 @@ func testServer(t *testing.T, cb func(*Config)) *Server {
 	return server
 }
 
+// testACLServer returns a server with ACLs enabled and a bootstrapped
+// management token.
+func testACLServer(t *testing.T, cb func(*Config)) (*Server, *structs.ACLToken) {
+	server := testServer(t, func(c *Config) {
+		c.ACLEnabled = true
+		if cb != nil {
+			cb(c)
+		}
+	})
+	token := mock.ACLManagementToken()
+	if err := server.fsm.State().BootstrapACLTokens(1, token); err != nil {
+		t.Fatalf("failed to bootstrap ACL token: %v", err)
+	}
+	return server, token
+}
+
 func testJoin(t *testing.T, s1 *Server, other ...*Server) {
 	addr := fmt.Sprintf("127.0.0.1:%d",
 		s1.config.SerfConfig.MemberlistConfig.BindPort)
@@ -136,3 +160,62 @@ func TestServer_Regions(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	})
 }
+
+func TestServer_Reload_TLS(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+
+	if s1.getRPCTLS() != nil {
+		t.Fatalf("expected no incoming TLS config")
+	}
+
+	const (
+		cafile  = "../helper/tlsutil/testdata/ca.pem"
+		foocert = "../helper/tlsutil/testdata/nomad-foo.pem"
+		fookey  = "../helper/tlsutil/testdata/nomad-foo-key.pem"
+	)
+
+	// Enable TLS by reloading
+	newConfig := DefaultConfig()
+	newConfig.TLSConfig = &config.TLSConfig{
+		EnableRPC: true,
+		CAFile:    cafile,
+		CertFile:  foocert,
+		KeyFile:   fookey,
+	}
+	if err := s1.Reload(newConfig); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.getRPCTLS() == nil {
+		t.Fatalf("expected incoming TLS config")
+	}
+	s1.connPool.Lock()
+	wrap := s1.connPool.tlsWrap
+	s1.connPool.Unlock()
+	if wrap == nil {
+		t.Fatalf("expected outgoing TLS wrapper")
+	}
+
+	// A bad configuration should be rejected and leave TLS untouched
+	badConfig := DefaultConfig()
+	badConfig.TLSConfig = &config.TLSConfig{
+		EnableRPC: true,
+		CAFile:    cafile,
+		CertFile:  foocert,
+		KeyFile:   "/does/not/exist",
+	}
+	if err := s1.Reload(badConfig); err == nil {
+		t.Fatalf("expected error")
+	}
+	if s1.getRPCTLS() == nil {
+		t.Fatalf("expected incoming TLS config")
+	}
+
+	// Disable TLS again
+	if err := s1.Reload(DefaultConfig()); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.getRPCTLS() != nil {
+		t.Fatalf("expected no incoming TLS config")
+	}
+}
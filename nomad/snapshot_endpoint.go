@@ -0,0 +1,291 @@
+package nomad
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/helper/snapshot"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// snapshotDialTimeout is the timeout to connect to a server when
+	// forwarding a snapshot request
+	snapshotDialTimeout = 10 * time.Second
+
+	// snapshotReassertTimeout bounds how long a restore waits for the leader
+	// to re-establish its leadership state
+	snapshotReassertTimeout = time.Minute
+)
+
+// halfCloser is implemented by connections that can signal the end of the
+// written data while still reading the response
+type halfCloser interface {
+	CloseWrite() error
+}
+
+// handleSnapshotConn serves a snapshot request over a dedicated connection.
+// Snapshots are streamed so they can not use the regular RPC codec.
+func (s *Server) handleSnapshotConn(conn net.Conn) {
+	go func() {
+		defer conn.Close()
+		if err := s.handleSnapshotRequest(conn); err != nil {
+			s.logger.Printf("[ERR] nomad.rpc: snapshot RPC error: %v", err)
+		}
+	}()
+}
+
+// handleSnapshotRequest reads the request header from the connection,
+// dispatches the request and streams back the response.
+func (s *Server) handleSnapshotRequest(conn net.Conn) error {
+	var args structs.SnapshotRequest
+	dec := codec.NewDecoder(conn, structs.MsgpackHandle)
+	if err := dec.Decode(&args); err != nil {
+		return fmt.Errorf("failed to decode request: %v", err)
+	}
+
+	var reply structs.SnapshotResponse
+	snap, err := s.dispatchSnapshotRequest(&args, conn, &reply)
+	if err != nil {
+		reply.Error = err.Error()
+	} else {
+		defer snap.Close()
+	}
+
+	enc := codec.NewEncoder(conn, structs.MsgpackHandle)
+	if err := enc.Encode(&reply); err != nil {
+		return fmt.Errorf("failed to encode response: %v", err)
+	}
+	if snap != nil {
+		if _, err := io.Copy(conn, snap); err != nil {
+			return fmt.Errorf("failed to stream snapshot: %v", err)
+		}
+	}
+	return nil
+}
+
+// SnapshotRPC performs the snapshot request on behalf of the local agent. The
+// returned reader streams the snapshot of a save request and must be closed by
+// the caller.
+func (s *Server) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader,
+	reply *structs.SnapshotResponse) (io.ReadCloser, error) {
+	return s.dispatchSnapshotRequest(args, in, reply)
+}
+
+// dispatchSnapshotRequest forwards the request to the right region and
+// server and otherwise performs the snapshot operation locally. The returned
+// reader is streamed back to the caller after the response header.
+func (s *Server) dispatchSnapshotRequest(args *structs.SnapshotRequest, in io.Reader,
+	reply *structs.SnapshotResponse) (io.ReadCloser, error) {
+
+	// Forward the request to the target region
+	if args.Region != s.config.Region {
+		s.peerLock.RLock()
+		servers := s.peers[args.Region]
+		if len(servers) == 0 {
+			s.peerLock.RUnlock()
+			return nil, structs.ErrNoRegionPath
+		}
+		server := servers[rand.Intn(len(servers))]
+		s.peerLock.RUnlock()
+		return SnapshotRPC(s.connPool, args.Region, server.Addr, args, in, reply)
+	}
+
+	// Forward the request to the leader unless a stale save is allowed
+	if !args.AllowStale || args.Op == structs.SnapshotRestore {
+		isLeader, server := s.getLeader()
+		if !isLeader {
+			if server == nil {
+				return nil, structs.ErrNoLeader
+			}
+			return SnapshotRPC(s.connPool, args.Region, server.Addr, args, in, reply)
+		}
+	}
+
+	// Snapshots contain all the state, including ACL tokens
+	if err := s.checkACL(args.AuthToken, (*acl.ACL).IsManagement); err != nil {
+		return nil, err
+	}
+
+	s.setQueryMeta(&reply.QueryMeta)
+	switch args.Op {
+	case structs.SnapshotSave:
+		var buf bytes.Buffer
+		index, err := s.snapshotSave(&buf)
+		if err != nil {
+			return nil, err
+		}
+		reply.Index = index
+		return ioutil.NopCloser(&buf), nil
+
+	case structs.SnapshotRestore:
+		if err := s.snapshotRestore(in); err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(nil)), nil
+
+	default:
+		return nil, fmt.Errorf("unrecognized snapshot op %d", args.Op)
+	}
+}
+
+// snapshotSave writes an archive of the current state to the writer and
+// returns the index of the saved state.
+func (s *Server) snapshotSave(w io.Writer) (uint64, error) {
+	defer metrics.MeasureSince([]string{"nomad", "snapshot", "save"}, time.Now())
+
+	fsmSnap, err := s.fsm.Snapshot()
+	if err != nil {
+		return 0, fmt.Errorf("failed to snapshot state: %v", err)
+	}
+	defer fsmSnap.Release()
+
+	index, err := fsmSnap.(*nomadSnapshot).snap.LatestIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	sink := &snapshotBuffer{}
+	if err := fsmSnap.Persist(sink); err != nil {
+		return 0, fmt.Errorf("failed to persist state: %v", err)
+	}
+
+	meta := &snapshot.Metadata{
+		Version:    snapshot.Version,
+		Index:      index,
+		CreateTime: time.Now().UTC(),
+	}
+	if err := snapshot.Write(w, meta, sink.Bytes()); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// snapshotRestore verifies the archive read from the reader and replaces the
+// state of all the servers with it. The leadership actions are re-run
+// afterwards so the leader's in-memory state matches the restored state.
+func (s *Server) snapshotRestore(in io.Reader) error {
+	defer metrics.MeasureSince([]string{"nomad", "snapshot", "restore"}, time.Now())
+
+	meta, state, err := snapshot.Read(in)
+	if err != nil {
+		return err
+	}
+
+	req := structs.SnapshotRestoreRequest{State: state}
+	resp, _, err := s.raftApply(structs.SnapshotRestoreRequestType, &req)
+	if err != nil {
+		return fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+	if err, ok := resp.(error); ok && err != nil {
+		return fmt.Errorf("failed to restore snapshot: %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	select {
+	case s.reassertLeaderCh <- errCh:
+	case <-time.After(snapshotReassertTimeout):
+		return fmt.Errorf("timed out reasserting leadership after restore")
+	case <-s.shutdownCh:
+		return fmt.Errorf("server shutting down")
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("failed to reassert leadership after restore: %v", err)
+		}
+	case <-time.After(snapshotReassertTimeout):
+		return fmt.Errorf("timed out reasserting leadership after restore")
+	case <-s.shutdownCh:
+		return fmt.Errorf("server shutting down")
+	}
+
+	s.logger.Printf("[INFO] nomad: restored snapshot of index %d taken at %v", meta.Index, meta.CreateTime)
+	return nil
+}
+
+// SnapshotRPC sends the snapshot request to the given server over a
+// dedicated connection. The input is streamed to the server after the request
+// header, and the returned reader streams the snapshot of a save request. The
+// caller must close the returned reader.
+func SnapshotRPC(pool *ConnPool, region string, addr net.Addr, args *structs.SnapshotRequest,
+	in io.Reader, reply *structs.SnapshotResponse) (io.ReadCloser, error) {
+
+	conn, err := pool.DialTimeout(region, addr, snapshotDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the connection unless the caller takes ownership of it
+	keepConn := false
+	defer func() {
+		if !keepConn {
+			conn.Close()
+		}
+	}()
+
+	// Write the snapshot RPC byte to set the mode, followed by the header and
+	// the input
+	if _, err := conn.Write([]byte{byte(rpcSnapshot)}); err != nil {
+		return nil, fmt.Errorf("failed to write stream type: %v", err)
+	}
+	enc := codec.NewEncoder(conn, structs.MsgpackHandle)
+	if err := enc.Encode(args); err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+	if in != nil {
+		if _, err := io.Copy(conn, in); err != nil {
+			return nil, fmt.Errorf("failed to copy snapshot in: %v", err)
+		}
+	}
+
+	// The size of the input is not known in advance, so the end of it is
+	// signaled by closing the write side of the connection
+	hc, ok := conn.(halfCloser)
+	if !ok {
+		return nil, fmt.Errorf("connection does not support half close")
+	}
+	if err := hc.CloseWrite(); err != nil {
+		return nil, fmt.Errorf("failed to half close snapshot connection: %v", err)
+	}
+
+	// Read the response header, the rest of the connection is the snapshot
+	dec := codec.NewDecoder(conn, structs.MsgpackHandle)
+	if err := dec.Decode(reply); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot response: %v", err)
+	}
+	if reply.Error != "" {
+		return nil, errors.New(reply.Error)
+	}
+
+	keepConn = true
+	return conn, nil
+}
+
+// snapshotBuffer is an in-memory raft.SnapshotSink used to capture the
+// serialized state of the FSM
+type snapshotBuffer struct {
+	bytes.Buffer
+}
+
+func (b *snapshotBuffer) ID() string {
+	return "snapshot"
+}
+
+func (b *snapshotBuffer) Cancel() error {
+	return nil
+}
+
+func (b *snapshotBuffer) Close() error {
+	return nil
+}
@@ -0,0 +1,149 @@
+package nomad
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+)
+
+// snapshotSave takes a snapshot through the given server and returns the
+// archive
+func snapshotSave(t *testing.T, s *Server, token string) []byte {
+	args := structs.SnapshotRequest{
+		Op:        structs.SnapshotSave,
+		Region:    "global",
+		AuthToken: token,
+	}
+	var reply structs.SnapshotResponse
+	snap, err := SnapshotRPC(s.connPool, "global", s.config.RPCAddr, &args, nil, &reply)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer snap.Close()
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, snap); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if reply.Index == 0 {
+		t.Fatalf("bad index: %d", reply.Index)
+	}
+	return buf.Bytes()
+}
+
+func TestSnapshot_SaveRestore(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a job to snapshot
+	state := s1.fsm.State()
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	archive := snapshotSave(t, s1, "")
+
+	// Replace the job with another one
+	other := mock.Job()
+	if err := state.UpsertJob(1001, other); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.DeleteJob(1002, job.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Restore the snapshot
+	args := structs.SnapshotRequest{
+		Op:     structs.SnapshotRestore,
+		Region: "global",
+	}
+	var reply structs.SnapshotResponse
+	snap, err := SnapshotRPC(s1.connPool, "global", s1.config.RPCAddr, &args, bytes.NewReader(archive), &reply)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	snap.Close()
+
+	// The state of the snapshot is back
+	state = s1.fsm.State()
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("job not restored")
+	}
+	out, err = state.JobByID(other.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("unexpected job: %#v", out)
+	}
+}
+
+func TestSnapshot_Restore_Invalid(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	args := structs.SnapshotRequest{
+		Op:     structs.SnapshotRestore,
+		Region: "global",
+	}
+	var reply structs.SnapshotResponse
+	_, err := SnapshotRPC(s1.connPool, "global", s1.config.RPCAddr, &args, bytes.NewReader([]byte("nope")), &reply)
+	if err == nil {
+		t.Fatalf("expected error restoring invalid snapshot")
+	}
+}
+
+func TestSnapshot_ACL(t *testing.T) {
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Anonymous requests are denied
+	args := structs.SnapshotRequest{
+		Op:     structs.SnapshotSave,
+		Region: "global",
+	}
+	var reply structs.SnapshotResponse
+	_, err := SnapshotRPC(s1.connPool, "global", s1.config.RPCAddr, &args, nil, &reply)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// So are client tokens
+	token := createTestToken(t, s1, 1001, `operator { policy = "write" }`)
+	args.AuthToken = token.SecretID
+	_, err = SnapshotRPC(s1.connPool, "global", s1.config.RPCAddr, &args, nil, &reply)
+	if err == nil || err.Error() != structs.ErrPermissionDenied.Error() {
+		t.Fatalf("expected permission denied: %v", err)
+	}
+
+	// Management tokens are allowed
+	snapshotSave(t, s1, root.SecretID)
+}
+
+func TestSnapshot_ForwardLeader(t *testing.T) {
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	testJoin(t, s1, s2)
+	testutil.WaitForLeader(t, s1.RPC)
+	testutil.WaitForLeader(t, s2.RPC)
+
+	// Snapshots can be taken through either server
+	for _, s := range []*Server{s1, s2} {
+		snapshotSave(t, s, "")
+	}
+}
@@ -1,7 +1,9 @@
 package state
 
 import (
+	"encoding/binary"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -20,10 +22,19 @@ func stateStoreSchema() *memdb.DBSchema {
 		nodeTableSchema,
 		jobTableSchema,
 		jobSummarySchema,
+		jobVersionSchema,
 		periodicLaunchTableSchema,
 		evalTableSchema,
 		allocTableSchema,
+		deploymentSchema,
 		vaultAccessorTableSchema,
+		namespaceTableSchema,
+		quotaSpecTableSchema,
+		namespaceUsageTableSchema,
+		aclPolicyTableSchema,
+		aclTokenTableSchema,
+		autopilotConfigTableSchema,
+		schedulerConfigTableSchema,
 	}
 
 	// Add each of the tables
@@ -72,6 +83,17 @@ func nodeTableSchema() *memdb.TableSchema {
 					Field: "ID",
 				},
 			},
+
+			// Secret index is used to authenticate the requests of
+			// clients, which use the secret of their node
+			"secret_id": &memdb.IndexSchema{
+				Name:         "secret_id",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "SecretID",
+				},
+			},
 		},
 	}
 }
@@ -103,6 +125,14 @@ func jobTableSchema() *memdb.TableSchema {
 					Lowercase: false,
 				},
 			},
+			"namespace": &memdb.IndexSchema{
+				Name:         "namespace",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Namespace",
+				},
+			},
 			"gc": &memdb.IndexSchema{
 				Name:         "gc",
 				AllowMissing: false,
@@ -141,6 +171,72 @@ func jobSummarySchema() *memdb.TableSchema {
 	}
 }
 
+// jobVersionSchema returns the memdb schema for the job version table which
+// keeps a historical view of job versions.
+func jobVersionSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "job_version",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer:      &jobVersionIndex{},
+			},
+		},
+	}
+}
+
+// jobVersionIndex indexes jobs by their ID and version. The version is
+// encoded big endian so that the versions of a job sort in order and the
+// versions of a job can be iterated using the job ID as a prefix.
+type jobVersionIndex struct{}
+
+func (j *jobVersionIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	job, ok := obj.(*structs.Job)
+	if !ok {
+		return false, nil, fmt.Errorf("Unexpected type: %v", obj)
+	}
+	if job.ID == "" {
+		return false, nil, nil
+	}
+	return true, jobVersionKey(job.ID, job.Version), nil
+}
+
+func (j *jobVersionIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("must provide two arguments")
+	}
+	id, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string: %#v", args[0])
+	}
+	version, ok := args[1].(uint64)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a uint64: %#v", args[1])
+	}
+	return jobVersionKey(id, version), nil
+}
+
+func (j *jobVersionIndex) PrefixFromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument")
+	}
+	id, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string: %#v", args[0])
+	}
+	return []byte(strings.ToLower(id) + "\x00"), nil
+}
+
+// jobVersionKey returns the index key of the given version of a job
+func jobVersionKey(id string, version uint64) []byte {
+	key := []byte(strings.ToLower(id) + "\x00")
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, version)
+	return append(key, buf...)
+}
+
 // jobIsGCable satisfies the ConditionalIndexFunc interface and creates an index
 // on whether a job is eligible for garbage collection.
 func jobIsGCable(obj interface{}) (bool, error) {
@@ -223,6 +319,36 @@ func evalTableSchema() *memdb.TableSchema {
 	}
 }
 
+// deploymentSchema returns the MemDB schema for the deployment table.
+// This table is used to store the deployments of jobs.
+func deploymentSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "deployment",
+		Indexes: map[string]*memdb.IndexSchema{
+			// Primary index is used for direct lookup.
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "ID",
+				},
+			},
+
+			// Job index is used to lookup deployments by job
+			"job": &memdb.IndexSchema{
+				Name:         "job",
+				AllowMissing: false,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "JobID",
+					Lowercase: true,
+				},
+			},
+		},
+	}
+}
+
 // allocTableSchema returns the MemDB schema for the allocation table.
 // This table is used to store all the task allocations between task groups
 // and nodes.
@@ -289,6 +415,16 @@ func allocTableSchema() *memdb.TableSchema {
 					Field: "EvalID",
 				},
 			},
+
+			// Deployment index is used to lookup allocations by deployment
+			"deployment": &memdb.IndexSchema{
+				Name:         "deployment",
+				AllowMissing: true, // Missing is allowed for allocations outside of deployments
+				Unique:       false,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "DeploymentID",
+				},
+			},
 		},
 	}
 }
@@ -330,3 +466,153 @@ func vaultAccessorTableSchema() *memdb.TableSchema {
 		},
 	}
 }
+
+// namespaceTableSchema returns the MemDB schema for the namespace table.
+// This table is used to store the namespaces jobs are registered in.
+func namespaceTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "namespaces",
+		Indexes: map[string]*memdb.IndexSchema{
+			// Primary index is used for direct lookup.
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Name",
+				},
+			},
+
+			// Quota index is used to lookup the namespaces a quota
+			// specification is attached to
+			"quota": &memdb.IndexSchema{
+				Name:         "quota",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Quota",
+				},
+			},
+		},
+	}
+}
+
+// quotaSpecTableSchema returns the MemDB schema for the quota specification
+// table. This table is used to store the quotas attached to namespaces.
+func quotaSpecTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "quota_specs",
+		Indexes: map[string]*memdb.IndexSchema{
+			// Primary index is used for direct lookup.
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Name",
+				},
+			},
+		},
+	}
+}
+
+// namespaceUsageTableSchema returns the MemDB schema for the namespace usage
+// table. This table is derived from the allocations, so it is not part of the
+// snapshots, and is used to check the quotas without scanning the allocations.
+func namespaceUsageTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "namespace_usage",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Namespace",
+				},
+			},
+		},
+	}
+}
+
+// aclPolicyTableSchema returns the MemDB schema for the policy table.
+// This table is used to store the policies which are referenced by tokens
+func aclPolicyTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "acl_policy",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Name",
+				},
+			},
+		},
+	}
+}
+
+// aclTokenTableSchema returns the MemDB schema for the tokens table.
+// This table is used to store the bearer tokens which are used to authenticate
+func aclTokenTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "acl_token",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "AccessorID",
+				},
+			},
+			"secret": &memdb.IndexSchema{
+				Name:         "secret",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "SecretID",
+				},
+			},
+		},
+	}
+}
+
+// autopilotConfigTableSchema returns the MemDB schema for the Autopilot
+// configuration table. The table holds a single entry with the configuration
+// of the cluster.
+func autopilotConfigTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "autopilot-config",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: true,
+				Unique:       true,
+				Indexer: &memdb.ConditionalIndex{
+					Conditional: func(obj interface{}) (bool, error) { return true, nil },
+				},
+			},
+		},
+	}
+}
+
+// schedulerConfigTableSchema returns the MemDB schema for the scheduler
+// configuration table. The table holds a single entry with the configuration
+// of the cluster.
+func schedulerConfigTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "scheduler-config",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: true,
+				Unique:       true,
+				Indexer: &memdb.ConditionalIndex{
+					Conditional: func(obj interface{}) (bool, error) { return true, nil },
+				},
+			},
+		},
+	}
+}
@@ -18,6 +18,13 @@ type IndexEntry struct {
 	Value uint64
 }
 
+// NamespaceUsage is used with the "namespace_usage" table to keep the
+// resources used by the non-terminal allocations of a namespace.
+type NamespaceUsage struct {
+	Namespace string
+	Used      *structs.QuotaLimit
+}
+
 // The StateStore is responsible for maintaining all the Nomad
 // state. It is manipulated by the FSM which maintains consistency
 // through the use of Raft. The goals of the StateStore are to provide
@@ -45,6 +52,19 @@ func NewStateStore(logOutput io.Writer) (*StateStore, error) {
 		db:     db,
 		watch:  newStateWatch(),
 	}
+
+	// Create the default namespace jobs are registered in when they do not
+	// specify one
+	txn := db.Txn(true)
+	defaultNamespace := &structs.Namespace{
+		Name:        structs.DefaultNamespace,
+		Description: "Default shared namespace",
+	}
+	if err := txn.Insert("namespaces", defaultNamespace); err != nil {
+		txn.Abort()
+		return nil, fmt.Errorf("default namespace insert failed: %v", err)
+	}
+	txn.Commit()
 	return s, nil
 }
 
@@ -144,9 +164,16 @@ func (s *StateStore) UpsertNode(index uint64, node *structs.Node) error {
 		node.CreateIndex = exist.CreateIndex
 		node.ModifyIndex = index
 		node.Drain = exist.Drain // Retain the drain mode
+		node.SchedulingEligibility = exist.SchedulingEligibility
+		node.IneligibleUntil = exist.IneligibleUntil
 	} else {
 		node.CreateIndex = index
 		node.ModifyIndex = index
+
+		// Nodes are eligible for scheduling when they first register
+		if node.SchedulingEligibility == "" {
+			node.SchedulingEligibility = structs.NodeSchedulingEligible
+		}
 	}
 
 	// Insert the node
@@ -234,7 +261,7 @@ func (s *StateStore) UpdateNodeStatus(index uint64, nodeID, status string) error
 }
 
 // UpdateNodeDrain is used to update the drain of a node
-func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool) error {
+func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool, strategy *structs.DrainStrategy) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
@@ -256,8 +283,56 @@ func (s *StateStore) UpdateNodeDrain(index uint64, nodeID string, drain bool) er
 	copyNode := new(structs.Node)
 	*copyNode = *existingNode
 
-	// Update the drain in the copy
+	// Update the drain in the copy. The strategy is only kept while the node
+	// is draining.
 	copyNode.Drain = drain
+	copyNode.DrainStrategy = nil
+	if drain {
+		copyNode.DrainStrategy = strategy
+	}
+	copyNode.ModifyIndex = index
+
+	// Insert the node
+	if err := txn.Insert("nodes", copyNode); err != nil {
+		return fmt.Errorf("node update failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"nodes", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// UpdateNodeEligibility is used to update the scheduling eligibility of a node.
+// ineligibleUntil is recorded on the node, and zero for any update not made by
+// the plan rejection tracker.
+func (s *StateStore) UpdateNodeEligibility(index uint64, nodeID string, eligibility string, ineligibleUntil int64) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "nodes"})
+	watcher.Add(watch.Item{Node: nodeID})
+
+	// Lookup the node
+	existing, err := txn.First("nodes", "id", nodeID)
+	if err != nil {
+		return fmt.Errorf("node lookup failed: %v", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("node not found")
+	}
+
+	// Copy the existing node
+	existingNode := existing.(*structs.Node)
+	copyNode := new(structs.Node)
+	*copyNode = *existingNode
+
+	// Update the eligibility in the copy
+	copyNode.SchedulingEligibility = eligibility
+	copyNode.IneligibleUntil = ineligibleUntil
 	copyNode.ModifyIndex = index
 
 	// Insert the node
@@ -288,6 +363,21 @@ func (s *StateStore) NodeByID(nodeID string) (*structs.Node, error) {
 	return nil, nil
 }
 
+// NodeBySecretID is used to lookup a node by the secret it authenticates with
+func (s *StateStore) NodeBySecretID(secretID string) (*structs.Node, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("nodes", "secret_id", secretID)
+	if err != nil {
+		return nil, fmt.Errorf("node lookup by secret failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Node), nil
+	}
+	return nil, nil
+}
+
 // NodesByIDPrefix is used to lookup nodes by prefix
 func (s *StateStore) NodesByIDPrefix(nodeID string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
@@ -318,20 +408,42 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 	defer txn.Abort()
 
 	watcher := watch.NewItems()
+	if err := s.upsertJobImpl(index, job, watcher, txn); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// upsertJobImpl is the implementation for registering a job or updating a job
+// definition within a transaction
+func (s *StateStore) upsertJobImpl(index uint64, job *structs.Job, watcher watch.Items, txn *memdb.Txn) error {
 	watcher.Add(watch.Item{Table: "jobs"})
 	watcher.Add(watch.Item{Job: job.ID})
 
+	// Jobs registered before namespaces existed belong to the default
+	// namespace
+	if job.Namespace == "" {
+		job.Namespace = structs.DefaultNamespace
+	}
+
 	// Check if the job already exists
 	existing, err := txn.First("jobs", "id", job.ID)
 	if err != nil {
 		return fmt.Errorf("job lookup failed: %v", err)
 	}
 
+	// A new version of the job is only stable once deployed successfully
+	job.Stable = false
+
 	// Setup the indexes correctly
 	if existing != nil {
 		job.CreateIndex = existing.(*structs.Job).CreateIndex
 		job.ModifyIndex = index
 		job.JobModifyIndex = index
+		job.Version = existing.(*structs.Job).Version + 1
 
 		// Compute the job status
 		var err error
@@ -343,10 +455,11 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 		job.CreateIndex = index
 		job.ModifyIndex = index
 		job.JobModifyIndex = index
+		job.Version = 0
 
 		// If we are inserting the job for the first time, we don't need to
 		// calculate the jobs status as it is known.
-		if job.IsPeriodic() {
+		if job.IsPeriodic() || job.IsParameterized() {
 			job.Status = structs.JobStatusRunning
 		} else {
 			job.Status = structs.JobStatusPending
@@ -361,6 +474,11 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 	// COMPAT 0.4.1 -> 0.5
 	s.addEphemeralDiskToTaskGroups(job)
 
+	// Track the version of the job
+	if err := s.upsertJobVersion(index, job, txn); err != nil {
+		return fmt.Errorf("unable to upsert job into job_version table: %v", err)
+	}
+
 	// Insert the job
 	if err := txn.Insert("jobs", job); err != nil {
 		return fmt.Errorf("job insert failed: %v", err)
@@ -368,9 +486,35 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 	if err := txn.Insert("index", &IndexEntry{"jobs", index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
+	return nil
+}
 
-	txn.Defer(func() { s.watch.notify(watcher) })
-	txn.Commit()
+// upsertJobVersion inserts a job into its historic version table and limits
+// the number of job versions that are tracked.
+func (s *StateStore) upsertJobVersion(index uint64, job *structs.Job, txn *memdb.Txn) error {
+	// Insert the job
+	if err := txn.Insert("job_version", job); err != nil {
+		return fmt.Errorf("failed to insert job into job_version table: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	// Get all the historic jobs for this ID
+	all, err := s.jobVersionByID(txn, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to look up job versions for %q: %v", job.ID, err)
+	}
+
+	// Delete the oldest versions beyond the number we track
+	if len(all) <= structs.JobTrackedVersions {
+		return nil
+	}
+	for _, old := range all[structs.JobTrackedVersions:] {
+		if err := txn.Delete("job_version", old); err != nil {
+			return fmt.Errorf("failed to delete job %q version %d: %v", old.ID, old.Version, err)
+		}
+	}
 	return nil
 }
 
@@ -410,6 +554,14 @@ func (s *StateStore) DeleteJob(index uint64, jobID string) error {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
+	// Delete the tracked versions of the job
+	if _, err = txn.DeleteAll("job_version", "id_prefix", jobID); err != nil {
+		return fmt.Errorf("deleting job versions failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
 	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
@@ -430,6 +582,66 @@ func (s *StateStore) JobByID(id string) (*structs.Job, error) {
 	return nil, nil
 }
 
+// JobVersionsByID returns all the tracked versions of a job, sorted from
+// newest to oldest.
+func (s *StateStore) JobVersionsByID(id string) ([]*structs.Job, error) {
+	txn := s.db.Txn(false)
+	return s.jobVersionByID(txn, id)
+}
+
+// jobVersionByID is the underlying implementation for retrieving all tracked
+// versions of a job and is useful if the caller already has a transaction.
+func (s *StateStore) jobVersionByID(txn *memdb.Txn, id string) ([]*structs.Job, error) {
+	// Get all the historic jobs for this ID
+	iter, err := txn.Get("job_version", "id_prefix", id)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []*structs.Job
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		all = append(all, raw.(*structs.Job))
+	}
+
+	// The index sorts the versions in ascending order so reverse them to
+	// return the newest version first.
+	for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+		all[i], all[j] = all[j], all[i]
+	}
+	return all, nil
+}
+
+// JobByIDAndVersion returns the job identified by its ID and version
+func (s *StateStore) JobByIDAndVersion(id string, version uint64) (*structs.Job, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("job_version", "id", id, version)
+	if err != nil {
+		return nil, fmt.Errorf("job version lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Job), nil
+	}
+	return nil, nil
+}
+
+// JobVersions returns an iterator over all the tracked job versions
+func (s *StateStore) JobVersions() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire job_version table
+	iter, err := txn.Get("job_version", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
 // JobsByIDPrefix is used to lookup a job by prefix
 func (s *StateStore) JobsByIDPrefix(id string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
@@ -454,6 +666,17 @@ func (s *StateStore) Jobs() (memdb.ResultIterator, error) {
 	return iter, nil
 }
 
+// JobsByNamespace returns an iterator over all the jobs of a namespace
+func (s *StateStore) JobsByNamespace(namespace string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("jobs", "namespace", namespace)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
 // JobsByPeriodic returns an iterator over all the periodic or non-periodic jobs.
 func (s *StateStore) JobsByPeriodic(periodic bool) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
@@ -753,6 +976,9 @@ func (s *StateStore) DeleteEval(index uint64, evals []string, allocs []string) e
 			return fmt.Errorf("alloc delete failed: %v", err)
 		}
 		realAlloc := existing.(*structs.Allocation)
+		if err := updateNamespaceUsage(txn, nil, realAlloc); err != nil {
+			return err
+		}
 		watcher.Add(watch.Item{Alloc: realAlloc.ID})
 		watcher.Add(watch.Item{AllocEval: realAlloc.EvalID})
 		watcher.Add(watch.Item{AllocJob: realAlloc.JobID})
@@ -895,6 +1121,7 @@ func (s *StateStore) nestedUpdateAllocFromClient(txn *memdb.Txn, watcher watch.I
 	copyAlloc.ClientStatus = alloc.ClientStatus
 	copyAlloc.ClientDescription = alloc.ClientDescription
 	copyAlloc.TaskStates = alloc.TaskStates
+	copyAlloc.DeploymentStatus = alloc.DeploymentStatus
 
 	// Update the modify index
 	copyAlloc.ModifyIndex = index
@@ -902,6 +1129,9 @@ func (s *StateStore) nestedUpdateAllocFromClient(txn *memdb.Txn, watcher watch.I
 	if err := s.updateSummaryWithAlloc(index, copyAlloc, exist, watcher, txn); err != nil {
 		return fmt.Errorf("error updating job summary: %v", err)
 	}
+	if err := updateNamespaceUsage(txn, copyAlloc, exist); err != nil {
+		return err
+	}
 
 	// Update the allocation
 	if err := txn.Insert("allocs", copyAlloc); err != nil {
@@ -920,6 +1150,57 @@ func (s *StateStore) nestedUpdateAllocFromClient(txn *memdb.Txn, watcher watch.I
 	return nil
 }
 
+// UpdateAllocsDesiredTransitions is used to set the desired transitions of a
+// set of allocations and create the evaluations processing them.
+func (s *StateStore) UpdateAllocsDesiredTransitions(index uint64, allocs map[string]*structs.DesiredTransition,
+	evals []*structs.Evaluation) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "allocs"})
+
+	for id, transition := range allocs {
+		existing, err := txn.First("allocs", "id", id)
+		if err != nil {
+			return fmt.Errorf("alloc lookup failed: %v", err)
+		}
+		if existing == nil {
+			continue
+		}
+		exist := existing.(*structs.Allocation)
+
+		copyAlloc := new(structs.Allocation)
+		*copyAlloc = *exist
+		copyAlloc.DesiredTransition = *transition
+		copyAlloc.ModifyIndex = index
+		copyAlloc.AllocModifyIndex = index
+
+		if err := txn.Insert("allocs", copyAlloc); err != nil {
+			return fmt.Errorf("alloc insert failed: %v", err)
+		}
+
+		watcher.Add(watch.Item{Alloc: exist.ID})
+		watcher.Add(watch.Item{AllocEval: exist.EvalID})
+		watcher.Add(watch.Item{AllocJob: exist.JobID})
+		watcher.Add(watch.Item{AllocNode: exist.NodeID})
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	for _, eval := range evals {
+		if err := s.nestedUpsertEvalWithWatch(txn, watcher, index, eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
 // UpsertAllocs is used to evict a set of allocations
 // and allocate new ones at the same time.
 func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) error {
@@ -958,6 +1239,10 @@ func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) er
 			if alloc.Job == nil {
 				alloc.Job = exist.Job
 			}
+
+			// The desired transition is only set by the drainer so a stale
+			// copy of the allocation must not reset it.
+			alloc.DesiredTransition = exist.DesiredTransition
 		}
 
 		if err := s.updateSummaryWithAlloc(index, alloc, exist, watcher, txn); err != nil {
@@ -970,6 +1255,9 @@ func (s *StateStore) UpsertAllocs(index uint64, allocs []*structs.Allocation) er
 			s.addEphemeralDiskToTaskGroups(alloc.Job)
 		}
 
+		if err := updateNamespaceUsage(txn, alloc, exist); err != nil {
+			return err
+		}
 		if err := txn.Insert("allocs", alloc); err != nil {
 			return fmt.Errorf("alloc insert failed: %v", err)
 		}
@@ -1114,6 +1402,27 @@ func (s *StateStore) AllocsByEval(evalID string) ([]*structs.Allocation, error)
 	return out, nil
 }
 
+// AllocsByDeployment returns all the allocations by deployment id
+func (s *StateStore) AllocsByDeployment(deploymentID string) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the deployment allocations
+	iter, err := txn.Get("allocs", "deployment", deploymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
 // Allocs returns an iterator over all the evaluations
 func (s *StateStore) Allocs() (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
@@ -1126,117 +1435,1283 @@ func (s *StateStore) Allocs() (memdb.ResultIterator, error) {
 	return iter, nil
 }
 
-// UpsertVaultAccessors is used to register a set of Vault Accessors
-func (s *StateStore) UpsertVaultAccessor(index uint64, accessors []*structs.VaultAccessor) error {
+// UpsertDeployment is used to insert or update a deployment along with any
+// status updates to other deployments of the same plan.
+func (s *StateStore) UpsertDeployment(index uint64, deployment *structs.Deployment, updates []*structs.DeploymentStatusUpdate) error {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
-	for _, accessor := range accessors {
-		// Set the create index
-		accessor.CreateIndex = index
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "deployment"})
 
-		// Insert the accessor
-		if err := txn.Insert("vault_accessors", accessor); err != nil {
-			return fmt.Errorf("accessor insert failed: %v", err)
+	if deployment != nil {
+		if err := s.nestedUpsertDeployment(txn, watcher, index, deployment); err != nil {
+			return err
 		}
 	}
 
-	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
-		return fmt.Errorf("index update failed: %v", err)
+	for _, u := range updates {
+		if err := s.nestedUpdateDeploymentStatus(txn, watcher, index, u); err != nil {
+			return err
+		}
 	}
 
+	txn.Defer(func() { s.watch.notify(watcher) })
 	txn.Commit()
 	return nil
 }
 
-// DeleteVaultAccessors is used to delete a set of Vault Accessors
-func (s *StateStore) DeleteVaultAccessors(index uint64, accessors []*structs.VaultAccessor) error {
-	txn := s.db.Txn(true)
-	defer txn.Abort()
+// nestedUpsertDeployment is used to nest a deployment upsert within a
+// transaction
+func (s *StateStore) nestedUpsertDeployment(txn *memdb.Txn, watcher watch.Items, index uint64, deployment *structs.Deployment) error {
+	existing, err := txn.First("deployment", "id", deployment.ID)
+	if err != nil {
+		return fmt.Errorf("deployment lookup failed: %v", err)
+	}
 
-	// Lookup the accessor
-	for _, accessor := range accessors {
-		// Delete the accessor
-		if err := txn.Delete("vault_accessors", accessor); err != nil {
-			return fmt.Errorf("accessor delete failed: %v", err)
-		}
+	// Setup the indexes correctly
+	if existing != nil {
+		deployment.CreateIndex = existing.(*structs.Deployment).CreateIndex
+		deployment.ModifyIndex = index
+	} else {
+		deployment.CreateIndex = index
+		deployment.ModifyIndex = index
 	}
 
-	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
+	if err := txn.Insert("deployment", deployment); err != nil {
+		return fmt.Errorf("deployment insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
 		return fmt.Errorf("index update failed: %v", err)
 	}
 
-	txn.Commit()
+	watcher.Add(watch.Item{Deployment: deployment.ID})
+	watcher.Add(watch.Item{DeploymentJob: deployment.JobID})
 	return nil
 }
 
-// VaultAccessor returns the given Vault accessor
-func (s *StateStore) VaultAccessor(accessor string) (*structs.VaultAccessor, error) {
-	txn := s.db.Txn(false)
-
-	existing, err := txn.First("vault_accessors", "id", accessor)
+// nestedUpdateDeploymentStatus is used to nest a deployment status update
+// within a transaction
+func (s *StateStore) nestedUpdateDeploymentStatus(txn *memdb.Txn, watcher watch.Items, index uint64, u *structs.DeploymentStatusUpdate) error {
+	existing, err := txn.First("deployment", "id", u.DeploymentID)
 	if err != nil {
-		return nil, fmt.Errorf("accessor lookup failed: %v", err)
+		return fmt.Errorf("deployment lookup failed: %v", err)
 	}
-
-	if existing != nil {
-		return existing.(*structs.VaultAccessor), nil
+	if existing == nil {
+		return fmt.Errorf("deployment %q not found", u.DeploymentID)
 	}
 
-	return nil, nil
+	// Copy the existing deployment so the readers of the old object are
+	// unaffected
+	updated := existing.(*structs.Deployment).Copy()
+	updated.Status = u.Status
+	updated.StatusDescription = u.StatusDescription
+	return s.nestedUpsertDeployment(txn, watcher, index, updated)
 }
 
-// VaultAccessors returns an iterator of Vault accessors.
-func (s *StateStore) VaultAccessors() (memdb.ResultIterator, error) {
-	txn := s.db.Txn(false)
-
-	iter, err := txn.Get("vault_accessors", "id")
-	if err != nil {
-		return nil, err
-	}
-	return iter, nil
-}
+// UpdateDeploymentStatus is used to update the status of a deployment and
+// optionally create an evaluation atomically.
+func (s *StateStore) UpdateDeploymentStatus(index uint64, req *structs.DeploymentStatusUpdateRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
 
-// VaultAccessorsByAlloc returns all the Vault accessors by alloc id
-func (s *StateStore) VaultAccessorsByAlloc(allocID string) ([]*structs.VaultAccessor, error) {
-	txn := s.db.Txn(false)
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "deployment"})
 
-	// Get an iterator over the accessors
-	iter, err := txn.Get("vault_accessors", "alloc_id", allocID)
-	if err != nil {
-		return nil, err
+	if err := s.nestedUpdateDeploymentStatus(txn, watcher, index, req.DeploymentUpdate); err != nil {
+		return err
 	}
 
-	var out []*structs.VaultAccessor
+	// Upsert the optional eval
+	if req.Eval != nil {
+		if err := s.nestedUpsertEvalWithWatch(txn, watcher, index, req.Eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// UpdateDeploymentPromotion is used to promote the canaries of a deployment
+// and create the evaluation that continues the rollout atomically.
+func (s *StateStore) UpdateDeploymentPromotion(index uint64, req *structs.ApplyDeploymentPromoteRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "deployment"})
+
+	existing, err := txn.First("deployment", "id", req.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("deployment lookup failed: %v", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("deployment %q not found", req.DeploymentID)
+	}
+	deployment := existing.(*structs.Deployment)
+	if !deployment.Active() {
+		return fmt.Errorf("deployment %q has terminal status %q", deployment.ID, deployment.Status)
+	}
+
+	// Determine the groups to promote
+	groups := make(map[string]struct{}, len(req.Groups))
+	for _, g := range req.Groups {
+		groups[g] = struct{}{}
+	}
+
+	updated := deployment.Copy()
+	for name, group := range updated.TaskGroups {
+		if _, ok := groups[name]; !req.All && !ok {
+			continue
+		}
+		group.Promoted = true
+	}
+
+	if !updated.RequiresPromotion() {
+		updated.StatusDescription = structs.DeploymentStatusDescriptionRunning
+	}
+
+	if err := s.nestedUpsertDeployment(txn, watcher, index, updated); err != nil {
+		return err
+	}
+
+	// Upsert the optional eval
+	if req.Eval != nil {
+		if err := s.nestedUpsertEvalWithWatch(txn, watcher, index, req.Eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// UpdateDeploymentAllocHealth is used to record the health of the
+// allocations of a deployment and optionally update its status, revert the
+// job and create an evaluation atomically.
+func (s *StateStore) UpdateDeploymentAllocHealth(index uint64, req *structs.ApplyDeploymentAllocHealthRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "deployment"})
+
+	existing, err := txn.First("deployment", "id", req.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("deployment lookup failed: %v", err)
+	}
+	if existing == nil {
+		return fmt.Errorf("deployment %q not found", req.DeploymentID)
+	}
+
+	updated := existing.(*structs.Deployment).Copy()
+	for name, group := range updated.TaskGroups {
+		group.HealthyAllocs = req.HealthyAllocs[name]
+		group.UnhealthyAllocs = req.UnhealthyAllocs[name]
+	}
+	if u := req.DeploymentUpdate; u != nil {
+		updated.Status = u.Status
+		updated.StatusDescription = u.StatusDescription
+	}
+	if err := s.nestedUpsertDeployment(txn, watcher, index, updated); err != nil {
+		return err
+	}
+
+	// The deployed version of the job is stable once the deployment succeeds
+	if updated.Status == structs.DeploymentStatusSuccessful {
+		if err := s.nestedSetJobStable(txn, watcher, index, updated); err != nil {
+			return err
+		}
+	}
+
+	// Revert the job
+	if req.Job != nil {
+		if err := s.upsertJobImpl(index, req.Job, watcher, txn); err != nil {
+			return err
+		}
+	}
+
+	// Upsert the optional eval
+	if req.Eval != nil {
+		if err := s.nestedUpsertEvalWithWatch(txn, watcher, index, req.Eval); err != nil {
+			return err
+		}
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// nestedSetJobStable marks the version of the job deployed by the deployment
+// as stable within a transaction.
+func (s *StateStore) nestedSetJobStable(txn *memdb.Txn, watcher watch.Items, index uint64, d *structs.Deployment) error {
+	versions, err := s.jobVersionByID(txn, d.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to look up job versions for %q: %v", d.JobID, err)
+	}
+
+	for _, job := range versions {
+		if job.CreateIndex != d.JobCreateIndex || job.JobModifyIndex != d.JobModifyIndex || job.Stable {
+			continue
+		}
+
+		// Copy the version so the readers of the old object are unaffected
+		stable := job.Copy()
+		stable.Stable = true
+		stable.ModifyIndex = index
+		if err := txn.Insert("job_version", stable); err != nil {
+			return fmt.Errorf("failed to update job %q version %d: %v", job.ID, job.Version, err)
+		}
+		if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
+			return fmt.Errorf("index update failed: %v", err)
+		}
+	}
+
+	existing, err := txn.First("jobs", "id", d.JobID)
+	if err != nil {
+		return fmt.Errorf("job lookup failed: %v", err)
+	}
+	if existing == nil {
+		return nil
+	}
+	job := existing.(*structs.Job)
+	if job.CreateIndex != d.JobCreateIndex || job.JobModifyIndex != d.JobModifyIndex || job.Stable {
+		return nil
+	}
+
+	stable := job.Copy()
+	stable.Stable = true
+	stable.ModifyIndex = index
+	if err := txn.Insert("jobs", stable); err != nil {
+		return fmt.Errorf("job insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"jobs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	watcher.Add(watch.Item{Table: "jobs"})
+	watcher.Add(watch.Item{Job: d.JobID})
+	return nil
+}
+
+// nestedUpsertEvalWithWatch upserts an evaluation within a transaction and
+// adds the watch items of the evaluation and its job.
+func (s *StateStore) nestedUpsertEvalWithWatch(txn *memdb.Txn, watcher watch.Items, index uint64, eval *structs.Evaluation) error {
+	watcher.Add(watch.Item{Table: "evals"})
+	watcher.Add(watch.Item{Eval: eval.ID})
+	watcher.Add(watch.Item{EvalJob: eval.JobID})
+	if err := s.nestedUpsertEval(txn, index, eval); err != nil {
+		return err
+	}
+
+	jobs := map[string]string{eval.JobID: ""}
+	if err := s.setJobStatuses(index, watcher, txn, jobs, false); err != nil {
+		return fmt.Errorf("setting job status failed: %v", err)
+	}
+	return nil
+}
+
+// DeleteDeployment is used to delete a set of deployments
+func (s *StateStore) DeleteDeployment(index uint64, deploymentIDs []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "deployment"})
+
+	for _, id := range deploymentIDs {
+		existing, err := txn.First("deployment", "id", id)
+		if err != nil {
+			return fmt.Errorf("deployment lookup failed: %v", err)
+		}
+		if existing == nil {
+			continue
+		}
+		if err := txn.Delete("deployment", existing); err != nil {
+			return fmt.Errorf("deployment delete failed: %v", err)
+		}
+		watcher.Add(watch.Item{Deployment: id})
+		watcher.Add(watch.Item{DeploymentJob: existing.(*structs.Deployment).JobID})
+	}
+
+	// Update the index
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// DeploymentByID is used to lookup a deployment by its ID
+func (s *StateStore) DeploymentByID(id string) (*structs.Deployment, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("deployment", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("deployment lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Deployment), nil
+	}
+	return nil, nil
+}
+
+// DeploymentsByIDPrefix is used to lookup deployments by prefix
+func (s *StateStore) DeploymentsByIDPrefix(id string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("deployment", "id_prefix", id)
+	if err != nil {
+		return nil, fmt.Errorf("deployment lookup failed: %v", err)
+	}
+
+	return iter, nil
+}
+
+// DeploymentsByJobID returns all the deployments of the given job
+func (s *StateStore) DeploymentsByJobID(jobID string) ([]*structs.Deployment, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("deployment", "job", jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Deployment
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Deployment))
+	}
+	return out, nil
+}
+
+// LatestDeploymentByJobID returns the most recently created deployment of
+// the given job
+func (s *StateStore) LatestDeploymentByJobID(jobID string) (*structs.Deployment, error) {
+	deployments, err := s.DeploymentsByJobID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *structs.Deployment
+	for _, d := range deployments {
+		if latest == nil || d.CreateIndex > latest.CreateIndex {
+			latest = d
+		}
+	}
+	return latest, nil
+}
+
+// Deployments returns an iterator over all the deployments
+func (s *StateStore) Deployments() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("deployment", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// UpsertVaultAccessors is used to register a set of Vault Accessors
+func (s *StateStore) UpsertVaultAccessor(index uint64, accessors []*structs.VaultAccessor) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, accessor := range accessors {
+		// Set the create index
+		accessor.CreateIndex = index
+
+		// Insert the accessor
+		if err := txn.Insert("vault_accessors", accessor); err != nil {
+			return fmt.Errorf("accessor insert failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// DeleteVaultAccessors is used to delete a set of Vault Accessors
+func (s *StateStore) DeleteVaultAccessors(index uint64, accessors []*structs.VaultAccessor) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// Lookup the accessor
+	for _, accessor := range accessors {
+		// Delete the accessor
+		if err := txn.Delete("vault_accessors", accessor); err != nil {
+			return fmt.Errorf("accessor delete failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"vault_accessors", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// VaultAccessor returns the given Vault accessor
+func (s *StateStore) VaultAccessor(accessor string) (*structs.VaultAccessor, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("vault_accessors", "id", accessor)
+	if err != nil {
+		return nil, fmt.Errorf("accessor lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.VaultAccessor), nil
+	}
+
+	return nil, nil
+}
+
+// VaultAccessors returns an iterator of Vault accessors.
+func (s *StateStore) VaultAccessors() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("vault_accessors", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// VaultAccessorsByAlloc returns all the Vault accessors by alloc id
+func (s *StateStore) VaultAccessorsByAlloc(allocID string) ([]*structs.VaultAccessor, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the accessors
+	iter, err := txn.Get("vault_accessors", "alloc_id", allocID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.VaultAccessor
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.VaultAccessor))
+	}
+	return out, nil
+}
+
+// VaultAccessorsByNode returns all the Vault accessors by node id
+func (s *StateStore) VaultAccessorsByNode(nodeID string) ([]*structs.VaultAccessor, error) {
+	txn := s.db.Txn(false)
+
+	// Get an iterator over the accessors
+	iter, err := txn.Get("vault_accessors", "node_id", nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.VaultAccessor
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.VaultAccessor))
+	}
+	return out, nil
+}
+
+// UpsertNamespaces is used to create or update a set of namespaces
+func (s *StateStore) UpsertNamespaces(index uint64, namespaces []*structs.Namespace) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "namespaces"})
+
+	for _, ns := range namespaces {
+		existing, err := txn.First("namespaces", "id", ns.Name)
+		if err != nil {
+			return fmt.Errorf("namespace lookup failed: %v", err)
+		}
+
+		// Setup the indexes correctly
+		if existing != nil {
+			ns.CreateIndex = existing.(*structs.Namespace).CreateIndex
+			ns.ModifyIndex = index
+		} else {
+			ns.CreateIndex = index
+			ns.ModifyIndex = index
+		}
+
+		if err := txn.Insert("namespaces", ns); err != nil {
+			return fmt.Errorf("namespace insert failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"namespaces", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// DeleteNamespaces is used to delete a set of namespaces
+func (s *StateStore) DeleteNamespaces(index uint64, names []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "namespaces"})
+
+	for _, name := range names {
+		existing, err := txn.First("namespaces", "id", name)
+		if err != nil {
+			return fmt.Errorf("namespace lookup failed: %v", err)
+		}
+		if existing == nil {
+			return fmt.Errorf("namespace not found")
+		}
+
+		if err := txn.Delete("namespaces", existing); err != nil {
+			return fmt.Errorf("namespace delete failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"namespaces", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// NamespaceByName is used to lookup a namespace by its name
+func (s *StateStore) NamespaceByName(name string) (*structs.Namespace, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("namespaces", "id", name)
+	if err != nil {
+		return nil, fmt.Errorf("namespace lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.Namespace), nil
+	}
+	return nil, nil
+}
+
+// NamespacesByNamePrefix is used to lookup namespaces by prefix
+func (s *StateStore) NamespacesByNamePrefix(prefix string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("namespaces", "id_prefix", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("namespace lookup failed: %v", err)
+	}
+	return iter, nil
+}
+
+// NamespacesByQuota returns the namespaces a quota specification is
+// attached to
+func (s *StateStore) NamespacesByQuota(quota string) ([]*structs.Namespace, error) {
+	txn := s.db.Txn(false)
+	return s.namespacesByQuotaImpl(txn, quota)
+}
+
+func (s *StateStore) namespacesByQuotaImpl(txn *memdb.Txn, quota string) ([]*structs.Namespace, error) {
+	iter, err := txn.Get("namespaces", "quota", quota)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*structs.Namespace
 	for {
 		raw := iter.Next()
 		if raw == nil {
 			break
 		}
-		out = append(out, raw.(*structs.VaultAccessor))
+		out = append(out, raw.(*structs.Namespace))
+	}
+	return out, nil
+}
+
+// Namespaces returns an iterator over all the namespaces
+func (s *StateStore) Namespaces() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("namespaces", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// UpsertQuotaSpecs is used to create or update a set of quota specifications
+func (s *StateStore) UpsertQuotaSpecs(index uint64, quotas []*structs.QuotaSpec) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "quota_specs"})
+
+	for _, quota := range quotas {
+		existing, err := txn.First("quota_specs", "id", quota.Name)
+		if err != nil {
+			return fmt.Errorf("quota specification lookup failed: %v", err)
+		}
+
+		// Setup the indexes correctly
+		if existing != nil {
+			quota.CreateIndex = existing.(*structs.QuotaSpec).CreateIndex
+			quota.ModifyIndex = index
+		} else {
+			quota.CreateIndex = index
+			quota.ModifyIndex = index
+		}
+
+		if err := txn.Insert("quota_specs", quota); err != nil {
+			return fmt.Errorf("quota specification insert failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"quota_specs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// DeleteQuotaSpecs is used to delete a set of quota specifications
+func (s *StateStore) DeleteQuotaSpecs(index uint64, names []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "quota_specs"})
+
+	for _, name := range names {
+		existing, err := txn.First("quota_specs", "id", name)
+		if err != nil {
+			return fmt.Errorf("quota specification lookup failed: %v", err)
+		}
+		if existing == nil {
+			return fmt.Errorf("quota specification not found")
+		}
+
+		if err := txn.Delete("quota_specs", existing); err != nil {
+			return fmt.Errorf("quota specification delete failed: %v", err)
+		}
+	}
+
+	if err := txn.Insert("index", &IndexEntry{"quota_specs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// QuotaSpecByName is used to lookup a quota specification by its name
+func (s *StateStore) QuotaSpecByName(name string) (*structs.QuotaSpec, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("quota_specs", "id", name)
+	if err != nil {
+		return nil, fmt.Errorf("quota specification lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.QuotaSpec), nil
+	}
+	return nil, nil
+}
+
+// QuotaSpecsByNamePrefix is used to lookup quota specifications by prefix
+func (s *StateStore) QuotaSpecsByNamePrefix(prefix string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("quota_specs", "id_prefix", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("quota specification lookup failed: %v", err)
+	}
+	return iter, nil
+}
+
+// QuotaSpecs returns an iterator over all the quota specifications
+func (s *StateStore) QuotaSpecs() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("quota_specs", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// QuotaUsage returns the resources used by the non-terminal allocations of
+// the namespaces a quota specification is attached to
+func (s *StateStore) QuotaUsage(quota string) (*structs.QuotaUsage, error) {
+	txn := s.db.Txn(false)
+
+	namespaces, err := s.namespacesByQuotaImpl(txn, quota)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &structs.QuotaUsage{
+		Name: quota,
+		Used: new(structs.QuotaLimit),
+	}
+	for _, ns := range namespaces {
+		usage.Namespaces = append(usage.Namespaces, ns.Name)
+
+		raw, err := txn.First("namespace_usage", "id", ns.Name)
+		if err != nil {
+			return nil, fmt.Errorf("namespace usage lookup failed: %v", err)
+		}
+		if raw == nil {
+			continue
+		}
+		used := raw.(*NamespaceUsage).Used
+		usage.Used.CPU += used.CPU
+		usage.Used.MemoryMB += used.MemoryMB
+		usage.Used.Count += used.Count
+	}
+	return usage, nil
+}
+
+// updateNamespaceUsage replaces the resources of the existing version of an
+// allocation by the ones of the allocation in the usage of its namespace.
+// Either may be nil, and terminal allocations use no resources.
+func updateNamespaceUsage(txn *memdb.Txn, alloc, existing *structs.Allocation) error {
+	if err := addNamespaceUsage(txn, existing, -1); err != nil {
+		return err
+	}
+	return addNamespaceUsage(txn, alloc, 1)
+}
+
+func addNamespaceUsage(txn *memdb.Txn, alloc *structs.Allocation, sign int) error {
+	if alloc == nil || alloc.Job == nil || alloc.TerminalStatus() {
+		return nil
+	}
+
+	raw, err := txn.First("namespace_usage", "id", alloc.Job.Namespace)
+	if err != nil {
+		return fmt.Errorf("namespace usage lookup failed: %v", err)
+	}
+	usage := &NamespaceUsage{
+		Namespace: alloc.Job.Namespace,
+		Used:      new(structs.QuotaLimit),
+	}
+	if raw != nil {
+		usage.Used = raw.(*NamespaceUsage).Used.Copy()
+	}
+	if sign > 0 {
+		usage.Used.AddAlloc(alloc)
+	} else {
+		usage.Used.SubtractAlloc(alloc)
+	}
+
+	if err := txn.Insert("namespace_usage", usage); err != nil {
+		return fmt.Errorf("namespace usage update failed: %v", err)
+	}
+	return nil
+}
+
+// UpsertACLPolicies is used to create or update a set of ACL policies
+func (s *StateStore) UpsertACLPolicies(index uint64, policies []*structs.ACLPolicy) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "acl_policy"})
+
+	for _, policy := range policies {
+		// Ensure the policy hash is non-nil. This should be done outside the state store
+		// for performance reasons, but we check here for defense in depth.
+		if len(policy.Hash) == 0 {
+			policy.SetHash()
+		}
+
+		// Check if the policy already exists
+		existing, err := txn.First("acl_policy", "id", policy.Name)
+		if err != nil {
+			return fmt.Errorf("policy lookup failed: %v", err)
+		}
+
+		// Update all the indexes
+		if existing != nil {
+			policy.CreateIndex = existing.(*structs.ACLPolicy).CreateIndex
+			policy.ModifyIndex = index
+		} else {
+			policy.CreateIndex = index
+			policy.ModifyIndex = index
+		}
+
+		// Update the policy
+		if err := txn.Insert("acl_policy", policy); err != nil {
+			return fmt.Errorf("upserting policy failed: %v", err)
+		}
+	}
+
+	// Update the indexes table
+	if err := txn.Insert("index", &IndexEntry{"acl_policy", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// DeleteACLPolicies deletes the policies with the given names
+func (s *StateStore) DeleteACLPolicies(index uint64, names []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "acl_policy"})
+
+	// Delete the policy
+	for _, name := range names {
+		if _, err := txn.DeleteAll("acl_policy", "id", name); err != nil {
+			return fmt.Errorf("deleting acl policy failed: %v", err)
+		}
+	}
+	if err := txn.Insert("index", &IndexEntry{"acl_policy", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// ACLPolicyByName is used to lookup a policy by name
+func (s *StateStore) ACLPolicyByName(name string) (*structs.ACLPolicy, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("acl_policy", "id", name)
+	if err != nil {
+		return nil, fmt.Errorf("acl policy lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.ACLPolicy), nil
+	}
+	return nil, nil
+}
+
+// ACLPolicyByNamePrefix is used to lookup policies by prefix
+func (s *StateStore) ACLPolicyByNamePrefix(prefix string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("acl_policy", "id_prefix", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("acl policy lookup failed: %v", err)
+	}
+	return iter, nil
+}
+
+// ACLPolicies returns an iterator over all the acl policies
+func (s *StateStore) ACLPolicies() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("acl_policy", "id")
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// UpsertACLTokens is used to create or update a set of ACL tokens
+func (s *StateStore) UpsertACLTokens(index uint64, tokens []*structs.ACLToken) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "acl_token"})
+
+	if err := s.upsertACLTokensImpl(index, tokens, txn); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// upsertACLTokensImpl is used to insert a set of ACL tokens within the given
+// transaction
+func (s *StateStore) upsertACLTokensImpl(index uint64, tokens []*structs.ACLToken, txn *memdb.Txn) error {
+	for _, token := range tokens {
+		// Check if the token already exists
+		existing, err := txn.First("acl_token", "id", token.AccessorID)
+		if err != nil {
+			return fmt.Errorf("token lookup failed: %v", err)
+		}
+
+		// Update all the indexes
+		if existing != nil {
+			existTK := existing.(*structs.ACLToken)
+			token.CreateIndex = existTK.CreateIndex
+			token.ModifyIndex = index
+
+			// Do not allow SecretID or create time to change
+			token.SecretID = existTK.SecretID
+			token.CreateTime = existTK.CreateTime
+		} else {
+			token.CreateIndex = index
+			token.ModifyIndex = index
+		}
+
+		// Update the token
+		if err := txn.Insert("acl_token", token); err != nil {
+			return fmt.Errorf("upserting token failed: %v", err)
+		}
 	}
-	return out, nil
+
+	// Update the indexes table
+	if err := txn.Insert("index", &IndexEntry{"acl_token", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	return nil
 }
 
-// VaultAccessorsByNode returns all the Vault accessors by node id
-func (s *StateStore) VaultAccessorsByNode(nodeID string) ([]*structs.VaultAccessor, error) {
+// DeleteACLTokens deletes the tokens with the given accessor ids
+func (s *StateStore) DeleteACLTokens(index uint64, ids []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "acl_token"})
+
+	// Delete the tokens
+	for _, id := range ids {
+		if _, err := txn.DeleteAll("acl_token", "id", id); err != nil {
+			return fmt.Errorf("deleting acl token failed: %v", err)
+		}
+	}
+	if err := txn.Insert("index", &IndexEntry{"acl_token", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// ACLTokenByAccessorID is used to lookup a token by accessor ID
+func (s *StateStore) ACLTokenByAccessorID(id string) (*structs.ACLToken, error) {
 	txn := s.db.Txn(false)
 
-	// Get an iterator over the accessors
-	iter, err := txn.Get("vault_accessors", "node_id", nodeID)
+	existing, err := txn.First("acl_token", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("acl token lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.ACLToken), nil
+	}
+	return nil, nil
+}
+
+// ACLTokenBySecretID is used to lookup a token by secret ID
+func (s *StateStore) ACLTokenBySecretID(secretID string) (*structs.ACLToken, error) {
+	txn := s.db.Txn(false)
+
+	existing, err := txn.First("acl_token", "secret", secretID)
+	if err != nil {
+		return nil, fmt.Errorf("acl token lookup failed: %v", err)
+	}
+
+	if existing != nil {
+		return existing.(*structs.ACLToken), nil
+	}
+	return nil, nil
+}
+
+// ACLTokenByAccessorIDPrefix is used to lookup tokens by prefix
+func (s *StateStore) ACLTokenByAccessorIDPrefix(prefix string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("acl_token", "id_prefix", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("acl token lookup failed: %v", err)
+	}
+	return iter, nil
+}
+
+// ACLTokens returns an iterator over all the tokens
+func (s *StateStore) ACLTokens() (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("acl_token", "id")
 	if err != nil {
 		return nil, err
 	}
+	return iter, nil
+}
 
-	var out []*structs.VaultAccessor
-	for {
-		raw := iter.Next()
-		if raw == nil {
-			break
-		}
-		out = append(out, raw.(*structs.VaultAccessor))
+// CanBootstrapACLToken checks if bootstrapping is possible and returns the
+// index of the bootstrap if it has already happened
+func (s *StateStore) CanBootstrapACLToken() (bool, uint64, error) {
+	txn := s.db.Txn(false)
+
+	// Lookup the bootstrap sentinel
+	out, err := txn.First("index", "id", "acl_token_bootstrap")
+	if err != nil {
+		return false, 0, err
 	}
-	return out, nil
+
+	// No entry, we haven't bootstrapped yet
+	if out == nil {
+		return true, 0, nil
+	}
+	return false, out.(*IndexEntry).Value, nil
+}
+
+// BootstrapACLTokens is used to create an initial ACL token
+func (s *StateStore) BootstrapACLTokens(index uint64, token *structs.ACLToken) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "acl_token"})
+
+	// Check if we have already done a bootstrap
+	existing, err := txn.First("index", "id", "acl_token_bootstrap")
+	if err != nil {
+		return fmt.Errorf("bootstrap check failed: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("ACL bootstrap already done")
+	}
+
+	// Insert the bootstrap token
+	if err := s.upsertACLTokensImpl(index, []*structs.ACLToken{token}, txn); err != nil {
+		return err
+	}
+
+	// Update the bootstrap sentinel
+	if err := txn.Insert("index", &IndexEntry{"acl_token_bootstrap", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// AutopilotConfig is used to get the current Autopilot configuration. A nil
+// configuration is returned if none has been stored yet.
+func (s *StateStore) AutopilotConfig() (uint64, *structs.AutopilotConfig, error) {
+	txn := s.db.Txn(false)
+
+	config, err := txn.First("autopilot-config", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed autopilot config lookup: %v", err)
+	}
+
+	if config == nil {
+		return 0, nil, nil
+	}
+	out := config.(*structs.AutopilotConfig)
+	return out.ModifyIndex, out, nil
+}
+
+// AutopilotSetConfig is used to set the current Autopilot configuration.
+func (s *StateStore) AutopilotSetConfig(index uint64, config *structs.AutopilotConfig) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "autopilot-config"})
+
+	if err := s.autopilotSetConfigTxn(index, txn, config); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// AutopilotCASConfig is used to try updating the Autopilot configuration with
+// a given modify index. It returns false if the index does not match the
+// stored configuration.
+func (s *StateStore) AutopilotCASConfig(index, cidx uint64, config *structs.AutopilotConfig) (bool, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "autopilot-config"})
+
+	// Check for an existing config
+	existing, err := txn.First("autopilot-config", "id")
+	if err != nil {
+		return false, fmt.Errorf("failed autopilot config lookup: %v", err)
+	}
+
+	// If the existing index does not match the provided CAS
+	// index arg, then we shouldn't update anything and can safely
+	// return early here.
+	e, ok := existing.(*structs.AutopilotConfig)
+	if !ok || e.ModifyIndex != cidx {
+		return false, nil
+	}
+
+	if err := s.autopilotSetConfigTxn(index, txn, config); err != nil {
+		return false, err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return true, nil
+}
+
+// autopilotSetConfigTxn stores the Autopilot configuration within the given
+// transaction
+func (s *StateStore) autopilotSetConfigTxn(index uint64, txn *memdb.Txn, config *structs.AutopilotConfig) error {
+	// Check for an existing config
+	existing, err := txn.First("autopilot-config", "id")
+	if err != nil {
+		return fmt.Errorf("failed autopilot config lookup: %v", err)
+	}
+
+	// Set the indexes
+	if existing != nil {
+		config.CreateIndex = existing.(*structs.AutopilotConfig).CreateIndex
+	} else {
+		config.CreateIndex = index
+	}
+	config.ModifyIndex = index
+
+	if err := txn.Insert("autopilot-config", config); err != nil {
+		return fmt.Errorf("failed updating autopilot config: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"autopilot-config", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	return nil
+}
+
+// SchedulerConfig is used to get the current scheduler configuration. A nil
+// configuration is returned if none has been stored yet.
+func (s *StateStore) SchedulerConfig() (uint64, *structs.SchedulerConfig, error) {
+	txn := s.db.Txn(false)
+
+	config, err := txn.First("scheduler-config", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed scheduler config lookup: %v", err)
+	}
+
+	if config == nil {
+		return 0, nil, nil
+	}
+	out := config.(*structs.SchedulerConfig)
+	return out.ModifyIndex, out, nil
+}
+
+// SchedulerSetConfig is used to set the current scheduler configuration.
+func (s *StateStore) SchedulerSetConfig(index uint64, config *structs.SchedulerConfig) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "scheduler-config"})
+
+	if err := s.schedulerSetConfigTxn(index, txn, config); err != nil {
+		return err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return nil
+}
+
+// SchedulerCASConfig is used to try updating the scheduler configuration with
+// a given modify index. It returns false if the index does not match the
+// stored configuration.
+func (s *StateStore) SchedulerCASConfig(index, cidx uint64, config *structs.SchedulerConfig) (bool, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	watcher := watch.NewItems()
+	watcher.Add(watch.Item{Table: "scheduler-config"})
+
+	// Check for an existing config
+	existing, err := txn.First("scheduler-config", "id")
+	if err != nil {
+		return false, fmt.Errorf("failed scheduler config lookup: %v", err)
+	}
+
+	// If the existing index does not match the provided CAS
+	// index arg, then we shouldn't update anything and can safely
+	// return early here.
+	e, ok := existing.(*structs.SchedulerConfig)
+	if !ok || e.ModifyIndex != cidx {
+		return false, nil
+	}
+
+	if err := s.schedulerSetConfigTxn(index, txn, config); err != nil {
+		return false, err
+	}
+
+	txn.Defer(func() { s.watch.notify(watcher) })
+	txn.Commit()
+	return true, nil
+}
+
+// schedulerSetConfigTxn stores the scheduler configuration within the given
+// transaction
+func (s *StateStore) schedulerSetConfigTxn(index uint64, txn *memdb.Txn, config *structs.SchedulerConfig) error {
+	// Check for an existing config
+	existing, err := txn.First("scheduler-config", "id")
+	if err != nil {
+		return fmt.Errorf("failed scheduler config lookup: %v", err)
+	}
+
+	// Set the indexes
+	if existing != nil {
+		config.CreateIndex = existing.(*structs.SchedulerConfig).CreateIndex
+	} else {
+		config.CreateIndex = index
+	}
+	config.ModifyIndex = index
+
+	if err := txn.Insert("scheduler-config", config); err != nil {
+		return fmt.Errorf("failed updating scheduler config: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"scheduler-config", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	return nil
 }
 
 // LastIndex returns the greatest index value for all indexes
@@ -1492,9 +2967,9 @@ func (s *StateStore) getJobStatus(txn *memdb.Txn, job *structs.Job, evalDelete b
 	}
 
 	// If there are no allocations or evaluations it is a new job. If the job is
-	// periodic, we mark it as running as it will never have an
-	// allocation/evaluation against it.
-	if job.IsPeriodic() {
+	// periodic or parameterized, we mark it as running as it will never have
+	// an allocation/evaluation against it.
+	if job.IsPeriodic() || job.IsParameterized() {
 		return structs.JobStatusRunning, nil
 	}
 	return structs.JobStatusPending, nil
@@ -1718,6 +3193,12 @@ func (r *StateRestore) JobRestore(job *structs.Job) error {
 	r.items.Add(watch.Item{Table: "jobs"})
 	r.items.Add(watch.Item{Job: job.ID})
 
+	// Jobs registered before namespaces existed belong to the default
+	// namespace
+	if job.Namespace == "" {
+		job.Namespace = structs.DefaultNamespace
+	}
+
 	// Create the EphemeralDisk if it's nil by adding up DiskMB from task resources.
 	// COMPAT 0.4.1 -> 0.5
 	r.addEphemeralDiskToTaskGroups(job)
@@ -1728,6 +3209,16 @@ func (r *StateRestore) JobRestore(job *structs.Job) error {
 	return nil
 }
 
+// JobVersionRestore is used to restore a tracked version of a job
+func (r *StateRestore) JobVersionRestore(job *structs.Job) error {
+	r.items.Add(watch.Item{Table: "job_version"})
+	r.items.Add(watch.Item{Job: job.ID})
+	if err := r.txn.Insert("job_version", job); err != nil {
+		return fmt.Errorf("job version insert failed: %v", err)
+	}
+	return nil
+}
+
 // EvalRestore is used to restore an evaluation
 func (r *StateRestore) EvalRestore(eval *structs.Evaluation) error {
 	r.items.Add(watch.Item{Table: "evals"})
@@ -1760,6 +3251,10 @@ func (r *StateRestore) AllocRestore(alloc *structs.Allocation) error {
 		r.addEphemeralDiskToTaskGroups(alloc.Job)
 	}
 
+	// The namespace usage is derived from the allocations
+	if err := updateNamespaceUsage(r.txn, alloc, nil); err != nil {
+		return err
+	}
 	if err := r.txn.Insert("allocs", alloc); err != nil {
 		return fmt.Errorf("alloc insert failed: %v", err)
 	}
@@ -1792,6 +3287,71 @@ func (r *StateRestore) JobSummaryRestore(jobSummary *structs.JobSummary) error {
 	return nil
 }
 
+// DeploymentRestore is used to restore a deployment
+func (r *StateRestore) DeploymentRestore(deployment *structs.Deployment) error {
+	r.items.Add(watch.Item{Table: "deployment"})
+	r.items.Add(watch.Item{Deployment: deployment.ID})
+	r.items.Add(watch.Item{DeploymentJob: deployment.JobID})
+	if err := r.txn.Insert("deployment", deployment); err != nil {
+		return fmt.Errorf("deployment insert failed: %v", err)
+	}
+	return nil
+}
+
+// NamespaceRestore is used to restore a namespace
+func (r *StateRestore) NamespaceRestore(ns *structs.Namespace) error {
+	r.items.Add(watch.Item{Table: "namespaces"})
+	if err := r.txn.Insert("namespaces", ns); err != nil {
+		return fmt.Errorf("namespace insert failed: %v", err)
+	}
+	return nil
+}
+
+// QuotaSpecRestore is used to restore a quota specification
+func (r *StateRestore) QuotaSpecRestore(quota *structs.QuotaSpec) error {
+	r.items.Add(watch.Item{Table: "quota_specs"})
+	if err := r.txn.Insert("quota_specs", quota); err != nil {
+		return fmt.Errorf("quota specification insert failed: %v", err)
+	}
+	return nil
+}
+
+// ACLPolicyRestore is used to restore an ACL policy
+func (r *StateRestore) ACLPolicyRestore(policy *structs.ACLPolicy) error {
+	r.items.Add(watch.Item{Table: "acl_policy"})
+	if err := r.txn.Insert("acl_policy", policy); err != nil {
+		return fmt.Errorf("inserting acl policy failed: %v", err)
+	}
+	return nil
+}
+
+// ACLTokenRestore is used to restore an ACL token
+func (r *StateRestore) ACLTokenRestore(token *structs.ACLToken) error {
+	r.items.Add(watch.Item{Table: "acl_token"})
+	if err := r.txn.Insert("acl_token", token); err != nil {
+		return fmt.Errorf("inserting acl token failed: %v", err)
+	}
+	return nil
+}
+
+// AutopilotConfigRestore is used to restore the Autopilot configuration
+func (r *StateRestore) AutopilotConfigRestore(config *structs.AutopilotConfig) error {
+	r.items.Add(watch.Item{Table: "autopilot-config"})
+	if err := r.txn.Insert("autopilot-config", config); err != nil {
+		return fmt.Errorf("inserting autopilot config failed: %v", err)
+	}
+	return nil
+}
+
+// SchedulerConfigRestore is used to restore the scheduler configuration
+func (r *StateRestore) SchedulerConfigRestore(config *structs.SchedulerConfig) error {
+	r.items.Add(watch.Item{Table: "scheduler-config"})
+	if err := r.txn.Insert("scheduler-config", config); err != nil {
+		return fmt.Errorf("inserting scheduler config failed: %v", err)
+	}
+	return nil
+}
+
 // VaultAccessorRestore is used to restore a vault accessor
 func (r *StateRestore) VaultAccessorRestore(accessor *structs.VaultAccessor) error {
 	if err := r.txn.Insert("vault_accessors", accessor); err != nil {
@@ -139,6 +139,75 @@ func TestStateStore_UpdateNodeStatus_Node(t *testing.T) {
 	notify.verify(t)
 }
 
+func TestStateStore_UpdateNodeEligibility(t *testing.T) {
+	state := testStateStore(t)
+	node := mock.Node()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "nodes"},
+		watch.Item{Node: node.ID})
+
+	if err := state.UpsertNode(1000, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	until := time.Now().Add(time.Minute).UnixNano()
+	err := state.UpdateNodeEligibility(1001, node.ID, structs.NodeSchedulingIneligible, until)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Eligible() || out.Ready() || out.IneligibleUntil != until || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	index, err := state.Index("nodes")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+
+	// Re-registering the node retains the eligibility
+	node2 := node.Copy()
+	node2.SchedulingEligibility = ""
+	if err := state.UpsertNode(1002, node2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Eligible() || out.IneligibleUntil != until {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Any other update clears the expiry
+	if err := state.UpdateNodeEligibility(1003, node.ID, structs.NodeSchedulingIneligible, 0); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Eligible() || out.IneligibleUntil != 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Updating an unknown node fails
+	if err := state.UpdateNodeEligibility(1004, "foo", structs.NodeSchedulingEligible, 0); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 	state := testStateStore(t)
 	node := mock.Node()
@@ -153,7 +222,11 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	err = state.UpdateNodeDrain(1001, node.ID, true)
+	strategy := &structs.DrainStrategy{
+		Deadline:      time.Hour,
+		ForceDeadline: time.Now().Add(time.Hour),
+	}
+	err = state.UpdateNodeDrain(1001, node.ID, true, strategy)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -163,7 +236,7 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	if !out.Drain {
+	if !out.Drain || !reflect.DeepEqual(out.DrainStrategy, strategy) {
 		t.Fatalf("bad: %#v", out)
 	}
 	if out.ModifyIndex != 1001 {
@@ -179,6 +252,18 @@ func TestStateStore_UpdateNodeDrain_Node(t *testing.T) {
 	}
 
 	notify.verify(t)
+
+	// Disabling drain clears the strategy
+	if err := state.UpdateNodeDrain(1002, node.ID, false, strategy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.NodeByID(node.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Drain || out.DrainStrategy != nil {
+		t.Fatalf("bad: %#v", out)
+	}
 }
 
 func TestStateStore_Nodes(t *testing.T) {
@@ -467,6 +552,71 @@ func TestStateStore_UpsertJob_NoEphemeralDisk(t *testing.T) {
 	}
 }
 
+func TestStateStore_UpsertJob_Versions(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+
+	// Register the job more times than the versions that are tracked
+	num := structs.JobTrackedVersions + 2
+	for i := 0; i < num; i++ {
+		copy := job.Copy()
+		copy.Priority = i + 1
+		if err := state.UpsertJob(1000+uint64(i), copy); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Version != uint64(num-1) {
+		t.Fatalf("bad version: got %d; want %d", out.Version, num-1)
+	}
+
+	// Only the newest versions are kept, sorted newest first
+	versions, err := state.JobVersionsByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(versions) != structs.JobTrackedVersions {
+		t.Fatalf("got %d versions; want %d", len(versions), structs.JobTrackedVersions)
+	}
+	for i, v := range versions {
+		expected := uint64(num - 1 - i)
+		if v.Version != expected {
+			t.Fatalf("version %d: got %d; want %d", i, v.Version, expected)
+		}
+		if v.Priority != int(expected)+1 {
+			t.Fatalf("version %d: bad priority %d", i, v.Priority)
+		}
+	}
+
+	// Lookup a tracked and a pruned version
+	v, err := state.JobByIDAndVersion(job.ID, 3)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v == nil || v.Version != 3 || v.Priority != 4 {
+		t.Fatalf("bad: %#v", v)
+	}
+	v, err = state.JobByIDAndVersion(job.ID, 0)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v != nil {
+		t.Fatalf("expected pruned version, got %#v", v)
+	}
+
+	index, err := state.Index("job_version")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1000+uint64(num-1) {
+		t.Fatalf("bad: %d", index)
+	}
+}
+
 func TestStateStore_DeleteJob_Job(t *testing.T) {
 	state := testStateStore(t)
 	job := mock.Job()
@@ -511,6 +661,14 @@ func TestStateStore_DeleteJob_Job(t *testing.T) {
 		t.Fatalf("expected summary to be nil, but got: %v", summary)
 	}
 
+	versions, err := state.JobVersionsByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(versions) != 0 {
+		t.Fatalf("expected no job versions, got: %v", versions)
+	}
+
 	notify.verify(t)
 }
 
@@ -847,6 +1005,39 @@ func TestStateStore_RestoreJob(t *testing.T) {
 	notify.verify(t)
 }
 
+func TestStateStore_RestoreJobVersion(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	job.Version = 3
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "job_version"},
+		watch.Item{Job: job.ID})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	err = restore.JobVersionRestore(job)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+
+	out, err := state.JobByIDAndVersion(job.ID, job.Version)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !reflect.DeepEqual(out, job) {
+		t.Fatalf("Bad: %#v %#v", out, job)
+	}
+
+	notify.verify(t)
+}
+
 // This test ensures that the state restore creates the EphemeralDisk for a job if
 // it doesn't have one
 // COMPAT 0.4.1 -> 0.5
@@ -1608,12 +1799,16 @@ func TestStateStore_UpdateAllocsFromClient(t *testing.T) {
 		JobID:        alloc.JobID,
 		TaskGroup:    alloc.TaskGroup,
 	}
+	healthy := true
 	update2 := &structs.Allocation{
 		ID:           alloc2.ID,
 		ClientStatus: structs.AllocClientStatusRunning,
 		TaskStates:   ts,
 		JobID:        alloc2.JobID,
 		TaskGroup:    alloc2.TaskGroup,
+		DeploymentStatus: &structs.AllocDeploymentStatus{
+			Healthy: &healthy,
+		},
 	}
 
 	err = state.UpdateAllocsFromClient(1001, []*structs.Allocation{update, update2})
@@ -1643,6 +1838,7 @@ func TestStateStore_UpdateAllocsFromClient(t *testing.T) {
 	alloc2.ModifyIndex = 1001
 	alloc2.ClientStatus = structs.AllocClientStatusRunning
 	alloc2.TaskStates = ts
+	alloc2.DeploymentStatus = update2.DeploymentStatus
 	if !reflect.DeepEqual(alloc2, out) {
 		t.Fatalf("bad: %#v %#v", alloc2, out)
 	}
@@ -2375,6 +2571,41 @@ func TestStateStore_AllocsByJob(t *testing.T) {
 	}
 }
 
+func TestStateStore_AllocsByDeployment(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+	var allocs []*structs.Allocation
+
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.DeploymentID = d.ID
+		allocs = append(allocs, alloc)
+	}
+
+	// Allocations outside of the deployment are not returned
+	other := mock.Alloc()
+	for i, alloc := range append(allocs, other) {
+		state.UpsertJobSummary(uint64(900+i), mock.JobSummary(alloc.JobID))
+	}
+
+	err := state.UpsertAllocs(1000, append(allocs, other))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocsByDeployment(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	sort.Sort(AllocIDSort(allocs))
+	sort.Sort(AllocIDSort(out))
+
+	if !reflect.DeepEqual(allocs, out) {
+		t.Fatalf("bad: %#v %#v", allocs, out)
+	}
+}
+
 func TestStateStore_AllocsByIDPrefix(t *testing.T) {
 	state := testStateStore(t)
 	var allocs []*structs.Allocation
@@ -2976,6 +3207,1327 @@ func TestJobSummary_UpdateClientStatus(t *testing.T) {
 	}
 }
 
+func TestStateStore_UpsertDeployment(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: d.ID},
+		watch.Item{DeploymentJob: d.JobID})
+
+	if err := state.UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(d, out) {
+		t.Fatalf("bad: %#v %#v", d, out)
+	}
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	index, err := state.Index("deployment")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_DeleteDeployment(t *testing.T) {
+	state := testStateStore(t)
+	d1 := mock.Deployment()
+	d2 := mock.Deployment()
+
+	if err := state.UpsertDeployment(1000, d1, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertDeployment(1001, d2, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: d1.ID},
+		watch.Item{DeploymentJob: d1.JobID})
+
+	if err := state.DeleteDeployment(1002, []string{d1.ID, structs.GenerateUUID()}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d1.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	out, err = state.DeploymentByID(d2.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("deployment %q deleted", d2.ID)
+	}
+
+	index, err := state.Index("deployment")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1002 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_UpsertDeployment_StatusUpdates(t *testing.T) {
+	state := testStateStore(t)
+	d1 := mock.Deployment()
+	d2 := mock.Deployment()
+	d2.JobID = d1.JobID
+
+	if err := state.UpsertDeployment(1000, d1, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create the new deployment and cancel the old one in the same update
+	update := &structs.DeploymentStatusUpdate{
+		DeploymentID:      d1.ID,
+		Status:            structs.DeploymentStatusCancelled,
+		StatusDescription: structs.DeploymentStatusDescriptionNewerJob,
+	}
+	if err := state.UpsertDeployment(1001, d2, []*structs.DeploymentStatusUpdate{update}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d1.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusCancelled || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	latest, err := state.LatestDeploymentByJobID(d1.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if latest == nil || latest.ID != d2.ID {
+		t.Fatalf("bad: %#v", latest)
+	}
+
+	deployments, err := state.DeploymentsByJobID(d1.JobID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(deployments) != 2 {
+		t.Fatalf("bad: %#v", deployments)
+	}
+}
+
+func TestStateStore_UpdateDeploymentStatus(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+	if err := state.UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	eval := mock.Eval()
+	eval.JobID = d.JobID
+	req := &structs.DeploymentStatusUpdateRequest{
+		Eval: eval,
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedByUser,
+		},
+	}
+	if err := state.UpdateDeploymentStatus(1001, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.StatusDescription != structs.DeploymentStatusDescriptionFailedByUser {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	outE, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outE == nil || outE.CreateIndex != 1001 {
+		t.Fatalf("bad: %#v", outE)
+	}
+
+	// Updating an unknown deployment fails
+	req.DeploymentUpdate.DeploymentID = structs.GenerateUUID()
+	if err := state.UpdateDeploymentStatus(1002, req); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestStateStore_UpdateDeploymentAllocHealth(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := mock.Deployment()
+	d.JobID = job.ID
+	if err := state.UpsertDeployment(1001, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Record the health of the allocations
+	req := &structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentID:    d.ID,
+		HealthyAllocs:   map[string]int{"web": 2},
+		UnhealthyAllocs: map[string]int{"web": 1},
+	}
+	if err := state.UpdateDeploymentAllocHealth(1002, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if group := out.TaskGroups["web"]; group.HealthyAllocs != 2 || group.UnhealthyAllocs != 1 {
+		t.Fatalf("bad: %#v", group)
+	}
+	if !out.Active() || out.ModifyIndex != 1002 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Fail the deployment and revert the job
+	revert := job.Copy()
+	revert.Priority = 10
+	eval := mock.Eval()
+	eval.JobID = job.ID
+	req = &structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentID:    d.ID,
+		HealthyAllocs:   map[string]int{"web": 2},
+		UnhealthyAllocs: map[string]int{"web": 1},
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedAllocations,
+		},
+		Job:  revert,
+		Eval: eval,
+	}
+	if err := state.UpdateDeploymentAllocHealth(1003, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err = state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Status != structs.DeploymentStatusFailed {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	outJ, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outJ.Priority != 10 || outJ.CreateIndex != 1000 || outJ.JobModifyIndex != 1003 {
+		t.Fatalf("bad: %#v", outJ)
+	}
+
+	outE, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outE == nil {
+		t.Fatalf("missing eval")
+	}
+}
+
+func TestStateStore_UpdateDeploymentAllocHealth_Stable(t *testing.T) {
+	state := testStateStore(t)
+	job := mock.Job()
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	d := mock.Deployment()
+	d.JobID = job.ID
+	d.JobCreateIndex = job.CreateIndex
+	d.JobModifyIndex = job.JobModifyIndex
+	if err := state.UpsertDeployment(1001, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "jobs"},
+		watch.Item{Job: job.ID})
+
+	// The deployment succeeds
+	req := &structs.ApplyDeploymentAllocHealthRequest{
+		DeploymentID:    d.ID,
+		HealthyAllocs:   map[string]int{"web": 2},
+		UnhealthyAllocs: map[string]int{},
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		},
+	}
+	if err := state.UpdateDeploymentAllocHealth(1002, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The job and its tracked version are stable
+	out, err := state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.Stable || out.ModifyIndex != 1002 || out.JobModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+	version, err := state.JobByIDAndVersion(job.ID, job.Version)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !version.Stable {
+		t.Fatalf("bad: %#v", version)
+	}
+	notify.verify(t)
+
+	// A new version of the job isn't stable
+	update := out.Copy()
+	update.Priority = 10
+	if err := state.UpsertJob(1003, update); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.JobByID(job.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Stable {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestStateStore_UpdateAllocsDesiredTransitions(t *testing.T) {
+	state := testStateStore(t)
+	alloc := mock.Alloc()
+	if err := state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "allocs"},
+		watch.Item{Alloc: alloc.ID},
+		watch.Item{AllocNode: alloc.NodeID},
+		watch.Item{Table: "evals"})
+
+	eval := mock.Eval()
+	eval.JobID = alloc.JobID
+	transitions := map[string]*structs.DesiredTransition{
+		alloc.ID: &structs.DesiredTransition{Migrate: true},
+	}
+	evals := []*structs.Evaluation{eval}
+	if err := state.UpdateAllocsDesiredTransitions(1001, transitions, evals); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DesiredTransition.ShouldMigrate() || out.ModifyIndex != 1001 || out.CreateIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	outE, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outE == nil || outE.CreateIndex != 1001 {
+		t.Fatalf("bad: %#v", outE)
+	}
+
+	index, err := state.Index("allocs")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+
+	// A stale copy of the allocation does not reset the transition
+	stale := alloc.Copy()
+	stale.DesiredStatus = structs.AllocDesiredStatusStop
+	if err := state.UpsertAllocs(1002, []*structs.Allocation{stale}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	out, err = state.AllocByID(alloc.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.DesiredTransition.ShouldMigrate() {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestStateStore_UpdateDeploymentPromotion(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+	d.TaskGroups["api"] = &structs.DeploymentState{
+		DesiredCanaries: 1,
+		DesiredTotal:    2,
+	}
+	if err := state.UpsertDeployment(1000, d, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Promote a single group
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			Groups:       []string{"web"},
+		},
+	}
+	if err := state.UpdateDeploymentPromotion(1001, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.TaskGroups["web"].Promoted || out.TaskGroups["api"].Promoted {
+		t.Fatalf("bad: %#v", out.TaskGroups)
+	}
+	if !out.RequiresPromotion() {
+		t.Fatalf("expected promotion to be required")
+	}
+
+	// The original object is not modified
+	if d.TaskGroups["web"].Promoted {
+		t.Fatalf("deployment modified in place")
+	}
+
+	// Promote the rest
+	eval := mock.Eval()
+	eval.JobID = d.JobID
+	req = &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
+		Eval: eval,
+	}
+	if err := state.UpdateDeploymentPromotion(1002, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err = state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.RequiresPromotion() {
+		t.Fatalf("bad: %#v", out.TaskGroups)
+	}
+
+	outE, err := state.EvalByID(eval.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if outE == nil {
+		t.Fatalf("missing eval")
+	}
+}
+
+func TestStateStore_RestoreDeployment(t *testing.T) {
+	state := testStateStore(t)
+	d := mock.Deployment()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "deployment"},
+		watch.Item{Deployment: d.ID})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := restore.DeploymentRestore(d); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+
+	out, err := state.DeploymentByID(d.ID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, d) {
+		t.Fatalf("Bad: %#v %#v", out, d)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_DefaultNamespace(t *testing.T) {
+	state := testStateStore(t)
+
+	out, err := state.NamespaceByName(structs.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected the default namespace")
+	}
+
+	// Jobs without a namespace are placed in the default namespace
+	job := mock.Job()
+	job.Namespace = ""
+	if err := state.UpsertJob(1000, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	iter, err := state.JobsByNamespace(structs.DefaultNamespace)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	raw := iter.Next()
+	if raw == nil || raw.(*structs.Job).ID != job.ID {
+		t.Fatalf("bad: %#v", raw)
+	}
+}
+
+func TestStateStore_UpsertNamespaces(t *testing.T) {
+	state := testStateStore(t)
+	ns := mock.Namespace()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "namespaces"})
+
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.NamespaceByName(ns.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(ns, out) {
+		t.Fatalf("bad: %#v %#v", ns, out)
+	}
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Update the namespace
+	ns2 := ns.Copy()
+	ns2.Quota = "foo"
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err = state.NamespaceByName(ns.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Quota != "foo" || out.CreateIndex != 1000 || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	attached, err := state.NamespacesByQuota("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(attached) != 1 || attached[0].Name != ns.Name {
+		t.Fatalf("bad: %#v", attached)
+	}
+
+	index, err := state.Index("namespaces")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_DeleteNamespaces(t *testing.T) {
+	state := testStateStore(t)
+	ns := mock.Namespace()
+
+	if err := state.UpsertNamespaces(1000, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "namespaces"})
+
+	if err := state.DeleteNamespaces(1001, []string{ns.Name}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.NamespaceByName(ns.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Deleting an unknown namespace fails
+	if err := state.DeleteNamespaces(1002, []string{ns.Name}); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_RestoreNamespace(t *testing.T) {
+	state := testStateStore(t)
+	ns := mock.Namespace()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "namespaces"})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := restore.NamespaceRestore(ns); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+
+	out, err := state.NamespaceByName(ns.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, ns) {
+		t.Fatalf("Bad: %#v %#v", out, ns)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_UpsertQuotaSpecs(t *testing.T) {
+	state := testStateStore(t)
+	q := mock.QuotaSpec()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "quota_specs"})
+
+	if err := state.UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.QuotaSpecByName(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(q, out) {
+		t.Fatalf("bad: %#v %#v", q, out)
+	}
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	index, err := state.Index("quota_specs")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_DeleteQuotaSpecs(t *testing.T) {
+	state := testStateStore(t)
+	q := mock.QuotaSpec()
+
+	if err := state.UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "quota_specs"})
+
+	if err := state.DeleteQuotaSpecs(1001, []string{q.Name}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.QuotaSpecByName(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_RestoreQuotaSpec(t *testing.T) {
+	state := testStateStore(t)
+	q := mock.QuotaSpec()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "quota_specs"})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := restore.QuotaSpecRestore(q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+
+	out, err := state.QuotaSpecByName(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, q) {
+		t.Fatalf("Bad: %#v %#v", out, q)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_AutopilotConfig(t *testing.T) {
+	state := testStateStore(t)
+	expected := &structs.AutopilotConfig{
+		CleanupDeadServers:      true,
+		LastContactThreshold:    5 * time.Second,
+		MaxTrailingLogs:         500,
+		ServerStabilizationTime: 100 * time.Second,
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "autopilot-config"})
+
+	if err := state.AutopilotSetConfig(0, expected); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	idx, config, err := state.AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if idx != 0 {
+		t.Fatalf("bad: %d", idx)
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("bad: %#v, %#v", expected, config)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_SchedulerConfig(t *testing.T) {
+	state := testStateStore(t)
+	expected := &structs.SchedulerConfig{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "scheduler-config"})
+
+	if err := state.SchedulerSetConfig(1, expected); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	idx, config, err := state.SchedulerConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if idx != 1 {
+		t.Fatalf("bad: %d", idx)
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("bad: %#v, %#v", expected, config)
+	}
+
+	notify.verify(t)
+
+	// Do a CAS with an index lower than the entry
+	ok, err := state.SchedulerCASConfig(2, 0, &structs.SchedulerConfig{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmBinpack,
+	})
+	if ok || err != nil {
+		t.Fatalf("expected (false, nil), got: (%v, %#v)", ok, err)
+	}
+
+	// Do another CAS, this time with the correct index
+	ok, err = state.SchedulerCASConfig(2, 1, &structs.SchedulerConfig{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmBinpack,
+		ModifyIndex:        1,
+	})
+	if !ok || err != nil {
+		t.Fatalf("expected (true, nil), got: (%v, %#v)", ok, err)
+	}
+
+	idx, config, err = state.SchedulerConfig()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if idx != 2 || config.CreateIndex != 1 || config.SchedulerAlgorithm != structs.SchedulerAlgorithmBinpack {
+		t.Fatalf("bad: %d %#v", idx, config)
+	}
+}
+
+func TestStateStore_AutopilotCASConfig(t *testing.T) {
+	state := testStateStore(t)
+	expected := &structs.AutopilotConfig{
+		CleanupDeadServers: true,
+	}
+
+	if err := state.AutopilotSetConfig(1, expected); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Do a CAS with an index lower than the entry
+	ok, err := state.AutopilotCASConfig(2, 0, &structs.AutopilotConfig{
+		CleanupDeadServers: false,
+		ModifyIndex:        0,
+	})
+	if ok || err != nil {
+		t.Fatalf("expected (false, nil), got: (%v, %#v)", ok, err)
+	}
+
+	// Check that the index is untouched and the entry
+	// has not been updated.
+	idx, config, err := state.AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 1 {
+		t.Fatalf("bad: %d", idx)
+	}
+	if !config.CleanupDeadServers {
+		t.Fatalf("bad: %#v", config)
+	}
+
+	// Do another CAS, this time with the correct index
+	ok, err = state.AutopilotCASConfig(2, 1, &structs.AutopilotConfig{
+		CleanupDeadServers: false,
+		ModifyIndex:        1,
+	})
+	if !ok || err != nil {
+		t.Fatalf("expected (true, nil), got: (%v, %#v)", ok, err)
+	}
+
+	// Make sure the config was updated
+	idx, config, err = state.AutopilotConfig()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 2 {
+		t.Fatalf("bad: %d", idx)
+	}
+	if config.CleanupDeadServers {
+		t.Fatalf("bad: %#v", config)
+	}
+}
+
+func TestStateStore_QuotaUsage(t *testing.T) {
+	state := testStateStore(t)
+	q := mock.QuotaSpec()
+	ns := mock.Namespace()
+	ns.Quota = q.Name
+
+	if err := state.UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Create a job in the namespace with a running and a terminal alloc
+	job := mock.Job()
+	job.Namespace = ns.Name
+	if err := state.UpsertJob(1002, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a1 := mock.Alloc()
+	a1.Job = job
+	a1.JobID = job.ID
+	a2 := mock.Alloc()
+	a2.Job = job
+	a2.JobID = job.ID
+	a2.DesiredStatus = structs.AllocDesiredStatusStop
+
+	// Allocs of the default namespace are not counted
+	a3 := mock.Alloc()
+	if err := state.UpsertJob(1003, a3.Job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertAllocs(1004, []*structs.Allocation{a1, a2, a3}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	usage, err := state.QuotaUsage(q.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &structs.QuotaUsage{
+		Name:       q.Name,
+		Namespaces: []string{ns.Name},
+		Used: &structs.QuotaLimit{
+			CPU:      500,
+			MemoryMB: 256,
+			Count:    1,
+		},
+	}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Fatalf("bad: %#v %#v", usage, expected)
+	}
+}
+
+func TestStateStore_QuotaUsage_Updates(t *testing.T) {
+	state := testStateStore(t)
+	q := mock.QuotaSpec()
+	ns := mock.Namespace()
+	ns.Quota = q.Name
+
+	if err := state.UpsertQuotaSpecs(1000, []*structs.QuotaSpec{q}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.UpsertNamespaces(1001, []*structs.Namespace{ns}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job := mock.Job()
+	job.Namespace = ns.Name
+	if err := state.UpsertJob(1002, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a1 := mock.Alloc()
+	a1.Job = job
+	a1.JobID = job.ID
+	a2 := mock.Alloc()
+	a2.Job = job
+	a2.JobID = job.ID
+	if err := state.UpsertAllocs(1003, []*structs.Allocation{a1, a2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	checkCount := func(expected int) {
+		usage, err := state.QuotaUsage(q.Name)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if usage.Used.Count != expected || usage.Used.CPU != expected*500 {
+			t.Fatalf("bad: %#v", usage.Used)
+		}
+	}
+	checkCount(2)
+
+	// Upserting an alloc again does not count it twice
+	if err := state.UpsertAllocs(1004, []*structs.Allocation{a1.Copy()}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	checkCount(2)
+
+	// A terminal client status releases the resources
+	update := a1.Copy()
+	update.ClientStatus = structs.AllocClientStatusComplete
+	if err := state.UpdateAllocsFromClient(1005, []*structs.Allocation{update}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	checkCount(1)
+
+	// Deleting the allocs releases the resources
+	if err := state.DeleteEval(1006, nil, []string{a1.ID, a2.ID}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	checkCount(0)
+
+	// The usage is rebuilt from restored allocs
+	state = testStateStore(t)
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := restore.QuotaSpecRestore(q); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := restore.NamespaceRestore(ns); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := restore.AllocRestore(a2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+	checkCount(1)
+}
+
+func TestStateStore_UpsertACLPolicies(t *testing.T) {
+	state := testStateStore(t)
+	policy := mock.ACLPolicy()
+	policy2 := mock.ACLPolicy()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "acl_policy"})
+
+	if err := state.UpsertACLPolicies(1000, []*structs.ACLPolicy{policy, policy2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.ACLPolicyByName(policy.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(policy, out) {
+		t.Fatalf("bad: %#v %#v", policy, out)
+	}
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	iter, err := state.ACLPolicies()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	count := 0
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		count++
+	}
+	if count != 2 {
+		t.Fatalf("bad: %d", count)
+	}
+
+	index, err := state.Index("acl_policy")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1000 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_DeleteACLPolicies(t *testing.T) {
+	state := testStateStore(t)
+	policy := mock.ACLPolicy()
+	policy2 := mock.ACLPolicy()
+
+	if err := state.UpsertACLPolicies(1000, []*structs.ACLPolicy{policy, policy2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "acl_policy"})
+
+	if err := state.DeleteACLPolicies(1001, []string{policy.Name}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.ACLPolicyByName(policy.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	out, err = state.ACLPolicyByName(policy2.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected policy %q", policy2.Name)
+	}
+
+	index, err := state.Index("acl_policy")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_ACLPolicyByNamePrefix(t *testing.T) {
+	state := testStateStore(t)
+	names := []string{"foo", "bar", "foobar", "foozip", "zip"}
+	var policies []*structs.ACLPolicy
+	for _, name := range names {
+		p := mock.ACLPolicy()
+		p.Name = name
+		policies = append(policies, p)
+	}
+
+	if err := state.UpsertACLPolicies(1000, policies); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	iter, err := state.ACLPolicyByNamePrefix("foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	var out []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		out = append(out, raw.(*structs.ACLPolicy).Name)
+	}
+	sort.Strings(out)
+
+	expect := []string{"foo", "foobar", "foozip"}
+	if !reflect.DeepEqual(out, expect) {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestStateStore_RestoreACLPolicy(t *testing.T) {
+	state := testStateStore(t)
+	policy := mock.ACLPolicy()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "acl_policy"})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := restore.ACLPolicyRestore(policy); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+
+	out, err := state.ACLPolicyByName(policy.Name)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, policy) {
+		t.Fatalf("Bad: %#v %#v", out, policy)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_UpsertACLTokens(t *testing.T) {
+	state := testStateStore(t)
+	token := mock.ACLToken()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "acl_token"})
+
+	if err := state.UpsertACLTokens(1000, []*structs.ACLToken{token}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(token, out) {
+		t.Fatalf("bad: %#v %#v", token, out)
+	}
+	if out.CreateIndex != 1000 || out.ModifyIndex != 1000 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	out, err = state.ACLTokenBySecretID(token.SecretID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil || out.AccessorID != token.AccessorID {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Update the token keeping its secret
+	token2 := token.Copy()
+	token2.Name = "updated"
+	token2.SecretID = ""
+	if err := state.UpsertACLTokens(1001, []*structs.ACLToken{token2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err = state.ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Name != "updated" || out.SecretID != token.SecretID ||
+		out.CreateIndex != 1000 || out.ModifyIndex != 1001 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	index, err := state.Index("acl_token")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_DeleteACLTokens(t *testing.T) {
+	state := testStateStore(t)
+	token := mock.ACLToken()
+	token2 := mock.ACLToken()
+
+	if err := state.UpsertACLTokens(1000, []*structs.ACLToken{token, token2}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "acl_token"})
+
+	if err := state.DeleteACLTokens(1001, []string{token.AccessorID}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out != nil {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	out, err = state.ACLTokenBySecretID(token2.SecretID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out == nil {
+		t.Fatalf("expected token %q", token2.AccessorID)
+	}
+
+	index, err := state.Index("acl_token")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_BootstrapACLTokens(t *testing.T) {
+	state := testStateStore(t)
+	token := mock.ACLManagementToken()
+
+	ok, index, err := state.CanBootstrapACLToken()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok || index != 0 {
+		t.Fatalf("bad: %v %d", ok, index)
+	}
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "acl_token"})
+
+	if err := state.BootstrapACLTokens(1000, token); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := state.ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(token, out) {
+		t.Fatalf("bad: %#v %#v", token, out)
+	}
+
+	ok, index, err = state.CanBootstrapACLToken()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ok || index != 1000 {
+		t.Fatalf("bad: %v %d", ok, index)
+	}
+
+	// A second bootstrap should fail
+	if err := state.BootstrapACLTokens(1001, mock.ACLManagementToken()); err == nil {
+		t.Fatalf("expected bootstrap error")
+	}
+
+	notify.verify(t)
+}
+
+func TestStateStore_RestoreACLToken(t *testing.T) {
+	state := testStateStore(t)
+	token := mock.ACLToken()
+
+	notify := setupNotifyTest(
+		state,
+		watch.Item{Table: "acl_token"})
+
+	restore, err := state.Restore()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := restore.ACLTokenRestore(token); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	restore.Commit()
+
+	out, err := state.ACLTokenByAccessorID(token.AccessorID)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(out, token) {
+		t.Fatalf("Bad: %#v %#v", out, token)
+	}
+
+	notify.verify(t)
+}
+
 func TestStateStore_UpsertVaultAccessors(t *testing.T) {
 	state := testStateStore(t)
 	a := mock.VaultAccessor()
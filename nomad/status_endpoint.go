@@ -29,6 +29,13 @@ func (s *Status) Ping(args struct{}, reply *struct{}) error {
 	return nil
 }
 
+// RaftStats is used by the leader to retrieve the Raft stats of this server
+// and track its health
+func (s *Status) RaftStats(args struct{}, reply *structs.RaftStats) error {
+	*reply = s.srv.raftStats()
+	return nil
+}
+
 // Leader is used to get the address of the leader
 func (s *Status) Leader(args *structs.GenericRequest, reply *string) error {
 	if args.Region == "" {
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"time"
+)
+
+// AutopilotConfig contains the Autopilot configuration of the servers. It is
+// only used to initialize the configuration of a new cluster, which can later
+// be changed at runtime with the operator commands.
+type AutopilotConfig struct {
+	// CleanupDeadServers controls whether to remove servers from the Raft
+	// peer set once they have been failed for ServerStabilizationTime.
+	CleanupDeadServers *bool `mapstructure:"cleanup_dead_servers"`
+
+	// LastContactThreshold is the limit on the amount of time a server can go
+	// without leader contact before being considered unhealthy.
+	LastContactThreshold time.Duration `mapstructure:"last_contact_threshold"`
+
+	// MaxTrailingLogs is the amount of entries in the Raft Log that a server
+	// can be behind before being considered unhealthy.
+	MaxTrailingLogs int `mapstructure:"max_trailing_logs"`
+
+	// ServerStabilizationTime is the minimum amount of time a server must be
+	// healthy before it is added to the Raft peer set, and the minimum amount
+	// of time it must be failed before it is cleaned up.
+	ServerStabilizationTime time.Duration `mapstructure:"server_stabilization_time"`
+}
+
+// DefaultAutopilotConfig returns the canonical defaults for the Nomad
+// `autopilot` configuration.
+func DefaultAutopilotConfig() *AutopilotConfig {
+	cleanup := true
+	return &AutopilotConfig{
+		CleanupDeadServers:      &cleanup,
+		LastContactThreshold:    200 * time.Millisecond,
+		MaxTrailingLogs:         250,
+		ServerStabilizationTime: 10 * time.Second,
+	}
+}
+
+// Merge merges two Autopilot configurations together.
+func (a *AutopilotConfig) Merge(b *AutopilotConfig) *AutopilotConfig {
+	result := *a
+
+	if b.CleanupDeadServers != nil {
+		result.CleanupDeadServers = b.CleanupDeadServers
+	}
+	if b.LastContactThreshold != 0 {
+		result.LastContactThreshold = b.LastContactThreshold
+	}
+	if b.MaxTrailingLogs != 0 {
+		result.MaxTrailingLogs = b.MaxTrailingLogs
+	}
+	if b.ServerStabilizationTime != 0 {
+		result.ServerStabilizationTime = b.ServerStabilizationTime
+	}
+	return &result
+}
+
+// Copy returns a copy of this Autopilot config.
+func (a *AutopilotConfig) Copy() *AutopilotConfig {
+	if a == nil {
+		return nil
+	}
+
+	nc := new(AutopilotConfig)
+	*nc = *a
+
+	// Copy the bools
+	if a.CleanupDeadServers != nil {
+		cleanup := *a.CleanupDeadServers
+		nc.CleanupDeadServers = &cleanup
+	}
+	return nc
+}
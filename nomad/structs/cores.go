@@ -0,0 +1,93 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoreIndex is used to index the CPU cores of a machine and the cores
+// reserved by allocations
+type CoreIndex struct {
+	AvailCores int              // Number of cores of the machine
+	UsedCores  map[int]struct{} // Cores reserved by allocations
+}
+
+// NewCoreIndex is used to construct a new core index
+func NewCoreIndex() *CoreIndex {
+	return &CoreIndex{
+		UsedCores: make(map[int]struct{}),
+	}
+}
+
+// SetNode is used to setup the available cores of the node
+func (idx *CoreIndex) SetNode(node *Node) {
+	if node.Resources != nil {
+		idx.AvailCores = node.Resources.Cores
+	}
+}
+
+// AddAllocs is used to add the cores reserved by the allocations. Returns
+// true if there is a collision
+func (idx *CoreIndex) AddAllocs(allocs []*Allocation) (collide bool) {
+	for _, alloc := range allocs {
+		// Allocations within the plan have the combined resources stripped,
+		// while an ask only has the combined resources.
+		if alloc.TaskResources != nil {
+			for _, task := range alloc.TaskResources {
+				if idx.AddReserved(task.ReservedCores) {
+					collide = true
+				}
+			}
+		} else if alloc.Resources != nil {
+			if idx.AddReserved(alloc.Resources.ReservedCores) {
+				collide = true
+			}
+		}
+	}
+	return
+}
+
+// AddReserved is used to add reserved cores, returns true if a core is
+// already reserved or does not exist on the node
+func (idx *CoreIndex) AddReserved(cores []int) (collide bool) {
+	for _, core := range cores {
+		if core < 0 || core >= idx.AvailCores {
+			collide = true
+		}
+		if _, ok := idx.UsedCores[core]; ok {
+			collide = true
+		}
+		idx.UsedCores[core] = struct{}{}
+	}
+	return
+}
+
+// AssignCores is used to reserve the requested number of free cores
+func (idx *CoreIndex) AssignCores(count int) ([]int, error) {
+	var cores []int
+	for core := 0; core < idx.AvailCores && len(cores) < count; core++ {
+		if _, ok := idx.UsedCores[core]; !ok {
+			cores = append(cores, core)
+		}
+	}
+	if len(cores) < count {
+		return nil, fmt.Errorf("cores exhausted: requested %d, %d available", count, len(cores))
+	}
+	return cores, nil
+}
+
+// FormatCores returns the cores in the list format of the cpuset cgroup,
+// e.g. "0,2,3".
+func FormatCores(cores []int) string {
+	sorted := make([]int, len(cores))
+	copy(sorted, cores)
+	sort.Ints(sorted)
+
+	parts := make([]string, len(sorted))
+	for i, core := range sorted {
+		parts[i] = strconv.Itoa(core)
+	}
+	return strings.Join(parts, ",")
+}
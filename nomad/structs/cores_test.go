@@ -0,0 +1,76 @@
+package structs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoreIndex_AssignCores(t *testing.T) {
+	node := &Node{Resources: &Resources{CPU: 4000, Cores: 4}}
+	allocs := []*Allocation{
+		&Allocation{
+			TaskResources: map[string]*Resources{
+				"web": &Resources{Cores: 2, ReservedCores: []int{0, 2}},
+			},
+		},
+	}
+
+	idx := NewCoreIndex()
+	idx.SetNode(node)
+	if idx.AddAllocs(allocs) {
+		t.Fatalf("unexpected collision")
+	}
+
+	cores, err := idx.AssignCores(2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !reflect.DeepEqual(cores, []int{1, 3}) {
+		t.Fatalf("bad: %#v", cores)
+	}
+	idx.AddReserved(cores)
+
+	if _, err := idx.AssignCores(1); err == nil {
+		t.Fatalf("expected cores to be exhausted")
+	}
+}
+
+func TestCoreIndex_AddAllocs_Collision(t *testing.T) {
+	node := &Node{Resources: &Resources{CPU: 2000, Cores: 2}}
+	alloc := func(cores ...int) *Allocation {
+		return &Allocation{Resources: &Resources{Cores: len(cores), ReservedCores: cores}}
+	}
+
+	idx := NewCoreIndex()
+	idx.SetNode(node)
+	if !idx.AddAllocs([]*Allocation{alloc(0), alloc(0, 1)}) {
+		t.Fatalf("expected collision of reserved core")
+	}
+
+	idx = NewCoreIndex()
+	idx.SetNode(node)
+	if !idx.AddAllocs([]*Allocation{alloc(2)}) {
+		t.Fatalf("expected collision of unknown core")
+	}
+}
+
+func TestFormatCores(t *testing.T) {
+	if out := FormatCores([]int{3, 0, 2}); out != "0,2,3" {
+		t.Fatalf("bad: %q", out)
+	}
+	if out := FormatCores(nil); out != "" {
+		t.Fatalf("bad: %q", out)
+	}
+}
+
+func TestResources_MeetsMinResources_Cores(t *testing.T) {
+	r := &Resources{Cores: 2, MemoryMB: 256}
+	if err := r.MeetsMinResources(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	r.Cores = -1
+	if err := r.MeetsMinResources(); err == nil {
+		t.Fatalf("expected error with negative cores")
+	}
+}
@@ -0,0 +1,223 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeviceResource is used to represent devices such as GPUs. On a node it
+// describes a group of identical device instances. On a task it is a request
+// for Count instances of a device matching Name, and once placed the IDs of
+// the instances assigned to the task.
+type DeviceResource struct {
+	// Name is the vendor/type/model of the devices. A request may only
+	// specify the type or the vendor and type of the devices it needs.
+	Name string
+
+	// Count is the number of device instances requested by a task
+	Count int
+
+	// IDs are the IDs of the device instances
+	IDs []string
+
+	// Attributes are the attributes of the devices, such as their memory
+	Attributes map[string]string
+}
+
+// Copy returns a deep copy of the device resource
+func (d *DeviceResource) Copy() *DeviceResource {
+	if d == nil {
+		return nil
+	}
+	newD := new(DeviceResource)
+	*newD = *d
+	if d.IDs != nil {
+		newD.IDs = make([]string, len(d.IDs))
+		copy(newD.IDs, d.IDs)
+	}
+	if d.Attributes != nil {
+		newD.Attributes = make(map[string]string, len(d.Attributes))
+		for k, v := range d.Attributes {
+			newD.Attributes[k] = v
+		}
+	}
+	return newD
+}
+
+// Canonicalize ensures that an empty and nil slices are treated the same to
+// avoid scheduling problems since we use reflect DeepEquals.
+func (d *DeviceResource) Canonicalize() {
+	if len(d.IDs) == 0 {
+		d.IDs = nil
+	}
+	if len(d.Attributes) == 0 {
+		d.Attributes = nil
+	}
+}
+
+// Vendor returns the vendor of the devices, if any.
+func (d *DeviceResource) Vendor() string {
+	parts := strings.SplitN(d.Name, "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// Type returns the type of the devices.
+func (d *DeviceResource) Type() string {
+	parts := strings.SplitN(d.Name, "/", 3)
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// Matches returns whether the devices satisfy the name of the requested
+// devices. The ask may be of the form type, vendor/type or vendor/type/model.
+func (d *DeviceResource) Matches(ask *DeviceResource) bool {
+	have := strings.SplitN(d.Name, "/", 3)
+	want := strings.SplitN(ask.Name, "/", 3)
+	switch len(want) {
+	case 1:
+		return d.Type() == want[0]
+	case 2:
+		return len(have) >= 2 && have[0] == want[0] && have[1] == want[1]
+	default:
+		return d.Name == ask.Name
+	}
+}
+
+// MeetsMinResources returns an error if the requested devices are invalid.
+func (d *DeviceResource) MeetsMinResources() error {
+	if d.Name == "" {
+		return fmt.Errorf("device name must be set")
+	}
+	for _, part := range strings.SplitN(d.Name, "/", 3) {
+		if part == "" {
+			return fmt.Errorf("device name %q must be of the form type, vendor/type or vendor/type/model", d.Name)
+		}
+	}
+	if d.Count < 1 {
+		return fmt.Errorf("minimum device count is 1; got %d", d.Count)
+	}
+	return nil
+}
+
+func (d *DeviceResource) GoString() string {
+	return fmt.Sprintf("*%#v", *d)
+}
+
+// DeviceIndex is used to index the available device instances and the device
+// instances used by allocations on a machine
+type DeviceIndex struct {
+	AvailDevices []*DeviceResource   // List of available devices
+	UsedIDs      map[string]struct{} // Used device instances by device and ID
+}
+
+// NewDeviceIndex is used to construct a new device index
+func NewDeviceIndex() *DeviceIndex {
+	return &DeviceIndex{
+		UsedIDs: make(map[string]struct{}),
+	}
+}
+
+// SetNode is used to setup the available devices of the node
+func (idx *DeviceIndex) SetNode(node *Node) {
+	if node.Resources != nil {
+		idx.AvailDevices = node.Resources.Devices
+	}
+}
+
+// AddAllocs is used to add the devices used by the allocations. Returns
+// true if there is a collision
+func (idx *DeviceIndex) AddAllocs(allocs []*Allocation) (collide bool) {
+	for _, alloc := range allocs {
+		// Allocations within the plan have the combined resources stripped,
+		// while an ask only has the combined resources.
+		if alloc.TaskResources != nil {
+			for _, task := range alloc.TaskResources {
+				if idx.AddReservedDevices(task.Devices) {
+					collide = true
+				}
+			}
+		} else if alloc.Resources != nil {
+			if idx.AddReservedDevices(alloc.Resources.Devices) {
+				collide = true
+			}
+		}
+	}
+	return
+}
+
+// AddReservedDevices is used to add used devices, returns true if a device
+// instance is already in use or does not exist on the node
+func (idx *DeviceIndex) AddReservedDevices(devices []*DeviceResource) (collide bool) {
+	for _, d := range devices {
+		for _, id := range d.IDs {
+			if !idx.exists(d.Name, id) {
+				collide = true
+			}
+
+			key := d.Name + "/" + id
+			if _, ok := idx.UsedIDs[key]; ok {
+				collide = true
+			}
+			idx.UsedIDs[key] = struct{}{}
+		}
+	}
+	return
+}
+
+// exists returns whether the node has the device instance
+func (idx *DeviceIndex) exists(name, id string) bool {
+	for _, d := range idx.AvailDevices {
+		if d.Name != name {
+			continue
+		}
+		for _, avail := range d.IDs {
+			if avail == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// AssignDevice is used to assign device instances to a request. The offer
+// contains the name of the matched devices and the assigned IDs.
+func (idx *DeviceIndex) AssignDevice(ask *DeviceResource) (*DeviceResource, error) {
+	matched := false
+	for _, d := range idx.AvailDevices {
+		if !d.Matches(ask) {
+			continue
+		}
+		matched = true
+
+		var ids []string
+		for _, id := range d.IDs {
+			if _, ok := idx.UsedIDs[d.Name+"/"+id]; ok {
+				continue
+			}
+			ids = append(ids, id)
+			if len(ids) == ask.Count {
+				break
+			}
+		}
+		if len(ids) < ask.Count {
+			continue
+		}
+
+		offer := &DeviceResource{
+			Name:  d.Name,
+			Count: ask.Count,
+			IDs:   ids,
+		}
+		return offer, nil
+	}
+
+	if !matched {
+		return nil, fmt.Errorf("no devices match %q", ask.Name)
+	}
+	return nil, fmt.Errorf("devices exhausted for %q", ask.Name)
+}
@@ -0,0 +1,140 @@
+package structs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeviceResource_Matches(t *testing.T) {
+	d := &DeviceResource{Name: "nvidia/gpu/Tesla K80"}
+	cases := map[string]bool{
+		"gpu":                  true,
+		"nvidia/gpu":           true,
+		"nvidia/gpu/Tesla K80": true,
+		"fpga":                 false,
+		"amd/gpu":              false,
+		"nvidia/gpu/Tesla P4":  false,
+	}
+	for name, expected := range cases {
+		if actual := d.Matches(&DeviceResource{Name: name}); actual != expected {
+			t.Fatalf("bad match for %q: %v", name, actual)
+		}
+	}
+
+	if v, ty := d.Vendor(), d.Type(); v != "nvidia" || ty != "gpu" {
+		t.Fatalf("bad: %q %q", v, ty)
+	}
+}
+
+func TestDeviceResource_MeetsMinResources(t *testing.T) {
+	valid := []*DeviceResource{
+		{Name: "gpu", Count: 1},
+		{Name: "nvidia/gpu/Tesla K80", Count: 4},
+	}
+	for _, d := range valid {
+		if err := d.MeetsMinResources(); err != nil {
+			t.Fatalf("err for %#v: %v", d, err)
+		}
+	}
+
+	invalid := []*DeviceResource{
+		{Count: 1},
+		{Name: "nvidia//K80", Count: 1},
+		{Name: "gpu", Count: 0},
+	}
+	for _, d := range invalid {
+		if err := d.MeetsMinResources(); err == nil {
+			t.Fatalf("expected error for %#v", d)
+		}
+	}
+}
+
+func TestDeviceIndex_AssignDevice(t *testing.T) {
+	node := &Node{
+		Resources: &Resources{
+			Devices: []*DeviceResource{
+				{Name: "nvidia/gpu/Tesla K80", IDs: []string{"GPU-1", "GPU-2"}},
+				{Name: "nvidia/gpu/Tesla P100", IDs: []string{"GPU-3"}},
+			},
+		},
+	}
+	allocs := []*Allocation{
+		&Allocation{
+			TaskResources: map[string]*Resources{
+				"web": &Resources{
+					Devices: []*DeviceResource{
+						{Name: "nvidia/gpu/Tesla K80", Count: 1, IDs: []string{"GPU-1"}},
+					},
+				},
+			},
+		},
+	}
+
+	idx := NewDeviceIndex()
+	idx.SetNode(node)
+	if idx.AddAllocs(allocs) {
+		t.Fatalf("unexpected collision")
+	}
+
+	// The request is satisfied by the first matching device with enough
+	// free instances
+	offer, err := idx.AssignDevice(&DeviceResource{Name: "gpu", Count: 1})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := &DeviceResource{Name: "nvidia/gpu/Tesla K80", Count: 1, IDs: []string{"GPU-2"}}
+	if !reflect.DeepEqual(offer, expected) {
+		t.Fatalf("bad: %#v", offer)
+	}
+	idx.AddReservedDevices([]*DeviceResource{offer})
+
+	offer, err = idx.AssignDevice(&DeviceResource{Name: "nvidia/gpu", Count: 1})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if offer.Name != "nvidia/gpu/Tesla P100" || !reflect.DeepEqual(offer.IDs, []string{"GPU-3"}) {
+		t.Fatalf("bad: %#v", offer)
+	}
+	idx.AddReservedDevices([]*DeviceResource{offer})
+
+	if _, err := idx.AssignDevice(&DeviceResource{Name: "gpu", Count: 1}); err == nil ||
+		!strings.Contains(err.Error(), "exhausted") {
+		t.Fatalf("expected exhausted devices: %v", err)
+	}
+	if _, err := idx.AssignDevice(&DeviceResource{Name: "fpga", Count: 1}); err == nil ||
+		!strings.Contains(err.Error(), "no devices match") {
+		t.Fatalf("expected no matching devices: %v", err)
+	}
+}
+
+func TestDeviceIndex_AddAllocs_Collision(t *testing.T) {
+	node := &Node{
+		Resources: &Resources{
+			Devices: []*DeviceResource{
+				{Name: "nvidia/gpu/Tesla K80", IDs: []string{"GPU-1"}},
+			},
+		},
+	}
+	alloc := func(id string) *Allocation {
+		return &Allocation{
+			Resources: &Resources{
+				Devices: []*DeviceResource{
+					{Name: "nvidia/gpu/Tesla K80", Count: 1, IDs: []string{id}},
+				},
+			},
+		}
+	}
+
+	idx := NewDeviceIndex()
+	idx.SetNode(node)
+	if !idx.AddAllocs([]*Allocation{alloc("GPU-1"), alloc("GPU-1")}) {
+		t.Fatalf("expected collision of used device")
+	}
+
+	idx = NewDeviceIndex()
+	idx.SetNode(node)
+	if !idx.AddAllocs([]*Allocation{alloc("GPU-2")}) {
+		t.Fatalf("expected collision of unknown device")
+	}
+}
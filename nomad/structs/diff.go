@@ -59,7 +59,7 @@ type JobDiff struct {
 func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 	diff := &JobDiff{Type: DiffTypeNone}
 	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
-	filter := []string{"ID", "Status", "StatusDescription", "CreateIndex", "ModifyIndex", "JobModifyIndex"}
+	filter := []string{"ID", "Status", "StatusDescription", "Version", "Stable", "CreateIndex", "ModifyIndex", "JobModifyIndex"}
 
 	// Have to treat this special since it is a struct literal, not a pointer
 	var jUpdate, otherUpdate *UpdateStrategy
@@ -97,6 +97,11 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 	// Diff the primitive fields.
 	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, false)
 
+	// Regions diff
+	if setDiff := stringSetDiff(j.Regions, other.Regions, "Regions", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+
 	// Datacenters diff
 	if setDiff := stringSetDiff(j.Datacenters, other.Datacenters, "Datacenters", contextual); setDiff != nil {
 		diff.Objects = append(diff.Objects, setDiff)
@@ -113,6 +118,22 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 		diff.Objects = append(diff.Objects, conDiff...)
 	}
 
+	// Affinities diff
+	affinitiesDiff := primitiveObjectSetDiff(
+		interfaceSlice(j.Affinities),
+		interfaceSlice(other.Affinities),
+		[]string{"str"},
+		"Affinity",
+		contextual)
+	if affinitiesDiff != nil {
+		diff.Objects = append(diff.Objects, affinitiesDiff...)
+	}
+
+	// Spreads diff
+	if sDiffs := spreadDiffs(j.Spreads, other.Spreads, contextual); sDiffs != nil {
+		diff.Objects = append(diff.Objects, sDiffs...)
+	}
+
 	// Task groups diff
 	tgs, err := taskGroupDiffs(j.TaskGroups, other.TaskGroups, contextual)
 	if err != nil {
@@ -130,6 +151,11 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 		diff.Objects = append(diff.Objects, pDiff)
 	}
 
+	// Parameterized job diff
+	if cDiff := parameterizedJobDiff(j.ParameterizedJob, other.ParameterizedJob, contextual); cDiff != nil {
+		diff.Objects = append(diff.Objects, cDiff)
+	}
+
 	return diff, nil
 }
 
@@ -207,18 +233,65 @@ func (tg *TaskGroup) Diff(other *TaskGroup, contextual bool) (*TaskGroupDiff, er
 		diff.Objects = append(diff.Objects, conDiff...)
 	}
 
+	// Affinities diff
+	affinitiesDiff := primitiveObjectSetDiff(
+		interfaceSlice(tg.Affinities),
+		interfaceSlice(other.Affinities),
+		[]string{"str"},
+		"Affinity",
+		contextual)
+	if affinitiesDiff != nil {
+		diff.Objects = append(diff.Objects, affinitiesDiff...)
+	}
+
+	// Spreads diff
+	if sDiffs := spreadDiffs(tg.Spreads, other.Spreads, contextual); sDiffs != nil {
+		diff.Objects = append(diff.Objects, sDiffs...)
+	}
+
 	// Restart policy diff
 	rDiff := primitiveObjectDiff(tg.RestartPolicy, other.RestartPolicy, nil, "RestartPolicy", contextual)
 	if rDiff != nil {
 		diff.Objects = append(diff.Objects, rDiff)
 	}
 
+	// Reschedule policy diff
+	if rsDiff := primitiveObjectDiff(tg.ReschedulePolicy, other.ReschedulePolicy, nil, "ReschedulePolicy", contextual); rsDiff != nil {
+		diff.Objects = append(diff.Objects, rsDiff)
+	}
+
 	// EphemeralDisk diff
 	diskDiff := primitiveObjectDiff(tg.EphemeralDisk, other.EphemeralDisk, nil, "EphemeralDisk", contextual)
 	if diskDiff != nil {
 		diff.Objects = append(diff.Objects, diskDiff)
 	}
 
+	// Update strategy diff
+	if uDiff := primitiveObjectDiff(tg.Update, other.Update, nil, "Update", contextual); uDiff != nil {
+		diff.Objects = append(diff.Objects, uDiff)
+	}
+
+	// Migrate strategy diff
+	if mDiff := primitiveObjectDiff(tg.Migrate, other.Migrate, nil, "Migrate", contextual); mDiff != nil {
+		diff.Objects = append(diff.Objects, mDiff)
+	}
+
+	// Volumes diff
+	volDiffs := primitiveObjectSetDiff(
+		volumeRequestSlice(tg.Volumes),
+		volumeRequestSlice(other.Volumes),
+		nil,
+		"Volume",
+		contextual)
+	if volDiffs != nil {
+		diff.Objects = append(diff.Objects, volDiffs...)
+	}
+
+	// Network Resources diff
+	if nDiffs := networkResourceDiffs(tg.Networks, other.Networks, contextual); nDiffs != nil {
+		diff.Objects = append(diff.Objects, nDiffs...)
+	}
+
 	// Tasks diff
 	tasks, err := taskDiffs(tg.Tasks, other.Tasks, contextual)
 	if err != nil {
@@ -354,6 +427,17 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, conDiff...)
 	}
 
+	// Affinities diff
+	affinitiesDiff := primitiveObjectSetDiff(
+		interfaceSlice(t.Affinities),
+		interfaceSlice(other.Affinities),
+		[]string{"str"},
+		"Affinity",
+		contextual)
+	if affinitiesDiff != nil {
+		diff.Objects = append(diff.Objects, affinitiesDiff...)
+	}
+
 	// Config diff
 	if cDiff := configDiff(t.Config, other.Config, contextual); cDiff != nil {
 		diff.Objects = append(diff.Objects, cDiff)
@@ -403,6 +487,27 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, tmplDiffs...)
 	}
 
+	// Dispatch payload diff
+	if dDiff := primitiveObjectDiff(t.DispatchPayload, other.DispatchPayload, nil, "DispatchPayload", contextual); dDiff != nil {
+		diff.Objects = append(diff.Objects, dDiff)
+	}
+
+	// Volume mounts diff
+	mountDiffs := primitiveObjectSetDiff(
+		interfaceSlice(t.VolumeMounts),
+		interfaceSlice(other.VolumeMounts),
+		nil,
+		"VolumeMount",
+		contextual)
+	if mountDiffs != nil {
+		diff.Objects = append(diff.Objects, mountDiffs...)
+	}
+
+	// Lifecycle diff
+	if lcDiff := primitiveObjectDiff(t.Lifecycle, other.Lifecycle, nil, "Lifecycle", contextual); lcDiff != nil {
+		diff.Objects = append(diff.Objects, lcDiff)
+	}
+
 	return diff, nil
 }
 
@@ -535,6 +640,78 @@ func serviceDiffs(old, new []*Service, contextual bool) []*ObjectDiff {
 	return diffs
 }
 
+// spreadDiff returns the diff of two spread objects. If contextual diff is
+// enabled, all fields will be returned, even if no diff occurred.
+func spreadDiff(old, new *Spread, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Spread"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	} else if old == nil {
+		old = &Spread{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	} else if new == nil {
+		new = &Spread{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Spread targets diff
+	targetDiffs := primitiveObjectSetDiff(
+		interfaceSlice(old.SpreadTarget),
+		interfaceSlice(new.SpreadTarget),
+		nil,
+		"SpreadTarget",
+		contextual)
+	if targetDiffs != nil {
+		diff.Objects = append(diff.Objects, targetDiffs...)
+	}
+
+	return diff
+}
+
+// spreadDiffs diffs a set of spreads keyed by their attribute. If contextual
+// diff is enabled, unchanged fields within the spreads will be returned.
+func spreadDiffs(old, new []*Spread, contextual bool) []*ObjectDiff {
+	oldMap := make(map[string]*Spread, len(old))
+	newMap := make(map[string]*Spread, len(new))
+	for _, o := range old {
+		oldMap[o.Attribute] = o
+	}
+	for _, n := range new {
+		newMap[n.Attribute] = n
+	}
+
+	var diffs []*ObjectDiff
+	for attr, oldSpread := range oldMap {
+		// Diff the same, deleted and edited
+		if diff := spreadDiff(oldSpread, newMap[attr], contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	for attr, newSpread := range newMap {
+		// Diff the added
+		if old, ok := oldMap[attr]; !ok {
+			if diff := spreadDiff(old, newSpread, contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
 // serviceCheckDiff returns the diff of two service check objects. If contextual
 // diff is enabled, all fields will be returned, even if no diff occurred.
 func serviceCheckDiff(old, new *ServiceCheck, contextual bool) *ObjectDiff {
@@ -559,6 +736,11 @@ func serviceCheckDiff(old, new *ServiceCheck, contextual bool) *ObjectDiff {
 
 	// Diff the primitive fields.
 	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Check restart diff
+	if crDiff := primitiveObjectDiff(old.CheckRestart, new.CheckRestart, nil, "CheckRestart", contextual); crDiff != nil {
+		diff.Objects = append(diff.Objects, crDiff)
+	}
 	return diff
 }
 
@@ -629,6 +811,44 @@ func vaultDiff(old, new *Vault, contextual bool) *ObjectDiff {
 	return diff
 }
 
+// parameterizedJobDiff returns the diff of two parameterized job objects. If
+// contextual diff is enabled, all fields will be returned, even if no diff
+// occurred.
+func parameterizedJobDiff(old, new *ParameterizedJobConfig, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "ParameterizedJob"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	} else if old == nil {
+		old = &ParameterizedJobConfig{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	} else if new == nil {
+		new = &ParameterizedJobConfig{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(old, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(new, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Meta diffs
+	if requiredDiff := stringSetDiff(old.MetaRequired, new.MetaRequired, "MetaRequired", contextual); requiredDiff != nil {
+		diff.Objects = append(diff.Objects, requiredDiff)
+	}
+
+	if optionalDiff := stringSetDiff(old.MetaOptional, new.MetaOptional, "MetaOptional", contextual); optionalDiff != nil {
+		diff.Objects = append(diff.Objects, optionalDiff)
+	}
+
+	return diff
+}
+
 // Diff returns a diff of two resource objects. If contextual diff is enabled,
 // non-changed fields will still be returned.
 func (r *Resources) Diff(other *Resources, contextual bool) *ObjectDiff {
@@ -659,6 +879,17 @@ func (r *Resources) Diff(other *Resources, contextual bool) *ObjectDiff {
 		diff.Objects = append(diff.Objects, nDiffs...)
 	}
 
+	// Device Resources diff
+	devDiff := primitiveObjectSetDiff(
+		interfaceSlice(r.Devices),
+		interfaceSlice(other.Devices),
+		nil,
+		"Device",
+		contextual)
+	if devDiff != nil {
+		diff.Objects = append(diff.Objects, devDiff...)
+	}
+
 	return diff
 }
 
@@ -667,7 +898,7 @@ func (r *Resources) Diff(other *Resources, contextual bool) *ObjectDiff {
 func (r *NetworkResource) Diff(other *NetworkResource, contextual bool) *ObjectDiff {
 	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Network"}
 	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
-	filter := []string{"Device", "CIDR", "IP"}
+	filter := []string{"Device", "CIDR", "IP", "IPv6"}
 
 	if reflect.DeepEqual(r, other) {
 		return nil
@@ -1118,6 +1349,16 @@ func primitiveObjectSetDiff(old, new []interface{}, filter []string, name string
 // interfaceSlice is a helper method that takes a slice of typed elements and
 // returns a slice of interface. This method will panic if given a non-slice
 // input.
+// volumeRequestSlice returns the volume requests of a task group as a slice
+// that can be diffed as a set.
+func volumeRequestSlice(volumes map[string]*VolumeRequest) []interface{} {
+	ret := make([]interface{}, 0, len(volumes))
+	for _, v := range volumes {
+		ret = append(ret, v)
+	}
+	return ret
+}
+
 func interfaceSlice(slice interface{}) []interface{} {
 	s := reflect.ValueOf(slice)
 	if s.Kind() != reflect.Slice {
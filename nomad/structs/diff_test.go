@@ -186,6 +186,24 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeDeleted,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "AutoRevert",
+								Old:  "false",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Canary",
+								Old:  "0",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "HealthyDeadline",
+								Old:  "0",
+								New:  "",
+							},
 							{
 								Type: DiffTypeDeleted,
 								Name: "MaxParallel",
@@ -263,6 +281,24 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeAdded,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "AutoRevert",
+								Old:  "",
+								New:  "false",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Canary",
+								Old:  "",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "HealthyDeadline",
+								Old:  "",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeAdded,
 								Name: "MaxParallel",
@@ -312,6 +348,38 @@ func TestJobDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Regions diff both added and removed
+			Old: &Job{
+				Regions: []string{"us", "eu"},
+			},
+			New: &Job{
+				Regions: []string{"eu", "asia"},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Regions",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Regions",
+								Old:  "",
+								New:  "asia",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Regions",
+								Old:  "us",
+								New:  "",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Datacenter diff both added and removed
 			Old: &Job{
@@ -489,6 +557,30 @@ func TestJobDiff(t *testing.T) {
 						Type: DiffTypeEdited,
 						Name: "Update",
 						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeNone,
+								Name: "AutoRevert",
+								Old:  "false",
+								New:  "false",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "Canary",
+								Old:  "0",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "HealthCheck",
+								Old:  "",
+								New:  "",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "HealthyDeadline",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeNone,
 								Name: "MaxParallel",
@@ -654,6 +746,65 @@ func TestJobDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Parameterized job edited
+			Old: &Job{
+				ParameterizedJob: &ParameterizedJobConfig{
+					Payload:      DispatchPayloadOptional,
+					MetaRequired: []string{"foo"},
+					MetaOptional: []string{"bar"},
+				},
+			},
+			New: &Job{
+				ParameterizedJob: &ParameterizedJobConfig{
+					Payload:      DispatchPayloadRequired,
+					MetaRequired: []string{"foo", "baz"},
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "ParameterizedJob",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Payload",
+								Old:  DispatchPayloadOptional,
+								New:  DispatchPayloadRequired,
+							},
+						},
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "MetaRequired",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "MetaRequired",
+										Old:  "",
+										New:  "baz",
+									},
+								},
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "MetaOptional",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeDeleted,
+										Name: "MetaOptional",
+										Old:  "bar",
+										New:  "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Periodic edited with context
 			Contextual: true,
@@ -797,6 +948,196 @@ func TestJobDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Affinities edited
+			Old: &Job{
+				Affinities: []*Affinity{
+					{
+						LTarget: "foo",
+						RTarget: "foo",
+						Operand: "foo",
+						Weight:  20,
+						str:     "foo",
+					},
+					{
+						LTarget: "bar",
+						RTarget: "bar",
+						Operand: "bar",
+						Weight:  20,
+						str:     "bar",
+					},
+				},
+			},
+			New: &Job{
+				Affinities: []*Affinity{
+					{
+						LTarget: "foo",
+						RTarget: "foo",
+						Operand: "foo",
+						Weight:  20,
+						str:     "foo",
+					},
+					{
+						LTarget: "bar",
+						RTarget: "bar",
+						Operand: "bar",
+						Weight:  -50,
+						str:     "bar",
+					},
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "Affinity",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "LTarget",
+								Old:  "",
+								New:  "bar",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Operand",
+								Old:  "",
+								New:  "bar",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "RTarget",
+								Old:  "",
+								New:  "bar",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Weight",
+								Old:  "",
+								New:  "-50",
+							},
+						},
+					},
+					{
+						Type: DiffTypeDeleted,
+						Name: "Affinity",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "LTarget",
+								Old:  "bar",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Operand",
+								Old:  "bar",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "RTarget",
+								Old:  "bar",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Weight",
+								Old:  "20",
+								New:  "",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			// Spreads edited
+			Old: &Job{
+				Spreads: []*Spread{
+					{
+						Attribute: "foo",
+						Weight:    50,
+						SpreadTarget: []*SpreadTarget{
+							{
+								Value:   "bar",
+								Percent: 50,
+							},
+						},
+					},
+				},
+			},
+			New: &Job{
+				Spreads: []*Spread{
+					{
+						Attribute: "foo",
+						Weight:    100,
+						SpreadTarget: []*SpreadTarget{
+							{
+								Value:   "baz",
+								Percent: 50,
+							},
+						},
+					},
+				},
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Spread",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Weight",
+								Old:  "50",
+								New:  "100",
+							},
+						},
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "SpreadTarget",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "Percent",
+										Old:  "",
+										New:  "50",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "Value",
+										Old:  "",
+										New:  "baz",
+									},
+								},
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "SpreadTarget",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeDeleted,
+										Name: "Percent",
+										Old:  "50",
+										New:  "",
+									},
+									{
+										Type: DiffTypeDeleted,
+										Name: "Value",
+										Old:  "bar",
+										New:  "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Task groups edited
 			Old: &Job{
@@ -1419,16 +1760,104 @@ func TestTaskGroupDiff(t *testing.T) {
 								New:  "false",
 							},
 							{
-								Type: DiffTypeEdited,
-								Name: "SizeMB",
-								Old:  "150",
-								New:  "90",
+								Type: DiffTypeEdited,
+								Name: "SizeMB",
+								Old:  "150",
+								New:  "90",
+							},
+
+							{
+								Type: DiffTypeEdited,
+								Name: "Sticky",
+								Old:  "true",
+								New:  "false",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			// Migrate strategy edited
+			Old: &TaskGroup{
+				Migrate: &MigrateStrategy{
+					MaxParallel:     1,
+					HealthCheck:     MigrateHealthCheckTaskStates,
+					MinHealthyTime:  10 * time.Second,
+					HealthyDeadline: 5 * time.Minute,
+				},
+			},
+			New: &TaskGroup{
+				Migrate: &MigrateStrategy{
+					MaxParallel:     2,
+					HealthCheck:     MigrateHealthCheckNone,
+					MinHealthyTime:  10 * time.Second,
+					HealthyDeadline: 5 * time.Minute,
+				},
+			},
+			Expected: &TaskGroupDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Migrate",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "HealthCheck",
+								Old:  "task_states",
+								New:  "none",
+							},
+							{
+								Type: DiffTypeEdited,
+								Name: "MaxParallel",
+								Old:  "1",
+								New:  "2",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			// ReschedulePolicy added
+			Old: &TaskGroup{},
+			New: &TaskGroup{
+				ReschedulePolicy: &ReschedulePolicy{
+					Attempts: 1,
+					Interval: 24 * time.Hour,
+					Delay:    5 * time.Second,
+				},
+			},
+			Expected: &TaskGroupDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "ReschedulePolicy",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Attempts",
+								Old:  "",
+								New:  "1",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Delay",
+								Old:  "",
+								New:  "5000000000",
 							},
-
 							{
-								Type: DiffTypeEdited,
-								Name: "Sticky",
-								Old:  "true",
+								Type: DiffTypeAdded,
+								Name: "Interval",
+								Old:  "",
+								New:  "86400000000000",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Unlimited",
+								Old:  "",
 								New:  "false",
 							},
 						},
@@ -1575,6 +2004,93 @@ func TestTaskGroupDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Volumes edited
+			Old: &TaskGroup{
+				Volumes: map[string]*VolumeRequest{
+					"certs": {
+						Name:   "certs",
+						Type:   "host",
+						Source: "certs",
+					},
+				},
+			},
+			New: &TaskGroup{
+				Volumes: map[string]*VolumeRequest{
+					"certs": {
+						Name:     "certs",
+						Type:     "host",
+						Source:   "ca-certs",
+						ReadOnly: true,
+					},
+				},
+			},
+			Expected: &TaskGroupDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "Volume",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Name",
+								Old:  "",
+								New:  "certs",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "ReadOnly",
+								Old:  "",
+								New:  "true",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Source",
+								Old:  "",
+								New:  "ca-certs",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Type",
+								Old:  "",
+								New:  "host",
+							},
+						},
+					},
+					{
+						Type: DiffTypeDeleted,
+						Name: "Volume",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeDeleted,
+								Name: "Name",
+								Old:  "certs",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "ReadOnly",
+								Old:  "false",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Source",
+								Old:  "certs",
+								New:  "",
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Type",
+								Old:  "host",
+								New:  "",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for i, c := range cases {
@@ -1860,6 +2376,37 @@ func TestTaskDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Lifecycle edited
+			Old: &Task{
+				Lifecycle: &TaskLifecycleConfig{
+					Hook: TaskLifecycleHookPrestart,
+				},
+			},
+			New: &Task{
+				Lifecycle: &TaskLifecycleConfig{
+					Hook:    TaskLifecycleHookPrestart,
+					Sidecar: true,
+				},
+			},
+			Expected: &TaskDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Lifecycle",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Sidecar",
+								Old:  "false",
+								New:  "true",
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// LogConfig added
 			Old: &Task{},
@@ -2181,6 +2728,12 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "100",
 								New:  "200",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "Cores",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "DiskMB",
@@ -2204,6 +2757,70 @@ func TestTaskDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Device Resources edited
+			Old: &Task{
+				Resources: &Resources{
+					Devices: []*DeviceResource{
+						{Name: "nvidia/gpu", Count: 1},
+					},
+				},
+			},
+			New: &Task{
+				Resources: &Resources{
+					Devices: []*DeviceResource{
+						{Name: "nvidia/gpu", Count: 2},
+					},
+				},
+			},
+			Expected: &TaskDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Resources",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Device",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "Count",
+										Old:  "",
+										New:  "2",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "Name",
+										Old:  "",
+										New:  "nvidia/gpu",
+									},
+								},
+							},
+							{
+								Type: DiffTypeDeleted,
+								Name: "Device",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeDeleted,
+										Name: "Count",
+										Old:  "1",
+										New:  "",
+									},
+									{
+										Type: DiffTypeDeleted,
+										Name: "Name",
+										Old:  "nvidia/gpu",
+										New:  "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Network Resources edited
 			Old: &Task{
@@ -2281,6 +2898,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "",
 												New:  "foo",
 											},
+											{
+												Type: DiffTypeAdded,
+												Name: "To",
+												Old:  "",
+												New:  "0",
+											},
 											{
 												Type: DiffTypeAdded,
 												Name: "Value",
@@ -2299,6 +2922,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "",
 												New:  "baz",
 											},
+											{
+												Type: DiffTypeAdded,
+												Name: "To",
+												Old:  "",
+												New:  "0",
+											},
 										},
 									},
 								},
@@ -2325,6 +2954,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "foo",
 												New:  "",
 											},
+											{
+												Type: DiffTypeDeleted,
+												Name: "To",
+												Old:  "0",
+												New:  "",
+											},
 											{
 												Type: DiffTypeDeleted,
 												Name: "Value",
@@ -2343,6 +2978,12 @@ func TestTaskDiff(t *testing.T) {
 												Old:  "bar",
 												New:  "",
 											},
+											{
+												Type: DiffTypeDeleted,
+												Name: "To",
+												Old:  "0",
+												New:  "",
+											},
 										},
 									},
 								},
@@ -2550,6 +3191,12 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "boom_port",
 								New:  "boom_port",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "boom.To",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeNone,
 								Name: "boom.Value",
@@ -2807,6 +3454,12 @@ func TestTaskDiff(t *testing.T) {
 										Old:  "",
 										New:  "foo",
 									},
+									{
+										Type: DiffTypeAdded,
+										Name: "GRPCUseTLS",
+										Old:  "",
+										New:  "false",
+									},
 									{
 										Type: DiffTypeAdded,
 										Name: "Interval",
@@ -2855,6 +3508,12 @@ func TestTaskDiff(t *testing.T) {
 										Old:  "foo",
 										New:  "",
 									},
+									{
+										Type: DiffTypeDeleted,
+										Name: "GRPCUseTLS",
+										Old:  "false",
+										New:  "",
+									},
 									{
 										Type: DiffTypeDeleted,
 										Name: "Interval",
@@ -2972,6 +3631,18 @@ func TestTaskDiff(t *testing.T) {
 										Old:  "foo",
 										New:  "foo",
 									},
+									{
+										Type: DiffTypeNone,
+										Name: "GRPCService",
+										Old:  "",
+										New:  "",
+									},
+									{
+										Type: DiffTypeNone,
+										Name: "GRPCUseTLS",
+										Old:  "false",
+										New:  "false",
+									},
 									{
 										Type: DiffTypeEdited,
 										Name: "InitialStatus",
@@ -3027,6 +3698,79 @@ func TestTaskDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Service Check restart added
+			Old: &Task{
+				Services: []*Service{
+					{
+						Name: "foo",
+						Checks: []*ServiceCheck{
+							{
+								Name: "foo",
+							},
+						},
+					},
+				},
+			},
+			New: &Task{
+				Services: []*Service{
+					{
+						Name: "foo",
+						Checks: []*ServiceCheck{
+							{
+								Name: "foo",
+								CheckRestart: &CheckRestart{
+									Limit:          3,
+									Grace:          10 * time.Second,
+									IgnoreWarnings: true,
+								},
+							},
+						},
+					},
+				},
+			},
+			Expected: &TaskDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "Service",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Check",
+								Objects: []*ObjectDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "CheckRestart",
+										Fields: []*FieldDiff{
+											{
+												Type: DiffTypeAdded,
+												Name: "Grace",
+												Old:  "",
+												New:  "10000000000",
+											},
+											{
+												Type: DiffTypeAdded,
+												Name: "IgnoreWarnings",
+												Old:  "",
+												New:  "true",
+											},
+											{
+												Type: DiffTypeAdded,
+												Name: "Limit",
+												Old:  "",
+												New:  "3",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			// Vault added
 			Old: &Task{},
@@ -113,6 +113,20 @@ func AllocsFit(node *Node, allocs []*Allocation, netIdx *NetworkIndex) (bool, st
 		return false, "bandwidth exceeded", used, nil
 	}
 
+	// Check that the devices exist and are not used more than once
+	devIdx := NewDeviceIndex()
+	devIdx.SetNode(node)
+	if devIdx.AddAllocs(allocs) {
+		return false, "devices exhausted", used, nil
+	}
+
+	// Check that the reserved cores exist and are not reserved more than once
+	coreIdx := NewCoreIndex()
+	coreIdx.SetNode(node)
+	if coreIdx.AddAllocs(allocs) {
+		return false, "cores exhausted", used, nil
+	}
+
 	// Allocations fit!
 	return true, "", used, nil
 }
@@ -121,19 +135,7 @@ func AllocsFit(node *Node, allocs []*Allocation, netIdx *NetworkIndex) (bool, st
 // http://www.columbia.edu/~cs2035/courses/ieor4405.S13/datacenter_scheduling.ppt
 // This is equivalent to their BestFit v3
 func ScoreFit(node *Node, util *Resources) float64 {
-	// Determine the node availability
-	nodeCpu := float64(node.Resources.CPU)
-	if node.Reserved != nil {
-		nodeCpu -= float64(node.Reserved.CPU)
-	}
-	nodeMem := float64(node.Resources.MemoryMB)
-	if node.Reserved != nil {
-		nodeMem -= float64(node.Reserved.MemoryMB)
-	}
-
-	// Compute the free percentage
-	freePctCpu := 1 - (float64(util.CPU) / nodeCpu)
-	freePctRam := 1 - (float64(util.MemoryMB) / nodeMem)
+	freePctCpu, freePctRam := computeFreePercentage(node, util)
 
 	// Total will be "maximized" the smaller the value is.
 	// At 100% utilization, the total is 2, while at 0% util it is 20.
@@ -154,6 +156,46 @@ func ScoreFit(node *Node, util *Resources) float64 {
 	return score
 }
 
+// ScoreFitSpread is used to score the fit for the spread scheduler algorithm.
+// It is the inverse of ScoreFit, so the least utilized nodes score the
+// highest and allocations are spread across the nodes.
+func ScoreFitSpread(node *Node, util *Resources) float64 {
+	freePctCpu, freePctRam := computeFreePercentage(node, util)
+
+	// At 100% utilization the total is 2, while at 0% util it is 20. Because
+	// the floor is 2, we simply use that as an anchor. This means an empty
+	// node scores 18, the same as a perfect fit with ScoreFit.
+	total := math.Pow(10, freePctCpu) + math.Pow(10, freePctRam)
+	score := total - 2.0
+
+	// Bound the score, just in case
+	if score > 18.0 {
+		score = 18.0
+	} else if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// computeFreePercentage returns the percentage of the CPU and memory of the
+// node left free with the given utilization
+func computeFreePercentage(node *Node, util *Resources) (freePctCpu, freePctRam float64) {
+	// Determine the node availability
+	nodeCpu := float64(node.Resources.CPU)
+	if node.Reserved != nil {
+		nodeCpu -= float64(node.Reserved.CPU)
+	}
+	nodeMem := float64(node.Resources.MemoryMB)
+	if node.Reserved != nil {
+		nodeMem -= float64(node.Reserved.MemoryMB)
+	}
+
+	// Compute the free percentage
+	freePctCpu = 1 - (float64(util.CPU) / nodeCpu)
+	freePctRam = 1 - (float64(util.MemoryMB) / nodeMem)
+	return freePctCpu, freePctRam
+}
+
 // GenerateUUID is used to generate a random UUID
 func GenerateUUID() string {
 	buf := make([]byte, 16)
@@ -248,6 +290,45 @@ func CopySliceConstraints(s []*Constraint) []*Constraint {
 	return c
 }
 
+func CopySliceAffinities(s []*Affinity) []*Affinity {
+	l := len(s)
+	if l == 0 {
+		return nil
+	}
+
+	a := make([]*Affinity, l)
+	for i, v := range s {
+		a[i] = v.Copy()
+	}
+	return a
+}
+
+func CopySliceSpreads(s []*Spread) []*Spread {
+	l := len(s)
+	if l == 0 {
+		return nil
+	}
+
+	c := make([]*Spread, l)
+	for i, v := range s {
+		c[i] = v.Copy()
+	}
+	return c
+}
+
+func CopySliceNetworkResources(s []*NetworkResource) []*NetworkResource {
+	l := len(s)
+	if l == 0 {
+		return nil
+	}
+
+	c := make([]*NetworkResource, l)
+	for i, v := range s {
+		c[i] = v.Copy()
+	}
+	return c
+}
+
 // SliceStringIsSubset returns whether the smaller set of strings is a subset of
 // the larger. If the smaller slice is not a subset, the offending elements are
 // returned.
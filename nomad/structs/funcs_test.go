@@ -104,7 +104,7 @@ func TestAllocsFit_PortsOvercommitted(t *testing.T) {
 						Device:        "eth0",
 						IP:            "10.0.0.1",
 						MBits:         50,
-						ReservedPorts: []Port{{"main", 8000}},
+						ReservedPorts: []Port{{"main", 8000, 0}},
 					},
 				},
 			},
@@ -155,7 +155,7 @@ func TestAllocsFit(t *testing.T) {
 					Device:        "eth0",
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"main", 80}},
+					ReservedPorts: []Port{{"main", 80, 0}},
 				},
 			},
 		},
@@ -172,7 +172,7 @@ func TestAllocsFit(t *testing.T) {
 					Device:        "eth0",
 					IP:            "10.0.0.1",
 					MBits:         50,
-					ReservedPorts: []Port{{"main", 8000}},
+					ReservedPorts: []Port{{"main", 8000, 0}},
 				},
 			},
 		},
@@ -256,6 +256,66 @@ func TestScoreFit(t *testing.T) {
 	}
 }
 
+func TestScoreFitSpread(t *testing.T) {
+	node := &Node{}
+	node.Resources = &Resources{
+		CPU:      4096,
+		MemoryMB: 8192,
+	}
+	node.Reserved = &Resources{
+		CPU:      2048,
+		MemoryMB: 4096,
+	}
+
+	// Test a full node
+	util := &Resources{
+		CPU:      2048,
+		MemoryMB: 4096,
+	}
+	score := ScoreFitSpread(node, util)
+	if score != 0.0 {
+		t.Fatalf("bad: %v", score)
+	}
+
+	// Test an empty node
+	util = &Resources{
+		CPU:      0,
+		MemoryMB: 0,
+	}
+	score = ScoreFitSpread(node, util)
+	if score != 18.0 {
+		t.Fatalf("bad: %v", score)
+	}
+
+	// Test a mid-case scenario
+	util = &Resources{
+		CPU:      1024,
+		MemoryMB: 2048,
+	}
+	score = ScoreFitSpread(node, util)
+	if score < 2.0 || score > 8.0 {
+		t.Fatalf("bad: %v", score)
+	}
+}
+
+func TestSchedulerConfig_Validate(t *testing.T) {
+	config := &SchedulerConfig{SchedulerAlgorithm: SchedulerAlgorithmSpread}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	config.SchedulerAlgorithm = "foo"
+	if err := config.Validate(); err == nil {
+		t.Fatalf("expected error")
+	}
+
+	// The algorithm defaults to binpack
+	var nilConfig *SchedulerConfig
+	if algorithm := nilConfig.EffectiveSchedulerAlgorithm(); algorithm != SchedulerAlgorithmBinpack {
+		t.Fatalf("bad: %v", algorithm)
+	}
+}
+
 func TestGenerateUUID(t *testing.T) {
 	prev := GenerateUUID()
 	for i := 0; i < 100; i++ {
@@ -301,3 +361,86 @@ func TestMapStringStringSliceValueSet(t *testing.T) {
 		t.Fatalf("Bad; got %v; want %v", act, exp)
 	}
 }
+
+func TestAllocsFit_Devices(t *testing.T) {
+	n := &Node{
+		Resources: &Resources{
+			CPU:      2000,
+			MemoryMB: 2048,
+			Devices: []*DeviceResource{
+				{Name: "nvidia/gpu/Tesla K80", IDs: []string{"GPU-1", "GPU-2"}},
+			},
+		},
+	}
+	alloc := func(id string) *Allocation {
+		return &Allocation{
+			TaskResources: map[string]*Resources{
+				"web": &Resources{
+					CPU:      100,
+					MemoryMB: 100,
+					Devices: []*DeviceResource{
+						{Name: "nvidia/gpu/Tesla K80", Count: 1, IDs: []string{id}},
+					},
+				},
+			},
+		}
+	}
+
+	// Should fit allocations using different devices
+	fit, _, _, err := AllocsFit(n, []*Allocation{alloc("GPU-1"), alloc("GPU-2")}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !fit {
+		t.Fatalf("Bad")
+	}
+
+	// Should not fit allocations using the same device
+	fit, dim, _, err := AllocsFit(n, []*Allocation{alloc("GPU-1"), alloc("GPU-1")}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit || dim != "devices exhausted" {
+		t.Fatalf("Bad: %v %q", fit, dim)
+	}
+}
+
+func TestAllocsFit_Cores(t *testing.T) {
+	n := &Node{
+		Resources: &Resources{
+			CPU:      4000,
+			Cores:    2,
+			MemoryMB: 2048,
+		},
+	}
+	alloc := func(cores ...int) *Allocation {
+		return &Allocation{
+			TaskResources: map[string]*Resources{
+				"web": &Resources{
+					CPU:           2000 * len(cores),
+					Cores:         len(cores),
+					ReservedCores: cores,
+					MemoryMB:      100,
+				},
+			},
+		}
+	}
+
+	// Should fit allocations reserving different cores
+	fit, _, used, err := AllocsFit(n, []*Allocation{alloc(0), alloc(1)}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !fit || used.Cores != 2 {
+		t.Fatalf("Bad: %v %#v", fit, used)
+	}
+
+	// Should not fit allocations reserving the same core
+	fit, dim, _, err := AllocsFit(n, []*Allocation{alloc(0), alloc(0)}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fit || dim != "cores exhausted" {
+		t.Fatalf("Bad: %v %q", fit, dim)
+	}
+}
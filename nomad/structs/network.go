@@ -102,6 +102,13 @@ func (idx *NetworkIndex) AddAllocs(allocs []*Allocation) (collide bool) {
 				collide = true
 			}
 		}
+
+		// Add the network shared by the tasks of the group
+		if alloc.SharedResources != nil && len(alloc.SharedResources.Networks) != 0 {
+			if idx.AddReserved(alloc.SharedResources.Networks[0]) {
+				collide = true
+			}
+		}
 	}
 	return
 }
@@ -109,8 +116,38 @@ func (idx *NetworkIndex) AddAllocs(allocs []*Allocation) (collide bool) {
 // AddReserved is used to add a reserved network usage, returns true
 // if there is a port collision
 func (idx *NetworkIndex) AddReserved(n *NetworkResource) (collide bool) {
-	// Add the port usage
-	used := idx.UsedPorts[n.IP]
+	// Add the port usage. The ports of a dual-stack network are used on
+	// both of its addresses.
+	ips := []string{n.IP}
+	if n.IPv6 != "" {
+		ips = append(ips, n.IPv6)
+	}
+	for _, ip := range ips {
+		used := idx.usedPorts(ip)
+		for _, ports := range [][]Port{n.ReservedPorts, n.DynamicPorts} {
+			for _, port := range ports {
+				// Guard against invalid port
+				if port.Value < 0 || port.Value >= maxValidPort {
+					return true
+				}
+				if used.Check(uint(port.Value)) {
+					collide = true
+				} else {
+					used.Set(uint(port.Value))
+				}
+			}
+		}
+	}
+
+	// Add the bandwidth
+	idx.UsedBandwidth[n.Device] += n.MBits
+	return
+}
+
+// usedPorts returns the bitmap of the ports used on the IP, creating it if
+// needed.
+func (idx *NetworkIndex) usedPorts(ip string) Bitmap {
+	used := idx.UsedPorts[ip]
 	if used == nil {
 		// Try to get a bitmap from the pool, else create
 		raw := bitmapPool.Get()
@@ -120,31 +157,14 @@ func (idx *NetworkIndex) AddReserved(n *NetworkResource) (collide bool) {
 		} else {
 			used, _ = NewBitmap(maxValidPort)
 		}
-		idx.UsedPorts[n.IP] = used
-	}
-
-	for _, ports := range [][]Port{n.ReservedPorts, n.DynamicPorts} {
-		for _, port := range ports {
-			// Guard against invalid port
-			if port.Value < 0 || port.Value >= maxValidPort {
-				return true
-			}
-			if used.Check(uint(port.Value)) {
-				collide = true
-			} else {
-				used.Set(uint(port.Value))
-			}
-		}
+		idx.UsedPorts[ip] = used
 	}
-
-	// Add the bandwidth
-	idx.UsedBandwidth[n.Device] += n.MBits
-	return
+	return used
 }
 
 // yieldIP is used to iteratively invoke the callback with
-// an available IP
-func (idx *NetworkIndex) yieldIP(cb func(net *NetworkResource, ip net.IP) bool) {
+// an available IP of the requested address family
+func (idx *NetworkIndex) yieldIP(ipv6 bool, cb func(net *NetworkResource, ip net.IP) bool) {
 	inc := func(ip net.IP) {
 		for j := len(ip) - 1; j >= 0; j-- {
 			ip[j]++
@@ -156,7 +176,7 @@ func (idx *NetworkIndex) yieldIP(cb func(net *NetworkResource, ip net.IP) bool)
 
 	for _, n := range idx.AvailNetworks {
 		ip, ipnet, err := net.ParseCIDR(n.CIDR)
-		if err != nil {
+		if err != nil || (ip.To4() == nil) != ipv6 {
 			continue
 		}
 		for ip := ip.Mask(ipnet.Mask); ipnet.Contains(ip); inc(ip) {
@@ -167,11 +187,30 @@ func (idx *NetworkIndex) yieldIP(cb func(net *NetworkResource, ip net.IP) bool)
 	}
 }
 
+// ipv6Addr returns the first IPv6 address available on the device or nil if
+// there is none.
+func (idx *NetworkIndex) ipv6Addr(device string) net.IP {
+	for _, n := range idx.AvailNetworks {
+		if n.Device != device {
+			continue
+		}
+		ip, ipnet, err := net.ParseCIDR(n.CIDR)
+		if err != nil || ip.To4() != nil {
+			continue
+		}
+		return ip.Mask(ipnet.Mask)
+	}
+	return nil
+}
+
 // AssignNetwork is used to assign network resources given an ask.
 // If the ask cannot be satisfied, returns nil
 func (idx *NetworkIndex) AssignNetwork(ask *NetworkResource) (out *NetworkResource, err error) {
 	err = fmt.Errorf("no networks available")
-	idx.yieldIP(func(n *NetworkResource, ip net.IP) (stop bool) {
+	family := ask.Family()
+	// Only IPv6 asks are assigned an IPv6 address as their primary address,
+	// dual-stack asks get one in addition to the IPv4 address
+	idx.yieldIP(family == NetworkAddressFamilyIPv6, func(n *NetworkResource, ip net.IP) (stop bool) {
 		// Convert the IP to a string
 		ipStr := ip.String()
 
@@ -185,6 +224,18 @@ func (idx *NetworkIndex) AssignNetwork(ask *NetworkResource) (out *NetworkResour
 
 		used := idx.UsedPorts[ipStr]
 
+		// The ports of a dual-stack ask must be free on both addresses
+		var ipv6Str string
+		if family == NetworkAddressFamilyDual {
+			ipv6 := idx.ipv6Addr(n.Device)
+			if ipv6 == nil {
+				err = fmt.Errorf("no IPv6 address available")
+				return
+			}
+			ipv6Str = ipv6.String()
+			used = unionBitmaps(used, idx.UsedPorts[ipv6Str])
+		}
+
 		// Check if any of the reserved ports are in use
 		for _, port := range ask.ReservedPorts {
 			// Guard against invalid port
@@ -202,8 +253,11 @@ func (idx *NetworkIndex) AssignNetwork(ask *NetworkResource) (out *NetworkResour
 
 		// Create the offer
 		offer := &NetworkResource{
+			Mode:          ask.Mode,
+			AddressFamily: ask.AddressFamily,
 			Device:        n.Device,
 			IP:            ipStr,
+			IPv6:          ipv6Str,
 			MBits:         ask.MBits,
 			ReservedPorts: ask.ReservedPorts,
 			DynamicPorts:  ask.DynamicPorts,
@@ -238,6 +292,26 @@ func (idx *NetworkIndex) AssignNetwork(ask *NetworkResource) (out *NetworkResour
 	return
 }
 
+// unionBitmaps returns the ports used in either of the bitmaps, which may be
+// nil if no ports have been allocated yet.
+func unionBitmaps(a, b Bitmap) Bitmap {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+
+	union, err := a.Copy()
+	if err != nil {
+		return a
+	}
+	for _, port := range b.IndexesInRange(true, 0, maxValidPort-1) {
+		union.Set(uint(port))
+	}
+	return union
+}
+
 // getDynamicPortsPrecise takes the nodes used port bitmap which may be nil if
 // no ports have been allocated yet, the network ask and returns a set of unused
 // ports to fullfil the ask's DynamicPorts or an error if it failed. An error
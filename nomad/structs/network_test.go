@@ -14,7 +14,7 @@ func TestNetworkIndex_Overcommitted(t *testing.T) {
 		Device:        "eth0",
 		IP:            "192.168.0.100",
 		MBits:         505,
-		ReservedPorts: []Port{{"one", 8000}, {"two", 9000}},
+		ReservedPorts: []Port{{"one", 8000, 0}, {"two", 9000, 0}},
 	}
 	collide := idx.AddReserved(reserved)
 	if collide {
@@ -65,7 +65,7 @@ func TestNetworkIndex_SetNode(t *testing.T) {
 				&NetworkResource{
 					Device:        "eth0",
 					IP:            "192.168.0.100",
-					ReservedPorts: []Port{{"ssh", 22}},
+					ReservedPorts: []Port{{"ssh", 22, 0}},
 					MBits:         1,
 				},
 			},
@@ -101,7 +101,7 @@ func TestNetworkIndex_AddAllocs(t *testing.T) {
 							Device:        "eth0",
 							IP:            "192.168.0.100",
 							MBits:         20,
-							ReservedPorts: []Port{{"one", 8000}, {"two", 9000}},
+							ReservedPorts: []Port{{"one", 8000, 0}, {"two", 9000, 0}},
 						},
 					},
 				},
@@ -115,7 +115,7 @@ func TestNetworkIndex_AddAllocs(t *testing.T) {
 							Device:        "eth0",
 							IP:            "192.168.0.100",
 							MBits:         50,
-							ReservedPorts: []Port{{"one", 10000}},
+							ReservedPorts: []Port{{"one", 10000, 0}},
 						},
 					},
 				},
@@ -148,7 +148,7 @@ func TestNetworkIndex_AddReserved(t *testing.T) {
 		Device:        "eth0",
 		IP:            "192.168.0.100",
 		MBits:         20,
-		ReservedPorts: []Port{{"one", 8000}, {"two", 9000}},
+		ReservedPorts: []Port{{"one", 8000, 0}, {"two", 9000, 0}},
 	}
 	collide := idx.AddReserved(reserved)
 	if collide {
@@ -189,7 +189,7 @@ func TestNetworkIndex_yieldIP(t *testing.T) {
 				&NetworkResource{
 					Device:        "eth0",
 					IP:            "192.168.0.100",
-					ReservedPorts: []Port{{"ssh", 22}},
+					ReservedPorts: []Port{{"ssh", 22, 0}},
 					MBits:         1,
 				},
 			},
@@ -198,7 +198,7 @@ func TestNetworkIndex_yieldIP(t *testing.T) {
 	idx.SetNode(n)
 
 	var out []string
-	idx.yieldIP(func(n *NetworkResource, ip net.IP) (stop bool) {
+	idx.yieldIP(false, func(n *NetworkResource, ip net.IP) (stop bool) {
 		out = append(out, ip.String())
 		return
 	})
@@ -227,7 +227,7 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 				&NetworkResource{
 					Device:        "eth0",
 					IP:            "192.168.0.100",
-					ReservedPorts: []Port{{"ssh", 22}},
+					ReservedPorts: []Port{{"ssh", 22, 0}},
 					MBits:         1,
 				},
 			},
@@ -244,7 +244,7 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 							Device:        "eth0",
 							IP:            "192.168.0.100",
 							MBits:         20,
-							ReservedPorts: []Port{{"one", 8000}, {"two", 9000}},
+							ReservedPorts: []Port{{"one", 8000, 0}, {"two", 9000, 0}},
 						},
 					},
 				},
@@ -258,7 +258,7 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 							Device:        "eth0",
 							IP:            "192.168.0.100",
 							MBits:         50,
-							ReservedPorts: []Port{{"main", 10000}},
+							ReservedPorts: []Port{{"main", 10000, 0}},
 						},
 					},
 				},
@@ -269,7 +269,7 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 
 	// Ask for a reserved port
 	ask := &NetworkResource{
-		ReservedPorts: []Port{{"main", 8000}},
+		ReservedPorts: []Port{{"main", 8000, 0}},
 	}
 	offer, err := idx.AssignNetwork(ask)
 	if err != nil {
@@ -281,14 +281,14 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 	if offer.IP != "192.168.0.101" {
 		t.Fatalf("bad: %#v", offer)
 	}
-	rp := Port{"main", 8000}
+	rp := Port{"main", 8000, 0}
 	if len(offer.ReservedPorts) != 1 || offer.ReservedPorts[0] != rp {
 		t.Fatalf("bad: %#v", offer)
 	}
 
 	// Ask for dynamic ports
 	ask = &NetworkResource{
-		DynamicPorts: []Port{{"http", 0}, {"https", 0}, {"admin", 0}},
+		DynamicPorts: []Port{{"http", 0, 0}, {"https", 0, 0}, {"admin", 0, 0}},
 	}
 	offer, err = idx.AssignNetwork(ask)
 	if err != nil {
@@ -311,8 +311,8 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 
 	// Ask for reserved + dynamic ports
 	ask = &NetworkResource{
-		ReservedPorts: []Port{{"main", 2345}},
-		DynamicPorts:  []Port{{"http", 0}, {"https", 0}, {"admin", 0}},
+		ReservedPorts: []Port{{"main", 2345, 0}},
+		DynamicPorts:  []Port{{"http", 0, 0}, {"https", 0, 0}, {"admin", 0, 0}},
 	}
 	offer, err = idx.AssignNetwork(ask)
 	if err != nil {
@@ -325,7 +325,7 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 		t.Fatalf("bad: %#v", offer)
 	}
 
-	rp = Port{"main", 2345}
+	rp = Port{"main", 2345, 0}
 	if len(offer.ReservedPorts) != 1 || offer.ReservedPorts[0] != rp {
 		t.Fatalf("bad: %#v", offer)
 	}
@@ -343,6 +343,135 @@ func TestNetworkIndex_AssignNetwork(t *testing.T) {
 	}
 }
 
+func TestNetworkIndex_AssignNetwork_IPv6(t *testing.T) {
+	idx := NewNetworkIndex()
+	n := &Node{
+		Resources: &Resources{
+			Networks: []*NetworkResource{
+				&NetworkResource{
+					Device: "eth0",
+					CIDR:   "192.168.0.100/32",
+					MBits:  1000,
+				},
+				&NetworkResource{
+					Device: "eth0",
+					CIDR:   "2001:db8::1/128",
+					MBits:  1000,
+				},
+			},
+		},
+		Reserved: &Resources{
+			Networks: []*NetworkResource{
+				&NetworkResource{
+					Device:        "eth0",
+					IP:            "2001:db8::1",
+					ReservedPorts: []Port{{"ssh", 22, 0}},
+				},
+			},
+		},
+	}
+	idx.SetNode(n)
+
+	allocs := []*Allocation{
+		&Allocation{
+			TaskResources: map[string]*Resources{
+				"web": &Resources{
+					Networks: []*NetworkResource{
+						&NetworkResource{
+							AddressFamily: NetworkAddressFamilyDual,
+							Device:        "eth0",
+							IP:            "192.168.0.100",
+							IPv6:          "2001:db8::1",
+							MBits:         20,
+							ReservedPorts: []Port{{"one", 8000, 0}},
+						},
+					},
+				},
+			},
+		},
+	}
+	if idx.AddAllocs(allocs) {
+		t.Fatalf("bad")
+	}
+
+	// The ports of the dual-stack network are used on both addresses
+	for _, ip := range []string{"192.168.0.100", "2001:db8::1"} {
+		if !idx.UsedPorts[ip].Check(8000) {
+			t.Fatalf("port 8000 not used on %s", ip)
+		}
+	}
+
+	// Ask for an IPv6 address
+	ask := &NetworkResource{
+		AddressFamily: NetworkAddressFamilyIPv6,
+		ReservedPorts: []Port{{"main", 8080, 0}},
+	}
+	offer, err := idx.AssignNetwork(ask)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if offer.IP != "2001:db8::1" || offer.IPv6 != "" {
+		t.Fatalf("bad: %#v", offer)
+	}
+
+	// Ask for a port only used on the IPv6 address
+	ask = &NetworkResource{
+		ReservedPorts: []Port{{"main", 22, 0}},
+	}
+	offer, err = idx.AssignNetwork(ask)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if offer.IP != "192.168.0.100" {
+		t.Fatalf("bad: %#v", offer)
+	}
+
+	ask.AddressFamily = NetworkAddressFamilyDual
+	offer, err = idx.AssignNetwork(ask)
+	if err == nil || err.Error() != "reserved port collision" {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ask for dual-stack dynamic ports
+	ask = &NetworkResource{
+		AddressFamily: NetworkAddressFamilyDual,
+		DynamicPorts:  []Port{{"http", 0, 0}},
+	}
+	offer, err = idx.AssignNetwork(ask)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if offer.IP != "192.168.0.100" || offer.IPv6 != "2001:db8::1" || offer.DynamicPorts[0].Value == 0 {
+		t.Fatalf("bad: %#v", offer)
+	}
+}
+
+func TestNetworkIndex_AssignNetwork_NoIPv6(t *testing.T) {
+	idx := NewNetworkIndex()
+	n := &Node{
+		Resources: &Resources{
+			Networks: []*NetworkResource{
+				&NetworkResource{
+					Device: "eth0",
+					CIDR:   "192.168.0.100/32",
+					MBits:  1000,
+				},
+			},
+		},
+	}
+	idx.SetNode(n)
+
+	ask := &NetworkResource{AddressFamily: NetworkAddressFamilyIPv6}
+	if _, err := idx.AssignNetwork(ask); err == nil || err.Error() != "no networks available" {
+		t.Fatalf("err: %v", err)
+	}
+
+	ask.AddressFamily = NetworkAddressFamilyDual
+	if _, err := idx.AssignNetwork(ask); err == nil || err.Error() != "no IPv6 address available" {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 // This test ensures that even with a small domain of available ports we are
 // able to make a dynamic port allocation.
 func TestNetworkIndex_AssignNetwork_Dynamic_Contention(t *testing.T) {
@@ -377,7 +506,7 @@ func TestNetworkIndex_AssignNetwork_Dynamic_Contention(t *testing.T) {
 
 	// Ask for dynamic ports
 	ask := &NetworkResource{
-		DynamicPorts: []Port{{"http", 0}},
+		DynamicPorts: []Port{{"http", 0, 0}},
 	}
 	offer, err := idx.AssignNetwork(ask)
 	if err != nil {
@@ -181,6 +181,26 @@ func TestNode_ComputedClass_Meta(t *testing.T) {
 	}
 }
 
+func TestNode_ComputedClass_HostVolumes(t *testing.T) {
+	// Create a node and gets it computed class
+	n := testNode()
+	if err := n.ComputeClass(); err != nil {
+		t.Fatalf("ComputeClass() failed: %v", err)
+	}
+	old := n.ComputedClass
+
+	// Add a host volume and compute the class again.
+	n.HostVolumes = map[string]*ClientHostVolumeConfig{
+		"certs": &ClientHostVolumeConfig{Name: "certs", Path: "/etc/ssl/certs"},
+	}
+	if err := n.ComputeClass(); err != nil {
+		t.Fatalf("ComputeClass() failed: %v", err)
+	}
+	if old == n.ComputedClass {
+		t.Fatal("ComputeClass() ignored host volume change")
+	}
+}
+
 func TestNode_EscapedConstraints(t *testing.T) {
 	// Non-escaped constraints
 	ne1 := &Constraint{
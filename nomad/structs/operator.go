@@ -0,0 +1,274 @@
+package structs
+
+import (
+	"fmt"
+	"time"
+)
+
+// AutopilotConfig holds the Autopilot configuration for a cluster. It is
+// stored in the state store so that it applies to all the servers and can be
+// changed at runtime.
+type AutopilotConfig struct {
+	// CleanupDeadServers controls whether to remove servers from the Raft
+	// peer set once they have been failed for ServerStabilizationTime.
+	CleanupDeadServers bool
+
+	// LastContactThreshold is the limit on the amount of time a server can go
+	// without leader contact before being considered unhealthy.
+	LastContactThreshold time.Duration
+
+	// MaxTrailingLogs is the amount of entries in the Raft Log that a server
+	// can be behind before being considered unhealthy.
+	MaxTrailingLogs uint64
+
+	// ServerStabilizationTime is the minimum amount of time a server must be
+	// healthy before it is added to the Raft peer set, and the minimum amount
+	// of time it must be failed before it is cleaned up.
+	ServerStabilizationTime time.Duration
+
+	// CreateIndex/ModifyIndex store the create/modify indexes of this
+	// configuration.
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Copy returns a copy of the configuration
+func (a *AutopilotConfig) Copy() *AutopilotConfig {
+	if a == nil {
+		return nil
+	}
+	n := new(AutopilotConfig)
+	*n = *a
+	return n
+}
+
+// AutopilotSetConfigRequest is used by the Operator endpoint to update the
+// current Autopilot configuration of the cluster.
+type AutopilotSetConfigRequest struct {
+	// Config is the new Autopilot configuration to use.
+	Config AutopilotConfig
+
+	// CAS controls whether to use check-and-set semantics for this request.
+	CAS bool
+
+	WriteRequest
+}
+
+// AutopilotConfigResponse is used to return the Autopilot configuration
+type AutopilotConfigResponse struct {
+	Config *AutopilotConfig
+	QueryMeta
+}
+
+// AutopilotSetConfigResponse is used to respond to an Autopilot
+// configuration update. Updated is false if the check-and-set failed.
+type AutopilotSetConfigResponse struct {
+	Updated bool
+	WriteMeta
+}
+
+const (
+	// SchedulerAlgorithmBinpack places allocations on the nodes that are
+	// the most utilized, keeping the other nodes free for large placements.
+	SchedulerAlgorithmBinpack = "binpack"
+
+	// SchedulerAlgorithmSpread places allocations on the nodes that are the
+	// least utilized, limiting the allocations lost with a node.
+	SchedulerAlgorithmSpread = "spread"
+)
+
+// SchedulerConfig holds the scheduler configuration of a cluster. It is
+// stored in the state store so that it applies to all the servers and can be
+// changed at runtime.
+type SchedulerConfig struct {
+	// SchedulerAlgorithm is the algorithm used to score the nodes an
+	// allocation fits on, either binpack or spread.
+	SchedulerAlgorithm string
+
+	// CreateIndex/ModifyIndex store the create/modify indexes of this
+	// configuration.
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Copy returns a copy of the configuration
+func (s *SchedulerConfig) Copy() *SchedulerConfig {
+	if s == nil {
+		return nil
+	}
+	n := new(SchedulerConfig)
+	*n = *s
+	return n
+}
+
+// EffectiveSchedulerAlgorithm returns the scheduler algorithm to use,
+// defaulting to binpack when none is configured.
+func (s *SchedulerConfig) EffectiveSchedulerAlgorithm() string {
+	if s == nil || s.SchedulerAlgorithm == "" {
+		return SchedulerAlgorithmBinpack
+	}
+	return s.SchedulerAlgorithm
+}
+
+// Validate returns an error if the configuration is invalid
+func (s *SchedulerConfig) Validate() error {
+	switch s.SchedulerAlgorithm {
+	case SchedulerAlgorithmBinpack, SchedulerAlgorithmSpread:
+		return nil
+	default:
+		return fmt.Errorf("invalid scheduler algorithm %q, must be %q or %q",
+			s.SchedulerAlgorithm, SchedulerAlgorithmBinpack, SchedulerAlgorithmSpread)
+	}
+}
+
+// SchedulerSetConfigRequest is used by the Operator endpoint to update the
+// current scheduler configuration of the cluster.
+type SchedulerSetConfigRequest struct {
+	// Config is the new scheduler configuration to use.
+	Config SchedulerConfig
+
+	// CAS controls whether to use check-and-set semantics for this request.
+	CAS bool
+
+	WriteRequest
+}
+
+// SchedulerConfigResponse is used to return the scheduler configuration
+type SchedulerConfigResponse struct {
+	Config *SchedulerConfig
+	QueryMeta
+}
+
+// SchedulerSetConfigResponse is used to respond to a scheduler configuration
+// update. Updated is false if the check-and-set failed.
+type SchedulerSetConfigResponse struct {
+	Updated bool
+	WriteMeta
+}
+
+// RaftStats holds miscellaneous Raft metrics for a server. It is returned by
+// each server to the leader so it can track the health of the cluster.
+type RaftStats struct {
+	// LastContact is the time since this server's last contact with the
+	// leader.
+	LastContact time.Duration
+
+	// LastTerm is the highest leader term this server has a record of in its
+	// Raft log.
+	LastTerm uint64
+
+	// LastIndex is the last log index this server has a record of in its
+	// Raft log.
+	LastIndex uint64
+}
+
+// ServerHealth is the health (from the leader's point of view) of a server.
+type ServerHealth struct {
+	// Name is the node name of the server.
+	Name string
+
+	// Address is the address of the server.
+	Address string
+
+	// Version is the Nomad version of the server.
+	Version string
+
+	// SerfStatus is the status reported by Serf for this server.
+	SerfStatus string
+
+	// Leader is whether this server is currently the leader.
+	Leader bool
+
+	// Voter is whether this server is a member of the Raft peer set.
+	Voter bool
+
+	// LastContact is the time since this server's last contact with the
+	// leader.
+	LastContact time.Duration
+
+	// LastTerm is the highest leader term this server has a record of in its
+	// Raft log.
+	LastTerm uint64
+
+	// LastIndex is the last log index this server has a record of in its
+	// Raft log.
+	LastIndex uint64
+
+	// Healthy is whether or not the server is healthy according to the
+	// current Autopilot configuration.
+	Healthy bool
+
+	// StableSince is the last time this server's Healthy value changed.
+	StableSince time.Time
+}
+
+// IsStable returns true if the server has been healthy for at least the
+// given stabilization time.
+func (h *ServerHealth) IsStable(now time.Time, stabilization time.Duration) bool {
+	if h == nil || !h.Healthy {
+		return false
+	}
+	return now.Sub(h.StableSince) >= stabilization
+}
+
+// IsDead returns true if the server has been unhealthy for at least the given
+// grace period and Serf considers it failed.
+func (h *ServerHealth) IsDead(now time.Time, grace time.Duration) bool {
+	if h == nil || h.Healthy || h.SerfStatus != "failed" {
+		return false
+	}
+	return now.Sub(h.StableSince) >= grace
+}
+
+// ClusterHealth is a representation of the overall health of the cluster.
+type ClusterHealth struct {
+	// Healthy is true if all the servers in the cluster are healthy.
+	Healthy bool
+
+	// FailureTolerance is the number of healthy servers that could be lost
+	// without an outage occurring.
+	FailureTolerance int
+
+	// Servers holds the health of each server.
+	Servers []ServerHealth
+}
+
+// ServerHealthResponse is used to return the health of the cluster
+type ServerHealthResponse struct {
+	Health *ClusterHealth
+	QueryMeta
+}
+
+// RaftServer has information about a server in the Raft configuration.
+type RaftServer struct {
+	// Node is the name of the server, as known by Serf, or "(unknown)" if it
+	// is not known.
+	Node string
+
+	// Address is the IP:port of the server, used for both Raft and RPC.
+	Address string
+
+	// Leader is true if this server is the current cluster leader.
+	Leader bool
+
+	// Voter is true if this server has a vote in the cluster.
+	Voter bool
+}
+
+// RaftConfigurationResponse is returned when querying for the current Raft
+// configuration.
+type RaftConfigurationResponse struct {
+	// Servers has the list of servers in the Raft configuration.
+	Servers []*RaftServer
+
+	QueryMeta
+}
+
+// RaftPeerByAddressRequest is used by the Operator endpoint to apply a Raft
+// operation on a specific Raft peer by address in the form of "IP:port".
+type RaftPeerByAddressRequest struct {
+	// Address is the peer to remove, in the form "IP:port".
+	Address string
+
+	WriteRequest
+}
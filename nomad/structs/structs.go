@@ -2,10 +2,12 @@ package structs
 
 import (
 	"bytes"
+	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -32,10 +34,16 @@ import (
 )
 
 var (
-	ErrNoLeader     = fmt.Errorf("No cluster leader")
-	ErrNoRegionPath = fmt.Errorf("No path to region")
+	ErrNoLeader         = fmt.Errorf("No cluster leader")
+	ErrNoRegionPath     = fmt.Errorf("No path to region")
+	ErrTokenNotFound    = errors.New("ACL token not found")
+	ErrPermissionDenied = errors.New("Permission denied")
 )
 
+// ErrInvalidFilterPrefix prefixes the errors of list queries whose filter
+// expression is invalid
+const ErrInvalidFilterPrefix = "Invalid filter"
+
 type MessageType uint8
 
 const (
@@ -52,6 +60,24 @@ const (
 	ReconcileJobSummariesRequestType
 	VaultAccessorRegisterRequestType
 	VaultAccessorDegisterRequestType
+	DeploymentStatusUpdateRequestType
+	DeploymentPromoteRequestType
+	DeploymentAllocHealthRequestType
+	AllocUpdateDesiredTransitionRequestType
+	NamespaceUpsertRequestType
+	NamespaceDeleteRequestType
+	QuotaSpecUpsertRequestType
+	QuotaSpecDeleteRequestType
+	ACLPolicyUpsertRequestType
+	ACLPolicyDeleteRequestType
+	ACLTokenUpsertRequestType
+	ACLTokenDeleteRequestType
+	ACLTokenBootstrapRequestType
+	SnapshotRestoreRequestType
+	AutopilotRequestType
+	DeploymentDeleteRequestType
+	SchedulerConfigRequestType
+	NodeUpdateEligibilityRequestType
 )
 
 const (
@@ -90,6 +116,10 @@ type QueryOptions struct {
 	// The target region for this query
 	Region string
 
+	// If set, list searches only return the objects of this namespace. An
+	// empty namespace or the AllNamespacesSentinel returns all of them.
+	Namespace string
+
 	// If set, wait until query exceeds given index. Must be provided
 	// with MaxQueryTime.
 	MinQueryIndex uint64
@@ -103,12 +133,32 @@ type QueryOptions struct {
 
 	// If set, used as prefix for resource list searches
 	Prefix string
+
+	// If set, the objects returned by list searches must match this filter
+	// expression
+	Filter string
+
+	// PerPage is the maximum number of objects returned by a list search, or
+	// zero for no limit
+	PerPage int32
+
+	// NextToken resumes a list search where the previous page ended
+	NextToken string
+
+	// AuthToken is secret portion of the ACL token used for the request
+	AuthToken string
 }
 
 func (q QueryOptions) RequestRegion() string {
 	return q.Region
 }
 
+// MatchesNamespace returns whether the objects of the namespace are returned by
+// list searches
+func (q QueryOptions) MatchesNamespace(namespace string) bool {
+	return q.Namespace == "" || q.Namespace == AllNamespacesSentinel || q.Namespace == namespace
+}
+
 // QueryOption only applies to reads, so always true
 func (q QueryOptions) IsRead() bool {
 	return true
@@ -121,6 +171,12 @@ func (q QueryOptions) AllowStaleRead() bool {
 type WriteRequest struct {
 	// The target region for this write
 	Region string
+
+	// Namespace is the namespace jobs that do not specify one are written to
+	Namespace string
+
+	// AuthToken is secret portion of the ACL token used for the request
+	AuthToken string
 }
 
 func (w WriteRequest) RequestRegion() string {
@@ -150,6 +206,10 @@ type QueryMeta struct {
 
 	// Used to indicate if there is a known leader node
 	KnownLeader bool
+
+	// NextToken is set on a page of a list search with more objects to
+	// return, and passed as QueryOptions.NextToken to get the next page
+	NextToken string
 }
 
 // WriteMeta allows a write response to include potentially
@@ -204,6 +264,25 @@ type NodeUpdateStatusRequest struct {
 type NodeUpdateDrainRequest struct {
 	NodeID string
 	Drain  bool
+
+	// DrainStrategy controls how the allocations of the node are drained. It
+	// is only used when enabling drain mode.
+	DrainStrategy *DrainStrategy
+
+	WriteRequest
+}
+
+// NodeUpdateEligibilityRequest is used for updating the scheduling
+// eligibility of a node
+type NodeUpdateEligibilityRequest struct {
+	NodeID      string
+	Eligibility string
+
+	// IneligibleUntil is only set by the servers when they mark a node
+	// rejecting plans ineligible, so that the ineligibility expires. It is
+	// in UnixNano.
+	IneligibleUntil int64
+
 	WriteRequest
 }
 
@@ -231,6 +310,10 @@ type JobRegisterRequest struct {
 	EnforceIndex   bool
 	JobModifyIndex uint64
 
+	// Trace makes the scheduler record its decision for each node considered
+	// while processing the evaluation of the registration.
+	Trace bool
+
 	WriteRequest
 }
 
@@ -258,6 +341,28 @@ type JobListRequest struct {
 	QueryOptions
 }
 
+// JobVersionsRequest is used to get the tracked versions of a job
+type JobVersionsRequest struct {
+	JobID string
+	Diffs bool // Toggles the diffs between consecutive versions
+	QueryOptions
+}
+
+// JobRevertRequest is used to revert a job to a prior version.
+type JobRevertRequest struct {
+	// JobID is the ID of the job being reverted
+	JobID string
+
+	// JobVersion the version to revert to.
+	JobVersion uint64
+
+	// EnforcePriorVersion if set will enforce that the job is at the given
+	// version before reverting.
+	EnforcePriorVersion *uint64
+
+	WriteRequest
+}
+
 // JobPlanRequest is used for the Job.Plan endpoint to trigger a dry-run
 // evaluation of the Job.
 type JobPlanRequest struct {
@@ -266,6 +371,20 @@ type JobPlanRequest struct {
 	WriteRequest
 }
 
+// JobValidateRequest is used to validate a job without registering it
+type JobValidateRequest struct {
+	Job *Job
+	WriteRequest
+}
+
+// JobDispatchRequest is used to dispatch a job based on a parameterized job
+type JobDispatchRequest struct {
+	JobID   string
+	Payload []byte
+	Meta    map[string]string
+	WriteRequest
+}
+
 // JobSummaryRequest is used when we just need to get a specific job summary
 type JobSummaryRequest struct {
 	JobID string
@@ -326,6 +445,18 @@ type PlanRequest struct {
 // AllocUpdateRequest is used to submit changes to allocations, either
 // to cause evictions or to assign new allocaitons. Both can be done
 // within a single transaction
+// AllocUpdateDesiredTransitionRequest is used to set the desired transition
+// of a set of allocations along with the evaluations processing them.
+type AllocUpdateDesiredTransitionRequest struct {
+	// Allocs is the desired transition of each allocation by ID
+	Allocs map[string]*DesiredTransition
+
+	// Evals are the evaluations to create
+	Evals []*Evaluation
+
+	WriteRequest
+}
+
 type AllocUpdateRequest struct {
 	// Alloc is the list of new allocations to assign
 	Alloc []*Allocation
@@ -334,6 +465,17 @@ type AllocUpdateRequest struct {
 	// It is pulled out since it is common to reduce payload size.
 	Job *Job
 
+	// Deployment is the deployment created or updated by the plan.
+	Deployment *Deployment
+
+	// DeploymentUpdates is a set of status updates to apply to deployments.
+	DeploymentUpdates []*DeploymentStatusUpdate
+
+	// Evals is the set of evaluations to create along with a client update
+	// of the allocations, such as to reschedule failed or preempted
+	// allocations.
+	Evals []*Evaluation
+
 	WriteRequest
 }
 
@@ -348,6 +490,13 @@ type AllocSpecificRequest struct {
 	QueryOptions
 }
 
+// AllocStopRequest is used to stop an allocation so that it is replaced by the
+// scheduler.
+type AllocStopRequest struct {
+	AllocID string
+	WriteRequest
+}
+
 // AllocsGetRequest is used to query a set of allocations
 type AllocsGetRequest struct {
 	AllocIDs []string
@@ -360,6 +509,232 @@ type PeriodicForceRequest struct {
 	WriteRequest
 }
 
+// DeploymentSpecificRequest is used to make a request specific to a particular
+// deployment
+type DeploymentSpecificRequest struct {
+	DeploymentID string
+	QueryOptions
+}
+
+// DeploymentListRequest is used to parameterize a list request
+type DeploymentListRequest struct {
+	QueryOptions
+}
+
+// DeploymentPromoteRequest is used to promote the canaries of a deployment.
+// Either all task groups are promoted or only the named groups.
+type DeploymentPromoteRequest struct {
+	DeploymentID string
+	All          bool
+	Groups       []string
+	WriteRequest
+}
+
+// ApplyDeploymentPromoteRequest is used to apply a promotion to the state
+// store along with the evaluation that continues the deployment.
+type ApplyDeploymentPromoteRequest struct {
+	DeploymentPromoteRequest
+
+	// Eval is the evaluation to create
+	Eval *Evaluation
+}
+
+// DeploymentFailRequest is used to mark a deployment as failed.
+type DeploymentFailRequest struct {
+	DeploymentID string
+	WriteRequest
+}
+
+// DeploymentDeleteRequest is used to delete terminal deployments
+type DeploymentDeleteRequest struct {
+	Deployments []string
+	WriteRequest
+}
+
+// DeploymentStatusUpdateRequest is used to update the status of a deployment
+// along with an optional evaluation.
+type DeploymentStatusUpdateRequest struct {
+	// Eval, if set, is created along with the status update
+	Eval *Evaluation
+
+	// DeploymentUpdate is the status update to apply
+	DeploymentUpdate *DeploymentStatusUpdate
+
+	WriteRequest
+}
+
+// ApplyDeploymentAllocHealthRequest is used to record the health of the
+// allocations of a deployment. The deployment may be failed at the same time,
+// in which case the job can be reverted and an evaluation created.
+type ApplyDeploymentAllocHealthRequest struct {
+	DeploymentID string
+
+	// HealthyAllocs and UnhealthyAllocs are the number of healthy and
+	// unhealthy allocations of each task group
+	HealthyAllocs   map[string]int
+	UnhealthyAllocs map[string]int
+
+	// DeploymentUpdate, if set, updates the status of the deployment
+	DeploymentUpdate *DeploymentStatusUpdate
+
+	// Job, if set, is the job to revert to
+	Job *Job
+
+	// Eval, if set, is created along with the update
+	Eval *Evaluation
+
+	WriteRequest
+}
+
+// NamespaceSpecificRequest is used to query a specific namespace
+type NamespaceSpecificRequest struct {
+	Name string
+	QueryOptions
+}
+
+// NamespaceListRequest is used to request a list of namespaces
+type NamespaceListRequest struct {
+	QueryOptions
+}
+
+// NamespaceUpsertRequest is used to create or update a set of namespaces
+type NamespaceUpsertRequest struct {
+	Namespaces []*Namespace
+	WriteRequest
+}
+
+// NamespaceDeleteRequest is used to delete a set of namespaces
+type NamespaceDeleteRequest struct {
+	Namespaces []string
+	WriteRequest
+}
+
+// QuotaSpecificRequest is used to query a specific quota specification
+type QuotaSpecificRequest struct {
+	Name string
+	QueryOptions
+}
+
+// QuotaSpecListRequest is used to request a list of quota specifications
+type QuotaSpecListRequest struct {
+	QueryOptions
+}
+
+// QuotaSpecUpsertRequest is used to create or update a set of quota
+// specifications
+type QuotaSpecUpsertRequest struct {
+	Quotas []*QuotaSpec
+	WriteRequest
+}
+
+// QuotaSpecDeleteRequest is used to delete a set of quota specifications
+type QuotaSpecDeleteRequest struct {
+	Names []string
+	WriteRequest
+}
+
+// ACLPolicySpecificRequest is used to query a specific policy
+type ACLPolicySpecificRequest struct {
+	Name string
+	QueryOptions
+}
+
+// ACLPolicyListRequest is used to request a list of policies
+type ACLPolicyListRequest struct {
+	QueryOptions
+}
+
+// ACLPolicyUpsertRequest is used to upsert a set of policies
+type ACLPolicyUpsertRequest struct {
+	Policies []*ACLPolicy
+	WriteRequest
+}
+
+// ACLPolicyDeleteRequest is used to delete a set of policies
+type ACLPolicyDeleteRequest struct {
+	Names []string
+	WriteRequest
+}
+
+// ACLTokenSpecificRequest is used to query a specific token
+type ACLTokenSpecificRequest struct {
+	AccessorID string
+	QueryOptions
+}
+
+// ACLTokenListRequest is used to request a list of tokens
+type ACLTokenListRequest struct {
+	QueryOptions
+}
+
+// ResolveACLTokenRequest is used to resolve a specific token
+type ResolveACLTokenRequest struct {
+	SecretID string
+	QueryOptions
+}
+
+// ACLTokenUpsertRequest is used to upsert a set of tokens
+type ACLTokenUpsertRequest struct {
+	Tokens []*ACLToken
+	WriteRequest
+}
+
+// ACLTokenDeleteRequest is used to delete a set of tokens
+type ACLTokenDeleteRequest struct {
+	AccessorIDs []string
+	WriteRequest
+}
+
+// ACLTokenBootstrapRequest is used to bootstrap ACLs
+type ACLTokenBootstrapRequest struct {
+	Token *ACLToken // Not client specifiable
+	WriteRequest
+}
+
+// SnapshotOp is the type of snapshot operation to perform
+type SnapshotOp int
+
+const (
+	SnapshotSave SnapshotOp = iota
+	SnapshotRestore
+)
+
+// SnapshotRequest is used as a header for a snapshot RPC request. It is sent
+// over a dedicated connection and is followed by the snapshot archive when
+// restoring.
+type SnapshotRequest struct {
+	// Op is the snapshot operation to perform
+	Op SnapshotOp
+
+	// Region is the region to forward the request to
+	Region string
+
+	// AuthToken is the ACL token used to authorize the request
+	AuthToken string
+
+	// AllowStale allows any server to serve a save request, instead of only
+	// the leader
+	AllowStale bool
+}
+
+// SnapshotResponse is the header of a snapshot RPC response. When saving, it
+// is followed by the snapshot archive.
+type SnapshotResponse struct {
+	// Error is set if the operation failed, since the response can not be
+	// carried back as an RPC error
+	Error string
+
+	QueryMeta
+}
+
+// SnapshotRestoreRequest is used to replace the state of all the servers with
+// the state of a snapshot
+type SnapshotRestoreRequest struct {
+	// State is the serialized FSM state to restore
+	State []byte
+	WriteRequest
+}
+
 // ServerMembersResponse has the list of servers in a cluster
 type ServerMembersResponse struct {
 	ServerName   string
@@ -489,6 +864,15 @@ type NodeDrainUpdateResponse struct {
 	QueryMeta
 }
 
+// NodeEligibilityUpdateResponse is used to respond to a node eligibility
+// update
+type NodeEligibilityUpdateResponse struct {
+	EvalIDs         []string
+	EvalCreateIndex uint64
+	NodeModifyIndex uint64
+	QueryMeta
+}
+
 // NodeAllocsResponse is used to return allocs for a single node
 type NodeAllocsResponse struct {
 	Allocs []*Allocation
@@ -498,6 +882,12 @@ type NodeAllocsResponse struct {
 // NodeClientAllocsResponse is used to return allocs meta data for a single node
 type NodeClientAllocsResponse struct {
 	Allocs map[string]uint64
+
+	// MigrateTokens are used when ACLs are enabled to allow cross node,
+	// authenticated access to the data of previous allocations. They are
+	// keyed by the ID of the allocation that migrates the data.
+	MigrateTokens map[string]string
+
 	QueryMeta
 }
 
@@ -519,6 +909,22 @@ type SingleJobResponse struct {
 	QueryMeta
 }
 
+// JobVersionsResponse is used for a job get versions request
+type JobVersionsResponse struct {
+	Versions []*Job
+	Diffs    []*JobDiff
+	QueryMeta
+}
+
+// JobDispatchResponse is used to respond to a job dispatch
+type JobDispatchResponse struct {
+	DispatchedJobID string
+	EvalID          string
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+	QueryMeta
+}
+
 // JobSummaryResponse is used to return a single job summary
 type JobSummaryResponse struct {
 	JobSummary *JobSummary
@@ -559,12 +965,30 @@ type JobPlanResponse struct {
 	WriteMeta
 }
 
+// JobValidateResponse is the response from a job validate request
+type JobValidateResponse struct {
+	// ValidationErrors are the problems preventing the job from being
+	// registered.
+	ValidationErrors []string
+
+	// Warnings are the problems that don't prevent the job from being
+	// registered but are likely unintended.
+	Warnings []string
+}
+
 // SingleAllocResponse is used to return a single allocation
 type SingleAllocResponse struct {
 	Alloc *Allocation
 	QueryMeta
 }
 
+// AllocStopResponse is used to respond to an allocation stop
+type AllocStopResponse struct {
+	EvalID          string
+	EvalCreateIndex uint64
+	WriteMeta
+}
+
 // AllocsGetResponse is used to return a set of allocations
 type AllocsGetResponse struct {
 	Allocs []*Allocation
@@ -620,6 +1044,99 @@ type EvalAllocationsResponse struct {
 	QueryMeta
 }
 
+// SingleDeploymentResponse is used to respond with a single deployment
+type SingleDeploymentResponse struct {
+	Deployment *Deployment
+	QueryMeta
+}
+
+// DeploymentListResponse is used for a list request
+type DeploymentListResponse struct {
+	Deployments []*Deployment
+	QueryMeta
+}
+
+// DeploymentUpdateResponse is used to respond to a deployment change.
+type DeploymentUpdateResponse struct {
+	EvalID                string
+	EvalCreateIndex       uint64
+	DeploymentModifyIndex uint64
+	WriteMeta
+}
+
+// SingleNamespaceResponse is used to return a single namespace
+type SingleNamespaceResponse struct {
+	Namespace *Namespace
+	QueryMeta
+}
+
+// NamespaceListResponse is used for a list request
+type NamespaceListResponse struct {
+	Namespaces []*Namespace
+	QueryMeta
+}
+
+// SingleQuotaSpecResponse is used to return a single quota specification
+type SingleQuotaSpecResponse struct {
+	Quota *QuotaSpec
+	QueryMeta
+}
+
+// QuotaSpecListResponse is used for a list request
+type QuotaSpecListResponse struct {
+	Quotas []*QuotaSpec
+	QueryMeta
+}
+
+// SingleQuotaUsageResponse is used to return the usage of a quota
+// specification
+type SingleQuotaUsageResponse struct {
+	Usage *QuotaUsage
+	QueryMeta
+}
+
+// SingleACLPolicyResponse is used to return a single policy
+type SingleACLPolicyResponse struct {
+	Policy *ACLPolicy
+	QueryMeta
+}
+
+// ACLPolicyListResponse is used for a list request
+type ACLPolicyListResponse struct {
+	Policies []*ACLPolicy
+	QueryMeta
+}
+
+// SingleACLTokenResponse is used to return a single token
+type SingleACLTokenResponse struct {
+	Token *ACLToken
+	QueryMeta
+}
+
+// ACLTokenListResponse is used for a list request
+type ACLTokenListResponse struct {
+	Tokens []*ACLToken
+	QueryMeta
+}
+
+// ResolveACLTokenResponse is used to resolve a single token
+type ResolveACLTokenResponse struct {
+	Token *ACLToken
+	QueryMeta
+}
+
+// ACLTokenUpsertResponse is used to return from an ACLTokenUpsertRequest
+type ACLTokenUpsertResponse struct {
+	Tokens []*ACLToken
+	WriteMeta
+}
+
+// BrokerStatsResponse is used to return the stats of the evaluation broker
+type BrokerStatsResponse struct {
+	Stats *BrokerStats
+	QueryMeta
+}
+
 // PeriodicForceResponse is used to respond to a periodic job force launch
 type PeriodicForceResponse struct {
 	EvalID          string
@@ -633,6 +1150,14 @@ const (
 	NodeStatusDown  = "down"
 )
 
+const (
+	// NodeSchedulingEligible and NodeSchedulingIneligible are the scheduling
+	// eligibilities of a node. Ineligible nodes receive no new allocations,
+	// while their existing allocations keep running.
+	NodeSchedulingEligible   = "eligible"
+	NodeSchedulingIneligible = "ineligible"
+)
+
 // ShouldDrainNode checks if a given node status should trigger an
 // evaluation. Some states don't require any further action.
 func ShouldDrainNode(status string) bool {
@@ -698,6 +1223,10 @@ type Node struct {
 	// consuming resources.
 	Reserved *Resources
 
+	// HostVolumes are the directories of the host that the client exposes
+	// to the task groups requesting them, keyed by name.
+	HostVolumes map[string]*ClientHostVolumeConfig
+
 	// Links are used to 'link' this client to external
 	// systems. For example 'consul=foo.dc1' 'aws=i-83212'
 	// 'ami=ami-123'
@@ -720,6 +1249,21 @@ type Node struct {
 	// allocations will be drained.
 	Drain bool
 
+	// DrainStrategy is the strategy used to drain the allocations of the
+	// node. It is only set while the node is draining.
+	DrainStrategy *DrainStrategy
+
+	// SchedulingEligibility is controlled by the servers, and not the
+	// client. If ineligible, no new allocations are placed on this node but
+	// the existing ones keep running. An empty value is eligible.
+	SchedulingEligibility string
+
+	// IneligibleUntil is set, in UnixNano, when the servers marked the node
+	// ineligible because it repeatedly rejected plans. The leader makes the
+	// node eligible again once it has passed. Any other eligibility update
+	// clears it, so that an operator's decision is never undone.
+	IneligibleUntil int64
+
 	// Status of this node
 	Status string
 
@@ -737,7 +1281,12 @@ type Node struct {
 
 // Ready returns if the node is ready for running allocations
 func (n *Node) Ready() bool {
-	return n.Status == NodeStatusReady && !n.Drain
+	return n.Status == NodeStatusReady && !n.Drain && n.Eligible()
+}
+
+// Eligible returns if new allocations may be placed on the node
+func (n *Node) Eligible() bool {
+	return n.SchedulingEligibility != NodeSchedulingIneligible
 }
 
 func (n *Node) Copy() *Node {
@@ -749,11 +1298,43 @@ func (n *Node) Copy() *Node {
 	nn.Attributes = CopyMapStringString(nn.Attributes)
 	nn.Resources = nn.Resources.Copy()
 	nn.Reserved = nn.Reserved.Copy()
+	nn.HostVolumes = CopyMapStringClientHostVolumeConfig(nn.HostVolumes)
 	nn.Links = CopyMapStringString(nn.Links)
 	nn.Meta = CopyMapStringString(nn.Meta)
+	nn.DrainStrategy = nn.DrainStrategy.Copy()
 	return nn
 }
 
+// DrainStrategy describes how the allocations of a node are drained
+type DrainStrategy struct {
+	// Deadline is the duration after which the remaining allocations of the
+	// node are migrated at once. Zero means there is no deadline and a
+	// negative value migrates all the allocations immediately.
+	Deadline time.Duration
+
+	// ForceDeadline is the time at which the remaining allocations are
+	// migrated. It is computed from the deadline when drain is enabled.
+	ForceDeadline time.Time
+}
+
+func (d *DrainStrategy) Copy() *DrainStrategy {
+	if d == nil {
+		return nil
+	}
+	nd := new(DrainStrategy)
+	*nd = *d
+	return nd
+}
+
+// DeadlineTime returns whether the drain has a deadline and the time at
+// which it is reached.
+func (d *DrainStrategy) DeadlineTime() (bool, time.Time) {
+	if d == nil || d.Deadline == 0 {
+		return false, time.Time{}
+	}
+	return true, d.ForceDeadline
+}
+
 // TerminalStatus returns if the current status is terminal and
 // will no longer transition.
 func (n *Node) TerminalStatus() bool {
@@ -768,40 +1349,45 @@ func (n *Node) TerminalStatus() bool {
 // Stub returns a summarized version of the node
 func (n *Node) Stub() *NodeListStub {
 	return &NodeListStub{
-		ID:                n.ID,
-		Datacenter:        n.Datacenter,
-		Name:              n.Name,
-		NodeClass:         n.NodeClass,
-		Drain:             n.Drain,
-		Status:            n.Status,
-		StatusDescription: n.StatusDescription,
-		CreateIndex:       n.CreateIndex,
-		ModifyIndex:       n.ModifyIndex,
+		ID:                    n.ID,
+		Datacenter:            n.Datacenter,
+		Name:                  n.Name,
+		NodeClass:             n.NodeClass,
+		Drain:                 n.Drain,
+		SchedulingEligibility: n.SchedulingEligibility,
+		Status:                n.Status,
+		StatusDescription:     n.StatusDescription,
+		CreateIndex:           n.CreateIndex,
+		ModifyIndex:           n.ModifyIndex,
 	}
 }
 
 // NodeListStub is used to return a subset of job information
 // for the job list
 type NodeListStub struct {
-	ID                string
-	Datacenter        string
-	Name              string
-	NodeClass         string
-	Drain             bool
-	Status            string
-	StatusDescription string
-	CreateIndex       uint64
-	ModifyIndex       uint64
+	ID                    string
+	Datacenter            string
+	Name                  string
+	NodeClass             string
+	Drain                 bool
+	SchedulingEligibility string
+	Status                string
+	StatusDescription     string
+	CreateIndex           uint64
+	ModifyIndex           uint64
 }
 
 // Resources is used to define the resources available
 // on a client
 type Resources struct {
-	CPU      int
-	MemoryMB int `mapstructure:"memory"`
-	DiskMB   int `mapstructure:"disk"`
-	IOPS     int
-	Networks []*NetworkResource
+	CPU           int
+	Cores         int `mapstructure:"cores"`
+	ReservedCores []int
+	MemoryMB      int `mapstructure:"memory"`
+	DiskMB        int `mapstructure:"disk"`
+	IOPS          int
+	Networks      []*NetworkResource
+	Devices       []*DeviceResource
 }
 
 const (
@@ -827,6 +1413,9 @@ func (r *Resources) Merge(other *Resources) {
 	if other.CPU != 0 {
 		r.CPU = other.CPU
 	}
+	if other.Cores != 0 {
+		r.Cores = other.Cores
+	}
 	if other.MemoryMB != 0 {
 		r.MemoryMB = other.MemoryMB
 	}
@@ -839,6 +1428,9 @@ func (r *Resources) Merge(other *Resources) {
 	if len(other.Networks) != 0 {
 		r.Networks = other.Networks
 	}
+	if len(other.Devices) != 0 {
+		r.Devices = other.Devices
+	}
 }
 
 func (r *Resources) Canonicalize() {
@@ -851,13 +1443,27 @@ func (r *Resources) Canonicalize() {
 	for _, n := range r.Networks {
 		n.Canonicalize()
 	}
+
+	if len(r.Devices) == 0 {
+		r.Devices = nil
+	}
+
+	if len(r.ReservedCores) == 0 {
+		r.ReservedCores = nil
+	}
+
+	for _, d := range r.Devices {
+		d.Canonicalize()
+	}
 }
 
 // MeetsMinResources returns an error if the resources specified are less than
 // the minimum allowed.
 func (r *Resources) MeetsMinResources() error {
 	var mErr multierror.Error
-	if r.CPU < 20 {
+	if r.Cores < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum Cores value is 0; got %d", r.Cores))
+	} else if r.Cores == 0 && r.CPU < 20 {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum CPU value is 20; got %d", r.CPU))
 	}
 	if r.MemoryMB < 10 {
@@ -871,6 +1477,11 @@ func (r *Resources) MeetsMinResources() error {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("network resource at index %d failed: %v", i, err))
 		}
 	}
+	for i, d := range r.Devices {
+		if err := d.MeetsMinResources(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("device resource at index %d failed: %v", i, err))
+		}
+	}
 
 	return mErr.ErrorOrNil()
 }
@@ -882,6 +1493,10 @@ func (r *Resources) Copy() *Resources {
 	}
 	newR := new(Resources)
 	*newR = *r
+	if r.ReservedCores != nil {
+		newR.ReservedCores = make([]int, len(r.ReservedCores))
+		copy(newR.ReservedCores, r.ReservedCores)
+	}
 	if r.Networks != nil {
 		n := len(r.Networks)
 		newR.Networks = make([]*NetworkResource, n)
@@ -889,6 +1504,13 @@ func (r *Resources) Copy() *Resources {
 			newR.Networks[i] = r.Networks[i].Copy()
 		}
 	}
+	if r.Devices != nil {
+		n := len(r.Devices)
+		newR.Devices = make([]*DeviceResource, n)
+		for i := 0; i < n; i++ {
+			newR.Devices[i] = r.Devices[i].Copy()
+		}
+	}
 	return newR
 }
 
@@ -903,12 +1525,15 @@ func (r *Resources) NetIndex(n *NetworkResource) int {
 }
 
 // Superset checks if one set of resources is a superset
-// of another. This ignores network resources, and the NetworkIndex
-// should be used for that.
+// of another. This ignores network and device resources, and the
+// NetworkIndex and DeviceIndex should be used for that.
 func (r *Resources) Superset(other *Resources) (bool, string) {
 	if r.CPU < other.CPU {
 		return false, "cpu exhausted"
 	}
+	if r.Cores < other.Cores {
+		return false, "cores exhausted"
+	}
 	if r.MemoryMB < other.MemoryMB {
 		return false, "memory exhausted"
 	}
@@ -928,6 +1553,8 @@ func (r *Resources) Add(delta *Resources) error {
 		return nil
 	}
 	r.CPU += delta.CPU
+	r.Cores += delta.Cores
+	r.ReservedCores = append(r.ReservedCores, delta.ReservedCores...)
 	r.MemoryMB += delta.MemoryMB
 	r.DiskMB += delta.DiskMB
 	r.IOPS += delta.IOPS
@@ -941,6 +1568,10 @@ func (r *Resources) Add(delta *Resources) error {
 			r.Networks[idx].Add(n)
 		}
 	}
+
+	for _, d := range delta.Devices {
+		r.Devices = append(r.Devices, d.Copy())
+	}
 	return nil
 }
 
@@ -951,14 +1582,45 @@ func (r *Resources) GoString() string {
 type Port struct {
 	Label string
 	Value int `mapstructure:"static"`
+
+	// To is the port inside the network namespace of the allocation that
+	// the host port is mapped to in bridge mode. If zero, the host port is
+	// mapped to the same port.
+	To int `mapstructure:"to"`
 }
 
+const (
+	// NetworkModeHost shares the network namespace of the host with the
+	// tasks
+	NetworkModeHost = "host"
+
+	// NetworkModeBridge places the tasks of an allocation in a shared
+	// network namespace attached to a bridge on the host
+	NetworkModeBridge = "bridge"
+)
+
+const (
+	// NetworkAddressFamilyIPv4 requests an IPv4 address. It is the default
+	// address family.
+	NetworkAddressFamilyIPv4 = "ipv4"
+
+	// NetworkAddressFamilyIPv6 requests an IPv6 address
+	NetworkAddressFamilyIPv6 = "ipv6"
+
+	// NetworkAddressFamilyDual requests both an IPv4 and an IPv6 address on
+	// which the ports are reserved
+	NetworkAddressFamilyDual = "dual"
+)
+
 // NetworkResource is used to represent available network
 // resources
 type NetworkResource struct {
+	Mode          string // Mode of the network, only set on task groups
+	AddressFamily string `mapstructure:"address_family"` // Address family of the requested addresses
 	Device        string // Name of the device
 	CIDR          string // CIDR block of addresses
 	IP            string // IP address
+	IPv6          string // IPv6 address of a dual-stack network
 	MBits         int    // Throughput
 	ReservedPorts []Port // Reserved ports
 	DynamicPorts  []Port // Dynamically assigned ports
@@ -1013,6 +1675,65 @@ func (n *NetworkResource) Add(delta *NetworkResource) {
 	n.DynamicPorts = append(n.DynamicPorts, delta.DynamicPorts...)
 }
 
+// Validate returns an error if the network requested by a task group or a
+// task is invalid.
+func (n *NetworkResource) Validate() error {
+	var mErr multierror.Error
+	switch n.Mode {
+	case "", NetworkModeHost, NetworkModeBridge:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid network mode %q", n.Mode))
+	}
+
+	switch n.AddressFamily {
+	case "", NetworkAddressFamilyIPv4, NetworkAddressFamilyIPv6, NetworkAddressFamilyDual:
+		// The bridge is only configured with an IPv4 subnet
+		if n.Mode == NetworkModeBridge && n.Family() != NetworkAddressFamilyIPv4 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("address family %q isn't supported in %q mode", n.AddressFamily, NetworkModeBridge))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid address family %q", n.AddressFamily))
+	}
+
+	ports := make(map[string]struct{})
+	for _, port := range append(n.ReservedPorts, n.DynamicPorts...) {
+		if _, ok := ports[port.Label]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("port label %q is duplicate", port.Label))
+		}
+		ports[port.Label] = struct{}{}
+
+		if port.To < 0 || port.To >= maxValidPort {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("port %q has invalid mapped port %d", port.Label, port.To))
+		}
+		if port.To != 0 && n.Mode != NetworkModeBridge {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("port %q can only be mapped in %q mode", port.Label, NetworkModeBridge))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Family returns the address family of the network, defaulting to IPv4.
+func (n *NetworkResource) Family() string {
+	if n.AddressFamily == "" {
+		return NetworkAddressFamilyIPv4
+	}
+	return n.AddressFamily
+}
+
+// PortMap returns the port each port label is mapped to inside the network
+// namespace of the allocation.
+func (n *NetworkResource) PortMap() map[string]int {
+	mapping := make(map[string]int)
+	for _, port := range append(n.ReservedPorts, n.DynamicPorts...) {
+		if port.To != 0 {
+			mapping[port.Label] = port.To
+		} else {
+			mapping[port.Label] = port.Value
+		}
+	}
+	return mapping
+}
+
 func (n *NetworkResource) GoString() string {
 	return fmt.Sprintf("*%#v", *n)
 }
@@ -1056,6 +1777,10 @@ const (
 	// JobMaxPriority is the maximum allowed priority
 	JobMaxPriority = 100
 
+	// JobTrackedVersions is the number of historic job versions that are
+	// kept.
+	JobTrackedVersions = 6
+
 	// Ensure CoreJobPriority is higher than any user
 	// specified job so that it gets priority. This is important
 	// for the system to remain healthy.
@@ -1103,6 +1828,11 @@ type Job struct {
 	// Region is the Nomad region that handles scheduling this job
 	Region string
 
+	// Regions is the list of regions a multi-region job is submitted to. A
+	// copy of the job is registered in each of them, with Region set to the
+	// region of the copy.
+	Regions []string
+
 	// ID is a unique identifier for the job per region. It can be
 	// specified hierarchically like LineOfBiz/OrgName/Team/Project
 	ID string
@@ -1110,6 +1840,10 @@ type Job struct {
 	// ParentID is the unique identifier of the job that spawned this job.
 	ParentID string
 
+	// Namespace is the namespace the job is registered in. The resources
+	// used by the job count against the quota attached to the namespace.
+	Namespace string
+
 	// Name is the logical name of the job used to refer to it. This is unique
 	// per region, but not unique globally.
 	Name string
@@ -1136,6 +1870,14 @@ type Job struct {
 	// all the task groups and tasks.
 	Constraints []*Constraint
 
+	// Affinities can be specified at a job level and express placement
+	// preferences for all the task groups and tasks.
+	Affinities []*Affinity
+
+	// Spreads can be specified at a job level and balance the allocations
+	// of all the task groups across the values of node attributes.
+	Spreads []*Spread
+
 	// TaskGroups are the collections of task groups that this job needs
 	// to run. Each task group is an atomic unit of scheduling and placement.
 	TaskGroups []*TaskGroup
@@ -1146,6 +1888,13 @@ type Job struct {
 	// Periodic is used to define the interval the job is run at.
 	Periodic *PeriodicConfig
 
+	// ParameterizedJob is used to specify the job as a parameterized job
+	// for dispatching.
+	ParameterizedJob *ParameterizedJobConfig
+
+	// Payload is the payload supplied when the job was dispatched.
+	Payload []byte
+
 	// Meta is used to associate arbitrary metadata with this
 	// job. This is opaque to Nomad.
 	Meta map[string]string
@@ -1161,6 +1910,14 @@ type Job struct {
 	// StatusDescription is meant to provide more human useful information
 	StatusDescription string
 
+	// Version is a monotonically increasing version number that is
+	// incremented on each job register.
+	Version uint64
+
+	// Stable marks the version of the job as stable, meaning a deployment of
+	// it succeeded. Failed deployments are reverted to stable versions.
+	Stable bool
+
 	// Raft Indexes
 	CreateIndex    uint64
 	ModifyIndex    uint64
@@ -1176,9 +1933,18 @@ func (j *Job) Canonicalize() {
 		j.Meta = nil
 	}
 
+	// Jobs without a namespace are registered in the default namespace
+	if j.Namespace == "" {
+		j.Namespace = DefaultNamespace
+	}
+
 	for _, tg := range j.TaskGroups {
 		tg.Canonicalize(j)
 	}
+
+	if j.ParameterizedJob != nil {
+		j.ParameterizedJob.Canonicalize()
+	}
 }
 
 // Copy returns a deep copy of the Job. It is expected that callers use recover.
@@ -1189,8 +1955,11 @@ func (j *Job) Copy() *Job {
 	}
 	nj := new(Job)
 	*nj = *j
+	nj.Regions = CopySliceString(nj.Regions)
 	nj.Datacenters = CopySliceString(nj.Datacenters)
 	nj.Constraints = CopySliceConstraints(nj.Constraints)
+	nj.Affinities = CopySliceAffinities(nj.Affinities)
+	nj.Spreads = CopySliceSpreads(nj.Spreads)
 
 	if j.TaskGroups != nil {
 		tgs := make([]*TaskGroup, len(nj.TaskGroups))
@@ -1202,6 +1971,7 @@ func (j *Job) Copy() *Job {
 
 	nj.Periodic = nj.Periodic.Copy()
 	nj.Meta = CopyMapStringString(nj.Meta)
+	nj.ParameterizedJob = nj.ParameterizedJob.Copy()
 	return nj
 }
 
@@ -1211,6 +1981,20 @@ func (j *Job) Validate() error {
 	if j.Region == "" {
 		mErr.Errors = append(mErr.Errors, errors.New("Missing job region"))
 	}
+	if len(j.Regions) > 0 {
+		seen := make(map[string]struct{}, len(j.Regions))
+		for _, region := range j.Regions {
+			if region == "" {
+				mErr.Errors = append(mErr.Errors, errors.New("Job regions contains an empty region"))
+			} else if _, ok := seen[region]; ok {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("Job regions contains duplicate region %q", region))
+			}
+			seen[region] = struct{}{}
+		}
+		if _, ok := seen[j.Region]; !ok && j.Region != "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Job region %q must be one of the job regions", j.Region))
+		}
+	}
 	if j.ID == "" {
 		mErr.Errors = append(mErr.Errors, errors.New("Missing job ID"))
 	} else if strings.Contains(j.ID, " ") {
@@ -1237,6 +2021,18 @@ func (j *Job) Validate() error {
 			mErr.Errors = append(mErr.Errors, outer)
 		}
 	}
+	for idx, affinity := range j.Affinities {
+		if err := affinity.Validate(); err != nil {
+			outer := fmt.Errorf("Affinity %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for idx, spread := range j.Spreads {
+		if err := spread.Validate(); err != nil {
+			outer := fmt.Errorf("Spread %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
 
 	// Check for duplicate task groups
 	taskGroups := make(map[string]int)
@@ -1276,9 +2072,84 @@ func (j *Job) Validate() error {
 		}
 	}
 
+	if j.IsParameterized() {
+		if j.Type != JobTypeBatch {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Parameterized job can only be used with %q scheduler", JobTypeBatch))
+		}
+
+		if err := j.ParameterizedJob.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	// System jobs are placed on every feasible node so placement preferences
+	// have no effect
+	if j.Type == JobTypeSystem && j.hasAffinities() {
+		mErr.Errors = append(mErr.Errors, errors.New("System jobs may not have affinities"))
+	}
+	if j.Type == JobTypeSystem && j.hasSpreads() {
+		mErr.Errors = append(mErr.Errors, errors.New("System jobs may not have spreads"))
+	}
+
+	return mErr.ErrorOrNil()
+}
+
+// Warnings returns the problems of the job that don't prevent it from being
+// registered but are likely unintended, such as settings having no effect.
+func (j *Job) Warnings() error {
+	var mErr multierror.Error
+	for _, tg := range j.TaskGroups {
+		u := j.LookupUpdateStrategy(tg.Name)
+		if (u.Stagger > 0) != (u.MaxParallel > 0) {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Task group %s update strategy must set both stagger and max_parallel for a rolling update", tg.Name))
+		}
+		if u.Canary > 0 && j.Type != JobTypeService {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Task group %s update strategy canaries are ignored by %q jobs", tg.Name, j.Type))
+		}
+		if u.AutoRevert && u.Canary == 0 && !u.Rolling() {
+			mErr.Errors = append(mErr.Errors,
+				fmt.Errorf("Task group %s update strategy auto_revert has no effect without canaries or a rolling update", tg.Name))
+		}
+	}
 	return mErr.ErrorOrNil()
 }
 
+// hasAffinities returns whether the job, any of its task groups or tasks
+// specify an affinity.
+func (j *Job) hasAffinities() bool {
+	if len(j.Affinities) != 0 {
+		return true
+	}
+	for _, tg := range j.TaskGroups {
+		if len(tg.Affinities) != 0 {
+			return true
+		}
+		for _, task := range tg.Tasks {
+			if len(task.Affinities) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasSpreads returns whether the job or any of its task groups specify a
+// spread.
+func (j *Job) hasSpreads() bool {
+	if len(j.Spreads) != 0 {
+		return true
+	}
+	for _, tg := range j.TaskGroups {
+		if len(tg.Spreads) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // LookupTaskGroup finds a task group by name
 func (j *Job) LookupTaskGroup(name string) *TaskGroup {
 	for _, tg := range j.TaskGroups {
@@ -1289,6 +2160,26 @@ func (j *Job) LookupTaskGroup(name string) *TaskGroup {
 	return nil
 }
 
+// LookupUpdateStrategy returns the update strategy of the named task group,
+// falling back to the job's update strategy if the group does not define one.
+func (j *Job) LookupUpdateStrategy(name string) *UpdateStrategy {
+	if tg := j.LookupTaskGroup(name); tg != nil && tg.Update != nil {
+		return tg.Update
+	}
+	return &j.Update
+}
+
+// LookupReschedulePolicy returns the reschedule policy of the named task
+// group, falling back to the default policy of the job type if the group
+// doesn't set one. Nil is returned if the allocations of the job are never
+// rescheduled.
+func (j *Job) LookupReschedulePolicy(name string) *ReschedulePolicy {
+	if tg := j.LookupTaskGroup(name); tg != nil && tg.ReschedulePolicy != nil {
+		return tg.ReschedulePolicy
+	}
+	return NewReschedulePolicy(j.Type)
+}
+
 // Stub is used to return a summary of the job
 func (j *Job) Stub(summary *JobSummary) *JobListStub {
 	return &JobListStub{
@@ -1311,6 +2202,11 @@ func (j *Job) IsPeriodic() bool {
 	return j.Periodic != nil
 }
 
+// IsParameterized returns whether a job is a parameterized job.
+func (j *Job) IsParameterized() bool {
+	return j.ParameterizedJob != nil
+}
+
 // VaultPolicies returns the set of Vault policies per task group, per task
 func (j *Job) VaultPolicies() map[string]map[string]*Vault {
 	policies := make(map[string]map[string]*Vault, len(j.TaskGroups))
@@ -1406,6 +2302,52 @@ type UpdateStrategy struct {
 
 	// MaxParallel is how many updates can be done in parallel
 	MaxParallel int `mapstructure:"max_parallel"`
+
+	// Canary is the number of canaries to place before the rest of the
+	// update waits for the deployment to be promoted.
+	Canary int
+
+	// AutoRevert reverts the job to its last healthy version if the
+	// deployment fails.
+	AutoRevert bool `mapstructure:"auto_revert"`
+
+	// HealthCheck is how the health of the allocations placed by a
+	// deployment is determined. It defaults to the states of the tasks.
+	HealthCheck string `mapstructure:"health_check"`
+
+	// HealthyDeadline is how long an allocation has to pass its health
+	// checks before it is marked unhealthy. It defaults to
+	// DefaultUpdateHealthyDeadline.
+	HealthyDeadline time.Duration `mapstructure:"healthy_deadline"`
+}
+
+const (
+	// UpdateHealthCheckTaskStates considers an allocation healthy once its
+	// tasks are running and unhealthy if it fails.
+	UpdateHealthCheckTaskStates = "task_states"
+
+	// UpdateHealthCheckChecks considers an allocation healthy once its tasks
+	// are running and the Consul checks of their services are passing, as
+	// reported by the client.
+	UpdateHealthCheckChecks = "checks"
+
+	// DefaultUpdateHealthyDeadline is the healthy deadline of update
+	// strategies that do not set one.
+	DefaultUpdateHealthyDeadline = 5 * time.Minute
+)
+
+// HealthChecks returns whether the health of the allocations is derived
+// from the Consul checks of their services.
+func (u *UpdateStrategy) HealthChecks() bool {
+	return u.HealthCheck == UpdateHealthCheckChecks
+}
+
+// Deadline returns how long an allocation has to become healthy.
+func (u *UpdateStrategy) Deadline() time.Duration {
+	if u.HealthyDeadline == 0 {
+		return DefaultUpdateHealthyDeadline
+	}
+	return u.HealthyDeadline
 }
 
 // Rolling returns if a rolling strategy should be used
@@ -1413,6 +2355,111 @@ func (u *UpdateStrategy) Rolling() bool {
 	return u.Stagger > 0 && u.MaxParallel > 0
 }
 
+func (u *UpdateStrategy) Copy() *UpdateStrategy {
+	if u == nil {
+		return nil
+	}
+	nu := new(UpdateStrategy)
+	*nu = *u
+	return nu
+}
+
+// Validate is used to sanity check an update strategy
+func (u *UpdateStrategy) Validate() error {
+	var mErr multierror.Error
+	if u.Stagger < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update stagger can't be negative"))
+	}
+	if u.MaxParallel < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update max parallel can't be negative"))
+	}
+	if u.Canary < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update canary count can't be negative"))
+	}
+	switch u.HealthCheck {
+	case "", UpdateHealthCheckTaskStates, UpdateHealthCheckChecks:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Update health check must be %q or %q",
+			UpdateHealthCheckTaskStates, UpdateHealthCheckChecks))
+	}
+	if u.HealthyDeadline < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Update healthy deadline can't be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	// MigrateHealthCheckTaskStates considers a migrated allocation healthy
+	// once all its tasks have been running for the minimum healthy time.
+	MigrateHealthCheckTaskStates = "task_states"
+
+	// MigrateHealthCheckNone considers a migrated allocation healthy as soon
+	// as it is running.
+	MigrateHealthCheckNone = "none"
+)
+
+// MigrateStrategy is used to control how the allocations of a task group
+// are migrated off a draining node.
+type MigrateStrategy struct {
+	// MaxParallel is the number of allocations of the task group that can be
+	// migrated at the same time.
+	MaxParallel int `mapstructure:"max_parallel"`
+
+	// HealthCheck is how the health of a migrated allocation is determined
+	HealthCheck string `mapstructure:"health_check"`
+
+	// MinHealthyTime is how long a migrated allocation must be healthy
+	// before the next allocation is migrated.
+	MinHealthyTime time.Duration `mapstructure:"min_healthy_time"`
+
+	// HealthyDeadline is how long a migrated allocation has to become
+	// healthy before the next allocation is migrated regardless.
+	HealthyDeadline time.Duration `mapstructure:"healthy_deadline"`
+}
+
+// DefaultMigrateStrategy returns the migrate strategy used by task groups
+// that do not specify one.
+func DefaultMigrateStrategy() *MigrateStrategy {
+	return &MigrateStrategy{
+		MaxParallel:     1,
+		HealthCheck:     MigrateHealthCheckTaskStates,
+		MinHealthyTime:  10 * time.Second,
+		HealthyDeadline: 5 * time.Minute,
+	}
+}
+
+func (m *MigrateStrategy) Copy() *MigrateStrategy {
+	if m == nil {
+		return nil
+	}
+	nm := new(MigrateStrategy)
+	*nm = *m
+	return nm
+}
+
+// Validate is used to sanity check a migrate strategy
+func (m *MigrateStrategy) Validate() error {
+	var mErr multierror.Error
+	if m.MaxParallel < 1 {
+		mErr.Errors = append(mErr.Errors, errors.New("Migrate max parallel must be at least one"))
+	}
+	switch m.HealthCheck {
+	case MigrateHealthCheckTaskStates, MigrateHealthCheckNone:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Migrate health check must be %q or %q",
+			MigrateHealthCheckTaskStates, MigrateHealthCheckNone))
+	}
+	if m.MinHealthyTime < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Migrate min healthy time can't be negative"))
+	}
+	if m.HealthyDeadline <= 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Migrate healthy deadline must be positive"))
+	} else if m.MinHealthyTime >= m.HealthyDeadline {
+		mErr.Errors = append(mErr.Errors, errors.New("Migrate min healthy time must be less than the healthy deadline"))
+	}
+	return mErr.ErrorOrNil()
+}
+
 const (
 	// PeriodicSpecCron is used for a cron spec.
 	PeriodicSpecCron = "cron"
@@ -1525,6 +2572,168 @@ type PeriodicLaunch struct {
 	ModifyIndex uint64
 }
 
+const (
+	// DispatchPayloadForbidden denotes that a dispatched job can not contain a
+	// payload.
+	DispatchPayloadForbidden = "forbidden"
+
+	// DispatchPayloadOptional denotes that the payload of a dispatched job is
+	// optional.
+	DispatchPayloadOptional = "optional"
+
+	// DispatchPayloadRequired denotes that a dispatched job must contain a
+	// payload.
+	DispatchPayloadRequired = "required"
+
+	// DispatchLaunchSuffix is the string appended to the parameterized job's
+	// ID when dispatching instances of it.
+	DispatchLaunchSuffix = "/dispatch-"
+
+	// DispatchPayloadSizeLimit is the maximum size of the payload of a
+	// dispatched job.
+	DispatchPayloadSizeLimit = 16 * 1024
+)
+
+// ParameterizedJobConfig is used to configure a job that is not run directly
+// but dispatched, possibly many times, with metadata and a payload.
+type ParameterizedJobConfig struct {
+	// Payload configures whether a payload is forbidden, optional or
+	// required when dispatching.
+	Payload string
+
+	// MetaRequired are the metadata keys that must be set when dispatching.
+	MetaRequired []string `mapstructure:"meta_required"`
+
+	// MetaOptional are the metadata keys that may be set when dispatching.
+	MetaOptional []string `mapstructure:"meta_optional"`
+}
+
+func (d *ParameterizedJobConfig) Validate() error {
+	var mErr multierror.Error
+	switch d.Payload {
+	case DispatchPayloadOptional, DispatchPayloadRequired, DispatchPayloadForbidden:
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Unknown payload requirement: %q", d.Payload))
+	}
+
+	// Check that the meta configurations are disjoint sets
+	optional := make(map[string]struct{}, len(d.MetaOptional))
+	for _, k := range d.MetaOptional {
+		optional[k] = struct{}{}
+	}
+	for _, k := range d.MetaRequired {
+		if _, ok := optional[k]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Metadata key %q can't be both required and optional", k))
+		}
+	}
+
+	return mErr.ErrorOrNil()
+}
+
+func (d *ParameterizedJobConfig) Canonicalize() {
+	if d.Payload == "" {
+		d.Payload = DispatchPayloadOptional
+	}
+}
+
+func (d *ParameterizedJobConfig) Copy() *ParameterizedJobConfig {
+	if d == nil {
+		return nil
+	}
+	nd := new(ParameterizedJobConfig)
+	*nd = *d
+	nd.MetaOptional = CopySliceString(nd.MetaOptional)
+	nd.MetaRequired = CopySliceString(nd.MetaRequired)
+	return nd
+}
+
+// DispatchedID returns an ID appropriate for a job dispatched against a
+// particular parameterized job
+func DispatchedID(templateID string, t time.Time) string {
+	u := GenerateUUID()[:8]
+	return fmt.Sprintf("%s%s%d-%s", templateID, DispatchLaunchSuffix, t.Unix(), u)
+}
+
+// DispatchPayloadConfig configures how a task gets its input from a job
+// dispatch
+type DispatchPayloadConfig struct {
+	// File specifies a relative path to where the input data should be
+	// written
+	File string
+}
+
+func (d *DispatchPayloadConfig) Copy() *DispatchPayloadConfig {
+	if d == nil {
+		return nil
+	}
+	nd := new(DispatchPayloadConfig)
+	*nd = *d
+	return nd
+}
+
+func (d *DispatchPayloadConfig) Validate() error {
+	// Verify the destination doesn't escape
+	escaped, err := PathEscapesAllocDir(d.File)
+	if err != nil {
+		return fmt.Errorf("invalid destination path: %v", err)
+	} else if escaped {
+		return fmt.Errorf("destination escapes allocation directory")
+	}
+
+	return nil
+}
+
+const (
+	// TaskLifecycleHookPrestart marks a task that runs before the main tasks
+	// of the task group are started.
+	TaskLifecycleHookPrestart = "prestart"
+
+	// TaskLifecycleHookPoststart marks a task that runs once the main tasks
+	// of the task group are running.
+	TaskLifecycleHookPoststart = "poststart"
+
+	// TaskLifecycleHookPoststop marks a task that runs once the main tasks of
+	// the task group are dead.
+	TaskLifecycleHookPoststop = "poststop"
+)
+
+// TaskLifecycleConfig orders a task relative to the main tasks of its task
+// group. Tasks without a lifecycle are the main tasks.
+type TaskLifecycleConfig struct {
+	// Hook is the point of the lifecycle of the main tasks at which the task
+	// is started.
+	Hook string
+
+	// Sidecar marks a task that keeps running alongside the main tasks
+	// instead of running to completion. Sidecar tasks are killed once the
+	// main tasks are dead.
+	Sidecar bool
+}
+
+func (l *TaskLifecycleConfig) Copy() *TaskLifecycleConfig {
+	if l == nil {
+		return nil
+	}
+	nl := new(TaskLifecycleConfig)
+	*nl = *l
+	return nl
+}
+
+func (l *TaskLifecycleConfig) Validate() error {
+	switch l.Hook {
+	case TaskLifecycleHookPrestart, TaskLifecycleHookPoststart:
+	case TaskLifecycleHookPoststop:
+		if l.Sidecar {
+			return fmt.Errorf("%s tasks can't be sidecars", l.Hook)
+		}
+	case "":
+		return fmt.Errorf("lifecycle hook must be set")
+	default:
+		return fmt.Errorf("invalid lifecycle hook %q", l.Hook)
+	}
+	return nil
+}
+
 var (
 	defaultServiceJobRestartPolicy = RestartPolicy{
 		Delay:    15 * time.Second,
@@ -1609,30 +2818,131 @@ func NewRestartPolicy(jobType string) *RestartPolicy {
 	return nil
 }
 
-// TaskGroup is an atomic unit of placement. Each task group belongs to
-// a job and may contain any number of tasks. A task group support running
-// in many replicas using the same configuration..
-type TaskGroup struct {
-	// Name of the task group
-	Name string
+var (
+	defaultServiceJobReschedulePolicy = ReschedulePolicy{
+		Delay:     30 * time.Second,
+		Unlimited: true,
+	}
+	defaultBatchJobReschedulePolicy = ReschedulePolicy{
+		Attempts: 1,
+		Interval: 24 * time.Hour,
+		Delay:    5 * time.Second,
+	}
+)
 
-	// Count is the number of replicas of this task group that should
-	// be scheduled.
-	Count int
+// ReschedulePolicy configures how the scheduler replaces the failed
+// allocations of a task group. Tasks are first restarted in place by the
+// client according to the restart policy, once it gives up the allocation
+// fails and is rescheduled.
+type ReschedulePolicy struct {
+	// Attempts is the number of reschedules allowed within the interval.
+	Attempts int
 
-	// Constraints can be specified at a task group level and apply to
+	// Interval is the duration over which the reschedules are counted.
+	Interval time.Duration
+
+	// Delay is the time between the failure of an allocation and the
+	// placement of its replacement.
+	Delay time.Duration
+
+	// Unlimited allows failed allocations to be rescheduled any number of
+	// times, ignoring the attempts and interval.
+	Unlimited bool
+}
+
+func (r *ReschedulePolicy) Copy() *ReschedulePolicy {
+	if r == nil {
+		return nil
+	}
+	nrp := new(ReschedulePolicy)
+	*nrp = *r
+	return nrp
+}
+
+func (r *ReschedulePolicy) Validate() error {
+	var mErr multierror.Error
+	if r.Delay < 0 {
+		multierror.Append(&mErr, fmt.Errorf("Reschedule delay must be non-negative: %v", r.Delay))
+	}
+	if r.Unlimited {
+		return mErr.ErrorOrNil()
+	}
+	if r.Attempts < 0 {
+		multierror.Append(&mErr, fmt.Errorf("Reschedule attempts must be non-negative: %d", r.Attempts))
+	}
+	if r.Attempts > 0 && r.Interval <= 0 {
+		multierror.Append(&mErr, fmt.Errorf("Reschedule interval must be positive when attempts are allowed: %v", r.Interval))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// NewReschedulePolicy returns the default reschedule policy of the job type.
+// Allocations of system jobs are never rescheduled.
+func NewReschedulePolicy(jobType string) *ReschedulePolicy {
+	switch jobType {
+	case JobTypeService:
+		rp := defaultServiceJobReschedulePolicy
+		return &rp
+	case JobTypeBatch:
+		rp := defaultBatchJobReschedulePolicy
+		return &rp
+	}
+	return nil
+}
+
+// TaskGroup is an atomic unit of placement. Each task group belongs to
+// a job and may contain any number of tasks. A task group support running
+// in many replicas using the same configuration..
+type TaskGroup struct {
+	// Name of the task group
+	Name string
+
+	// Count is the number of replicas of this task group that should
+	// be scheduled.
+	Count int
+
+	// Constraints can be specified at a task group level and apply to
 	// all the tasks contained.
 	Constraints []*Constraint
 
+	// Affinities can be specified at a task group level and express
+	// placement preferences for all the tasks contained.
+	Affinities []*Affinity
+
+	// Spreads can be specified at a task group level and balance its
+	// allocations across the values of node attributes.
+	Spreads []*Spread
+
 	//RestartPolicy of a TaskGroup
 	RestartPolicy *RestartPolicy
 
+	// ReschedulePolicy is used to control how the failed allocations of the
+	// task group are replaced.
+	ReschedulePolicy *ReschedulePolicy
+
 	// Tasks are the collection of tasks that this task group needs to run
 	Tasks []*Task
 
 	// EphemeralDisk is the disk resources that the task group requests
 	EphemeralDisk *EphemeralDisk
 
+	// Volumes are the volumes requested by the task group, keyed by name.
+	// Tasks mount them using their VolumeMounts.
+	Volumes map[string]*VolumeRequest
+
+	// Networks are the networks shared by all the tasks of the task group.
+	// In bridge mode, the tasks run in a network namespace of their own and
+	// the ports are mapped from the host into it.
+	Networks []*NetworkResource
+
+	// Update is used to control the update strategy of the task group. If
+	// nil, the update strategy of the job is used.
+	Update *UpdateStrategy
+
+	// Migrate is used to control how the allocations of the task group are
+	// migrated off draining nodes. If nil, the default strategy is used.
+	Migrate *MigrateStrategy
+
 	// Meta is used to associate arbitrary metadata with this
 	// task group. This is opaque to Nomad.
 	Meta map[string]string
@@ -1645,8 +2955,11 @@ func (tg *TaskGroup) Copy() *TaskGroup {
 	ntg := new(TaskGroup)
 	*ntg = *tg
 	ntg.Constraints = CopySliceConstraints(ntg.Constraints)
+	ntg.Affinities = CopySliceAffinities(ntg.Affinities)
+	ntg.Spreads = CopySliceSpreads(ntg.Spreads)
 
 	ntg.RestartPolicy = ntg.RestartPolicy.Copy()
+	ntg.ReschedulePolicy = ntg.ReschedulePolicy.Copy()
 
 	if tg.Tasks != nil {
 		tasks := make([]*Task, len(ntg.Tasks))
@@ -1657,10 +2970,17 @@ func (tg *TaskGroup) Copy() *TaskGroup {
 	}
 
 	ntg.Meta = CopyMapStringString(ntg.Meta)
+	ntg.Volumes = CopyMapVolumeRequest(ntg.Volumes)
+	ntg.Networks = CopySliceNetworkResources(ntg.Networks)
 
 	if tg.EphemeralDisk != nil {
 		ntg.EphemeralDisk = tg.EphemeralDisk.Copy()
 	}
+
+	if tg.Update != nil {
+		ntg.Update = tg.Update.Copy()
+	}
+	ntg.Migrate = ntg.Migrate.Copy()
 	return ntg
 }
 
@@ -1671,17 +2991,48 @@ func (tg *TaskGroup) Canonicalize(job *Job) {
 	if len(tg.Meta) == 0 {
 		tg.Meta = nil
 	}
+	if len(tg.Volumes) == 0 {
+		tg.Volumes = nil
+	}
+	if len(tg.Networks) == 0 {
+		tg.Networks = nil
+	}
+	for _, n := range tg.Networks {
+		n.Canonicalize()
+		if n.Mode == "" {
+			n.Mode = NetworkModeHost
+		}
+	}
 
 	// Set the default restart policy.
 	if tg.RestartPolicy == nil {
 		tg.RestartPolicy = NewRestartPolicy(job.Type)
 	}
 
+	// Set the default reschedule policy.
+	if tg.ReschedulePolicy == nil {
+		tg.ReschedulePolicy = NewReschedulePolicy(job.Type)
+	}
+
 	// Set a default ephemeral disk object if the user has not requested for one
 	if tg.EphemeralDisk == nil {
 		tg.EphemeralDisk = DefaultEphemeralDisk()
 	}
 
+	// Fill in the unset fields of the migrate strategy from the defaults
+	if m := tg.Migrate; m != nil {
+		defaults := DefaultMigrateStrategy()
+		if m.MaxParallel == 0 {
+			m.MaxParallel = defaults.MaxParallel
+		}
+		if m.HealthCheck == "" {
+			m.HealthCheck = defaults.HealthCheck
+		}
+		if m.HealthyDeadline == 0 {
+			m.HealthyDeadline = defaults.HealthyDeadline
+		}
+	}
+
 	for _, task := range tg.Tasks {
 		task.Canonicalize(job, tg)
 	}
@@ -1718,6 +3069,18 @@ func (tg *TaskGroup) Validate() error {
 			mErr.Errors = append(mErr.Errors, outer)
 		}
 	}
+	for idx, affinity := range tg.Affinities {
+		if err := affinity.Validate(); err != nil {
+			outer := fmt.Errorf("Affinity %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+	for idx, spread := range tg.Spreads {
+		if err := spread.Validate(); err != nil {
+			outer := fmt.Errorf("Spread %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
 
 	if tg.RestartPolicy != nil {
 		if err := tg.RestartPolicy.Validate(); err != nil {
@@ -1735,9 +3098,53 @@ func (tg *TaskGroup) Validate() error {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("Task Group %v should have an ephemeral disk object", tg.Name))
 	}
 
-	// Check for duplicate tasks
+	if tg.Update != nil {
+		if err := tg.Update.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	if tg.Migrate != nil {
+		if err := tg.Migrate.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	if tg.ReschedulePolicy != nil {
+		if err := tg.ReschedulePolicy.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+
+	for name, v := range tg.Volumes {
+		if v.Name != name {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Volume %q has mismatched name %q", name, v.Name))
+		}
+		if err := v.Validate(); err != nil {
+			outer := fmt.Errorf("Volume %q validation failed: %v", name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
+	if len(tg.Networks) > 1 {
+		mErr.Errors = append(mErr.Errors, errors.New("Only one network resource is allowed in a task group"))
+	}
+	for idx, n := range tg.Networks {
+		if err := n.Validate(); err != nil {
+			outer := fmt.Errorf("Network %d validation failed: %v", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
+	// Check for duplicate tasks and that there is a main task the lifecycle
+	// of the other tasks is relative to
 	tasks := make(map[string]int)
+	mainTasks := 0
 	for idx, task := range tg.Tasks {
+		if task.IsMainTask() {
+			mainTasks++
+		}
+
 		if task.Name == "" {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("Task %d missing name", idx+1))
 		} else if existing, ok := tasks[task.Name]; ok {
@@ -1746,6 +3153,9 @@ func (tg *TaskGroup) Validate() error {
 			tasks[task.Name] = idx
 		}
 	}
+	if len(tg.Tasks) != 0 && mainTasks == 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Task group must have at least one task without a lifecycle"))
+	}
 
 	// Validate the tasks
 	for _, task := range tg.Tasks {
@@ -1753,10 +3163,38 @@ func (tg *TaskGroup) Validate() error {
 			outer := fmt.Errorf("Task %s validation failed: %s", task.Name, err)
 			mErr.Errors = append(mErr.Errors, outer)
 		}
+
+		// Validate the volume mounts reference volumes of the task group
+		for _, m := range task.VolumeMounts {
+			if _, ok := tg.Volumes[m.Volume]; m.Volume != "" && !ok {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("Task %s mounts undefined volume %q", task.Name, m.Volume))
+			}
+		}
+
+		// Tasks sharing the bridge network of the task group can't have
+		// networks of their own
+		if tg.bridgeNetwork() && task.Resources != nil && len(task.Resources.Networks) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Task %s can't request network resources in a task group with a %q network", task.Name, NetworkModeBridge))
+		}
 	}
 	return mErr.ErrorOrNil()
 }
 
+// bridgeNetwork returns whether the tasks of the task group share a bridge
+// network.
+func (tg *TaskGroup) bridgeNetwork() bool {
+	return len(tg.Networks) != 0 && tg.Networks[0].Mode == NetworkModeBridge
+}
+
+// LookupMigrateStrategy returns the migrate strategy of the task group or
+// the default strategy if it has none.
+func (tg *TaskGroup) LookupMigrateStrategy() *MigrateStrategy {
+	if tg.Migrate != nil {
+		return tg.Migrate
+	}
+	return DefaultMigrateStrategy()
+}
+
 // LookupTask finds a task by name
 func (tg *TaskGroup) LookupTask(name string) *Task {
 	for _, t := range tg.Tasks {
@@ -1776,6 +3214,7 @@ const (
 	ServiceCheckHTTP   = "http"
 	ServiceCheckTCP    = "tcp"
 	ServiceCheckScript = "script"
+	ServiceCheckGRPC   = "grpc"
 
 	// minCheckInterval is the minimum check interval permitted.  Consul
 	// currently has its MinInterval set to 1s.  Mirror that here for
@@ -1800,6 +3239,9 @@ type ServiceCheck struct {
 	Interval      time.Duration // Interval of the check
 	Timeout       time.Duration // Timeout of the response from the check before consul fails the check
 	InitialStatus string        `mapstructure:"initial_status"` // Initial status of the check
+	GRPCService   string        `mapstructure:"grpc_service"`   // Service to check for grpc checks
+	GRPCUseTLS    bool          `mapstructure:"grpc_use_tls"`   // Use TLS for grpc checks
+	CheckRestart  *CheckRestart // Restarts the task if the check stays unhealthy
 }
 
 func (sc *ServiceCheck) Copy() *ServiceCheck {
@@ -1808,9 +3250,46 @@ func (sc *ServiceCheck) Copy() *ServiceCheck {
 	}
 	nsc := new(ServiceCheck)
 	*nsc = *sc
+	nsc.CheckRestart = sc.CheckRestart.Copy()
 	return nsc
 }
 
+// CheckRestart describes when the client restarts a task whose check stays
+// unhealthy.
+type CheckRestart struct {
+	// Limit is the number of consecutive unhealthy check intervals after
+	// which the task is restarted. Zero disables the restarts.
+	Limit int
+
+	// Grace is how long to wait after the task started before the health of
+	// the check is considered.
+	Grace time.Duration
+
+	// IgnoreWarnings considers checks in the warning state healthy.
+	IgnoreWarnings bool `mapstructure:"ignore_warnings"`
+}
+
+func (c *CheckRestart) Copy() *CheckRestart {
+	if c == nil {
+		return nil
+	}
+	nc := new(CheckRestart)
+	*nc = *c
+	return nc
+}
+
+// Validate is used to sanity check a check restart policy
+func (c *CheckRestart) Validate() error {
+	var mErr multierror.Error
+	if c.Limit < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Check restart limit can't be negative"))
+	}
+	if c.Grace < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Check restart grace can't be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
 func (sc *ServiceCheck) Canonicalize(serviceName string) {
 	// Ensure empty slices are treated as null to avoid scheduling issues when
 	// using DeepEquals.
@@ -1849,8 +3328,14 @@ func (sc *ServiceCheck) validate() error {
 
 		// TODO: enforce timeout on the Client side and reenable
 		// validation.
+	case ServiceCheckGRPC:
+		if sc.Timeout == 0 {
+			return fmt.Errorf("missing required value timeout. Timeout cannot be less than %v", minCheckInterval)
+		} else if sc.Timeout < minCheckTimeout {
+			return fmt.Errorf("timeout (%v) is lower than required minimum timeout %v", sc.Timeout, minCheckInterval)
+		}
 	default:
-		return fmt.Errorf(`invalid type (%+q), must be one of "http", "tcp", or "script" type`, sc.Type)
+		return fmt.Errorf(`invalid type (%+q), must be one of "http", "tcp", "grpc", or "script" type`, sc.Type)
 	}
 
 	if sc.Interval == 0 {
@@ -1870,13 +3355,19 @@ func (sc *ServiceCheck) validate() error {
 
 	}
 
+	if sc.CheckRestart != nil {
+		if err := sc.CheckRestart.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // RequiresPort returns whether the service check requires the task has a port.
 func (sc *ServiceCheck) RequiresPort() bool {
 	switch sc.Type {
-	case ServiceCheckHTTP, ServiceCheckTCP:
+	case ServiceCheckHTTP, ServiceCheckTCP, ServiceCheckGRPC:
 		return true
 	default:
 		return false
@@ -1895,6 +3386,10 @@ func (sc *ServiceCheck) Hash(serviceID string) string {
 	io.WriteString(h, sc.PortLabel)
 	io.WriteString(h, sc.Interval.String())
 	io.WriteString(h, sc.Timeout.String())
+	io.WriteString(h, sc.GRPCService)
+	if sc.GRPCUseTLS {
+		io.WriteString(h, "grpc_use_tls")
+	}
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
@@ -2073,6 +3568,10 @@ type Task struct {
 	// the particular task.
 	Constraints []*Constraint
 
+	// Affinities can be specified at a task level and express placement
+	// preferences for the particular task.
+	Affinities []*Affinity
+
 	// Resources is the resources needed by this task
 	Resources *Resources
 
@@ -2090,6 +3589,17 @@ type Task struct {
 	// Artifacts is a list of artifacts to download and extract before running
 	// the task.
 	Artifacts []*TaskArtifact
+
+	// DispatchPayload configures how the task retrieves its input from a
+	// dispatch
+	DispatchPayload *DispatchPayloadConfig `mapstructure:"dispatch_payload"`
+
+	// VolumeMounts mount the volumes of the task group into the task.
+	VolumeMounts []*VolumeMount
+
+	// Lifecycle orders the task relative to the main tasks of the task
+	// group. It is nil for main tasks.
+	Lifecycle *TaskLifecycleConfig
 }
 
 func (t *Task) Copy() *Task {
@@ -2109,10 +3619,14 @@ func (t *Task) Copy() *Task {
 	}
 
 	nt.Constraints = CopySliceConstraints(nt.Constraints)
+	nt.Affinities = CopySliceAffinities(nt.Affinities)
 
 	nt.Vault = nt.Vault.Copy()
 	nt.Resources = nt.Resources.Copy()
 	nt.Meta = CopyMapStringString(nt.Meta)
+	nt.DispatchPayload = nt.DispatchPayload.Copy()
+	nt.VolumeMounts = CopySliceVolumeMount(nt.VolumeMounts)
+	nt.Lifecycle = nt.Lifecycle.Copy()
 
 	if t.Artifacts != nil {
 		artifacts := make([]*TaskArtifact, 0, len(t.Artifacts))
@@ -2189,6 +3703,24 @@ func (t *Task) FindHostAndPortFor(portLabel string) (string, int) {
 	return "", 0
 }
 
+// IsMainTask returns whether the task is a main task of its task group,
+// that is it has no lifecycle hook.
+func (t *Task) IsMainTask() bool {
+	return t.Lifecycle == nil
+}
+
+// IsSidecar returns whether the task is a sidecar that is killed once the
+// main tasks of its task group are dead.
+func (t *Task) IsSidecar() bool {
+	return t.Lifecycle != nil && t.Lifecycle.Sidecar
+}
+
+// IsPoststop returns whether the task runs once the main tasks of its task
+// group are dead.
+func (t *Task) IsPoststop() bool {
+	return t.Lifecycle != nil && t.Lifecycle.Hook == TaskLifecycleHookPoststop
+}
+
 // Validate is used to sanity check a task
 func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 	var mErr multierror.Error
@@ -2214,6 +3746,12 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		if err := t.Resources.MeetsMinResources(); err != nil {
 			mErr.Errors = append(mErr.Errors, err)
 		}
+		for idx, n := range t.Resources.Networks {
+			if err := n.Validate(); err != nil {
+				outer := fmt.Errorf("Network %d validation failed: %v", idx+1, err)
+				mErr.Errors = append(mErr.Errors, outer)
+			}
+		}
 
 		// Ensure the task isn't asking for disk resources
 		if t.Resources.DiskMB > 0 {
@@ -2234,6 +3772,12 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 			mErr.Errors = append(mErr.Errors, outer)
 		}
 	}
+	for idx, affinity := range t.Affinities {
+		if err := affinity.Validate(); err != nil {
+			outer := fmt.Errorf("Affinity %d validation failed: %s", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
 
 	// Validate Services
 	if err := validateServices(t); err != nil {
@@ -2256,6 +3800,19 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	for idx, m := range t.VolumeMounts {
+		if err := m.Validate(); err != nil {
+			outer := fmt.Errorf("Volume mount %d validation failed: %v", idx+1, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
+	if t.Lifecycle != nil {
+		if err := t.Lifecycle.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Lifecycle validation failed: %v", err))
+		}
+	}
+
 	if t.Vault != nil {
 		if err := t.Vault.Validate(); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("Vault validation failed: %v", err))
@@ -2277,6 +3834,13 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	// Validate the dispatch payload block if there
+	if t.DispatchPayload != nil {
+		if err := t.DispatchPayload.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Dispatch Payload validation failed: %v", err))
+		}
+	}
+
 	return mErr.ErrorOrNil()
 }
 
@@ -2555,6 +4119,15 @@ const (
 	// TaskSiblingFailed indicates that a sibling task in the task group has
 	// failed.
 	TaskSiblingFailed = "Sibling task failed"
+
+	// TaskDriverMessage is an informational event message emitted by
+	// drivers such as when they're performing a long running action like
+	// downloading an image.
+	TaskDriverMessage = "Driver"
+
+	// TaskMainDead indicates that the main tasks of the task group are dead
+	// and the sidecar task is being killed.
+	TaskMainDead = "Main Tasks Dead"
 )
 
 // TaskEvent is an event that effects the state of a task and contains meta-data
@@ -2580,6 +4153,10 @@ type TaskEvent struct {
 	Signal   int    // The signal that terminated the task.
 	Message  string // A possible message explaining the termination of the task.
 
+	// OOMKilled marks whether the task was killed for exceeding its memory
+	// limit.
+	OOMKilled bool
+
 	// Killing fields
 	KillTimeout time.Duration
 
@@ -2613,6 +4190,9 @@ type TaskEvent struct {
 
 	// TaskSignal is the signal that was sent to the task
 	TaskSignal string
+
+	// DriverMessage indicates a driver action being taken.
+	DriverMessage string
 }
 
 func (te *TaskEvent) GoString() string {
@@ -2666,6 +4246,11 @@ func (e *TaskEvent) SetSignal(s int) *TaskEvent {
 	return e
 }
 
+func (e *TaskEvent) SetOOMKilled(oom bool) *TaskEvent {
+	e.OOMKilled = oom
+	return e
+}
+
 func (e *TaskEvent) SetExitMessage(err error) *TaskEvent {
 	if err != nil {
 		e.Message = err.Error()
@@ -2741,6 +4326,11 @@ func (e *TaskEvent) SetVaultRenewalError(err error) *TaskEvent {
 	return e
 }
 
+func (e *TaskEvent) SetDriverMessage(m string) *TaskEvent {
+	e.DriverMessage = m
+	return e
+}
+
 // TaskArtifact is an artifact to download before running the task.
 type TaskArtifact struct {
 	// GetterSource is the source to download an artifact using go-getter
@@ -2850,10 +4440,11 @@ func (ta *TaskArtifact) Validate() error {
 }
 
 const (
-	ConstraintDistinctHosts = "distinct_hosts"
-	ConstraintRegex         = "regexp"
-	ConstraintVersion       = "version"
-	ConstraintSetContains   = "set_contains"
+	ConstraintDistinctHosts    = "distinct_hosts"
+	ConstraintDistinctProperty = "distinct_property"
+	ConstraintRegex            = "regexp"
+	ConstraintVersion          = "version"
+	ConstraintSetContains      = "set_contains"
 )
 
 // Constraints are used to restrict placement options.
@@ -2904,10 +4495,189 @@ func (c *Constraint) Validate() error {
 		if _, err := version.NewConstraint(c.RTarget); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("Version constraint is invalid: %v", err))
 		}
+	case ConstraintDistinctProperty:
+		if c.LTarget == "" {
+			mErr.Errors = append(mErr.Errors, errors.New("Distinct property constraint requires an attribute"))
+		}
+		if _, err := c.DistinctPropertyLimit(); err != nil {
+			mErr.Errors = append(mErr.Errors, err)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// DistinctPropertyLimit returns the number of allocations allowed to share a
+// value of the property of a distinct_property constraint. It defaults to one
+// if the constraint doesn't specify it.
+func (c *Constraint) DistinctPropertyLimit() (uint64, error) {
+	if c.RTarget == "" {
+		return 1, nil
+	}
+	limit, err := strconv.ParseUint(c.RTarget, 10, 64)
+	if err != nil || limit == 0 {
+		return 0, fmt.Errorf("Distinct property limit must be a positive integer, got %q", c.RTarget)
+	}
+	return limit, nil
+}
+
+const (
+	// DefaultAffinityWeight is the weight of an affinity that doesn't
+	// specify one.
+	DefaultAffinityWeight = 50
+)
+
+// Affinities are used to express placement preferences. Unlike constraints,
+// nodes not matching an affinity remain eligible for placement but are scored
+// according to the weight of the affinity.
+type Affinity struct {
+	LTarget string // Left-hand target
+	RTarget string // Right-hand target
+	Operand string // Affinity operand (<=, <, =, !=, >, >=), regexp, version, set_contains
+	Weight  int    // Weight of the affinity, negative weights express anti-affinity
+	str     string // Memoized string
+}
+
+// Equal checks if two affinities are equal
+func (a *Affinity) Equal(o *Affinity) bool {
+	return a.LTarget == o.LTarget &&
+		a.RTarget == o.RTarget &&
+		a.Operand == o.Operand &&
+		a.Weight == o.Weight
+}
+
+func (a *Affinity) Copy() *Affinity {
+	if a == nil {
+		return nil
+	}
+	na := new(Affinity)
+	*na = *a
+	return na
+}
+
+func (a *Affinity) String() string {
+	if a.str != "" {
+		return a.str
+	}
+	a.str = fmt.Sprintf("%s %s %s %d", a.LTarget, a.Operand, a.RTarget, a.Weight)
+	return a.str
+}
+
+func (a *Affinity) Validate() error {
+	var mErr multierror.Error
+	if a.Operand == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing affinity operand"))
+	}
+
+	// Perform additional validation based on operand
+	switch a.Operand {
+	case ConstraintDistinctHosts, ConstraintDistinctProperty:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Operand %q is not supported by affinities", a.Operand))
+	case ConstraintRegex:
+		if _, err := regexp.Compile(a.RTarget); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Regular expression failed to compile: %v", err))
+		}
+	case ConstraintVersion:
+		if _, err := version.NewConstraint(a.RTarget); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Version affinity is invalid: %v", err))
+		}
+	}
+
+	if a.Weight == 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("Affinity weight can't be zero"))
+	} else if a.Weight < -100 || a.Weight > 100 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Affinity weight must be between -100 and 100, got %d", a.Weight))
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	// DefaultSpreadWeight is the weight of a spread that doesn't specify
+	// one.
+	DefaultSpreadWeight = 50
+)
+
+// Spread is used to balance the allocations of a task group across the values
+// of a node attribute. Without targets, the allocations are spread evenly
+// across the values. Otherwise each target specifies the percentage of the
+// allocations desired for a value.
+type Spread struct {
+	// Attribute is the node attribute whose values the allocations are
+	// spread across.
+	Attribute string
+
+	// Weight is the weight of the spread relative to the other spreads.
+	Weight int
+
+	// SpreadTarget is the desired percentage of allocations for each value.
+	SpreadTarget []*SpreadTarget
+}
+
+func (s *Spread) Copy() *Spread {
+	if s == nil {
+		return nil
+	}
+	ns := new(Spread)
+	*ns = *s
+	if s.SpreadTarget != nil {
+		ns.SpreadTarget = make([]*SpreadTarget, len(s.SpreadTarget))
+		for i, t := range s.SpreadTarget {
+			ns.SpreadTarget[i] = t.Copy()
+		}
+	}
+	return ns
+}
+
+func (s *Spread) String() string {
+	return fmt.Sprintf("spread %s %d", s.Attribute, s.Weight)
+}
+
+func (s *Spread) Validate() error {
+	var mErr multierror.Error
+	if s.Attribute == "" {
+		mErr.Errors = append(mErr.Errors, errors.New("Missing spread attribute"))
+	}
+	if s.Weight <= 0 || s.Weight > 100 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread weight must be between 1 and 100, got %d", s.Weight))
+	}
+
+	seen := make(map[string]struct{}, len(s.SpreadTarget))
+	var total uint32
+	for _, target := range s.SpreadTarget {
+		if _, ok := seen[target.Value]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread target value %q already defined", target.Value))
+		}
+		seen[target.Value] = struct{}{}
+
+		if target.Percent > 100 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Spread target percentage for value %q must be between 0 and 100", target.Value))
+		}
+		total += target.Percent
+	}
+	if total > 100 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Sum of spread target percentages must not exceed 100, got %d", total))
 	}
 	return mErr.ErrorOrNil()
 }
 
+// SpreadTarget is the desired percentage of the allocations of a spread
+// placed on nodes with the given attribute value.
+type SpreadTarget struct {
+	// Value is the value of the spread attribute
+	Value string
+
+	// Percent is the desired percentage of allocations for the value
+	Percent uint32
+}
+
+func (t *SpreadTarget) Copy() *SpreadTarget {
+	if t == nil {
+		return nil
+	}
+	nt := new(SpreadTarget)
+	*nt = *t
+	return nt
+}
+
 // EphemeralDisk is an ephemeral disk object
 type EphemeralDisk struct {
 	// Sticky indicates whether the allocation is sticky to a node
@@ -3089,6 +4859,31 @@ type Allocation struct {
 	// PreviousAllocation is the allocation that this allocation is replacing
 	PreviousAllocation string
 
+	// DeploymentID is the deployment that placed the allocation, if any.
+	DeploymentID string
+
+	// Canary marks the allocation as a canary of its deployment.
+	Canary bool
+
+	// DeploymentStatus is the health of the allocation as reported by the
+	// client when its deployment derives health from its checks.
+	DeploymentStatus *AllocDeploymentStatus
+
+	// DesiredTransition is the transition the servers want the schedulers
+	// to apply to the allocation, such as migrating it off a draining node.
+	DesiredTransition DesiredTransition
+
+	// RescheduleTracker records the reschedules of the failed allocations
+	// this allocation replaces.
+	RescheduleTracker *RescheduleTracker
+
+	// PreemptedAllocations are the allocations that were preempted to make
+	// room for this allocation.
+	PreemptedAllocations []string
+
+	// PreemptedByAllocation is the allocation that preempted this one.
+	PreemptedByAllocation string
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -3130,10 +4925,147 @@ func (a *Allocation) Copy() *Allocation {
 		}
 		na.TaskStates = ts
 	}
+
+	na.DeploymentStatus = na.DeploymentStatus.Copy()
+	na.RescheduleTracker = na.RescheduleTracker.Copy()
+	na.PreemptedAllocations = CopySliceString(na.PreemptedAllocations)
 	return na
 }
 
-// TerminalStatus returns if the desired or actual status is terminal and
+// AllocDeploymentStatus is the health of an allocation placed by a
+// deployment as determined by the client.
+type AllocDeploymentStatus struct {
+	// Healthy is whether the allocation is healthy. It is nil until the
+	// client determined the health of the allocation.
+	Healthy *bool
+
+	// Timestamp is when the health was determined.
+	Timestamp time.Time
+}
+
+// IsHealthy returns if the allocation was marked healthy.
+func (a *AllocDeploymentStatus) IsHealthy() bool {
+	return a != nil && a.Healthy != nil && *a.Healthy
+}
+
+// IsUnhealthy returns if the allocation was marked unhealthy.
+func (a *AllocDeploymentStatus) IsUnhealthy() bool {
+	return a != nil && a.Healthy != nil && !*a.Healthy
+}
+
+func (a *AllocDeploymentStatus) Copy() *AllocDeploymentStatus {
+	if a == nil {
+		return nil
+	}
+	na := new(AllocDeploymentStatus)
+	*na = *a
+	if a.Healthy != nil {
+		healthy := *a.Healthy
+		na.Healthy = &healthy
+	}
+	return na
+}
+
+// RescheduleTracker tracks the previous reschedules of an allocation
+type RescheduleTracker struct {
+	Events []*RescheduleEvent
+}
+
+func (rt *RescheduleTracker) Copy() *RescheduleTracker {
+	if rt == nil {
+		return nil
+	}
+	nt := &RescheduleTracker{
+		Events: make([]*RescheduleEvent, len(rt.Events)),
+	}
+	for i, e := range rt.Events {
+		ne := *e
+		nt.Events[i] = &ne
+	}
+	return nt
+}
+
+// RescheduleEvent records the reschedule of a failed allocation
+type RescheduleEvent struct {
+	// RescheduleTime is the time of the reschedule in nanoseconds.
+	RescheduleTime int64
+
+	// PrevAllocID is the ID of the failed allocation.
+	PrevAllocID string
+
+	// PrevNodeID is the node of the failed allocation.
+	PrevNodeID string
+}
+
+// FailTime returns the time the allocation failed, approximated by the time
+// of the last event of its tasks. The zero time is returned if no task
+// event has been recorded.
+func (a *Allocation) FailTime() time.Time {
+	var last int64
+	for _, state := range a.TaskStates {
+		for _, e := range state.Events {
+			if e.Time > last {
+				last = e.Time
+			}
+		}
+	}
+	if last == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, last)
+}
+
+// RescheduleEligible returns whether the failed allocation can be replaced
+// according to the reschedule policy, counting the reschedules that led to
+// it within the interval of the policy.
+func (a *Allocation) RescheduleEligible(policy *ReschedulePolicy, now time.Time) bool {
+	if policy == nil || a.ClientStatus != AllocClientStatusFailed ||
+		a.DesiredStatus != AllocDesiredStatusRun {
+		return false
+	}
+	if policy.Unlimited {
+		return true
+	}
+	if policy.Attempts == 0 {
+		return false
+	}
+
+	attempted := 0
+	if a.RescheduleTracker != nil {
+		since := now.Add(-policy.Interval).UnixNano()
+		for _, e := range a.RescheduleTracker.Events {
+			if e.RescheduleTime > since {
+				attempted++
+			}
+		}
+	}
+	return attempted < policy.Attempts
+}
+
+// NextRescheduleTime returns the time at which the failed allocation is
+// due to be replaced according to the delay of the reschedule policy.
+func (a *Allocation) NextRescheduleTime(policy *ReschedulePolicy) time.Time {
+	failed := a.FailTime()
+	if failed.IsZero() {
+		return failed
+	}
+	return failed.Add(policy.Delay)
+}
+
+// DesiredTransition is used to mark an allocation as having a desired state
+// transition. It is set by the servers and acted upon by the schedulers.
+type DesiredTransition struct {
+	// Migrate is used to indicate that the allocation should be migrated to
+	// another node.
+	Migrate bool
+}
+
+// ShouldMigrate returns whether the allocation should be migrated
+func (d DesiredTransition) ShouldMigrate() bool {
+	return d.Migrate
+}
+
+// TerminalStatus returns if the desired or actual status is terminal and
 // will no longer transition.
 func (a *Allocation) TerminalStatus() bool {
 	// First check the desired state and if that isn't terminal, check client
@@ -3182,20 +5114,23 @@ func (a *Allocation) RanSuccessfully() bool {
 // Stub returns a list stub for the allocation
 func (a *Allocation) Stub() *AllocListStub {
 	return &AllocListStub{
-		ID:                 a.ID,
-		EvalID:             a.EvalID,
-		Name:               a.Name,
-		NodeID:             a.NodeID,
-		JobID:              a.JobID,
-		TaskGroup:          a.TaskGroup,
-		DesiredStatus:      a.DesiredStatus,
-		DesiredDescription: a.DesiredDescription,
-		ClientStatus:       a.ClientStatus,
-		ClientDescription:  a.ClientDescription,
-		TaskStates:         a.TaskStates,
-		CreateIndex:        a.CreateIndex,
-		ModifyIndex:        a.ModifyIndex,
-		CreateTime:         a.CreateTime,
+		ID:                    a.ID,
+		EvalID:                a.EvalID,
+		Name:                  a.Name,
+		NodeID:                a.NodeID,
+		JobID:                 a.JobID,
+		TaskGroup:             a.TaskGroup,
+		DeploymentID:          a.DeploymentID,
+		DesiredStatus:         a.DesiredStatus,
+		DesiredDescription:    a.DesiredDescription,
+		ClientStatus:          a.ClientStatus,
+		ClientDescription:     a.ClientDescription,
+		TaskStates:            a.TaskStates,
+		PreemptedAllocations:  a.PreemptedAllocations,
+		PreemptedByAllocation: a.PreemptedByAllocation,
+		CreateIndex:           a.CreateIndex,
+		ModifyIndex:           a.ModifyIndex,
+		CreateTime:            a.CreateTime,
 	}
 }
 
@@ -3222,6 +5157,32 @@ func (a *Allocation) ShouldMigrate() bool {
 	return true
 }
 
+// GenerateMigrateToken returns the token a node presents to the node of the
+// given previous allocation to migrate its data. The token is derived from the
+// secret of the node running the previous allocation, so that node can verify
+// it without a round trip to the servers.
+func GenerateMigrateToken(allocID, nodeSecretID string) (string, error) {
+	h := hmac.New(sha512.New, []byte(nodeSecretID))
+	if _, err := h.Write([]byte(allocID)); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// CompareMigrateToken returns whether the migrate token is valid for the
+// allocation on the node with the given secret.
+func CompareMigrateToken(allocID, nodeSecretID, otherMigrateToken string) bool {
+	h := hmac.New(sha512.New, []byte(nodeSecretID))
+	if _, err := h.Write([]byte(allocID)); err != nil {
+		return false
+	}
+	otherBytes, err := base64.URLEncoding.DecodeString(otherMigrateToken)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(otherBytes, h.Sum(nil))
+}
+
 var (
 	// AllocationIndexRegex is a regular expression to find the allocation index.
 	AllocationIndexRegex = regexp.MustCompile(".+\\[(\\d+)\\]$")
@@ -3245,20 +5206,23 @@ func (a *Allocation) Index() int {
 
 // AllocListStub is used to return a subset of alloc information
 type AllocListStub struct {
-	ID                 string
-	EvalID             string
-	Name               string
-	NodeID             string
-	JobID              string
-	TaskGroup          string
-	DesiredStatus      string
-	DesiredDescription string
-	ClientStatus       string
-	ClientDescription  string
-	TaskStates         map[string]*TaskState
-	CreateIndex        uint64
-	ModifyIndex        uint64
-	CreateTime         int64
+	ID                    string
+	EvalID                string
+	Name                  string
+	NodeID                string
+	JobID                 string
+	TaskGroup             string
+	DeploymentID          string
+	DesiredStatus         string
+	DesiredDescription    string
+	ClientStatus          string
+	ClientDescription     string
+	TaskStates            map[string]*TaskState
+	PreemptedAllocations  []string
+	PreemptedByAllocation string
+	CreateIndex           uint64
+	ModifyIndex           uint64
+	CreateTime            int64
 }
 
 // AllocMetric is used to track various metrics while attempting
@@ -3290,6 +5254,10 @@ type AllocMetric struct {
 	// DimensionExhausted provides the count by dimension or reason
 	DimensionExhausted map[string]int
 
+	// QuotaExhausted provides the exhausted dimensions of the quota attached
+	// to the namespace of the job
+	QuotaExhausted []string
+
 	// Scores is the scores of the final few nodes remaining
 	// for placement. The top score is typically selected.
 	Scores map[string]float64
@@ -3303,6 +5271,35 @@ type AllocMetric struct {
 	// This is to prevent creating many failed allocations for a
 	// single task group.
 	CoalescedFailures int
+
+	// NodeTraces holds the decision made for each node considered, keyed by
+	// node ID. It is only populated when the evaluation is traced.
+	NodeTraces map[string]*NodeTrace
+}
+
+// NodeTrace records why a node was or wasn't picked for a placement
+type NodeTrace struct {
+	// NodeName is the name of the node
+	NodeName string
+
+	// Filtered is the constraint that made the node infeasible
+	Filtered string
+
+	// Exhausted is the resource dimension the node ran out of
+	Exhausted string
+
+	// Scores is the breakdown of the scores given to the node
+	Scores map[string]float64
+}
+
+func (t *NodeTrace) Copy() *NodeTrace {
+	if t == nil {
+		return nil
+	}
+	nt := new(NodeTrace)
+	*nt = *t
+	nt.Scores = CopyMapStringFloat64(nt.Scores)
+	return nt
 }
 
 func (a *AllocMetric) Copy() *AllocMetric {
@@ -3316,10 +5313,37 @@ func (a *AllocMetric) Copy() *AllocMetric {
 	na.ConstraintFiltered = CopyMapStringInt(na.ConstraintFiltered)
 	na.ClassExhausted = CopyMapStringInt(na.ClassExhausted)
 	na.DimensionExhausted = CopyMapStringInt(na.DimensionExhausted)
+	na.QuotaExhausted = CopySliceString(na.QuotaExhausted)
 	na.Scores = CopyMapStringFloat64(na.Scores)
+	if a.NodeTraces != nil {
+		na.NodeTraces = make(map[string]*NodeTrace, len(a.NodeTraces))
+		for nodeID, trace := range a.NodeTraces {
+			na.NodeTraces[nodeID] = trace.Copy()
+		}
+	}
 	return na
 }
 
+// EnableTrace makes the metric record the decision made for each node
+func (a *AllocMetric) EnableTrace() {
+	if a.NodeTraces == nil {
+		a.NodeTraces = make(map[string]*NodeTrace)
+	}
+}
+
+// nodeTrace returns the trace of the node, or nil if tracing is disabled
+func (a *AllocMetric) nodeTrace(node *Node) *NodeTrace {
+	if a.NodeTraces == nil || node == nil {
+		return nil
+	}
+	trace, ok := a.NodeTraces[node.ID]
+	if !ok {
+		trace = &NodeTrace{NodeName: node.Name}
+		a.NodeTraces[node.ID] = trace
+	}
+	return trace
+}
+
 func (a *AllocMetric) EvaluateNode() {
 	a.NodesEvaluated += 1
 }
@@ -3338,6 +5362,9 @@ func (a *AllocMetric) FilterNode(node *Node, constraint string) {
 		}
 		a.ConstraintFiltered[constraint] += 1
 	}
+	if trace := a.nodeTrace(node); trace != nil {
+		trace.Filtered = constraint
+	}
 }
 
 func (a *AllocMetric) ExhaustedNode(node *Node, dimension string) {
@@ -3354,6 +5381,14 @@ func (a *AllocMetric) ExhaustedNode(node *Node, dimension string) {
 		}
 		a.DimensionExhausted[dimension] += 1
 	}
+	if trace := a.nodeTrace(node); trace != nil {
+		trace.Exhausted = dimension
+	}
+}
+
+// ExhaustQuota records the dimensions of the quota that were exhausted
+func (a *AllocMetric) ExhaustQuota(dimensions []string) {
+	a.QuotaExhausted = append(a.QuotaExhausted, dimensions...)
 }
 
 func (a *AllocMetric) ScoreNode(node *Node, name string, score float64) {
@@ -3362,6 +5397,12 @@ func (a *AllocMetric) ScoreNode(node *Node, name string, score float64) {
 	}
 	key := fmt.Sprintf("%s.%s", node.ID, name)
 	a.Scores[key] = score
+	if trace := a.nodeTrace(node); trace != nil {
+		if trace.Scores == nil {
+			trace.Scores = make(map[string]float64)
+		}
+		trace.Scores[name] = score
+	}
 }
 
 const (
@@ -3377,9 +5418,14 @@ const (
 	EvalTriggerJobDeregister = "job-deregister"
 	EvalTriggerPeriodicJob   = "periodic-job"
 	EvalTriggerNodeUpdate    = "node-update"
+	EvalTriggerNodeDrain     = "node-drain"
 	EvalTriggerScheduled     = "scheduled"
 	EvalTriggerRollingUpdate = "rolling-update"
+	EvalTriggerDeployment    = "deployment"
 	EvalTriggerMaxPlans      = "max-plan-attempts"
+	EvalTriggerAllocFailure  = "alloc-failure"
+	EvalTriggerPreemption    = "preemption"
+	EvalTriggerAllocStop     = "alloc-stop"
 )
 
 const (
@@ -3400,6 +5446,10 @@ const (
 	// the system.
 	CoreJobJobGC = "job-gc"
 
+	// CoreJobDeploymentGC is used for the garbage collection of terminal
+	// deployments which are no longer referenced by non-terminal allocations.
+	CoreJobDeploymentGC = "deployment-gc"
+
 	// CoreJobForceGC is used to force garbage collection of all GCable objects.
 	CoreJobForceGC = "force-gc"
 )
@@ -3481,6 +5531,11 @@ type Evaluation struct {
 	// during the evaluation. This should not be set during normal operations.
 	AnnotatePlan bool
 
+	// Trace triggers the scheduler to record the feasibility decision and the
+	// scores of each node it considers for a placement. It is carried over to
+	// the blocked evaluation created to place the remaining allocations.
+	Trace bool
+
 	// SnapshotIndex is the Raft index of the snapshot used to process the
 	// evaluation. As such it will only be set once it has gone through the
 	// scheduler.
@@ -3490,6 +5545,15 @@ type Evaluation struct {
 	// evaluation was processed. The map is keyed by Task Group names.
 	QueuedAllocations map[string]int
 
+	// QuotaLimitReached marks whether a quota limit was reached for the
+	// evaluation. It is set to the name of the quota.
+	QuotaLimitReached string
+
+	// LeaderACL provides the ACL token to when issuing RPCs back to the
+	// leader. This will be a valid management token as long as the leader is
+	// active. This should not ever be exposed via the API.
+	LeaderACL string
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -3577,11 +5641,12 @@ func (e *Evaluation) ShouldBlock() bool {
 // for a given Job
 func (e *Evaluation) MakePlan(j *Job) *Plan {
 	p := &Plan{
-		EvalID:         e.ID,
-		Priority:       e.Priority,
-		Job:            j,
-		NodeUpdate:     make(map[string][]*Allocation),
-		NodeAllocation: make(map[string][]*Allocation),
+		EvalID:          e.ID,
+		Priority:        e.Priority,
+		Job:             j,
+		NodeUpdate:      make(map[string][]*Allocation),
+		NodeAllocation:  make(map[string][]*Allocation),
+		NodePreemptions: make(map[string][]*Allocation),
 	}
 	if j != nil {
 		p.AllAtOnce = j.AllAtOnce
@@ -3604,6 +5669,22 @@ func (e *Evaluation) NextRollingEval(wait time.Duration) *Evaluation {
 	}
 }
 
+// RescheduleEval creates an evaluation to followup this eval once the failed
+// allocations it delayed are due to be rescheduled.
+func (e *Evaluation) RescheduleEval(wait time.Duration) *Evaluation {
+	return &Evaluation{
+		ID:             GenerateUUID(),
+		Priority:       e.Priority,
+		Type:           e.Type,
+		TriggeredBy:    EvalTriggerAllocFailure,
+		JobID:          e.JobID,
+		JobModifyIndex: e.JobModifyIndex,
+		Status:         EvalStatusPending,
+		Wait:           wait,
+		PreviousEval:   e.ID,
+	}
+}
+
 // CreateBlockedEval creates a blocked evaluation to followup this eval to place any
 // failed allocations. It takes the classes marked explicitly eligible or
 // ineligible and whether the job has escaped computed node classes.
@@ -3619,7 +5700,545 @@ func (e *Evaluation) CreateBlockedEval(classEligibility map[string]bool, escaped
 		PreviousEval:         e.ID,
 		ClassEligibility:     classEligibility,
 		EscapedComputedClass: escaped,
+		Trace:                e.Trace,
+	}
+}
+
+const (
+	DeploymentStatusRunning    = "running"
+	DeploymentStatusFailed     = "failed"
+	DeploymentStatusSuccessful = "successful"
+	DeploymentStatusCancelled  = "cancelled"
+)
+
+const (
+	DeploymentStatusDescriptionRunning           = "Deployment is running"
+	DeploymentStatusDescriptionCanaries          = "Deployment is running but requires promotion"
+	DeploymentStatusDescriptionSuccessful        = "Deployment completed successfully"
+	DeploymentStatusDescriptionFailedByUser      = "Deployment marked as failed"
+	DeploymentStatusDescriptionFailedAllocations = "Failed due to unhealthy allocations"
+	DeploymentStatusDescriptionNewerJob          = "Cancelled because job is stopped or has a newer version"
+)
+
+// DeploymentStatusDescriptionRollback is used to get the status description
+// of a deployment when rolling back to an older stable version of the job.
+func DeploymentStatusDescriptionRollback(baseDescription string, jobVersion uint64) string {
+	return fmt.Sprintf("%s - rolling back to stable job version %d", baseDescription, jobVersion)
+}
+
+// Deployment tracks the rollout of a version of a job and the health of the
+// allocations placed for it. Task groups using canaries must be promoted
+// before the remaining allocations of the group are updated.
+type Deployment struct {
+	// ID is a generated UUID for the deployment
+	ID string
+
+	// JobID is the job the deployment is created for
+	JobID string
+
+	// JobModifyIndex and JobCreateIndex identify the version of the job
+	// being deployed.
+	JobModifyIndex uint64
+	JobCreateIndex uint64
+
+	// TaskGroups is the set of task groups effected by the deployment and
+	// their current deployment status.
+	TaskGroups map[string]*DeploymentState
+
+	// Status of the deployment
+	Status string
+
+	// StatusDescription allows a human readable description of the
+	// deployment status.
+	StatusDescription string
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// NewDeployment creates a running deployment for the given job.
+func NewDeployment(job *Job) *Deployment {
+	return &Deployment{
+		ID:                GenerateUUID(),
+		JobID:             job.ID,
+		JobModifyIndex:    job.JobModifyIndex,
+		JobCreateIndex:    job.CreateIndex,
+		TaskGroups:        make(map[string]*DeploymentState, len(job.TaskGroups)),
+		Status:            DeploymentStatusRunning,
+		StatusDescription: DeploymentStatusDescriptionRunning,
+	}
+}
+
+func (d *Deployment) Copy() *Deployment {
+	if d == nil {
+		return nil
+	}
+	c := new(Deployment)
+	*c = *d
+
+	c.TaskGroups = nil
+	if d.TaskGroups != nil {
+		c.TaskGroups = make(map[string]*DeploymentState, len(d.TaskGroups))
+		for tg, s := range d.TaskGroups {
+			c.TaskGroups[tg] = s.Copy()
+		}
+	}
+	return c
+}
+
+// Active returns whether the deployment is still in progress.
+func (d *Deployment) Active() bool {
+	return d.Status == DeploymentStatusRunning
+}
+
+// Healthy returns whether every task group of the deployment has been
+// promoted and has its desired number of healthy allocations.
+func (d *Deployment) Healthy() bool {
+	for _, group := range d.TaskGroups {
+		if group.DesiredCanaries > 0 && !group.Promoted {
+			return false
+		}
+		if group.HealthyAllocs < group.DesiredTotal {
+			return false
+		}
 	}
+	return true
+}
+
+// HasAutoRevert returns whether any task group of the deployment reverts the
+// job on failure.
+func (d *Deployment) HasAutoRevert() bool {
+	for _, group := range d.TaskGroups {
+		if group.AutoRevert {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresPromotion returns whether any task group has canaries that have
+// not been promoted.
+func (d *Deployment) RequiresPromotion() bool {
+	if d == nil || !d.Active() {
+		return false
+	}
+	for _, group := range d.TaskGroups {
+		if group.DesiredCanaries > 0 && !group.Promoted {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Deployment) GoString() string {
+	return fmt.Sprintf("Deployment ID %q for job %q has status %q (%v)", d.ID, d.JobID, d.Status, d.StatusDescription)
+}
+
+// DeploymentState tracks the state of a deployment for a given task group.
+type DeploymentState struct {
+	// AutoRevert marks whether the job should be reverted if the group
+	// becomes unhealthy
+	AutoRevert bool
+
+	// Promoted marks whether the canaries have been promoted
+	Promoted bool
+
+	// DesiredCanaries is the number of canaries that should be created.
+	DesiredCanaries int
+
+	// DesiredTotal is the total number of allocations that should be created
+	// as part of the deployment.
+	DesiredTotal int
+
+	// PlacedCanaries is the set of placed canary allocations
+	PlacedCanaries []string
+
+	// PlacedAllocs is the number of allocations placed for the deployment
+	PlacedAllocs int
+
+	// HealthyAllocs is the number of running allocations of the deployed
+	// version of the job
+	HealthyAllocs int
+
+	// UnhealthyAllocs is the number of failed allocations of the deployed
+	// version of the job
+	UnhealthyAllocs int
+}
+
+func (d *DeploymentState) Copy() *DeploymentState {
+	if d == nil {
+		return nil
+	}
+	c := new(DeploymentState)
+	*c = *d
+	if d.PlacedCanaries != nil {
+		c.PlacedCanaries = make([]string, len(d.PlacedCanaries))
+		copy(c.PlacedCanaries, d.PlacedCanaries)
+	}
+	return c
+}
+
+// DeploymentStatusUpdate is used to update the status of a given deployment
+type DeploymentStatusUpdate struct {
+	// DeploymentID is the ID of the deployment to update
+	DeploymentID string
+
+	// Status is the new status of the deployment.
+	Status string
+
+	// StatusDescription is the new status description of the deployment.
+	StatusDescription string
+}
+
+// BrokerStats returns all the stats about the evaluation broker
+type BrokerStats struct {
+	TotalReady   int
+	TotalUnacked int
+	TotalBlocked int
+	TotalWaiting int
+	ByScheduler  map[string]*SchedulerStats
+}
+
+// SchedulerStats returns the stats of the evaluation broker per scheduler
+type SchedulerStats struct {
+	Ready   int
+	Unacked int
+}
+
+const (
+	// DefaultNamespace is the namespace jobs are registered in when they do
+	// not specify one. It always exists and can not be deleted.
+	DefaultNamespace = "default"
+
+	// AllNamespacesSentinel is the namespace of list queries returning the
+	// objects of all namespaces
+	AllNamespacesSentinel = "*"
+
+	// maxNamespaceDescriptionLength limits the length of the description of
+	// a namespace or quota specification
+	maxNamespaceDescriptionLength = 256
+)
+
+var (
+	// validNamespaceName is used to validate the names of namespaces and
+	// quota specifications
+	validNamespaceName = regexp.MustCompile("^[a-zA-Z0-9-]{1,128}$")
+)
+
+// Namespace allows jobs and their allocations to be segmented from other
+// jobs. The resources used by the jobs of a namespace can be limited by
+// attaching a quota specification.
+type Namespace struct {
+	// Name is the name of the namespace
+	Name string
+
+	// Description is a human readable description of the namespace
+	Description string
+
+	// Quota is the name of the quota specification attached to the
+	// namespace. An empty quota leaves the namespace unlimited.
+	Quota string
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Validate is used to sanity check a namespace
+func (n *Namespace) Validate() error {
+	var mErr multierror.Error
+	if !validNamespaceName.MatchString(n.Name) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid name %q. Must match regex %s", n.Name, validNamespaceName))
+	}
+	if len(n.Description) > maxNamespaceDescriptionLength {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("description longer than %d", maxNamespaceDescriptionLength))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the namespace
+func (n *Namespace) Copy() *Namespace {
+	if n == nil {
+		return nil
+	}
+	nn := new(Namespace)
+	*nn = *n
+	return nn
+}
+
+// QuotaSpec specifies the resources that may be used by the allocations of
+// the namespaces the specification is attached to.
+type QuotaSpec struct {
+	// Name is the name of the quota specification
+	Name string
+
+	// Description is a human readable description of the specification
+	Description string
+
+	// Limit is the limit on the resources used by the namespaces
+	Limit *QuotaLimit
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Validate is used to sanity check a quota specification
+func (q *QuotaSpec) Validate() error {
+	var mErr multierror.Error
+	if !validNamespaceName.MatchString(q.Name) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid name %q. Must match regex %s", q.Name, validNamespaceName))
+	}
+	if len(q.Description) > maxNamespaceDescriptionLength {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("description longer than %d", maxNamespaceDescriptionLength))
+	}
+	if q.Limit == nil {
+		mErr.Errors = append(mErr.Errors, errors.New("missing limit"))
+	} else if err := q.Limit.Validate(); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the quota specification
+func (q *QuotaSpec) Copy() *QuotaSpec {
+	if q == nil {
+		return nil
+	}
+	nq := new(QuotaSpec)
+	*nq = *q
+	nq.Limit = q.Limit.Copy()
+	return nq
+}
+
+// QuotaLimit is an amount of resources tracked by a quota. When used as a
+// limit, a zero value leaves the resource unlimited.
+type QuotaLimit struct {
+	// CPU is the CPU in MHz
+	CPU int
+
+	// MemoryMB is the memory in megabytes
+	MemoryMB int
+
+	// Count is the number of allocations
+	Count int
+}
+
+// Validate is used to sanity check a quota limit
+func (l *QuotaLimit) Validate() error {
+	var mErr multierror.Error
+	if l.CPU < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("cpu limit can not be negative"))
+	}
+	if l.MemoryMB < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("memory limit can not be negative"))
+	}
+	if l.Count < 0 {
+		mErr.Errors = append(mErr.Errors, errors.New("count limit can not be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the quota limit
+func (l *QuotaLimit) Copy() *QuotaLimit {
+	if l == nil {
+		return nil
+	}
+	nl := new(QuotaLimit)
+	*nl = *l
+	return nl
+}
+
+// AddAlloc adds the resources of the allocation
+func (l *QuotaLimit) AddAlloc(alloc *Allocation) {
+	l.add(alloc, 1)
+}
+
+// SubtractAlloc removes the resources of the allocation
+func (l *QuotaLimit) SubtractAlloc(alloc *Allocation) {
+	l.add(alloc, -1)
+}
+
+func (l *QuotaLimit) add(alloc *Allocation, sign int) {
+	l.Count += sign
+	if alloc.Resources != nil {
+		l.CPU += sign * alloc.Resources.CPU
+		l.MemoryMB += sign * alloc.Resources.MemoryMB
+		return
+	}
+
+	// Allocations within a plan have the combined resources stripped, so sum
+	// up the individual task resources.
+	if alloc.SharedResources != nil {
+		l.CPU += sign * alloc.SharedResources.CPU
+		l.MemoryMB += sign * alloc.SharedResources.MemoryMB
+	}
+	for _, r := range alloc.TaskResources {
+		l.CPU += sign * r.CPU
+		l.MemoryMB += sign * r.MemoryMB
+	}
+}
+
+// Exceeded returns the dimensions in which the usage exceeds the given limit
+func (l *QuotaLimit) Exceeded(limit *QuotaLimit) []string {
+	if limit == nil {
+		return nil
+	}
+
+	var exceeded []string
+	if limit.CPU > 0 && l.CPU > limit.CPU {
+		exceeded = append(exceeded, "cpu")
+	}
+	if limit.MemoryMB > 0 && l.MemoryMB > limit.MemoryMB {
+		exceeded = append(exceeded, "memory")
+	}
+	if limit.Count > 0 && l.Count > limit.Count {
+		exceeded = append(exceeded, "count")
+	}
+	return exceeded
+}
+
+// QuotaUsage is the amount of resources used by the allocations of the
+// namespaces a quota specification is attached to.
+type QuotaUsage struct {
+	// Name is the name of the quota specification
+	Name string
+
+	// Namespaces is the namespaces attached to the quota specification
+	Namespaces []string
+
+	// Used is the resources used by the non-terminal allocations of the
+	// namespaces
+	Used *QuotaLimit
+}
+
+const (
+	// ACLClientToken and ACLManagementToken are the only types of tokens
+	ACLClientToken     = "client"
+	ACLManagementToken = "management"
+
+	// maxACLPolicyDescriptionLength limits a policy description length
+	maxACLPolicyDescriptionLength = 256
+
+	// maxACLTokenNameLength limits a ACL token name length
+	maxACLTokenNameLength = 256
+)
+
+var (
+	// validPolicyName is used to validate a policy name
+	validPolicyName = regexp.MustCompile("^[a-zA-Z0-9-]{1,128}$")
+
+	// AnonymousACLToken is used no SecretID is provided, and the
+	// request is made anonymously.
+	AnonymousACLToken = &ACLToken{
+		AccessorID: "anonymous",
+		Name:       "Anonymous Token",
+		Type:       ACLClientToken,
+		Policies:   []string{"anonymous"},
+	}
+)
+
+// ACLPolicy is used to represent an ACL policy
+type ACLPolicy struct {
+	Name        string // Unique name
+	Description string // Human readable
+	Rules       string // HCL or JSON format
+	Hash        []byte
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// SetHash is used to compute and set the hash of the ACL policy
+func (c *ACLPolicy) SetHash() []byte {
+	// Write all the user set fields
+	hash := sha256.New()
+	hash.Write([]byte(c.Name))
+	hash.Write([]byte(c.Description))
+	hash.Write([]byte(c.Rules))
+
+	// Finalize the hash
+	hashVal := hash.Sum(nil)
+
+	// Set and return the hash
+	c.Hash = hashVal
+	return hashVal
+}
+
+// Validate is used to sanity check a policy. The rules are parsed by the
+// caller since the policy language lives outside of this package.
+func (a *ACLPolicy) Validate() error {
+	var mErr multierror.Error
+	if !validPolicyName.MatchString(a.Name) {
+		err := fmt.Errorf("invalid name '%s'", a.Name)
+		mErr.Errors = append(mErr.Errors, err)
+	}
+	if len(a.Description) > maxACLPolicyDescriptionLength {
+		err := fmt.Errorf("description longer than %d", maxACLPolicyDescriptionLength)
+		mErr.Errors = append(mErr.Errors, err)
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ACLToken represents a client token which is used to Authenticate
+type ACLToken struct {
+	AccessorID  string   // Public Accessor ID (UUID)
+	SecretID    string   // Secret ID, private (UUID)
+	Name        string   // Human friendly name
+	Type        string   // Client or Management
+	Policies    []string // Policies this token ties to
+	CreateTime  time.Time
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Copy returns a copy of the token
+func (a *ACLToken) Copy() *ACLToken {
+	if a == nil {
+		return nil
+	}
+	c := new(ACLToken)
+	*c = *a
+	c.Policies = CopySliceString(a.Policies)
+	return c
+}
+
+// Validate is used to sanity check a token
+func (a *ACLToken) Validate() error {
+	var mErr multierror.Error
+	if len(a.Name) > maxACLTokenNameLength {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("token name too long"))
+	}
+	switch a.Type {
+	case ACLClientToken:
+		if len(a.Policies) == 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("client token missing policies"))
+		}
+	case ACLManagementToken:
+		if len(a.Policies) != 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("management token cannot be associated with policies"))
+		}
+	default:
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("token type must be client or management"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// PolicySubset checks if a given set of policies is a subset of the token
+func (a *ACLToken) PolicySubset(policies []string) bool {
+	// Hot-path the management tokens, superset of all policies.
+	if a.Type == ACLManagementToken {
+		return true
+	}
+	associatedPolicies := make(map[string]struct{}, len(a.Policies))
+	for _, policy := range a.Policies {
+		associatedPolicies[policy] = struct{}{}
+	}
+	for _, policy := range policies {
+		if _, ok := associatedPolicies[policy]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
 // Plan is used to submit a commit plan for task allocations. These
@@ -3658,9 +6277,23 @@ type Plan struct {
 	// The evicts must be considered prior to the allocations.
 	NodeAllocation map[string][]*Allocation
 
+	// NodePreemptions contains the allocations of lower priority jobs that
+	// are evicted from each node to make room for the allocations of the
+	// plan.
+	NodePreemptions map[string][]*Allocation
+
 	// Annotations contains annotations by the scheduler to be used by operators
 	// to understand the decisions made by the scheduler.
 	Annotations *PlanAnnotations
+
+	// Deployment is the deployment created or updated by the scheduler that
+	// should be applied by the planner.
+	Deployment *Deployment
+
+	// DeploymentUpdates is a set of status updates to apply to the given
+	// deployments. This allows the scheduler to cancel any unneeded
+	// deployment because the job is stopped or the update block is removed.
+	DeploymentUpdates []*DeploymentStatusUpdate
 }
 
 // AppendUpdate marks the allocation for eviction. The clientStatus of the
@@ -3712,9 +6345,33 @@ func (p *Plan) AppendAlloc(alloc *Allocation) {
 	p.NodeAllocation[node] = append(existing, alloc)
 }
 
+// AppendPreemptedAlloc marks the allocation for eviction because it is
+// preempted by the allocation with the given ID.
+func (p *Plan) AppendPreemptedAlloc(alloc *Allocation, preemptingAllocID string) {
+	newAlloc := new(Allocation)
+	*newAlloc = *alloc
+
+	// Normalize the job and strip the resources as they are kept by the
+	// existing allocation.
+	newAlloc.Job = nil
+	newAlloc.Resources = nil
+	newAlloc.DesiredStatus = AllocDesiredStatusEvict
+	newAlloc.DesiredDescription = fmt.Sprintf("Preempted by alloc ID %v", preemptingAllocID)
+	newAlloc.PreemptedByAllocation = preemptingAllocID
+
+	if p.NodePreemptions == nil {
+		p.NodePreemptions = make(map[string][]*Allocation)
+	}
+	node := alloc.NodeID
+	existing := p.NodePreemptions[node]
+	p.NodePreemptions[node] = append(existing, newAlloc)
+}
+
 // IsNoOp checks if this plan would do nothing
 func (p *Plan) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 &&
+		len(p.NodePreemptions) == 0 &&
+		p.Deployment == nil && len(p.DeploymentUpdates) == 0
 }
 
 // PlanResult is the result of a plan submitted to the leader.
@@ -3725,6 +6382,15 @@ type PlanResult struct {
 	// NodeAllocation contains all the allocations that were committed.
 	NodeAllocation map[string][]*Allocation
 
+	// NodePreemptions contains the preempted allocations that were committed.
+	NodePreemptions map[string][]*Allocation
+
+	// Deployment is the deployment that was committed.
+	Deployment *Deployment
+
+	// DeploymentUpdates is the set of deployment updates that were committed.
+	DeploymentUpdates []*DeploymentStatusUpdate
+
 	// RefreshIndex is the index the worker should refresh state up to.
 	// This allows all evictions and allocations to be materialized.
 	// If any allocations were rejected due to stale data (node state,
@@ -3734,11 +6400,17 @@ type PlanResult struct {
 	// AllocIndex is the Raft index in which the evictions and
 	// allocations took place. This is used for the write index.
 	AllocIndex uint64
+
+	// RejectedNodes are the ready and eligible nodes the plan could not be
+	// applied to.
+	RejectedNodes []string
 }
 
 // IsNoOp checks if this plan result would do nothing
 func (p *PlanResult) IsNoOp() bool {
-	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0
+	return len(p.NodeUpdate) == 0 && len(p.NodeAllocation) == 0 &&
+		len(p.NodePreemptions) == 0 &&
+		p.Deployment == nil && len(p.DeploymentUpdates) == 0
 }
 
 // FullCommit is used to check if all the allocations in a plan
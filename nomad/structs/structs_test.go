@@ -174,6 +174,38 @@ func testJob() *Job {
 	}
 }
 
+func TestJob_Warnings(t *testing.T) {
+	j := testJob()
+	if err := j.Warnings(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	j.Type = JobTypeBatch
+	j.Update = UpdateStrategy{
+		Stagger:    10 * time.Second,
+		Canary:     1,
+		AutoRevert: true,
+	}
+	err := j.Warnings()
+	mErr := err.(*multierror.Error)
+	if len(mErr.Errors) != 2 {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[0].Error(), "stagger and max_parallel") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "canaries are ignored") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Group strategies override the one of the job
+	j.TaskGroups[0].Update = &UpdateStrategy{AutoRevert: true}
+	err = j.Warnings()
+	if err == nil || !strings.Contains(err.Error(), "auto_revert has no effect") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestJob_Copy(t *testing.T) {
 	j := testJob()
 	c := j.Copy()
@@ -446,6 +478,384 @@ func TestTaskGroup_Validate(t *testing.T) {
 	}
 }
 
+func TestTaskGroup_Validate_Volumes(t *testing.T) {
+	tg := &TaskGroup{
+		Name:          "web",
+		Count:         1,
+		EphemeralDisk: DefaultEphemeralDisk(),
+		RestartPolicy: NewRestartPolicy(JobTypeService),
+		Volumes: map[string]*VolumeRequest{
+			"certs": &VolumeRequest{Name: "certs", Type: "csi", Source: ""},
+		},
+		Tasks: []*Task{
+			&Task{
+				Name: "web",
+				VolumeMounts: []*VolumeMount{
+					&VolumeMount{Volume: "certs", Destination: "/etc/ssl/certs"},
+					&VolumeMount{Volume: "data"},
+				},
+			},
+		},
+	}
+
+	err := tg.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), `unsupported type "csi"`) {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[0].Error(), "must have a source") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "must have a destination") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[2].Error(), `mounts undefined volume "data"`) {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestTaskGroup_Validate_Lifecycle(t *testing.T) {
+	tg := &TaskGroup{
+		Name:          "web",
+		Count:         1,
+		EphemeralDisk: DefaultEphemeralDisk(),
+		RestartPolicy: NewRestartPolicy(JobTypeService),
+		Tasks: []*Task{
+			&Task{
+				Name:      "init",
+				Lifecycle: &TaskLifecycleConfig{Hook: TaskLifecycleHookPrestart},
+			},
+			&Task{
+				Name:      "cleanup",
+				Lifecycle: &TaskLifecycleConfig{Hook: TaskLifecycleHookPoststop, Sidecar: true},
+			},
+		},
+	}
+
+	err := tg.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "at least one task without a lifecycle") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(err.Error(), "poststop tasks can't be sidecars") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestTaskGroup_Validate_Networks(t *testing.T) {
+	tg := &TaskGroup{
+		Name:          "web",
+		Count:         1,
+		EphemeralDisk: DefaultEphemeralDisk(),
+		RestartPolicy: NewRestartPolicy(JobTypeService),
+		Networks: []*NetworkResource{
+			&NetworkResource{
+				Mode:          "overlay",
+				ReservedPorts: []Port{{Label: "http", Value: 80, To: 70000}},
+				DynamicPorts:  []Port{{Label: "http"}},
+			},
+			&NetworkResource{
+				Mode:         NetworkModeHost,
+				DynamicPorts: []Port{{Label: "admin", To: 8080}},
+			},
+		},
+		Tasks: []*Task{
+			&Task{Name: "web"},
+		},
+	}
+
+	err := tg.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "Only one network resource") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "invalid network mode") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "invalid mapped port") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), `port label "http" is duplicate`) {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[2].Error(), "can only be mapped in \"bridge\" mode") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// A bridge network mapping its ports is valid
+	tg.Networks = []*NetworkResource{
+		&NetworkResource{
+			Mode:          NetworkModeBridge,
+			ReservedPorts: []Port{{Label: "http", Value: 80, To: 8080}},
+			DynamicPorts:  []Port{{Label: "admin", To: 9090}},
+		},
+	}
+	if err := tg.Validate(); err != nil && strings.Contains(err.Error(), "Network") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The tasks can't request networks of their own
+	tg.Tasks[0].Resources = &Resources{
+		Networks: []*NetworkResource{{MBits: 10}},
+	}
+	err = tg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "can't request network resources") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestNetworkResource_Validate_AddressFamily(t *testing.T) {
+	n := &NetworkResource{AddressFamily: "ipx"}
+	if err := n.Validate(); err == nil || !strings.Contains(err.Error(), "invalid address family") {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The bridge only has IPv4 addresses
+	n = &NetworkResource{Mode: NetworkModeBridge, AddressFamily: NetworkAddressFamilyDual}
+	if err := n.Validate(); err == nil || !strings.Contains(err.Error(), "isn't supported") {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, family := range []string{"", NetworkAddressFamilyIPv4, NetworkAddressFamilyIPv6, NetworkAddressFamilyDual} {
+		n = &NetworkResource{AddressFamily: family}
+		if err := n.Validate(); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func TestNetworkResource_PortMap(t *testing.T) {
+	n := &NetworkResource{
+		Mode:          NetworkModeBridge,
+		ReservedPorts: []Port{{Label: "http", Value: 80, To: 8080}},
+		DynamicPorts:  []Port{{Label: "admin", Value: 25000}},
+	}
+
+	expected := map[string]int{"http": 8080, "admin": 25000}
+	if act := n.PortMap(); !reflect.DeepEqual(act, expected) {
+		t.Fatalf("bad: %#v", act)
+	}
+}
+
+func TestTaskLifecycleConfig_Validate(t *testing.T) {
+	l := &TaskLifecycleConfig{Hook: "prerun"}
+	if err := l.Validate(); err == nil || !strings.Contains(err.Error(), `invalid lifecycle hook "prerun"`) {
+		t.Fatalf("err: %v", err)
+	}
+
+	l = &TaskLifecycleConfig{}
+	if err := l.Validate(); err == nil || !strings.Contains(err.Error(), "hook must be set") {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, hook := range []string{TaskLifecycleHookPrestart, TaskLifecycleHookPoststart} {
+		l = &TaskLifecycleConfig{Hook: hook, Sidecar: true}
+		if err := l.Validate(); err != nil {
+			t.Fatalf("hook %q: %v", hook, err)
+		}
+	}
+}
+
+func TestUpdateStrategy_Validate(t *testing.T) {
+	u := &UpdateStrategy{
+		Stagger:         -1 * time.Second,
+		MaxParallel:     -1,
+		HealthCheck:     "foo",
+		HealthyDeadline: -1 * time.Second,
+	}
+
+	err := u.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "stagger can't be negative") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "max parallel can't be negative") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[2].Error(), "health check must be") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[3].Error(), "healthy deadline can't be negative") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The health check and healthy deadline default
+	u = &UpdateStrategy{}
+	if err := u.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if u.HealthChecks() || u.Deadline() != DefaultUpdateHealthyDeadline {
+		t.Fatalf("bad: %#v", u)
+	}
+}
+
+func TestJob_LookupUpdateStrategy(t *testing.T) {
+	j := testJob()
+	j.Update = UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
+	}
+
+	if u := j.LookupUpdateStrategy("web"); !reflect.DeepEqual(u, &j.Update) {
+		t.Fatalf("bad: %#v", u)
+	}
+
+	override := &UpdateStrategy{
+		Stagger:     10 * time.Second,
+		MaxParallel: 1,
+	}
+	j.TaskGroups[0].Update = override
+	if u := j.LookupUpdateStrategy("web"); u != override {
+		t.Fatalf("bad: %#v", u)
+	}
+}
+
+func TestMigrateStrategy_Validate(t *testing.T) {
+	if err := DefaultMigrateStrategy().Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	m := &MigrateStrategy{
+		MaxParallel:     0,
+		HealthCheck:     "foo",
+		MinHealthyTime:  10 * time.Second,
+		HealthyDeadline: 5 * time.Second,
+	}
+
+	err := m.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "max parallel must be at least one") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "health check must be") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[2].Error(), "less than the healthy deadline") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestTaskGroup_LookupMigrateStrategy(t *testing.T) {
+	j := testJob()
+	tg := j.TaskGroups[0]
+	if m := tg.LookupMigrateStrategy(); !reflect.DeepEqual(m, DefaultMigrateStrategy()) {
+		t.Fatalf("bad: %#v", m)
+	}
+
+	// Unset fields are defaulted when canonicalizing
+	tg.Migrate = &MigrateStrategy{MaxParallel: 3}
+	j.Canonicalize()
+	expected := DefaultMigrateStrategy()
+	expected.MaxParallel = 3
+	expected.MinHealthyTime = 0
+	if m := tg.LookupMigrateStrategy(); !reflect.DeepEqual(m, expected) {
+		t.Fatalf("bad: %#v", m)
+	}
+}
+
+func TestReschedulePolicy_Validate(t *testing.T) {
+	for _, jobType := range []string{JobTypeService, JobTypeBatch} {
+		if err := NewReschedulePolicy(jobType).Validate(); err != nil {
+			t.Fatalf("%s err: %v", jobType, err)
+		}
+	}
+
+	r := &ReschedulePolicy{
+		Attempts: -1,
+		Delay:    -time.Second,
+	}
+	err := r.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "delay must be non-negative") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "attempts must be non-negative") {
+		t.Fatalf("err: %s", err)
+	}
+
+	r = &ReschedulePolicy{Attempts: 2}
+	if err := r.Validate(); err == nil || !strings.Contains(err.Error(), "interval must be positive") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestAllocation_RescheduleEligible(t *testing.T) {
+	now := time.Now()
+	policy := &ReschedulePolicy{
+		Attempts: 2,
+		Interval: time.Hour,
+		Delay:    time.Minute,
+	}
+
+	alloc := &Allocation{
+		DesiredStatus: AllocDesiredStatusRun,
+		ClientStatus:  AllocClientStatusRunning,
+	}
+	if alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("running allocation eligible")
+	}
+
+	alloc.ClientStatus = AllocClientStatusFailed
+	if !alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("failed allocation not eligible")
+	}
+	if alloc.RescheduleEligible(nil, now) {
+		t.Fatalf("allocation without policy eligible")
+	}
+
+	// Only the reschedules within the interval are counted
+	alloc.RescheduleTracker = &RescheduleTracker{
+		Events: []*RescheduleEvent{
+			{RescheduleTime: now.Add(-2 * time.Hour).UnixNano()},
+			{RescheduleTime: now.Add(-30 * time.Minute).UnixNano()},
+		},
+	}
+	if !alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("allocation with one recent reschedule not eligible")
+	}
+	alloc.RescheduleTracker.Events[0].RescheduleTime = now.Add(-10 * time.Minute).UnixNano()
+	if alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("allocation exceeding attempts eligible")
+	}
+
+	policy.Unlimited = true
+	if !alloc.RescheduleEligible(policy, now) {
+		t.Fatalf("allocation with unlimited policy not eligible")
+	}
+
+	// The next reschedule is delayed from the last task event
+	alloc.TaskStates = map[string]*TaskState{
+		"web": &TaskState{
+			Events: []*TaskEvent{
+				{Type: TaskStarted, Time: now.Add(-time.Minute).UnixNano()},
+				{Type: TaskTerminated, Time: now.UnixNano()},
+			},
+		},
+	}
+	if next := alloc.NextRescheduleTime(policy); !next.Equal(time.Unix(0, now.UnixNano()).Add(time.Minute)) {
+		t.Fatalf("bad: %v", next)
+	}
+}
+
+func TestJob_LookupReschedulePolicy(t *testing.T) {
+	j := testJob()
+	j.Type = JobTypeBatch
+	expected := NewReschedulePolicy(JobTypeBatch)
+	if r := j.LookupReschedulePolicy("web"); !reflect.DeepEqual(r, expected) {
+		t.Fatalf("bad: %#v", r)
+	}
+
+	j.TaskGroups[0].ReschedulePolicy = &ReschedulePolicy{Attempts: 3, Interval: time.Hour}
+	if r := j.LookupReschedulePolicy("web"); r != j.TaskGroups[0].ReschedulePolicy {
+		t.Fatalf("bad: %#v", r)
+	}
+
+	j.Type = JobTypeSystem
+	if r := j.LookupReschedulePolicy("missing"); r != nil {
+		t.Fatalf("bad: %#v", r)
+	}
+}
+
 func TestTask_Validate(t *testing.T) {
 	task := &Task{}
 	ephemeralDisk := DefaultEphemeralDisk()
@@ -591,6 +1001,33 @@ func TestTask_Validate_Service_Check(t *testing.T) {
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
+
+	// gRPC checks require a timeout
+	check2 := ServiceCheck{
+		Name:        "check-grpc",
+		Type:        ServiceCheckGRPC,
+		GRPCService: "db",
+		Interval:    10 * time.Second,
+	}
+	err = check2.validate()
+	if err == nil || !strings.Contains(err.Error(), "missing required value timeout") {
+		t.Fatalf("err: %v", err)
+	}
+
+	check2.Timeout = 2 * time.Second
+	if err := check2.validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !check2.RequiresPort() {
+		t.Fatalf("gRPC checks should require a port")
+	}
+
+	// The check restart policy is validated
+	check2.CheckRestart = &CheckRestart{Limit: -1}
+	err = check2.validate()
+	if err == nil || !strings.Contains(err.Error(), "limit can't be negative") {
+		t.Fatalf("err: %v", err)
+	}
 }
 
 func TestTask_Validate_LogConfig(t *testing.T) {
@@ -756,6 +1193,173 @@ func TestConstraint_Validate(t *testing.T) {
 	}
 }
 
+func TestConstraint_Validate_DistinctProperty(t *testing.T) {
+	c := &Constraint{
+		Operand: ConstraintDistinctProperty,
+	}
+	err := c.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "requires an attribute") {
+		t.Fatalf("err: %s", err)
+	}
+
+	c.LTarget = "${meta.rack}"
+	if err := c.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if limit, _ := c.DistinctPropertyLimit(); limit != 1 {
+		t.Fatalf("bad limit: %d", limit)
+	}
+
+	c.RTarget = "3"
+	if limit, err := c.DistinctPropertyLimit(); err != nil || limit != 3 {
+		t.Fatalf("bad limit: %d %v", limit, err)
+	}
+
+	// The limit must be a positive integer
+	for _, rTarget := range []string{"0", "-1", "foo"} {
+		c.RTarget = rTarget
+		err = c.Validate()
+		if err == nil || !strings.Contains(err.Error(), "must be a positive integer") {
+			t.Fatalf("limit %q: err: %v", rTarget, err)
+		}
+	}
+}
+
+func TestAffinity_Validate(t *testing.T) {
+	a := &Affinity{}
+	err := a.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "Missing affinity operand") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "weight can't be zero") {
+		t.Fatalf("err: %s", err)
+	}
+
+	a = &Affinity{
+		LTarget: "${node.datacenter}",
+		RTarget: "dc1",
+		Operand: "=",
+		Weight:  50,
+	}
+	err = a.Validate()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Weights are bounded
+	a.Weight = -101
+	err = a.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "between -100 and 100") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Perform additional regexp validation
+	a.Weight = -50
+	a.Operand = ConstraintRegex
+	a.RTarget = "(foo"
+	err = a.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "missing closing") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Distinct hosts is not a property of a single node
+	a.Operand = ConstraintDistinctHosts
+	err = a.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "not supported") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestJob_Validate_Regions(t *testing.T) {
+	job := testJob()
+	job.Regions = []string{"global", "eu"}
+	if err := job.Validate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	job.Regions = []string{"us", "", "us"}
+	err := job.Validate()
+	mErr := err.(*multierror.Error)
+	if len(mErr.Errors) != 3 {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(mErr.Errors[0].Error(), "empty region") {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), `duplicate region "us"`) {
+		t.Fatalf("err: %v", err)
+	}
+	if !strings.Contains(mErr.Errors[2].Error(), `region "global" must be one of the job regions`) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestJob_Validate_SystemAffinity(t *testing.T) {
+	job := testJob()
+	job.Type = JobTypeSystem
+	job.TaskGroups[0].Count = 1
+	job.TaskGroups[0].Affinities = []*Affinity{
+		&Affinity{
+			LTarget: "${node.datacenter}",
+			RTarget: "dc1",
+			Operand: "=",
+			Weight:  50,
+		},
+	}
+
+	err := job.Validate()
+	if err == nil || !strings.Contains(err.Error(), "System jobs may not have affinities") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestSpread_Validate(t *testing.T) {
+	s := &Spread{}
+	err := s.Validate()
+	mErr := err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "Missing spread attribute") {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(mErr.Errors[1].Error(), "weight must be between 1 and 100") {
+		t.Fatalf("err: %s", err)
+	}
+
+	s = &Spread{
+		Attribute: "${node.datacenter}",
+		Weight:    50,
+		SpreadTarget: []*SpreadTarget{
+			&SpreadTarget{Value: "dc1", Percent: 60},
+			&SpreadTarget{Value: "dc2", Percent: 40},
+		},
+	}
+	err = s.Validate()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Target values must be unique
+	s.SpreadTarget[1].Value = "dc1"
+	err = s.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "already defined") {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Target percentages can't exceed 100
+	s.SpreadTarget[1].Value = "dc2"
+	s.SpreadTarget[1].Percent = 50
+	err = s.Validate()
+	mErr = err.(*multierror.Error)
+	if !strings.Contains(mErr.Errors[0].Error(), "must not exceed 100") {
+		t.Fatalf("err: %s", err)
+	}
+}
+
 func TestResource_NetIndex(t *testing.T) {
 	r := &Resources{
 		Networks: []*NetworkResource{
@@ -813,7 +1417,7 @@ func TestResource_Add(t *testing.T) {
 			&NetworkResource{
 				CIDR:          "10.0.0.0/8",
 				MBits:         100,
-				ReservedPorts: []Port{{"ssh", 22}},
+				ReservedPorts: []Port{{"ssh", 22, 0}},
 			},
 		},
 	}
@@ -826,7 +1430,7 @@ func TestResource_Add(t *testing.T) {
 			&NetworkResource{
 				IP:            "10.0.0.1",
 				MBits:         50,
-				ReservedPorts: []Port{{"web", 80}},
+				ReservedPorts: []Port{{"web", 80, 0}},
 			},
 		},
 	}
@@ -845,7 +1449,7 @@ func TestResource_Add(t *testing.T) {
 			&NetworkResource{
 				CIDR:          "10.0.0.0/8",
 				MBits:         150,
-				ReservedPorts: []Port{{"ssh", 22}, {"web", 80}},
+				ReservedPorts: []Port{{"ssh", 22, 0}, {"web", 80, 0}},
 			},
 		},
 	}
@@ -861,7 +1465,7 @@ func TestResource_Add_Network(t *testing.T) {
 		Networks: []*NetworkResource{
 			&NetworkResource{
 				MBits:        50,
-				DynamicPorts: []Port{{"http", 0}, {"https", 0}},
+				DynamicPorts: []Port{{"http", 0, 0}, {"https", 0, 0}},
 			},
 		},
 	}
@@ -869,7 +1473,7 @@ func TestResource_Add_Network(t *testing.T) {
 		Networks: []*NetworkResource{
 			&NetworkResource{
 				MBits:        25,
-				DynamicPorts: []Port{{"admin", 0}},
+				DynamicPorts: []Port{{"admin", 0, 0}},
 			},
 		},
 	}
@@ -887,7 +1491,7 @@ func TestResource_Add_Network(t *testing.T) {
 		Networks: []*NetworkResource{
 			&NetworkResource{
 				MBits:        75,
-				DynamicPorts: []Port{{"http", 0}, {"https", 0}, {"admin", 0}},
+				DynamicPorts: []Port{{"http", 0, 0}, {"https", 0, 0}, {"admin", 0, 0}},
 			},
 		},
 	}
@@ -1193,6 +1797,52 @@ func TestPeriodicConfig_NextCron(t *testing.T) {
 	}
 }
 
+func TestParameterizedJobConfig_Validate(t *testing.T) {
+	d := &ParameterizedJobConfig{
+		Payload: "foo",
+	}
+
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "payload") {
+		t.Fatalf("Expected unknown payload requirement: %v", err)
+	}
+
+	d.Payload = DispatchPayloadOptional
+	d.MetaOptional = []string{"foo", "bar"}
+	d.MetaRequired = []string{"bar", "baz"}
+
+	if err := d.Validate(); err == nil || !strings.Contains(err.Error(), "both required and optional") {
+		t.Fatalf("Expected meta not being disjoint error: %v", err)
+	}
+}
+
+func TestParameterizedJobConfig_Validate_NonBatch(t *testing.T) {
+	job := testJob()
+	job.ParameterizedJob = &ParameterizedJobConfig{
+		Payload: DispatchPayloadOptional,
+	}
+	job.Type = JobTypeSystem
+
+	if err := job.Validate(); err == nil || !strings.Contains(err.Error(), "only be used with") {
+		t.Fatalf("Expected bad scheduler type: %v", err)
+	}
+}
+
+func TestDispatchPayloadConfig_Validate(t *testing.T) {
+	d := &DispatchPayloadConfig{
+		File: "foo",
+	}
+
+	if err := d.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// The payload can't be written outside of the task's local directory
+	d.File = "../haha"
+	if err := d.Validate(); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 func TestRestartPolicy_Validate(t *testing.T) {
 	// Policy with acceptable restart options passes
 	p := &RestartPolicy{
@@ -1344,6 +1994,30 @@ func TestAllocation_ShouldMigrate(t *testing.T) {
 	}
 }
 
+func TestMigrateToken(t *testing.T) {
+	allocID := GenerateUUID()
+	nodeSecret := GenerateUUID()
+
+	token, err := GenerateMigrateToken(allocID, nodeSecret)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !CompareMigrateToken(allocID, nodeSecret, token) {
+		t.Fatalf("expected the token to be valid")
+	}
+
+	// The token is bound to the allocation and the node
+	if CompareMigrateToken(GenerateUUID(), nodeSecret, token) {
+		t.Fatalf("expected the token to be invalid for another allocation")
+	}
+	if CompareMigrateToken(allocID, GenerateUUID(), token) {
+		t.Fatalf("expected the token to be invalid for another node")
+	}
+	if CompareMigrateToken(allocID, nodeSecret, "") {
+		t.Fatalf("expected an empty token to be invalid")
+	}
+}
+
 func TestTaskArtifact_Validate_Checksum(t *testing.T) {
 	cases := []struct {
 		Input *TaskArtifact
@@ -1444,3 +2118,39 @@ func TestVault_Validate(t *testing.T) {
 		t.Fatalf("Expected signal empty error")
 	}
 }
+
+func TestAllocMetric_Trace(t *testing.T) {
+	node := &Node{ID: "foo", Name: "bar"}
+
+	// Nothing is traced unless enabled
+	metric := new(AllocMetric)
+	metric.FilterNode(node, "constraint")
+	metric.ScoreNode(node, "binpack", 1.5)
+	if metric.NodeTraces != nil {
+		t.Fatalf("bad: %#v", metric.NodeTraces)
+	}
+
+	metric.EnableTrace()
+	metric.ExhaustedNode(node, "memory exhausted")
+	metric.ScoreNode(node, "binpack", 1.5)
+	metric.ScoreNode(node, "job-anti-affinity", -10)
+
+	expected := &NodeTrace{
+		NodeName:  "bar",
+		Exhausted: "memory exhausted",
+		Scores: map[string]float64{
+			"binpack":           1.5,
+			"job-anti-affinity": -10,
+		},
+	}
+	if trace := metric.NodeTraces["foo"]; !reflect.DeepEqual(trace, expected) {
+		t.Fatalf("bad: %#v", trace)
+	}
+
+	// The traces are deep copied
+	copied := metric.Copy()
+	copied.NodeTraces["foo"].Scores["binpack"] = 0
+	if metric.NodeTraces["foo"].Scores["binpack"] != 1.5 {
+		t.Fatalf("bad: %#v", metric.NodeTraces["foo"])
+	}
+}
@@ -0,0 +1,140 @@
+package structs
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// VolumeTypeHost is the type of volumes exposed by a client from a
+	// directory of the host
+	VolumeTypeHost = "host"
+)
+
+// ClientHostVolumeConfig is a named directory of the host that a client
+// exposes to the task groups requesting it.
+type ClientHostVolumeConfig struct {
+	Name     string
+	Path     string
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+// Copy returns a copy of the host volume config
+func (p *ClientHostVolumeConfig) Copy() *ClientHostVolumeConfig {
+	if p == nil {
+		return nil
+	}
+	c := new(ClientHostVolumeConfig)
+	*c = *p
+	return c
+}
+
+// Validate returns an error if the host volume config is invalid
+func (p *ClientHostVolumeConfig) Validate() error {
+	var mErr multierror.Error
+	if p.Name == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("host volume name must be set"))
+	}
+	if !filepath.IsAbs(p.Path) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("host volume %q path must be absolute; got %q", p.Name, p.Path))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopyMapStringClientHostVolumeConfig returns a copy of the host volumes
+func CopyMapStringClientHostVolumeConfig(m map[string]*ClientHostVolumeConfig) map[string]*ClientHostVolumeConfig {
+	if m == nil {
+		return nil
+	}
+	nm := make(map[string]*ClientHostVolumeConfig, len(m))
+	for k, v := range m {
+		nm[k] = v.Copy()
+	}
+	return nm
+}
+
+// VolumeRequest is a request by a task group for a volume. The tasks of the
+// group mount the volume using a VolumeMount.
+type VolumeRequest struct {
+	Name     string
+	Type     string
+	Source   string
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+// Copy returns a copy of the volume request
+func (v *VolumeRequest) Copy() *VolumeRequest {
+	if v == nil {
+		return nil
+	}
+	nv := new(VolumeRequest)
+	*nv = *v
+	return nv
+}
+
+// Validate returns an error if the volume request is invalid
+func (v *VolumeRequest) Validate() error {
+	var mErr multierror.Error
+	if v.Type != VolumeTypeHost {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("volume %q has unsupported type %q", v.Name, v.Type))
+	}
+	if v.Source == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("volume %q must have a source", v.Name))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopyMapVolumeRequest returns a copy of the volume requests
+func CopyMapVolumeRequest(m map[string]*VolumeRequest) map[string]*VolumeRequest {
+	if m == nil {
+		return nil
+	}
+	nm := make(map[string]*VolumeRequest, len(m))
+	for k, v := range m {
+		nm[k] = v.Copy()
+	}
+	return nm
+}
+
+// VolumeMount mounts a volume requested by the task group into the task.
+type VolumeMount struct {
+	Volume      string
+	Destination string
+	ReadOnly    bool `mapstructure:"read_only"`
+}
+
+// Copy returns a copy of the volume mount
+func (v *VolumeMount) Copy() *VolumeMount {
+	if v == nil {
+		return nil
+	}
+	nv := new(VolumeMount)
+	*nv = *v
+	return nv
+}
+
+// Validate returns an error if the volume mount is invalid
+func (v *VolumeMount) Validate() error {
+	var mErr multierror.Error
+	if v.Volume == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("volume mount must reference a volume"))
+	}
+	if v.Destination == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("volume mount of %q must have a destination", v.Volume))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// CopySliceVolumeMount returns a copy of the volume mounts
+func CopySliceVolumeMount(s []*VolumeMount) []*VolumeMount {
+	if s == nil {
+		return nil
+	}
+	ns := make([]*VolumeMount, len(s))
+	for i, v := range s {
+		ns[i] = v.Copy()
+	}
+	return ns
+}
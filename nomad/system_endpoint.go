@@ -3,6 +3,7 @@ package nomad
 import (
 	"fmt"
 
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -18,6 +19,11 @@ func (s *System) GarbageCollect(args *structs.GenericRequest, reply *structs.Gen
 		return err
 	}
 
+	// Check management level permissions
+	if err := s.srv.checkACL(args.AuthToken, (*acl.ACL).IsManagement); err != nil {
+		return err
+	}
+
 	// Get the states current index
 	snapshotIndex, err := s.srv.fsm.State().LatestIndex()
 	if err != nil {
@@ -35,6 +41,11 @@ func (s *System) ReconcileJobSummaries(args *structs.GenericRequest, reply *stru
 		return err
 	}
 
+	// Check management level permissions
+	if err := s.srv.checkACL(args.AuthToken, (*acl.ACL).IsManagement); err != nil {
+		return err
+	}
+
 	_, index, err := s.srv.raftApply(structs.ReconcileJobSummariesRequestType, args)
 	if err != nil {
 		return fmt.Errorf("reconciliation of job summaries failed: %v", err)
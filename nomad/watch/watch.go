@@ -9,16 +9,18 @@ package watch
 // multiple fields does not place a watch on multiple items. Each Item
 // describes exactly one scoped watch.
 type Item struct {
-	Alloc      string
-	AllocEval  string
-	AllocJob   string
-	AllocNode  string
-	Eval       string
-	EvalJob    string
-	Job        string
-	JobSummary string
-	Node       string
-	Table      string
+	Alloc         string
+	AllocEval     string
+	AllocJob      string
+	AllocNode     string
+	Deployment    string
+	DeploymentJob string
+	Eval          string
+	EvalJob       string
+	Job           string
+	JobSummary    string
+	Node          string
+	Table         string
 }
 
 // Items is a helper used to construct a set of watchItems. It deduplicates
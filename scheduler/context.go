@@ -68,6 +68,9 @@ type EvalContext struct {
 	logger      *log.Logger
 	metrics     *structs.AllocMetric
 	eligibility *EvalEligibility
+
+	// trace enables recording the decision made for each node in the metrics
+	trace bool
 }
 
 // NewEvalContext constructs a new EvalContext
@@ -101,8 +104,20 @@ func (e *EvalContext) SetState(s State) {
 	e.state = s
 }
 
+// SetTrace is used to enable or disable recording the decision made for each
+// node considered for a placement
+func (e *EvalContext) SetTrace(trace bool) {
+	e.trace = trace
+	if trace {
+		e.metrics.EnableTrace()
+	}
+}
+
 func (e *EvalContext) Reset() {
 	e.metrics = new(structs.AllocMetric)
+	if e.trace {
+		e.metrics.EnableTrace()
+	}
 }
 
 func (e *EvalContext) ProposedAllocs(nodeID string) ([]*structs.Allocation, error) {
@@ -118,6 +133,9 @@ func (e *EvalContext) ProposedAllocs(nodeID string) ([]*structs.Allocation, erro
 	if update := e.plan.NodeUpdate[nodeID]; len(update) > 0 {
 		proposed = structs.RemoveAllocs(existingAlloc, update)
 	}
+	if preempted := e.plan.NodePreemptions[nodeID]; len(preempted) > 0 {
+		proposed = structs.RemoveAllocs(proposed, preempted)
+	}
 
 	// We create an index of the existing allocations so that if an inplace
 	// update occurs, we do not double count and we override the old allocation.
@@ -142,11 +142,58 @@ func (c *DriverChecker) hasDrivers(option *structs.Node) bool {
 	return true
 }
 
+// HostVolumeChecker is a FeasibilityChecker which returns whether a node has
+// the host volumes requested by a task group.
+type HostVolumeChecker struct {
+	ctx     Context
+	volumes map[string]*structs.VolumeRequest
+}
+
+// NewHostVolumeChecker creates a HostVolumeChecker
+func NewHostVolumeChecker(ctx Context) *HostVolumeChecker {
+	return &HostVolumeChecker{
+		ctx: ctx,
+	}
+}
+
+// SetVolumes sets the volumes requested by the task group
+func (h *HostVolumeChecker) SetVolumes(volumes map[string]*structs.VolumeRequest) {
+	h.volumes = volumes
+}
+
+func (h *HostVolumeChecker) Feasible(option *structs.Node) bool {
+	if h.hasVolumes(option) {
+		return true
+	}
+	h.ctx.Metrics().FilterNode(option, "missing compatible host volumes")
+	return false
+}
+
+// hasVolumes is used to check if the node exposes all the host volumes
+// requested by the task group. A writable volume can't be placed on a node
+// exposing the host volume read-only.
+func (h *HostVolumeChecker) hasVolumes(option *structs.Node) bool {
+	for _, req := range h.volumes {
+		if req.Type != structs.VolumeTypeHost {
+			continue
+		}
+
+		vol, ok := option.HostVolumes[req.Source]
+		if !ok {
+			return false
+		}
+		if vol.ReadOnly && !req.ReadOnly {
+			return false
+		}
+	}
+	return true
+}
+
 // ProposedAllocConstraintIterator is a FeasibleIterator which returns nodes that
 // match constraints that are not static such as Node attributes but are
-// effected by proposed alloc placements. Examples are distinct_hosts and
-// tenancy constraints. This is used to filter on job and task group
-// constraints.
+// effected by proposed alloc placements. Examples are distinct_hosts,
+// distinct_property and tenancy constraints. This is used to filter on job and
+// task group constraints.
 type ProposedAllocConstraintIterator struct {
 	ctx    Context
 	source FeasibleIterator
@@ -157,6 +204,24 @@ type ProposedAllocConstraintIterator struct {
 	// they don't have to be calculated every time Next() is called.
 	tgDistinctHosts  bool
 	jobDistinctHosts bool
+
+	// distinctProperties are the distinct_property constraints of the job and
+	// the task group along with the usage of their property values.
+	distinctProperties []*distinctProperty
+}
+
+// distinctProperty tracks the number of allocations using each value of the
+// property of a distinct_property constraint.
+type distinctProperty struct {
+	constraint *structs.Constraint
+	limit      uint64
+
+	// jobWide is set if the constraint is specified at the job level, in which
+	// case the allocations of every task group count against the limit.
+	jobWide bool
+
+	// counts is the number of allocations using each value of the property
+	counts map[string]uint64
 }
 
 // NewProposedAllocConstraintIterator creates a ProposedAllocConstraintIterator
@@ -171,11 +236,13 @@ func NewProposedAllocConstraintIterator(ctx Context, source FeasibleIterator) *P
 func (iter *ProposedAllocConstraintIterator) SetTaskGroup(tg *structs.TaskGroup) {
 	iter.tg = tg
 	iter.tgDistinctHosts = iter.hasDistinctHostsConstraint(tg.Constraints)
+	iter.setDistinctProperties()
 }
 
 func (iter *ProposedAllocConstraintIterator) SetJob(job *structs.Job) {
 	iter.job = job
 	iter.jobDistinctHosts = iter.hasDistinctHostsConstraint(job.Constraints)
+	iter.setDistinctProperties()
 }
 
 func (iter *ProposedAllocConstraintIterator) hasDistinctHostsConstraint(constraints []*structs.Constraint) bool {
@@ -187,13 +254,81 @@ func (iter *ProposedAllocConstraintIterator) hasDistinctHostsConstraint(constrai
 	return false
 }
 
+// setDistinctProperties collects the distinct_property constraints of the job
+// and task group and counts the proposed allocations using each value of their
+// property.
+func (iter *ProposedAllocConstraintIterator) setDistinctProperties() {
+	iter.distinctProperties = nil
+	if iter.job == nil || iter.tg == nil {
+		return
+	}
+
+	add := func(constraints []*structs.Constraint, jobWide bool) {
+		for _, con := range constraints {
+			if con.Operand != structs.ConstraintDistinctProperty {
+				continue
+			}
+			limit, err := con.DistinctPropertyLimit()
+			if err != nil {
+				iter.ctx.Logger().Printf(
+					"[ERR] scheduler.dynamic-constraint: invalid constraint %q: %v", con, err)
+				continue
+			}
+			iter.distinctProperties = append(iter.distinctProperties, &distinctProperty{
+				constraint: con,
+				limit:      limit,
+				jobWide:    jobWide,
+				counts:     make(map[string]uint64),
+			})
+		}
+	}
+	add(iter.job.Constraints, true)
+	add(iter.tg.Constraints, false)
+	if len(iter.distinctProperties) == 0 {
+		return
+	}
+
+	allocs, err := proposedJobAllocs(iter.ctx, iter.job.ID)
+	if err != nil {
+		iter.ctx.Logger().Printf(
+			"[ERR] scheduler.dynamic-constraint: failed to get proposed allocations: %v", err)
+		return
+	}
+
+	nodes := make(map[string]*structs.Node)
+	for _, alloc := range allocs {
+		node, ok := nodes[alloc.NodeID]
+		if !ok {
+			node, err = iter.ctx.State().NodeByID(alloc.NodeID)
+			if err != nil {
+				iter.ctx.Logger().Printf(
+					"[ERR] scheduler.dynamic-constraint: failed to lookup node %q: %v", alloc.NodeID, err)
+				continue
+			}
+			nodes[alloc.NodeID] = node
+		}
+		if node == nil {
+			continue
+		}
+
+		for _, property := range iter.distinctProperties {
+			if !property.jobWide && alloc.TaskGroup != iter.tg.Name {
+				continue
+			}
+			if value, ok := resolveConstraintTarget(property.constraint.LTarget, node); ok {
+				property.counts[value.(string)]++
+			}
+		}
+	}
+}
+
 func (iter *ProposedAllocConstraintIterator) Next() *structs.Node {
 	for {
 		// Get the next option from the source
 		option := iter.source.Next()
 
-		// Hot-path if the option is nil or there are no distinct_hosts constraints.
-		if option == nil || !(iter.jobDistinctHosts || iter.tgDistinctHosts) {
+		// Hot-path if the option is nil or there are no distinct constraints.
+		if option == nil || !(iter.jobDistinctHosts || iter.tgDistinctHosts || len(iter.distinctProperties) != 0) {
 			return option
 		}
 
@@ -202,10 +337,31 @@ func (iter *ProposedAllocConstraintIterator) Next() *structs.Node {
 			continue
 		}
 
+		if !iter.satisfiesDistinctProperties(option) {
+			iter.ctx.Metrics().FilterNode(option, structs.ConstraintDistinctProperty)
+			continue
+		}
+
 		return option
 	}
 }
 
+// satisfiesDistinctProperties checks if the node has a value for the property
+// of every distinct_property constraint that isn't already used by the allowed
+// number of allocations.
+func (iter *ProposedAllocConstraintIterator) satisfiesDistinctProperties(option *structs.Node) bool {
+	for _, property := range iter.distinctProperties {
+		value, ok := resolveConstraintTarget(property.constraint.LTarget, option)
+		if !ok {
+			return false
+		}
+		if property.counts[value.(string)] >= property.limit {
+			return false
+		}
+	}
+	return true
+}
+
 // satisfiesDistinctHosts checks if the node satisfies a distinct_hosts
 // constraint either specified at the job level or the TaskGroup level.
 func (iter *ProposedAllocConstraintIterator) satisfiesDistinctHosts(option *structs.Node) bool {
@@ -327,7 +483,7 @@ func resolveConstraintTarget(target string, node *structs.Node) (interface{}, bo
 func checkConstraint(ctx Context, operand string, lVal, rVal interface{}) bool {
 	// Check for constraints not handled by this checker.
 	switch operand {
-	case structs.ConstraintDistinctHosts:
+	case structs.ConstraintDistinctHosts, structs.ConstraintDistinctProperty:
 		return true
 	default:
 		break
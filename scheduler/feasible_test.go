@@ -123,6 +123,68 @@ func TestDriverChecker(t *testing.T) {
 	}
 }
 
+func TestHostVolumeChecker(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	nodes[1].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"certs": &structs.ClientHostVolumeConfig{Name: "certs", Path: "/etc/ssl/certs", ReadOnly: true},
+	}
+	nodes[2].HostVolumes = map[string]*structs.ClientHostVolumeConfig{
+		"certs": &structs.ClientHostVolumeConfig{Name: "certs", Path: "/etc/ssl/certs"},
+	}
+
+	readOnly := map[string]*structs.VolumeRequest{
+		"certs": &structs.VolumeRequest{Name: "certs", Type: "host", Source: "certs", ReadOnly: true},
+	}
+	writable := map[string]*structs.VolumeRequest{
+		"certs": &structs.VolumeRequest{Name: "certs", Type: "host", Source: "certs"},
+	}
+
+	checker := NewHostVolumeChecker(ctx)
+	cases := []struct {
+		Node    *structs.Node
+		Volumes map[string]*structs.VolumeRequest
+		Result  bool
+	}{
+		{
+			Node:    nodes[0],
+			Volumes: nil,
+			Result:  true,
+		},
+		{
+			Node:    nodes[0],
+			Volumes: readOnly,
+			Result:  false,
+		},
+		{
+			Node:    nodes[1],
+			Volumes: readOnly,
+			Result:  true,
+		},
+		{
+			Node:    nodes[1],
+			Volumes: writable,
+			Result:  false,
+		},
+		{
+			Node:    nodes[2],
+			Volumes: writable,
+			Result:  true,
+		},
+	}
+
+	for i, c := range cases {
+		checker.SetVolumes(c.Volumes)
+		if act := checker.Feasible(c.Node); act != c.Result {
+			t.Fatalf("case(%d) failed: got %v; want %v", i, act, c.Result)
+		}
+	}
+}
+
 func TestConstraintChecker(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*structs.Node{
@@ -612,6 +674,136 @@ func TestProposedAllocConstraint_TaskGroupDistinctHosts(t *testing.T) {
 	}
 }
 
+func TestProposedAllocConstraint_JobDistinctProperty(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	for i, rack := range []string{"r1", "r1", "r2", "r3"} {
+		nodes[i].Meta["rack"] = rack
+	}
+	for i, node := range nodes {
+		if err := state.UpsertNode(uint64(100+i), node); err != nil {
+			t.Fatalf("failed to upsert node: %v", err)
+		}
+	}
+	static := NewStaticIterator(ctx, nodes)
+
+	// Create a job with a distinct_property constraint and two task groups.
+	tg1 := &structs.TaskGroup{Name: "bar"}
+	tg2 := &structs.TaskGroup{Name: "baz"}
+
+	job := &structs.Job{
+		ID: "foo",
+		Constraints: []*structs.Constraint{
+			{
+				Operand: structs.ConstraintDistinctProperty,
+				LTarget: "${meta.rack}",
+			},
+		},
+		TaskGroups: []*structs.TaskGroup{tg1, tg2},
+	}
+
+	// Place tg1 in the second rack and plan tg2 in the first rack.
+	alloc := mock.Alloc()
+	alloc.JobID = job.ID
+	alloc.TaskGroup = tg1.Name
+	alloc.NodeID = nodes[2].ID
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("failed to upsert allocs: %v", err)
+	}
+	plan := ctx.Plan()
+	plan.NodeAllocation[nodes[0].ID] = []*structs.Allocation{
+		&structs.Allocation{
+			TaskGroup: tg2.Name,
+			JobID:     job.ID,
+			NodeID:    nodes[0].ID,
+			ID:        structs.GenerateUUID(),
+		},
+
+		// Should be ignored as it is a different job.
+		&structs.Allocation{
+			TaskGroup: tg2.Name,
+			JobID:     "ignore 2",
+			NodeID:    nodes[0].ID,
+			ID:        structs.GenerateUUID(),
+		},
+	}
+
+	propsed := NewProposedAllocConstraintIterator(ctx, static)
+	propsed.SetTaskGroup(tg1)
+	propsed.SetJob(job)
+
+	// Only the node in the unused rack is feasible. The last node is missing
+	// the property.
+	out := collectFeasible(propsed)
+	if len(out) != 1 || out[0] != nodes[3] {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if n := ctx.Metrics().ConstraintFiltered[structs.ConstraintDistinctProperty]; n != 4 {
+		t.Fatalf("Bad: %#v", ctx.Metrics())
+	}
+}
+
+func TestProposedAllocConstraint_TaskGroupDistinctProperty_Limit(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := []*structs.Node{
+		mock.Node(),
+		mock.Node(),
+		mock.Node(),
+	}
+	for i, rack := range []string{"r1", "r1", "r2"} {
+		nodes[i].Meta["rack"] = rack
+		if err := state.UpsertNode(uint64(100+i), nodes[i]); err != nil {
+			t.Fatalf("failed to upsert node: %v", err)
+		}
+	}
+	static := NewStaticIterator(ctx, nodes)
+
+	// Create a task group allowing two allocations per rack.
+	taskGroup := &structs.TaskGroup{
+		Name: "example",
+		Constraints: []*structs.Constraint{
+			{
+				Operand: structs.ConstraintDistinctProperty,
+				LTarget: "${meta.rack}",
+				RTarget: "2",
+			},
+		},
+	}
+	job := &structs.Job{ID: "foo"}
+
+	// Place two allocations in each rack.
+	var allocs []*structs.Allocation
+	for _, node := range []*structs.Node{nodes[0], nodes[1], nodes[2], nodes[2]} {
+		alloc := mock.Alloc()
+		alloc.JobID = job.ID
+		alloc.TaskGroup = taskGroup.Name
+		alloc.NodeID = node.ID
+		allocs = append(allocs, alloc)
+	}
+	if err := state.UpsertAllocs(1000, allocs); err != nil {
+		t.Fatalf("failed to upsert allocs: %v", err)
+	}
+
+	// Stop one of the allocations of the second rack.
+	plan := ctx.Plan()
+	plan.NodeUpdate[nodes[2].ID] = []*structs.Allocation{allocs[3]}
+
+	propsed := NewProposedAllocConstraintIterator(ctx, static)
+	propsed.SetTaskGroup(taskGroup)
+	propsed.SetJob(job)
+
+	out := collectFeasible(propsed)
+	if len(out) != 1 || out[0] != nodes[2] {
+		t.Fatalf("Bad: %#v", out)
+	}
+}
+
 func collectFeasible(iter FeasibleIterator) (out []*structs.Node) {
 	for {
 		next := iter.Next()
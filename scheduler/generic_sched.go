@@ -3,6 +3,7 @@ package scheduler
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -70,11 +71,26 @@ type GenericScheduler struct {
 	stack      *GenericStack
 
 	limitReached bool
+	stagger      time.Duration
 	nextEval     *structs.Evaluation
 
+	// deployment is the deployment of the current version of the job, if
+	// any. It is only set while the deployment is tracked by this scheduler.
+	deployment *structs.Deployment
+
 	blocked        *structs.Evaluation
 	failedTGAllocs map[string]*structs.AllocMetric
 	queuedAllocs   map[string]int
+
+	// quotaReached is the name of the quota whose limit prevented
+	// placements, if any
+	quotaReached string
+
+	// followupWait is the shortest time until a failed allocation that was
+	// not replaced is due to be rescheduled. followupEval is the evaluation
+	// created to reschedule it.
+	followupWait time.Duration
+	followupEval *structs.Evaluation
 }
 
 // NewServiceScheduler is a factory function to instantiate a new service scheduler
@@ -108,7 +124,10 @@ func (s *GenericScheduler) Process(eval *structs.Evaluation) error {
 	switch eval.TriggeredBy {
 	case structs.EvalTriggerJobRegister, structs.EvalTriggerNodeUpdate,
 		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate,
-		structs.EvalTriggerPeriodicJob, structs.EvalTriggerMaxPlans:
+		structs.EvalTriggerPeriodicJob, structs.EvalTriggerMaxPlans,
+		structs.EvalTriggerDeployment, structs.EvalTriggerNodeDrain,
+		structs.EvalTriggerAllocFailure, structs.EvalTriggerAllocStop,
+		structs.EvalTriggerPreemption:
 	default:
 		desc := fmt.Sprintf("scheduler cannot handle '%s' evaluation reason",
 			eval.TriggeredBy)
@@ -168,6 +187,7 @@ func (s *GenericScheduler) createBlockedEval(planFailure bool) error {
 	}
 
 	s.blocked = s.eval.CreateBlockedEval(classEligibility, escaped)
+	s.blocked.QuotaLimitReached = s.quotaReached
 	if planFailure {
 		s.blocked.TriggeredBy = structs.EvalTriggerMaxPlans
 		s.blocked.StatusDescription = blockedEvalMaxPlanDesc
@@ -197,14 +217,27 @@ func (s *GenericScheduler) process() (bool, error) {
 	// Create a plan
 	s.plan = s.eval.MakePlan(s.job)
 
+	// Lookup the deployment of the current version of the job
+	if err := s.setDeployment(); err != nil {
+		return false, err
+	}
+
 	// Reset the failed allocations
 	s.failedTGAllocs = nil
+	s.quotaReached = ""
+	s.followupWait = 0
 
 	// Create an evaluation context
 	s.ctx = NewEvalContext(s.state, s.plan, s.logger)
+	s.ctx.SetTrace(s.eval.Trace)
 
 	// Construct the placement stack
+	_, schedConfig, err := s.state.SchedulerConfig()
+	if err != nil {
+		return false, fmt.Errorf("failed to get scheduler configuration: %v", err)
+	}
 	s.stack = NewGenericStack(s.batch, s.ctx)
+	s.stack.SetSchedulerConfig(schedConfig)
 	if s.job != nil {
 		s.stack.SetJob(s.job)
 	}
@@ -226,6 +259,17 @@ func (s *GenericScheduler) process() (bool, error) {
 		s.logger.Printf("[DEBUG] sched: %#v: failed to place all allocations, blocked eval '%s' created", s.eval, s.blocked.ID)
 	}
 
+	// If failed allocations are waiting for the delay of their reschedule
+	// policy, create an evaluation to replace them once it has passed.
+	if s.followupWait > 0 && s.followupEval == nil {
+		s.followupEval = s.eval.RescheduleEval(s.followupWait)
+		if err := s.planner.CreateEval(s.followupEval); err != nil {
+			s.logger.Printf("[ERR] sched: %#v failed to make followup eval for rescheduling: %v", s.eval, err)
+			return false, err
+		}
+		s.logger.Printf("[DEBUG] sched: %#v: failed allocations delayed, followup eval '%s' created", s.eval, s.followupEval.ID)
+	}
+
 	// If the plan is a no-op, we can bail. If AnnotatePlan is set submit the plan
 	// anyways to get the annotations.
 	if s.plan.IsNoOp() && !s.eval.AnnotatePlan {
@@ -235,7 +279,7 @@ func (s *GenericScheduler) process() (bool, error) {
 	// If the limit of placements was reached we need to create an evaluation
 	// to pickup from here after the stagger period.
 	if s.limitReached && s.nextEval == nil {
-		s.nextEval = s.eval.NextRollingEval(s.job.Update.Stagger)
+		s.nextEval = s.eval.NextRollingEval(s.stagger)
 		if err := s.planner.CreateEval(s.nextEval); err != nil {
 			s.logger.Printf("[ERR] sched: %#v failed to make next eval for rolling update: %v", s.eval, err)
 			return false, err
@@ -277,8 +321,10 @@ func (s *GenericScheduler) process() (bool, error) {
 }
 
 // filterCompleteAllocs filters allocations that are terminal and should be
-// re-placed.
+// re-placed. Failed allocations are only re-placed once their reschedule
+// policy allows it.
 func (s *GenericScheduler) filterCompleteAllocs(allocs []*structs.Allocation) ([]*structs.Allocation, map[string]*structs.Allocation) {
+	now := time.Now()
 	filter := func(a *structs.Allocation) bool {
 		if s.batch {
 			// Allocs from batch jobs should be filtered when the desired status
@@ -293,12 +339,18 @@ func (s *GenericScheduler) filterCompleteAllocs(allocs []*structs.Allocation) ([
 
 			switch a.ClientStatus {
 			case structs.AllocClientStatusFailed:
-				return true
+				return s.rescheduleNow(a, now)
 			default:
 				return false
 			}
 		}
 
+		// Filter failed allocations due to be rescheduled
+		if a.ClientStatus == structs.AllocClientStatusFailed &&
+			a.DesiredStatus == structs.AllocDesiredStatusRun {
+			return s.rescheduleNow(a, now)
+		}
+
 		// Filter terminal, non batch allocations
 		return a.TerminalStatus()
 	}
@@ -344,6 +396,30 @@ func (s *GenericScheduler) filterCompleteAllocs(allocs []*structs.Allocation) ([
 	return filtered, terminalAllocsByName
 }
 
+// rescheduleNow returns whether a failed allocation is replaced by this
+// evaluation. Allocations that exhausted the attempts of their reschedule
+// policy are left in place, while those waiting for the delay of the policy
+// are replaced by a followup evaluation.
+func (s *GenericScheduler) rescheduleNow(alloc *structs.Allocation, now time.Time) bool {
+	// The allocations of a deregistered job are never replaced
+	if s.job == nil {
+		return true
+	}
+
+	policy := s.job.LookupReschedulePolicy(alloc.TaskGroup)
+	if !alloc.RescheduleEligible(policy, now) {
+		return false
+	}
+
+	if wait := alloc.NextRescheduleTime(policy).Sub(now); wait > 0 {
+		if s.followupWait == 0 || wait < s.followupWait {
+			s.followupWait = wait
+		}
+		return false
+	}
+	return true
+}
+
 // computeJobAllocs is used to reconcile differences between the job,
 // existing allocations and node status to update the allocations.
 func (s *GenericScheduler) computeJobAllocs() error {
@@ -393,21 +469,46 @@ func (s *GenericScheduler) computeJobAllocs() error {
 		}
 	}
 
-	// Check if a rolling upgrade strategy is being used
-	limit := len(diff.update) + len(diff.migrate) + len(diff.lost)
-	if s.job != nil && s.job.Update.Rolling() {
-		limit = s.job.Update.MaxParallel
-	}
+	// Check if a rolling upgrade strategy is being used. Task groups may
+	// override the update strategy of the job so the limit is per group.
+	s.limitReached = false
+	s.stagger = 0
+	if s.job != nil {
+		for _, tg := range s.job.TaskGroups {
+			migrate := filterByTaskGroup(diff.migrate, tg.Name)
+			update := filterByTaskGroup(diff.update, tg.Name)
+			lost := filterByTaskGroup(diff.lost, tg.Name)
+
+			// Destructive updates are tracked by the deployment of the job
+			// and wait for it to be promoted if the group uses canaries.
+			strategy := s.job.LookupUpdateStrategy(tg.Name)
+			update = s.computeDeployment(diff, tg, strategy, update, allocs)
+
+			limit := len(migrate) + len(update) + len(lost)
+			if strategy.Rolling() {
+				limit = strategy.MaxParallel
+			}
 
-	// Treat migrations as an eviction and a new placement.
-	s.limitReached = evictAndPlace(s.ctx, diff, diff.migrate, allocMigrating, &limit)
+			// Treat migrations as an eviction and a new placement.
+			reached := evictAndPlace(s.ctx, diff, migrate, allocMigrating, &limit)
 
-	// Treat non in-place updates as an eviction and new placement.
-	s.limitReached = s.limitReached || evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
+			// Treat non in-place updates as an eviction and new placement.
+			reached = reached || evictAndPlace(s.ctx, diff, update, allocUpdating, &limit)
 
-	// Lost allocations should be transistioned to desired status stop and client
-	// status lost and a new placement should be made
-	s.limitReached = s.limitReached || markLostAndPlace(s.ctx, diff, diff.lost, allocLost, &limit)
+			// Lost allocations should be transistioned to desired status stop and client
+			// status lost and a new placement should be made
+			reached = reached || markLostAndPlace(s.ctx, diff, lost, allocLost, &limit)
+
+			// The next batch is evaluated after the shortest stagger of the
+			// groups that reached their limit.
+			if reached {
+				if !s.limitReached || strategy.Stagger < s.stagger {
+					s.stagger = strategy.Stagger
+				}
+				s.limitReached = true
+			}
+		}
+	}
 
 	// Nothing remaining to do if placement is not required
 	if len(diff.place) == 0 {
@@ -439,6 +540,12 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 	// Update the set of placement ndoes
 	s.stack.SetNodes(nodes)
 
+	// Track the quota attached to the namespace of the job
+	quota, err := newQuotaTracker(s.state, s.plan, s.job)
+	if err != nil {
+		return err
+	}
+
 	for _, missing := range place {
 		// Check if this task group has already failed
 		if metric, ok := s.failedTGAllocs[missing.TaskGroup.Name]; ok {
@@ -479,14 +586,50 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 				ClientStatus:  structs.AllocClientStatusPending,
 
 				SharedResources: &structs.Resources{
-					DiskMB: missing.TaskGroup.EphemeralDisk.SizeMB,
+					DiskMB:   missing.TaskGroup.EphemeralDisk.SizeMB,
+					Networks: option.SharedNetworks,
 				},
 			}
 
+			// Fail the placement if it exceeds the quota of the namespace
+			if quota != nil {
+				if exceeded := quota.exceeded(alloc); len(exceeded) != 0 {
+					if s.failedTGAllocs == nil {
+						s.failedTGAllocs = make(map[string]*structs.AllocMetric)
+					}
+					metric := s.ctx.Metrics()
+					metric.ExhaustQuota(exceeded)
+					s.failedTGAllocs[missing.TaskGroup.Name] = metric
+					s.quotaReached = quota.quota.Name
+					continue
+				}
+				quota.add(alloc)
+			}
+
 			// If the new allocation is replacing an older allocation then we
 			// set the record the older allocation id so that they are chained
 			if missing.Alloc != nil {
 				alloc.PreviousAllocation = missing.Alloc.ID
+				alloc.RescheduleTracker = s.rescheduleTracker(missing.Alloc)
+			}
+
+			// Evict the allocations preempted to make room for the new one
+			for _, preempted := range option.PreemptedAllocs {
+				s.plan.AppendPreemptedAlloc(preempted, alloc.ID)
+				alloc.PreemptedAllocations = append(alloc.PreemptedAllocations, preempted.ID)
+			}
+
+			// Associate the allocation with the deployment of its group
+			if d := s.deployment; d != nil && d.Active() {
+				if state, ok := d.TaskGroups[missing.TaskGroup.Name]; ok {
+					alloc.DeploymentID = d.ID
+					state.PlacedAllocs++
+					if missing.Canary {
+						alloc.Canary = true
+						state.PlacedCanaries = append(state.PlacedCanaries, alloc.ID)
+					}
+					s.plan.Deployment = d
+				}
 			}
 
 			s.plan.AppendAlloc(alloc)
@@ -503,6 +646,157 @@ func (s *GenericScheduler) computePlacements(place []allocTuple) error {
 	return nil
 }
 
+// rescheduleTracker returns the reschedule tracker of an allocation replacing
+// the given one. Reschedules outside of the interval of the policy are no
+// longer relevant and dropped.
+func (s *GenericScheduler) rescheduleTracker(prev *structs.Allocation) *structs.RescheduleTracker {
+	if prev.ClientStatus != structs.AllocClientStatusFailed ||
+		prev.DesiredStatus != structs.AllocDesiredStatusRun {
+		return nil
+	}
+
+	now := time.Now()
+	policy := s.job.LookupReschedulePolicy(prev.TaskGroup)
+	tracker := &structs.RescheduleTracker{}
+	if prev.RescheduleTracker != nil && policy != nil && !policy.Unlimited {
+		since := now.Add(-policy.Interval).UnixNano()
+		for _, e := range prev.RescheduleTracker.Events {
+			if e.RescheduleTime > since {
+				tracker.Events = append(tracker.Events, e)
+			}
+		}
+	}
+	tracker.Events = append(tracker.Events, &structs.RescheduleEvent{
+		RescheduleTime: now.UnixNano(),
+		PrevAllocID:    prev.ID,
+		PrevNodeID:     prev.NodeID,
+	})
+	return tracker
+}
+
+// setDeployment looks up the latest deployment of the job. A running
+// deployment of another version of the job is cancelled since it can no
+// longer make progress.
+func (s *GenericScheduler) setDeployment() error {
+	s.deployment = nil
+	if s.batch {
+		return nil
+	}
+
+	d, err := s.state.LatestDeploymentByJobID(s.eval.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get deployment for job '%s': %v", s.eval.JobID, err)
+	}
+	if d == nil {
+		return nil
+	}
+
+	current := s.job != nil &&
+		d.JobCreateIndex == s.job.CreateIndex && d.JobModifyIndex == s.job.JobModifyIndex
+	if current {
+		s.deployment = d.Copy()
+		return nil
+	}
+
+	if d.Active() {
+		s.plan.DeploymentUpdates = append(s.plan.DeploymentUpdates, &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusCancelled,
+			StatusDescription: structs.DeploymentStatusDescriptionNewerJob,
+		})
+	}
+	return nil
+}
+
+// computeDeployment tracks the destructive updates of a task group with a
+// rolling or canary update strategy in the deployment of the job and returns
+// the updates that may proceed. Groups using canaries have their canaries
+// placed and the existing allocations are left untouched until the
+// deployment is promoted.
+func (s *GenericScheduler) computeDeployment(diff *diffResult, tg *structs.TaskGroup,
+	strategy *structs.UpdateStrategy, updates []allocTuple, allocs []*structs.Allocation) []allocTuple {
+	if s.batch || (strategy.Canary == 0 && !strategy.Rolling()) || len(updates) == 0 {
+		return updates
+	}
+
+	// Create the deployment for this version of the job
+	d := s.deployment
+	if d == nil {
+		d = structs.NewDeployment(s.job)
+		s.deployment = d
+		s.plan.Deployment = d
+	}
+
+	switch d.Status {
+	case structs.DeploymentStatusSuccessful:
+		return updates
+	case structs.DeploymentStatusRunning:
+	default:
+		// The deployment was failed or cancelled so the remaining
+		// allocations are not updated.
+		return nil
+	}
+
+	state, ok := d.TaskGroups[tg.Name]
+	if !ok {
+		state = &structs.DeploymentState{
+			AutoRevert:      strategy.AutoRevert,
+			DesiredCanaries: strategy.Canary,
+			DesiredTotal:    tg.Count,
+		}
+		d.TaskGroups[tg.Name] = state
+		s.plan.Deployment = d
+	}
+
+	if state.DesiredCanaries == 0 {
+		return updates
+	}
+
+	// Index the canaries of the deployment by the name of the allocation
+	// they replace
+	canaries := make(map[string]struct{})
+	for _, alloc := range allocs {
+		if alloc.DeploymentID == d.ID && alloc.Canary && alloc.TaskGroup == tg.Name {
+			canaries[alloc.Name] = struct{}{}
+		}
+	}
+
+	if state.Promoted {
+		// Allocations already replaced by a canary only need to be stopped
+		var remaining []allocTuple
+		for _, u := range updates {
+			if _, ok := canaries[u.Name]; ok {
+				s.plan.AppendUpdate(u.Alloc, structs.AllocDesiredStatusStop, allocUpdating, "")
+				continue
+			}
+			remaining = append(remaining, u)
+		}
+		return remaining
+	}
+
+	// Place the missing canaries alongside the allocations they replace
+	for _, u := range updates {
+		if len(canaries) >= state.DesiredCanaries {
+			break
+		}
+		if _, ok := canaries[u.Name]; ok {
+			continue
+		}
+		canaries[u.Name] = struct{}{}
+		diff.place = append(diff.place, allocTuple{
+			Name:      u.Name,
+			TaskGroup: tg,
+			Canary:    true,
+		})
+	}
+
+	if d.StatusDescription != structs.DeploymentStatusDescriptionCanaries {
+		d.StatusDescription = structs.DeploymentStatusDescriptionCanaries
+		s.plan.Deployment = d
+	}
+	return nil
+}
+
 // findPreferredNode finds the preferred node for an allocation
 func (s *GenericScheduler) findPreferredNode(allocTuple *allocTuple) (node *structs.Node, err error) {
 	if allocTuple.Alloc != nil {
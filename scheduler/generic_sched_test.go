@@ -11,6 +11,109 @@ import (
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+func TestServiceSched_JobRegister_SchedulerAlgorithm(t *testing.T) {
+	for _, algorithm := range []string{structs.SchedulerAlgorithmBinpack, structs.SchedulerAlgorithmSpread} {
+		h := NewHarness(t)
+		noErr(t, h.State.SchedulerSetConfig(h.NextIndex(), &structs.SchedulerConfig{
+			SchedulerAlgorithm: algorithm,
+		}))
+
+		// Create two nodes, one of them running an allocation
+		used, empty := mock.Node(), mock.Node()
+		noErr(t, h.State.UpsertNode(h.NextIndex(), used))
+		noErr(t, h.State.UpsertNode(h.NextIndex(), empty))
+		existing := mock.Alloc()
+		existing.NodeID = used.ID
+		noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{existing}))
+
+		// Create a job with a single allocation
+		job := mock.Job()
+		job.TaskGroups[0].Count = 1
+		noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+		// Create a mock evaluation to register the job
+		eval := &structs.Evaluation{
+			ID:          structs.GenerateUUID(),
+			Priority:    job.Priority,
+			TriggeredBy: structs.EvalTriggerJobRegister,
+			JobID:       job.ID,
+		}
+
+		// Process the evaluation
+		if err := h.Process(NewServiceScheduler, eval); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Binpack places the allocation on the used node, spread on the
+		// empty one
+		expected := used.ID
+		if algorithm == structs.SchedulerAlgorithmSpread {
+			expected = empty.ID
+		}
+		out, err := h.State.AllocsByJob(job.ID)
+		noErr(t, err)
+		if len(out) != 1 || out[0].NodeID != expected {
+			t.Fatalf("bad: %s: %#v", algorithm, out)
+		}
+	}
+}
+
+func TestServiceSched_JobRegister_Trace(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a feasible node, a node filtered by the job constraint and a
+	// node without enough CPU
+	feasible := mock.Node()
+	filtered := mock.Node()
+	filtered.Attributes["kernel.name"] = "windows"
+	noErr(t, filtered.ComputeClass())
+	exhausted := mock.Node()
+	exhausted.Resources.CPU = 100
+	for _, node := range []*structs.Node{feasible, filtered, exhausted} {
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Create a job with a single allocation
+	job := mock.Job()
+	job.TaskGroups[0].Count = 1
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job with tracing enabled
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+		Trace:       true,
+	}
+
+	// Process the evaluation
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := h.State.AllocsByJob(job.ID)
+	noErr(t, err)
+	if len(out) != 1 || out[0].NodeID != feasible.ID {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	// Ensure the decision made for each node was recorded
+	traces := out[0].Metrics.NodeTraces
+	if len(traces) != 3 {
+		t.Fatalf("bad: %#v", traces)
+	}
+	if trace := traces[feasible.ID]; trace.Filtered != "" || trace.Exhausted != "" || trace.Scores["binpack"] == 0 {
+		t.Fatalf("bad: %#v", trace)
+	}
+	if trace := traces[filtered.ID]; trace.Filtered == "" {
+		t.Fatalf("bad: %#v", trace)
+	}
+	if trace := traces[exhausted.ID]; trace.Exhausted != "cpu exhausted" {
+		t.Fatalf("bad: %#v", trace)
+	}
+}
+
 func TestServiceSched_JobRegister(t *testing.T) {
 	h := NewHarness(t)
 
@@ -91,6 +194,259 @@ func TestServiceSched_JobRegister(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_JobRegister_Spread(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes, most of them in the first rack
+	nodeRacks := make(map[string]string)
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		node.Meta["rack"] = "r1"
+		if i < 2 {
+			node.Meta["rack"] = "r2"
+		}
+		nodeRacks[node.ID] = node.Meta["rack"]
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Create a job spread across the racks
+	job := mock.Job()
+	job.TaskGroups[0].Count = 4
+	job.TaskGroups[0].Spreads = []*structs.Spread{
+		&structs.Spread{
+			Attribute: "${meta.rack}",
+			Weight:    100,
+		},
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the allocations are balanced across the racks
+	racks := make(map[string]int)
+	for nodeID, allocList := range plan.NodeAllocation {
+		racks[nodeRacks[nodeID]] += len(allocList)
+	}
+	if racks["r1"] != 2 || racks["r2"] != 2 {
+		t.Fatalf("bad: %#v", racks)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobRegister_DistinctProperty(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes spread across three racks
+	nodeRacks := make(map[string]string)
+	for i := 0; i < 9; i++ {
+		node := mock.Node()
+		node.Meta["rack"] = fmt.Sprintf("r%d", i%3)
+		nodeRacks[node.ID] = node.Meta["rack"]
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Create a job placing a single allocation per rack
+	job := mock.Job()
+	job.TaskGroups[0].Count = 4
+	job.Constraints = append(job.Constraints, &structs.Constraint{
+		Operand: structs.ConstraintDistinctProperty,
+		LTarget: "${meta.rack}",
+	})
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure a single allocation was placed in each rack
+	racks := make(map[string]int)
+	for nodeID, allocList := range plan.NodeAllocation {
+		racks[nodeRacks[nodeID]] += len(allocList)
+	}
+	if len(racks) != 3 {
+		t.Fatalf("bad: %#v", racks)
+	}
+	for rack, count := range racks {
+		if count != 1 {
+			t.Fatalf("rack %q has %d allocations", rack, count)
+		}
+	}
+
+	// Ensure the remaining allocation failed to be placed
+	outEval := h.Evals[0]
+	metrics, ok := outEval.FailedTGAllocs[job.TaskGroups[0].Name]
+	if !ok {
+		t.Fatalf("no failed metrics: %#v", outEval.FailedTGAllocs)
+	}
+	if metrics.ConstraintFiltered[structs.ConstraintDistinctProperty] == 0 {
+		t.Fatalf("bad: %#v", metrics)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobRegister_Preemption(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a low priority job which consumes most of the node resources
+	lowJob := mock.Job()
+	lowJob.Priority = 20
+	lowJob.TaskGroups[0].Count = 1
+	lowJob.TaskGroups[0].Tasks[0].Resources.CPU = 3600
+	noErr(t, h.State.UpsertJob(h.NextIndex(), lowJob))
+
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    lowJob.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       lowJob.ID,
+	}
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	lowAllocs, err := h.State.AllocsByJob(lowJob.ID)
+	noErr(t, err)
+	if len(lowAllocs) != 1 {
+		t.Fatalf("bad: %#v", lowAllocs)
+	}
+
+	// Create a high priority job which does not fit along side it
+	job := mock.Job()
+	job.Priority = 70
+	job.TaskGroups[0].Count = 1
+	job.TaskGroups[0].Tasks[0].Resources.CPU = 3600
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	eval = &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the plan preempted the allocation of the low priority job
+	if len(h.Plans) != 2 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[1]
+	preempted := plan.NodePreemptions[node.ID]
+	if len(preempted) != 1 || preempted[0].ID != lowAllocs[0].ID {
+		t.Fatalf("bad: %#v", plan.NodePreemptions)
+	}
+	placed := plan.NodeAllocation[node.ID]
+	if len(placed) != 1 {
+		t.Fatalf("bad: %#v", plan.NodeAllocation)
+	}
+	if p := placed[0].PreemptedAllocations; len(p) != 1 || p[0] != lowAllocs[0].ID {
+		t.Fatalf("bad: %#v", p)
+	}
+
+	// Ensure the preempted allocation was evicted
+	out, err := h.State.AllocByID(lowAllocs[0].ID)
+	noErr(t, err)
+	if out.DesiredStatus != structs.AllocDesiredStatusEvict {
+		t.Fatalf("bad: %#v", out)
+	}
+	if out.PreemptedByAllocation != placed[0].ID {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	if len(h.Evals) != 2 || h.Evals[1].Status != structs.EvalStatusComplete {
+		t.Fatalf("bad: %#v", h.Evals)
+	}
+}
+
+func TestServiceSched_PreemptionEval(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a job whose only allocation was preempted
+	job := mock.Job()
+	job.TaskGroups[0].Count = 1
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	alloc.DesiredStatus = structs.AllocDesiredStatusEvict
+	alloc.PreemptedByAllocation = structs.GenerateUUID()
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	// Create the evaluation the plan applier creates for preempted jobs
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerPreemption,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the preempted allocation is replaced
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	var planned []*structs.Allocation
+	for _, allocList := range h.Plans[0].NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 1 || planned[0].Name != alloc.Name {
+		t.Fatalf("bad: %#v", h.Plans[0])
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestServiceSched_JobRegister_StickyAllocs(t *testing.T) {
 	h := NewHarness(t)
 
@@ -416,23 +772,26 @@ func TestServiceSched_JobRegister_AllocFail(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
-func TestServiceSched_JobRegister_CreateBlockedEval(t *testing.T) {
+func TestServiceSched_JobRegister_QuotaExhausted(t *testing.T) {
 	h := NewHarness(t)
 
-	// Create a full node
-	node := mock.Node()
-	node.Reserved = node.Resources
-	node.ComputeClass()
-	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	// Create some nodes
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
 
-	// Create an ineligible node
-	node2 := mock.Node()
-	node2.Attributes["kernel.name"] = "windows"
-	node2.ComputeClass()
-	noErr(t, h.State.UpsertNode(h.NextIndex(), node2))
+	// Attach a quota allowing two allocations to a namespace
+	q := mock.QuotaSpec()
+	q.Limit = &structs.QuotaLimit{Count: 2}
+	noErr(t, h.State.UpsertQuotaSpecs(h.NextIndex(), []*structs.QuotaSpec{q}))
+	ns := mock.Namespace()
+	ns.Quota = q.Name
+	noErr(t, h.State.UpsertNamespaces(h.NextIndex(), []*structs.Namespace{ns}))
 
-	// Create a jobs
+	// Create a job in the namespace
 	job := mock.Job()
+	job.Namespace = ns.Name
 	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
 
 	// Create a mock evaluation to register the job
@@ -449,31 +808,100 @@ func TestServiceSched_JobRegister_CreateBlockedEval(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
-	// Ensure no plan
-	if len(h.Plans) != 0 {
+	// Ensure a single plan placing up to the quota
+	if len(h.Plans) != 1 {
 		t.Fatalf("bad: %#v", h.Plans)
 	}
-
-	// Ensure the plan has created a follow up eval.
-	if len(h.CreateEvals) != 1 {
-		t.Fatalf("bad: %#v", h.CreateEvals)
-	}
-
-	created := h.CreateEvals[0]
-	if created.Status != structs.EvalStatusBlocked {
-		t.Fatalf("bad: %#v", created)
-	}
-
-	classes := created.ClassEligibility
-	if len(classes) != 2 || !classes[node.ComputedClass] || classes[node2.ComputedClass] {
-		t.Fatalf("bad: %#v", classes)
+	var planned []*structs.Allocation
+	for _, allocList := range h.Plans[0].NodeAllocation {
+		planned = append(planned, allocList...)
 	}
-
-	if created.EscapedComputedClass {
-		t.Fatalf("bad: %#v", created)
+	if len(planned) != 2 {
+		t.Fatalf("bad: %#v", planned)
 	}
 
-	// Ensure there is a follow up eval.
+	// Ensure there is a follow up eval blocked on the quota
+	if len(h.CreateEvals) != 1 || h.CreateEvals[0].Status != structs.EvalStatusBlocked {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+	if h.CreateEvals[0].QuotaLimitReached != q.Name {
+		t.Fatalf("bad: %#v", h.CreateEvals[0])
+	}
+
+	// Ensure the remaining placements failed on the quota
+	outEval := h.Evals[0]
+	metrics, ok := outEval.FailedTGAllocs[job.TaskGroups[0].Name]
+	if !ok {
+		t.Fatalf("no failed metrics: %#v", outEval.FailedTGAllocs)
+	}
+	if !reflect.DeepEqual(metrics.QuotaExhausted, []string{"count"}) {
+		t.Fatalf("bad: %#v", metrics)
+	}
+	if metrics.CoalescedFailures != 7 {
+		t.Fatalf("bad: %#v", metrics)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobRegister_CreateBlockedEval(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a full node
+	node := mock.Node()
+	node.Reserved = node.Resources
+	node.ComputeClass()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create an ineligible node
+	node2 := mock.Node()
+	node2.Attributes["kernel.name"] = "windows"
+	node2.ComputeClass()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node2))
+
+	// Create a jobs
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create a mock evaluation to register the job
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure no plan
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+
+	// Ensure the plan has created a follow up eval.
+	if len(h.CreateEvals) != 1 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+
+	created := h.CreateEvals[0]
+	if created.Status != structs.EvalStatusBlocked {
+		t.Fatalf("bad: %#v", created)
+	}
+
+	classes := created.ClassEligibility
+	if len(classes) != 2 || !classes[node.ComputedClass] || classes[node2.ComputedClass] {
+		t.Fatalf("bad: %#v", classes)
+	}
+
+	if created.EscapedComputedClass {
+		t.Fatalf("bad: %#v", created)
+	}
+
+	// Ensure there is a follow up eval.
 	if len(h.CreateEvals) != 1 || h.CreateEvals[0].Status != structs.EvalStatusBlocked {
 		t.Fatalf("bad: %#v", h.CreateEvals)
 	}
@@ -1048,66 +1476,417 @@ func TestServiceSched_JobModify_CountZero(t *testing.T) {
 		alloc.DesiredStatus = structs.AllocDesiredStatusStop
 		terminal = append(terminal, alloc)
 	}
-	noErr(t, h.State.UpsertAllocs(h.NextIndex(), terminal))
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), terminal))
+
+	// Update the job to be count zero
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.TaskGroups[0].Count = 0
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a mock evaluation to deal with drain
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan evicted all allocs
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != len(allocs) {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the plan didn't allocated
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Lookup the allocations by JobID
+	out, err := h.State.AllocsByJob(job.ID)
+	noErr(t, err)
+
+	// Ensure all allocations placed
+	out, _ = structs.FilterTerminalAllocs(out)
+	if len(out) != 0 {
+		t.Fatalf("bad: %#v", out)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestServiceSched_JobModify_Rolling(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
+	}
+
+	// Update the task, such that it cannot be done in-place
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a mock evaluation to deal with drain
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan evicted only MaxParallel
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != job2.Update.MaxParallel {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the plan allocated
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != job2.Update.MaxParallel {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+
+	// Ensure a follow up eval was created
+	eval = h.Evals[0]
+	if eval.NextEval == "" {
+		t.Fatalf("missing next eval")
+	}
+
+	// Check for create
+	if len(h.CreateEvals) == 0 {
+		t.Fatalf("missing created eval")
+	}
+	create := h.CreateEvals[0]
+	if eval.NextEval != create.ID {
+		t.Fatalf("ID mismatch")
+	}
+	if create.PreviousEval != eval.ID {
+		t.Fatalf("missing previous eval")
+	}
+
+	if create.TriggeredBy != structs.EvalTriggerRollingUpdate {
+		t.Fatalf("bad: %#v", create)
+	}
+}
+
+func TestServiceSched_JobModify_Rolling_TaskGroup(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job, overriding the update strategy for the task group
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
+	}
+	job2.TaskGroups[0].Update = &structs.UpdateStrategy{
+		Stagger:     10 * time.Second,
+		MaxParallel: 2,
+	}
+
+	// Update the task, such that it cannot be done in-place
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a mock evaluation to deal with the update
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan evicted only the task group's MaxParallel
+	var update []*structs.Allocation
+	for _, updateList := range plan.NodeUpdate {
+		update = append(update, updateList...)
+	}
+	if len(update) != 2 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the plan allocated
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 2 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+
+	// Ensure the follow up eval uses the task group's stagger
+	if len(h.CreateEvals) == 0 {
+		t.Fatalf("missing created eval")
+	}
+	create := h.CreateEvals[0]
+	if create.TriggeredBy != structs.EvalTriggerRollingUpdate {
+		t.Fatalf("bad: %#v", create)
+	}
+	if create.Wait != 10*time.Second {
+		t.Fatalf("bad: %#v", create)
+	}
+}
+
+func TestServiceSched_JobModify_Canaries(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create some nodes
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		node := mock.Node()
+		nodes = append(nodes, node)
+		noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+	}
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 10; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[i].ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	// Update the job with a canary update strategy
+	job2 := mock.Job()
+	job2.ID = job.ID
+	job2.Update = structs.UpdateStrategy{
+		Stagger:     30 * time.Second,
+		MaxParallel: 5,
+		Canary:      2,
+	}
+
+	// Update the task, such that it cannot be done in-place
+	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+
+	// Create a mock evaluation to deal with the update
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the plan didn't stop any existing allocation
+	if len(plan.NodeUpdate) != 0 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the plan placed only the canaries
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 2 {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	// Ensure the deployment was created and tracks the canaries
+	d := plan.Deployment
+	if d == nil {
+		t.Fatalf("missing deployment")
+	}
+	state, ok := d.TaskGroups["web"]
+	if !ok {
+		t.Fatalf("bad: %#v", d)
+	}
+	if state.DesiredCanaries != 2 || state.Promoted || len(state.PlacedCanaries) != 2 {
+		t.Fatalf("bad: %#v", state)
+	}
+	for _, alloc := range planned {
+		if !alloc.Canary || alloc.DeploymentID != d.ID {
+			t.Fatalf("bad: %#v", alloc)
+		}
+	}
+
+	// Ensure no follow up eval was created
+	if len(h.CreateEvals) != 0 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 
-	// Update the job to be count zero
-	job2 := mock.Job()
-	job2.ID = job.ID
-	job2.TaskGroups[0].Count = 0
-	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
+	// Processing the job again shouldn't place more canaries
+	h2 := NewHarnessWithState(t, h.State)
+	if err := h2.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(h2.Plans) != 0 {
+		t.Fatalf("bad: %#v", h2.Plans[0])
+	}
 
-	// Create a mock evaluation to deal with drain
-	eval := &structs.Evaluation{
-		ID:          structs.GenerateUUID(),
-		Priority:    50,
-		TriggeredBy: structs.EvalTriggerJobRegister,
-		JobID:       job.ID,
+	// Promote the deployment and process the job again
+	req := &structs.ApplyDeploymentPromoteRequest{
+		DeploymentPromoteRequest: structs.DeploymentPromoteRequest{
+			DeploymentID: d.ID,
+			All:          true,
+		},
 	}
+	noErr(t, h.State.UpdateDeploymentPromotion(h.NextIndex(), req))
 
-	// Process the evaluation
-	err := h.Process(NewServiceScheduler, eval)
-	if err != nil {
+	h3 := NewHarnessWithState(t, h.State)
+	if err := h3.Process(NewServiceScheduler, eval); err != nil {
 		t.Fatalf("err: %v", err)
 	}
-
-	// Ensure a single plan
-	if len(h.Plans) != 1 {
-		t.Fatalf("bad: %#v", h.Plans)
+	if len(h3.Plans) != 1 {
+		t.Fatalf("bad: %#v", h3.Plans)
 	}
-	plan := h.Plans[0]
+	plan = h3.Plans[0]
 
-	// Ensure the plan evicted all allocs
+	// The allocations replaced by canaries are stopped and MaxParallel of
+	// the rest are updated
 	var update []*structs.Allocation
 	for _, updateList := range plan.NodeUpdate {
 		update = append(update, updateList...)
 	}
-	if len(update) != len(allocs) {
+	if len(update) != 7 {
 		t.Fatalf("bad: %#v", plan)
 	}
-
-	// Ensure the plan didn't allocated
-	var planned []*structs.Allocation
+	planned = nil
 	for _, allocList := range plan.NodeAllocation {
 		planned = append(planned, allocList...)
 	}
-	if len(planned) != 0 {
+	if len(planned) != 5 {
 		t.Fatalf("bad: %#v", plan)
 	}
-
-	// Lookup the allocations by JobID
-	out, err := h.State.AllocsByJob(job.ID)
-	noErr(t, err)
-
-	// Ensure all allocations placed
-	out, _ = structs.FilterTerminalAllocs(out)
-	if len(out) != 0 {
-		t.Fatalf("bad: %#v", out)
+	for _, alloc := range planned {
+		if alloc.Canary || alloc.DeploymentID != d.ID {
+			t.Fatalf("bad: %#v", alloc)
+		}
 	}
 
-	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+	// The rollout isn't complete so the deployment is still running
+	if len(plan.DeploymentUpdates) != 0 {
+		t.Fatalf("bad: %#v", plan.DeploymentUpdates)
+	}
+	if len(h3.CreateEvals) != 1 {
+		t.Fatalf("bad: %#v", h3.CreateEvals)
+	}
 }
 
-func TestServiceSched_JobModify_Rolling(t *testing.T) {
+func TestServiceSched_JobModify_Rolling_Deployment(t *testing.T) {
 	h := NewHarness(t)
 
 	// Create some nodes
@@ -1133,19 +1912,20 @@ func TestServiceSched_JobModify_Rolling(t *testing.T) {
 	}
 	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
 
-	// Update the job
+	// Update the job with a rolling update strategy
 	job2 := mock.Job()
 	job2.ID = job.ID
 	job2.Update = structs.UpdateStrategy{
 		Stagger:     30 * time.Second,
 		MaxParallel: 5,
+		AutoRevert:  true,
 	}
 
 	// Update the task, such that it cannot be done in-place
 	job2.TaskGroups[0].Tasks[0].Config["command"] = "/bin/other"
 	noErr(t, h.State.UpsertJob(h.NextIndex(), job2))
 
-	// Create a mock evaluation to deal with drain
+	// Create a mock evaluation to deal with the update
 	eval := &structs.Evaluation{
 		ID:          structs.GenerateUUID(),
 		Priority:    50,
@@ -1165,46 +1945,77 @@ func TestServiceSched_JobModify_Rolling(t *testing.T) {
 	}
 	plan := h.Plans[0]
 
-	// Ensure the plan evicted only MaxParallel
-	var update []*structs.Allocation
-	for _, updateList := range plan.NodeUpdate {
-		update = append(update, updateList...)
+	// Ensure the deployment tracks the first batch
+	d := plan.Deployment
+	if d == nil {
+		t.Fatalf("missing deployment")
 	}
-	if len(update) != job2.Update.MaxParallel {
-		t.Fatalf("bad: %#v", plan)
+	state, ok := d.TaskGroups["web"]
+	if !ok {
+		t.Fatalf("bad: %#v", d)
 	}
-
-	// Ensure the plan allocated
-	var planned []*structs.Allocation
-	for _, allocList := range plan.NodeAllocation {
-		planned = append(planned, allocList...)
+	if !state.AutoRevert || state.DesiredCanaries != 0 || state.DesiredTotal != 10 || state.PlacedAllocs != 5 {
+		t.Fatalf("bad: %#v", state)
 	}
-	if len(planned) != job2.Update.MaxParallel {
-		t.Fatalf("bad: %#v", plan)
+	for _, allocList := range plan.NodeAllocation {
+		for _, alloc := range allocList {
+			if alloc.Canary || alloc.DeploymentID != d.ID {
+				t.Fatalf("bad: %#v", alloc)
+			}
+		}
 	}
 
-	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+	// Fail the deployment
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), nil, []*structs.DeploymentStatusUpdate{
+		{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusFailed,
+			StatusDescription: structs.DeploymentStatusDescriptionFailedAllocations,
+		},
+	}))
 
-	// Ensure a follow up eval was created
-	eval = h.Evals[0]
-	if eval.NextEval == "" {
-		t.Fatalf("missing next eval")
+	// The next batch of the rollout is held
+	h2 := NewHarnessWithState(t, h.State)
+	if err := h2.Process(NewServiceScheduler, h.CreateEvals[0]); err != nil {
+		t.Fatalf("err: %v", err)
 	}
-
-	// Check for create
-	if len(h.CreateEvals) == 0 {
-		t.Fatalf("missing created eval")
+	if len(h2.Plans) != 0 {
+		t.Fatalf("bad: %#v", h2.Plans[0])
 	}
-	create := h.CreateEvals[0]
-	if eval.NextEval != create.ID {
-		t.Fatalf("ID mismatch")
+}
+
+func TestServiceSched_JobModify_CancelDeployment(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a job with a running deployment of an older version
+	job := mock.Job()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	d := mock.Deployment()
+	d.JobID = job.ID
+	d.JobCreateIndex = job.CreateIndex
+	d.JobModifyIndex = job.JobModifyIndex - 1
+	noErr(t, h.State.UpsertDeployment(h.NextIndex(), d, nil))
+
+	// Create a mock evaluation
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerJobRegister,
+		JobID:       job.ID,
 	}
-	if create.PreviousEval != eval.ID {
-		t.Fatalf("missing previous eval")
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
 	}
 
-	if create.TriggeredBy != structs.EvalTriggerRollingUpdate {
-		t.Fatalf("bad: %#v", create)
+	// Ensure the deployment was cancelled
+	out, err := h.State.DeploymentByID(d.ID)
+	noErr(t, err)
+	if out.Status != structs.DeploymentStatusCancelled {
+		t.Fatalf("bad: %#v", out)
 	}
 }
 
@@ -1519,6 +2330,7 @@ func TestServiceSched_NodeDrain(t *testing.T) {
 		alloc.Job = job
 		alloc.JobID = job.ID
 		alloc.NodeID = node.ID
+		alloc.DesiredTransition.Migrate = true
 		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
 		allocs = append(allocs, alloc)
 	}
@@ -1688,6 +2500,7 @@ func TestServiceSched_NodeDrain_Queued_Allocations(t *testing.T) {
 		alloc.Job = job
 		alloc.JobID = job.ID
 		alloc.NodeID = node.ID
+		alloc.DesiredTransition.Migrate = true
 		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
 		allocs = append(allocs, alloc)
 	}
@@ -1717,6 +2530,70 @@ func TestServiceSched_NodeDrain_Queued_Allocations(t *testing.T) {
 	}
 }
 
+func TestServiceSched_AllocStop(t *testing.T) {
+	h := NewHarness(t)
+
+	// Register a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Generate a fake job with allocations
+	job := mock.Job()
+	job.TaskGroups[0].Count = 2
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 2; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = node.ID
+		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
+		allocs = append(allocs, alloc)
+	}
+
+	// Stop one of the allocations
+	allocs[0].DesiredTransition.Migrate = true
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), allocs))
+
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    50,
+		TriggeredBy: structs.EvalTriggerAllocStop,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	err := h.Process(NewServiceScheduler, eval)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure a single plan
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	plan := h.Plans[0]
+
+	// Ensure the stopped allocation is evicted and replaced
+	update := plan.NodeUpdate[node.ID]
+	if len(update) != 1 || update[0].ID != allocs[0].ID {
+		t.Fatalf("bad: %#v", plan)
+	}
+	if update[0].DesiredDescription != allocMigrating {
+		t.Fatalf("bad: %#v", update[0])
+	}
+	var planned []*structs.Allocation
+	for _, allocList := range plan.NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 1 || planned[0].Name != allocs[0].Name {
+		t.Fatalf("bad: %#v", plan)
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestServiceSched_NodeDrain_UpdateStrategy(t *testing.T) {
 	h := NewHarness(t)
 
@@ -1746,6 +2623,7 @@ func TestServiceSched_NodeDrain_UpdateStrategy(t *testing.T) {
 		alloc.Job = job
 		alloc.JobID = job.ID
 		alloc.NodeID = node.ID
+		alloc.DesiredTransition.Migrate = true
 		alloc.Name = fmt.Sprintf("my-job.web[%d]", i)
 		allocs = append(allocs, alloc)
 	}
@@ -2005,6 +2883,143 @@ func TestBatchSched_Run_FailedAlloc(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestServiceSched_Reschedule_Delayed(t *testing.T) {
+	h := NewHarness(t)
+
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a job whose failed allocations are rescheduled after a delay
+	job := mock.Job()
+	job.TaskGroups[0].Count = 1
+	job.TaskGroups[0].ReschedulePolicy = &structs.ReschedulePolicy{
+		Delay:     time.Minute,
+		Unlimited: true,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	// Create an allocation that just failed
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	alloc.ClientStatus = structs.AllocClientStatusFailed
+	alloc.TaskStates = map[string]*structs.TaskState{
+		"web": &structs.TaskState{
+			State:  structs.TaskStateDead,
+			Failed: true,
+			Events: []*structs.TaskEvent{
+				{Type: structs.TaskTerminated, ExitCode: 1, Time: time.Now().UnixNano()},
+			},
+		},
+	}
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerAllocFailure,
+		JobID:       job.ID,
+	}
+	if err := h.Process(NewServiceScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Nothing is placed until the delay has passed
+	if len(h.Plans) != 0 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+
+	// A followup evaluation replaces the allocation after the delay
+	if len(h.CreateEvals) != 1 {
+		t.Fatalf("bad: %#v", h.CreateEvals)
+	}
+	followup := h.CreateEvals[0]
+	if followup.TriggeredBy != structs.EvalTriggerAllocFailure ||
+		followup.Wait <= 0 || followup.Wait > time.Minute {
+		t.Fatalf("bad: %#v", followup)
+	}
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
+func TestBatchSched_Reschedule_Attempts(t *testing.T) {
+	h := NewHarness(t)
+
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a batch job allowing a single reschedule
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.TaskGroups[0].Count = 1
+	job.TaskGroups[0].ReschedulePolicy = &structs.ReschedulePolicy{
+		Attempts: 1,
+		Interval: time.Hour,
+	}
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	alloc.ClientStatus = structs.AllocClientStatusFailed
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerAllocFailure,
+		JobID:       job.ID,
+	}
+	if err := h.Process(NewBatchScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The failed allocation is replaced and the reschedule tracked
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	var planned []*structs.Allocation
+	for _, allocList := range h.Plans[0].NodeAllocation {
+		planned = append(planned, allocList...)
+	}
+	if len(planned) != 1 {
+		t.Fatalf("bad: %#v", planned)
+	}
+	replacement := planned[0]
+	if replacement.PreviousAllocation != alloc.ID {
+		t.Fatalf("bad: %#v", replacement)
+	}
+	tracker := replacement.RescheduleTracker
+	if tracker == nil || len(tracker.Events) != 1 || tracker.Events[0].PrevAllocID != alloc.ID {
+		t.Fatalf("bad: %#v", tracker)
+	}
+
+	// Once the replacement fails too the attempts are exhausted
+	failed, err := h.State.AllocByID(replacement.ID)
+	noErr(t, err)
+	failed = failed.Copy()
+	failed.ClientStatus = structs.AllocClientStatusFailed
+	noErr(t, h.State.UpdateAllocsFromClient(h.NextIndex(), []*structs.Allocation{failed}))
+
+	h1 := NewHarnessWithState(t, h.State)
+	eval = &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerAllocFailure,
+		JobID:       job.ID,
+	}
+	if err := h1.Process(NewBatchScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(h1.Plans) != 0 {
+		t.Fatalf("bad: %#v", h1.Plans)
+	}
+	h1.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestBatchSched_Run_FailedAllocQueuedAllocations(t *testing.T) {
 	h := NewHarness(t)
 
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"sort"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// preemptionPriorityDelta is the minimum difference between the priority
+	// of a job and the priority of the jobs whose allocations it may preempt.
+	preemptionPriorityDelta = 10
+
+	// preemptionPenalty is the penalty applied to the score of a node for
+	// each allocation preempted to fit the allocation on it. It makes nodes
+	// that do not require preemption preferable.
+	preemptionPenalty = 10.0
+)
+
+// preemptibleAllocs returns the allocations of the proposed allocations that
+// belong to jobs whose priority is low enough to be preempted by a job of the
+// given priority. The allocations are ordered by the priority of their job
+// and then from the most recently created one so that the allocations that
+// did the least work are preempted first.
+func preemptibleAllocs(proposed []*structs.Allocation, priority int) []*structs.Allocation {
+	var allocs []*structs.Allocation
+	for _, alloc := range proposed {
+		// Allocations placed by the plan have their job stripped and are
+		// never preempted
+		if alloc.Job == nil || alloc.Job.Priority > priority-preemptionPriorityDelta {
+			continue
+		}
+		allocs = append(allocs, alloc)
+	}
+
+	sort.Sort(preemptionOrder(allocs))
+	return allocs
+}
+
+// preemptionOrder sorts allocations in the order they are preempted
+type preemptionOrder []*structs.Allocation
+
+func (p preemptionOrder) Len() int {
+	return len(p)
+}
+
+func (p preemptionOrder) Less(i, j int) bool {
+	if p[i].Job.Priority != p[j].Job.Priority {
+		return p[i].Job.Priority < p[j].Job.Priority
+	}
+	return p[i].CreateIndex > p[j].CreateIndex
+}
+
+func (p preemptionOrder) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// preemptForAsk returns the allocations to preempt so that the ask fits on
+// the node along with the remaining proposed allocations and the resulting
+// utilization of the node. Nil is returned if preempting every allocation of
+// a lower priority job does not free enough resources.
+func preemptForAsk(node *structs.Node, proposed []*structs.Allocation, ask *structs.Allocation,
+	netIdx *structs.NetworkIndex, priority int) ([]*structs.Allocation, *structs.Resources) {
+	candidates := preemptibleAllocs(proposed, priority)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Preempt the candidates one at a time until the ask fits
+	for i := range candidates {
+		preempted := candidates[:i+1]
+		remaining := make([]*structs.Allocation, 0, len(proposed)+1)
+		remaining = append(remaining, proposed...)
+		remaining = structs.RemoveAllocs(remaining, preempted)
+		remaining = append(remaining, ask)
+
+		fit, _, util, _ := structs.AllocsFit(node, remaining, netIdx)
+		if fit {
+			return preempted, util
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// quotaTracker tracks the resources used from the quota attached to the
+// namespace of a job while the placements of an evaluation are computed.
+type quotaTracker struct {
+	// quota is the quota specification attached to the namespace
+	quota *structs.QuotaSpec
+
+	// used is the resources used by the namespaces of the quota, including
+	// the changes made by the plan
+	used *structs.QuotaLimit
+}
+
+// newQuotaTracker returns a tracker of the quota attached to the namespace of
+// the job, accounting for the allocations the plan already stops, preempts or
+// updates. Nil is returned if the namespace is not limited by a quota.
+func newQuotaTracker(state State, plan *structs.Plan, job *structs.Job) (*quotaTracker, error) {
+	if job == nil {
+		return nil, nil
+	}
+
+	ns, err := state.NamespaceByName(job.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup namespace %q: %v", job.Namespace, err)
+	}
+	if ns == nil || ns.Quota == "" {
+		return nil, nil
+	}
+
+	quota, err := state.QuotaSpecByName(ns.Quota)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup quota %q: %v", ns.Quota, err)
+	}
+	if quota == nil {
+		return nil, nil
+	}
+
+	usage, err := state.QuotaUsage(quota.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute usage of quota %q: %v", quota.Name, err)
+	}
+
+	q := &quotaTracker{
+		quota: quota,
+		used:  usage.Used,
+	}
+
+	// Remove the allocations the plan stops or replaces from the usage
+	counted := func(alloc *structs.Allocation) (*structs.Allocation, error) {
+		existing, err := state.AllocByID(alloc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lookup allocation %q: %v", alloc.ID, err)
+		}
+		if existing == nil || existing.TerminalStatus() || existing.Job == nil {
+			return nil, nil
+		}
+		for _, name := range usage.Namespaces {
+			if existing.Job.Namespace == name {
+				return existing, nil
+			}
+		}
+		return nil, nil
+	}
+	for _, updates := range []map[string][]*structs.Allocation{plan.NodeUpdate, plan.NodePreemptions, plan.NodeAllocation} {
+		for _, allocs := range updates {
+			for _, alloc := range allocs {
+				existing, err := counted(alloc)
+				if err != nil {
+					return nil, err
+				}
+				if existing != nil {
+					q.used.SubtractAlloc(existing)
+				}
+			}
+		}
+	}
+
+	// Add the allocations placed or updated by the plan
+	for _, allocs := range plan.NodeAllocation {
+		for _, alloc := range allocs {
+			q.used.AddAlloc(alloc)
+		}
+	}
+	return q, nil
+}
+
+// exceeded returns the dimensions of the quota that placing the allocation
+// would exceed
+func (q *quotaTracker) exceeded(alloc *structs.Allocation) []string {
+	used := q.used.Copy()
+	used.AddAlloc(alloc)
+	return used.Exceeded(q.quota.Limit)
+}
+
+// add records the placement of the allocation
+func (q *quotaTracker) add(alloc *structs.Allocation) {
+	q.used.AddAlloc(alloc)
+}
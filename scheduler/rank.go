@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -14,9 +15,17 @@ type RankedNode struct {
 	Score         float64
 	TaskResources map[string]*structs.Resources
 
+	// SharedNetworks are the networks assigned to the task group that are
+	// shared by all of its tasks.
+	SharedNetworks []*structs.NetworkResource
+
 	// Allocs is used to cache the proposed allocations on the
 	// node. This can be shared between iterators that require it.
 	Proposed []*structs.Allocation
+
+	// PreemptedAllocs are the allocations of lower priority jobs that must
+	// be preempted to fit the task group on the node.
+	PreemptedAllocs []*structs.Allocation
 }
 
 func (r *RankedNode) GoString() string {
@@ -129,12 +138,14 @@ func (iter *StaticRankIterator) Reset() {
 }
 
 // BinPackIterator is a RankIterator that scores potential options
-// based on a bin-packing algorithm.
+// based on a bin-packing algorithm. The scheduler configuration may
+// invert the scoring to spread allocations across the nodes instead.
 type BinPackIterator struct {
 	ctx       Context
 	source    RankIterator
 	evict     bool
 	priority  int
+	algorithm string
 	taskGroup *structs.TaskGroup
 }
 
@@ -142,14 +153,21 @@ type BinPackIterator struct {
 // potentially evicting other tasks based on a given priority.
 func NewBinPackIterator(ctx Context, source RankIterator, evict bool, priority int) *BinPackIterator {
 	iter := &BinPackIterator{
-		ctx:      ctx,
-		source:   source,
-		evict:    evict,
-		priority: priority,
+		ctx:       ctx,
+		source:    source,
+		evict:     evict,
+		priority:  priority,
+		algorithm: structs.SchedulerAlgorithmBinpack,
 	}
 	return iter
 }
 
+// SetSchedulerConfig sets the scheduler algorithm used to score the fit of
+// the nodes from the scheduler configuration of the cluster.
+func (iter *BinPackIterator) SetSchedulerConfig(config *structs.SchedulerConfig) {
+	iter.algorithm = config.EffectiveSchedulerAlgorithm()
+}
+
 func (iter *BinPackIterator) SetPriority(p int) {
 	iter.priority = p
 }
@@ -181,10 +199,40 @@ OUTER:
 		netIdx.SetNode(option.Node)
 		netIdx.AddAllocs(proposed)
 
+		// Index the existing device usage
+		devIdx := structs.NewDeviceIndex()
+		devIdx.SetNode(option.Node)
+		devIdx.AddAllocs(proposed)
+
+		// Index the existing reserved cores
+		coreIdx := structs.NewCoreIndex()
+		coreIdx.SetNode(option.Node)
+		coreIdx.AddAllocs(proposed)
+
 		// Assign the resources for each task
 		total := &structs.Resources{
 			DiskMB: iter.taskGroup.EphemeralDisk.SizeMB,
 		}
+
+		// Assign the network shared by the tasks of the group
+		option.SharedNetworks = nil
+		if len(iter.taskGroup.Networks) > 0 {
+			ask := iter.taskGroup.Networks[0].Copy()
+			offer, err := netIdx.AssignNetwork(ask)
+			if offer == nil {
+				iter.ctx.Metrics().ExhaustedNode(option.Node,
+					fmt.Sprintf("network: %s", err))
+				netIdx.Release()
+				continue OUTER
+			}
+
+			// Reserve this to prevent a task from colliding
+			netIdx.AddReserved(offer)
+
+			option.SharedNetworks = []*structs.NetworkResource{offer}
+			total.Add(&structs.Resources{Networks: option.SharedNetworks})
+		}
+
 		for _, task := range iter.taskGroup.Tasks {
 			taskResources := task.Resources.Copy()
 
@@ -206,6 +254,38 @@ OUTER:
 				taskResources.Networks = []*structs.NetworkResource{offer}
 			}
 
+			// Assign the requested device instances
+			for i, ask := range taskResources.Devices {
+				offer, err := devIdx.AssignDevice(ask)
+				if offer == nil {
+					iter.ctx.Metrics().ExhaustedNode(option.Node,
+						fmt.Sprintf("devices: %s", err))
+					netIdx.Release()
+					continue OUTER
+				}
+
+				// Reserve this to prevent another task from using them
+				devIdx.AddReservedDevices([]*structs.DeviceResource{offer})
+
+				// Update the device ask to the offer
+				taskResources.Devices[i] = offer
+			}
+
+			// Reserve whole cores, which account for their share of the
+			// node's CPU instead of the requested MHz
+			if taskResources.Cores > 0 {
+				cores, err := coreIdx.AssignCores(taskResources.Cores)
+				if err != nil {
+					iter.ctx.Metrics().ExhaustedNode(option.Node, err.Error())
+					netIdx.Release()
+					continue OUTER
+				}
+				coreIdx.AddReserved(cores)
+
+				taskResources.ReservedCores = cores
+				taskResources.CPU = taskResources.Cores * option.Node.Resources.CPU / option.Node.Resources.Cores
+			}
+
 			// Store the task resource
 			option.SetTaskResources(task, taskResources)
 
@@ -214,25 +294,38 @@ OUTER:
 		}
 
 		// Add the resources we are trying to fit
-		proposed = append(proposed, &structs.Allocation{Resources: total})
-
-		// Check if these allocations fit, if they do not, simply skip this node
-		fit, dim, util, _ := structs.AllocsFit(option.Node, proposed, netIdx)
+		ask := &structs.Allocation{Resources: total}
+
+		// Check if these allocations fit. If they do not, try to make room
+		// by preempting the allocations of lower priority jobs when evictions
+		// are allowed, otherwise simply skip this node.
+		fit, dim, util, _ := structs.AllocsFit(option.Node, append(proposed, ask), netIdx)
+		if !fit && iter.evict {
+			option.PreemptedAllocs, util = preemptForAsk(option.Node, proposed, ask, netIdx, iter.priority)
+			fit = option.PreemptedAllocs != nil
+		}
 		netIdx.Release()
 		if !fit {
 			iter.ctx.Metrics().ExhaustedNode(option.Node, dim)
 			continue
 		}
 
-		// XXX: For now we completely ignore evictions. We should use that flag
-		// to determine if its possible to evict other lower priority allocations
-		// to make room. This explodes the search space, so it must be done
-		// carefully.
-
 		// Score the fit normally otherwise
-		fitness := structs.ScoreFit(option.Node, util)
+		var fitness float64
+		if iter.algorithm == structs.SchedulerAlgorithmSpread {
+			fitness = structs.ScoreFitSpread(option.Node, util)
+		} else {
+			fitness = structs.ScoreFit(option.Node, util)
+		}
 		option.Score += fitness
-		iter.ctx.Metrics().ScoreNode(option.Node, "binpack", fitness)
+		iter.ctx.Metrics().ScoreNode(option.Node, iter.algorithm, fitness)
+
+		// Prefer nodes that do not require preempting allocations
+		if n := len(option.PreemptedAllocs); n > 0 {
+			penalty := -1 * float64(n) * preemptionPenalty
+			option.Score += penalty
+			iter.ctx.Metrics().ScoreNode(option.Node, "preemption", penalty)
+		}
 		return option
 	}
 }
@@ -304,3 +397,94 @@ func (iter *JobAntiAffinityIterator) Next() *RankedNode {
 func (iter *JobAntiAffinityIterator) Reset() {
 	iter.source.Reset()
 }
+
+// NodeAffinityIterator is used to apply the affinities of a job and task group
+// to the score of the nodes. Unlike constraints, affinities never filter a
+// node. The score of a node is proportional to the weights of the affinities
+// it matches relative to the total weight of the affinities.
+type NodeAffinityIterator struct {
+	ctx           Context
+	source        RankIterator
+	maxScore      float64
+	jobAffinities []*structs.Affinity
+	affinities    []*structs.Affinity
+	totalWeight   float64
+}
+
+// NewNodeAffinityIterator is used to create a NodeAffinityIterator that
+// applies up to the given score for nodes matching the affinities.
+func NewNodeAffinityIterator(ctx Context, source RankIterator, maxScore float64) *NodeAffinityIterator {
+	iter := &NodeAffinityIterator{
+		ctx:      ctx,
+		source:   source,
+		maxScore: maxScore,
+	}
+	return iter
+}
+
+func (iter *NodeAffinityIterator) SetJob(job *structs.Job) {
+	iter.jobAffinities = job.Affinities
+}
+
+// SetAffinities sets the affinities of the task group and its tasks. They are
+// combined with the affinities of the job.
+func (iter *NodeAffinityIterator) SetAffinities(affinities []*structs.Affinity) {
+	iter.affinities = make([]*structs.Affinity, 0, len(iter.jobAffinities)+len(affinities))
+	iter.affinities = append(iter.affinities, iter.jobAffinities...)
+	iter.affinities = append(iter.affinities, affinities...)
+
+	iter.totalWeight = 0
+	for _, affinity := range iter.affinities {
+		iter.totalWeight += math.Abs(float64(affinity.Weight))
+	}
+}
+
+// HasAffinities returns whether any affinity applies to the task group
+func (iter *NodeAffinityIterator) HasAffinities() bool {
+	return len(iter.affinities) != 0
+}
+
+func (iter *NodeAffinityIterator) Next() *RankedNode {
+	option := iter.source.Next()
+	if option == nil {
+		return nil
+	}
+	if !iter.HasAffinities() || iter.totalWeight == 0 {
+		return option
+	}
+
+	// Sum up the weights of the matched affinities
+	matched := 0.0
+	for _, affinity := range iter.affinities {
+		if matchesAffinity(iter.ctx, affinity, option.Node) {
+			matched += float64(affinity.Weight)
+		}
+	}
+
+	if matched != 0 {
+		score := matched / iter.totalWeight * iter.maxScore
+		option.Score += score
+		iter.ctx.Metrics().ScoreNode(option.Node, "node-affinity", score)
+	}
+	return option
+}
+
+func (iter *NodeAffinityIterator) Reset() {
+	iter.source.Reset()
+}
+
+// matchesAffinity returns whether the node matches the affinity
+func matchesAffinity(ctx Context, affinity *structs.Affinity, option *structs.Node) bool {
+	// Resolve the targets
+	lVal, ok := resolveConstraintTarget(affinity.LTarget, option)
+	if !ok {
+		return false
+	}
+	rVal, ok := resolveConstraintTarget(affinity.RTarget, option)
+	if !ok {
+		return false
+	}
+
+	// Check if satisfied
+	return checkConstraint(ctx, affinity.Operand, lVal, rVal)
+}
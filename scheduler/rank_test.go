@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -99,6 +100,70 @@ func TestBinPackIterator_NoExistingAlloc(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_Spread(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				// Perfect fit
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+				},
+				Reserved: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				// 50% fit
+				Resources: &structs.Resources{
+					CPU:      4096,
+					MemoryMB: 4096,
+				},
+				Reserved: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetSchedulerConfig(&structs.SchedulerConfig{
+		SchedulerAlgorithm: structs.SchedulerAlgorithmSpread,
+	})
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	if len(out) != 2 {
+		t.Fatalf("Bad: %v", out)
+	}
+
+	// The least utilized node scores the highest
+	if out[0].Score != 0 {
+		t.Fatalf("Bad: %v", out[0])
+	}
+	if out[1].Score < 2 || out[1].Score > 8 {
+		t.Fatalf("Bad: %v", out[1])
+	}
+}
+
 func TestBinPackIterator_PlannedAlloc(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*RankedNode{
@@ -175,6 +240,294 @@ func TestBinPackIterator_PlannedAlloc(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_Devices(t *testing.T) {
+	_, ctx := testContext(t)
+	gpus := func() []*structs.DeviceResource {
+		return []*structs.DeviceResource{
+			{Name: "nvidia/gpu/Tesla K80", IDs: []string{"GPU-1", "GPU-2"}},
+		}
+	}
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				// No devices
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+				},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				// One of the GPUs is used
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+					Devices:  gpus(),
+				},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				// All of the GPUs are free
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+					Devices:  gpus(),
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	// Add a planned alloc to node2 that uses one of the GPUs
+	plan := ctx.Plan()
+	plan.NodeAllocation[nodes[1].Node.ID] = []*structs.Allocation{
+		&structs.Allocation{
+			TaskResources: map[string]*structs.Resources{
+				"web": &structs.Resources{
+					CPU:      512,
+					MemoryMB: 512,
+					Devices: []*structs.DeviceResource{
+						{Name: "nvidia/gpu/Tesla K80", Count: 1, IDs: []string{"GPU-1"}},
+					},
+				},
+			},
+		},
+	}
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+					Devices: []*structs.DeviceResource{
+						{Name: "nvidia/gpu", Count: 2},
+					},
+				},
+			},
+		},
+	}
+
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[0] != nodes[2] {
+		t.Fatalf("Bad: %v", out)
+	}
+
+	expected := []*structs.DeviceResource{
+		{Name: "nvidia/gpu/Tesla K80", Count: 2, IDs: []string{"GPU-1", "GPU-2"}},
+	}
+	if devices := out[0].TaskResources["web"].Devices; !reflect.DeepEqual(devices, expected) {
+		t.Fatalf("Bad: %#v", devices)
+	}
+
+	// The request is not modified
+	if ids := taskGroup.Tasks[0].Resources.Devices[0].IDs; ids != nil {
+		t.Fatalf("Bad: %#v", ids)
+	}
+
+	if ctx.Metrics().NodesExhausted != 2 {
+		t.Fatalf("Bad: %#v", ctx.Metrics())
+	}
+}
+
+func TestBinPackIterator_Cores(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				// Cores are not fingerprinted
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      4000,
+					MemoryMB: 2048,
+				},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				// Two of the cores are reserved
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      4000,
+					Cores:    4,
+					MemoryMB: 2048,
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	plan := ctx.Plan()
+	plan.NodeAllocation[nodes[1].Node.ID] = []*structs.Allocation{
+		&structs.Allocation{
+			ID: structs.GenerateUUID(),
+			TaskResources: map[string]*structs.Resources{
+				"web": &structs.Resources{
+					CPU:           2000,
+					Cores:         2,
+					ReservedCores: []int{0, 1},
+					MemoryMB:      512,
+				},
+			},
+		},
+	}
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      100,
+					Cores:    2,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[0] != nodes[1] {
+		t.Fatalf("Bad: %v", out)
+	}
+
+	// The cores account for their share of the node's CPU
+	resources := out[0].TaskResources["web"]
+	if !reflect.DeepEqual(resources.ReservedCores, []int{2, 3}) || resources.CPU != 2000 {
+		t.Fatalf("Bad: %#v", resources)
+	}
+
+	// No further cores can be reserved
+	plan.NodeAllocation[nodes[1].Node.ID] = append(plan.NodeAllocation[nodes[1].Node.ID],
+		&structs.Allocation{ID: structs.GenerateUUID(), TaskResources: out[0].TaskResources})
+	static = NewStaticRankIterator(ctx, []*RankedNode{{Node: nodes[1].Node}})
+	binp = NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+	if out := collectRanked(binp); len(out) != 0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+}
+
+func TestBinPackIterator_GroupNetwork(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+					Networks: []*structs.NetworkResource{
+						{
+							Device: "eth0",
+							CIDR:   "192.168.0.100/32",
+							MBits:  100,
+						},
+					},
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	// The static port is already used by an allocation on the node
+	plan := ctx.Plan()
+	plan.NodeAllocation[nodes[0].Node.ID] = []*structs.Allocation{
+		&structs.Allocation{
+			ID:            structs.GenerateUUID(),
+			TaskResources: map[string]*structs.Resources{},
+			SharedResources: &structs.Resources{
+				Networks: []*structs.NetworkResource{
+					{
+						Device:        "eth0",
+						IP:            "192.168.0.100",
+						MBits:         50,
+						ReservedPorts: []structs.Port{{Label: "http", Value: 8080}},
+					},
+				},
+			},
+		},
+	}
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Networks: []*structs.NetworkResource{
+			{
+				Mode:         structs.NetworkModeBridge,
+				MBits:        50,
+				DynamicPorts: []structs.Port{{Label: "http", To: 8080}},
+			},
+		},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %#v", out)
+	}
+
+	networks := out[0].SharedNetworks
+	if len(networks) != 1 {
+		t.Fatalf("Bad: %#v", networks)
+	}
+	n := networks[0]
+	if n.Mode != structs.NetworkModeBridge || n.IP != "192.168.0.100" || n.MBits != 50 {
+		t.Fatalf("Bad: %#v", n)
+	}
+	if port := n.DynamicPorts[0]; port.Value == 0 || port.Value == 8080 || port.To != 8080 {
+		t.Fatalf("Bad: %#v", port)
+	}
+
+	// The ask of the task group is left untouched
+	if port := taskGroup.Networks[0].DynamicPorts[0]; port.Value != 0 {
+		t.Fatalf("Bad: %#v", port)
+	}
+
+	// The bandwidth of the node is exhausted by a second allocation
+	plan.NodeAllocation[nodes[0].Node.ID] = append(plan.NodeAllocation[nodes[0].Node.ID],
+		&structs.Allocation{
+			ID:              structs.GenerateUUID(),
+			TaskResources:   out[0].TaskResources,
+			SharedResources: &structs.Resources{Networks: networks},
+		})
+	static = NewStaticRankIterator(ctx, []*RankedNode{{Node: nodes[0].Node}})
+	binp = NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+	if out := collectRanked(binp); len(out) != 0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+}
+
 func TestBinPackIterator_ExistingAlloc(t *testing.T) {
 	state, ctx := testContext(t)
 	nodes := []*RankedNode{
@@ -351,6 +704,94 @@ func TestBinPackIterator_ExistingAlloc_PlannedEvict(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_Preemption(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				ID: structs.GenerateUUID(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	// Fill the node with the allocations of a low and a medium priority job
+	var allocs []*structs.Allocation
+	for i, priority := range []int{20, 45} {
+		job := mock.Job()
+		job.Priority = priority
+		alloc := &structs.Allocation{
+			ID:     structs.GenerateUUID(),
+			EvalID: structs.GenerateUUID(),
+			NodeID: nodes[0].Node.ID,
+			JobID:  job.ID,
+			Job:    job,
+			Resources: &structs.Resources{
+				CPU:      1024,
+				MemoryMB: 1024,
+			},
+			DesiredStatus: structs.AllocDesiredStatusRun,
+			ClientStatus:  structs.AllocClientStatusPending,
+			TaskGroup:     "web",
+		}
+		noErr(t, state.UpsertJobSummary(uint64(998+i), mock.JobSummary(alloc.JobID)))
+		allocs = append(allocs, alloc)
+	}
+	noErr(t, state.UpsertAllocs(1000, allocs))
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{
+				Name: "web",
+				Resources: &structs.Resources{
+					CPU:      1024,
+					MemoryMB: 1024,
+				},
+			},
+		},
+	}
+
+	// Without evictions the node is exhausted
+	binp := NewBinPackIterator(ctx, static, false, 50)
+	binp.SetTaskGroup(taskGroup)
+	if out := collectRanked(binp); len(out) != 0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+
+	// Only the allocation of the low priority job is preempted
+	static.Reset()
+	binp = NewBinPackIterator(ctx, static, true, 50)
+	binp.SetTaskGroup(taskGroup)
+	out := collectRanked(binp)
+	if len(out) != 1 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	preempted := out[0].PreemptedAllocs
+	if len(preempted) != 1 || preempted[0].ID != allocs[0].ID {
+		t.Fatalf("Bad: %#v", preempted)
+	}
+	if out[0].Score != 18-preemptionPenalty {
+		t.Fatalf("Bad: %v", out[0])
+	}
+
+	// Preempting the low priority job does not free enough resources
+	taskGroup.Tasks[0].Resources = &structs.Resources{
+		CPU:      2048,
+		MemoryMB: 2048,
+	}
+	static.Reset()
+	binp = NewBinPackIterator(ctx, static, true, 50)
+	binp.SetTaskGroup(taskGroup)
+	if out := collectRanked(binp); len(out) != 0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+}
+
 func TestJobAntiAffinity_PlannedAlloc(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*RankedNode{
@@ -408,6 +849,74 @@ func TestJobAntiAffinity_PlannedAlloc(t *testing.T) {
 	}
 }
 
+func TestNodeAffinity(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		&RankedNode{
+			Node: &structs.Node{
+				ID:         structs.GenerateUUID(),
+				Datacenter: "dc1",
+				Attributes: map[string]string{"kernel.name": "linux"},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				ID:         structs.GenerateUUID(),
+				Datacenter: "dc2",
+				Attributes: map[string]string{"kernel.name": "linux"},
+			},
+		},
+		&RankedNode{
+			Node: &structs.Node{
+				ID:         structs.GenerateUUID(),
+				Datacenter: "dc2",
+				Attributes: map[string]string{"kernel.name": "windows"},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	job := &structs.Job{
+		Affinities: []*structs.Affinity{
+			&structs.Affinity{
+				LTarget: "${node.datacenter}",
+				RTarget: "dc1",
+				Operand: "=",
+				Weight:  100,
+			},
+		},
+	}
+	affinities := []*structs.Affinity{
+		&structs.Affinity{
+			LTarget: "${attr.kernel.name}",
+			RTarget: "windows",
+			Operand: "=",
+			Weight:  -100,
+		},
+	}
+
+	aff := NewNodeAffinityIterator(ctx, static, 20.0)
+	aff.SetJob(job)
+	aff.SetAffinities(affinities)
+	if !aff.HasAffinities() {
+		t.Fatalf("affinities not set")
+	}
+
+	out := collectRanked(aff)
+	if len(out) != 3 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[0].Score != 10.0 {
+		t.Fatalf("Bad: %#v", out[0])
+	}
+	if out[1].Score != 0.0 {
+		t.Fatalf("Bad: %#v", out[1])
+	}
+	if out[2].Score != -10.0 {
+		t.Fatalf("Bad: %#v", out[2])
+	}
+}
+
 func collectRanked(iter RankIterator) (out []*RankedNode) {
 	for {
 		next := iter.Next()
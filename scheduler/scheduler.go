@@ -79,6 +79,25 @@ type State interface {
 
 	// GetJobByID is used to lookup a job by ID
 	JobByID(id string) (*structs.Job, error)
+
+	// LatestDeploymentByJobID returns the latest deployment of the job
+	LatestDeploymentByJobID(jobID string) (*structs.Deployment, error)
+
+	// AllocByID is used to lookup an allocation by ID
+	AllocByID(id string) (*structs.Allocation, error)
+
+	// NamespaceByName is used to lookup a namespace by name
+	NamespaceByName(name string) (*structs.Namespace, error)
+
+	// QuotaSpecByName is used to lookup a quota specification by name
+	QuotaSpecByName(name string) (*structs.QuotaSpec, error)
+
+	// QuotaUsage returns the resources used by the namespaces a quota
+	// specification is attached to
+	QuotaUsage(quota string) (*structs.QuotaUsage, error)
+
+	// SchedulerConfig returns the scheduler configuration of the cluster
+	SchedulerConfig() (uint64, *structs.SchedulerConfig, error)
 }
 
 // Planner interface is used to submit a task allocation plan.
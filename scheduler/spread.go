@@ -0,0 +1,214 @@
+package scheduler
+
+import (
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// SpreadIterator is used to balance the allocations of a task group across the
+// values of node attributes. Nodes whose attribute value has fewer allocations
+// than desired are scored higher than the nodes whose value already has its
+// share of the allocations.
+type SpreadIterator struct {
+	ctx         Context
+	source      RankIterator
+	maxScore    float64
+	job         *structs.Job
+	jobSpreads  []*structs.Spread
+	tg          *structs.TaskGroup
+	spreads     []*structs.Spread
+	totalWeight float64
+
+	// valueCounts is the number of allocations of the task group for each
+	// value of the attribute of the spread at the same index.
+	valueCounts []map[string]int
+}
+
+// NewSpreadIterator is used to create a SpreadIterator that applies up to the
+// given score for nodes whose attribute values lack allocations.
+func NewSpreadIterator(ctx Context, source RankIterator, maxScore float64) *SpreadIterator {
+	iter := &SpreadIterator{
+		ctx:      ctx,
+		source:   source,
+		maxScore: maxScore,
+	}
+	return iter
+}
+
+func (iter *SpreadIterator) SetJob(job *structs.Job) {
+	iter.job = job
+	iter.jobSpreads = job.Spreads
+}
+
+// SetTaskGroup sets the task group being placed and counts its allocations
+// for each value of the spread attributes. The spreads of the task group are
+// combined with the spreads of the job.
+func (iter *SpreadIterator) SetTaskGroup(tg *structs.TaskGroup) {
+	iter.tg = tg
+	iter.spreads = make([]*structs.Spread, 0, len(iter.jobSpreads)+len(tg.Spreads))
+	iter.spreads = append(iter.spreads, iter.jobSpreads...)
+	iter.spreads = append(iter.spreads, tg.Spreads...)
+
+	iter.totalWeight = 0
+	for _, spread := range iter.spreads {
+		iter.totalWeight += float64(spread.Weight)
+	}
+
+	iter.valueCounts = make([]map[string]int, len(iter.spreads))
+	for i := range iter.spreads {
+		iter.valueCounts[i] = make(map[string]int)
+	}
+	if !iter.HasSpreads() {
+		return
+	}
+
+	allocs, err := iter.proposedAllocs()
+	if err != nil {
+		iter.ctx.Logger().Printf(
+			"[ERR] sched.spread: failed to get proposed allocations: %v", err)
+		return
+	}
+
+	// Count the allocations for each value of the spread attributes
+	nodes := make(map[string]*structs.Node)
+	for _, alloc := range allocs {
+		node, ok := nodes[alloc.NodeID]
+		if !ok {
+			node, err = iter.ctx.State().NodeByID(alloc.NodeID)
+			if err != nil {
+				iter.ctx.Logger().Printf(
+					"[ERR] sched.spread: failed to lookup node %q: %v", alloc.NodeID, err)
+				continue
+			}
+			nodes[alloc.NodeID] = node
+		}
+		if node == nil {
+			continue
+		}
+
+		for i, spread := range iter.spreads {
+			value, ok := resolveConstraintTarget(spread.Attribute, node)
+			if !ok {
+				continue
+			}
+			iter.valueCounts[i][value.(string)]++
+		}
+	}
+}
+
+// HasSpreads returns whether any spread applies to the task group
+func (iter *SpreadIterator) HasSpreads() bool {
+	return len(iter.spreads) != 0
+}
+
+// proposedAllocs returns the non-terminal allocations of the task group once
+// the plan is applied.
+func (iter *SpreadIterator) proposedAllocs() ([]*structs.Allocation, error) {
+	allocs, err := proposedJobAllocs(iter.ctx, iter.job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*structs.Allocation, 0, len(allocs))
+	for _, alloc := range allocs {
+		if alloc.TaskGroup == iter.tg.Name {
+			out = append(out, alloc)
+		}
+	}
+	return out, nil
+}
+
+func (iter *SpreadIterator) Next() *RankedNode {
+	option := iter.source.Next()
+	if option == nil {
+		return nil
+	}
+	if !iter.HasSpreads() || iter.totalWeight == 0 {
+		return option
+	}
+
+	total := 0.0
+	for i, spread := range iter.spreads {
+		boost := -1.0
+		if value, ok := resolveConstraintTarget(spread.Attribute, option.Node); ok {
+			if len(spread.SpreadTarget) == 0 {
+				boost = evenSpreadBoost(iter.valueCounts[i], value.(string))
+			} else {
+				boost = targetSpreadBoost(spread, iter.valueCounts[i], value.(string), iter.tg.Count)
+			}
+		}
+		total += boost * float64(spread.Weight)
+	}
+
+	if total != 0 {
+		score := total / iter.totalWeight * iter.maxScore
+		option.Score += score
+		iter.ctx.Metrics().ScoreNode(option.Node, "allocation-spread", score)
+	}
+	return option
+}
+
+func (iter *SpreadIterator) Reset() {
+	iter.source.Reset()
+}
+
+// evenSpreadBoost returns the boost of a node whose attribute has the given
+// value when the allocations are spread evenly. Values with fewer allocations
+// than the most used value are boosted.
+func evenSpreadBoost(counts map[string]int, value string) float64 {
+	max := 0
+	for _, count := range counts {
+		if count > max {
+			max = count
+		}
+	}
+	if max == 0 {
+		return 0
+	}
+	return float64(max-counts[value]) / float64(max)
+}
+
+// targetSpreadBoost returns the boost of a node whose attribute has the given
+// value when the allocations are spread according to the targets of the
+// spread. The values without a target share the remaining percentage.
+func targetSpreadBoost(spread *structs.Spread, counts map[string]int, value string, count int) float64 {
+	var percent, total uint32
+	targeted := false
+	for _, target := range spread.SpreadTarget {
+		total += target.Percent
+		if target.Value == value {
+			percent = target.Percent
+			targeted = true
+		}
+	}
+
+	used := counts[value]
+	if !targeted {
+		percent = 100 - total
+		used = 0
+		for v, c := range counts {
+			if !hasSpreadTarget(spread, v) {
+				used += c
+			}
+		}
+	}
+
+	desired := float64(percent) / 100 * float64(count)
+	if desired == 0 {
+		return -1
+	}
+	boost := (desired - float64(used)) / desired
+	if boost < -1 {
+		boost = -1
+	}
+	return boost
+}
+
+// hasSpreadTarget returns whether the spread has a target for the value
+func hasSpreadTarget(spread *structs.Spread, value string) bool {
+	for _, target := range spread.SpreadTarget {
+		if target.Value == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// spreadTestNodes upserts a node in each of the given datacenters
+func spreadTestNodes(t *testing.T, state *state.StateStore, dcs ...string) []*RankedNode {
+	var nodes []*RankedNode
+	for i, dc := range dcs {
+		node := mock.Node()
+		node.Datacenter = dc
+		if err := state.UpsertNode(uint64(100+i), node); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		nodes = append(nodes, &RankedNode{Node: node})
+	}
+	return nodes
+}
+
+func TestSpreadIterator_Even(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := spreadTestNodes(t, state, "dc1", "dc1", "dc2")
+
+	job := mock.Job()
+	job.Spreads = []*structs.Spread{
+		&structs.Spread{
+			Attribute: "${node.datacenter}",
+			Weight:    100,
+		},
+	}
+
+	// Place an allocation in dc1
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = nodes[0].Node.ID
+	if err := state.UpsertAllocs(1000, []*structs.Allocation{alloc}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	static := NewStaticRankIterator(ctx, nodes)
+	spread := NewSpreadIterator(ctx, static, 20.0)
+	spread.SetJob(job)
+	spread.SetTaskGroup(job.TaskGroups[0])
+	if !spread.HasSpreads() {
+		t.Fatalf("spreads not set")
+	}
+
+	out := collectRanked(spread)
+	if len(out) != 3 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[0].Score != 0.0 || out[1].Score != 0.0 {
+		t.Fatalf("Bad: %#v", out)
+	}
+	if out[2].Score != 20.0 {
+		t.Fatalf("Bad: %#v", out[2])
+	}
+}
+
+func TestSpreadIterator_Targets(t *testing.T) {
+	state, ctx := testContext(t)
+	nodes := spreadTestNodes(t, state, "dc1", "dc2", "dc3")
+
+	job := mock.Job()
+	job.TaskGroups[0].Count = 10
+	job.TaskGroups[0].Spreads = []*structs.Spread{
+		&structs.Spread{
+			Attribute: "${node.datacenter}",
+			Weight:    100,
+			SpreadTarget: []*structs.SpreadTarget{
+				&structs.SpreadTarget{Value: "dc1", Percent: 70},
+				&structs.SpreadTarget{Value: "dc2", Percent: 30},
+			},
+		},
+	}
+
+	// Place the desired allocations in dc1
+	var allocs []*structs.Allocation
+	for i := 0; i < 7; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		alloc.NodeID = nodes[0].Node.ID
+		allocs = append(allocs, alloc)
+	}
+	if err := state.UpsertAllocs(1000, allocs); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Plan an allocation in dc2
+	planned := mock.Alloc()
+	planned.Job = job
+	planned.JobID = job.ID
+	planned.NodeID = nodes[1].Node.ID
+	ctx.Plan().NodeAllocation[planned.NodeID] = []*structs.Allocation{planned}
+
+	static := NewStaticRankIterator(ctx, nodes)
+	spread := NewSpreadIterator(ctx, static, 20.0)
+	spread.SetJob(job)
+	spread.SetTaskGroup(job.TaskGroups[0])
+
+	out := collectRanked(spread)
+	if len(out) != 3 {
+		t.Fatalf("Bad: %#v", out)
+	}
+
+	// dc1 has its share, dc2 lacks two of three and dc3 is not desired
+	if out[0].Score != 0.0 {
+		t.Fatalf("Bad: %#v", out[0])
+	}
+	if expected := 20.0 * 2 / 3; math.Abs(out[1].Score-expected) > 0.001 {
+		t.Fatalf("Bad: %#v", out[1])
+	}
+	if out[2].Score != -20.0 {
+		t.Fatalf("Bad: %#v", out[2])
+	}
+}
@@ -16,6 +16,16 @@ const (
 	// batchJobAntiAffinityPenalty is the same as the
 	// serviceJobAntiAffinityPenalty but for batch type jobs.
 	batchJobAntiAffinityPenalty = 5.0
+
+	// nodeAffinityMaxScore is the score applied to a node matching all
+	// the affinities of a task group. Nodes matching only some of them
+	// receive a share of it proportional to the weights of the matched
+	// affinities.
+	nodeAffinityMaxScore = 20.0
+
+	// spreadMaxScore is the score applied to a node whose attribute values
+	// lack the most allocations of the task group across all its spreads.
+	spreadMaxScore = 20.0
 )
 
 // Stack is a chained collection of iterators. The stack is used to
@@ -28,6 +38,10 @@ type Stack interface {
 	// SetTaskGroup is used to set the job for selection
 	SetJob(job *structs.Job)
 
+	// SetSchedulerConfig is used to apply the scheduler configuration of
+	// the cluster
+	SetSchedulerConfig(config *structs.SchedulerConfig)
+
 	// Select is used to select a node for the task group
 	Select(tg *structs.TaskGroup) (*RankedNode, *structs.Resources)
 }
@@ -43,20 +57,28 @@ type GenericStack struct {
 	jobConstraint       *ConstraintChecker
 	taskGroupDrivers    *DriverChecker
 	taskGroupConstraint *ConstraintChecker
+	taskGroupVolumes    *HostVolumeChecker
 
 	proposedAllocConstraint *ProposedAllocConstraintIterator
 	binPack                 *BinPackIterator
 	jobAntiAff              *JobAntiAffinityIterator
+	nodeAffinity            *NodeAffinityIterator
+	spread                  *SpreadIterator
 	limit                   *LimitIterator
 	maxScore                *MaxScoreIterator
+
+	// nodeLimit is the number of nodes visited when the task group has no
+	// affinities or spreads.
+	nodeLimit int
 }
 
 // NewGenericStack constructs a stack used for selecting service placements
 func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	// Create a new stack
 	s := &GenericStack{
-		batch: batch,
-		ctx:   ctx,
+		batch:     batch,
+		ctx:       ctx,
+		nodeLimit: 2,
 	}
 
 	// Create the source iterator. We randomize the order we visit nodes
@@ -73,12 +95,15 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	// Filter on task group constraints second
 	s.taskGroupConstraint = NewConstraintChecker(ctx, nil)
 
+	// Filter on the host volumes requested by the task group
+	s.taskGroupVolumes = NewHostVolumeChecker(ctx)
+
 	// Create the feasibility wrapper which wraps all feasibility checks in
 	// which feasibility checking can be skipped if the computed node class has
 	// previously been marked as eligible or ineligible. Generally this will be
 	// checks that only needs to examine the single node to determine feasibility.
 	jobs := []FeasibilityChecker{s.jobConstraint}
-	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint}
+	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint, s.taskGroupVolumes}
 	s.wrappedChecks = NewFeasibilityWrapper(ctx, s.source, jobs, tgs)
 
 	// Filter on constraints that are affected by propsed allocations.
@@ -102,8 +127,16 @@ func NewGenericStack(batch bool, ctx Context) *GenericStack {
 	}
 	s.jobAntiAff = NewJobAntiAffinityIterator(ctx, s.binPack, penalty, "")
 
+	// Apply the node affinity iterator. This prefers nodes matching the
+	// affinities of the job and task group.
+	s.nodeAffinity = NewNodeAffinityIterator(ctx, s.jobAntiAff, nodeAffinityMaxScore)
+
+	// Apply the spread iterator. This balances the allocations of the task
+	// group across the values of the spread attributes.
+	s.spread = NewSpreadIterator(ctx, s.nodeAffinity, spreadMaxScore)
+
 	// Apply a limit function. This is to avoid scanning *every* possible node.
-	s.limit = NewLimitIterator(ctx, s.jobAntiAff, 2)
+	s.limit = NewLimitIterator(ctx, s.spread, 2)
 
 	// Select the node with the maximum score for placement
 	s.maxScore = NewMaxScoreIterator(ctx, s.limit)
@@ -129,14 +162,21 @@ func (s *GenericStack) SetNodes(baseNodes []*structs.Node) {
 			limit = logLimit
 		}
 	}
+	s.nodeLimit = limit
 	s.limit.SetLimit(limit)
 }
 
+func (s *GenericStack) SetSchedulerConfig(config *structs.SchedulerConfig) {
+	s.binPack.SetSchedulerConfig(config)
+}
+
 func (s *GenericStack) SetJob(job *structs.Job) {
 	s.jobConstraint.SetConstraints(job.Constraints)
 	s.proposedAllocConstraint.SetJob(job)
 	s.binPack.SetPriority(job.Priority)
 	s.jobAntiAff.SetJob(job.ID)
+	s.nodeAffinity.SetJob(job)
+	s.spread.SetJob(job)
 	s.ctx.Eligibility().SetJob(job)
 }
 
@@ -152,9 +192,20 @@ func (s *GenericStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Reso
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
+	s.taskGroupVolumes.SetVolumes(tg.Volumes)
 	s.proposedAllocConstraint.SetTaskGroup(tg)
 	s.wrappedChecks.SetTaskGroup(tg.Name)
 	s.binPack.SetTaskGroup(tg)
+	s.nodeAffinity.SetAffinities(tgConstr.affinities)
+	s.spread.SetTaskGroup(tg)
+
+	// Affinities and spreads can only be honored by comparing every feasible
+	// node, so the limit is lifted when there are any.
+	if s.nodeAffinity.HasAffinities() || s.spread.HasSpreads() {
+		s.limit.SetLimit(math.MaxInt32)
+	} else {
+		s.limit.SetLimit(s.nodeLimit)
+	}
 
 	// Find the node with the max score
 	option := s.maxScore.Next()
@@ -193,6 +244,7 @@ type SystemStack struct {
 	jobConstraint       *ConstraintChecker
 	taskGroupDrivers    *DriverChecker
 	taskGroupConstraint *ConstraintChecker
+	taskGroupVolumes    *HostVolumeChecker
 	binPack             *BinPackIterator
 }
 
@@ -214,12 +266,15 @@ func NewSystemStack(ctx Context) *SystemStack {
 	// Filter on task group constraints second
 	s.taskGroupConstraint = NewConstraintChecker(ctx, nil)
 
+	// Filter on the host volumes requested by the task group
+	s.taskGroupVolumes = NewHostVolumeChecker(ctx)
+
 	// Create the feasibility wrapper which wraps all feasibility checks in
 	// which feasibility checking can be skipped if the computed node class has
 	// previously been marked as eligible or ineligible. Generally this will be
 	// checks that only needs to examine the single node to determine feasibility.
 	jobs := []FeasibilityChecker{s.jobConstraint}
-	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint}
+	tgs := []FeasibilityChecker{s.taskGroupDrivers, s.taskGroupConstraint, s.taskGroupVolumes}
 	s.wrappedChecks = NewFeasibilityWrapper(ctx, s.source, jobs, tgs)
 
 	// Upgrade from feasible to rank iterator
@@ -237,6 +292,10 @@ func (s *SystemStack) SetNodes(baseNodes []*structs.Node) {
 	s.source.SetNodes(baseNodes)
 }
 
+func (s *SystemStack) SetSchedulerConfig(config *structs.SchedulerConfig) {
+	s.binPack.SetSchedulerConfig(config)
+}
+
 func (s *SystemStack) SetJob(job *structs.Job) {
 	s.jobConstraint.SetConstraints(job.Constraints)
 	s.binPack.SetPriority(job.Priority)
@@ -255,6 +314,7 @@ func (s *SystemStack) Select(tg *structs.TaskGroup) (*RankedNode, *structs.Resou
 	// Update the parameters of iterators
 	s.taskGroupDrivers.SetDrivers(tgConstr.drivers)
 	s.taskGroupConstraint.SetConstraints(tgConstr.constraints)
+	s.taskGroupVolumes.SetVolumes(tg.Volumes)
 	s.binPack.SetTaskGroup(tg)
 	s.wrappedChecks.SetTaskGroup(tg.Name)
 
@@ -265,6 +265,47 @@ func TestServiceStack_Select_ConstraintFilter(t *testing.T) {
 	}
 }
 
+func TestServiceStack_Select_Affinity(t *testing.T) {
+	_, ctx := testContext(t)
+	var nodes []*structs.Node
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, mock.Node())
+	}
+	preferred := nodes[7]
+	preferred.Meta["rack"] = "r1"
+	if err := preferred.ComputeClass(); err != nil {
+		t.Fatalf("ComputedClass() failed: %v", err)
+	}
+
+	stack := NewGenericStack(false, ctx)
+	stack.SetNodes(nodes)
+
+	job := mock.Job()
+	job.TaskGroups[0].Affinities = []*structs.Affinity{
+		&structs.Affinity{
+			LTarget: "${meta.rack}",
+			RTarget: "r1",
+			Operand: "=",
+			Weight:  100,
+		},
+	}
+	stack.SetJob(job)
+
+	// Every node is visited so the preferred node is always selected
+	node, _ := stack.Select(job.TaskGroups[0])
+	if node == nil {
+		t.Fatalf("missing node %#v", ctx.Metrics())
+	}
+	if node.Node != preferred {
+		t.Fatalf("bad: %#v", node)
+	}
+
+	met := ctx.Metrics()
+	if met.NodesEvaluated != len(nodes) {
+		t.Fatalf("bad: %#v", met)
+	}
+}
+
 func TestServiceStack_Select_BinPack_Overflow(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*structs.Node{
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -35,6 +36,7 @@ type SystemScheduler struct {
 	nodesByDC  map[string]int
 
 	limitReached bool
+	stagger      time.Duration
 	nextEval     *structs.Evaluation
 
 	failedTGAllocs map[string]*structs.AllocMetric
@@ -59,7 +61,9 @@ func (s *SystemScheduler) Process(eval *structs.Evaluation) error {
 	// Verify the evaluation trigger reason is understood
 	switch eval.TriggeredBy {
 	case structs.EvalTriggerJobRegister, structs.EvalTriggerNodeUpdate,
-		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate:
+		structs.EvalTriggerJobDeregister, structs.EvalTriggerRollingUpdate,
+		structs.EvalTriggerNodeDrain, structs.EvalTriggerAllocStop,
+		structs.EvalTriggerPreemption:
 	default:
 		desc := fmt.Sprintf("scheduler cannot handle '%s' evaluation reason",
 			eval.TriggeredBy)
@@ -114,9 +118,15 @@ func (s *SystemScheduler) process() (bool, error) {
 
 	// Create an evaluation context
 	s.ctx = NewEvalContext(s.state, s.plan, s.logger)
+	s.ctx.SetTrace(s.eval.Trace)
 
 	// Construct the placement stack
+	_, schedConfig, err := s.state.SchedulerConfig()
+	if err != nil {
+		return false, fmt.Errorf("failed to get scheduler configuration: %v", err)
+	}
 	s.stack = NewSystemStack(s.ctx)
+	s.stack.SetSchedulerConfig(schedConfig)
 	if s.job != nil {
 		s.stack.SetJob(s.job)
 	}
@@ -136,7 +146,7 @@ func (s *SystemScheduler) process() (bool, error) {
 	// If the limit of placements was reached we need to create an evaluation
 	// to pickup from here after the stagger period.
 	if s.limitReached && s.nextEval == nil {
-		s.nextEval = s.eval.NextRollingEval(s.job.Update.Stagger)
+		s.nextEval = s.eval.NextRollingEval(s.stagger)
 		if err := s.planner.CreateEval(s.nextEval); err != nil {
 			s.logger.Printf("[ERR] sched: %#v failed to make next eval for rolling update: %v", s.eval, err)
 			return false, err
@@ -223,14 +233,29 @@ func (s *SystemScheduler) computeJobAllocs() error {
 		}
 	}
 
-	// Check if a rolling upgrade strategy is being used
-	limit := len(diff.update)
-	if s.job != nil && s.job.Update.Rolling() {
-		limit = s.job.Update.MaxParallel
-	}
+	// Check if a rolling upgrade strategy is being used. Task groups may
+	// override the update strategy of the job so the limit is per group.
+	s.limitReached = false
+	s.stagger = 0
+	if s.job != nil {
+		for _, tg := range s.job.TaskGroups {
+			update := filterByTaskGroup(diff.update, tg.Name)
+
+			limit := len(update)
+			strategy := s.job.LookupUpdateStrategy(tg.Name)
+			if strategy.Rolling() {
+				limit = strategy.MaxParallel
+			}
 
-	// Treat non in-place updates as an eviction and new placement.
-	s.limitReached = evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
+			// Treat non in-place updates as an eviction and new placement.
+			if evictAndPlace(s.ctx, diff, update, allocUpdating, &limit) {
+				if !s.limitReached || strategy.Stagger < s.stagger {
+					s.stagger = strategy.Stagger
+				}
+				s.limitReached = true
+			}
+		}
+	}
 
 	// Nothing remaining to do if placement is not required
 	if len(diff.place) == 0 {
@@ -258,6 +283,12 @@ func (s *SystemScheduler) computePlacements(place []allocTuple) error {
 		nodeByID[node.ID] = node
 	}
 
+	// Track the quota attached to the namespace of the job
+	quota, err := newQuotaTracker(s.state, s.plan, s.job)
+	if err != nil {
+		return err
+	}
+
 	nodes := make([]*structs.Node, 1)
 	for _, missing := range place {
 		node, ok := nodeByID[missing.Alloc.NodeID]
@@ -314,16 +345,41 @@ func (s *SystemScheduler) computePlacements(place []allocTuple) error {
 				ClientStatus:  structs.AllocClientStatusPending,
 
 				SharedResources: &structs.Resources{
-					DiskMB: missing.TaskGroup.EphemeralDisk.SizeMB,
+					DiskMB:   missing.TaskGroup.EphemeralDisk.SizeMB,
+					Networks: option.SharedNetworks,
 				},
 			}
 
+			// Fail the placement if it exceeds the quota of the namespace
+			if quota != nil {
+				if exceeded := quota.exceeded(alloc); len(exceeded) != 0 {
+					if metric, ok := s.failedTGAllocs[missing.TaskGroup.Name]; ok {
+						metric.CoalescedFailures += 1
+						continue
+					}
+					if s.failedTGAllocs == nil {
+						s.failedTGAllocs = make(map[string]*structs.AllocMetric)
+					}
+					metric := s.ctx.Metrics()
+					metric.ExhaustQuota(exceeded)
+					s.failedTGAllocs[missing.TaskGroup.Name] = metric
+					continue
+				}
+				quota.add(alloc)
+			}
+
 			// If the new allocation is replacing an older allocation then we
 			// set the record the older allocation id so that they are chained
 			if missing.Alloc != nil {
 				alloc.PreviousAllocation = missing.Alloc.ID
 			}
 
+			// Evict the allocations preempted to make room for the new one
+			for _, preempted := range option.PreemptedAllocs {
+				s.plan.AppendPreemptedAlloc(preempted, alloc.ID)
+				alloc.PreemptedAllocations = append(alloc.PreemptedAllocations, preempted.ID)
+			}
+
 			s.plan.AppendAlloc(alloc)
 		} else {
 			// Lazy initialize the failed map
@@ -80,6 +80,51 @@ func TestSystemSched_JobRegister(t *testing.T) {
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
 }
 
+func TestSystemSched_PreemptionEval(t *testing.T) {
+	h := NewHarness(t)
+
+	// Create a node
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
+	// Create a job whose allocation on the node was preempted
+	job := mock.SystemJob()
+	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
+
+	alloc := mock.Alloc()
+	alloc.Job = job
+	alloc.JobID = job.ID
+	alloc.NodeID = node.ID
+	alloc.Name = "my-job.web[0]"
+	alloc.DesiredStatus = structs.AllocDesiredStatusEvict
+	alloc.PreemptedByAllocation = structs.GenerateUUID()
+	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
+
+	// Create the evaluation the plan applier creates for preempted jobs
+	eval := &structs.Evaluation{
+		ID:          structs.GenerateUUID(),
+		Priority:    job.Priority,
+		TriggeredBy: structs.EvalTriggerPreemption,
+		JobID:       job.ID,
+	}
+
+	// Process the evaluation
+	if err := h.Process(NewSystemScheduler, eval); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Ensure the allocation is placed on the node again
+	if len(h.Plans) != 1 {
+		t.Fatalf("bad: %#v", h.Plans)
+	}
+	planned := h.Plans[0].NodeAllocation[node.ID]
+	if len(planned) != 1 {
+		t.Fatalf("bad: %#v", h.Plans[0])
+	}
+
+	h.AssertEvalStatus(t, structs.EvalStatusComplete)
+}
+
 func TestSystemeSched_JobRegister_StickyAllocs(t *testing.T) {
 	h := NewHarness(t)
 
@@ -219,8 +264,10 @@ func TestSystemSched_ExhaustResources(t *testing.T) {
 	node := mock.Node()
 	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
 
-	// Create a service job which consumes most of the system resources
+	// Create a service job which consumes most of the system resources. Its
+	// priority prevents the system job from preempting it.
 	svcJob := mock.Job()
+	svcJob.Priority = 100
 	svcJob.TaskGroups[0].Count = 1
 	svcJob.TaskGroups[0].Tasks[0].Resources.CPU = 3600
 	noErr(t, h.State.UpsertJob(h.NextIndex(), svcJob))
@@ -972,6 +1019,7 @@ func TestSystemSched_NodeDrain(t *testing.T) {
 	alloc.Job = job
 	alloc.JobID = job.ID
 	alloc.NodeID = node.ID
+	alloc.DesiredTransition.Migrate = true
 	alloc.Name = "my-job.web[0]"
 	noErr(t, h.State.UpsertAllocs(h.NextIndex(), []*structs.Allocation{alloc}))
 
@@ -1265,6 +1313,7 @@ func TestSystemSched_PlanWithDrainedNode(t *testing.T) {
 	alloc.Job = job
 	alloc.JobID = job.ID
 	alloc.NodeID = node.ID
+	alloc.DesiredTransition.Migrate = true
 	alloc.Name = "my-job.web[0]"
 	alloc.TaskGroup = "web"
 
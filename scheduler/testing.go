@@ -96,6 +96,7 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 	result := new(structs.PlanResult)
 	result.NodeUpdate = plan.NodeUpdate
 	result.NodeAllocation = plan.NodeAllocation
+	result.NodePreemptions = plan.NodePreemptions
 	result.AllocIndex = index
 
 	// Flatten evicts and allocs
@@ -118,6 +119,20 @@ func (h *Harness) SubmitPlan(plan *structs.Plan) (*structs.PlanResult, State, er
 		}
 	}
 
+	// The preempted allocations belong to other jobs
+	for _, preemptedList := range plan.NodePreemptions {
+		allocs = append(allocs, preemptedList...)
+	}
+
+	// Apply the deployment changes of the plan
+	if plan.Deployment != nil || len(plan.DeploymentUpdates) != 0 {
+		result.Deployment = plan.Deployment
+		result.DeploymentUpdates = plan.DeploymentUpdates
+		if err := h.State.UpsertDeployment(index, plan.Deployment, plan.DeploymentUpdates); err != nil {
+			return result, nil, err
+		}
+	}
+
 	// Apply the full plan
 	err := h.State.UpsertAllocs(index, allocs)
 	return result, nil, err
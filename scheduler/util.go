@@ -14,6 +14,9 @@ type allocTuple struct {
 	Name      string
 	TaskGroup *structs.TaskGroup
 	Alloc     *structs.Allocation
+
+	// Canary marks a placement as a canary of the job's deployment
+	Canary bool
 }
 
 // materializeTaskGroups is used to materialize all the task groups
@@ -56,7 +59,7 @@ func (d *diffResult) Append(other *diffResult) {
 // and the existing allocations. This returns 6 sets of results, the list of
 // named task groups that need to be placed (no existing allocation), the
 // allocations that need to be updated (job definition is newer), allocs that
-// need to be migrated (node is draining), the allocs that need to be evicted
+// need to be migrated (node is draining or the alloc was stopped), the allocs that need to be evicted
 // (no longer required), those that should be ignored and those that are lost
 // that need to be replaced (running on a lost node).
 //
@@ -103,20 +106,31 @@ func diffAllocs(job *structs.Job, taintedNodes map[string]*structs.Node,
 				goto IGNORE
 			}
 
+			// Failed allocations left in place by their reschedule policy
+			// are not replaced because of their node either.
+			if exist.ClientStatus == structs.AllocClientStatusFailed {
+				goto IGNORE
+			}
+
 			if node == nil || node.TerminalStatus() {
 				result.lost = append(result.lost, allocTuple{
 					Name:      name,
 					TaskGroup: tg,
 					Alloc:     exist,
 				})
-			} else {
-				// This is the drain case
-				result.migrate = append(result.migrate, allocTuple{
-					Name:      name,
-					TaskGroup: tg,
-					Alloc:     exist,
-				})
+				continue
 			}
+		}
+
+		// The drainer marks the allocations of draining nodes to migrate so
+		// they are moved gradually, the others are left in place until then.
+		// Allocations stopped by an operator are marked the same way.
+		if exist.DesiredTransition.ShouldMigrate() {
+			result.migrate = append(result.migrate, allocTuple{
+				Name:      name,
+				TaskGroup: tg,
+				Alloc:     exist,
+			})
 			continue
 		}
 
@@ -247,6 +261,9 @@ func readyNodesInDCs(state State, dcs []string) ([]*structs.Node, map[string]int
 		if node.Drain {
 			continue
 		}
+		if !node.Eligible() {
+			continue
+		}
 		if _, ok := dcMap[node.Datacenter]; !ok {
 			continue
 		}
@@ -340,6 +357,16 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 		return true
 	}
 
+	// Check the requested volumes
+	if !reflect.DeepEqual(a.Volumes, b.Volumes) {
+		return true
+	}
+
+	// Check the group networks
+	if networkUpdated(a.Networks, b.Networks) {
+		return true
+	}
+
 	// Check each task
 	for _, at := range a.Tasks {
 		bt := b.LookupTask(at.Name)
@@ -370,28 +397,28 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 		if !reflect.DeepEqual(at.Templates, bt.Templates) {
 			return true
 		}
+		if !reflect.DeepEqual(at.VolumeMounts, bt.VolumeMounts) {
+			return true
+		}
+		if !reflect.DeepEqual(at.Lifecycle, bt.Lifecycle) {
+			return true
+		}
 
 		// Inspect the network to see if the dynamic ports are different
-		if len(at.Resources.Networks) != len(bt.Resources.Networks) {
+		if networkUpdated(at.Resources.Networks, bt.Resources.Networks) {
 			return true
 		}
-		for idx := range at.Resources.Networks {
-			an := at.Resources.Networks[idx]
-			bn := bt.Resources.Networks[idx]
 
-			if an.MBits != bn.MBits {
-				return true
-			}
-
-			aPorts, bPorts := networkPortMap(an), networkPortMap(bn)
-			if !reflect.DeepEqual(aPorts, bPorts) {
-				return true
-			}
+		// Inspect the requested devices
+		if !reflect.DeepEqual(at.Resources.Devices, bt.Resources.Devices) {
+			return true
 		}
 
 		// Inspect the non-network resources
 		if ar, br := at.Resources, bt.Resources; ar.CPU != br.CPU {
 			return true
+		} else if ar.Cores != br.Cores {
+			return true
 		} else if ar.MemoryMB != br.MemoryMB {
 			return true
 		} else if ar.IOPS != br.IOPS {
@@ -401,16 +428,37 @@ func tasksUpdated(a, b *structs.TaskGroup) bool {
 	return false
 }
 
+// networkUpdated returns whether the requested networks differ, ignoring the
+// values assigned to the dynamic ports.
+func networkUpdated(a, b []*structs.NetworkResource) bool {
+	if len(a) != len(b) {
+		return true
+	}
+	for idx := range a {
+		an, bn := a[idx], b[idx]
+		if an.Mode != bn.Mode || an.Family() != bn.Family() || an.MBits != bn.MBits {
+			return true
+		}
+
+		aPorts, bPorts := networkPortMap(an), networkPortMap(bn)
+		if !reflect.DeepEqual(aPorts, bPorts) {
+			return true
+		}
+	}
+	return false
+}
+
 // networkPortMap takes a network resource and returns a map of port labels to
-// values. The value for dynamic ports is disregarded even if it is set. This
+// ports. The value for dynamic ports is disregarded even if it is set. This
 // makes this function suitable for comparing two network resources for changes.
-func networkPortMap(n *structs.NetworkResource) map[string]int {
-	m := make(map[string]int, len(n.DynamicPorts)+len(n.ReservedPorts))
+func networkPortMap(n *structs.NetworkResource) map[string]structs.Port {
+	m := make(map[string]structs.Port, len(n.DynamicPorts)+len(n.ReservedPorts))
 	for _, p := range n.ReservedPorts {
-		m[p.Label] = p.Value
+		m[p.Label] = p
 	}
 	for _, p := range n.DynamicPorts {
-		m[p.Label] = -1
+		p.Value = -1
+		m[p.Label] = p
 	}
 	return m
 }
@@ -484,18 +532,21 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 		// Pop the allocation
 		ctx.Plan().PopUpdate(update.Alloc)
 
-		// Skip if we could not do an in-place update
-		if option == nil {
+		// Skip if we could not do an in-place update. Updates requiring the
+		// preemption of other allocations are done as a new placement.
+		if option == nil || len(option.PreemptedAllocs) != 0 {
 			continue
 		}
 
-		// Restore the network offers from the existing allocation.
-		// We do not allow network resources (reserved/dynamic ports)
-		// to be updated. This is guarded in taskUpdated, so we can
-		// safely restore those here.
+		// Restore the network, device and core offers from the existing
+		// allocation. We do not allow network resources (reserved/dynamic
+		// ports), devices or cores to be updated. This is guarded in
+		// taskUpdated, so we can safely restore those here.
 		for task, resources := range option.TaskResources {
 			existing := update.Alloc.TaskResources[task]
 			resources.Networks = existing.Networks
+			resources.Devices = existing.Devices
+			resources.ReservedCores = existing.ReservedCores
 		}
 
 		// Create a shallow copy
@@ -522,6 +573,18 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 	return updates[:n], updates[n:]
 }
 
+// filterByTaskGroup returns the allocation tuples belonging to the named
+// task group.
+func filterByTaskGroup(allocs []allocTuple, name string) []allocTuple {
+	var out []allocTuple
+	for _, a := range allocs {
+		if a.TaskGroup != nil && a.TaskGroup.Name == name {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
 // evictAndPlace is used to mark allocations for evicts and add them to the
 // placement queue. evictAndPlace modifies both the diffResult and the
 // limit. It returns true if the limit has been reached.
@@ -563,6 +626,9 @@ type tgConstrainTuple struct {
 	// Holds the combined constraints of the task group and all it's sub-tasks.
 	constraints []*structs.Constraint
 
+	// Holds the combined affinities of the task group and all it's sub-tasks.
+	affinities []*structs.Affinity
+
 	// The set of required drivers within the task group.
 	drivers map[string]struct{}
 
@@ -580,15 +646,58 @@ func taskGroupConstraints(tg *structs.TaskGroup) tgConstrainTuple {
 	}
 
 	c.constraints = append(c.constraints, tg.Constraints...)
+	c.affinities = append(c.affinities, tg.Affinities...)
 	for _, task := range tg.Tasks {
 		c.drivers[task.Driver] = struct{}{}
 		c.constraints = append(c.constraints, task.Constraints...)
+		c.affinities = append(c.affinities, task.Affinities...)
 		c.size.Add(task.Resources)
 	}
 
 	return c
 }
 
+// proposedJobAllocs returns the non-terminal allocations of the job once the
+// plan of the context is applied.
+func proposedJobAllocs(ctx Context, jobID string) ([]*structs.Allocation, error) {
+	existing, err := ctx.State().AllocsByJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := ctx.Plan()
+	stopped := make(map[string]struct{})
+	for _, updates := range plan.NodeUpdate {
+		for _, alloc := range updates {
+			stopped[alloc.ID] = struct{}{}
+		}
+	}
+
+	proposed := make(map[string]*structs.Allocation)
+	for _, alloc := range existing {
+		if alloc.TerminalStatus() {
+			continue
+		}
+		if _, ok := stopped[alloc.ID]; ok {
+			continue
+		}
+		proposed[alloc.ID] = alloc
+	}
+	for _, allocs := range plan.NodeAllocation {
+		for _, alloc := range allocs {
+			if alloc.JobID == jobID {
+				proposed[alloc.ID] = alloc
+			}
+		}
+	}
+
+	out := make([]*structs.Allocation, 0, len(proposed))
+	for _, alloc := range proposed {
+		out = append(out, alloc)
+	}
+	return out, nil
+}
+
 // desiredUpdates takes the diffResult as well as the set of inplace and
 // destructive updates and returns a map of task groups to their set of desired
 // updates.
@@ -85,10 +85,11 @@ func TestDiffAllocs(t *testing.T) {
 
 		// Migrate the 3rd
 		&structs.Allocation{
-			ID:     structs.GenerateUUID(),
-			NodeID: "drainNode",
-			Name:   "my-job.web[2]",
-			Job:    oldJob,
+			ID:                structs.GenerateUUID(),
+			NodeID:            "drainNode",
+			Name:              "my-job.web[2]",
+			Job:               oldJob,
+			DesiredTransition: structs.DesiredTransition{Migrate: true},
 		},
 		// Mark the 4th lost
 		&structs.Allocation{
@@ -172,6 +173,43 @@ func TestDiffAllocs(t *testing.T) {
 	}
 }
 
+func TestDiffAllocs_DrainNotMigrated(t *testing.T) {
+	job := mock.Job()
+	required := materializeTaskGroups(job)
+
+	drainNode := mock.Node()
+	drainNode.Drain = true
+	tainted := map[string]*structs.Node{
+		drainNode.ID: drainNode,
+	}
+
+	// Allocations on a draining node are only migrated once the drainer
+	// marks them
+	allocs := []*structs.Allocation{
+		&structs.Allocation{
+			ID:     structs.GenerateUUID(),
+			NodeID: drainNode.ID,
+			Name:   "my-job.web[0]",
+			Job:    job,
+		},
+		&structs.Allocation{
+			ID:                structs.GenerateUUID(),
+			NodeID:            drainNode.ID,
+			Name:              "my-job.web[1]",
+			Job:               job,
+			DesiredTransition: structs.DesiredTransition{Migrate: true},
+		},
+	}
+
+	diff := diffAllocs(job, tainted, required, allocs, nil)
+	if len(diff.ignore) != 1 || diff.ignore[0].Alloc != allocs[0] {
+		t.Fatalf("bad: %#v", diff.ignore)
+	}
+	if len(diff.migrate) != 1 || diff.migrate[0].Alloc != allocs[1] {
+		t.Fatalf("bad: %#v", diff.migrate)
+	}
+}
+
 func TestDiffSystemAllocs(t *testing.T) {
 	job := mock.SystemJob()
 
@@ -214,10 +252,11 @@ func TestDiffSystemAllocs(t *testing.T) {
 
 		// Stop allocation on draining node.
 		&structs.Allocation{
-			ID:     structs.GenerateUUID(),
-			NodeID: drainNode.ID,
-			Name:   "my-job.web[0]",
-			Job:    oldJob,
+			ID:                structs.GenerateUUID(),
+			NodeID:            drainNode.ID,
+			Name:              "my-job.web[0]",
+			Job:               oldJob,
+			DesiredTransition: structs.DesiredTransition{Migrate: true},
 		},
 		// Mark as lost on a dead node
 		&structs.Allocation{
@@ -303,11 +342,14 @@ func TestReadyNodesInDCs(t *testing.T) {
 	node3.Status = structs.NodeStatusDown
 	node4 := mock.Node()
 	node4.Drain = true
+	node5 := mock.Node()
+	node5.SchedulingEligibility = structs.NodeSchedulingIneligible
 
 	noErr(t, state.UpsertNode(1000, node1))
 	noErr(t, state.UpsertNode(1001, node2))
 	noErr(t, state.UpsertNode(1002, node3))
 	noErr(t, state.UpsertNode(1003, node4))
+	noErr(t, state.UpsertNode(1004, node5))
 
 	nodes, dc, err := readyNodesInDCs(state, []string{"dc1", "dc2"})
 	if err != nil {
@@ -484,7 +526,7 @@ func TestTasksUpdated(t *testing.T) {
 	}
 
 	j6 := mock.Job()
-	j6.TaskGroups[0].Tasks[0].Resources.Networks[0].DynamicPorts = []structs.Port{{"http", 0}, {"https", 0}, {"admin", 0}}
+	j6.TaskGroups[0].Tasks[0].Resources.Networks[0].DynamicPorts = []structs.Port{{"http", 0, 0}, {"https", 0, 0}, {"admin", 0, 0}}
 	if !tasksUpdated(j1.TaskGroups[0], j6.TaskGroups[0]) {
 		t.Fatalf("bad")
 	}
@@ -552,6 +594,66 @@ func TestTasksUpdated(t *testing.T) {
 	if !tasksUpdated(j1.TaskGroups[0], j16.TaskGroups[0]) {
 		t.Fatal("bad")
 	}
+
+	j17 := mock.Job()
+	j17.TaskGroups[0].Volumes = map[string]*structs.VolumeRequest{
+		"certs": &structs.VolumeRequest{Name: "certs", Type: "host", Source: "certs"},
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j17.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
+
+	j18 := mock.Job()
+	j18.TaskGroups[0].Tasks[0].VolumeMounts = []*structs.VolumeMount{
+		&structs.VolumeMount{Volume: "certs", Destination: "/etc/ssl/certs"},
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j18.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
+
+	j19 := mock.Job()
+	j19.TaskGroups[0].Tasks[0].Lifecycle = &structs.TaskLifecycleConfig{
+		Hook: structs.TaskLifecycleHookPrestart,
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j19.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
+
+	j20 := mock.Job()
+	j20.TaskGroups[0].Networks = []*structs.NetworkResource{
+		{
+			Mode:         structs.NetworkModeBridge,
+			DynamicPorts: []structs.Port{{Label: "http", To: 8080}},
+		},
+	}
+	if !tasksUpdated(j1.TaskGroups[0], j20.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
+
+	// The value assigned to dynamic ports is not an update
+	j21 := j20.Copy()
+	j21.TaskGroups[0].Networks[0].DynamicPorts[0].Value = 25000
+	if tasksUpdated(j20.TaskGroups[0], j21.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
+
+	// Changing the mapped port is
+	j21.TaskGroups[0].Networks[0].DynamicPorts[0].To = 9090
+	if !tasksUpdated(j20.TaskGroups[0], j21.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
+
+	// Defaulting the address family is not an update but changing it is
+	j22 := j1.Copy()
+	j22.TaskGroups[0].Tasks[0].Resources.Networks[0].AddressFamily = structs.NetworkAddressFamilyIPv4
+	if tasksUpdated(j1.TaskGroups[0], j22.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
+
+	j22.TaskGroups[0].Tasks[0].Resources.Networks[0].AddressFamily = structs.NetworkAddressFamilyDual
+	if !tasksUpdated(j1.TaskGroups[0], j22.TaskGroups[0]) {
+		t.Fatal("bad")
+	}
 }
 
 func TestEvictAndPlace_LimitLessThanAllocs(t *testing.T) {